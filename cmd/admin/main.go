@@ -25,20 +25,25 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/admin"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/admin/handler"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/network"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/rediscache"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"github.com/beckn/beckn-onix/pkg/plugin/definition"
 	"github.com/beckn/beckn-onix/pkg/plugin/implementation/encrypter"
+	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signer"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"gopkg.in/yaml.v2"
 )
 
@@ -52,6 +57,59 @@ type config struct {
 	Admin    *service.AdminConfig                    `yaml:"admin"`
 	Event    *event.Config                           `yaml:"event"`
 	Setup    *service.RegistrySelfRegistrationConfig `yaml:"setup"`
+	Snapshot *service.SnapshotConfig                 `yaml:"snapshot"`
+	Tracing  *log.TracingConfig                      `yaml:"tracing"`
+	// DenylistRedisAddr is the Redis instance the denylist is published to for the gateway.
+	DenylistRedisAddr string `yaml:"denylistRedisAddr"`
+	// Network is the name of the network served to requests that select no network profile.
+	Network string `yaml:"network"`
+	// Networks are the network profiles served besides the default network.
+	Networks []networkConfig `yaml:"networks"`
+}
+
+// networkConfig is a network profile, such as a sandbox network served next to production.
+// Its sections replace those of the default network; sections it does not set are inherited.
+type networkConfig struct {
+	Name              string                                  `yaml:"name"`
+	DB                *repository.Config                      `yaml:"db"`
+	NPClient          *client.NPClientConfig                  `yaml:"npClient"`
+	Admin             *service.AdminConfig                    `yaml:"admin"`
+	Event             *event.Config                           `yaml:"event"`
+	Setup             *service.RegistrySelfRegistrationConfig `yaml:"setup"`
+	Snapshot          *service.SnapshotConfig                 `yaml:"snapshot"`
+	DenylistRedisAddr string                                  `yaml:"denylistRedisAddr"`
+}
+
+// profile returns the configuration of network profile n.
+func (c *config) profile(n networkConfig) *config {
+	p := *c
+	p.Network, p.Networks = n.Name, nil
+	if n.DB != nil {
+		p.DB = n.DB
+	}
+	// Pool statistics are published under fixed names, so only the default pool is monitored.
+	db := *p.DB
+	db.Monitor = nil
+	p.DB = &db
+	if n.NPClient != nil {
+		p.NPClient = n.NPClient
+	}
+	if n.Admin != nil {
+		p.Admin = n.Admin
+	}
+	if n.Event != nil {
+		p.Event = n.Event
+	}
+	if n.Setup != nil {
+		p.Setup = n.Setup
+	}
+	if n.Snapshot != nil {
+		p.Snapshot = n.Snapshot
+	}
+	if n.DenylistRedisAddr != "" {
+		p.DenylistRedisAddr = n.DenylistRedisAddr
+	}
+	return &p
 }
 
 type serverConfig struct {
@@ -114,6 +172,9 @@ func (c *config) valid() error {
 	if c.Admin.OperationRetryMax <= 0 {
 		return fmt.Errorf("admin.OperationRetryMax must be greater than zero")
 	}
+	if c.Admin.LROExpiry != nil && c.Admin.LROExpiry.Timeout <= 0 {
+		return fmt.Errorf("admin.lroExpiry.timeout must be greater than zero")
+	}
 	if c.Event == nil {
 		return fmt.Errorf("missing required config section: event")
 	}
@@ -123,6 +184,16 @@ func (c *config) valid() error {
 	if c.Setup.KeyID == "" {
 		return fmt.Errorf("encryptionKeyID is missing in setup config")
 	}
+	names := map[string]bool{c.Network: true}
+	for _, n := range c.Networks {
+		if n.Name == "" || names[n.Name] || strings.Contains(n.Name, "/") {
+			return fmt.Errorf("invalid network profile name %q: it must be non-empty, unique and without '/'", n.Name)
+		}
+		names[n.Name] = true
+		if err := c.profile(n).valid(); err != nil {
+			return fmt.Errorf("network %s: %w", n.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -135,6 +206,17 @@ func run(ctx context.Context) error {
 	if err := log.Setup(cfg.Log); err != nil {
 		return err
 	}
+	if cfg.Tracing != nil {
+		shutdownTracing, err := log.SetupTracing(cfg.Tracing)
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				slog.Error("failed to flush traces", "error", err)
+			}
+		}()
+	}
 	db, dbCleanUp, err := newConnectionPool(ctx, cfg.DB)
 	if err != nil {
 		return fmt.Errorf("failed to open database connection: %w", err)
@@ -144,6 +226,22 @@ func run(ctx context.Context) error {
 			slog.Error("failed to clean up database connection", "error", err)
 		}
 	}()
+	networkDBs := make(map[string]*sql.DB)
+	for _, n := range cfg.Networks {
+		if n.DB == nil {
+			continue
+		}
+		ndb, ndbCleanUp, err := newConnectionPool(ctx, n.DB)
+		if err != nil {
+			return fmt.Errorf("failed to open database connection of network %s: %w", n.Name, err)
+		}
+		defer func() {
+			if err := ndbCleanUp(); err != nil {
+				slog.Error("failed to clean up database connection", "network", n.Name, "error", err)
+			}
+		}()
+		networkDBs[n.Name] = ndb
+	}
 	encry, _, err := encrypter.New(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create signature validator: %w", err)
@@ -153,7 +251,7 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to create secret manager client for encryption service: %w", err)
 	}
 	defer sm.Close()
-	server, err := newServer(ctx, cfg, db, encry, sm)
+	server, err := newServer(ctx, cfg, db, networkDBs, encry, sm)
 	if err != nil {
 		return err
 	}
@@ -195,53 +293,344 @@ func run(ctx context.Context) error {
 var configPath string
 var newConnectionPool = repository.NewConnectionPool
 
-func newServer(ctx context.Context, cfg *config, db *sql.DB, encyr definition.Encrypter, sm *secretmanager.Client) (*http.Server, error) {
+// backgroundJob is a task started with the server and stopped when it shuts down.
+type backgroundJob interface {
+	Start(context.Context)
+	Stop()
+}
+
+// stopFunc is a backgroundJob that only has to be stopped, such as releasing a connection.
+type stopFunc func()
+
+func (f stopFunc) Start(context.Context) {}
+func (f stopFunc) Stop()                 { f() }
+
+// newServer creates the HTTP server of the default network and of each network profile.
+// networkDBs holds the connection pools of the network profiles with their own database.
+func newServer(ctx context.Context, cfg *config, db *sql.DB, networkDBs map[string]*sql.DB, encyr definition.Encrypter, sm *secretmanager.Client) (*http.Server, error) {
+	h, jobs, err := newHandler(ctx, cfg, db, encyr, sm)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Networks) > 0 {
+		profiles := make(map[string]http.Handler, len(cfg.Networks))
+		for _, n := range cfg.Networks {
+			ndb := db
+			if n.DB != nil {
+				ndb = networkDBs[n.Name]
+			}
+			nh, njobs, err := newHandler(ctx, cfg.profile(n), ndb, encyr, sm)
+			if err != nil {
+				return nil, fmt.Errorf("network %s: %w", n.Name, err)
+			}
+			profiles[n.Name] = nh
+			jobs = append(jobs, njobs...)
+		}
+		if h, err = network.NewRouter(cfg.Network, h, profiles); err != nil {
+			slog.Error("Failed to create network router", "error", err)
+			return nil, fmt.Errorf("failed to create network router: %w", err)
+		}
+	}
+	srv := &http.Server{
+		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
+		Handler:      h,
+		ReadTimeout:  cfg.Timeouts.Read,
+		WriteTimeout: cfg.Timeouts.Write,
+		IdleTimeout:  cfg.Timeouts.Idle,
+	}
+	if cfg.Tracing != nil {
+		srv.Handler = otelhttp.NewHandler(srv.Handler, "admin", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}))
+	}
+	for _, j := range jobs {
+		j.Start(ctx)
+		srv.RegisterOnShutdown(j.Stop)
+	}
+	return srv, nil
+}
 
+// newHandler creates the router of the network configured by cfg, with the background jobs
+// to run while it is served.
+func newHandler(ctx context.Context, cfg *config, db *sql.DB, encyr definition.Encrypter, sm *secretmanager.Client) (http.Handler, []backgroundJob, error) {
+	var jobs []backgroundJob
 	regRepo, err := repository.NewRegistry(db)
 	if err != nil {
 		slog.Error("Failed to create registry repository", "error", err)
-		return nil, fmt.Errorf("failed to create registry repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to create registry repository: %w", err)
+	}
+	regRepo.SetQueryTimeouts(cfg.DB.QueryTimeouts)
+	regRepo.SetSlowQueryLog(cfg.DB.SlowQueries)
+	regRepo.SetRetry(cfg.DB.Retry)
+	if cfg.DB.Encryption != nil {
+		cipher, err := repository.NewKMSColumnCipher(ctx, cfg.DB.Encryption)
+		if err != nil {
+			slog.Error("Failed to create column cipher", "error", err)
+			return nil, nil, fmt.Errorf("failed to create column cipher: %w", err)
+		}
+		regRepo.SetColumnCipher(cipher)
+	}
+	if cfg.DB.Monitor != nil {
+		mon, err := repository.NewPoolMonitor(db, cfg.DB.Monitor)
+		if err != nil {
+			slog.Error("Failed to create connection pool monitor", "error", err)
+			return nil, nil, fmt.Errorf("failed to create connection pool monitor: %w", err)
+		}
+		regRepo.SetConnTracker(mon)
+		jobs = append(jobs, mon)
 	}
 	encSrv, err := service.NewEcryptionService(ctx, encyr, sm, cfg.Event.ProjectID, cfg.Setup.KeyID)
 	if err != nil {
 		slog.Error("Failed to create encryption service", "error", err)
-		return nil, fmt.Errorf("failed to create encryption service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create encryption service: %w", err)
 	}
 	setup, err := service.NewRegistrySetupService(regRepo, encSrv, cfg.Setup)
 	if err != nil {
 		slog.Error("Failed to create registry setup service", "error", err)
-		return nil, fmt.Errorf("failed to create registry setup service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create registry setup service: %w", err)
 	}
 	if err := setup.SelfRegister(ctx); err != nil {
 		slog.Error("Failed to self register", "error", err)
-		return nil, fmt.Errorf("failed to self register: %w", err)
+		return nil, nil, fmt.Errorf("failed to self register: %w", err)
 	}
 	evPub, _, err := event.NewPublisher(ctx, cfg.Event)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create event publisher: %w", err)
+		return nil, nil, fmt.Errorf("failed to create event publisher: %w", err)
+	}
+	webhookCfg := cfg.Admin.Webhooks
+	if webhookCfg == nil {
+		webhookCfg = &service.WebhookConfig{}
+	}
+	webhookSrv, err := service.NewWebhookService(regRepo, webhookCfg)
+	if err != nil {
+		slog.Error("Failed to create webhook service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create webhook service: %w", err)
 	}
+	// LRO transitions are published to Pub/Sub and delivered to registered webhooks.
+	pub := webhookSrv.Publisher(evPub)
+	// The /on_subscribe calls are signed with the registry's keyset so that NPs can verify them.
+	becknSigner, _, err := signer.New(ctx, &signer.Config{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+	keyAlgoSigner, err := service.NewKeyAlgoSigner(becknSigner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create key algorithm signer: %w", err)
+	}
+	authGen, err := service.NewAuthGenService(encSrv, keyAlgoSigner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create auth gen service: %w", err)
+	}
+	npClient := client.NewNPClient(*cfg.NPClient)
+	npClient.SetAuth(authGen, cfg.Setup.SubscriberID)
 	adminSrv, err := service.NewAdminService(regRepo,
 		service.NewChallengeService(),
 		encSrv,
-		client.NewNPClient(*cfg.NPClient),
-		evPub,
+		npClient,
+		pub,
 		cfg.Admin)
 	if err != nil {
 		slog.Error("Failed to create admin service", "error", err)
-		return nil, fmt.Errorf("failed to create admin service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create admin service: %w", err)
+	}
+	adminSrv.SetChallengeAttemptRecorder(regRepo)
+	adminSrv.SetTransitionNotifier(webhookSrv)
+	if cfg.Admin.Nonce != nil {
+		nonceSrv, err := service.NewNonceService(regRepo, cfg.Admin.Nonce)
+		if err != nil {
+			slog.Error("Failed to create nonce service", "error", err)
+			return nil, nil, fmt.Errorf("failed to create nonce service: %w", err)
+		}
+		adminSrv.SetNonceConsumer(nonceSrv)
+	}
+	if cfg.Admin.LROExpiry != nil {
+		job, err := service.NewLROExpiryJob(regRepo, pub, cfg.Admin.LROExpiry)
+		if err != nil {
+			slog.Error("Failed to create LRO expiry job", "error", err)
+			return nil, nil, fmt.Errorf("failed to create LRO expiry job: %w", err)
+		}
+		job.SetTransitionNotifier(webhookSrv)
+		jobs = append(jobs, job)
+	}
+	if cfg.Admin.Activation != nil {
+		job, err := service.NewActivationJob(regRepo, pub, cfg.Admin.Activation)
+		if err != nil {
+			slog.Error("Failed to create activation job", "error", err)
+			return nil, nil, fmt.Errorf("failed to create activation job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if cfg.Admin.Digest != nil {
+		job, err := service.NewDigestJob(regRepo, cfg.Admin.Digest)
+		if err != nil {
+			slog.Error("Failed to create digest job", "error", err)
+			return nil, nil, fmt.Errorf("failed to create digest job: %w", err)
+		}
+		jobs = append(jobs, job)
 	}
 	h, err := handler.NewAdminHandler(adminSrv)
 	if err != nil {
 		slog.Error("Failed to create admin handler", "error", err)
-		return nil, fmt.Errorf("failed to create admin handler: %w", err)
+		return nil, nil, fmt.Errorf("failed to create admin handler: %w", err)
 	}
-	return &http.Server{
-		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      admin.NewRouter(h),
-		ReadTimeout:  cfg.Timeouts.Read,
-		WriteTimeout: cfg.Timeouts.Write,
-		IdleTimeout:  cfg.Timeouts.Idle,
-	}, nil
+	if cfg.Admin.Reviewer != nil {
+		h.SetReviewer(cfg.Admin.Reviewer.Header, cfg.Admin.Reviewer.Required)
+	}
+	apiKeySrv, err := service.NewAPIKeyService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create API key service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create API key service: %w", err)
+	}
+	apiKeyHandler, err := handler.NewAPIKeyHandler(apiKeySrv)
+	if err != nil {
+		slog.Error("Failed to create API key handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create API key handler: %w", err)
+	}
+	webhookHandler, err := handler.NewWebhookHandler(webhookSrv)
+	if err != nil {
+		slog.Error("Failed to create webhook handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create webhook handler: %w", err)
+	}
+	maintenanceSrv, err := service.NewMaintenanceService(regRepo, &service.MaintenanceConfig{})
+	if err != nil {
+		slog.Error("Failed to create maintenance service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create maintenance service: %w", err)
+	}
+	maintenanceHandler, err := handler.NewMaintenanceHandler(maintenanceSrv)
+	if err != nil {
+		slog.Error("Failed to create maintenance handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create maintenance handler: %w", err)
+	}
+	denylistSrv, err := service.NewDenylistManager(regRepo)
+	if err != nil {
+		slog.Error("Failed to create denylist manager", "error", err)
+		return nil, nil, fmt.Errorf("failed to create denylist manager: %w", err)
+	}
+	var closeRedis func() error
+	if cfg.DenylistRedisAddr != "" {
+		redis, closeFn, err := rediscache.New(ctx, map[string]string{"addr": cfg.DenylistRedisAddr})
+		if err != nil {
+			slog.Error("Failed to create denylist redis cache", "error", err)
+			return nil, nil, fmt.Errorf("failed to create denylist redis cache: %w", err)
+		}
+		closeRedis = closeFn
+		denylistSrv.SetPublisher(redis)
+		// The gateway reads the denylist only from Redis, so publish it before serving changes.
+		if err := denylistSrv.Publish(ctx); err != nil {
+			slog.Error("Failed to publish denylist", "error", err)
+		}
+	}
+	denylistHandler, err := handler.NewDenylistHandler(denylistSrv)
+	if err != nil {
+		slog.Error("Failed to create denylist handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create denylist handler: %w", err)
+	}
+	statsSrv, err := service.NewLROStatsService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create LRO stats service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create LRO stats service: %w", err)
+	}
+	statsHandler, err := handler.NewLROStatsHandler(statsSrv)
+	if err != nil {
+		slog.Error("Failed to create LRO stats handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create LRO stats handler: %w", err)
+	}
+	importSrv, err := service.NewDecisionImportService(adminSrv)
+	if err != nil {
+		slog.Error("Failed to create decision import service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create decision import service: %w", err)
+	}
+	importHandler, err := handler.NewDecisionImportHandler(importSrv)
+	if err != nil {
+		slog.Error("Failed to create decision import handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create decision import handler: %w", err)
+	}
+	if cfg.Admin.Reviewer != nil {
+		importHandler.SetReviewer(cfg.Admin.Reviewer.Header, cfg.Admin.Reviewer.Required)
+	}
+	historySrv, err := service.NewSubscriptionHistoryService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create subscription history service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create subscription history service: %w", err)
+	}
+	historyHandler, err := handler.NewSubscriptionHistoryHandler(historySrv)
+	if err != nil {
+		slog.Error("Failed to create subscription history handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create subscription history handler: %w", err)
+	}
+	snapshotSrv, err := service.NewSnapshotService(regRepo, cfg.Snapshot)
+	if err != nil {
+		slog.Error("Failed to create snapshot service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create snapshot service: %w", err)
+	}
+	snapshotHandler, err := handler.NewSnapshotHandler(snapshotSrv)
+	if err != nil {
+		slog.Error("Failed to create snapshot handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create snapshot handler: %w", err)
+	}
+	domainSrv, err := service.NewDomainManager(regRepo)
+	if err != nil {
+		slog.Error("Failed to create domain manager", "error", err)
+		return nil, nil, fmt.Errorf("failed to create domain manager: %w", err)
+	}
+	domainHandler, err := handler.NewDomainHandler(domainSrv)
+	if err != nil {
+		slog.Error("Failed to create domain handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create domain handler: %w", err)
+	}
+	labelSrv, err := service.NewSubscriptionLabelService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create subscription label service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create subscription label service: %w", err)
+	}
+	labelHandler, err := handler.NewSubscriptionLabelHandler(labelSrv)
+	if err != nil {
+		slog.Error("Failed to create subscription label handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create subscription label handler: %w", err)
+	}
+	mergeSrv, err := service.NewSubscriberMergeService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create subscriber merge service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create subscriber merge service: %w", err)
+	}
+	mergeHandler, err := handler.NewSubscriberMergeHandler(mergeSrv)
+	if err != nil {
+		slog.Error("Failed to create subscriber merge handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create subscriber merge handler: %w", err)
+	}
+	commentSrv, err := service.NewOperationCommentService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create operation comment service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create operation comment service: %w", err)
+	}
+	commentHandler, err := handler.NewOperationCommentHandler(commentSrv)
+	if err != nil {
+		slog.Error("Failed to create operation comment handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create operation comment handler: %w", err)
+	}
+	if cfg.Admin.Reviewer != nil {
+		commentHandler.SetReviewer(cfg.Admin.Reviewer.Header, cfg.Admin.Reviewer.Required)
+	}
+	timelineSrv, err := service.NewOperationTimelineService(regRepo)
+	if err != nil {
+		slog.Error("Failed to create operation timeline service", "error", err)
+		return nil, nil, fmt.Errorf("failed to create operation timeline service: %w", err)
+	}
+	timelineHandler, err := handler.NewOperationTimelineHandler(timelineSrv)
+	if err != nil {
+		slog.Error("Failed to create operation timeline handler", "error", err)
+		return nil, nil, fmt.Errorf("failed to create operation timeline handler: %w", err)
+	}
+	router := admin.NewRouter(h, apiKeyHandler, webhookHandler, maintenanceHandler, denylistHandler, statsHandler, importHandler, historyHandler, snapshotHandler, domainHandler, labelHandler, mergeHandler, commentHandler, timelineHandler)
+	jobs = append(jobs, stopFunc(webhookSrv.Stop))
+	if closeRedis != nil {
+		jobs = append(jobs, stopFunc(func() {
+			if err := closeRedis(); err != nil {
+				slog.Error("Failed to close denylist redis connection", "error", err)
+			}
+		}))
+	}
+	return router, jobs, nil
 }
 
 func main() {
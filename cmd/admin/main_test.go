@@ -43,6 +43,33 @@ func TestConfig_Valid_Success(t *testing.T) {
 	}
 }
 
+func TestConfig_Profile(t *testing.T) {
+	cfg := &config{
+		DB:                &repository.Config{Name: "production", Monitor: &repository.PoolMonitorConfig{}},
+		Admin:             &service.AdminConfig{OperationRetryMax: 3},
+		Setup:             &service.RegistrySelfRegistrationConfig{KeyID: "production-key", SubscriberID: "registry.example.com"},
+		DenylistRedisAddr: "redis:6379",
+		Network:           "production",
+	}
+	sandboxDB := &repository.Config{Name: "sandbox"}
+	sandboxSetup := &service.RegistrySelfRegistrationConfig{KeyID: "sandbox-key", SubscriberID: "sandbox.registry.example.com"}
+
+	got := cfg.profile(networkConfig{Name: "sandbox", DB: sandboxDB, Setup: sandboxSetup})
+
+	if got.Network != "sandbox" {
+		t.Errorf("profile() network = %q, want sandbox", got.Network)
+	}
+	if got.DB.Name != "sandbox" || got.Setup != sandboxSetup {
+		t.Error("profile() did not replace the sections set by the network profile")
+	}
+	if got.Admin != cfg.Admin || got.DenylistRedisAddr != "redis:6379" {
+		t.Error("profile() did not inherit the sections not set by the network profile")
+	}
+	if cfg.DB.Monitor == nil || cfg.DB.Name != "production" {
+		t.Error("profile() modified the default network configuration")
+	}
+}
+
 func TestInitConfig_Success(t *testing.T) {
 	configPath := "testData/valid_config.yaml"
 
@@ -196,6 +223,11 @@ func TestConfig_Valid_Error(t *testing.T) {
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Event: validEventCfg, Setup: validSetupCfg},
 			expectedError: "missing required config section: admin",
 		},
+		{
+			name:          "admin.lroExpiry.timeout is zero",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 1, LROExpiry: &service.LROExpiryConfig{}}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.lroExpiry.timeout must be greater than zero",
+		},
 		{
 			name:          "admin.OperationRetryMax is zero",
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 0}, Event: validEventCfg, Setup: validSetupCfg},
@@ -225,6 +257,21 @@ func TestConfig_Valid_Error(t *testing.T) {
 			},
 			expectedError: "encryptionKeyID is missing in setup config",
 		},
+		{
+			name:          "duplicate network profiles",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg, NPClient: validNPClientCfg, Networks: []networkConfig{{Name: "sandbox"}, {Name: "sandbox"}}},
+			expectedError: `invalid network profile name "sandbox"`,
+		},
+		{
+			name:          "network profile named as default network",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg, NPClient: validNPClientCfg, Network: "production", Networks: []networkConfig{{Name: "production"}}},
+			expectedError: `invalid network profile name "production"`,
+		},
+		{
+			name:          "invalid network profile section",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg, NPClient: validNPClientCfg, Networks: []networkConfig{{Name: "sandbox", Setup: &service.RegistrySelfRegistrationConfig{}}}},
+			expectedError: "network sandbox: encryptionKeyID is missing in setup config",
+		},
 	}
 
 	for _, tt := range tests {
@@ -30,9 +30,11 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/gateway"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/gateway/handler"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
-	keyManager "github.com/google/dpi-accelerator-beckn-onix/plugins/inmemorysecretkeymanager"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	"github.com/google/dpi-accelerator-beckn-onix/plugins/rediscache"
 
 	beckn "github.com/beckn/beckn-onix/core/module/client"
@@ -43,23 +45,59 @@ import (
 
 // config represents application configuration.
 type config struct {
-	Log                       *log.Config                  `yaml:"log"`
-	Timeouts                  *timeoutConfig               `yaml:"timeouts"`
-	Server                    *serverConfig                `yaml:"server"`
-	ProjectID                 string                       `yaml:"projectID"`
-	KeyManagerCacheTTL        *keyManager.CacheTTL         `yaml:"keyManagerCacheTTL"`
-	Registry                  *client.RegistryClientConfig `yaml:"registry"`
-	RedisAddr                 string                       `yaml:"redisAddr"`
-	MaxConcurrentFanoutTasks  int                          `yaml:"maxConcurrentFanoutTasks"`
-	TaskQueueWorkersCount     int                          `yaml:"taskQueueWorkersCount"`
-	TaskQueueBufferSize       int                          `yaml:"taskQueueBufferSize"`
-	SubscriberID              string                       `yaml:"subscriberID"`
-	HTTPClientRetry           *service.RetryConfig         `yaml:"httpClientRetry"`
+	Log                       *log.Config                    `yaml:"log"`
+	Timeouts                  *timeoutConfig                 `yaml:"timeouts"`
+	Server                    *serverConfig                  `yaml:"server"`
+	ProjectID                 string                         `yaml:"projectID"`
+	KeyManagerType            keymanager.Type                `yaml:"keyManagerType"`
+	KeyManagerCacheTTL        *keymanager.CacheTTL           `yaml:"keyManagerCacheTTL"`
+	KeyManagerMigration       *keymanager.MigrationConfig    `yaml:"keyManagerMigration"`
+	KeyManagerWarmup          *keymanager.WarmupConfig       `yaml:"keyManagerWarmup"`
+	KeyManagerExpiry          *keymanager.ExpiryConfig       `yaml:"keyManagerExpiry"`
+	KeyManagerK8sSecret       *keymanager.K8sSecretConfig    `yaml:"keyManagerK8sSecret"`
+	KeyAudit                  *keymanager.AuditConfig        `yaml:"keyAudit"`
+	Event                     *event.Config                  `yaml:"event"`
+	Registry                  *client.RegistryClientConfig   `yaml:"registry"`
+	RedisAddr                 string                         `yaml:"redisAddr"`
+	MaxConcurrentFanoutTasks  int                            `yaml:"maxConcurrentFanoutTasks"`
+	TaskQueueWorkersCount     int                            `yaml:"taskQueueWorkersCount"`
+	TaskQueueBufferSize       int                            `yaml:"taskQueueBufferSize"`
+	TaskQueuePartitions       []service.TaskQueuePartition   `yaml:"taskQueuePartitions"`
+	SubscriberID              string                         `yaml:"subscriberID"`
+	HTTPClientRetry           *service.RetryConfig           `yaml:"httpClientRetry"`
+	CoreVersions              *service.CoreVersionConfig     `yaml:"coreVersions"`
+	Journal                   *service.JournalConfig         `yaml:"journal"`
+	DeadLetter                *service.JournalConfig         `yaml:"deadLetter"`
+	TargetPolicy              *service.TargetPolicyConfig    `yaml:"targetPolicy"`
+	Backpressure              *service.BackpressureConfig    `yaml:"backpressure"`
+	Actions                   []service.ActionConfig         `yaml:"actions"`
+	Transforms                []service.TransformConfig      `yaml:"transforms"`
+	Batching                  *service.BatchConfig           `yaml:"batching"`
+	Denylist                  *service.DenylistConfig        `yaml:"denylist"`
+	Identity                  *service.GatewayIdentityConfig `yaml:"identity"`
+	SelfTest                  *service.SelfTestConfig        `yaml:"selfTest"`
+	ClockSkew                 *service.ClockSkewConfig       `yaml:"clockSkew"`
+	Throttle                  *service.ThrottleConfig        `yaml:"throttle"`
+	SignPool                  *service.SignPoolConfig        `yaml:"signPool"`
+	TaskLog                   *service.TaskLogConfig         `yaml:"taskLog"`
+	TxnMetrics                *service.TxnMetricsConfig      `yaml:"txnMetrics"`
+	DeliveryStats             *service.DeliveryStatsConfig   `yaml:"deliveryStats"`
+	Hedging                   *service.HedgeConfig           `yaml:"hedging"`
+	Compression               *service.PayloadCompressionConfig `yaml:"compression"`
+	Shadow                    *service.ShadowConfig          `yaml:"shadow"`
+	DualStack                 *service.DualStackConfig       `yaml:"dualStack"`
+	TargetStatus              *service.TargetStatusConfig    `yaml:"targetStatus"`
+	RegistrationCheck         *service.RegistrationCheckConfig `yaml:"registrationCheck"`
+	AuthSchemes               *service.AuthSchemeConfig      `yaml:"authSchemes"`
+	Redaction                 *service.RedactionConfig       `yaml:"redaction"`
+	TTLDeadline               *service.TTLDeadlineConfig     `yaml:"ttlDeadline"`
+	HostRateLimit             *service.HostRateLimitConfig   `yaml:"hostRateLimit"`
 }
 
 type serverConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+	Network string `yaml:"network"` // "tcp" (dual-stack, default), "tcp4" or "tcp6".
 }
 
 type timeoutConfig struct {
@@ -104,6 +142,11 @@ func (c *config) valid() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	switch c.Server.Network {
+	case "", "tcp", "tcp4", "tcp6":
+	default:
+		return fmt.Errorf("invalid server network: %q, must be tcp, tcp4 or tcp6", c.Server.Network)
+	}
 	if c.Registry == nil {
 		return fmt.Errorf("missing required config section: registry")
 	}
@@ -124,15 +167,61 @@ func (c *config) valid() error {
 		// Provide default values or handle as an error if strict config is required
 		c.HTTPClientRetry = &service.RetryConfig{RetryMax: 1, RetryWaitMin: 1 * time.Second, RetryWaitMax: 30 * time.Second}
 	}
+	if err := validateJournal("journal", c.Journal); err != nil {
+		return err
+	}
+	if err := validateJournal("deadLetter", c.DeadLetter); err != nil {
+		return err
+	}
+	if c.DeadLetter != nil && c.DeadLetter.Lease != nil {
+		return fmt.Errorf("lease is not supported for the deadLetter journal")
+	}
+	if c.KeyAudit != nil && c.Event == nil {
+		return fmt.Errorf("missing required config section for keyAudit: event")
+	}
 	if c.KeyManagerCacheTTL == nil {
 		slog.Warn("Config validation: keyManagerCacheTTL section missing, using default retry values.")
 		// Provide default values or handle as an error if strict config is required
-		c.KeyManagerCacheTTL = &keyManager.CacheTTL{PrivateKeysSeconds: 5, PublicKeysSeconds: 3600}
+		c.KeyManagerCacheTTL = &keymanager.CacheTTL{PrivateKeysSeconds: 5, PublicKeysSeconds: 3600}
 	}
 
 	return nil
 }
 
+// validateJournal validates an optional journal config section.
+func validateJournal(name string, j *service.JournalConfig) error {
+	if j == nil {
+		return nil
+	}
+	switch j.Type {
+	case service.JournalTypeRedis:
+		if j.Stream == "" {
+			return fmt.Errorf("missing %s stream for redis journal", name)
+		}
+	case service.JournalTypeDisk:
+		if j.Dir == "" {
+			return fmt.Errorf("missing %s dir for disk journal", name)
+		}
+		if j.Lease != nil {
+			return fmt.Errorf("%s lease requires a redis journal", name)
+		}
+	default:
+		return fmt.Errorf("invalid %s type: %q", name, j.Type)
+	}
+	return nil
+}
+
+// listen binds the server address on the configured network. With the default "tcp",
+// an empty or unspecified host accepts both IPv4 and IPv6 connections; "tcp6" binds
+// IPv6 only.
+func listen(cfg *serverConfig) (net.Listener, error) {
+	network := cfg.Network
+	if network == "" {
+		network = "tcp"
+	}
+	return net.Listen(network, net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+}
+
 // run starts the HTTP server and handles graceful shutdown.
 func run(ctx context.Context) error {
 	cfg, err := initConfig(configPath)
@@ -167,52 +256,256 @@ func run(ctx context.Context) error {
 	}()
 	rClient := beckn.NewRegisteryClient(&beckn.Config{RegisteryURL: cfg.Registry.BaseURL})
 
-	keyManagerConfig := &keyManager.Config{
+	km, closeKM, err := keymanager.New(ctx, redis, rClient, &keymanager.Config{
+		Type:      cfg.KeyManagerType,
 		ProjectID: cfg.ProjectID,
-		CacheTTL: keyManager.CacheTTL{
-			PrivateKeysSeconds: cfg.KeyManagerCacheTTL.PrivateKeysSeconds,
-			PublicKeysSeconds:  cfg.KeyManagerCacheTTL.PublicKeysSeconds,
-		},
-	}
-
-	km, closeKM, err := keyManager.New(ctx, redis, rClient, keyManagerConfig)
+		CacheTTL:  *cfg.KeyManagerCacheTTL,
+		Migration: cfg.KeyManagerMigration,
+		Warmup:    cfg.KeyManagerWarmup,
+		Expiry:    cfg.KeyManagerExpiry,
+		K8sSecret: cfg.KeyManagerK8sSecret,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create secrets key manager: %w", err)
+		return fmt.Errorf("failed to create key manager: %w", err)
 	}
 	defer func() {
 		if err := closeKM(); err != nil {
 			slog.ErrorContext(ctx, "failed to close key manager", "error", err)
 		}
 	}()
+	if cfg.KeyAudit != nil {
+		evPub, closePub, err := event.NewPublisher(ctx, cfg.Event)
+		if err != nil {
+			return fmt.Errorf("failed to create event publisher: %w", err)
+		}
+		defer closePub()
+		var closeAudit func() error
+		km, closeAudit, err = keymanager.NewAuditing(km, evPub, "gateway", cfg.KeyAudit)
+		if err != nil {
+			return fmt.Errorf("invalid key audit config: %w", err)
+		}
+		// Pending audit events are published before the publisher is closed.
+		defer closeAudit()
+	}
 
 	signer, _, err := signer.New(ctx, &signer.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to create signer: %w", err)
 	}
+	// Keysets of every key algorithm can sign and be validated, not only Ed25519 ones.
+	keyAlgoSigner, err := service.NewKeyAlgoSigner(signer)
+	if err != nil {
+		return fmt.Errorf("failed to create key algorithm signer: %w", err)
+	}
+	keyAlgoSV, err := service.NewKeyAlgoSignValidator(sv)
+	if err != nil {
+		return fmt.Errorf("failed to create key algorithm signature validator: %w", err)
+	}
 
 	// Initialize TxnSignValidator
-	txnValidator, err := service.NewTxnSignValidator(sv, km)
+	txnValidator, err := service.NewTxnSignValidator(keyAlgoSV, km)
 	if err != nil {
 		return fmt.Errorf("failed to create transaction sign validator: %w", err)
 	}
+	if cfg.SignPool != nil {
+		if err := txnValidator.SetPool(cfg.SignPool); err != nil {
+			return fmt.Errorf("invalid signature validation pool config: %w", err)
+		}
+		slog.InfoContext(ctx, "Prewarmed signing keys", "count", txnValidator.Prewarm(ctx))
+	}
 
-	authGen, err := service.NewAuthGenService(km, signer)
+	authGen, err := service.NewAuthGenService(km, keyAlgoSigner)
 	if err != nil {
 		return fmt.Errorf("failed to create auth gen service: %w", err)
 	}
+	var authSchemes interface {
+		SubscriberAuth(h http.Header) (string, error)
+		Emit() service.AuthScheme
+	}
+	if cfg.AuthSchemes != nil {
+		if authSchemes, err = service.NewAuthSchemes(cfg.AuthSchemes); err != nil {
+			return fmt.Errorf("invalid auth schemes config: %w", err)
+		}
+		authGen.SetScheme(authSchemes.Emit())
+	}
 
 	pTaskProcessor, err := service.NewProxyTaskProcessor(authGen, cfg.SubscriberID, *cfg.HTTPClientRetry)
 	if err != nil {
 		return fmt.Errorf("failed to create proxy task processor: %w", err)
 	}
+	// Outbound requests and health checks identify the gateway, by default by its subscriber ID.
+	identity, err := service.NewGatewayIdentity(cfg.Identity, cfg.SubscriberID)
+	if err != nil {
+		return fmt.Errorf("failed to create gateway identity: %w", err)
+	}
+	pTaskProcessor.SetIdentity(identity)
+	if cfg.TaskLog != nil {
+		taskLog, err := service.NewTaskLogger(cfg.TaskLog)
+		if err != nil {
+			return fmt.Errorf("failed to create task logger: %w", err)
+		}
+		pTaskProcessor.SetTaskLogger(taskLog)
+	}
+	// Payloads that are logged or dead-lettered have personal data redacted first.
+	var redactor interface {
+		Redact(body []byte) []byte
+	}
+	if cfg.Redaction != nil {
+		r, err := service.NewPayloadRedactor(cfg.Redaction)
+		if err != nil {
+			return fmt.Errorf("failed to create payload redactor: %w", err)
+		}
+		pTaskProcessor.SetRedactor(r)
+		redactor = r
+	}
+	var txnMetrics interface {
+		RecordRequest(c *model.Context, acked bool, d time.Duration)
+		Summary() *model.TxnSummary
+	}
+	if cfg.TxnMetrics != nil {
+		m, err := service.NewTxnMetrics(cfg.TxnMetrics)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction metrics: %w", err)
+		}
+		pTaskProcessor.SetTxnMetrics(m)
+		txnMetrics = m
+	}
+	if cfg.Hedging != nil {
+		hedger, err := service.NewHedger(cfg.Hedging)
+		if err != nil {
+			return fmt.Errorf("failed to create hedger: %w", err)
+		}
+		pTaskProcessor.SetHedging(hedger)
+	}
+	if cfg.TTLDeadline != nil {
+		deadline, err := service.NewTTLDeadline(cfg.TTLDeadline)
+		if err != nil {
+			return fmt.Errorf("failed to create ttl deadline: %w", err)
+		}
+		pTaskProcessor.SetTTLDeadline(deadline)
+	}
+	if cfg.HostRateLimit != nil {
+		l, err := service.NewHostRateLimiter(cfg.HostRateLimit)
+		if err != nil {
+			return fmt.Errorf("failed to create host rate limiter: %w", err)
+		}
+		pTaskProcessor.SetHostRateLimit(l)
+	}
+	var compression interface {
+		Decompress(encoding string, body []byte) ([]byte, error)
+	}
+	if cfg.Compression != nil {
+		c, err := service.NewPayloadCompression(cfg.Compression)
+		if err != nil {
+			return fmt.Errorf("failed to create payload compression: %w", err)
+		}
+		pTaskProcessor.SetCompression(c)
+		compression = c
+	}
+	var deliveryStats interface {
+		Report(ctx context.Context) (*model.DeliveryStatsReport, error)
+	}
+	if cfg.DeliveryStats != nil {
+		d, err := service.NewDeliveryStats(redis.GetClient(), cfg.DeliveryStats)
+		if err != nil {
+			return fmt.Errorf("failed to create delivery stats: %w", err)
+		}
+		d.Start()
+		defer d.Stop()
+		pTaskProcessor.SetDeliveryStats(d)
+		deliveryStats = d
+	}
+	if cfg.TargetPolicy != nil {
+		targetPolicy, err := service.NewTargetPolicy(cfg.TargetPolicy)
+		if err != nil {
+			return fmt.Errorf("failed to create target policy: %w", err)
+		}
+		pTaskProcessor.SetTargetPolicy(targetPolicy)
+	}
+	if cfg.DualStack != nil {
+		if err := pTaskProcessor.SetDualStack(cfg.DualStack); err != nil {
+			return fmt.Errorf("failed to configure dual-stack dialing: %w", err)
+		}
+	}
+	if len(cfg.Transforms) > 0 {
+		transformer, err := service.NewPayloadTransformer(cfg.Transforms)
+		if err != nil {
+			return fmt.Errorf("failed to create payload transformer: %w", err)
+		}
+		pTaskProcessor.SetTransformer(transformer)
+	}
+	var batcher interface {
+		Batches(uris []string) [][]string
+	}
+	if cfg.Batching != nil {
+		b, err := service.NewBatcher(cfg.Batching)
+		if err != nil {
+			return fmt.Errorf("failed to create fanout batcher: %w", err)
+		}
+		pTaskProcessor.SetBatchPacer(b)
+		batcher = b
+	}
 	registryClient, err := client.NewRegistryClient(cfg.Registry)
 	if err != nil {
 		return fmt.Errorf("failed to create registry client: %w", err)
 	}
+	if cfg.RegistrationCheck != nil {
+		// A gateway whose own registration lapsed would only send requests that participants reject.
+		registrationCheck, err := service.NewRegistrationCheck(registryClient, km, cfg.SubscriberID, cfg.RegistrationCheck)
+		if err != nil {
+			return fmt.Errorf("failed to create registration check: %w", err)
+		}
+		if err := registrationCheck.Run(ctx); err != nil {
+			return fmt.Errorf("gateway registration check failed: %w", err)
+		}
+	}
 	channelTaskQ, err := service.NewChannelTaskQueue(cfg.TaskQueueWorkersCount, ctx, pTaskProcessor, nil, cfg.TaskQueueBufferSize) // Lookup processor will be set later
 	if err != nil {
 		return fmt.Errorf("failed to create channel task queue: %w", err)
 	}
+	if err := channelTaskQ.SetPartitions(cfg.TaskQueuePartitions); err != nil {
+		return fmt.Errorf("invalid taskQueuePartitions: %w", err)
+	}
+	actions, err := service.NewActionRegistry(cfg.Actions)
+	if err != nil {
+		return fmt.Errorf("failed to create action registry: %w", err)
+	}
+	channelTaskQ.SetActionRouter(actions)
+	var throttle interface {
+		Allow(uri string) bool
+	}
+	if cfg.Throttle != nil {
+		t, err := service.NewLatencyThrottle(channelTaskQ, cfg.Throttle)
+		if err != nil {
+			return fmt.Errorf("failed to create fanout throttle: %w", err)
+		}
+		pTaskProcessor.SetThrottle(t)
+		throttle = t
+	}
+	if cfg.Journal != nil {
+		journal, err := service.NewJournal(cfg.Journal, redis.GetClient())
+		if err != nil {
+			return fmt.Errorf("failed to create request journal: %w", err)
+		}
+		channelTaskQ.SetJournal(journal)
+		if cfg.Journal.Lease != nil {
+			lease, err := service.NewRedisTaskLease(redis.GetClient(), cfg.Journal.Lease)
+			if err != nil {
+				return fmt.Errorf("failed to create journal lease: %w", err)
+			}
+			channelTaskQ.SetLease(lease, cfg.Journal.Lease)
+		}
+	}
+	if cfg.DeadLetter != nil {
+		deadLetter, err := service.NewJournal(cfg.DeadLetter, redis.GetClient())
+		if err != nil {
+			return fmt.Errorf("failed to create dead-letter journal: %w", err)
+		}
+		channelTaskQ.SetDeadLetter(deadLetter)
+		if redactor != nil {
+			channelTaskQ.SetRedactor(redactor)
+		}
+	}
 	channelTaskQ.StartWorkers()
 	defer channelTaskQ.StopWorkers() // Add to graceful shutdown logic
 
@@ -220,27 +513,110 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create lookup task processor: %w", err)
 	}
+	if batcher != nil {
+		lTaskProcessor.SetBatching(batcher, channelTaskQ)
+	}
+	if throttle != nil {
+		lTaskProcessor.SetThrottle(throttle)
+	}
 	channelTaskQ.SetLookupProcessor(lTaskProcessor)
+	if _, err := channelTaskQ.ReplayJournal(ctx); err != nil {
+		return fmt.Errorf("failed to replay request journal: %w", err)
+	}
 
 	// Initialize Gateway Handler
 	gwHandler, err := handler.NewGatewayHandler(txnValidator, channelTaskQ)
 	if err != nil {
 		return fmt.Errorf("failed to create gateway handler: %w", err)
 	}
+	gwHandler.SetActionValidator(actions)
+	gwHandler.SetIdentity(identity)
+	if authSchemes != nil {
+		gwHandler.SetAuthSchemes(authSchemes)
+	}
+	if txnMetrics != nil {
+		gwHandler.SetTxnMetrics(txnMetrics)
+	}
+	if deliveryStats != nil {
+		gwHandler.SetDeliveryStats(deliveryStats)
+	}
+	if compression != nil {
+		gwHandler.SetCompression(compression)
+	}
+	if cfg.SelfTest != nil {
+		selfTest, err := service.NewSelfTest(keyAlgoSV, km, registryClient, cfg.SelfTest)
+		if err != nil {
+			return fmt.Errorf("failed to create self-test: %w", err)
+		}
+		gwHandler.SetSelfTest(selfTest)
+	}
+	if cfg.ClockSkew != nil {
+		clockSkew, err := service.NewClockSkew(cfg.ClockSkew)
+		if err != nil {
+			return fmt.Errorf("failed to create clock skew check: %w", err)
+		}
+		gwHandler.SetClockSkew(clockSkew)
+	}
+	if cfg.CoreVersions != nil {
+		versionPolicy, err := service.NewCoreVersionPolicy(cfg.CoreVersions)
+		if err != nil {
+			return fmt.Errorf("failed to create core version policy: %w", err)
+		}
+		gwHandler.SetCoreVersionPolicy(versionPolicy)
+	}
+	if cfg.Backpressure != nil {
+		bp, err := service.NewBackpressure(channelTaskQ, cfg.Backpressure)
+		if err != nil {
+			return fmt.Errorf("failed to create task queue backpressure: %w", err)
+		}
+		gwHandler.SetBackpressure(bp)
+	}
+	if cfg.Denylist != nil {
+		// The admin service publishes the denylist to Redis after every change.
+		src, err := service.NewCachedDenylistSource(redis)
+		if err != nil {
+			return fmt.Errorf("failed to create denylist source: %w", err)
+		}
+		denylist, err := service.NewDenylist(src, cfg.Denylist)
+		if err != nil {
+			return fmt.Errorf("failed to create denylist: %w", err)
+		}
+		gwHandler.SetDenylist(denylist)
+	}
+	if cfg.TargetStatus != nil {
+		targetStatus, err := service.NewTargetStatus(registryClient, cfg.TargetStatus)
+		if err != nil {
+			return fmt.Errorf("failed to create target status check: %w", err)
+		}
+		gwHandler.SetTargetStatus(targetStatus)
+	}
+	if cfg.Shadow != nil {
+		shadow, err := service.NewShadowMirror(authGen, cfg.Shadow)
+		if err != nil {
+			return fmt.Errorf("failed to create shadow mirror: %w", err)
+		}
+		shadow.Start()
+		defer shadow.Stop()
+		gwHandler.SetShadow(shadow)
+	}
 
 	// Initialize HTTP Server
 	server := &http.Server{
 		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      gateway.NewRouter(gwHandler),
+		Handler:      gateway.NewRouter(gwHandler, actions.Names()...),
 		ReadTimeout:  cfg.Timeouts.Read,
 		WriteTimeout: cfg.Timeouts.Write,
 		IdleTimeout:  cfg.Timeouts.Idle,
 	}
 
+	ln, err := listen(cfg.Server)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
+	}
 	serverErr := make(chan error, 1)
 	go func() {
-		slog.Info("Gateway server starting...", "address", server.Addr)
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Info("Gateway server starting...", "address", ln.Addr().String(), "network", ln.Addr().Network())
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErr <- err
 		}
 	}()
@@ -251,6 +627,8 @@ func run(ctx context.Context) error {
 	select {
 	case err := <-serverErr:
 		slog.Error("FATAL: Gateway server failed to start or encountered an error", "error", err)
+		// os.Exit skips the deferred Close of the key manager.
+		keymanager.Wipe(km)
 		os.Exit(1) // Consider returning error instead of os.Exit for better testability
 	case sig := <-quit:
 		slog.Info("Shutdown signal received", "signal", sig.String())
@@ -259,12 +637,17 @@ func run(ctx context.Context) error {
 	slog.Info("Attempting to shut down Gateway server gracefully...", "timeout", cfg.Timeouts.Shutdown.String())
 	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, cfg.Timeouts.Shutdown)
 	defer cancelShutdown()
+	// A second signal while requests drain exits right away, after wiping the keys.
+	stopWipe := keymanager.WipeOnSignal(km, quit, os.Exit)
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("Graceful Gateway server shutdown failed", "error", err)
 	} else {
 		slog.Info("Gateway server shut down gracefully.")
 	}
+	stopWipe()
+	// Keys are wiped as soon as requests have drained, before the remaining cleanup runs.
+	keymanager.Wipe(km)
 
 	slog.Info("Gateway service has stopped.")
 	return nil
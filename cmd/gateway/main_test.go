@@ -15,6 +15,7 @@
 package main
 
 import (
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -22,6 +23,7 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
 )
 
 func TestConfig_Valid_Success(t *testing.T) {
@@ -198,6 +200,15 @@ func TestConfig_Valid_Error(t *testing.T) {
 			SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg},
 			expectedError: "invalid server port: 65536",
 		},
+		{
+			name:          "invalid server network",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: &serverConfig{Port: 8080, Network: "udp"}, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+			MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount: 5,
+				TaskQueueBufferSize: 100,
+			SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg},
+			expectedError: `invalid server network: "udp"`,
+		},
 		{
 			name: "missing registry config",
 			cfg: &config{
@@ -279,6 +290,54 @@ func TestConfig_Valid_Error(t *testing.T) {
 			},
 			expectedError: "missing subscriber ID",
 		},
+		{
+			name: "invalid journal type",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, Journal: &service.JournalConfig{Type: "kafka"}},
+			expectedError: "invalid journal type",
+		},
+		{
+			name: "redis journal missing stream",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, Journal: &service.JournalConfig{Type: service.JournalTypeRedis}},
+			expectedError: "missing journal stream",
+		},
+		{
+			name: "disk journal missing dir",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, Journal: &service.JournalConfig{Type: service.JournalTypeDisk}},
+			expectedError: "missing journal dir",
+		},
+		{
+			name: "invalid dead-letter type",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, DeadLetter: &service.JournalConfig{Type: "kafka"}},
+			expectedError: "invalid deadLetter type",
+		},
+		{
+			name: "redis dead-letter missing stream",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, DeadLetter: &service.JournalConfig{Type: service.JournalTypeRedis}},
+			expectedError: "missing deadLetter stream",
+		},
+		{
+			name: "disk journal with lease",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, Journal: &service.JournalConfig{Type: service.JournalTypeDisk, Dir: "/tmp/journal", Lease: &service.TaskLeaseConfig{}}},
+			expectedError: "journal lease requires a redis journal",
+		},
+		{
+			name: "dead-letter with lease",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, DeadLetter: &service.JournalConfig{Type: service.JournalTypeRedis, Stream: "dlq", Lease: &service.TaskLeaseConfig{}}},
+			expectedError: "lease is not supported for the deadLetter journal",
+		},
+		{
+			name: "key audit missing event",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, ProjectID: "proj", Registry: validRegistryCfg, RedisAddr: "redis",
+				SubscriberID: "sub-id", HTTPClientRetry: validRetryCfg, KeyAudit: &keymanager.AuditConfig{}},
+			expectedError: "missing required config section for keyAudit: event",
+		},
 		{
 			name: "nil HTTPClientRetry (should not error, but set defaults)",
 			cfg: &config{
@@ -325,3 +384,32 @@ func TestConfig_Valid_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestListen(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         *serverConfig
+		wantNetwork string
+		wantIPv4    bool
+	}{
+		{name: "dual-stack default", cfg: &serverConfig{Host: "127.0.0.1"}, wantNetwork: "tcp", wantIPv4: true},
+		{name: "ipv4 only", cfg: &serverConfig{Host: "127.0.0.1", Network: "tcp4"}, wantNetwork: "tcp", wantIPv4: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := listen(tt.cfg)
+			if err != nil {
+				t.Fatalf("listen() error = %v", err)
+			}
+			defer ln.Close()
+			addr := ln.Addr().(*net.TCPAddr)
+			if ln.Addr().Network() != tt.wantNetwork || (addr.IP.To4() != nil) != tt.wantIPv4 {
+				t.Errorf("listen() address = %s %s, want %s with IPv4 %v", ln.Addr().Network(), addr, tt.wantNetwork, tt.wantIPv4)
+			}
+		})
+	}
+
+	if _, err := listen(&serverConfig{Host: "127.0.0.1", Network: "tcp6"}); err == nil {
+		t.Error("listen() on an IPv4 address with tcp6 error = nil, want error")
+	}
+}
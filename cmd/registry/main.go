@@ -25,9 +25,11 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/network"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/registry"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/registry/handler"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
@@ -37,16 +39,108 @@ import (
 
 	"github.com/beckn/beckn-onix/pkg/plugin/definition"
 	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signvalidator"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"gopkg.in/yaml.v2"
 )
 
 // config represents application configuration.
 type config struct {
-	Log      *log.Config        `yaml:"log"`
-	Timeouts *timeoutConfig     `yaml:"timeouts"`
-	Server   *serverConfig      `yaml:"server"`
-	DB       *repository.Config `yaml:"db"`
-	Event    *event.Config      `yaml:"event"`
+	Log          *log.Config                  `yaml:"log"`
+	Timeouts     *timeoutConfig               `yaml:"timeouts"`
+	Server       *serverConfig                `yaml:"server"`
+	DB           *repository.Config           `yaml:"db"`
+	Event        *event.Config                `yaml:"event"`
+	Nonce        *service.NonceConfig         `yaml:"nonce"`
+	URLProbe     *service.URLProbeConfig      `yaml:"urlProbe"`
+	PendingQuota *service.PendingQuotaConfig  `yaml:"pendingQuota"`
+	Attestation  *service.AttestationConfig   `yaml:"attestation"`
+	Maintenance  *service.MaintenanceConfig   `yaml:"maintenance"`
+	Denylist     *service.DenylistConfig      `yaml:"denylist"`
+	Domains      *service.DomainCatalogConfig `yaml:"domains"`
+	Compression  *handler.CompressionConfig   `yaml:"compression"`
+	Tracing      *log.TracingConfig           `yaml:"tracing"`
+	// ValidityPolicy limits the validity of requested subscriptions per role if set.
+	ValidityPolicy *service.ValidityPolicyConfig `yaml:"validityPolicy"`
+	// LookupTiers rate limits lookups and returns only public fields to unsigned ones if set.
+	LookupTiers *service.LookupTierConfig `yaml:"lookupTiers"`
+	// RequestQuota caps the operations and lookups of each caller per hour and per day if set.
+	RequestQuota *service.RequestQuotaConfig `yaml:"requestQuota"`
+	// LookupCache caches lookup results if set. Set db.notifications to evict them as soon as
+	// subscriptions change rather than only once they expire.
+	LookupCache *service.LookupCacheConfig `yaml:"lookupCache"`
+	// Network is the name of the network served to requests that select no network profile.
+	Network string `yaml:"network"`
+	// Networks are the network profiles served besides the default network.
+	Networks []networkConfig `yaml:"networks"`
+}
+
+// networkConfig is a network profile, such as a sandbox network served next to production.
+// Its sections replace those of the default network; sections it does not set are inherited.
+type networkConfig struct {
+	Name           string                        `yaml:"name"`
+	DB             *repository.Config            `yaml:"db"`
+	Event          *event.Config                 `yaml:"event"`
+	Nonce          *service.NonceConfig          `yaml:"nonce"`
+	URLProbe       *service.URLProbeConfig       `yaml:"urlProbe"`
+	PendingQuota   *service.PendingQuotaConfig   `yaml:"pendingQuota"`
+	Attestation    *service.AttestationConfig    `yaml:"attestation"`
+	Maintenance    *service.MaintenanceConfig    `yaml:"maintenance"`
+	Denylist       *service.DenylistConfig       `yaml:"denylist"`
+	Domains        *service.DomainCatalogConfig  `yaml:"domains"`
+	ValidityPolicy *service.ValidityPolicyConfig `yaml:"validityPolicy"`
+	LookupTiers    *service.LookupTierConfig     `yaml:"lookupTiers"`
+	RequestQuota   *service.RequestQuotaConfig   `yaml:"requestQuota"`
+}
+
+// profile returns the configuration of network profile n.
+func (c *config) profile(n networkConfig) *config {
+	p := *c
+	p.Network, p.Networks = n.Name, nil
+	if n.DB != nil {
+		p.DB = n.DB
+	}
+	// Pool statistics are published under fixed names, so only the default pool is monitored.
+	db := *p.DB
+	db.Monitor = nil
+	if n.DB == nil {
+		// Changes to the shared database are already received by the default network.
+		db.Notifications = nil
+	}
+	p.DB = &db
+	if n.Event != nil {
+		p.Event = n.Event
+	}
+	if n.Nonce != nil {
+		p.Nonce = n.Nonce
+	}
+	if n.URLProbe != nil {
+		p.URLProbe = n.URLProbe
+	}
+	if n.PendingQuota != nil {
+		p.PendingQuota = n.PendingQuota
+	}
+	if n.Attestation != nil {
+		p.Attestation = n.Attestation
+	}
+	if n.Maintenance != nil {
+		p.Maintenance = n.Maintenance
+	}
+	if n.Denylist != nil {
+		p.Denylist = n.Denylist
+	}
+	if n.Domains != nil {
+		p.Domains = n.Domains
+	}
+	if n.ValidityPolicy != nil {
+		p.ValidityPolicy = n.ValidityPolicy
+	}
+	if n.LookupTiers != nil {
+		p.LookupTiers = n.LookupTiers
+	}
+	if n.RequestQuota != nil {
+		p.RequestQuota = n.RequestQuota
+	}
+	return &p
 }
 
 type serverConfig struct {
@@ -102,6 +196,13 @@ func (c *config) valid() error {
 	if c.Event == nil {
 		return fmt.Errorf("missing required config section: event")
 	}
+	names := map[string]bool{c.Network: true}
+	for _, n := range c.Networks {
+		if n.Name == "" || names[n.Name] || strings.Contains(n.Name, "/") {
+			return fmt.Errorf("invalid network profile name %q: it must be non-empty, unique and without '/'", n.Name)
+		}
+		names[n.Name] = true
+	}
 	return nil
 }
 
@@ -114,6 +215,17 @@ func run(ctx context.Context) error {
 	if err := log.Setup(cfg.Log); err != nil {
 		return err
 	}
+	if cfg.Tracing != nil {
+		shutdownTracing, err := log.SetupTracing(cfg.Tracing)
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				slog.Error("failed to flush traces", "error", err)
+			}
+		}()
+	}
 	db, dbCleanUp, err := newConnectionPool(ctx, cfg.DB)
 	if err != nil {
 		return fmt.Errorf("failed to open database connection: %w", err)
@@ -124,6 +236,23 @@ func run(ctx context.Context) error {
 		}
 	}()
 
+	networkDBs := make(map[string]*sql.DB)
+	for _, n := range cfg.Networks {
+		if n.DB == nil {
+			continue
+		}
+		ndb, ndbCleanUp, err := newConnectionPool(ctx, n.DB)
+		if err != nil {
+			return fmt.Errorf("failed to open database connection of network %s: %w", n.Name, err)
+		}
+		defer func() {
+			if err := ndbCleanUp(); err != nil {
+				slog.Error("failed to clean up database connection", "network", n.Name, "error", err)
+			}
+		}()
+		networkDBs[n.Name] = ndb
+	}
+
 	sv, svClose, err := signvalidator.New(ctx, &signvalidator.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to create signature validator: %w", err)
@@ -135,7 +264,12 @@ func run(ctx context.Context) error {
 			}
 		}()
 	}
-	server, err := newServer(ctx, cfg, db, sv)
+	// Signatures of keys of every key algorithm are validated, not only Ed25519 ones.
+	keyAlgoSV, err := service.NewKeyAlgoSignValidator(sv)
+	if err != nil {
+		return fmt.Errorf("failed to create key algorithm signature validator: %w", err)
+	}
+	server, err := newServer(ctx, cfg, db, networkDBs, keyAlgoSV)
 	if err != nil {
 		return err
 	}
@@ -177,49 +311,270 @@ func run(ctx context.Context) error {
 var configPath string
 var newConnectionPool = repository.NewConnectionPool
 
-func newServer(ctx context.Context, cfg *config, db *sql.DB, sv definition.SignValidator) (*http.Server, error) {
+// backgroundJob is a task started with the server and stopped when it shuts down.
+type backgroundJob interface {
+	Start(context.Context)
+	Stop()
+}
+
+// subscriptionListener broadcasts the changes to the subscriptions table while it runs.
+type subscriptionListener interface {
+	backgroundJob
+	OnChange(repository.SubscriptionChangeHook)
+}
+
+var newSubscriptionListener = func(ctx context.Context, cfg *repository.Config) (subscriptionListener, error) {
+	l, err := repository.NewSubscriptionListener(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// newServer creates the HTTP server of the default network and of each network profile.
+// networkDBs holds the connection pools of the network profiles with their own database.
+func newServer(ctx context.Context, cfg *config, db *sql.DB, networkDBs map[string]*sql.DB, sv definition.SignValidator) (*http.Server, error) {
+	h, jobs, listener, err := newHandler(ctx, cfg, db, sv, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Networks) > 0 {
+		profiles := make(map[string]http.Handler, len(cfg.Networks))
+		for _, n := range cfg.Networks {
+			ndb, nlistener := db, listener
+			if n.DB != nil {
+				ndb, nlistener = networkDBs[n.Name], nil
+			}
+			nh, njobs, _, err := newHandler(ctx, cfg.profile(n), ndb, sv, nlistener)
+			if err != nil {
+				return nil, fmt.Errorf("network %s: %w", n.Name, err)
+			}
+			profiles[n.Name] = nh
+			jobs = append(jobs, njobs...)
+		}
+		if h, err = network.NewRouter(cfg.Network, h, profiles); err != nil {
+			slog.Error("Failed to create network router", "error", err)
+			return nil, fmt.Errorf("failed to create network router: %w", err)
+		}
+	}
+	srv := &http.Server{
+		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
+		Handler:      h,
+		ReadTimeout:  cfg.Timeouts.Read,
+		WriteTimeout: cfg.Timeouts.Write,
+		IdleTimeout:  cfg.Timeouts.Idle,
+	}
+	if cfg.Tracing != nil {
+		srv.Handler = otelhttp.NewHandler(srv.Handler, "registry", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}))
+	}
+	for _, j := range jobs {
+		j.Start(ctx)
+		srv.RegisterOnShutdown(j.Stop)
+	}
+	return srv, nil
+}
+
+// newHandler creates the router of the network configured by cfg, with the background jobs
+// to run while it is served and the listener for changes to its subscriptions, if any.
+// Networks sharing the database of another network are given the listener of that network.
+func newHandler(ctx context.Context, cfg *config, db *sql.DB, sv definition.SignValidator, listener subscriptionListener) (http.Handler, []backgroundJob, subscriptionListener, error) {
+	var jobs []backgroundJob
 	regRep, err := repository.NewRegistry(db)
 	if err != nil {
 		slog.Error("Failed to create registry repository", "error", err)
-		return nil, fmt.Errorf("failed to create registry repository: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create registry repository: %w", err)
+	}
+	regRep.SetQueryTimeouts(cfg.DB.QueryTimeouts)
+	regRep.SetSlowQueryLog(cfg.DB.SlowQueries)
+	regRep.SetRetry(cfg.DB.Retry)
+	if cfg.DB.Encryption != nil {
+		cipher, err := repository.NewKMSColumnCipher(ctx, cfg.DB.Encryption)
+		if err != nil {
+			slog.Error("Failed to create column cipher", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create column cipher: %w", err)
+		}
+		regRep.SetColumnCipher(cipher)
+	}
+	if cfg.DB.Monitor != nil {
+		mon, err := repository.NewPoolMonitor(db, cfg.DB.Monitor)
+		if err != nil {
+			slog.Error("Failed to create connection pool monitor", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create connection pool monitor: %w", err)
+		}
+		regRep.SetConnTracker(mon)
+		jobs = append(jobs, mon)
+	}
+	if cfg.DB.Notifications != nil {
+		listener, err = newSubscriptionListener(ctx, cfg.DB)
+		if err != nil {
+			slog.Error("Failed to create subscription change listener", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create subscription change listener: %w", err)
+		}
+		jobs = append(jobs, listener)
 	}
 	lroSrv, err := service.NewLROService(regRep)
 	if err != nil {
 		slog.Error("Failed to create LRO service", "error", err)
-		return nil, fmt.Errorf("failed to create LRO service: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create LRO service: %w", err)
 	}
 
 	evPub, _, err := event.NewPublisher(ctx, cfg.Event)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create event publisher: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create event publisher: %w", err)
 	}
 	subSrv, err := service.NewSubscriptionService(lroSrv, regRep, evPub)
 	if err != nil {
 		slog.Error("Failed to create subscription service", "error", err)
-		return nil, fmt.Errorf("failed to create subscription service: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create subscription service: %w", err)
+	}
+	if cfg.Nonce != nil {
+		nonceSrv, err := service.NewNonceService(regRep, cfg.Nonce)
+		if err != nil {
+			slog.Error("Failed to create nonce service", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create nonce service: %w", err)
+		}
+		subSrv.SetNonceValidator(nonceSrv)
+	}
+	if cfg.URLProbe != nil {
+		prober, err := service.NewURLProber(regRep, cfg.URLProbe)
+		if err != nil {
+			slog.Error("Failed to create URL prober", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create URL prober: %w", err)
+		}
+		subSrv.SetURLProber(prober)
+	}
+	if cfg.PendingQuota != nil {
+		quota, err := service.NewPendingQuota(regRep, cfg.PendingQuota)
+		if err != nil {
+			slog.Error("Failed to create pending operation quota", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create pending operation quota: %w", err)
+		}
+		subSrv.SetPendingQuota(quota)
+	}
+	domainsCfg := cfg.Domains
+	if domainsCfg == nil {
+		domainsCfg = &service.DomainCatalogConfig{}
+	}
+	domainCatalog, err := service.NewDomainCatalog(regRep, domainsCfg)
+	if err != nil {
+		slog.Error("Failed to create domain catalog", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create domain catalog: %w", err)
+	}
+	if domainsCfg.Enforce {
+		subSrv.SetDomainValidator(domainCatalog)
+	}
+	if cfg.ValidityPolicy != nil {
+		if err := subSrv.SetValidityPolicy(cfg.ValidityPolicy); err != nil {
+			slog.Error("Failed to set validity policy", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to set validity policy: %w", err)
+		}
 	}
 	auth, err := service.NewAuthService(subSrv, sv)
 	if err != nil {
 		slog.Error("Failed to create auth service", "error", err)
-		return nil, fmt.Errorf("failed to create auth service: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create auth service: %w", err)
 	}
 	subHandler, err := handler.NewSubscriptionHandler(subSrv, auth)
 	if err != nil {
 		slog.Error("Failed to create subscription handler", "error", err)
-		return nil, fmt.Errorf("failed to create subscription handler: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create subscription handler: %w", err)
+	}
+	if cfg.Attestation != nil {
+		verifier, err := service.NewAttestationVerifier(cfg.Attestation)
+		if err != nil {
+			slog.Error("Failed to create attestation verifier", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create attestation verifier: %w", err)
+		}
+		subHandler.SetAttestation(verifier)
 	}
 	lroHandler, err := handler.NewLROHandler(lroSrv)
 	if err != nil {
 		slog.Error("Failed to create LRO handler", "error", err)
-		return nil, fmt.Errorf("failed to create LRO handler: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create LRO handler: %w", err)
 	}
-	return &http.Server{
-		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      registry.NewRouter(subHandler, handler.NewLookupHandler(subSrv), lroHandler),
-		ReadTimeout:  cfg.Timeouts.Read,
-		WriteTimeout: cfg.Timeouts.Write,
-		IdleTimeout:  cfg.Timeouts.Idle,
-	}, nil
+	apiKeySrv, err := service.NewAPIKeyService(regRep)
+	if err != nil {
+		slog.Error("Failed to create API key service", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create API key service: %w", err)
+	}
+	apiKeyHandler, err := handler.NewAPIKeyHandler(apiKeySrv)
+	if err != nil {
+		slog.Error("Failed to create API key handler", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create API key handler: %w", err)
+	}
+	maintenanceCfg := cfg.Maintenance
+	if maintenanceCfg == nil {
+		maintenanceCfg = &service.MaintenanceConfig{}
+	}
+	maintenanceSrv, err := service.NewMaintenanceService(regRep, maintenanceCfg)
+	if err != nil {
+		slog.Error("Failed to create maintenance service", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create maintenance service: %w", err)
+	}
+	maintenanceHandler, err := handler.NewMaintenanceHandler(maintenanceSrv)
+	if err != nil {
+		slog.Error("Failed to create maintenance handler", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create maintenance handler: %w", err)
+	}
+	denylistCfg := cfg.Denylist
+	if denylistCfg == nil {
+		denylistCfg = &service.DenylistConfig{}
+	}
+	denylist, err := service.NewDenylist(regRep, denylistCfg)
+	if err != nil {
+		slog.Error("Failed to create denylist", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create denylist: %w", err)
+	}
+	denylistHandler, err := handler.NewDenylistHandler(denylist)
+	if err != nil {
+		slog.Error("Failed to create denylist handler", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create denylist handler: %w", err)
+	}
+	domainHandler, err := handler.NewDomainHandler(domainCatalog)
+	if err != nil {
+		slog.Error("Failed to create domain handler", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create domain handler: %w", err)
+	}
+	compressionHandler, err := handler.NewCompressionHandler(cfg.Compression)
+	if err != nil {
+		slog.Error("Failed to create compression handler", "error", err)
+		return nil, nil, nil, fmt.Errorf("failed to create compression handler: %w", err)
+	}
+	lookupHandler := handler.NewLookupHandler(subSrv)
+	if cfg.LookupCache != nil {
+		cache, err := service.NewLookupCache(subSrv, cfg.LookupCache)
+		if err != nil {
+			slog.Error("Failed to create lookup cache", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create lookup cache: %w", err)
+		}
+		// Changes to subscriptions made by any instance or the admin service evict the cached lookups.
+		if listener != nil {
+			listener.OnChange(cache.Invalidate)
+		}
+		lookupHandler = handler.NewLookupHandler(cache)
+	}
+	if cfg.LookupTiers != nil {
+		tiers, err := service.NewLookupTiers(regRep, sv, cfg.LookupTiers)
+		if err != nil {
+			slog.Error("Failed to create lookup tiers", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create lookup tiers: %w", err)
+		}
+		lookupHandler.SetTiers(tiers)
+	}
+	if cfg.RequestQuota != nil {
+		quota, err := service.NewRequestQuota(regRep, cfg.RequestQuota)
+		if err != nil {
+			slog.Error("Failed to create request quota", "error", err)
+			return nil, nil, nil, fmt.Errorf("failed to create request quota: %w", err)
+		}
+		subSrv.SetRequestQuota(quota)
+		lookupHandler.SetQuota(quota)
+		jobs = append(jobs, quota)
+	}
+	router := registry.NewRouter(subHandler, lookupHandler, lroHandler, apiKeyHandler, maintenanceHandler, denylistHandler, compressionHandler, domainHandler)
+	return router, jobs, listener, nil
 }
 
 func main() {
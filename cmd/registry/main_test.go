@@ -19,15 +19,19 @@ import (
 	"database/sql"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/network"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 
 	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
 	"cloud.google.com/go/pubsub/pstest"
@@ -253,6 +257,21 @@ func TestConfigValidError(t *testing.T) {
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg},
 			expectedError: "missing required config section: event",
 		},
+		{
+			name:          "network profile without name",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg, Networks: []networkConfig{{}}},
+			expectedError: `invalid network profile name ""`,
+		},
+		{
+			name:          "network profile named as default network",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg, Network: "production", Networks: []networkConfig{{Name: "production"}}},
+			expectedError: `invalid network profile name "production"`,
+		},
+		{
+			name:          "duplicate network profiles",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg, Networks: []networkConfig{{Name: "sandbox"}, {Name: "sandbox"}}},
+			expectedError: `invalid network profile name "sandbox"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -293,7 +312,7 @@ func TestNewServerSuccess(t *testing.T) {
 
 	mockSV := &mockSignValidator{}
 
-	server, err := newServer(ctx, cfg, mockDB, mockSV)
+	server, err := newServer(ctx, cfg, mockDB, nil, mockSV)
 	if err != nil {
 		t.Fatalf("newServer() error = %v, wantErr nil", err)
 	}
@@ -319,6 +338,163 @@ func TestNewServerSuccess(t *testing.T) {
 	}
 }
 
+func TestConfigProfile(t *testing.T) {
+	cfg := &config{
+		DB:             &repository.Config{Name: "production", Monitor: &repository.PoolMonitorConfig{}, Notifications: &repository.SubscriptionNotifyConfig{}},
+		Event:          &event.Config{ProjectID: "test", TopicID: "production"},
+		Nonce:          &service.NonceConfig{},
+		ValidityPolicy: &service.ValidityPolicyConfig{},
+		Network:        "production",
+		Networks:       []networkConfig{{Name: "sandbox"}},
+	}
+	sandboxDomains := &service.DomainCatalogConfig{Enforce: true}
+	sandboxEvent := &event.Config{ProjectID: "test", TopicID: "sandbox"}
+
+	got := cfg.profile(networkConfig{Name: "sandbox", Domains: sandboxDomains, Event: sandboxEvent})
+
+	if got.Network != "sandbox" || got.Networks != nil {
+		t.Errorf("profile() network = %q, networks = %v, want sandbox and none", got.Network, got.Networks)
+	}
+	if got.Domains != sandboxDomains || got.Event != sandboxEvent {
+		t.Error("profile() did not replace the sections set by the network profile")
+	}
+	if got.Nonce != cfg.Nonce || got.ValidityPolicy != cfg.ValidityPolicy {
+		t.Error("profile() did not inherit the sections not set by the network profile")
+	}
+	if got.DB.Name != "production" || got.DB.Monitor != nil || got.DB.Notifications != nil {
+		t.Errorf("profile() db = %+v, want the default database without monitor and notifications", got.DB)
+	}
+	if cfg.DB.Monitor == nil || cfg.DB.Notifications == nil || cfg.Network != "production" {
+		t.Error("profile() modified the default network configuration")
+	}
+
+	own := &repository.Config{Name: "sandbox", Notifications: &repository.SubscriptionNotifyConfig{}}
+	if got := cfg.profile(networkConfig{Name: "sandbox", DB: own}); got.DB.Notifications != own.Notifications {
+		t.Error("profile() dropped the notifications of a network with its own database")
+	}
+}
+
+func TestNewServer_Networks(t *testing.T) {
+	ctx := context.Background()
+	_, clientOpts, cleanupPubsub := setUpTestPubsub(ctx, t, "test-topic")
+	defer cleanupPubsub()
+
+	cfg := &config{
+		Log:      &log.Config{Level: "DEBUG"},
+		Server:   &serverConfig{Host: "127.0.0.1", Port: 9090},
+		Timeouts: &timeoutConfig{Read: 5 * time.Second, Write: 10 * time.Second, Idle: 15 * time.Second, Shutdown: 20 * time.Second},
+		DB:       &repository.Config{User: "user", Name: "dbname", ConnectionName: "host:port"},
+		Event:    &event.Config{ProjectID: testProject, TopicID: "test-topic", Opts: clientOpts},
+		Network:  "production",
+		Networks: []networkConfig{
+			{Name: "sandbox"},
+			{Name: "staging", DB: &repository.Config{User: "user", Name: "staging", ConnectionName: "host:port"}},
+		},
+	}
+	mockDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer mockDB.Close()
+	stagingDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer stagingDB.Close()
+
+	server, err := newServer(ctx, cfg, mockDB, map[string]*sql.DB{"staging": stagingDB}, &mockSignValidator{})
+	if err != nil {
+		t.Fatalf("newServer() error = %v, wantErr nil", err)
+	}
+
+	tests := []struct {
+		network    string
+		wantStatus int
+	}{
+		{network: "", wantStatus: http.StatusOK},
+		{network: "production", wantStatus: http.StatusOK},
+		{network: "sandbox", wantStatus: http.StatusOK},
+		{network: "staging", wantStatus: http.StatusOK},
+		{network: "unknown", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		if tc.network != "" {
+			req.Header.Set(network.Header, tc.network)
+		}
+		rr := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rr, req)
+		if rr.Code != tc.wantStatus {
+			t.Errorf("GET /health on network %q status = %d, want %d", tc.network, rr.Code, tc.wantStatus)
+		}
+	}
+}
+
+type mockSubscriptionListener struct {
+	hooks []repository.SubscriptionChangeHook
+}
+
+func (m *mockSubscriptionListener) Start(context.Context) {}
+func (m *mockSubscriptionListener) Stop()                 {}
+
+func (m *mockSubscriptionListener) OnChange(hook repository.SubscriptionChangeHook) {
+	m.hooks = append(m.hooks, hook)
+}
+
+func TestNewServer_LookupCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	_, clientOpts, cleanupPubsub := setUpTestPubsub(ctx, t, "test-topic")
+	defer cleanupPubsub()
+
+	listeners := map[string]*mockSubscriptionListener{}
+	orig := newSubscriptionListener
+	newSubscriptionListener = func(_ context.Context, cfg *repository.Config) (subscriptionListener, error) {
+		l := &mockSubscriptionListener{}
+		listeners[cfg.Name] = l
+		return l, nil
+	}
+	defer func() { newSubscriptionListener = orig }()
+
+	cfg := &config{
+		Log:         &log.Config{Level: "DEBUG"},
+		Server:      &serverConfig{Host: "127.0.0.1", Port: 9090},
+		Timeouts:    &timeoutConfig{Read: 5 * time.Second, Write: 10 * time.Second, Idle: 15 * time.Second, Shutdown: 20 * time.Second},
+		DB:          &repository.Config{User: "user", Name: "dbname", ConnectionName: "host:port", Notifications: &repository.SubscriptionNotifyConfig{}},
+		Event:       &event.Config{ProjectID: testProject, TopicID: "test-topic", Opts: clientOpts},
+		LookupCache: &service.LookupCacheConfig{},
+		Network:     "production",
+		Networks: []networkConfig{
+			{Name: "sandbox"},
+			{Name: "staging", DB: &repository.Config{User: "user", Name: "staging", ConnectionName: "host:port", Notifications: &repository.SubscriptionNotifyConfig{}}},
+		},
+	}
+	mockDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer mockDB.Close()
+	stagingDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer stagingDB.Close()
+
+	if _, err := newServer(ctx, cfg, mockDB, map[string]*sql.DB{"staging": stagingDB}, &mockSignValidator{}); err != nil {
+		t.Fatalf("newServer() error = %v, wantErr nil", err)
+	}
+
+	// The default network and the sandbox share the default database and its listener.
+	if got := len(listeners); got != 2 {
+		t.Fatalf("newServer() created %d subscription listeners, want 2", got)
+	}
+	if got := len(listeners["dbname"].hooks); got != 2 {
+		t.Errorf("default database listener has %d hooks, want 2", got)
+	}
+	if got := len(listeners["staging"].hooks); got != 1 {
+		t.Errorf("staging database listener has %d hooks, want 1", got)
+	}
+}
+
 func TestNewServerError(t *testing.T) {
 	cfg := &config{ // A minimal valid config for other parts
 		Log:      &log.Config{Level: "INFO"},
@@ -349,7 +525,7 @@ func TestNewServerError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			server, err := newServer(context.Background(), cfg, tt.db, tt.sv)
+			server, err := newServer(context.Background(), cfg, tt.db, nil, tt.sv)
 			if err == nil {
 				t.Fatalf("newServer() error = nil, wantErr containing %q", tt.expectedError)
 			}
@@ -33,11 +33,14 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
 	"github.com/google/dpi-accelerator-beckn-onix/plugins/rediscache"
-	keyManager "github.com/google/dpi-accelerator-beckn-onix/plugins/inmemorysecretkeymanager"
 	becknclient "github.com/beckn/beckn-onix/core/module/client"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
 	decryption "github.com/beckn/beckn-onix/pkg/plugin/implementation/decrypter"
 	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signer"
+	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signvalidator"
 	"gopkg.in/yaml.v2"
 )
 
@@ -47,12 +50,30 @@ type config struct {
 	Timeouts  *timeoutConfig               `yaml:"timeouts"`
 	Server    *serverConfig                `yaml:"server"`
 	ProjectID string                       `yaml:"projectID"`
-	KeyManagerCacheTTL  *keyManager.CacheTTL   `yaml:"keyManagerCacheTTL"`
+	KeyManagerType      keymanager.Type        `yaml:"keyManagerType"`
+	KeyManagerCacheTTL  *keymanager.CacheTTL   `yaml:"keyManagerCacheTTL"`
+	KeyManagerSoftDelete *keymanager.SoftDeleteConfig `yaml:"keyManagerSoftDelete"`
+	KeyManagerMigration  *keymanager.MigrationConfig  `yaml:"keyManagerMigration"`
+	KeyManagerWarmup     *keymanager.WarmupConfig     `yaml:"keyManagerWarmup"`
+	KeyManagerExpiry     *keymanager.ExpiryConfig     `yaml:"keyManagerExpiry"`
+	KeyManagerK8sSecret  *keymanager.K8sSecretConfig  `yaml:"keyManagerK8sSecret"`
+	KeyAudit             *keymanager.AuditConfig      `yaml:"keyAudit"`
+	KeyAlgorithm         keyalgo.Algorithm            `yaml:"keyAlgorithm"`
 	Registry  *client.RegistryClientConfig `yaml:"registry"`
 	RedisAddr string                       `yaml:"redisAddr"`
 	RegID     string                       `yaml:"regID"`    // Registry's ID
 	RegKeyID  string                       `yaml:"regKeyID"` // Registry's public key ID for decryption
 	Event     *event.Config                `yaml:"event"`
+	KeyRotation *service.KeyRotationConfig `yaml:"keyRotation"`
+	KeyUndelete *service.KeyUndeleteConfig `yaml:"keyUndelete"`
+	KeyWatch    *service.KeyWatchConfig    `yaml:"keyWatch"`
+	ChallengeRecorder *service.ChallengeRecorderConfig `yaml:"challengeRecorder"`
+	// AllowUnsignedRegistryCalls accepts /on_subscribe requests that the registry did not sign.
+	AllowUnsignedRegistryCalls bool `yaml:"allowUnsignedRegistryCalls"`
+	// Egress routes the calls to the registry and the event system through an HTTP(S) proxy.
+	Egress *client.EgressConfig `yaml:"egress"`
+	// SmokeTest configures the throwaway participant subscribed by the smoketest command.
+	SmokeTest *service.SmokeTestConfig `yaml:"smokeTest"`
 }
 
 type serverConfig struct {
@@ -126,12 +147,32 @@ func (c *config) valid() error {
 	if c.KeyManagerCacheTTL == nil {
 		slog.Warn("Config validation: cacheTTL section missing, using default retry values.")
 		// Provide default values or handle as an error if strict config is required
-		c.KeyManagerCacheTTL = &keyManager.CacheTTL{PrivateKeysSeconds: 5, PublicKeysSeconds: 3600}
+		c.KeyManagerCacheTTL = &keymanager.CacheTTL{PrivateKeysSeconds: 5, PublicKeysSeconds: 3600}
 	}
 
 	return nil
 }
 
+// newKeyManager creates the key manager configured by cfg, which looks up the keys of
+// other participants with keyLookup.
+func newKeyManager(ctx context.Context, cfg *config, cache plugin.Cache, keyLookup plugin.RegistryLookup) (keymanager.KeyManager, func() error, error) {
+	km, closeKM, err := keymanager.New(ctx, cache, keyLookup, &keymanager.Config{
+		Type:      cfg.KeyManagerType,
+		ProjectID: cfg.ProjectID,
+		CacheTTL:  *cfg.KeyManagerCacheTTL,
+		SoftDelete: cfg.KeyManagerSoftDelete,
+		Migration:  cfg.KeyManagerMigration,
+		Warmup:     cfg.KeyManagerWarmup,
+		Expiry:     cfg.KeyManagerExpiry,
+		K8sSecret:  cfg.KeyManagerK8sSecret,
+		SigningAlgorithm: cfg.KeyAlgorithm,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create key manager: %w", err)
+	}
+	return km, closeKM, nil
+}
+
 // run starts the HTTP server and handles graceful shutdown.
 func run(ctx context.Context) error {
 	cfg, err := initConfig(configPath)
@@ -152,18 +193,23 @@ func run(ctx context.Context) error {
 		}
 	}()
 
-	becknRegClient := becknclient.NewRegisteryClient(&becknclient.Config{RegisteryURL: cfg.Registry.BaseURL})
-    keyManagerConfig := &keyManager.Config{
-    ProjectID: cfg.ProjectID,
-    CacheTTL: keyManager.CacheTTL{
-        PrivateKeysSeconds: cfg.KeyManagerCacheTTL.PrivateKeysSeconds,
-        PublicKeysSeconds:  cfg.KeyManagerCacheTTL.PublicKeysSeconds,
-    },
-    }
+	if cfg.Egress != nil && cfg.Registry.Egress == nil {
+		cfg.Registry.Egress = cfg.Egress
+	}
+	registryClient, err := client.NewRegistryClient(cfg.Registry)
+	if err != nil {
+		return fmt.Errorf("failed to create registry client: %w", err)
+	}
 
-   km, closeKM, err := keyManager.New(ctx, redis, becknRegClient, keyManagerConfig)
+	var keyLookup plugin.RegistryLookup = becknclient.NewRegisteryClient(&becknclient.Config{RegisteryURL: cfg.Registry.BaseURL})
+	if len(cfg.Registry.SecondaryURLs) > 0 || cfg.Registry.Egress != nil {
+		// Key lookups fail over between registries and go through the egress proxy like the
+		// subscription flows.
+		keyLookup = client.NewKeyLookup(registryClient)
+	}
+	km, closeKM, err := newKeyManager(ctx, cfg, redis, keyLookup)
 	if err != nil {
-		return fmt.Errorf("failed to create secrets key manager: %w", err)
+		return err
 	}
 	defer func() {
 		if err := closeKM(); err != nil {
@@ -177,11 +223,6 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to create decrypter: %w", err)
 	}
 
-	registryClient, err := client.NewRegistryClient(cfg.Registry)
-	if err != nil {
-		return fmt.Errorf("failed to create registry client: %w", err)
-	}
-
 	signer, sCloser, err := signer.New(ctx, &signer.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to create signer: %w", err)
@@ -191,14 +232,34 @@ func run(ctx context.Context) error {
 			slog.Error("failed to close signer", "error", err)
 		}
 	}()
+	keyAlgoSigner, err := service.NewKeyAlgoSigner(signer)
+	if err != nil {
+		return fmt.Errorf("failed to create key algorithm signer: %w", err)
+	}
 
+	if cfg.Egress != nil {
+		opts, err := client.EgressGRPCOptions(cfg.Egress)
+		if err != nil {
+			return err
+		}
+		cfg.Event.Opts = append(cfg.Event.Opts, opts...)
+	}
 	evPub, close, err := event.NewPublisher(ctx, cfg.Event)
 	if err != nil {
 		return fmt.Errorf("failed to create event publisher: %w", err)
 	}
 	defer close()
+	if cfg.KeyAudit != nil {
+		var closeAudit func() error
+		km, closeAudit, err = keymanager.NewAuditing(km, evPub, "subscriber", cfg.KeyAudit)
+		if err != nil {
+			return fmt.Errorf("invalid key audit config: %w", err)
+		}
+		// Pending audit events are published before the publisher is closed.
+		defer closeAudit()
+	}
 
-	authGen, err := service.NewAuthGenService(km, signer)
+	authGen, err := service.NewAuthGenService(km, keyAlgoSigner)
 	if err != nil {
 		return fmt.Errorf("failed to create auth gen service: %w", err)
 	}
@@ -207,12 +268,54 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create subscriber service: %w", err)
 	}
+	subService.SetHealthCheckers(registryClient, evPub)
+	subService.SetKeyRotatedPublisher(evPub)
+	if cfg.KeyRotation != nil {
+		if err := subService.SetKeyRotation(cfg.KeyRotation); err != nil {
+			return fmt.Errorf("invalid key rotation config: %w", err)
+		}
+	}
+	if cfg.KeyUndelete != nil {
+		if err := subService.SetKeyUndelete(cfg.KeyUndelete); err != nil {
+			return fmt.Errorf("invalid key undelete config: %w", err)
+		}
+	}
+	if cfg.KeyWatch != nil {
+		if err := subService.SetKeyWatch(cfg.KeyWatch, registryClient); err != nil {
+			return fmt.Errorf("invalid key watch config: %w", err)
+		}
+	}
+	if cfg.ChallengeRecorder != nil {
+		if err := subService.SetChallengeRecorder(cfg.ChallengeRecorder); err != nil {
+			return fmt.Errorf("invalid challenge recorder config: %w", err)
+		}
+	}
 
 	// Initialize Subscriber Handler
 	subHandler, err := handler.NewSubscriberHandler(subService)
 	if err != nil {
 		return fmt.Errorf("failed to create subscriber handler: %w", err)
 	}
+	sv, svClose, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create signature validator: %w", err)
+	}
+	if svClose != nil {
+		defer func() {
+			if err := svClose(); err != nil {
+				slog.Error("failed to close signature validator", "error", err)
+			}
+		}()
+	}
+	keyAlgoSV, err := service.NewKeyAlgoSignValidator(sv)
+	if err != nil {
+		return fmt.Errorf("failed to create key algorithm signature validator: %w", err)
+	}
+	registryAuth, err := service.NewRegistryAuth(keyAlgoSV, km, cfg.RegID, cfg.RegKeyID, cfg.AllowUnsignedRegistryCalls)
+	if err != nil {
+		return fmt.Errorf("failed to create registry signature validator: %w", err)
+	}
+	subHandler.SetRegistryAuth(registryAuth)
 
 	// Initialize HTTP Server
 	server := &http.Server{
@@ -222,6 +325,8 @@ func run(ctx context.Context) error {
 		WriteTimeout: cfg.Timeouts.Write,
 		IdleTimeout:  cfg.Timeouts.Idle,
 	}
+	server.RegisterOnShutdown(subService.Stop)
+	subService.StartKeyWatch()
 
 	serverErr := make(chan error, 1)
 	go func() {
@@ -237,6 +342,8 @@ func run(ctx context.Context) error {
 	select {
 	case err := <-serverErr:
 		slog.Error("FATAL: Subscriber server failed to start or encountered an error", "error", err)
+		// os.Exit skips the deferred Close of the key manager.
+		keymanager.Wipe(km)
 		os.Exit(1)
 	case sig := <-quit:
 		slog.Info("Shutdown signal received", "signal", sig.String())
@@ -245,12 +352,17 @@ func run(ctx context.Context) error {
 	slog.Info("Attempting to shut down Subscriber server gracefully...", "timeout", cfg.Timeouts.Shutdown.String())
 	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, cfg.Timeouts.Shutdown)
 	defer cancelShutdown()
+	// A second signal while requests drain exits right away, after wiping the keys.
+	stopWipe := keymanager.WipeOnSignal(km, quit, os.Exit)
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("Graceful Subscriber server shutdown failed", "error", err)
 	} else {
 		slog.Info("Subscriber server shut down gracefully.")
 	}
+	stopWipe()
+	// Keys are wiped as soon as requests have drained, before the remaining cleanup runs.
+	keymanager.Wipe(km)
 
 	slog.Info("Subscriber service has stopped.")
 	return nil
@@ -261,6 +373,16 @@ var configPath string
 func main() {
 	ctx := context.Background()
 	configPath = os.Getenv("CONFIG_FILE")
+	if len(os.Args) > 1 && os.Args[1] == "smoketest" {
+		passed, err := runSmokeTest(ctx, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+		}
+		if err != nil || !passed {
+			os.Exit(1)
+		}
+		return
+	}
 	if err := run(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -290,3 +291,18 @@ func TestConfig_Valid_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestRunSmokeTest_MissingConfig(t *testing.T) {
+	orig := configPath
+	t.Cleanup(func() { configPath = orig })
+	configPath = "testdata/valid_config.yaml"
+
+	var out strings.Builder
+	passed, err := runSmokeTest(context.Background(), &out)
+	if err == nil || err.Error() != "missing required config section: smokeTest" {
+		t.Fatalf("runSmokeTest() error = %v, want missing smokeTest section", err)
+	}
+	if passed || out.Len() != 0 {
+		t.Errorf("runSmokeTest() = %v with output %q, want false without a report", passed, out.String())
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+
+	becknclient "github.com/beckn/beckn-onix/core/module/client"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+	decryption "github.com/beckn/beckn-onix/pkg/plugin/implementation/decrypter"
+	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signvalidator"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/subscriber"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/subscriber/handler"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/rediscache"
+)
+
+// runSmokeTest subscribes a throwaway participant to the configured registry, serving its
+// /on_subscribe endpoint until the operation is done, and writes a pass/fail report to out.
+// It reports whether the smoke test passed.
+func runSmokeTest(ctx context.Context, out io.Writer) (bool, error) {
+	cfg, err := initConfig(configPath)
+	if err != nil {
+		return false, err
+	}
+	if err := log.Setup(cfg.Log); err != nil {
+		return false, err
+	}
+	if cfg.SmokeTest == nil {
+		return false, errors.New("missing required config section: smokeTest")
+	}
+
+	redis, closeRedis, err := rediscache.New(ctx, map[string]string{"addr": cfg.RedisAddr})
+	if err != nil {
+		return false, fmt.Errorf("failed to create redis cache: %w", err)
+	}
+	defer func() {
+		if err := closeRedis(); err != nil {
+			slog.Error("failed to close redis", "error", err)
+		}
+	}()
+
+	if cfg.Egress != nil && cfg.Registry.Egress == nil {
+		cfg.Registry.Egress = cfg.Egress
+	}
+	registryClient, err := client.NewRegistryClient(cfg.Registry)
+	if err != nil {
+		return false, fmt.Errorf("failed to create registry client: %w", err)
+	}
+	var keyLookup plugin.RegistryLookup = becknclient.NewRegisteryClient(&becknclient.Config{RegisteryURL: cfg.Registry.BaseURL})
+	if len(cfg.Registry.SecondaryURLs) > 0 || cfg.Registry.Egress != nil {
+		keyLookup = client.NewKeyLookup(registryClient)
+	}
+	km, closeKM, err := newKeyManager(ctx, cfg, redis, keyLookup)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := closeKM(); err != nil {
+			slog.Error("failed to close key manager", "error", err)
+		}
+	}()
+	dec, _, err := decryption.New(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to create decrypter: %w", err)
+	}
+
+	smoke, err := service.NewSmokeTest(registryClient, km, dec, cfg.RegID, cfg.RegKeyID, cfg.SmokeTest)
+	if err != nil {
+		return false, fmt.Errorf("invalid smoke test config: %w", err)
+	}
+	subHandler, err := handler.NewSubscriberHandler(smoke.Subscriber())
+	if err != nil {
+		return false, fmt.Errorf("failed to create subscriber handler: %w", err)
+	}
+	sv, svClose, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create signature validator: %w", err)
+	}
+	if svClose != nil {
+		defer func() {
+			if err := svClose(); err != nil {
+				slog.Error("failed to close signature validator", "error", err)
+			}
+		}()
+	}
+	keyAlgoSV, err := service.NewKeyAlgoSignValidator(sv)
+	if err != nil {
+		return false, fmt.Errorf("failed to create key algorithm signature validator: %w", err)
+	}
+	registryAuth, err := service.NewRegistryAuth(keyAlgoSV, km, cfg.RegID, cfg.RegKeyID, cfg.AllowUnsignedRegistryCalls)
+	if err != nil {
+		return false, fmt.Errorf("failed to create registry signature validator: %w", err)
+	}
+	subHandler.SetRegistryAuth(registryAuth)
+
+	server := &http.Server{
+		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
+		Handler:      subscriber.NewRouter(subHandler),
+		ReadTimeout:  cfg.Timeouts.Read,
+		WriteTimeout: cfg.Timeouts.Write,
+		IdleTimeout:  cfg.Timeouts.Idle,
+	}
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return false, fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
+	}
+	go func() {
+		slog.Info("Smoke test server starting...", "address", server.Addr)
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Smoke test server failed", "error", err)
+		}
+	}()
+	report := smoke.Run(ctx)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, cfg.Timeouts.Shutdown)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Smoke test server shutdown failed", "error", err)
+	}
+	if err := report.Write(out); err != nil {
+		return false, fmt.Errorf("failed to write smoke test report: %w", err)
+	}
+	return report.Passed(), nil
+}
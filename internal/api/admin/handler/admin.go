@@ -21,21 +21,27 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // adminService defines the interface for LRO operations relevant to admin actions.
 type adminService interface {
 	ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error)
 	RejectSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error)
+	ReplayChallenge(ctx context.Context, operationID string) (*model.ChallengeReplay, error)
 }
 
 // adminHandler handles admin-specific Long-Running Operation (LRO) actions.
 type adminHandler struct {
-	srv adminService
+	srv              adminService
+	reviewerHeader   string
+	reviewerRequired bool
 }
 
 // NewAdminHandler creates a new AdminLROHandler.
@@ -47,6 +53,14 @@ func NewAdminHandler(srv adminService) (*adminHandler, error) {
 	return &adminHandler{srv: srv}, nil
 }
 
+// SetReviewer configures the header from which the reviewer identity is read.
+// The header must be set by a trusted proxy in front of the admin API.
+// If required is true, actions without a reviewer are rejected.
+func (h *adminHandler) SetReviewer(header string, required bool) {
+	h.reviewerHeader = header
+	h.reviewerRequired = required
+}
+
 // writeAdminJSONError is a helper function to construct and write standardized JSON error responses for admin API.
 func writeAdminJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -63,6 +77,26 @@ func writeAdminJSONError(w http.ResponseWriter, statusCode int, errType model.Er
 	}
 }
 
+// writeAdminInternalError writes the error response for an unexpected service failure.
+// Repository query timeouts are reported as 504 Gateway Timeout, anything else as 500.
+func writeAdminInternalError(w http.ResponseWriter, err error, errMsg string) {
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		writeAdminJSONError(w, http.StatusGatewayTimeout, model.ErrorTypeInternalError, model.ErrorCodeQueryTimeout, "The request timed out waiting for the database.")
+		return
+	}
+	writeAdminJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, errMsg)
+}
+
+// writeAdminValidationError writes a 400 Bad Request response listing the invalid fields of a request.
+func writeAdminValidationError(ctx context.Context, w http.ResponseWriter, err error, errCode model.ErrorCode) {
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, errCode, err.Error())
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusBadRequest, verr.ErrorResponse(errCode))
+}
+
 // HandleSubscriptionAction processes APPROVE/REJECT actions for a subscription LRO.
 func (h *adminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -74,45 +108,135 @@ func (h *adminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *http.R
 	}
 	defer r.Body.Close()
 
+	if h.reviewerHeader != "" {
+		req.Reviewer = strings.TrimSpace(r.Header.Get(h.reviewerHeader))
+	}
+	if h.reviewerRequired && req.Reviewer == "" && !req.DryRun {
+		slog.WarnContext(ctx, "AdminLROHandler: Reviewer header missing", "operation_id", req.OperationID, "header", h.reviewerHeader)
+		writeAdminJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, fmt.Sprintf("Missing reviewer header %s.", h.reviewerHeader))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		slog.WarnContext(ctx, "AdminLROHandler: Invalid action request", "operation_id", req.OperationID, "action", req.Action, "error", err)
+		writeAdminValidationError(ctx, w, err, model.ErrorCodeTypeInvalidAction)
+		return
+	}
+
 	var lro *model.LRO
 	var err error
 
 	switch req.Action {
 	case model.OperationActionApproveSubscription:
-		slog.InfoContext(ctx, "AdminLROHandler: Approving subscription", "operation_id", req.OperationID)
-		_, lro, err = h.srv.ApproveSubscription(ctx, &req)
-	case model.OperationActionRejectSubscription:
-		if req.Reason == "" {
-			slog.WarnContext(ctx, "AdminLROHandler: Reason missing for REJECT action", "operation_id", req.OperationID)
-			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeTypeInvalidAction, "Reason is required for REJECT action.")
+		if req.DryRun {
+			h.approveDryRun(w, r, &req)
 			return
 		}
-		slog.InfoContext(ctx, "AdminLROHandler: Rejecting subscription", "operation_id", req.OperationID, "reason", req.Reason)
+		slog.InfoContext(ctx, "AdminLROHandler: Approving subscription", "operation_id", req.OperationID, "reviewer", req.Reviewer)
+		_, lro, err = h.srv.ApproveSubscription(ctx, &req)
+	case model.OperationActionRejectSubscription:
+		slog.InfoContext(ctx, "AdminLROHandler: Rejecting subscription", "operation_id", req.OperationID, "reason", req.Reason, "reviewer", req.Reviewer)
 		lro, err = h.srv.RejectSubscription(ctx, &req)
-	default:
-		slog.WarnContext(ctx, "AdminLROHandler: Invalid action specified", "operation_id", req.OperationID, "action", req.Action)
-		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeTypeInvalidAction, "Invalid action specified. Must be 'APPROVE_SUBSCRIPTION' or 'REJECT_SUBSCRIPTION'.")
-		return
 	}
 
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminLROHandler: Error processing subscription action", "operation_id", req.OperationID, "action", req.Action, "error", err)
-		if errors.Is(err, repository.ErrOperationNotFound) {
-			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", req.OperationID))
+		if writeOperationLookupError(w, err, req.OperationID) {
+			return
+		}
+		if errors.Is(err, service.ErrDuplicateApprover) {
+			writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateApprover, fmt.Sprintf("Operation %s requires the approval of a different admin.", req.OperationID))
+			return
+		}
+		if errors.Is(err, service.ErrApproverRequired) {
+			writeAdminJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, fmt.Sprintf("Operation %s requires two approvals, each with a reviewer identity.", req.OperationID))
+			return
+		}
+		if errors.Is(err, repository.ErrNonceReplayed) {
+			writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeNonceReplayed, fmt.Sprintf("Nonce of operation %s has already been used.", req.OperationID))
+			return
+		}
+		if errors.Is(err, repository.ErrNonceExpired) || errors.Is(err, repository.ErrNonceNotFound) {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeNonceExpired, fmt.Sprintf("Nonce of operation %s is no longer valid.", req.OperationID))
 			return
 		}
-		if errors.Is(err, service.ErrLROAlreadyProcessed) {
-			writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, fmt.Sprintf("Operation %s has already been processed.", req.OperationID))
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			writeAdminValidationError(ctx, w, err, model.ErrorCodeBadRequest)
 			return
 		}
-		writeAdminJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process subscription action due to an internal error.")
+		writeAdminInternalError(w, err, "Failed to process subscription action due to an internal error.")
 		return
 	}
 
+	status := http.StatusOK
+	if lro.SubState == model.LROSubStatePendingSecondApproval {
+		// The approval was recorded, but the operation is only applied on the second approval.
+		status = http.StatusAccepted
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(lro); err != nil {
 		slog.ErrorContext(ctx, "AdminLROHandler: Failed to encode LRO response for action", "error", err, "operation_id", lro.OperationID)
 		// Client has already received 200 OK, this error is server-side logging.
 	}
 }
+
+// approveDryRun runs an approval dry run and reports whether the network participant is ready.
+// Failures of the checks themselves are part of the result rather than an error response.
+func (h *adminHandler) approveDryRun(w http.ResponseWriter, r *http.Request, req *model.OperationActionRequest) {
+	ctx := r.Context()
+	slog.InfoContext(ctx, "AdminLROHandler: Running approval dry run", "operation_id", req.OperationID)
+	sub, _, err := h.srv.ApproveSubscription(ctx, req)
+	resp := model.ApprovalDryRunResponse{OperationID: req.OperationID, DryRun: true, Ready: err == nil, Subscription: sub}
+	if err != nil {
+		slog.WarnContext(ctx, "AdminLROHandler: Approval dry run failed", "operation_id", req.OperationID, "error", err)
+		if writeOperationLookupError(w, err, req.OperationID) {
+			return
+		}
+		resp.Error = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to encode dry run response", "error", err, "operation_id", req.OperationID)
+	}
+}
+
+// ReplayChallenge handles POST /operations/{operation_id}/challenge/replay. A subscriber that
+// fails the challenge is reported in the result with 200 OK.
+func (h *adminHandler) ReplayChallenge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	operationID := chi.URLParam(r, "operation_id")
+	slog.InfoContext(ctx, "AdminLROHandler: Replaying challenge", "operation_id", operationID)
+	res, err := h.srv.ReplayChallenge(ctx, operationID)
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to replay challenge", "operation_id", operationID, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrOperationNotFound):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID))
+		case errors.Is(err, service.ErrOperationNotApproved):
+			writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrReplaySubscriptionNotFound):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, err.Error())
+		default:
+			writeAdminInternalError(w, err, "Failed to replay challenge due to an internal error.")
+		}
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, res)
+}
+
+// writeOperationLookupError writes the response for errors caused by a missing or already processed
+// operation and reports whether it did so.
+func writeOperationLookupError(w http.ResponseWriter, err error, operationID string) bool {
+	if errors.Is(err, repository.ErrOperationNotFound) {
+		writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID))
+		return true
+	}
+	if errors.Is(err, service.ErrLROAlreadyProcessed) {
+		writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, fmt.Sprintf("Operation %s has already been processed.", operationID))
+		return true
+	}
+	return false
+}
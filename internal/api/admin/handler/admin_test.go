@@ -29,23 +29,35 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/go-cmp/cmp"
 )
 
 // mockAdminService is a mock implementation of adminService.
 type mockAdminService struct {
-	lro *model.LRO
-	err error
+	lro    *model.LRO
+	sub    *model.Subscription
+	replay *model.ChallengeReplay
+	err    error
+	gotReq *model.OperationActionRequest
+	gotID  string
 }
 
 func (m *mockAdminService) ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error) {
-	return nil, m.lro, m.err
+	m.gotReq = req
+	return m.sub, m.lro, m.err
 }
 
 func (m *mockAdminService) RejectSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error) {
+	m.gotReq = req
 	return m.lro, m.err
 }
 
+func (m *mockAdminService) ReplayChallenge(ctx context.Context, operationID string) (*model.ChallengeReplay, error) {
+	m.gotID = operationID
+	return m.replay, m.err
+}
+
 // TestNewAdminHandler_Success tests successful creation of AdminHandler.
 func TestNewAdminHandler_Success(t *testing.T) {
 	mockSrv := &mockAdminService{}
@@ -150,6 +162,7 @@ func TestAdminHandler_HandleSubscriptionAction_Success(t *testing.T) {
 	operationID := "test-op-123"
 	approvedLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusApproved, Type: model.OperationTypeCreateSubscription}
 	rejectedLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusRejected, Type: model.OperationTypeCreateSubscription, ErrorDataJSON: []byte(`{"reason":"admin rejected"}`)}
+	firstApprovalLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusPending, SubState: model.LROSubStatePendingSecondApproval, Type: model.OperationTypeUpdateSubscription}
 
 	tests := []struct {
 		name             string
@@ -170,6 +183,18 @@ func TestAdminHandler_HandleSubscriptionAction_Success(t *testing.T) {
 			wantStatusCode: http.StatusOK,
 			wantLROBody:    approvedLRO,
 		},
+		{
+			name: "first of two approvals",
+			actionRequest: model.OperationActionRequest{
+				OperationID: operationID,
+				Action:      model.OperationActionApproveSubscription,
+			},
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.lro = firstApprovalLRO
+			},
+			wantStatusCode: http.StatusAccepted,
+			wantLROBody:    firstApprovalLRO,
+		},
 		{
 			name: "reject subscription success",
 			actionRequest: model.OperationActionRequest{
@@ -247,7 +272,7 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 			wantStatusCode:   http.StatusBadRequest,
 			wantErrorType:    model.ErrorTypeValidationError,
 			wantErrorCode:    model.ErrorCodeTypeInvalidAction,
-			wantErrorMessage: "Reason is required for REJECT action.",
+			wantErrorMessage: "reason is required",
 		},
 		{
 			name: "invalid action specified",
@@ -260,7 +285,7 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 			wantStatusCode:   http.StatusBadRequest,
 			wantErrorType:    model.ErrorTypeValidationError,
 			wantErrorCode:    model.ErrorCodeTypeInvalidAction,
-			wantErrorMessage: "Invalid action specified. Must be 'APPROVE_SUBSCRIPTION' or 'REJECT_SUBSCRIPTION'.",
+			wantErrorMessage: "action must be one of APPROVE_SUBSCRIPTION, REJECT_SUBSCRIPTION",
 		},
 		{
 			name: "service returns ErrOperationNotFound on approve",
@@ -277,6 +302,36 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeOperationNotFound,
 			wantErrorMessage: fmt.Sprintf("Operation with id %s not found.", operationID),
 		},
+		{
+			name: "service returns ErrDuplicateApprover on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = fmt.Errorf("%w: alice has already approved operation %s", service.ErrDuplicateApprover, operationID)
+			},
+			wantStatusCode:   http.StatusConflict,
+			wantErrorType:    model.ErrorTypeConflictError,
+			wantErrorCode:    model.ErrorCodeDuplicateApprover,
+			wantErrorMessage: fmt.Sprintf("Operation %s requires the approval of a different admin.", operationID),
+		},
+		{
+			name: "service returns ErrApproverRequired on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = service.ErrApproverRequired
+			},
+			wantStatusCode:   http.StatusUnauthorized,
+			wantErrorType:    model.ErrorTypeAuthError,
+			wantErrorCode:    model.ErrorCodeMissingAuthHeader,
+			wantErrorMessage: fmt.Sprintf("Operation %s requires two approvals, each with a reviewer identity.", operationID),
+		},
 		{
 			name: "service returns ErrOperationNotFound on reject",
 			requestBody: func() []byte {
@@ -307,6 +362,51 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeDuplicateRequest,
 			wantErrorMessage: fmt.Sprintf("Operation %s has already been processed.", operationID),
 		},
+		{
+			name: "service returns ErrNonceReplayed on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = fmt.Errorf("invalid nonce: %w", repository.ErrNonceReplayed)
+			},
+			wantStatusCode:   http.StatusConflict,
+			wantErrorType:    model.ErrorTypeConflictError,
+			wantErrorCode:    model.ErrorCodeNonceReplayed,
+			wantErrorMessage: fmt.Sprintf("Nonce of operation %s has already been used.", operationID),
+		},
+		{
+			name: "service returns ErrNonceExpired on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = fmt.Errorf("invalid nonce: %w", repository.ErrNonceExpired)
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorType:    model.ErrorTypeValidationError,
+			wantErrorCode:    model.ErrorCodeNonceExpired,
+			wantErrorMessage: fmt.Sprintf("Nonce of operation %s is no longer valid.", operationID),
+		},
+		{
+			name: "service returns validation error on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = &model.ValidationError{Fields: []model.FieldError{{Field: "valid_until", Message: "exceeds the validity policy"}}}
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorType:    model.ErrorTypeValidationError,
+			wantErrorCode:    model.ErrorCodeBadRequest,
+			wantErrorMessage: "valid_until exceeds the validity policy",
+		},
 		{
 			name: "service returns generic error on approve",
 			requestBody: func() []byte {
@@ -364,3 +464,215 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 		})
 	}
 }
+
+// TestAdminHandler_HandleSubscriptionAction_ValidationFields tests that every invalid field is reported.
+func TestAdminHandler_HandleSubscriptionAction_ValidationFields(t *testing.T) {
+	handler, _ := NewAdminHandler(&mockAdminService{})
+	body := []byte(`{"action":"REJECT_SUBSCRIPTION"}`)
+	req := httptest.NewRequest(http.MethodPost, "/operations/action", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	handler.HandleSubscriptionAction(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("HandleSubscriptionAction() status code = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+	var got model.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v. Body: %s", err, rr.Body.String())
+	}
+	want := model.Error{
+		Type:    model.ErrorTypeValidationError,
+		Code:    model.ErrorCodeTypeInvalidAction,
+		Message: "operation_id is required; reason is required",
+		Path:    "operation_id",
+		Fields: []model.FieldError{
+			{Field: "operation_id", Message: "is required"},
+			{Field: "reason", Message: "is required"},
+		},
+	}
+	if diff := cmp.Diff(want, got.Error); diff != "" {
+		t.Errorf("HandleSubscriptionAction() error mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestAdminHandler_HandleSubscriptionAction_DryRun tests the approval dry run responses.
+func TestAdminHandler_HandleSubscriptionAction_DryRun(t *testing.T) {
+	operationID := "test-op-dry"
+	sub := &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub1"}, Status: model.SubscriptionStatusSubscribed}
+
+	tests := []struct {
+		name           string
+		mockService    *mockAdminService
+		wantStatusCode int
+		wantBody       *model.ApprovalDryRunResponse
+	}{
+		{
+			name:           "participant ready",
+			mockService:    &mockAdminService{sub: sub},
+			wantStatusCode: http.StatusOK,
+			wantBody:       &model.ApprovalDryRunResponse{OperationID: operationID, DryRun: true, Ready: true, Subscription: sub},
+		},
+		{
+			name:           "challenge failed",
+			mockService:    &mockAdminService{err: errors.New("challenge verification failed")},
+			wantStatusCode: http.StatusOK,
+			wantBody:       &model.ApprovalDryRunResponse{OperationID: operationID, DryRun: true, Error: "challenge verification failed"},
+		},
+		{
+			name:           "operation not found",
+			mockService:    &mockAdminService{err: repository.ErrOperationNotFound},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "operation already processed",
+			mockService:    &mockAdminService{err: service.ErrLROAlreadyProcessed},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewAdminHandler(tt.mockService)
+			body, _ := json.Marshal(model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription, DryRun: true})
+			req := httptest.NewRequest(http.MethodPost, "/operations/action", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+			handler.HandleSubscriptionAction(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("HandleSubscriptionAction() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			if tt.wantBody == nil {
+				return
+			}
+			got := &model.ApprovalDryRunResponse{}
+			if err := json.Unmarshal(rr.Body.Bytes(), got); err != nil {
+				t.Fatalf("Failed to unmarshal dry run response: %v. Body: %s", err, rr.Body.String())
+			}
+			if diff := cmp.Diff(tt.wantBody, got); diff != "" {
+				t.Errorf("HandleSubscriptionAction() dry run response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_HandleSubscriptionAction_Reviewer(t *testing.T) {
+	lro := &model.LRO{OperationID: "test-op", Status: model.LROStatusApproved}
+
+	tests := []struct {
+		name           string
+		required       bool
+		headerValue    string
+		body           string
+		wantStatusCode int
+		wantReviewer   string
+	}{
+		{
+			name:           "reviewer from header",
+			headerValue:    "admin@example.com",
+			body:           `{"operation_id":"test-op","action":"APPROVE_SUBSCRIPTION","comment":"checked documents"}`,
+			wantStatusCode: http.StatusOK,
+			wantReviewer:   "admin@example.com",
+		},
+		{
+			name:           "reviewer in body is ignored",
+			body:           `{"operation_id":"test-op","action":"APPROVE_SUBSCRIPTION","Reviewer":"spoofed@example.com"}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "required reviewer missing",
+			required:       true,
+			body:           `{"operation_id":"test-op","action":"REJECT_SUBSCRIPTION","reason":"bad"}`,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "required reviewer present",
+			required:       true,
+			headerValue:    " admin@example.com ",
+			body:           `{"operation_id":"test-op","action":"REJECT_SUBSCRIPTION","reason":"bad"}`,
+			wantStatusCode: http.StatusOK,
+			wantReviewer:   "admin@example.com",
+		},
+		{
+			name:           "required reviewer not needed for dry run",
+			required:       true,
+			body:           `{"operation_id":"test-op","action":"APPROVE_SUBSCRIPTION","dry_run":true}`,
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSrv := &mockAdminService{lro: lro}
+			handler, _ := NewAdminHandler(mockSrv)
+			handler.SetReviewer("X-Reviewer", tt.required)
+			req := httptest.NewRequest(http.MethodPost, "/operations/action", strings.NewReader(tt.body))
+			if tt.headerValue != "" {
+				req.Header.Set("X-Reviewer", tt.headerValue)
+			}
+			rr := httptest.NewRecorder()
+			handler.HandleSubscriptionAction(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("HandleSubscriptionAction() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			if tt.wantStatusCode != http.StatusOK {
+				if mockSrv.gotReq != nil {
+					t.Errorf("HandleSubscriptionAction() called service for rejected request")
+				}
+				return
+			}
+			if mockSrv.gotReq.Reviewer != tt.wantReviewer {
+				t.Errorf("HandleSubscriptionAction() reviewer = %q, want %q", mockSrv.gotReq.Reviewer, tt.wantReviewer)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_ReplayChallenge(t *testing.T) {
+	replay := &model.ChallengeReplay{OperationID: "op-1", SubscriberID: "np.example.com", KeyID: "key-1", URL: "https://np.example.com", Error: "challenge verification failed"}
+	tests := []struct {
+		name       string
+		srv        *mockAdminService
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "replayed", srv: &mockAdminService{replay: replay}, wantStatus: http.StatusOK},
+		{name: "operation not found", srv: &mockAdminService{err: repository.ErrOperationNotFound}, wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeOperationNotFound},
+		{name: "operation not approved", srv: &mockAdminService{err: fmt.Errorf("%w: operation op-1 has status PENDING", service.ErrOperationNotApproved)}, wantStatus: http.StatusConflict, wantCode: model.ErrorCodeBadRequest},
+		{name: "subscription not found", srv: &mockAdminService{err: service.ErrReplaySubscriptionNotFound}, wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeSubscriptionNotFound},
+		{name: "internal error", srv: &mockAdminService{err: errors.New("db down")}, wantStatus: http.StatusInternalServerError, wantCode: model.ErrorCodeInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAdminHandler(tc.srv)
+			r := chi.NewRouter()
+			r.Post("/operations/{operation_id}/challenge/replay", h.ReplayChallenge)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/operations/op-1/challenge/replay", nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("ReplayChallenge() status = %d, want %d, body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.srv.gotID != "op-1" {
+				t.Errorf("ReplayChallenge() operation ID = %q, want op-1", tc.srv.gotID)
+			}
+			if tc.wantCode != "" {
+				var errResp model.ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if errResp.Error.Code != tc.wantCode {
+					t.Errorf("ReplayChallenge() error code = %s, want %s", errResp.Error.Code, tc.wantCode)
+				}
+				return
+			}
+			var got model.ChallengeReplay
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(*replay, got); diff != "" {
+				t.Errorf("ReplayChallenge() response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
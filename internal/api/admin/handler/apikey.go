@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// apiKeyService defines the interface for managing the API keys of subscribers.
+type apiKeyService interface {
+	Issue(ctx context.Context, subscriberID string) (*model.IssuedAPIKey, error)
+	List(ctx context.Context, subscriberID string) ([]model.APIKey, error)
+	Revoke(ctx context.Context, subscriberID, keyID string) error
+}
+
+// apiKeyHandler handles the admin endpoints that manage subscriber API keys.
+type apiKeyHandler struct {
+	srv apiKeyService
+}
+
+// NewAPIKeyHandler creates a new apiKeyHandler.
+func NewAPIKeyHandler(srv apiKeyService) (*apiKeyHandler, error) {
+	if srv == nil {
+		slog.Error("NewAPIKeyHandler: apiKeyService dependency is nil.")
+		return nil, errors.New("apiKeyService dependency is nil")
+	}
+	return &apiKeyHandler{srv: srv}, nil
+}
+
+func writeAdminJSON(ctx context.Context, w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.ErrorContext(ctx, "APIKeyHandler: Failed to encode response", "error", err)
+	}
+}
+
+// Issue handles POST /subscribers/{subscriber_id}/api-keys.
+// The plain API key is only part of this response; the registry stores its hash.
+func (h *apiKeyHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID := chi.URLParam(r, "subscriber_id")
+	key, err := h.srv.Issue(ctx, subscriberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMissingSubscriberID):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrSubscriberNotFound):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, fmt.Sprintf("Subscriber %s not found.", subscriberID))
+		default:
+			slog.ErrorContext(ctx, "APIKeyHandler: Failed to issue API key", "subscriber_id", subscriberID, "error", err)
+			writeAdminInternalError(w, err, "Failed to issue API key due to an internal error.")
+		}
+		return
+	}
+	slog.InfoContext(ctx, "APIKeyHandler: Issued API key", "subscriber_id", subscriberID, "key_id", key.KeyID)
+	writeAdminJSON(ctx, w, http.StatusCreated, key)
+}
+
+// List handles GET /subscribers/{subscriber_id}/api-keys, including revoked keys.
+func (h *apiKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID := chi.URLParam(r, "subscriber_id")
+	keys, err := h.srv.List(ctx, subscriberID)
+	if err != nil {
+		if errors.Is(err, service.ErrMissingSubscriberID) {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "APIKeyHandler: Failed to list API keys", "subscriber_id", subscriberID, "error", err)
+		writeAdminInternalError(w, err, "Failed to list API keys due to an internal error.")
+		return
+	}
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, keys)
+}
+
+// Revoke handles DELETE /subscribers/{subscriber_id}/api-keys/{key_id}.
+func (h *apiKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID := chi.URLParam(r, "subscriber_id")
+	keyID := chi.URLParam(r, "key_id")
+	if err := h.srv.Revoke(ctx, subscriberID, keyID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrMissingSubscriberID), errors.Is(err, service.ErrMissingKeyID):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, repository.ErrAPIKeyNotFound):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeAPIKeyNotFound, fmt.Sprintf("API key %s of subscriber %s not found.", keyID, subscriberID))
+		default:
+			slog.ErrorContext(ctx, "APIKeyHandler: Failed to revoke API key", "subscriber_id", subscriberID, "key_id", keyID, "error", err)
+			writeAdminInternalError(w, err, "Failed to revoke API key due to an internal error.")
+		}
+		return
+	}
+	slog.InfoContext(ctx, "APIKeyHandler: Revoked API key", "subscriber_id", subscriberID, "key_id", keyID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockAPIKeyService is a mock implementation of apiKeyService.
+type mockAPIKeyService struct {
+	issued *model.IssuedAPIKey
+	keys   []model.APIKey
+	err    error
+
+	gotSubscriberID string
+	gotKeyID        string
+}
+
+func (m *mockAPIKeyService) Issue(ctx context.Context, subscriberID string) (*model.IssuedAPIKey, error) {
+	m.gotSubscriberID = subscriberID
+	return m.issued, m.err
+}
+
+func (m *mockAPIKeyService) List(ctx context.Context, subscriberID string) ([]model.APIKey, error) {
+	m.gotSubscriberID = subscriberID
+	return m.keys, m.err
+}
+
+func (m *mockAPIKeyService) Revoke(ctx context.Context, subscriberID, keyID string) error {
+	m.gotSubscriberID, m.gotKeyID = subscriberID, keyID
+	return m.err
+}
+
+// serveAPIKeyRequest routes a request to the handler the same way the admin router does.
+func serveAPIKeyRequest(h *apiKeyHandler, method, path string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Post("/subscribers/{subscriber_id}/api-keys", h.Issue)
+	r.Get("/subscribers/{subscriber_id}/api-keys", h.List)
+	r.Delete("/subscribers/{subscriber_id}/api-keys/{key_id}", h.Revoke)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+	return rr
+}
+
+func TestNewAPIKeyHandler(t *testing.T) {
+	if _, err := NewAPIKeyHandler(&mockAPIKeyService{}); err != nil {
+		t.Errorf("NewAPIKeyHandler() error = %v, want nil", err)
+	}
+	if _, err := NewAPIKeyHandler(nil); err == nil || err.Error() != "apiKeyService dependency is nil" {
+		t.Errorf("NewAPIKeyHandler(nil) error = %v, want apiKeyService dependency is nil", err)
+	}
+}
+
+func TestAPIKeyHandler_Issue_Success(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	issued := &model.IssuedAPIKey{
+		APIKey: model.APIKey{KeyID: "key1", SubscriberID: "sub1", Hash: "secret-hash", CreatedAt: now},
+		Key:    "plain-key",
+	}
+	srv := &mockAPIKeyService{issued: issued}
+	h, _ := NewAPIKeyHandler(srv)
+
+	rr := serveAPIKeyRequest(h, http.MethodPost, "/subscribers/sub1/api-keys")
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+	if srv.gotSubscriberID != "sub1" {
+		t.Errorf("Issue() called with subscriber %q, want %q", srv.gotSubscriberID, "sub1")
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	want := map[string]any{"key_id": "key1", "subscriber_id": "sub1", "created_at": "2025-01-01T00:00:00Z", "api_key": "plain-key"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAPIKeyHandler_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "issue for unknown subscriber",
+			method:     http.MethodPost,
+			path:       "/subscribers/sub1/api-keys",
+			err:        fmt.Errorf("%w: sub1", service.ErrSubscriberNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeSubscriptionNotFound,
+		},
+		{
+			name:       "issue internal error",
+			method:     http.MethodPost,
+			path:       "/subscribers/sub1/api-keys",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "list internal error",
+			method:     http.MethodGet,
+			path:       "/subscribers/sub1/api-keys",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "revoke unknown key",
+			method:     http.MethodDelete,
+			path:       "/subscribers/sub1/api-keys/key1",
+			err:        repository.ErrAPIKeyNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeAPIKeyNotFound,
+		},
+		{
+			name:       "revoke missing key id",
+			method:     http.MethodDelete,
+			path:       "/subscribers/sub1/api-keys/key1",
+			err:        service.ErrMissingKeyID,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "revoke internal error",
+			method:     http.MethodDelete,
+			path:       "/subscribers/sub1/api-keys/key1",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAPIKeyHandler(&mockAPIKeyService{err: tc.err})
+
+			rr := serveAPIKeyRequest(h, tc.method, tc.path)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", got.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_List(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []model.APIKey
+		want string
+	}{
+		{
+			name: "keys",
+			keys: []model.APIKey{{KeyID: "key1", SubscriberID: "sub1", Hash: "secret-hash", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			want: `[{"key_id":"key1","subscriber_id":"sub1","created_at":"2025-01-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			name: "no keys",
+			want: "[]\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAPIKeyHandler(&mockAPIKeyService{keys: tc.keys})
+
+			rr := serveAPIKeyRequest(h, http.MethodGet, "/subscribers/sub1/api-keys")
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if diff := cmp.Diff(tc.want, rr.Body.String()); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_Revoke_Success(t *testing.T) {
+	srv := &mockAPIKeyService{}
+	h, _ := NewAPIKeyHandler(srv)
+
+	rr := serveAPIKeyRequest(h, http.MethodDelete, "/subscribers/sub1/api-keys/key1")
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if srv.gotSubscriberID != "sub1" || srv.gotKeyID != "key1" {
+		t.Errorf("Revoke() called with (%q, %q), want (%q, %q)", srv.gotSubscriberID, srv.gotKeyID, "sub1", "key1")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// operationCommentService defines the interface for commenting on operations under review.
+type operationCommentService interface {
+	Add(ctx context.Context, operationID, author string, req *model.OperationCommentRequest) (*model.OperationComment, error)
+	List(ctx context.Context, operationID string) ([]model.OperationComment, error)
+}
+
+// operationCommentHandler handles the admin endpoints that comment on operations.
+type operationCommentHandler struct {
+	srv              operationCommentService
+	reviewerHeader   string
+	reviewerRequired bool
+}
+
+// NewOperationCommentHandler creates a new operationCommentHandler.
+func NewOperationCommentHandler(srv operationCommentService) (*operationCommentHandler, error) {
+	if srv == nil {
+		slog.Error("NewOperationCommentHandler: operationCommentService dependency is nil.")
+		return nil, errors.New("operationCommentService dependency is nil")
+	}
+	return &operationCommentHandler{srv: srv}, nil
+}
+
+// SetReviewer configures the header from which the author of a comment is read,
+// as for subscription actions. If required is true, comments without an author
+// are rejected.
+func (h *operationCommentHandler) SetReviewer(header string, required bool) {
+	h.reviewerHeader = header
+	h.reviewerRequired = required
+}
+
+// Add handles POST /operations/{operation_id}/comments.
+func (h *operationCommentHandler) Add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	operationID := chi.URLParam(r, "operation_id")
+	var author string
+	if h.reviewerHeader != "" {
+		author = strings.TrimSpace(r.Header.Get(h.reviewerHeader))
+	}
+	if h.reviewerRequired && author == "" {
+		slog.WarnContext(ctx, "OperationCommentHandler: Reviewer header missing", "operation_id", operationID, "header", h.reviewerHeader)
+		writeAdminJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, fmt.Sprintf("Missing reviewer header %s.", h.reviewerHeader))
+		return
+	}
+	var req model.OperationCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "OperationCommentHandler: Failed to decode request body", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	comment, err := h.srv.Add(ctx, operationID, author, &req)
+	if err != nil {
+		if writeOperationCommentError(w, err, operationID) {
+			return
+		}
+		slog.ErrorContext(ctx, "OperationCommentHandler: Failed to add comment", "operation_id", operationID, "error", err)
+		writeAdminInternalError(w, err, "Failed to add comment due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusCreated, comment)
+}
+
+// List handles GET /operations/{operation_id}/comments.
+func (h *operationCommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	operationID := chi.URLParam(r, "operation_id")
+	comments, err := h.srv.List(ctx, operationID)
+	if err != nil {
+		if writeOperationCommentError(w, err, operationID) {
+			return
+		}
+		slog.ErrorContext(ctx, "OperationCommentHandler: Failed to list comments", "operation_id", operationID, "error", err)
+		writeAdminInternalError(w, err, "Failed to list comments due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, comments)
+}
+
+// writeOperationCommentError writes the response for an invalid comment or a missing
+// operation and reports whether it did so.
+func writeOperationCommentError(w http.ResponseWriter, err error, operationID string) bool {
+	switch {
+	case errors.Is(err, service.ErrInvalidOperationComment):
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+	case errors.Is(err, repository.ErrOperationNotFound):
+		writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID))
+	default:
+		return false
+	}
+	return true
+}
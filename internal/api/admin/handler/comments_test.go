@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockOperationCommentService is a mock implementation of operationCommentService.
+type mockOperationCommentService struct {
+	comment  *model.OperationComment
+	comments []model.OperationComment
+	err      error
+
+	gotID     string
+	gotAuthor string
+	gotReq    *model.OperationCommentRequest
+}
+
+func (m *mockOperationCommentService) Add(ctx context.Context, operationID, author string, req *model.OperationCommentRequest) (*model.OperationComment, error) {
+	m.gotID = operationID
+	m.gotAuthor = author
+	m.gotReq = req
+	return m.comment, m.err
+}
+
+func (m *mockOperationCommentService) List(ctx context.Context, operationID string) ([]model.OperationComment, error) {
+	m.gotID = operationID
+	return m.comments, m.err
+}
+
+// serveCommentRequest routes a request to the handler the same way the admin router does.
+func serveCommentRequest(h *operationCommentHandler, method, path string, body io.Reader, header http.Header) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Post("/operations/{operation_id}/comments", h.Add)
+	r.Get("/operations/{operation_id}/comments", h.List)
+	req := httptest.NewRequest(method, path, body)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestNewOperationCommentHandler(t *testing.T) {
+	if _, err := NewOperationCommentHandler(&mockOperationCommentService{}); err != nil {
+		t.Errorf("NewOperationCommentHandler() error = %v, want nil", err)
+	}
+	if _, err := NewOperationCommentHandler(nil); err == nil || err.Error() != "operationCommentService dependency is nil" {
+		t.Errorf("NewOperationCommentHandler(nil) error = %v, want operationCommentService dependency is nil", err)
+	}
+}
+
+func TestOperationCommentHandler_Add_Success(t *testing.T) {
+	comment := &model.OperationComment{ID: "c-1", Author: "alice", Text: "PAN checked", Attachments: []string{"gs://kyc/pan.pdf"}, CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	srv := &mockOperationCommentService{comment: comment}
+	h, _ := NewOperationCommentHandler(srv)
+	h.SetReviewer("X-Reviewer", true)
+
+	body := `{"text":"PAN checked","attachments":["gs://kyc/pan.pdf"]}`
+	rr := serveCommentRequest(h, http.MethodPost, "/operations/op-1/comments", strings.NewReader(body), http.Header{"X-Reviewer": {" alice "}})
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Add() status = %d, want %d, body %s", rr.Code, http.StatusCreated, rr.Body)
+	}
+	if srv.gotID != "op-1" || srv.gotAuthor != "alice" {
+		t.Errorf("Add() called service with (%q, %q), want (op-1, alice)", srv.gotID, srv.gotAuthor)
+	}
+	if diff := cmp.Diff(&model.OperationCommentRequest{Text: "PAN checked", Attachments: []string{"gs://kyc/pan.pdf"}}, srv.gotReq); diff != "" {
+		t.Errorf("Add() request mismatch (-want +got):\n%s", diff)
+	}
+	var got model.OperationComment
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if diff := cmp.Diff(comment, &got); diff != "" {
+		t.Errorf("Add() response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationCommentHandler_Add_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		header     http.Header
+		srvErr     error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "missing reviewer", body: `{"text":"x"}`, wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeMissingAuthHeader},
+		{name: "invalid JSON", body: `{`, header: http.Header{"X-Reviewer": {"alice"}}, wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeInvalidJSON},
+		{name: "invalid comment", body: `{}`, header: http.Header{"X-Reviewer": {"alice"}}, srvErr: service.ErrInvalidOperationComment, wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeBadRequest},
+		{name: "operation not found", body: `{"text":"x"}`, header: http.Header{"X-Reviewer": {"alice"}}, srvErr: repository.ErrOperationNotFound, wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeOperationNotFound},
+		{name: "internal error", body: `{"text":"x"}`, header: http.Header{"X-Reviewer": {"alice"}}, srvErr: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantCode: model.ErrorCodeInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := NewOperationCommentHandler(&mockOperationCommentService{err: tt.srvErr})
+			h.SetReviewer("X-Reviewer", true)
+			rr := serveCommentRequest(h, http.MethodPost, "/operations/op-1/comments", strings.NewReader(tt.body), tt.header)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Add() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			var errResp model.ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if errResp.Error.Code != tt.wantCode {
+				t.Errorf("Add() error code = %q, want %q", errResp.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestOperationCommentHandler_List(t *testing.T) {
+	comments := []model.OperationComment{{ID: "c-1", Text: "awaiting GST certificate"}}
+	srv := &mockOperationCommentService{comments: comments}
+	h, _ := NewOperationCommentHandler(srv)
+
+	rr := serveCommentRequest(h, http.MethodGet, "/operations/op-1/comments", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("List() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if srv.gotID != "op-1" {
+		t.Errorf("List() operation ID = %q, want op-1", srv.gotID)
+	}
+	var got []model.OperationComment
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if diff := cmp.Diff(comments, got); diff != "" {
+		t.Errorf("List() response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationCommentHandler_List_NotFound(t *testing.T) {
+	h, _ := NewOperationCommentHandler(&mockOperationCommentService{err: repository.ErrOperationNotFound})
+	rr := serveCommentRequest(h, http.MethodGet, "/operations/op-1/comments", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("List() status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// maxDecisionImportBytes is the maximum size of an uploaded decision file.
+const maxDecisionImportBytes = 1 << 20
+
+// decisionImportService defines the interface for importing approval decisions.
+type decisionImportService interface {
+	Import(ctx context.Context, r io.Reader, reviewer string) (*model.DecisionImportReport, error)
+}
+
+// decisionImportHandler handles the admin endpoint importing approval decisions from CSV.
+type decisionImportHandler struct {
+	srv              decisionImportService
+	reviewerHeader   string
+	reviewerRequired bool
+}
+
+// NewDecisionImportHandler creates a new decisionImportHandler.
+func NewDecisionImportHandler(srv decisionImportService) (*decisionImportHandler, error) {
+	if srv == nil {
+		slog.Error("NewDecisionImportHandler: decisionImportService dependency is nil.")
+		return nil, errors.New("decisionImportService dependency is nil")
+	}
+	return &decisionImportHandler{srv: srv}, nil
+}
+
+// SetReviewer configures the header from which the reviewer identity is read,
+// as for single subscription actions. If required is true, imports without a
+// reviewer are rejected.
+func (h *decisionImportHandler) SetReviewer(header string, required bool) {
+	h.reviewerHeader = header
+	h.reviewerRequired = required
+}
+
+// Import handles POST /operations/import.
+// The body is a CSV file of operation_id, action and reason, sent either as the raw
+// body or as the "file" field of a multipart form. The decisions are applied in order
+// and the result of each is returned as a CSV report, or as JSON if the client accepts it.
+func (h *decisionImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var reviewer string
+	if h.reviewerHeader != "" {
+		reviewer = strings.TrimSpace(r.Header.Get(h.reviewerHeader))
+	}
+	if h.reviewerRequired && reviewer == "" {
+		slog.WarnContext(ctx, "DecisionImportHandler: Reviewer header missing", "header", h.reviewerHeader)
+		writeAdminJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, fmt.Sprintf("Missing reviewer header %s.", h.reviewerHeader))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDecisionImportBytes)
+	defer r.Body.Close()
+	body, err := decisionFile(r)
+	if err != nil {
+		writeDecisionFileError(w, err)
+		return
+	}
+
+	report, err := h.srv.Import(ctx, body, reviewer)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDecisionImport) {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeDecisionFileError(w, tooLarge)
+				return
+			}
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "DecisionImportHandler: Failed to import decisions", "error", err)
+		writeAdminInternalError(w, err, "Failed to import decisions due to an internal error.")
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		writeAdminJSON(ctx, w, http.StatusOK, report)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="decision-import-report.csv"`)
+	w.WriteHeader(http.StatusOK)
+	if err := writeDecisionReport(w, report); err != nil {
+		slog.ErrorContext(ctx, "DecisionImportHandler: Failed to write import report", "error", err)
+	}
+}
+
+// decisionFile returns the uploaded CSV file of the request.
+func decisionFile(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// writeDecisionFileError writes the response for an upload that cannot be read.
+func writeDecisionFileError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeAdminJSONError(w, http.StatusRequestEntityTooLarge, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, fmt.Sprintf("Decision file may not be larger than %d bytes.", tooLarge.Limit))
+		return
+	}
+	writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "Missing decision file: "+err.Error())
+}
+
+// writeDecisionReport writes the import report as CSV, one row per decision.
+func writeDecisionReport(w io.Writer, report *model.DecisionImportReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "operation_id", "action", "reason", "result", "lro_status", "error"}); err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		record := []string{strconv.Itoa(row.Line), row.OperationID, string(row.Action), row.Reason, string(row.Result), string(row.LROStatus), row.Error}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockDecisionImportService is a mock implementation of decisionImportService.
+type mockDecisionImportService struct {
+	report *model.DecisionImportReport
+	err    error
+
+	gotBody     string
+	gotReviewer string
+}
+
+func (m *mockDecisionImportService) Import(ctx context.Context, r io.Reader, reviewer string) (*model.DecisionImportReport, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", service.ErrInvalidDecisionImport, err)
+	}
+	m.gotBody, m.gotReviewer = string(b), reviewer
+	return m.report, m.err
+}
+
+var testImportReport = &model.DecisionImportReport{
+	Total:   2,
+	Applied: 1,
+	Failed:  1,
+	Rows: []model.DecisionImportRow{
+		{Line: 1, OperationID: "op-1", Action: model.OperationActionApproveSubscription, Result: model.DecisionImportResultApplied, LROStatus: model.LROStatusApproved},
+		{Line: 2, OperationID: "op-2", Action: model.OperationActionRejectSubscription, Reason: "Bad, docs", Result: model.DecisionImportResultFailed, Error: "LRO_ALREADY_PROCESSED"},
+	},
+}
+
+func TestNewDecisionImportHandler(t *testing.T) {
+	if _, err := NewDecisionImportHandler(&mockDecisionImportService{}); err != nil {
+		t.Errorf("NewDecisionImportHandler() unexpected error: %v", err)
+	}
+	if _, err := NewDecisionImportHandler(nil); err == nil {
+		t.Error("NewDecisionImportHandler(nil) expected error, got nil")
+	}
+}
+
+func TestDecisionImportHandler_Import_CSVReport(t *testing.T) {
+	srv := &mockDecisionImportService{report: testImportReport}
+	h, _ := NewDecisionImportHandler(srv)
+	h.SetReviewer("X-Reviewer", true)
+	body := "op-1,APPROVE\nop-2,REJECT,\"Bad, docs\"\n"
+	req := httptest.NewRequest(http.MethodPost, "/operations/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("X-Reviewer", " alice@example.com ")
+	rr := httptest.NewRecorder()
+
+	h.Import(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Import() status = %d, want %d, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	if got := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment;") {
+		t.Errorf("Content-Disposition = %q, want attachment", got)
+	}
+	want := "line,operation_id,action,reason,result,lro_status,error\n" +
+		"1,op-1,APPROVE_SUBSCRIPTION,,APPLIED,APPROVED,\n" +
+		"2,op-2,REJECT_SUBSCRIPTION,\"Bad, docs\",FAILED,,LRO_ALREADY_PROCESSED\n"
+	if diff := cmp.Diff(want, rr.Body.String()); diff != "" {
+		t.Errorf("Import() report mismatch (-want +got):\n%s", diff)
+	}
+	if srv.gotBody != body {
+		t.Errorf("imported body = %q, want %q", srv.gotBody, body)
+	}
+	if srv.gotReviewer != "alice@example.com" {
+		t.Errorf("reviewer = %q, want %q", srv.gotReviewer, "alice@example.com")
+	}
+}
+
+func TestDecisionImportHandler_Import_JSONReport(t *testing.T) {
+	srv := &mockDecisionImportService{report: testImportReport}
+	h, _ := NewDecisionImportHandler(srv)
+	req := httptest.NewRequest(http.MethodPost, "/operations/import", strings.NewReader("op-1,APPROVE\n"))
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+
+	h.Import(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Import() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var got model.DecisionImportReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if diff := cmp.Diff(testImportReport, &got); diff != "" {
+		t.Errorf("Import() report mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecisionImportHandler_Import_Multipart(t *testing.T) {
+	srv := &mockDecisionImportService{report: testImportReport}
+	h, _ := NewDecisionImportHandler(srv)
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "decisions.csv")
+	if err != nil {
+		t.Fatalf("CreateFormFile() error: %v", err)
+	}
+	fw.Write([]byte("op-1,APPROVE\n"))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/operations/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	h.Import(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Import() status = %d, want %d, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if srv.gotBody != "op-1,APPROVE\n" {
+		t.Errorf("imported body = %q, want %q", srv.gotBody, "op-1,APPROVE\n")
+	}
+}
+
+func TestDecisionImportHandler_Import_Error(t *testing.T) {
+	tests := []struct {
+		name        string
+		srvErr      error
+		body        string
+		contentType string
+		reviewer    bool
+		wantStatus  int
+		wantCode    model.ErrorCode
+	}{
+		{
+			name:       "missing reviewer",
+			reviewer:   true,
+			body:       "op-1,APPROVE\n",
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:       "invalid file",
+			srvErr:     fmt.Errorf("%w: no decisions found", service.ErrInvalidDecisionImport),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "file too large",
+			body:       strings.Repeat("a", maxDecisionImportBytes+1),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:        "multipart without file",
+			contentType: "multipart/form-data; boundary=x",
+			body:        "--x--\r\n",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "query timeout",
+			srvErr:     fmt.Errorf("wrapped: %w", repository.ErrQueryTimeout),
+			body:       "op-1,APPROVE\n",
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+		{
+			name:       "internal error",
+			srvErr:     errors.New("db down"),
+			body:       "op-1,APPROVE\n",
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDecisionImportHandler(&mockDecisionImportService{err: tc.srvErr})
+			h.SetReviewer("X-Reviewer", tc.reviewer)
+			req := httptest.NewRequest(http.MethodPost, "/operations/import", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rr := httptest.NewRecorder()
+
+			h.Import(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("Import() status = %d, want %d, body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			var errResp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &errResp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if errResp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", errResp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// denylistService defines the interface for managing the denylist.
+type denylistService interface {
+	Add(ctx context.Context, req *model.DenylistEntryRequest) (*model.DenylistEntry, error)
+	List(ctx context.Context) ([]model.DenylistEntry, error)
+	Remove(ctx context.Context, id string) error
+}
+
+// denylistHandler handles the admin endpoints that manage the denylist.
+type denylistHandler struct {
+	srv denylistService
+}
+
+// NewDenylistHandler creates a new denylistHandler.
+func NewDenylistHandler(srv denylistService) (*denylistHandler, error) {
+	if srv == nil {
+		slog.Error("NewDenylistHandler: denylistService dependency is nil.")
+		return nil, errors.New("denylistService dependency is nil")
+	}
+	return &denylistHandler{srv: srv}, nil
+}
+
+// Add handles POST /denylist.
+func (h *denylistHandler) Add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.DenylistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "DenylistHandler: Failed to decode request body", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	e, err := h.srv.Add(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidDenylistEntry):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrDenylistEntryExists):
+			writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, err.Error())
+		default:
+			slog.ErrorContext(ctx, "DenylistHandler: Failed to add denylist entry", "kind", req.Kind, "value", req.Value, "error", err)
+			writeAdminInternalError(w, err, "Failed to add denylist entry due to an internal error.")
+		}
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusCreated, e)
+}
+
+// List handles GET /denylist. Expired entries are not listed.
+func (h *denylistHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	entries, err := h.srv.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "DenylistHandler: Failed to list denylist entries", "error", err)
+		writeAdminInternalError(w, err, "Failed to list denylist entries due to an internal error.")
+		return
+	}
+	if entries == nil {
+		entries = []model.DenylistEntry{}
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, entries)
+}
+
+// Remove handles DELETE /denylist/{entry_id}.
+func (h *denylistHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "entry_id")
+	if err := h.srv.Remove(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrDenylistEntryNotFound) {
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeDenylistEntryNotFound, fmt.Sprintf("Denylist entry %s not found.", id))
+			return
+		}
+		slog.ErrorContext(ctx, "DenylistHandler: Failed to remove denylist entry", "entry_id", id, "error", err)
+		writeAdminInternalError(w, err, "Failed to remove denylist entry due to an internal error.")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockDenylistService is a mock implementation of denylistService.
+type mockDenylistService struct {
+	entry   *model.DenylistEntry
+	entries []model.DenylistEntry
+	err     error
+
+	gotReq *model.DenylistEntryRequest
+	gotID  string
+}
+
+func (m *mockDenylistService) Add(ctx context.Context, req *model.DenylistEntryRequest) (*model.DenylistEntry, error) {
+	m.gotReq = req
+	return m.entry, m.err
+}
+
+func (m *mockDenylistService) List(ctx context.Context) ([]model.DenylistEntry, error) {
+	return m.entries, m.err
+}
+
+func (m *mockDenylistService) Remove(ctx context.Context, id string) error {
+	m.gotID = id
+	return m.err
+}
+
+// serveDenylistRequest routes a request to the handler the same way the admin router does.
+func serveDenylistRequest(h *denylistHandler, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Post("/denylist", h.Add)
+	r.Get("/denylist", h.List)
+	r.Delete("/denylist/{entry_id}", h.Remove)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, body))
+	return rr
+}
+
+func TestNewDenylistHandler(t *testing.T) {
+	if _, err := NewDenylistHandler(&mockDenylistService{}); err != nil {
+		t.Errorf("NewDenylistHandler() error = %v, want nil", err)
+	}
+	if _, err := NewDenylistHandler(nil); err == nil || err.Error() != "denylistService dependency is nil" {
+		t.Errorf("NewDenylistHandler(nil) error = %v, want denylistService dependency is nil", err)
+	}
+}
+
+func TestDenylistHandler_Add_Success(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := now.Add(24 * time.Hour)
+	srv := &mockDenylistService{entry: &model.DenylistEntry{ID: "e1", Kind: model.DenylistKindSubscriber, Value: "np1", Reason: "revoked", CreatedAt: now, ExpiresAt: &expires}}
+	h, _ := NewDenylistHandler(srv)
+
+	rr := serveDenylistRequest(h, http.MethodPost, "/denylist", strings.NewReader(`{"kind":"SUBSCRIBER","value":"np1","reason":"revoked","expires_at":"2025-01-02T00:00:00Z"}`))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+	wantReq := &model.DenylistEntryRequest{Kind: model.DenylistKindSubscriber, Value: "np1", Reason: "revoked", ExpiresAt: &expires}
+	if diff := cmp.Diff(wantReq, srv.gotReq); diff != "" {
+		t.Errorf("Add() request mismatch (-want +got):\n%s", diff)
+	}
+	want := `{"entry_id":"e1","kind":"SUBSCRIBER","value":"np1","reason":"revoked","created_at":"2025-01-01T00:00:00Z","expires_at":"2025-01-02T00:00:00Z"}` + "\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestDenylistHandler_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []model.DenylistEntry
+		want    string
+	}{
+		{
+			name:    "entries",
+			entries: []model.DenylistEntry{{ID: "e1", Kind: model.DenylistKindIP, Value: "10.0.0.0/8", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			want:    `[{"entry_id":"e1","kind":"IP","value":"10.0.0.0/8","created_at":"2025-01-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			name: "no entries",
+			want: "[]\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDenylistHandler(&mockDenylistService{entries: tc.entries})
+
+			rr := serveDenylistRequest(h, http.MethodGet, "/denylist", nil)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Body.String(); got != tc.want {
+				t.Errorf("body = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDenylistHandler_Remove(t *testing.T) {
+	srv := &mockDenylistService{}
+	h, _ := NewDenylistHandler(srv)
+
+	rr := serveDenylistRequest(h, http.MethodDelete, "/denylist/e1", nil)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if srv.gotID != "e1" {
+		t.Errorf("Remove() called with %q, want %q", srv.gotID, "e1")
+	}
+}
+
+func TestDenylistHandler_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "add invalid json",
+			method:     http.MethodPost,
+			path:       "/denylist",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:       "add invalid entry",
+			method:     http.MethodPost,
+			path:       "/denylist",
+			body:       `{"kind":"IP","value":"not-an-ip"}`,
+			err:        fmt.Errorf("%w: bad value", service.ErrInvalidDenylistEntry),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "add duplicate",
+			method:     http.MethodPost,
+			path:       "/denylist",
+			body:       `{"kind":"SUBSCRIBER","value":"np1"}`,
+			err:        service.ErrDenylistEntryExists,
+			wantStatus: http.StatusConflict,
+			wantCode:   model.ErrorCodeDuplicateRequest,
+		},
+		{
+			name:       "add internal error",
+			method:     http.MethodPost,
+			path:       "/denylist",
+			body:       `{"kind":"SUBSCRIBER","value":"np1"}`,
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "list internal error",
+			method:     http.MethodGet,
+			path:       "/denylist",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "list query timeout",
+			method:     http.MethodGet,
+			path:       "/denylist",
+			err:        fmt.Errorf("failed to list denylist entries: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+		{
+			name:       "remove not found",
+			method:     http.MethodDelete,
+			path:       "/denylist/e1",
+			err:        fmt.Errorf("failed to remove denylist entry e1: %w", repository.ErrDenylistEntryNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeDenylistEntryNotFound,
+		},
+		{
+			name:       "remove internal error",
+			method:     http.MethodDelete,
+			path:       "/denylist/e1",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDenylistHandler(&mockDenylistService{err: tc.err})
+
+			rr := serveDenylistRequest(h, tc.method, tc.path, strings.NewReader(tc.body))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// domainService defines the interface for managing the domain catalog.
+type domainService interface {
+	Add(ctx context.Context, req *model.DomainRequest) (*model.Domain, error)
+	List(ctx context.Context) ([]model.Domain, error)
+	Update(ctx context.Context, name string, req *model.DomainRequest) (*model.Domain, error)
+	Remove(ctx context.Context, name string) error
+}
+
+// domainHandler handles the admin endpoints that manage the domain catalog.
+type domainHandler struct {
+	srv domainService
+}
+
+// NewDomainHandler creates a new domainHandler.
+func NewDomainHandler(srv domainService) (*domainHandler, error) {
+	if srv == nil {
+		slog.Error("NewDomainHandler: domainService dependency is nil.")
+		return nil, errors.New("domainService dependency is nil")
+	}
+	return &domainHandler{srv: srv}, nil
+}
+
+// decodeDomainRequest decodes the request body, writing the error response if it is invalid.
+func decodeDomainRequest(w http.ResponseWriter, r *http.Request) (*model.DomainRequest, bool) {
+	var req model.DomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(r.Context(), "DomainHandler: Failed to decode request body", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return nil, false
+	}
+	defer r.Body.Close()
+	return &req, true
+}
+
+// writeDomainError writes the response for a failed change to the domain catalog.
+func writeDomainError(ctx context.Context, w http.ResponseWriter, name string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidDomain):
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+	case errors.Is(err, service.ErrDomainExists):
+		writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, err.Error())
+	case errors.Is(err, service.ErrDomainHasChildren):
+		writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeBadRequest, err.Error())
+	case errors.Is(err, repository.ErrDomainNotFound):
+		writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeDomainNotFound, fmt.Sprintf("Domain %s not found.", name))
+	default:
+		slog.ErrorContext(ctx, "DomainHandler: Failed to change domain catalog", "domain", name, "error", err)
+		writeAdminInternalError(w, err, "Failed to change the domain catalog due to an internal error.")
+	}
+}
+
+// Add handles POST /domains.
+func (h *domainHandler) Add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, ok := decodeDomainRequest(w, r)
+	if !ok {
+		return
+	}
+	d, err := h.srv.Add(ctx, req)
+	if err != nil {
+		writeDomainError(ctx, w, req.Name, err)
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusCreated, d)
+}
+
+// List handles GET /domains.
+func (h *domainHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	domains, err := h.srv.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "DomainHandler: Failed to list domains", "error", err)
+		writeAdminInternalError(w, err, "Failed to list domains due to an internal error.")
+		return
+	}
+	if domains == nil {
+		domains = []model.Domain{}
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, domains)
+}
+
+// Update handles PUT /domains/{domain}.
+func (h *domainHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "domain")
+	req, ok := decodeDomainRequest(w, r)
+	if !ok {
+		return
+	}
+	d, err := h.srv.Update(ctx, name, req)
+	if err != nil {
+		writeDomainError(ctx, w, name, err)
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, d)
+}
+
+// Remove handles DELETE /domains/{domain}.
+func (h *domainHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "domain")
+	if err := h.srv.Remove(ctx, name); err != nil {
+		writeDomainError(ctx, w, name, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockDomainService is a mock implementation of domainService.
+type mockDomainService struct {
+	domain  *model.Domain
+	domains []model.Domain
+	err     error
+
+	gotReq  *model.DomainRequest
+	gotName string
+}
+
+func (m *mockDomainService) Add(ctx context.Context, req *model.DomainRequest) (*model.Domain, error) {
+	m.gotReq = req
+	return m.domain, m.err
+}
+
+func (m *mockDomainService) List(ctx context.Context) ([]model.Domain, error) {
+	return m.domains, m.err
+}
+
+func (m *mockDomainService) Update(ctx context.Context, name string, req *model.DomainRequest) (*model.Domain, error) {
+	m.gotName = name
+	m.gotReq = req
+	return m.domain, m.err
+}
+
+func (m *mockDomainService) Remove(ctx context.Context, name string) error {
+	m.gotName = name
+	return m.err
+}
+
+// serveDomainRequest routes a request to the handler the same way the admin router does.
+func serveDomainRequest(h *domainHandler, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Post("/domains", h.Add)
+	r.Get("/domains", h.List)
+	r.Put("/domains/{domain}", h.Update)
+	r.Delete("/domains/{domain}", h.Remove)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, body))
+	return rr
+}
+
+func TestNewDomainHandler(t *testing.T) {
+	if _, err := NewDomainHandler(&mockDomainService{}); err != nil {
+		t.Errorf("NewDomainHandler() error = %v, want nil", err)
+	}
+	if _, err := NewDomainHandler(nil); err == nil || err.Error() != "domainService dependency is nil" {
+		t.Errorf("NewDomainHandler(nil) error = %v, want domainService dependency is nil", err)
+	}
+}
+
+func TestDomainHandler_Add_Success(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := &mockDomainService{domain: &model.Domain{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", CreatedAt: now, UpdatedAt: now}}
+	h, _ := NewDomainHandler(srv)
+
+	rr := serveDomainRequest(h, http.MethodPost, "/domains", strings.NewReader(`{"name":"ONDC:RET10","parent":"ONDC:RET","display_name":"Grocery"}`))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+	wantReq := &model.DomainRequest{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery"}
+	if diff := cmp.Diff(wantReq, srv.gotReq); diff != "" {
+		t.Errorf("Add() request mismatch (-want +got):\n%s", diff)
+	}
+	want := `{"name":"ONDC:RET10","parent":"ONDC:RET","display_name":"Grocery","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}` + "\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestDomainHandler_List(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		domains []model.Domain
+		want    string
+	}{
+		{
+			name:    "domains",
+			domains: []model.Domain{{Name: "ONDC:RET", DisplayName: "Retail", LocationGranularity: model.LocationGranularityCity, CreatedAt: now, UpdatedAt: now}},
+			want:    `[{"name":"ONDC:RET","display_name":"Retail","location_granularity":"CITY","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			name: "no domains",
+			want: "[]\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDomainHandler(&mockDomainService{domains: tc.domains})
+
+			rr := serveDomainRequest(h, http.MethodGet, "/domains", nil)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Body.String(); got != tc.want {
+				t.Errorf("body = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDomainHandler_Update_Success(t *testing.T) {
+	srv := &mockDomainService{domain: &model.Domain{Name: "ONDC:RET10", DisplayName: "Grocery and staples"}}
+	h, _ := NewDomainHandler(srv)
+
+	rr := serveDomainRequest(h, http.MethodPut, "/domains/ONDC:RET10", strings.NewReader(`{"display_name":"Grocery and staples"}`))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if srv.gotName != "ONDC:RET10" {
+		t.Errorf("Update() called with %q, want %q", srv.gotName, "ONDC:RET10")
+	}
+	wantReq := &model.DomainRequest{DisplayName: "Grocery and staples"}
+	if diff := cmp.Diff(wantReq, srv.gotReq); diff != "" {
+		t.Errorf("Update() request mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDomainHandler_Remove(t *testing.T) {
+	srv := &mockDomainService{}
+	h, _ := NewDomainHandler(srv)
+
+	rr := serveDomainRequest(h, http.MethodDelete, "/domains/ONDC:RET10", nil)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if srv.gotName != "ONDC:RET10" {
+		t.Errorf("Remove() called with %q, want %q", srv.gotName, "ONDC:RET10")
+	}
+}
+
+func TestDomainHandler_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "add invalid json",
+			method:     http.MethodPost,
+			path:       "/domains",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:       "add invalid domain",
+			method:     http.MethodPost,
+			path:       "/domains",
+			body:       `{"name":"ONDC:RET10","parent":"ONDC:RET"}`,
+			err:        fmt.Errorf("%w: parent ONDC:RET does not exist", service.ErrInvalidDomain),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "add duplicate",
+			method:     http.MethodPost,
+			path:       "/domains",
+			body:       `{"name":"ONDC:RET"}`,
+			err:        service.ErrDomainExists,
+			wantStatus: http.StatusConflict,
+			wantCode:   model.ErrorCodeDuplicateRequest,
+		},
+		{
+			name:       "list query timeout",
+			method:     http.MethodGet,
+			path:       "/domains",
+			err:        fmt.Errorf("failed to list domains: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+		{
+			name:       "update invalid json",
+			method:     http.MethodPut,
+			path:       "/domains/ONDC:RET",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:       "update not found",
+			method:     http.MethodPut,
+			path:       "/domains/ONDC:RET",
+			body:       `{"display_name":"Retail"}`,
+			err:        fmt.Errorf("failed to update domain ONDC:RET: %w", repository.ErrDomainNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeDomainNotFound,
+		},
+		{
+			name:       "remove with children",
+			method:     http.MethodDelete,
+			path:       "/domains/ONDC:RET",
+			err:        service.ErrDomainHasChildren,
+			wantStatus: http.StatusConflict,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "remove internal error",
+			method:     http.MethodDelete,
+			path:       "/domains/ONDC:RET",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDomainHandler(&mockDomainService{err: tc.err})
+
+			rr := serveDomainRequest(h, tc.method, tc.path, strings.NewReader(tc.body))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
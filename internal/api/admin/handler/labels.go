@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// subscriptionLabelService defines the interface for labelling and searching subscriptions.
+type subscriptionLabelService interface {
+	Set(ctx context.Context, subscriberID string, req *model.SubscriptionLabelsRequest) (*model.Subscription, error)
+	Search(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
+}
+
+// subscriptionLabelHandler handles the admin endpoints that label and search subscriptions.
+type subscriptionLabelHandler struct {
+	srv subscriptionLabelService
+}
+
+// NewSubscriptionLabelHandler creates a new subscriptionLabelHandler.
+func NewSubscriptionLabelHandler(srv subscriptionLabelService) (*subscriptionLabelHandler, error) {
+	if srv == nil {
+		slog.Error("NewSubscriptionLabelHandler: subscriptionLabelService dependency is nil.")
+		return nil, errors.New("subscriptionLabelService dependency is nil")
+	}
+	return &subscriptionLabelHandler{srv: srv}, nil
+}
+
+// Set handles PUT /subscribers/{subscriber_id}/labels.
+func (h *subscriptionLabelHandler) Set(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID := chi.URLParam(r, "subscriber_id")
+	var req model.SubscriptionLabelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "SubscriptionLabelHandler: Failed to decode request body", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	sub, err := h.srv.Set(ctx, subscriberID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLabels):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, repository.ErrSubscriptionNotFound):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, fmt.Sprintf("Subscriber %s has no %s subscription in domain %s.", subscriberID, req.Type, req.Domain))
+		default:
+			slog.ErrorContext(ctx, "SubscriptionLabelHandler: Failed to set labels", "subscriber_id", subscriberID, "error", err)
+			writeAdminInternalError(w, err, "Failed to set subscription labels due to an internal error.")
+		}
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, sub)
+}
+
+// Search handles GET /subscriptions.
+// The label query parameter may be repeated; a subscription matches if it carries every label.
+// The optional subscriber_id, domain, type and status query parameters narrow the search as in a lookup.
+func (h *subscriptionLabelHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	filter := &model.Subscription{
+		Subscriber: model.Subscriber{
+			SubscriberID: q.Get("subscriber_id"),
+			Domain:       q.Get("domain"),
+			Type:         model.Role(q.Get("type")),
+		},
+		Status: model.SubscriptionStatus(q.Get("status")),
+		Labels: model.Labels(q["label"]),
+	}
+	subs, err := h.srv.Search(ctx, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLabels) {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "SubscriptionLabelHandler: Failed to search subscriptions", "error", err)
+		writeAdminInternalError(w, err, "Failed to search subscriptions due to an internal error.")
+		return
+	}
+	if subs == nil {
+		subs = []model.Subscription{}
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, subs)
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockSubscriptionLabelService is a mock implementation of subscriptionLabelService.
+type mockSubscriptionLabelService struct {
+	sub  *model.Subscription
+	subs []model.Subscription
+	err  error
+
+	gotID     string
+	gotReq    *model.SubscriptionLabelsRequest
+	gotFilter *model.Subscription
+}
+
+func (m *mockSubscriptionLabelService) Set(ctx context.Context, subscriberID string, req *model.SubscriptionLabelsRequest) (*model.Subscription, error) {
+	m.gotID = subscriberID
+	m.gotReq = req
+	return m.sub, m.err
+}
+
+func (m *mockSubscriptionLabelService) Search(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	m.gotFilter = filter
+	return m.subs, m.err
+}
+
+// serveLabelRequest routes a request to the handler the same way the admin router does.
+func serveLabelRequest(h *subscriptionLabelHandler, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Put("/subscribers/{subscriber_id}/labels", h.Set)
+	r.Get("/subscriptions", h.Search)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, body))
+	return rr
+}
+
+func TestNewSubscriptionLabelHandler(t *testing.T) {
+	if _, err := NewSubscriptionLabelHandler(&mockSubscriptionLabelService{}); err != nil {
+		t.Errorf("NewSubscriptionLabelHandler() error = %v, want nil", err)
+	}
+	if _, err := NewSubscriptionLabelHandler(nil); err == nil || err.Error() != "subscriptionLabelService dependency is nil" {
+		t.Errorf("NewSubscriptionLabelHandler(nil) error = %v, want subscriptionLabelService dependency is nil", err)
+	}
+}
+
+func TestSubscriptionLabelHandler_Set_Success(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := &mockSubscriptionLabelService{sub: &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "ONDC:RET10", Type: model.RoleBPP},
+		Labels:     model.Labels{"pilot"},
+		Updated:    now,
+	}}
+	h, _ := NewSubscriptionLabelHandler(srv)
+
+	rr := serveLabelRequest(h, http.MethodPut, "/subscribers/sub1/labels", strings.NewReader(`{"domain":"ONDC:RET10","type":"BPP","labels":["pilot"]}`))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if srv.gotID != "sub1" {
+		t.Errorf("Set() subscriber_id = %q, want sub1", srv.gotID)
+	}
+	wantReq := &model.SubscriptionLabelsRequest{Domain: "ONDC:RET10", Type: model.RoleBPP, Labels: model.Labels{"pilot"}}
+	if diff := cmp.Diff(wantReq, srv.gotReq); diff != "" {
+		t.Errorf("Set() request mismatch (-want +got):\n%s", diff)
+	}
+	want := `{"subscriber_id":"sub1","type":"BPP","domain":"ONDC:RET10","labels":["pilot"],"updated":"2025-01-01T00:00:00Z"}` + "\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestSubscriptionLabelHandler_Set_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid json", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "invalid labels", body: `{"domain":"d","type":"BPP","labels":["a b"]}`, err: service.ErrInvalidLabels, wantStatus: http.StatusBadRequest},
+		{name: "not found", body: `{"domain":"d","type":"BPP","labels":["pilot"]}`, err: repository.ErrSubscriptionNotFound, wantStatus: http.StatusNotFound},
+		{name: "internal error", body: `{"domain":"d","type":"BPP","labels":["pilot"]}`, err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewSubscriptionLabelHandler(&mockSubscriptionLabelService{err: tc.err})
+			rr := serveLabelRequest(h, http.MethodPut, "/subscribers/sub1/labels", strings.NewReader(tc.body))
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSubscriptionLabelHandler_Search(t *testing.T) {
+	srv := &mockSubscriptionLabelService{}
+	h, _ := NewSubscriptionLabelHandler(srv)
+
+	rr := serveLabelRequest(h, http.MethodGet, "/subscriptions?label=pilot&label=tier-1&domain=ONDC:*&type=BPP&status=SUBSCRIBED", nil)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	wantFilter := &model.Subscription{
+		Subscriber: model.Subscriber{Domain: "ONDC:*", Type: model.RoleBPP},
+		Status:     model.SubscriptionStatusSubscribed,
+		Labels:     model.Labels{"pilot", "tier-1"},
+	}
+	if diff := cmp.Diff(wantFilter, srv.gotFilter); diff != "" {
+		t.Errorf("Search() filter mismatch (-want +got):\n%s", diff)
+	}
+	if got := rr.Body.String(); got != "[]\n" {
+		t.Errorf("body = %s, want []", got)
+	}
+}
+
+func TestSubscriptionLabelHandler_Search_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid labels", err: service.ErrInvalidLabels, wantStatus: http.StatusBadRequest},
+		{name: "internal error", err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewSubscriptionLabelHandler(&mockSubscriptionLabelService{err: tc.err})
+			rr := serveLabelRequest(h, http.MethodGet, "/subscriptions?label=pilot", nil)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+		})
+	}
+}
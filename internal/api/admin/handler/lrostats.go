@@ -0,0 +1,85 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// lroStatsService defines the interface for computing LRO statistics.
+type lroStatsService interface {
+	Stats(ctx context.Context, from, to time.Time) (*model.LROStats, error)
+}
+
+// lroStatsHandler handles the admin endpoint reporting LRO statistics.
+type lroStatsHandler struct {
+	srv lroStatsService
+}
+
+// NewLROStatsHandler creates a new lroStatsHandler.
+func NewLROStatsHandler(srv lroStatsService) (*lroStatsHandler, error) {
+	if srv == nil {
+		slog.Error("NewLROStatsHandler: lroStatsService dependency is nil.")
+		return nil, errors.New("lroStatsService dependency is nil")
+	}
+	return &lroStatsHandler{srv: srv}, nil
+}
+
+// Stats handles GET /operations/stats.
+// The optional from and to query parameters bound the submission time of the operations,
+// as RFC 3339 timestamps or YYYY-MM-DD dates in UTC.
+func (h *lroStatsHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var bounds [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := parseStatsTime(v)
+		if err != nil {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, fmt.Sprintf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date.", name))
+			return
+		}
+		bounds[i] = t
+	}
+	stats, err := h.srv.Stats(ctx, bounds[0], bounds[1])
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatsWindow) {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "LROStatsHandler: Failed to compute operation statistics", "error", err)
+		writeAdminInternalError(w, err, "Failed to compute operation statistics due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, stats)
+}
+
+// parseStatsTime parses an RFC 3339 timestamp or a YYYY-MM-DD date in UTC.
+func parseStatsTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
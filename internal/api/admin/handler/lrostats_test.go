@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockLROStatsService is a mock implementation of lroStatsService.
+type mockLROStatsService struct {
+	stats *model.LROStats
+	err   error
+
+	gotFrom, gotTo time.Time
+}
+
+func (m *mockLROStatsService) Stats(ctx context.Context, from, to time.Time) (*model.LROStats, error) {
+	m.gotFrom, m.gotTo = from, to
+	return m.stats, m.err
+}
+
+func TestNewLROStatsHandler(t *testing.T) {
+	if _, err := NewLROStatsHandler(&mockLROStatsService{}); err != nil {
+		t.Errorf("NewLROStatsHandler() unexpected error: %v", err)
+	}
+	if _, err := NewLROStatsHandler(nil); err == nil {
+		t.Error("NewLROStatsHandler(nil) expected error, got nil")
+	}
+}
+
+func TestLROStatsHandler_Stats(t *testing.T) {
+	stats := &model.LROStats{
+		From:           time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+		Total:          2,
+		ByStatus:       map[model.LROStatus]int{model.LROStatusApproved: 2},
+		TimeToApproval: model.LROLatency{Count: 2, P50: 60, P90: 90, P99: 99},
+		Daily:          []model.LRODailyVolume{{Date: "2025-06-01", Count: 2}},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{name: "no window"},
+		{
+			name:     "dates",
+			query:    "?from=2025-06-01&to=2025-06-08",
+			wantFrom: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "timestamps",
+			query:    "?from=2025-06-01T10:00:00Z&to=2025-06-01T12:00:00Z",
+			wantFrom: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &mockLROStatsService{stats: stats}
+			h, _ := NewLROStatsHandler(srv)
+
+			rr := httptest.NewRecorder()
+			h.Stats(rr, httptest.NewRequest(http.MethodGet, "/operations/stats"+tc.query, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if !srv.gotFrom.Equal(tc.wantFrom) || !srv.gotTo.Equal(tc.wantTo) {
+				t.Errorf("window = [%v, %v), want [%v, %v)", srv.gotFrom, srv.gotTo, tc.wantFrom, tc.wantTo)
+			}
+			var got model.LROStats
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(stats, &got); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLROStatsHandler_Stats_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "invalid from",
+			query:      "?from=yesterday",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "invalid window",
+			query:      "?from=2025-06-08&to=2025-06-01",
+			err:        fmt.Errorf("%w: from is not before to", service.ErrInvalidStatsWindow),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "query timeout",
+			err:        fmt.Errorf("failed to compute operation statistics: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+		{
+			name:       "internal error",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewLROStatsHandler(&mockLROStatsService{err: tc.err})
+
+			rr := httptest.NewRecorder()
+			h.Stats(rr, httptest.NewRequest(http.MethodGet, "/operations/stats"+tc.query, nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal error response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %s, want %s", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// maintenanceService defines the interface for reading and changing the registry's maintenance mode.
+type maintenanceService interface {
+	Status(ctx context.Context) *model.Maintenance
+	Set(ctx context.Context, m *model.Maintenance) (*model.Maintenance, error)
+}
+
+// maintenanceHandler handles the admin endpoints that toggle the registry's maintenance mode.
+type maintenanceHandler struct {
+	srv maintenanceService
+}
+
+// NewMaintenanceHandler creates a new maintenanceHandler.
+func NewMaintenanceHandler(srv maintenanceService) (*maintenanceHandler, error) {
+	if srv == nil {
+		slog.Error("NewMaintenanceHandler: maintenanceService dependency is nil.")
+		return nil, errors.New("maintenanceService dependency is nil")
+	}
+	return &maintenanceHandler{srv: srv}, nil
+}
+
+// Get handles GET /maintenance.
+func (h *maintenanceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	writeAdminJSON(ctx, w, http.StatusOK, h.srv.Status(ctx))
+}
+
+// Set handles PUT /maintenance. Registry instances pick up the change within their refresh interval.
+func (h *maintenanceHandler) Set(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.Maintenance
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	m, err := h.srv.Set(ctx, &model.Maintenance{Enabled: req.Enabled, Message: req.Message})
+	if err != nil {
+		slog.ErrorContext(ctx, "MaintenanceHandler: Failed to set maintenance mode", "error", err)
+		writeAdminInternalError(w, err, "Failed to set maintenance mode due to an internal error.")
+		return
+	}
+	slog.InfoContext(ctx, "MaintenanceHandler: Set maintenance mode", "enabled", m.Enabled)
+	writeAdminJSON(ctx, w, http.StatusOK, m)
+}
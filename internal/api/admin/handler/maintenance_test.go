@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockMaintenanceService is a mock implementation of maintenanceService.
+type mockMaintenanceService struct {
+	status *model.Maintenance
+	setErr error
+	set    *model.Maintenance
+}
+
+func (m *mockMaintenanceService) Status(ctx context.Context) *model.Maintenance {
+	return m.status
+}
+
+func (m *mockMaintenanceService) Set(ctx context.Context, mt *model.Maintenance) (*model.Maintenance, error) {
+	if m.setErr != nil {
+		return nil, m.setErr
+	}
+	m.set = mt
+	mt.UpdatedAt = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return mt, nil
+}
+
+func TestNewMaintenanceHandler(t *testing.T) {
+	if _, err := NewMaintenanceHandler(&mockMaintenanceService{}); err != nil {
+		t.Errorf("NewMaintenanceHandler() unexpected error: %v", err)
+	}
+	if _, err := NewMaintenanceHandler(nil); err == nil {
+		t.Error("NewMaintenanceHandler(nil) expected error, got nil")
+	}
+}
+
+func TestMaintenanceHandler_Get(t *testing.T) {
+	want := &model.Maintenance{Enabled: true, Message: "migrating", UpdatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+	h, _ := NewMaintenanceHandler(&mockMaintenanceService{status: want})
+	rr := httptest.NewRecorder()
+
+	h.Get(rr, httptest.NewRequest(http.MethodGet, "/maintenance", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Get() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var got model.Maintenance
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if diff := cmp.Diff(want, &got); diff != "" {
+		t.Errorf("Get() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaintenanceHandler_Set(t *testing.T) {
+	srv := &mockMaintenanceService{}
+	h, _ := NewMaintenanceHandler(srv)
+	rr := httptest.NewRecorder()
+
+	h.Set(rr, httptest.NewRequest(http.MethodPut, "/maintenance", strings.NewReader(`{"enabled":true,"message":"migrating","updated_at":"2000-01-01T00:00:00Z"}`)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Set() status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if diff := cmp.Diff(&model.Maintenance{Enabled: true, Message: "migrating", UpdatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, srv.set); diff != "" {
+		t.Errorf("Set() service input mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaintenanceHandler_Set_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		setErr     error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "invalid JSON", body: "{not-json", wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeInvalidJSON},
+		{name: "service error", body: `{"enabled":true}`, setErr: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantCode: model.ErrorCodeInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewMaintenanceHandler(&mockMaintenanceService{setErr: tc.setErr})
+			rr := httptest.NewRecorder()
+
+			h.Set(rr, httptest.NewRequest(http.MethodPut, "/maintenance", strings.NewReader(tc.body)))
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("Set() status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("Set() error code = %s, want %s", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
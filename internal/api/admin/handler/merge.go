@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// subscriberMergeService defines the interface for finding and merging duplicate subscribers.
+type subscriberMergeService interface {
+	Duplicates(ctx context.Context) ([]model.DuplicateGroup, error)
+	Merge(ctx context.Context, req *model.SubscriberMergeRequest) (*model.SubscriberMergeReport, error)
+}
+
+// subscriberMergeHandler handles the admin endpoints that find and merge duplicate subscribers.
+type subscriberMergeHandler struct {
+	srv subscriberMergeService
+}
+
+// NewSubscriberMergeHandler creates a new subscriberMergeHandler.
+func NewSubscriberMergeHandler(srv subscriberMergeService) (*subscriberMergeHandler, error) {
+	if srv == nil {
+		slog.Error("NewSubscriberMergeHandler: subscriberMergeService dependency is nil.")
+		return nil, errors.New("subscriberMergeService dependency is nil")
+	}
+	return &subscriberMergeHandler{srv: srv}, nil
+}
+
+// Duplicates handles GET /subscribers/duplicates.
+func (h *subscriberMergeHandler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	groups, err := h.srv.Duplicates(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberMergeHandler: Failed to find duplicates", "error", err)
+		writeAdminInternalError(w, err, "Failed to find duplicate subscribers due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, groups)
+}
+
+// Merge handles POST /subscribers/merge.
+func (h *subscriberMergeHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.SubscriberMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "SubscriberMergeHandler: Failed to decode request body", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	report, err := h.srv.Merge(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMerge):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, repository.ErrSubscriptionNotFound):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, err.Error())
+		default:
+			slog.ErrorContext(ctx, "SubscriberMergeHandler: Failed to merge subscribers", "source", req.SourceSubscriberID, "target", req.TargetSubscriberID, "error", err)
+			writeAdminInternalError(w, err, "Failed to merge subscribers due to an internal error.")
+		}
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, report)
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockSubscriberMergeService is a mock implementation of subscriberMergeService.
+type mockSubscriberMergeService struct {
+	groups []model.DuplicateGroup
+	report *model.SubscriberMergeReport
+	err    error
+
+	gotReq *model.SubscriberMergeRequest
+}
+
+func (m *mockSubscriberMergeService) Duplicates(ctx context.Context) ([]model.DuplicateGroup, error) {
+	return m.groups, m.err
+}
+
+func (m *mockSubscriberMergeService) Merge(ctx context.Context, req *model.SubscriberMergeRequest) (*model.SubscriberMergeReport, error) {
+	m.gotReq = req
+	return m.report, m.err
+}
+
+// serveMergeRequest routes a request to the handler the same way the admin router does.
+func serveMergeRequest(h *subscriberMergeHandler, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/subscribers/duplicates", h.Duplicates)
+	r.Post("/subscribers/merge", h.Merge)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, body))
+	return rr
+}
+
+func TestNewSubscriberMergeHandler(t *testing.T) {
+	if _, err := NewSubscriberMergeHandler(&mockSubscriberMergeService{}); err != nil {
+		t.Errorf("NewSubscriberMergeHandler() error = %v, want nil", err)
+	}
+	if _, err := NewSubscriberMergeHandler(nil); err == nil || err.Error() != "subscriberMergeService dependency is nil" {
+		t.Errorf("NewSubscriberMergeHandler(nil) error = %v, want subscriberMergeService dependency is nil", err)
+	}
+}
+
+func TestSubscriberMergeHandler_Duplicates(t *testing.T) {
+	srv := &mockSubscriberMergeService{groups: []model.DuplicateGroup{
+		{Reason: model.DuplicateReasonURL, Match: "https://np1.com", SubscriberIDs: []string{"np1.com", "np1.org"}},
+	}}
+	h, _ := NewSubscriberMergeHandler(srv)
+
+	rr := serveMergeRequest(h, http.MethodGet, "/subscribers/duplicates", nil)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	want := `[{"reason":"SAME_URL","match":"https://np1.com","subscriber_ids":["np1.com","np1.org"]}]` + "\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestSubscriberMergeHandler_Duplicates_Error(t *testing.T) {
+	h, _ := NewSubscriberMergeHandler(&mockSubscriberMergeService{err: errors.New("db down")})
+	rr := serveMergeRequest(h, http.MethodGet, "/subscribers/duplicates", nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestSubscriberMergeHandler_Merge_Success(t *testing.T) {
+	srv := &mockSubscriberMergeService{report: &model.SubscriberMergeReport{
+		SourceSubscriberID: "np1.com",
+		TargetSubscriberID: "np1.org",
+		DryRun:             true,
+		Moved:              []model.Subscriber{{SubscriberID: "np1.com", Domain: "retail", Type: model.RoleBAP}},
+		Dropped:            []model.Subscriber{},
+		Operations:         2,
+	}}
+	h, _ := NewSubscriberMergeHandler(srv)
+
+	rr := serveMergeRequest(h, http.MethodPost, "/subscribers/merge", strings.NewReader(`{"source_subscriber_id":"np1.com","target_subscriber_id":"np1.org","dry_run":true}`))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	wantReq := &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org", DryRun: true}
+	if diff := cmp.Diff(wantReq, srv.gotReq); diff != "" {
+		t.Errorf("Merge() request mismatch (-want +got):\n%s", diff)
+	}
+	want := `{"source_subscriber_id":"np1.com","target_subscriber_id":"np1.org","dry_run":true,` +
+		`"moved_subscriptions":[{"subscriber_id":"np1.com","type":"BAP","domain":"retail"}],"dropped_subscriptions":[],` +
+		`"api_keys":0,"denylist_entries":0,"operations":2}` + "\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestSubscriberMergeHandler_Merge_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid json", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "invalid merge", body: `{"source_subscriber_id":"np1.com"}`, err: service.ErrInvalidMerge, wantStatus: http.StatusBadRequest},
+		{name: "not found", body: `{"source_subscriber_id":"np1.com","target_subscriber_id":"np1.org"}`, err: repository.ErrSubscriptionNotFound, wantStatus: http.StatusNotFound},
+		{name: "internal error", body: `{"source_subscriber_id":"np1.com","target_subscriber_id":"np1.org"}`, err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewSubscriberMergeHandler(&mockSubscriberMergeService{err: tc.err})
+			rr := serveMergeRequest(h, http.MethodPost, "/subscribers/merge", strings.NewReader(tc.body))
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+		})
+	}
+}
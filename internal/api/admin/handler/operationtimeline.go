@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// operationTimelineService defines the interface for composing the timeline of an operation.
+type operationTimelineService interface {
+	Timeline(ctx context.Context, operationID string) (*model.OperationTimeline, error)
+}
+
+// operationTimelineHandler handles the admin endpoint viewing the timeline of an operation.
+type operationTimelineHandler struct {
+	srv operationTimelineService
+}
+
+// NewOperationTimelineHandler creates a new operationTimelineHandler.
+func NewOperationTimelineHandler(srv operationTimelineService) (*operationTimelineHandler, error) {
+	if srv == nil {
+		slog.Error("NewOperationTimelineHandler: operationTimelineService dependency is nil.")
+		return nil, errors.New("operationTimelineService dependency is nil")
+	}
+	return &operationTimelineHandler{srv: srv}, nil
+}
+
+// Get handles GET /operations/{operation_id}/timeline.
+func (h *operationTimelineHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	operationID := chi.URLParam(r, "operation_id")
+	t, err := h.srv.Timeline(ctx, operationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOperationNotFound) {
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID))
+			return
+		}
+		slog.ErrorContext(ctx, "OperationTimelineHandler: Failed to compose timeline", "operation_id", operationID, "error", err)
+		writeAdminInternalError(w, err, "Failed to compose operation timeline due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, t)
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockOperationTimelineService is a mock implementation of operationTimelineService.
+type mockOperationTimelineService struct {
+	timeline *model.OperationTimeline
+	err      error
+
+	gotOperationID string
+}
+
+func (m *mockOperationTimelineService) Timeline(ctx context.Context, operationID string) (*model.OperationTimeline, error) {
+	m.gotOperationID = operationID
+	return m.timeline, m.err
+}
+
+// serveTimelineRequest routes a request to the handler the same way the admin router does.
+func serveTimelineRequest(h *operationTimelineHandler, path string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/operations/{operation_id}/timeline", h.Get)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestNewOperationTimelineHandler(t *testing.T) {
+	if _, err := NewOperationTimelineHandler(&mockOperationTimelineService{}); err != nil {
+		t.Errorf("NewOperationTimelineHandler() unexpected error: %v", err)
+	}
+	if _, err := NewOperationTimelineHandler(nil); err == nil {
+		t.Error("NewOperationTimelineHandler(nil) expected error, got nil")
+	}
+}
+
+func TestOperationTimelineHandler_Get(t *testing.T) {
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	timeline := &model.OperationTimeline{
+		OperationID:  "op-1",
+		SubscriberID: "np1",
+		Type:         model.OperationTypeCreateSubscription,
+		Status:       model.LROStatusApproved,
+		Entries: []model.OperationTimelineEntry{
+			{At: at, Source: model.OperationTimelineSourceOperation, Summary: "CREATE_SUBSCRIPTION requested", Actor: "np1"},
+			{At: at.Add(time.Hour), Source: model.OperationTimelineSourceOperation, Summary: "Operation APPROVED"},
+		},
+	}
+	srv := &mockOperationTimelineService{timeline: timeline}
+	h, _ := NewOperationTimelineHandler(srv)
+
+	rr := serveTimelineRequest(h, "/operations/op-1/timeline")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if srv.gotOperationID != "op-1" {
+		t.Errorf("Timeline() called with %q, want %q", srv.gotOperationID, "op-1")
+	}
+	var got model.OperationTimeline
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if diff := cmp.Diff(timeline, &got); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationTimelineHandler_Get_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "operation not found",
+			err:        fmt.Errorf("failed to get operation op-1: %w", repository.ErrOperationNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeOperationNotFound,
+		},
+		{
+			name:       "query timeout",
+			err:        fmt.Errorf("failed to list webhook deliveries: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+		{
+			name:       "internal error",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewOperationTimelineHandler(&mockOperationTimelineService{err: tc.err})
+
+			rr := serveTimelineRequest(h, "/operations/op-1/timeline")
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal error response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %s, want %s", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// maxSnapshotBytes is the maximum size of a snapshot restore request.
+const maxSnapshotBytes = 32 << 20
+
+// snapshotService defines the interface for exporting and restoring the registry state.
+type snapshotService interface {
+	Export(ctx context.Context) (*model.RegistrySnapshot, error)
+	Restore(ctx context.Context, req *model.SnapshotRestoreRequest) (*model.SnapshotRestoreReport, error)
+}
+
+// snapshotHandler handles the admin endpoints for snapshots of the registry state.
+type snapshotHandler struct {
+	srv snapshotService
+}
+
+// NewSnapshotHandler creates a new snapshotHandler.
+func NewSnapshotHandler(srv snapshotService) (*snapshotHandler, error) {
+	if srv == nil {
+		slog.Error("NewSnapshotHandler: snapshotService dependency is nil.")
+		return nil, errors.New("snapshotService dependency is nil")
+	}
+	return &snapshotHandler{srv: srv}, nil
+}
+
+// Export handles GET /snapshot.
+// The snapshot holds the subscriptions and operations of the registry; secrets such as
+// API keys, webhooks and nonces are not exported.
+func (h *snapshotHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	snap, err := h.srv.Export(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "SnapshotHandler: Failed to export snapshot", "error", err)
+		writeAdminInternalError(w, err, "Failed to export snapshot due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, snap)
+}
+
+// Restore handles POST /snapshot/restore.
+// The snapshot is upserted into the registry, optionally with its subscriber IDs remapped
+// and its operation IDs prefixed so that it can be restored alongside existing data.
+func (h *snapshotHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSnapshotBytes)
+	defer r.Body.Close()
+	var req model.SnapshotRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeAdminJSONError(w, http.StatusRequestEntityTooLarge, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, fmt.Sprintf("Snapshot may not be larger than %d bytes.", tooLarge.Limit))
+			return
+		}
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+
+	report, err := h.srv.Restore(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSnapshotRestoreDisabled):
+			writeAdminJSONError(w, http.StatusForbidden, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrInvalidSnapshot):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		default:
+			slog.ErrorContext(ctx, "SnapshotHandler: Failed to restore snapshot", "error", err)
+			writeAdminInternalError(w, err, "Failed to restore snapshot due to an internal error.")
+		}
+		return
+	}
+	slog.InfoContext(ctx, "SnapshotHandler: Restored snapshot", "subscriptions", report.Subscriptions, "operations", report.Operations)
+	writeAdminJSON(ctx, w, http.StatusOK, report)
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockSnapshotService is a mock implementation of snapshotService.
+type mockSnapshotService struct {
+	snap      *model.RegistrySnapshot
+	exportErr error
+
+	report     *model.SnapshotRestoreReport
+	restoreErr error
+	gotReq     *model.SnapshotRestoreRequest
+}
+
+func (m *mockSnapshotService) Export(ctx context.Context) (*model.RegistrySnapshot, error) {
+	return m.snap, m.exportErr
+}
+
+func (m *mockSnapshotService) Restore(ctx context.Context, req *model.SnapshotRestoreRequest) (*model.SnapshotRestoreReport, error) {
+	m.gotReq = req
+	return m.report, m.restoreErr
+}
+
+func TestNewSnapshotHandler(t *testing.T) {
+	if _, err := NewSnapshotHandler(&mockSnapshotService{}); err != nil {
+		t.Errorf("NewSnapshotHandler() unexpected error: %v", err)
+	}
+	if _, err := NewSnapshotHandler(nil); err == nil {
+		t.Error("NewSnapshotHandler(nil) expected error, got nil")
+	}
+}
+
+func TestSnapshotHandler_Export(t *testing.T) {
+	snap := &model.RegistrySnapshot{
+		TakenAt:       time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "np1"}, KeyID: "key-1"}},
+		Operations:    []model.LRO{{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusApproved}},
+	}
+	h, _ := NewSnapshotHandler(&mockSnapshotService{snap: snap})
+
+	rr := httptest.NewRecorder()
+	h.Export(rr, httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var got model.RegistrySnapshot
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if diff := cmp.Diff(snap, &got); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSnapshotHandler_Export_Error(t *testing.T) {
+	h, _ := NewSnapshotHandler(&mockSnapshotService{exportErr: errors.New("db down")})
+
+	rr := httptest.NewRecorder()
+	h.Export(rr, httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestSnapshotHandler_Restore(t *testing.T) {
+	srv := &mockSnapshotService{report: &model.SnapshotRestoreReport{Subscriptions: 1}}
+	h, _ := NewSnapshotHandler(srv)
+	body := `{"snapshot":{"subscriptions":[{"subscriber_id":"np1","key_id":"key-1"}]},"subscriber_ids":{"np1":"np1.staging"},"operation_id_prefix":"stg-"}`
+
+	rr := httptest.NewRecorder()
+	h.Restore(rr, httptest.NewRequest(http.MethodPost, "/snapshot/restore", strings.NewReader(body)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	want := &model.SnapshotRestoreRequest{
+		Snapshot:          model.RegistrySnapshot{Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "np1"}, KeyID: "key-1"}}},
+		SubscriberIDs:     map[string]string{"np1": "np1.staging"},
+		OperationIDPrefix: "stg-",
+	}
+	if diff := cmp.Diff(want, srv.gotReq); diff != "" {
+		t.Errorf("Restore() request mismatch (-want +got):\n%s", diff)
+	}
+	var got model.SnapshotRestoreReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if got.Subscriptions != 1 {
+		t.Errorf("report subscriptions = %d, want 1", got.Subscriptions)
+	}
+}
+
+func TestSnapshotHandler_Restore_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "invalid json",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:       "too large",
+			body:       `{"operation_id_prefix":"` + strings.Repeat("x", maxSnapshotBytes) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "restore disabled",
+			body:       `{}`,
+			err:        service.ErrSnapshotRestoreDisabled,
+			wantStatus: http.StatusForbidden,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "invalid snapshot",
+			body:       `{}`,
+			err:        fmt.Errorf("%w: subscription 0 has no subscriber_id or key_id", service.ErrInvalidSnapshot),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "internal error",
+			body:       `{}`,
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewSnapshotHandler(&mockSnapshotService{restoreErr: tc.err})
+
+			rr := httptest.NewRecorder()
+			h.Restore(rr, httptest.NewRequest(http.MethodPost, "/snapshot/restore", strings.NewReader(tc.body)))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal error response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %s, want %s", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// subscriptionHistoryService defines the interface for viewing subscriptions at a point in time.
+type subscriptionHistoryService interface {
+	At(ctx context.Context, subscriberID string, at time.Time) (*model.SubscriptionHistoryView, error)
+}
+
+// subscriptionHistoryHandler handles the admin endpoint viewing the subscription history.
+type subscriptionHistoryHandler struct {
+	srv subscriptionHistoryService
+}
+
+// NewSubscriptionHistoryHandler creates a new subscriptionHistoryHandler.
+func NewSubscriptionHistoryHandler(srv subscriptionHistoryService) (*subscriptionHistoryHandler, error) {
+	if srv == nil {
+		slog.Error("NewSubscriptionHistoryHandler: subscriptionHistoryService dependency is nil.")
+		return nil, errors.New("subscriptionHistoryService dependency is nil")
+	}
+	return &subscriptionHistoryHandler{srv: srv}, nil
+}
+
+// At handles GET /subscribers/{subscriber_id}/history.
+// The optional at query parameter is the RFC 3339 timestamp to view the subscriptions at,
+// defaulting to now.
+func (h *subscriptionHistoryHandler) At(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID := chi.URLParam(r, "subscriber_id")
+	var at time.Time
+	if v := r.URL.Query().Get("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "at must be an RFC 3339 timestamp.")
+			return
+		}
+		at = t
+	}
+	view, err := h.srv.At(ctx, subscriberID, at)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidHistoryQuery):
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrNoSubscriptionHistory):
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, err.Error())
+		default:
+			slog.ErrorContext(ctx, "SubscriptionHistoryHandler: Failed to read subscription history", "subscriber_id", subscriberID, "error", err)
+			writeAdminInternalError(w, err, "Failed to read subscription history due to an internal error.")
+		}
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, view)
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockSubscriptionHistoryService is a mock implementation of subscriptionHistoryService.
+type mockSubscriptionHistoryService struct {
+	view *model.SubscriptionHistoryView
+	err  error
+
+	gotSubscriberID string
+	gotAt           time.Time
+}
+
+func (m *mockSubscriptionHistoryService) At(ctx context.Context, subscriberID string, at time.Time) (*model.SubscriptionHistoryView, error) {
+	m.gotSubscriberID, m.gotAt = subscriberID, at
+	return m.view, m.err
+}
+
+// serveHistoryRequest routes a request to the handler the same way the admin router does.
+func serveHistoryRequest(h *subscriptionHistoryHandler, path string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/subscribers/{subscriber_id}/history", h.At)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestNewSubscriptionHistoryHandler(t *testing.T) {
+	if _, err := NewSubscriptionHistoryHandler(&mockSubscriptionHistoryService{}); err != nil {
+		t.Errorf("NewSubscriptionHistoryHandler() unexpected error: %v", err)
+	}
+	if _, err := NewSubscriptionHistoryHandler(nil); err == nil {
+		t.Error("NewSubscriptionHistoryHandler(nil) expected error, got nil")
+	}
+}
+
+func TestSubscriptionHistoryHandler_At(t *testing.T) {
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	view := &model.SubscriptionHistoryView{
+		SubscriberID: "np1",
+		At:           at,
+		Subscriptions: []model.SubscriptionVersion{{
+			Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np1", Domain: "retail"}, KeyID: "key-1"},
+			Change:       model.SubscriptionChangeUpdate,
+			ChangedAt:    at.Add(-time.Hour),
+		}},
+	}
+	tests := []struct {
+		name   string
+		query  string
+		wantAt time.Time
+	}{
+		{name: "now"},
+		{name: "timestamp", query: "?at=2025-06-01T10:00:00Z", wantAt: at},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &mockSubscriptionHistoryService{view: view}
+			h, _ := NewSubscriptionHistoryHandler(srv)
+
+			rr := serveHistoryRequest(h, "/subscribers/np1/history"+tc.query)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if srv.gotSubscriberID != "np1" || !srv.gotAt.Equal(tc.wantAt) {
+				t.Errorf("At() called with (%q, %v), want (%q, %v)", srv.gotSubscriberID, srv.gotAt, "np1", tc.wantAt)
+			}
+			var got model.SubscriptionHistoryView
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(view, &got); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSubscriptionHistoryHandler_At_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "invalid at",
+			query:      "?at=2025-06-01",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "future at",
+			err:        fmt.Errorf("%w: at is in the future", service.ErrInvalidHistoryQuery),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "no subscriptions",
+			err:        fmt.Errorf("%w: subscriber np1", service.ErrNoSubscriptionHistory),
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeSubscriptionNotFound,
+		},
+		{
+			name:       "query timeout",
+			err:        fmt.Errorf("failed to read subscription history: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+		{
+			name:       "internal error",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewSubscriptionHistoryHandler(&mockSubscriptionHistoryService{err: tc.err})
+
+			rr := serveHistoryRequest(h, "/subscribers/np1/history"+tc.query)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal error response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %s, want %s", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
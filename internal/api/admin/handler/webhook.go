@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// webhookService defines the interface for managing webhooks and their deliveries.
+type webhookService interface {
+	Register(ctx context.Context, req *model.WebhookRequest) (*model.RegisteredWebhook, error)
+	List(ctx context.Context) ([]model.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	Deliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID string) (*model.WebhookDelivery, error)
+}
+
+// webhookHandler handles the admin endpoints that manage webhooks.
+type webhookHandler struct {
+	srv webhookService
+}
+
+// NewWebhookHandler creates a new webhookHandler.
+func NewWebhookHandler(srv webhookService) (*webhookHandler, error) {
+	if srv == nil {
+		slog.Error("NewWebhookHandler: webhookService dependency is nil.")
+		return nil, errors.New("webhookService dependency is nil")
+	}
+	return &webhookHandler{srv: srv}, nil
+}
+
+// Register handles POST /webhooks.
+// The webhook secret is only part of this response.
+func (h *webhookHandler) Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "WebhookHandler: Failed to decode request body", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	hook, err := h.srv.Register(ctx, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookURL) || errors.Is(err, service.ErrInvalidEventType) {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "WebhookHandler: Failed to register webhook", "url", req.URL, "error", err)
+		writeAdminInternalError(w, err, "Failed to register webhook due to an internal error.")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusCreated, hook)
+}
+
+// List handles GET /webhooks.
+func (h *webhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	hooks, err := h.srv.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "WebhookHandler: Failed to list webhooks", "error", err)
+		writeAdminInternalError(w, err, "Failed to list webhooks due to an internal error.")
+		return
+	}
+	if hooks == nil {
+		hooks = []model.Webhook{}
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, hooks)
+}
+
+// Delete handles DELETE /webhooks/{webhook_id}.
+func (h *webhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "webhook_id")
+	if err := h.srv.Delete(ctx, id); err != nil {
+		h.writeError(ctx, w, err, id, "delete webhook")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries handles GET /webhooks/{webhook_id}/deliveries?limit=N, newest first.
+func (h *webhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "webhook_id")
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "limit must be a positive integer.")
+			return
+		}
+		limit = n
+	}
+	deliveries, err := h.srv.Deliveries(ctx, id, limit)
+	if err != nil {
+		h.writeError(ctx, w, err, id, "list webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, deliveries)
+}
+
+// Retry handles POST /webhooks/{webhook_id}/deliveries/{delivery_id}/retry.
+// The delivery is attempted once more and its updated record is returned.
+func (h *webhookHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "webhook_id")
+	deliveryID := chi.URLParam(r, "delivery_id")
+	d, err := h.srv.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeWebhookNotFound, fmt.Sprintf("Delivery %s of webhook %s not found.", deliveryID, id))
+			return
+		}
+		h.writeError(ctx, w, err, id, "retry webhook delivery")
+		return
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, d)
+}
+
+// writeError maps webhook errors to responses.
+func (h *webhookHandler) writeError(ctx context.Context, w http.ResponseWriter, err error, id, op string) {
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeWebhookNotFound, fmt.Sprintf("Webhook %s not found.", id))
+		return
+	}
+	slog.ErrorContext(ctx, "WebhookHandler: Failed to "+op, "webhook_id", id, "error", err)
+	writeAdminInternalError(w, err, fmt.Sprintf("Failed to %s due to an internal error.", op))
+}
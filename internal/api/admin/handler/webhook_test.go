@@ -0,0 +1,342 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockWebhookService is a mock implementation of webhookService.
+type mockWebhookService struct {
+	registered *model.RegisteredWebhook
+	hooks      []model.Webhook
+	deliveries []model.WebhookDelivery
+	delivery   *model.WebhookDelivery
+	err        error
+
+	gotReq        *model.WebhookRequest
+	gotWebhookID  string
+	gotDeliveryID string
+	gotLimit      int
+}
+
+func (m *mockWebhookService) Register(ctx context.Context, req *model.WebhookRequest) (*model.RegisteredWebhook, error) {
+	m.gotReq = req
+	return m.registered, m.err
+}
+
+func (m *mockWebhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	return m.hooks, m.err
+}
+
+func (m *mockWebhookService) Delete(ctx context.Context, id string) error {
+	m.gotWebhookID = id
+	return m.err
+}
+
+func (m *mockWebhookService) Deliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error) {
+	m.gotWebhookID, m.gotLimit = webhookID, limit
+	return m.deliveries, m.err
+}
+
+func (m *mockWebhookService) Redeliver(ctx context.Context, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	m.gotWebhookID, m.gotDeliveryID = webhookID, deliveryID
+	return m.delivery, m.err
+}
+
+// serveWebhookRequest routes a request to the handler the same way the admin router does.
+func serveWebhookRequest(h *webhookHandler, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Post("/webhooks", h.Register)
+	r.Get("/webhooks", h.List)
+	r.Delete("/webhooks/{webhook_id}", h.Delete)
+	r.Get("/webhooks/{webhook_id}/deliveries", h.Deliveries)
+	r.Post("/webhooks/{webhook_id}/deliveries/{delivery_id}/retry", h.Retry)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, body))
+	return rr
+}
+
+func TestNewWebhookHandler(t *testing.T) {
+	if _, err := NewWebhookHandler(&mockWebhookService{}); err != nil {
+		t.Errorf("NewWebhookHandler() error = %v, want nil", err)
+	}
+	if _, err := NewWebhookHandler(nil); err == nil || err.Error() != "webhookService dependency is nil" {
+		t.Errorf("NewWebhookHandler(nil) error = %v, want webhookService dependency is nil", err)
+	}
+}
+
+func TestWebhookHandler_Register_Success(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := &mockWebhookService{registered: &model.RegisteredWebhook{
+		Webhook: model.Webhook{ID: "hook1", URL: "https://hooks.example.com", Secret: "s3cret", CreatedAt: now},
+		Secret:  "s3cret",
+	}}
+	h, _ := NewWebhookHandler(srv)
+
+	rr := serveWebhookRequest(h, http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://hooks.example.com","event_types":["SUBSCRIPTION_REQUEST_APPROVED"]}`))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+	wantReq := &model.WebhookRequest{URL: "https://hooks.example.com", EventTypes: []model.EventType{model.EventTypeSubscriptionRequestApproved}}
+	if diff := cmp.Diff(wantReq, srv.gotReq); diff != "" {
+		t.Errorf("Register() request mismatch (-want +got):\n%s", diff)
+	}
+	want := `{"webhook_id":"hook1","url":"https://hooks.example.com","created_at":"2025-01-01T00:00:00Z","secret":"s3cret"}` + "\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookHandler_List(t *testing.T) {
+	tests := []struct {
+		name  string
+		hooks []model.Webhook
+		want  string
+	}{
+		{
+			name:  "webhooks",
+			hooks: []model.Webhook{{ID: "hook1", URL: "https://hooks.example.com", Secret: "s3cret", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			want:  `[{"webhook_id":"hook1","url":"https://hooks.example.com","created_at":"2025-01-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			name: "no webhooks",
+			want: "[]\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewWebhookHandler(&mockWebhookService{hooks: tc.hooks})
+
+			rr := serveWebhookRequest(h, http.MethodGet, "/webhooks", nil)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Body.String(); got != tc.want {
+				t.Errorf("body = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_Delete(t *testing.T) {
+	srv := &mockWebhookService{}
+	h, _ := NewWebhookHandler(srv)
+
+	rr := serveWebhookRequest(h, http.MethodDelete, "/webhooks/hook1", nil)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if srv.gotWebhookID != "hook1" {
+		t.Errorf("Delete() called with %q, want %q", srv.gotWebhookID, "hook1")
+	}
+}
+
+func TestWebhookHandler_Deliveries(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantLimit int
+	}{
+		{name: "default limit", path: "/webhooks/hook1/deliveries", wantLimit: 0},
+		{name: "limit", path: "/webhooks/hook1/deliveries?limit=5", wantLimit: 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &mockWebhookService{deliveries: []model.WebhookDelivery{{ID: "d1", WebhookID: "hook1", EventType: model.EventTypeSubscriptionRequestApproved}}}
+			h, _ := NewWebhookHandler(srv)
+
+			rr := serveWebhookRequest(h, http.MethodGet, tc.path, nil)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if srv.gotWebhookID != "hook1" || srv.gotLimit != tc.wantLimit {
+				t.Errorf("Deliveries() called with %q, %d, want %q, %d", srv.gotWebhookID, srv.gotLimit, "hook1", tc.wantLimit)
+			}
+			var got []model.WebhookDelivery
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if len(got) != 1 || got[0].ID != "d1" {
+				t.Errorf("response = %+v, want delivery d1", got)
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_Retry(t *testing.T) {
+	srv := &mockWebhookService{delivery: &model.WebhookDelivery{ID: "d1", WebhookID: "hook1", EventType: model.EventTypeSubscriptionRequestRejected, Status: model.WebhookDeliveryStatusDelivered, Attempts: 2}}
+	h, _ := NewWebhookHandler(srv)
+
+	rr := serveWebhookRequest(h, http.MethodPost, "/webhooks/hook1/deliveries/d1/retry", nil)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if srv.gotWebhookID != "hook1" || srv.gotDeliveryID != "d1" {
+		t.Errorf("Redeliver() called with %q, %q, want %q, %q", srv.gotWebhookID, srv.gotDeliveryID, "hook1", "d1")
+	}
+	var got model.WebhookDelivery
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if got.Status != model.WebhookDeliveryStatusDelivered || got.Attempts != 2 {
+		t.Errorf("response = %+v, want a delivered delivery after 2 attempts", got)
+	}
+}
+
+func TestWebhookHandler_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "register invalid json",
+			method:     http.MethodPost,
+			path:       "/webhooks",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:       "register invalid url",
+			method:     http.MethodPost,
+			path:       "/webhooks",
+			body:       `{"url":"ftp://hooks.example.com"}`,
+			err:        service.ErrInvalidWebhookURL,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "register unknown event type",
+			method:     http.MethodPost,
+			path:       "/webhooks",
+			body:       `{"url":"https://hooks.example.com","event_types":["UNKNOWN"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:       "register unsupported event type",
+			method:     http.MethodPost,
+			path:       "/webhooks",
+			body:       `{"url":"https://hooks.example.com","event_types":["KEY_ROTATED"]}`,
+			err:        fmt.Errorf("%w: %q", service.ErrInvalidEventType, model.EventTypeKeyRotated),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "register internal error",
+			method:     http.MethodPost,
+			path:       "/webhooks",
+			body:       `{"url":"https://hooks.example.com"}`,
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "list internal error",
+			method:     http.MethodGet,
+			path:       "/webhooks",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "delete unknown webhook",
+			method:     http.MethodDelete,
+			path:       "/webhooks/hook1",
+			err:        repository.ErrWebhookNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeWebhookNotFound,
+		},
+		{
+			name:       "deliveries invalid limit",
+			method:     http.MethodGet,
+			path:       "/webhooks/hook1/deliveries?limit=abc",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeBadRequest,
+		},
+		{
+			name:       "deliveries unknown webhook",
+			method:     http.MethodGet,
+			path:       "/webhooks/hook1/deliveries",
+			err:        repository.ErrWebhookNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeWebhookNotFound,
+		},
+		{
+			name:       "retry unknown delivery",
+			method:     http.MethodPost,
+			path:       "/webhooks/hook1/deliveries/d1/retry",
+			err:        repository.ErrWebhookDeliveryNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeWebhookNotFound,
+		},
+		{
+			name:       "retry internal error",
+			method:     http.MethodPost,
+			path:       "/webhooks/hook1/deliveries/d1/retry",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewWebhookHandler(&mockWebhookService{err: tc.err})
+
+			rr := serveWebhookRequest(h, tc.method, tc.path, strings.NewReader(tc.body))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", got.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/openapi"
+)
+
+// apiSpec annotates the admin routes for the OpenAPI document served at /openapi.json.
+var apiSpec = openapi.Spec{
+	Title:   "ONIX Admin API",
+	Version: "1.0.0",
+	Error:   model.ErrorResponse{},
+	Endpoints: map[string]openapi.Endpoint{
+		"POST /operations/action": {
+			ID:        "actOnOperation",
+			Summary:   "Approve or reject a subscription operation, or dry-run an approval.",
+			Request:   model.OperationActionRequest{},
+			Responses: map[int]any{http.StatusOK: openapi.OneOf{model.LRO{}, model.ApprovalDryRunResponse{}}},
+		},
+		"GET /operations/stats": {
+			ID:      "getOperationStats",
+			Summary: "Report statistics of the subscription operations submitted in a time window.",
+			Query: []openapi.Param{
+				{Name: "from", Description: "Start of the window, as an RFC 3339 timestamp or a YYYY-MM-DD date."},
+				{Name: "to", Description: "End of the window, as an RFC 3339 timestamp or a YYYY-MM-DD date."},
+			},
+			Responses: map[int]any{http.StatusOK: model.LROStats{}},
+		},
+		"POST /operations/import": {
+			ID:          "importDecisions",
+			Summary:     "Apply approval decisions from a CSV file of operation_id, action and reason.",
+			Request:     "",
+			RequestType: "text/csv",
+			Responses:   map[int]any{http.StatusOK: model.DecisionImportReport{}},
+		},
+		"POST /operations/{operation_id}/comments": {
+			ID:        "addOperationComment",
+			Summary:   "Attach a comment and references to verification documents (GCS URIs) to an operation.",
+			Request:   model.OperationCommentRequest{},
+			Responses: map[int]any{http.StatusCreated: model.OperationComment{}},
+		},
+		"GET /operations/{operation_id}/comments": {
+			ID:        "listOperationComments",
+			Summary:   "List the comments of an operation, oldest first.",
+			Responses: map[int]any{http.StatusOK: []model.OperationComment{}},
+		},
+		"GET /operations/{operation_id}/timeline": {
+			ID:        "getOperationTimeline",
+			Summary:   "View everything that happened to an operation in chronological order: its submission, URL probe, reviews, comments, challenges, subscription changes, webhook events and outcome.",
+			Responses: map[int]any{http.StatusOK: model.OperationTimeline{}},
+		},
+		"POST /operations/{operation_id}/challenge/replay": {
+			ID:        "replayChallenge",
+			Summary:   "Send a new /on_subscribe challenge to the subscription of an approved operation and report whether it still passes, without modifying it.",
+			Responses: map[int]any{http.StatusOK: model.ChallengeReplay{}},
+		},
+		"GET /subscribers/{subscriber_id}/history": {
+			ID:        "getSubscriptionHistory",
+			Summary:   "View the subscriptions of a subscriber as they were at a point in time.",
+			Query:     []openapi.Param{{Name: "at", Description: "Point in time as an RFC 3339 timestamp. Defaults to now.", Format: "date-time"}},
+			Responses: map[int]any{http.StatusOK: model.SubscriptionHistoryView{}},
+		},
+		"PUT /subscribers/{subscriber_id}/labels": {
+			ID:        "setSubscriptionLabels",
+			Summary:   "Replace the labels of one subscription of a subscriber.",
+			Request:   model.SubscriptionLabelsRequest{},
+			Responses: map[int]any{http.StatusOK: model.Subscription{}},
+		},
+		"GET /subscriptions": {
+			ID:      "searchSubscriptions",
+			Summary: "Search subscriptions by labels and lookup fields.",
+			Query: []openapi.Param{
+				{Name: "label", Description: "Label the subscriptions must carry. Repeat to require several labels."},
+				{Name: "subscriber_id", Description: "Subscriber ID to match."},
+				{Name: "domain", Description: "Domain to match. A trailing * matches domains with the prefix."},
+				{Name: "type", Description: "Subscriber type to match: BAP, BPP or BG."},
+				{Name: "status", Description: "Subscription status to match."},
+			},
+			Responses: map[int]any{http.StatusOK: []model.Subscription{}},
+		},
+		"GET /subscribers/duplicates": {
+			ID:        "findDuplicateSubscribers",
+			Summary:   "Find subscribers that share a URL or signing key, or have near-identical IDs.",
+			Responses: map[int]any{http.StatusOK: []model.DuplicateGroup{}},
+		},
+		"POST /subscribers/merge": {
+			ID:        "mergeSubscribers",
+			Summary:   "Merge a duplicate subscriber into another, or dry-run the merge.",
+			Request:   model.SubscriberMergeRequest{},
+			Responses: map[int]any{http.StatusOK: model.SubscriberMergeReport{}},
+		},
+		"GET /snapshot": {
+			ID:        "exportSnapshot",
+			Summary:   "Export the subscriptions and operations of the registry, without secrets.",
+			Responses: map[int]any{http.StatusOK: model.RegistrySnapshot{}},
+		},
+		"POST /snapshot/restore": {
+			ID:        "restoreSnapshot",
+			Summary:   "Restore a snapshot, optionally remapping its subscriber IDs and prefixing its operation IDs.",
+			Request:   model.SnapshotRestoreRequest{},
+			Responses: map[int]any{http.StatusOK: model.SnapshotRestoreReport{}},
+		},
+		"POST /subscribers/{subscriber_id}/api-keys": {
+			ID:        "issueAPIKey",
+			Summary:   "Issue an API key to a subscriber. The key is only returned in this response.",
+			Responses: map[int]any{http.StatusCreated: model.IssuedAPIKey{}},
+		},
+		"GET /subscribers/{subscriber_id}/api-keys": {
+			ID:        "listAPIKeys",
+			Summary:   "List the API keys of a subscriber.",
+			Responses: map[int]any{http.StatusOK: []model.APIKey{}},
+		},
+		"DELETE /subscribers/{subscriber_id}/api-keys/{key_id}": {
+			ID:        "revokeAPIKey",
+			Summary:   "Revoke an API key.",
+			Responses: map[int]any{http.StatusNoContent: nil},
+		},
+		"POST /webhooks": {
+			ID:        "registerWebhook",
+			Summary:   "Register a webhook. The signing secret is only returned in this response.",
+			Request:   model.WebhookRequest{},
+			Responses: map[int]any{http.StatusCreated: model.RegisteredWebhook{}},
+		},
+		"GET /webhooks": {
+			ID:        "listWebhooks",
+			Summary:   "List the registered webhooks.",
+			Responses: map[int]any{http.StatusOK: []model.Webhook{}},
+		},
+		"DELETE /webhooks/{webhook_id}": {
+			ID:        "deleteWebhook",
+			Summary:   "Delete a webhook.",
+			Responses: map[int]any{http.StatusNoContent: nil},
+		},
+		"GET /webhooks/{webhook_id}/deliveries": {
+			ID:        "listWebhookDeliveries",
+			Summary:   "List the deliveries of a webhook, newest first.",
+			Query:     []openapi.Param{{Name: "limit", Description: "Number of deliveries returned.", Type: "integer"}},
+			Responses: map[int]any{http.StatusOK: []model.WebhookDelivery{}},
+		},
+		"POST /webhooks/{webhook_id}/deliveries/{delivery_id}/retry": {
+			ID:        "retryWebhookDelivery",
+			Summary:   "Attempt a webhook delivery once more.",
+			Responses: map[int]any{http.StatusOK: model.WebhookDelivery{}},
+		},
+		"GET /maintenance": {
+			ID:        "getMaintenance",
+			Summary:   "Get the maintenance mode of the registry.",
+			Responses: map[int]any{http.StatusOK: model.Maintenance{}},
+		},
+		"PUT /maintenance": {
+			ID:        "setMaintenance",
+			Summary:   "Enable or disable the maintenance mode of the registry.",
+			Request:   model.Maintenance{},
+			Responses: map[int]any{http.StatusOK: model.Maintenance{}},
+		},
+		"POST /denylist": {
+			ID:        "addDenylistEntry",
+			Summary:   "Deny a subscriber or IP address access to the registry and gateway.",
+			Request:   model.DenylistEntryRequest{},
+			Responses: map[int]any{http.StatusCreated: model.DenylistEntry{}},
+		},
+		"GET /denylist": {
+			ID:        "listDenylistEntries",
+			Summary:   "List the denylist entries.",
+			Responses: map[int]any{http.StatusOK: []model.DenylistEntry{}},
+		},
+		"DELETE /denylist/{entry_id}": {
+			ID:        "removeDenylistEntry",
+			Summary:   "Remove a denylist entry.",
+			Responses: map[int]any{http.StatusNoContent: nil},
+		},
+		"POST /domains": {
+			ID:        "addDomain",
+			Summary:   "Add a domain to the domain catalog.",
+			Request:   model.DomainRequest{},
+			Responses: map[int]any{http.StatusCreated: model.Domain{}},
+		},
+		"GET /domains": {
+			ID:        "listDomains",
+			Summary:   "List the domain catalog as stored, without inherited metadata.",
+			Responses: map[int]any{http.StatusOK: []model.Domain{}},
+		},
+		"PUT /domains/{domain}": {
+			ID:        "updateDomain",
+			Summary:   "Update the metadata of a domain.",
+			Request:   model.DomainRequest{},
+			Responses: map[int]any{http.StatusOK: model.Domain{}},
+		},
+		"DELETE /domains/{domain}": {
+			ID:        "removeDomain",
+			Summary:   "Remove a domain that has no children from the domain catalog.",
+			Responses: map[int]any{http.StatusNoContent: nil},
+		},
+	},
+}
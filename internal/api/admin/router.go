@@ -15,20 +15,106 @@
 package admin
 
 import (
+	"expvar"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/openapi"
 )
 
 // adminHandler defines the interface for admin LRO handlers.
 type adminHandler interface {
 	HandleSubscriptionAction(w http.ResponseWriter, r *http.Request)
+	ReplayChallenge(w http.ResponseWriter, r *http.Request)
+}
+
+// apiKeyHandler defines the interface for handlers managing subscriber API keys.
+type apiKeyHandler interface {
+	Issue(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
+}
+
+// webhookHandler defines the interface for handlers managing webhooks.
+type webhookHandler interface {
+	Register(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Deliveries(w http.ResponseWriter, r *http.Request)
+	Retry(w http.ResponseWriter, r *http.Request)
+}
+
+// maintenanceHandler defines the interface for handlers toggling the registry's maintenance mode.
+type maintenanceHandler interface {
+	Get(w http.ResponseWriter, r *http.Request)
+	Set(w http.ResponseWriter, r *http.Request)
+}
+
+// denylistHandler defines the interface for handlers managing the denylist.
+type denylistHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Remove(w http.ResponseWriter, r *http.Request)
+}
+
+// domainHandler defines the interface for handlers managing the domain catalog.
+type domainHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Remove(w http.ResponseWriter, r *http.Request)
+}
+
+// lroStatsHandler defines the interface for handlers reporting LRO statistics.
+type lroStatsHandler interface {
+	Stats(w http.ResponseWriter, r *http.Request)
+}
+
+// decisionImportHandler defines the interface for handlers importing approval decisions.
+type decisionImportHandler interface {
+	Import(w http.ResponseWriter, r *http.Request)
+}
+
+// subscriptionHistoryHandler defines the interface for handlers viewing the subscription history.
+type subscriptionHistoryHandler interface {
+	At(w http.ResponseWriter, r *http.Request)
+}
+
+// subscriptionLabelHandler defines the interface for handlers labelling and searching subscriptions.
+type subscriptionLabelHandler interface {
+	Set(w http.ResponseWriter, r *http.Request)
+	Search(w http.ResponseWriter, r *http.Request)
+}
+
+// subscriberMergeHandler defines the interface for handlers finding and merging duplicate subscribers.
+type subscriberMergeHandler interface {
+	Duplicates(w http.ResponseWriter, r *http.Request)
+	Merge(w http.ResponseWriter, r *http.Request)
+}
+
+// operationCommentHandler defines the interface for handlers commenting on operations under review.
+type operationCommentHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+}
+
+// operationTimelineHandler defines the interface for handlers composing the timeline of an operation.
+type operationTimelineHandler interface {
+	Get(w http.ResponseWriter, r *http.Request)
+}
+
+// snapshotHandler defines the interface for handlers exporting and restoring the registry state.
+type snapshotHandler interface {
+	Export(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRouter configures and returns the Chi router for the Admin service functionalities.
-func NewRouter(lroh adminHandler) *chi.Mux {
+func NewRouter(lroh adminHandler, akh apiKeyHandler, wh webhookHandler, mh maintenanceHandler, dh denylistHandler, sh lroStatsHandler, ih decisionImportHandler, hh subscriptionHistoryHandler, xh snapshotHandler, domh domainHandler, lh subscriptionLabelHandler, smh subscriberMergeHandler, ch operationCommentHandler, th operationTimelineHandler) *chi.Mux {
 	router := chi.NewRouter()
 
 	router.Use(middleware.Logger)
@@ -41,7 +127,55 @@ func NewRouter(lroh adminHandler) *chi.Mux {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, `{"status":"ok"}`)
 	})
+	// Runtime and connection pool metrics published via expvar.
+	router.Handle("/debug/vars", expvar.Handler())
 
 	router.Post("/operations/action", lroh.HandleSubscriptionAction)
+	router.Get("/operations/stats", sh.Stats)
+	router.Post("/operations/import", ih.Import)
+	router.Post("/operations/{operation_id}/comments", ch.Add)
+	router.Get("/operations/{operation_id}/comments", ch.List)
+	router.Get("/operations/{operation_id}/timeline", th.Get)
+	router.Post("/operations/{operation_id}/challenge/replay", lroh.ReplayChallenge)
+	router.Get("/subscribers/{subscriber_id}/history", hh.At)
+	router.Put("/subscribers/{subscriber_id}/labels", lh.Set)
+	router.Get("/subscriptions", lh.Search)
+	router.Get("/subscribers/duplicates", smh.Duplicates)
+	router.Post("/subscribers/merge", smh.Merge)
+	router.Route("/subscribers/{subscriber_id}/api-keys", func(r chi.Router) {
+		r.Post("/", akh.Issue)
+		r.Get("/", akh.List)
+		r.Delete("/{key_id}", akh.Revoke)
+	})
+	router.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", wh.Register)
+		r.Get("/", wh.List)
+		r.Delete("/{webhook_id}", wh.Delete)
+		r.Get("/{webhook_id}/deliveries", wh.Deliveries)
+		r.Post("/{webhook_id}/deliveries/{delivery_id}/retry", wh.Retry)
+	})
+	router.Get("/snapshot", xh.Export)
+	router.Post("/snapshot/restore", xh.Restore)
+	router.Get("/maintenance", mh.Get)
+	router.Put("/maintenance", mh.Set)
+	router.Route("/denylist", func(r chi.Router) {
+		r.Post("/", dh.Add)
+		r.Get("/", dh.List)
+		r.Delete("/{entry_id}", dh.Remove)
+	})
+	router.Route("/domains", func(r chi.Router) {
+		r.Post("/", domh.Add)
+		r.Get("/", domh.List)
+		r.Put("/{domain}", domh.Update)
+		r.Delete("/{domain}", domh.Remove)
+	})
+
+	// The OpenAPI document is generated from the routes registered above.
+	doc, err := openapi.Generate(apiSpec, router)
+	if err != nil {
+		slog.Error("AdminRouter: Failed to generate OpenAPI document", "error", err)
+		return router
+	}
+	router.Get("/openapi.json", doc.ServeHTTP)
 	return router
 }
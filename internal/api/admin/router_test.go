@@ -15,15 +15,21 @@
 package admin
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/openapi"
 )
 
 type mockAdminHandler struct {
 	handleSubscriptionActionCalled bool
+	replayChallengeCalled          bool
 }
 
 func (m *mockAdminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *http.Request) {
@@ -31,10 +37,241 @@ func (m *mockAdminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockAdminHandler) ReplayChallenge(w http.ResponseWriter, r *http.Request) {
+	m.replayChallengeCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockAPIKeyHandler struct {
+	issueCalled  bool
+	listCalled   bool
+	revokeCalled bool
+}
+
+func (m *mockAPIKeyHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	m.issueCalled = true
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (m *mockAPIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	m.listCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockAPIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	m.revokeCalled = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type mockWebhookHandler struct {
+	registerCalled   bool
+	listCalled       bool
+	deleteCalled     bool
+	deliveriesCalled bool
+	retryCalled      bool
+}
+
+func (m *mockWebhookHandler) Register(w http.ResponseWriter, r *http.Request) {
+	m.registerCalled = true
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (m *mockWebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	m.listCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockWebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	m.deleteCalled = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *mockWebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	m.deliveriesCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockWebhookHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	m.retryCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockMaintenanceHandler struct {
+	getCalled bool
+	setCalled bool
+}
+
+func (m *mockMaintenanceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	m.getCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockMaintenanceHandler) Set(w http.ResponseWriter, r *http.Request) {
+	m.setCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockDenylistHandler struct {
+	addCalled    bool
+	listCalled   bool
+	removeCalled bool
+}
+
+func (m *mockDenylistHandler) Add(w http.ResponseWriter, r *http.Request) {
+	m.addCalled = true
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (m *mockDenylistHandler) List(w http.ResponseWriter, r *http.Request) {
+	m.listCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockDenylistHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	m.removeCalled = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type mockDomainHandler struct {
+	addCalled    bool
+	listCalled   bool
+	updateCalled bool
+	removeCalled bool
+}
+
+func (m *mockDomainHandler) Add(w http.ResponseWriter, r *http.Request) {
+	m.addCalled = true
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (m *mockDomainHandler) List(w http.ResponseWriter, r *http.Request) {
+	m.listCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockDomainHandler) Update(w http.ResponseWriter, r *http.Request) {
+	m.updateCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockDomainHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	m.removeCalled = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type mockLROStatsHandler struct {
+	statsCalled bool
+}
+
+func (m *mockLROStatsHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	m.statsCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockDecisionImportHandler struct {
+	importCalled bool
+}
+
+func (m *mockDecisionImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	m.importCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockSubscriptionHistoryHandler struct {
+	atCalled bool
+}
+
+func (m *mockSubscriptionHistoryHandler) At(w http.ResponseWriter, r *http.Request) {
+	m.atCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockSubscriptionLabelHandler struct {
+	setCalled    bool
+	searchCalled bool
+}
+
+func (m *mockSubscriptionLabelHandler) Set(w http.ResponseWriter, r *http.Request) {
+	m.setCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSubscriptionLabelHandler) Search(w http.ResponseWriter, r *http.Request) {
+	m.searchCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockSubscriberMergeHandler struct {
+	duplicatesCalled bool
+	mergeCalled      bool
+}
+
+func (m *mockSubscriberMergeHandler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	m.duplicatesCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSubscriberMergeHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	m.mergeCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockOperationCommentHandler struct {
+	addCalled  bool
+	listCalled bool
+}
+
+func (m *mockOperationCommentHandler) Add(w http.ResponseWriter, r *http.Request) {
+	m.addCalled = true
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (m *mockOperationCommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	m.listCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockOperationTimelineHandler struct {
+	getCalled bool
+}
+
+func (m *mockOperationTimelineHandler) Get(w http.ResponseWriter, r *http.Request) {
+	m.getCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+type mockSnapshotHandler struct {
+	exportCalled  bool
+	restoreCalled bool
+}
+
+func (m *mockSnapshotHandler) Export(w http.ResponseWriter, r *http.Request) {
+	m.exportCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSnapshotHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	m.restoreCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 func TestRouter_Routes(t *testing.T) {
 	h := &mockAdminHandler{}
+	akh := &mockAPIKeyHandler{}
+	wh := &mockWebhookHandler{}
+	mh := &mockMaintenanceHandler{}
+	dh := &mockDenylistHandler{}
+	sh := &mockLROStatsHandler{}
+	ih := &mockDecisionImportHandler{}
+	hh := &mockSubscriptionHistoryHandler{}
+	xh := &mockSnapshotHandler{}
+	domh := &mockDomainHandler{}
+	lh := &mockSubscriptionLabelHandler{}
+	smh := &mockSubscriberMergeHandler{}
+	ch := &mockOperationCommentHandler{}
+	th := &mockOperationTimelineHandler{}
 
-	router := NewRouter(h)
+	router := NewRouter(h, akh, wh, mh, dh, sh, ih, hh, xh, domh, lh, smh, ch, th)
 
 	tests := []struct {
 		name           string
@@ -54,6 +291,22 @@ func TestRouter_Routes(t *testing.T) {
 			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
 			handlerCheck:   func(t *testing.T) { /* No specific handler mock to check */ },
 		},
+		{
+			name:            "DebugVars",
+			method:          http.MethodGet,
+			path:            "/debug/vars",
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			handlerCheck:    func(t *testing.T) {},
+		},
+		{
+			name:            "OpenAPI",
+			method:          http.MethodGet,
+			path:            "/openapi.json",
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
+			handlerCheck:    func(t *testing.T) {},
+		},
 		{
 			name:           "SubscriptionAction",
 			method:         http.MethodPost,
@@ -65,6 +318,336 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "IssueAPIKey",
+			method:         http.MethodPost,
+			path:           "/subscribers/sub1/api-keys",
+			expectedStatus: http.StatusCreated,
+			handlerCheck: func(t *testing.T) {
+				if !akh.issueCalled {
+					t.Error("apiKeyHandler.Issue was not called")
+				}
+			},
+		},
+		{
+			name:           "ListAPIKeys",
+			method:         http.MethodGet,
+			path:           "/subscribers/sub1/api-keys",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !akh.listCalled {
+					t.Error("apiKeyHandler.List was not called")
+				}
+			},
+		},
+		{
+			name:           "RevokeAPIKey",
+			method:         http.MethodDelete,
+			path:           "/subscribers/sub1/api-keys/key1",
+			expectedStatus: http.StatusNoContent,
+			handlerCheck: func(t *testing.T) {
+				if !akh.revokeCalled {
+					t.Error("apiKeyHandler.Revoke was not called")
+				}
+			},
+		},
+		{
+			name:           "GetMaintenance",
+			method:         http.MethodGet,
+			path:           "/maintenance",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !mh.getCalled {
+					t.Error("maintenanceHandler.Get was not called")
+				}
+			},
+		},
+		{
+			name:           "SetMaintenance",
+			method:         http.MethodPut,
+			path:           "/maintenance",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !mh.setCalled {
+					t.Error("maintenanceHandler.Set was not called")
+				}
+			},
+		},
+		{
+			name:           "AddDenylistEntry",
+			method:         http.MethodPost,
+			path:           "/denylist",
+			expectedStatus: http.StatusCreated,
+			handlerCheck: func(t *testing.T) {
+				if !dh.addCalled {
+					t.Error("denylistHandler.Add was not called")
+				}
+			},
+		},
+		{
+			name:           "ListDenylist",
+			method:         http.MethodGet,
+			path:           "/denylist",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !dh.listCalled {
+					t.Error("denylistHandler.List was not called")
+				}
+			},
+		},
+		{
+			name:           "RemoveDenylistEntry",
+			method:         http.MethodDelete,
+			path:           "/denylist/e1",
+			expectedStatus: http.StatusNoContent,
+			handlerCheck: func(t *testing.T) {
+				if !dh.removeCalled {
+					t.Error("denylistHandler.Remove was not called")
+				}
+			},
+		},
+		{
+			name:           "AddDomain",
+			method:         http.MethodPost,
+			path:           "/domains",
+			expectedStatus: http.StatusCreated,
+			handlerCheck: func(t *testing.T) {
+				if !domh.addCalled {
+					t.Error("domainHandler.Add was not called")
+				}
+			},
+		},
+		{
+			name:           "ListDomains",
+			method:         http.MethodGet,
+			path:           "/domains",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !domh.listCalled {
+					t.Error("domainHandler.List was not called")
+				}
+			},
+		},
+		{
+			name:           "UpdateDomain",
+			method:         http.MethodPut,
+			path:           "/domains/ONDC:RET10",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !domh.updateCalled {
+					t.Error("domainHandler.Update was not called")
+				}
+			},
+		},
+		{
+			name:           "RemoveDomain",
+			method:         http.MethodDelete,
+			path:           "/domains/ONDC:RET10",
+			expectedStatus: http.StatusNoContent,
+			handlerCheck: func(t *testing.T) {
+				if !domh.removeCalled {
+					t.Error("domainHandler.Remove was not called")
+				}
+			},
+		},
+		{
+			name:           "OperationStats",
+			method:         http.MethodGet,
+			path:           "/operations/stats",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !sh.statsCalled {
+					t.Error("lroStatsHandler.Stats was not called")
+				}
+			},
+		},
+		{
+			name:           "ImportDecisions",
+			method:         http.MethodPost,
+			path:           "/operations/import",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !ih.importCalled {
+					t.Error("decisionImportHandler.Import was not called")
+				}
+			},
+		},
+		{
+			name:           "SubscriptionHistory",
+			method:         http.MethodGet,
+			path:           "/subscribers/np1/history",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !hh.atCalled {
+					t.Error("subscriptionHistoryHandler.At was not called")
+				}
+			},
+		},
+		{
+			name:           "SetSubscriptionLabels",
+			method:         http.MethodPut,
+			path:           "/subscribers/np1/labels",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !lh.setCalled {
+					t.Error("subscriptionLabelHandler.Set was not called")
+				}
+			},
+		},
+		{
+			name:           "SearchSubscriptions",
+			method:         http.MethodGet,
+			path:           "/subscriptions?label=pilot",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !lh.searchCalled {
+					t.Error("subscriptionLabelHandler.Search was not called")
+				}
+			},
+		},
+		{
+			name:           "FindDuplicateSubscribers",
+			method:         http.MethodGet,
+			path:           "/subscribers/duplicates",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !smh.duplicatesCalled {
+					t.Error("subscriberMergeHandler.Duplicates was not called")
+				}
+			},
+		},
+		{
+			name:           "AddOperationComment",
+			method:         http.MethodPost,
+			path:           "/operations/op-1/comments",
+			expectedStatus: http.StatusCreated,
+			handlerCheck: func(t *testing.T) {
+				if !ch.addCalled {
+					t.Error("operationCommentHandler.Add was not called")
+				}
+			},
+		},
+		{
+			name:           "ListOperationComments",
+			method:         http.MethodGet,
+			path:           "/operations/op-1/comments",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !ch.listCalled {
+					t.Error("operationCommentHandler.List was not called")
+				}
+			},
+		},
+		{
+			name:           "GetOperationTimeline",
+			method:         http.MethodGet,
+			path:           "/operations/op-1/timeline",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !th.getCalled {
+					t.Error("operationTimelineHandler.Get was not called")
+				}
+			},
+		},
+		{
+			name:           "ReplayChallenge",
+			method:         http.MethodPost,
+			path:           "/operations/op-1/challenge/replay",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !h.replayChallengeCalled {
+					t.Error("adminHandler.ReplayChallenge was not called")
+				}
+			},
+		},
+		{
+			name:           "MergeSubscribers",
+			method:         http.MethodPost,
+			path:           "/subscribers/merge",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !smh.mergeCalled {
+					t.Error("subscriberMergeHandler.Merge was not called")
+				}
+			},
+		},
+		{
+			name:           "ExportSnapshot",
+			method:         http.MethodGet,
+			path:           "/snapshot",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !xh.exportCalled {
+					t.Error("snapshotHandler.Export was not called")
+				}
+			},
+		},
+		{
+			name:           "RestoreSnapshot",
+			method:         http.MethodPost,
+			path:           "/snapshot/restore",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !xh.restoreCalled {
+					t.Error("snapshotHandler.Restore was not called")
+				}
+			},
+		},
+		{
+			name:           "RegisterWebhook",
+			method:         http.MethodPost,
+			path:           "/webhooks",
+			expectedStatus: http.StatusCreated,
+			handlerCheck: func(t *testing.T) {
+				if !wh.registerCalled {
+					t.Error("webhookHandler.Register was not called")
+				}
+			},
+		},
+		{
+			name:           "ListWebhooks",
+			method:         http.MethodGet,
+			path:           "/webhooks",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !wh.listCalled {
+					t.Error("webhookHandler.List was not called")
+				}
+			},
+		},
+		{
+			name:           "DeleteWebhook",
+			method:         http.MethodDelete,
+			path:           "/webhooks/hook1",
+			expectedStatus: http.StatusNoContent,
+			handlerCheck: func(t *testing.T) {
+				if !wh.deleteCalled {
+					t.Error("webhookHandler.Delete was not called")
+				}
+			},
+		},
+		{
+			name:           "WebhookDeliveries",
+			method:         http.MethodGet,
+			path:           "/webhooks/hook1/deliveries",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !wh.deliveriesCalled {
+					t.Error("webhookHandler.Deliveries was not called")
+				}
+			},
+		},
+		{
+			name:           "RetryWebhookDelivery",
+			method:         http.MethodPost,
+			path:           "/webhooks/hook1/deliveries/d1/retry",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !wh.retryCalled {
+					t.Error("webhookHandler.Retry was not called")
+				}
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -95,3 +678,33 @@ func TestRouter_Routes(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_OpenAPI(t *testing.T) {
+	router := NewRouter(&mockAdminHandler{}, &mockAPIKeyHandler{}, &mockWebhookHandler{}, &mockMaintenanceHandler{}, &mockDenylistHandler{}, &mockLROStatsHandler{}, &mockDecisionImportHandler{}, &mockSubscriptionHistoryHandler{}, &mockSnapshotHandler{}, &mockDomainHandler{}, &mockSubscriptionLabelHandler{}, &mockSubscriberMergeHandler{}, &mockOperationCommentHandler{}, &mockOperationTimelineHandler{})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.NewDecoder(rr.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+
+	// Every API route must be documented.
+	undocumented := map[string]bool{"/health": true, "/debug/vars": true, "/openapi.json": true}
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if undocumented[route] {
+			return nil
+		}
+		if doc.Paths[route][strings.ToLower(method)] == nil {
+			t.Errorf("Route %s %s is not documented", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk() error = %v", err)
+	}
+	if _, ok := doc.Components.Schemas["OperationActionRequest"]; !ok {
+		t.Errorf("Schema OperationActionRequest missing from components")
+	}
+}
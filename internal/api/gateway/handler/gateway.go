@@ -18,10 +18,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/jsonschema"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -33,9 +41,87 @@ type taskQueuer interface {
 	QueueTxn(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error)
 }
 
+type coreVersionPolicy interface {
+	Apply(reqCtx *model.Context, body []byte) ([]byte, error)
+	Matrix() *service.CoreVersionConfig
+}
+
+type actionValidator interface {
+	Validate(action string, body []byte) error
+}
+
+type queuePressure interface {
+	Level(domain string) service.PressureLevel
+	RetryAfter() time.Duration
+}
+
+type denylistChecker interface {
+	Check(ctx context.Context, remoteAddr, authHeader string) *model.DenylistEntry
+}
+
+// identityApplier sets the headers that identify the gateway.
+type identityApplier interface {
+	Apply(h http.Header)
+}
+
+// selfTester diagnoses the signed requests of network participants.
+type selfTester interface {
+	Run(ctx context.Context, body []byte, authHeader string) *model.SelfTestReport
+}
+
+// clockSkewChecker checks the times of a request against the gateway's clock.
+type clockSkewChecker interface {
+	CheckSignature(authHeader string) error
+	CheckTimestamp(timestamp string) error
+}
+
+// txnRecorder records the requests received per market segment and summarizes them.
+type txnRecorder interface {
+	RecordRequest(c *model.Context, acked bool, d time.Duration)
+	Summary() *model.TxnSummary
+}
+
+// deliveryReporter reports the outcomes of the requests delivered to each target subscriber.
+type deliveryReporter interface {
+	Report(ctx context.Context) (*model.DeliveryStatsReport, error)
+}
+
+// shadowMirror copies a sample of validated requests to a shadow gateway.
+type shadowMirror interface {
+	Mirror(path string, body []byte, h http.Header)
+}
+
+// targetChecker checks that the subscriber a request is addressed to can receive it.
+type targetChecker interface {
+	Check(ctx context.Context, reqCtx *model.Context) error
+}
+
+// authSchemeParser reads the subscriber signature of a request in the accepted formats.
+type authSchemeParser interface {
+	SubscriberAuth(h http.Header) (string, error)
+}
+
+// payloadDecompressor decodes request bodies sent with a Content-Encoding.
+type payloadDecompressor interface {
+	Decompress(encoding string, body []byte) ([]byte, error)
+}
+
 type gatewayHandler struct {
 	authValidator gatewayAuthValidator
 	taskQueuer    taskQueuer
+	versionPolicy coreVersionPolicy
+	pressure      queuePressure
+	actions       actionValidator
+	denylist      denylistChecker
+	identity      identityApplier
+	selfTest      selfTester
+	clockSkew     clockSkewChecker
+	txnMetrics    txnRecorder
+	delivery      deliveryReporter
+	shadow        shadowMirror
+	target        targetChecker
+	compression   payloadDecompressor
+	schemes       authSchemeParser
 }
 
 func NewGatewayHandler(authValidator gatewayAuthValidator, taskQueuer taskQueuer) (*gatewayHandler, error) {
@@ -50,9 +136,212 @@ func NewGatewayHandler(authValidator gatewayAuthValidator, taskQueuer taskQueuer
 	return &gatewayHandler{authValidator: authValidator, taskQueuer: taskQueuer}, nil
 }
 
+// SetCoreVersionPolicy sets the policy used to enforce the core version compatibility matrix.
+func (h *gatewayHandler) SetCoreVersionPolicy(p coreVersionPolicy) {
+	h.versionPolicy = p
+}
+
+// SetBackpressure sets the task queue pressure used to signal senders to slow down.
+// Above the soft watermark ACKs carry a Retry-After hint; above the hard watermark
+// requests are NACKed rather than blocking on a full queue.
+func (h *gatewayHandler) SetBackpressure(p queuePressure) {
+	h.pressure = p
+}
+
+// SetActionValidator sets the validator that checks requests against the schema of their action.
+func (h *gatewayHandler) SetActionValidator(v actionValidator) {
+	h.actions = v
+}
+
+// SetDenylist sets the denylist used to drop requests from denylisted subscribers and IPs.
+func (h *gatewayHandler) SetDenylist(d denylistChecker) {
+	h.denylist = d
+}
+
+// SetIdentity sets the headers that identify the gateway in responses to health checks.
+func (h *gatewayHandler) SetIdentity(identity identityApplier) {
+	h.identity = identity
+}
+
+// SetSelfTest enables the connectivity self-test served by SelfTest.
+func (h *gatewayHandler) SetSelfTest(st selfTester) {
+	h.selfTest = st
+}
+
+// SetClockSkew sets the check that NACKs requests whose signature or context timestamp is
+// too far from the gateway's clock.
+func (h *gatewayHandler) SetClockSkew(c clockSkewChecker) {
+	h.clockSkew = c
+}
+
+// SetTxnMetrics records the count and latency of requests per market segment, and enables
+// the summary served by TxnMetrics.
+func (h *gatewayHandler) SetTxnMetrics(m txnRecorder) {
+	h.txnMetrics = m
+}
+
+// SetDeliveryStats enables the per-target delivery outcomes served by DeliveryStats.
+func (h *gatewayHandler) SetDeliveryStats(d deliveryReporter) {
+	h.delivery = d
+}
+
+// SetShadow mirrors a sample of the requests the gateway accepts to a shadow gateway.
+func (h *gatewayHandler) SetShadow(m shadowMirror) {
+	h.shadow = m
+}
+
+// SetTargetStatus NACKs requests addressed to a bpp_id that is suspended or expired,
+// which would otherwise be ACKed and then dropped by the lookup of the fanout.
+func (h *gatewayHandler) SetTargetStatus(t targetChecker) {
+	h.target = t
+}
+
+// SetCompression accepts gzip compressed request bodies, which are decompressed before their
+// signature is validated, and advertises it with an Accept-Encoding response header.
+func (h *gatewayHandler) SetCompression(c payloadDecompressor) {
+	h.compression = c
+}
+
+// SetAuthSchemes sets the signature formats accepted from subscribers. Without them only the
+// Authorization header is read.
+func (h *gatewayHandler) SetAuthSchemes(s authSchemeParser) {
+	h.schemes = s
+}
+
+// subscriberAuth returns the subscriber signature of r in the Authorization header format.
+func (h *gatewayHandler) subscriberAuth(r *http.Request) (string, error) {
+	if h.schemes == nil {
+		return r.Header.Get(model.AuthHeaderSubscriber), nil
+	}
+	return h.schemes.SubscriberAuth(r.Header)
+}
+
+// Identify is a middleware that adds the gateway's identity headers to the response,
+// so that network participants checking the gateway's health can verify which gateway
+// answered. It is a no-op without an identity.
+func (h *gatewayHandler) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.identity != nil {
+			h.identity.Apply(w.Header())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Enforce is a middleware that NACKs requests from denylisted subscribers and IPs with
+// 403 Forbidden before their signature is validated. It is a no-op without a denylist.
+func (h *gatewayHandler) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.denylist == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth, _ := h.subscriberAuth(r)
+		e := h.denylist.Check(r.Context(), r.RemoteAddr, auth)
+		if e == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		slog.WarnContext(r.Context(), "GatewayHandler: Dropped request from denylisted client", "entry_id", e.ID, "kind", e.Kind, "remote_addr", r.RemoteAddr, "path", r.URL.Path)
+		writeGatewayError(w, http.StatusForbidden, string(model.ErrorCodeDenylisted), "Request denied.")
+	})
+}
+
+// CoreVersions serves the supported core version matrix for discovery.
+func (h *gatewayHandler) CoreVersions(w http.ResponseWriter, r *http.Request) {
+	matrix := &service.CoreVersionConfig{}
+	if h.versionPolicy != nil {
+		matrix = h.versionPolicy.Matrix()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(matrix); err != nil {
+		slog.ErrorContext(r.Context(), "GatewayHandler: Failed to write core versions response", "error", err)
+	}
+}
+
+// TxnMetrics serves the counts and latencies of the transactions through the gateway per
+// (action, domain, city) segment.
+func (h *gatewayHandler) TxnMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.txnMetrics == nil {
+		writeGatewayError(w, http.StatusNotFound, "NOT_FOUND", "Transaction metrics are not enabled on this gateway.")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.txnMetrics.Summary()); err != nil {
+		slog.ErrorContext(r.Context(), "GatewayHandler: Failed to write transaction metrics", "error", err)
+	}
+}
+
+// DeliveryStats serves the rolling counts of delivery outcomes per target subscriber, from
+// which the registry and admin compute reliability scorecards. The target query parameter
+// limits the report to one subscriber URL.
+func (h *gatewayHandler) DeliveryStats(w http.ResponseWriter, r *http.Request) {
+	if h.delivery == nil {
+		writeGatewayError(w, http.StatusNotFound, "NOT_FOUND", "Delivery stats are not enabled on this gateway.")
+		return
+	}
+	report, err := h.delivery.Report(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "GatewayHandler: Failed to report delivery stats", "error", err)
+		writeGatewayError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read delivery stats.")
+		return
+	}
+	if target := r.URL.Query().Get("target"); target != "" {
+		report.Targets = slices.DeleteFunc(report.Targets, func(t model.TargetDeliveryStats) bool { return t.Target != target })
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.ErrorContext(r.Context(), "GatewayHandler: Failed to write delivery stats", "error", err)
+	}
+}
+
+// SelfTest serves the connectivity self-test. Network participants send it a request signed
+// the same way as their search requests, and get back a report of whether the signature
+// validates, their clock is in sync and their bap_uri or bpp_uri is registered.
+// Failed checks do not fail the request; the report is always returned with 200 OK.
+func (h *gatewayHandler) SelfTest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.selfTest == nil {
+		writeGatewayError(w, http.StatusNotFound, "NOT_FOUND", "Self-test is not enabled on this gateway.")
+		return
+	}
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "GatewayHandler: Failed to read self-test request body", "error", err)
+		writeGatewayError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read request body.")
+		return
+	}
+	defer r.Body.Close()
+	bodyBytes, ok := h.decompress(w, r, bodyBytes)
+	if !ok {
+		return
+	}
+
+	// A signature in a format that is not accepted is reported as unparsable.
+	auth, _ := h.subscriberAuth(r)
+	report := h.selfTest.Run(ctx, bodyBytes, auth)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.ErrorContext(ctx, "GatewayHandler: Failed to write self-test report", "error", err)
+	}
+}
+
 func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	var (
+		bodyBytes []byte
+		txnReq    model.TxnRequest
+		acked     bool
+	)
+	if h.txnMetrics != nil {
+		start := time.Now()
+		defer func() { h.recordTxn(bodyBytes, &txnReq, acked, time.Since(start)) }()
+	}
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
 		slog.ErrorContext(ctx, "GatewayHandler: Failed to read request body", "error", err)
@@ -60,8 +349,25 @@ func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer r.Body.Close()
+	bodyBytes, ok := h.decompress(w, r, bodyBytes)
+	if !ok {
+		return
+	}
 
-	authHeader := r.Header.Get(model.AuthHeaderSubscriber)
+	authHeader, err := h.subscriberAuth(r)
+	if err != nil {
+		slog.WarnContext(ctx, "GatewayHandler: Invalid signature headers", "error", err)
+		writeGatewayError(w, http.StatusUnauthorized, string(model.ErrorCodeInvalidAuthHeader), err.Error())
+		return
+	}
+	if h.clockSkew != nil {
+		// Checked before the signature, which would otherwise fail without saying why.
+		if err := h.clockSkew.CheckSignature(authHeader); err != nil {
+			slog.WarnContext(ctx, "GatewayHandler: Signature outside the allowed clock skew", "error", err)
+			writeGatewayError(w, http.StatusUnauthorized, string(model.ErrorCodeClockSkew), err.Error())
+			return
+		}
+	}
 	if authErr := h.authValidator.Validate(ctx, bodyBytes, authHeader); authErr != nil {
 		slog.ErrorContext(ctx, "GatewayHandler: Authentication failed", "error", authErr)
 		writeGatewayError(w, authErr.StatusCode, string(authErr.ErrorCode), authErr.Message)
@@ -69,20 +375,81 @@ func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 	}
 	slog.InfoContext(ctx, "GatewayHandler: Authentication successful")
 
-	var txnReq model.TxnRequest
 	if err := json.Unmarshal(bodyBytes, &txnReq); err != nil {
 		slog.ErrorContext(ctx, "GatewayHandler: Failed to unmarshal request body", "error", err)
 		writeGatewayError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body.")
 		return
 	}
+	if h.clockSkew != nil {
+		if err := h.clockSkew.CheckTimestamp(txnReq.Context.Timestamp); err != nil {
+			slog.WarnContext(ctx, "GatewayHandler: Context timestamp outside the allowed clock skew", "error", err)
+			writeGatewayError(w, http.StatusBadRequest, string(model.ErrorCodeClockSkew), err.Error())
+			return
+		}
+	}
+	if h.versionPolicy != nil {
+		if bodyBytes, err = h.versionPolicy.Apply(&txnReq.Context, bodyBytes); err != nil {
+			slog.ErrorContext(ctx, "GatewayHandler: Core version check failed", "error", err)
+			var verr *service.UnsupportedCoreVersionError
+			if errors.As(err, &verr) {
+				writeUnsupportedVersion(w, verr)
+				return
+			}
+			if errors.Is(err, service.ErrUnsupportedCoreVersion) {
+				writeGatewayError(w, http.StatusBadRequest, string(model.ErrorCodeUnsupportedVersion), err.Error())
+				return
+			}
+			writeGatewayError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body.")
+			return
+		}
+	}
+	if h.actions != nil {
+		if err := h.actions.Validate(txnReq.Context.Action, bodyBytes); err != nil {
+			slog.ErrorContext(ctx, "GatewayHandler: Action schema validation failed", "error", err)
+			writeSchemaViolation(w, txnReq.Context.Action, err)
+			return
+		}
+	}
+	if h.target != nil {
+		if err := h.target.Check(ctx, &txnReq.Context); err != nil {
+			slog.WarnContext(ctx, "GatewayHandler: Target subscriber cannot receive requests", "bpp_id", txnReq.Context.BppID, "error", err)
+			state := "suspended"
+			if errors.Is(err, service.ErrTargetExpired) {
+				state = "expired"
+			}
+			writeGatewayError(w, http.StatusNotFound, string(model.ErrorCodeSubscriberInactive), fmt.Sprintf("Subscriber %s is %s.", txnReq.Context.BppID, state))
+			return
+		}
+	}
+	level := service.PressureNone
+	if h.pressure != nil {
+		level = h.pressure.Level(txnReq.Context.Domain)
+	}
+	if level == service.PressureHard {
+		slog.WarnContext(ctx, "GatewayHandler: Task queue above hard watermark, rejecting request")
+		h.writeOverloaded(w)
+		return
+	}
 	queuedTask, err := h.taskQueuer.QueueTxn(ctx, &txnReq.Context, bodyBytes, r.Header.Clone())
+	if errors.Is(err, service.ErrTaskQueueFull) {
+		slog.WarnContext(ctx, "GatewayHandler: Task queue full, rejecting request")
+		h.writeOverloaded(w)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "GatewayHandler: Failed to queue task via QueueTxn", "error", err)
 		writeGatewayError(w, http.StatusInternalServerError, "QUEUEING_FAILED", "Failed to queue task.")
 		return
 	}
 	slog.InfoContext(ctx, "GatewayHandler: Task queued successfully via QueueTxn", "task", queuedTask)
+	acked = true
+	if h.shadow != nil {
+		h.shadow.Mirror(r.URL.Path, bodyBytes, r.Header)
+	}
 	response := model.TxnResponse{Message: model.Message{Ack: model.Ack{Status: model.StatusACK}}}
+	if level == service.PressureSoft {
+		w.Header().Set("Retry-After", retryAfterSeconds(h.pressure.RetryAfter()))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -90,6 +457,111 @@ func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// recordTxn records a request in the transaction metrics. Requests NACKed before their body
+// was parsed, such as those failing signature validation, are parsed here for their segment.
+func (h *gatewayHandler) recordTxn(body []byte, txnReq *model.TxnRequest, acked bool, d time.Duration) {
+	if txnReq.Context.Action == "" && len(body) > 0 {
+		// A body that cannot be parsed is recorded under an empty segment.
+		_ = json.Unmarshal(body, txnReq)
+	}
+	h.txnMetrics.RecordRequest(&txnReq.Context, acked, d)
+}
+
+// writeOverloaded NACKs a request the task queue has no room for, with a Retry-After hint if
+// backpressure is configured.
+func (h *gatewayHandler) writeOverloaded(w http.ResponseWriter) {
+	if h.pressure != nil {
+		w.Header().Set("Retry-After", retryAfterSeconds(h.pressure.RetryAfter()))
+	}
+	writeGatewayError(w, http.StatusServiceUnavailable, string(model.ErrorCodeServiceOverloaded), "Gateway is overloaded, retry later.")
+}
+
+// retryAfterSeconds formats d as a Retry-After header value, rounded up to whole seconds.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// writeSchemaViolation NACKs a request that does not conform to the schema of its action.
+// The error message lists every violation with its JSON path and what the schema expected,
+// and the error path points at the first one, so that NPs can fix the payload themselves.
+func writeSchemaViolation(w http.ResponseWriter, action string, err error) {
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) == 0 {
+		writeGatewayError(w, http.StatusBadRequest, string(model.ErrorCodeBadRequest), err.Error())
+		return
+	}
+	details := make([]string, len(verr.Violations))
+	for i, v := range verr.Violations {
+		details[i] = v.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	errResp := model.TxnResponse{
+		Message: model.Message{
+			Ack: model.Ack{Status: model.StatusNACK},
+			Error: &model.Error{
+				Type:    model.ErrorTypeValidationError,
+				Code:    model.ErrorCodeBadRequest,
+				Path:    verr.Violations[0].Path,
+				Message: fmt.Sprintf("Request does not conform to the schema of action %q: %s", action, strings.Join(details, "; ")),
+			},
+		},
+	}
+	if err := json.NewEncoder(w).Encode(errResp); err != nil {
+		slog.Error("writeSchemaViolation: Failed to encode/write error response", "error", err)
+	}
+}
+
+// writeUnsupportedVersion writes a NACK for a request with an unsupported core version, with the
+// versions supported for its domain and the domain's upgrade guide so the sender can upgrade.
+func writeUnsupportedVersion(w http.ResponseWriter, verr *service.UnsupportedCoreVersionError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	errResp := model.TxnResponse{
+		Message: model.Message{
+			Ack: model.Ack{Status: model.StatusNACK},
+			Error: &model.Error{
+				Type:    model.ErrorTypeValidationError,
+				Code:    model.ErrorCodeUnsupportedVersion,
+				Path:    "context.core_version",
+				Message: verr.Error(),
+				Upgrade: &model.UpgradeHint{
+					SupportedVersions: verr.Supported,
+					MigrationDocURL:   verr.MigrationDocURL,
+				},
+			},
+		},
+	}
+	if err := json.NewEncoder(w).Encode(errResp); err != nil {
+		slog.Error("writeUnsupportedVersion: Failed to encode/write error response", "error", err)
+	}
+}
+
+// decompress returns the request body as signed by its sender, decompressing it according to
+// its Content-Encoding. If the body cannot be decompressed, it NACKs the request and returns false.
+func (h *gatewayHandler) decompress(w http.ResponseWriter, r *http.Request, body []byte) ([]byte, bool) {
+	if h.compression == nil {
+		return body, true
+	}
+	w.Header().Set("Accept-Encoding", "gzip")
+	out, err := h.compression.Decompress(r.Header.Get("Content-Encoding"), body)
+	if err == nil {
+		// The headers are forwarded with the decompressed body.
+		r.Header.Del("Content-Encoding")
+		return out, true
+	}
+	slog.WarnContext(r.Context(), "GatewayHandler: Failed to decompress request body", "content_encoding", r.Header.Get("Content-Encoding"), "error", err)
+	switch {
+	case errors.Is(err, service.ErrUnsupportedEncoding):
+		writeGatewayError(w, http.StatusUnsupportedMediaType, string(model.ErrorCodeUnsupportedEncoding), err.Error())
+	case errors.Is(err, service.ErrPayloadTooLarge):
+		writeGatewayError(w, http.StatusRequestEntityTooLarge, string(model.ErrorCodeBadRequest), err.Error())
+	default:
+		writeGatewayError(w, http.StatusBadRequest, string(model.ErrorCodeBadRequest), err.Error())
+	}
+	return nil, false
+}
+
 func writeGatewayError(w http.ResponseWriter, statusCode int, errorCode string, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -16,23 +16,32 @@ package handler
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/jsonschema"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // mockGatewayAuthValidator is a mock implementation of gatewayAuthValidator.
 type mockGatewayAuthValidator struct {
-	validateErr *model.AuthError
+	validateErr   *model.AuthError
+	gotAuthHeader string
 }
 
 func (m *mockGatewayAuthValidator) Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError {
+	m.gotAuthHeader = authHeader
 	return m.validateErr
 }
 
@@ -40,12 +49,37 @@ func (m *mockGatewayAuthValidator) Validate(ctx context.Context, body []byte, au
 type mockTaskQueuer struct {
 	queueTxnTask *model.AsyncTask
 	queueTxnErr  error
+	queuedMsg    []byte
+	queuedHeader http.Header
 }
 
 func (m *mockTaskQueuer) QueueTxn(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+	m.queuedMsg = msg
+	m.queuedHeader = h
 	return m.queueTxnTask, m.queueTxnErr
 }
 
+// mockCoreVersionPolicy is a mock implementation of coreVersionPolicy.
+type mockCoreVersionPolicy struct {
+	body   []byte
+	err    error
+	matrix *service.CoreVersionConfig
+}
+
+func (m *mockCoreVersionPolicy) Apply(reqCtx *model.Context, body []byte) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.body != nil {
+		return m.body, nil
+	}
+	return body, nil
+}
+
+func (m *mockCoreVersionPolicy) Matrix() *service.CoreVersionConfig {
+	return m.matrix
+}
+
 // failingReader is an io.Reader that always returns an error.
 type failingReader struct{}
 
@@ -302,3 +336,908 @@ func TestServeHttp_EncodeResponseError(t *testing.T) {
 	}
 	// No body can be asserted as the write failed. The error would be logged.
 }
+
+// TestServeHttp_CoreVersion tests enforcement of the core version matrix.
+func TestServeHttp_CoreVersion(t *testing.T) {
+	reqBody := `{"context":{"action":"search","domain":"retail","core_version":"0.9.0"},"message":{}}`
+	tests := []struct {
+		name        string
+		policy      *mockCoreVersionPolicy
+		wantStatus  int
+		wantCode    model.ErrorCode
+		wantUpgrade *model.UpgradeHint
+		wantQueued  string
+	}{
+		{
+			name:       "supported version is forwarded unchanged",
+			policy:     &mockCoreVersionPolicy{},
+			wantStatus: http.StatusOK,
+			wantQueued: reqBody,
+		},
+		{
+			name:       "rewritten body is forwarded",
+			policy:     &mockCoreVersionPolicy{body: []byte(`{"rewritten":true}`)},
+			wantStatus: http.StatusOK,
+			wantQueued: `{"rewritten":true}`,
+		},
+		{
+			name:       "unsupported version is rejected",
+			policy:     &mockCoreVersionPolicy{err: fmt.Errorf("%w: 0.9.0", service.ErrUnsupportedCoreVersion)},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   model.ErrorCodeUnsupportedVersion,
+		},
+		{
+			name: "unsupported version is rejected with upgrade hints",
+			policy: &mockCoreVersionPolicy{err: &service.UnsupportedCoreVersionError{
+				Version:         "0.9.0",
+				Domain:          "retail",
+				Supported:       []string{"1.1.0", "1.2.0"},
+				MigrationDocURL: "https://docs.example.com/retail/upgrade",
+			}},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    model.ErrorCodeUnsupportedVersion,
+			wantUpgrade: &model.UpgradeHint{SupportedVersions: []string{"1.1.0", "1.2.0"}, MigrationDocURL: "https://docs.example.com/retail/upgrade"},
+		},
+		{
+			name:       "rewrite failure",
+			policy:     &mockCoreVersionPolicy{err: errors.New("failed to parse request body")},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+			handler.SetCoreVersionPolicy(tt.policy)
+
+			req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(reqBody))
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var errResp model.TxnResponse
+				_ = json.Unmarshal(rr.Body.Bytes(), &errResp)
+				if errResp.Message.Error == nil || errResp.Message.Error.Code != tt.wantCode {
+					t.Errorf("Error = %+v, want code %q", errResp.Message.Error, tt.wantCode)
+				}
+				if errResp.Message.Error != nil {
+					if diff := cmp.Diff(tt.wantUpgrade, errResp.Message.Error.Upgrade); diff != "" {
+						t.Errorf("Error.Upgrade mismatch (-want +got):\n%s", diff)
+					}
+				}
+				if mockQueuer.queuedMsg != nil {
+					t.Error("QueueTxn was called for a rejected request")
+				}
+				return
+			}
+			if string(mockQueuer.queuedMsg) != tt.wantQueued {
+				t.Errorf("queued body = %s, want %s", mockQueuer.queuedMsg, tt.wantQueued)
+			}
+		})
+	}
+}
+
+// TestCoreVersions tests the core version discovery endpoint.
+// mockQueuePressure is a mock implementation of queuePressure.
+type mockQueuePressure struct {
+	level      service.PressureLevel
+	retryAfter time.Duration
+}
+
+func (m *mockQueuePressure) Level(domain string) service.PressureLevel {
+	return m.level
+}
+
+func (m *mockQueuePressure) RetryAfter() time.Duration {
+	return m.retryAfter
+}
+
+func TestServeHttp_Backpressure(t *testing.T) {
+	tests := []struct {
+		name           string
+		level          service.PressureLevel
+		queueErr       error
+		wantStatus     int
+		wantAck        model.Status
+		wantRetryAfter string
+		wantQueued     bool
+	}{
+		{name: "no pressure", level: service.PressureNone, wantStatus: http.StatusOK, wantAck: model.StatusACK, wantQueued: true},
+		{name: "above soft watermark", level: service.PressureSoft, wantStatus: http.StatusOK, wantAck: model.StatusACK, wantRetryAfter: "3", wantQueued: true},
+		{name: "above hard watermark", level: service.PressureHard, wantStatus: http.StatusServiceUnavailable, wantAck: model.StatusNACK, wantRetryAfter: "3"},
+		{name: "queue full below watermark", level: service.PressureSoft, queueErr: service.ErrTaskQueueFull, wantStatus: http.StatusServiceUnavailable, wantAck: model.StatusNACK, wantRetryAfter: "3", wantQueued: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}, queueTxnErr: tt.queueErr}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+			handler.SetBackpressure(&mockQueuePressure{level: tt.level, retryAfter: 2500 * time.Millisecond})
+
+			req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewBufferString(`{"context":{"action":"search"},"message":{}}`))
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("ServeHttp() Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			var resp model.TxnResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp.Message.Ack.Status != tt.wantAck {
+				t.Errorf("Response Ack Status = %q, want %q", resp.Message.Ack.Status, tt.wantAck)
+			}
+			if queued := mockQueuer.queuedMsg != nil; queued != tt.wantQueued {
+				t.Errorf("QueueTxn called = %v, want %v", queued, tt.wantQueued)
+			}
+		})
+	}
+}
+
+// mockActionValidator is a mock implementation of actionValidator.
+type mockActionValidator struct {
+	err       error
+	gotAction string
+}
+
+func (m *mockActionValidator) Validate(action string, body []byte) error {
+	m.gotAction = action
+	return m.err
+}
+
+func TestServeHttp_ActionValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantAck    model.Status
+		wantQueued bool
+	}{
+		{name: "valid", wantStatus: http.StatusOK, wantAck: model.StatusACK, wantQueued: true},
+		{name: "schema violation", err: service.ErrActionSchemaViolation, wantStatus: http.StatusBadRequest, wantAck: model.StatusNACK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+			validator := &mockActionValidator{err: tt.err}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+			handler.SetActionValidator(validator)
+
+			req := httptest.NewRequest(http.MethodPost, "/issue_status", bytes.NewBufferString(`{"context":{"action":"issue_status"},"message":{}}`))
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if validator.gotAction != "issue_status" {
+				t.Errorf("Validate() action = %q, want %q", validator.gotAction, "issue_status")
+			}
+			var resp model.TxnResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp.Message.Ack.Status != tt.wantAck {
+				t.Errorf("Response Ack Status = %q, want %q", resp.Message.Ack.Status, tt.wantAck)
+			}
+			if tt.err != nil && resp.Message.Error.Code != model.ErrorCodeBadRequest {
+				t.Errorf("Response Error Code = %q, want %q", resp.Message.Error.Code, model.ErrorCodeBadRequest)
+			}
+			if queued := mockQueuer.queuedMsg != nil; queued != tt.wantQueued {
+				t.Errorf("QueueTxn called = %v, want %v", queued, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestServeHttp_ActionValidation_Details(t *testing.T) {
+	verr := &jsonschema.ValidationError{Violations: []jsonschema.Violation{
+		{Path: "$.message.issue_id", Expected: "required property", Got: "missing"},
+		{Path: "$.context.ttl", Expected: "type string", Got: "integer"},
+	}}
+	mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+	handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+	handler.SetActionValidator(&mockActionValidator{err: fmt.Errorf("%w %q: %w", service.ErrActionSchemaViolation, "issue_status", verr)})
+
+	req := httptest.NewRequest(http.MethodPost, "/issue_status", bytes.NewBufferString(`{"context":{"action":"issue_status"},"message":{}}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHttp(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("ServeHttp() status code = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+	var resp model.TxnResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	want := &model.Error{
+		Type:    model.ErrorTypeValidationError,
+		Code:    model.ErrorCodeBadRequest,
+		Path:    "$.message.issue_id",
+		Message: `Request does not conform to the schema of action "issue_status": $.message.issue_id: expected required property, got missing; $.context.ttl: expected type string, got integer`,
+	}
+	if diff := cmp.Diff(want, resp.Message.Error); diff != "" {
+		t.Errorf("Response Error mismatch (-want +got):\n%s", diff)
+	}
+	if mockQueuer.queuedMsg != nil {
+		t.Error("QueueTxn called for a request violating its schema")
+	}
+}
+
+// mockDenylistChecker is a mock implementation of denylistChecker.
+type mockDenylistChecker struct {
+	entry         *model.DenylistEntry
+	gotRemoteAddr string
+	gotAuthHeader string
+}
+
+func (m *mockDenylistChecker) Check(ctx context.Context, remoteAddr, authHeader string) *model.DenylistEntry {
+	m.gotRemoteAddr, m.gotAuthHeader = remoteAddr, authHeader
+	return m.entry
+}
+
+func TestEnforce(t *testing.T) {
+	tests := []struct {
+		name       string
+		denylist   *mockDenylistChecker
+		wantStatus int
+		wantNext   bool
+	}{
+		{name: "no denylist", wantStatus: http.StatusOK, wantNext: true},
+		{name: "allowed", denylist: &mockDenylistChecker{}, wantStatus: http.StatusOK, wantNext: true},
+		{name: "denylisted", denylist: &mockDenylistChecker{entry: &model.DenylistEntry{ID: "e1", Kind: model.DenylistKindIP, Value: "192.0.2.7"}}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+			if tt.denylist != nil {
+				handler.SetDenylist(tt.denylist)
+			}
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			req := httptest.NewRequest(http.MethodPost, "/search", nil)
+			req.RemoteAddr = "192.0.2.7"
+			req.Header.Set(model.AuthHeaderSubscriber, `Signature keyId="np1|k1|ed25519"`)
+			rr := httptest.NewRecorder()
+			handler.Enforce(next).ServeHTTP(rr, req)
+
+			if called != tt.wantNext {
+				t.Errorf("next called = %v, want %v", called, tt.wantNext)
+			}
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Enforce() status code = %v, want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.denylist != nil && (tt.denylist.gotRemoteAddr != "192.0.2.7" || tt.denylist.gotAuthHeader != `Signature keyId="np1|k1|ed25519"`) {
+				t.Errorf("Check() called with %q, %q", tt.denylist.gotRemoteAddr, tt.denylist.gotAuthHeader)
+			}
+			if tt.wantNext {
+				return
+			}
+			var resp model.TxnResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp.Message.Ack.Status != model.StatusNACK || resp.Message.Error.Code != model.ErrorCodeDenylisted {
+				t.Errorf("Response = %+v, want NACK with %q", resp.Message, model.ErrorCodeDenylisted)
+			}
+		})
+	}
+}
+
+// mockIdentity is a mock implementation of identityApplier.
+type mockIdentity struct{}
+
+func (mockIdentity) Apply(h http.Header) {
+	h.Set(model.GatewayIDHeader, "gw.example.com")
+}
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity identityApplier
+		wantID   string
+	}{
+		{name: "no identity"},
+		{name: "identity", identity: mockIdentity{}, wantID: "gw.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+			if tt.identity != nil {
+				handler.SetIdentity(tt.identity)
+			}
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			rr := httptest.NewRecorder()
+			handler.Identify(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if !called {
+				t.Error("next was not called")
+			}
+			if got := rr.Header().Get(model.GatewayIDHeader); got != tt.wantID {
+				t.Errorf("%s = %q, want %q", model.GatewayIDHeader, got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCoreVersions(t *testing.T) {
+	matrix := &service.CoreVersionConfig{
+		Default: []string{"1.1.0"},
+		Domains: map[string][]string{"retail": {"1.1.0", "1.2.0"}},
+	}
+	tests := []struct {
+		name   string
+		policy coreVersionPolicy
+		want   *service.CoreVersionConfig
+	}{
+		{"configured matrix", &mockCoreVersionPolicy{matrix: matrix}, matrix},
+		{"no policy", nil, &service.CoreVersionConfig{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+			if tt.policy != nil {
+				handler.SetCoreVersionPolicy(tt.policy)
+			}
+			rr := httptest.NewRecorder()
+			handler.CoreVersions(rr, httptest.NewRequest(http.MethodGet, "/core-versions", nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("CoreVersions() status code = %v, want %v", rr.Code, http.StatusOK)
+			}
+			got := &service.CoreVersionConfig{}
+			if err := json.Unmarshal(rr.Body.Bytes(), got); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("CoreVersions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// mockSelfTester is a mock implementation of selfTester.
+type mockSelfTester struct {
+	report        *model.SelfTestReport
+	gotBody       string
+	gotAuthHeader string
+}
+
+func (m *mockSelfTester) Run(ctx context.Context, body []byte, authHeader string) *model.SelfTestReport {
+	m.gotBody, m.gotAuthHeader = string(body), authHeader
+	return m.report
+}
+
+func TestSelfTest(t *testing.T) {
+	report := &model.SelfTestReport{
+		SubscriberID: "np1",
+		KeyID:        "key1",
+		GatewayTime:  time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Checks: []model.SelfTestCheck{
+			{Name: model.SelfTestCheckAuthHeader, Status: model.SelfTestStatusPass},
+			{Name: model.SelfTestCheckSignature, Status: model.SelfTestStatusFail, Detail: "signature mismatch"},
+		},
+	}
+	st := &mockSelfTester{report: report}
+	h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+	h.SetSelfTest(st)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"context":{}}`))
+	req.Header.Set(model.AuthHeaderSubscriber, "Signature keyId=\"np1|key1|ed25519\"")
+	rr := httptest.NewRecorder()
+	h.SelfTest(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("SelfTest() status code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	if st.gotBody != `{"context":{}}` || st.gotAuthHeader != "Signature keyId=\"np1|key1|ed25519\"" {
+		t.Errorf("Run() called with (%q, %q)", st.gotBody, st.gotAuthHeader)
+	}
+	var got model.SelfTestReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if diff := cmp.Diff(report, &got); diff != "" {
+		t.Errorf("SelfTest() report mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSelfTest_Disabled(t *testing.T) {
+	h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+
+	rr := httptest.NewRecorder()
+	h.SelfTest(rr, httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{}`)))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("SelfTest() status code = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+// mockClockSkewChecker is a mock implementation of clockSkewChecker.
+type mockClockSkewChecker struct {
+	sigErr        error
+	tsErr         error
+	gotAuthHeader string
+	gotTimestamp  string
+}
+
+func (m *mockClockSkewChecker) CheckSignature(authHeader string) error {
+	m.gotAuthHeader = authHeader
+	return m.sigErr
+}
+
+func (m *mockClockSkewChecker) CheckTimestamp(timestamp string) error {
+	m.gotTimestamp = timestamp
+	return m.tsErr
+}
+
+func TestServeHttp_ClockSkew(t *testing.T) {
+	tests := []struct {
+		name       string
+		sigErr     error
+		tsErr      error
+		wantStatus int
+		wantCode   model.ErrorCode
+		wantQueued bool
+	}{
+		{name: "in sync", wantStatus: http.StatusOK, wantQueued: true},
+		{name: "signature skewed", sigErr: fmt.Errorf("%w: the signature expired", service.ErrClockSkew), wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeClockSkew},
+		{name: "timestamp skewed", tsErr: fmt.Errorf("%w: the context timestamp is behind", service.ErrClockSkew), wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeClockSkew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+			skew := &mockClockSkewChecker{sigErr: tt.sigErr, tsErr: tt.tsErr}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+			handler.SetClockSkew(skew)
+
+			req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(`{"context":{"action":"search","timestamp":"2025-01-01T00:00:00Z"},"message":{}}`))
+			req.Header.Set(model.AuthHeaderSubscriber, "Signature created=\"1735689600\"")
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if skew.gotAuthHeader != "Signature created=\"1735689600\"" {
+				t.Errorf("CheckSignature() authHeader = %q", skew.gotAuthHeader)
+			}
+			if tt.sigErr == nil && skew.gotTimestamp != "2025-01-01T00:00:00Z" {
+				t.Errorf("CheckTimestamp() timestamp = %q, want %q", skew.gotTimestamp, "2025-01-01T00:00:00Z")
+			}
+			var resp model.TxnResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if tt.wantCode != "" {
+				if resp.Message.Error == nil || resp.Message.Error.Code != tt.wantCode {
+					t.Errorf("Response Error = %+v, want code %q", resp.Message.Error, tt.wantCode)
+				}
+			}
+			if queued := mockQueuer.queuedMsg != nil; queued != tt.wantQueued {
+				t.Errorf("QueueTxn called = %v, want %v", queued, tt.wantQueued)
+			}
+		})
+	}
+}
+
+// mockTxnRecorder is a mock implementation of txnRecorder.
+type mockTxnRecorder struct {
+	summary *model.TxnSummary
+	gotCtx  *model.Context
+	gotAck  bool
+	calls   int
+}
+
+func (m *mockTxnRecorder) RecordRequest(c *model.Context, acked bool, d time.Duration) {
+	m.gotCtx, m.gotAck = c, acked
+	m.calls++
+}
+
+func (m *mockTxnRecorder) Summary() *model.TxnSummary {
+	return m.summary
+}
+
+func TestServeHttp_TxnMetrics(t *testing.T) {
+	body := `{"context":{"action":"search","domain":"ONDC:RET10","location":{"city":{"code":"std:080"}}},"message":{}}`
+	tests := []struct {
+		name     string
+		body     string
+		authErr  *model.AuthError
+		queueErr error
+		wantAck  bool
+		wantCtx  model.Context
+	}{
+		{name: "acked", body: body, wantAck: true, wantCtx: model.Context{Action: "search", Domain: "ONDC:RET10", Location: &model.Location{City: &model.City{Code: "std:080"}}}},
+		{name: "nacked after parsing", body: body, queueErr: errors.New("queue full"), wantCtx: model.Context{Action: "search", Domain: "ONDC:RET10", Location: &model.Location{City: &model.City{Code: "std:080"}}}},
+		{name: "nacked before parsing", body: body, authErr: model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid signature.", "np1"), wantCtx: model.Context{Action: "search", Domain: "ONDC:RET10", Location: &model.Location{City: &model.City{Code: "std:080"}}}},
+		{name: "invalid body", body: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}, queueTxnErr: tt.queueErr}
+			rec := &mockTxnRecorder{}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{validateErr: tt.authErr}, mockQueuer)
+			handler.SetTxnMetrics(rec)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(tt.body)))
+
+			if rec.calls != 1 {
+				t.Fatalf("RecordRequest() called %d times, want 1", rec.calls)
+			}
+			if rec.gotAck != tt.wantAck {
+				t.Errorf("RecordRequest() acked = %v, want %v", rec.gotAck, tt.wantAck)
+			}
+			if diff := cmp.Diff(&tt.wantCtx, rec.gotCtx); diff != "" {
+				t.Errorf("RecordRequest() context mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTxnMetrics(t *testing.T) {
+	summary := &model.TxnSummary{
+		Since: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Segments: []model.TxnSegmentStats{{
+			TxnSegment:    model.TxnSegment{Action: "search", Domain: "ONDC:RET10", City: "std:080"},
+			Requests:      3,
+			Nacks:         1,
+			Fanouts:       4,
+			FanoutLatency: model.LatencySummary{AvgMS: 42.5, P50MS: 50, P95MS: 100, MaxMS: 80},
+		}},
+	}
+	h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+	h.SetTxnMetrics(&mockTxnRecorder{summary: summary})
+
+	rr := httptest.NewRecorder()
+	h.TxnMetrics(rr, httptest.NewRequest(http.MethodGet, "/metrics/transactions", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("TxnMetrics() status code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	var got model.TxnSummary
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if diff := cmp.Diff(summary, &got); diff != "" {
+		t.Errorf("TxnMetrics() summary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTxnMetrics_Disabled(t *testing.T) {
+	h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+
+	rr := httptest.NewRecorder()
+	h.TxnMetrics(rr, httptest.NewRequest(http.MethodGet, "/metrics/transactions", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("TxnMetrics() status code = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+type mockDeliveryReporter struct {
+	report *model.DeliveryStatsReport
+	err    error
+}
+
+func (m *mockDeliveryReporter) Report(ctx context.Context) (*model.DeliveryStatsReport, error) {
+	return m.report, m.err
+}
+
+func TestDeliveryStats(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	bpp1 := model.TargetDeliveryStats{Target: "https://bpp1.example.com/beckn", Windows: []model.DeliveryCounts{{Window: "1h0m0s", Success: 3, Error: 1, SuccessRate: 0.75}}}
+	bpp2 := model.TargetDeliveryStats{Target: "https://bpp2.example.com/beckn", Windows: []model.DeliveryCounts{{Window: "1h0m0s", Timeout: 2}}}
+	tests := []struct {
+		name string
+		path string
+		want *model.DeliveryStatsReport
+	}{
+		{
+			name: "all targets",
+			path: "/metrics/targets",
+			want: &model.DeliveryStatsReport{At: at, Targets: []model.TargetDeliveryStats{bpp1, bpp2}},
+		},
+		{
+			name: "one target",
+			path: "/metrics/targets?target=https://bpp2.example.com/beckn",
+			want: &model.DeliveryStatsReport{At: at, Targets: []model.TargetDeliveryStats{bpp2}},
+		},
+		{
+			name: "unknown target",
+			path: "/metrics/targets?target=https://bpp3.example.com/beckn",
+			want: &model.DeliveryStatsReport{At: at, Targets: []model.TargetDeliveryStats{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+			h.SetDeliveryStats(&mockDeliveryReporter{report: &model.DeliveryStatsReport{At: at, Targets: []model.TargetDeliveryStats{bpp1, bpp2}}})
+
+			rr := httptest.NewRecorder()
+			h.DeliveryStats(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("DeliveryStats() status code = %v, want %v", rr.Code, http.StatusOK)
+			}
+			var got model.DeliveryStatsReport
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, &got); diff != "" {
+				t.Errorf("DeliveryStats() report mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeliveryStats_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		reporter deliveryReporter
+		want     int
+	}{
+		{name: "disabled", want: http.StatusNotFound},
+		{name: "report fails", reporter: &mockDeliveryReporter{err: errors.New("redis down")}, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, &mockTaskQueuer{})
+			if tt.reporter != nil {
+				h.SetDeliveryStats(tt.reporter)
+			}
+
+			rr := httptest.NewRecorder()
+			h.DeliveryStats(rr, httptest.NewRequest(http.MethodGet, "/metrics/targets", nil))
+
+			if rr.Code != tt.want {
+				t.Errorf("DeliveryStats() status code = %v, want %v", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+type mockShadowMirror struct {
+	calls   int
+	gotPath string
+	gotBody []byte
+}
+
+func (m *mockShadowMirror) Mirror(path string, body []byte, h http.Header) {
+	m.calls++
+	m.gotPath, m.gotBody = path, body
+}
+
+func TestServeHttp_Shadow(t *testing.T) {
+	body := `{"context":{"action":"search"},"message":{}}`
+	tests := []struct {
+		name      string
+		authErr   *model.AuthError
+		queueErr  error
+		wantCalls int
+	}{
+		{name: "acked request mirrored", wantCalls: 1},
+		{name: "invalid signature not mirrored", authErr: model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid signature.", "np1")},
+		{name: "queueing failure not mirrored", queueErr: errors.New("queue full")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}, queueTxnErr: tt.queueErr}
+			shadow := &mockShadowMirror{}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{validateErr: tt.authErr}, mockQueuer)
+			handler.SetShadow(shadow)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(body)))
+
+			if shadow.calls != tt.wantCalls {
+				t.Fatalf("Mirror() called %d times, want %d", shadow.calls, tt.wantCalls)
+			}
+			if tt.wantCalls == 0 {
+				return
+			}
+			if shadow.gotPath != "/search" {
+				t.Errorf("Mirror() path = %q, want /search", shadow.gotPath)
+			}
+			if string(shadow.gotBody) != body {
+				t.Errorf("Mirror() body = %s, want %s", shadow.gotBody, body)
+			}
+		})
+	}
+}
+
+type mockTargetChecker struct {
+	err      error
+	gotBppID string
+}
+
+func (m *mockTargetChecker) Check(ctx context.Context, reqCtx *model.Context) error {
+	m.gotBppID = reqCtx.BppID
+	return m.err
+}
+
+func TestServeHttp_TargetStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    model.ErrorCode
+		wantMessage string
+		wantQueued  bool
+	}{
+		{name: "subscribed target", wantStatus: http.StatusOK, wantQueued: true},
+		{name: "suspended target", err: fmt.Errorf("%w: bpp1", service.ErrTargetSuspended), wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeSubscriberInactive, wantMessage: "Subscriber bpp1 is suspended."},
+		{name: "expired target", err: fmt.Errorf("%w: bpp1", service.ErrTargetExpired), wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeSubscriberInactive, wantMessage: "Subscriber bpp1 is expired."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+			target := &mockTargetChecker{err: tt.err}
+			handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+			handler.SetTargetStatus(target)
+
+			req := httptest.NewRequest(http.MethodPost, "/select", bytes.NewBufferString(`{"context":{"action":"select","bpp_id":"bpp1"},"message":{}}`))
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if target.gotBppID != "bpp1" {
+				t.Errorf("Check() bpp_id = %q, want bpp1", target.gotBppID)
+			}
+			if queued := mockQueuer.queuedMsg != nil; queued != tt.wantQueued {
+				t.Errorf("QueueTxn called = %v, want %v", queued, tt.wantQueued)
+			}
+			if tt.wantQueued {
+				return
+			}
+			var resp model.TxnResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp.Message.Ack.Status != model.StatusNACK {
+				t.Errorf("Response Ack Status = %q, want %q", resp.Message.Ack.Status, model.StatusNACK)
+			}
+			if resp.Message.Error.Code != tt.wantCode || resp.Message.Error.Message != tt.wantMessage {
+				t.Errorf("Response error = %s %q, want %s %q", resp.Message.Error.Code, resp.Message.Error.Message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestServeHttp_Compression(t *testing.T) {
+	reqBody := `{"context":{"action":"search"},"message":{}}`
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(reqBody))
+	zw.Close()
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "gzip body", encoding: "gzip", body: gz.Bytes(), wantStatus: http.StatusOK},
+		{name: "uncompressed body", body: []byte(reqBody), wantStatus: http.StatusOK},
+		{name: "unsupported encoding", encoding: "br", body: []byte(reqBody), wantStatus: http.StatusUnsupportedMediaType, wantCode: model.ErrorCodeUnsupportedEncoding},
+		{name: "corrupt gzip body", encoding: "gzip", body: []byte(reqBody), wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+			h, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, queuer)
+			c, err := service.NewPayloadCompression(&service.PayloadCompressionConfig{})
+			if err != nil {
+				t.Fatalf("NewPayloadCompression() unexpected error: %v", err)
+			}
+			h.SetCompression(c)
+
+			req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("ServeHttp() status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if got := rr.Header().Get("Accept-Encoding"); got != "gzip" {
+				t.Errorf("ServeHttp() Accept-Encoding = %q, want %q", got, "gzip")
+			}
+			if tt.wantCode != "" {
+				var resp model.TxnResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("Failed to unmarshal response body: %v", err)
+				}
+				if resp.Message.Error == nil || resp.Message.Error.Code != tt.wantCode {
+					t.Errorf("ServeHttp() error = %+v, want code %s", resp.Message.Error, tt.wantCode)
+				}
+				return
+			}
+			if string(queuer.queuedMsg) != reqBody {
+				t.Errorf("queued body = %q, want the decompressed body %q", queuer.queuedMsg, reqBody)
+			}
+			if got := queuer.queuedHeader.Get("Content-Encoding"); got != "" {
+				t.Errorf("queued Content-Encoding = %q, want it removed", got)
+			}
+		})
+	}
+}
+
+// mockAuthSchemeParser is a mock implementation of authSchemeParser.
+type mockAuthSchemeParser struct {
+	auth string
+	err  error
+}
+
+func (m *mockAuthSchemeParser) SubscriberAuth(h http.Header) (string, error) {
+	return m.auth, m.err
+}
+
+func TestServeHttp_AuthSchemes(t *testing.T) {
+	tests := []struct {
+		name       string
+		parser     *mockAuthSchemeParser
+		wantStatus int
+		wantAuth   string
+	}{
+		{name: "no schemes", wantStatus: http.StatusOK, wantAuth: "Signature raw"},
+		{name: "parsed", parser: &mockAuthSchemeParser{auth: "Signature parsed"}, wantStatus: http.StatusOK, wantAuth: "Signature parsed"},
+		{name: "not accepted", parser: &mockAuthSchemeParser{err: service.ErrAuthSchemeNotAccepted}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &mockGatewayAuthValidator{}
+			mockQueuer := &mockTaskQueuer{queueTxnTask: &model.AsyncTask{Type: model.AsyncTaskTypeProxy}}
+			handler, _ := NewGatewayHandler(validator, mockQueuer)
+			if tt.parser != nil {
+				handler.SetAuthSchemes(tt.parser)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(`{"context":{"action":"search"},"message":{}}`))
+			req.Header.Set(model.AuthHeaderSubscriber, "Signature raw")
+			rr := httptest.NewRecorder()
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if validator.gotAuthHeader != tt.wantAuth {
+				t.Errorf("Validate() authHeader = %q, want %q", validator.gotAuthHeader, tt.wantAuth)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				var resp model.TxnResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("Failed to unmarshal response body: %v", err)
+				}
+				if resp.Message.Error == nil || resp.Message.Error.Code != model.ErrorCodeInvalidAuthHeader {
+					t.Errorf("Response Error = %+v, want code %q", resp.Message.Error, model.ErrorCodeInvalidAuthHeader)
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package gateway
 
 import (
+	"expvar"
 	"fmt"
 	"net/http"
 
@@ -25,26 +26,50 @@ import (
 // gatewayHandler defines the interface for handling gateway requests.
 type gatewayHandler interface {
 	ServeHttp(w http.ResponseWriter, r *http.Request)
+	CoreVersions(w http.ResponseWriter, r *http.Request)
+	Enforce(next http.Handler) http.Handler
+	Identify(next http.Handler) http.Handler
+	SelfTest(w http.ResponseWriter, r *http.Request)
+	TxnMetrics(w http.ResponseWriter, r *http.Request)
+	DeliveryStats(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRouter configures and returns the Chi router for the Registry service.
-func NewRouter(gh gatewayHandler) *chi.Mux {
+// actions lists the custom Beckn actions enabled through config, served alongside search and on_search.
+func NewRouter(gh gatewayHandler, actions ...string) *chi.Mux {
 	router := chi.NewRouter()
 
 	// Standard middleware stack
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Logger) // Chi's structured logger
 	router.Use(middleware.Recoverer)
-	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	// Health checks are answered with the gateway's identity headers.
+	router.With(gh.Identify).Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, `{"status":"ok"}`)
 	})
+	// Runtime and denylist metrics published via expvar.
+	router.Handle("/debug/vars", expvar.Handler())
+	// Discovery endpoint for the supported core version matrix.
+	router.Get("/core-versions", gh.CoreVersions)
+	// Counts and latencies of transactions per (action, domain, city) segment.
+	router.Get("/metrics/transactions", gh.TxnMetrics)
+	// Rolling counts of delivery outcomes per target subscriber.
+	router.Get("/metrics/targets", gh.DeliveryStats)
 
 	// Beckn specific routes
-	// Group for routes that might share common Beckn-specific middleware or prefixes
-	router.Post("/search", gh.ServeHttp)
-	router.Post("/on_search", gh.ServeHttp)
+	// Requests from denylisted subscribers and IPs are dropped before their signature is validated.
+	router.Group(func(r chi.Router) {
+		r.Use(gh.Enforce)
+		r.Post("/search", gh.ServeHttp)
+		r.Post("/on_search", gh.ServeHttp)
+		for _, action := range actions {
+			r.Post("/"+action, gh.ServeHttp)
+		}
+		// Connectivity self-test for network participants, answered with the gateway's identity.
+		r.With(gh.Identify).Post("/echo", gh.SelfTest)
+	})
 
 	return router
 }
@@ -24,7 +24,12 @@ import (
 
 // mockGatewayHandler is a mock implementation of the gatewayHandler interface.
 type mockGatewayHandler struct {
-	serveHttpCalled bool
+	serveHttpCalled    bool
+	coreVersionsCalled bool
+	selfTestCalled     bool
+	txnMetricsCalled   bool
+	deliveryCalled     bool
+	deny               bool
 }
 
 func (m *mockGatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +37,43 @@ func (m *mockGatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockGatewayHandler) CoreVersions(w http.ResponseWriter, r *http.Request) {
+	m.coreVersionsCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockGatewayHandler) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.deny {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *mockGatewayHandler) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Gateway-Id", "gw.example.com")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *mockGatewayHandler) SelfTest(w http.ResponseWriter, r *http.Request) {
+	m.selfTestCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockGatewayHandler) TxnMetrics(w http.ResponseWriter, r *http.Request) {
+	m.txnMetricsCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockGatewayHandler) DeliveryStats(w http.ResponseWriter, r *http.Request) {
+	m.deliveryCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 func TestNewRouter(t *testing.T) {
 	gh := &mockGatewayHandler{}
 	router := NewRouter(gh)
@@ -82,7 +124,7 @@ func TestRouter_Routes(t *testing.T) {
 			path:            "/health",
 			expectedStatus:  http.StatusOK,
 			expectedBody:    `{"status":"ok"}`,
-			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}, "X-Gateway-Id": []string{"gw.example.com"}},
 			handlerCheck: func(t *testing.T, h *mockGatewayHandler) {
 				if h.serveHttpCalled {
 					t.Error("ServeHttp was called for /health, but should not have been")
@@ -111,6 +153,51 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "CoreVersions",
+			method:         http.MethodGet,
+			path:           "/core-versions",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockGatewayHandler) {
+				if !h.coreVersionsCalled {
+					t.Error("CoreVersions was not called for /core-versions")
+				}
+			},
+		},
+		{
+			name:           "TxnMetrics",
+			method:         http.MethodGet,
+			path:           "/metrics/transactions",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockGatewayHandler) {
+				if !h.txnMetricsCalled {
+					t.Error("TxnMetrics was not called for /metrics/transactions")
+				}
+			},
+		},
+		{
+			name:           "DeliveryStats",
+			method:         http.MethodGet,
+			path:           "/metrics/targets",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockGatewayHandler) {
+				if !h.deliveryCalled {
+					t.Error("DeliveryStats was not called for /metrics/targets")
+				}
+			},
+		},
+		{
+			name:            "SelfTest",
+			method:          http.MethodPost,
+			path:            "/echo",
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: http.Header{"X-Gateway-Id": []string{"gw.example.com"}},
+			handlerCheck: func(t *testing.T, h *mockGatewayHandler) {
+				if !h.selfTestCalled {
+					t.Error("SelfTest was not called for /echo")
+				}
+			},
+		},
 		{
 			name:           "NotFound",
 			method:         http.MethodGet,
@@ -128,7 +215,7 @@ func TestRouter_Routes(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Reset mock handler state for each test
-			gh.serveHttpCalled = false
+			gh.serveHttpCalled, gh.coreVersionsCalled, gh.selfTestCalled = false, false, false
 
 			req := httptest.NewRequest(tc.method, tc.path, nil)
 			rr := httptest.NewRecorder()
@@ -154,4 +241,46 @@ func TestRouter_Routes(t *testing.T) {
 			tc.handlerCheck(t, gh)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestRouter_CustomActions(t *testing.T) {
+	gh := &mockGatewayHandler{}
+	router := NewRouter(gh, "issue_status", "support")
+
+	for _, path := range []string{"/issue_status", "/support"} {
+		gh.serveHttpCalled = false
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		if rr.Code != http.StatusOK || !gh.serveHttpCalled {
+			t.Errorf("POST %s: status = %v, ServeHttp called = %v, want %v, true", path, rr.Code, gh.serveHttpCalled, http.StatusOK)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/issue", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("POST /issue: status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRouter_Denylist(t *testing.T) {
+	gh := &mockGatewayHandler{deny: true}
+	router := NewRouter(gh, "issue_status")
+
+	for _, path := range []string{"/search", "/on_search", "/issue_status", "/echo"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("POST %s: status = %v, want %v", path, rr.Code, http.StatusForbidden)
+		}
+	}
+	if gh.serveHttpCalled || gh.selfTestCalled {
+		t.Error("handler called for a denylisted request")
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET /health: status = %v, want %v", rr.Code, http.StatusOK)
+	}
+}
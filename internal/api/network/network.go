@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package network serves several Beckn networks, such as a sandbox and production, from one
+// deployment by routing each request to the handler of the network profile it selects.
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	// Header is the request header that selects the network profile of a request.
+	// Responses of a selected profile carry it too.
+	Header = "X-Onix-Network"
+	// PathPrefix, followed by the name of a network profile, selects the profile of a request
+	// for clients that cannot set headers. It is stripped before the request is routed.
+	PathPrefix = "/networks/"
+)
+
+// Router routes requests to the handler of the network profile they select with the
+// X-Onix-Network header or the /networks/<name> path prefix. Requests that select no
+// profile, or the default network by its name, are served by the default handler.
+type Router struct {
+	defaultName string
+	def         http.Handler
+	profiles    map[string]http.Handler
+}
+
+// NewRouter creates a Router serving the default network with def and each network profile
+// with its handler in profiles. defaultName is the name of the default network, if it has one.
+func NewRouter(defaultName string, def http.Handler, profiles map[string]http.Handler) (*Router, error) {
+	if def == nil {
+		slog.Error("NewRouter: default handler cannot be nil")
+		return nil, errors.New("default handler cannot be nil")
+	}
+	for name, h := range profiles {
+		if name == "" || name == defaultName || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid network profile name %q: it must be non-empty, without '/' and differ from the default network", name)
+		}
+		if h == nil {
+			return nil, fmt.Errorf("handler of network profile %s cannot be nil", name)
+		}
+	}
+	return &Router{defaultName: defaultName, def: def, profiles: profiles}, nil
+}
+
+// ServeHTTP routes r to the handler of the network profile it selects.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.Header.Get(Header)
+	rest, prefixed := strings.CutPrefix(r.URL.Path, PathPrefix)
+	if prefixed {
+		pathName, _, _ := strings.Cut(rest, "/")
+		if pathName == "" {
+			writeError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeNetworkNotFound,
+				"The path does not name a network after "+PathPrefix+".")
+			return
+		}
+		if name != "" && name != pathName {
+			writeError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest,
+				fmt.Sprintf("The %s header %q does not match the network %q of the path.", Header, name, pathName))
+			return
+		}
+		name = pathName
+	}
+	h := rt.def
+	if name != "" && name != rt.defaultName {
+		var ok bool
+		if h, ok = rt.profiles[name]; !ok {
+			writeError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeNetworkNotFound,
+				fmt.Sprintf("Network %s is not served here.", name))
+			return
+		}
+	}
+	if prefixed {
+		h = http.StripPrefix(PathPrefix+name, h)
+	}
+	if name != "" {
+		w.Header().Set(Header, name)
+	}
+	h.ServeHTTP(w, r)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, errType model.ErrorType, code model.ErrorCode, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	resp := model.ErrorResponse{Error: model.Error{Type: errType, Code: code, Message: msg}}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("NetworkRouter: Failed to encode error response", "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// echoHandler answers with its name and the path it received.
+func echoHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name+" "+r.URL.Path)
+	})
+}
+
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+	rt, err := NewRouter("production", echoHandler("default"), map[string]http.Handler{"sandbox": echoHandler("sandbox")})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	return rt
+}
+
+func TestRouter_Success(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantBody   string
+		wantHeader string
+	}{
+		{name: "no selection", path: "/lookup", wantBody: "default /lookup"},
+		{name: "header", path: "/lookup", header: "sandbox", wantBody: "sandbox /lookup", wantHeader: "sandbox"},
+		{name: "path prefix", path: "/networks/sandbox/operations/op-1", wantBody: "sandbox /operations/op-1", wantHeader: "sandbox"},
+		{name: "path prefix and matching header", path: "/networks/sandbox/lookup", header: "sandbox", wantBody: "sandbox /lookup", wantHeader: "sandbox"},
+		{name: "default by name", path: "/lookup", header: "production", wantBody: "default /lookup", wantHeader: "production"},
+		{name: "default by path prefix", path: "/networks/production/lookup", wantBody: "default /lookup", wantHeader: "production"},
+	}
+	rt := newTestRouter(t)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set(Header, tc.header)
+			}
+			rr := httptest.NewRecorder()
+			rt.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("ServeHTTP() status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if got := rr.Body.String(); got != tc.wantBody {
+				t.Errorf("ServeHTTP() body = %q, want %q", got, tc.wantBody)
+			}
+			if got := rr.Header().Get(Header); got != tc.wantHeader {
+				t.Errorf("ServeHTTP() %s header = %q, want %q", Header, got, tc.wantHeader)
+			}
+		})
+	}
+}
+
+func TestRouter_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "unknown header", path: "/lookup", header: "staging", wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeNetworkNotFound},
+		{name: "unknown path prefix", path: "/networks/staging/lookup", wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeNetworkNotFound},
+		{name: "empty path prefix", path: "/networks/", wantStatus: http.StatusNotFound, wantCode: model.ErrorCodeNetworkNotFound},
+		{name: "header and path prefix differ", path: "/networks/sandbox/lookup", header: "production", wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeBadRequest},
+	}
+	rt := newTestRouter(t)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set(Header, tc.header)
+			}
+			rr := httptest.NewRecorder()
+			rt.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v. Body: %s", err, rr.Body.String())
+			}
+			if got.Error.Code != tc.wantCode {
+				t.Errorf("ServeHTTP() error code = %s, want %s", got.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestNewRouter_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      http.Handler
+		profiles map[string]http.Handler
+		wantErr  string
+	}{
+		{name: "nil default", wantErr: "default handler cannot be nil"},
+		{name: "empty name", def: echoHandler("default"), profiles: map[string]http.Handler{"": echoHandler("x")}, wantErr: "invalid network profile name"},
+		{name: "name of default", def: echoHandler("default"), profiles: map[string]http.Handler{"production": echoHandler("x")}, wantErr: "invalid network profile name"},
+		{name: "name with slash", def: echoHandler("default"), profiles: map[string]http.Handler{"a/b": echoHandler("x")}, wantErr: "invalid network profile name"},
+		{name: "nil profile handler", def: echoHandler("default"), profiles: map[string]http.Handler{"sandbox": nil}, wantErr: "cannot be nil"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRouter("production", tc.def, tc.profiles)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("NewRouter() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// apiKeyService defines the operations available to subscribers authenticated with an API key.
+type apiKeyService interface {
+	Authenticate(ctx context.Context, key string) (string, error)
+	Subscriptions(ctx context.Context, subscriberID string) ([]model.Subscription, error)
+	Operations(ctx context.Context, subscriberID string, limit int) ([]model.LRO, error)
+	Operation(ctx context.Context, subscriberID, operationID string) (*model.LRO, error)
+}
+
+// subscriberIDKey is the context key under which the authenticated subscriber ID is stored.
+type subscriberIDKey struct{}
+
+// APIKeyHandler serves read-only requests of subscribers that authenticate with an API key
+// instead of signing the request.
+type APIKeyHandler struct {
+	srv apiKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(srv apiKeyService) (*APIKeyHandler, error) {
+	if srv == nil {
+		slog.Error("NewAPIKeyHandler: apiKeyService dependency is nil.")
+		return nil, errors.New("apiKeyService dependency is nil")
+	}
+	return &APIKeyHandler{srv: srv}, nil
+}
+
+// writeAPIKeyError writes an error response. Unlike writeJSONError, it does not ask for a
+// Beckn signature on 401, as these endpoints authenticate with an API key.
+func writeAPIKeyError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(model.ErrorResponse{Error: model.Error{Type: errType, Code: errCode, Message: errMsg}}); err != nil {
+		slog.Error("APIKeyHandler: Failed to encode error response", "error", err)
+	}
+}
+
+func writeAPIKeyJSON(ctx context.Context, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.ErrorContext(ctx, "APIKeyHandler: Failed to encode response", "error", err)
+	}
+}
+
+// Authenticate is a middleware that resolves the API key of a request to its subscriber.
+func (h *APIKeyHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		key := r.Header.Get(model.APIKeyHeader)
+		if key == "" {
+			writeAPIKeyError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, "Missing "+model.APIKeyHeader+" header.")
+			return
+		}
+		subscriberID, err := h.srv.Authenticate(ctx, key)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				writeAPIKeyError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Invalid or revoked API key.")
+				return
+			}
+			slog.ErrorContext(ctx, "APIKeyHandler: Failed to authenticate API key", "error", err)
+			writeInternalError(w, err, "Failed to authenticate API key due to an internal error.")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, subscriberIDKey{}, subscriberID)))
+	})
+}
+
+// Subscriptions handles GET /me/subscriptions, returning the subscriptions of the authenticated subscriber.
+func (h *APIKeyHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID, _ := ctx.Value(subscriberIDKey{}).(string)
+	subs, err := h.srv.Subscriptions(ctx, subscriberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "APIKeyHandler: Failed to get subscriptions", "subscriber_id", subscriberID, "error", err)
+		writeInternalError(w, err, "Failed to retrieve subscriptions due to an internal error.")
+		return
+	}
+	writeAPIKeyJSON(ctx, w, subs)
+}
+
+// Operations handles GET /me/operations, returning the latest operations of the authenticated subscriber.
+// The optional limit query parameter sets the number of operations returned.
+func (h *APIKeyHandler) Operations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID, _ := ctx.Value(subscriberIDKey{}).(string)
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			writeAPIKeyError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "limit must be an integer.")
+			return
+		}
+	}
+	lros, err := h.srv.Operations(ctx, subscriberID, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "APIKeyHandler: Failed to get operations", "subscriber_id", subscriberID, "error", err)
+		writeInternalError(w, err, "Failed to retrieve operations due to an internal error.")
+		return
+	}
+	writeAPIKeyJSON(ctx, w, model.NewOperations(lros))
+}
+
+// Operation handles GET /me/operations/{operation_id}, returning an operation of the authenticated subscriber.
+func (h *APIKeyHandler) Operation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID, _ := ctx.Value(subscriberIDKey{}).(string)
+	operationID := chi.URLParam(r, "operation_id")
+	lro, err := h.srv.Operation(ctx, subscriberID, operationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOperationNotFound) {
+			writeAPIKeyError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, "Operation with id "+operationID+" not found.")
+			return
+		}
+		slog.ErrorContext(ctx, "APIKeyHandler: Failed to get operation", "operation_id", operationID, "error", err)
+		writeInternalError(w, err, "Failed to retrieve operation due to an internal error.")
+		return
+	}
+	writeAPIKeyJSON(ctx, w, model.NewOperation(lro))
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockAPIKeyService is a mock implementation of the apiKeyService interface.
+type mockAPIKeyService struct {
+	subscriberID string
+	authErr      error
+	subs         []model.Subscription
+	lros         []model.LRO
+	lro          *model.LRO
+	err          error
+
+	gotSubscriberID string
+	gotLimit        int
+}
+
+func (m *mockAPIKeyService) Authenticate(ctx context.Context, key string) (string, error) {
+	return m.subscriberID, m.authErr
+}
+
+func (m *mockAPIKeyService) Subscriptions(ctx context.Context, subscriberID string) ([]model.Subscription, error) {
+	m.gotSubscriberID = subscriberID
+	return m.subs, m.err
+}
+
+func (m *mockAPIKeyService) Operations(ctx context.Context, subscriberID string, limit int) ([]model.LRO, error) {
+	m.gotSubscriberID, m.gotLimit = subscriberID, limit
+	return m.lros, m.err
+}
+
+func (m *mockAPIKeyService) Operation(ctx context.Context, subscriberID, operationID string) (*model.LRO, error) {
+	m.gotSubscriberID = subscriberID
+	return m.lro, m.err
+}
+
+// newAPIKeyTestRouter mounts the handler the same way the registry router does.
+func newAPIKeyTestRouter(h *APIKeyHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Route("/me", func(r chi.Router) {
+		r.Use(h.Authenticate)
+		r.Get("/subscriptions", h.Subscriptions)
+		r.Get("/operations", h.Operations)
+		r.Get("/operations/{operation_id}", h.Operation)
+	})
+	return r
+}
+
+func TestNewAPIKeyHandler(t *testing.T) {
+	if _, err := NewAPIKeyHandler(&mockAPIKeyService{}); err != nil {
+		t.Errorf("NewAPIKeyHandler() error = %v, want nil", err)
+	}
+	if _, err := NewAPIKeyHandler(nil); err == nil || err.Error() != "apiKeyService dependency is nil" {
+		t.Errorf("NewAPIKeyHandler(nil) error = %v, want apiKeyService dependency is nil", err)
+	}
+}
+
+func TestAPIKeyHandler_Authenticate_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		authErr    error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "missing header",
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:       "invalid key",
+			key:        "bad-key",
+			authErr:    service.ErrInvalidAPIKey,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:       "repository error",
+			key:        "key",
+			authErr:    errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAPIKeyHandler(&mockAPIKeyService{authErr: tc.authErr})
+			req := httptest.NewRequest(http.MethodGet, "/me/subscriptions", nil)
+			if tc.key != "" {
+				req.Header.Set(model.APIKeyHeader, tc.key)
+			}
+			rr := httptest.NewRecorder()
+			newAPIKeyTestRouter(h).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if rr.Header().Get("WWW-Authenticate") != "" {
+				t.Errorf("WWW-Authenticate = %q, want empty", rr.Header().Get("WWW-Authenticate"))
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", got.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_Subscriptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		srv        *mockAPIKeyService
+		wantStatus int
+		wantBody   []model.Subscription
+	}{
+		{
+			name: "success",
+			srv: &mockAPIKeyService{
+				subscriberID: "sub1",
+				subs:         []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub1"}, KeyID: "k1"}},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub1"}, KeyID: "k1"}},
+		},
+		{
+			name:       "service error",
+			srv:        &mockAPIKeyService{subscriberID: "sub1", err: errors.New("db down")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAPIKeyHandler(tc.srv)
+			req := httptest.NewRequest(http.MethodGet, "/me/subscriptions", nil)
+			req.Header.Set(model.APIKeyHeader, "key")
+			rr := httptest.NewRecorder()
+			newAPIKeyTestRouter(h).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.srv.gotSubscriberID != "sub1" {
+				t.Errorf("service called with subscriber %q, want %q", tc.srv.gotSubscriberID, "sub1")
+			}
+			if tc.wantBody == nil {
+				return
+			}
+			var got []model.Subscription
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantBody, got); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_Operations(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		srv        *mockAPIKeyService
+		wantStatus int
+		wantLimit  int
+	}{
+		{
+			name:       "default limit",
+			srv:        &mockAPIKeyService{subscriberID: "sub1", lros: []model.LRO{{OperationID: "op1"}}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "explicit limit",
+			query:      "?limit=5",
+			srv:        &mockAPIKeyService{subscriberID: "sub1"},
+			wantStatus: http.StatusOK,
+			wantLimit:  5,
+		},
+		{
+			name:       "invalid limit",
+			query:      "?limit=abc",
+			srv:        &mockAPIKeyService{subscriberID: "sub1"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "service error",
+			srv:        &mockAPIKeyService{subscriberID: "sub1", err: errors.New("db down")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAPIKeyHandler(tc.srv)
+			req := httptest.NewRequest(http.MethodGet, "/me/operations"+tc.query, nil)
+			req.Header.Set(model.APIKeyHeader, "key")
+			rr := httptest.NewRecorder()
+			newAPIKeyTestRouter(h).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusOK && tc.srv.gotLimit != tc.wantLimit {
+				t.Errorf("service called with limit %d, want %d", tc.srv.gotLimit, tc.wantLimit)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_Operation(t *testing.T) {
+	tests := []struct {
+		name       string
+		srv        *mockAPIKeyService
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "success",
+			srv:        &mockAPIKeyService{subscriberID: "sub1", lro: &model.LRO{OperationID: "op1"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "not found",
+			srv:        &mockAPIKeyService{subscriberID: "sub1", err: repository.ErrOperationNotFound},
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeOperationNotFound,
+		},
+		{
+			name:       "service error",
+			srv:        &mockAPIKeyService{subscriberID: "sub1", err: errors.New("db down")},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewAPIKeyHandler(tc.srv)
+			req := httptest.NewRequest(http.MethodGet, "/me/operations/op1", nil)
+			req.Header.Set(model.APIKeyHeader, "key")
+			rr := httptest.NewRecorder()
+			newAPIKeyTestRouter(h).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.wantCode == "" {
+				var got model.LRO
+				if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if got.OperationID != "op1" {
+					t.Errorf("OperationID = %q, want %q", got.OperationID, "op1")
+				}
+				return
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", got.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+
+	defaultCompressionMinSize = 1024
+)
+
+// CompressionConfig holds the configuration for response compression.
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Level is the gzip/deflate compression level from 1 (fastest) to 9 (smallest).
+	// Zero uses the default level.
+	Level int `yaml:"level"`
+	// MinSize is the response size in bytes below which responses are sent uncompressed.
+	// Zero uses the default of 1024 bytes.
+	MinSize int `yaml:"minSize"`
+}
+
+// CompressionHandler compresses large responses with gzip or deflate.
+type CompressionHandler struct {
+	enabled bool
+	level   int
+	minSize int
+}
+
+// NewCompressionHandler creates a new CompressionHandler. A nil config disables compression.
+func NewCompressionHandler(cfg *CompressionConfig) (*CompressionHandler, error) {
+	if cfg == nil || !cfg.Enabled {
+		return &CompressionHandler{}, nil
+	}
+	if cfg.Level < 0 || cfg.Level > gzip.BestCompression {
+		slog.Error("NewCompressionHandler: Invalid compression level.", "level", cfg.Level)
+		return nil, fmt.Errorf("compression level %d is out of range [1, 9]", cfg.Level)
+	}
+	if cfg.MinSize < 0 {
+		slog.Error("NewCompressionHandler: Invalid compression min size.", "min_size", cfg.MinSize)
+		return nil, errors.New("compression minSize must not be negative")
+	}
+	h := &CompressionHandler{enabled: true, level: cfg.Level, minSize: cfg.MinSize}
+	if h.level == 0 {
+		h.level = gzip.DefaultCompression
+	}
+	if h.minSize == 0 {
+		h.minSize = defaultCompressionMinSize
+	}
+	return h, nil
+}
+
+// Compress is a middleware that compresses the response with the encoding preferred by the
+// Accept-Encoding request header once the body reaches the configured minimum size.
+// Smaller responses, and responses the handler has already encoded, are sent as is.
+func (h *CompressionHandler) Compress(next http.Handler) http.Handler {
+	if !h.enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, level: h.level, minSize: h.minSize}
+		defer func() {
+			if err := cw.Close(); err != nil {
+				slog.ErrorContext(r.Context(), "CompressionHandler: Failed to finish compressed response", "encoding", encoding, "error", err)
+			}
+		}()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality value in an
+// Accept-Encoding header, preferring gzip on ties, or "" if neither is acceptable.
+func negotiateEncoding(header string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = f
+		}
+		q[name] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range []string{encodingGzip, encodingDeflate} {
+		weight, ok := q[enc]
+		if !ok {
+			weight = q["*"]
+		}
+		if weight > bestQ {
+			best, bestQ = enc, weight
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the response until it reaches minSize and then either
+// compresses the rest of it or, if the handler finishes first, writes it uncompressed.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	level    int
+	minSize  int
+
+	status  int
+	buf     []byte
+	started bool
+	enc     io.WriteCloser
+}
+
+// WriteHeader records the status code; it is sent once the encoding is decided.
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.started || cw.status != 0 {
+		return
+	}
+	cw.status = status
+}
+
+// Write buffers p until the response reaches minSize.
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.started {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < cw.minSize {
+		return len(p), nil
+	}
+	if err := cw.start(true); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// start sends the header and the buffered body, compressing it if compress is set and
+// the response is eligible.
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	h := cw.Header()
+	if compress && h.Get("Content-Encoding") == "" && cw.status >= http.StatusOK &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusNotModified {
+		enc, err := cw.newEncoder()
+		if err != nil {
+			return err
+		}
+		cw.enc = enc
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) newEncoder() (io.WriteCloser, error) {
+	if cw.encoding == encodingDeflate {
+		return zlib.NewWriterLevel(cw.ResponseWriter, cw.level)
+	}
+	return gzip.NewWriterLevel(cw.ResponseWriter, cw.level)
+}
+
+// Close flushes a response that stayed below minSize, or finishes the compressed stream.
+func (cw *compressWriter) Close() error {
+	if !cw.started {
+		if cw.status == 0 {
+			return nil
+		}
+		return cw.start(false)
+	}
+	if cw.enc != nil {
+		return cw.enc.Close()
+	}
+	return nil
+}
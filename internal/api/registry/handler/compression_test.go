@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewCompressionHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         *CompressionConfig
+		wantErr     bool
+		wantEnabled bool
+		wantMinSize int
+	}{
+		{name: "nil config", cfg: nil},
+		{name: "disabled", cfg: &CompressionConfig{Level: 42}},
+		{name: "defaults", cfg: &CompressionConfig{Enabled: true}, wantEnabled: true, wantMinSize: defaultCompressionMinSize},
+		{name: "custom", cfg: &CompressionConfig{Enabled: true, Level: 9, MinSize: 10}, wantEnabled: true, wantMinSize: 10},
+		{name: "level too high", cfg: &CompressionConfig{Enabled: true, Level: 10}, wantErr: true},
+		{name: "negative level", cfg: &CompressionConfig{Enabled: true, Level: -1}, wantErr: true},
+		{name: "negative min size", cfg: &CompressionConfig{Enabled: true, MinSize: -1}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewCompressionHandler(tc.cfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewCompressionHandler() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if h.enabled != tc.wantEnabled || h.minSize != tc.wantMinSize {
+				t.Errorf("NewCompressionHandler() = {enabled: %v, minSize: %d}, want {enabled: %v, minSize: %d}", h.enabled, h.minSize, tc.wantEnabled, tc.wantMinSize)
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "identity", want: ""},
+		{header: "gzip", want: "gzip"},
+		{header: "deflate", want: "deflate"},
+		{header: "gzip, deflate, br", want: "gzip"},
+		{header: "deflate, gzip", want: "gzip"},
+		{header: "gzip;q=0.5, deflate", want: "deflate"},
+		{header: "gzip;q=0, deflate;q=0", want: ""},
+		{header: "*", want: "gzip"},
+		{header: "*;q=0.1, gzip;q=0", want: "deflate"},
+		{header: "GZIP ; q=0.8", want: "gzip"},
+		{header: "gzip;q=abc", want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			if got := negotiateEncoding(tc.header); got != tc.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func decodeBody(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var r io.Reader
+	var err error
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(body)
+	case "deflate":
+		r, err = zlib.NewReader(body)
+	default:
+		r = body
+	}
+	if err != nil {
+		t.Fatalf("failed to create %s reader: %v", encoding, err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read %s body: %v", encoding, err)
+	}
+	return string(b)
+}
+
+func TestCompressionHandler_Compress(t *testing.T) {
+	large := strings.Repeat(`{"subscriber_id":"np1"}`, 10)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		status         int
+		body           string
+		contentEnc     string
+		wantStatus     int
+		wantEncoding   string
+	}{
+		{name: "gzip", method: http.MethodPost, acceptEncoding: "gzip", body: large, wantStatus: http.StatusOK, wantEncoding: "gzip"},
+		{name: "deflate", method: http.MethodPost, acceptEncoding: "deflate", body: large, wantStatus: http.StatusOK, wantEncoding: "deflate"},
+		{name: "below min size", method: http.MethodPost, acceptEncoding: "gzip", body: `{"ok":true}`, wantStatus: http.StatusOK},
+		{name: "not accepted", method: http.MethodPost, body: large, wantStatus: http.StatusOK},
+		{name: "error status kept", method: http.MethodPost, acceptEncoding: "gzip", status: http.StatusNotFound, body: large, wantStatus: http.StatusNotFound, wantEncoding: "gzip"},
+		{name: "status without body", method: http.MethodGet, acceptEncoding: "gzip", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "already encoded", method: http.MethodGet, acceptEncoding: "gzip", contentEnc: "br", body: large, wantStatus: http.StatusOK, wantEncoding: "br"},
+		{name: "head request", method: http.MethodHead, acceptEncoding: "gzip", wantStatus: http.StatusOK},
+	}
+
+	h, err := NewCompressionHandler(&CompressionConfig{Enabled: true, MinSize: 64})
+	if err != nil {
+		t.Fatalf("NewCompressionHandler() unexpected error: %v", err)
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tc.contentEnc != "" {
+					w.Header().Set("Content-Encoding", tc.contentEnc)
+				}
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				// Write in small chunks so that the threshold is crossed mid-response.
+				for i := 0; i < len(tc.body); i += 16 {
+					io.WriteString(w, tc.body[i:min(i+16, len(tc.body))])
+				}
+			})
+			req := httptest.NewRequest(tc.method, "/lookup", nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			h.Compress(next).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tc.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tc.wantEncoding)
+			}
+			if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want %q", got, "Accept-Encoding")
+			}
+			if tc.contentEnc != "" {
+				return
+			}
+			if got := decodeBody(t, tc.wantEncoding, rr.Body); got != tc.body {
+				t.Errorf("body = %q, want %q", got, tc.body)
+			}
+		})
+	}
+}
+
+func TestCompressionHandler_Compress_Disabled(t *testing.T) {
+	h, err := NewCompressionHandler(nil)
+	if err != nil {
+		t.Fatalf("NewCompressionHandler() unexpected error: %v", err)
+	}
+	body := strings.Repeat("a", 4096)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/lookup", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	h.Compress(next).ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+	if rr.Body.String() != body {
+		t.Error("body was modified while compression is disabled")
+	}
+}
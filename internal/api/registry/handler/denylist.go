@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// denylistChecker decides whether a request comes from a denylisted subscriber or IP.
+type denylistChecker interface {
+	Check(ctx context.Context, remoteAddr, authHeader string) *model.DenylistEntry
+}
+
+// DenylistHandler drops requests from denylisted subscribers and IPs.
+type DenylistHandler struct {
+	srv denylistChecker
+}
+
+// NewDenylistHandler creates a new DenylistHandler.
+func NewDenylistHandler(srv denylistChecker) (*DenylistHandler, error) {
+	if srv == nil {
+		slog.Error("NewDenylistHandler: denylistChecker dependency is nil.")
+		return nil, errors.New("denylistChecker dependency is nil")
+	}
+	return &DenylistHandler{srv: srv}, nil
+}
+
+// Enforce is a middleware that rejects requests from denylisted subscribers and IPs with
+// 403 Forbidden before their signature is validated. It must run after middleware.RealIP.
+func (h *DenylistHandler) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := h.srv.Check(r.Context(), r.RemoteAddr, r.Header.Get(model.AuthHeaderSubscriber))
+		if e == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		slog.WarnContext(r.Context(), "DenylistHandler: Dropped request from denylisted client", "entry_id", e.ID, "kind", e.Kind, "remote_addr", r.RemoteAddr, "path", r.URL.Path)
+		writeJSONError(w, http.StatusForbidden, model.ErrorTypeAuthError, model.ErrorCodeDenylisted, "Request denied.", "", "")
+	})
+}
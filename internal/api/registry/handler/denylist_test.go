@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockDenylistChecker is a mock implementation of denylistChecker.
+type mockDenylistChecker struct {
+	entry         *model.DenylistEntry
+	gotRemoteAddr string
+	gotAuthHeader string
+}
+
+func (m *mockDenylistChecker) Check(ctx context.Context, remoteAddr, authHeader string) *model.DenylistEntry {
+	m.gotRemoteAddr, m.gotAuthHeader = remoteAddr, authHeader
+	return m.entry
+}
+
+func TestNewDenylistHandler(t *testing.T) {
+	if _, err := NewDenylistHandler(&mockDenylistChecker{}); err != nil {
+		t.Errorf("NewDenylistHandler() unexpected error: %v", err)
+	}
+	if _, err := NewDenylistHandler(nil); err == nil {
+		t.Error("NewDenylistHandler(nil) expected error, got nil")
+	}
+}
+
+func TestDenylistHandler_Enforce(t *testing.T) {
+	tests := []struct {
+		name       string
+		entry      *model.DenylistEntry
+		wantStatus int
+		wantNext   bool
+	}{
+		{name: "allowed", wantStatus: http.StatusOK, wantNext: true},
+		{name: "denylisted", entry: &model.DenylistEntry{ID: "e1", Kind: model.DenylistKindSubscriber, Value: "np1", Reason: "revoked"}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := &mockDenylistChecker{entry: tc.entry}
+			h, _ := NewDenylistHandler(checker)
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+			req.RemoteAddr = "192.0.2.7"
+			req.Header.Set(model.AuthHeaderSubscriber, `Signature keyId="np1|k1|ed25519"`)
+			rr := httptest.NewRecorder()
+			h.Enforce(next).ServeHTTP(rr, req)
+
+			if called != tc.wantNext {
+				t.Errorf("next called = %v, want %v", called, tc.wantNext)
+			}
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if checker.gotRemoteAddr != "192.0.2.7" || checker.gotAuthHeader != `Signature keyId="np1|k1|ed25519"` {
+				t.Errorf("Check() called with %q, %q", checker.gotRemoteAddr, checker.gotAuthHeader)
+			}
+			if tc.wantNext {
+				return
+			}
+			var resp model.ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Error.Code != model.ErrorCodeDenylisted || resp.Error.Type != model.ErrorTypeAuthError {
+				t.Errorf("error = %+v, want %s/%s", resp.Error, model.ErrorTypeAuthError, model.ErrorCodeDenylisted)
+			}
+		})
+	}
+}
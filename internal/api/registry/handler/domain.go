@@ -0,0 +1,63 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// domainLister lists the domain catalog with inherited metadata resolved.
+type domainLister interface {
+	List(ctx context.Context) ([]model.Domain, error)
+}
+
+// DomainHandler serves the domain catalog to onboarding tooling.
+type DomainHandler struct {
+	srv domainLister
+}
+
+// NewDomainHandler creates a new DomainHandler.
+func NewDomainHandler(srv domainLister) (*DomainHandler, error) {
+	if srv == nil {
+		slog.Error("NewDomainHandler: domainLister dependency is nil.")
+		return nil, errors.New("domainLister dependency is nil")
+	}
+	return &DomainHandler{srv: srv}, nil
+}
+
+// List handles GET /domains.
+func (h *DomainHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	domains, err := h.srv.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "DomainHandler: Failed to list domains", "error", err)
+		writeInternalError(w, err, "Failed to list domains due to an internal error.")
+		return
+	}
+	if domains == nil {
+		domains = []model.Domain{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(domains); err != nil {
+		slog.ErrorContext(ctx, "DomainHandler: Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+type mockDomainLister struct {
+	domains []model.Domain
+	err     error
+}
+
+func (m *mockDomainLister) List(ctx context.Context) ([]model.Domain, error) {
+	return m.domains, m.err
+}
+
+func TestNewDomainHandler(t *testing.T) {
+	if _, err := NewDomainHandler(&mockDomainLister{}); err != nil {
+		t.Errorf("NewDomainHandler() error = %v, want nil", err)
+	}
+	if _, err := NewDomainHandler(nil); err == nil || err.Error() != "domainLister dependency is nil" {
+		t.Errorf("NewDomainHandler(nil) error = %v, want domainLister dependency is nil", err)
+	}
+}
+
+func TestDomainHandler_List(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		domains []model.Domain
+		want    string
+	}{
+		{
+			name: "domains",
+			domains: []model.Domain{
+				{Name: "ONDC:RET", DisplayName: "Retail", LocationGranularity: model.LocationGranularityCity, CreatedAt: now, UpdatedAt: now},
+				{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", LocationGranularity: model.LocationGranularityCity, CreatedAt: now, UpdatedAt: now},
+			},
+			want: `[{"name":"ONDC:RET","display_name":"Retail","location_granularity":"CITY","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"},` +
+				`{"name":"ONDC:RET10","parent":"ONDC:RET","display_name":"Grocery","location_granularity":"CITY","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			name: "no domains",
+			want: "[]\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDomainHandler(&mockDomainLister{domains: tc.domains})
+			rr := httptest.NewRecorder()
+
+			h.List(rr, httptest.NewRequest(http.MethodGet, "/domains", nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := rr.Body.String(); got != tc.want {
+				t.Errorf("body = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDomainHandler_List_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{
+			name:       "internal error",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+		},
+		{
+			name:       "query timeout",
+			err:        fmt.Errorf("failed to list domains: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   model.ErrorCodeQueryTimeout,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewDomainHandler(&mockDomainLister{err: tc.err})
+			rr := httptest.NewRecorder()
+
+			h.List(rr, httptest.NewRequest(http.MethodGet, "/domains", nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("error code = %q, want %q", resp.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
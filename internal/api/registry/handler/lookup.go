@@ -15,21 +15,47 @@
 package handler
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
 type lookupService interface {
 	Lookup(context.Context, *model.Subscription) ([]model.Subscription, error)
+	LookupAny(context.Context, []*model.Subscription) ([]model.Subscription, error)
+	LookupChanges(context.Context, []*model.Subscription, time.Time) (*model.LookupChanges, error)
+}
+
+// lookupTierAdmitter places lookup requests in a rate limited tier.
+type lookupTierAdmitter interface {
+	Admit(ctx context.Context, body []byte, authHeader, remoteAddr string) (*service.LookupAdmission, *model.AuthError)
+}
+
+// lookupQuotaTaker counts lookups against the request quota of their caller.
+type lookupQuotaTaker interface {
+	TakeLookup(ctx context.Context, caller string) error
 }
 
 // lookupHandler handles lookup requests.
 type lookupHandler struct {
 	lhService lookupService
+	tiers     lookupTierAdmitter
+	quota     lookupQuotaTaker
 }
 
 // NewLookupHandler creates a new LookupHandler.
@@ -37,31 +63,199 @@ func NewLookupHandler(svc lookupService) *lookupHandler {
 	return &lookupHandler{lhService: svc}
 }
 
+// SetTiers enforces the public and authenticated lookup tiers: unsigned lookups are rate
+// limited per client IP and return only the public fields of subscriptions, while lookups
+// signed by a subscribed participant get a higher limit and all fields.
+func (h *lookupHandler) SetTiers(tiers lookupTierAdmitter) {
+	h.tiers = tiers
+}
+
+// SetQuota enforces the hourly and daily lookup quotas of callers. Callers are identified
+// by the lookup tiers when they are set, and by client IP otherwise.
+func (h *lookupHandler) SetQuota(q lookupQuotaTaker) {
+	h.quota = q
+}
+
 // Lookup handles the HTTP POST request for subscriber lookup.
 // It unmarshals the request body, calls the service layer, and returns JSON response.
+// The response carries an ETag computed from its body. If the request's If-None-Match
+// header matches it, the handler responds with 304 Not Modified and no body, so that
+// clients polling for subscribers only download changes.
+// A JSON array body is treated as a list of filters and returns the subscriptions
+// matching any of them.
+// With an updated_after query parameter, the response is a model.LookupChanges holding
+// only the changes to the results at or after that time, for incremental syncs.
 func (h *lookupHandler) Lookup(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Handler: Received lookup request", "method", r.Method, "path", r.URL.Path)
 
-	var lookupReq model.Subscription
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Error("Handler: Failed to read request body", "error", err)
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var admission *service.LookupAdmission
+	if h.tiers != nil {
+		var authErr *model.AuthError
+		admission, authErr = h.tiers.Admit(r.Context(), body, r.Header.Get(model.AuthHeaderSubscriber), r.RemoteAddr)
+		if authErr != nil {
+			writeJSONError(w, authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message, "", authErr.SubscriberID)
+			return
+		}
+		if admission.RetryAfter > 0 {
+			slog.WarnContext(r.Context(), "Handler: Lookup rate limited", "tier", admission.Tier, "remote_addr", r.RemoteAddr, "retry_after", admission.RetryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(int((admission.RetryAfter+time.Second-1)/time.Second)))
+			writeJSONError(w, http.StatusTooManyRequests, model.ErrorTypeConflictError, model.ErrorCodeRateLimited, "Too many lookups, retry later.", "", "")
+			return
+		}
+	}
+	if h.quota != nil {
+		caller := r.RemoteAddr
+		if admission != nil {
+			caller = admission.Caller
+		}
+		if err := h.quota.TakeLookup(r.Context(), caller); err != nil {
+			slog.WarnContext(r.Context(), "Handler: Lookup quota check failed", "error", err, "remote_addr", r.RemoteAddr)
+			if !writeRequestQuotaError(w, err) {
+				http.Error(w, "Failed to check lookup quota", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	var updatedAfter time.Time
+	incremental := r.URL.Query().Has("updated_after")
+	if incremental {
+		if updatedAfter, err = time.Parse(time.RFC3339, r.URL.Query().Get("updated_after")); err != nil {
+			slog.Error("Handler: Invalid updated_after", "error", err)
+			http.Error(w, "Invalid updated_after, want an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&lookupReq); err != nil {
+	var raw json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
 		slog.Error("Handler: Failed to unmarshal request body", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	subscriptions, err := h.lhService.Lookup(r.Context(), &lookupReq)
+	var subscriptions []model.Subscription
+	var changes *model.LookupChanges
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		var filters []*model.Subscription
+		if err := json.Unmarshal(raw, &filters); err != nil {
+			slog.Error("Handler: Failed to unmarshal request body", "error", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if incremental {
+			changes, err = h.lhService.LookupChanges(r.Context(), filters, updatedAfter)
+		} else {
+			subscriptions, err = h.lhService.LookupAny(r.Context(), filters)
+		}
+	} else {
+		var lookupReq model.Subscription
+		if err := json.Unmarshal(raw, &lookupReq); err != nil {
+			slog.Error("Handler: Failed to unmarshal request body", "error", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if incremental {
+			changes, err = h.lhService.LookupChanges(r.Context(), []*model.Subscription{&lookupReq}, updatedAfter)
+		} else {
+			subscriptions, err = h.lhService.Lookup(r.Context(), &lookupReq)
+		}
+	}
+	if changes != nil {
+		subscriptions = changes.Subscriptions
+	}
 	if err != nil {
-		slog.Error("Handler: Failed to perform lookup", "error", err, "request", lookupReq)
+		slog.Error("Handler: Failed to perform lookup", "error", err)
+		if errors.Is(err, service.ErrInvalidLookup) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrQueryTimeout) {
+			http.Error(w, "Lookup timed out", http.StatusGatewayTimeout)
+			return
+		}
 		http.Error(w, "Failed to lookup subscriptions", http.StatusInternalServerError)
 		return
 	}
 
+	if admission != nil {
+		admission.Filter(subscriptions)
+	}
+	// Sort by primary key so that unchanged results always have the same ETag.
+	slices.SortFunc(subscriptions, func(a, b model.Subscription) int {
+		return cmp.Or(
+			strings.Compare(a.SubscriberID, b.SubscriberID),
+			strings.Compare(a.Domain, b.Domain),
+			strings.Compare(string(a.Type), string(b.Type)),
+		)
+	})
+	var resp any = subscriptions
+	if changes != nil {
+		slices.SortFunc(changes.Deleted, func(a, b model.SubscriptionTombstone) int {
+			return cmp.Or(
+				strings.Compare(a.SubscriberID, b.SubscriberID),
+				strings.Compare(a.Domain, b.Domain),
+				strings.Compare(string(a.Type), string(b.Type)),
+			)
+		})
+		resp = changes
+	}
+	body, err = json.Marshal(resp)
+	if err != nil {
+		slog.Error("Handler: Failed to encode lookup response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	etag := lookupETag(body)
+	w.Header().Set("ETag", etag)
+	if lastModified := lastUpdated(subscriptions); !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		slog.Info("Handler: Lookup results not modified", "count", len(subscriptions))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(subscriptions); err != nil {
+	if _, err := w.Write(append(body, '\n')); err != nil {
 		slog.Error("Handler: Failed to encode lookup response", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 
 	slog.Info("Handler: Lookup request processed successfully", "count", len(subscriptions))
 }
+
+// lookupETag returns a strong entity tag for a lookup response body.
+func lookupETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// lastUpdated returns the latest update time of the subscriptions.
+func lastUpdated(subscriptions []model.Subscription) time.Time {
+	var last time.Time
+	for _, s := range subscriptions {
+		if s.Updated.After(last) {
+			last = s.Updated
+		}
+	}
+	return last
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag, using weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
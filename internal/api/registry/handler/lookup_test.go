@@ -19,11 +19,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/go-chi/chi/v5"
@@ -35,12 +40,25 @@ import (
 type mockLookupService struct {
 	subscriptions []model.Subscription
 	err           error
+	anyFilters    []*model.Subscription
+	changes       *model.LookupChanges
+	gotSince      time.Time
 }
 
 func (m *mockLookupService) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
 	return m.subscriptions, m.err
 }
 
+func (m *mockLookupService) LookupAny(ctx context.Context, filters []*model.Subscription) ([]model.Subscription, error) {
+	m.anyFilters = filters
+	return m.subscriptions, m.err
+}
+
+func (m *mockLookupService) LookupChanges(ctx context.Context, filters []*model.Subscription, since time.Time) (*model.LookupChanges, error) {
+	m.anyFilters, m.gotSince = filters, since
+	return m.changes, m.err
+}
+
 // TestNewLookupHandlerSuccess tests the successful creation of a new LookupHandler.
 func TestNewLookupHandlerSuccess(t *testing.T) {
 	mockSvc := &mockLookupService{}
@@ -191,6 +209,15 @@ func TestLookupHandlerLookupError(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   "Failed to lookup subscriptions\n",
 		},
+		{
+			name:        "ServiceLookupTimeout",
+			requestBody: bytes.NewBufferString(`{"subscriber_id":"slow-id"}`),
+			mockService: &mockLookupService{
+				err: fmt.Errorf("failed to lookup subscriptions: %w", repository.ErrQueryTimeout),
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   "Lookup timed out\n",
+		},
 	}
 
 	for _, tc := range tests {
@@ -272,3 +299,412 @@ func TestLookupHandlerLookupErrorEncodeFailure(t *testing.T) {
 		t.Errorf("handler.Lookup WriteHeader status code = %d, want %d", ew.StatusCode, http.StatusInternalServerError)
 	}
 }
+
+func TestLookupHandlerLookupAny(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "sub-1", Domain: "retail"}},
+		{Subscriber: model.Subscriber{SubscriberID: "sub-2", Domain: "mobility"}},
+	}
+	mockSvc := &mockLookupService{subscriptions: subs}
+	req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewBufferString(` [{"domain":"retail"},{"domain":"mobility"}]`))
+	rr := httptest.NewRecorder()
+
+	NewLookupHandler(mockSvc).Lookup(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler.Lookup returned wrong status code: got %v want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	wantFilters := []*model.Subscription{
+		{Subscriber: model.Subscriber{Domain: "retail"}},
+		{Subscriber: model.Subscriber{Domain: "mobility"}},
+	}
+	if diff := cmp.Diff(wantFilters, mockSvc.anyFilters); diff != "" {
+		t.Errorf("LookupAny() filters mismatch (-want +got):\n%s", diff)
+	}
+	var got []model.Subscription
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if diff := cmp.Diff(subs, got); diff != "" {
+		t.Errorf("handler.Lookup returned unexpected body (-want +got):\n%s", diff)
+	}
+}
+
+func TestLookupHandlerLookupAnyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "malformed array",
+			body:       `[{"domain":1}]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid lookup",
+			body:       `[]`,
+			err:        fmt.Errorf("%w: got 0 filters", service.ErrInvalidLookup),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "timeout",
+			body:       `[{"domain":"retail"}]`,
+			err:        fmt.Errorf("failed to lookup subscriptions: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+
+			NewLookupHandler(&mockLookupService{err: tc.err}).Lookup(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler.Lookup returned wrong status code: got %v want %v. Body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestLookupHandlerLookupChanges(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	changes := &model.LookupChanges{
+		Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub-1", Domain: "retail"}, Updated: since.Add(time.Hour)}},
+		Deleted: []model.SubscriptionTombstone{
+			{SubscriberID: "sub-3", Domain: "retail", Type: model.RoleBPP, DeletedAt: since.Add(2 * time.Hour)},
+			{SubscriberID: "sub-2", Domain: "retail", Type: model.RoleBPP, DeletedAt: since.Add(time.Hour)},
+		},
+		NextUpdatedAfter: since.Add(2 * time.Hour),
+	}
+	tests := []struct {
+		name        string
+		body        string
+		wantFilters []*model.Subscription
+	}{
+		{
+			name:        "single filter",
+			body:        `{"domain":"retail"}`,
+			wantFilters: []*model.Subscription{{Subscriber: model.Subscriber{Domain: "retail"}}},
+		},
+		{
+			name:        "list of filters",
+			body:        `[{"domain":"retail"},{"domain":"mobility"}]`,
+			wantFilters: []*model.Subscription{{Subscriber: model.Subscriber{Domain: "retail"}}, {Subscriber: model.Subscriber{Domain: "mobility"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := *changes
+			c.Deleted = slices.Clone(changes.Deleted)
+			mockSvc := &mockLookupService{changes: &c}
+			req := httptest.NewRequest(http.MethodPost, "/lookup?updated_after=2025-06-01T00:00:00Z", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+
+			NewLookupHandler(mockSvc).Lookup(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler.Lookup returned wrong status code: got %v want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if diff := cmp.Diff(tc.wantFilters, mockSvc.anyFilters); diff != "" {
+				t.Errorf("LookupChanges() filters mismatch (-want +got):\n%s", diff)
+			}
+			if !mockSvc.gotSince.Equal(since) {
+				t.Errorf("LookupChanges() since = %v, want %v", mockSvc.gotSince, since)
+			}
+			var got model.LookupChanges
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			want := &model.LookupChanges{
+				Subscriptions:    changes.Subscriptions,
+				Deleted:          []model.SubscriptionTombstone{changes.Deleted[1], changes.Deleted[0]},
+				NextUpdatedAfter: changes.NextUpdatedAfter,
+			}
+			if diff := cmp.Diff(want, &got); diff != "" {
+				t.Errorf("handler.Lookup returned unexpected body (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLookupHandlerLookupChangesError(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "invalid updated_after",
+			query:      "?updated_after=2025-06-01",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "timeout",
+			query:      "?updated_after=2025-06-01T00:00:00Z",
+			err:        fmt.Errorf("failed to lookup subscription changes: %w", repository.ErrQueryTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "repository error",
+			query:      "?updated_after=2025-06-01T00:00:00Z",
+			err:        errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/lookup"+tc.query, bytes.NewBufferString(`{"domain":"retail"}`))
+			rr := httptest.NewRecorder()
+
+			NewLookupHandler(&mockLookupService{err: tc.err}).Lookup(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler.Lookup returned wrong status code: got %v want %v. Body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestLookupHandlerLookupConditional(t *testing.T) {
+	updated := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "sub-2", Domain: "retail", Type: model.RoleBPP}, Updated: updated.Add(-time.Hour)},
+		{Subscriber: model.Subscriber{SubscriberID: "sub-1", Domain: "retail", Type: model.RoleBPP}, Updated: updated},
+	}
+	serve := func(subs []model.Subscription, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewReader([]byte(`{}`)))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		NewLookupHandler(&mockLookupService{subscriptions: subs}).Lookup(rr, req)
+		return rr
+	}
+
+	first := serve(slices.Clone(subs), "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Lookup() status = %d, ETag = %q, want 200 with an ETag", first.Code, etag)
+	}
+	if got, want := first.Header().Get("Last-Modified"), "Thu, 02 Jan 2025 03:04:05 GMT"; got != want {
+		t.Errorf("Lookup() Last-Modified = %q, want %q", got, want)
+	}
+	var got []model.Subscription
+	if err := json.Unmarshal(first.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if got[0].SubscriberID != "sub-1" || got[1].SubscriberID != "sub-2" {
+		t.Errorf("Lookup() returned %s, %s, want results sorted by subscriber_id", got[0].SubscriberID, got[1].SubscriberID)
+	}
+
+	changed := slices.Clone(subs)
+	changed[0].URL = "https://sub-2.example.com"
+	tests := []struct {
+		name        string
+		subs        []model.Subscription
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "matching etag", subs: subs, ifNoneMatch: etag, wantStatus: http.StatusNotModified},
+		{name: "results in another order", subs: []model.Subscription{subs[1], subs[0]}, ifNoneMatch: etag, wantStatus: http.StatusNotModified},
+		{name: "weak etag in list", subs: subs, ifNoneMatch: `"other", W/` + etag, wantStatus: http.StatusNotModified},
+		{name: "wildcard", subs: subs, ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale etag", subs: subs, ifNoneMatch: `"stale"`, wantStatus: http.StatusOK},
+		{name: "changed results", subs: changed, ifNoneMatch: etag, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := serve(slices.Clone(tc.subs), tc.ifNoneMatch)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("Lookup() status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusNotModified {
+				if rr.Body.Len() != 0 {
+					t.Errorf("Lookup() body = %q, want empty", rr.Body.String())
+				}
+				if rr.Header().Get("ETag") != etag {
+					t.Errorf("Lookup() ETag = %q, want %q", rr.Header().Get("ETag"), etag)
+				}
+				return
+			}
+			if rr.Body.Len() == 0 {
+				t.Error("Lookup() body is empty, want results")
+			}
+		})
+	}
+}
+
+// mockLookupSigningKeys is a mock of the signing key store and signature validator of the lookup tiers.
+type mockLookupSigningKeys struct {
+	sigErr error
+}
+
+func (m *mockLookupSigningKeys) SigningKey(ctx context.Context, subscriberID, keyID string) (string, error) {
+	return "key", nil
+}
+
+func (m *mockLookupSigningKeys) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	return m.sigErr
+}
+
+func TestLookupHandlerLookupTiers(t *testing.T) {
+	const authHeader = `Signature keyId="bap.example.com|key1|ed25519",algorithm="ed25519",signature="sig"`
+	subs := []model.Subscription{{
+		Subscriber:       model.Subscriber{SubscriberID: "bpp.example.com", URL: "https://bpp.example.com", Type: model.RoleBPP, Domain: "retail"},
+		SigningPublicKey: "signing",
+		EncrPublicKey:    "encr",
+		Nonce:            "nonce",
+	}}
+	newHandler := func(sigErr error) *lookupHandler {
+		keys := &mockLookupSigningKeys{sigErr: sigErr}
+		tiers, err := service.NewLookupTiers(keys, keys, &service.LookupTierConfig{Public: service.LookupTierLimit{PerMinute: 1, Burst: 1}})
+		if err != nil {
+			t.Fatalf("NewLookupTiers() unexpected error: %v", err)
+		}
+		h := NewLookupHandler(&mockLookupService{subscriptions: subs})
+		h.SetTiers(tiers)
+		return h
+	}
+	serve := func(h *lookupHandler, authHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewReader([]byte(`{}`)))
+		req.RemoteAddr = "192.0.2.1:4000"
+		if authHeader != "" {
+			req.Header.Set(model.AuthHeaderSubscriber, authHeader)
+		}
+		rr := httptest.NewRecorder()
+		h.Lookup(rr, req)
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder) model.Subscription {
+		t.Helper()
+		var got []model.Subscription
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || len(got) != 1 {
+			t.Fatalf("Lookup() body = %s, want one subscription", rr.Body.String())
+		}
+		return got[0]
+	}
+
+	t.Run("public", func(t *testing.T) {
+		h := newHandler(nil)
+		rr := serve(h, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Lookup() status = %d, want %d", rr.Code, http.StatusOK)
+		}
+		want := model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "bpp.example.com", URL: "https://bpp.example.com", Type: model.RoleBPP, Domain: "retail"},
+			SigningPublicKey: "signing",
+		}
+		if diff := cmp.Diff(want, decode(rr)); diff != "" {
+			t.Errorf("Lookup() public mismatch (-want +got):\n%s", diff)
+		}
+
+		serve(h, "")
+		rr = serve(h, "")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("Lookup() status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+		}
+		if got := rr.Header().Get("Retry-After"); got != "60" {
+			t.Errorf("Lookup() Retry-After = %q, want %q", got, "60")
+		}
+		if !bytes.Contains(rr.Body.Bytes(), []byte(model.ErrorCodeRateLimited)) {
+			t.Errorf("Lookup() body = %s, want error code %s", rr.Body.String(), model.ErrorCodeRateLimited)
+		}
+
+		// The public limit of the client does not apply to its signed lookups.
+		if rr := serve(h, authHeader); rr.Code != http.StatusOK {
+			t.Errorf("Lookup() signed status = %d, want %d", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		rr := serve(newHandler(nil), authHeader)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Lookup() status = %d, want %d", rr.Code, http.StatusOK)
+		}
+		if diff := cmp.Diff(subs[0], decode(rr)); diff != "" {
+			t.Errorf("Lookup() authenticated mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		rr := serve(newHandler(errors.New("bad signature")), authHeader)
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Lookup() status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+		if rr.Header().Get(model.UnauthorizedHeaderSubscriber) == "" {
+			t.Errorf("Lookup() missing %s header", model.UnauthorizedHeaderSubscriber)
+		}
+	})
+}
+
+// mockLookupQuota is a mock implementation of lookupQuotaTaker.
+type mockLookupQuota struct {
+	err    error
+	caller string
+}
+
+func (m *mockLookupQuota) TakeLookup(ctx context.Context, caller string) error {
+	m.caller = caller
+	return m.err
+}
+
+func TestLookupHandlerLookupQuota(t *testing.T) {
+	const authHeader = `Signature keyId="bap.example.com|key1|ed25519",algorithm="ed25519",signature="sig"`
+	quotaErr := &service.QuotaExceededError{Scope: service.QuotaScopeLookups, Caller: "192.0.2.1", Period: service.QuotaPeriodDay, Limit: 1000, Reset: time.Now().Add(time.Hour)}
+	tests := []struct {
+		name       string
+		tiers      bool
+		authHeader string
+		quotaErr   error
+		wantStatus int
+		wantCaller string
+	}{
+		{name: "under quota", wantStatus: http.StatusOK, wantCaller: "192.0.2.1:4000"},
+		{name: "quota exceeded", quotaErr: quotaErr, wantStatus: http.StatusTooManyRequests, wantCaller: "192.0.2.1:4000"},
+		{name: "quota check fails", quotaErr: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantCaller: "192.0.2.1:4000"},
+		{name: "public tier caller", tiers: true, wantStatus: http.StatusOK, wantCaller: "192.0.2.1"},
+		{name: "authenticated tier caller", tiers: true, authHeader: authHeader, wantStatus: http.StatusOK, wantCaller: "bap.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewLookupHandler(&mockLookupService{})
+			if tt.tiers {
+				keys := &mockLookupSigningKeys{}
+				tiers, err := service.NewLookupTiers(keys, keys, &service.LookupTierConfig{})
+				if err != nil {
+					t.Fatalf("NewLookupTiers() unexpected error: %v", err)
+				}
+				h.SetTiers(tiers)
+			}
+			quota := &mockLookupQuota{err: tt.quotaErr}
+			h.SetQuota(quota)
+
+			req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewReader([]byte(`{}`)))
+			req.RemoteAddr = "192.0.2.1:4000"
+			if tt.authHeader != "" {
+				req.Header.Set(model.AuthHeaderSubscriber, tt.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			h.Lookup(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Lookup() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if quota.caller != tt.wantCaller {
+				t.Errorf("TakeLookup() caller = %q, want %q", quota.caller, tt.wantCaller)
+			}
+			if tt.quotaErr == quotaErr && rr.Header().Get("X-RateLimit-Limit") != "1000" {
+				t.Errorf("Lookup() X-RateLimit-Limit = %q, want %q", rr.Header().Get("X-RateLimit-Limit"), "1000")
+			}
+		})
+	}
+}
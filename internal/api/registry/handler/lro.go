@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
@@ -32,9 +33,19 @@ type lroService interface {
 	Get(ctx context.Context, id string) (*model.LRO, error)
 }
 
+const (
+	// defaultWaitTimeout is how long Wait waits for an operation without a timeout parameter.
+	defaultWaitTimeout = 30 * time.Second
+	// maxWaitTimeout caps the timeout parameter of Wait.
+	maxWaitTimeout = 60 * time.Second
+	// defaultWaitPollInterval is how often Wait reads the operation.
+	defaultWaitPollInterval = time.Second
+)
+
 // LROHandler handles Long-Running Operation (LRO) status requests.
 type LROHandler struct {
-	srv lroService
+	srv          lroService
+	pollInterval time.Duration
 }
 
 // NewLROHandler creates a new LROHandler.
@@ -43,7 +54,7 @@ func NewLROHandler(srv lroService) (*LROHandler, error) {
 		slog.Error("NewLROHandler: lroService dependency is nil.")
 		return nil, errors.New("lroService dependency is nil")
 	}
-	return &LROHandler{srv: srv}, nil
+	return &LROHandler{srv: srv, pollInterval: defaultWaitPollInterval}, nil
 }
 
 // Get retrieves the status of a Long-Running Operation.
@@ -52,19 +63,75 @@ func (h *LROHandler) Get(w http.ResponseWriter, r *http.Request) {
 	operationID := chi.URLParam(r, "operation_id")
 	lro, err := h.srv.Get(ctx, operationID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to get LRO from service", "operation_id", operationID, "error", err)
-		if errors.Is(err, repository.ErrOperationNotFound) {
-			writeJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError,
-				model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID), "", "")
+		writeLROError(ctx, w, operationID, err)
+		return
+	}
+	writeOperation(ctx, w, lro)
+}
+
+// Wait handles GET /operations/{operation_id}/wait. Like WaitOperation of google.longrunning,
+// it returns the operation as soon as it is done, or its latest state once the timeout
+// query parameter, 30s by default and at most 60s, has passed.
+func (h *LROHandler) Wait(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	operationID := chi.URLParam(r, "operation_id")
+	timeout := defaultWaitTimeout
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			writeJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest,
+				"timeout must be a non-negative duration, e.g. 30s.", "", "")
 			return
 		}
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError,
-			"Failed to retrieve operation status due to an internal error.", "", "")
+		timeout = min(d, maxWaitTimeout)
+	}
+	// The wait may outlast the write timeout of the server.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second)); err != nil {
+		slog.DebugContext(ctx, "LROHandler: Failed to extend write deadline for wait", "error", err)
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+	for {
+		lro, err := h.srv.Get(ctx, operationID)
+		if err != nil {
+			writeLROError(ctx, w, operationID, err)
+			return
+		}
+		if lro.Done() {
+			writeOperation(ctx, w, lro)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			writeOperation(ctx, w, lro)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeLROError writes the error response for a failed read of an operation.
+func writeLROError(ctx context.Context, w http.ResponseWriter, operationID string, err error) {
+	slog.ErrorContext(ctx, "Failed to get LRO from service", "operation_id", operationID, "error", err)
+	if errors.Is(err, repository.ErrOperationNotFound) {
+		writeJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError,
+			model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID), "", "")
 		return
 	}
+	writeInternalError(w, err,
+		"Failed to retrieve operation status due to an internal error.")
+}
+
+// writeOperation writes lro in its google.longrunning form.
+func writeOperation(ctx context.Context, w http.ResponseWriter, lro *model.LRO) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(lro); err != nil {
+	if err := json.NewEncoder(w).Encode(model.NewOperation(lro)); err != nil {
 		slog.ErrorContext(ctx, "LROHandler: Failed to encode LRO response for get", "error", err, "operation_id", lro.OperationID)
 	}
 }
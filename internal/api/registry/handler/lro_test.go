@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -150,6 +151,19 @@ func TestLROHandler_Get_Error(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:           "query timeout from service",
+			operationID:    opID,
+			srv:            &mockLROService{err: fmt.Errorf("failed to get operation: %w", repository.ErrQueryTimeout)},
+			wantStatusCode: http.StatusGatewayTimeout,
+			wantResponse: model.ErrorResponse{
+				Error: model.Error{
+					Type:    model.ErrorTypeInternalError,
+					Code:    model.ErrorCodeQueryTimeout,
+					Message: "The request timed out waiting for the database.",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,3 +194,130 @@ func TestLROHandler_Get_Error(t *testing.T) {
 		})
 	}
 }
+
+// sequenceLROService returns its operations in turn, repeating the last one.
+type sequenceLROService struct {
+	mu    sync.Mutex
+	lros  []*model.LRO
+	calls int
+}
+
+func (m *sequenceLROService) Get(ctx context.Context, id string) (*model.LRO, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lro := m.lros[min(m.calls, len(m.lros)-1)]
+	m.calls++
+	return lro, nil
+}
+
+func TestLROHandler_Wait_Success(t *testing.T) {
+	pending := &model.LRO{OperationID: "op-1", Status: model.LROStatusPending}
+	approved := &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved}
+	tests := []struct {
+		name      string
+		lros      []*model.LRO
+		query     string
+		wantDone  bool
+		wantCalls int
+	}{
+		{name: "already done", lros: []*model.LRO{approved}, wantDone: true, wantCalls: 1},
+		{name: "done while waiting", lros: []*model.LRO{pending, pending, approved}, wantDone: true, wantCalls: 3},
+		{name: "timeout", lros: []*model.LRO{pending}, query: "?timeout=50ms", wantDone: false},
+		{name: "zero timeout", lros: []*model.LRO{pending}, query: "?timeout=0s", wantDone: false, wantCalls: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &sequenceLROService{lros: tc.lros}
+			handler, err := NewLROHandler(srv)
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+			handler.pollInterval = 10 * time.Millisecond
+
+			req := httptest.NewRequest(http.MethodGet, "/operations/op-1/wait"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Get("/operations/{operation_id}/wait", handler.Wait)
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler.Wait() status code = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			var got model.Operation
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if got.Done != tc.wantDone {
+				t.Errorf("handler.Wait() done = %v, want %v", got.Done, tc.wantDone)
+			}
+			if got.Name != "operations/op-1" || got.OperationID != "op-1" {
+				t.Errorf("handler.Wait() name = %q, operation_id = %q, want operations/op-1 and op-1", got.Name, got.OperationID)
+			}
+			if tc.wantCalls > 0 && srv.calls != tc.wantCalls {
+				t.Errorf("handler.Wait() read the operation %d times, want %d", srv.calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestLROHandler_Wait_Error(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		srv            lroService
+		wantStatusCode int
+		wantCode       model.ErrorCode
+	}{
+		{
+			name:           "invalid timeout",
+			query:          "?timeout=soon",
+			srv:            &mockLROService{lro: &model.LRO{OperationID: "op-1"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       model.ErrorCodeBadRequest,
+		},
+		{
+			name:           "negative timeout",
+			query:          "?timeout=-1s",
+			srv:            &mockLROService{lro: &model.LRO{OperationID: "op-1"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       model.ErrorCodeBadRequest,
+		},
+		{
+			name:           "operation not found",
+			srv:            &mockLROService{err: repository.ErrOperationNotFound},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       model.ErrorCodeOperationNotFound,
+		},
+		{
+			name:           "internal error",
+			srv:            &mockLROService{err: errors.New("db down")},
+			wantStatusCode: http.StatusInternalServerError,
+			wantCode:       model.ErrorCodeInternalServerError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, err := NewLROHandler(tc.srv)
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/operations/op-1/wait"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Get("/operations/{operation_id}/wait", handler.Wait)
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatusCode {
+				t.Errorf("handler.Wait() status code = %d, want %d. Body: %s", rr.Code, tc.wantStatusCode, rr.Body.String())
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal error response body: %v. Body: %s", err, rr.Body.String())
+			}
+			if got.Error.Code != tc.wantCode {
+				t.Errorf("handler.Wait() error code = %s, want %s", got.Error.Code, tc.wantCode)
+			}
+		})
+	}
+}
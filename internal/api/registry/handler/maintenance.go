@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// maintenanceStatus reports the maintenance mode of the registry.
+type maintenanceStatus interface {
+	Status(ctx context.Context) *model.Maintenance
+	RetryAfter() time.Duration
+}
+
+// MaintenanceHandler enforces the read-only maintenance mode of the registry.
+type MaintenanceHandler struct {
+	srv maintenanceStatus
+}
+
+// NewMaintenanceHandler creates a new MaintenanceHandler.
+func NewMaintenanceHandler(srv maintenanceStatus) (*MaintenanceHandler, error) {
+	if srv == nil {
+		slog.Error("NewMaintenanceHandler: maintenanceStatus dependency is nil.")
+		return nil, errors.New("maintenanceStatus dependency is nil")
+	}
+	return &MaintenanceHandler{srv: srv}, nil
+}
+
+// Advertise is a middleware that sets model.MaintenanceHeader on responses while the
+// registry is in maintenance mode, so that clients can detect it on successful reads.
+func (h *MaintenanceHandler) Advertise(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.srv.Status(r.Context()).Enabled {
+			w.Header().Set(model.MaintenanceHeader, model.MaintenanceModeReadOnly)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReadOnly is a middleware that rejects requests with 503 Service Unavailable and a
+// REGISTRY_MAINTENANCE error while the registry is in maintenance mode.
+// It wraps the routes that modify the registry.
+func (h *MaintenanceHandler) ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := h.srv.Status(r.Context())
+		if !m.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		msg := "Registry is in read-only maintenance mode."
+		if m.Message != "" {
+			msg += " " + m.Message
+		}
+		slog.InfoContext(r.Context(), "MaintenanceHandler: Rejected request during maintenance", "method", r.Method, "path", r.URL.Path)
+		w.Header().Set(model.MaintenanceHeader, model.MaintenanceModeReadOnly)
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.srv.RetryAfter().Seconds())))
+		writeJSONError(w, http.StatusServiceUnavailable, model.ErrorTypeInternalError, model.ErrorCodeMaintenance, msg, "", "")
+	})
+}
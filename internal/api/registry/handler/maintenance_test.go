@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockMaintenanceStatus is a mock implementation of maintenanceStatus.
+type mockMaintenanceStatus struct {
+	m *model.Maintenance
+}
+
+func (m *mockMaintenanceStatus) Status(ctx context.Context) *model.Maintenance {
+	return m.m
+}
+
+func (m *mockMaintenanceStatus) RetryAfter() time.Duration {
+	return 2 * time.Minute
+}
+
+func TestNewMaintenanceHandler(t *testing.T) {
+	if _, err := NewMaintenanceHandler(&mockMaintenanceStatus{}); err != nil {
+		t.Errorf("NewMaintenanceHandler() unexpected error: %v", err)
+	}
+	if _, err := NewMaintenanceHandler(nil); err == nil {
+		t.Error("NewMaintenanceHandler(nil) expected error, got nil")
+	}
+}
+
+func TestMaintenanceHandler_Advertise(t *testing.T) {
+	tests := []struct {
+		name       string
+		m          *model.Maintenance
+		wantHeader string
+	}{
+		{name: "disabled", m: &model.Maintenance{}},
+		{name: "enabled", m: &model.Maintenance{Enabled: true}, wantHeader: model.MaintenanceModeReadOnly},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := NewMaintenanceHandler(&mockMaintenanceStatus{m: tc.m})
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+			rr := httptest.NewRecorder()
+
+			h.Advertise(next).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/lookup", nil))
+
+			if !called {
+				t.Error("Advertise() did not call the next handler")
+			}
+			if got := rr.Header().Get(model.MaintenanceHeader); got != tc.wantHeader {
+				t.Errorf("Advertise() %s = %q, want %q", model.MaintenanceHeader, got, tc.wantHeader)
+			}
+		})
+	}
+}
+
+func TestMaintenanceHandler_ReadOnly_Disabled(t *testing.T) {
+	h, _ := NewMaintenanceHandler(&mockMaintenanceStatus{m: &model.Maintenance{}})
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	h.ReadOnly(next).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/subscribe", nil))
+
+	if !called {
+		t.Error("ReadOnly() did not call the next handler")
+	}
+}
+
+func TestMaintenanceHandler_ReadOnly_Enabled(t *testing.T) {
+	h, _ := NewMaintenanceHandler(&mockMaintenanceStatus{m: &model.Maintenance{Enabled: true, Message: "Back at 10:00 UTC."}})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("ReadOnly() called the next handler during maintenance")
+	})
+	rr := httptest.NewRecorder()
+
+	h.ReadOnly(next).ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/subscribe", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("ReadOnly() status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("ReadOnly() Retry-After = %q, want %q", got, "120")
+	}
+	if got := rr.Header().Get(model.MaintenanceHeader); got != model.MaintenanceModeReadOnly {
+		t.Errorf("ReadOnly() %s = %q, want %q", model.MaintenanceHeader, got, model.MaintenanceModeReadOnly)
+	}
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v", err)
+	}
+	want := model.Error{Type: model.ErrorTypeInternalError, Code: model.ErrorCodeMaintenance, Message: "Registry is in read-only maintenance mode. Back at 10:00 UTC."}
+	if diff := cmp.Diff(want, resp.Error); diff != "" {
+		t.Errorf("ReadOnly() error mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -18,9 +18,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository" // Import the new service package
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
@@ -37,11 +40,18 @@ type authenticator interface {
 	AuthenticatedReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError)
 }
 
+// attestationVerifier verifies the attestation token of a request, such as a reCAPTCHA response.
+type attestationVerifier interface {
+	Header() string
+	Verify(ctx context.Context, token, remoteAddr string) error
+}
+
 // subscriptionHandler handles HTTP requests for the /subscribe endpoint.
 type subscriptionHandler struct {
 	subService subscriptionService
 	// signValidator service.signValidator // Type from service package
-	auth authenticator // Type from service package
+	auth        authenticator // Type from service package
+	attestation attestationVerifier
 }
 
 // NewSubscriptionHandler creates a new SubscribeHandler.
@@ -58,6 +68,32 @@ func NewSubscriptionHandler(ss subscriptionService, auth authenticator) (*subscr
 	return &subscriptionHandler{subService: ss, auth: auth}, nil
 }
 
+// SetAttestation requires new subscription requests to carry an attestation token
+// accepted by v before an operation is created for them.
+func (h *subscriptionHandler) SetAttestation(v attestationVerifier) {
+	h.attestation = v
+}
+
+// attest verifies the request's attestation token when attestation is set, writing the
+// error response and reporting false if the request is not accepted.
+func (h *subscriptionHandler) attest(w http.ResponseWriter, r *http.Request) bool {
+	if h.attestation == nil {
+		return true
+	}
+	err := h.attestation.Verify(r.Context(), r.Header.Get(h.attestation.Header()), r.RemoteAddr)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrAttestationRequired), errors.Is(err, service.ErrAttestationFailed):
+		slog.WarnContext(r.Context(), "SubscribeHandler: Attestation rejected", "error", err, "remote_addr", r.RemoteAddr)
+		writeJSONError(w, http.StatusForbidden, model.ErrorTypeAuthError, model.ErrorCodeAttestationFailed, "Attestation failed: provide a valid token in the "+h.attestation.Header()+" header.", "", "")
+	default:
+		slog.ErrorContext(r.Context(), "SubscribeHandler: Attestation could not be verified", "error", err)
+		writeJSONError(w, http.StatusServiceUnavailable, model.ErrorTypeInternalError, model.ErrorCodeServiceOverloaded, "Attestation could not be verified, try again later.", "", "")
+	}
+	return false
+}
+
 // writeJSONError is a helper function to construct and write standardized JSON error responses.
 func writeJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg, errPath, realmForAuthHeader string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -78,10 +114,23 @@ func writeJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorTy
 	}
 }
 
+// writeInternalError writes the error response for an unexpected service failure.
+// Repository query timeouts are reported as 504 Gateway Timeout, anything else as 500.
+func writeInternalError(w http.ResponseWriter, err error, errMsg string) {
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		writeJSONError(w, http.StatusGatewayTimeout, model.ErrorTypeInternalError, model.ErrorCodeQueryTimeout, "The request timed out waiting for the database.", "", "")
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, errMsg, "", "")
+}
+
 // Create handles POST requests to the /subscribe endpoint to create a new subscription.
 func (h *subscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	slog.InfoContext(ctx, "SubscribeHandler: Received create request", "method", r.Method, "path", r.URL.Path)
+	if !h.attest(w, r) {
+		return
+	}
 	slog.DebugContext(ctx, "SubscribeHandler: Attempting to decode create request body")
 
 	var subReq model.SubscriptionRequest
@@ -104,7 +153,10 @@ func (h *subscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 			writeJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, "Duplicate request: An operation with this message_id already exists or is in progress.", "", "")
 			return
 		}
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process subscription request.", "", "")
+		if writeNonceError(w, err) || writeValidationError(w, err) || writePendingQuotaError(w, err) || writeRequestQuotaError(w, err) {
+			return
+		}
+		writeInternalError(w, err, "Failed to process subscription request.")
 		return
 	}
 	slog.DebugContext(ctx, "SubscribeHandler: LRO created successfully for create request", "operation_id", lro.OperationID, "status", lro.Status)
@@ -151,7 +203,10 @@ func (h *subscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
 			writeJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, "Duplicate request: An operation with this message_id already exists or is in progress for update.", "", "")
 			return
 		}
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process subscription update request.", "", "")
+		if writeNonceError(w, err) || writeValidationError(w, err) || writePendingQuotaError(w, err) || writeRequestQuotaError(w, err) {
+			return
+		}
+		writeInternalError(w, err, "Failed to process subscription update request.")
 
 		return
 	}
@@ -167,3 +222,56 @@ func (h *subscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
 		slog.ErrorContext(ctx, "SubscribeHandler: Failed to encode subscription response for update", "error", err, "message_id", lro.OperationID)
 	}
 }
+
+// writeNonceError writes the response for a rejected request nonce and reports whether it did so.
+func writeNonceError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, repository.ErrNonceReplayed):
+		writeJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeNonceReplayed, "Nonce has already been used.", "", "")
+	case errors.Is(err, service.ErrNonceRequired):
+		writeJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "Nonce is required.", "", "")
+	default:
+		return false
+	}
+	return true
+}
+
+// writeValidationError writes the response listing the invalid fields of a request and reports whether it did so.
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(verr.ErrorResponse(model.ErrorCodeBadRequest)); err != nil {
+		slog.Error("Failed to encode error response", "error", err)
+	}
+	return true
+}
+
+// writePendingQuotaError writes the response for a subscriber over its pending operation quota and reports whether it did so.
+func writePendingQuotaError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, service.ErrPendingQuotaExceeded) {
+		return false
+	}
+	writeJSONError(w, http.StatusTooManyRequests, model.ErrorTypeConflictError, model.ErrorCodeTooManyPendingOperations, "Too many pending operations: wait for the pending operations of the subscriber to be resolved.", "", "")
+	return true
+}
+
+// writeRequestQuotaError writes the response for a caller over its hourly or daily request
+// quota and reports whether it did so. The response carries the X-RateLimit headers of the
+// quota window that was used up and a Retry-After until it resets.
+func writeRequestQuotaError(w http.ResponseWriter, err error) bool {
+	var qerr *service.QuotaExceededError
+	if !errors.As(err, &qerr) {
+		return false
+	}
+	retryAfter := max(time.Until(qerr.Reset), 0)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(qerr.Limit))
+	w.Header().Set("X-RateLimit-Remaining", "0")
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(qerr.Reset.Unix(), 10))
+	w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	msg := fmt.Sprintf("Request quota exceeded: the %s quota of %d per %s is used up until %s.", qerr.Scope, qerr.Limit, qerr.Period, qerr.Reset.UTC().Format(time.RFC3339))
+	writeJSONError(w, http.StatusTooManyRequests, model.ErrorTypeConflictError, model.ErrorCodeQuotaExceeded, msg, "", "")
+	return true
+}
@@ -23,10 +23,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -45,15 +48,33 @@ type mockSubscriptionService struct {
 	key       string
 	createErr error // Specific error for Create
 	updateErr error // Specific error for Update
+	created   bool
 }
 
 func (m *mockSubscriptionService) Create(ctx context.Context, req *model.SubscriptionRequest) (*model.LRO, error) {
+	m.created = true
 	return m.lro, m.createErr
 }
 func (m *mockSubscriptionService) Update(ctx context.Context, req *model.SubscriptionRequest) (*model.LRO, error) {
 	return m.lro, m.updateErr
 }
 
+// mockAttestationVerifier is a mock implementation of attestationVerifier.
+type mockAttestationVerifier struct {
+	err        error
+	token      string
+	remoteAddr string
+}
+
+func (m *mockAttestationVerifier) Header() string {
+	return "X-Attestation-Token"
+}
+
+func (m *mockAttestationVerifier) Verify(ctx context.Context, token, remoteAddr string) error {
+	m.token, m.remoteAddr = token, remoteAddr
+	return m.err
+}
+
 // errorReader is a helper for testing io.ReadAll errors
 type errorReader struct{}
 
@@ -275,6 +296,46 @@ func TestSubscriptionHandler_Create_Error(t *testing.T) {
 			wantContentType:  "application/json",
 			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeDuplicateRequest), `"message":"Duplicate request: An operation with this message_id already exists or is in progress."`},
 		},
+		{
+			name:             "service returns ErrNonceReplayed",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: fmt.Errorf("invalid nonce: %w", repository.ErrNonceReplayed)},
+			wantStatusCode:   http.StatusConflict,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeNonceReplayed), `"message":"Nonce has already been used."`},
+		},
+		{
+			name:             "service returns ErrNonceRequired",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: fmt.Errorf("invalid nonce: %w", service.ErrNonceRequired)},
+			wantStatusCode:   http.StatusBadRequest,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeValidationError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeBadRequest), `"message":"Nonce is required."`},
+		},
+		{
+			name:             "service returns ErrPendingQuotaExceeded",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: fmt.Errorf("%w: subscriber sub1 has 3 pending operations, at most 3 allowed", service.ErrPendingQuotaExceeded)},
+			wantStatusCode:   http.StatusTooManyRequests,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeTooManyPendingOperations), `"message":"Too many pending operations: wait for the pending operations of the subscriber to be resolved."`},
+		},
+		{
+			name:             "service returns QuotaExceededError",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: &service.QuotaExceededError{Scope: service.QuotaScopeOperations, Caller: "sub1", Period: service.QuotaPeriodDay, Limit: 20, Reset: time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)}},
+			wantStatusCode:   http.StatusTooManyRequests,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeQuotaExceeded), `"message":"Request quota exceeded: the operations quota of 20 per day is used up until 2025-06-11T00:00:00Z."`},
+		},
+		{
+			name:             "service returns ValidationError",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: &model.ValidationError{Fields: []model.FieldError{{Field: "url", Message: "is required"}, {Field: "key_id", Message: "is required"}}}},
+			wantStatusCode:   http.StatusBadRequest,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeValidationError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeBadRequest), `"message":"url is required; key_id is required"`, `"path":"url"`, `"fields":[{"field":"url","message":"is required"},{"field":"key_id","message":"is required"}]`},
+		},
 		{
 			name:             "service returns generic error",
 			requestBody:      defaultSubReqBytes,
@@ -312,6 +373,49 @@ func TestSubscriptionHandler_Create_Error(t *testing.T) {
 	}
 }
 
+func TestSubscriptionHandler_Create_Attestation(t *testing.T) {
+	subReqBytes, _ := json.Marshal(model.SubscriptionRequest{MessageID: "test-msg-id"})
+	tests := []struct {
+		name           string
+		verifyErr      error
+		wantStatusCode int
+		wantCode       model.ErrorCode
+		wantCreated    bool
+	}{
+		{name: "accepted", wantStatusCode: http.StatusOK, wantCreated: true},
+		{name: "missing token", verifyErr: service.ErrAttestationRequired, wantStatusCode: http.StatusForbidden, wantCode: model.ErrorCodeAttestationFailed},
+		{name: "rejected token", verifyErr: fmt.Errorf("%w: score 0.1 is below 0.5", service.ErrAttestationFailed), wantStatusCode: http.StatusForbidden, wantCode: model.ErrorCodeAttestationFailed},
+		{name: "verifier unavailable", verifyErr: fmt.Errorf("%w: timeout", service.ErrAttestationUnavailable), wantStatusCode: http.StatusServiceUnavailable, wantCode: model.ErrorCodeServiceOverloaded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subSrv := &mockSubscriptionService{lro: &model.LRO{OperationID: "test-op-id"}}
+			verifier := &mockAttestationVerifier{err: tt.verifyErr}
+			handler, _ := NewSubscriptionHandler(subSrv, &mockAuthenticator{})
+			handler.SetAttestation(verifier)
+
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", bytes.NewBuffer(subReqBytes))
+			req.Header.Set("X-Attestation-Token", "token")
+			rr := httptest.NewRecorder()
+			handler.Create(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("Create() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			if subSrv.created != tt.wantCreated {
+				t.Errorf("Create() called service = %v, want %v", subSrv.created, tt.wantCreated)
+			}
+			if verifier.token != "token" || verifier.remoteAddr != req.RemoteAddr {
+				t.Errorf("Verify() called with (%q, %q), want (%q, %q)", verifier.token, verifier.remoteAddr, "token", req.RemoteAddr)
+			}
+			if tt.wantCode != "" && !strings.Contains(rr.Body.String(), fmt.Sprintf(`"code":"%s"`, tt.wantCode)) {
+				t.Errorf("Create() body does not contain code %q. Body: %s", tt.wantCode, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestSubscriptionHandler_Update_Success(t *testing.T) {
 	defaultSubReq := model.SubscriptionRequest{
 		Subscription: model.Subscription{
@@ -428,6 +532,18 @@ func TestSubscriptionHandler_Update_Error(t *testing.T) {
 			wantContentType:  "application/json",
 			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeDuplicateRequest), `"message":"Duplicate request: An operation with this message_id already exists or is in progress for update."`},
 		},
+		{
+			name: "service returns ErrNonceReplayed after successful auth",
+			requestSetup: func(r *http.Request) {
+				r.Header.Set("Authorization", validAuthHeader)
+				r.Body = io.NopCloser(bytes.NewBuffer(defaultSubReqBytes))
+			},
+			auth:             mockAuth,
+			subSrv:           &mockSubscriptionService{updateErr: fmt.Errorf("invalid nonce: %w", repository.ErrNonceReplayed)},
+			wantStatusCode:   http.StatusConflict,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeNonceReplayed)},
+		},
 		{
 			name: "service returns generic error after successful auth (mocking auth success)",
 			requestSetup: func(r *http.Request) {
@@ -444,7 +560,7 @@ func TestSubscriptionHandler_Update_Error(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := &subscriptionHandler{tt.subSrv, tt.auth}
+			handler := &subscriptionHandler{subService: tt.subSrv, auth: tt.auth}
 			req := httptest.NewRequest(http.MethodPatch, "/subscribe", nil)
 			tt.requestSetup(req)
 			rr := httptest.NewRecorder()
@@ -468,3 +584,35 @@ func TestSubscriptionHandler_Update_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteRequestQuotaError(t *testing.T) {
+	reset := time.Now().Add(90 * time.Second).Truncate(time.Second)
+	rr := httptest.NewRecorder()
+	err := fmt.Errorf("lookup rejected: %w", &service.QuotaExceededError{Scope: service.QuotaScopeLookups, Caller: "192.0.2.1", Period: service.QuotaPeriodHour, Limit: 100, Reset: reset})
+	if !writeRequestQuotaError(rr, err) {
+		t.Fatal("writeRequestQuotaError() = false, want true")
+	}
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	wantHeaders := map[string]string{
+		"X-RateLimit-Limit":     "100",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+	}
+	for k, want := range wantHeaders {
+		if got := rr.Header().Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if got, _ := strconv.Atoi(rr.Header().Get("Retry-After")); got < 89 || got > 90 {
+		t.Errorf("Retry-After = %q, want about 90", rr.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(rr.Body.String(), string(model.ErrorCodeQuotaExceeded)) {
+		t.Errorf("body = %s, want error code %s", rr.Body.String(), model.ErrorCodeQuotaExceeded)
+	}
+
+	if writeRequestQuotaError(httptest.NewRecorder(), service.ErrPendingQuotaExceeded) {
+		t.Error("writeRequestQuotaError() = true for another error, want false")
+	}
+}
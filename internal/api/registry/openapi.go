@@ -0,0 +1,85 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/openapi"
+)
+
+// apiSpec annotates the registry routes for the OpenAPI document served at /openapi.json.
+var apiSpec = openapi.Spec{
+	Title:   "ONIX Registry API",
+	Version: "1.0.0",
+	Error:   model.ErrorResponse{},
+	Endpoints: map[string]openapi.Endpoint{
+		"POST /subscribe": {
+			ID:        "createSubscription",
+			Summary:   "Request a new subscription. The request must be signed by the network participant. If attestation is configured, it must carry an attestation token.",
+			Request:   model.SubscriptionRequest{},
+			Responses: map[int]any{http.StatusOK: model.SubscriptionResponse{}},
+		},
+		"PATCH /subscribe": {
+			ID:        "updateSubscription",
+			Summary:   "Request an update of an existing subscription.",
+			Request:   model.SubscriptionRequest{},
+			Responses: map[int]any{http.StatusOK: model.SubscriptionResponse{}},
+		},
+		"POST /lookup": {
+			ID:      "lookup",
+			Summary: "Look up the subscriptions matching the non-empty fields of the request, or of any request in an array. With updated_after, only the changes to the results since that time are returned.",
+			Request: model.Subscription{},
+			Query:   []openapi.Param{{Name: "updated_after", Description: "Returns the subscriptions updated at or after this RFC 3339 timestamp and tombstones of those removed from the results since, as a LookupChanges.", Format: "date-time"}},
+			Responses: map[int]any{
+				http.StatusOK:          openapi.OneOf{[]model.Subscription{}, model.LookupChanges{}},
+				http.StatusNotModified: nil,
+			},
+		},
+		"GET /operations/{operation_id}": {
+			ID:        "getOperation",
+			Summary:   "Get the status of a subscription operation.",
+			Responses: map[int]any{http.StatusOK: model.Operation{}},
+		},
+		"GET /operations/{operation_id}/wait": {
+			ID:        "waitOperation",
+			Summary:   "Wait until a subscription operation is done or the timeout has passed, and get its status.",
+			Query:     []openapi.Param{{Name: "timeout", Description: "How long to wait, e.g. 30s. Defaults to 30s and is capped at 60s.", Type: "string"}},
+			Responses: map[int]any{http.StatusOK: model.Operation{}},
+		},
+		"GET /domains": {
+			ID:        "listDomains",
+			Summary:   "List the domains of the network with their inherited metadata.",
+			Responses: map[int]any{http.StatusOK: []model.Domain{}},
+		},
+		"GET /me/subscriptions": {
+			ID:        "listMySubscriptions",
+			Summary:   "List the subscriptions of the subscriber authenticated by API key.",
+			Responses: map[int]any{http.StatusOK: []model.Subscription{}},
+		},
+		"GET /me/operations": {
+			ID:        "listMyOperations",
+			Summary:   "List the latest operations of the subscriber authenticated by API key.",
+			Query:     []openapi.Param{{Name: "limit", Description: "Number of operations returned.", Type: "integer"}},
+			Responses: map[int]any{http.StatusOK: []model.Operation{}},
+		},
+		"GET /me/operations/{operation_id}": {
+			ID:        "getMyOperation",
+			Summary:   "Get an operation of the subscriber authenticated by API key.",
+			Responses: map[int]any{http.StatusOK: model.Operation{}},
+		},
+	},
+}
@@ -15,11 +15,15 @@
 package registry
 
 import (
+	"expvar"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/openapi"
 )
 
 type subscriptionHandler interface {
@@ -29,17 +33,47 @@ type subscriptionHandler interface {
 
 type lroHandler interface {
 	Get(http.ResponseWriter, *http.Request)
+	Wait(http.ResponseWriter, *http.Request)
 }
 
 type lookupHandler interface {
 	Lookup(http.ResponseWriter, *http.Request)
 }
 
+type apiKeyHandler interface {
+	Authenticate(http.Handler) http.Handler
+	Subscriptions(http.ResponseWriter, *http.Request)
+	Operations(http.ResponseWriter, *http.Request)
+	Operation(http.ResponseWriter, *http.Request)
+}
+
+type maintenanceHandler interface {
+	Advertise(http.Handler) http.Handler
+	ReadOnly(http.Handler) http.Handler
+}
+
+type denylistHandler interface {
+	Enforce(http.Handler) http.Handler
+}
+
+type compressionHandler interface {
+	Compress(http.Handler) http.Handler
+}
+
+type domainHandler interface {
+	List(http.ResponseWriter, *http.Request)
+}
+
 // NewRouter configures and returns the Chi router for the Registry service.
 func NewRouter(
 	sh subscriptionHandler,
 	lh lookupHandler,
 	lroh lroHandler,
+	akh apiKeyHandler,
+	mh maintenanceHandler,
+	dh denylistHandler,
+	ch compressionHandler,
+	domh domainHandler,
 ) *chi.Mux {
 	router := chi.NewRouter()
 
@@ -47,22 +81,49 @@ func NewRouter(
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Logger) // Chi's structured logger
 	router.Use(middleware.Recoverer)
+	router.Use(mh.Advertise)
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, `{"status":"ok"}`)
 	})
+	// Runtime and connection pool metrics published via expvar.
+	router.Handle("/debug/vars", expvar.Handler())
 
 	// Beckn specific routes
 	// Group for routes that might share common Beckn-specific middleware or prefixes
+	// Requests from denylisted subscribers and IPs are dropped before their signature is validated.
 	router.Group(func(r chi.Router) {
-		r.Post("/subscribe", sh.Create)
-		r.Patch("/subscribe", sh.Update)
-		r.Post("/lookup", lh.Lookup)
+		r.Use(dh.Enforce)
+		r.With(mh.ReadOnly).Post("/subscribe", sh.Create)
+		r.With(mh.ReadOnly).Patch("/subscribe", sh.Update)
+		r.With(ch.Compress).Post("/lookup", lh.Lookup)
 	})
 
 	router.Group(func(r chi.Router) {
+		r.Use(dh.Enforce)
 		r.Get("/operations/{operation_id}", lroh.Get)
+		r.Get("/operations/{operation_id}/wait", lroh.Wait)
+		// The domain catalog is public so that onboarding tooling can discover it before subscribing.
+		r.With(ch.Compress).Get("/domains", domh.List)
 	})
+
+	// Read-only routes for subscribers authenticating with an API key instead of a signature.
+	// Lookup and list responses can grow to hundreds of KB on large networks and are compressed.
+	router.Route("/me", func(r chi.Router) {
+		r.Use(dh.Enforce)
+		r.Use(akh.Authenticate)
+		r.With(ch.Compress).Get("/subscriptions", akh.Subscriptions)
+		r.With(ch.Compress).Get("/operations", akh.Operations)
+		r.Get("/operations/{operation_id}", akh.Operation)
+	})
+
+	// The OpenAPI document is generated from the routes registered above.
+	doc, err := openapi.Generate(apiSpec, router)
+	if err != nil {
+		slog.Error("RegistryRouter: Failed to generate OpenAPI document", "error", err)
+		return router
+	}
+	router.Get("/openapi.json", doc.ServeHTTP)
 	return router
 }
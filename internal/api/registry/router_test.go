@@ -15,12 +15,16 @@
 package registry
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/openapi"
 )
 
 // mockSubscriptionHandler is a mock implementation of the subscriptionHandler interface.
@@ -52,6 +56,7 @@ func (m *mockLookupHandler) Lookup(w http.ResponseWriter, r *http.Request) {
 // mockLROHandler is a mock implementation of the lroHandler interface.
 type mockLROHandler struct {
 	getCalled   bool
+	waitCalled  bool
 	operationID string
 }
 
@@ -61,12 +66,109 @@ func (m *mockLROHandler) Get(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockLROHandler) Wait(w http.ResponseWriter, r *http.Request) {
+	m.waitCalled = true
+	m.operationID = chi.URLParam(r, "operation_id")
+	w.WriteHeader(http.StatusOK)
+}
+
+// mockAPIKeyHandler is a mock implementation of the apiKeyHandler interface.
+type mockAPIKeyHandler struct {
+	authenticated       bool
+	subscriptionsCalled bool
+	operationsCalled    bool
+	operationCalled     bool
+	operationID         string
+}
+
+func (m *mockAPIKeyHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.authenticated = true
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *mockAPIKeyHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	m.subscriptionsCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockAPIKeyHandler) Operations(w http.ResponseWriter, r *http.Request) {
+	m.operationsCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockAPIKeyHandler) Operation(w http.ResponseWriter, r *http.Request) {
+	m.operationCalled = true
+	m.operationID = chi.URLParam(r, "operation_id")
+	w.WriteHeader(http.StatusOK)
+}
+
+// mockMaintenanceHandler is a mock implementation of the maintenanceHandler interface.
+type mockMaintenanceHandler struct {
+	readOnly bool
+}
+
+func (m *mockMaintenanceHandler) Advertise(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.readOnly {
+			w.Header().Set("X-Onix-Maintenance", "read-only")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *mockMaintenanceHandler) ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.readOnly {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mockDenylistHandler is a mock implementation of the denylistHandler interface.
+type mockDenylistHandler struct {
+	deny bool
+}
+
+func (m *mockDenylistHandler) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.deny {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mockCompressionHandler is a mock implementation of the compressionHandler interface.
+type mockCompressionHandler struct{}
+
+func (m *mockCompressionHandler) Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Compressed", "true")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mockDomainHandler is a mock implementation of the domainHandler interface.
+type mockDomainHandler struct {
+	listCalled bool
+}
+
+func (m *mockDomainHandler) List(w http.ResponseWriter, r *http.Request) {
+	m.listCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 func TestNewRouter_Initialization(t *testing.T) {
 	sh := &mockSubscriptionHandler{}
 	lh := &mockLookupHandler{}
 	lroh := &mockLROHandler{}
 
-	router := NewRouter(sh, lh, lroh)
+	router := NewRouter(sh, lh, lroh, &mockAPIKeyHandler{}, &mockMaintenanceHandler{}, &mockDenylistHandler{}, &mockCompressionHandler{}, &mockDomainHandler{})
 
 	if router == nil {
 		t.Fatal("New() returned nil, expected a chi.Mux router")
@@ -77,7 +179,7 @@ func TestRouter_Middleware_Recoverer(t *testing.T) {
 	sh := &mockSubscriptionHandler{}
 	lh := &mockLookupHandler{}
 	lroh := &mockLROHandler{}
-	router := NewRouter(sh, lh, lroh)
+	router := NewRouter(sh, lh, lroh, &mockAPIKeyHandler{}, &mockMaintenanceHandler{}, &mockDenylistHandler{}, &mockCompressionHandler{}, &mockDomainHandler{})
 
 	// Add a temporary route that panics
 	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
@@ -102,8 +204,10 @@ func TestRouter_Routes(t *testing.T) {
 	sh := &mockSubscriptionHandler{}
 	lh := &mockLookupHandler{}
 	lroh := &mockLROHandler{}
+	akh := &mockAPIKeyHandler{}
+	domh := &mockDomainHandler{}
 
-	router := NewRouter(sh, lh, lroh)
+	router := NewRouter(sh, lh, lroh, akh, &mockMaintenanceHandler{}, &mockDenylistHandler{}, &mockCompressionHandler{}, domh)
 
 	tests := []struct {
 		name           string
@@ -123,6 +227,14 @@ func TestRouter_Routes(t *testing.T) {
 			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
 			handlerCheck:   func(t *testing.T) { /* No specific handler mock to check */ },
 		},
+		{
+			name:            "DebugVars",
+			method:          http.MethodGet,
+			path:            "/debug/vars",
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			handlerCheck:    func(t *testing.T) {},
+		},
 		{
 			name:           "SubscribeCreate",
 			method:         http.MethodPost,
@@ -170,6 +282,67 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "WaitLRO",
+			method:         http.MethodGet,
+			path:           "/operations/op123/wait",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !lroh.waitCalled {
+					t.Error("lroHandler.Wait was not called")
+				}
+				if lroh.operationID != "op123" {
+					t.Errorf("lroHandler.Wait received wrong operation_id: got %q, want %q", lroh.operationID, "op123")
+				}
+			},
+		},
+		{
+			name:           "MySubscriptions",
+			method:         http.MethodGet,
+			path:           "/me/subscriptions",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !akh.authenticated || !akh.subscriptionsCalled {
+					t.Error("apiKeyHandler.Subscriptions was not called behind Authenticate")
+				}
+			},
+		},
+		{
+			name:           "MyOperations",
+			method:         http.MethodGet,
+			path:           "/me/operations",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !akh.authenticated || !akh.operationsCalled {
+					t.Error("apiKeyHandler.Operations was not called behind Authenticate")
+				}
+			},
+		},
+		{
+			name:           "MyOperation",
+			method:         http.MethodGet,
+			path:           "/me/operations/op123",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !akh.authenticated || !akh.operationCalled {
+					t.Error("apiKeyHandler.Operation was not called behind Authenticate")
+				}
+				if akh.operationID != "op123" {
+					t.Errorf("apiKeyHandler.Operation received wrong operation_id: got %q, want %q", akh.operationID, "op123")
+				}
+			},
+		},
+		{
+			name:           "Domains",
+			method:         http.MethodGet,
+			path:           "/domains",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !domh.listCalled {
+					t.Error("domainHandler.List was not called")
+				}
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -177,7 +350,8 @@ func TestRouter_Routes(t *testing.T) {
 			// Reset mock states for each test
 			sh.createCalled, sh.updateCalled = false, false
 			lh.lookupCalled = false
-			lroh.getCalled, lroh.operationID = false, ""
+			lroh.getCalled, lroh.waitCalled, lroh.operationID = false, false, ""
+			*akh = mockAPIKeyHandler{}
 
 			req := httptest.NewRequest(tc.method, tc.path, nil)
 			rr := httptest.NewRecorder()
@@ -204,3 +378,145 @@ func TestRouter_Routes(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_Maintenance(t *testing.T) {
+	sh := &mockSubscriptionHandler{}
+	lh := &mockLookupHandler{}
+	router := NewRouter(sh, lh, &mockLROHandler{}, &mockAPIKeyHandler{}, &mockMaintenanceHandler{readOnly: true}, &mockDenylistHandler{}, &mockCompressionHandler{}, &mockDomainHandler{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "create rejected", method: http.MethodPost, path: "/subscribe", wantStatus: http.StatusServiceUnavailable},
+		{name: "update rejected", method: http.MethodPatch, path: "/subscribe", wantStatus: http.StatusServiceUnavailable},
+		{name: "lookup allowed", method: http.MethodPost, path: "/lookup", wantStatus: http.StatusOK},
+		{name: "operation allowed", method: http.MethodGet, path: "/operations/op1", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if got := rr.Header().Get("X-Onix-Maintenance"); got != "read-only" {
+				t.Errorf("X-Onix-Maintenance = %q, want %q", got, "read-only")
+			}
+		})
+	}
+	if sh.createCalled || sh.updateCalled {
+		t.Error("subscription handler was called during maintenance")
+	}
+	if !lh.lookupCalled {
+		t.Error("lookup handler was not called during maintenance")
+	}
+}
+
+func TestRouter_Denylist(t *testing.T) {
+	sh := &mockSubscriptionHandler{}
+	lh := &mockLookupHandler{}
+	router := NewRouter(sh, lh, &mockLROHandler{}, &mockAPIKeyHandler{}, &mockMaintenanceHandler{}, &mockDenylistHandler{deny: true}, &mockCompressionHandler{}, &mockDomainHandler{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "create dropped", method: http.MethodPost, path: "/subscribe", wantStatus: http.StatusForbidden},
+		{name: "update dropped", method: http.MethodPatch, path: "/subscribe", wantStatus: http.StatusForbidden},
+		{name: "lookup dropped", method: http.MethodPost, path: "/lookup", wantStatus: http.StatusForbidden},
+		{name: "operation dropped", method: http.MethodGet, path: "/operations/op1", wantStatus: http.StatusForbidden},
+		{name: "api key route dropped", method: http.MethodGet, path: "/me/subscriptions", wantStatus: http.StatusForbidden},
+		{name: "domains dropped", method: http.MethodGet, path: "/domains", wantStatus: http.StatusForbidden},
+		{name: "health allowed", method: http.MethodGet, path: "/health", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+		})
+	}
+	if sh.createCalled || sh.updateCalled || lh.lookupCalled {
+		t.Error("handler was called for a denylisted request")
+	}
+}
+
+func TestRouter_Compression(t *testing.T) {
+	router := NewRouter(&mockSubscriptionHandler{}, &mockLookupHandler{}, &mockLROHandler{}, &mockAPIKeyHandler{}, &mockMaintenanceHandler{}, &mockDenylistHandler{}, &mockCompressionHandler{}, &mockDomainHandler{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   bool
+	}{
+		{name: "lookup compressed", method: http.MethodPost, path: "/lookup", want: true},
+		{name: "subscription list compressed", method: http.MethodGet, path: "/me/subscriptions", want: true},
+		{name: "operation list compressed", method: http.MethodGet, path: "/me/operations", want: true},
+		{name: "domains compressed", method: http.MethodGet, path: "/domains", want: true},
+		{name: "single operation not compressed", method: http.MethodGet, path: "/me/operations/op1", want: false},
+		{name: "subscribe not compressed", method: http.MethodPost, path: "/subscribe", want: false},
+		{name: "health not compressed", method: http.MethodGet, path: "/health", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if got := rr.Header().Get("X-Compressed") == "true"; got != tc.want {
+				t.Errorf("compressed = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRouter_OpenAPI(t *testing.T) {
+	router := NewRouter(&mockSubscriptionHandler{}, &mockLookupHandler{}, &mockLROHandler{}, &mockAPIKeyHandler{}, &mockMaintenanceHandler{}, &mockDenylistHandler{}, &mockCompressionHandler{}, &mockDomainHandler{})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var doc openapi.Document
+	if err := json.NewDecoder(rr.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+
+	// Every API route must be documented.
+	undocumented := map[string]bool{"/health": true, "/debug/vars": true, "/openapi.json": true}
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if undocumented[route] {
+			return nil
+		}
+		if doc.Paths[route][strings.ToLower(method)] == nil {
+			t.Errorf("Route %s %s is not documented", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk() error = %v", err)
+	}
+	lookup := doc.Paths["/lookup"]["post"].Responses["200"].Content["application/json"].Schema
+	if len(lookup.OneOf) != 2 {
+		t.Fatalf("POST /lookup response has %d alternatives, want 2", len(lookup.OneOf))
+	}
+	if got := lookup.OneOf[0].Items.Ref; got != "#/components/schemas/Subscription" {
+		t.Errorf("POST /lookup response items = %q, want the Subscription schema", got)
+	}
+	if got := lookup.OneOf[1].Ref; got != "#/components/schemas/LookupChanges" {
+		t.Errorf("POST /lookup incremental response = %q, want the LookupChanges schema", got)
+	}
+}
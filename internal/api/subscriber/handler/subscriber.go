@@ -18,11 +18,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
 )
 
 // subscriberService defines the interface for subscription-related business logic.
@@ -31,11 +37,24 @@ type subscriberService interface {
 	UpdateSubscription(ctx context.Context, req *model.NpSubscriptionRequest) (string, error)
 	UpdateStatus(ctx context.Context, opID string) (model.LROStatus, error)
 	OnSubscribe(ctx context.Context, req *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error)
+	Status(ctx context.Context) *model.SubscriberStatus
+	AuthorizeUndelete(token string) error
+	UndeleteKeyset(ctx context.Context, keyID string) error
+	AuthorizeRotation(token string) error
+	RotateKeys(ctx context.Context, req *model.NpSubscriptionRequest) (string, error)
+	AuthorizeChallengeRecords(token string) error
+	ChallengeRecords() ([]model.ChallengeRecord, error)
+}
+
+// registryAuthValidator validates the registry's signature on the requests it sends.
+type registryAuthValidator interface {
+	Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError
 }
 
 // subscriberHandler handles HTTP requests for subscriber operations.
 type subscriberHandler struct {
-	srv subscriberService
+	srv          subscriberService
+	registryAuth registryAuthValidator
 }
 
 // NewSubscriberHandler creates a new subscriberHandler.
@@ -47,6 +66,12 @@ func NewSubscriberHandler(srv subscriberService) (*subscriberHandler, error) {
 	return &subscriberHandler{srv: srv}, nil
 }
 
+// SetRegistryAuth validates the registry's signature on /on_subscribe requests before the
+// challenge is decrypted.
+func (h *subscriberHandler) SetRegistryAuth(v registryAuthValidator) {
+	h.registryAuth = v
+}
+
 // writeSubscriberJSONError is a helper function to construct and write standardized JSON error responses.
 func writeSubscriberJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -63,6 +88,49 @@ func writeSubscriberJSONError(w http.ResponseWriter, statusCode int, errType mod
 	}
 }
 
+// writeSubscriberValidationError writes a 400 Bad Request response listing the invalid fields
+// of a request if err is a validation error. It reports whether a response was written.
+func writeSubscriberValidationError(w http.ResponseWriter, err error) bool {
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(verr.ErrorResponse(model.ErrorCodeBadRequest)); err != nil {
+		slog.Error("SubscriberHandler: Failed to encode error response", "error", err)
+	}
+	return true
+}
+
+// challengeErrors maps the causes of a failure to decrypt an /on_subscribe challenge to their responses.
+// Causes the registry can act on are reported as 400 Bad Request, misconfigured NP keys as 500.
+var challengeErrors = []struct {
+	err        error
+	statusCode int
+	errType    model.ErrorType
+	errCode    model.ErrorCode
+	msg        string
+}{
+	{service.ErrChallengeEncoding, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeChallengeEncoding, "The challenge is not valid base64."},
+	{service.ErrChallengeCorrupted, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeChallengeCorrupted, "The challenge ciphertext is truncated or corrupted."},
+	{service.ErrChallengeKeyMismatch, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeChallengeKeyMismatch, "The challenge was not encrypted for the encryption key of this operation and the configured registry key. Check that the subscription was submitted with this keyset and that regKeyID names the registry's current key."},
+	{service.ErrChallengeRegistryKey, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeChallengeRegistryKey, "The registry's encryption key could not be resolved. Check regID and regKeyID."},
+	{service.ErrChallengePrivateKey, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeChallengePrivateKey, "The encryption private key of this operation's keyset is invalid."},
+}
+
+// writeChallengeError writes the response for a challenge that could not be decrypted if err
+// identifies its cause. It reports whether a response was written.
+func writeChallengeError(w http.ResponseWriter, err error) bool {
+	for _, ce := range challengeErrors {
+		if errors.Is(err, ce.err) {
+			writeSubscriberJSONError(w, ce.statusCode, ce.errType, ce.errCode, ce.msg)
+			return true
+		}
+	}
+	return false
+}
+
 // CreateSubscription handles POST /subscribe requests.
 func (h *subscriberHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -79,6 +147,9 @@ func (h *subscriberHandler) CreateSubscription(w http.ResponseWriter, r *http.Re
 	operationID, err := h.srv.CreateSubscription(ctx, &req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberHandler: Error creating subscription", "error", err)
+		if writeSubscriberValidationError(w, err) {
+			return
+		}
 		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
 
 		return
@@ -107,6 +178,9 @@ func (h *subscriberHandler) UpdateSubscription(w http.ResponseWriter, r *http.Re
 	lroID, err := h.srv.UpdateSubscription(ctx, &req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberHandler: Error updating subscription", "error", err)
+		if writeSubscriberValidationError(w, err) {
+			return
+		}
 		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
 		return
 	}
@@ -146,17 +220,33 @@ func (h *subscriberHandler) OnSubscribe(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	var req model.OnSubscribeRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.ErrorContext(ctx, "SubscriberHandler: Failed to decode on_subscribe request", "error", err)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to read on_subscribe request", "error", err)
 		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
 		return
 	}
 	defer r.Body.Close()
+	if h.registryAuth != nil {
+		if authErr := h.registryAuth.Validate(ctx, body, r.Header.Get(model.AuthHeaderSubscriber)); authErr != nil {
+			slog.ErrorContext(ctx, "SubscriberHandler: Registry signature validation failed", "error", authErr)
+			writeSubscriberJSONError(w, authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message)
+			return
+		}
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to decode on_subscribe request", "error", err)
+		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
 
 	slog.InfoContext(ctx, "SubscriberHandler: Received on_subscribe request", "message_id", req.MessageID)
 	resp, err := h.srv.OnSubscribe(ctx, &req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberHandler: Error processing on_subscribe request", "message_id", req.MessageID, "error", err)
+		if writeSubscriberValidationError(w, err) || writeChallengeError(w, err) {
+			return
+		}
 		// Beckn spec usually expects an ACK/NACK for /on_subscribe, but here we're returning the error directly.
 		// For a more compliant Beckn error, you might return a NACK with an error object.
 		writeSubscriberJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process on_subscribe: "+err.Error())
@@ -169,3 +259,159 @@ func (h *subscriberHandler) OnSubscribe(w http.ResponseWriter, r *http.Request)
 		slog.ErrorContext(ctx, "SubscriberHandler: Failed to encode on_subscribe response", "error", err, "message_id", req.MessageID)
 	}
 }
+
+// UndeleteKeyset handles POST /keys/undelete requests to recover a soft deleted keyset.
+// It requires the configured keyset undelete token as a bearer token.
+func (h *subscriberHandler) UndeleteKeyset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+	if err := h.srv.AuthorizeUndelete(token); err != nil {
+		slog.WarnContext(ctx, "SubscriberHandler: Unauthorized undelete keyset request", "error", err)
+		if errors.Is(err, service.ErrKeyUndeleteDisabled) {
+			writeSubscriberJSONError(w, http.StatusForbidden, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, err.Error())
+			return
+		}
+		writeSubscriberJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, err.Error())
+		return
+	}
+
+	var req model.UndeleteKeysetRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to decode undelete keyset request", "error", err)
+		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	slog.InfoContext(ctx, "SubscriberHandler: Received undelete keyset request", "key_id", req.KeyID)
+	if err := h.srv.UndeleteKeyset(ctx, req.KeyID); err != nil {
+		var badReq *becknmodel.BadReqErr
+		if errors.Is(err, service.ErrMissingKeyID) || errors.Is(err, keymanager.ErrUndeleteNotSupported) || errors.As(err, &badReq) {
+			writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+			return
+		}
+		writeSubscriberJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to undelete keyset: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// RotateKeys handles POST /keys/rotate requests. It requires the configured key rotation
+// token as a bearer token and starts a rotation that completes once the registry approves it.
+func (h *subscriberHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+	if err := h.srv.AuthorizeRotation(token); err != nil {
+		slog.WarnContext(ctx, "SubscriberHandler: Unauthorized key rotation request", "error", err)
+		if errors.Is(err, service.ErrKeyRotationDisabled) {
+			writeSubscriberJSONError(w, http.StatusForbidden, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, err.Error())
+			return
+		}
+		writeSubscriberJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, err.Error())
+		return
+	}
+
+	var req model.NpSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to decode key rotation request", "error", err)
+		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	slog.InfoContext(ctx, "SubscriberHandler: Received key rotation request", "subscriber_id", req.SubscriberID)
+	lroID, err := h.srv.RotateKeys(ctx, &req)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Error rotating keys", "error", err)
+		if writeSubscriberValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrRotationInProgress):
+			writeSubscriberJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, err.Error())
+		case errors.Is(err, service.ErrKeyGenerationFailed), errors.Is(err, service.ErrKeyStoreFailed), errors.Is(err, service.ErrKeyFetchFailed), errors.Is(err, service.ErrRegistryOperationFailed):
+			writeSubscriberJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to rotate keys: "+err.Error())
+		default:
+			writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted) // 202 Accepted for LRO
+	if err := json.NewEncoder(w).Encode(lroID); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to encode LRO response for key rotation", "error", err, "message_id", lroID)
+	}
+}
+
+// bearerToken returns the bearer token of the request. If the request has none,
+// it writes a 401 Unauthorized response and returns false.
+func bearerToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		writeSubscriberJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, "Authorization header is required")
+		return "", false
+	}
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok {
+		writeSubscriberJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Authorization header must be a bearer token")
+		return "", false
+	}
+	return token, true
+}
+
+// ChallengeRecords handles GET /debug/on_subscribe requests. It requires the configured
+// challenge recorder token as a bearer token and responds with the most recent
+// on_subscribe exchanges, with private keys redacted.
+func (h *subscriberHandler) ChallengeRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+	if err := h.srv.AuthorizeChallengeRecords(token); err != nil {
+		slog.WarnContext(ctx, "SubscriberHandler: Unauthorized challenge records request", "error", err)
+		if errors.Is(err, service.ErrChallengeRecorderDisabled) {
+			writeSubscriberJSONError(w, http.StatusForbidden, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, err.Error())
+			return
+		}
+		writeSubscriberJSONError(w, http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, err.Error())
+		return
+	}
+	records, err := h.srv.ChallengeRecords()
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to get challenge records", "error", err)
+		writeSubscriberJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to encode challenge records", "error", err)
+	}
+}
+
+// Status handles GET /status requests from NP operators' monitoring.
+// It responds with 503 Service Unavailable when a dependency of the service is unhealthy.
+func (h *subscriberHandler) Status(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	status := h.srv.Status(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	if status.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to encode status response", "error", err)
+	}
+}
@@ -19,15 +19,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/google/go-cmp/cmp"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
 )
 
 // failingResponseWriter is a custom http.ResponseWriter that fails on Write,
@@ -52,6 +57,16 @@ type mockSubscriberService struct {
 	updateStatusErr error
 	onSubscribeResp *model.OnSubscribeResponse
 	onSubscribeErr  error
+	status          *model.SubscriberStatus
+	undeleteAuthErr error
+	undeleteErr     error
+	undeletedKeyID  string
+	authorizeErr    error
+	rotateLroID     string
+	rotateErr       error
+	recordsAuthErr  error
+	records         []model.ChallengeRecord
+	recordsErr      error
 }
 
 func (m *mockSubscriberService) CreateSubscription(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
@@ -70,6 +85,35 @@ func (m *mockSubscriberService) OnSubscribe(ctx context.Context, req *model.OnSu
 	return m.onSubscribeResp, m.onSubscribeErr
 }
 
+func (m *mockSubscriberService) Status(ctx context.Context) *model.SubscriberStatus {
+	return m.status
+}
+
+func (m *mockSubscriberService) AuthorizeUndelete(token string) error {
+	return m.undeleteAuthErr
+}
+
+func (m *mockSubscriberService) UndeleteKeyset(ctx context.Context, keyID string) error {
+	m.undeletedKeyID = keyID
+	return m.undeleteErr
+}
+
+func (m *mockSubscriberService) AuthorizeRotation(token string) error {
+	return m.authorizeErr
+}
+
+func (m *mockSubscriberService) RotateKeys(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
+	return m.rotateLroID, m.rotateErr
+}
+
+func (m *mockSubscriberService) AuthorizeChallengeRecords(token string) error {
+	return m.recordsAuthErr
+}
+
+func (m *mockSubscriberService) ChallengeRecords() ([]model.ChallengeRecord, error) {
+	return m.records, m.recordsErr
+}
+
 // TestNewSubscriberHandler_Success tests successful creation of SubscriberHandler.
 func TestNewSubscriberHandler_Success(t *testing.T) {
 	mockSrv := &mockSubscriberService{}
@@ -236,6 +280,16 @@ func TestSubscriberHandler_CreateSubscription_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeBadRequest,
 			wantErrorMessage: "service layer error",
 		},
+		{
+			name:        "service returns validation error",
+			requestBody: []byte(`{"subscriber_id":"test"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.createSubErr = &model.ValidationError{Fields: []model.FieldError{{Field: "domain", Message: "is required"}}}
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeBadRequest,
+			wantErrorMessage: "domain is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -530,6 +584,62 @@ func TestSubscriberHandler_OnSubscribe_Success(t *testing.T) {
 	}
 }
 
+// mockRegistryAuth is a mock implementation of registryAuthValidator.
+type mockRegistryAuth struct {
+	err           *model.AuthError
+	gotBody       []byte
+	gotAuthHeader string
+}
+
+func (m *mockRegistryAuth) Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError {
+	m.gotBody = body
+	m.gotAuthHeader = authHeader
+	return m.err
+}
+
+func TestSubscriberHandler_OnSubscribe_RegistryAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		authErr    *model.AuthError
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "valid signature", wantStatus: http.StatusOK},
+		{name: "missing signature", authErr: model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, "Authorization header missing.", "unknown"), wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeMissingAuthHeader},
+		{name: "invalid signature", authErr: model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", "registry.example.com"), wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewSubscriberHandler(&mockSubscriberService{onSubscribeResp: &model.OnSubscribeResponse{Answer: "decrypted-challenge"}})
+			auth := &mockRegistryAuth{err: tt.authErr}
+			handler.SetRegistryAuth(auth)
+
+			reqBytes := []byte(`{"message_id":"msg-123","challenge":"encrypted-challenge"}`)
+			req := httptest.NewRequest(http.MethodPost, "/on_subscribe", bytes.NewBuffer(reqBytes))
+			req.Header.Set(model.AuthHeaderSubscriber, "Signature registry")
+			rr := httptest.NewRecorder()
+			handler.OnSubscribe(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("OnSubscribe() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if string(auth.gotBody) != string(reqBytes) || auth.gotAuthHeader != "Signature registry" {
+				t.Errorf("Validate() got body %s and header %q", auth.gotBody, auth.gotAuthHeader)
+			}
+			if tt.wantCode != "" {
+				var errResp model.ErrorResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &errResp); err != nil {
+					t.Fatalf("Failed to unmarshal error response: %v", err)
+				}
+				if errResp.Error.Code != tt.wantCode {
+					t.Errorf("OnSubscribe() error code = %q, want %q", errResp.Error.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
 // TestSubscriberHandler_OnSubscribe_Error tests error cases.
 func TestSubscriberHandler_OnSubscribe_Error(t *testing.T) {
 	tests := []struct {
@@ -558,6 +668,66 @@ func TestSubscriberHandler_OnSubscribe_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeInternalServerError,
 			wantErrorMessage: "Failed to process on_subscribe: on_subscribe processing failed",
 		},
+		{
+			name:        "service returns validation error",
+			requestBody: []byte(`{"message_id":"msg-123"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.onSubscribeErr = &model.ValidationError{Fields: []model.FieldError{{Field: "challenge", Message: "is required"}}}
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeBadRequest,
+			wantErrorMessage: "challenge is required",
+		},
+		{
+			name:        "challenge not base64",
+			requestBody: []byte(`{"message_id":"msg-123"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.onSubscribeErr = fmt.Errorf("failed to decrypt challenge for message_id msg-123: %w: %w", errors.New("illegal base64 data"), service.ErrChallengeEncoding)
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeChallengeEncoding,
+			wantErrorMessage: "not valid base64",
+		},
+		{
+			name:        "challenge corrupted",
+			requestBody: []byte(`{"message_id":"msg-123"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.onSubscribeErr = fmt.Errorf("failed to decrypt challenge for message_id msg-123: %w: %w", errors.New("ciphertext is not a multiple of the blocksize"), service.ErrChallengeCorrupted)
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeChallengeCorrupted,
+			wantErrorMessage: "truncated or corrupted",
+		},
+		{
+			name:        "challenge key mismatch",
+			requestBody: []byte(`{"message_id":"msg-123"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.onSubscribeErr = fmt.Errorf("failed to decrypt challenge for message_id msg-123: %w: %w", errors.New("failed to unpad data"), service.ErrChallengeKeyMismatch)
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeChallengeKeyMismatch,
+			wantErrorMessage: "regKeyID",
+		},
+		{
+			name:        "registry key not found",
+			requestBody: []byte(`{"message_id":"msg-123"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.onSubscribeErr = fmt.Errorf("registry public key not found for message_id msg-123: %w", service.ErrChallengeRegistryKey)
+			},
+			wantStatusCode:   http.StatusInternalServerError,
+			wantErrorCode:    model.ErrorCodeChallengeRegistryKey,
+			wantErrorMessage: "Check regID and regKeyID",
+		},
+		{
+			name:        "invalid encryption private key",
+			requestBody: []byte(`{"message_id":"msg-123"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.onSubscribeErr = fmt.Errorf("failed to decrypt challenge for message_id msg-123: %w: %w", errors.New("invalid private key"), service.ErrChallengePrivateKey)
+			},
+			wantStatusCode:   http.StatusInternalServerError,
+			wantErrorCode:    model.ErrorCodeChallengePrivateKey,
+			wantErrorMessage: "private key",
+		},
 	}
 
 	for _, tt := range tests {
@@ -589,3 +759,374 @@ func TestSubscriberHandler_OnSubscribe_Error(t *testing.T) {
 		})
 	}
 }
+
+// TestSubscriberHandler_Status tests the status report and its status code.
+func TestSubscriberHandler_Status(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         *model.SubscriberStatus
+		wantStatusCode int
+	}{
+		{
+			name: "healthy",
+			status: &model.SubscriberStatus{
+				Healthy:        true,
+				Subscription:   &model.SubscriberSubscriptionStatus{SubscriberID: "sub1", OperationID: "op1", Status: model.LROStatusApproved},
+				Registry:       model.ComponentHealth{Status: model.HealthStatusOK},
+				EventPublisher: model.ComponentHealth{Status: model.HealthStatusOK},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "unhealthy",
+			status: &model.SubscriberStatus{
+				Registry:       model.ComponentHealth{Status: model.HealthStatusError, Error: "connection refused"},
+				EventPublisher: model.ComponentHealth{Status: model.HealthStatusOK},
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewSubscriberHandler(&mockSubscriberService{status: tt.status})
+			req := httptest.NewRequest(http.MethodGet, "/status", nil)
+			rr := httptest.NewRecorder()
+
+			handler.Status(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("Status() status code = %v, want %v", rr.Code, tt.wantStatusCode)
+			}
+			var got model.SubscriberStatus
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal status response: %v. Body: %s", err, rr.Body.String())
+			}
+			if diff := cmp.Diff(tt.status, &got); diff != "" {
+				t.Errorf("Status() response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSubscriberHandler_UndeleteKeyset_Success(t *testing.T) {
+	mockSrv := &mockSubscriberService{}
+	handler, _ := NewSubscriberHandler(mockSrv)
+
+	req := httptest.NewRequest(http.MethodPost, "/keys/undelete", strings.NewReader(`{"key_id":"sub1"}`))
+	req.Header.Set("Authorization", "Bearer undelete-token")
+	rr := httptest.NewRecorder()
+
+	handler.UndeleteKeyset(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("UndeleteKeyset() status code = %v, want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if mockSrv.undeletedKeyID != "sub1" {
+		t.Errorf("UndeleteKeyset() keyID = %q, want %q", mockSrv.undeletedKeyID, "sub1")
+	}
+}
+
+func TestSubscriberHandler_UndeleteKeyset_Error(t *testing.T) {
+	tests := []struct {
+		name           string
+		authHeader     string
+		requestBody    string
+		authorizeErr   error
+		serviceErr     error
+		wantStatusCode int
+		wantErrorCode  model.ErrorCode
+	}{
+		{
+			name:           "missing auth header",
+			requestBody:    `{"key_id":"sub1"}`,
+			wantStatusCode: http.StatusUnauthorized,
+			wantErrorCode:  model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:           "not a bearer token",
+			authHeader:     "Basic dXNlcjpwYXNz",
+			requestBody:    `{"key_id":"sub1"}`,
+			wantStatusCode: http.StatusUnauthorized,
+			wantErrorCode:  model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:           "invalid token",
+			authHeader:     "Bearer wrong",
+			requestBody:    `{"key_id":"sub1"}`,
+			authorizeErr:   service.ErrInvalidUndeleteToken,
+			wantStatusCode: http.StatusUnauthorized,
+			wantErrorCode:  model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:           "undelete disabled",
+			authHeader:     "Bearer undelete-token",
+			requestBody:    `{"key_id":"sub1"}`,
+			authorizeErr:   service.ErrKeyUndeleteDisabled,
+			wantStatusCode: http.StatusForbidden,
+			wantErrorCode:  model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:           "invalid JSON request body",
+			authHeader:     "Bearer undelete-token",
+			requestBody:    "{not-json",
+			wantStatusCode: http.StatusBadRequest,
+			wantErrorCode:  model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:           "missing key id",
+			authHeader:     "Bearer undelete-token",
+			requestBody:    `{}`,
+			serviceErr:     service.ErrMissingKeyID,
+			wantStatusCode: http.StatusBadRequest,
+			wantErrorCode:  model.ErrorCodeBadRequest,
+		},
+		{
+			name:           "undelete not supported",
+			authHeader:     "Bearer undelete-token",
+			requestBody:    `{"key_id":"sub1"}`,
+			serviceErr:     fmt.Errorf("%w: %w", service.ErrKeyRecoveryFailed, keymanager.ErrUndeleteNotSupported),
+			wantStatusCode: http.StatusBadRequest,
+			wantErrorCode:  model.ErrorCodeBadRequest,
+		},
+		{
+			name:           "keyset not recoverable",
+			authHeader:     "Bearer undelete-token",
+			requestBody:    `{"key_id":"sub1"}`,
+			serviceErr:     fmt.Errorf("%w: %w", service.ErrKeyRecoveryFailed, becknmodel.NewBadReqErr(errors.New("not found"))),
+			wantStatusCode: http.StatusBadRequest,
+			wantErrorCode:  model.ErrorCodeBadRequest,
+		},
+		{
+			name:           "backend failure",
+			authHeader:     "Bearer undelete-token",
+			requestBody:    `{"key_id":"sub1"}`,
+			serviceErr:     fmt.Errorf("%w: %w", service.ErrKeyRecoveryFailed, errors.New("permission denied")),
+			wantStatusCode: http.StatusInternalServerError,
+			wantErrorCode:  model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSrv := &mockSubscriberService{undeleteAuthErr: tt.authorizeErr, undeleteErr: tt.serviceErr}
+			handler, _ := NewSubscriberHandler(mockSrv)
+
+			req := httptest.NewRequest(http.MethodPost, "/keys/undelete", strings.NewReader(tt.requestBody))
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.UndeleteKeyset(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("UndeleteKeyset() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			var gotErrorResp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &gotErrorResp); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v. Body: %s", err, rr.Body.String())
+			}
+			if gotErrorResp.Error.Code != tt.wantErrorCode {
+				t.Errorf("UndeleteKeyset() Error.Code = %s, want %s", gotErrorResp.Error.Code, tt.wantErrorCode)
+			}
+			if tt.wantStatusCode == http.StatusUnauthorized || tt.wantStatusCode == http.StatusForbidden {
+				if mockSrv.undeletedKeyID != "" {
+					t.Errorf("UndeleteKeyset() undeleted %q without authorization", mockSrv.undeletedKeyID)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriberHandler_RotateKeys_Success(t *testing.T) {
+	handler, _ := NewSubscriberHandler(&mockSubscriberService{rotateLroID: "op-789"})
+
+	req := httptest.NewRequest(http.MethodPost, "/keys/rotate", strings.NewReader(`{"subscriber_id":"sub1","domain":"test.com","type":"BAP"}`))
+	req.Header.Set("Authorization", "Bearer rotation-token")
+	rr := httptest.NewRecorder()
+
+	handler.RotateKeys(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("RotateKeys() status code = %v, want %v. Body: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+	}
+	var got string
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if got != "op-789" {
+		t.Errorf("RotateKeys() response = %q, want %q", got, "op-789")
+	}
+}
+
+func TestSubscriberHandler_RotateKeys_Error(t *testing.T) {
+	tests := []struct {
+		name           string
+		authHeader     string
+		requestBody    string
+		authorizeErr   error
+		rotateErr      error
+		wantStatusCode int
+		wantErrorCode  model.ErrorCode
+	}{
+		{
+			name:           "missing auth header",
+			requestBody:    `{}`,
+			wantStatusCode: http.StatusUnauthorized,
+			wantErrorCode:  model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:           "not a bearer token",
+			authHeader:     "Basic dXNlcjpwYXNz",
+			requestBody:    `{}`,
+			wantStatusCode: http.StatusUnauthorized,
+			wantErrorCode:  model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:           "invalid token",
+			authHeader:     "Bearer wrong",
+			requestBody:    `{}`,
+			authorizeErr:   service.ErrInvalidRotationToken,
+			wantStatusCode: http.StatusUnauthorized,
+			wantErrorCode:  model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:           "rotation disabled",
+			authHeader:     "Bearer rotation-token",
+			requestBody:    `{}`,
+			authorizeErr:   service.ErrKeyRotationDisabled,
+			wantStatusCode: http.StatusForbidden,
+			wantErrorCode:  model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:           "invalid JSON request body",
+			authHeader:     "Bearer rotation-token",
+			requestBody:    "{not-json",
+			wantStatusCode: http.StatusBadRequest,
+			wantErrorCode:  model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:           "validation error",
+			authHeader:     "Bearer rotation-token",
+			requestBody:    `{}`,
+			rotateErr:      service.ErrMissingSubscriberID,
+			wantStatusCode: http.StatusBadRequest,
+			wantErrorCode:  model.ErrorCodeBadRequest,
+		},
+		{
+			name:           "rotation in progress",
+			authHeader:     "Bearer rotation-token",
+			requestBody:    `{}`,
+			rotateErr:      fmt.Errorf("%w: operation op-1", service.ErrRotationInProgress),
+			wantStatusCode: http.StatusConflict,
+			wantErrorCode:  model.ErrorCodeDuplicateRequest,
+		},
+		{
+			name:           "registry failure",
+			authHeader:     "Bearer rotation-token",
+			requestBody:    `{}`,
+			rotateErr:      fmt.Errorf("%w: registry down", service.ErrRegistryOperationFailed),
+			wantStatusCode: http.StatusInternalServerError,
+			wantErrorCode:  model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewSubscriberHandler(&mockSubscriberService{authorizeErr: tt.authorizeErr, rotateErr: tt.rotateErr})
+
+			req := httptest.NewRequest(http.MethodPost, "/keys/rotate", strings.NewReader(tt.requestBody))
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.RotateKeys(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("RotateKeys() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			var gotErrorResp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &gotErrorResp); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v. Body: %s", err, rr.Body.String())
+			}
+			if gotErrorResp.Error.Code != tt.wantErrorCode {
+				t.Errorf("RotateKeys() Error.Code = %s, want %s", gotErrorResp.Error.Code, tt.wantErrorCode)
+			}
+		})
+	}
+}
+
+func TestSubscriberHandler_ChallengeRecords(t *testing.T) {
+	records := []model.ChallengeRecord{{
+		MessageID:          "op-1",
+		Request:            model.OnSubscribeRequest{MessageID: "op-1", Challenge: "encrypted"},
+		DecryptedChallenge: "answer",
+		Response:           &model.OnSubscribeResponse{Answer: "answer"},
+	}}
+	tests := []struct {
+		name           string
+		authHeader     string
+		srv            *mockSubscriberService
+		wantStatusCode int
+		wantRecords    []model.ChallengeRecord
+	}{
+		{
+			name:           "success",
+			authHeader:     "Bearer debug-token",
+			srv:            &mockSubscriberService{records: records},
+			wantStatusCode: http.StatusOK,
+			wantRecords:    records,
+		},
+		{
+			name:           "missing auth header",
+			srv:            &mockSubscriberService{},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "not a bearer token",
+			authHeader:     "Basic abc",
+			srv:            &mockSubscriberService{},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "recorder disabled",
+			authHeader:     "Bearer debug-token",
+			srv:            &mockSubscriberService{recordsAuthErr: service.ErrChallengeRecorderDisabled},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "invalid token",
+			authHeader:     "Bearer wrong",
+			srv:            &mockSubscriberService{recordsAuthErr: service.ErrInvalidRecorderToken},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewSubscriberHandler(tt.srv)
+			req := httptest.NewRequest(http.MethodGet, "/debug/on_subscribe", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ChallengeRecords(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("ChallengeRecords() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			if tt.wantRecords == nil {
+				return
+			}
+			var got []model.ChallengeRecord
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRecords, got); diff != "" {
+				t.Errorf("ChallengeRecords() response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -29,6 +29,10 @@ type subscriberHandler interface {
 	UpdateSubscription(w http.ResponseWriter, r *http.Request)
 	StatusUpdate(w http.ResponseWriter, r *http.Request)
 	OnSubscribe(w http.ResponseWriter, r *http.Request)
+	Status(w http.ResponseWriter, r *http.Request)
+	UndeleteKeyset(w http.ResponseWriter, r *http.Request)
+	RotateKeys(w http.ResponseWriter, r *http.Request)
+	ChallengeRecords(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRouter configures and returns the Chi router for subscriber service functionalities.
@@ -48,6 +52,10 @@ func NewRouter(sh subscriberHandler) *chi.Mux {
 	router.Post("/subscribe", sh.CreateSubscription)
 	router.Patch("/subscribe", sh.UpdateSubscription) 
 	router.Post("/updateStatus", sh.StatusUpdate)
+	router.Get("/status", sh.Status)
+	router.Post("/keys/undelete", sh.UndeleteKeyset)
+	router.Post("/keys/rotate", sh.RotateKeys)
+	router.Get("/debug/on_subscribe", sh.ChallengeRecords)
 
 	// Catch-all for POST requests to paths ending in /on_subscribe
 	router.Post("/*", func(w http.ResponseWriter, r *http.Request) {
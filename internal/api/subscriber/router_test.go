@@ -28,6 +28,10 @@ type mockSubscriberHandler struct {
 	updateSubscriptionCalled bool
 	statusUpdateCalled       bool
 	onSubscribeCalled        bool
+	statusCalled             bool
+	undeleteKeysetCalled     bool
+	rotateKeysCalled         bool
+	challengeRecordsCalled   bool
 }
 
 func (m *mockSubscriberHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
@@ -50,6 +54,26 @@ func (m *mockSubscriberHandler) OnSubscribe(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockSubscriberHandler) Status(w http.ResponseWriter, r *http.Request) {
+	m.statusCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSubscriberHandler) UndeleteKeyset(w http.ResponseWriter, r *http.Request) {
+	m.undeleteKeysetCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSubscriberHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	m.rotateKeysCalled = true
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (m *mockSubscriberHandler) ChallengeRecords(w http.ResponseWriter, r *http.Request) {
+	m.challengeRecordsCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 func TestRouter_Routes(t *testing.T) {
 	h := &mockSubscriberHandler{}
 	router := NewRouter(h)
@@ -105,6 +129,50 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "Status",
+			method:         http.MethodGet,
+			path:           "/status",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockSubscriberHandler) {
+				if !h.statusCalled {
+					t.Error("Status was not called")
+				}
+			},
+		},
+		{
+			name:           "UndeleteKeyset",
+			method:         http.MethodPost,
+			path:           "/keys/undelete",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockSubscriberHandler) {
+				if !h.undeleteKeysetCalled {
+					t.Error("UndeleteKeyset was not called")
+				}
+			},
+		},
+		{
+			name:           "RotateKeys",
+			method:         http.MethodPost,
+			path:           "/keys/rotate",
+			expectedStatus: http.StatusAccepted,
+			handlerCheck: func(t *testing.T, h *mockSubscriberHandler) {
+				if !h.rotateKeysCalled {
+					t.Error("RotateKeys was not called")
+				}
+			},
+		},
+		{
+			name:           "ChallengeRecords",
+			method:         http.MethodGet,
+			path:           "/debug/on_subscribe",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockSubscriberHandler) {
+				if !h.challengeRecordsCalled {
+					t.Error("ChallengeRecords was not called")
+				}
+			},
+		},
 		{
 			name:           "OnSubscribe at root",
 			method:         http.MethodPost,
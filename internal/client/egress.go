@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// EgressConfig routes outbound calls through an HTTP(S) proxy, for deployments whose
+// outbound traffic must go through an egress proxy.
+type EgressConfig struct {
+	// ProxyURL is the proxy, e.g. "http://proxy.example.com:3128", with optional user info for
+	// basic authentication. Without it, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+	// variables apply.
+	ProxyURL string `yaml:"proxyURL"`
+	// NoProxy lists the hosts reached without the proxy, with the syntax of NO_PROXY: host
+	// names, which also match their subdomains, IP addresses and CIDR ranges, each with an
+	// optional port. Loopback addresses are never proxied.
+	NoProxy []string `yaml:"noProxy"`
+	// CAFile is a PEM file of certificate authorities trusted in addition to the system ones,
+	// e.g. that of a TLS-inspecting proxy or of a private registry.
+	CAFile string `yaml:"caFile"`
+}
+
+// egress holds an EgressConfig ready for use.
+type egress struct {
+	// proxy returns the proxy for a request URL, or nil to connect directly. It is nil if no
+	// proxy is configured.
+	proxy func(*url.URL) (*url.URL, error)
+	// rootCAs are the trusted certificate authorities. They are nil if no CA file is configured.
+	rootCAs *x509.CertPool
+}
+
+// newEgress validates cfg and loads its CA file.
+func newEgress(cfg *EgressConfig) (*egress, error) {
+	e := &egress{}
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress config: proxyURL: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid egress config: proxyURL %q must be an http or https URL with a host", cfg.ProxyURL)
+		}
+		e.proxy = (&httpproxy.Config{
+			HTTPProxy:  cfg.ProxyURL,
+			HTTPSProxy: cfg.ProxyURL,
+			NoProxy:    strings.Join(cfg.NoProxy, ","),
+		}).ProxyFunc()
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress config: caFile: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid egress config: caFile %s has no PEM certificates", cfg.CAFile)
+		}
+		e.rootCAs = pool
+	}
+	return e, nil
+}
+
+// configureTransport makes t use the proxy and certificate authorities of cfg.
+func configureTransport(t *http.Transport, cfg *EgressConfig) error {
+	e, err := newEgress(cfg)
+	if err != nil {
+		return err
+	}
+	if e.proxy != nil {
+		t.Proxy = func(r *http.Request) (*url.URL, error) { return e.proxy(r.URL) }
+	}
+	if e.rootCAs != nil {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.RootCAs = e.rootCAs
+	}
+	return nil
+}
+
+// EgressGRPCOptions returns the options that make a Google Cloud gRPC client, such as the
+// Pub/Sub client of the event publisher, use the proxy and certificate authorities of cfg.
+// gRPC connections are tunnelled through the proxy with HTTP CONNECT.
+func EgressGRPCOptions(cfg *EgressConfig) ([]option.ClientOption, error) {
+	e, err := newEgress(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var opts []option.ClientOption
+	if e.proxy != nil {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithContextDialer(e.dialContext)))
+	}
+	if e.rootCAs != nil {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: e.rootCAs}))))
+	}
+	return opts, nil
+}
+
+// dialContext connects to addr, a host and port, through a CONNECT tunnel of the proxy
+// unless NoProxy excludes it.
+func (e *egress) dialContext(ctx context.Context, addr string) (net.Conn, error) {
+	proxyURL, err := e.proxy(&url.URL{Scheme: "https", Host: addr})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find proxy for %s: %w", addr, err)
+	}
+	var d net.Dialer
+	if proxyURL == nil {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr(proxyURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyURL.Host, err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), RootCAs: e.rootCAs})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with proxy %s failed: %w", proxyURL.Host, err)
+		}
+		conn = tlsConn
+	}
+	if err := connectTunnel(ctx, conn, proxyURL, addr); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// proxyAddr returns the host and port of a proxy, defaulting the port from its scheme.
+func proxyAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// connectTunnel asks the proxy on conn to open a tunnel to addr.
+func connectTunnel(ctx context.Context, conn net.Conn, proxyURL *url.URL, addr string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: addr}, Host: addr, Header: http.Header{}}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(u.Username()+":"+password)))
+	}
+	if err := req.Write(conn); err != nil {
+		return fmt.Errorf("failed to send CONNECT %s to proxy %s: %w", addr, proxyURL.Host, err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return fmt.Errorf("failed to read CONNECT %s response from proxy %s: %w", addr, proxyURL.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxy %s refused CONNECT %s: %s", proxyURL.Host, addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		// The target speaks after the client, so nothing may follow the response yet.
+		return fmt.Errorf("proxy %s sent unexpected data after CONNECT %s", proxyURL.Host, addr)
+	}
+	return nil
+}
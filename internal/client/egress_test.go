@@ -0,0 +1,249 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// writeCAFile writes the certificate of a TLS test server to a PEM file and returns its path.
+func writeCAFile(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	return path
+}
+
+func TestNewEgress_Error(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	tests := []struct {
+		name    string
+		cfg     *EgressConfig
+		wantErr string
+	}{
+		{name: "unparsable proxy", cfg: &EgressConfig{ProxyURL: "http://[::1"}, wantErr: "proxyURL"},
+		{name: "unsupported proxy scheme", cfg: &EgressConfig{ProxyURL: "socks5://proxy:1080"}, wantErr: "must be an http or https URL"},
+		{name: "proxy without host", cfg: &EgressConfig{ProxyURL: "proxy:3128"}, wantErr: "must be an http or https URL"},
+		{name: "missing CA file", cfg: &EgressConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, wantErr: "caFile"},
+		{name: "CA file without certificates", cfg: &EgressConfig{CAFile: empty}, wantErr: "has no PEM certificates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newEgress(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newEgress() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEgress_NoProxy(t *testing.T) {
+	e, err := newEgress(&EgressConfig{ProxyURL: "http://proxy.example.com:3128", NoProxy: []string{"internal.example.com", "10.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("newEgress() error = %v", err)
+	}
+	tests := []struct {
+		host      string
+		wantProxy bool
+	}{
+		{host: "registry.example.org", wantProxy: true},
+		{host: "internal.example.com", wantProxy: false},
+		{host: "registry.internal.example.com", wantProxy: false},
+		{host: "10.1.2.3:8080", wantProxy: false},
+	}
+	for _, tt := range tests {
+		got, err := e.proxy(&url.URL{Scheme: "https", Host: tt.host})
+		if err != nil {
+			t.Fatalf("proxy(%s) error = %v", tt.host, err)
+		}
+		if (got != nil) != tt.wantProxy {
+			t.Errorf("proxy(%s) = %v, want proxied %t", tt.host, got, tt.wantProxy)
+		}
+	}
+}
+
+func TestRegistryClient_Egress_Proxy(t *testing.T) {
+	var gotURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer proxy.Close()
+
+	cfg := testRegistryClientConfig("http://registry.example.com")
+	cfg.Egress = &EgressConfig{ProxyURL: proxy.URL}
+	c, err := NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	if _, err := c.Lookup(context.Background(), &model.Subscription{}); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if want := "http://registry.example.com/lookup"; gotURL != want {
+		t.Errorf("proxy received request for %q, want %q", gotURL, want)
+	}
+}
+
+func TestRegistryClient_Egress_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c, err := NewRegistryClient(testRegistryClientConfig(srv.URL))
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	if _, err := c.Lookup(context.Background(), &model.Subscription{}); err == nil {
+		t.Fatal("Lookup() without CA file error = nil, want certificate error")
+	}
+
+	cfg := testRegistryClientConfig(srv.URL)
+	cfg.Egress = &EgressConfig{CAFile: writeCAFile(t, srv)}
+	c, err = NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	if _, err := c.Lookup(context.Background(), &model.Subscription{}); err != nil {
+		t.Errorf("Lookup() with CA file error = %v", err)
+	}
+}
+
+func TestNewRegistryClient_EgressError(t *testing.T) {
+	cfg := testRegistryClientConfig("http://registry.example.com")
+	cfg.Egress = &EgressConfig{ProxyURL: "ftp://proxy"}
+	if _, err := NewRegistryClient(cfg); err == nil {
+		t.Error("NewRegistryClient() error = nil, want invalid egress config error")
+	}
+}
+
+// connectProxy is a proxy that accepts a single CONNECT request, replying with status, and
+// then echoes the tunnelled data.
+func connectProxy(t *testing.T, status int) (addr string, gotReq chan *http.Request) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	gotReq = make(chan *http.Request, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return
+		}
+		gotReq <- req
+		resp := &http.Response{StatusCode: status, ProtoMajor: 1, ProtoMinor: 1}
+		resp.Write(conn)
+		if status == http.StatusOK {
+			io.Copy(conn, br)
+		}
+	}()
+	return l.Addr().String(), gotReq
+}
+
+func TestEgress_DialContext(t *testing.T) {
+	addr, gotReq := connectProxy(t, http.StatusOK)
+	e, err := newEgress(&EgressConfig{ProxyURL: "http://user:secret@" + addr})
+	if err != nil {
+		t.Fatalf("newEgress() error = %v", err)
+	}
+
+	conn, err := e.dialContext(context.Background(), "pubsub.googleapis.com:443")
+	if err != nil {
+		t.Fatalf("dialContext() error = %v", err)
+	}
+	defer conn.Close()
+
+	req := <-gotReq
+	if req.Method != http.MethodConnect || req.Host != "pubsub.googleapis.com:443" {
+		t.Errorf("proxy received %s %s, want CONNECT pubsub.googleapis.com:443", req.Method, req.Host)
+	}
+	if got, want := req.Header.Get("Proxy-Authorization"), "Basic dXNlcjpzZWNyZXQ="; got != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", got, want)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("failed to write to tunnel: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("read %q, %v from tunnel, want ping", buf, err)
+	}
+}
+
+func TestEgress_DialContext_Refused(t *testing.T) {
+	addr, _ := connectProxy(t, http.StatusProxyAuthRequired)
+	e, err := newEgress(&EgressConfig{ProxyURL: "http://" + addr})
+	if err != nil {
+		t.Fatalf("newEgress() error = %v", err)
+	}
+	if _, err := e.dialContext(context.Background(), "pubsub.googleapis.com:443"); err == nil || !strings.Contains(err.Error(), "refused CONNECT") {
+		t.Errorf("dialContext() error = %v, want refused CONNECT error", err)
+	}
+}
+
+func TestEgressGRPCOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	tests := []struct {
+		name string
+		cfg  *EgressConfig
+		want int
+	}{
+		{name: "empty", cfg: &EgressConfig{}, want: 0},
+		{name: "proxy", cfg: &EgressConfig{ProxyURL: "http://proxy.example.com:3128"}, want: 1},
+		{name: "proxy and CA file", cfg: &EgressConfig{ProxyURL: "http://proxy.example.com:3128", CAFile: writeCAFile(t, srv)}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := EgressGRPCOptions(tt.cfg)
+			if err != nil {
+				t.Fatalf("EgressGRPCOptions() error = %v", err)
+			}
+			if len(opts) != tt.want {
+				t.Errorf("EgressGRPCOptions() returned %d options, want %d", len(opts), tt.want)
+			}
+		})
+	}
+	if _, err := EgressGRPCOptions(&EgressConfig{ProxyURL: "ftp://proxy"}); err == nil {
+		t.Error("EgressGRPCOptions() error = nil, want invalid egress config error")
+	}
+}
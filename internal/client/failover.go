@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultFailoverCooldown = 30 * time.Second
+
+// registryPool tracks the health of the configured registries. A registry that fails is
+// skipped for a cooldown, so that requests go straight to the next registry instead of
+// waiting on the failed one each time.
+type registryPool struct {
+	urls     []string
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu             sync.Mutex
+	unhealthyUntil map[string]time.Time
+}
+
+func newRegistryPool(urls []string, cooldown time.Duration) *registryPool {
+	if cooldown <= 0 {
+		cooldown = defaultFailoverCooldown
+	}
+	return &registryPool{
+		urls:           urls,
+		cooldown:       cooldown,
+		now:            time.Now,
+		unhealthyUntil: make(map[string]time.Time),
+	}
+}
+
+// order returns the registries to try, healthy ones first in configured order. Registries
+// still in their cooldown come last, so a request is attempted even if all of them failed.
+func (p *registryPool) order() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	healthy := make([]string, 0, len(p.urls))
+	var unhealthy []string
+	for _, u := range p.urls {
+		if until, ok := p.unhealthyUntil[u]; ok && now.Before(until) {
+			unhealthy = append(unhealthy, u)
+			continue
+		}
+		healthy = append(healthy, u)
+	}
+	return append(healthy, unhealthy...)
+}
+
+// markFailed puts the registry in cooldown.
+func (p *registryPool) markFailed(url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unhealthyUntil[url] = p.now().Add(p.cooldown)
+}
+
+// markHealthy clears the cooldown of the registry.
+func (p *registryPool) markHealthy(url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.unhealthyUntil, url)
+}
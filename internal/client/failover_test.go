@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistryPool_Order(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newRegistryPool([]string{"http://primary", "http://secondary", "http://tertiary"}, time.Minute)
+	p.now = func() time.Time { return now }
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		failed  []string
+		healthy []string
+		want    []string
+	}{
+		{name: "all healthy", want: []string{"http://primary", "http://secondary", "http://tertiary"}},
+		{name: "primary failed", failed: []string{"http://primary"}, want: []string{"http://secondary", "http://tertiary", "http://primary"}},
+		{name: "all failed keep configured order", failed: []string{"http://secondary", "http://tertiary"}, want: []string{"http://primary", "http://secondary", "http://tertiary"}},
+		{name: "secondary recovered", healthy: []string{"http://secondary"}, want: []string{"http://secondary", "http://primary", "http://tertiary"}},
+		{name: "cooldown elapsed", advance: time.Minute, want: []string{"http://primary", "http://secondary", "http://tertiary"}},
+	}
+	for _, s := range steps {
+		now = now.Add(s.advance)
+		for _, u := range s.failed {
+			p.markFailed(u)
+		}
+		for _, u := range s.healthy {
+			p.markHealthy(u)
+		}
+		if diff := cmp.Diff(s.want, p.order()); diff != "" {
+			t.Errorf("%s: order() mismatch (-want +got):\n%s", s.name, diff)
+		}
+	}
+}
+
+func TestNewRegistryPool_DefaultCooldown(t *testing.T) {
+	p := newRegistryPool([]string{"http://primary"}, 0)
+	if p.cooldown != defaultFailoverCooldown {
+		t.Errorf("cooldown = %v, want %v", p.cooldown, defaultFailoverCooldown)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// subscriptionLookuper looks up subscriptions in the registry.
+type subscriptionLookuper interface {
+	Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error)
+}
+
+// keyLookup adapts the registry client to the lookup interface of the key managers, so that
+// key lookups fail over between registries like the subscription flows do.
+type keyLookup struct {
+	client subscriptionLookuper
+}
+
+// NewKeyLookup creates a new keyLookup backed by client.
+func NewKeyLookup(client subscriptionLookuper) *keyLookup {
+	return &keyLookup{client: client}
+}
+
+// Lookup looks up the subscriptions matching req.
+func (l *keyLookup) Lookup(ctx context.Context, req *becknmodel.Subscription) ([]becknmodel.Subscription, error) {
+	subs, err := l.client.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{
+			SubscriberID: req.SubscriberID,
+			URL:          req.URL,
+			Type:         model.Role(req.Type),
+			Domain:       req.Domain,
+		},
+		KeyID: req.KeyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]becknmodel.Subscription, 0, len(subs))
+	for _, s := range subs {
+		res = append(res, becknmodel.Subscription{
+			Subscriber: becknmodel.Subscriber{
+				SubscriberID: s.SubscriberID,
+				URL:          s.URL,
+				Type:         string(s.Type),
+				Domain:       s.Domain,
+			},
+			KeyID:            s.KeyID,
+			SigningPublicKey: s.SigningPublicKey,
+			EncrPublicKey:    s.EncrPublicKey,
+			ValidFrom:        s.ValidFrom,
+			ValidUntil:       s.ValidUntil,
+			Status:           string(s.Status),
+			Created:          s.Created,
+			Updated:          s.Updated,
+			Nonce:            s.Nonce,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockSubscriptionLookuper is a mock implementation of subscriptionLookuper.
+type mockSubscriptionLookuper struct {
+	req  *model.Subscription
+	subs []model.Subscription
+	err  error
+}
+
+func (m *mockSubscriptionLookuper) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	m.req = req
+	return m.subs, m.err
+}
+
+func TestKeyLookup_Lookup(t *testing.T) {
+	validFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := &mockSubscriptionLookuper{subs: []model.Subscription{{
+		Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.com", Type: model.RoleBAP, Domain: "retail"},
+		KeyID:            "key1",
+		SigningPublicKey: "signing",
+		EncrPublicKey:    "encr",
+		ValidFrom:        validFrom,
+		Status:           model.SubscriptionStatusSubscribed,
+	}}}
+
+	got, err := NewKeyLookup(m).Lookup(context.Background(), &becknmodel.Subscription{
+		Subscriber: becknmodel.Subscriber{SubscriberID: "np1"},
+		KeyID:      "key1",
+	})
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	wantReq := &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np1"}, KeyID: "key1"}
+	if diff := cmp.Diff(wantReq, m.req); diff != "" {
+		t.Errorf("registry Lookup() request mismatch (-want +got):\n%s", diff)
+	}
+	want := []becknmodel.Subscription{{
+		Subscriber:       becknmodel.Subscriber{SubscriberID: "np1", URL: "https://np1.com", Type: "BAP", Domain: "retail"},
+		KeyID:            "key1",
+		SigningPublicKey: "signing",
+		EncrPublicKey:    "encr",
+		ValidFrom:        validFrom,
+		Status:           "SUBSCRIBED",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lookup() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestKeyLookup_LookupError(t *testing.T) {
+	wantErr := errors.New("registry down")
+	_, err := NewKeyLookup(&mockSubscriptionLookuper{err: wantErr}).Lookup(context.Background(), &becknmodel.Subscription{})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Lookup() error = %v, want %v", err, wantErr)
+	}
+}
@@ -42,8 +42,15 @@ func DefaultNPClientConfig() NPClientConfig {
 	}
 }
 
+// authGen generates the Authorization header that signs a request body.
+type authGen interface {
+	AuthHeader(ctx context.Context, body []byte, subscriberID string) (string, error)
+}
+
 type httpNPClient struct {
-	client *http.Client
+	client       *http.Client
+	auth         authGen
+	subscriberID string
 }
 
 // NewNPClient creates a new NPClient that uses a retryable HTTP client.
@@ -56,6 +63,13 @@ func NewNPClient(cfg NPClientConfig) *httpNPClient {
 	}
 }
 
+// SetAuth signs the requests with the keys of subscriberID, the registry's own subscriber ID,
+// so that network participants can check that they come from the registry.
+func (c *httpNPClient) SetAuth(auth authGen, subscriberID string) {
+	c.auth = auth
+	c.subscriberID = subscriberID
+}
+
 var jsonMarshal = json.Marshal
 
 // OnSubscribe sends a request to the Network Participant's (NP) /on_subscribe endpoint.
@@ -75,6 +89,14 @@ func (c *httpNPClient) OnSubscribe(ctx context.Context, callbackURL string, requ
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.auth != nil {
+		authHeader, err := c.auth.AuthHeader(ctx, requestBody, c.subscriberID)
+		if err != nil {
+			slog.ErrorContext(ctx, "NPClient: Failed to sign /on_subscribe request", "error", err)
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+		req.Header.Set(model.AuthHeaderSubscriber, authHeader)
+	}
 
 	slog.InfoContext(ctx, "NPClient: Sending /on_subscribe request", "url", callbackURL)
 	resp, err := c.client.Do(req)
@@ -68,6 +68,57 @@ func TestHttpNPClient_OnSubscribe_Success(t *testing.T) {
 	}
 }
 
+// mockAuthGen is a mock implementation of authGen.
+type mockAuthGen struct {
+	header        string
+	err           error
+	gotBody       []byte
+	gotSubscriber string
+}
+
+func (m *mockAuthGen) AuthHeader(ctx context.Context, body []byte, subscriberID string) (string, error) {
+	m.gotBody = body
+	m.gotSubscriber = subscriberID
+	return m.header, m.err
+}
+
+func TestHttpNPClient_OnSubscribe_Signed(t *testing.T) {
+	var gotAuth string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get(model.AuthHeaderSubscriber)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"answer":"correct_answer"}`))
+	}))
+	defer server.Close()
+
+	auth := &mockAuthGen{header: `Signature keyId="registry.example.com|reg-key|ed25519"`}
+	client := NewNPClient(testRetryConfig())
+	client.SetAuth(auth, "registry.example.com")
+	if _, err := client.OnSubscribe(context.Background(), server.URL, &model.OnSubscribeRequest{Challenge: "test_challenge"}); err != nil {
+		t.Fatalf("OnSubscribe() returned an unexpected error: %v", err)
+	}
+	if gotAuth != auth.header {
+		t.Errorf("Authorization = %q, want %q", gotAuth, auth.header)
+	}
+	if string(gotBody) != string(auth.gotBody) {
+		t.Errorf("signed body = %s, want the sent body %s", auth.gotBody, gotBody)
+	}
+	if auth.gotSubscriber != "registry.example.com" {
+		t.Errorf("AuthHeader() subscriberID = %q, want %q", auth.gotSubscriber, "registry.example.com")
+	}
+}
+
+func TestHttpNPClient_OnSubscribe_SignError(t *testing.T) {
+	client := NewNPClient(testRetryConfig())
+	client.SetAuth(&mockAuthGen{err: fmt.Errorf("no signing key")}, "registry.example.com")
+	_, err := client.OnSubscribe(context.Background(), "http://np.invalid", &model.OnSubscribeRequest{Challenge: "test_challenge"})
+	if err == nil || !strings.Contains(err.Error(), "failed to sign request") {
+		t.Errorf("OnSubscribe() error = %v, want a signing error", err)
+	}
+}
+
 func TestHttpNPClient_OnSubscribe_Error(t *testing.T) {
 	validRequest := &model.OnSubscribeRequest{Challenge: "test_challenge"}
 	testCases := []struct {
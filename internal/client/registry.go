@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -31,8 +32,13 @@ const (
 	lookupPath        = "/lookup"
 	subscribePath     = "/subscribe"
 	operationsPathFmt = "/operations/%s" // Format string for operation ID
+	healthPath        = "/health"
 )
 
+// ErrRegistryMaintenance is returned when the registry rejects a request because it is in
+// read-only maintenance mode. Lookups keep working during maintenance.
+var ErrRegistryMaintenance = errors.New("registry is in maintenance mode")
+
 // RegistryClientConfig holds configuration for the retryable HTTP client for the Registry.
 type RegistryClientConfig struct {
 	Timeout             time.Duration `yaml:"timeout"` // Timeout for each individual HTTP request attempt.
@@ -41,11 +47,19 @@ type RegistryClientConfig struct {
 	MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost"`
 	MaxConnsPerHost     int           `yaml:"maxConnsPerHost"`
 	IdleConnTimeout     time.Duration `yaml:"idleConnTimeout"`
+	// SecondaryURLs are base URLs of registries to fail over to, in order, when the registry at
+	// BaseURL is unreachable or returns a server error.
+	SecondaryURLs []string `yaml:"secondaryURLs"`
+	// FailoverCooldown is how long a failed registry is skipped before it is tried first again.
+	FailoverCooldown time.Duration `yaml:"failoverCooldown"`
+	// Egress routes the calls through an HTTP(S) proxy and trusts additional certificate authorities.
+	Egress *EgressConfig `yaml:"egress"`
 }
 
 type httpRegistryClient struct {
-	client  *http.Client
-	baseURL string
+	client     *http.Client
+	baseURL    string
+	registries *registryPool
 }
 
 // NewRegistryClient creates a new RegistryClient that uses a retryable HTTP client.
@@ -56,6 +70,11 @@ func NewRegistryClient(cfg *RegistryClientConfig) (*httpRegistryClient, error) {
 	if cfg.BaseURL == "" {
 		return nil, fmt.Errorf("BaseURL cannot be empty in RegistryClientConfig")
 	}
+	for i, u := range cfg.SecondaryURLs {
+		if u == "" {
+			return nil, fmt.Errorf("SecondaryURLs[%d] cannot be empty in RegistryClientConfig", i)
+		}
+	}
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 10 * time.Second // Provide a default timeout if not configured
 	}
@@ -78,18 +97,27 @@ func NewRegistryClient(cfg *RegistryClientConfig) (*httpRegistryClient, error) {
 	if cfg.IdleConnTimeout > 0 {
 		transport.IdleConnTimeout = cfg.IdleConnTimeout
 	}
+	if cfg.Egress != nil {
+		if err := configureTransport(transport, cfg.Egress); err != nil {
+			return nil, err
+		}
+	}
 
 	client := &http.Client{
 		Timeout: cfg.Timeout,
 		Transport: transport,
 	}
+	urls := append([]string{cfg.BaseURL}, cfg.SecondaryURLs...)
 	return &httpRegistryClient{
-		client:  client,
-		baseURL: cfg.BaseURL,
+		client:     client,
+		baseURL:    cfg.BaseURL,
+		registries: newRegistryPool(urls, cfg.FailoverCooldown),
 	}, nil
 }
 
 // doAPIRequest is a helper function to handle common logic for making API requests.
+// The request is sent to each registry in turn until one of them is reachable and does not
+// return a server error, in which case its response is returned.
 func (c *httpRegistryClient) doAPIRequest(
 	ctx context.Context,
 	method string,
@@ -101,28 +129,63 @@ func (c *httpRegistryClient) doAPIRequest(
 	logAction string, // e.g., "POST /subscribe"
 	authHeader string,
 ) error {
-	fullURL := c.baseURL + fmt.Sprintf(pathFormat, pathArgs...)
-	slog.DebugContext(ctx, "RegistryClient: Preparing request", "action", logAction, "url", fullURL)
-
-	var reqBodyReader io.Reader
+	var requestBytes []byte
 	if requestData != nil {
-		requestBytes, err := jsonMarshal(requestData)
+		var err error
+		requestBytes, err = jsonMarshal(requestData)
 		if err != nil {
 			slog.ErrorContext(ctx, "RegistryClient: Failed to marshal request", "action", logAction, "error", err)
 			return fmt.Errorf("failed to marshal %s request: %w", logAction, err)
 		}
-		reqBodyReader = bytes.NewBuffer(requestBytes)
+	}
+
+	var err error
+	for i, baseURL := range c.registries.order() {
+		if i > 0 {
+			slog.WarnContext(ctx, "RegistryClient: Failing over to next registry", "action", logAction, "url", baseURL, "error", err)
+		}
+		var failover bool
+		failover, err = c.send(ctx, method, baseURL+fmt.Sprintf(pathFormat, pathArgs...), requestBytes, responseData, expectedStatusCode, logAction, authHeader)
+		if !failover {
+			c.registries.markHealthy(baseURL)
+			return err
+		}
+		c.registries.markFailed(baseURL)
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// send sends a single request to fullURL. It reports whether the registry is unavailable, so
+// that the request should be retried on the next registry.
+func (c *httpRegistryClient) send(
+	ctx context.Context,
+	method string,
+	fullURL string,
+	requestBytes []byte,
+	responseData any,
+	expectedStatusCode int,
+	logAction string,
+	authHeader string,
+) (failover bool, err error) {
+	slog.DebugContext(ctx, "RegistryClient: Preparing request", "action", logAction, "url", fullURL)
+
+	var reqBodyReader io.Reader
+	if requestBytes != nil {
+		reqBodyReader = bytes.NewReader(requestBytes)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, reqBodyReader)
 	if err != nil {
 		slog.ErrorContext(ctx, "RegistryClient: Failed to create HTTP request", "action", logAction, "error", err)
-		return fmt.Errorf("failed to create HTTP request for %s: %w", logAction, err)
+		return false, fmt.Errorf("failed to create HTTP request for %s: %w", logAction, err)
 	}
 	if authHeader != "" {
 		req.Header.Set(model.AuthHeaderSubscriber, authHeader)
 	}
-	if requestData != nil {
+	if requestBytes != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
@@ -130,30 +193,34 @@ func (c *httpRegistryClient) doAPIRequest(
 	resp, err := c.client.Do(req)
 	if err != nil {
 		slog.ErrorContext(ctx, "RegistryClient: Failed to send request", "action", logAction, "url", fullURL, "error", err)
-		return fmt.Errorf("HTTP request to Registry %s failed: %w", logAction, err)
+		return ctx.Err() == nil, fmt.Errorf("HTTP request to Registry %s failed: %w", logAction, err)
 	}
 	defer resp.Body.Close()
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		slog.ErrorContext(ctx, "RegistryClient: Failed to read response body", "action", logAction, "url", fullURL, "error", err)
-		return fmt.Errorf("failed to read Registry %s response body: %w", logAction, err)
+		return ctx.Err() == nil, fmt.Errorf("failed to read Registry %s response body: %w", logAction, err)
 	}
 
 	if resp.StatusCode != expectedStatusCode {
 		slog.WarnContext(ctx, "RegistryClient: Endpoint returned unexpected status", "action", logAction, "url", fullURL, "status_code", resp.StatusCode, "expected_status_code", expectedStatusCode, "response_body", string(responseBody))
-		return fmt.Errorf("registry %s failed with status %d: %s", logAction, resp.StatusCode, string(responseBody))
+		failover := resp.StatusCode >= http.StatusInternalServerError
+		if resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get(model.MaintenanceHeader) != "" {
+			return failover, fmt.Errorf("%w: registry %s failed with status %d: %s", ErrRegistryMaintenance, logAction, resp.StatusCode, string(responseBody))
+		}
+		return failover, fmt.Errorf("registry %s failed with status %d: %s", logAction, resp.StatusCode, string(responseBody))
 	}
 
 	if responseData != nil {
 		if err := json.Unmarshal(responseBody, responseData); err != nil {
 			slog.ErrorContext(ctx, "RegistryClient: Failed to unmarshal response", "action", logAction, "url", fullURL, "error", err, "response_body", string(responseBody))
-			return fmt.Errorf("failed to unmarshal Registry %s response: %w", logAction, err)
+			return false, fmt.Errorf("failed to unmarshal Registry %s response: %w", logAction, err)
 		}
 	}
 
 	slog.DebugContext(ctx, "RegistryClient: Successfully received response", "action", logAction, "url", fullURL)
-	return nil
+	return false, nil
 }
 
 // Lookup sends a POST request to the Registry's /lookup endpoint.
@@ -173,7 +240,7 @@ func (c *httpRegistryClient) CreateSubscription(ctx context.Context, request *mo
 	if err != nil {
 		return nil, err
 	}
-	slog.DebugContext(ctx, "RegistryClient: Successfully received POST /subscribe response", "url", c.baseURL+subscribePath, "message_id", subResponse.MessageID)
+	slog.DebugContext(ctx, "RegistryClient: Successfully received POST /subscribe response", "message_id", subResponse.MessageID)
 	return &subResponse, nil
 }
 
@@ -184,7 +251,7 @@ func (c *httpRegistryClient) UpdateSubscription(ctx context.Context, request *mo
 	if err != nil {
 		return nil, err
 	}
-	slog.DebugContext(ctx, "RegistryClient: Successfully received PATCH /subscribe response", "url", c.baseURL+subscribePath, "message_id", subResponse.MessageID)
+	slog.DebugContext(ctx, "RegistryClient: Successfully received PATCH /subscribe response", "message_id", subResponse.MessageID)
 	return &subResponse, nil
 }
 
@@ -196,6 +263,11 @@ func (c *httpRegistryClient) GetOperation(ctx context.Context, operationID strin
 	if err != nil {
 		return nil, err
 	}
-	slog.DebugContext(ctx, "RegistryClient: Successfully received GET /operations response", "url", c.baseURL+fmt.Sprintf(operationsPathFmt, operationID), "operation_id", lro.OperationID)
+	slog.DebugContext(ctx, "RegistryClient: Successfully received GET /operations response", "operation_id", lro.OperationID)
 	return &lro, nil
 }
+
+// Health sends a GET request to the Registry's /health endpoint to check that it is reachable.
+func (c *httpRegistryClient) Health(ctx context.Context) error {
+	return c.doAPIRequest(ctx, http.MethodGet, healthPath, nil, nil, nil, http.StatusOK, "GET /health", "")
+}
@@ -135,6 +135,11 @@ func TestNewRegistryClient(t *testing.T) {
 				config:  &RegistryClientConfig{},
 				wantErr: "BaseURL cannot be empty in RegistryClientConfig",
 			},
+			{
+				name:    "empty secondary URL",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", SecondaryURLs: []string{"http://localhost:8081", ""}},
+				wantErr: "SecondaryURLs[1] cannot be empty in RegistryClientConfig",
+			},
 		}
 
 		for _, tc := range testCases {
@@ -453,6 +458,23 @@ func TestHttpRegistryClient_UpdateSubscription_MarshalError(t *testing.T) {
 		"PATCH /subscribe")
 }
 
+func TestHttpRegistryClient_UpdateSubscription_Maintenance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(model.MaintenanceHeader, model.MaintenanceModeReadOnly)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := io.WriteString(w, `{"error":{"type":"INTERNAL_ERROR","code":"REGISTRY_MAINTENANCE"}}`); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+
+	_, err := client.UpdateSubscription(context.Background(), &model.SubscriptionRequest{}, "auth")
+	if !errors.Is(err, ErrRegistryMaintenance) {
+		t.Errorf("UpdateSubscription() error = %v, want %v", err, ErrRegistryMaintenance)
+	}
+}
+
 // --- GetOperation Tests ---
 
 func TestHttpRegistryClient_GetOperation_Success(t *testing.T) {
@@ -494,3 +516,158 @@ func TestHttpRegistryClient_GetOperation_Error(t *testing.T) {
 		return client.GetOperation(ctx, operationID)
 	}, logAction, false)
 }
+
+func TestHttpRegistryClient_Health(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantErrMsg string
+	}{
+		{name: "healthy", status: http.StatusOK},
+		{name: "unhealthy", status: http.StatusServiceUnavailable, wantErrMsg: "registry GET /health failed with status 503"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != healthPath {
+					t.Errorf("expected path %q, got %q", healthPath, r.URL.Path)
+				}
+				if r.Method != http.MethodGet {
+					t.Errorf("expected method %q, got %q", http.MethodGet, r.Method)
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+			err := client.Health(context.Background())
+			if tc.wantErrMsg == "" {
+				if err != nil {
+					t.Fatalf("Health() returned an unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrMsg) {
+				t.Errorf("Health() error = %v, want error containing %q", err, tc.wantErrMsg)
+			}
+		})
+	}
+}
+
+// --- Failover Tests ---
+
+func TestHttpRegistryClient_Failover(t *testing.T) {
+	tests := []struct {
+		name            string
+		primaryStatus   int
+		primaryDown     bool
+		wantErrMsg      string
+		wantSecondary   int
+		wantPrimaryNext bool
+	}{
+		{name: "primary healthy", primaryStatus: http.StatusOK, wantPrimaryNext: true},
+		{name: "primary returns 500", primaryStatus: http.StatusInternalServerError, wantSecondary: 1},
+		{name: "primary unreachable", primaryDown: true, wantSecondary: 1},
+		{name: "primary rejects request", primaryStatus: http.StatusBadRequest, wantErrMsg: "registry POST /lookup failed with status 400", wantPrimaryNext: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var secondaryCalls int
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.primaryStatus)
+				if _, err := io.WriteString(w, `[]`); err != nil {
+					t.Fatalf("failed to write response: %v", err)
+				}
+			}))
+			defer primary.Close()
+			secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				secondaryCalls++
+				var got model.Subscription
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil || got.SubscriberID != "np1" {
+					t.Errorf("secondary received request %+v, decode error %v", got, err)
+				}
+				if _, err := io.WriteString(w, `[{"subscriber_id":"np1"}]`); err != nil {
+					t.Fatalf("failed to write response: %v", err)
+				}
+			}))
+			defer secondary.Close()
+			if tc.primaryDown {
+				primary.Close()
+			}
+
+			cfg := testRegistryClientConfig(primary.URL)
+			cfg.SecondaryURLs = []string{secondary.URL}
+			client, err := NewRegistryClient(cfg)
+			if err != nil {
+				t.Fatalf("NewRegistryClient() error = %v", err)
+			}
+
+			_, err = client.Lookup(context.Background(), &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np1"}})
+			if tc.wantErrMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrMsg) {
+					t.Errorf("Lookup() error = %v, want error containing %q", err, tc.wantErrMsg)
+				}
+			} else if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if secondaryCalls != tc.wantSecondary {
+				t.Errorf("secondary calls = %d, want %d", secondaryCalls, tc.wantSecondary)
+			}
+			if got := client.registries.order()[0] == primary.URL; got != tc.wantPrimaryNext {
+				t.Errorf("primary tried first next = %t, want %t", got, tc.wantPrimaryNext)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_FailoverSkipsFailedRegistry(t *testing.T) {
+	var primaryCalls, secondaryCalls int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondary.Close()
+
+	cfg := testRegistryClientConfig(primary.URL)
+	cfg.SecondaryURLs = []string{secondary.URL}
+	client, _ := NewRegistryClient(cfg)
+
+	for i := 0; i < 3; i++ {
+		if err := client.Health(context.Background()); err != nil {
+			t.Fatalf("Health() error = %v", err)
+		}
+	}
+	if primaryCalls != 1 || secondaryCalls != 3 {
+		t.Errorf("calls = (primary %d, secondary %d), want (1, 3)", primaryCalls, secondaryCalls)
+	}
+}
+
+func TestHttpRegistryClient_AllRegistriesFail(t *testing.T) {
+	newServer := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := io.WriteString(w, body); err != nil {
+				t.Fatalf("failed to write response: %v", err)
+			}
+		}))
+	}
+	primary, secondary := newServer("primary down"), newServer("secondary down")
+	defer primary.Close()
+	defer secondary.Close()
+
+	cfg := testRegistryClientConfig(primary.URL)
+	cfg.SecondaryURLs = []string{secondary.URL}
+	client, _ := NewRegistryClient(cfg)
+
+	_, err := client.GetOperation(context.Background(), "op-1")
+	if err == nil || !strings.Contains(err.Error(), "secondary down") {
+		t.Errorf("GetOperation() error = %v, want the error of the last registry", err)
+	}
+}
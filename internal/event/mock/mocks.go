@@ -17,6 +17,7 @@ package mock
 import (
 	"context"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -47,6 +48,16 @@ type EventPublisher struct {
 	OnSubscribeRecievedMsgID string
 	// OnSubscribeRecievedErr is the error to return for PublishOnSubscribeRecievedEvent.
 	OnSubscribeRecievedErr error
+
+	// KeyRotatedMsgID is the message ID to return for PublishKeyRotatedEvent.
+	KeyRotatedMsgID string
+	// KeyRotatedErr is the error to return for PublishKeyRotatedEvent.
+	KeyRotatedErr error
+
+	// KeyAccessedMsgID is the message ID to return for PublishKeyAccessedEvent.
+	KeyAccessedMsgID string
+	// KeyAccessedErr is the error to return for PublishKeyAccessedEvent.
+	KeyAccessedErr error
 }
 
 // PublishNewSubscriptionRequestEvent mocks the publishing of a new subscription request event.
@@ -73,3 +84,13 @@ func (m *EventPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Con
 func (m *EventPublisher) PublishOnSubscribeRecievedEvent(ctx context.Context, lroID string) (string, error) {
 	return m.OnSubscribeRecievedMsgID, m.OnSubscribeRecievedErr
 }
+
+// PublishKeyRotatedEvent mocks the publishing of a key rotated event.
+func (m *EventPublisher) PublishKeyRotatedEvent(ctx context.Context, ev *events.KeyRotated) (string, error) {
+	return m.KeyRotatedMsgID, m.KeyRotatedErr
+}
+
+// PublishKeyAccessedEvent mocks the publishing of a key accessed event.
+func (m *EventPublisher) PublishKeyAccessedEvent(ctx context.Context, ev *events.KeyAccessed) (string, error) {
+	return m.KeyAccessedMsgID, m.KeyAccessedErr
+}
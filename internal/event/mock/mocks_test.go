@@ -19,6 +19,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -129,3 +130,45 @@ func TestEventPublisher_PublishOnSubscribeRecievedEvent(t *testing.T) {
 		t.Errorf("PublishOnSubscribeRecievedEvent() error = %v, wantErr %v", err, expectedErr)
 	}
 }
+
+func TestEventPublisher_PublishKeyRotatedEvent(t *testing.T) {
+	ctx := context.Background()
+	ev := &events.KeyRotated{}
+	expectedMsgID := "test-msg-id"
+	expectedErr := errors.New("test error")
+
+	m := &EventPublisher{
+		KeyRotatedMsgID: expectedMsgID,
+		KeyRotatedErr:   expectedErr,
+	}
+
+	msgID, err := m.PublishKeyRotatedEvent(ctx, ev)
+
+	if msgID != expectedMsgID {
+		t.Errorf("PublishKeyRotatedEvent() msgID = %v, want %v", msgID, expectedMsgID)
+	}
+	if err != expectedErr {
+		t.Errorf("PublishKeyRotatedEvent() error = %v, wantErr %v", err, expectedErr)
+	}
+}
+
+func TestEventPublisher_PublishKeyAccessedEvent(t *testing.T) {
+	ctx := context.Background()
+	ev := &events.KeyAccessed{}
+	expectedMsgID := "test-msg-id"
+	expectedErr := errors.New("test error")
+
+	m := &EventPublisher{
+		KeyAccessedMsgID: expectedMsgID,
+		KeyAccessedErr:   expectedErr,
+	}
+
+	msgID, err := m.PublishKeyAccessedEvent(ctx, ev)
+
+	if msgID != expectedMsgID {
+		t.Errorf("PublishKeyAccessedEvent() msgID = %v, want %v", msgID, expectedMsgID)
+	}
+	if err != expectedErr {
+		t.Errorf("PublishKeyAccessedEvent() error = %v, wantErr %v", err, expectedErr)
+	}
+}
@@ -18,13 +18,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"cloud.google.com/go/pubsub"
+	"github.com/google/uuid"
 	"google.golang.org/api/option"
 )
 
@@ -40,8 +46,44 @@ var (
 
 	// ErrMissingConfig occurs if the config is nil.
 	ErrMissingConfig = errors.New("missing config")
+
+	// ErrInvalidRetryConfig occurs if the retry policy has negative values.
+	ErrInvalidRetryConfig = errors.New("invalid retry config")
+
+	// ErrInvalidSource occurs if the CloudEvents source is not a URI reference.
+	ErrInvalidSource = errors.New("invalid event source")
+)
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
 )
 
+// Attributes set on events published to the dead-letter topic.
+const (
+	// DeadLetterTopicAttribute is the topic the event failed to publish to.
+	DeadLetterTopicAttribute = "dead_letter_topic"
+	// DeadLetterErrorAttribute is the error of the last publish attempt.
+	DeadLetterErrorAttribute = "dead_letter_error"
+	// DeadLetterAttemptsAttribute is the number of publish attempts.
+	DeadLetterAttemptsAttribute = "dead_letter_attempts"
+)
+
+// metrics publishes event publishing counters on the expvar endpoint (/debug/vars):
+// published, retried, dead_lettered and dropped events.
+var metrics = expvar.NewMap("events")
+
+// RetryConfig is the policy for retrying events that fail to publish.
+type RetryConfig struct {
+	// MaxAttempts is the number of publish attempts, including the first. Defaults to 3.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// InitialBackoff is the delay before the second attempt, doubled for every further attempt. Defaults to 100ms.
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	// MaxBackoff caps the delay between attempts. Defaults to 5s.
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+}
+
 // Config describes the connection config for a list given CloudPubSub topics.
 type Config struct {
 	// Target pubsub topic id.
@@ -50,6 +92,17 @@ type Config struct {
 	// Target project to be used.
 	ProjectID string `yaml:"projectID"`
 
+	// Retry is the policy for retrying failed publishes. Without it, a publish is attempted once.
+	Retry *RetryConfig `yaml:"retry"`
+
+	// DeadLetterTopicID is an optional topic, in the same project, that receives events
+	// which still fail to publish after all attempts.
+	DeadLetterTopicID string `yaml:"deadLetterTopicID"`
+
+	// Source is the CloudEvents source of the published events, a URI reference identifying
+	// the publishing service, e.g. "https://registry.example.com". Defaults to "/onix".
+	Source string `yaml:"source"`
+
 	// Client Option, If provided, these will be used.
 	// otherwise it will be populated with defaults.
 	Opts []option.ClientOption
@@ -57,8 +110,18 @@ type Config struct {
 
 // publisher is wrapper around Cloud PubSub client.
 type publisher struct {
-	client *pubsub.Client
-	topic  *pubsub.Topic
+	client     *pubsub.Client
+	topic      *pubsub.Topic
+	deadLetter *pubsub.Topic
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	sleep          func(ctx context.Context, d time.Duration) error
+
+	source string
+	newID  func() string
+	now    func() time.Time
 }
 
 // NewPublisher creates a new Publisher.
@@ -85,21 +148,133 @@ func NewPublisher(ctx context.Context, cfg *Config) (*publisher, func(), error)
 	if err != nil {
 		return nil, nil, fmt.Errorf("conn(%v): %w", cfg, err)
 	}
+	// Events about the same subscriber carry its ID as ordering key, so that consumers
+	// receive its lifecycle events in the order they were published.
+	tp.EnableMessageOrdering = true
 	p := &publisher{
-		client: cl,
-		topic:  tp,
+		client:      cl,
+		topic:       tp,
+		maxAttempts: 1,
+		sleep:       sleep,
+		source:      cfg.Source,
+		newID:       uuid.NewString,
+		now:         time.Now,
+	}
+	if p.source == "" {
+		p.source = events.DefaultSource
+	}
+	if r := cfg.Retry; r != nil {
+		p.maxAttempts, p.initialBackoff, p.maxBackoff = r.MaxAttempts, r.InitialBackoff, r.MaxBackoff
+		if p.maxAttempts == 0 {
+			p.maxAttempts = defaultMaxAttempts
+		}
+		if p.initialBackoff == 0 {
+			p.initialBackoff = defaultInitialBackoff
+		}
+		if p.maxBackoff == 0 {
+			p.maxBackoff = defaultMaxBackoff
+		}
+	}
+	if cfg.DeadLetterTopicID != "" {
+		dl, err := topic(ctx, cl, cfg.DeadLetterTopicID)
+		if err != nil {
+			tp.Stop()
+			cl.Close()
+			return nil, nil, fmt.Errorf("topic(%s): %w", cfg.DeadLetterTopicID, err)
+		}
+		dl.EnableMessageOrdering = true
+		p.deadLetter = dl
 	}
 	slog.DebugContext(ctx, "Successfully initialized publisher")
 	return p, func() {
 		tp.Stop()
+		if p.deadLetter != nil {
+			p.deadLetter.Stop()
+		}
 		cl.Close()
 	}, nil
 }
 
 // Publish publishes the provided message to the configured topics in Cloud PubSub.
+// Failed publishes are retried according to the retry policy. A message that still fails
+// is published to the dead-letter topic, if configured, and otherwise dropped; in both
+// cases the error of the last attempt is returned.
 func (p *publisher) Publish(ctx context.Context, msg *pubsub.Message) (string, error) {
-	res := p.topic.Publish(ctx, msg)
-	return res.Get(ctx)
+	var err error
+	backoff := p.initialBackoff
+	for attempt := 1; attempt <= p.maxAttempts; attempt++ {
+		if attempt > 1 {
+			if serr := p.sleep(ctx, backoff); serr != nil {
+				break
+			}
+			backoff = min(2*backoff, p.maxBackoff)
+			metrics.Add("retried", 1)
+		}
+		var id string
+		if id, err = p.topic.Publish(ctx, msg).Get(ctx); err == nil {
+			metrics.Add("published", 1)
+			return id, nil
+		}
+		slog.WarnContext(ctx, "Publisher: Failed to publish event", "topic", p.topic.ID(), "attempt", attempt, "error", err)
+		if msg.OrderingKey != "" {
+			// A failed publish pauses its ordering key until publishing is resumed.
+			p.topic.ResumePublish(msg.OrderingKey)
+		}
+	}
+	return "", p.deadLetterMsg(ctx, msg, err)
+}
+
+// deadLetterMsg publishes a message that failed to publish with err to the dead-letter
+// topic and returns err annotated with the outcome.
+func (p *publisher) deadLetterMsg(ctx context.Context, msg *pubsub.Message, err error) error {
+	if p.deadLetter == nil {
+		metrics.Add("dropped", 1)
+		return fmt.Errorf("event dropped: %w", err)
+	}
+	attrs := make(map[string]string, len(msg.Attributes)+3)
+	for k, v := range msg.Attributes {
+		attrs[k] = v
+	}
+	attrs[DeadLetterTopicAttribute] = p.topic.ID()
+	attrs[DeadLetterErrorAttribute] = err.Error()
+	attrs[DeadLetterAttemptsAttribute] = strconv.Itoa(p.maxAttempts)
+	dlMsg := &pubsub.Message{Data: msg.Data, Attributes: attrs, OrderingKey: msg.OrderingKey}
+	id, dlErr := p.deadLetter.Publish(context.WithoutCancel(ctx), dlMsg).Get(context.WithoutCancel(ctx))
+	if dlErr != nil {
+		if dlMsg.OrderingKey != "" {
+			p.deadLetter.ResumePublish(dlMsg.OrderingKey)
+		}
+		slog.ErrorContext(ctx, "Publisher: Failed to publish event to dead-letter topic, dropping it", "topic", p.deadLetter.ID(), "error", dlErr)
+		metrics.Add("dropped", 1)
+		return fmt.Errorf("event dropped, dead-letter publish failed with %v: %w", dlErr, err)
+	}
+	slog.WarnContext(ctx, "Publisher: Event published to dead-letter topic", "topic", p.deadLetter.ID(), "message_id", id)
+	metrics.Add("dead_lettered", 1)
+	return fmt.Errorf("event dead-lettered as %s: %w", id, err)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Health checks that the configured topic is reachable and still exists.
+func (p *publisher) Health(ctx context.Context) error {
+	exists, err := p.topic.Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("topic.Exists: %w", err)
+	}
+	if !exists {
+		return ErrTopicNotFound
+	}
+	return nil
 }
 
 func initPS(ctx context.Context, pID string, tID string, opts []option.ClientOption) (*pubsub.Client, *pubsub.Topic, error) {
@@ -136,22 +311,71 @@ func validate(c *Config) error {
 	if strings.TrimSpace(c.TopicID) == "" {
 		return ErrMissingTopicID
 	}
+	if r := c.Retry; r != nil && (r.MaxAttempts < 0 || r.InitialBackoff < 0 || r.MaxBackoff < 0) {
+		return ErrInvalidRetryConfig
+	}
+	if _, err := url.Parse(c.Source); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSource, err)
+	}
 
 	return nil
 }
 
+// publishMsg publishes data as the payload of a CloudEvent of type tp in structured mode.
+// The envelope attributes are also set on the message, so that subscriptions can filter on them.
 func (p *publisher) publishMsg(ctx context.Context, tp model.EventType, data any) (string, error) {
 	b, err := json.Marshal(data)
 	if err != nil {
 		return "", fmt.Errorf("json.Marshal(%v): %w", data, err)
 	}
+	key := orderingKey(data)
+	ce, err := json.Marshal(events.NewCloudEvent(p.newID(), p.source, tp, subject(data, key), p.now(), b))
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal(CloudEvent): %w", err)
+	}
 	msg := &pubsub.Message{
-		Attributes: map[string]string{"event_type": string(tp)},
-		Data:       b,
+		Attributes:  events.Attributes(tp),
+		Data:        ce,
+		OrderingKey: key,
+	}
+	msg.Attributes[events.AttributeContentType] = events.CloudEventsContentType
+	if msg.OrderingKey != "" {
+		msg.Attributes[events.AttributeSubscriberID] = msg.OrderingKey
 	}
 	return p.Publish(ctx, msg)
 }
 
+// subject returns the CloudEvents subject of an event payload: the subscriber it is about,
+// given as key, or the operation of an ON_SUBSCRIBE_RECIEVED event.
+func subject(data any, key string) string {
+	if ev, ok := data.(*events.OnSubscribeRecieved); ok {
+		return ev.OperationID
+	}
+	return key
+}
+
+// orderingKey returns the ID of the subscriber the event payload is about, or "" if it is
+// not about a single subscriber.
+func orderingKey(data any) string {
+	switch d := data.(type) {
+	case *model.SubscriptionRequest:
+		return d.SubscriberID
+	case *model.Subscription:
+		return d.SubscriberID
+	case *model.LRO:
+		// The operation's request is the subscription request it was created for.
+		var req model.SubscriptionRequest
+		if err := json.Unmarshal(d.RequestJSON, &req); err != nil {
+			return ""
+		}
+		return req.SubscriberID
+	case *events.KeyRotated:
+		return d.SubscriberID
+	default:
+		return ""
+	}
+}
+
 // PublishNewSubscriptionRequestEvent publishes a new subscription request event to PubSub.
 func (p *publisher) PublishNewSubscriptionRequestEvent(ctx context.Context, req *model.SubscriptionRequest) (string, error) {
 	return p.publishMsg(ctx, model.EventTypeNewSubscriptionRequest, req)
@@ -172,10 +396,25 @@ func (p *publisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context,
 	return p.publishMsg(ctx, model.EventTypeSubscriptionRequestRejected, req)
 }
 
-type OnSubscribeRecievedEvent struct {
-	OperationID string `json:"operation_id"`
+// PublishSubscriptionActivatedEvent publishes a subscription activated event to PubSub.
+func (p *publisher) PublishSubscriptionActivatedEvent(ctx context.Context, sub *model.Subscription) (string, error) {
+	return p.publishMsg(ctx, model.EventTypeSubscriptionActivated, sub)
 }
 
+// OnSubscribeRecievedEvent is the payload of an ON_SUBSCRIBE_RECIEVED event.
+type OnSubscribeRecievedEvent = events.OnSubscribeRecieved
+
 func (p *publisher) PublishOnSubscribeRecievedEvent(ctx context.Context, lroID string) (string, error) {
 	return p.publishMsg(ctx, model.EventTypeOnSubscribeRecieved, &OnSubscribeRecievedEvent{OperationID: lroID})
 }
+
+// PublishKeyRotatedEvent publishes a key rotated event to PubSub.
+func (p *publisher) PublishKeyRotatedEvent(ctx context.Context, ev *events.KeyRotated) (string, error) {
+	return p.publishMsg(ctx, model.EventTypeKeyRotated, ev)
+}
+
+// PublishKeyAccessedEvent publishes a key accessed audit event to PubSub.
+// Audit events are not about a subscriber's lifecycle and are published without an ordering key.
+func (p *publisher) PublishKeyAccessedEvent(ctx context.Context, ev *events.KeyAccessed) (string, error) {
+	return p.publishMsg(ctx, model.EventTypeKeyAccessed, ev)
+}
@@ -17,9 +17,14 @@ package event
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"cloud.google.com/go/pubsub"
@@ -229,6 +234,29 @@ func TestPublishError(t *testing.T) {
 	}
 }
 
+func TestHealthSuccess(t *testing.T) {
+	ctx := context.Background()
+	p, _, cleanup := setUpPublisher(ctx, t)
+	defer cleanup()
+
+	if err := p.Health(ctx); err != nil {
+		t.Errorf("Health() = %v, want nil", err)
+	}
+}
+
+func TestHealthTopicDeleted(t *testing.T) {
+	ctx := context.Background()
+	p, _, cleanup := setUpPublisher(ctx, t)
+	defer cleanup()
+	if err := p.topic.Delete(ctx); err != nil {
+		t.Fatalf("topic.Delete() = %v, want nil", err)
+	}
+
+	if err := p.Health(ctx); !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("Health() = %v, want %v", err, ErrTopicNotFound)
+	}
+}
+
 func TestValidateFailure(t *testing.T) {
 	tc := []struct {
 		name      string
@@ -249,11 +277,26 @@ func TestValidateFailure(t *testing.T) {
 			cfg:       &Config{TopicID: "missing-topic"},
 			wantError: ErrMissingProjectID,
 		},
+		{
+			name:      "negative_retry_attempts",
+			cfg:       &Config{TopicID: "test-topic", ProjectID: testProject, Retry: &RetryConfig{MaxAttempts: -1}},
+			wantError: ErrInvalidRetryConfig,
+		},
+		{
+			name:      "negative_retry_backoff",
+			cfg:       &Config{TopicID: "test-topic", ProjectID: testProject, Retry: &RetryConfig{InitialBackoff: -time.Second}},
+			wantError: ErrInvalidRetryConfig,
+		},
+		{
+			name:      "invalid_source",
+			cfg:       &Config{TopicID: "test-topic", ProjectID: testProject, Source: "://registry"},
+			wantError: ErrInvalidSource,
+		},
 	}
 
 	for _, tc := range tc {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := validate(tc.cfg); err != tc.wantError {
+			if err := validate(tc.cfg); !errors.Is(err, tc.wantError) {
 				t.Fatalf("validate(%v) = %v, want %v", tc.cfg, err, tc.wantError)
 			}
 		})
@@ -279,12 +322,38 @@ func setUpPublisher(ctx context.Context, t *testing.T) (*publisher, *pstest.Serv
 	if err != nil {
 		t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
 	}
+	publisher.newID = func() string { return testEventID }
+	publisher.now = func() time.Time { return testEventTime }
 	return publisher, psSrv, func() {
 		close()
 		cleanup()
 	}
 }
 
+const testEventID = "test-event-id"
+
+var testEventTime = time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+// wantCloudEvent returns the CloudEvent a publisher created by setUpPublisher publishes for a payload.
+func wantCloudEvent(t *testing.T, tp model.EventType, subject string, payload []byte) []byte {
+	t.Helper()
+	b, err := json.Marshal(&events.CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              testEventID,
+		Source:          "/onix",
+		Type:            string(tp),
+		Subject:         subject,
+		Time:            testEventTime,
+		DataContentType: "application/json",
+		EventVersion:    "v1",
+		Data:            payload,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal CloudEvent: %v", err)
+	}
+	return b
+}
+
 func TestPublishMsg(t *testing.T) {
 	ctx := context.Background()
 	testMsgID := "testMsgID"
@@ -315,10 +384,12 @@ func TestPublishNewSubscriptionRequestEvent(t *testing.T) {
 	}
 	want := &pstest.Message{
 		Attributes: map[string]string{
-			"event_type": "NEW_SUBSCRIPTION_REQUEST",
+			"event_type":    "NEW_SUBSCRIPTION_REQUEST",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
 		},
 		Topic: testTopicName,
-		Data:  byts,
+		Data:  wantCloudEvent(t, model.EventTypeNewSubscriptionRequest, "", byts),
 	}
 	if _, err := publisher.PublishNewSubscriptionRequestEvent(ctx, req); err != nil {
 		t.Fatalf("PublishNewSubscriptionRequestEvent() returned an unexpected error: %v", err)
@@ -340,10 +411,12 @@ func TestPublishUpdateSubscriptionRequestEvent(t *testing.T) {
 	}
 	want := &pstest.Message{
 		Attributes: map[string]string{
-			"event_type": "UPDATE_SUBSCRIPTION_REQUEST",
+			"event_type":    "UPDATE_SUBSCRIPTION_REQUEST",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
 		},
 		Topic: testTopicName,
-		Data:  byts,
+		Data:  wantCloudEvent(t, model.EventTypeUpdateSubscriptionRequest, "", byts),
 	}
 	if _, err := publisher.PublishUpdateSubscriptionRequestEvent(ctx, req); err != nil {
 		t.Fatalf("PublishUpdateSubscriptionRequestEvent() returned an unexpected error: %v", err)
@@ -366,10 +439,12 @@ func TestPublishSubscriptionRequestApprovedEvent(t *testing.T) {
 	}
 	want := &pstest.Message{
 		Attributes: map[string]string{
-			"event_type": "SUBSCRIPTION_REQUEST_APPROVED",
+			"event_type":    "SUBSCRIPTION_REQUEST_APPROVED",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
 		},
 		Topic: testTopicName,
-		Data:  byts,
+		Data:  wantCloudEvent(t, model.EventTypeSubscriptionRequestApproved, "", byts),
 	}
 	if _, err := publisher.PublishSubscriptionRequestApprovedEvent(ctx, req); err != nil {
 		t.Fatalf("PublishSubscriptionRequestApprovedEvent() returned an unexpected error: %v", err)
@@ -392,10 +467,12 @@ func TestPublishSubscriptionRequestRejectedEvent(t *testing.T) {
 	}
 	want := &pstest.Message{
 		Attributes: map[string]string{
-			"event_type": "SUBSCRIPTION_REQUEST_REJECTED",
+			"event_type":    "SUBSCRIPTION_REQUEST_REJECTED",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
 		},
 		Topic: testTopicName,
-		Data:  byts,
+		Data:  wantCloudEvent(t, model.EventTypeSubscriptionRequestRejected, "", byts),
 	}
 	if _, err := publisher.PublishSubscriptionRequestRejectedEvent(ctx, req); err != nil {
 		t.Fatalf("PublishSubscriptionRequestRejectedEvent() returned an unexpected error: %v", err)
@@ -419,10 +496,12 @@ func TestPublishOnSubscribeRecievedEvent(t *testing.T) {
 	}
 	want := &pstest.Message{
 		Attributes: map[string]string{
-			"event_type": "ON_SUBSCRIBE_RECIEVED",
+			"event_type":    "ON_SUBSCRIBE_RECIEVED",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
 		},
 		Topic: testTopicName,
-		Data:  byts,
+		Data:  wantCloudEvent(t, model.EventTypeOnSubscribeRecieved, lroID, byts),
 	}
 	if _, err := publisher.PublishOnSubscribeRecievedEvent(ctx, lroID); err != nil {
 		t.Fatalf("PublishOnSubscribeRecievedEvent() returned an unexpected error: %v", err)
@@ -435,3 +514,358 @@ func TestPublishOnSubscribeRecievedEvent(t *testing.T) {
 		t.Errorf("PublishOnSubscribeRecievedEvent(%v) returned diff (-want +got):\n%s", lroID, d)
 	}
 }
+
+func TestPublishKeyRotatedEvent(t *testing.T) {
+	ctx := context.Background()
+	publisher, psSrv, cleanup := setUpPublisher(ctx, t)
+	defer cleanup()
+	ev := &events.KeyRotated{SubscriberID: "test-subscriber", KeyID: "new-key", PreviousKeyID: "old-key"}
+
+	byts, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatalf("failed to marshal testData: %v", err)
+	}
+	want := &pstest.Message{
+		Attributes: map[string]string{
+			"event_type":    "KEY_ROTATED",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
+			"subscriber_id": "test-subscriber",
+		},
+		Topic:       testTopicName,
+		Data:        wantCloudEvent(t, model.EventTypeKeyRotated, ev.SubscriberID, byts),
+		OrderingKey: "test-subscriber",
+	}
+	if _, err := publisher.PublishKeyRotatedEvent(ctx, ev); err != nil {
+		t.Fatalf("PublishKeyRotatedEvent() returned an unexpected error: %v", err)
+	}
+	got := psSrv.Messages()[0]
+	if d := cmp.Diff(want, got, msgCmpOpts...); d != "" {
+		t.Errorf("PublishKeyRotatedEvent(%v) returned diff (-want +got):\n%s", ev, d)
+	}
+}
+
+func TestPublishSubscriptionActivatedEvent(t *testing.T) {
+	ctx := context.Background()
+	publisher, psSrv, cleanup := setUpPublisher(ctx, t)
+	defer cleanup()
+	sub := &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: "test-subscriber", Domain: "retail", Type: model.RoleBPP},
+		Status:     model.SubscriptionStatusSubscribed,
+	}
+
+	byts, err := json.Marshal(sub)
+	if err != nil {
+		t.Fatalf("failed to marshal testData: %v", err)
+	}
+	want := &pstest.Message{
+		Attributes: map[string]string{
+			"event_type":    "SUBSCRIPTION_ACTIVATED",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
+			"subscriber_id": "test-subscriber",
+		},
+		Topic:       testTopicName,
+		Data:        wantCloudEvent(t, model.EventTypeSubscriptionActivated, sub.SubscriberID, byts),
+		OrderingKey: "test-subscriber",
+	}
+	if _, err := publisher.PublishSubscriptionActivatedEvent(ctx, sub); err != nil {
+		t.Fatalf("PublishSubscriptionActivatedEvent() returned an unexpected error: %v", err)
+	}
+	got := psSrv.Messages()[0]
+	if d := cmp.Diff(want, got, msgCmpOpts...); d != "" {
+		t.Errorf("PublishSubscriptionActivatedEvent(%v) returned diff (-want +got):\n%s", sub, d)
+	}
+}
+
+func TestPublishKeyAccessedEvent(t *testing.T) {
+	ctx := context.Background()
+	publisher, psSrv, cleanup := setUpPublisher(ctx, t)
+	defer cleanup()
+	ev := &events.KeyAccessed{Service: "gateway", Operation: events.KeyAccessLookupNPKeys, KeyID: "key-1", SubscriberID: "test-subscriber", Result: events.KeyAccessSuccess}
+
+	byts, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatalf("failed to marshal testData: %v", err)
+	}
+	want := &pstest.Message{
+		Attributes: map[string]string{
+			"event_type":    "KEY_ACCESSED",
+			"event_version": "v1",
+			"content-type":  "application/cloudevents+json",
+		},
+		Topic: testTopicName,
+		Data:  wantCloudEvent(t, model.EventTypeKeyAccessed, "", byts),
+	}
+	if _, err := publisher.PublishKeyAccessedEvent(ctx, ev); err != nil {
+		t.Fatalf("PublishKeyAccessedEvent() returned an unexpected error: %v", err)
+	}
+	got := psSrv.Messages()[0]
+	if d := cmp.Diff(want, got, msgCmpOpts...); d != "" {
+		t.Errorf("PublishKeyAccessedEvent(%v) returned diff (-want +got):\n%s", ev, d)
+	}
+}
+
+func TestPublishOrderingKey(t *testing.T) {
+	reqJSON, err := json.Marshal(&model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np1"}}})
+	if err != nil {
+		t.Fatalf("failed to marshal testData: %v", err)
+	}
+	sub := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np1"}}
+	tests := []struct {
+		name    string
+		publish func(ctx context.Context, p *publisher) (string, error)
+		wantKey string
+	}{
+		{
+			name: "new subscription request",
+			publish: func(ctx context.Context, p *publisher) (string, error) {
+				return p.PublishNewSubscriptionRequestEvent(ctx, &model.SubscriptionRequest{Subscription: sub})
+			},
+			wantKey: "np1",
+		},
+		{
+			name: "update subscription request",
+			publish: func(ctx context.Context, p *publisher) (string, error) {
+				return p.PublishUpdateSubscriptionRequestEvent(ctx, &model.SubscriptionRequest{Subscription: sub})
+			},
+			wantKey: "np1",
+		},
+		{
+			name: "approved operation",
+			publish: func(ctx context.Context, p *publisher) (string, error) {
+				return p.PublishSubscriptionRequestApprovedEvent(ctx, &model.LRO{OperationID: "op1", RequestJSON: reqJSON})
+			},
+			wantKey: "np1",
+		},
+		{
+			name: "rejected operation",
+			publish: func(ctx context.Context, p *publisher) (string, error) {
+				return p.PublishSubscriptionRequestRejectedEvent(ctx, &model.LRO{OperationID: "op1", RequestJSON: reqJSON})
+			},
+			wantKey: "np1",
+		},
+		{
+			name: "operation without request",
+			publish: func(ctx context.Context, p *publisher) (string, error) {
+				return p.PublishSubscriptionRequestRejectedEvent(ctx, &model.LRO{OperationID: "op1"})
+			},
+		},
+		{
+			name: "on_subscribe received",
+			publish: func(ctx context.Context, p *publisher) (string, error) {
+				return p.PublishOnSubscribeRecievedEvent(ctx, "op1")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p, psSrv, cleanup := setUpPublisher(ctx, t)
+			defer cleanup()
+			if _, err := tt.publish(ctx, p); err != nil {
+				t.Fatalf("publish returned an unexpected error: %v", err)
+			}
+			got := psSrv.Messages()[0]
+			if got.OrderingKey != tt.wantKey {
+				t.Errorf("OrderingKey = %q, want %q", got.OrderingKey, tt.wantKey)
+			}
+			if attr, ok := got.Attributes[events.AttributeSubscriberID]; attr != tt.wantKey || ok != (tt.wantKey != "") {
+				t.Errorf("Attributes[%s] = %q (present %v), want %q", events.AttributeSubscriberID, attr, ok, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestPublishRetryResumesOrderingKey(t *testing.T) {
+	ctx := context.Background()
+	p, psSrv, _, cleanup := setUpRetryingPublisher(ctx, t, &RetryConfig{MaxAttempts: 2})
+	defer cleanup()
+	psSrv.SetAutoPublishResponse(false)
+	psSrv.AddPublishResponse(nil, status.Errorf(codes.DataLoss, "obscure error"))
+	psSrv.AddPublishResponse(&pb.PublishResponse{MessageIds: []string{"msg-1"}}, nil)
+
+	got, err := p.Publish(ctx, &pubsub.Message{Data: []byte("data"), OrderingKey: "np1"})
+	if err != nil {
+		t.Fatalf("Publish() = %v, want nil", err)
+	}
+	if got != "msg-1" {
+		t.Errorf("Publish() = %q, want %q", got, "msg-1")
+	}
+}
+
+const testDeadLetterTopic = "test-dead-letter-topic"
+
+// setUpRetryingPublisher creates a publisher with the given retry policy.
+// Backoffs are recorded instead of slept.
+func setUpRetryingPublisher(ctx context.Context, t *testing.T, retry *RetryConfig) (*publisher, *pstest.Server, *[]time.Duration, func()) {
+	t.Helper()
+	psSrv, opts, cleanup := setUpTestPubsub(ctx, t, testTopic)
+	cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, Retry: retry}
+	p, close, err := NewPublisher(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
+	}
+	var backoffs []time.Duration
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		backoffs = append(backoffs, d)
+		return nil
+	}
+	return p, psSrv, &backoffs, func() {
+		close()
+		cleanup()
+	}
+}
+
+// counter returns the value of an events counter.
+func counter(name string) int64 {
+	if v, ok := metrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestNewPublisherRetryDefaults(t *testing.T) {
+	p, _, _, cleanup := setUpRetryingPublisher(context.Background(), t, &RetryConfig{})
+	defer cleanup()
+
+	if p.maxAttempts != defaultMaxAttempts || p.initialBackoff != defaultInitialBackoff || p.maxBackoff != defaultMaxBackoff {
+		t.Errorf("NewPublisher() retry = %d, %v, %v, want defaults", p.maxAttempts, p.initialBackoff, p.maxBackoff)
+	}
+}
+
+func TestNewPublisherDeadLetterTopicNotFound(t *testing.T) {
+	_, opts, cleanup := setUpTestPubsub(context.Background(), t, testTopic)
+	defer cleanup()
+	cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, DeadLetterTopicID: "missing-topic"}
+
+	if _, _, err := NewPublisher(context.Background(), cfg); !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("NewPublisher(%v) = %v, want %v", cfg, err, ErrTopicNotFound)
+	}
+}
+
+func TestPublishRetrySuccess(t *testing.T) {
+	ctx := context.Background()
+	p, psSrv, backoffs, cleanup := setUpRetryingPublisher(ctx, t, &RetryConfig{MaxAttempts: 4, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second})
+	defer cleanup()
+	psSrv.SetAutoPublishResponse(false)
+	publishErr := status.Errorf(codes.DataLoss, "obscure error")
+	psSrv.AddPublishResponse(nil, publishErr)
+	psSrv.AddPublishResponse(nil, publishErr)
+	psSrv.AddPublishResponse(nil, publishErr)
+	psSrv.AddPublishResponse(&pb.PublishResponse{MessageIds: []string{"msg-1"}}, nil)
+	retried, published := counter("retried"), counter("published")
+
+	got, err := p.Publish(ctx, &pubsub.Message{Data: []byte("data")})
+	if err != nil {
+		t.Fatalf("Publish() = %v, want nil", err)
+	}
+	if got != "msg-1" {
+		t.Errorf("Publish() = %q, want %q", got, "msg-1")
+	}
+	if d := cmp.Diff([]time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, *backoffs); d != "" {
+		t.Errorf("Publish() backoffs diff (-want +got):\n%s", d)
+	}
+	if n := counter("retried") - retried; n != 3 {
+		t.Errorf("retried increased by %d, want 3", n)
+	}
+	if n := counter("published") - published; n != 1 {
+		t.Errorf("published increased by %d, want 1", n)
+	}
+}
+
+func TestPublishRetryExhaustedDropped(t *testing.T) {
+	ctx := context.Background()
+	p, psSrv, _, cleanup := setUpRetryingPublisher(ctx, t, &RetryConfig{MaxAttempts: 2})
+	defer cleanup()
+	psSrv.SetAutoPublishResponse(false)
+	publishErr := status.Errorf(codes.DataLoss, "obscure error")
+	psSrv.AddPublishResponse(nil, publishErr)
+	psSrv.AddPublishResponse(nil, publishErr)
+	dropped := counter("dropped")
+
+	if _, err := p.Publish(ctx, &pubsub.Message{Data: []byte("data")}); !errors.Is(err, publishErr) {
+		t.Errorf("Publish() = %v, want %v", err, publishErr)
+	}
+	if n := counter("dropped") - dropped; n != 1 {
+		t.Errorf("dropped increased by %d, want 1", n)
+	}
+}
+
+// topicErrorReactor fails publishes to one topic and lets the test server handle the rest.
+type topicErrorReactor struct {
+	topic string
+	err   error
+}
+
+func (r *topicErrorReactor) React(req interface{}) (bool, interface{}, error) {
+	if pr, ok := req.(*pb.PublishRequest); ok && pr.GetTopic() == r.topic {
+		return true, nil, r.err
+	}
+	return false, nil, nil
+}
+
+func TestPublishRetryExhaustedDeadLettered(t *testing.T) {
+	ctx := context.Background()
+	publishErr := status.Errorf(codes.DataLoss, "obscure error")
+	psSrv, opts, cleanup := setUpTestPubsub(ctx, t, testTopic, pstest.ServerReactorOption{
+		FuncName: "Publish",
+		Reactor:  &topicErrorReactor{topic: testTopicName, err: publishErr},
+	})
+	defer cleanup()
+	if _, err := psSrv.GServer.CreateTopic(ctx, &pb.Topic{Name: "projects/" + testProject + "/topics/" + testDeadLetterTopic}); err != nil {
+		t.Fatalf("failed to create dead-letter topic: %v", err)
+	}
+	cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, Retry: &RetryConfig{MaxAttempts: 2}, DeadLetterTopicID: testDeadLetterTopic}
+	p, close, err := NewPublisher(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
+	}
+	defer close()
+	p.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	deadLettered := counter("dead_lettered")
+
+	msg := &pubsub.Message{Data: []byte("data"), Attributes: events.Attributes(model.EventTypeNewSubscriptionRequest)}
+	if _, err := p.Publish(ctx, msg); status.Code(errors.Unwrap(err)) != codes.DataLoss {
+		t.Errorf("Publish() = %v, want %v", err, publishErr)
+	}
+	if n := counter("dead_lettered") - deadLettered; n != 1 {
+		t.Errorf("dead_lettered increased by %d, want 1", n)
+	}
+	msgs := psSrv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("published %d messages, want 1", len(msgs))
+	}
+	want := &pstest.Message{
+		Attributes: map[string]string{
+			"event_type":                "NEW_SUBSCRIPTION_REQUEST",
+			"event_version":             "v1",
+			DeadLetterTopicAttribute:    testTopic,
+			DeadLetterErrorAttribute:    msgs[0].Attributes[DeadLetterErrorAttribute],
+			DeadLetterAttemptsAttribute: "2",
+		},
+		Topic: "projects/" + testProject + "/topics/" + testDeadLetterTopic,
+		Data:  []byte("data"),
+	}
+	if d := cmp.Diff(want, msgs[0], msgCmpOpts...); d != "" {
+		t.Errorf("dead-lettered message diff (-want +got):\n%s", d)
+	}
+	if !strings.Contains(msgs[0].Attributes[DeadLetterErrorAttribute], "obscure error") {
+		t.Errorf("%s = %q, want the publish error", DeadLetterErrorAttribute, msgs[0].Attributes[DeadLetterErrorAttribute])
+	}
+}
+
+func TestPublishRetryContextCancelled(t *testing.T) {
+	ctx := context.Background()
+	p, psSrv, _, cleanup := setUpRetryingPublisher(ctx, t, &RetryConfig{MaxAttempts: 3})
+	defer cleanup()
+	psSrv.SetAutoPublishResponse(false)
+	publishErr := status.Errorf(codes.DataLoss, "obscure error")
+	psSrv.AddPublishResponse(nil, publishErr)
+	p.sleep = func(ctx context.Context, d time.Duration) error { return context.Canceled }
+
+	if _, err := p.Publish(ctx, &pubsub.Message{Data: []byte("data")}); !errors.Is(err, publishErr) {
+		t.Errorf("Publish() = %v, want %v", err, publishErr)
+	}
+}
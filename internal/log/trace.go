@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TracingConfig controls the OpenTelemetry traces recorded for HTTP requests and the work they trigger.
+type TracingConfig struct {
+	SampleRatio float64 `yaml:"sampleRatio"` // Fraction (0 to 1) of new traces recorded. Incoming sampled traces are always recorded.
+}
+
+// SetupTracing installs the global tracer provider and W3C trace context propagator.
+// Finished spans are written to the slog logger so they share the logging pipeline.
+// The returned function flushes pending spans and must be called on shutdown.
+func SetupTracing(cfg *TracingConfig) (func(context.Context) error, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sampleRatio: %v, must be between 0 and 1", cfg.SampleRatio)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithBatcher(spanLogger{}),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	slog.Info("Tracing initialized", "sample_ratio", cfg.SampleRatio)
+	return tp.Shutdown, nil
+}
+
+// spanLogger exports finished spans as structured log records.
+type spanLogger struct{}
+
+// ExportSpans logs each span with its trace identifiers, timing and attributes.
+func (spanLogger) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, s := range spans {
+		attrs := []any{
+			"name", s.Name(),
+			"trace_id", s.SpanContext().TraceID().String(),
+			"span_id", s.SpanContext().SpanID().String(),
+			"duration", s.EndTime().Sub(s.StartTime()),
+		}
+		if s.Parent().IsValid() {
+			attrs = append(attrs, "parent_span_id", s.Parent().SpanID().String())
+		}
+		if s.Status().Code != codes.Unset {
+			attrs = append(attrs, "status", s.Status().Code.String(), "status_message", s.Status().Description)
+		}
+		for _, kv := range s.Attributes() {
+			attrs = append(attrs, string(kv.Key), kv.Value.Emit())
+		}
+		slog.InfoContext(ctx, "Trace: Span", attrs...)
+	}
+	return nil
+}
+
+// Shutdown has nothing to release.
+func (spanLogger) Shutdown(context.Context) error {
+	return nil
+}
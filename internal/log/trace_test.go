@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetupTracing_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *TracingConfig
+	}{
+		{name: "nil config", cfg: nil},
+		{name: "negative sample ratio", cfg: &TracingConfig{SampleRatio: -0.1}},
+		{name: "sample ratio above 1", cfg: &TracingConfig{SampleRatio: 1.5}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := SetupTracing(tc.cfg); err == nil {
+				t.Error("SetupTracing() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestSetupTracing_Success(t *testing.T) {
+	defer saveAndRestoreDefaultSlog(t)()
+	defaultProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(defaultProvider)
+
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	shutdown, err := SetupTracing(&TracingConfig{SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("SetupTracing() error = %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "GET /lookup")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"msg":"Trace: Span","name":"GET /lookup"`)) {
+		t.Errorf("log = %s, want the span", buf.String())
+	}
+}
+
+func TestSpanLogger_ExportSpans(t *testing.T) {
+	defer saveAndRestoreDefaultSlog(t)()
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanLogger{}))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "GET /lookup")
+	_, child := tp.Tracer("test").Start(ctx, "Lookup")
+	child.SetAttributes(attribute.Int64("db.response.returned_rows", 2))
+	child.RecordError(errors.New("boom"))
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	parent.End()
+
+	var got map[string]any
+	line, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	if err := json.Unmarshal(line, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, log = %s", err, buf.String())
+	}
+	want := map[string]any{
+		"name":                      "Lookup",
+		"trace_id":                  parent.SpanContext().TraceID().String(),
+		"span_id":                   child.SpanContext().SpanID().String(),
+		"parent_span_id":            parent.SpanContext().SpanID().String(),
+		"status":                    "Error",
+		"status_message":            "boom",
+		"db.response.returned_rows": "2",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("log[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+	if _, ok := got["duration"]; !ok {
+		t.Error("log has no duration")
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+)
+
+// ErrColumnKeyUnknown is returned when a column value was encrypted with a data key that is not configured.
+var ErrColumnKeyUnknown = errors.New("column value encrypted with an unknown data key")
+
+// Column names bound to their encrypted values, so a value cannot be decrypted as another column.
+const (
+	nonceColumn        = "subscriptions.nonce"
+	errorDataColumn    = "operations.error_data_json"
+	requestNonceColumn = "operations.request_json.nonce"
+	nonceKeyColumn     = "subscription_nonces.nonce"
+)
+
+// encryptedPrefix marks a column value encrypted by a columnCipher.
+const encryptedPrefix = "enc:v1:"
+
+// digestPrefix marks a column value replaced by its keyed hash.
+const digestPrefix = "hmac:v1:"
+
+// ColumnEncryptionConfig enables application-level encryption of sensitive columns.
+// Values are encrypted with AES-256-GCM under a data key, which is stored wrapped by a Cloud KMS key.
+type ColumnEncryptionConfig struct {
+	KMSKey      string           `yaml:"kmsKey"`      // Cloud KMS key wrapping the data keys, as projects/*/locations/*/keyRings/*/cryptoKeys/*.
+	ActiveKeyID string           `yaml:"activeKeyID"` // ID of the data key new values are encrypted with.
+	DataKeys    []WrappedDataKey `yaml:"dataKeys"`    // All data keys values may be encrypted with, including retired ones.
+}
+
+// WrappedDataKey is a 256-bit data key encrypted with the Cloud KMS key.
+type WrappedDataKey struct {
+	ID      string `yaml:"id"`      // Stored with every value encrypted with the key.
+	Wrapped string `yaml:"wrapped"` // Base64 ciphertext of the data key returned by Cloud KMS.
+}
+
+// keyUnwrapper decrypts data keys with a key management service.
+type keyUnwrapper interface {
+	Unwrap(ctx context.Context, kmsKey string, wrapped []byte) ([]byte, error)
+}
+
+// columnCipher encrypts and decrypts column values with the configured data keys.
+type columnCipher struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+	macKeys  map[string][]byte // HMAC keys derived from the data keys, by data key ID.
+}
+
+// NewKMSColumnCipher unwraps the configured data keys with Cloud KMS. The KMS client is only
+// needed at startup and is closed before returning.
+func NewKMSColumnCipher(ctx context.Context, cfg *ColumnEncryptionConfig) (*columnCipher, error) {
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KMS client: %w", err)
+	}
+	defer client.Close()
+	return newColumnCipher(ctx, &kmsUnwrapper{client: client}, cfg)
+}
+
+// newColumnCipher validates cfg and unwraps its data keys with u.
+func newColumnCipher(ctx context.Context, u keyUnwrapper, cfg *ColumnEncryptionConfig) (*columnCipher, error) {
+	if cfg == nil {
+		return nil, errors.New("column encryption config cannot be nil")
+	}
+	if cfg.KMSKey == "" {
+		return nil, errors.New("encryption.kmsKey is required")
+	}
+	c := &columnCipher{
+		activeID: cfg.ActiveKeyID,
+		keys:     make(map[string]cipher.AEAD, len(cfg.DataKeys)),
+		macKeys:  make(map[string][]byte, len(cfg.DataKeys)),
+	}
+	for _, dk := range cfg.DataKeys {
+		if dk.ID == "" || strings.Contains(dk.ID, ":") {
+			return nil, fmt.Errorf("invalid data key id '%s': must be non-empty and must not contain ':'", dk.ID)
+		}
+		if _, ok := c.keys[dk.ID]; ok {
+			return nil, fmt.Errorf("duplicate data key id '%s'", dk.ID)
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(dk.Wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode data key '%s': %w", dk.ID, err)
+		}
+		key, err := u.Unwrap(ctx, cfg.KMSKey, wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap data key '%s': %w", dk.ID, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("data key '%s' must be 32 bytes, got %d", dk.ID, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher for data key '%s': %w", dk.ID, err)
+		}
+		if c.keys[dk.ID], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("failed to create GCM for data key '%s': %w", dk.ID, err)
+		}
+		// The data key is not used for hashing directly, so that it only ever keys AES-GCM.
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("onix column digest key"))
+		c.macKeys[dk.ID] = mac.Sum(nil)
+	}
+	if _, ok := c.keys[cfg.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("encryption.activeKeyID '%s' is not one of encryption.dataKeys", cfg.ActiveKeyID)
+	}
+	return c, nil
+}
+
+// encrypt seals plaintext with the active data key, bound to column.
+// The result is "enc:v1:<key id>:<base64 of nonce and ciphertext>".
+func (c *columnCipher) encrypt(column string, plaintext []byte) (string, error) {
+	aead := c.keys[c.activeID]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(column))
+	return encryptedPrefix + c.activeID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a value returned by encrypt with the data key it names. Values without the
+// encrypted prefix were stored before encryption was enabled and are returned unchanged.
+func (c *columnCipher) decrypt(column, value string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return []byte(value), nil
+	}
+	keyID, data, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, errors.New("malformed encrypted column value")
+	}
+	aead, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrColumnKeyUnknown, keyID)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted column value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted column value is too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(column))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt column value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// digest returns the HMAC-SHA256 of value, bound to column, with the data key keyID. Equal values
+// have equal digests, so a digest can be looked up where the value itself must not be stored.
+// The result is "hmac:v1:<key id>:<base64 of the HMAC>".
+func (c *columnCipher) digest(keyID, column, value string) string {
+	mac := hmac.New(sha256.New, c.macKeys[keyID])
+	mac.Write([]byte(column))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return digestPrefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encryptedJSON is how an encrypted JSON value is stored in a JSONB column. It keeps the names
+// of the top-level fields and the "code" field readable, so failures can still be counted by code.
+type encryptedJSON struct {
+	Encrypted string   `json:"encrypted"`
+	Fields    []string `json:"fields,omitempty"`
+	Code      string   `json:"code,omitempty"`
+}
+
+// SetColumnCipher enables encryption of the subscription nonce, the operation error data and the
+// nonce in the operation request, and stores reserved nonces as their digests. Values stored
+// without encryption remain readable.
+func (r *registry) SetColumnCipher(c *columnCipher) {
+	r.cipher = c
+}
+
+// sealNonce returns the nonce of a subscription as stored.
+func (r *registry) sealNonce(nonce string) (string, error) {
+	if r.cipher == nil || nonce == "" {
+		return nonce, nil
+	}
+	sealed, err := r.cipher.encrypt(nonceColumn, []byte(nonce))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt nonce: %w", err)
+	}
+	return sealed, nil
+}
+
+// nonceKeys returns the values nonce may be stored as in subscription_nonces: first its digest with
+// the active data key, which new reservations use, then its digests with the other data keys and the
+// nonce itself, as reserved before the key was rotated or encryption was enabled.
+func (r *registry) nonceKeys(nonce string) []string {
+	if r.cipher == nil {
+		return []string{nonce}
+	}
+	keys := []string{r.cipher.digest(r.cipher.activeID, nonceKeyColumn, nonce)}
+	ids := make([]string, 0, len(r.cipher.macKeys))
+	for id := range r.cipher.macKeys {
+		if id != r.cipher.activeID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		keys = append(keys, r.cipher.digest(id, nonceKeyColumn, nonce))
+	}
+	return append(keys, nonce)
+}
+
+// sealRequest returns the request of an operation as stored, with its nonce encrypted.
+// The nonce is kept rather than dropped, as it is consumed when the operation is approved.
+func (r *registry) sealRequest(req json.RawMessage) (json.RawMessage, error) {
+	if r.cipher == nil {
+		return req, nil
+	}
+	fields, nonce := requestNonce(req)
+	if nonce == "" || strings.HasPrefix(nonce, encryptedPrefix) {
+		return req, nil
+	}
+	sealed, err := r.cipher.encrypt(requestNonceColumn, []byte(nonce))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt request nonce: %w", err)
+	}
+	fields["nonce"], _ = json.Marshal(sealed)
+	return json.Marshal(fields)
+}
+
+// openRequest returns the stored request of an operation with its nonce decrypted if needed.
+func (r *registry) openRequest(operationID string, stored json.RawMessage) (json.RawMessage, error) {
+	fields, nonce := requestNonce(stored)
+	if !strings.HasPrefix(nonce, encryptedPrefix) {
+		return stored, nil
+	}
+	if r.cipher == nil {
+		return nil, fmt.Errorf("request nonce of operation %s is encrypted but column encryption is not configured", operationID)
+	}
+	plaintext, err := r.cipher.decrypt(requestNonceColumn, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request nonce of operation %s: %w", operationID, err)
+	}
+	fields["nonce"], _ = json.Marshal(string(plaintext))
+	return json.Marshal(fields)
+}
+
+// requestNonce returns the top-level fields of an operation request and its nonce, if any.
+func requestNonce(req json.RawMessage) (map[string]json.RawMessage, string) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(req, &fields) != nil {
+		return nil, ""
+	}
+	var nonce string
+	_ = json.Unmarshal(fields["nonce"], &nonce)
+	return fields, nonce
+}
+
+// withoutNonce returns an operation request without its nonce, encrypted or not.
+func withoutNonce(req json.RawMessage) (json.RawMessage, error) {
+	fields, _ := requestNonce(req)
+	if _, ok := fields["nonce"]; !ok {
+		return req, nil
+	}
+	delete(fields, "nonce")
+	return json.Marshal(fields)
+}
+
+// sealErrorData returns the error data of an operation as stored.
+func (r *registry) sealErrorData(data json.RawMessage) (sql.NullString, error) {
+	if r.cipher == nil || data == nil {
+		return nullJSON(data), nil
+	}
+	var env encryptedJSON
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) == nil {
+		for k := range fields {
+			env.Fields = append(env.Fields, k)
+		}
+		sort.Strings(env.Fields)
+		_ = json.Unmarshal(fields["code"], &env.Code)
+	}
+	var err error
+	if env.Encrypted, err = r.cipher.encrypt(errorDataColumn, data); err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encrypt error data: %w", err)
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal encrypted error data: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// errorData returns the stored error data of an operation, decrypted if needed.
+func (r *registry) errorData(operationID string, stored sql.NullString) (json.RawMessage, error) {
+	if !stored.Valid {
+		return nil, nil
+	}
+	var env encryptedJSON
+	if json.Unmarshal([]byte(stored.String), &env) != nil || !strings.HasPrefix(env.Encrypted, encryptedPrefix) {
+		return json.RawMessage(stored.String), nil
+	}
+	if r.cipher == nil {
+		return nil, fmt.Errorf("error data of operation %s is encrypted but column encryption is not configured", operationID)
+	}
+	data, err := r.cipher.decrypt(errorDataColumn, env.Encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error data of operation %s: %w", operationID, err)
+	}
+	return data, nil
+}
+
+// redactedErrorData returns the stored error data of an operation reduced to its code, which is
+// readable in both plain and encrypted error data, or nil if it has no code.
+func redactedErrorData(stored sql.NullString) json.RawMessage {
+	var data struct {
+		Code string `json:"code"`
+	}
+	if !stored.Valid || json.Unmarshal([]byte(stored.String), &data) != nil || data.Code == "" {
+		return nil
+	}
+	b, _ := json.Marshal(data)
+	return b
+}
+
+// kmsUnwrapper unwraps data keys with Cloud KMS.
+type kmsUnwrapper struct {
+	client *kms.KeyManagementClient
+}
+
+// Unwrap decrypts wrapped with kmsKey. Cloud KMS picks the key version that wrapped it, so
+// rotating the KMS key does not require rewrapping the data keys.
+func (u *kmsUnwrapper) Unwrap(ctx context.Context, kmsKey string, wrapped []byte) ([]byte, error) {
+	resp, err := u.client.Decrypt(ctx, &kmspb.DecryptRequest{Name: kmsKey, Ciphertext: wrapped})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,313 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// fakeUnwrapper "unwraps" data keys by returning them unchanged.
+type fakeUnwrapper struct {
+	err    error
+	gotKey string
+}
+
+func (f *fakeUnwrapper) Unwrap(_ context.Context, kmsKey string, wrapped []byte) ([]byte, error) {
+	f.gotKey = kmsKey
+	return wrapped, f.err
+}
+
+func dataKey(id string, b byte) WrappedDataKey {
+	return WrappedDataKey{ID: id, Wrapped: base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))}
+}
+
+func newTestCipher(t *testing.T, active string, keys ...WrappedDataKey) *columnCipher {
+	t.Helper()
+	c, err := newColumnCipher(context.Background(), &fakeUnwrapper{}, &ColumnEncryptionConfig{KMSKey: "projects/p/locations/l/keyRings/r/cryptoKeys/k", ActiveKeyID: active, DataKeys: keys})
+	if err != nil {
+		t.Fatalf("newColumnCipher() unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestNewColumnCipher(t *testing.T) {
+	const kmsKey = "projects/p/locations/l/keyRings/r/cryptoKeys/k"
+	tests := []struct {
+		name      string
+		cfg       *ColumnEncryptionConfig
+		unwrapErr error
+		wantErr   string
+	}{
+		{name: "nil config", wantErr: "cannot be nil"},
+		{name: "missing kms key", cfg: &ColumnEncryptionConfig{ActiveKeyID: "k1", DataKeys: []WrappedDataKey{dataKey("k1", 1)}}, wantErr: "kmsKey is required"},
+		{name: "active key not configured", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k2", DataKeys: []WrappedDataKey{dataKey("k1", 1)}}, wantErr: "activeKeyID 'k2'"},
+		{name: "invalid key id", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k:1", DataKeys: []WrappedDataKey{dataKey("k:1", 1)}}, wantErr: "invalid data key id"},
+		{name: "duplicate key id", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k1", DataKeys: []WrappedDataKey{dataKey("k1", 1), dataKey("k1", 2)}}, wantErr: "duplicate data key id"},
+		{name: "wrapped key not base64", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k1", DataKeys: []WrappedDataKey{{ID: "k1", Wrapped: "!"}}}, wantErr: "failed to decode data key"},
+		{name: "unwrap fails", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k1", DataKeys: []WrappedDataKey{dataKey("k1", 1)}}, unwrapErr: errors.New("permission denied"), wantErr: "permission denied"},
+		{name: "wrong key size", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k1", DataKeys: []WrappedDataKey{{ID: "k1", Wrapped: base64.StdEncoding.EncodeToString([]byte("short"))}}}, wantErr: "must be 32 bytes"},
+		{name: "valid", cfg: &ColumnEncryptionConfig{KMSKey: kmsKey, ActiveKeyID: "k2", DataKeys: []WrappedDataKey{dataKey("k1", 1), dataKey("k2", 2)}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u := &fakeUnwrapper{err: tc.unwrapErr}
+			_, err := newColumnCipher(context.Background(), u, tc.cfg)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("newColumnCipher() unexpected error: %v", err)
+				}
+				if u.gotKey != kmsKey {
+					t.Errorf("Unwrap() called with key %q, want %q", u.gotKey, kmsKey)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("newColumnCipher() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestColumnCipher_EncryptDecrypt(t *testing.T) {
+	c := newTestCipher(t, "k1", dataKey("k1", 1))
+	sealed, err := c.encrypt(nonceColumn, []byte("nonce-123"))
+	if err != nil {
+		t.Fatalf("encrypt() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:k1:") || strings.Contains(sealed, "nonce-123") {
+		t.Fatalf("encrypt() = %q, want an opaque value encrypted with k1", sealed)
+	}
+	again, _ := c.encrypt(nonceColumn, []byte("nonce-123"))
+	if again == sealed {
+		t.Errorf("encrypt() returned the same value twice, want a random nonce per value")
+	}
+	got, err := c.decrypt(nonceColumn, sealed)
+	if err != nil || string(got) != "nonce-123" {
+		t.Errorf("decrypt() = %q, %v, want %q", got, err, "nonce-123")
+	}
+	if _, err := c.decrypt(errorDataColumn, sealed); err == nil {
+		t.Errorf("decrypt() of another column succeeded, want error")
+	}
+	if got, err := c.decrypt(nonceColumn, "plain-nonce"); err != nil || string(got) != "plain-nonce" {
+		t.Errorf("decrypt() of an unencrypted value = %q, %v, want it unchanged", got, err)
+	}
+	for _, bad := range []string{"enc:v1:k1", "enc:v1:k1:!!", "enc:v1:k1:AAAA", sealed[:len(sealed)-4] + "AAAA"} {
+		if _, err := c.decrypt(nonceColumn, bad); err == nil {
+			t.Errorf("decrypt(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestColumnCipher_Rotation(t *testing.T) {
+	old := newTestCipher(t, "k1", dataKey("k1", 1))
+	sealed, err := old.encrypt(errorDataColumn, []byte(`{"error":"boom"}`))
+	if err != nil {
+		t.Fatalf("encrypt() unexpected error: %v", err)
+	}
+
+	rotated := newTestCipher(t, "k2", dataKey("k1", 1), dataKey("k2", 2))
+	if got, err := rotated.decrypt(errorDataColumn, sealed); err != nil || string(got) != `{"error":"boom"}` {
+		t.Errorf("decrypt() with a retired key = %q, %v, want the original value", got, err)
+	}
+	fresh, _ := rotated.encrypt(errorDataColumn, []byte("x"))
+	if !strings.HasPrefix(fresh, "enc:v1:k2:") {
+		t.Errorf("encrypt() after rotation = %q, want it encrypted with k2", fresh)
+	}
+
+	removed := newTestCipher(t, "k2", dataKey("k2", 2))
+	if _, err := removed.decrypt(errorDataColumn, sealed); !errors.Is(err, ErrColumnKeyUnknown) {
+		t.Errorf("decrypt() with a removed key error = %v, want %v", err, ErrColumnKeyUnknown)
+	}
+}
+
+func TestRegistry_SealErrorData(t *testing.T) {
+	r := &registry{}
+	if got, err := r.sealErrorData(json.RawMessage(`{"error":"boom"}`)); err != nil || got.String != `{"error":"boom"}` {
+		t.Errorf("sealErrorData() without cipher = %v, %v, want the value unchanged", got, err)
+	}
+
+	r.SetColumnCipher(newTestCipher(t, "k1", dataKey("k1", 1)))
+	if got, err := r.sealErrorData(nil); err != nil || got.Valid {
+		t.Errorf("sealErrorData(nil) = %v, %v, want NULL", got, err)
+	}
+	got, err := r.sealErrorData(json.RawMessage(`{"error":"secret detail","code":"CHALLENGE_FAILED"}`))
+	if err != nil {
+		t.Fatalf("sealErrorData() unexpected error: %v", err)
+	}
+	if strings.Contains(got.String, "secret detail") {
+		t.Errorf("sealErrorData() = %s, want the error detail encrypted", got.String)
+	}
+	var env encryptedJSON
+	if err := json.Unmarshal([]byte(got.String), &env); err != nil {
+		t.Fatalf("sealErrorData() = %s, want JSON: %v", got.String, err)
+	}
+	if env.Code != "CHALLENGE_FAILED" || strings.Join(env.Fields, ",") != "code,error" {
+		t.Errorf("sealErrorData() envelope = %+v, want code and field names readable", env)
+	}
+	back, err := r.errorData("op-1", got)
+	if err != nil || string(back) != `{"error":"secret detail","code":"CHALLENGE_FAILED"}` {
+		t.Errorf("errorData() = %s, %v, want the original value", back, err)
+	}
+	if back, err := r.errorData("op-1", sql.NullString{String: `{"reason":"plain"}`, Valid: true}); err != nil || string(back) != `{"reason":"plain"}` {
+		t.Errorf("errorData() of an unencrypted value = %s, %v, want it unchanged", back, err)
+	}
+	if _, err := (&registry{}).errorData("op-1", got); err == nil {
+		t.Errorf("errorData() of an encrypted value without cipher succeeded, want error")
+	}
+}
+
+func TestRegistry_SealRequest(t *testing.T) {
+	r := &registry{}
+	req := json.RawMessage(`{"nonce":"nonce-1","subscriber_id":"sub-1"}`)
+	if got, err := r.sealRequest(req); err != nil || string(got) != string(req) {
+		t.Errorf("sealRequest() without cipher = %s, %v, want the request unchanged", got, err)
+	}
+
+	r.SetColumnCipher(newTestCipher(t, "k1", dataKey("k1", 1)))
+	sealed, err := r.sealRequest(req)
+	if err != nil {
+		t.Fatalf("sealRequest() unexpected error: %v", err)
+	}
+	if strings.Contains(string(sealed), "nonce-1") || !strings.Contains(string(sealed), `"subscriber_id":"sub-1"`) {
+		t.Errorf("sealRequest() = %s, want only the nonce encrypted", sealed)
+	}
+	if again, err := r.sealRequest(sealed); err != nil || string(again) != string(sealed) {
+		t.Errorf("sealRequest() of a sealed request = %s, %v, want it unchanged", again, err)
+	}
+	if got, err := r.openRequest("op-1", sealed); err != nil || string(got) != string(req) {
+		t.Errorf("openRequest() = %s, %v, want %s", got, err, req)
+	}
+	if _, err := (&registry{}).openRequest("op-1", sealed); err == nil {
+		t.Errorf("openRequest() of a sealed request without cipher succeeded, want error")
+	}
+	noNonce := json.RawMessage(`{"subscriber_id":"sub-1"}`)
+	if got, err := r.sealRequest(noNonce); err != nil || string(got) != string(noNonce) {
+		t.Errorf("sealRequest() of a request without nonce = %s, %v, want it unchanged", got, err)
+	}
+	if got, err := withoutNonce(sealed); err != nil || string(got) != string(noNonce) {
+		t.Errorf("withoutNonce() = %s, %v, want %s", got, err, noNonce)
+	}
+}
+
+func TestRegistry_ReserveNonce_Digests(t *testing.T) {
+	ctx := context.Background()
+	windowStart := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	// k1 was rotated out for k2: nonces reserved with k1 or before encryption must still be found.
+	r.SetColumnCipher(newTestCipher(t, "k2", dataKey("k1", 1), dataKey("k2", 2)))
+
+	keys := r.nonceKeys("nonce-1")
+	if len(keys) != 3 || keys[2] != "nonce-1" {
+		t.Fatalf("nonceKeys() = %v, want the k2 and k1 digests and the nonce", keys)
+	}
+	for i, prefix := range []string{"hmac:v1:k2:", "hmac:v1:k1:"} {
+		if !strings.HasPrefix(keys[i], prefix) || strings.Contains(keys[i], "nonce-1") {
+			t.Errorf("nonceKeys()[%d] = %q, want a digest with prefix %q", i, keys[i], prefix)
+		}
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(getNonceQuery)).WithArgs(keys[1]).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta(getNonceQuery)).WithArgs(keys[2]).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta(reserveNonceQuery)).WithArgs(keys[0], "sub-1", "op-1", windowStart).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(windowStart.Add(time.Hour)))
+	if err := r.ReserveNonce(ctx, "nonce-1", "sub-1", "op-1", windowStart); err != nil {
+		t.Errorf("ReserveNonce() unexpected error: %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(getNonceQuery)).WithArgs(keys[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"operation_id", "created_at", "consumed_at"}).AddRow("op-0", windowStart.Add(-time.Hour), windowStart))
+	if err := r.ReserveNonce(ctx, "nonce-1", "sub-1", "op-1", windowStart); !errors.Is(err, ErrNonceReplayed) {
+		t.Errorf("ReserveNonce() of a nonce consumed under k1 error = %v, want %v", err, ErrNonceReplayed)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(consumeNonceQuery)).WithArgs(keys[0], "op-1", windowStart).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta(consumeNonceQuery)).WithArgs(keys[1], "op-1", windowStart).
+		WillReturnRows(sqlmock.NewRows([]string{"consumed_at"}).AddRow(windowStart.Add(time.Hour)))
+	if err := r.ConsumeNonce(ctx, "nonce-1", "op-1", windowStart); err != nil {
+		t.Errorf("ConsumeNonce() of a nonce reserved under k1 unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+// captureArg matches any argument and records it.
+type captureArg struct{ got *string }
+
+func (c captureArg) Match(v driver.Value) bool {
+	s, _ := v.(string)
+	*c.got = s
+	return true
+}
+
+func TestRegistry_ColumnEncryption(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	r.SetColumnCipher(newTestCipher(t, "k1", dataKey("k1", 1)))
+	ctx := context.Background()
+	now := time.Now()
+
+	var storedNonce string
+	mock.ExpectQuery(regexp.QuoteMeta(insertOnlySubscriptionQuery)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), captureArg{&storedNonce}).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+	sub := &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub-1"}, KeyID: "key-1", Nonce: "nonce-1"}
+	if _, err := r.InsertSubscription(ctx, sub); err != nil {
+		t.Fatalf("InsertSubscription() unexpected error: %v", err)
+	}
+	if got, err := r.cipher.decrypt(nonceColumn, storedNonce); err != nil || string(got) != "nonce-1" {
+		t.Errorf("stored nonce %q decrypts to %q, %v, want %q", storedNonce, got, err, "nonce-1")
+	}
+
+	var storedErr string
+	lro := &model.LRO{OperationID: "op-1", Status: model.LROStatusFailure, ErrorDataJSON: json.RawMessage(`{"error":"boom"}`)}
+	mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
+		WithArgs(lro.OperationID, lro.Status, sql.NullString{}, captureArg{&storedErr}, 0, sql.NullString{}).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).AddRow(now, now, model.OperationTypeCreateSubscription, []byte(`{}`)))
+	if _, err := r.UpdateOperation(ctx, lro); err != nil {
+		t.Fatalf("UpdateOperation() unexpected error: %v", err)
+	}
+	if strings.Contains(storedErr, "boom") {
+		t.Errorf("stored error data = %s, want it encrypted", storedErr)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).WithArgs("op-1").
+		WillReturnRows(sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "probe_json", "review_json", "comments_json", "created_at", "updated_at"}).
+			AddRow("op-1", model.LROStatusFailure, model.OperationTypeCreateSubscription, []byte(`{}`), nil, storedErr, nil, nil, nil, now, now))
+	got, err := r.GetOperation(ctx, "op-1")
+	if err != nil {
+		t.Fatalf("GetOperation() unexpected error: %v", err)
+	}
+	if string(got.ErrorDataJSON) != `{"error":"boom"}` {
+		t.Errorf("GetOperation() ErrorDataJSON = %s, want the decrypted value", got.ErrorDataJSON)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolMonitorInterval = 30 * time.Second
+	defaultPoolLeakThreshold   = time.Minute
+	maxTrackedStackDepth       = 32
+)
+
+// PoolMonitorConfig configures periodic sampling of connection pool statistics
+// and detection of connections that are held for longer than expected.
+type PoolMonitorConfig struct {
+	Interval      time.Duration `yaml:"interval"`      // How often pool statistics are sampled.
+	LeakThreshold time.Duration `yaml:"leakThreshold"` // Age after which a held connection is reported as a potential leak.
+	// CaptureStacks records the call stack of every tracked operation so that leaks are logged with it.
+	// It costs a stack capture per repository call and is meant for debugging.
+	CaptureStacks bool `yaml:"captureStacks"`
+}
+
+// poolMetrics publishes the most recent pool statistics on the expvar endpoint (/debug/vars).
+var poolMetrics = expvar.NewMap("db_pool")
+
+// dbStatser is satisfied by *sql.DB.
+type dbStatser interface {
+	Stats() sql.DBStats
+}
+
+// heldConn records a single in-flight use of a pooled connection.
+type heldConn struct {
+	op       string
+	acquired time.Time
+	stack    []uintptr // nil unless stacks are captured
+	reported bool
+}
+
+// poolMonitor samples sql.DBStats and reports connections held beyond LeakThreshold.
+type poolMonitor struct {
+	db            dbStatser
+	interval      time.Duration
+	leakThreshold time.Duration
+	captureStacks bool
+	now           func() time.Time
+
+	mu     sync.Mutex
+	held   map[uint64]*heldConn
+	nextID uint64
+	last   sql.DBStats
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewPoolMonitor creates a new connection pool monitor for the given database.
+func NewPoolMonitor(db dbStatser, cfg *PoolMonitorConfig) (*poolMonitor, error) {
+	if db == nil {
+		slog.Error("NewPoolMonitor: db cannot be nil")
+		return nil, ErrDBNil
+	}
+	if cfg == nil {
+		slog.Error("NewPoolMonitor: config cannot be nil")
+		return nil, errors.New("pool monitor config cannot be nil")
+	}
+	m := &poolMonitor{
+		db:            db,
+		interval:      cfg.Interval,
+		leakThreshold: cfg.LeakThreshold,
+		captureStacks: cfg.CaptureStacks,
+		now:           time.Now,
+		held:          make(map[uint64]*heldConn),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if m.interval <= 0 {
+		m.interval = defaultPoolMonitorInterval
+	}
+	if m.leakThreshold <= 0 {
+		m.leakThreshold = defaultPoolLeakThreshold
+	}
+	return m, nil
+}
+
+// Start launches the background watchdog. It runs until ctx is cancelled or Stop is called.
+func (m *poolMonitor) Start(ctx context.Context) {
+	slog.InfoContext(ctx, "Repository: Starting connection pool monitor", "interval", m.interval, "leak_threshold", m.leakThreshold)
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.sample(ctx)
+				m.detectLeaks(ctx)
+			}
+		}
+	}()
+}
+
+// Stop terminates the watchdog and waits for it to exit. It is safe to call more than once.
+func (m *poolMonitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+		<-m.done
+	})
+}
+
+// Track marks the start of an operation that holds a pooled connection.
+// The returned function must be called once the connection is released.
+func (m *poolMonitor) Track(op string) func() {
+	hc := &heldConn{op: op, acquired: m.now()}
+	if m.captureStacks {
+		// Only the program counters are recorded here; they are resolved if the connection leaks.
+		pcs := make([]uintptr, maxTrackedStackDepth)
+		hc.stack = pcs[:runtime.Callers(2, pcs)]
+	}
+	m.mu.Lock()
+	m.nextID++
+	id := m.nextID
+	m.held[id] = hc
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if hc, ok := m.held[id]; ok && hc.reported {
+			slog.Info("Repository: Previously reported long-held connection released", "operation", hc.op, "held_for", m.now().Sub(hc.acquired))
+		}
+		delete(m.held, id)
+	}
+}
+
+// sample records the current pool statistics and warns when callers had to wait for a connection.
+func (m *poolMonitor) sample(ctx context.Context) sql.DBStats {
+	stats := m.db.Stats()
+
+	poolMetrics.Set("max_open_connections", intVar(int64(stats.MaxOpenConnections)))
+	poolMetrics.Set("open_connections", intVar(int64(stats.OpenConnections)))
+	poolMetrics.Set("in_use", intVar(int64(stats.InUse)))
+	poolMetrics.Set("idle", intVar(int64(stats.Idle)))
+	poolMetrics.Set("wait_count", intVar(stats.WaitCount))
+	poolMetrics.Set("wait_duration_ms", intVar(stats.WaitDuration.Milliseconds()))
+	poolMetrics.Set("max_idle_closed", intVar(stats.MaxIdleClosed))
+	poolMetrics.Set("max_idle_time_closed", intVar(stats.MaxIdleTimeClosed))
+	poolMetrics.Set("max_lifetime_closed", intVar(stats.MaxLifetimeClosed))
+
+	m.mu.Lock()
+	last := m.last
+	m.last = stats
+	m.mu.Unlock()
+
+	slog.DebugContext(ctx, "Repository: Connection pool stats",
+		"open", stats.OpenConnections, "in_use", stats.InUse, "idle", stats.Idle,
+		"wait_count", stats.WaitCount, "wait_duration", stats.WaitDuration)
+
+	if waits := stats.WaitCount - last.WaitCount; waits > 0 {
+		slog.WarnContext(ctx, "Repository: Callers waited for a database connection; pool may be exhausted",
+			"new_waits", waits,
+			"wait_duration", stats.WaitDuration-last.WaitDuration,
+			"in_use", stats.InUse,
+			"max_open", stats.MaxOpenConnections)
+	}
+	return stats
+}
+
+// detectLeaks logs every tracked connection held for longer than leakThreshold, once per connection.
+func (m *poolMonitor) detectLeaks(ctx context.Context) int {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	leaks := 0
+	for _, hc := range m.held {
+		age := now.Sub(hc.acquired)
+		if age < m.leakThreshold || hc.reported {
+			continue
+		}
+		hc.reported = true
+		leaks++
+		attrs := []any{"operation", hc.op, "held_for", age, "threshold", m.leakThreshold}
+		if hc.stack != nil {
+			attrs = append(attrs, "stack", formatStack(hc.stack))
+		}
+		slog.WarnContext(ctx, "Repository: Database connection held longer than leak threshold", attrs...)
+	}
+	return leaks
+}
+
+// formatStack resolves the program counters of a captured stack into one "function file:line" per line.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			return b.String()
+		}
+	}
+}
+
+// intVar wraps v in an *expvar.Int suitable for expvar.Map.Set.
+func intVar(v int64) *expvar.Int {
+	i := new(expvar.Int)
+	i.Set(v)
+	return i
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockStatser struct {
+	stats sql.DBStats
+}
+
+func (m *mockStatser) Stats() sql.DBStats {
+	return m.stats
+}
+
+type mockConnTracker struct {
+	tracked  []string
+	released int
+}
+
+func (m *mockConnTracker) Track(op string) func() {
+	m.tracked = append(m.tracked, op)
+	return func() { m.released++ }
+}
+
+func TestNewPoolMonitor(t *testing.T) {
+	tests := []struct {
+		name              string
+		db                dbStatser
+		cfg               *PoolMonitorConfig
+		wantErr           bool
+		wantInterval      time.Duration
+		wantLeakThreshold time.Duration
+	}{
+		{
+			name:              "success with config values",
+			db:                &mockStatser{},
+			cfg:               &PoolMonitorConfig{Interval: 5 * time.Second, LeakThreshold: 10 * time.Second},
+			wantInterval:      5 * time.Second,
+			wantLeakThreshold: 10 * time.Second,
+		},
+		{
+			name:              "success with defaults",
+			db:                &mockStatser{},
+			cfg:               &PoolMonitorConfig{},
+			wantInterval:      defaultPoolMonitorInterval,
+			wantLeakThreshold: defaultPoolLeakThreshold,
+		},
+		{
+			name:    "nil db",
+			cfg:     &PoolMonitorConfig{},
+			wantErr: true,
+		},
+		{
+			name:    "nil config",
+			db:      &mockStatser{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewPoolMonitor(tt.db, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPoolMonitor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if m.interval != tt.wantInterval {
+				t.Errorf("interval = %v, want %v", m.interval, tt.wantInterval)
+			}
+			if m.leakThreshold != tt.wantLeakThreshold {
+				t.Errorf("leakThreshold = %v, want %v", m.leakThreshold, tt.wantLeakThreshold)
+			}
+		})
+	}
+}
+
+func TestPoolMonitor_Sample(t *testing.T) {
+	db := &mockStatser{stats: sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 3, Idle: 1, WaitCount: 2, WaitDuration: 150 * time.Millisecond}}
+	m, err := NewPoolMonitor(db, &PoolMonitorConfig{})
+	if err != nil {
+		t.Fatalf("NewPoolMonitor() error = %v", err)
+	}
+
+	got := m.sample(context.Background())
+	if got != db.stats {
+		t.Errorf("sample() = %+v, want %+v", got, db.stats)
+	}
+	want := map[string]string{
+		"max_open_connections": "10",
+		"open_connections":     "4",
+		"in_use":               "3",
+		"idle":                 "1",
+		"wait_count":           "2",
+		"wait_duration_ms":     "150",
+	}
+	for k, v := range want {
+		gotVar := poolMetrics.Get(k)
+		if gotVar == nil {
+			t.Errorf("metric %q not published", k)
+			continue
+		}
+		if gotVar.String() != v {
+			t.Errorf("metric %q = %s, want %s", k, gotVar.String(), v)
+		}
+	}
+	if m.last != db.stats {
+		t.Errorf("last sample = %+v, want %+v", m.last, db.stats)
+	}
+}
+
+func TestPoolMonitor_DetectLeaks(t *testing.T) {
+	m, err := NewPoolMonitor(&mockStatser{}, &PoolMonitorConfig{LeakThreshold: time.Minute})
+	if err != nil {
+		t.Fatalf("NewPoolMonitor() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	releaseOld := m.Track("UpsertSubscriptionAndLRO")
+	now = now.Add(30 * time.Second)
+	releaseNew := m.Track("Lookup")
+	now = now.Add(45 * time.Second)
+
+	if got := m.detectLeaks(context.Background()); got != 1 {
+		t.Errorf("detectLeaks() = %d, want 1", got)
+	}
+	// A connection is only reported once.
+	if got := m.detectLeaks(context.Background()); got != 0 {
+		t.Errorf("second detectLeaks() = %d, want 0", got)
+	}
+
+	releaseOld()
+	releaseNew()
+	if len(m.held) != 0 {
+		t.Errorf("held connections after release = %d, want 0", len(m.held))
+	}
+}
+
+func TestPoolMonitor_Track_Stacks(t *testing.T) {
+	for _, capture := range []bool{false, true} {
+		m, err := NewPoolMonitor(&mockStatser{}, &PoolMonitorConfig{CaptureStacks: capture})
+		if err != nil {
+			t.Fatalf("NewPoolMonitor() error = %v", err)
+		}
+		release := m.Track("Lookup")
+		hc := m.held[m.nextID]
+		if !capture {
+			if hc.stack != nil {
+				t.Errorf("Track() captured a stack with CaptureStacks disabled")
+			}
+		} else if got := formatStack(hc.stack); !strings.Contains(got, "TestPoolMonitor_Track_Stacks") {
+			t.Errorf("formatStack() = %q, want it to include the caller of Track", got)
+		}
+		release()
+	}
+}
+
+func TestPoolMonitor_StartStop(t *testing.T) {
+	m, err := NewPoolMonitor(&mockStatser{}, &PoolMonitorConfig{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewPoolMonitor() error = %v", err)
+	}
+	m.Start(context.Background())
+	time.Sleep(5 * time.Millisecond)
+	m.Stop()
+	// Stop must be idempotent.
+	m.Stop()
+
+	select {
+	case <-m.done:
+	default:
+		t.Error("watchdog goroutine did not exit after Stop")
+	}
+}
+
+func TestRegistry_ConnTracker(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	tracker := &mockConnTracker{}
+	r.SetConnTracker(tracker)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).
+		WithArgs("op-1").
+		WillReturnError(errors.New("db error"))
+
+	if _, err := r.GetOperation(context.Background(), "op-1"); err == nil {
+		t.Fatal("GetOperation() error = nil, want error")
+	}
+	if len(tracker.tracked) != 1 || tracker.tracked[0] != "GetOperation" {
+		t.Errorf("tracked operations = %v, want [GetOperation]", tracker.tracked)
+	}
+	if tracker.released != 1 {
+		t.Errorf("released = %d, want 1", tracker.released)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// Errors returned when a repository call is cut short by its context.
+var (
+	ErrQueryTimeout  = errors.New("database query timed out")
+	ErrQueryCanceled = errors.New("database query canceled")
+)
+
+// QueryTimeoutConfig bounds how long a single repository call may run.
+// A zero value disables the timeout for that class of query.
+type QueryTimeoutConfig struct {
+	Lookup   time.Duration `yaml:"lookup"`   // Timeout for read-only queries.
+	Mutation time.Duration `yaml:"mutation"` // Timeout for inserts, updates, deletes and transactions.
+}
+
+// queryKind classifies a repository call for timeout selection.
+type queryKind int
+
+const (
+	lookupQuery queryKind = iota
+	mutationQuery
+)
+
+// queryMetrics counts repository calls that ended because of their context.
+var queryMetrics = expvar.NewMap("db_queries")
+
+// SetQueryTimeouts sets the per-query timeouts applied to every repository call.
+func (r *registry) SetQueryTimeouts(cfg *QueryTimeoutConfig) {
+	r.timeouts = cfg
+}
+
+// timeout returns the configured timeout for the given kind of query.
+func (r *registry) timeout(kind queryKind) time.Duration {
+	if r.timeouts == nil {
+		return 0
+	}
+	if kind == mutationQuery {
+		return r.timeouts.Mutation
+	}
+	return r.timeouts.Lookup
+}
+
+// begin prepares a repository call: it starts connection tracking and bounds ctx
+// with the configured timeout. The returned function must be deferred with the
+// call's error; it releases resources and converts context failures into
+// ErrQueryTimeout or ErrQueryCanceled.
+func (r *registry) begin(ctx context.Context, op string, kind queryKind) (context.Context, func(error) error) {
+	release := r.track(op)
+	ctx = withStatement(ctx, op)
+	cancel := context.CancelFunc(func() {})
+	if d := r.timeout(kind); d > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d)
+	}
+	return ctx, func(err error) error {
+		defer release()
+		defer cancel()
+		if err == nil {
+			return nil
+		}
+		switch {
+		case errors.Is(err, ErrQueryTimeout), errors.Is(err, ErrQueryCanceled):
+			return err
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(ctx.Err(), context.DeadlineExceeded):
+			queryMetrics.Add("timeouts", 1)
+			return fmt.Errorf("%w: %s: %w", ErrQueryTimeout, op, err)
+		case errors.Is(err, context.Canceled), errors.Is(ctx.Err(), context.Canceled):
+			queryMetrics.Add("canceled", 1)
+			return fmt.Errorf("%w: %s: %w", ErrQueryCanceled, op, err)
+		}
+		return err
+	}
+}
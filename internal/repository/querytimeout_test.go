@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRegistry_QueryTimeouts(t *testing.T) {
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name     string
+		timeouts *QueryTimeoutConfig
+		delay    time.Duration
+		queryErr error // Returned by the query if it completes before its context.
+		wantErr  error
+	}{
+		{
+			name:     "lookup exceeds lookup timeout",
+			timeouts: &QueryTimeoutConfig{Lookup: 10 * time.Millisecond},
+			delay:    time.Second,
+			queryErr: dbErr,
+			wantErr:  ErrQueryTimeout,
+		},
+		{
+			name:     "lookup not bounded by mutation timeout",
+			timeouts: &QueryTimeoutConfig{Mutation: time.Millisecond},
+			delay:    20 * time.Millisecond,
+			queryErr: dbErr,
+			wantErr:  dbErr,
+		},
+		{
+			name:     "non-context errors are passed through",
+			timeouts: &QueryTimeoutConfig{Lookup: time.Second},
+			queryErr: dbErr,
+			wantErr:  dbErr,
+		},
+		{
+			name:     "no timeouts configured",
+			delay:    20 * time.Millisecond,
+			queryErr: dbErr,
+			wantErr:  dbErr,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			r.SetQueryTimeouts(tc.timeouts)
+
+			mock.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).
+				WithArgs("op-1").
+				WillDelayFor(tc.delay).
+				WillReturnError(tc.queryErr)
+
+			_, err := r.GetOperation(context.Background(), "op-1")
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("GetOperation() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_MutationTimeout(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	r.SetQueryTimeouts(&QueryTimeoutConfig{Lookup: time.Minute, Mutation: 10 * time.Millisecond})
+
+	mock.ExpectExec(regexp.QuoteMeta(deleteWebhookQuery)).
+		WithArgs("wh-1").
+		WillDelayFor(time.Second).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := r.DeleteWebhook(context.Background(), "wh-1")
+	if !errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("DeleteWebhook() error = %v, want %v", err, ErrQueryTimeout)
+	}
+}
+
+func TestRegistry_QueryCanceled(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).
+		WithArgs("op-1").
+		WillDelayFor(time.Second).
+		WillReturnError(errors.New("db error"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := r.GetOperation(ctx, "op-1")
+	if !errors.Is(err, ErrQueryCanceled) {
+		t.Errorf("GetOperation() error = %v, want %v", err, ErrQueryCanceled)
+	}
+	if errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("GetOperation() error = %v, must not be a timeout", err)
+	}
+}
@@ -21,12 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
@@ -47,6 +49,29 @@ var (
 	ErrSubscriberKeyNotFound = errors.New("subscriber signing key not found")
 	ErrSubscriptionConflict  = errors.New("subscription already exists or conflicts with an existing one")
 	ErrOperationNotFound     = errors.New("operation not found")
+	ErrOperationNotPending   = errors.New("operation is no longer pending")
+	// Nonce lifecycle errors
+	ErrNonceReplayed = errors.New("nonce has already been used")
+	ErrNonceExpired  = errors.New("nonce has expired")
+	ErrNonceNotFound = errors.New("nonce not found")
+	// API key errors
+	ErrAPIKeyIsNil    = errors.New("API key object is nil")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	ErrWebhookIsNil            = errors.New("webhook object is nil")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryIsNil    = errors.New("webhook delivery object is nil")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrDenylistEntryIsNil    = errors.New("denylist entry object is nil")
+	ErrDenylistEntryNotFound = errors.New("denylist entry not found")
+
+	ErrDomainIsNil    = errors.New("domain object is nil")
+	ErrDomainNotFound = errors.New("domain not found")
+
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+
+	ErrChallengeAttemptIsNil = errors.New("challenge attempt object is nil")
 )
 
 // subscriptionsTableName defines the name of the database table for subscriptions.
@@ -54,17 +79,34 @@ const subscriptionsTableName = "subscriptions"
 
 // registry implements the lookUpRepository interface using PostgreSQL.
 type Config struct {
-	User             string        `yaml:"user"`
-	Name             string        `yaml:"name"`             // Database name.
-	ConnectionName   string        `yaml:"connectionName"`   // Cloud SQL connection name.
-	MaxOpenConns     int           `yaml:"maxOpenConns"`     // Maximum number of open connections to the database.
-	MaxIdleConns     int           `yaml:"maxIdleConns"`     // Maximum number of connections in the idle connection pool.
-	ConnMaxIdleTime  time.Duration `yaml:"connMaxIdleTime"`  // Maximum amount of time a connection may be idle.
-	ConnMaxLifetime  time.Duration `yaml:"connMaxLifetime"`  // Maximum amount of time a connection may be reused.
+	User            string                    `yaml:"user"`
+	Name            string                    `yaml:"name"`            // Database name.
+	ConnectionName  string                    `yaml:"connectionName"`  // Cloud SQL connection name.
+	MaxOpenConns    int                       `yaml:"maxOpenConns"`    // Maximum number of open connections to the database.
+	MaxIdleConns    int                       `yaml:"maxIdleConns"`    // Maximum number of connections in the idle connection pool.
+	ConnMaxIdleTime time.Duration             `yaml:"connMaxIdleTime"` // Maximum amount of time a connection may be idle.
+	ConnMaxLifetime time.Duration             `yaml:"connMaxLifetime"` // Maximum amount of time a connection may be reused.
+	Monitor         *PoolMonitorConfig        `yaml:"monitor"`         // Optional connection pool health monitoring.
+	QueryTimeouts   *QueryTimeoutConfig       `yaml:"queryTimeouts"`   // Optional per-query timeouts.
+	SlowQueries     *SlowQueryConfig          `yaml:"slowQueries"`     // Optional slow query logging.
+	Retry           *RetryConfig              `yaml:"retry"`           // Optional retries of transient errors.
+	Tracing         bool                      `yaml:"tracing"`         // Record a span per statement in the trace of the request running it.
+	Encryption      *ColumnEncryptionConfig   `yaml:"encryption"`      // Optional encryption of sensitive columns.
+	Notifications   *SubscriptionNotifyConfig `yaml:"notifications"`   // Optional LISTEN/NOTIFY of subscription changes.
+}
+
+// connTracker records how long repository operations hold a pooled connection.
+type connTracker interface {
+	Track(op string) func()
 }
 
 type registry struct {
-	db *sqlx.DB // Use sqlx.DB for enhanced functionality.
+	db          *sqlx.DB // Use sqlx.DB for enhanced functionality.
+	tracker     connTracker
+	timeouts    *QueryTimeoutConfig
+	slowQueries *SlowQueryConfig
+	retries     *RetryConfig
+	cipher      *columnCipher
 }
 
 // NewRegistry creates a new PostgresSubscriberRepository.
@@ -76,28 +118,39 @@ func NewRegistry(db *sql.DB) (*registry, error) {
 	return &registry{db: sqlx.NewDb(db, "postgres")}, nil
 }
 
-// Lookup retrieves subscriptions based on the provided filter criteria.
-func (r *registry) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
-	slog.Info("Repository: Executing Lookup query", "filter", filter)
+// SetConnTracker sets the tracker used to detect long-held connections.
+func (r *registry) SetConnTracker(t connTracker) {
+	r.tracker = t
+}
 
-	// Create a new goqu dataset for the "subscriptions" table.
-	// We'll select all columns, and sqlx will map them to the Subscription struct.
-	dataset := goqu.From(subscriptionsTableName).Select(
-		"subscriber_id", "url", "type", "domain", "location", "key_id",
-		"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-		"status", "created_at", "updated_at",
-	)
+// track starts tracking a connection-holding operation and returns its release function.
+func (r *registry) track(op string) func() {
+	if r.tracker == nil {
+		return func() {}
+	}
+	return r.tracker.Track(op)
+}
 
-	// Build conditions using a helper function to centralize the logic.
-	conditions := buildLookupConditions(filter)
+// Lookup retrieves subscriptions based on the provided filter criteria.
+func (r *registry) Lookup(ctx context.Context, filter *model.Subscription) (_ []model.Subscription, err error) {
+	ctx, done := r.begin(ctx, "Lookup", lookupQuery)
+	defer func() { err = done(err) }()
+	slog.Info("Repository: Executing Lookup query", "filter", filter)
+	return r.lookup(ctx, "Lookup", filter)
+}
 
-	// Apply all conditions to the dataset.
-	if len(conditions) > 0 {
-		dataset = dataset.Where(conditions...)
-	}
+// LookupAny retrieves the subscriptions matching any of the filters in a single query.
+// Each filter is applied as in Lookup; a subscription matching several filters is returned once.
+func (r *registry) LookupAny(ctx context.Context, filters []*model.Subscription) (_ []model.Subscription, err error) {
+	ctx, done := r.begin(ctx, "LookupAny", lookupQuery)
+	defer func() { err = done(err) }()
+	slog.Info("Repository: Executing LookupAny query", "filters", len(filters))
+	return r.lookup(ctx, "LookupAny", filters...)
+}
 
-	// Generate SQL and arguments.
-	sql, args, err := dataset.ToSQL()
+// lookup runs the lookup query for the filters on behalf of the operation op.
+func (r *registry) lookup(ctx context.Context, op string, filters ...*model.Subscription) ([]model.Subscription, error) {
+	sql, args, err := buildLookupQuery(filters...)
 	if err != nil {
 		slog.Error("Repository: Failed to build SQL query", "error", err)
 		return nil, fmt.Errorf("failed to build SQL query: %w", err)
@@ -107,7 +160,12 @@ func (r *registry) Lookup(ctx context.Context, filter *model.Subscription) ([]mo
 
 	subscriptions := []model.Subscription{}
 	// Use sqlx.SelectContext to execute the query and unmarshal results into []model.Subscription.
-	err = r.db.SelectContext(ctx, &subscriptions, sql, args...)
+	err = r.retry(ctx, op, idempotentCall, func() error {
+		subscriptions = subscriptions[:0]
+		observed := r.observeQuery(ctx, op, sql, args)
+		defer observed()
+		return r.db.SelectContext(ctx, &subscriptions, sql, args...)
+	})
 	if err != nil {
 		slog.Error("Repository: Failed to execute lookup query", "error", err)
 		return nil, fmt.Errorf("failed to execute lookup query: %w", err)
@@ -117,6 +175,132 @@ func (r *registry) Lookup(ctx context.Context, filter *model.Subscription) ([]mo
 	return subscriptions, nil
 }
 
+// LookupChanges retrieves the subscriptions matching any of the filters that were updated at
+// or after since, and tombstones of the subscriptions removed from the results of the filters
+// since: those deleted, and those that no longer match after a change, e.g. of their status.
+// Deleted subscriptions are matched on the subscriber ID, type and domain of the filters
+// only, as the other columns filters may use are not kept in the history.
+func (r *registry) LookupChanges(ctx context.Context, since time.Time, filters []*model.Subscription) (_ *model.LookupChanges, err error) {
+	ctx, done := r.begin(ctx, "LookupChanges", lookupQuery)
+	defer func() { err = done(err) }()
+	updatedSQL, removedSQL, err := buildLookupChangesQueries(since, filters)
+	if err != nil {
+		slog.Error("Repository: Failed to build SQL query", "error", err)
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	changes := &model.LookupChanges{Subscriptions: []model.Subscription{}, Deleted: []model.SubscriptionTombstone{}}
+	err = r.retry(ctx, "LookupChanges", idempotentCall, func() error {
+		changes.Subscriptions = changes.Subscriptions[:0]
+		changes.Deleted = changes.Deleted[:0]
+		observed := r.observeQuery(ctx, "LookupChanges", updatedSQL, nil)
+		err := r.db.SelectContext(ctx, &changes.Subscriptions, updatedSQL)
+		observed()
+		if err != nil {
+			return err
+		}
+		observed = r.observeQuery(ctx, "LookupChanges", removedSQL, nil)
+		defer observed()
+		return r.db.SelectContext(ctx, &changes.Deleted, removedSQL)
+	})
+	if err != nil {
+		slog.Error("Repository: Failed to execute lookup changes query", "error", err)
+		return nil, fmt.Errorf("failed to execute lookup changes query: %w", err)
+	}
+	slog.Info("Repository: Lookup changes query successful", "updated", len(changes.Subscriptions), "deleted", len(changes.Deleted))
+	return changes, nil
+}
+
+// subscriptionRecreatedCondition excludes deleted subscriptions that have been created again.
+const subscriptionRecreatedCondition = `NOT EXISTS (SELECT 1 FROM subscriptions s WHERE s.subscriber_id = subscription_history.subscriber_id AND s.domain = subscription_history.domain AND s.type = subscription_history.type)`
+
+// buildLookupChangesQueries generates the SQL for the subscriptions matching any of the filters
+// updated since a time, and for the tombstones of those removed from their results since.
+func buildLookupChangesQueries(since time.Time, filters []*model.Subscription) (string, string, error) {
+	conditions := buildAnyLookupConditions(filters)
+	identity := anyConditions(filters, buildIdentityConditions)
+
+	updated, _, err := goqu.From(subscriptionsTableName).Select(lookupColumns...).
+		Where(append([]goqu.Expression{goqu.C("updated_at").Gte(since)}, conditions...)...).ToSQL()
+	if err != nil {
+		return "", "", err
+	}
+
+	removed := goqu.From("subscription_history").
+		Select("subscriber_id", "type", "domain", "key_id", goqu.C("changed_at").As("deleted_at")).
+		Where(append([]goqu.Expression{
+			goqu.C("change").Eq(string(model.SubscriptionChangeDelete)),
+			goqu.C("changed_at").Gte(since),
+			goqu.L(subscriptionRecreatedCondition),
+		}, identity...)...)
+	if len(conditions) > 0 {
+		// Subscriptions changed since that no longer match the filters. A condition on a NULL
+		// column, such as the city code of a subscription without a location, does not match.
+		left := goqu.From(subscriptionsTableName).
+			Select("subscriber_id", "type", "domain", "key_id", goqu.C("updated_at").As("deleted_at")).
+			Where(append([]goqu.Expression{
+				goqu.C("updated_at").Gte(since),
+				goqu.L("NOT COALESCE(?, FALSE)", goqu.And(conditions...)),
+			}, identity...)...)
+		removed = removed.UnionAll(left)
+	}
+	removedSQL, _, err := removed.ToSQL()
+	if err != nil {
+		return "", "", err
+	}
+	return updated, removedSQL, nil
+}
+
+// lookupColumns are the columns of the subscriptions returned by lookups.
+var lookupColumns = []any{
+	"subscriber_id", "url", "type", "domain", "location", "key_id",
+	"signing_public_key", "encr_public_key", "valid_from", "valid_until",
+	"status", "labels", "created_at", "updated_at",
+}
+
+// buildLookupQuery generates the SQL for a lookup matching any of the given filters.
+func buildLookupQuery(filters ...*model.Subscription) (string, []any, error) {
+	// Create a new goqu dataset for the "subscriptions" table.
+	// We'll select all columns, and sqlx will map them to the Subscription struct.
+	dataset := goqu.From(subscriptionsTableName).Select(lookupColumns...)
+
+	// Build conditions using a helper function to centralize the logic.
+	conditions := buildAnyLookupConditions(filters)
+
+	// Apply all conditions to the dataset.
+	if len(conditions) > 0 {
+		dataset = dataset.Where(conditions...)
+	}
+	return dataset.ToSQL()
+}
+
+// buildAnyLookupConditions combines the conditions of each filter with OR, e.g.
+// (city = 'std:080' AND type = 'BPP') OR (city = 'std:022' AND type = 'BPP'),
+// so that a lookup for several cities or types is a single query. A single filter
+// yields its own conditions, and a filter without conditions matches everything.
+func buildAnyLookupConditions(filters []*model.Subscription) []goqu.Expression {
+	return anyConditions(filters, buildLookupConditions)
+}
+
+// anyConditions combines the conditions built for each filter with OR, as buildAnyLookupConditions.
+func anyConditions(filters []*model.Subscription, build func(*model.Subscription) []goqu.Expression) []goqu.Expression {
+	if len(filters) == 1 {
+		return build(filters[0])
+	}
+	alternatives := make([]goqu.Expression, 0, len(filters))
+	for _, f := range filters {
+		conditions := build(f)
+		if len(conditions) == 0 {
+			return nil
+		}
+		alternatives = append(alternatives, goqu.And(conditions...))
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	return []goqu.Expression{goqu.Or(alternatives...)}
+}
+
 // buildLookupConditions creates a slice of goqu expressions based on the model.Subscription filter.
 // This centralizes the logic for building the WHERE clause, making the main Lookup method cleaner.
 func buildLookupConditions(filter *model.Subscription) []goqu.Expression {
@@ -132,8 +316,8 @@ func buildLookupConditions(filter *model.Subscription) []goqu.Expression {
 	if filter.Type != "" {
 		conditions = append(conditions, goqu.C("type").Eq(filter.Type))
 	}
-	if filter.Domain != "" {
-		conditions = append(conditions, goqu.C("domain").Eq(filter.Domain))
+	if cond := buildDomainCondition(filter.Domain); cond != nil {
+		conditions = append(conditions, cond)
 	}
 	if filter.Status != "" {
 		conditions = append(conditions, goqu.C("status").Eq(filter.Status))
@@ -147,11 +331,67 @@ func buildLookupConditions(filter *model.Subscription) []goqu.Expression {
 	locationConditions := buildLocationConditions(filter.Location)
 	conditions = append(conditions, locationConditions...)
 
+	if cond := buildLabelsCondition(filter.Labels); cond != nil {
+		conditions = append(conditions, cond)
+	}
+
+	return conditions
+}
+
+// buildIdentityConditions creates the conditions of a filter on the primary key of subscriptions,
+// which are the only ones that apply to deleted subscriptions.
+func buildIdentityConditions(filter *model.Subscription) []goqu.Expression {
+	var conditions []goqu.Expression
+	if filter.SubscriberID != "" {
+		conditions = append(conditions, goqu.C("subscriber_id").Eq(filter.SubscriberID))
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, goqu.C("type").Eq(filter.Type))
+	}
+	if cond := buildDomainCondition(filter.Domain); cond != nil {
+		conditions = append(conditions, cond)
+	}
 	return conditions
 }
 
+// domainWildcard is the suffix that turns a domain filter into a prefix match, e.g. "nic2004:*".
+const domainWildcard = "*"
+
+// likeEscaper escapes LIKE metacharacters so that a domain prefix is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// buildDomainCondition creates the condition for a domain filter.
+// A trailing "*" matches every domain with the given prefix using a left-anchored LIKE,
+// which is served by the idx_subscribers_domain_pattern index; a lone "*" matches any domain.
+// All other values are matched exactly.
+func buildDomainCondition(domain string) goqu.Expression {
+	if domain == "" || domain == domainWildcard {
+		return nil
+	}
+	prefix, ok := strings.CutSuffix(domain, domainWildcard)
+	if !ok {
+		return goqu.C("domain").Eq(domain)
+	}
+	return goqu.C("domain").Like(likeEscaper.Replace(prefix) + "%")
+}
+
+// buildLabelsCondition creates the condition for a labels filter. A subscription matches if it
+// carries every label of the filter, expressed as JSONB containment, e.g. labels @> '["pilot"]',
+// so that it is served by the idx_subscribers_labels_gin index.
+func buildLabelsCondition(labels model.Labels) goqu.Expression {
+	if len(labels) == 0 {
+		return nil
+	}
+	// A slice of strings always marshals successfully.
+	b, _ := json.Marshal([]string(labels))
+	return goqu.L("labels @> ?::jsonb", string(b))
+}
+
 // buildLocationConditions creates a slice of goqu expressions for location-related filters.
-// This helper method encapsulates the logic for building conditions on the 'location' JSONB column.
+// City and country codes are matched on the city_code and country_code columns generated from
+// location, served by the idx_subscribers_city_code and idx_subscribers_country_code indexes.
+// All other location fields are combined into a single JSONB containment condition, e.g.
+// location @> '{"city":{"name":"Mumbai"}}', so that it is served by the idx_subscribers_location_gin index.
 // It uses an early return pattern to reduce nesting for the primary nil check.
 func buildLocationConditions(locationFilter *model.Location) []goqu.Expression {
 	// If no location filter is provided, return early with no conditions.
@@ -160,59 +400,68 @@ func buildLocationConditions(locationFilter *model.Location) []goqu.Expression {
 	}
 
 	var conditions []goqu.Expression
+	doc := map[string]any{}
 
 	// Using a slice of anonymous structs to iterate through direct location fields for cleaner code.
 	directLocationFilters := []struct {
 		Value string
-		Path  string
+		Key   string
 	}{
-		{locationFilter.ID, "location->>'id'"},
-		{locationFilter.MapURL, "location->>'map_url'"},
-		{locationFilter.Address, "location->>'address'"},
-		{locationFilter.District, "location->>'district'"},
-		{locationFilter.AreaCode, "location->>'area_code'"},
-		{locationFilter.Polygon, "location->>'polygon'"},
-		{locationFilter.ThreeDSpace, "location->>'3dspace'"},
-		{locationFilter.Rating, "location->>'rating'"},
+		{locationFilter.ID, "id"},
+		{locationFilter.MapURL, "map_url"},
+		{locationFilter.Address, "address"},
+		{locationFilter.District, "district"},
+		{locationFilter.AreaCode, "area_code"},
+		{locationFilter.Polygon, "polygon"},
+		{locationFilter.ThreeDSpace, "3dspace"},
+		{locationFilter.Rating, "rating"},
 	}
 
-	// Iterate over the defined location filters and add conditions if the value is present.
+	// Iterate over the defined location filters and add them to the document if the value is present.
 	for _, lf := range directLocationFilters {
 		if lf.Value != "" {
-			conditions = append(conditions, goqu.L(lf.Path).Eq(lf.Value))
+			doc[lf.Key] = lf.Value
 		}
 	}
 
 	// Nested fields: Check if City, State, Country pointers are not nil before accessing their fields.
 	// This prevents nil pointer dereferences.
 	if locationFilter.City != nil {
-		if locationFilter.City.Name != "" {
-			conditions = append(conditions, goqu.L("location->'city'->>'name'").Eq(locationFilter.City.Name))
-		}
+		addNameCode(doc, "city", locationFilter.City.Name, "")
 		if locationFilter.City.Code != "" {
-			conditions = append(conditions, goqu.L("location->'city'->>'code'").Eq(locationFilter.City.Code))
+			conditions = append(conditions, goqu.C("city_code").Eq(locationFilter.City.Code))
 		}
 	}
-
 	if locationFilter.State != nil {
-		if locationFilter.State.Name != "" {
-			conditions = append(conditions, goqu.L("location->'state'->>'name'").Eq(locationFilter.State.Name))
-		}
-		if locationFilter.State.Code != "" {
-			conditions = append(conditions, goqu.L("location->'state'->>'code'").Eq(locationFilter.State.Code))
-		}
+		addNameCode(doc, "state", locationFilter.State.Name, locationFilter.State.Code)
 	}
-
 	if locationFilter.Country != nil {
-		if locationFilter.Country.Name != "" {
-			conditions = append(conditions, goqu.L("location->'country'->>'name'").Eq(locationFilter.Country.Name))
-		}
+		addNameCode(doc, "country", locationFilter.Country.Name, "")
 		if locationFilter.Country.Code != "" {
-			conditions = append(conditions, goqu.L("location->'country'->>'code'").Eq(locationFilter.Country.Code))
+			conditions = append(conditions, goqu.C("country_code").Eq(locationFilter.Country.Code))
 		}
 	}
 
-	return conditions
+	if len(doc) == 0 {
+		return conditions
+	}
+	// A map of strings always marshals successfully.
+	b, _ := json.Marshal(doc)
+	return append(conditions, goqu.L("location @> ?::jsonb", string(b)))
+}
+
+// addNameCode adds the non-empty name and code of a nested location field to the containment document.
+func addNameCode(doc map[string]any, key, name, code string) {
+	nested := map[string]string{}
+	if name != "" {
+		nested["name"] = name
+	}
+	if code != "" {
+		nested["code"] = code
+	}
+	if len(nested) > 0 {
+		doc[key] = nested
+	}
 }
 
 var pgxv5Registerer = pgxv5.RegisterDriver
@@ -245,6 +494,14 @@ func NewConnectionPool(ctx context.Context, cfg *Config) (*sql.DB, func() error,
 	if err != nil {
 		return nil, nil, fmt.Errorf("sql.Open: %w", err)
 	}
+	if cfg.Tracing {
+		if db, err = newTracedDB(db, dsn, otel.Tracer(tracerName)); err != nil {
+			if cleanupErr := cleanup(); cleanupErr != nil {
+				slog.ErrorContext(ctx, "failed to run pgxv5 cleanup after tracing setup failure", "error", cleanupErr)
+			}
+			return nil, nil, fmt.Errorf("failed to trace database statements: %w", err)
+		}
+	}
 
 	// Configure the Connection Pool.
 	// A value of 0 or less for any of these settings means default behavior.
@@ -288,7 +545,7 @@ const insertOperationQuery = `
 
 const updateOperationQuery = `
 	UPDATE Operations
-	SET status = $2, result_json = $3, error_data_json = $4, retry_count = $5
+	SET status = $2, result_json = $3, error_data_json = $4, retry_count = $5, review_json = COALESCE($6, review_json)
 	WHERE operation_id = $1
 	RETURNING created_at, updated_at, type, request_json;`
 
@@ -315,6 +572,18 @@ const insertOnlySubscriptionQuery = `
 	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	RETURNING created_at, updated_at;`
 
+// reviewJSON marshals the review of an LRO for storage. A nil review leaves the stored review unchanged.
+func reviewJSON(lro *model.LRO) (sql.NullString, error) {
+	if lro.Review == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(lro.Review)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal review of operation %s: %w", lro.OperationID, err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
 // validateLRO checks if the LRO object has the minimum required fields for a new operation insertion.
 func validateLRO(lro *model.LRO) error {
 	if lro == nil {
@@ -333,13 +602,20 @@ func validateLRO(lro *model.LRO) error {
 }
 
 // InsertOperation inserts a new operation into the Operations table.
-func (r *registry) InsertOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+func (r *registry) InsertOperation(ctx context.Context, lro *model.LRO) (_ *model.LRO, err error) {
+	ctx, done := r.begin(ctx, "InsertOperation", mutationQuery)
+	defer func() { err = done(err) }()
 	if err := validateLRO(lro); err != nil {
 		return nil, fmt.Errorf("LRO validation failed: %w", err)
 	}
 
+	request, err := r.sealRequest(lro.RequestJSON)
+	if err != nil {
+		return nil, err
+	}
+
 	// Scan the database-generated timestamps back into the struct.
-	err := r.db.QueryRowContext(ctx, insertOperationQuery, lro.OperationID, lro.Status, lro.Type, lro.RequestJSON).Scan(&lro.CreatedAt, &lro.UpdatedAt)
+	err = r.queryRow(ctx, "InsertOperation", nonIdempotentCall, insertOperationQuery, []any{lro.OperationID, lro.Status, lro.Type, request}, &lro.CreatedAt, &lro.UpdatedAt)
 
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
@@ -368,7 +644,9 @@ func validateSubscriptionForInsert(sub *model.Subscription) error {
 
 // InsertSubscription inserts a new subscription record into the database.
 // It expects the database to handle 'created_at' and 'updated_at' timestamps.
-func (r *registry) InsertSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error) {
+func (r *registry) InsertSubscription(ctx context.Context, sub *model.Subscription) (_ *model.Subscription, err error) {
+	ctx, done := r.begin(ctx, "InsertSubscription", mutationQuery)
+	defer func() { err = done(err) }()
 	if err := validateSubscriptionForInsert(sub); err != nil {
 		return nil, fmt.Errorf("subscription validation failed: %w", err)
 	}
@@ -380,12 +658,16 @@ func (r *registry) InsertSubscription(ctx context.Context, sub *model.Subscripti
 		}
 		locationJSON = sql.NullString{String: string(locBytes), Valid: true}
 	}
+	nonce, err := r.sealNonce(sub.Nonce)
+	if err != nil {
+		return nil, err
+	}
 
-	err := r.db.QueryRowContext(ctx, insertOnlySubscriptionQuery,
+	err = r.queryRow(ctx, "InsertSubscription", nonIdempotentCall, insertOnlySubscriptionQuery, []any{
 		sub.SubscriberID, sub.URL, sub.Type, sub.Domain, locationJSON, sub.KeyID,
 		sub.SigningPublicKey, sub.EncrPublicKey, sub.ValidFrom, sub.ValidUntil,
-		sub.Status, sub.Nonce,
-	).Scan(&sub.Created, &sub.Updated) // Scan back the DB-generated timestamps
+		sub.Status, nonce,
+	}, &sub.Created, &sub.Updated) // Scan back the DB-generated timestamps
 
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
@@ -402,9 +684,11 @@ const getSubscriberSigningKeyQuery = `
 `
 
 // GetSubscriberSigningKey fetches the signing public key for a given subscriber_id and key_id.
-func (r *registry) GetSubscriberSigningKey(ctx context.Context, subscriberID string, domain string, role model.Role, keyID string) (string, error) {
+func (r *registry) GetSubscriberSigningKey(ctx context.Context, subscriberID string, domain string, role model.Role, keyID string) (_ string, err error) {
+	ctx, done := r.begin(ctx, "GetSubscriberSigningKey", lookupQuery)
+	defer func() { err = done(err) }()
 	var publicKey string
-	err := r.db.QueryRowContext(ctx, getSubscriberSigningKeyQuery, subscriberID, domain, role, keyID).Scan(&publicKey)
+	err = r.queryRow(ctx, "GetSubscriberSigningKey", idempotentCall, getSubscriberSigningKeyQuery, []any{subscriberID, domain, role, keyID}, &publicKey)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", fmt.Errorf("%w: for subscriber_id '%s', domain '%s', type '%s', key_id '%s'", ErrSubscriberKeyNotFound, subscriberID, domain, role, keyID)
@@ -414,23 +698,50 @@ func (r *registry) GetSubscriberSigningKey(ctx context.Context, subscriberID str
 	return publicKey, nil
 }
 
+const getSigningKeyQuery = `
+	SELECT signing_public_key FROM subscriptions
+	WHERE subscriber_id = $1 AND key_id = $2 AND status = 'SUBSCRIBED'
+	LIMIT 1
+`
+
+// SigningKey fetches the signing public key of a SUBSCRIBED subscription of subscriber_id with
+// key_id, in any domain and role.
+func (r *registry) SigningKey(ctx context.Context, subscriberID string, keyID string) (_ string, err error) {
+	ctx, done := r.begin(ctx, "SigningKey", lookupQuery)
+	defer func() { err = done(err) }()
+	var publicKey string
+	err = r.queryRow(ctx, "SigningKey", idempotentCall, getSigningKeyQuery, []any{subscriberID, keyID}, &publicKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: for subscriber_id '%s', key_id '%s'", ErrSubscriberKeyNotFound, subscriberID, keyID)
+		}
+		return "", fmt.Errorf("failed to query subscriber signing key: %w", err)
+	}
+	return publicKey, nil
+}
+
 const getOperationQuery = `
-	SELECT operation_id, status, type, request_json, result_json, error_data_json, created_at, updated_at
+	SELECT operation_id, status, type, request_json, result_json, error_data_json, probe_json, review_json, comments_json, created_at, updated_at
 	FROM Operations
 	WHERE operation_id = $1`
 
 // GetOperation retrieves a specific LRO from the database by its ID. (No changes needed here)
-func (r *registry) GetOperation(ctx context.Context, id string) (*model.LRO, error) {
+func (r *registry) GetOperation(ctx context.Context, id string) (_ *model.LRO, err error) {
+	ctx, done := r.begin(ctx, "GetOperation", lookupQuery)
+	defer func() { err = done(err) }()
 	lro := &model.LRO{}
-	var resultJSON, errorDataJSON sql.NullString
+	var resultJSON, errorDataJSON, probeJSON, reviewJSON, commentsJSON sql.NullString
 
-	err := r.db.QueryRowContext(ctx, getOperationQuery, id).Scan(
+	err = r.queryRow(ctx, "GetOperation", idempotentCall, getOperationQuery, []any{id},
 		&lro.OperationID,
 		&lro.Status,
 		&lro.Type,
 		&lro.RequestJSON,
 		&resultJSON,
 		&errorDataJSON,
+		&probeJSON,
+		&reviewJSON,
+		&commentsJSON,
 		&lro.CreatedAt,
 		&lro.UpdatedAt,
 	)
@@ -440,25 +751,147 @@ func (r *registry) GetOperation(ctx context.Context, id string) (*model.LRO, err
 		}
 		return nil, fmt.Errorf("failed to get operation with ID %s: %w", id, err)
 	}
+	if lro.RequestJSON, err = r.openRequest(lro.OperationID, lro.RequestJSON); err != nil {
+		return nil, err
+	}
 	if resultJSON.Valid {
 		lro.ResultJSON = []byte(resultJSON.String)
 	}
-	if errorDataJSON.Valid {
-		lro.ErrorDataJSON = []byte(errorDataJSON.String)
+	if lro.ErrorDataJSON, err = r.errorData(lro.OperationID, errorDataJSON); err != nil {
+		return nil, err
+	}
+	if probeJSON.Valid {
+		lro.ProbeJSON = []byte(probeJSON.String)
+	}
+	if reviewJSON.Valid {
+		lro.Review = &model.OperationReview{}
+		if err := json.Unmarshal([]byte(reviewJSON.String), lro.Review); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal review of operation %s: %w", id, err)
+		}
+		lro.SubState = lro.Review.SubState()
+	}
+	if lro.Comments, err = operationComments(id, commentsJSON); err != nil {
+		return nil, err
 	}
 
 	return lro, nil
 }
 
+// operationComments unmarshals the stored comments of an operation.
+func operationComments(operationID string, commentsJSON sql.NullString) ([]model.OperationComment, error) {
+	if !commentsJSON.Valid {
+		return nil, nil
+	}
+	var comments []model.OperationComment
+	if err := json.Unmarshal([]byte(commentsJSON.String), &comments); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal comments of operation %s: %w", operationID, err)
+	}
+	return comments, nil
+}
+
+// commentsJSON marshals the comments of an LRO for storage. An LRO without comments stores none.
+func commentsJSON(lro *model.LRO) (sql.NullString, error) {
+	if len(lro.Comments) == 0 {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(lro.Comments)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal comments of operation %s: %w", lro.OperationID, err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// addOperationCommentQuery appends a comment ($2, a JSON object) to the comments of an operation.
+const addOperationCommentQuery = `
+	UPDATE Operations
+	SET comments_json = COALESCE(comments_json, '[]'::jsonb) || jsonb_build_array($2::jsonb)
+	WHERE operation_id = $1
+	RETURNING operation_id;`
+
+// AddOperationComment appends a comment to the comments of an operation. The comments of
+// concurrent reviewers are appended atomically, so none is lost.
+func (r *registry) AddOperationComment(ctx context.Context, operationID string, comment *model.OperationComment) (err error) {
+	ctx, done := r.begin(ctx, "AddOperationComment", mutationQuery)
+	defer func() { err = done(err) }()
+	b, err := json.Marshal(comment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment of operation %s: %w", operationID, err)
+	}
+	var id string
+	if err := r.queryRow(ctx, "AddOperationComment", nonIdempotentCall, addOperationCommentQuery, []any{operationID, string(b)}, &id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOperationNotFound
+		}
+		return fmt.Errorf("failed to add comment to operation %s: %w", operationID, err)
+	}
+	return nil
+}
+
+const setOperationProbeQuery = `
+	UPDATE Operations
+	SET probe_json = $2
+	WHERE operation_id = $1
+	RETURNING operation_id;`
+
+// SetOperationProbe stores the result of probing the subscriber URL of an operation.
+func (r *registry) SetOperationProbe(ctx context.Context, operationID string, probe json.RawMessage) (err error) {
+	ctx, done := r.begin(ctx, "SetOperationProbe", mutationQuery)
+	defer func() { err = done(err) }()
+	var id string
+	if err := r.queryRow(ctx, "SetOperationProbe", idempotentCall, setOperationProbeQuery, []any{operationID, string(probe)}, &id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOperationNotFound
+		}
+		return fmt.Errorf("failed to set probe result for operation %s: %w", operationID, err)
+	}
+	return nil
+}
+
+const insertChallengeAttemptQuery = `
+	INSERT INTO operation_challenges (operation_id, url, passed, signed, error, attempted_at)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6);`
+
+// RecordChallengeAttempt records an /on_subscribe challenge sent while approving an operation.
+func (r *registry) RecordChallengeAttempt(ctx context.Context, a *model.ChallengeAttempt) (err error) {
+	ctx, done := r.begin(ctx, "RecordChallengeAttempt", mutationQuery)
+	defer func() { err = done(err) }()
+	if a == nil {
+		return ErrChallengeAttemptIsNil
+	}
+	if _, err := r.db.ExecContext(ctx, insertChallengeAttemptQuery, a.OperationID, a.URL, a.Passed, a.Signed, a.Error, a.AttemptedAt); err != nil {
+		return fmt.Errorf("failed to record challenge attempt of operation %s: %w", a.OperationID, err)
+	}
+	return nil
+}
+
+const listChallengeAttemptsQuery = `
+	SELECT operation_id, url, passed, signed, COALESCE(error, '') AS error, attempted_at
+	FROM operation_challenges
+	WHERE operation_id = $1
+	ORDER BY attempted_at, challenge_id`
+
+// ListChallengeAttempts returns the challenges sent while approving an operation, oldest first.
+func (r *registry) ListChallengeAttempts(ctx context.Context, operationID string) (_ []model.ChallengeAttempt, err error) {
+	ctx, done := r.begin(ctx, "ListChallengeAttempts", lookupQuery)
+	defer func() { err = done(err) }()
+	attempts := []model.ChallengeAttempt{}
+	if err := r.selectAll(ctx, "ListChallengeAttempts", idempotentCall, &attempts, listChallengeAttemptsQuery, []any{operationID}); err != nil {
+		return nil, fmt.Errorf("failed to query challenge attempts of operation %s: %w", operationID, err)
+	}
+	return attempts, nil
+}
+
 const getSubscriberEncryptionKeyQuery = `
 	SELECT encr_public_key FROM subscriptions
 	WHERE subscriber_id = $1 AND key_id = $2 AND status = 'SUBSCRIBED'
 `
 
 // EncryptionKey fetches the encryption public key for a given subscriber_id and key_id.
-func (r *registry) EncryptionKey(ctx context.Context, subscriberID string, keyID string) (string, error) {
+func (r *registry) EncryptionKey(ctx context.Context, subscriberID string, keyID string) (_ string, err error) {
+	ctx, done := r.begin(ctx, "EncryptionKey", lookupQuery)
+	defer func() { err = done(err) }()
 	var publicKey string
-	err := r.db.QueryRowContext(ctx, getSubscriberEncryptionKeyQuery, subscriberID, keyID).Scan(&publicKey)
+	err = r.queryRow(ctx, "EncryptionKey", idempotentCall, getSubscriberEncryptionKeyQuery, []any{subscriberID, keyID}, &publicKey)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", fmt.Errorf("%w: for subscriber_id '%s', key_id '%s'", ErrEncrKeyNotFound, subscriberID, keyID)
@@ -468,8 +901,26 @@ func (r *registry) EncryptionKey(ctx context.Context, subscriberID string, keyID
 	return publicKey, nil
 }
 
+const updateSigningKeyQuery = `
+	UPDATE subscriptions SET signing_public_key = $3, updated_at = NOW()
+	WHERE subscriber_id = $1 AND key_id = $2 AND signing_public_key IS DISTINCT FROM $3
+`
+
+// UpdateSigningKey sets the signing public key of the subscriptions of subscriber_id with
+// key_id. Subscriptions that already hold the key are left unchanged.
+func (r *registry) UpdateSigningKey(ctx context.Context, subscriberID, keyID, signingPublicKey string) (err error) {
+	ctx, done := r.begin(ctx, "UpdateSigningKey", mutationQuery)
+	defer func() { err = done(err) }()
+	if _, err := r.db.ExecContext(ctx, updateSigningKeyQuery, subscriberID, keyID, signingPublicKey); err != nil {
+		return fmt.Errorf("failed to update signing key of subscriber %s: %w", subscriberID, err)
+	}
+	return nil
+}
+
 // UpdateOperation updates an existing LRO record in the database.
-func (r *registry) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+func (r *registry) UpdateOperation(ctx context.Context, lro *model.LRO) (_ *model.LRO, err error) {
+	ctx, done := r.begin(ctx, "UpdateOperation", mutationQuery)
+	defer func() { err = done(err) }()
 	if lro == nil {
 		return nil, errors.New("lro cannot be nil")
 	}
@@ -481,13 +932,17 @@ func (r *registry) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.
 	if lro.ResultJSON != nil {
 		resultJSON = sql.NullString{String: string(lro.ResultJSON), Valid: true}
 	}
-	if lro.ErrorDataJSON != nil {
-		errorDataJSON = sql.NullString{String: string(lro.ErrorDataJSON), Valid: true}
+	if errorDataJSON, err = r.sealErrorData(lro.ErrorDataJSON); err != nil {
+		return nil, err
+	}
+	review, err := reviewJSON(lro)
+	if err != nil {
+		return nil, err
 	}
 
-	err := r.db.QueryRowContext(ctx, updateOperationQuery,
-		lro.OperationID, lro.Status, resultJSON, errorDataJSON, lro.RetryCount,
-	).Scan(&lro.CreatedAt, &lro.UpdatedAt, &lro.Type, &lro.RequestJSON) // Scan back all returned fields
+	err = r.queryRow(ctx, "UpdateOperation", idempotentCall, updateOperationQuery, []any{
+		lro.OperationID, lro.Status, resultJSON, errorDataJSON, lro.RetryCount, review,
+	}, &lro.CreatedAt, &lro.UpdatedAt, &lro.Type, &lro.RequestJSON) // Scan back all returned fields
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -495,92 +950,1060 @@ func (r *registry) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.
 		}
 		return nil, fmt.Errorf("failed to update operation %s: %w", lro.OperationID, err)
 	}
+	if lro.RequestJSON, err = r.openRequest(lro.OperationID, lro.RequestJSON); err != nil {
+		return nil, err
+	}
 	return lro, nil
 }
 
-// UpsertSubscriptionAndLRO performs an upsert on the subscriptions table and an update on the Operations table
-// within the same database transaction. Timestamps are handled by the database.
-func (r *registry) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
-	if err := r.validateUpsertInputs(sub, lro); err != nil {
-		return nil, nil, err
-	}
+const listStaleOperationsQuery = `
+	SELECT operation_id, status, type, request_json, result_json, error_data_json, comments_json, retry_count, created_at, updated_at
+	FROM Operations
+	WHERE status = 'PENDING' AND updated_at < $1
+	ORDER BY updated_at
+	LIMIT $2`
+
+// operationRow is a row of the Operations table as selected by the operation list queries.
+type operationRow struct {
+	OperationID   string              `db:"operation_id"`
+	Status        model.LROStatus     `db:"status"`
+	Type          model.OperationType `db:"type"`
+	RequestJSON   json.RawMessage     `db:"request_json"`
+	ResultJSON    sql.NullString      `db:"result_json"`
+	ErrorDataJSON sql.NullString      `db:"error_data_json"`
+	CommentsJSON  sql.NullString      `db:"comments_json"`
+	RetryCount    int                 `db:"retry_count"`
+	CreatedAt     time.Time           `db:"created_at"`
+	UpdatedAt     time.Time           `db:"updated_at"`
+}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer func() {
-		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+// operations converts operation rows to LROs, decrypting their request nonce and error data.
+func (r *registry) operations(rows []operationRow) ([]model.LRO, error) {
+	lros := make([]model.LRO, 0, len(rows))
+	for _, row := range rows {
+		lro := model.LRO{
+			OperationID: row.OperationID,
+			Status:      row.Status,
+			Type:        row.Type,
+			RequestJSON: row.RequestJSON,
+			RetryCount:  row.RetryCount,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
 		}
-	}()
-	
-	if err := r.upsertSubscription(ctx, tx, sub); err != nil {
-		return nil, nil, err
-	}
-
-	if err := r.updateLRO(ctx, tx, lro); err != nil {
-		return nil, nil, err
+		if row.ResultJSON.Valid {
+			lro.ResultJSON = []byte(row.ResultJSON.String)
+		}
+		var err error
+		if lro.RequestJSON, err = r.openRequest(lro.OperationID, row.RequestJSON); err != nil {
+			return nil, err
+		}
+		if lro.ErrorDataJSON, err = r.errorData(lro.OperationID, row.ErrorDataJSON); err != nil {
+			return nil, err
+		}
+		if lro.Comments, err = operationComments(lro.OperationID, row.CommentsJSON); err != nil {
+			return nil, err
+		}
+		lros = append(lros, lro)
 	}
+	return lros, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+// ListStaleOperations returns up to limit PENDING LROs that have not been updated since before, oldest first.
+func (r *registry) ListStaleOperations(ctx context.Context, before time.Time, limit int) (_ []model.LRO, err error) {
+	ctx, done := r.begin(ctx, "ListStaleOperations", lookupQuery)
+	defer func() { err = done(err) }()
+	var rows []operationRow
+	if err := r.selectAll(ctx, "ListStaleOperations", idempotentCall, &rows, listStaleOperationsQuery, []any{before, limit}); err != nil {
+		return nil, fmt.Errorf("failed to query stale operations: %w", err)
 	}
-
-	return sub, lro, nil
+	return r.operations(rows)
 }
 
-// validateUpsertInputs checks the validity of subscription and LRO inputs.
-func (r *registry) validateUpsertInputs(sub *model.Subscription, lro *model.LRO) error {
-	if sub == nil {
-		return errors.New("subscription cannot be nil")
-	}
+const expireOperationQuery = `
+	UPDATE Operations
+	SET status = $2, error_data_json = $3
+	WHERE operation_id = $1 AND status = 'PENDING' AND updated_at < $4
+	RETURNING created_at, updated_at`
+
+// ExpireOperation sets the status and error data of a stale LRO, but only if it is still PENDING
+// and has not been updated since before. It returns ErrOperationNotPending if an admin acted on the
+// operation in the meantime, so concurrent expiry jobs and admin actions never overwrite each other.
+func (r *registry) ExpireOperation(ctx context.Context, lro *model.LRO, before time.Time) (_ *model.LRO, err error) {
+	ctx, done := r.begin(ctx, "ExpireOperation", mutationQuery)
+	defer func() { err = done(err) }()
 	if lro == nil {
-		return errors.New("lro cannot be nil")
+		return nil, ErrLROIsNil
 	}
-	if err := validateLRO(lro); err != nil {
-		return fmt.Errorf("LRO validation failed: %w", err)
+	errorDataJSON, err := r.sealErrorData(lro.ErrorDataJSON)
+	if err != nil {
+		return nil, err
 	}
-	if sub.SubscriberID == "" || sub.KeyID == "" {
-		return errors.New("subscriberID and keyID are required for subscription")
+	err = r.queryRow(ctx, "ExpireOperation", nonIdempotentCall, expireOperationQuery, []any{lro.OperationID, lro.Status, errorDataJSON, before}, &lro.CreatedAt, &lro.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOperationNotPending
+		}
+		return nil, fmt.Errorf("failed to expire operation %s: %w", lro.OperationID, err)
 	}
-	return nil
+	return lro, nil
 }
 
-// upsertSubscription handles the database upsert operation for a subscription within a transaction.
-func (r *registry) upsertSubscription(ctx context.Context, tx *sql.Tx, sub *model.Subscription) error {
-	var locationJSON sql.NullString
-	if sub.Location != nil {
-		locBytes, err := json.Marshal(sub.Location)
+// reserveNonceQuery claims a nonce for an operation. An existing reservation is only taken over
+// once it falls outside the uniqueness window and was never consumed, or when the same operation
+// retries its own unconsumed reservation.
+const reserveNonceQuery = `
+	INSERT INTO subscription_nonces (nonce, subscriber_id, operation_id)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (nonce) DO UPDATE SET
+		subscriber_id = EXCLUDED.subscriber_id,
+		operation_id = EXCLUDED.operation_id,
+		created_at = CURRENT_TIMESTAMP
+	WHERE subscription_nonces.consumed_at IS NULL
+		AND (subscription_nonces.created_at < $4 OR subscription_nonces.operation_id = EXCLUDED.operation_id)
+	RETURNING created_at;`
+
+// ReserveNonce records that nonce is used by the given operation. It returns ErrNonceReplayed if the
+// nonce has been consumed, or is reserved by another operation since windowStart.
+func (r *registry) ReserveNonce(ctx context.Context, nonce, subscriberID, operationID string, windowStart time.Time) (err error) {
+	ctx, done := r.begin(ctx, "ReserveNonce", mutationQuery)
+	defer func() { err = done(err) }()
+	keys := r.nonceKeys(nonce)
+	// A nonce reserved before the data key was rotated, or before encryption was enabled, is
+	// stored under another key than the one reserved below and has to be checked first.
+	for _, key := range keys[1:] {
+		owner, err := r.getNonce(ctx, "ReserveNonce", key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
 		if err != nil {
-			return fmt.Errorf("failed to marshal subscription location: %w", err)
+			return fmt.Errorf("failed to get nonce for operation %s: %w", operationID, err)
+		}
+		if owner.consumedAt.Valid || (owner.operationID != operationID && !owner.createdAt.Before(windowStart)) {
+			return fmt.Errorf("%w: nonce '%s'", ErrNonceReplayed, nonce)
 		}
-		locationJSON = sql.NullString{String: string(locBytes), Valid: true}
 	}
-
-	err := tx.QueryRowContext(ctx, upsertSubscriptionQuery,
-		sub.SubscriberID, sub.URL, sub.Type, sub.Domain, locationJSON, sub.KeyID,
-		sub.SigningPublicKey, sub.EncrPublicKey, sub.ValidFrom, sub.ValidUntil,
-		sub.Status,
-	).Scan(&sub.Created, &sub.Updated) // Scan back the DB-generated timestamps
-
+	var createdAt time.Time
+	err = r.queryRow(ctx, "ReserveNonce", nonIdempotentCall, reserveNonceQuery, []any{keys[0], subscriberID, operationID, windowStart}, &createdAt)
 	if err != nil {
-		return fmt.Errorf("failed to upsert subscription: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: nonce '%s'", ErrNonceReplayed, nonce)
+		}
+		return fmt.Errorf("failed to reserve nonce for operation %s: %w", operationID, err)
 	}
 	return nil
 }
 
-func (r *registry) updateLRO(ctx context.Context, tx *sql.Tx, lro *model.LRO) error {
-	var resultJSON, errorDataJSON sql.NullString
-	if lro.ResultJSON != nil {
-		resultJSON = sql.NullString{String: string(lro.ResultJSON), Valid: true}
-	}
-	if lro.ErrorDataJSON != nil {
-		errorDataJSON = sql.NullString{String: string(lro.ErrorDataJSON), Valid: true}
+// consumeNonceQuery marks a nonce as consumed by the operation that reserved it.
+// Consuming again from the same operation is allowed so that a failed approval can be retried.
+const consumeNonceQuery = `
+	UPDATE subscription_nonces
+	SET consumed_at = COALESCE(consumed_at, CURRENT_TIMESTAMP)
+	WHERE nonce = $1 AND operation_id = $2 AND created_at >= $3
+	RETURNING consumed_at;`
+
+const getNonceQuery = `
+	SELECT operation_id, created_at, consumed_at FROM subscription_nonces
+	WHERE nonce = $1`
+
+// nonceReservation is the reservation of a nonce in subscription_nonces.
+type nonceReservation struct {
+	operationID string
+	createdAt   time.Time
+	consumedAt  sql.NullTime
+}
+
+// getNonce returns the reservation stored under key, or sql.ErrNoRows if there is none.
+func (r *registry) getNonce(ctx context.Context, op, key string) (*nonceReservation, error) {
+	var n nonceReservation
+	if err := r.queryRow(ctx, op, idempotentCall, getNonceQuery, []any{key}, &n.operationID, &n.createdAt, &n.consumedAt); err != nil {
+		return nil, err
 	}
+	return &n, nil
+}
 
-	err := tx.QueryRowContext(ctx, updateOperationQuery,
-		lro.OperationID, lro.Status, resultJSON, errorDataJSON, lro.RetryCount,
+// ConsumeNonce marks nonce as used by operationID. It returns ErrNonceNotFound if the nonce was never
+// reserved, ErrNonceReplayed if it belongs to another operation and ErrNonceExpired if it was
+// reserved before issuedAfter.
+func (r *registry) ConsumeNonce(ctx context.Context, nonce, operationID string, issuedAfter time.Time) (err error) {
+	ctx, done := r.begin(ctx, "ConsumeNonce", mutationQuery)
+	defer func() { err = done(err) }()
+	keys := r.nonceKeys(nonce)
+	for _, key := range keys {
+		var consumedAt time.Time
+		err := r.queryRow(ctx, "ConsumeNonce", nonIdempotentCall, consumeNonceQuery, []any{key, operationID, issuedAfter}, &consumedAt)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to consume nonce for operation %s: %w", operationID, err)
+		}
+	}
+
+	for _, key := range keys {
+		owner, err := r.getNonce(ctx, "ConsumeNonce", key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get nonce for operation %s: %w", operationID, err)
+		}
+		if owner.operationID != operationID {
+			return fmt.Errorf("%w: nonce '%s' belongs to operation %s", ErrNonceReplayed, nonce, owner.operationID)
+		}
+		return fmt.Errorf("%w: nonce '%s' was issued at %s", ErrNonceExpired, nonce, owner.createdAt.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%w: nonce '%s'", ErrNonceNotFound, nonce)
+}
+
+const insertAPIKeyQuery = `
+	INSERT INTO subscriber_api_keys (key_id, subscriber_id, key_hash)
+	VALUES ($1, $2, $3)
+	RETURNING created_at;`
+
+// InsertAPIKey stores the hash of an API key issued to a subscriber.
+func (r *registry) InsertAPIKey(ctx context.Context, key *model.APIKey) (_ *model.APIKey, err error) {
+	ctx, done := r.begin(ctx, "InsertAPIKey", mutationQuery)
+	defer func() { err = done(err) }()
+	if key == nil {
+		return nil, ErrAPIKeyIsNil
+	}
+	if err := r.queryRow(ctx, "InsertAPIKey", nonIdempotentCall, insertAPIKeyQuery, []any{key.KeyID, key.SubscriberID, key.Hash}, &key.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert API key for subscriber %s: %w", key.SubscriberID, err)
+	}
+	return key, nil
+}
+
+const getAPIKeyByHashQuery = `
+	SELECT key_id, subscriber_id, key_hash, created_at
+	FROM subscriber_api_keys
+	WHERE key_hash = $1 AND revoked_at IS NULL`
+
+// GetAPIKeyByHash returns the unrevoked API key with the given hash, or ErrAPIKeyNotFound.
+func (r *registry) GetAPIKeyByHash(ctx context.Context, hash string) (_ *model.APIKey, err error) {
+	ctx, done := r.begin(ctx, "GetAPIKeyByHash", lookupQuery)
+	defer func() { err = done(err) }()
+	key := &model.APIKey{}
+	if err := r.queryRow(ctx, "GetAPIKeyByHash", idempotentCall, getAPIKeyByHashQuery, []any{hash}, &key.KeyID, &key.SubscriberID, &key.Hash, &key.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+const listAPIKeysQuery = `
+	SELECT key_id, subscriber_id, created_at, revoked_at
+	FROM subscriber_api_keys
+	WHERE subscriber_id = $1
+	ORDER BY created_at`
+
+// ListAPIKeys returns the API keys issued to a subscriber, including revoked ones, oldest first.
+func (r *registry) ListAPIKeys(ctx context.Context, subscriberID string) (_ []model.APIKey, err error) {
+	ctx, done := r.begin(ctx, "ListAPIKeys", lookupQuery)
+	defer func() { err = done(err) }()
+	keys := []model.APIKey{}
+	if err := r.selectAll(ctx, "ListAPIKeys", idempotentCall, &keys, listAPIKeysQuery, []any{subscriberID}); err != nil {
+		return nil, fmt.Errorf("failed to query API keys of subscriber %s: %w", subscriberID, err)
+	}
+	return keys, nil
+}
+
+const revokeAPIKeyQuery = `
+	UPDATE subscriber_api_keys
+	SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+	WHERE key_id = $1 AND subscriber_id = $2
+	RETURNING revoked_at;`
+
+// RevokeAPIKey revokes an API key of a subscriber. Revoking a revoked key is a no-op.
+func (r *registry) RevokeAPIKey(ctx context.Context, subscriberID, keyID string) (err error) {
+	ctx, done := r.begin(ctx, "RevokeAPIKey", mutationQuery)
+	defer func() { err = done(err) }()
+	var revokedAt time.Time
+	if err := r.queryRow(ctx, "RevokeAPIKey", nonIdempotentCall, revokeAPIKeyQuery, []any{keyID, subscriberID}, &revokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("failed to revoke API key %s: %w", keyID, err)
+	}
+	return nil
+}
+
+const listSubscriberOperationsQuery = `
+	SELECT operation_id, status, type, request_json, result_json, error_data_json, retry_count, created_at, updated_at
+	FROM Operations
+	WHERE request_json->>'subscriber_id' = $1
+	ORDER BY created_at DESC
+	LIMIT $2`
+
+// ListSubscriberOperations returns up to limit LROs requested by a subscriber, newest first.
+func (r *registry) ListSubscriberOperations(ctx context.Context, subscriberID string, limit int) (_ []model.LRO, err error) {
+	ctx, done := r.begin(ctx, "ListSubscriberOperations", lookupQuery)
+	defer func() { err = done(err) }()
+	var rows []operationRow
+	if err := r.selectAll(ctx, "ListSubscriberOperations", idempotentCall, &rows, listSubscriberOperationsQuery, []any{subscriberID, limit}); err != nil {
+		return nil, fmt.Errorf("failed to query operations of subscriber %s: %w", subscriberID, err)
+	}
+	return r.operations(rows)
+}
+
+const countPendingOperationsQuery = `
+	SELECT COUNT(*)
+	FROM Operations
+	WHERE request_json->>'subscriber_id' = $1 AND status = 'PENDING'`
+
+// CountPendingOperations returns the number of pending LROs requested by a subscriber.
+func (r *registry) CountPendingOperations(ctx context.Context, subscriberID string) (_ int, err error) {
+	ctx, done := r.begin(ctx, "CountPendingOperations", lookupQuery)
+	defer func() { err = done(err) }()
+	var count int
+	if err := r.queryRow(ctx, "CountPendingOperations", idempotentCall, countPendingOperationsQuery, []any{subscriberID}, &count); err != nil {
+		return 0, fmt.Errorf("failed to count pending operations of subscriber %s: %w", subscriberID, err)
+	}
+	return count, nil
+}
+
+const incrementQuotaCounterQuery = `
+	INSERT INTO request_quota_counters (scope, caller, period, window_start, request_count)
+	VALUES ($1, $2, $3, $4, 1)
+	ON CONFLICT (scope, caller, period, window_start) DO UPDATE SET
+		request_count = request_quota_counters.request_count + 1
+	RETURNING request_count;`
+
+// IncrementQuotaCounter counts a request of caller in a quota window and returns the number
+// of requests counted in the window, including this one.
+func (r *registry) IncrementQuotaCounter(ctx context.Context, scope, caller, period string, windowStart time.Time) (_ int, err error) {
+	ctx, done := r.begin(ctx, "IncrementQuotaCounter", mutationQuery)
+	defer func() { err = done(err) }()
+	var count int
+	if err := r.queryRow(ctx, "IncrementQuotaCounter", nonIdempotentCall, incrementQuotaCounterQuery, []any{scope, caller, period, windowStart}, &count); err != nil {
+		return 0, fmt.Errorf("failed to increment %s quota counter of %s: %w", scope, caller, err)
+	}
+	return count, nil
+}
+
+const deleteQuotaCountersQuery = `DELETE FROM request_quota_counters WHERE window_start < $1`
+
+// DeleteQuotaCounters deletes the quota counters of windows that started before the given time
+// and returns how many it deleted.
+func (r *registry) DeleteQuotaCounters(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, done := r.begin(ctx, "DeleteQuotaCounters", mutationQuery)
+	defer func() { err = done(err) }()
+	res, err := r.db.ExecContext(ctx, deleteQuotaCountersQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete quota counters: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
+
+const insertWebhookQuery = `
+	INSERT INTO webhooks (webhook_id, url, event_types, secret)
+	VALUES ($1, $2, $3, $4)
+	RETURNING created_at;`
+
+// InsertWebhook registers a webhook.
+func (r *registry) InsertWebhook(ctx context.Context, hook *model.Webhook) (_ *model.Webhook, err error) {
+	ctx, done := r.begin(ctx, "InsertWebhook", mutationQuery)
+	defer func() { err = done(err) }()
+	if hook == nil {
+		return nil, ErrWebhookIsNil
+	}
+	eventTypes, err := json.Marshal(hook.EventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event types: %w", err)
+	}
+	if err := r.queryRow(ctx, "InsertWebhook", nonIdempotentCall, insertWebhookQuery, []any{hook.ID, hook.URL, eventTypes, hook.Secret}, &hook.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook %s: %w", hook.ID, err)
+	}
+	return hook, nil
+}
+
+const selectWebhooksQuery = `
+	SELECT webhook_id, url, event_types, secret, created_at
+	FROM webhooks`
+
+// webhookRow is a row of selectWebhooksQuery.
+type webhookRow struct {
+	ID         string    `db:"webhook_id"`
+	URL        string    `db:"url"`
+	EventTypes []byte    `db:"event_types"`
+	Secret     string    `db:"secret"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// webhook converts the row to a webhook.
+func (w *webhookRow) webhook() (*model.Webhook, error) {
+	hook := &model.Webhook{ID: w.ID, URL: w.URL, Secret: w.Secret, CreatedAt: w.CreatedAt}
+	if err := json.Unmarshal(w.EventTypes, &hook.EventTypes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event types of webhook %s: %w", hook.ID, err)
+	}
+	return hook, nil
+}
+
+// scanWebhook scans a row of selectWebhooksQuery.
+func scanWebhook(row interface{ Scan(...any) error }) (*model.Webhook, error) {
+	var w webhookRow
+	if err := row.Scan(&w.ID, &w.URL, &w.EventTypes, &w.Secret, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	return w.webhook()
+}
+
+// ListWebhooks returns all registered webhooks, oldest first.
+func (r *registry) ListWebhooks(ctx context.Context) (_ []model.Webhook, err error) {
+	ctx, done := r.begin(ctx, "ListWebhooks", lookupQuery)
+	defer func() { err = done(err) }()
+	var rows []webhookRow
+	if err := r.selectAll(ctx, "ListWebhooks", idempotentCall, &rows, selectWebhooksQuery+" ORDER BY created_at", nil); err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	hooks := make([]model.Webhook, 0, len(rows))
+	for _, row := range rows {
+		hook, err := row.webhook()
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, *hook)
+	}
+	return hooks, nil
+}
+
+// GetWebhook returns the webhook with the given ID, or ErrWebhookNotFound.
+func (r *registry) GetWebhook(ctx context.Context, id string) (_ *model.Webhook, err error) {
+	ctx, done := r.begin(ctx, "GetWebhook", lookupQuery)
+	defer func() { err = done(err) }()
+	hook, err := scanWebhook(r.db.QueryRowContext(ctx, selectWebhooksQuery+" WHERE webhook_id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook %s: %w", id, err)
+	}
+	return hook, nil
+}
+
+const deleteWebhookQuery = `DELETE FROM webhooks WHERE webhook_id = $1`
+
+// DeleteWebhook deletes a webhook and its delivery log, or returns ErrWebhookNotFound.
+func (r *registry) DeleteWebhook(ctx context.Context, id string) (err error) {
+	ctx, done := r.begin(ctx, "DeleteWebhook", mutationQuery)
+	defer func() { err = done(err) }()
+	res, err := r.db.ExecContext(ctx, deleteWebhookQuery, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+const insertWebhookDeliveryQuery = `
+	INSERT INTO webhook_deliveries (delivery_id, webhook_id, event_type, operation_id, payload, status, attempts)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at, updated_at;`
+
+// InsertWebhookDelivery records a delivery of an event to a webhook.
+func (r *registry) InsertWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) (_ *model.WebhookDelivery, err error) {
+	ctx, done := r.begin(ctx, "InsertWebhookDelivery", mutationQuery)
+	defer func() { err = done(err) }()
+	if d == nil {
+		return nil, ErrWebhookDeliveryIsNil
+	}
+	if err := r.queryRow(ctx, "InsertWebhookDelivery", nonIdempotentCall, insertWebhookDeliveryQuery, []any{d.ID, d.WebhookID, d.EventType, d.OperationID, []byte(d.Payload), d.Status, d.Attempts}, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook delivery %s: %w", d.ID, err)
+	}
+	return d, nil
+}
+
+const updateWebhookDeliveryQuery = `
+	UPDATE webhook_deliveries
+	SET status = $2, attempts = $3, response_code = NULLIF($4, 0), last_error = NULLIF($5, '')
+	WHERE delivery_id = $1
+	RETURNING updated_at;`
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt, or returns ErrWebhookDeliveryNotFound.
+func (r *registry) UpdateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) (err error) {
+	ctx, done := r.begin(ctx, "UpdateWebhookDelivery", mutationQuery)
+	defer func() { err = done(err) }()
+	if d == nil {
+		return ErrWebhookDeliveryIsNil
+	}
+	if err := r.queryRow(ctx, "UpdateWebhookDelivery", idempotentCall, updateWebhookDeliveryQuery, []any{d.ID, d.Status, d.Attempts, d.ResponseCode, d.LastError}, &d.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookDeliveryNotFound
+		}
+		return fmt.Errorf("failed to update webhook delivery %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+const selectWebhookDeliveriesQuery = `
+	SELECT delivery_id, webhook_id, event_type, operation_id, payload, status, attempts, response_code, last_error, created_at, updated_at
+	FROM webhook_deliveries`
+
+// webhookDeliveryRow is a row of selectWebhookDeliveriesQuery.
+type webhookDeliveryRow struct {
+	ID           string                      `db:"delivery_id"`
+	WebhookID    string                      `db:"webhook_id"`
+	EventType    model.EventType             `db:"event_type"`
+	OperationID  string                      `db:"operation_id"`
+	Payload      []byte                      `db:"payload"`
+	Status       model.WebhookDeliveryStatus `db:"status"`
+	Attempts     int                         `db:"attempts"`
+	ResponseCode sql.NullInt64               `db:"response_code"`
+	LastError    sql.NullString              `db:"last_error"`
+	CreatedAt    time.Time                   `db:"created_at"`
+	UpdatedAt    time.Time                   `db:"updated_at"`
+}
+
+// delivery converts the row to a webhook delivery.
+func (d *webhookDeliveryRow) delivery() model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:           d.ID,
+		WebhookID:    d.WebhookID,
+		EventType:    d.EventType,
+		OperationID:  d.OperationID,
+		Payload:      d.Payload,
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: int(d.ResponseCode.Int64),
+		LastError:    d.LastError.String,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+}
+
+// scanWebhookDelivery scans a row of selectWebhookDeliveriesQuery.
+func scanWebhookDelivery(row interface{ Scan(...any) error }) (*model.WebhookDelivery, error) {
+	var d webhookDeliveryRow
+	if err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.OperationID, &d.Payload, &d.Status, &d.Attempts, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	delivery := d.delivery()
+	return &delivery, nil
+}
+
+// selectWebhookDeliveries runs a query of webhook deliveries.
+func (r *registry) selectWebhookDeliveries(ctx context.Context, op, query string, args ...any) ([]model.WebhookDelivery, error) {
+	var rows []webhookDeliveryRow
+	if err := r.selectAll(ctx, op, idempotentCall, &rows, query, args); err != nil {
+		return nil, err
+	}
+	deliveries := make([]model.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.delivery())
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDelivery returns a delivery of a webhook, or ErrWebhookDeliveryNotFound.
+func (r *registry) GetWebhookDelivery(ctx context.Context, webhookID, deliveryID string) (_ *model.WebhookDelivery, err error) {
+	ctx, done := r.begin(ctx, "GetWebhookDelivery", lookupQuery)
+	defer func() { err = done(err) }()
+	d, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, selectWebhookDeliveriesQuery+" WHERE webhook_id = $1 AND delivery_id = $2", webhookID, deliveryID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery %s: %w", deliveryID, err)
+	}
+	return d, nil
+}
+
+// ListWebhookDeliveries returns up to limit deliveries of a webhook, newest first.
+func (r *registry) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) (_ []model.WebhookDelivery, err error) {
+	ctx, done := r.begin(ctx, "ListWebhookDeliveries", lookupQuery)
+	defer func() { err = done(err) }()
+	deliveries, err := r.selectWebhookDeliveries(ctx, "ListWebhookDeliveries", selectWebhookDeliveriesQuery+" WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2", webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deliveries of webhook %s: %w", webhookID, err)
+	}
+	return deliveries, nil
+}
+
+// ListOperationDeliveries returns the deliveries of the events about an operation to any webhook, oldest first.
+func (r *registry) ListOperationDeliveries(ctx context.Context, operationID string) (_ []model.WebhookDelivery, err error) {
+	ctx, done := r.begin(ctx, "ListOperationDeliveries", lookupQuery)
+	defer func() { err = done(err) }()
+	deliveries, err := r.selectWebhookDeliveries(ctx, "ListOperationDeliveries", selectWebhookDeliveriesQuery+" WHERE operation_id = $1 ORDER BY created_at", operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deliveries of operation %s: %w", operationID, err)
+	}
+	return deliveries, nil
+}
+
+const getMaintenanceQuery = `
+	SELECT enabled, message, updated_at
+	FROM registry_maintenance
+	WHERE id`
+
+// GetMaintenance returns the maintenance mode of the registry. It is disabled if it was never set.
+func (r *registry) GetMaintenance(ctx context.Context) (_ *model.Maintenance, err error) {
+	ctx, done := r.begin(ctx, "GetMaintenance", lookupQuery)
+	defer func() { err = done(err) }()
+	m := &model.Maintenance{}
+	if err := r.queryRow(ctx, "GetMaintenance", idempotentCall, getMaintenanceQuery, nil, &m.Enabled, &m.Message, &m.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Maintenance{}, nil
+		}
+		return nil, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+	return m, nil
+}
+
+const setMaintenanceQuery = `
+	INSERT INTO registry_maintenance (id, enabled, message, updated_at)
+	VALUES (TRUE, $1, $2, CURRENT_TIMESTAMP)
+	ON CONFLICT (id) DO UPDATE SET
+		enabled = EXCLUDED.enabled,
+		message = EXCLUDED.message,
+		updated_at = EXCLUDED.updated_at
+	RETURNING updated_at;`
+
+// SetMaintenance sets the maintenance mode of the registry.
+func (r *registry) SetMaintenance(ctx context.Context, m *model.Maintenance) (_ *model.Maintenance, err error) {
+	ctx, done := r.begin(ctx, "SetMaintenance", mutationQuery)
+	defer func() { err = done(err) }()
+	if err := r.queryRow(ctx, "SetMaintenance", idempotentCall, setMaintenanceQuery, []any{m.Enabled, m.Message}, &m.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+	return m, nil
+}
+
+const insertDenylistEntryQuery = `
+	INSERT INTO denylist (entry_id, kind, value, reason, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at;`
+
+// InsertDenylistEntry adds an entry to the denylist.
+func (r *registry) InsertDenylistEntry(ctx context.Context, e *model.DenylistEntry) (_ *model.DenylistEntry, err error) {
+	ctx, done := r.begin(ctx, "InsertDenylistEntry", mutationQuery)
+	defer func() { err = done(err) }()
+	if e == nil {
+		return nil, ErrDenylistEntryIsNil
+	}
+	if err := r.queryRow(ctx, "InsertDenylistEntry", nonIdempotentCall, insertDenylistEntryQuery, []any{e.ID, e.Kind, e.Value, e.Reason, e.ExpiresAt}, &e.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert denylist entry %s: %w", e.ID, err)
+	}
+	return e, nil
+}
+
+const listDenylistEntriesQuery = `
+	SELECT entry_id, kind, value, reason, created_at, expires_at
+	FROM denylist
+	WHERE expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP
+	ORDER BY created_at`
+
+// ListDenylistEntries returns the denylist entries that have not expired, oldest first.
+func (r *registry) ListDenylistEntries(ctx context.Context) (_ []model.DenylistEntry, err error) {
+	ctx, done := r.begin(ctx, "ListDenylistEntries", lookupQuery)
+	defer func() { err = done(err) }()
+	entries := []model.DenylistEntry{}
+	if err := r.selectAll(ctx, "ListDenylistEntries", idempotentCall, &entries, listDenylistEntriesQuery, nil); err != nil {
+		return nil, fmt.Errorf("failed to query denylist entries: %w", err)
+	}
+	return entries, nil
+}
+
+const deleteDenylistEntryQuery = `DELETE FROM denylist WHERE entry_id = $1`
+
+// DeleteDenylistEntry removes an entry from the denylist, or returns ErrDenylistEntryNotFound.
+func (r *registry) DeleteDenylistEntry(ctx context.Context, id string) (err error) {
+	ctx, done := r.begin(ctx, "DeleteDenylistEntry", mutationQuery)
+	defer func() { err = done(err) }()
+	res, err := r.db.ExecContext(ctx, deleteDenylistEntryQuery, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete denylist entry %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrDenylistEntryNotFound
+	}
+	return nil
+}
+
+const insertDomainQuery = `
+	INSERT INTO domains (name, parent, display_name, location_granularity, schema_version)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at, updated_at;`
+
+// InsertDomain adds a domain to the domain catalog.
+func (r *registry) InsertDomain(ctx context.Context, d *model.Domain) (_ *model.Domain, err error) {
+	ctx, done := r.begin(ctx, "InsertDomain", mutationQuery)
+	defer func() { err = done(err) }()
+	if d == nil {
+		return nil, ErrDomainIsNil
+	}
+	if err := r.queryRow(ctx, "InsertDomain", nonIdempotentCall, insertDomainQuery, []any{d.Name, d.Parent, d.DisplayName, d.LocationGranularity, d.SchemaVersion}, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert domain %s: %w", d.Name, err)
+	}
+	return d, nil
+}
+
+const updateDomainQuery = `
+	UPDATE domains
+	SET parent = $2, display_name = $3, location_granularity = $4, schema_version = $5
+	WHERE name = $1
+	RETURNING created_at, updated_at;`
+
+// UpdateDomain replaces the metadata of a domain, or returns ErrDomainNotFound.
+func (r *registry) UpdateDomain(ctx context.Context, d *model.Domain) (_ *model.Domain, err error) {
+	ctx, done := r.begin(ctx, "UpdateDomain", mutationQuery)
+	defer func() { err = done(err) }()
+	if d == nil {
+		return nil, ErrDomainIsNil
+	}
+	if err := r.queryRow(ctx, "UpdateDomain", idempotentCall, updateDomainQuery, []any{d.Name, d.Parent, d.DisplayName, d.LocationGranularity, d.SchemaVersion}, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to update domain %s: %w", d.Name, err)
+	}
+	return d, nil
+}
+
+const listDomainsQuery = `
+	SELECT name, parent, display_name, location_granularity, schema_version, created_at, updated_at
+	FROM domains
+	ORDER BY name`
+
+// ListDomains returns the domain catalog ordered by name.
+func (r *registry) ListDomains(ctx context.Context) (_ []model.Domain, err error) {
+	ctx, done := r.begin(ctx, "ListDomains", lookupQuery)
+	defer func() { err = done(err) }()
+	domains := []model.Domain{}
+	if err := r.selectAll(ctx, "ListDomains", idempotentCall, &domains, listDomainsQuery, nil); err != nil {
+		return nil, fmt.Errorf("failed to query domains: %w", err)
+	}
+	return domains, nil
+}
+
+const deleteDomainQuery = `DELETE FROM domains WHERE name = $1`
+
+// DeleteDomain removes a domain from the catalog, or returns ErrDomainNotFound.
+func (r *registry) DeleteDomain(ctx context.Context, name string) (err error) {
+	ctx, done := r.begin(ctx, "DeleteDomain", mutationQuery)
+	defer func() { err = done(err) }()
+	res, err := r.db.ExecContext(ctx, deleteDomainQuery, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain %s: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrDomainNotFound
+	}
+	return nil
+}
+
+const operationStatusCountsQuery = `
+	SELECT status, COUNT(*)
+	FROM Operations
+	WHERE created_at >= $1 AND created_at < $2
+	GROUP BY status`
+
+// The time to approval of an operation is the time between its submission and its
+// last update, which for an approved operation is the approval.
+const operationApprovalLatencyQuery = `
+	SELECT COUNT(*),
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM updated_at - created_at)), 0),
+		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM updated_at - created_at)), 0),
+		COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM updated_at - created_at)), 0)
+	FROM Operations
+	WHERE status = 'APPROVED' AND created_at >= $1 AND created_at < $2`
+
+const operationDailyVolumeQuery = `
+	SELECT to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*)
+	FROM Operations
+	WHERE created_at >= $1 AND created_at < $2
+	GROUP BY day
+	ORDER BY day`
+
+// OperationStats computes statistics of the operations submitted in [from, to).
+func (r *registry) OperationStats(ctx context.Context, from, to time.Time) (_ *model.LROStats, err error) {
+	ctx, done := r.begin(ctx, "OperationStats", lookupQuery)
+	defer func() { err = done(err) }()
+	stats := &model.LROStats{From: from, To: to, ByStatus: map[model.LROStatus]int{}, Daily: []model.LRODailyVolume{}}
+
+	var counts []struct {
+		Status model.LROStatus `db:"status"`
+		Count  int             `db:"count"`
+	}
+	if err := r.selectAll(ctx, "OperationStats", idempotentCall, &counts, operationStatusCountsQuery, []any{from, to}); err != nil {
+		return nil, fmt.Errorf("failed to count operations by status: %w", err)
+	}
+	for _, c := range counts {
+		stats.ByStatus[c.Status] = c.Count
+		stats.Total += c.Count
+	}
+
+	l := &stats.TimeToApproval
+	if err := r.queryRow(ctx, "OperationStats", idempotentCall, operationApprovalLatencyQuery, []any{from, to}, &l.Count, &l.P50, &l.P90, &l.P99); err != nil {
+		return nil, fmt.Errorf("failed to compute operation approval latency: %w", err)
+	}
+
+	if err := r.selectAll(ctx, "OperationStats", idempotentCall, &stats.Daily, operationDailyVolumeQuery, []any{from, to}); err != nil {
+		return nil, fmt.Errorf("failed to count operations by day: %w", err)
+	}
+	return stats, nil
+}
+
+// operationFailureCountsQuery counts the operations that failed since $1 by error, keeping the
+// $2 most frequent. Rejections count as failures only when they were caused by a processing
+// error, recorded under "error", rather than by an admin, whose reason is recorded under "reason".
+// Encrypted error data keeps its code and field names readable; without a code it counts as ENCRYPTED.
+const operationFailureCountsQuery = `
+	SELECT COALESCE(error_data_json->>'code',
+		CASE WHEN error_data_json ? 'encrypted' THEN 'ENCRYPTED' ELSE error_data_json->>'error' END,
+		'UNKNOWN') AS code, COUNT(*)
+	FROM Operations
+	WHERE updated_at >= $1
+		AND (status = 'FAILURE' OR (status = 'REJECTED'
+			AND (error_data_json->>'error' IS NOT NULL OR error_data_json->'fields' ? 'error')))
+	GROUP BY code
+	ORDER BY COUNT(*) DESC, code
+	LIMIT $2`
+
+// OperationFailureCounts returns up to limit counts of the operations that failed since since,
+// grouped by error code, most frequent first.
+func (r *registry) OperationFailureCounts(ctx context.Context, since time.Time, limit int) (_ []model.LROFailureCount, err error) {
+	ctx, done := r.begin(ctx, "OperationFailureCounts", lookupQuery)
+	defer func() { err = done(err) }()
+	counts := []model.LROFailureCount{}
+	if err := r.selectAll(ctx, "OperationFailureCounts", idempotentCall, &counts, operationFailureCountsQuery, []any{since, limit}); err != nil {
+		return nil, fmt.Errorf("failed to count operation failures: %w", err)
+	}
+	return counts, nil
+}
+
+const setSubscriptionLabelsQuery = `
+	UPDATE subscriptions
+	SET labels = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE subscriber_id = $1 AND domain = $2 AND type = $3
+	RETURNING updated_at`
+
+// SetSubscriptionLabels replaces the labels of the subscription of subscriberID in domain with role typ.
+// It returns ErrSubscriptionNotFound if the subscriber has no such subscription.
+func (r *registry) SetSubscriptionLabels(ctx context.Context, subscriberID, domain string, typ model.Role, labels model.Labels) (_ time.Time, err error) {
+	ctx, done := r.begin(ctx, "SetSubscriptionLabels", mutationQuery)
+	defer func() { err = done(err) }()
+	var updated time.Time
+	if err := r.queryRow(ctx, "SetSubscriptionLabels", idempotentCall, setSubscriptionLabelsQuery, []any{subscriberID, domain, typ, labels}, &updated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrSubscriptionNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to set labels of subscription %s/%s/%s: %w", subscriberID, domain, typ, err)
+	}
+	return updated, nil
+}
+
+const listExpiringSubscriptionsQuery = `
+	SELECT subscriber_id, url, type, domain, location, key_id, signing_public_key, encr_public_key,
+		valid_from, valid_until, status, created_at, updated_at
+	FROM subscriptions
+	WHERE status = 'SUBSCRIBED' AND valid_until < $1
+	ORDER BY valid_until, subscriber_id
+	LIMIT $2`
+
+// ListExpiringSubscriptions returns up to limit SUBSCRIBED subscriptions whose validity ends
+// before before, soonest first. Subscriptions that have already expired are included.
+func (r *registry) ListExpiringSubscriptions(ctx context.Context, before time.Time, limit int) (_ []model.Subscription, err error) {
+	ctx, done := r.begin(ctx, "ListExpiringSubscriptions", lookupQuery)
+	defer func() { err = done(err) }()
+	subs := []model.Subscription{}
+	if err := r.selectAll(ctx, "ListExpiringSubscriptions", idempotentCall, &subs, listExpiringSubscriptionsQuery, []any{before, limit}); err != nil {
+		return nil, fmt.Errorf("failed to query expiring subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// activateSubscriptionsQuery sets up to $2 subscriptions approved for activation at or before $1
+// to SUBSCRIBED, earliest first. Rows locked by a concurrent activation are skipped, so that
+// every subscription is activated, and returned, once.
+const activateSubscriptionsQuery = `
+	UPDATE subscriptions s SET status = 'SUBSCRIBED'
+	FROM (
+		SELECT subscriber_id, domain, type FROM subscriptions
+		WHERE status = 'APPROVED_PENDING_ACTIVATION' AND valid_from <= $1
+		ORDER BY valid_from, subscriber_id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	) due
+	WHERE s.subscriber_id = due.subscriber_id AND s.domain = due.domain AND s.type = due.type
+	RETURNING s.subscriber_id, s.url, s.type, s.domain, s.location, s.key_id, s.signing_public_key, s.encr_public_key,
+		s.valid_from, s.valid_until, s.status, s.created_at, s.updated_at`
+
+// ActivateSubscriptions activates up to limit subscriptions pending activation whose validity
+// starts at or before now, and returns them as activated.
+func (r *registry) ActivateSubscriptions(ctx context.Context, now time.Time, limit int) (_ []model.Subscription, err error) {
+	ctx, done := r.begin(ctx, "ActivateSubscriptions", mutationQuery)
+	defer func() { err = done(err) }()
+	subs := []model.Subscription{}
+	// A repeated activation would not return the subscriptions the lost attempt activated,
+	// so only failures that guarantee nothing was activated are retried.
+	if err := r.selectAll(ctx, "ActivateSubscriptions", nonIdempotentCall, &subs, activateSubscriptionsQuery, []any{now, limit}); err != nil {
+		return nil, fmt.Errorf("failed to activate subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// subscriptionsAtQuery selects the latest version of each subscription of a subscriber
+// recorded at or before a point in time, leaving out subscriptions deleted by then.
+const subscriptionsAtQuery = `
+	SELECT * FROM (
+		SELECT DISTINCT ON (domain, type)
+			change, changed_at, subscriber_id, url, type, domain, location, key_id,
+			signing_public_key, encr_public_key, valid_from, valid_until, status, created_at, updated_at
+		FROM subscription_history
+		WHERE subscriber_id = $1 AND changed_at <= $2
+		ORDER BY domain, type, changed_at DESC, history_id DESC
+	) AS versions
+	WHERE change <> 'DELETE'
+	ORDER BY domain, type`
+
+// SubscriptionsAt returns the versions of the subscriptions of a subscriber in effect at a point in time.
+func (r *registry) SubscriptionsAt(ctx context.Context, subscriberID string, at time.Time) (_ []model.SubscriptionVersion, err error) {
+	ctx, done := r.begin(ctx, "SubscriptionsAt", lookupQuery)
+	defer func() { err = done(err) }()
+	versions := []model.SubscriptionVersion{}
+	if err := r.selectAll(ctx, "SubscriptionsAt", idempotentCall, &versions, subscriptionsAtQuery, []any{subscriberID, at}); err != nil {
+		return nil, fmt.Errorf("failed to query subscription history: %w", err)
+	}
+	return versions, nil
+}
+
+// subscriptionChangesQuery selects the recorded versions of one key of a subscription from a point in time.
+const subscriptionChangesQuery = `
+	SELECT change, changed_at, subscriber_id, url, type, domain, location, key_id,
+		signing_public_key, encr_public_key, valid_from, valid_until, status, created_at, updated_at
+	FROM subscription_history
+	WHERE subscriber_id = $1 AND domain = $2 AND type = $3 AND key_id = $4 AND changed_at >= $5
+	ORDER BY changed_at, history_id
+	LIMIT $6`
+
+// SubscriptionChanges returns up to limit versions of the subscription of a subscriber in a domain
+// and type with a key, recorded at or after since, oldest first.
+func (r *registry) SubscriptionChanges(ctx context.Context, subscriberID, domain string, typ model.Role, keyID string, since time.Time, limit int) (_ []model.SubscriptionVersion, err error) {
+	ctx, done := r.begin(ctx, "SubscriptionChanges", lookupQuery)
+	defer func() { err = done(err) }()
+	versions := []model.SubscriptionVersion{}
+	if err := r.selectAll(ctx, "SubscriptionChanges", idempotentCall, &versions, subscriptionChangesQuery, []any{subscriberID, domain, typ, keyID, since, limit}); err != nil {
+		return nil, fmt.Errorf("failed to query subscription history: %w", err)
+	}
+	return versions, nil
+}
+
+// UpsertSubscriptionAndLRO performs an upsert on the subscriptions table and an update on the Operations table
+// within the same database transaction. Timestamps are handled by the database.
+func (r *registry) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (_ *model.Subscription, _ *model.LRO, err error) {
+	ctx, done := r.begin(ctx, "UpsertSubscriptionAndLRO", mutationQuery)
+	defer func() { err = done(err) }()
+	if err := r.validateUpsertInputs(sub, lro); err != nil {
+		return nil, nil, err
+	}
+	// Retrying is safe as the transaction only upserts the subscription and sets the LRO to given values.
+	if err := r.retry(ctx, "UpsertSubscriptionAndLRO", idempotentCall, func() error { return r.upsertSubscriptionAndLRO(ctx, sub, lro) }); err != nil {
+		return nil, nil, err
+	}
+	return sub, lro, nil
+}
+
+// upsertSubscriptionAndLRO runs the transaction of UpsertSubscriptionAndLRO.
+func (r *registry) upsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	if err := r.upsertSubscription(ctx, tx, sub); err != nil {
+		return err
+	}
+
+	if err := r.updateLRO(ctx, tx, lro); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// validateUpsertInputs checks the validity of subscription and LRO inputs.
+func (r *registry) validateUpsertInputs(sub *model.Subscription, lro *model.LRO) error {
+	if sub == nil {
+		return errors.New("subscription cannot be nil")
+	}
+	if lro == nil {
+		return errors.New("lro cannot be nil")
+	}
+	if err := validateLRO(lro); err != nil {
+		return fmt.Errorf("LRO validation failed: %w", err)
+	}
+	if sub.SubscriberID == "" || sub.KeyID == "" {
+		return errors.New("subscriberID and keyID are required for subscription")
+	}
+	return nil
+}
+
+// upsertSubscription handles the database upsert operation for a subscription within a transaction.
+func (r *registry) upsertSubscription(ctx context.Context, tx *sql.Tx, sub *model.Subscription) error {
+	var locationJSON sql.NullString
+	if sub.Location != nil {
+		locBytes, err := json.Marshal(sub.Location)
+		if err != nil {
+			return fmt.Errorf("failed to marshal subscription location: %w", err)
+		}
+		locationJSON = sql.NullString{String: string(locBytes), Valid: true}
+	}
+
+	err := tx.QueryRowContext(ctx, upsertSubscriptionQuery,
+		sub.SubscriberID, sub.URL, sub.Type, sub.Domain, locationJSON, sub.KeyID,
+		sub.SigningPublicKey, sub.EncrPublicKey, sub.ValidFrom, sub.ValidUntil,
+		sub.Status,
+	).Scan(&sub.Created, &sub.Updated) // Scan back the DB-generated timestamps
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *registry) updateLRO(ctx context.Context, tx *sql.Tx, lro *model.LRO) error {
+	var resultJSON sql.NullString
+	if lro.ResultJSON != nil {
+		resultJSON = sql.NullString{String: string(lro.ResultJSON), Valid: true}
+	}
+	errorDataJSON, err := r.sealErrorData(lro.ErrorDataJSON)
+	if err != nil {
+		return err
+	}
+	review, err := reviewJSON(lro)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, updateOperationQuery,
+		lro.OperationID, lro.Status, resultJSON, errorDataJSON, lro.RetryCount, review,
 	).Scan(&lro.CreatedAt, &lro.UpdatedAt, &lro.Type, &lro.RequestJSON) // Scan back all returned fields
 
 	if err != nil {
@@ -589,5 +2012,330 @@ func (r *registry) updateLRO(ctx context.Context, tx *sql.Tx, lro *model.LRO) er
 		}
 		return fmt.Errorf("failed to update LRO %s in transaction: %w", lro.OperationID, err)
 	}
+	if lro.RequestJSON, err = r.openRequest(lro.OperationID, lro.RequestJSON); err != nil {
+		return err
+	}
 	return nil
 }
+
+const snapshotSubscriptionsQuery = `
+	SELECT subscriber_id, url, type, domain, location, key_id, signing_public_key, encr_public_key,
+		valid_from, valid_until, status, created_at, updated_at
+	FROM subscriptions
+	ORDER BY subscriber_id, domain, type`
+
+const snapshotOperationsQuery = `
+	SELECT operation_id, status, type, request_json, result_json, error_data_json, probe_json, review_json, comments_json, retry_count, created_at, updated_at
+	FROM Operations
+	ORDER BY created_at, operation_id`
+
+// Snapshot reads all subscriptions and operations in a single repeatable read transaction,
+// so that the operations are consistent with the subscriptions they produced. The nonces are
+// dropped from the operation requests and the error data is reduced to its code, so the
+// snapshot holds nothing that column encryption protects.
+func (r *registry) Snapshot(ctx context.Context) (_ *model.RegistrySnapshot, err error) {
+	ctx, done := r.begin(ctx, "Snapshot", lookupQuery)
+	defer func() { err = done(err) }()
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin snapshot transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	snap := &model.RegistrySnapshot{Subscriptions: []model.Subscription{}, Operations: []model.LRO{}}
+	if err := tx.SelectContext(ctx, &snap.Subscriptions, snapshotSubscriptionsQuery); err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions for snapshot: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, snapshotOperationsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations for snapshot: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lro model.LRO
+		var resultJSON, errorDataJSON, probeJSON, reviewJSON, commentsJSON sql.NullString
+		if err := rows.Scan(&lro.OperationID, &lro.Status, &lro.Type, &lro.RequestJSON, &resultJSON, &errorDataJSON, &probeJSON, &reviewJSON, &commentsJSON, &lro.RetryCount, &lro.CreatedAt, &lro.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan operation for snapshot: %w", err)
+		}
+		if lro.RequestJSON, err = withoutNonce(lro.RequestJSON); err != nil {
+			return nil, fmt.Errorf("failed to redact request of operation %s: %w", lro.OperationID, err)
+		}
+		if resultJSON.Valid {
+			lro.ResultJSON = []byte(resultJSON.String)
+		}
+		lro.ErrorDataJSON = redactedErrorData(errorDataJSON)
+		if probeJSON.Valid {
+			lro.ProbeJSON = []byte(probeJSON.String)
+		}
+		if reviewJSON.Valid {
+			lro.Review = &model.OperationReview{}
+			if err := json.Unmarshal([]byte(reviewJSON.String), lro.Review); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal review of operation %s: %w", lro.OperationID, err)
+			}
+		}
+		if lro.Comments, err = operationComments(lro.OperationID, commentsJSON); err != nil {
+			return nil, err
+		}
+		snap.Operations = append(snap.Operations, lro)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating operations for snapshot: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit snapshot transaction: %w", err)
+	}
+	return snap, nil
+}
+
+// restoreSubscriptionQuery inserts or overwrites a subscription, keeping its original creation time if known.
+const restoreSubscriptionQuery = `
+	INSERT INTO subscriptions (subscriber_id, url, type, domain, location, key_id, signing_public_key, encr_public_key, valid_from, valid_until, status, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, CURRENT_TIMESTAMP))
+	ON CONFLICT (subscriber_id, domain, type) DO UPDATE SET
+		url = EXCLUDED.url,
+		location = EXCLUDED.location,
+		key_id = EXCLUDED.key_id,
+		signing_public_key = EXCLUDED.signing_public_key,
+		encr_public_key = EXCLUDED.encr_public_key,
+		valid_from = EXCLUDED.valid_from,
+		valid_until = EXCLUDED.valid_until,
+		status = EXCLUDED.status`
+
+// restoreOperationQuery inserts or overwrites an operation, keeping its original creation time if known.
+const restoreOperationQuery = `
+	INSERT INTO Operations (operation_id, status, type, request_json, result_json, error_data_json, probe_json, review_json, comments_json, retry_count, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, CURRENT_TIMESTAMP))
+	ON CONFLICT (operation_id) DO UPDATE SET
+		status = EXCLUDED.status,
+		type = EXCLUDED.type,
+		request_json = EXCLUDED.request_json,
+		result_json = EXCLUDED.result_json,
+		error_data_json = EXCLUDED.error_data_json,
+		probe_json = EXCLUDED.probe_json,
+		review_json = EXCLUDED.review_json,
+		comments_json = EXCLUDED.comments_json,
+		retry_count = EXCLUDED.retry_count`
+
+// RestoreSnapshot writes the subscriptions and operations of a snapshot in a single transaction,
+// overwriting the subscriptions and operations with the same keys. Nothing is restored if any
+// of them fails.
+func (r *registry) RestoreSnapshot(ctx context.Context, snap *model.RegistrySnapshot) (_ *model.SnapshotRestoreReport, err error) {
+	ctx, done := r.begin(ctx, "RestoreSnapshot", mutationQuery)
+	defer func() { err = done(err) }()
+	if snap == nil {
+		return nil, errors.New("snapshot cannot be nil")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	for _, sub := range snap.Subscriptions {
+		var locationJSON sql.NullString
+		if sub.Location != nil {
+			locBytes, err := json.Marshal(sub.Location)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal location of subscription %s: %w", sub.SubscriberID, err)
+			}
+			locationJSON = sql.NullString{String: string(locBytes), Valid: true}
+		}
+		if _, err := tx.ExecContext(ctx, restoreSubscriptionQuery,
+			sub.SubscriberID, sub.URL, sub.Type, sub.Domain, locationJSON, sub.KeyID,
+			sub.SigningPublicKey, sub.EncrPublicKey, sub.ValidFrom, sub.ValidUntil,
+			sub.Status, nullTime(sub.Created),
+		); err != nil {
+			return nil, fmt.Errorf("failed to restore subscription %s (%s, %s): %w", sub.SubscriberID, sub.Domain, sub.Type, err)
+		}
+	}
+	for i := range snap.Operations {
+		lro := &snap.Operations[i]
+		if err := validateLRO(lro); err != nil {
+			return nil, fmt.Errorf("LRO validation failed: %w", err)
+		}
+		review, err := reviewJSON(lro)
+		if err != nil {
+			return nil, err
+		}
+		comments, err := commentsJSON(lro)
+		if err != nil {
+			return nil, err
+		}
+		errorData, err := r.sealErrorData(lro.ErrorDataJSON)
+		if err != nil {
+			return nil, err
+		}
+		request, err := r.sealRequest(lro.RequestJSON)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, restoreOperationQuery,
+			lro.OperationID, lro.Status, lro.Type, request, nullJSON(lro.ResultJSON),
+			errorData, nullJSON(lro.ProbeJSON), review, comments, lro.RetryCount, nullTime(lro.CreatedAt),
+		); err != nil {
+			return nil, fmt.Errorf("failed to restore operation %s: %w", lro.OperationID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &model.SnapshotRestoreReport{Subscriptions: len(snap.Subscriptions), Operations: len(snap.Operations)}, nil
+}
+
+// dropMergedSubscriptionsQuery deletes the subscriptions of the source subscriber ($1) that
+// the target subscriber ($2) already has.
+const dropMergedSubscriptionsQuery = `
+	DELETE FROM subscriptions s
+	WHERE s.subscriber_id = $1
+	AND EXISTS (SELECT 1 FROM subscriptions t WHERE t.subscriber_id = $2 AND t.domain = s.domain AND t.type = s.type)
+	RETURNING s.domain, s.type`
+
+// moveMergedSubscriptionsQuery moves the remaining subscriptions of the source subscriber ($1)
+// to the target subscriber ($2).
+const moveMergedSubscriptionsQuery = `
+	UPDATE subscriptions SET subscriber_id = $2
+	WHERE subscriber_id = $1
+	RETURNING domain, type`
+
+// moveMergedAPIKeysQuery moves the API keys of the source subscriber ($1) to the target subscriber ($2).
+const moveMergedAPIKeysQuery = `UPDATE subscriber_api_keys SET subscriber_id = $2 WHERE subscriber_id = $1`
+
+// dropMergedDenylistQuery deletes the subscriber denylist entries of the source subscriber ($1)
+// when the target subscriber ($2) is already denylisted, as entries are unique per value.
+const dropMergedDenylistQuery = `
+	DELETE FROM denylist
+	WHERE kind = 'SUBSCRIBER' AND value = $1
+	AND EXISTS (SELECT 1 FROM denylist WHERE kind = 'SUBSCRIBER' AND value = $2)`
+
+// moveMergedDenylistQuery moves the subscriber denylist entries of the source subscriber ($1)
+// to the target subscriber ($2).
+const moveMergedDenylistQuery = `UPDATE denylist SET value = $2 WHERE kind = 'SUBSCRIBER' AND value = $1`
+
+// moveMergedOperationsQuery renames the source subscriber ($1) to the target subscriber ($2)
+// in the request and result of every operation.
+const moveMergedOperationsQuery = `
+	UPDATE Operations SET
+		request_json = CASE WHEN request_json->>'subscriber_id' = $1
+			THEN jsonb_set(request_json, '{subscriber_id}', to_jsonb($2::text)) ELSE request_json END,
+		result_json = CASE WHEN result_json->>'subscriber_id' = $1
+			THEN jsonb_set(result_json, '{subscriber_id}', to_jsonb($2::text)) ELSE result_json END
+	WHERE request_json->>'subscriber_id' = $1 OR result_json->>'subscriber_id' = $1`
+
+// insertSubscriberMergeQuery records a merge in the merge audit log.
+const insertSubscriberMergeQuery = `
+	INSERT INTO subscriber_merges (merge_id, source_subscriber_id, target_subscriber_id, report)
+	VALUES ($1, $2, $3, $4)
+	RETURNING merged_at`
+
+// MergeSubscribers merges the source subscriber of req into its target in a single transaction.
+// Subscriptions of the source move to the target, except those whose domain and type the target
+// already has, which are deleted. API keys, subscriber denylist entries and operations move too.
+// The merge is recorded under mergeID. A dry run reports the same changes and rolls them back.
+// It returns ErrSubscriptionNotFound if the source has no subscriptions.
+func (r *registry) MergeSubscribers(ctx context.Context, req *model.SubscriberMergeRequest, mergeID string) (_ *model.SubscriberMergeReport, err error) {
+	ctx, done := r.begin(ctx, "MergeSubscribers", mutationQuery)
+	defer func() { err = done(err) }()
+	if req == nil {
+		return nil, errors.New("merge request cannot be nil")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	source, target := req.SourceSubscriberID, req.TargetSubscriberID
+	report := &model.SubscriberMergeReport{SourceSubscriberID: source, TargetSubscriberID: target, DryRun: req.DryRun}
+	if report.Dropped, err = mergedSubscriptions(ctx, tx, dropMergedSubscriptionsQuery, source, target); err != nil {
+		return nil, fmt.Errorf("failed to drop subscriptions of %s: %w", source, err)
+	}
+	if report.Moved, err = mergedSubscriptions(ctx, tx, moveMergedSubscriptionsQuery, source, target); err != nil {
+		return nil, fmt.Errorf("failed to move subscriptions of %s: %w", source, err)
+	}
+	if len(report.Dropped)+len(report.Moved) == 0 {
+		return nil, fmt.Errorf("%w: subscriber %s", ErrSubscriptionNotFound, source)
+	}
+	if report.APIKeys, err = execCount(ctx, tx, moveMergedAPIKeysQuery, source, target); err != nil {
+		return nil, fmt.Errorf("failed to move API keys of %s: %w", source, err)
+	}
+	if _, err := tx.ExecContext(ctx, dropMergedDenylistQuery, source, target); err != nil {
+		return nil, fmt.Errorf("failed to drop denylist entries of %s: %w", source, err)
+	}
+	if report.DenylistEntries, err = execCount(ctx, tx, moveMergedDenylistQuery, source, target); err != nil {
+		return nil, fmt.Errorf("failed to move denylist entries of %s: %w", source, err)
+	}
+	if report.Operations, err = execCount(ctx, tx, moveMergedOperationsQuery, source, target); err != nil {
+		return nil, fmt.Errorf("failed to move operations of %s: %w", source, err)
+	}
+	if req.DryRun {
+		// The deferred rollback discards the changes.
+		return report, nil
+	}
+
+	report.MergeID = mergeID
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merge report: %w", err)
+	}
+	if err := tx.QueryRowContext(ctx, insertSubscriberMergeQuery, mergeID, source, target, reportJSON).Scan(&report.MergedAt); err != nil {
+		return nil, fmt.Errorf("failed to record merge %s: %w", mergeID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return report, nil
+}
+
+// mergedSubscriptions runs a merge query returning the domain and type of the subscriptions it
+// changed, and returns them as subscribers of the source ($1).
+func mergedSubscriptions(ctx context.Context, tx *sql.Tx, query, source, target string) ([]model.Subscriber, error) {
+	rows, err := tx.QueryContext(ctx, query, source, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	subs := []model.Subscriber{}
+	for rows.Next() {
+		sub := model.Subscriber{SubscriberID: source}
+		if err := rows.Scan(&sub.Domain, &sub.Type); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// execCount runs a statement and returns the number of rows it affected.
+func execCount(ctx context.Context, tx *sql.Tx, query string, args ...any) (int, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// nullJSON converts an optional JSON document for storage.
+func nullJSON(b json.RawMessage) sql.NullString {
+	if b == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(b), Valid: true}
+}
+
+// nullTime converts an optional time for storage.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -269,7 +269,7 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at", // Note: changed from "created", "updated" to "created_at", "updated_at"
+					"status", "labels", "created_at", "updated_at", // Note: changed from "created", "updated" to "created_at", "updated_at"
 				)
 				sqlStr, _, _ := dataset.ToSQL()
 
@@ -303,7 +303,7 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at",
+					"status", "labels", "created_at", "updated_at",
 				).Where(
 					buildLookupConditions(filter)...,
 				)
@@ -341,7 +341,7 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at",
+					"status", "labels", "created_at", "updated_at",
 				).Where(
 					buildLookupConditions(filter)...,
 				)
@@ -384,7 +384,7 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at",
+					"status", "labels", "created_at", "updated_at",
 				).Where(
 					buildLookupConditions(filter)...,
 				)
@@ -424,7 +424,7 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at",
+					"status", "labels", "created_at", "updated_at",
 				).Where(
 					buildLookupConditions(filter)...,
 				)
@@ -473,7 +473,7 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at",
+					"status", "labels", "created_at", "updated_at",
 				).Where(
 					buildLookupConditions(filter)...,
 				)
@@ -545,6 +545,34 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 	}
 }
 
+func TestRegistry_LookupAny(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	filters := []*model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "np1"}},
+		{Subscriber: model.Subscriber{SubscriberID: "np2"}},
+	}
+	rows := sqlmock.NewRows([]string{"subscriber_id", "url", "type", "domain", "key_id", "status"}).
+		AddRow("np1", "http://np1.com", "BPP", "retail", "key1", "SUBSCRIBED").
+		AddRow("np2", "http://np2.com", "BPP", "retail", "key2", "SUBSCRIBED")
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM "subscriptions" WHERE (("subscriber_id" = 'np1') OR ("subscriber_id" = 'np2'))`)).WillReturnRows(rows)
+
+	got, err := r.LookupAny(context.Background(), filters)
+	if err != nil {
+		t.Fatalf("LookupAny() error = %v", err)
+	}
+	want := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "np1", URL: "http://np1.com", Type: model.RoleBPP, Domain: "retail"}, KeyID: "key1", Status: model.SubscriptionStatusSubscribed},
+		{Subscriber: model.Subscriber{SubscriberID: "np2", URL: "http://np2.com", Type: model.RoleBPP, Domain: "retail"}, KeyID: "key2", Status: model.SubscriptionStatusSubscribed},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LookupAny() mismatch (-want +got):\n%s", diff)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestRegistry_Lookup_Failure(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -561,7 +589,7 @@ func TestRegistry_Lookup_Failure(t *testing.T) {
 				dataset := goqu.From(subscriptionsTableName).Select(
 					"subscriber_id", "url", "type", "domain", "location", "key_id",
 					"signing_public_key", "encr_public_key", "valid_from", "valid_until",
-					"status", "created_at", "updated_at",
+					"status", "labels", "created_at", "updated_at",
 				).Where(
 					buildLookupConditions(filter)...,
 				)
@@ -748,7 +776,7 @@ func TestRegistry_updateLRO_Failure_NotFound(t *testing.T) {
 
 	// Expect the update query to return sql.ErrNoRows
 	mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-		WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{}, lro.RetryCount).
+		WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{}, lro.RetryCount, sql.NullString{}).
 		WillReturnError(sql.ErrNoRows)
 
 	err = r.updateLRO(ctx, tx, lro)
@@ -1033,9 +1061,9 @@ func TestRegistry_UpdateOperation_Success(t *testing.T) {
 	expectedErrorSQLNullString := sql.NullString{String: string(errorDataJSONInput), Valid: true}
 
 	mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-		WithArgs(lroToUpdate.OperationID, lroToUpdate.Status, expectedResultSQLNullString, expectedErrorSQLNullString, lroToUpdate.RetryCount). // Corrected WithArgs
-		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).                                           // Added updated_at
-																			AddRow(expectedCreatedAt, expectedUpdatedAt, expectedType, originalRequestJSON))
+		WithArgs(lroToUpdate.OperationID, lroToUpdate.Status, expectedResultSQLNullString, expectedErrorSQLNullString, lroToUpdate.RetryCount, sql.NullString{}). // Corrected WithArgs
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).
+			AddRow(expectedCreatedAt, expectedUpdatedAt, expectedType, originalRequestJSON))
 
 	updatedLRO, err := r.UpdateOperation(ctx, lroToUpdate)
 	if err != nil {
@@ -1082,6 +1110,29 @@ func TestRegistry_UpdateOperation_Success(t *testing.T) {
 	}
 }
 
+func TestRegistry_UpdateOperation_Review(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	reviewedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lro := &model.LRO{
+		OperationID: "op-1",
+		Status:      model.LROStatusApproved,
+		Review:      &model.OperationReview{Reviewer: "admin@example.com", Action: model.OperationActionApproveSubscription, Comment: "checked", ReviewedAt: reviewedAt},
+	}
+	wantReview := `{"reviewer":"admin@example.com","action":"APPROVE_SUBSCRIPTION","comment":"checked","reviewed_at":"2025-01-01T00:00:00Z"}`
+
+	mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
+		WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{}, 0, sql.NullString{String: wantReview, Valid: true}).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).AddRow(reviewedAt, reviewedAt, model.OperationTypeCreateSubscription, []byte(`{}`)))
+
+	if _, err := r.UpdateOperation(context.Background(), lro); err != nil {
+		t.Fatalf("UpdateOperation() unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
 func TestRegistry_UpdateOperation_Failure(t *testing.T) {
 	ctx := context.Background()
 	opID := "test-update-op-id-fail"
@@ -1113,7 +1164,7 @@ func TestRegistry_UpdateOperation_Failure(t *testing.T) {
 			},
 			mockSetup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
 				mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount).
+					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount, sqlmock.AnyArg()).
 					WillReturnError(sql.ErrNoRows)
 			},
 			wantErr: ErrOperationNotFound,
@@ -1127,7 +1178,7 @@ func TestRegistry_UpdateOperation_Failure(t *testing.T) {
 			},
 			mockSetup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
 				mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount).
+					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount, sqlmock.AnyArg()).
 					WillReturnError(dbErr)
 			},
 			wantErr: fmt.Errorf("failed to update operation %s: %w", opID, dbErr),
@@ -1217,7 +1268,7 @@ func TestRegistry_UpsertSubscriptionAndLRO_Success(t *testing.T) {
 
 	// Expect updateLRO query
 	mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-		WithArgs(lro.OperationID, lro.Status, sql.NullString{String: string(lroResultJSON), Valid: true}, sql.NullString{String: string(lroErrorDataJSON), Valid: true}, lro.RetryCount).
+		WithArgs(lro.OperationID, lro.Status, sql.NullString{String: string(lroResultJSON), Valid: true}, sql.NullString{String: string(lroErrorDataJSON), Valid: true}, lro.RetryCount, sql.NullString{}).
 		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).AddRow(fixedTime, fixedTime, lro.Type, lro.RequestJSON))
 
 	// Expect transaction commit
@@ -1369,7 +1420,7 @@ func TestRegistry_UpsertSubscriptionAndLRO_Failure(t *testing.T) {
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(fixedTime, fixedTime))
 				mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount).
+					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount, sqlmock.AnyArg()).
 					WillReturnError(errors.New("update LRO error"))
 				mock.ExpectRollback() // Expect rollback on error
 			},
@@ -1396,7 +1447,7 @@ func TestRegistry_UpsertSubscriptionAndLRO_Failure(t *testing.T) {
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(fixedTime, fixedTime))
 				mock.ExpectQuery(regexp.QuoteMeta(updateOperationQuery)).
-					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount).
+					WithArgs(lro.OperationID, lro.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), lro.RetryCount, sqlmock.AnyArg()).
 					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).AddRow(fixedTime, fixedTime, lro.Type, lro.RequestJSON))
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 			},
@@ -1463,6 +1514,62 @@ func TestRegistry_EncryptionKey_Success(t *testing.T) {
 	}
 }
 
+func TestRegistry_SigningKey(t *testing.T) {
+	ctx := context.Background()
+	dbErr := errors.New("connection refused")
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		want      string
+		wantErr   error
+	}{
+		{
+			name: "found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getSigningKeyQuery)).WithArgs("bap.example.com", "key1").
+					WillReturnRows(sqlmock.NewRows([]string{"signing_public_key"}).AddRow("signing-key"))
+			},
+			want: "signing-key",
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getSigningKeyQuery)).WithArgs("bap.example.com", "key1").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrSubscriberKeyNotFound,
+		},
+		{
+			name: "database error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getSigningKeyQuery)).WithArgs("bap.example.com", "key1").WillReturnError(dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer mockDB.Close()
+			r, _ := NewRegistry(mockDB)
+			tt.mockSetup(mock)
+
+			got, err := r.SigningKey(ctx, "bap.example.com", "key1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SigningKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SigningKey() = %q, want %q", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
 func TestRegistry_EncryptionKey_Failure(t *testing.T) {
 	ctx := context.Background()
 	subscriberID := "sub-enc-fail"
@@ -1527,6 +1634,77 @@ func TestRegistry_EncryptionKey_Failure(t *testing.T) {
 	}
 }
 
+func TestBuildDomainCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		wantSQL string
+	}{
+		{"empty", "", ""},
+		{"match all", "*", ""},
+		{"exact", "nic2004:52110", `"domain" = 'nic2004:52110'`},
+		{"prefix", "nic2004:*", `"domain" LIKE 'nic2004:%'`},
+		{"prefix with LIKE metacharacters", "ret_ail%:*", `"domain" LIKE 'ret\_ail\%:%'`},
+		{"wildcard not at end is literal", "nic*2004", `"domain" = 'nic*2004'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := buildDomainCondition(tt.domain)
+			if tt.wantSQL == "" {
+				if cond != nil {
+					t.Errorf("buildDomainCondition(%q) = %v, want nil", tt.domain, cond)
+				}
+				return
+			}
+			sql, _, err := goqu.From("temp").Where(cond).ToSQL()
+			if err != nil {
+				t.Fatalf("ToSQL() error = %v", err)
+			}
+			if got := extractWhereClause(sql); got != tt.wantSQL {
+				t.Errorf("buildDomainCondition(%q) = %s, want %s", tt.domain, got, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestBuildAnyLookupConditions(t *testing.T) {
+	bpp := func(city string) *model.Subscription {
+		return &model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBPP, Location: &model.Location{City: &model.City{Code: city}}}}
+	}
+	tests := []struct {
+		name    string
+		filters []*model.Subscription
+		wantSQL string
+	}{
+		{"no filters", nil, ""},
+		{"single filter", []*model.Subscription{{Subscriber: model.Subscriber{Type: model.RoleBPP}}}, `"type" = 'BPP'`},
+		{
+			name:    "several cities",
+			filters: []*model.Subscription{bpp("std:080"), bpp("std:022")},
+			wantSQL: `(("type" = 'BPP') AND ("city_code" = 'std:080')) OR (("type" = 'BPP') AND ("city_code" = 'std:022'))`,
+		},
+		{"filter matching everything", []*model.Subscription{bpp("std:080"), {}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := buildAnyLookupConditions(tt.filters)
+			if tt.wantSQL == "" {
+				if len(conditions) != 0 {
+					t.Errorf("buildAnyLookupConditions() = %v, want no conditions", conditions)
+				}
+				return
+			}
+			sql, _, err := goqu.From("temp").Where(conditions...).ToSQL()
+			if err != nil {
+				t.Fatalf("ToSQL() error = %v", err)
+			}
+			if got := extractWhereClause(sql); got != tt.wantSQL {
+				t.Errorf("buildAnyLookupConditions() = %s, want %s", got, tt.wantSQL)
+			}
+		})
+	}
+}
+
 func TestBuildLookupConditions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1578,6 +1756,33 @@ func TestBuildLookupConditions(t *testing.T) {
 				goqu.C("key_id").Eq("key_all"),
 			},
 		},
+		{
+			name: "Domain prefix wildcard filter",
+			filter: &model.Subscription{
+				Subscriber: model.Subscriber{Domain: "nic2004:*"},
+			},
+			expected: []goqu.Expression{
+				goqu.C("domain").Like("nic2004:%"),
+			},
+		},
+		{
+			name: "Domain match-all wildcard filter",
+			filter: &model.Subscription{
+				Subscriber: model.Subscriber{Domain: "*"},
+			},
+			expected: []goqu.Expression{},
+		},
+		{
+			name: "Labels filter",
+			filter: &model.Subscription{
+				Subscriber: model.Subscriber{Type: model.RoleBPP},
+				Labels:     model.Labels{"pilot", "tier-1"},
+			},
+			expected: []goqu.Expression{
+				goqu.C("type").Eq("BPP"),
+				goqu.L("labels @> ?::jsonb", `["pilot","tier-1"]`),
+			},
+		},
 		{
 			name: "Filter with basic Location fields",
 			filter: &model.Subscription{
@@ -1591,10 +1796,7 @@ func TestBuildLookupConditions(t *testing.T) {
 				},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->>'id'").Eq("loc_id"),
-				goqu.L("location->>'map_url'").Eq("http://map.test"),
-				goqu.L("location->>'address'").Eq("123 Main St"),
-				goqu.L("location->>'area_code'").Eq("100001"),
+				goqu.L("location @> ?::jsonb", `{"address":"123 Main St","area_code":"100001","id":"loc_id","map_url":"http://map.test"}`),
 			},
 		},
 		{
@@ -1608,9 +1810,8 @@ func TestBuildLookupConditions(t *testing.T) {
 				},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->'city'->>'name'").Eq("Mumbai"),
-				goqu.L("location->'city'->>'code'").Eq("MH"),
-				goqu.L("location->'state'->>'name'").Eq("Maharashtra"),
+				goqu.C("city_code").Eq("MH"),
+				goqu.L("location @> ?::jsonb", `{"city":{"name":"Mumbai"},"state":{"name":"Maharashtra"}}`),
 			},
 		},
 		{
@@ -1633,20 +1834,9 @@ func TestBuildLookupConditions(t *testing.T) {
 				},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->>'id'").Eq("L1"),
-				goqu.L("location->>'map_url'").Eq("http://map.com"),
-				goqu.L("location->>'address'").Eq("addr1"),
-				goqu.L("location->>'district'").Eq("dist1"),
-				goqu.L("location->>'area_code'").Eq("code1"),
-				goqu.L("location->>'polygon'").Eq("poly1"),
-				goqu.L("location->>'3dspace'").Eq("3d1"),
-				goqu.L("location->>'rating'").Eq("5"),
-				goqu.L("location->'city'->>'name'").Eq("C1"),
-				goqu.L("location->'city'->>'code'").Eq("CC1"),
-				goqu.L("location->'state'->>'name'").Eq("S1"),
-				goqu.L("location->'state'->>'code'").Eq("SC1"),
-				goqu.L("location->'country'->>'name'").Eq("CO1"),
-				goqu.L("location->'country'->>'code'").Eq("COC1"),
+				goqu.C("city_code").Eq("CC1"),
+				goqu.C("country_code").Eq("COC1"),
+				goqu.L("location @> ?::jsonb", `{"3dspace":"3d1","address":"addr1","area_code":"code1","city":{"name":"C1"},"country":{"name":"CO1"},"district":"dist1","id":"L1","map_url":"http://map.com","polygon":"poly1","rating":"5","state":{"code":"SC1","name":"S1"}}`),
 			},
 		},
 		{
@@ -1666,8 +1856,7 @@ func TestBuildLookupConditions(t *testing.T) {
 				goqu.C("subscriber_id").Eq("hybrid_sub"),
 				goqu.C("type").Eq("BG"),
 				goqu.C("status").Eq("UNDER_SUBSCRIPTION"),
-				goqu.L("location->>'address'").Eq("Hybrid Address"),
-				goqu.L("location->'city'->>'name'").Eq("Hybrid City"),
+				goqu.L("location @> ?::jsonb", `{"address":"Hybrid Address","city":{"name":"Hybrid City"}}`),
 			},
 		},
 	}
@@ -1731,14 +1920,7 @@ func TestBuildLocationConditions(t *testing.T) {
 				Rating:      "4.5",
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->>'id'").Eq("some_id"),
-				goqu.L("location->>'map_url'").Eq("http://map.test"),
-				goqu.L("location->>'address'").Eq("Test Address"),
-				goqu.L("location->>'district'").Eq("Test District"),
-				goqu.L("location->>'area_code'").Eq("123456"),
-				goqu.L("location->>'polygon'").Eq("polygon_data"),
-				goqu.L("location->>'3dspace'").Eq("3d_space_data"),
-				goqu.L("location->>'rating'").Eq("4.5"),
+				goqu.L("location @> ?::jsonb", `{"3dspace":"3d_space_data","address":"Test Address","area_code":"123456","district":"Test District","id":"some_id","map_url":"http://map.test","polygon":"polygon_data","rating":"4.5"}`),
 			},
 		},
 		{
@@ -1747,8 +1929,8 @@ func TestBuildLocationConditions(t *testing.T) {
 				City: &model.City{Name: "Test City", Code: "TC"},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->'city'->>'name'").Eq("Test City"),
-				goqu.L("location->'city'->>'code'").Eq("TC"),
+				goqu.C("city_code").Eq("TC"),
+				goqu.L("location @> ?::jsonb", `{"city":{"name":"Test City"}}`),
 			},
 		},
 		{
@@ -1757,8 +1939,7 @@ func TestBuildLocationConditions(t *testing.T) {
 				State: &model.State{Name: "Test State", Code: "TS"},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->'state'->>'name'").Eq("Test State"),
-				goqu.L("location->'state'->>'code'").Eq("TS"),
+				goqu.L("location @> ?::jsonb", `{"state":{"code":"TS","name":"Test State"}}`),
 			},
 		},
 		{
@@ -1767,8 +1948,8 @@ func TestBuildLocationConditions(t *testing.T) {
 				Country: &model.Country{Name: "Test Country", Code: "TCtry"},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->'country'->>'name'").Eq("Test Country"),
-				goqu.L("location->'country'->>'code'").Eq("TCtry"),
+				goqu.C("country_code").Eq("TCtry"),
+				goqu.L("location @> ?::jsonb", `{"country":{"name":"Test Country"}}`),
 			},
 		},
 		{
@@ -1779,9 +1960,8 @@ func TestBuildLocationConditions(t *testing.T) {
 				Country: &model.Country{Code: "IN"},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->>'address'").Eq("Combined Address"),
-				goqu.L("location->'city'->>'name'").Eq("Combined City"),
-				goqu.L("location->'country'->>'code'").Eq("IN"),
+				goqu.L("location @> ?::jsonb", `{"address":"Combined Address","city":{"name":"Combined City"}}`),
+				goqu.C("country_code").Eq("IN"),
 			},
 		},
 		{
@@ -1792,8 +1972,8 @@ func TestBuildLocationConditions(t *testing.T) {
 				Country: &model.Country{Code: "US"},
 			},
 			expected: []goqu.Expression{
-				goqu.L("location->'city'->>'name'").Eq("Partial City"),
-				goqu.L("location->'country'->>'code'").Eq("US"),
+				goqu.L("location @> ?::jsonb", `{"city":{"name":"Partial City"}}`),
+				goqu.C("country_code").Eq("US"),
 			},
 		},
 	}
@@ -1980,6 +2160,11 @@ func TestRegistry_GetOperation_Success(t *testing.T) {
 	requestJSON, _ := json.Marshal(map[string]string{"req": "data"})
 	resultJSON, _ := json.Marshal(map[string]string{"res": "data"})
 	errorDataJSON, _ := json.Marshal(map[string]string{"err": "detail"})
+	probeJSON, _ := json.Marshal(model.URLProbeResult{URL: "https://np.com", Reachable: true})
+	review := &model.OperationReview{Reviewer: "admin@example.com", Action: model.OperationActionRejectSubscription, Comment: "bad url", ReviewedAt: now.UTC()}
+	reviewJSON, _ := json.Marshal(review)
+	comments := []model.OperationComment{{ID: "c-1", Author: "admin@example.com", Text: "KYC checked", Attachments: []string{"gs://kyc-docs/np/pan.pdf"}, CreatedAt: now.UTC()}}
+	commentsJSON, _ := json.Marshal(comments)
 
 	expectedLRO := &model.LRO{
 		OperationID:   opID,
@@ -1988,12 +2173,15 @@ func TestRegistry_GetOperation_Success(t *testing.T) {
 		RequestJSON:   requestJSON,
 		ResultJSON:    resultJSON,
 		ErrorDataJSON: errorDataJSON,
+		ProbeJSON:     probeJSON,
+		Review:        review,
+		Comments:      comments,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
 
-	rows := sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "created_at", "updated_at"}).
-		AddRow(expectedLRO.OperationID, expectedLRO.Status, expectedLRO.Type, expectedLRO.RequestJSON, expectedLRO.ResultJSON, expectedLRO.ErrorDataJSON, expectedLRO.CreatedAt, expectedLRO.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "probe_json", "review_json", "comments_json", "created_at", "updated_at"}).
+		AddRow(expectedLRO.OperationID, expectedLRO.Status, expectedLRO.Type, expectedLRO.RequestJSON, expectedLRO.ResultJSON, expectedLRO.ErrorDataJSON, expectedLRO.ProbeJSON, reviewJSON, commentsJSON, expectedLRO.CreatedAt, expectedLRO.UpdatedAt)
 
 	mock.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).
 		WithArgs(opID).
@@ -2033,8 +2221,8 @@ func TestRegistry_GetOperation_Success(t *testing.T) {
 			UpdatedAt:     now,
 		}
 
-		rowsNullErr := sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "created_at", "updated_at"}).
-			AddRow(expectedLRONullError.OperationID, expectedLRONullError.Status, expectedLRONullError.Type, expectedLRONullError.RequestJSON, expectedLRONullError.ResultJSON, nil, expectedLRONullError.CreatedAt, expectedLRONullError.UpdatedAt)
+		rowsNullErr := sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "probe_json", "review_json", "comments_json", "created_at", "updated_at"}).
+			AddRow(expectedLRONullError.OperationID, expectedLRONullError.Status, expectedLRONullError.Type, expectedLRONullError.RequestJSON, expectedLRONullError.ResultJSON, nil, nil, nil, nil, expectedLRONullError.CreatedAt, expectedLRONullError.UpdatedAt)
 
 		mockNullErr.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).
 			WithArgs(opIDNullErr).
@@ -2053,6 +2241,40 @@ func TestRegistry_GetOperation_Success(t *testing.T) {
 	})
 }
 
+func TestRegistry_GetOperation_PendingSecondApproval(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer mockDB.Close()
+	r, err := NewRegistry(mockDB)
+	if err != nil {
+		t.Fatalf("NewRegistry failed: %v", err)
+	}
+
+	now := time.Now().UTC()
+	reviewJSON, _ := json.Marshal(&model.OperationReview{
+		Reviewer:   "alice",
+		Action:     model.OperationActionApproveSubscription,
+		ReviewedAt: now,
+		Approvals:  []model.OperationApproval{{Approver: "alice", ApprovedAt: now}},
+	})
+	rows := sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "probe_json", "review_json", "comments_json", "created_at", "updated_at"}).
+		AddRow("op-1", model.LROStatusPending, model.OperationTypeUpdateSubscription, []byte(`{}`), nil, nil, nil, reviewJSON, nil, now, now)
+	mock.ExpectQuery(regexp.QuoteMeta(getOperationQuery)).WithArgs("op-1").WillReturnRows(rows)
+
+	lro, err := r.GetOperation(context.Background(), "op-1")
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	if lro.SubState != model.LROSubStatePendingSecondApproval {
+		t.Errorf("GetOperation() SubState = %q, want %q", lro.SubState, model.LROSubStatePendingSecondApproval)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
 func TestRegistry_GetOperation_Failure(t *testing.T) {
 	ctx := context.Background()
 	opID := "test-op-id-fail"
@@ -2115,3 +2337,2040 @@ func TestRegistry_GetOperation_Failure(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistry_ListStaleOperations(t *testing.T) {
+	ctx := context.Background()
+	before := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	created := before.Add(-48 * time.Hour)
+	cols := []string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "comments_json", "retry_count", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		rows := sqlmock.NewRows(cols).
+			AddRow("op-1", model.LROStatusPending, model.OperationTypeCreateSubscription, []byte(`{"a":1}`), nil, nil, nil, 0, created, created).
+			AddRow("op-2", model.LROStatusPending, model.OperationTypeUpdateSubscription, []byte(`{"b":2}`), nil, `{"error":"x"}`, `[{"id":"c-1","text":"awaiting documents","created_at":"2025-05-30T00:00:00Z"}]`, 2, created, created)
+		mock.ExpectQuery(regexp.QuoteMeta(listStaleOperationsQuery)).WithArgs(before, 10).WillReturnRows(rows)
+
+		got, err := r.ListStaleOperations(ctx, before, 10)
+		if err != nil {
+			t.Fatalf("ListStaleOperations() error = %v", err)
+		}
+		want := []model.LRO{
+			{OperationID: "op-1", Status: model.LROStatusPending, Type: model.OperationTypeCreateSubscription, RequestJSON: []byte(`{"a":1}`), CreatedAt: created, UpdatedAt: created},
+			{OperationID: "op-2", Status: model.LROStatusPending, Type: model.OperationTypeUpdateSubscription, RequestJSON: []byte(`{"b":2}`), ErrorDataJSON: []byte(`{"error":"x"}`), RetryCount: 2, CreatedAt: created, UpdatedAt: created,
+				Comments: []model.OperationComment{{ID: "c-1", Text: "awaiting documents", CreatedAt: created}}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListStaleOperations() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listStaleOperationsQuery)).WithArgs(before, 10).WillReturnError(errors.New("db error"))
+
+		if _, err := r.ListStaleOperations(ctx, before, 10); err == nil {
+			t.Error("ListStaleOperations() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_ExpireOperation(t *testing.T) {
+	ctx := context.Background()
+	before := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := before.Add(time.Hour)
+	errData := []byte(`{"reason":"expired"}`)
+
+	tests := []struct {
+		name    string
+		lro     *model.LRO
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			lro:  &model.LRO{OperationID: "op-1", Status: model.LROStatusRejected, ErrorDataJSON: errData},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(expireOperationQuery)).
+					WithArgs("op-1", model.LROStatusRejected, string(errData), before).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(before, now))
+			},
+		},
+		{
+			name: "no longer pending",
+			lro:  &model.LRO{OperationID: "op-1", Status: model.LROStatusStale, ErrorDataJSON: errData},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(expireOperationQuery)).
+					WithArgs("op-1", model.LROStatusStale, string(errData), before).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrOperationNotPending,
+		},
+		{
+			name:    "nil lro",
+			setup:   func(mock sqlmock.Sqlmock) {},
+			wantErr: ErrLROIsNil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			got, err := r.ExpireOperation(ctx, tt.lro, before)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExpireOperation() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !got.UpdatedAt.Equal(now) {
+				t.Errorf("ExpireOperation() UpdatedAt = %v, want %v", got.UpdatedAt, now)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_ReserveNonce(t *testing.T) {
+	ctx := context.Background()
+	windowStart := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveNonceQuery)).
+					WithArgs("nonce-1", "sub-1", "op-1", windowStart).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(windowStart.Add(time.Hour)))
+			},
+		},
+		{
+			name: "replayed",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveNonceQuery)).
+					WithArgs("nonce-1", "sub-1", "op-1", windowStart).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNonceReplayed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			err := r.ReserveNonce(ctx, "nonce-1", "sub-1", "op-1", windowStart)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReserveNonce() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(reserveNonceQuery)).WillReturnError(errors.New("db down"))
+
+		err := r.ReserveNonce(ctx, "nonce-1", "sub-1", "op-1", windowStart)
+		if err == nil || errors.Is(err, ErrNonceReplayed) {
+			t.Errorf("ReserveNonce() error = %v, want a non-replay error", err)
+		}
+	})
+}
+
+func TestRegistry_ConsumeNonce(t *testing.T) {
+	ctx := context.Background()
+	issuedAfter := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	consumeMiss := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta(consumeNonceQuery)).
+			WithArgs("nonce-1", "op-1", issuedAfter).
+			WillReturnError(sql.ErrNoRows)
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(consumeNonceQuery)).
+					WithArgs("nonce-1", "op-1", issuedAfter).
+					WillReturnRows(sqlmock.NewRows([]string{"consumed_at"}).AddRow(issuedAfter.Add(time.Hour)))
+			},
+		},
+		{
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				consumeMiss(mock)
+				mock.ExpectQuery(regexp.QuoteMeta(getNonceQuery)).WithArgs("nonce-1").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNonceNotFound,
+		},
+		{
+			name: "reserved by another operation",
+			setup: func(mock sqlmock.Sqlmock) {
+				consumeMiss(mock)
+				mock.ExpectQuery(regexp.QuoteMeta(getNonceQuery)).WithArgs("nonce-1").
+					WillReturnRows(sqlmock.NewRows([]string{"operation_id", "created_at", "consumed_at"}).AddRow("op-2", issuedAfter.Add(time.Hour), nil))
+			},
+			wantErr: ErrNonceReplayed,
+		},
+		{
+			name: "expired",
+			setup: func(mock sqlmock.Sqlmock) {
+				consumeMiss(mock)
+				mock.ExpectQuery(regexp.QuoteMeta(getNonceQuery)).WithArgs("nonce-1").
+					WillReturnRows(sqlmock.NewRows([]string{"operation_id", "created_at", "consumed_at"}).AddRow("op-1", issuedAfter.Add(-time.Hour), nil))
+			},
+			wantErr: ErrNonceExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			err := r.ConsumeNonce(ctx, "nonce-1", "op-1", issuedAfter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConsumeNonce() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_SetOperationProbe(t *testing.T) {
+	ctx := context.Background()
+	probe := json.RawMessage(`{"url":"https://np.com","reachable":true}`)
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(setOperationProbeQuery)).
+					WithArgs("op-1", string(probe)).
+					WillReturnRows(sqlmock.NewRows([]string{"operation_id"}).AddRow("op-1"))
+			},
+		},
+		{
+			name: "operation not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(setOperationProbeQuery)).
+					WithArgs("op-1", string(probe)).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrOperationNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			if err := r.SetOperationProbe(ctx, "op-1", probe); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetOperationProbe() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_AddOperationComment(t *testing.T) {
+	ctx := context.Background()
+	comment := &model.OperationComment{ID: "c-1", Author: "alice", Text: "PAN verified", Attachments: []string{"gs://kyc/np1/pan.pdf"}, CreatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+	commentJSON := `{"id":"c-1","author":"alice","text":"PAN verified","attachments":["gs://kyc/np1/pan.pdf"],"created_at":"2025-06-01T00:00:00Z"}`
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(addOperationCommentQuery)).
+					WithArgs("op-1", commentJSON).
+					WillReturnRows(sqlmock.NewRows([]string{"operation_id"}).AddRow("op-1"))
+			},
+		},
+		{
+			name: "operation not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(addOperationCommentQuery)).
+					WithArgs("op-1", commentJSON).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrOperationNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			if err := r.AddOperationComment(ctx, "op-1", comment); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddOperationComment() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_InsertAPIKey(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertAPIKeyQuery)).
+			WithArgs("key-1", "sub-1", "hash-1").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(created))
+
+		got, err := r.InsertAPIKey(ctx, &model.APIKey{KeyID: "key-1", SubscriberID: "sub-1", Hash: "hash-1"})
+		if err != nil {
+			t.Fatalf("InsertAPIKey() error = %v", err)
+		}
+		want := &model.APIKey{KeyID: "key-1", SubscriberID: "sub-1", Hash: "hash-1", CreatedAt: created}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("InsertAPIKey() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("nil key", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.InsertAPIKey(ctx, nil); !errors.Is(err, ErrAPIKeyIsNil) {
+			t.Errorf("InsertAPIKey() error = %v, want %v", err, ErrAPIKeyIsNil)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertAPIKeyQuery)).WillReturnError(errors.New("db down"))
+		if _, err := r.InsertAPIKey(ctx, &model.APIKey{KeyID: "key-1"}); err == nil {
+			t.Error("InsertAPIKey() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_GetAPIKeyByHash(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"key_id", "subscriber_id", "key_hash", "created_at"}
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		want    *model.APIKey
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getAPIKeyByHashQuery)).WithArgs("hash-1").
+					WillReturnRows(sqlmock.NewRows(cols).AddRow("key-1", "sub-1", "hash-1", created))
+			},
+			want: &model.APIKey{KeyID: "key-1", SubscriberID: "sub-1", Hash: "hash-1", CreatedAt: created},
+		},
+		{
+			name: "not found or revoked",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getAPIKeyByHashQuery)).WithArgs("hash-1").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			got, err := r.GetAPIKeyByHash(ctx, "hash-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetAPIKeyByHash() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetAPIKeyByHash() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegistry_ListAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	revoked := created.Add(time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		rows := sqlmock.NewRows([]string{"key_id", "subscriber_id", "created_at", "revoked_at"}).
+			AddRow("key-1", "sub-1", created, revoked).
+			AddRow("key-2", "sub-1", created, nil)
+		mock.ExpectQuery(regexp.QuoteMeta(listAPIKeysQuery)).WithArgs("sub-1").WillReturnRows(rows)
+
+		got, err := r.ListAPIKeys(ctx, "sub-1")
+		if err != nil {
+			t.Fatalf("ListAPIKeys() error = %v", err)
+		}
+		want := []model.APIKey{
+			{KeyID: "key-1", SubscriberID: "sub-1", CreatedAt: created, RevokedAt: &revoked},
+			{KeyID: "key-2", SubscriberID: "sub-1", CreatedAt: created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListAPIKeys() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listAPIKeysQuery)).WillReturnError(errors.New("db error"))
+		if _, err := r.ListAPIKeys(ctx, "sub-1"); err == nil {
+			t.Error("ListAPIKeys() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_RevokeAPIKey(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(revokeAPIKeyQuery)).WithArgs("key-1", "sub-1").
+					WillReturnRows(sqlmock.NewRows([]string{"revoked_at"}).AddRow(time.Now()))
+			},
+		},
+		{
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(revokeAPIKeyQuery)).WithArgs("key-1", "sub-1").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			if err := r.RevokeAPIKey(ctx, "sub-1", "key-1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RevokeAPIKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_ListSubscriberOperations(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "retry_count", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		rows := sqlmock.NewRows(cols).
+			AddRow("op-2", model.LROStatusApproved, model.OperationTypeUpdateSubscription, []byte(`{"subscriber_id":"sub-1"}`), `{"ok":true}`, nil, 0, created, created).
+			AddRow("op-1", model.LROStatusPending, model.OperationTypeCreateSubscription, []byte(`{"subscriber_id":"sub-1"}`), nil, nil, 0, created, created)
+		mock.ExpectQuery(regexp.QuoteMeta(listSubscriberOperationsQuery)).WithArgs("sub-1", 20).WillReturnRows(rows)
+
+		got, err := r.ListSubscriberOperations(ctx, "sub-1", 20)
+		if err != nil {
+			t.Fatalf("ListSubscriberOperations() error = %v", err)
+		}
+		want := []model.LRO{
+			{OperationID: "op-2", Status: model.LROStatusApproved, Type: model.OperationTypeUpdateSubscription, RequestJSON: []byte(`{"subscriber_id":"sub-1"}`), ResultJSON: []byte(`{"ok":true}`), CreatedAt: created, UpdatedAt: created},
+			{OperationID: "op-1", Status: model.LROStatusPending, Type: model.OperationTypeCreateSubscription, RequestJSON: []byte(`{"subscriber_id":"sub-1"}`), CreatedAt: created, UpdatedAt: created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListSubscriberOperations() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listSubscriberOperationsQuery)).WillReturnError(errors.New("db error"))
+		if _, err := r.ListSubscriberOperations(ctx, "sub-1", 20); err == nil {
+			t.Error("ListSubscriberOperations() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_CountPendingOperations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).WithArgs("sub-1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		got, err := r.CountPendingOperations(ctx, "sub-1")
+		if err != nil {
+			t.Fatalf("CountPendingOperations() error = %v", err)
+		}
+		if got != 2 {
+			t.Errorf("CountPendingOperations() = %d, want 2", got)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).WillReturnError(errors.New("db error"))
+		if _, err := r.CountPendingOperations(ctx, "sub-1"); err == nil {
+			t.Error("CountPendingOperations() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_IncrementQuotaCounter(t *testing.T) {
+	ctx := context.Background()
+	window := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(incrementQuotaCounterQuery)).
+			WithArgs("operations", "sub-1", "hour", window).
+			WillReturnRows(sqlmock.NewRows([]string{"request_count"}).AddRow(3))
+
+		got, err := r.IncrementQuotaCounter(ctx, "operations", "sub-1", "hour", window)
+		if err != nil {
+			t.Fatalf("IncrementQuotaCounter() error = %v", err)
+		}
+		if got != 3 {
+			t.Errorf("IncrementQuotaCounter() = %d, want 3", got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(incrementQuotaCounterQuery)).WillReturnError(errors.New("db error"))
+		if _, err := r.IncrementQuotaCounter(ctx, "operations", "sub-1", "hour", window); err == nil || !strings.Contains(err.Error(), "failed to increment operations quota counter of sub-1") {
+			t.Errorf("IncrementQuotaCounter() error = %v, want increment error", err)
+		}
+	})
+}
+
+func TestRegistry_DeleteQuotaCounters(t *testing.T) {
+	ctx := context.Background()
+	before := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuotaCountersQuery)).WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 4))
+
+		got, err := r.DeleteQuotaCounters(ctx, before)
+		if err != nil {
+			t.Fatalf("DeleteQuotaCounters() error = %v", err)
+		}
+		if got != 4 {
+			t.Errorf("DeleteQuotaCounters() = %d, want 4", got)
+		}
+	})
+
+	t.Run("exec error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuotaCountersQuery)).WillReturnError(errors.New("db error"))
+		if _, err := r.DeleteQuotaCounters(ctx, before); err == nil {
+			t.Error("DeleteQuotaCounters() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_InsertWebhook(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertWebhookQuery)).
+			WithArgs("hook-1", "https://example.com/hook", []byte(`["SUBSCRIPTION_REQUEST_APPROVED"]`), "secret").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(created))
+
+		hook := &model.Webhook{ID: "hook-1", URL: "https://example.com/hook", EventTypes: []model.EventType{model.EventTypeSubscriptionRequestApproved}, Secret: "secret"}
+		got, err := r.InsertWebhook(ctx, hook)
+		if err != nil {
+			t.Fatalf("InsertWebhook() error = %v", err)
+		}
+		if got.CreatedAt != created {
+			t.Errorf("InsertWebhook() CreatedAt = %v, want %v", got.CreatedAt, created)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("nil webhook", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.InsertWebhook(ctx, nil); !errors.Is(err, ErrWebhookIsNil) {
+			t.Errorf("InsertWebhook() error = %v, want %v", err, ErrWebhookIsNil)
+		}
+	})
+}
+
+func TestRegistry_ListWebhooks(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"webhook_id", "url", "event_types", "secret", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		rows := sqlmock.NewRows(cols).
+			AddRow("hook-1", "https://example.com/a", []byte(`["SUBSCRIPTION_REQUEST_REJECTED"]`), "s1", created).
+			AddRow("hook-2", "https://example.com/b", []byte(`null`), "s2", created)
+		mock.ExpectQuery(regexp.QuoteMeta(selectWebhooksQuery + " ORDER BY created_at")).WillReturnRows(rows)
+
+		got, err := r.ListWebhooks(ctx)
+		if err != nil {
+			t.Fatalf("ListWebhooks() error = %v", err)
+		}
+		want := []model.Webhook{
+			{ID: "hook-1", URL: "https://example.com/a", EventTypes: []model.EventType{model.EventTypeSubscriptionRequestRejected}, Secret: "s1", CreatedAt: created},
+			{ID: "hook-2", URL: "https://example.com/b", Secret: "s2", CreatedAt: created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListWebhooks() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(selectWebhooksQuery)).WillReturnError(errors.New("db error"))
+		if _, err := r.ListWebhooks(ctx); err == nil {
+			t.Error("ListWebhooks() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_GetWebhook(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		want    *model.Webhook
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(selectWebhooksQuery + " WHERE webhook_id = $1")).WithArgs("hook-1").
+					WillReturnRows(sqlmock.NewRows([]string{"webhook_id", "url", "event_types", "secret", "created_at"}).AddRow("hook-1", "https://example.com/a", []byte(`[]`), "s1", created))
+			},
+			want: &model.Webhook{ID: "hook-1", URL: "https://example.com/a", EventTypes: []model.EventType{}, Secret: "s1", CreatedAt: created},
+		},
+		{
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(selectWebhooksQuery)).WithArgs("hook-1").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrWebhookNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			got, err := r.GetWebhook(ctx, "hook-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetWebhook() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegistry_DeleteWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		result  driver.Result
+		wantErr error
+	}{
+		{name: "success", result: sqlmock.NewResult(0, 1)},
+		{name: "not found", result: sqlmock.NewResult(0, 0), wantErr: ErrWebhookNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			mock.ExpectExec(regexp.QuoteMeta(deleteWebhookQuery)).WithArgs("hook-1").WillReturnResult(tt.result)
+
+			if err := r.DeleteWebhook(ctx, "hook-1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_UpdateSigningKey(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "success"},
+		{name: "db error", err: errors.New("db error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			exp := mock.ExpectExec(regexp.QuoteMeta(updateSigningKeyQuery)).WithArgs("registry.example.com", "reg-key", "signing-public")
+			if tt.err != nil {
+				exp.WillReturnError(tt.err)
+			} else {
+				exp.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err := r.UpdateSigningKey(ctx, "registry.example.com", "reg-key", "signing-public")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateSigningKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_InsertWebhookDelivery(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	mock.ExpectQuery(regexp.QuoteMeta(insertWebhookDeliveryQuery)).
+		WithArgs("del-1", "hook-1", model.EventTypeSubscriptionRequestApproved, "op-1", []byte(`{}`), model.WebhookDeliveryStatusPending, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(created, created))
+
+	d := &model.WebhookDelivery{ID: "del-1", WebhookID: "hook-1", EventType: model.EventTypeSubscriptionRequestApproved, OperationID: "op-1", Payload: []byte(`{}`), Status: model.WebhookDeliveryStatusPending}
+	got, err := r.InsertWebhookDelivery(ctx, d)
+	if err != nil {
+		t.Fatalf("InsertWebhookDelivery() error = %v", err)
+	}
+	if got.CreatedAt != created || got.UpdatedAt != created {
+		t.Errorf("InsertWebhookDelivery() timestamps = %v, %v, want %v", got.CreatedAt, got.UpdatedAt, created)
+	}
+	if _, err := r.InsertWebhookDelivery(ctx, nil); !errors.Is(err, ErrWebhookDeliveryIsNil) {
+		t.Errorf("InsertWebhookDelivery(nil) error = %v, want %v", err, ErrWebhookDeliveryIsNil)
+	}
+}
+
+func TestRegistry_UpdateWebhookDelivery(t *testing.T) {
+	ctx := context.Background()
+	d := &model.WebhookDelivery{ID: "del-1", Status: model.WebhookDeliveryStatusFailed, Attempts: 3, ResponseCode: 500, LastError: "unexpected status 500"}
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(updateWebhookDeliveryQuery)).
+					WithArgs("del-1", model.WebhookDeliveryStatusFailed, 3, 500, "unexpected status 500").
+					WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+			},
+		},
+		{
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(updateWebhookDeliveryQuery)).WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrWebhookDeliveryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			if err := r.UpdateWebhookDelivery(ctx, d); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateWebhookDelivery() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_WebhookDeliveries(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"delivery_id", "webhook_id", "event_type", "operation_id", "payload", "status", "attempts", "response_code", "last_error", "created_at", "updated_at"}
+	delivered := model.WebhookDelivery{ID: "del-2", WebhookID: "hook-1", EventType: model.EventTypeSubscriptionRequestApproved, OperationID: "op-2", Payload: []byte(`{"a":1}`), Status: model.WebhookDeliveryStatusDelivered, Attempts: 1, ResponseCode: 200, CreatedAt: created, UpdatedAt: created}
+	failed := model.WebhookDelivery{ID: "del-1", WebhookID: "hook-1", EventType: model.EventTypeSubscriptionRequestRejected, OperationID: "op-1", Payload: []byte(`{"a":2}`), Status: model.WebhookDeliveryStatusFailed, Attempts: 5, LastError: "connection refused", CreatedAt: created, UpdatedAt: created}
+
+	t.Run("list", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		rows := sqlmock.NewRows(cols).
+			AddRow("del-2", "hook-1", model.EventTypeSubscriptionRequestApproved, "op-2", []byte(`{"a":1}`), model.WebhookDeliveryStatusDelivered, 1, 200, nil, created, created).
+			AddRow("del-1", "hook-1", model.EventTypeSubscriptionRequestRejected, "op-1", []byte(`{"a":2}`), model.WebhookDeliveryStatusFailed, 5, nil, "connection refused", created, created)
+		mock.ExpectQuery(regexp.QuoteMeta(selectWebhookDeliveriesQuery+" WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2")).WithArgs("hook-1", 20).WillReturnRows(rows)
+
+		got, err := r.ListWebhookDeliveries(ctx, "hook-1", 20)
+		if err != nil {
+			t.Fatalf("ListWebhookDeliveries() error = %v", err)
+		}
+		if diff := cmp.Diff([]model.WebhookDelivery{delivered, failed}, got); diff != "" {
+			t.Errorf("ListWebhookDeliveries() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		rows := sqlmock.NewRows(cols).
+			AddRow("del-2", "hook-1", model.EventTypeSubscriptionRequestApproved, "op-2", []byte(`{"a":1}`), model.WebhookDeliveryStatusDelivered, 1, 200, nil, created, created)
+		mock.ExpectQuery(regexp.QuoteMeta(selectWebhookDeliveriesQuery+" WHERE webhook_id = $1 AND delivery_id = $2")).WithArgs("hook-1", "del-2").WillReturnRows(rows)
+
+		got, err := r.GetWebhookDelivery(ctx, "hook-1", "del-2")
+		if err != nil {
+			t.Fatalf("GetWebhookDelivery() error = %v", err)
+		}
+		if diff := cmp.Diff(&delivered, got); diff != "" {
+			t.Errorf("GetWebhookDelivery() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(selectWebhookDeliveriesQuery)).WillReturnError(sql.ErrNoRows)
+
+		if _, err := r.GetWebhookDelivery(ctx, "hook-1", "del-2"); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+			t.Errorf("GetWebhookDelivery() error = %v, want %v", err, ErrWebhookDeliveryNotFound)
+		}
+	})
+}
+
+func TestRegistry_GetMaintenance(t *testing.T) {
+	ctx := context.Background()
+	updated := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"enabled", "message", "updated_at"}
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		want    *model.Maintenance
+		wantErr bool
+	}{
+		{
+			name: "enabled",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getMaintenanceQuery)).
+					WillReturnRows(sqlmock.NewRows(cols).AddRow(true, "migrating", updated))
+			},
+			want: &model.Maintenance{Enabled: true, Message: "migrating", UpdatedAt: updated},
+		},
+		{
+			name: "never set",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getMaintenanceQuery)).WillReturnError(sql.ErrNoRows)
+			},
+			want: &model.Maintenance{},
+		},
+		{
+			name: "db error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getMaintenanceQuery)).WillReturnError(errors.New("db down"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			got, err := r.GetMaintenance(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMaintenance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetMaintenance() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegistry_SetMaintenance(t *testing.T) {
+	ctx := context.Background()
+	updated := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(setMaintenanceQuery)).WithArgs(true, "migrating").
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updated))
+
+		got, err := r.SetMaintenance(ctx, &model.Maintenance{Enabled: true, Message: "migrating"})
+		if err != nil {
+			t.Fatalf("SetMaintenance() unexpected error: %v", err)
+		}
+		want := &model.Maintenance{Enabled: true, Message: "migrating", UpdatedAt: updated}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("SetMaintenance() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(setMaintenanceQuery)).WithArgs(false, "").WillReturnError(errors.New("db down"))
+
+		if _, err := r.SetMaintenance(ctx, &model.Maintenance{}); err == nil {
+			t.Error("SetMaintenance() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_InsertDenylistEntry(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expires := created.Add(time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertDenylistEntryQuery)).
+			WithArgs("entry-1", "IP", "10.0.0.0/8", "abuse", expires).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(created))
+
+		e := &model.DenylistEntry{ID: "entry-1", Kind: model.DenylistKindIP, Value: "10.0.0.0/8", Reason: "abuse", ExpiresAt: &expires}
+		got, err := r.InsertDenylistEntry(ctx, e)
+		if err != nil {
+			t.Fatalf("InsertDenylistEntry() unexpected error: %v", err)
+		}
+		if got.CreatedAt != created {
+			t.Errorf("InsertDenylistEntry() CreatedAt = %v, want %v", got.CreatedAt, created)
+		}
+	})
+
+	t.Run("nil entry", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.InsertDenylistEntry(ctx, nil); !errors.Is(err, ErrDenylistEntryIsNil) {
+			t.Errorf("InsertDenylistEntry() error = %v, want %v", err, ErrDenylistEntryIsNil)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertDenylistEntryQuery)).WillReturnError(errors.New("duplicate key"))
+
+		if _, err := r.InsertDenylistEntry(ctx, &model.DenylistEntry{ID: "entry-1", Kind: model.DenylistKindSubscriber, Value: "np1"}); err == nil {
+			t.Error("InsertDenylistEntry() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_ListDenylistEntries(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expires := created.Add(time.Hour)
+	cols := []string{"entry_id", "kind", "value", "reason", "created_at", "expires_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listDenylistEntriesQuery)).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("entry-1", "IP", "10.0.0.0/8", "abuse", created, expires).
+				AddRow("entry-2", "SUBSCRIBER", "np1", "", created, nil))
+
+		got, err := r.ListDenylistEntries(ctx)
+		if err != nil {
+			t.Fatalf("ListDenylistEntries() unexpected error: %v", err)
+		}
+		want := []model.DenylistEntry{
+			{ID: "entry-1", Kind: model.DenylistKindIP, Value: "10.0.0.0/8", Reason: "abuse", CreatedAt: created, ExpiresAt: &expires},
+			{ID: "entry-2", Kind: model.DenylistKindSubscriber, Value: "np1", CreatedAt: created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListDenylistEntries() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listDenylistEntriesQuery)).WillReturnError(errors.New("db down"))
+
+		if _, err := r.ListDenylistEntries(ctx); err == nil {
+			t.Error("ListDenylistEntries() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_DeleteDenylistEntry(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		result  driver.Result
+		wantErr error
+	}{
+		{name: "success", result: sqlmock.NewResult(0, 1)},
+		{name: "not found", result: sqlmock.NewResult(0, 0), wantErr: ErrDenylistEntryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			mock.ExpectExec(regexp.QuoteMeta(deleteDenylistEntryQuery)).WithArgs("entry-1").WillReturnResult(tt.result)
+
+			if err := r.DeleteDenylistEntry(ctx, "entry-1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteDenylistEntry() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_InsertDomain(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertDomainQuery)).
+			WithArgs("ONDC:RET10", "ONDC:RET", "Grocery", "CITY", "1.2.0").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(created, created))
+
+		d := &model.Domain{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0"}
+		got, err := r.InsertDomain(ctx, d)
+		if err != nil {
+			t.Fatalf("InsertDomain() unexpected error: %v", err)
+		}
+		if got.CreatedAt != created || got.UpdatedAt != created {
+			t.Errorf("InsertDomain() CreatedAt, UpdatedAt = %v, %v, want %v", got.CreatedAt, got.UpdatedAt, created)
+		}
+	})
+
+	t.Run("nil domain", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.InsertDomain(ctx, nil); !errors.Is(err, ErrDomainIsNil) {
+			t.Errorf("InsertDomain() error = %v, want %v", err, ErrDomainIsNil)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(insertDomainQuery)).WillReturnError(errors.New("duplicate key"))
+
+		if _, err := r.InsertDomain(ctx, &model.Domain{Name: "ONDC:RET10", DisplayName: "Grocery"}); err == nil {
+			t.Error("InsertDomain() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_UpdateDomain(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(updateDomainQuery)).
+			WithArgs("ONDC:RET10", "", "Grocery", "", "1.2.0").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(created, updated))
+
+		got, err := r.UpdateDomain(ctx, &model.Domain{Name: "ONDC:RET10", DisplayName: "Grocery", SchemaVersion: "1.2.0"})
+		if err != nil {
+			t.Fatalf("UpdateDomain() unexpected error: %v", err)
+		}
+		if got.CreatedAt != created || got.UpdatedAt != updated {
+			t.Errorf("UpdateDomain() CreatedAt, UpdatedAt = %v, %v, want %v, %v", got.CreatedAt, got.UpdatedAt, created, updated)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(updateDomainQuery)).WillReturnError(sql.ErrNoRows)
+
+		if _, err := r.UpdateDomain(ctx, &model.Domain{Name: "ONDC:RET10"}); !errors.Is(err, ErrDomainNotFound) {
+			t.Errorf("UpdateDomain() error = %v, want %v", err, ErrDomainNotFound)
+		}
+	})
+
+	t.Run("nil domain", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.UpdateDomain(ctx, nil); !errors.Is(err, ErrDomainIsNil) {
+			t.Errorf("UpdateDomain() error = %v, want %v", err, ErrDomainIsNil)
+		}
+	})
+}
+
+func TestRegistry_SetSubscriptionLabels(t *testing.T) {
+	ctx := context.Background()
+	updated := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(setSubscriptionLabelsQuery)).
+			WithArgs("sub1", "ONDC:RET10", model.RoleBPP, []byte(`["pilot","tier-1"]`)).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updated))
+
+		got, err := r.SetSubscriptionLabels(ctx, "sub1", "ONDC:RET10", model.RoleBPP, model.Labels{"pilot", "tier-1"})
+		if err != nil {
+			t.Fatalf("SetSubscriptionLabels() unexpected error: %v", err)
+		}
+		if got != updated {
+			t.Errorf("SetSubscriptionLabels() = %v, want %v", got, updated)
+		}
+	})
+
+	t.Run("clear labels", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(setSubscriptionLabelsQuery)).
+			WithArgs("sub1", "ONDC:RET10", model.RoleBPP, []byte(`[]`)).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updated))
+
+		if _, err := r.SetSubscriptionLabels(ctx, "sub1", "ONDC:RET10", model.RoleBPP, nil); err != nil {
+			t.Fatalf("SetSubscriptionLabels() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(setSubscriptionLabelsQuery)).WillReturnError(sql.ErrNoRows)
+
+		if _, err := r.SetSubscriptionLabels(ctx, "sub1", "ONDC:RET10", model.RoleBPP, model.Labels{"pilot"}); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("SetSubscriptionLabels() error = %v, want %v", err, ErrSubscriptionNotFound)
+		}
+	})
+}
+
+func TestRegistry_ListDomains(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"name", "parent", "display_name", "location_granularity", "schema_version", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listDomainsQuery)).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("ONDC:RET", "", "Retail", "CITY", "1.2.0", created, created).
+				AddRow("ONDC:RET10", "ONDC:RET", "Grocery", "", "", created, created))
+
+		got, err := r.ListDomains(ctx)
+		if err != nil {
+			t.Fatalf("ListDomains() unexpected error: %v", err)
+		}
+		want := []model.Domain{
+			{Name: "ONDC:RET", DisplayName: "Retail", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0", CreatedAt: created, UpdatedAt: created},
+			{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", CreatedAt: created, UpdatedAt: created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListDomains() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listDomainsQuery)).WillReturnError(errors.New("db down"))
+
+		if _, err := r.ListDomains(ctx); err == nil {
+			t.Error("ListDomains() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_DeleteDomain(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		result  driver.Result
+		wantErr error
+	}{
+		{name: "success", result: sqlmock.NewResult(0, 1)},
+		{name: "not found", result: sqlmock.NewResult(0, 0), wantErr: ErrDomainNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			mock.ExpectExec(regexp.QuoteMeta(deleteDomainQuery)).WithArgs("ONDC:RET10").WillReturnResult(tt.result)
+
+			if err := r.DeleteDomain(ctx, "ONDC:RET10"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteDomain() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_OperationStats(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(operationStatusCountsQuery)).WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+				AddRow("APPROVED", 3).
+				AddRow("REJECTED", 1).
+				AddRow("PENDING", 2))
+		mock.ExpectQuery(regexp.QuoteMeta(operationApprovalLatencyQuery)).WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "p50", "p90", "p99"}).AddRow(3, 60.0, 3600.0, 7200.0))
+		mock.ExpectQuery(regexp.QuoteMeta(operationDailyVolumeQuery)).WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "count"}).
+				AddRow("2025-06-01", 4).
+				AddRow("2025-06-03", 2))
+
+		got, err := r.OperationStats(ctx, from, to)
+		if err != nil {
+			t.Fatalf("OperationStats() unexpected error: %v", err)
+		}
+		want := &model.LROStats{
+			From:           from,
+			To:             to,
+			Total:          6,
+			ByStatus:       map[model.LROStatus]int{model.LROStatusApproved: 3, model.LROStatusRejected: 1, model.LROStatusPending: 2},
+			TimeToApproval: model.LROLatency{Count: 3, P50: 60, P90: 3600, P99: 7200},
+			Daily:          []model.LRODailyVolume{{Date: "2025-06-01", Count: 4}, {Date: "2025-06-03", Count: 2}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("OperationStats() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("no operations", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(operationStatusCountsQuery)).WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}))
+		mock.ExpectQuery(regexp.QuoteMeta(operationApprovalLatencyQuery)).WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "p50", "p90", "p99"}).AddRow(0, 0.0, 0.0, 0.0))
+		mock.ExpectQuery(regexp.QuoteMeta(operationDailyVolumeQuery)).WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "count"}))
+
+		got, err := r.OperationStats(ctx, from, to)
+		if err != nil {
+			t.Fatalf("OperationStats() unexpected error: %v", err)
+		}
+		want := &model.LROStats{From: from, To: to, ByStatus: map[model.LROStatus]int{}, Daily: []model.LRODailyVolume{}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("OperationStats() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(operationStatusCountsQuery)).WillReturnError(errors.New("db down"))
+
+		if _, err := r.OperationStats(ctx, from, to); err == nil {
+			t.Error("OperationStats() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_OperationFailureCounts(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(operationFailureCountsQuery)).WithArgs(since, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"code", "count"}).
+				AddRow("callback failed", 3).
+				AddRow("UNKNOWN", 1))
+
+		got, err := r.OperationFailureCounts(ctx, since, 10)
+		if err != nil {
+			t.Fatalf("OperationFailureCounts() error = %v", err)
+		}
+		want := []model.LROFailureCount{{Code: "callback failed", Count: 3}, {Code: "UNKNOWN", Count: 1}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("OperationFailureCounts() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(operationFailureCountsQuery)).WithArgs(since, 10).WillReturnError(errors.New("db error"))
+
+		if _, err := r.OperationFailureCounts(ctx, since, 10); err == nil || !strings.Contains(err.Error(), "failed to count operation failures") {
+			t.Errorf("OperationFailureCounts() error = %v, want query error", err)
+		}
+	})
+}
+
+func TestRegistry_ActivateSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+	from := now.Add(-time.Minute)
+	until := now.Add(365 * 24 * time.Hour)
+	cols := []string{"subscriber_id", "url", "type", "domain", "location", "key_id", "signing_public_key", "encr_public_key", "valid_from", "valid_until", "status", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(activateSubscriptionsQuery)).WithArgs(now, 10).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("np1", "https://np1.com", "BPP", "retail", nil, "key1", "signing", "encr", from, until, "SUBSCRIBED", from, now))
+
+		got, err := r.ActivateSubscriptions(ctx, now, 10)
+		if err != nil {
+			t.Fatalf("ActivateSubscriptions() error = %v", err)
+		}
+		want := []model.Subscription{{
+			Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.com", Type: model.RoleBPP, Domain: "retail"},
+			KeyID:            "key1",
+			SigningPublicKey: "signing",
+			EncrPublicKey:    "encr",
+			ValidFrom:        from,
+			ValidUntil:       until,
+			Status:           model.SubscriptionStatusSubscribed,
+			Created:          from,
+			Updated:          now,
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ActivateSubscriptions() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(activateSubscriptionsQuery)).WithArgs(now, 10).WillReturnError(errors.New("db error"))
+
+		if _, err := r.ActivateSubscriptions(ctx, now, 10); err == nil {
+			t.Error("ActivateSubscriptions() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_ListExpiringSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	before := time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+	until := before.Add(-24 * time.Hour)
+	cols := []string{"subscriber_id", "url", "type", "domain", "location", "key_id", "signing_public_key", "encr_public_key", "valid_from", "valid_until", "status", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listExpiringSubscriptionsQuery)).WithArgs(before, 5).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("np1", "https://np1.com", "BAP", "retail", nil, "key1", "signing", "encr", until, until, "SUBSCRIBED", until, until))
+
+		got, err := r.ListExpiringSubscriptions(ctx, before, 5)
+		if err != nil {
+			t.Fatalf("ListExpiringSubscriptions() error = %v", err)
+		}
+		want := []model.Subscription{{
+			Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			SigningPublicKey: "signing",
+			EncrPublicKey:    "encr",
+			ValidFrom:        until,
+			ValidUntil:       until,
+			Status:           model.SubscriptionStatusSubscribed,
+			Created:          until,
+			Updated:          until,
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListExpiringSubscriptions() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listExpiringSubscriptionsQuery)).WithArgs(before, 5).WillReturnError(errors.New("db error"))
+
+		if _, err := r.ListExpiringSubscriptions(ctx, before, 5); err == nil || !strings.Contains(err.Error(), "failed to query expiring subscriptions") {
+			t.Errorf("ListExpiringSubscriptions() error = %v, want query error", err)
+		}
+	})
+}
+
+func TestRegistry_SubscriptionsAt(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	changed := at.Add(-time.Hour)
+	validFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := validFrom.AddDate(1, 0, 0)
+	cols := []string{"change", "changed_at", "subscriber_id", "url", "type", "domain", "location", "key_id",
+		"signing_public_key", "encr_public_key", "valid_from", "valid_until", "status", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(subscriptionsAtQuery)).WithArgs("np1", at).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("UPDATE", changed, "np1", "https://np1.example.com", "BAP", "retail", nil, "key-2",
+					"signing-2", "encr-2", validFrom, validUntil, "SUBSCRIBED", validFrom, changed))
+
+		got, err := r.SubscriptionsAt(ctx, "np1", at)
+		if err != nil {
+			t.Fatalf("SubscriptionsAt() unexpected error: %v", err)
+		}
+		want := []model.SubscriptionVersion{{
+			Subscription: model.Subscription{
+				Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.example.com", Type: model.RoleBAP, Domain: "retail"},
+				KeyID:            "key-2",
+				SigningPublicKey: "signing-2",
+				EncrPublicKey:    "encr-2",
+				ValidFrom:        validFrom,
+				ValidUntil:       validUntil,
+				Status:           model.SubscriptionStatusSubscribed,
+				Created:          validFrom,
+				Updated:          changed,
+			},
+			Change:    model.SubscriptionChangeUpdate,
+			ChangedAt: changed,
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("SubscriptionsAt() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(subscriptionsAtQuery)).WithArgs("np1", at).WillReturnRows(sqlmock.NewRows(cols))
+
+		got, err := r.SubscriptionsAt(ctx, "np1", at)
+		if err != nil {
+			t.Fatalf("SubscriptionsAt() unexpected error: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("SubscriptionsAt() = %v, want no versions", got)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(subscriptionsAtQuery)).WillReturnError(errors.New("db down"))
+
+		if _, err := r.SubscriptionsAt(ctx, "np1", at); err == nil {
+			t.Error("SubscriptionsAt() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	subColumns := []string{"subscriber_id", "url", "type", "domain", "location", "key_id", "signing_public_key", "encr_public_key", "valid_from", "valid_until", "status", "created_at", "updated_at"}
+	opColumns := []string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "probe_json", "review_json", "comments_json", "retry_count", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(snapshotSubscriptionsQuery)).WillReturnRows(sqlmock.NewRows(subColumns).
+			AddRow("np1", "https://np1.com", "BAP", "retail", nil, "key1", "signing", "encr", ts, ts, "SUBSCRIBED", ts, ts))
+		mock.ExpectQuery(regexp.QuoteMeta(snapshotOperationsQuery)).WillReturnRows(sqlmock.NewRows(opColumns).
+			AddRow("op1", "APPROVED", "CREATE_SUBSCRIPTION", []byte(`{"subscriber_id":"np1"}`), `{"ok":true}`, nil, nil, `{"reviewer":"alice"}`, `[{"id":"c-1","author":"alice","attachments":["gs://kyc/np1.pdf"],"created_at":"2025-06-01T00:00:00Z"}]`, 0, ts, ts).
+			AddRow("op2", "FAILURE", "CREATE_SUBSCRIPTION", []byte(`{"nonce":"enc:v1:k1:sealed","subscriber_id":"np1"}`), nil, `{"code":"CHALLENGE_FAILED","error":"secret detail"}`, nil, nil, nil, 0, ts, ts))
+		mock.ExpectCommit()
+
+		got, err := r.Snapshot(ctx)
+		if err != nil {
+			t.Fatalf("Snapshot() error = %v", err)
+		}
+		want := &model.RegistrySnapshot{
+			Subscriptions: []model.Subscription{{
+				Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.com", Type: model.RoleBAP, Domain: "retail"},
+				KeyID:            "key1",
+				SigningPublicKey: "signing",
+				EncrPublicKey:    "encr",
+				ValidFrom:        ts,
+				ValidUntil:       ts,
+				Status:           model.SubscriptionStatusSubscribed,
+				Created:          ts,
+				Updated:          ts,
+			}},
+			Operations: []model.LRO{{
+				OperationID: "op1",
+				Status:      model.LROStatusApproved,
+				Type:        model.OperationTypeCreateSubscription,
+				RequestJSON: []byte(`{"subscriber_id":"np1"}`),
+				ResultJSON:  []byte(`{"ok":true}`),
+				Review:      &model.OperationReview{Reviewer: "alice"},
+				Comments:    []model.OperationComment{{ID: "c-1", Author: "alice", Attachments: []string{"gs://kyc/np1.pdf"}, CreatedAt: ts}},
+				CreatedAt:   ts,
+				UpdatedAt:   ts,
+			}, {
+				// The nonce is dropped and the error data reduced to its code.
+				OperationID:   "op2",
+				Status:        model.LROStatusFailure,
+				Type:          model.OperationTypeCreateSubscription,
+				RequestJSON:   []byte(`{"subscriber_id":"np1"}`),
+				ErrorDataJSON: []byte(`{"code":"CHALLENGE_FAILED"}`),
+				CreatedAt:     ts,
+				UpdatedAt:     ts,
+			}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Snapshot() mismatch (-want +got):\n%s", diff)
+		}
+		b, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if strings.Contains(string(b), "nonce") || strings.Contains(string(b), "secret detail") {
+			t.Errorf("Snapshot() JSON = %s, want no nonce or error detail", b)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("operations query error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(snapshotSubscriptionsQuery)).WillReturnRows(sqlmock.NewRows(subColumns))
+		mock.ExpectQuery(regexp.QuoteMeta(snapshotOperationsQuery)).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		if _, err := r.Snapshot(ctx); err == nil || !strings.Contains(err.Error(), "failed to query operations for snapshot") {
+			t.Errorf("Snapshot() error = %v, want operations query error", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRegistry_RestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	snap := &model.RegistrySnapshot{
+		Subscriptions: []model.Subscription{{
+			Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			SigningPublicKey: "signing",
+			EncrPublicKey:    "encr",
+			ValidFrom:        ts,
+			ValidUntil:       ts,
+			Status:           model.SubscriptionStatusSubscribed,
+			Created:          ts,
+		}},
+		Operations: []model.LRO{{
+			OperationID: "op1",
+			Status:      model.LROStatusPending,
+			Type:        model.OperationTypeCreateSubscription,
+			RequestJSON: []byte(`{"subscriber_id":"np1"}`),
+		}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(restoreSubscriptionQuery)).
+			WithArgs("np1", "https://np1.com", model.RoleBAP, "retail", sql.NullString{}, "key1", "signing", "encr", ts, ts, model.SubscriptionStatusSubscribed, sql.NullTime{Time: ts, Valid: true}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(restoreOperationQuery)).
+			WithArgs("op1", model.LROStatusPending, model.OperationTypeCreateSubscription, json.RawMessage(`{"subscriber_id":"np1"}`), sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullString{}, 0, sql.NullTime{}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := r.RestoreSnapshot(ctx, snap)
+		if err != nil {
+			t.Fatalf("RestoreSnapshot() error = %v", err)
+		}
+		if diff := cmp.Diff(&model.SnapshotRestoreReport{Subscriptions: 1, Operations: 1}, got); diff != "" {
+			t.Errorf("RestoreSnapshot() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("operation fails rolls back", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(restoreSubscriptionQuery)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(restoreOperationQuery)).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		if _, err := r.RestoreSnapshot(ctx, snap); err == nil || !strings.Contains(err.Error(), "failed to restore operation op1") {
+			t.Errorf("RestoreSnapshot() error = %v, want restore operation error", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("nil snapshot", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.RestoreSnapshot(ctx, nil); err == nil {
+			t.Error("RestoreSnapshot() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_MergeSubscribers(t *testing.T) {
+	ctx := context.Background()
+	mergedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expectMerge := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(dropMergedSubscriptionsQuery)).WithArgs("np1.com", "np1.org").
+			WillReturnRows(sqlmock.NewRows([]string{"domain", "type"}).AddRow("retail", model.RoleBAP))
+		mock.ExpectQuery(regexp.QuoteMeta(moveMergedSubscriptionsQuery)).WithArgs("np1.com", "np1.org").
+			WillReturnRows(sqlmock.NewRows([]string{"domain", "type"}).AddRow("mobility", model.RoleBAP))
+		mock.ExpectExec(regexp.QuoteMeta(moveMergedAPIKeysQuery)).WithArgs("np1.com", "np1.org").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(dropMergedDenylistQuery)).WithArgs("np1.com", "np1.org").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(moveMergedDenylistQuery)).WithArgs("np1.com", "np1.org").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(moveMergedOperationsQuery)).WithArgs("np1.com", "np1.org").WillReturnResult(sqlmock.NewResult(0, 3))
+	}
+	wantReport := func(dryRun bool) *model.SubscriberMergeReport {
+		report := &model.SubscriberMergeReport{
+			SourceSubscriberID: "np1.com",
+			TargetSubscriberID: "np1.org",
+			DryRun:             dryRun,
+			Moved:              []model.Subscriber{{SubscriberID: "np1.com", Domain: "mobility", Type: model.RoleBAP}},
+			Dropped:            []model.Subscriber{{SubscriberID: "np1.com", Domain: "retail", Type: model.RoleBAP}},
+			APIKeys:            2,
+			DenylistEntries:    1,
+			Operations:         3,
+		}
+		if !dryRun {
+			report.MergeID = "merge1"
+			report.MergedAt = mergedAt
+		}
+		return report
+	}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		expectMerge(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(insertSubscriberMergeQuery)).WithArgs("merge1", "np1.com", "np1.org", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"merged_at"}).AddRow(mergedAt))
+		mock.ExpectCommit()
+
+		got, err := r.MergeSubscribers(ctx, &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"}, "merge1")
+		if err != nil {
+			t.Fatalf("MergeSubscribers() error = %v", err)
+		}
+		if diff := cmp.Diff(wantReport(false), got); diff != "" {
+			t.Errorf("MergeSubscribers() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("dry run rolls back", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		expectMerge(mock)
+		mock.ExpectRollback()
+
+		got, err := r.MergeSubscribers(ctx, &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org", DryRun: true}, "merge1")
+		if err != nil {
+			t.Fatalf("MergeSubscribers() error = %v", err)
+		}
+		if diff := cmp.Diff(wantReport(true), got); diff != "" {
+			t.Errorf("MergeSubscribers() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("source not found", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(dropMergedSubscriptionsQuery)).WillReturnRows(sqlmock.NewRows([]string{"domain", "type"}))
+		mock.ExpectQuery(regexp.QuoteMeta(moveMergedSubscriptionsQuery)).WillReturnRows(sqlmock.NewRows([]string{"domain", "type"}))
+		mock.ExpectRollback()
+
+		_, err := r.MergeSubscribers(ctx, &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"}, "merge1")
+		if !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("MergeSubscribers() error = %v, want %v", err, ErrSubscriptionNotFound)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("operations fail rolls back", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(dropMergedSubscriptionsQuery)).WillReturnRows(sqlmock.NewRows([]string{"domain", "type"}))
+		mock.ExpectQuery(regexp.QuoteMeta(moveMergedSubscriptionsQuery)).WillReturnRows(sqlmock.NewRows([]string{"domain", "type"}).AddRow("retail", model.RoleBAP))
+		mock.ExpectExec(regexp.QuoteMeta(moveMergedAPIKeysQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(dropMergedDenylistQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(moveMergedDenylistQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(moveMergedOperationsQuery)).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		_, err := r.MergeSubscribers(ctx, &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"}, "merge1")
+		if err == nil || !strings.Contains(err.Error(), "failed to move operations of np1.com") {
+			t.Errorf("MergeSubscribers() error = %v, want move operations error", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("nil request", func(t *testing.T) {
+		r, _, db := newMockRegistry(t)
+		defer db.Close()
+		if _, err := r.MergeSubscribers(ctx, nil, "merge1"); err == nil {
+			t.Error("MergeSubscribers() error = nil, want error")
+		}
+	})
+}
+
+func TestRegistry_RecordChallengeAttempt(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	attempt := &model.ChallengeAttempt{OperationID: "op-1", URL: "https://np1.example.com/on_subscribe", Passed: false, Signed: true, Error: "challenge mismatch", AttemptedAt: at}
+
+	tests := []struct {
+		name    string
+		attempt *model.ChallengeAttempt
+		setup   func(sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			attempt: attempt,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(insertChallengeAttemptQuery)).
+					WithArgs("op-1", "https://np1.example.com/on_subscribe", false, true, "challenge mismatch", at).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:    "nil attempt",
+			attempt: nil,
+			setup:   func(sqlmock.Sqlmock) {},
+			wantErr: true,
+		},
+		{
+			name:    "db error",
+			attempt: attempt,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(insertChallengeAttemptQuery)).WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			if err := r.RecordChallengeAttempt(ctx, tt.attempt); (err != nil) != tt.wantErr {
+				t.Fatalf("RecordChallengeAttempt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_ListChallengeAttempts(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cols := []string{"operation_id", "url", "passed", "signed", "error", "attempted_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listChallengeAttemptsQuery)).WithArgs("op-1").
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("op-1", "https://np1.example.com/on_subscribe", false, false, "timeout", at).
+				AddRow("op-1", "https://np1.example.com/on_subscribe", true, true, "", at.Add(time.Minute)))
+
+		got, err := r.ListChallengeAttempts(ctx, "op-1")
+		if err != nil {
+			t.Fatalf("ListChallengeAttempts() unexpected error: %v", err)
+		}
+		want := []model.ChallengeAttempt{
+			{OperationID: "op-1", URL: "https://np1.example.com/on_subscribe", Error: "timeout", AttemptedAt: at},
+			{OperationID: "op-1", URL: "https://np1.example.com/on_subscribe", Passed: true, Signed: true, AttemptedAt: at.Add(time.Minute)},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListChallengeAttempts() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(listChallengeAttemptsQuery)).WithArgs("op-1").WillReturnError(errors.New("db error"))
+
+		if _, err := r.ListChallengeAttempts(ctx, "op-1"); err == nil {
+			t.Fatal("ListChallengeAttempts() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_ListOperationDeliveries(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"delivery_id", "webhook_id", "event_type", "operation_id", "payload", "status", "attempts", "response_code", "last_error", "created_at", "updated_at"}
+	query := regexp.QuoteMeta(selectWebhookDeliveriesQuery + " WHERE operation_id = $1 ORDER BY created_at")
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(query).WithArgs("op-1").WillReturnRows(sqlmock.NewRows(cols).
+			AddRow("del-1", "hook-1", model.EventTypeSubscriptionRequestApproved, "op-1", []byte(`{"a":1}`), model.WebhookDeliveryStatusDelivered, 1, 200, nil, created, created))
+
+		got, err := r.ListOperationDeliveries(ctx, "op-1")
+		if err != nil {
+			t.Fatalf("ListOperationDeliveries() unexpected error: %v", err)
+		}
+		want := []model.WebhookDelivery{{ID: "del-1", WebhookID: "hook-1", EventType: model.EventTypeSubscriptionRequestApproved, OperationID: "op-1", Payload: []byte(`{"a":1}`), Status: model.WebhookDeliveryStatusDelivered, Attempts: 1, ResponseCode: 200, CreatedAt: created, UpdatedAt: created}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ListOperationDeliveries() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(query).WithArgs("op-1").WillReturnError(errors.New("db error"))
+
+		if _, err := r.ListOperationDeliveries(ctx, "op-1"); err == nil {
+			t.Fatal("ListOperationDeliveries() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_SubscriptionChanges(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	changed := since.Add(time.Hour)
+	validFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := validFrom.AddDate(1, 0, 0)
+	cols := []string{"change", "changed_at", "subscriber_id", "url", "type", "domain", "location", "key_id",
+		"signing_public_key", "encr_public_key", "valid_from", "valid_until", "status", "created_at", "updated_at"}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(subscriptionChangesQuery)).WithArgs("np1", "retail", model.RoleBAP, "key-2", since, 20).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("INSERT", changed, "np1", "https://np1.example.com", "BAP", "retail", nil, "key-2",
+					"signing-2", "encr-2", validFrom, validUntil, "SUBSCRIBED", changed, changed))
+
+		got, err := r.SubscriptionChanges(ctx, "np1", "retail", model.RoleBAP, "key-2", since, 20)
+		if err != nil {
+			t.Fatalf("SubscriptionChanges() unexpected error: %v", err)
+		}
+		want := []model.SubscriptionVersion{{
+			Subscription: model.Subscription{
+				Subscriber:       model.Subscriber{SubscriberID: "np1", URL: "https://np1.example.com", Type: model.RoleBAP, Domain: "retail"},
+				KeyID:            "key-2",
+				SigningPublicKey: "signing-2",
+				EncrPublicKey:    "encr-2",
+				ValidFrom:        validFrom,
+				ValidUntil:       validUntil,
+				Status:           model.SubscriptionStatusSubscribed,
+				Created:          changed,
+				Updated:          changed,
+			},
+			Change:    model.SubscriptionChangeInsert,
+			ChangedAt: changed,
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("SubscriptionChanges() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(subscriptionChangesQuery)).WillReturnError(errors.New("db error"))
+
+		if _, err := r.SubscriptionChanges(ctx, "np1", "retail", model.RoleBAP, "key-2", since, 20); err == nil {
+			t.Fatal("SubscriptionChanges() expected error, got nil")
+		}
+	})
+}
+
+func TestRegistry_LookupChanges(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	changed := since.Add(time.Hour)
+	filters := []*model.Subscription{{Subscriber: model.Subscriber{Domain: "retail"}, Status: model.SubscriptionStatusSubscribed}}
+
+	t.Run("success", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "subscriptions" WHERE (("updated_at" >= '2025-06-01T00:00:00Z') AND ("domain" = 'retail') AND ("status" = 'SUBSCRIBED'))`)).
+			WillReturnRows(sqlmock.NewRows([]string{"subscriber_id", "url", "type", "domain", "key_id", "status", "updated_at"}).
+				AddRow("np1", "http://np1.com", "BPP", "retail", "key1", "SUBSCRIBED", changed))
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "subscription_history" WHERE (("change" = 'DELETE') AND ("changed_at" >= '2025-06-01T00:00:00Z') AND ` + subscriptionRecreatedCondition + ` AND ("domain" = 'retail')) UNION ALL (SELECT "subscriber_id", "type", "domain", "key_id", "updated_at" AS "deleted_at" FROM "subscriptions" WHERE (("updated_at" >= '2025-06-01T00:00:00Z') AND NOT COALESCE((("domain" = 'retail') AND ("status" = 'SUBSCRIBED')), FALSE) AND ("domain" = 'retail')))`)).
+			WillReturnRows(sqlmock.NewRows([]string{"subscriber_id", "type", "domain", "key_id", "deleted_at"}).
+				AddRow("np2", "BPP", "retail", "key2", changed).
+				AddRow("np3", "BAP", "retail", "key3", changed))
+
+		got, err := r.LookupChanges(context.Background(), since, filters)
+		if err != nil {
+			t.Fatalf("LookupChanges() error = %v", err)
+		}
+		want := &model.LookupChanges{
+			Subscriptions: []model.Subscription{
+				{Subscriber: model.Subscriber{SubscriberID: "np1", URL: "http://np1.com", Type: model.RoleBPP, Domain: "retail"}, KeyID: "key1", Status: model.SubscriptionStatusSubscribed, Updated: changed},
+			},
+			Deleted: []model.SubscriptionTombstone{
+				{SubscriberID: "np2", Type: model.RoleBPP, Domain: "retail", KeyID: "key2", DeletedAt: changed},
+				{SubscriberID: "np3", Type: model.RoleBAP, Domain: "retail", KeyID: "key3", DeletedAt: changed},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("LookupChanges() mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("filter matching everything", func(t *testing.T) {
+		_, removed, err := buildLookupChangesQueries(since, []*model.Subscription{{}})
+		if err != nil {
+			t.Fatalf("buildLookupChangesQueries() error = %v", err)
+		}
+		if strings.Contains(removed, "UNION") {
+			t.Errorf("buildLookupChangesQueries() removed = %q, want only deleted subscriptions", removed)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		r, mock, db := newMockRegistry(t)
+		defer db.Close()
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "subscriptions"`)).WillReturnError(errors.New("db error"))
+
+		if _, err := r.LookupChanges(context.Background(), since, filters); err == nil {
+			t.Fatal("LookupChanges() expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 50 * time.Millisecond
+	defaultRetryMaxBackoff     = time.Second
+)
+
+// RetryConfig configures the retries of repository calls that fail with transient errors,
+// such as serialization failures, connection resets and Cloud SQL failovers.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"maxAttempts"`    // Attempts per call, including the first. Defaults to 3.
+	InitialBackoff time.Duration `yaml:"initialBackoff"` // Backoff before the first retry, doubled for each retry. Defaults to 50ms.
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`     // Upper bound of the backoff. Defaults to 1s.
+}
+
+// retryMode tells whether a repository call may be repeated after an ambiguous failure.
+type retryMode int
+
+const (
+	// idempotentCall can safely be applied more than once, e.g. a read, an upsert or an update to given values.
+	idempotentCall retryMode = iota
+	// nonIdempotentCall must not be applied twice, e.g. an insert or consuming a nonce. It is only
+	// retried if the failure guarantees that it was not applied.
+	nonIdempotentCall
+)
+
+// backoffJitter returns a random duration in [d/2, d) to spread out the retries of concurrent calls.
+var backoffJitter = func(d time.Duration) time.Duration {
+	return d/2 + rand.N(d/2+1)
+}
+
+// SetRetry enables retrying repository calls that fail with transient errors.
+// A nil cfg disables retries.
+func (r *registry) SetRetry(cfg *RetryConfig) {
+	if cfg == nil {
+		r.retries = nil
+		return
+	}
+	c := *cfg
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultRetryMaxBackoff
+	}
+	r.retries = &c
+}
+
+// retry runs fn until it succeeds, fails with an error that is not retryable in mode,
+// runs out of attempts or ctx is done. It returns the error of the last attempt.
+func (r *registry) retry(ctx context.Context, op string, mode retryMode, fn func() error) error {
+	if r.retries == nil {
+		return fn()
+	}
+	backoff := r.retries.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.retries.MaxAttempts || !retryable(err, mode) {
+			if err != nil && attempt > 1 {
+				queryMetrics.Add("retries_failed", 1)
+			}
+			return err
+		}
+		queryMetrics.Add("retries", 1)
+		wait := backoffJitter(backoff)
+		slog.WarnContext(ctx, "Repository: Retrying after transient error", "operation", op, "attempt", attempt, "backoff", wait, "error", err)
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff = min(2*backoff, r.retries.MaxBackoff)
+	}
+}
+
+// queryRow runs a query returning a single row and scans the row into dest, retrying transient errors.
+func (r *registry) queryRow(ctx context.Context, op string, mode retryMode, query string, args []any, dest ...any) error {
+	return r.retry(ctx, op, mode, func() error {
+		return r.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	})
+}
+
+// selectAll runs a query returning any number of rows and scans them into the slice dest points to,
+// retrying transient errors. The slice is reset before each attempt.
+func (r *registry) selectAll(ctx context.Context, op string, mode retryMode, dest any, query string, args []any) error {
+	return r.retry(ctx, op, mode, func() error {
+		reflect.ValueOf(dest).Elem().SetLen(0)
+		return r.db.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// retryable reports whether a call that failed with err may be attempted again in mode.
+// Failures that guarantee the call was not applied, e.g. a rolled back serialization failure
+// or a connection that could not be established, are retryable in any mode. Failures that leave
+// the outcome unknown, e.g. a connection reset while the statement ran, only for idempotent calls.
+func retryable(err error, mode retryMode) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// Both the pgx and the lib/pq errors report their SQLSTATE.
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		switch code := pgErr.SQLState(); {
+		case code == "40001", code == "40P01": // serialization_failure, deadlock_detected
+			return true
+		case code == "08001", code == "08004", code == "57P03": // connection rejected, cannot_connect_now
+			return true
+		case strings.HasPrefix(code, "08"), code == "57P01", code == "57P02": // connection_exception, admin and crash shutdown
+			return mode == idempotentCall
+		}
+		return false
+	}
+	// pgx marks errors that occurred before any data was sent to the server.
+	var safe interface{ SafeToRetry() bool }
+	if errors.As(err, &safe) && safe.SafeToRetry() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	if mode != idempotentCall {
+		return false
+	}
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
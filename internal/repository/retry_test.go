@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// safeToRetryError is an error that reports whether it occurred before any data was sent.
+type safeToRetryError struct{ safe bool }
+
+func (e *safeToRetryError) Error() string     { return "connection failed" }
+func (e *safeToRetryError) SafeToRetry() bool { return e.safe }
+
+// noBackoff disables the retry backoff until the test ends.
+func noBackoff(t *testing.T) {
+	t.Helper()
+	defaultJitter := backoffJitter
+	backoffJitter = func(time.Duration) time.Duration { return 0 }
+	t.Cleanup(func() { backoffJitter = defaultJitter })
+}
+
+func TestSetRetry(t *testing.T) {
+	r := &registry{}
+	r.SetRetry(&RetryConfig{MaxAttempts: 5})
+	want := RetryConfig{MaxAttempts: 5, InitialBackoff: defaultRetryInitialBackoff, MaxBackoff: defaultRetryMaxBackoff}
+	if *r.retries != want {
+		t.Errorf("SetRetry() config = %+v, want %+v", *r.retries, want)
+	}
+	r.SetRetry(nil)
+	if r.retries != nil {
+		t.Errorf("SetRetry(nil) config = %+v, want nil", *r.retries)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		wantIdempotent    bool
+		wantNonIdempotent bool
+	}{
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, wantIdempotent: true, wantNonIdempotent: true},
+		{name: "wrapped deadlock", err: fmt.Errorf("failed: %w", &pq.Error{Code: "40P01"}), wantIdempotent: true, wantNonIdempotent: true},
+		{name: "cannot connect now", err: &pq.Error{Code: "57P03"}, wantIdempotent: true, wantNonIdempotent: true},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, wantIdempotent: true},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, wantIdempotent: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}},
+		{name: "safe to retry", err: &safeToRetryError{safe: true}, wantIdempotent: true, wantNonIdempotent: true},
+		{name: "unsafe to retry", err: &safeToRetryError{}, wantIdempotent: false},
+		{name: "connection refused", err: syscall.ECONNREFUSED, wantIdempotent: true, wantNonIdempotent: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), wantIdempotent: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, wantIdempotent: true},
+		{name: "deadline exceeded", err: context.DeadlineExceeded},
+		{name: "canceled", err: context.Canceled},
+		{name: "other error", err: errors.New("syntax error")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err, idempotentCall); got != tt.wantIdempotent {
+				t.Errorf("retryable(%v, idempotentCall) = %t, want %t", tt.err, got, tt.wantIdempotent)
+			}
+			if got := retryable(tt.err, nonIdempotentCall); got != tt.wantNonIdempotent {
+				t.Errorf("retryable(%v, nonIdempotentCall) = %t, want %t", tt.err, got, tt.wantNonIdempotent)
+			}
+		})
+	}
+}
+
+func TestRegistry_Retry(t *testing.T) {
+	noBackoff(t)
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name      string
+		cfg       *RetryConfig
+		mode      retryMode
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "disabled", mode: idempotentCall, errs: []error{io.EOF}, wantCalls: 1, wantErr: io.EOF},
+		{name: "succeeds after transient error", cfg: &RetryConfig{}, mode: idempotentCall, errs: []error{io.EOF, nil}, wantCalls: 2},
+		{name: "gives up after max attempts", cfg: &RetryConfig{MaxAttempts: 2}, mode: idempotentCall, errs: []error{io.EOF, io.EOF, nil}, wantCalls: 2, wantErr: io.EOF},
+		{name: "non-retryable error", cfg: &RetryConfig{}, mode: idempotentCall, errs: []error{errBoom, nil}, wantCalls: 1, wantErr: errBoom},
+		{name: "ambiguous error not retried for non-idempotent call", cfg: &RetryConfig{}, mode: nonIdempotentCall, errs: []error{io.EOF, nil}, wantCalls: 1, wantErr: io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &registry{}
+			r.SetRetry(tt.cfg)
+			calls := 0
+			err := r.retry(context.Background(), "Test", tt.mode, func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("retry() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("retry() made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRegistry_Retry_ContextDone(t *testing.T) {
+	r := &registry{}
+	r.SetRetry(&RetryConfig{MaxAttempts: 3, InitialBackoff: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := r.retry(ctx, "Test", idempotentCall, func() error {
+		calls++
+		cancel()
+		return io.EOF
+	})
+	if !errors.Is(err, io.EOF) || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want %v after 1 call", err, calls, io.EOF)
+	}
+}
+
+func TestRegistry_EncryptionKey_RetriesTransientError(t *testing.T) {
+	noBackoff(t)
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	r.SetRetry(&RetryConfig{})
+
+	mock.ExpectQuery(regexp.QuoteMeta(getSubscriberEncryptionKeyQuery)).
+		WithArgs("sub1", "key1").
+		WillReturnError(&pq.Error{Code: "40001"})
+	mock.ExpectQuery(regexp.QuoteMeta(getSubscriberEncryptionKeyQuery)).
+		WithArgs("sub1", "key1").
+		WillReturnRows(sqlmock.NewRows([]string{"encr_public_key"}).AddRow("encr-key"))
+
+	got, err := r.EncryptionKey(context.Background(), "sub1", "key1")
+	if err != nil {
+		t.Fatalf("EncryptionKey() error = %v", err)
+	}
+	if got != "encr-key" {
+		t.Errorf("EncryptionKey() = %q, want %q", got, "encr-key")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_InsertOperation_DoesNotRetryAmbiguousError(t *testing.T) {
+	noBackoff(t)
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	r.SetRetry(&RetryConfig{})
+	lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: []byte(`{}`)}
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertOperationQuery)).WillReturnError(io.ErrUnexpectedEOF)
+
+	if _, err := r.InsertOperation(context.Background(), lro); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("InsertOperation() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_ListDomains_RetriesTransientError(t *testing.T) {
+	noBackoff(t)
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	r.SetRetry(&RetryConfig{})
+	cols := []string{"name", "parent", "display_name", "location_granularity", "schema_version", "created_at", "updated_at"}
+	now := time.Now()
+
+	// The first attempt fails after a row was read, which must not be returned twice.
+	mock.ExpectQuery(regexp.QuoteMeta(listDomainsQuery)).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow("ONDC:RET10", "", "Grocery", "", "", now, now).
+			AddRow("ONDC:RET11", "", "F&B", "", "", now, now).
+			RowError(1, io.ErrUnexpectedEOF))
+	mock.ExpectQuery(regexp.QuoteMeta(listDomainsQuery)).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow("ONDC:RET10", "", "Grocery", "", "", now, now).
+			AddRow("ONDC:RET11", "", "F&B", "", "", now, now))
+
+	got, err := r.ListDomains(context.Background())
+	if err != nil {
+		t.Fatalf("ListDomains() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "ONDC:RET10" || got[1].Name != "ONDC:RET11" {
+		t.Errorf("ListDomains() = %+v, want ONDC:RET10 and ONDC:RET11", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_ActivateSubscriptions_DoesNotRetryAmbiguousError(t *testing.T) {
+	noBackoff(t)
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	r.SetRetry(&RetryConfig{})
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(activateSubscriptionsQuery)).WithArgs(now, 10).WillReturnError(io.ErrUnexpectedEOF)
+
+	if _, err := r.ActivateSubscriptions(context.Background(), now, 10); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ActivateSubscriptions() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// SlowQueryConfig controls logging of repository queries that exceed a latency threshold.
+type SlowQueryConfig struct {
+	Threshold  time.Duration `yaml:"threshold"`  // Queries slower than this are logged. Zero disables logging.
+	SampleRate float64       `yaml:"sampleRate"` // Fraction (0 to 1) of slow queries logged with full SQL and arguments.
+}
+
+// sampleFloat returns a pseudo-random number in [0, 1) used to sample slow queries.
+var sampleFloat = rand.Float64
+
+// SetSlowQueryLog enables structured logging of queries slower than cfg.Threshold.
+func (r *registry) SetSlowQueryLog(cfg *SlowQueryConfig) {
+	r.slowQueries = cfg
+}
+
+// observeQuery starts timing a query and returns a function that logs it
+// if it ran longer than the configured threshold. A sampled fraction of
+// slow queries is logged with the full SQL and its arguments.
+func (r *registry) observeQuery(ctx context.Context, op, query string, args []any) func() {
+	if r.slowQueries == nil || r.slowQueries.Threshold <= 0 {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		if elapsed < r.slowQueries.Threshold {
+			return
+		}
+		queryMetrics.Add("slow", 1)
+		attrs := []any{"operation", op, "duration", elapsed, "threshold", r.slowQueries.Threshold, "arg_count", len(args)}
+		if sampleFloat() < r.slowQueries.SampleRate {
+			attrs = append(attrs, "sql", query, "args", args)
+		}
+		slog.WarnContext(ctx, "Repository: Slow query", attrs...)
+	}
+}
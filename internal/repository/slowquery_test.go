@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRegistry_ObserveQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *SlowQueryConfig
+		elapsed time.Duration
+		sample  float64
+		wantLog bool
+		wantSQL bool
+	}{
+		{
+			name:    "not configured",
+			elapsed: 20 * time.Millisecond,
+		},
+		{
+			name:    "faster than threshold",
+			cfg:     &SlowQueryConfig{Threshold: time.Second, SampleRate: 1},
+			elapsed: time.Millisecond,
+		},
+		{
+			name:    "slow query sampled",
+			cfg:     &SlowQueryConfig{Threshold: 10 * time.Millisecond, SampleRate: 0.5},
+			elapsed: 20 * time.Millisecond,
+			sample:  0.2,
+			wantLog: true,
+			wantSQL: true,
+		},
+		{
+			name:    "slow query not sampled",
+			cfg:     &SlowQueryConfig{Threshold: 10 * time.Millisecond, SampleRate: 0.5},
+			elapsed: 20 * time.Millisecond,
+			sample:  0.7,
+			wantLog: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+			defer slog.SetDefault(defaultLogger)
+			defaultSample := sampleFloat
+			sampleFloat = func() float64 { return tc.sample }
+			defer func() { sampleFloat = defaultSample }()
+
+			r := &registry{}
+			r.SetSlowQueryLog(tc.cfg)
+			observed := r.observeQuery(context.Background(), "Lookup", "SELECT 1 WHERE a = $1", []any{"x"})
+			time.Sleep(tc.elapsed)
+			observed()
+
+			if !tc.wantLog {
+				if buf.Len() != 0 {
+					t.Errorf("observeQuery() logged %q, want nothing", buf.String())
+				}
+				return
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to decode log entry %q: %v", buf.String(), err)
+			}
+			if entry["msg"] != "Repository: Slow query" || entry["operation"] != "Lookup" {
+				t.Errorf("observeQuery() logged %v, want slow Lookup query", entry)
+			}
+			if _, ok := entry["sql"]; ok != tc.wantSQL {
+				t.Errorf("observeQuery() logged sql = %t, want %t", ok, tc.wantSQL)
+			}
+			if _, ok := entry["args"]; ok != tc.wantSQL {
+				t.Errorf("observeQuery() logged args = %t, want %t", ok, tc.wantSQL)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/cloudsqlconn"
+	"github.com/lib/pq"
+)
+
+const (
+	// subscriptionChangesChannel is the channel the subscriptions table trigger notifies changes on.
+	subscriptionChangesChannel        = "subscription_changes"
+	defaultMinNotifyReconnectInterval = time.Second
+	defaultMaxNotifyReconnectInterval = time.Minute
+	// notifyPingInterval is how long the listener waits for a notification before checking
+	// that its connection is still alive.
+	notifyPingInterval = 90 * time.Second
+)
+
+// notifyMetrics counts subscription change notifications and the state changes of the listener connection.
+var notifyMetrics = expvar.NewMap("db_subscription_notifications")
+
+// SubscriptionNotifyConfig configures the listener for changes to the subscriptions table.
+type SubscriptionNotifyConfig struct {
+	MinReconnectInterval time.Duration `yaml:"minReconnectInterval"` // Wait before the first reconnection attempt. Defaults to 1s.
+	MaxReconnectInterval time.Duration `yaml:"maxReconnectInterval"` // Upper bound of the doubling wait between attempts. Defaults to 1m.
+}
+
+// SubscriptionChangeOp is the kind of change made to a subscription.
+type SubscriptionChangeOp string
+
+const (
+	SubscriptionInserted SubscriptionChangeOp = "INSERT"
+	SubscriptionUpdated  SubscriptionChangeOp = "UPDATE"
+	SubscriptionDeleted  SubscriptionChangeOp = "DELETE"
+	// SubscriptionResync is broadcast when notifications may have been missed, after the
+	// listener connection was lost. Hooks should drop everything they cached.
+	SubscriptionResync SubscriptionChangeOp = "RESYNC"
+)
+
+// SubscriptionChange is a change to a row of the subscriptions table. Inserted and updated
+// rows are identified by their new values, deleted rows by their last values.
+type SubscriptionChange struct {
+	Op           SubscriptionChangeOp `json:"op"`
+	SubscriberID string               `json:"subscriber_id,omitempty"`
+	KeyID        string               `json:"key_id,omitempty"`
+	Domain       string               `json:"domain,omitempty"`
+	Type         string               `json:"type,omitempty"`
+}
+
+// SubscriptionChangeHook is called with each change to the subscriptions table.
+type SubscriptionChangeHook func(ctx context.Context, change SubscriptionChange)
+
+// notificationListener is satisfied by *pq.Listener.
+type notificationListener interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+// subscriptionListener receives the changes to the subscriptions table that the database
+// publishes with NOTIFY and broadcasts them to in-process hooks.
+type subscriptionListener struct {
+	listener notificationListener
+	closer   func() error
+
+	mu    sync.RWMutex
+	hooks []SubscriptionChangeHook
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newNotificationListener opens the dedicated connection the listener receives notifications on.
+// It returns the listener and a function releasing the resources used to connect.
+var newNotificationListener = func(ctx context.Context, cfg *Config, ncfg *SubscriptionNotifyConfig, cb pq.EventCallbackType) (notificationListener, func() error, error) {
+	d, err := cloudsqlconn.NewDialer(ctx, cloudsqlconn.WithIAMAuthN())
+	if err != nil {
+		return nil, nil, fmt.Errorf("cloudsqlconn.NewDialer: %w", err)
+	}
+	dsn := fmt.Sprintf("host=%s user=%s dbname=%s sslmode=disable", cfg.ConnectionName, cfg.User, cfg.Name)
+	l := pq.NewDialListener(&cloudSQLDialer{ctx: ctx, dialer: d, instance: cfg.ConnectionName}, dsn, ncfg.MinReconnectInterval, ncfg.MaxReconnectInterval, cb)
+	return l, d.Close, nil
+}
+
+// NewSubscriptionListener creates a listener for changes to the subscriptions table of the
+// database configured by cfg. Notifications are received on a dedicated connection, outside of
+// the connection pool.
+func NewSubscriptionListener(ctx context.Context, cfg *Config) (*subscriptionListener, error) {
+	if cfg == nil || cfg.Notifications == nil {
+		slog.Error("NewSubscriptionListener: notifications config cannot be nil")
+		return nil, errors.New("notifications config cannot be nil")
+	}
+	if cfg.ConnectionName == "" || cfg.User == "" || cfg.Name == "" {
+		return nil, errors.New("db.connectionName, db.user and db.name are required to listen for notifications")
+	}
+	ncfg := *cfg.Notifications
+	if ncfg.MinReconnectInterval < 0 || ncfg.MaxReconnectInterval < 0 {
+		return nil, errors.New("invalid notifications config: reconnect intervals cannot be negative")
+	}
+	if ncfg.MinReconnectInterval == 0 {
+		ncfg.MinReconnectInterval = defaultMinNotifyReconnectInterval
+	}
+	if ncfg.MaxReconnectInterval == 0 {
+		ncfg.MaxReconnectInterval = defaultMaxNotifyReconnectInterval
+	}
+	if ncfg.MaxReconnectInterval < ncfg.MinReconnectInterval {
+		return nil, fmt.Errorf("invalid notifications config: maxReconnectInterval %v is shorter than minReconnectInterval %v", ncfg.MaxReconnectInterval, ncfg.MinReconnectInterval)
+	}
+
+	l := &subscriptionListener{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	var err error
+	if l.listener, l.closer, err = newNotificationListener(ctx, cfg, &ncfg, l.event); err != nil {
+		return nil, fmt.Errorf("failed to open notification listener: %w", err)
+	}
+	return l, nil
+}
+
+// OnChange registers a hook called with each change to the subscriptions table. Hooks are
+// called one at a time, in the order the changes were committed, and should return quickly.
+func (l *subscriptionListener) OnChange(hook SubscriptionChangeHook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Start subscribes to the notification channel and dispatches changes until ctx is cancelled or Stop is called.
+func (l *subscriptionListener) Start(ctx context.Context) {
+	slog.InfoContext(ctx, "Repository: Starting subscription change listener", "channel", subscriptionChangesChannel)
+	go func() {
+		defer close(l.done)
+		// Listen blocks until the connection is established.
+		if err := l.listener.Listen(subscriptionChangesChannel); err != nil {
+			select {
+			case <-l.stop:
+			default:
+				slog.ErrorContext(ctx, "Repository: Failed to listen for subscription changes", "channel", subscriptionChangesChannel, "error", err)
+			}
+			return
+		}
+		notifications := l.listener.NotificationChannel()
+		ping := time.NewTimer(notifyPingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-l.stop:
+				return
+			case n, ok := <-notifications:
+				if !ok {
+					return
+				}
+				l.dispatch(ctx, n)
+				ping.Reset(notifyPingInterval)
+			case <-ping.C:
+				// A dead connection is only noticed when it is used.
+				go func() {
+					if err := l.listener.Ping(); err != nil {
+						slog.WarnContext(ctx, "Repository: Subscription change listener ping failed", "error", err)
+					}
+				}()
+				ping.Reset(notifyPingInterval)
+			}
+		}
+	}()
+}
+
+// Stop closes the listener connection and waits for the dispatch loop to exit.
+func (l *subscriptionListener) Stop() {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+		if err := l.listener.Close(); err != nil {
+			slog.Error("Repository: Failed to close subscription change listener", "error", err)
+		}
+		<-l.done
+		if l.closer != nil {
+			if err := l.closer(); err != nil {
+				slog.Error("Repository: Failed to close subscription change listener dialer", "error", err)
+			}
+		}
+	})
+}
+
+// dispatch decodes a notification and calls the hooks with it. A nil notification means the
+// connection was re-established and notifications may have been missed.
+func (l *subscriptionListener) dispatch(ctx context.Context, n *pq.Notification) {
+	var change SubscriptionChange
+	if n == nil {
+		notifyMetrics.Add("resyncs", 1)
+		change.Op = SubscriptionResync
+	} else if err := json.Unmarshal([]byte(n.Extra), &change); err != nil || change.Op == "" {
+		// Hooks cannot tell what changed, so they are told to drop everything.
+		notifyMetrics.Add("invalid", 1)
+		slog.WarnContext(ctx, "Repository: Invalid subscription change notification", "payload", n.Extra, "error", err)
+		change = SubscriptionChange{Op: SubscriptionResync}
+	} else {
+		notifyMetrics.Add("received", 1)
+	}
+	slog.DebugContext(ctx, "Repository: Subscription changed", "op", change.Op, "subscriber_id", change.SubscriberID, "key_id", change.KeyID)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, hook := range l.hooks {
+		hook(ctx, change)
+	}
+}
+
+// event records the state changes of the listener connection.
+func (l *subscriptionListener) event(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventConnected:
+		slog.Info("Repository: Subscription change listener connected")
+	case pq.ListenerEventDisconnected:
+		notifyMetrics.Add("disconnects", 1)
+		slog.Warn("Repository: Subscription change listener disconnected", "error", err)
+	case pq.ListenerEventReconnected:
+		notifyMetrics.Add("reconnects", 1)
+		slog.Info("Repository: Subscription change listener reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		notifyMetrics.Add("connect_failures", 1)
+		slog.Warn("Repository: Subscription change listener failed to connect", "error", err)
+	}
+}
+
+// cloudSQLDialer connects lib/pq to a Cloud SQL instance through the Cloud SQL connector.
+type cloudSQLDialer struct {
+	ctx      context.Context
+	dialer   *cloudsqlconn.Dialer
+	instance string
+}
+
+// Dial connects to the instance. The network and address derived from the DSN are ignored.
+func (d *cloudSQLDialer) Dial(_, _ string) (net.Conn, error) {
+	return d.dialer.Dial(d.ctx, d.instance)
+}
+
+// DialTimeout connects to the instance within timeout.
+func (d *cloudSQLDialer) DialTimeout(_, _ string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, timeout)
+	defer cancel()
+	return d.dialer.Dial(ctx, d.instance)
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lib/pq"
+)
+
+type mockNotificationListener struct {
+	listenErr error
+	ch        chan *pq.Notification
+
+	mu       sync.Mutex
+	channels []string
+	closed   bool
+}
+
+func (m *mockNotificationListener) Listen(channel string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels = append(m.channels, channel)
+	return m.listenErr
+}
+
+func (m *mockNotificationListener) NotificationChannel() <-chan *pq.Notification { return m.ch }
+func (m *mockNotificationListener) Ping() error                                  { return nil }
+
+func (m *mockNotificationListener) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.ch)
+	}
+	return nil
+}
+
+func TestNewSubscriptionListener(t *testing.T) {
+	tests := []struct {
+		name    string
+		ncfg    *SubscriptionNotifyConfig
+		want    SubscriptionNotifyConfig
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			ncfg: &SubscriptionNotifyConfig{},
+			want: SubscriptionNotifyConfig{MinReconnectInterval: time.Second, MaxReconnectInterval: time.Minute},
+		},
+		{
+			name: "custom",
+			ncfg: &SubscriptionNotifyConfig{MinReconnectInterval: 5 * time.Second, MaxReconnectInterval: 10 * time.Second},
+			want: SubscriptionNotifyConfig{MinReconnectInterval: 5 * time.Second, MaxReconnectInterval: 10 * time.Second},
+		},
+		{name: "nil config", wantErr: true},
+		{name: "negative interval", ncfg: &SubscriptionNotifyConfig{MinReconnectInterval: -time.Second}, wantErr: true},
+		{name: "max shorter than min", ncfg: &SubscriptionNotifyConfig{MinReconnectInterval: time.Minute, MaxReconnectInterval: time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *SubscriptionNotifyConfig
+			orig := newNotificationListener
+			newNotificationListener = func(_ context.Context, _ *Config, ncfg *SubscriptionNotifyConfig, _ pq.EventCallbackType) (notificationListener, func() error, error) {
+				got = ncfg
+				return &mockNotificationListener{ch: make(chan *pq.Notification)}, nil, nil
+			}
+			defer func() { newNotificationListener = orig }()
+
+			_, err := NewSubscriptionListener(context.Background(), &Config{ConnectionName: "p:r:i", User: "u", Name: "registry", Notifications: tt.ncfg})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSubscriptionListener() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, *got); diff != "" {
+				t.Errorf("NewSubscriptionListener() config mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewSubscriptionListener_Errors(t *testing.T) {
+	ncfg := &SubscriptionNotifyConfig{}
+	if _, err := NewSubscriptionListener(context.Background(), &Config{User: "u", Name: "registry", Notifications: ncfg}); err == nil {
+		t.Error("NewSubscriptionListener() without connection name error = nil, want error")
+	}
+
+	orig := newNotificationListener
+	newNotificationListener = func(context.Context, *Config, *SubscriptionNotifyConfig, pq.EventCallbackType) (notificationListener, func() error, error) {
+		return nil, nil, errors.New("no credentials")
+	}
+	defer func() { newNotificationListener = orig }()
+	if _, err := NewSubscriptionListener(context.Background(), &Config{ConnectionName: "p:r:i", User: "u", Name: "registry", Notifications: ncfg}); err == nil {
+		t.Error("NewSubscriptionListener() with failing dialer error = nil, want error")
+	}
+}
+
+func TestSubscriptionListener_Dispatch(t *testing.T) {
+	mock := &mockNotificationListener{ch: make(chan *pq.Notification)}
+	l := &subscriptionListener{listener: mock, stop: make(chan struct{}), done: make(chan struct{})}
+	var got []SubscriptionChange
+	received := make(chan struct{}, 10)
+	l.OnChange(func(_ context.Context, c SubscriptionChange) {
+		got = append(got, c)
+		received <- struct{}{}
+	})
+
+	l.Start(context.Background())
+	for _, n := range []*pq.Notification{
+		{Channel: subscriptionChangesChannel, Extra: `{"op":"UPDATE","subscriber_id":"bap.example.com","key_id":"k1","domain":"retail","type":"BAP"}`},
+		nil,
+		{Channel: subscriptionChangesChannel, Extra: `not json`},
+		{Channel: subscriptionChangesChannel, Extra: `{"op":"DELETE","subscriber_id":"bpp.example.com","key_id":"k2"}`},
+	} {
+		mock.ch <- n
+		<-received
+	}
+	l.Stop()
+
+	want := []SubscriptionChange{
+		{Op: SubscriptionUpdated, SubscriberID: "bap.example.com", KeyID: "k1", Domain: "retail", Type: "BAP"},
+		{Op: SubscriptionResync},
+		{Op: SubscriptionResync},
+		{Op: SubscriptionDeleted, SubscriberID: "bpp.example.com", KeyID: "k2"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("hooks received mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{subscriptionChangesChannel}, mock.channels); diff != "" {
+		t.Errorf("Listen() channels mismatch (-want +got):\n%s", diff)
+	}
+	if !mock.closed {
+		t.Error("Stop() did not close the listener")
+	}
+}
+
+func TestSubscriptionListener_ListenError(t *testing.T) {
+	mock := &mockNotificationListener{ch: make(chan *pq.Notification), listenErr: errors.New("listener closed")}
+	l := &subscriptionListener{listener: mock, stop: make(chan struct{}), done: make(chan struct{})}
+	l.OnChange(func(context.Context, SubscriptionChange) { t.Error("hook called without notifications") })
+
+	l.Start(context.Background())
+	select {
+	case <-l.done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch loop did not exit after Listen() failed")
+	}
+	l.Stop()
+}
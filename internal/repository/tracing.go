@@ -0,0 +1,218 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans recorded by the repository.
+const tracerName = "github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+
+// statementKey is the context key holding the name of the repository operation running a statement.
+type statementKey struct{}
+
+// withStatement names the statements run with ctx after the repository operation op.
+func withStatement(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, statementKey{}, op)
+}
+
+// statementName returns the repository operation running query, falling back to its SQL verb.
+func statementName(ctx context.Context, query string) string {
+	if op, ok := ctx.Value(statementKey{}).(string); ok && op != "" {
+		return op
+	}
+	if verb, _, _ := strings.Cut(strings.TrimSpace(query), " "); verb != "" {
+		return strings.ToUpper(verb)
+	}
+	return "SQL"
+}
+
+// newTracedDB reopens db, opened with dsn, on connections that record a span per statement.
+// The spans are children of the span in the statement's context, such as the HTTP request span.
+func newTracedDB(db *sql.DB, dsn string, tracer trace.Tracer) (*sql.DB, error) {
+	var connector driver.Connector
+	switch d := db.Driver().(type) {
+	case driver.DriverContext:
+		c, err := d.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		connector = c
+	default:
+		connector = dsnConnector{driver: d, dsn: dsn}
+	}
+	db.Close()
+	return sql.OpenDB(&tracedConnector{Connector: connector, tracer: tracer}), nil
+}
+
+// dsnConnector opens connections of drivers that do not provide a connector.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+// tracedConnector wraps the connections of a connector in tracedConn.
+type tracedConnector struct {
+	driver.Connector
+	tracer trace.Tracer
+}
+
+// Connect opens a connection that traces its statements.
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, tracer: c.tracer}, nil
+}
+
+// tracedConn records a span for every query and exec run on the connection.
+// Optional driver interfaces are forwarded, returning driver.ErrSkip when
+// the wrapped connection does not implement them.
+type tracedConn struct {
+	driver.Conn
+	tracer trace.Tracer
+}
+
+// start starts the span of a statement.
+func (c *tracedConn) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, statementName(ctx, query),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", statementName(ctx, query)),
+			attribute.String("db.query.text", query),
+		))
+}
+
+// end ends span, recording err unless it asks database/sql to fall back.
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, query)
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil {
+		end(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, query)
+	res, err := e.ExecContext(ctx, query, args)
+	if err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			span.SetAttributes(attribute.Int64("db.response.rows_affected", n))
+		}
+	}
+	end(span, err)
+	return res, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// tracedRows counts the rows read from a query and ends its span when closed.
+type tracedRows struct {
+	driver.Rows
+	span trace.Span
+	rows int64
+	err  error
+}
+
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.rows++
+	case err != io.EOF:
+		r.err = err
+	}
+	return err
+}
+
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	r.span.SetAttributes(attribute.Int64("db.response.returned_rows", r.rows))
+	end(r.span, errors.Join(r.err, err))
+	return err
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStatementName(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		query string
+		want  string
+	}{
+		{name: "repository operation", ctx: withStatement(context.Background(), "Lookup"), query: "SELECT 1", want: "Lookup"},
+		{name: "SQL verb", ctx: context.Background(), query: "\n\tdelete FROM webhooks WHERE id = $1", want: "DELETE"},
+		{name: "empty query", ctx: context.Background(), query: " ", want: "SQL"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := statementName(tc.ctx, tc.query); got != tc.want {
+				t.Errorf("statementName() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// newTracedMock returns a traced database backed by sqlmock and the recorder of its spans.
+func newTracedMock(t *testing.T) (*registry, sqlmock.Sqlmock, *tracetest.SpanRecorder) {
+	t.Helper()
+	dsn := "traced_" + t.Name()
+	db, mock, err := sqlmock.NewWithDSN(dsn)
+	if err != nil {
+		t.Fatalf("sqlmock.NewWithDSN() error = %v", err)
+	}
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	// The sqlmock connection is shared with db, so db is kept open rather than reopened by newTracedDB.
+	traced := sql.OpenDB(&tracedConnector{Connector: dsnConnector{driver: db.Driver(), dsn: dsn}, tracer: tp.Tracer(tracerName)})
+	t.Cleanup(func() {
+		traced.Close()
+		db.Close()
+	})
+	r, err := NewRegistry(traced)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	return r, mock, rec
+}
+
+// spanAttr returns the value of the attribute key of span s.
+func spanAttr(s sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracedDB_Query(t *testing.T) {
+	r, mock, rec := newTracedMock(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"subscriber_id"}).AddRow("a").AddRow("b"))
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "GET /lookup")
+	ids := []string{}
+	if err := r.db.SelectContext(withStatement(ctx, "Lookup"), &ids, "SELECT subscriber_id FROM subscriptions"); err != nil {
+		t.Fatalf("SelectContext() error = %v", err)
+	}
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "Lookup" {
+		t.Errorf("span name = %q, want %q", s.Name(), "Lookup")
+	}
+	if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span parent = %s, want request span %s", s.Parent().SpanID(), parent.SpanContext().SpanID())
+	}
+	if v, ok := spanAttr(s, "db.response.returned_rows"); !ok || v.AsInt64() != 2 {
+		t.Errorf("db.response.returned_rows = %v, want 2", v.Emit())
+	}
+	if v, _ := spanAttr(s, "db.query.text"); v.AsString() != "SELECT subscriber_id FROM subscriptions" {
+		t.Errorf("db.query.text = %q, want the statement", v.AsString())
+	}
+	if !s.EndTime().After(s.StartTime()) {
+		t.Errorf("span ended at %v, want after its start %v", s.EndTime(), s.StartTime())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestTracedDB_Exec(t *testing.T) {
+	r, mock, rec := newTracedMock(t)
+	mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	if _, err := r.db.ExecContext(context.Background(), "DELETE FROM nonces"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Name() != "DELETE" {
+		t.Errorf("span name = %q, want %q", spans[0].Name(), "DELETE")
+	}
+	if v, ok := spanAttr(spans[0], "db.response.rows_affected"); !ok || v.AsInt64() != 3 {
+		t.Errorf("db.response.rows_affected = %v, want 3", v.Emit())
+	}
+}
+
+func TestTracedDB_Error(t *testing.T) {
+	r, mock, rec := newTracedMock(t)
+	mock.ExpectQuery("SELECT").WillReturnError(errors.New("relation does not exist"))
+
+	ids := []string{}
+	if err := r.db.SelectContext(context.Background(), &ids, "SELECT subscriber_id FROM missing"); err == nil {
+		t.Fatal("SelectContext() error = nil, want error")
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := spans[0].Status().Code; got != codes.Error {
+		t.Errorf("span status = %v, want %v", got, codes.Error)
+	}
+}
+
+func TestRegistry_BeginNamesStatements(t *testing.T) {
+	r := &registry{}
+	ctx, done := r.begin(context.Background(), "GetOperation", lookupQuery)
+	defer done(nil)
+	if got := statementName(ctx, "SELECT 1"); got != "GetOperation" {
+		t.Errorf("statementName() = %q, want %q", got, "GetOperation")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/jsonschema"
+)
+
+// ErrActionSchemaViolation is returned when a request does not conform to the schema of its action.
+var ErrActionSchemaViolation = errors.New("request violates action schema")
+
+// ActionRouting describes where the gateway forwards requests of an action.
+type ActionRouting string
+
+const (
+	// ActionRoutingBroadcast forwards to context.bpp_uri if set, otherwise to every BPP
+	// of the domain found by a registry lookup, like search.
+	ActionRoutingBroadcast ActionRouting = "broadcast"
+	// ActionRoutingBPP forwards to context.bpp_uri, which is required.
+	ActionRoutingBPP ActionRouting = "bpp"
+	// ActionRoutingBAP forwards to context.bap_uri, which is required, like on_search.
+	ActionRoutingBAP ActionRouting = "bap"
+)
+
+// builtinActions are the actions the gateway always serves.
+var builtinActions = map[string]ActionRouting{
+	"search":    ActionRoutingBroadcast,
+	"on_search": ActionRoutingBAP,
+}
+
+// actionNamePattern restricts action names to those usable as a URL path segment.
+var actionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ActionConfig enables an additional Beckn action on the gateway.
+type ActionConfig struct {
+	// Name is the Beckn action, e.g. "issue_status". It is served at POST /<name>.
+	Name string `yaml:"name"`
+	// Schema is the path to an optional JSON schema that requests must conform to.
+	Schema string `yaml:"schema"`
+	// Routing is where requests are forwarded: broadcast, bpp or bap.
+	Routing ActionRouting `yaml:"routing"`
+}
+
+// actionRegistry holds the routing and validation of the configured custom actions.
+type actionRegistry struct {
+	routes  map[string]ActionRouting
+	schemas map[string]*jsonschema.Schema
+}
+
+// NewActionRegistry creates a new action registry from the given configs.
+func NewActionRegistry(cfgs []ActionConfig) (*actionRegistry, error) {
+	r := &actionRegistry{routes: map[string]ActionRouting{}, schemas: map[string]*jsonschema.Schema{}}
+	for _, cfg := range cfgs {
+		if !actionNamePattern.MatchString(cfg.Name) {
+			return nil, fmt.Errorf("invalid action name %q: must match %s", cfg.Name, actionNamePattern)
+		}
+		if _, ok := builtinActions[cfg.Name]; ok {
+			return nil, fmt.Errorf("action %q is built in and cannot be configured", cfg.Name)
+		}
+		if _, ok := r.routes[cfg.Name]; ok {
+			return nil, fmt.Errorf("action %q is configured more than once", cfg.Name)
+		}
+		switch cfg.Routing {
+		case ActionRoutingBroadcast, ActionRoutingBPP, ActionRoutingBAP:
+		default:
+			return nil, fmt.Errorf("invalid routing %q for action %q: must be one of %s, %s, %s", cfg.Routing, cfg.Name, ActionRoutingBroadcast, ActionRoutingBPP, ActionRoutingBAP)
+		}
+		r.routes[cfg.Name] = cfg.Routing
+		if cfg.Schema == "" {
+			continue
+		}
+		raw, err := os.ReadFile(cfg.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema for action %q: %w", cfg.Name, err)
+		}
+		s, err := jsonschema.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema for action %q: %w", cfg.Name, err)
+		}
+		r.schemas[cfg.Name] = s
+	}
+	if len(r.routes) > 0 {
+		slog.Info("NewActionRegistry: Custom actions enabled", "actions", r.Names())
+	}
+	return r, nil
+}
+
+// Names returns the configured custom actions in sorted order.
+func (r *actionRegistry) Names() []string {
+	names := make([]string, 0, len(r.routes))
+	for name := range r.routes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Routing returns the routing of a custom action.
+func (r *actionRegistry) Routing(action string) (ActionRouting, bool) {
+	routing, ok := r.routes[action]
+	return routing, ok
+}
+
+// Validate checks the body of a request against the schema of its action, if any.
+func (r *actionRegistry) Validate(action string, body []byte) error {
+	s, ok := r.schemas[action]
+	if !ok {
+		return nil
+	}
+	if err := s.Validate(body); err != nil {
+		return fmt.Errorf("%w %q: %w", ErrActionSchemaViolation, action, err)
+	}
+	return nil
+}
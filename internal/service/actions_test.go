@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/jsonschema"
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeSchema writes a JSON schema to a temporary file and returns its path.
+func writeSchema(t *testing.T, schema string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(p, []byte(schema), 0o600); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	return p
+}
+
+func TestNewActionRegistry_Success(t *testing.T) {
+	schema := writeSchema(t, `{"type":"object","required":["message"]}`)
+	r, err := NewActionRegistry([]ActionConfig{
+		{Name: "support", Routing: ActionRoutingBroadcast},
+		{Name: "issue_status", Schema: schema, Routing: ActionRoutingBPP},
+		{Name: "on_issue_status", Routing: ActionRoutingBAP},
+	})
+	if err != nil {
+		t.Fatalf("NewActionRegistry() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"issue_status", "on_issue_status", "support"}, r.Names()); diff != "" {
+		t.Errorf("Names() mismatch (-want +got):\n%s", diff)
+	}
+	if got, ok := r.Routing("issue_status"); !ok || got != ActionRoutingBPP {
+		t.Errorf("Routing(issue_status) = %v, %v, want %v, true", got, ok, ActionRoutingBPP)
+	}
+	if _, ok := r.Routing("search"); ok {
+		t.Errorf("Routing(search) ok = true, want built-in actions to be excluded")
+	}
+}
+
+func TestNewActionRegistry_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgs    []ActionConfig
+		wantErr string
+	}{
+		{
+			name:    "empty name",
+			cfgs:    []ActionConfig{{Routing: ActionRoutingBPP}},
+			wantErr: "invalid action name",
+		},
+		{
+			name:    "name with path separator",
+			cfgs:    []ActionConfig{{Name: "issue/status", Routing: ActionRoutingBPP}},
+			wantErr: "invalid action name",
+		},
+		{
+			name:    "built-in action",
+			cfgs:    []ActionConfig{{Name: "search", Routing: ActionRoutingBroadcast}},
+			wantErr: `action "search" is built in`,
+		},
+		{
+			name:    "duplicate action",
+			cfgs:    []ActionConfig{{Name: "support", Routing: ActionRoutingBPP}, {Name: "support", Routing: ActionRoutingBAP}},
+			wantErr: `action "support" is configured more than once`,
+		},
+		{
+			name:    "invalid routing",
+			cfgs:    []ActionConfig{{Name: "support", Routing: "bg"}},
+			wantErr: `invalid routing "bg"`,
+		},
+		{
+			name:    "missing schema file",
+			cfgs:    []ActionConfig{{Name: "support", Schema: "/nonexistent/schema.json", Routing: ActionRoutingBPP}},
+			wantErr: `failed to read schema for action "support"`,
+		},
+		{
+			name:    "invalid schema",
+			cfgs:    []ActionConfig{{Name: "support", Schema: writeSchema(t, `{`), Routing: ActionRoutingBPP}},
+			wantErr: `failed to parse schema for action "support"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewActionRegistry(tt.cfgs)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewActionRegistry() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActionRegistry_Validate(t *testing.T) {
+	schema := writeSchema(t, `{"type":"object","required":["message"],"properties":{"message":{"type":"object","required":["issue_id"]}}}`)
+	r, err := NewActionRegistry([]ActionConfig{
+		{Name: "issue_status", Schema: schema, Routing: ActionRoutingBPP},
+		{Name: "support", Routing: ActionRoutingBPP},
+	})
+	if err != nil {
+		t.Fatalf("NewActionRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		action  string
+		body    string
+		wantErr bool
+	}{
+		{"valid", "issue_status", `{"message":{"issue_id":"i1"}}`, false},
+		{"missing required", "issue_status", `{"message":{}}`, true},
+		{"action without schema", "support", `{}`, false},
+		{"built-in action", "search", `{}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Validate(tt.action, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrActionSchemaViolation) {
+				t.Errorf("Validate() error = %v, want wrapping %v", err, ErrActionSchemaViolation)
+			}
+			var verr *jsonschema.ValidationError
+			if err != nil && !errors.As(err, &verr) {
+				t.Errorf("Validate() error = %v, want wrapping *jsonschema.ValidationError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultActivationInterval  = time.Minute
+	defaultActivationBatchSize = 100
+)
+
+// ActivationConfig configures the scheduled activation of subscriptions approved with a
+// future valid_from. Such subscriptions are stored as APPROVED_PENDING_ACTIVATION and a
+// background job sets them to SUBSCRIBED once their validity starts.
+type ActivationConfig struct {
+	// Interval is how often the job activates the subscriptions that are due. Defaults to 1m.
+	Interval time.Duration `yaml:"interval"`
+	// BatchSize is the maximum number of subscriptions activated per run. Defaults to 100.
+	BatchSize int `yaml:"batchSize"`
+}
+
+// activationRepo is the repository used to activate subscriptions that are due.
+type activationRepo interface {
+	ActivateSubscriptions(ctx context.Context, now time.Time, limit int) ([]model.Subscription, error)
+}
+
+// activationEventPublisher publishes the activation of a subscription.
+type activationEventPublisher interface {
+	PublishSubscriptionActivatedEvent(ctx context.Context, sub *model.Subscription) (string, error)
+}
+
+// activationJob periodically activates the subscriptions whose validity has started.
+type activationJob struct {
+	repo        activationRepo
+	evPublisher activationEventPublisher
+	interval    time.Duration
+	batchSize   int
+	now         func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewActivationJob creates a new activationJob.
+func NewActivationJob(repo activationRepo, evPub activationEventPublisher, cfg *ActivationConfig) (*activationJob, error) {
+	if repo == nil {
+		slog.Error("NewActivationJob: repo cannot be nil")
+		return nil, errors.New("repo cannot be nil")
+	}
+	if evPub == nil {
+		slog.Error("NewActivationJob: eventPublisher cannot be nil")
+		return nil, errors.New("eventPublisher cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewActivationJob: ActivationConfig cannot be nil")
+		return nil, errors.New("ActivationConfig cannot be nil")
+	}
+	j := &activationJob{
+		repo:        repo,
+		evPublisher: evPub,
+		interval:    cfg.Interval,
+		batchSize:   cfg.BatchSize,
+		now:         time.Now,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if j.interval <= 0 {
+		j.interval = defaultActivationInterval
+	}
+	if j.batchSize <= 0 {
+		j.batchSize = defaultActivationBatchSize
+	}
+	return j, nil
+}
+
+// Start launches the background activation loop. It returns immediately.
+func (j *activationJob) Start(ctx context.Context) {
+	slog.InfoContext(ctx, "ActivationJob: Starting", "interval", j.interval, "batch_size", j.batchSize)
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := j.RunOnce(ctx); err != nil {
+				slog.ErrorContext(ctx, "ActivationJob: Activation run failed", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-j.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the activation loop to exit. It is safe to call more than once.
+func (j *activationJob) Stop() {
+	j.stopOnce.Do(func() { close(j.stop) })
+}
+
+// RunOnce activates the subscriptions that are due, publishing an event for each, and returns
+// how many were activated. It keeps going in batches until none are left, so that a backlog,
+// e.g. after downtime, is cleared in one run.
+func (j *activationJob) RunOnce(ctx context.Context) (int, error) {
+	activated := 0
+	for {
+		subs, err := j.repo.ActivateSubscriptions(ctx, j.now(), j.batchSize)
+		if err != nil {
+			return activated, fmt.Errorf("failed to activate subscriptions: %w", err)
+		}
+		for i := range subs {
+			sub := &subs[i]
+			slog.InfoContext(ctx, "ActivationJob: Subscription activated", "subscriber_id", sub.SubscriberID, "domain", sub.Domain, "type", sub.Type, "valid_from", sub.ValidFrom)
+			if evID, err := j.evPublisher.PublishSubscriptionActivatedEvent(ctx, sub); err != nil {
+				slog.ErrorContext(ctx, "ActivationJob: Failed to publish subscription activated event", "subscriber_id", sub.SubscriberID, "error", err)
+			} else {
+				slog.InfoContext(ctx, "ActivationJob: Published subscription activated event", "subscriber_id", sub.SubscriberID, "event_id", evID)
+			}
+		}
+		activated += len(subs)
+		if len(subs) < j.batchSize {
+			return activated, nil
+		}
+	}
+}
+
+// approvedStatus returns the status of a subscription approved at now: APPROVED_PENDING_ACTIVATION
+// if scheduled activation is enabled and its validity starts later, SUBSCRIBED otherwise.
+func approvedStatus(cfg *ActivationConfig, sub *model.Subscription, now time.Time) model.SubscriptionStatus {
+	if cfg != nil && sub.ValidFrom.After(now) {
+		return model.SubscriptionStatusApprovedPendingActivation
+	}
+	return model.SubscriptionStatusSubscribed
+}
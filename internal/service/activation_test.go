@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockActivationRepo is a mock for activationRepo that returns one batch per call.
+type mockActivationRepo struct {
+	batches  [][]model.Subscription
+	err      error
+	calls    int
+	gotNow   time.Time
+	gotLimit int
+}
+
+func (m *mockActivationRepo) ActivateSubscriptions(ctx context.Context, now time.Time, limit int) ([]model.Subscription, error) {
+	m.calls++
+	m.gotNow = now
+	m.gotLimit = limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	if len(m.batches) == 0 {
+		return nil, nil
+	}
+	batch := m.batches[0]
+	m.batches = m.batches[1:]
+	return batch, nil
+}
+
+// mockActivationPublisher records the activation events published.
+type mockActivationPublisher struct {
+	err       error
+	published []string
+}
+
+func (m *mockActivationPublisher) PublishSubscriptionActivatedEvent(ctx context.Context, sub *model.Subscription) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.published = append(m.published, sub.SubscriberID)
+	return "ev-" + sub.SubscriberID, nil
+}
+
+func activatedSub(id string) model.Subscription {
+	return model.Subscription{Subscriber: model.Subscriber{SubscriberID: id, Domain: "retail", Type: model.RoleBPP}, Status: model.SubscriptionStatusSubscribed}
+}
+
+func TestNewActivationJob(t *testing.T) {
+	repo, pub := &mockActivationRepo{}, &mockActivationPublisher{}
+	tests := []struct {
+		name    string
+		repo    activationRepo
+		pub     activationEventPublisher
+		cfg     *ActivationConfig
+		wantErr bool
+	}{
+		{name: "defaults", repo: repo, pub: pub, cfg: &ActivationConfig{}},
+		{name: "nil repo", pub: pub, cfg: &ActivationConfig{}, wantErr: true},
+		{name: "nil publisher", repo: repo, cfg: &ActivationConfig{}, wantErr: true},
+		{name: "nil config", repo: repo, pub: pub, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := NewActivationJob(tt.repo, tt.pub, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewActivationJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (j.interval != defaultActivationInterval || j.batchSize != defaultActivationBatchSize) {
+				t.Errorf("NewActivationJob() interval, batchSize = %v, %d, want defaults", j.interval, j.batchSize)
+			}
+		})
+	}
+}
+
+func TestActivationJob_RunOnce(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mockActivationRepo{batches: [][]model.Subscription{
+		{activatedSub("np1"), activatedSub("np2")},
+		{activatedSub("np3")},
+	}}
+	pub := &mockActivationPublisher{}
+	j, _ := NewActivationJob(repo, pub, &ActivationConfig{BatchSize: 2})
+	j.now = func() time.Time { return now }
+
+	got, err := j.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if got != 3 {
+		t.Errorf("RunOnce() = %d, want 3", got)
+	}
+	if repo.calls != 2 {
+		t.Errorf("RunOnce() called the repository %d times, want 2 until a partial batch", repo.calls)
+	}
+	if !repo.gotNow.Equal(now) || repo.gotLimit != 2 {
+		t.Errorf("RunOnce() activated with (%v, %d), want (%v, 2)", repo.gotNow, repo.gotLimit, now)
+	}
+	if diff := cmp.Diff([]string{"np1", "np2", "np3"}, pub.published); diff != "" {
+		t.Errorf("RunOnce() published events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestActivationJob_RunOnce_PublishErrorDoesNotStop(t *testing.T) {
+	repo := &mockActivationRepo{batches: [][]model.Subscription{{activatedSub("np1"), activatedSub("np2")}}}
+	j, _ := NewActivationJob(repo, &mockActivationPublisher{err: errors.New("pubsub down")}, &ActivationConfig{})
+
+	if got, err := j.RunOnce(context.Background()); err != nil || got != 2 {
+		t.Errorf("RunOnce() = %d, %v, want 2, nil", got, err)
+	}
+}
+
+func TestActivationJob_RunOnce_RepoError(t *testing.T) {
+	j, _ := NewActivationJob(&mockActivationRepo{err: errors.New("db down")}, &mockActivationPublisher{}, &ActivationConfig{})
+
+	if _, err := j.RunOnce(context.Background()); err == nil {
+		t.Error("RunOnce() error = nil, want error")
+	}
+}
+
+func TestActivationJob_StartStop(t *testing.T) {
+	repo := &mockActivationRepo{}
+	j, _ := NewActivationJob(repo, &mockActivationPublisher{}, &ActivationConfig{Interval: time.Hour})
+	j.Start(context.Background())
+	j.Stop()
+	j.Stop()
+	select {
+	case <-j.done:
+	case <-time.After(time.Second):
+		t.Fatal("activation loop did not stop")
+	}
+	if repo.calls == 0 {
+		t.Error("Start() did not run an activation immediately")
+	}
+}
+
+func TestAdminService_ApproveSubscription_ScheduledActivation(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		activation *ActivationConfig
+		validFrom  time.Time
+		want       model.SubscriptionStatus
+		wantEvents []model.EventType
+	}{
+		{name: "future valid_from is held back", activation: &ActivationConfig{}, validFrom: now.Add(24 * time.Hour), want: model.SubscriptionStatusApprovedPendingActivation, wantEvents: []model.EventType{model.EventTypeSubscriptionApprovedPendingActivation}},
+		{name: "started valid_from is live", activation: &ActivationConfig{}, validFrom: now.Add(-time.Hour), want: model.SubscriptionStatusSubscribed},
+		{name: "no valid_from is live", activation: &ActivationConfig{}, want: model.SubscriptionStatusSubscribed},
+		{name: "disabled", validFrom: now.Add(24 * time.Hour), want: model.SubscriptionStatusSubscribed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subReq := &model.SubscriptionRequest{
+				Subscription: model.Subscription{
+					Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+					KeyID:            "key1",
+					EncrPublicKey:    "np-encr-pub-key",
+					SigningPublicKey: "np-signing-pub-key",
+					ValidFrom:        tt.validFrom,
+				},
+				MessageID: "op-1",
+			}
+			subReqJSON, _ := json.Marshal(subReq)
+			mockRepo := &mockRegRepo{
+				lroToReturn:        &model.LRO{OperationID: "op-1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+				subToReturn:        &subReq.Subscription,
+				updatedLROToReturn: &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved},
+			}
+			cfg := &AdminConfig{OperationRetryMax: 3, Activation: tt.activation}
+			s, _ := NewAdminService(mockRepo, &mockChallengeSrv{challengeToReturn: "c", verifyResult: true}, &mockEncryptionSrv{encryptedDataToReturn: "e"},
+				&mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "c"}}, &mockAdminEventPublisher{}, cfg)
+			s.now = func() time.Time { return now }
+			notifier := &recordingNotifier{}
+			s.SetTransitionNotifier(notifier)
+
+			if _, _, err := s.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op-1"}); err != nil {
+				t.Fatalf("ApproveSubscription() error = %v", err)
+			}
+			if mockRepo.gotSub == nil || mockRepo.gotSub.Status != tt.want {
+				t.Errorf("ApproveSubscription() stored status = %v, want %q", mockRepo.gotSub, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantEvents, notifier.events); diff != "" {
+				t.Errorf("ApproveSubscription() notified transitions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -54,17 +56,60 @@ type adminEventPublisher interface {
 	PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error)
 }
 
+// nonceConsumer marks a subscription request's nonce as used when it is approved.
+type nonceConsumer interface {
+	Consume(ctx context.Context, operationID, nonce string) error
+}
+
+// challengeAttemptRecorder logs the /on_subscribe challenges sent while approving operations.
+type challengeAttemptRecorder interface {
+	RecordChallengeAttempt(ctx context.Context, a *model.ChallengeAttempt) error
+}
+
+// transitionNotifier is notified of the LRO transitions that have no Pub/Sub event, so that
+// webhooks receive every transition. It is satisfied by the webhook service.
+type transitionNotifier interface {
+	Notify(ctx context.Context, tp model.EventType, lro *model.LRO)
+}
+
 type adminService struct {
-	cfg         *AdminConfig
-	regRepo     regRepo
-	chSrv       challengeSrv
-	encryptor   encrypterSrv
-	npClient    npClient
-	evPublisher adminEventPublisher
+	cfg           *AdminConfig
+	regRepo       regRepo
+	chSrv         challengeSrv
+	encryptor     encrypterSrv
+	npClient      npClient
+	evPublisher   adminEventPublisher
+	nonceConsumer nonceConsumer
+	attempts      challengeAttemptRecorder
+	transitions   transitionNotifier
+	now           func() time.Time
 }
 
 type AdminConfig struct {
-	OperationRetryMax int `yaml:"operationRetryMax"`
+	OperationRetryMax int              `yaml:"operationRetryMax"`
+	LROExpiry         *LROExpiryConfig `yaml:"lroExpiry"`
+	Nonce             *NonceConfig     `yaml:"nonce"`
+	Reviewer          *ReviewerConfig  `yaml:"reviewer"`
+	Webhooks          *WebhookConfig   `yaml:"webhooks"`
+	// RequireChallengeSignature fails approvals whose /on_subscribe response does not carry
+	// a signature over the challenge answer. A signature that is present is always verified.
+	RequireChallengeSignature bool `yaml:"requireChallengeSignature"`
+	// TwoPersonRule requires two distinct admins to approve high-risk operations if set.
+	TwoPersonRule *TwoPersonRuleConfig `yaml:"twoPersonRule"`
+	// Digest sends operators digests of stuck operations, failures and expiring subscriptions if set.
+	Digest *DigestConfig `yaml:"digest"`
+	// ValidityPolicy limits the validity of approved subscriptions per role if set.
+	ValidityPolicy *ValidityPolicyConfig `yaml:"validityPolicy"`
+	// Activation holds back subscriptions approved with a future valid_from until it starts if set.
+	Activation *ActivationConfig `yaml:"activation"`
+}
+
+// ReviewerConfig configures how the identity of the admin acting on an operation is obtained.
+type ReviewerConfig struct {
+	// Header is the request header, set by a trusted proxy, that carries the reviewer identity.
+	Header string `yaml:"header"`
+	// Required rejects actions that do not carry a reviewer identity.
+	Required bool `yaml:"required"`
 }
 
 // NewAdminService creates a new adminService.
@@ -99,7 +144,42 @@ func NewAdminService(regRepo regRepo, chSrv challengeSrv, encryptor encrypterSrv
 		slog.Error("NewAdminService: eventPublisher cannot be nil")
 		return nil, errors.New("eventPublisher cannot be nil")
 	}
-	return &adminService{regRepo: regRepo, chSrv: chSrv, encryptor: encryptor, npClient: npClient, evPublisher: evPub, cfg: cfg}, nil
+	if cfg.TwoPersonRule != nil {
+		if err := cfg.TwoPersonRule.validate(cfg.Reviewer); err != nil {
+			slog.Error("NewAdminService: Invalid two-person rule config", "error", err)
+			return nil, err
+		}
+	}
+	if cfg.ValidityPolicy != nil {
+		if err := cfg.ValidityPolicy.validate(); err != nil {
+			slog.Error("NewAdminService: Invalid validity policy config", "error", err)
+			return nil, err
+		}
+	}
+	return &adminService{regRepo: regRepo, chSrv: chSrv, encryptor: encryptor, npClient: npClient, evPublisher: evPub, cfg: cfg, now: time.Now}, nil
+}
+
+// SetNonceConsumer enables consuming subscription request nonces on approval.
+func (s *adminService) SetNonceConsumer(c nonceConsumer) {
+	s.nonceConsumer = c
+}
+
+// SetChallengeAttemptRecorder enables logging the outcome of every challenge sent on approval,
+// for the timeline of the operation.
+func (s *adminService) SetChallengeAttemptRecorder(r challengeAttemptRecorder) {
+	s.attempts = r
+}
+
+// SetTransitionNotifier enables notifying n of the LRO transitions that have no Pub/Sub event.
+func (s *adminService) SetTransitionNotifier(n transitionNotifier) {
+	s.transitions = n
+}
+
+// notify notifies the transition notifier, if any, that lro went through a transition of type tp.
+func (s *adminService) notify(ctx context.Context, tp model.EventType, lro *model.LRO) {
+	if s.transitions != nil {
+		s.transitions.Notify(ctx, tp, lro)
+	}
 }
 
 // ApproveSubscription approves a pending subscription LRO.
@@ -108,17 +188,43 @@ func (s *adminService) ApproveSubscription(ctx context.Context, req *model.Opera
 		slog.ErrorContext(ctx, "AdminService: OperationActionRequest cannot be nil")
 		return nil, nil, errors.New("OperationActionRequest cannot be nil")
 	}
-	if req.OperationID == "" {
-		slog.ErrorContext(ctx, "AdminService: OperationID cannot be empty")
-		return nil, nil, errors.New("OperationID cannot be empty")
-
+	if err := validateAction(req, model.OperationActionApproveSubscription); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Invalid approval request", "error", err)
+		return nil, nil, err
+	}
+	if req.DryRun {
+		slog.InfoContext(ctx, "AdminService: Starting subscription approval dry run", "operation_id", req.OperationID)
+		dry := *s
+		dry.regRepo = &dryRunRegRepo{regRepo: s.regRepo}
+		dry.attempts = nil
+		dry.transitions = nil
+		return dry.approveSubscription(ctx, req)
 	}
-	slog.InfoContext(ctx, "AdminService: Starting subscription approval process", "operation_id", req.OperationID)
+	slog.InfoContext(ctx, "AdminService: Starting subscription approval process", "operation_id", req.OperationID, "reviewer", req.Reviewer)
+	return s.approveSubscription(ctx, req)
+}
 
+// approveSubscription runs the approval flow for an LRO.
+// If the LRO requires two approvals, the first approval is only recorded and the LRO is
+// returned in the PENDING_SECOND_APPROVAL sub-state without a subscription.
+// In a dry run the flow stops after challenge verification and the subscription that
+// would have been stored is returned along with the unmodified LRO.
+// Otherwise the review is recorded on the LRO, whether the approval succeeds or fails.
+func (s *adminService) approveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error) {
+	dryRun := req.DryRun
 	lro, err := s.lro(ctx, req.OperationID)
 	if err != nil {
 		return nil, nil, err
 	}
+	if !dryRun {
+		prev := lro.Review
+		lro.Review = s.review(req, model.OperationActionApproveSubscription)
+		if s.requiresTwoApprovals(lro) {
+			if approved, err := s.recordApproval(ctx, lro, prev); err != nil || !approved {
+				return nil, lro, err
+			}
+		}
+	}
 	subReq, err := s.subReq(ctx, lro)
 	if err != nil {
 		return nil, nil, err
@@ -153,6 +259,19 @@ func (s *adminService) ApproveSubscription(ctx context.Context, req *model.Opera
 		}
 		return nil, nil, err
 	}
+	// An approved update that changes the key of the subscription rotates its keys.
+	var prevKeyID string
+	if len(subs) > 0 {
+		prevKeyID = subs[0].KeyID
+	}
+	override, err := s.overrideValidity(ctx, req, subReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	validity, err := s.applyValidityPolicy(ctx, lro, subReq)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	challenge, encryptedChallenge, err := s.challenge(ctx, lro, subReq.EncrPublicKey)
 	if err != nil {
@@ -160,17 +279,51 @@ func (s *adminService) ApproveSubscription(ctx context.Context, req *model.Opera
 		return nil, nil, err
 	}
 
+	sentAt := s.now().UTC()
 	onSubscribeResp, err := s.onSubscribe(ctx, lro, subReq, encryptedChallenge)
 	if err != nil {
+		s.recordChallenge(ctx, lro, subReq, sentAt, nil, err)
 		return nil, nil, err
 	}
 
 	if err := s.verifyChallenge(ctx, lro, challenge, onSubscribeResp.Answer); err != nil {
 		// verifyChallengeResponse logs and updates LRO
+		s.recordChallenge(ctx, lro, subReq, sentAt, onSubscribeResp, err)
+		return nil, nil, err
+	}
+	if err := s.verifySigningKey(ctx, lro, subReq, onSubscribeResp); err != nil {
+		s.recordChallenge(ctx, lro, subReq, sentAt, onSubscribeResp, err)
+		return nil, nil, err
+	}
+	s.recordChallenge(ctx, lro, subReq, sentAt, onSubscribeResp, nil)
+
+	if dryRun {
+		slog.InfoContext(ctx, "AdminService: Approval dry run succeeded, no changes persisted", "operation_id", lro.OperationID)
+		subReq.Status = approvedStatus(s.cfg.Activation, &subReq.Subscription, s.now())
+		return &subReq.Subscription, lro, nil
+	}
+	if err := s.consumeNonce(ctx, lro, subReq); err != nil {
 		return nil, nil, err
 	}
+	return s.approve(ctx, lro, subReq, model.ApprovalResult{ValidityPolicy: validity, ValidityOverride: override}, prevKeyID)
+}
+
+// dryRunRegRepo passes reads through to the wrapped repository and discards all writes,
+// so that failures during an approval dry run do not mutate the LRO.
+type dryRunRegRepo struct {
+	regRepo
+}
 
-	return s.approve(ctx, lro, subReq)
+// UpdateOperation returns the LRO unchanged without persisting it.
+func (r *dryRunRegRepo) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	slog.InfoContext(ctx, "AdminService: Dry run, skipping LRO update", "operation_id", lro.OperationID, "status", lro.Status)
+	return lro, nil
+}
+
+// UpsertSubscriptionAndLRO returns its inputs without persisting them.
+func (r *dryRunRegRepo) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
+	slog.InfoContext(ctx, "AdminService: Dry run, skipping subscription upsert", "operation_id", lro.OperationID)
+	return sub, lro, nil
 }
 
 // lro retrieves the LRO and performs initial validations.
@@ -210,17 +363,9 @@ func (s *adminService) subReq(ctx context.Context, lro *model.LRO) (*model.Subsc
 		return nil, err
 	}
 
-	if subReq.URL == "" {
-		slog.ErrorContext(ctx, "AdminService: Callback URL missing in subscription request", "operation_id", lro.OperationID)
-		err := errors.New("callback URL missing in subscription request")
-		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
-			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
-		}
-		return nil, err
-	}
-	if subReq.EncrPublicKey == "" {
-		slog.ErrorContext(ctx, "AdminService: Encryption public key missing in subscription request", "operation_id", lro.OperationID)
-		err := errors.New("encryption public key missing")
+	if err := subReq.Validate(); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Invalid subscription request", "operation_id", lro.OperationID, "error", err)
+		err := fmt.Errorf("invalid subscription request: %w", err)
 		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
 			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
 		}
@@ -229,6 +374,13 @@ func (s *adminService) subReq(ctx context.Context, lro *model.LRO) (*model.Subsc
 	return &subReq, nil
 }
 
+// validateAction validates req as a request for action, which is implied by the service method called.
+func validateAction(req *model.OperationActionRequest, action model.OperationAction) error {
+	r := *req
+	r.Action = action
+	return r.Validate()
+}
+
 // challenge handles challenge generation and encryption.
 func (s *adminService) challenge(ctx context.Context, lro *model.LRO, subscriberEncrPublicKey string) (string, string, error) {
 	challenge, err := s.chSrv.NewChallenge()
@@ -268,6 +420,24 @@ func (s *adminService) onSubscribe(ctx context.Context, lro *model.LRO, subReq *
 	return onSubscribeResp, nil
 }
 
+// recordChallenge logs the outcome of a challenge sent at sentAt, if enabled. resp is nil if the
+// subscriber did not answer. A failure to log it does not fail the approval.
+func (s *adminService) recordChallenge(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest, sentAt time.Time, resp *model.OnSubscribeResponse, challengeErr error) {
+	if s.attempts == nil {
+		return
+	}
+	a := &model.ChallengeAttempt{OperationID: lro.OperationID, URL: subReq.URL, Passed: challengeErr == nil, AttemptedAt: sentAt}
+	if resp != nil {
+		a.Signed = resp.Signature != ""
+	}
+	if challengeErr != nil {
+		a.Error = challengeErr.Error()
+	}
+	if err := s.attempts.RecordChallengeAttempt(ctx, a); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to record challenge attempt", "operation_id", lro.OperationID, "error", err)
+	}
+}
+
 // verifyChallenge verifies the NP's answer to the challenge.
 func (s *adminService) verifyChallenge(ctx context.Context, lro *model.LRO, challenge, answer string) error {
 	if !s.chSrv.Verify(challenge, answer) {
@@ -282,22 +452,142 @@ func (s *adminService) verifyChallenge(ctx context.Context, lro *model.LRO, chal
 	return nil
 }
 
+// verifySigningKey checks the NP's signature over the challenge answer against the signing
+// public key in the subscription request, so that an NP cannot be onboarded with a signing
+// key that does not match the private key it signs requests with.
+func (s *adminService) verifySigningKey(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest, resp *model.OnSubscribeResponse) error {
+	var err error
+	switch {
+	case resp.Signature != "":
+		err = verifyChallengeSignature(resp.Answer, resp.Signature, subReq.SigningPublicKey)
+	case s.cfg.RequireChallengeSignature:
+		err = fmt.Errorf("%w: /on_subscribe response is not signed", ErrChallengeSignature)
+	default:
+		slog.WarnContext(ctx, "AdminService: /on_subscribe response is not signed, skipping signing key verification", "operation_id", lro.OperationID)
+		return nil
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "AdminService: Signing key verification failed", "operation_id", lro.OperationID, "error", err)
+		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusFailure); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return err
+	}
+	slog.InfoContext(ctx, "AdminService: Signing key verification successful", "operation_id", lro.OperationID)
+	return nil
+}
+
+// consumeNonce marks the request's nonce as used. A nonce that was replayed or has expired
+// can never become valid again, so the LRO is rejected.
+func (s *adminService) consumeNonce(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest) error {
+	if s.nonceConsumer == nil {
+		return nil
+	}
+	if err := s.nonceConsumer.Consume(ctx, lro.OperationID, subReq.Nonce); err != nil {
+		slog.WarnContext(ctx, "AdminService: Nonce rejected on approval", "operation_id", lro.OperationID, "error", err)
+		err = fmt.Errorf("invalid nonce: %w", err)
+		status := model.LROStatusFailure
+		if errors.Is(err, repository.ErrNonceReplayed) || errors.Is(err, repository.ErrNonceExpired) || errors.Is(err, repository.ErrNonceNotFound) {
+			status = model.LROStatusRejected
+		}
+		if updateErr := s.updateLROError(ctx, lro, err, status); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with nonce error", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// review records the admin's action on an operation.
+func (s *adminService) review(req *model.OperationActionRequest, action model.OperationAction) *model.OperationReview {
+	return &model.OperationReview{Reviewer: req.Reviewer, Action: action, Comment: req.Comment, ReviewedAt: s.now().UTC()}
+}
+
+// overrideValidity replaces the requested validity window with the one set by the approver, if any.
+// The approver's valid_until must be within the validity policy; unlike a requested one, it is
+// never clamped, and a violation fails the approval without changing the LRO.
+func (s *adminService) overrideValidity(ctx context.Context, req *model.OperationActionRequest, subReq *model.SubscriptionRequest) (*model.ValidityOverrideResult, error) {
+	if req.ValidFrom.IsZero() && req.ValidUntil.IsZero() {
+		return nil, nil
+	}
+	res := &model.ValidityOverrideResult{
+		Approver:            req.Reviewer,
+		RequestedValidFrom:  subReq.ValidFrom,
+		RequestedValidUntil: subReq.ValidUntil,
+	}
+	if !req.ValidFrom.IsZero() {
+		subReq.ValidFrom = req.ValidFrom.UTC()
+	}
+	if !req.ValidUntil.IsZero() {
+		subReq.ValidUntil = req.ValidUntil.UTC()
+	}
+	res.ValidFrom, res.ValidUntil = subReq.ValidFrom, subReq.ValidUntil
+	if !subReq.ValidFrom.IsZero() && !subReq.ValidUntil.IsZero() && !subReq.ValidUntil.After(subReq.ValidFrom) {
+		slog.ErrorContext(ctx, "AdminService: Overridden validity window is empty", "operation_id", req.OperationID, "valid_from", subReq.ValidFrom, "valid_until", subReq.ValidUntil)
+		return nil, &model.ValidationError{Fields: []model.FieldError{{Field: "valid_until", Message: "must be after valid_from"}}}
+	}
+	if s.cfg.ValidityPolicy != nil && !req.ValidUntil.IsZero() {
+		if limit, maxValidity, ok := s.cfg.ValidityPolicy.limit(&subReq.Subscription, s.now()); ok && subReq.ValidUntil.After(limit) {
+			slog.ErrorContext(ctx, "AdminService: Overridden valid_until violates validity policy", "operation_id", req.OperationID, "type", subReq.Type, "valid_until", subReq.ValidUntil, "limit", limit)
+			return nil, validUntilBeyondLimit(subReq.Type, limit, maxValidity)
+		}
+	}
+	slog.InfoContext(ctx, "AdminService: Approver overrode validity window", "operation_id", req.OperationID, "reviewer", req.Reviewer, "valid_from", res.ValidFrom, "valid_until", res.ValidUntil)
+	return res, nil
+}
+
+// applyValidityPolicy enforces the validity policy on the requested subscription when one is set.
+// A rejection is recorded on the LRO.
+func (s *adminService) applyValidityPolicy(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest) (*model.ValidityPolicyResult, error) {
+	if s.cfg.ValidityPolicy == nil {
+		return nil, nil
+	}
+	res, err := s.cfg.ValidityPolicy.apply(&subReq.Subscription, s.now())
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminService: Subscription violates validity policy", "operation_id", lro.OperationID, "type", subReq.Type, "error", err)
+		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with rejected status", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return nil, err
+	}
+	if res != nil && res.Clamped {
+		slog.InfoContext(ctx, "AdminService: Clamped valid_until to validity policy", "operation_id", lro.OperationID, "type", subReq.Type, "requested_valid_until", res.RequestedValidUntil, "valid_until", res.ValidUntil)
+	}
+	return res, nil
+}
+
 // approve updates subscription and LRO status to approved/succeeded.
-func (s *adminService) approve(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest) (*model.Subscription, *model.LRO, error) {
-	subReq.Status = model.SubscriptionStatusSubscribed
+// The validity override and the applied validity policy, if any, are recorded as the LRO result.
+// prevKeyID is the key of the subscription before the approval, if it existed.
+func (s *adminService) approve(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest, res model.ApprovalResult, prevKeyID string) (*model.Subscription, *model.LRO, error) {
+	subReq.Status = approvedStatus(s.cfg.Activation, &subReq.Subscription, s.now())
 	lro.Status = model.LROStatusApproved
+	if res.ValidityPolicy != nil || res.ValidityOverride != nil {
+		result, err := json.Marshal(res)
+		if err != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to marshal approval result", "operation_id", lro.OperationID, "error", err)
+			return nil, lro, fmt.Errorf("failed to marshal approval result: %w", err)
+		}
+		lro.ResultJSON = result
+	}
 	sub, updatedLRO, err := s.regRepo.UpsertSubscriptionAndLRO(ctx, &subReq.Subscription, lro)
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminService: Failed to upsert subscription and update LRO", "operation_id", lro.OperationID, "error", err)
 		return nil, lro, err
 	}
-	slog.InfoContext(ctx, "AdminService: Subscription approved and LRO updated successfully", "operation_id", updatedLRO.OperationID)
+	slog.InfoContext(ctx, "AdminService: Subscription approved and LRO updated successfully", "operation_id", updatedLRO.OperationID, "status", subReq.Status)
 	evID, err := s.evPublisher.PublishSubscriptionRequestApprovedEvent(ctx, updatedLRO)
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminService: Failed to publish subscription approved event", "error", err)
 	} else {
 		slog.InfoContext(ctx, "AdminService: Published subscription approved event", "operation_id", updatedLRO.OperationID, "event_id", evID)
 	}
+	if subReq.Status == model.SubscriptionStatusApprovedPendingActivation {
+		s.notify(ctx, model.EventTypeSubscriptionApprovedPendingActivation, updatedLRO)
+	}
+	if prevKeyID != "" && prevKeyID != subReq.KeyID {
+		s.notify(ctx, model.EventTypeKeyRotated, updatedLRO)
+	}
 	return sub, updatedLRO, nil
 }
 func (s *adminService) updateLROError(ctx context.Context, lro *model.LRO, originalErr error, status model.LROStatus) error {
@@ -319,6 +609,9 @@ func (s *adminService) updateLROError(ctx context.Context, lro *model.LRO, origi
 		// If this fails, we're in a bad state, but we should still return the original processing error.
 		return fmt.Errorf("failed to update LRO status after processing error: %w (original error: %v)", updateErr, originalErr)
 	}
+	if tp, ok := lroTransitionEvent(lro); ok {
+		s.notify(ctx, tp, lro)
+	}
 	return nil
 }
 
@@ -329,24 +622,21 @@ func (s *adminService) RejectSubscription(ctx context.Context, req *model.Operat
 		slog.ErrorContext(ctx, "AdminService: OperationActionRequest cannot be nil")
 		return nil, errors.New("OperationActionRequest cannot be nil")
 	}
-	if req.OperationID == "" {
-		slog.ErrorContext(ctx, "AdminService: OperationID cannot be empty")
-		return nil, errors.New("OperationID cannot be empty")
-	}
-	if req.Reason == "" {
-		slog.ErrorContext(ctx, "AdminService: Reason cannot be empty")
-		return nil, errors.New("reason cannot be empty")
+	if err := validateAction(req, model.OperationActionRejectSubscription); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Invalid rejection request", "error", err)
+		return nil, err
 	}
 	operationID := req.OperationID
 	reason := req.Reason
 
-	slog.InfoContext(ctx, "LROService: Rejecting subscription", "operation_id", operationID, "reason", reason)
+	slog.InfoContext(ctx, "LROService: Rejecting subscription", "operation_id", operationID, "reason", reason, "reviewer", req.Reviewer)
 
 	lro, err := s.lro(ctx, operationID)
 	if err != nil {
 		return nil, err
 	}
 	lro.Status = model.LROStatusRejected
+	lro.Review = s.review(req, model.OperationActionRejectSubscription)
 	errorPayload := map[string]string{"reason": reason}
 	resJson, err := json.Marshal(errorPayload)
 	if err != nil {
@@ -31,16 +31,30 @@ import (
 
 // mockAdminEventPublisher is a mock implementation of adminEventPublisher.
 type mockAdminEventPublisher struct {
-	msgID      string
-	err        error
+	msgID         string
+	err           error
+	approvedCalls int
 }
 
 func (m *mockAdminEventPublisher) PublishSubscriptionRequestApprovedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	m.approvedCalls++
 	return m.msgID, m.err
 }
 func (m *mockAdminEventPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error) {
 	return m.msgID, m.err
 }
+func (m *mockAdminEventPublisher) PublishSubscriptionActivatedEvent(ctx context.Context, sub *model.Subscription) (string, error) {
+	return m.msgID, m.err
+}
+
+// recordingNotifier records the transitions it is notified of.
+type recordingNotifier struct {
+	events []model.EventType
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, tp model.EventType, lro *model.LRO) {
+	n.events = append(n.events, tp)
+}
 
 // mockRegRepo is a mock implementation of regRepo interface.
 type mockRegRepo struct {
@@ -52,6 +66,10 @@ type mockRegRepo struct {
 	lookupSubsToReturn          []model.Subscription
 	lookupErr                   error
 	updatedLROToReturn          *model.LRO // For UpdateOperation and Upsert
+	updateOperationCalls        int
+	upsertCalls                 int
+	gotLRO                      *model.LRO          // LRO passed to the last UpdateOperation or Upsert
+	gotSub                      *model.Subscription // Subscription passed to the last Upsert
 }
 
 func (m *mockRegRepo) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
@@ -59,10 +77,15 @@ func (m *mockRegRepo) GetOperation(ctx context.Context, operationID string) (*mo
 }
 
 func (m *mockRegRepo) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	m.updateOperationCalls++
+	m.gotLRO = lro
 	return m.updatedLROToReturn, m.updateOperationErr
 }
 
 func (m *mockRegRepo) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
+	m.upsertCalls++
+	m.gotLRO = lro
+	m.gotSub = sub
 	return m.subToReturn, m.updatedLROToReturn, m.upsertSubscriptionAndLROErr
 }
 
@@ -161,8 +184,9 @@ func TestAdminService_ApproveSubscription_Success(t *testing.T) {
 				Type:         model.RoleBAP,
 				Domain:       "retail",
 			},
-			KeyID:         "key1",
-			EncrPublicKey: "np-encr-pub-key",
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
 		},
 		MessageID: opID,
 	}
@@ -269,9 +293,10 @@ func TestAdminService_ApproveSubscription_Error(t *testing.T) {
 	now := time.Now()
 	validSubReq := &model.SubscriptionRequest{
 		Subscription: model.Subscription{
-			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
-			KeyID:         "key1",
-			EncrPublicKey: "np-encr-pub-key",
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
 		},
 		MessageID: opID,
 	}
@@ -366,7 +391,7 @@ func TestAdminService_ApproveSubscription_Error(t *testing.T) {
 				lro.RequestJSON = badSubReqJSON
 				m.lroToReturn = lro
 			},
-			wantErrMsgContains: "callback URL missing in subscription request",
+			wantErrMsgContains: "invalid subscription request: url is required",
 			wantLROStatus:      model.LROStatusRejected,
 		},
 		{
@@ -380,7 +405,7 @@ func TestAdminService_ApproveSubscription_Error(t *testing.T) {
 				lro.RequestJSON = badSubReqJSON
 				m.lroToReturn = lro
 			},
-			wantErrMsgContains: "encryption public key missing",
+			wantErrMsgContains: "invalid subscription request: encr_public_key is required",
 			wantLROStatus:      model.LROStatusRejected,
 		},
 		{
@@ -696,7 +721,7 @@ func TestAdminService_RejectSubscription_Error(t *testing.T) {
 			mockRepoSetup: func(m *mockRegRepo) {
 				m.lroToReturn = baseLRO()
 			},
-			wantErrMsgContains: "OperationID cannot be empty",
+			wantErrMsgContains: "operation_id is required",
 			req:                &model.OperationActionRequest{OperationID: "", Reason: reason},
 		},
 		{
@@ -704,7 +729,7 @@ func TestAdminService_RejectSubscription_Error(t *testing.T) {
 			mockRepoSetup: func(m *mockRegRepo) {
 				m.lroToReturn = baseLRO()
 			},
-			wantErrMsgContains: "reason cannot be empty",
+			wantErrMsgContains: "reason is required",
 			req:                &model.OperationActionRequest{OperationID: opID, Reason: ""},
 		},
 	}
@@ -734,3 +759,462 @@ func TestAdminService_RejectSubscription_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestAdminService_ApproveSubscription_DryRun(t *testing.T) {
+	opID := "test-op-dry-run"
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "sub1",
+				URL:          "http://np.com",
+				Type:         model.RoleBAP,
+				Domain:       "retail",
+			},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+		},
+		MessageID: opID,
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	newLRO := func() *model.LRO {
+		return &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+	}
+
+	tests := []struct {
+		name        string
+		chSrv       *mockChallengeSrv
+		npClient    *mockNPClient
+		wantErr     bool
+		wantSubStat model.SubscriptionStatus
+	}{
+		{
+			name:        "ready",
+			chSrv:       &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true},
+			npClient:    &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}},
+			wantSubStat: model.SubscriptionStatusSubscribed,
+		},
+		{
+			name:     "verification fails",
+			chSrv:    &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: false},
+			npClient: &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "wrong"}},
+			wantErr:  true,
+		},
+		{
+			name:     "on_subscribe fails",
+			chSrv:    &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true},
+			npClient: &mockNPClient{onSubscribeErr: errors.New("connection refused")},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := newLRO()
+			mockRepo := &mockRegRepo{lroToReturn: lro}
+			evPub := &mockAdminEventPublisher{}
+			srv, _ := NewAdminService(mockRepo, tt.chSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, tt.npClient, evPub, &AdminConfig{OperationRetryMax: 3})
+
+			gotSub, gotLRO, err := srv.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: opID, DryRun: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApproveSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mockRepo.updateOperationCalls != 0 || mockRepo.upsertCalls != 0 {
+				t.Errorf("dry run wrote to repository: updates=%d upserts=%d", mockRepo.updateOperationCalls, mockRepo.upsertCalls)
+			}
+			if evPub.approvedCalls != 0 {
+				t.Errorf("dry run published %d approved events, want 0", evPub.approvedCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotSub.Status != tt.wantSubStat || gotSub.SubscriberID != "sub1" {
+				t.Errorf("ApproveSubscription() sub = %+v, want subscriber sub1 with status %s", gotSub, tt.wantSubStat)
+			}
+			if diff := cmp.Diff(newLRO(), gotLRO); diff != "" {
+				t.Errorf("dry run modified the LRO (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// mockNonceConsumer is a mock implementation of nonceConsumer.
+type mockNonceConsumer struct {
+	err      error
+	gotOpID  string
+	gotNonce string
+	calls    int
+}
+
+func (m *mockNonceConsumer) Consume(ctx context.Context, operationID, nonce string) error {
+	m.calls++
+	m.gotOpID, m.gotNonce = operationID, nonce
+	return m.err
+}
+
+func TestAdminService_ApproveSubscription_Nonce(t *testing.T) {
+	opID := "test-op-nonce"
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+			Nonce:            "nonce-1",
+		},
+		MessageID: opID,
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+
+	tests := []struct {
+		name       string
+		consumeErr error
+		wantErr    error
+		wantStatus model.LROStatus
+		wantUpsert int
+	}{
+		{name: "nonce consumed", wantUpsert: 1},
+		{name: "nonce replayed", consumeErr: repository.ErrNonceReplayed, wantErr: repository.ErrNonceReplayed, wantStatus: model.LROStatusRejected},
+		{name: "nonce expired", consumeErr: repository.ErrNonceExpired, wantErr: repository.ErrNonceExpired, wantStatus: model.LROStatusRejected},
+		{name: "repository failure", consumeErr: errors.New("db down"), wantErr: errors.New("db down"), wantStatus: model.LROStatusFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro, updatedLROToReturn: lro, subToReturn: &subReq.Subscription}
+			chSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			np := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			srv, _ := NewAdminService(mockRepo, chSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, np, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+			nc := &mockNonceConsumer{err: tt.consumeErr}
+			srv.SetNonceConsumer(nc)
+
+			_, _, err := srv.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: opID})
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if nc.calls != 1 || nc.gotOpID != opID || nc.gotNonce != "nonce-1" {
+				t.Errorf("Consume() called %d times with (%s, %s), want once with (%s, nonce-1)", nc.calls, nc.gotOpID, nc.gotNonce, opID)
+			}
+			if mockRepo.upsertCalls != tt.wantUpsert {
+				t.Errorf("UpsertSubscriptionAndLRO() calls = %d, want %d", mockRepo.upsertCalls, tt.wantUpsert)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if errors.Is(tt.wantErr, repository.ErrNonceReplayed) || errors.Is(tt.wantErr, repository.ErrNonceExpired) {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+				}
+			}
+			if lro.Status != tt.wantStatus {
+				t.Errorf("LRO status = %s, want %s", lro.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_DryRunSkipsNonce(t *testing.T) {
+	opID := "test-op-nonce-dry-run"
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+			Nonce:            "nonce-1",
+		},
+		MessageID: opID,
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	lro := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+	chSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+	np := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+	srv, _ := NewAdminService(&mockRegRepo{lroToReturn: lro}, chSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, np, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+	nc := &mockNonceConsumer{}
+	srv.SetNonceConsumer(nc)
+
+	if _, _, err := srv.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: opID, DryRun: true}); err != nil {
+		t.Fatalf("ApproveSubscription() error = %v", err)
+	}
+	if nc.calls != 0 {
+		t.Errorf("Consume() calls = %d, want 0 for a dry run", nc.calls)
+	}
+}
+
+// mockChallengeAttemptRecorder is a mock for challengeAttemptRecorder.
+type mockChallengeAttemptRecorder struct {
+	err      error
+	attempts []model.ChallengeAttempt
+}
+
+func (m *mockChallengeAttemptRecorder) RecordChallengeAttempt(ctx context.Context, a *model.ChallengeAttempt) error {
+	m.attempts = append(m.attempts, *a)
+	return m.err
+}
+
+func TestAdminService_ApproveSubscription_ChallengeAttempts(t *testing.T) {
+	opID := "test-op-challenge"
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+		},
+		MessageID: opID,
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+
+	tests := []struct {
+		name      string
+		np        *mockNPClient
+		verify    bool
+		recordErr error
+		dryRun    bool
+		want      []model.ChallengeAttempt
+	}{
+		{
+			name:   "passed",
+			np:     &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}},
+			verify: true,
+			want:   []model.ChallengeAttempt{{OperationID: opID, URL: "http://np.com", Passed: true, AttemptedAt: now}},
+		},
+		{
+			name:   "wrong answer",
+			np:     &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "wrong"}},
+			verify: false,
+			want:   []model.ChallengeAttempt{{OperationID: opID, URL: "http://np.com", AttemptedAt: now}},
+		},
+		{
+			name: "no answer",
+			np:   &mockNPClient{onSubscribeErr: errors.New("connection refused")},
+			want: []model.ChallengeAttempt{{OperationID: opID, URL: "http://np.com", AttemptedAt: now}},
+		},
+		{
+			name:      "recorder failure does not fail the approval",
+			np:        &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}},
+			verify:    true,
+			recordErr: errors.New("db down"),
+			want:      []model.ChallengeAttempt{{OperationID: opID, URL: "http://np.com", Passed: true, AttemptedAt: now}},
+		},
+		{
+			name:   "dry run",
+			np:     &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}},
+			verify: true,
+			dryRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro, updatedLROToReturn: lro, subToReturn: &subReq.Subscription}
+			chSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: tt.verify}
+			srv, _ := NewAdminService(mockRepo, chSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, tt.np, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+			srv.now = func() time.Time { return now }
+			rec := &mockChallengeAttemptRecorder{err: tt.recordErr}
+			srv.SetChallengeAttemptRecorder(rec)
+
+			_, _, err := srv.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: opID, DryRun: tt.dryRun})
+			if (err != nil) != !tt.verify {
+				t.Fatalf("ApproveSubscription() error = %v, want error %v", err, !tt.verify)
+			}
+			got := rec.attempts
+			for i := range got {
+				// The error of a failed attempt is whatever the service wrapped, so only its presence is checked.
+				if (got[i].Error != "") == got[i].Passed {
+					t.Errorf("attempt %d: Passed = %t with Error %q", i, got[i].Passed, got[i].Error)
+				}
+				got[i].Error = ""
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("recorded attempts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminService_Review(t *testing.T) {
+	opID := "test-op-review"
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+		},
+		MessageID: opID,
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+
+	tests := []struct {
+		name       string
+		req        *model.OperationActionRequest
+		wantAction model.OperationAction
+	}{
+		{
+			name:       "approve",
+			req:        &model.OperationActionRequest{OperationID: opID, Action: model.OperationActionApproveSubscription, Comment: "documents verified", Reviewer: "admin@example.com"},
+			wantAction: model.OperationActionApproveSubscription,
+		},
+		{
+			name:       "reject",
+			req:        &model.OperationActionRequest{OperationID: opID, Action: model.OperationActionRejectSubscription, Reason: "bad", Comment: "domain mismatch", Reviewer: "admin@example.com"},
+			wantAction: model.OperationActionRejectSubscription,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro, subToReturn: &model.Subscription{}, updatedLROToReturn: lro}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			srv, _ := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, mockNpCli, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+			srv.now = func() time.Time { return now }
+
+			var err error
+			if tt.wantAction == model.OperationActionApproveSubscription {
+				_, _, err = srv.ApproveSubscription(context.Background(), tt.req)
+			} else {
+				_, err = srv.RejectSubscription(context.Background(), tt.req)
+			}
+			if err != nil {
+				t.Fatalf("%s unexpected error: %v", tt.wantAction, err)
+			}
+
+			want := &model.OperationReview{Reviewer: "admin@example.com", Action: tt.wantAction, Comment: tt.req.Comment, ReviewedAt: now}
+			if mockRepo.gotLRO == nil {
+				t.Fatal("LRO was not written to the repository")
+			}
+			if diff := cmp.Diff(want, mockRepo.gotLRO.Review); diff != "" {
+				t.Errorf("LRO review mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminService_VerifySigningKey(t *testing.T) {
+	pub, priv := testSigningKeys(t)
+	otherPub, _ := testSigningKeys(t)
+	sig, err := signChallenge("challenge123", priv)
+	if err != nil {
+		t.Fatalf("signChallenge() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		require    bool
+		publicKey  string
+		signature  string
+		wantErr    bool
+		wantUpdate bool
+	}{
+		{name: "valid signature", publicKey: pub, signature: sig},
+		{name: "valid signature when required", require: true, publicKey: pub, signature: sig},
+		{name: "unsigned response when optional", publicKey: pub},
+		{name: "unsigned response when required", require: true, publicKey: pub, wantErr: true, wantUpdate: true},
+		{name: "mismatched signing key", publicKey: otherPub, signature: sig, wantErr: true, wantUpdate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRegRepo{}
+			cfg := &AdminConfig{OperationRetryMax: 3, RequireChallengeSignature: tt.require}
+			s, err := NewAdminService(repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+			subReq := &model.SubscriptionRequest{Subscription: model.Subscription{SigningPublicKey: tt.publicKey}}
+			resp := &model.OnSubscribeResponse{Answer: "challenge123", Signature: tt.signature}
+
+			err = s.verifySigningKey(context.Background(), &model.LRO{OperationID: "op1"}, subReq, resp)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("verifySigningKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrChallengeSignature) {
+				t.Errorf("verifySigningKey() error = %v, want %v", err, ErrChallengeSignature)
+			}
+			if got := repo.updateOperationCalls > 0; got != tt.wantUpdate {
+				t.Errorf("verifySigningKey() updated LRO = %v, want %v", got, tt.wantUpdate)
+			}
+			if tt.wantUpdate && repo.gotLRO.Status != model.LROStatusFailure {
+				t.Errorf("verifySigningKey() LRO status = %v, want %v", repo.gotLRO.Status, model.LROStatusFailure)
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_KeyRotated(t *testing.T) {
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key2",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+		},
+		MessageID: "op-1",
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	tests := []struct {
+		name       string
+		prevKeyID  string
+		wantEvents []model.EventType
+	}{
+		{name: "new key", prevKeyID: "key1", wantEvents: []model.EventType{model.EventTypeKeyRotated}},
+		{name: "same key", prevKeyID: "key2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := subReq.Subscription
+			prev.KeyID = tt.prevKeyID
+			mockRepo := &mockRegRepo{
+				lroToReturn:        &model.LRO{OperationID: "op-1", Type: model.OperationTypeUpdateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+				subToReturn:        &subReq.Subscription,
+				updatedLROToReturn: &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved},
+				lookupSubsToReturn: []model.Subscription{prev},
+			}
+			s, _ := NewAdminService(mockRepo, &mockChallengeSrv{challengeToReturn: "c", verifyResult: true}, &mockEncryptionSrv{encryptedDataToReturn: "e"},
+				&mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "c"}}, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+			notifier := &recordingNotifier{}
+			s.SetTransitionNotifier(notifier)
+
+			if _, _, err := s.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op-1"}); err != nil {
+				t.Fatalf("ApproveSubscription() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantEvents, notifier.events); diff != "" {
+				t.Errorf("ApproveSubscription() notified transitions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminService_UpdateLROError_NotifiesTransition(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryCount int
+		status     model.LROStatus
+		want       model.EventType
+	}{
+		{name: "failure", status: model.LROStatusFailure, want: model.EventTypeSubscriptionRequestFailed},
+		{name: "rejection", status: model.LROStatusRejected, want: model.EventTypeSubscriptionRequestRejected},
+		{name: "retries exhausted", retryCount: 3, status: model.LROStatusFailure, want: model.EventTypeSubscriptionRequestRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: "op-1", Status: model.LROStatusPending, RetryCount: tt.retryCount}
+			s, _ := NewAdminService(&mockRegRepo{updatedLROToReturn: lro}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+			notifier := &recordingNotifier{}
+			s.SetTransitionNotifier(notifier)
+
+			if err := s.updateLROError(context.Background(), lro, errors.New("boom"), tt.status); err != nil {
+				t.Fatalf("updateLROError() error = %v", err)
+			}
+			if diff := cmp.Diff([]model.EventType{tt.want}, notifier.events); diff != "" {
+				t.Errorf("updateLROError() notified transitions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/uuid"
+)
+
+// API key errors.
+var (
+	ErrInvalidAPIKey      = errors.New("invalid or revoked API key")
+	ErrSubscriberNotFound = errors.New("subscriber not found")
+)
+
+const (
+	// apiKeyBytes is the number of random bytes in an API key.
+	apiKeyBytes = 32
+	// defaultSubscriberOperationsLimit is the number of operations listed when no limit is given.
+	defaultSubscriberOperationsLimit = 20
+	// maxSubscriberOperationsLimit is the largest number of operations that can be listed at once.
+	maxSubscriberOperationsLimit = 100
+)
+
+// apiKeyRepository defines the repository operations needed to manage API keys
+// and to serve the records of their subscribers.
+type apiKeyRepository interface {
+	InsertAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context, subscriberID string) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, subscriberID, keyID string) error
+	Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
+	GetOperation(ctx context.Context, id string) (*model.LRO, error)
+	ListSubscriberOperations(ctx context.Context, subscriberID string, limit int) ([]model.LRO, error)
+}
+
+// apiKeyService issues per-subscriber API keys and serves read-only requests authenticated with them.
+type apiKeyService struct {
+	repo apiKeyRepository
+}
+
+// NewAPIKeyService creates a new apiKeyService.
+func NewAPIKeyService(repo apiKeyRepository) (*apiKeyService, error) {
+	if repo == nil {
+		slog.Error("NewAPIKeyService: apiKeyRepository cannot be nil")
+		return nil, errors.New("apiKeyRepository cannot be nil")
+	}
+	return &apiKeyService{repo: repo}, nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 hash under which an API key is stored.
+// API keys are random, so an unsalted fast hash is sufficient.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue creates a new API key for a subscriber. The returned key is not stored and cannot be retrieved again.
+func (s *apiKeyService) Issue(ctx context.Context, subscriberID string) (*model.IssuedAPIKey, error) {
+	if subscriberID == "" {
+		return nil, ErrMissingSubscriberID
+	}
+	subs, err := s.repo.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: subscriberID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up subscriber %s: %w", subscriberID, err)
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSubscriberNotFound, subscriberID)
+	}
+
+	raw := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	key, err := s.repo.InsertAPIKey(ctx, &model.APIKey{
+		KeyID:        uuid.NewString(),
+		SubscriberID: subscriberID,
+		Hash:         hashAPIKey(secret),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "APIKeyService: Failed to store API key", "subscriber_id", subscriberID, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "APIKeyService: API key issued", "subscriber_id", subscriberID, "key_id", key.KeyID)
+	return &model.IssuedAPIKey{APIKey: *key, Key: secret}, nil
+}
+
+// List returns the API keys issued to a subscriber.
+func (s *apiKeyService) List(ctx context.Context, subscriberID string) ([]model.APIKey, error) {
+	if subscriberID == "" {
+		return nil, ErrMissingSubscriberID
+	}
+	return s.repo.ListAPIKeys(ctx, subscriberID)
+}
+
+// Revoke revokes an API key of a subscriber.
+func (s *apiKeyService) Revoke(ctx context.Context, subscriberID, keyID string) error {
+	if subscriberID == "" {
+		return ErrMissingSubscriberID
+	}
+	if keyID == "" {
+		return ErrMissingKeyID
+	}
+	if err := s.repo.RevokeAPIKey(ctx, subscriberID, keyID); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "APIKeyService: API key revoked", "subscriber_id", subscriberID, "key_id", keyID)
+	return nil
+}
+
+// Authenticate returns the ID of the subscriber an API key was issued to.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidAPIKey
+	}
+	k, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return "", ErrInvalidAPIKey
+		}
+		return "", fmt.Errorf("failed to get API key: %w", err)
+	}
+	return k.SubscriberID, nil
+}
+
+// Subscriptions returns the subscriptions of a subscriber.
+func (s *apiKeyService) Subscriptions(ctx context.Context, subscriberID string) ([]model.Subscription, error) {
+	subs, err := s.repo.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: subscriberID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up subscriptions of %s: %w", subscriberID, err)
+	}
+	if subs == nil {
+		subs = []model.Subscription{}
+	}
+	return subs, nil
+}
+
+// Operations returns up to limit operations requested by a subscriber, newest first.
+// A limit outside of 1 to 100 is replaced by the default of 20.
+func (s *apiKeyService) Operations(ctx context.Context, subscriberID string, limit int) ([]model.LRO, error) {
+	if limit <= 0 || limit > maxSubscriberOperationsLimit {
+		limit = defaultSubscriberOperationsLimit
+	}
+	return s.repo.ListSubscriberOperations(ctx, subscriberID, limit)
+}
+
+// Operation returns an operation requested by a subscriber. It returns repository.ErrOperationNotFound
+// for operations of other subscribers, so that their existence is not revealed.
+func (s *apiKeyService) Operation(ctx context.Context, subscriberID, operationID string) (*model.LRO, error) {
+	lro, err := s.repo.GetOperation(ctx, operationID)
+	if err != nil {
+		return nil, err
+	}
+	var req model.SubscriptionRequest
+	if err := json.Unmarshal(lro.RequestJSON, &req); err != nil || req.SubscriberID != subscriberID {
+		return nil, repository.ErrOperationNotFound
+	}
+	return lro, nil
+}
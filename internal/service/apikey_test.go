@@ -0,0 +1,263 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockAPIKeyRepo is a mock for apiKeyRepository.
+type mockAPIKeyRepo struct {
+	keys       map[string]*model.APIKey // by hash
+	subs       []model.Subscription
+	lookupErr  error
+	insertErr  error
+	revokeErr  error
+	lro        *model.LRO
+	getOpErr   error
+	lros       []model.LRO
+	gotLimit   int
+	gotRevoked string
+}
+
+func (m *mockAPIKeyRepo) InsertAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	if m.insertErr != nil {
+		return nil, m.insertErr
+	}
+	if m.keys == nil {
+		m.keys = map[string]*model.APIKey{}
+	}
+	m.keys[key.Hash] = key
+	return key, nil
+}
+
+func (m *mockAPIKeyRepo) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	k, ok := m.keys[hash]
+	if !ok || k.RevokedAt != nil {
+		return nil, repository.ErrAPIKeyNotFound
+	}
+	return k, nil
+}
+
+func (m *mockAPIKeyRepo) ListAPIKeys(ctx context.Context, subscriberID string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	for _, k := range m.keys {
+		if k.SubscriberID == subscriberID {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyRepo) RevokeAPIKey(ctx context.Context, subscriberID, keyID string) error {
+	m.gotRevoked = keyID
+	return m.revokeErr
+}
+
+func (m *mockAPIKeyRepo) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	return m.subs, m.lookupErr
+}
+
+func (m *mockAPIKeyRepo) GetOperation(ctx context.Context, id string) (*model.LRO, error) {
+	return m.lro, m.getOpErr
+}
+
+func (m *mockAPIKeyRepo) ListSubscriberOperations(ctx context.Context, subscriberID string, limit int) ([]model.LRO, error) {
+	m.gotLimit = limit
+	return m.lros, nil
+}
+
+func TestNewAPIKeyService_Error(t *testing.T) {
+	if _, err := NewAPIKeyService(nil); err == nil {
+		t.Error("NewAPIKeyService(nil) error = nil, want error")
+	}
+}
+
+func TestAPIKeyService_IssueAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockAPIKeyRepo{subs: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub1"}}}}
+	svc, _ := NewAPIKeyService(repo)
+
+	issued, err := svc.Issue(ctx, "sub1")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if issued.Key == "" || issued.KeyID == "" || issued.SubscriberID != "sub1" {
+		t.Fatalf("Issue() = %+v, want key for sub1", issued)
+	}
+	if issued.Hash == issued.Key {
+		t.Error("Issue() stored the key in plain text")
+	}
+
+	got, err := svc.Authenticate(ctx, issued.Key)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if got != "sub1" {
+		t.Errorf("Authenticate() = %q, want %q", got, "sub1")
+	}
+
+	for _, key := range []string{"", "wrong-key"} {
+		if _, err := svc.Authenticate(ctx, key); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", key, err, ErrInvalidAPIKey)
+		}
+	}
+}
+
+func TestAPIKeyService_Issue_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscriberID string
+		repo         *mockAPIKeyRepo
+		wantErr      error
+	}{
+		{
+			name:    "missing subscriber id",
+			repo:    &mockAPIKeyRepo{},
+			wantErr: ErrMissingSubscriberID,
+		},
+		{
+			name:         "unknown subscriber",
+			subscriberID: "sub1",
+			repo:         &mockAPIKeyRepo{},
+			wantErr:      ErrSubscriberNotFound,
+		},
+		{
+			name:         "insert fails",
+			subscriberID: "sub1",
+			repo:         &mockAPIKeyRepo{subs: []model.Subscription{{}}, insertErr: errors.New("db error")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewAPIKeyService(tc.repo)
+			_, err := svc.Issue(context.Background(), tc.subscriberID)
+			if err == nil {
+				t.Fatal("Issue() error = nil, want error")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("Issue() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	repo := &mockAPIKeyRepo{}
+	svc, _ := NewAPIKeyService(repo)
+
+	if err := svc.Revoke(context.Background(), "sub1", "key1"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if repo.gotRevoked != "key1" {
+		t.Errorf("Revoke() revoked %q, want %q", repo.gotRevoked, "key1")
+	}
+	if err := svc.Revoke(context.Background(), "sub1", ""); !errors.Is(err, ErrMissingKeyID) {
+		t.Errorf("Revoke() error = %v, want %v", err, ErrMissingKeyID)
+	}
+	repo.revokeErr = repository.ErrAPIKeyNotFound
+	if err := svc.Revoke(context.Background(), "sub1", "key1"); !errors.Is(err, repository.ErrAPIKeyNotFound) {
+		t.Errorf("Revoke() error = %v, want %v", err, repository.ErrAPIKeyNotFound)
+	}
+}
+
+func TestAPIKeyService_Operations_Limit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: 0, want: 20},
+		{limit: 5, want: 5},
+		{limit: 100, want: 100},
+		{limit: 1000, want: 20},
+	}
+
+	for _, tc := range tests {
+		repo := &mockAPIKeyRepo{}
+		svc, _ := NewAPIKeyService(repo)
+		if _, err := svc.Operations(context.Background(), "sub1", tc.limit); err != nil {
+			t.Fatalf("Operations() error = %v", err)
+		}
+		if repo.gotLimit != tc.want {
+			t.Errorf("Operations(%d) used limit %d, want %d", tc.limit, repo.gotLimit, tc.want)
+		}
+	}
+}
+
+func TestAPIKeyService_Operation(t *testing.T) {
+	lro := &model.LRO{OperationID: "op1", RequestJSON: []byte(`{"subscriber_id":"sub1"}`)}
+	tests := []struct {
+		name         string
+		subscriberID string
+		repo         *mockAPIKeyRepo
+		want         *model.LRO
+		wantErr      error
+	}{
+		{
+			name:         "own operation",
+			subscriberID: "sub1",
+			repo:         &mockAPIKeyRepo{lro: lro},
+			want:         lro,
+		},
+		{
+			name:         "operation of another subscriber",
+			subscriberID: "sub2",
+			repo:         &mockAPIKeyRepo{lro: lro},
+			wantErr:      repository.ErrOperationNotFound,
+		},
+		{
+			name:         "operation not found",
+			subscriberID: "sub1",
+			repo:         &mockAPIKeyRepo{getOpErr: repository.ErrOperationNotFound},
+			wantErr:      repository.ErrOperationNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewAPIKeyService(tc.repo)
+			got, err := svc.Operation(context.Background(), tc.subscriberID, "op1")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Operation() error = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Operation() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_Subscriptions(t *testing.T) {
+	svc, _ := NewAPIKeyService(&mockAPIKeyRepo{})
+	got, err := svc.Subscriptions(context.Background(), "sub1")
+	if err != nil {
+		t.Fatalf("Subscriptions() error = %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("Subscriptions() = %v, want empty list", got)
+	}
+
+	svc, _ = NewAPIKeyService(&mockAPIKeyRepo{lookupErr: errors.New("db error")})
+	if _, err := svc.Subscriptions(context.Background(), "sub1"); err == nil {
+		t.Error("Subscriptions() error = nil, want error")
+	}
+}
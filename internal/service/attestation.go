@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Attestation errors.
+var (
+	ErrAttestationRequired    = errors.New("attestation token is required")
+	ErrAttestationFailed      = errors.New("attestation failed")
+	ErrAttestationUnavailable = errors.New("attestation service unavailable")
+)
+
+const (
+	defaultAttestationHeader  = "X-Attestation-Token"
+	defaultAttestationTimeout = 5 * time.Second
+)
+
+// attestationMetrics publishes the outcomes of attestation checks on the expvar endpoint (/debug/vars).
+var attestationMetrics = expvar.NewMap("subscription_attestation")
+
+// AttestationConfig configures the verification of an attestation token, such as a
+// reCAPTCHA response, before a subscription request is accepted.
+type AttestationConfig struct {
+	// URL is the verification endpoint. It is called with a form encoded POST of secret,
+	// response and remoteip, as by the reCAPTCHA, hCaptcha and Turnstile siteverify APIs.
+	URL string `yaml:"url"`
+	// Secret is the secret shared with the verification service.
+	Secret string `yaml:"secret"`
+	// Header is the request header that carries the token. Defaults to X-Attestation-Token.
+	Header string `yaml:"header"`
+	// MinScore is the lowest accepted score for services that score tokens. Ignored when 0.
+	MinScore float64 `yaml:"minScore"`
+	// Timeout bounds the call to the verification service. Defaults to 5s.
+	Timeout time.Duration `yaml:"timeout"`
+	// FailOpen accepts requests when the verification service cannot be reached.
+	FailOpen bool `yaml:"failOpen"`
+}
+
+// attestationResponse is the response of a siteverify compatible verification service.
+type attestationResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// attestationVerifier verifies attestation tokens with an external verification service.
+type attestationVerifier struct {
+	client   *http.Client
+	url      string
+	secret   string
+	header   string
+	minScore float64
+	failOpen bool
+}
+
+// NewAttestationVerifier creates a new attestationVerifier.
+func NewAttestationVerifier(cfg *AttestationConfig) (*attestationVerifier, error) {
+	if cfg == nil {
+		slog.Error("NewAttestationVerifier: AttestationConfig cannot be nil")
+		return nil, errors.New("AttestationConfig cannot be nil")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("attestation url must be an absolute http or https URL, got %q", cfg.URL)
+	}
+	if cfg.Secret == "" {
+		return nil, errors.New("attestation secret cannot be empty")
+	}
+	if cfg.MinScore < 0 || cfg.MinScore > 1 {
+		return nil, fmt.Errorf("attestation minScore must be between 0 and 1, got %v", cfg.MinScore)
+	}
+	v := &attestationVerifier{url: cfg.URL, secret: cfg.Secret, header: cfg.Header, minScore: cfg.MinScore, failOpen: cfg.FailOpen}
+	if v.header == "" {
+		v.header = defaultAttestationHeader
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultAttestationTimeout
+	}
+	v.client = &http.Client{Timeout: timeout}
+	return v, nil
+}
+
+// Header returns the request header that carries the attestation token.
+func (v *attestationVerifier) Header() string {
+	return v.header
+}
+
+// Verify checks token with the verification service. remoteAddr is passed on as the
+// client IP when it can be parsed.
+func (v *attestationVerifier) Verify(ctx context.Context, token, remoteAddr string) error {
+	if token == "" {
+		attestationMetrics.Add("missing", 1)
+		return ErrAttestationRequired
+	}
+	res, err := v.siteverify(ctx, token, remoteAddr)
+	if err != nil {
+		attestationMetrics.Add("unavailable", 1)
+		if v.failOpen {
+			slog.WarnContext(ctx, "AttestationVerifier: Verification service unavailable, accepting request", "error", err)
+			return nil
+		}
+		return fmt.Errorf("%w: %w", ErrAttestationUnavailable, err)
+	}
+	if !res.Success {
+		attestationMetrics.Add("rejected", 1)
+		return fmt.Errorf("%w: %s", ErrAttestationFailed, strings.Join(res.ErrorCodes, ", "))
+	}
+	if v.minScore > 0 && res.Score != nil && *res.Score < v.minScore {
+		attestationMetrics.Add("rejected", 1)
+		return fmt.Errorf("%w: score %v is below %v", ErrAttestationFailed, *res.Score, v.minScore)
+	}
+	attestationMetrics.Add("passed", 1)
+	return nil
+}
+
+// siteverify calls the verification service.
+func (v *attestationVerifier) siteverify(ctx context.Context, token, remoteAddr string) (*attestationResponse, error) {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if addr, ok := parseRemoteAddr(remoteAddr); ok {
+		form.Set("remoteip", addr.String())
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		return nil, fmt.Errorf("verification service responded with status %d", resp.StatusCode)
+	}
+	var res attestationResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode verification response: %w", err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAttestationServer starts a verification service that records the form it receives
+// and responds with status and body.
+func newAttestationServer(t *testing.T, status int, body string, form *map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() unexpected error: %v", err)
+		}
+		if form != nil {
+			*form = map[string]string{"secret": r.PostForm.Get("secret"), "response": r.PostForm.Get("response"), "remoteip": r.PostForm.Get("remoteip")}
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewAttestationVerifier_Defaults(t *testing.T) {
+	v, err := NewAttestationVerifier(&AttestationConfig{URL: "https://verify.example.com/siteverify", Secret: "secret"})
+	if err != nil {
+		t.Fatalf("NewAttestationVerifier() unexpected error: %v", err)
+	}
+	if v.Header() != defaultAttestationHeader {
+		t.Errorf("Header() = %q, want %q", v.Header(), defaultAttestationHeader)
+	}
+	if v.client.Timeout != defaultAttestationTimeout {
+		t.Errorf("client.Timeout = %v, want %v", v.client.Timeout, defaultAttestationTimeout)
+	}
+}
+
+func TestNewAttestationVerifier_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *AttestationConfig
+	}{
+		{name: "nil config"},
+		{name: "missing url", cfg: &AttestationConfig{Secret: "secret"}},
+		{name: "relative url", cfg: &AttestationConfig{URL: "/siteverify", Secret: "secret"}},
+		{name: "unsupported scheme", cfg: &AttestationConfig{URL: "ftp://verify.example.com", Secret: "secret"}},
+		{name: "missing secret", cfg: &AttestationConfig{URL: "https://verify.example.com"}},
+		{name: "negative min score", cfg: &AttestationConfig{URL: "https://verify.example.com", Secret: "secret", MinScore: -0.1}},
+		{name: "min score above 1", cfg: &AttestationConfig{URL: "https://verify.example.com", Secret: "secret", MinScore: 1.5}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewAttestationVerifier(tc.cfg); err == nil {
+				t.Error("NewAttestationVerifier() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestAttestationVerifier_Verify_Success(t *testing.T) {
+	var form map[string]string
+	srv := newAttestationServer(t, http.StatusOK, `{"success": true, "score": 0.9}`, &form)
+	v, err := NewAttestationVerifier(&AttestationConfig{URL: srv.URL, Secret: "secret", Header: "X-Captcha", MinScore: 0.5})
+	if err != nil {
+		t.Fatalf("NewAttestationVerifier() unexpected error: %v", err)
+	}
+
+	if err := v.Verify(context.Background(), "token", "203.0.113.7:4321"); err != nil {
+		t.Fatalf("Verify() unexpected error: %v", err)
+	}
+	want := map[string]string{"secret": "secret", "response": "token", "remoteip": "203.0.113.7"}
+	for k, w := range want {
+		if form[k] != w {
+			t.Errorf("verification form %s = %q, want %q", k, form[k], w)
+		}
+	}
+	if v.Header() != "X-Captcha" {
+		t.Errorf("Header() = %q, want %q", v.Header(), "X-Captcha")
+	}
+}
+
+func TestAttestationVerifier_Verify_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		status   int
+		body     string
+		failOpen bool
+		wantErr  error
+	}{
+		{name: "missing token", token: "", status: http.StatusOK, body: `{"success": true}`, wantErr: ErrAttestationRequired},
+		{name: "missing token with fail open", token: "", status: http.StatusOK, body: `{"success": true}`, failOpen: true, wantErr: ErrAttestationRequired},
+		{name: "token rejected", token: "token", status: http.StatusOK, body: `{"success": false, "error-codes": ["invalid-input-response"]}`, wantErr: ErrAttestationFailed},
+		{name: "score below minimum", token: "token", status: http.StatusOK, body: `{"success": true, "score": 0.1}`, wantErr: ErrAttestationFailed},
+		{name: "service error", token: "token", status: http.StatusInternalServerError, wantErr: ErrAttestationUnavailable},
+		{name: "invalid response", token: "token", status: http.StatusOK, body: `not json`, wantErr: ErrAttestationUnavailable},
+		{name: "service error with fail open", token: "token", status: http.StatusInternalServerError, failOpen: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newAttestationServer(t, tc.status, tc.body, nil)
+			v, err := NewAttestationVerifier(&AttestationConfig{URL: srv.URL, Secret: "secret", MinScore: 0.5, FailOpen: tc.failOpen})
+			if err != nil {
+				t.Fatalf("NewAttestationVerifier() unexpected error: %v", err)
+			}
+			if err := v.Verify(context.Background(), tc.token, "203.0.113.7"); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttestationVerifier_Verify_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	v, err := NewAttestationVerifier(&AttestationConfig{URL: srv.URL, Secret: "secret", Timeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewAttestationVerifier() unexpected error: %v", err)
+	}
+	if err := v.Verify(context.Background(), "token", ""); !errors.Is(err, ErrAttestationUnavailable) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrAttestationUnavailable)
+	}
+}
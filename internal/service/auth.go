@@ -159,8 +159,9 @@ type npKeyProvider interface {
 }
 
 type txnSignValidator struct {
-	sv signValidator
-	km npKeyProvider
+	sv   signValidator
+	km   npKeyProvider
+	pool *signPool
 }
 
 // NewTxnSignValidator initializes and returns a new validate sign step.
@@ -176,6 +177,27 @@ func NewTxnSignValidator(sv signValidator, km npKeyProvider) (*txnSignValidator,
 	return &txnSignValidator{sv: sv, km: km}, nil
 }
 
+// SetPool bounds the number of signatures verified at once and caches signing keys in
+// front of the key manager. Requests that wait longer than the queue timeout for a
+// worker are rejected as overloaded.
+func (s *txnSignValidator) SetPool(cfg *SignPoolConfig) error {
+	p, err := newSignPool(s.km, cfg)
+	if err != nil {
+		return err
+	}
+	s.pool = p
+	return nil
+}
+
+// Prewarm loads the signing keys configured for the pool into its cache and returns how
+// many were loaded. It does nothing without a pool.
+func (s *txnSignValidator) Prewarm(ctx context.Context) int {
+	if s.pool == nil {
+		return 0
+	}
+	return s.pool.warm(ctx)
+}
+
 func (s *txnSignValidator) Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError {
 	ah, authErr := keySet(ctx, authHeader)
 	if authErr != nil {
@@ -184,14 +206,33 @@ func (s *txnSignValidator) Validate(ctx context.Context, body []byte, authHeader
 
 	slog.DebugContext(ctx, "txnSignValidator.Validate: Auth header parsed", "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
 
-	key, _, err := s.km.LookupNPKeys(ctx, ah.SubscriberID, ah.UniqueID)
+	var key string
+	var err error
+	if s.pool != nil {
+		key, err = s.pool.signingKey(ctx, ah.SubscriberID, ah.UniqueID)
+	} else {
+		key, _, err = s.km.LookupNPKeys(ctx, ah.SubscriberID, ah.UniqueID)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "txnSignValidator.Validate: Failed to get signing public key from npKeyProvider", "error", err, "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
 		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeKeyUnavailable, "Failed to retrieve signing key for validation.", ah.SubscriberID)
 	}
 
+	if s.pool != nil {
+		// Only the verification is bounded; key lookups wait on I/O, not on the CPU.
+		release, err := s.pool.acquire(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "txnSignValidator.Validate: No signature validation worker available", "error", err, "subscriber_id", ah.SubscriberID)
+			return model.NewAuthError(http.StatusServiceUnavailable, model.ErrorTypeInternalError, model.ErrorCodeServiceOverloaded, "Gateway is overloaded, retry later.", ah.SubscriberID)
+		}
+		defer release()
+	}
 	if err := s.sv.Validate(ctx, body, authHeader, key); err != nil {
 		slog.ErrorContext(ctx, "txnSignValidator.Validate: Signature validation failed", "error", err, "subscriber_id", ah.SubscriberID)
+		if s.pool != nil {
+			// The key may have been revoked or replaced since it was cached.
+			s.pool.evict(ah.SubscriberID, ah.UniqueID)
+		}
 		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
 	}
 
@@ -16,12 +16,15 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/beckn/beckn-onix/pkg/model"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // signingKM defines the interface for retrieving signing keys.
@@ -39,6 +42,7 @@ type signer interface {
 type authGenService struct {
 	keyManager signingKM
 	signer     signer
+	scheme     AuthScheme
 }
 
 // NewAuthGenService creates a new authGenService.
@@ -54,9 +58,17 @@ func NewAuthGenService(keyManager signingKM, signer signer) (*authGenService, er
 	return &authGenService{
 		keyManager: keyManager,
 		signer:     signer,
+		scheme:     AuthSchemeCavage,
 	}, nil
 }
 
+// SetScheme sets the format of the signatures. RFC 9421 signatures are returned in the
+// Authorization header format with headers="content-digest", and are moved to the
+// Signature-Input and Signature headers when the request is sent.
+func (s *authGenService) SetScheme(scheme AuthScheme) {
+	s.scheme = scheme
+}
+
 // AuthHeader signs the provided body using the specified subscriber's key
 // and generates the Authorization header value.
 func (s *authGenService) AuthHeader(ctx context.Context, body []byte, subscriberID string) (string, error) {
@@ -69,12 +81,25 @@ func (s *authGenService) AuthHeader(ctx context.Context, body []byte, subscriber
 	createdAt := time.Now().Unix()
 	expires := time.Now().Add(5 * time.Minute).Unix()
 
+	alg, _ := keyalgo.PrivateKey(keySet.SigningPrivate)
+	if s.scheme == AuthSchemeRFC9421 {
+		keyID := fmt.Sprintf("%s|%s|%s", subscriberID, keySet.UniqueKeyID, alg)
+		_, sig, err := keyalgo.Sign(keySet.SigningPrivate, rfc9421Base(body, rfc9421Params(createdAt, expires, keyID, string(alg))))
+		if err != nil {
+			slog.ErrorContext(ctx, "AuthGenService: Failed to sign body", "error", err)
+			return "", fmt.Errorf("failed to sign body: %w", err)
+		}
+		return fmt.Sprintf(
+			`Signature keyId="%s",algorithm="%s",created="%d",expires="%d",headers="%s",signature="%s"`,
+			keyID, alg, createdAt, expires, rfc9421Components, base64.StdEncoding.EncodeToString(sig)), nil
+	}
+
 	signature, err := s.signer.Sign(ctx, body, keySet.SigningPrivate, createdAt, expires)
 	if err != nil {
 		slog.ErrorContext(ctx, "AuthGenService: Failed to sign body", "error", err)
 		return "", fmt.Errorf("failed to sign body: %w", err)
 	}
 	return fmt.Sprintf(
-		`Signature keyId="%s|%s|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="%s"`,
-		subscriberID, keySet.UniqueKeyID, createdAt, expires, signature), nil
+		`Signature keyId="%s|%s|%s",algorithm="%s",created="%d",expires="%d",headers="(created) (expires) digest",signature="%s"`,
+		subscriberID, keySet.UniqueKeyID, alg, alg, createdAt, expires, signature), nil
 }
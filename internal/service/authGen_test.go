@@ -119,6 +119,17 @@ func TestAuthHeader(t *testing.T) {
 			},
 			wantErr: "",
 		},
+		{
+			name:         "secp256k1 keyset",
+			mockKM:       &mockSigningKM{keyset: &model.Keyset{UniqueKeyID: "key-456", SigningPrivate: "secp256k1:private-key-data"}},
+			mockSigner:   &mockSigner{signature: validSignature},
+			subscriberID: subscriberID,
+			body:         body,
+			wantHeaderPart: []string{
+				`keyId="test.subscriber.com|key-456|secp256k1"`,
+				`algorithm="secp256k1"`,
+			},
+		},
 		{
 			name:         "keyset fetch error",
 			mockKM:       &mockSigningKM{err: errors.New("db connection failed")},
@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// AuthScheme is a format of the HTTP signature headers that sign Beckn requests.
+type AuthScheme string
+
+const (
+	// AuthSchemeCavage is the draft-cavage-http-signatures format of the Authorization and
+	// X-Gateway-Authorization headers.
+	AuthSchemeCavage AuthScheme = "cavage"
+	// AuthSchemeRFC9421 is the RFC 9421 HTTP Message Signatures format of the Signature-Input
+	// and Signature headers, covering a Content-Digest of the body.
+	AuthSchemeRFC9421 AuthScheme = "rfc9421"
+)
+
+// ErrAuthSchemeNotAccepted is returned when a request is signed in a format the gateway does not accept.
+var ErrAuthSchemeNotAccepted = errors.New("signature format not accepted")
+
+const (
+	// headerSignatureInput and headerSignature carry RFC 9421 signatures.
+	headerSignatureInput = "Signature-Input"
+	headerSignature      = "Signature"
+	// headerContentDigest carries the RFC 9530 digest of the body covered by RFC 9421 signatures.
+	headerContentDigest = "Content-Digest"
+	// rfc9421Components is the headers parameter that marks an Authorization header value
+	// carrying an RFC 9421 signature, which signs the RFC 9421 signature base.
+	rfc9421Components = "content-digest"
+	// gatewaySignatureLabel labels the gateway's own RFC 9421 signature.
+	gatewaySignatureLabel = "gateway"
+)
+
+// AuthSchemeConfig selects the signature formats the gateway accepts and emits, so that a
+// network profile can move between Beckn versions without a flag day.
+type AuthSchemeConfig struct {
+	// Accept lists the formats accepted on requests from subscribers. Defaults to cavage.
+	Accept []AuthScheme `yaml:"accept"`
+	// Emit is the format of the gateway's signature on the requests it forwards. Defaults to cavage.
+	Emit AuthScheme `yaml:"emit"`
+}
+
+// authSchemes parses subscriber signatures in the accepted formats.
+//
+// Whatever their format, signatures are handled internally in the Authorization header
+// format, so that key lookup, the denylist and the clock skew check apply unchanged. An RFC
+// 9421 signature becomes the keyId, algorithm, created, expires and signature parameters with
+// headers="content-digest", which tells the validator to verify the RFC 9421 signature base.
+type authSchemes struct {
+	accept []AuthScheme
+	emit   AuthScheme
+}
+
+// NewAuthSchemes creates the signature formats of a network profile.
+func NewAuthSchemes(cfg *AuthSchemeConfig) (*authSchemes, error) {
+	if cfg == nil {
+		slog.Error("NewAuthSchemes: config cannot be nil")
+		return nil, errors.New("auth scheme config cannot be nil")
+	}
+	s := &authSchemes{accept: cfg.Accept, emit: cfg.Emit}
+	if len(s.accept) == 0 {
+		s.accept = []AuthScheme{AuthSchemeCavage}
+	}
+	if s.emit == "" {
+		s.emit = AuthSchemeCavage
+	}
+	for _, scheme := range append(slices.Clone(s.accept), s.emit) {
+		if scheme != AuthSchemeCavage && scheme != AuthSchemeRFC9421 {
+			return nil, fmt.Errorf("unknown auth scheme %q, must be %q or %q", scheme, AuthSchemeCavage, AuthSchemeRFC9421)
+		}
+	}
+	return s, nil
+}
+
+// Emit returns the format of the gateway's own signatures.
+func (s *authSchemes) Emit() AuthScheme {
+	return s.emit
+}
+
+// SubscriberAuth returns the subscriber signature of a request in the Authorization header
+// format, or an empty string if the request is not signed.
+func (s *authSchemes) SubscriberAuth(h http.Header) (string, error) {
+	if auth := h.Get(model.AuthHeaderSubscriber); auth != "" {
+		if !slices.Contains(s.accept, AuthSchemeCavage) {
+			return "", fmt.Errorf("%w: %s header", ErrAuthSchemeNotAccepted, model.AuthHeaderSubscriber)
+		}
+		return auth, nil
+	}
+	if h.Get(headerSignatureInput) == "" {
+		return "", nil
+	}
+	if !slices.Contains(s.accept, AuthSchemeRFC9421) {
+		return "", fmt.Errorf("%w: %s header", ErrAuthSchemeNotAccepted, headerSignatureInput)
+	}
+	return parseRFC9421(h, gatewaySignatureLabel)
+}
+
+// parseRFC9421 returns the first RFC 9421 signature of h not labelled skip in the
+// Authorization header format. Only signatures over the content-digest with the created,
+// expires, keyid and alg parameters in that order are supported, since the signature base
+// is rebuilt from the parameters.
+func parseRFC9421(h http.Header, skip string) (string, error) {
+	sigs := map[string]string{}
+	for _, member := range splitDictionary(strings.Join(h.Values(headerSignature), ",")) {
+		label, value, _ := strings.Cut(member, "=")
+		sigs[label] = value
+	}
+	for _, member := range splitDictionary(strings.Join(h.Values(headerSignatureInput), ",")) {
+		label, input, _ := strings.Cut(member, "=")
+		if label == skip {
+			continue
+		}
+		params, err := signatureInputParams(input)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s %q: %w", headerSignatureInput, label, err)
+		}
+		sig, ok := sigs[label]
+		if !ok || len(sig) < 2 || !strings.HasPrefix(sig, ":") || !strings.HasSuffix(sig, ":") {
+			return "", fmt.Errorf("%s header has no signature labelled %q", headerSignature, label)
+		}
+		return fmt.Sprintf(`Signature keyId="%s",algorithm="%s",created="%s",expires="%s",headers="%s",signature="%s"`,
+			params["keyid"], params["alg"], params["created"], params["expires"], rfc9421Components, sig[1:len(sig)-1]), nil
+	}
+	return "", fmt.Errorf("%s header has no subscriber signature", headerSignatureInput)
+}
+
+// signatureInputParams parses the parameters of a Signature-Input member and checks that
+// it is the serialization rfc9421Params would produce.
+func signatureInputParams(input string) (map[string]string, error) {
+	params := map[string]string{}
+	parts := strings.Split(input, ";")
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[k] = strings.Trim(v, `"`)
+	}
+	created, err := strconv.ParseInt(params["created"], 10, 64)
+	if err != nil {
+		return nil, errors.New("created parameter is not a Unix timestamp")
+	}
+	expires, err := strconv.ParseInt(params["expires"], 10, 64)
+	if err != nil {
+		return nil, errors.New("expires parameter is not a Unix timestamp")
+	}
+	if want := rfc9421Params(created, expires, params["keyid"], params["alg"]); input != want {
+		return nil, fmt.Errorf("must have the form %s", want)
+	}
+	return params, nil
+}
+
+// splitDictionary splits a structured field dictionary into its members.
+func splitDictionary(s string) []string {
+	var members []string
+	quoted, depth, start := false, 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			members = append(members, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if m := strings.TrimSpace(s[start:]); m != "" {
+		members = append(members, m)
+	}
+	return members
+}
+
+// rfc9421Params serializes the signature parameters of an RFC 9421 signature.
+func rfc9421Params(created, expires int64, keyID, alg string) string {
+	return fmt.Sprintf(`("%s");created=%d;expires=%d;keyid="%s";alg="%s"`, rfc9421Components, created, expires, keyID, alg)
+}
+
+// contentDigest returns the RFC 9530 Content-Digest of body.
+func contentDigest(body []byte) string {
+	digest := sha512.Sum512(body)
+	return "sha-512=:" + base64.StdEncoding.EncodeToString(digest[:]) + ":"
+}
+
+// rfc9421Base builds the RFC 9421 signature base of body with the given signature parameters.
+func rfc9421Base(body []byte, params string) []byte {
+	return []byte(fmt.Sprintf("\"%s\": %s\n\"@signature-params\": %s", rfc9421Components, contentDigest(body), params))
+}
+
+// encodeRFC9421 moves an RFC 9421 signature held in the name header in the Authorization
+// header format to the Signature-Input and Signature headers under label, next to any
+// signatures already there, and sets the Content-Digest of body. Signatures in the
+// draft-cavage format are left in place.
+func encodeRFC9421(h http.Header, name, label string, body []byte) {
+	auth := h.Get(name)
+	if components, _ := signatureParam(auth, "headers"); components != rfc9421Components {
+		return
+	}
+	keyID, _ := signatureParam(auth, "keyId")
+	alg, _ := signatureParam(auth, "algorithm")
+	created, _ := signatureTime(auth, "created")
+	expires, _ := signatureTime(auth, "expires")
+	sig, _ := signatureParam(auth, "signature")
+	h.Del(name)
+	h.Set(headerContentDigest, contentDigest(body))
+	h.Add(headerSignatureInput, label+"="+rfc9421Params(created.Unix(), expires.Unix(), keyID, alg))
+	h.Add(headerSignature, label+"=:"+sig+":")
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signvalidator"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+)
+
+func TestNewAuthSchemes(t *testing.T) {
+	if _, err := NewAuthSchemes(nil); err == nil {
+		t.Error("NewAuthSchemes(nil) error = nil, want error")
+	}
+	if _, err := NewAuthSchemes(&AuthSchemeConfig{Emit: "hs2019"}); err == nil {
+		t.Error("NewAuthSchemes() with unknown scheme error = nil, want error")
+	}
+	s, err := NewAuthSchemes(&AuthSchemeConfig{})
+	if err != nil {
+		t.Fatalf("NewAuthSchemes() error = %v", err)
+	}
+	if s.Emit() != AuthSchemeCavage || len(s.accept) != 1 || s.accept[0] != AuthSchemeCavage {
+		t.Errorf("NewAuthSchemes() = %+v, want cavage defaults", s)
+	}
+}
+
+// rfc9421Request signs body with a new keyset of alg in the RFC 9421 format and returns the
+// request headers, with the signature labelled sig1, and the signing public key.
+func rfc9421Request(t *testing.T, alg keyalgo.Algorithm, body []byte) (http.Header, string) {
+	t.Helper()
+	priv, pub, err := keyalgo.GenerateSigningKey(alg)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	gen, err := NewAuthGenService(&mockSigningKM{keyset: &model.Keyset{UniqueKeyID: "key-1", SigningPrivate: priv}}, &mockSigner{})
+	if err != nil {
+		t.Fatalf("NewAuthGenService() error = %v", err)
+	}
+	gen.SetScheme(AuthSchemeRFC9421)
+	auth, err := gen.AuthHeader(context.Background(), body, "np.example.com")
+	if err != nil {
+		t.Fatalf("AuthHeader() error = %v", err)
+	}
+	h := http.Header{}
+	h.Set(model.AuthHeaderSubscriber, auth)
+	encodeRFC9421(h, model.AuthHeaderSubscriber, "sig1", body)
+	return h, pub
+}
+
+func TestAuthSchemes_RFC9421RoundTrip(t *testing.T) {
+	becknValidator, _, err := signvalidator.New(context.Background(), &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	v, err := NewKeyAlgoSignValidator(becknValidator)
+	if err != nil {
+		t.Fatalf("NewKeyAlgoSignValidator() error = %v", err)
+	}
+	s, err := NewAuthSchemes(&AuthSchemeConfig{Accept: []AuthScheme{AuthSchemeRFC9421}})
+	if err != nil {
+		t.Fatalf("NewAuthSchemes() error = %v", err)
+	}
+	body := []byte(`{"context":{"action":"search"}}`)
+
+	for _, alg := range []keyalgo.Algorithm{keyalgo.Ed25519, keyalgo.Secp256k1} {
+		t.Run(string(alg), func(t *testing.T) {
+			h, pub := rfc9421Request(t, alg, body)
+			if h.Get(model.AuthHeaderSubscriber) != "" {
+				t.Errorf("encodeRFC9421() left %s = %q", model.AuthHeaderSubscriber, h.Get(model.AuthHeaderSubscriber))
+			}
+			if got, want := h.Get(headerContentDigest), contentDigest(body); got != want {
+				t.Errorf("Content-Digest = %q, want %q", got, want)
+			}
+			auth, err := s.SubscriberAuth(h)
+			if err != nil {
+				t.Fatalf("SubscriberAuth() error = %v", err)
+			}
+			ah, err := parseAuthHeader(auth)
+			if err != nil {
+				t.Fatalf("parseAuthHeader() error = %v", err)
+			}
+			if ah.SubscriberID != "np.example.com" || ah.UniqueID != "key-1" || ah.Algorithm != string(alg) {
+				t.Errorf("parseAuthHeader() = %+v", ah)
+			}
+			if err := v.Validate(context.Background(), body, auth, pub); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if err := v.Validate(context.Background(), []byte(`{"context":{"action":"select"}}`), auth, pub); err == nil {
+				t.Error("Validate() of a tampered body error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestAuthSchemes_SubscriberAuth(t *testing.T) {
+	body := []byte(`{}`)
+	signed, _ := rfc9421Request(t, keyalgo.Ed25519, body)
+	reordered := signed.Clone()
+	reordered.Set(headerSignatureInput, strings.Replace(signed.Get(headerSignatureInput), `;alg="ed25519"`, "", 1)+`;alg="ed25519";nonce="n"`)
+	gatewayOnly := http.Header{}
+	for k, vs := range signed {
+		for _, v := range vs {
+			gatewayOnly.Add(k, strings.Replace(v, "sig1=", gatewaySignatureLabel+"=", 1))
+		}
+	}
+	cavage := http.Header{}
+	cavage.Set(model.AuthHeaderSubscriber, `Signature keyId="np.example.com|key-1|ed25519"`)
+
+	tests := []struct {
+		name     string
+		accept   []AuthScheme
+		h        http.Header
+		wantAuth string
+		wantErr  error
+	}{
+		{name: "cavage accepted", h: cavage, wantAuth: cavage.Get(model.AuthHeaderSubscriber)},
+		{name: "cavage not accepted", accept: []AuthScheme{AuthSchemeRFC9421}, h: cavage, wantErr: ErrAuthSchemeNotAccepted},
+		{name: "rfc9421 not accepted", h: signed, wantErr: ErrAuthSchemeNotAccepted},
+		{name: "unsigned", accept: []AuthScheme{AuthSchemeRFC9421}, h: http.Header{}},
+		{name: "unsupported parameters", accept: []AuthScheme{AuthSchemeRFC9421}, h: reordered, wantErr: errors.New("must have the form")},
+		{name: "gateway signature only", accept: []AuthScheme{AuthSchemeRFC9421}, h: gatewayOnly, wantErr: errors.New("no subscriber signature")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewAuthSchemes(&AuthSchemeConfig{Accept: tt.accept})
+			if err != nil {
+				t.Fatalf("NewAuthSchemes() error = %v", err)
+			}
+			got, err := s.SubscriberAuth(tt.h)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("SubscriberAuth() error = %v", err)
+			case tt.wantErr != nil && (err == nil || !errors.Is(err, tt.wantErr) && !strings.Contains(err.Error(), tt.wantErr.Error())):
+				t.Fatalf("SubscriberAuth() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.wantAuth {
+				t.Errorf("SubscriberAuth() = %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestEncodeRFC9421_KeepsCavage(t *testing.T) {
+	h := http.Header{}
+	h.Set(model.AuthHeaderGateway, `Signature keyId="gw|key-1|ed25519",headers="(created) (expires) digest"`)
+	encodeRFC9421(h, model.AuthHeaderGateway, gatewaySignatureLabel, []byte(`{}`))
+	if h.Get(model.AuthHeaderGateway) == "" || h.Get(headerSignatureInput) != "" {
+		t.Errorf("encodeRFC9421() = %v, want the draft-cavage signature left in place", h)
+	}
+}
+
+func TestSplitDictionary(t *testing.T) {
+	got := splitDictionary(`sig1=("content-digest" "@method");keyid="a,b", gateway=("content-digest")`)
+	want := []string{`sig1=("content-digest" "@method");keyid="a,b"`, `gateway=("content-digest")`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("splitDictionary() = %q, want %q", got, want)
+	}
+}
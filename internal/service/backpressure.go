@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultSoftWatermark = 0.7
+	defaultHardWatermark = 0.9
+	defaultRetryAfter    = 5 * time.Second
+)
+
+// BackpressureConfig configures how the gateway signals a filling task queue to senders.
+// Watermarks are fractions of the task queue buffer size.
+type BackpressureConfig struct {
+	// SoftWatermark is the occupancy above which ACKs carry a Retry-After hint. Defaults to 0.7.
+	SoftWatermark float64 `yaml:"softWatermark"`
+	// HardWatermark is the occupancy above which requests are NACKed. Defaults to 0.9.
+	HardWatermark float64 `yaml:"hardWatermark"`
+	// RetryAfter is the delay suggested to senders. Defaults to 5s.
+	RetryAfter time.Duration `yaml:"retryAfter"`
+}
+
+// PressureLevel is the level of backpressure a queue is under.
+type PressureLevel int
+
+const (
+	// PressureNone indicates that the queue is below the soft watermark.
+	PressureNone PressureLevel = iota
+	// PressureSoft indicates that the queue is above the soft watermark.
+	PressureSoft
+	// PressureHard indicates that the queue is above the hard watermark.
+	PressureHard
+)
+
+// queueOccupancy reports how full a task queue is.
+type queueOccupancy interface {
+	Occupancy() (queued, capacity int)
+}
+
+// domainQueueOccupancy reports how full the part of a task queue that holds the tasks of a domain is.
+type domainQueueOccupancy interface {
+	DomainOccupancy(domain string) (queued, capacity int)
+}
+
+// backpressure maps the occupancy of a task queue to a PressureLevel.
+type backpressure struct {
+	queue      domainQueueOccupancy
+	soft       float64
+	hard       float64
+	retryAfter time.Duration
+}
+
+// NewBackpressure creates a new backpressure for the given queue.
+func NewBackpressure(queue domainQueueOccupancy, cfg *BackpressureConfig) (*backpressure, error) {
+	if queue == nil {
+		slog.Error("NewBackpressure: queue cannot be nil")
+		return nil, errors.New("queue cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewBackpressure: BackpressureConfig cannot be nil")
+		return nil, errors.New("BackpressureConfig cannot be nil")
+	}
+	b := &backpressure{queue: queue, soft: cfg.SoftWatermark, hard: cfg.HardWatermark, retryAfter: cfg.RetryAfter}
+	if b.soft == 0 {
+		b.soft = defaultSoftWatermark
+	}
+	if b.hard == 0 {
+		b.hard = defaultHardWatermark
+	}
+	if b.retryAfter <= 0 {
+		b.retryAfter = defaultRetryAfter
+	}
+	if b.soft < 0 || b.hard > 1 || b.soft > b.hard {
+		return nil, fmt.Errorf("invalid watermarks: soft %v and hard %v must satisfy 0 <= soft <= hard <= 1", b.soft, b.hard)
+	}
+	return b, nil
+}
+
+// Level returns the current pressure level of the queue partition that holds the tasks of
+// domain, so that a full partition only slows down the senders of its own domains.
+func (b *backpressure) Level(domain string) PressureLevel {
+	queued, capacity := b.queue.DomainOccupancy(domain)
+	if capacity <= 0 {
+		return PressureNone
+	}
+	switch occupancy := float64(queued) / float64(capacity); {
+	case occupancy >= b.hard:
+		return PressureHard
+	case occupancy >= b.soft:
+		return PressureSoft
+	default:
+		return PressureNone
+	}
+}
+
+// RetryAfter returns the delay suggested to senders while the queue is under pressure.
+func (b *backpressure) RetryAfter() time.Duration {
+	return b.retryAfter
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockQueueOccupancy is a mock for queueOccupancy.
+type mockQueueOccupancy struct {
+	queued, capacity int
+	gotDomain        string
+}
+
+func (m *mockQueueOccupancy) Occupancy() (int, int) {
+	return m.queued, m.capacity
+}
+
+func (m *mockQueueOccupancy) DomainOccupancy(domain string) (int, int) {
+	m.gotDomain = domain
+	return m.queued, m.capacity
+}
+
+func TestNewBackpressure(t *testing.T) {
+	b, err := NewBackpressure(&mockQueueOccupancy{}, &BackpressureConfig{})
+	if err != nil {
+		t.Fatalf("NewBackpressure() unexpected error: %v", err)
+	}
+	if b.soft != defaultSoftWatermark || b.hard != defaultHardWatermark || b.RetryAfter() != defaultRetryAfter {
+		t.Errorf("NewBackpressure() = soft %v, hard %v, retryAfter %v, want defaults", b.soft, b.hard, b.RetryAfter())
+	}
+}
+
+func TestNewBackpressure_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		queue   domainQueueOccupancy
+		cfg     *BackpressureConfig
+		wantErr string
+	}{
+		{name: "nil queue", cfg: &BackpressureConfig{}, wantErr: "queue cannot be nil"},
+		{name: "nil config", queue: &mockQueueOccupancy{}, wantErr: "BackpressureConfig cannot be nil"},
+		{name: "soft above hard", queue: &mockQueueOccupancy{}, cfg: &BackpressureConfig{SoftWatermark: 0.8, HardWatermark: 0.5}, wantErr: "invalid watermarks"},
+		{name: "hard above buffer", queue: &mockQueueOccupancy{}, cfg: &BackpressureConfig{HardWatermark: 1.5}, wantErr: "invalid watermarks"},
+		{name: "negative soft", queue: &mockQueueOccupancy{}, cfg: &BackpressureConfig{SoftWatermark: -0.1}, wantErr: "invalid watermarks"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewBackpressure(tc.queue, tc.cfg); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("NewBackpressure() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackpressure_Level(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued int
+		cap    int
+		want   PressureLevel
+	}{
+		{name: "empty", queued: 0, cap: 10, want: PressureNone},
+		{name: "below soft", queued: 4, cap: 10, want: PressureNone},
+		{name: "at soft", queued: 5, cap: 10, want: PressureSoft},
+		{name: "at hard", queued: 8, cap: 10, want: PressureHard},
+		{name: "full", queued: 10, cap: 10, want: PressureHard},
+		{name: "unbuffered", queued: 0, cap: 0, want: PressureNone},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &mockQueueOccupancy{queued: tc.queued, capacity: tc.cap}
+			b, err := NewBackpressure(q, &BackpressureConfig{SoftWatermark: 0.5, HardWatermark: 0.8, RetryAfter: time.Second})
+			if err != nil {
+				t.Fatalf("NewBackpressure() unexpected error: %v", err)
+			}
+			if got := b.Level("ONDC:TRV10"); got != tc.want {
+				t.Errorf("Level() = %v, want %v", got, tc.want)
+			}
+			if q.gotDomain != "ONDC:TRV10" {
+				t.Errorf("Level() read the occupancy of domain %q, want ONDC:TRV10", q.gotDomain)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchThreshold   = 10
+	defaultBatchMaxSize     = 20
+	defaultBatchMinValidity = 30 * time.Second
+)
+
+// expiresPattern extracts the expiry of a signed Authorization header.
+var expiresPattern = regexp.MustCompile(`expires="(\d+)"`)
+
+// BatchConfig configures the batching of fanout proxy tasks per target host.
+type BatchConfig struct {
+	// Threshold is the number of fanout targets above which proxy tasks are batched. Defaults to 10.
+	Threshold int `yaml:"threshold"`
+	// MaxSize is the maximum number of targets in a batch. Defaults to 20.
+	MaxSize int `yaml:"maxSize"`
+	// HostInterval is the minimum delay between two requests to the same host. Defaults to no pacing.
+	HostInterval time.Duration `yaml:"hostInterval"`
+	// MinValidity is how long a signed header must remain valid to be reused for the next target
+	// of a batch. Otherwise the body is signed again. Defaults to 30s.
+	MinValidity time.Duration `yaml:"minValidity"`
+}
+
+// batcher groups fanout targets into per-host batches and paces requests to each host.
+type batcher struct {
+	threshold    int
+	maxSize      int
+	hostInterval time.Duration
+	minValidity  time.Duration
+	now          func() time.Time
+
+	mu   sync.Mutex
+	next map[string]time.Time // earliest time of the next request to each host
+}
+
+// NewBatcher creates a new batcher.
+func NewBatcher(cfg *BatchConfig) (*batcher, error) {
+	if cfg == nil {
+		slog.Error("NewBatcher: BatchConfig cannot be nil")
+		return nil, errors.New("BatchConfig cannot be nil")
+	}
+	if cfg.Threshold < 0 || cfg.MaxSize < 0 || cfg.HostInterval < 0 || cfg.MinValidity < 0 {
+		return nil, fmt.Errorf("invalid batch config: values cannot be negative")
+	}
+	b := &batcher{
+		threshold:    cfg.Threshold,
+		maxSize:      cfg.MaxSize,
+		hostInterval: cfg.HostInterval,
+		minValidity:  cfg.MinValidity,
+		now:          time.Now,
+		next:         map[string]time.Time{},
+	}
+	if b.threshold == 0 {
+		b.threshold = defaultBatchThreshold
+	}
+	if b.maxSize == 0 {
+		b.maxSize = defaultBatchMaxSize
+	}
+	if b.minValidity == 0 {
+		b.minValidity = defaultBatchMinValidity
+	}
+	return b, nil
+}
+
+// Batches groups target URIs by host into batches of at most MaxSize, keeping their order.
+// It returns nil if there are no more targets than the threshold, in which case they are not batched.
+// URIs that cannot be parsed are returned in batches of their own.
+func (b *batcher) Batches(uris []string) [][]string {
+	if len(uris) <= b.threshold {
+		return nil
+	}
+	var hosts []string
+	byHost := map[string][]string{}
+	for _, uri := range uris {
+		host := uri
+		if u, err := url.Parse(uri); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], uri)
+	}
+	var batches [][]string
+	for _, host := range hosts {
+		group := byHost[host]
+		for len(group) > b.maxSize {
+			batches = append(batches, group[:b.maxSize])
+			group = group[b.maxSize:]
+		}
+		batches = append(batches, group)
+	}
+	return batches
+}
+
+// Wait blocks until a request to the host is allowed by the configured pacing and reserves it.
+func (b *batcher) Wait(ctx context.Context, host string) error {
+	if b.hostInterval <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	now := b.now()
+	at := b.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	b.next[host] = at.Add(b.hostInterval)
+	b.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Reusable reports whether a signed Authorization header remains valid long enough to be
+// sent to another target.
+func (b *batcher) Reusable(authHeader string) bool {
+	m := expiresPattern.FindStringSubmatch(authHeader)
+	if m == nil {
+		return false
+	}
+	expires, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Unix(expires, 0).Sub(b.now()) >= b.minValidity
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewBatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *BatchConfig
+		want    *batcher
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  &BatchConfig{},
+			want: &batcher{threshold: defaultBatchThreshold, maxSize: defaultBatchMaxSize, minValidity: defaultBatchMinValidity},
+		},
+		{
+			name: "configured",
+			cfg:  &BatchConfig{Threshold: 3, MaxSize: 5, HostInterval: time.Second, MinValidity: time.Minute},
+			want: &batcher{threshold: 3, maxSize: 5, hostInterval: time.Second, minValidity: time.Minute},
+		},
+		{
+			name:    "nil config",
+			wantErr: "BatchConfig cannot be nil",
+		},
+		{
+			name:    "negative max size",
+			cfg:     &BatchConfig{MaxSize: -1},
+			wantErr: "invalid batch config: values cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBatcher(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewBatcher() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewBatcher() unexpected error: %v", err)
+			}
+			if got.threshold != tt.want.threshold || got.maxSize != tt.want.maxSize || got.hostInterval != tt.want.hostInterval || got.minValidity != tt.want.minValidity {
+				t.Errorf("NewBatcher() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatcher_Batches(t *testing.T) {
+	tests := []struct {
+		name string
+		uris []string
+		want [][]string
+	}{
+		{
+			name: "at threshold",
+			uris: []string{"http://a.com/1", "http://b.com/1", "http://a.com/2"},
+			want: nil,
+		},
+		{
+			name: "grouped by host in order",
+			uris: []string{"http://a.com/1", "http://b.com/1", "http://a.com/2", "http://b.com/2"},
+			want: [][]string{{"http://a.com/1", "http://a.com/2"}, {"http://b.com/1", "http://b.com/2"}},
+		},
+		{
+			name: "split at max size",
+			uris: []string{"http://a.com/1", "http://a.com/2", "http://a.com/3", "http://a.com/4", "http://a.com/5"},
+			want: [][]string{{"http://a.com/1", "http://a.com/2"}, {"http://a.com/3", "http://a.com/4"}, {"http://a.com/5"}},
+		},
+		{
+			name: "ports are distinct hosts",
+			uris: []string{"http://a.com/1", "http://a.com:8080/1", "http://a.com/2", "http://a.com:8080/2"},
+			want: [][]string{{"http://a.com/1", "http://a.com/2"}, {"http://a.com:8080/1", "http://a.com:8080/2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := NewBatcher(&BatchConfig{Threshold: 3, MaxSize: 2})
+			if diff := cmp.Diff(tt.want, b.Batches(tt.uris)); diff != "" {
+				t.Errorf("Batches() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBatcher_Wait(t *testing.T) {
+	b, _ := NewBatcher(&BatchConfig{HostInterval: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := b.Wait(ctx, "a.com"); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests to a.com took %v, want at least 100ms", elapsed)
+	}
+
+	start = time.Now()
+	if err := b.Wait(ctx, "b.com"); err != nil {
+		t.Fatalf("Wait() unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("first request to b.com waited %v, want no delay", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := b.Wait(cancelled, "a.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestBatcher_Wait_NoPacing(t *testing.T) {
+	b, _ := NewBatcher(&BatchConfig{})
+	start := time.Now()
+	for range 10 {
+		if err := b.Wait(context.Background(), "a.com"); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("Wait() without pacing took %v, want no delay", elapsed)
+	}
+}
+
+func TestBatcher_Reusable(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	header := func(expires time.Time) string {
+		return fmt.Sprintf(`Signature keyId="gw|k1|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="c2ln"`, now.Unix(), expires.Unix())
+	}
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "valid", header: header(now.Add(5 * time.Minute)), want: true},
+		{name: "expires soon", header: header(now.Add(10 * time.Second))},
+		{name: "expired", header: header(now.Add(-time.Minute))},
+		{name: "no expiry", header: `Signature keyId="gw|k1|ed25519",signature="c2ln"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := NewBatcher(&BatchConfig{})
+			b.now = func() time.Time { return now }
+			if got := b.Reusable(tt.header); got != tt.want {
+				t.Errorf("Reusable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
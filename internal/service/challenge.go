@@ -16,8 +16,34 @@ package service
 
 import (
 	"fmt"
+	"crypto/aes"
+	"crypto/ecdh"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+)
+
+// ErrChallengeSignature occurs if the signature over a challenge answer cannot be verified
+// with the signing public key submitted in the subscription request.
+var ErrChallengeSignature = errors.New("challenge signature verification failed")
+
+// Errors identifying why an NP could not decrypt an /on_subscribe challenge.
+var (
+	// ErrChallengeRegistryKey occurs if the registry's encryption public key cannot be resolved
+	// or is not an X25519 key, usually because regKeyID does not name the registry's key.
+	ErrChallengeRegistryKey = errors.New("registry encryption key is unavailable or invalid")
+	// ErrChallengePrivateKey occurs if the encryption private key of the NP's keyset is not an X25519 key.
+	ErrChallengePrivateKey = errors.New("encryption private key is invalid")
+	// ErrChallengeEncoding occurs if the challenge is not base64 encoded.
+	ErrChallengeEncoding = errors.New("challenge is not valid base64")
+	// ErrChallengeCorrupted occurs if the challenge is not a whole number of AES blocks.
+	ErrChallengeCorrupted = errors.New("challenge ciphertext is corrupted")
+	// ErrChallengeKeyMismatch occurs if a well formed challenge does not decrypt with the NP's
+	// private key and the registry's public key, because it was encrypted for another key pair.
+	ErrChallengeKeyMismatch = errors.New("challenge was not encrypted for this key pair")
 )
 
 type challengeService struct{}
@@ -41,3 +67,58 @@ func (s *challengeService) NewChallenge() (string, error) {
 func (s *challengeService) Verify(challenge, answer string) bool {
 	return challenge == answer
 }
+
+// challengeDecryptCause identifies why a challenge could not be decrypted with the base64
+// encoded X25519 private and public keys, by checking the inputs in the order the decrypter uses them.
+func challengeDecryptCause(challenge, privateKey, publicKey string) error {
+	x25519 := ecdh.X25519()
+	priv, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return ErrChallengePrivateKey
+	}
+	if _, err := x25519.NewPrivateKey(priv); err != nil {
+		return ErrChallengePrivateKey
+	}
+	pub, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return ErrChallengeRegistryKey
+	}
+	if _, err := x25519.NewPublicKey(pub); err != nil {
+		return ErrChallengeRegistryKey
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(challenge)
+	if err != nil {
+		return ErrChallengeEncoding
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return ErrChallengeCorrupted
+	}
+	// The inputs are well formed, so the padding did not check out after decryption.
+	return ErrChallengeKeyMismatch
+}
+
+// signChallenge signs the decrypted challenge answer with the NP's keyset signing private key,
+// proving that the NP holds the private key for its signing public key.
+func signChallenge(answer, signingPrivateKey string) (string, error) {
+	_, sig, err := keyalgo.Sign(signingPrivateKey, []byte(answer))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign challenge answer: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// verifyChallengeSignature checks that signature is a valid signature over the challenge
+// answer by the base64 encoded signing public key, of any keyalgo algorithm.
+func verifyChallengeSignature(answer, signature, signingPublicKey string) error {
+	if _, err := keyalgo.PublicKey(signingPublicKey); err != nil {
+		return fmt.Errorf("%w: invalid signing public key", ErrChallengeSignature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding", ErrChallengeSignature)
+	}
+	if _, err := keyalgo.Verify(signingPublicKey, []byte(answer), sig); err != nil {
+		return ErrChallengeSignature
+	}
+	return nil
+}
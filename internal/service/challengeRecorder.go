@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+// Challenge recorder errors.
+var (
+	ErrChallengeRecorderDisabled = errors.New("challenge recorder is not enabled")
+	ErrInvalidRecorderToken      = errors.New("invalid challenge recorder token")
+)
+
+// defaultChallengeRecorderSize is the number of on_subscribe exchanges kept if none is configured.
+const defaultChallengeRecorderSize = 10
+
+// redacted replaces secrets in captured on_subscribe exchanges.
+const redacted = "[REDACTED]"
+
+// ChallengeRecorderConfig configures the capture of on_subscribe exchanges for debugging.
+type ChallengeRecorderConfig struct {
+	// Size is the number of most recent exchanges kept. Defaults to 10.
+	Size int `yaml:"size"`
+	// TokenSHA256 is the hex encoded SHA-256 hash of the bearer token that authorizes reading the exchanges.
+	TokenSHA256 string `yaml:"tokenSHA256"`
+}
+
+// challengeRecorder keeps the most recent on_subscribe exchanges in memory.
+type challengeRecorder struct {
+	tokenHash []byte
+	size      int
+
+	mu      sync.Mutex
+	records []model.ChallengeRecord // oldest first
+}
+
+// challengeCapture collects an on_subscribe exchange while it is handled.
+// The secrets are redacted from the record when it is added to the recorder.
+type challengeCapture struct {
+	record  model.ChallengeRecord
+	secrets []string
+}
+
+// SetChallengeRecorder enables capturing the most recent on_subscribe exchanges.
+func (s *subscriberService) SetChallengeRecorder(cfg *ChallengeRecorderConfig) error {
+	if cfg == nil {
+		return errors.New("ChallengeRecorderConfig cannot be nil")
+	}
+	if cfg.Size < 0 {
+		return errors.New("challenge recorder size cannot be negative")
+	}
+	tokenHash, err := parseTokenHash(cfg.TokenSHA256)
+	if err != nil {
+		return err
+	}
+	r := &challengeRecorder{tokenHash: tokenHash, size: cfg.Size}
+	if r.size == 0 {
+		r.size = defaultChallengeRecorderSize
+	}
+	s.recorder = r
+	return nil
+}
+
+// AuthorizeChallengeRecords checks a bearer token against the configured challenge recorder token.
+func (s *subscriberService) AuthorizeChallengeRecords(token string) error {
+	if s.recorder == nil {
+		return ErrChallengeRecorderDisabled
+	}
+	if !tokenMatches(token, s.recorder.tokenHash) {
+		return ErrInvalidRecorderToken
+	}
+	return nil
+}
+
+// ChallengeRecords returns the captured on_subscribe exchanges, most recent first.
+func (s *subscriberService) ChallengeRecords() ([]model.ChallengeRecord, error) {
+	r := s.recorder
+	if r == nil {
+		return nil, ErrChallengeRecorderDisabled
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := make([]model.ChallengeRecord, len(r.records))
+	for i, rec := range r.records {
+		records[len(records)-1-i] = rec
+	}
+	return records, nil
+}
+
+// capture starts capturing an on_subscribe exchange. It returns nil if the recorder is disabled.
+func (r *challengeRecorder) capture(req *model.OnSubscribeRequest, at time.Time) *challengeCapture {
+	if r == nil {
+		return nil
+	}
+	return &challengeCapture{record: model.ChallengeRecord{MessageID: req.MessageID, ReceivedAt: at, Request: *req}}
+}
+
+// keys records the public keys used to decrypt the challenge, and the private keys to redact.
+func (c *challengeCapture) keys(keys *becknmodel.Keyset, registryPublicKey string) {
+	if c == nil {
+		return
+	}
+	c.record.EncrPublicKey = keys.EncrPublic
+	c.record.RegistryPublicKey = registryPublicKey
+	c.secrets = append(c.secrets, keys.EncrPrivate, keys.SigningPrivate)
+}
+
+// answer records the decrypted challenge.
+func (c *challengeCapture) answer(decrypted string) {
+	if c == nil {
+		return
+	}
+	c.record.DecryptedChallenge = decrypted
+}
+
+// add completes a captured exchange with its outcome, redacts it and keeps it,
+// evicting the oldest exchange once the recorder is full.
+func (r *challengeRecorder) add(c *challengeCapture, resp *model.OnSubscribeResponse, err error, at time.Time) {
+	if r == nil || c == nil {
+		return
+	}
+	rec := c.record
+	rec.DurationMS = at.Sub(rec.ReceivedAt).Milliseconds()
+	if resp != nil {
+		cp := *resp
+		rec.Response = &cp
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	c.redact(&rec)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+	if len(r.records) > r.size {
+		r.records = r.records[len(r.records)-r.size:]
+	}
+}
+
+// redact replaces the private keys seen while handling the exchange in all fields of rec.
+func (c *challengeCapture) redact(rec *model.ChallengeRecord) {
+	var pairs []string
+	for _, s := range c.secrets {
+		if s != "" {
+			pairs = append(pairs, s, redacted)
+		}
+	}
+	if len(pairs) == 0 {
+		return
+	}
+	rp := strings.NewReplacer(pairs...)
+	for _, f := range []*string{&rec.Request.Challenge, &rec.DecryptedChallenge, &rec.Error} {
+		*f = rp.Replace(*f)
+	}
+	if rec.Response != nil {
+		rec.Response.Answer = rp.Replace(rec.Response.Answer)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+// recorderTokenHash is the SHA-256 hash of "recorder-token".
+var recorderTokenHash = hex.EncodeToString(func() []byte { s := sha256.Sum256([]byte("recorder-token")); return s[:] }())
+
+func newRecorderService(t *testing.T, km keyManager, dec decrypter, size int) *subscriberService {
+	t.Helper()
+	svc, err := NewSubscriberService(&mockRegistryClient{}, km, dec, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	if err != nil {
+		t.Fatalf("NewSubscriberService() unexpected error: %v", err)
+	}
+	if err := svc.SetChallengeRecorder(&ChallengeRecorderConfig{Size: size, TokenSHA256: recorderTokenHash}); err != nil {
+		t.Fatalf("SetChallengeRecorder() unexpected error: %v", err)
+	}
+	return svc
+}
+
+func TestSubscriberService_SetChallengeRecorder_Defaults(t *testing.T) {
+	svc := newRecorderService(t, &mockKeyManager{}, &mockDecrypter{}, 0)
+	if svc.recorder.size != defaultChallengeRecorderSize {
+		t.Errorf("size = %d, want %d", svc.recorder.size, defaultChallengeRecorderSize)
+	}
+}
+
+func TestSubscriberService_SetChallengeRecorder_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *ChallengeRecorderConfig
+	}{
+		{name: "nil config"},
+		{name: "negative size", cfg: &ChallengeRecorderConfig{Size: -1, TokenSHA256: recorderTokenHash}},
+		{name: "missing token hash", cfg: &ChallengeRecorderConfig{}},
+		{name: "token hash not hex", cfg: &ChallengeRecorderConfig{TokenSHA256: "not-hex"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			if err := svc.SetChallengeRecorder(tc.cfg); err == nil {
+				t.Error("SetChallengeRecorder() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSubscriberService_AuthorizeChallengeRecords(t *testing.T) {
+	svc := newRecorderService(t, &mockKeyManager{}, &mockDecrypter{}, 0)
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid token", token: "recorder-token"},
+		{name: "wrong token", token: "other-token", wantErr: ErrInvalidRecorderToken},
+		{name: "empty token", token: "", wantErr: ErrInvalidRecorderToken},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := svc.AuthorizeChallengeRecords(tc.token); !errors.Is(err, tc.wantErr) {
+				t.Errorf("AuthorizeChallengeRecords() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_ChallengeRecorder_Disabled(t *testing.T) {
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	if err := svc.AuthorizeChallengeRecords("recorder-token"); !errors.Is(err, ErrChallengeRecorderDisabled) {
+		t.Errorf("AuthorizeChallengeRecords() error = %v, want %v", err, ErrChallengeRecorderDisabled)
+	}
+	if _, err := svc.ChallengeRecords(); !errors.Is(err, ErrChallengeRecorderDisabled) {
+		t.Errorf("ChallengeRecords() error = %v, want %v", err, ErrChallengeRecorderDisabled)
+	}
+}
+
+func TestSubscriberService_ChallengeRecords_Success(t *testing.T) {
+	km := &mockKeyManager{
+		keysetToReturn:   &becknmodel.Keyset{EncrPublic: "np-public-key", EncrPrivate: "np-private-key"},
+		lookupNPKeysEncr: "reg-public-key",
+	}
+	svc := newRecorderService(t, km, &mockDecrypter{decryptedData: "decrypted-answer"}, 0)
+
+	if _, err := svc.OnSubscribe(context.Background(), &model.OnSubscribeRequest{MessageID: "msg1", Challenge: "encrypted-challenge"}); err != nil {
+		t.Fatalf("OnSubscribe() unexpected error: %v", err)
+	}
+
+	records, err := svc.ChallengeRecords()
+	if err != nil {
+		t.Fatalf("ChallengeRecords() unexpected error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("ChallengeRecords() returned %d records, want 1", len(records))
+	}
+	rec := records[0]
+	if rec.MessageID != "msg1" || rec.Request.Challenge != "encrypted-challenge" {
+		t.Errorf("record request = %+v, want message_id msg1 and challenge encrypted-challenge", rec.Request)
+	}
+	if rec.EncrPublicKey != "np-public-key" || rec.RegistryPublicKey != "reg-public-key" {
+		t.Errorf("record keys = (%q, %q), want (np-public-key, reg-public-key)", rec.EncrPublicKey, rec.RegistryPublicKey)
+	}
+	if rec.DecryptedChallenge != "decrypted-answer" {
+		t.Errorf("record decrypted challenge = %q, want %q", rec.DecryptedChallenge, "decrypted-answer")
+	}
+	if rec.Response == nil || rec.Response.Answer != "decrypted-answer" {
+		t.Errorf("record response = %+v, want answer decrypted-answer", rec.Response)
+	}
+	if rec.Error != "" {
+		t.Errorf("record error = %q, want empty", rec.Error)
+	}
+}
+
+func TestSubscriberService_ChallengeRecords_Error(t *testing.T) {
+	km := &mockKeyManager{
+		keysetToReturn:   &becknmodel.Keyset{EncrPrivate: "np-private-key", SigningPrivate: "np-signing-key"},
+		lookupNPKeysEncr: "reg-public-key",
+	}
+	dec := &mockDecrypter{decryptErr: errors.New("bad key np-private-key for signer np-signing-key")}
+	svc := newRecorderService(t, km, dec, 0)
+
+	if _, err := svc.OnSubscribe(context.Background(), &model.OnSubscribeRequest{MessageID: "msg1", Challenge: "encrypted-challenge"}); err == nil {
+		t.Fatal("OnSubscribe() expected error, got nil")
+	}
+
+	records, err := svc.ChallengeRecords()
+	if err != nil {
+		t.Fatalf("ChallengeRecords() unexpected error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("ChallengeRecords() returned %d records, want 1", len(records))
+	}
+	rec := records[0]
+	if rec.Response != nil {
+		t.Errorf("record response = %+v, want nil", rec.Response)
+	}
+	if !strings.Contains(rec.Error, "failed to decrypt challenge") {
+		t.Errorf("record error = %q, want decryption failure", rec.Error)
+	}
+	for _, secret := range []string{"np-private-key", "np-signing-key"} {
+		if strings.Contains(rec.Error, secret) {
+			t.Errorf("record error = %q, leaks %q", rec.Error, secret)
+		}
+	}
+	if !strings.Contains(rec.Error, redacted) {
+		t.Errorf("record error = %q, want %q", rec.Error, redacted)
+	}
+}
+
+func TestSubscriberService_ChallengeRecords_Evicts(t *testing.T) {
+	km := &mockKeyManager{
+		keysetToReturn:   &becknmodel.Keyset{EncrPrivate: "np-private-key"},
+		lookupNPKeysEncr: "reg-public-key",
+	}
+	svc := newRecorderService(t, km, &mockDecrypter{decryptedData: "decrypted-answer"}, 2)
+
+	for i := 1; i <= 3; i++ {
+		req := &model.OnSubscribeRequest{MessageID: fmt.Sprintf("msg%d", i), Challenge: "encrypted-challenge"}
+		if _, err := svc.OnSubscribe(context.Background(), req); err != nil {
+			t.Fatalf("OnSubscribe(%s) unexpected error: %v", req.MessageID, err)
+		}
+	}
+
+	records, err := svc.ChallengeRecords()
+	if err != nil {
+		t.Fatalf("ChallengeRecords() unexpected error: %v", err)
+	}
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.MessageID)
+	}
+	if want := []string{"msg3", "msg2"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ChallengeRecords() message IDs = %v, want %v", got, want)
+	}
+}
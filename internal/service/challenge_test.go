@@ -15,9 +15,26 @@
 package service
 
 import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
+// testSigningKeys returns a base64 encoded ed25519 public key and private key seed.
+func testSigningKeys(t *testing.T) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() failed: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv.Seed())
+}
+
 func TestNewChallengeService(t *testing.T) {
 	s := NewChallengeService()
 	if s == nil {
@@ -104,3 +121,106 @@ func TestChallengeService_Verify(t *testing.T) {
 		})
 	}
 }
+
+func TestChallengeSignature(t *testing.T) {
+	pub, priv := testSigningKeys(t)
+	otherPub, _ := testSigningKeys(t)
+	sig, err := signChallenge("answer", priv)
+	if err != nil {
+		t.Fatalf("signChallenge() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		answer    string
+		signature string
+		publicKey string
+		wantErr   bool
+	}{
+		{name: "valid", answer: "answer", signature: sig, publicKey: pub},
+		{name: "different answer", answer: "other", signature: sig, publicKey: pub, wantErr: true},
+		{name: "mismatched public key", answer: "answer", signature: sig, publicKey: otherPub, wantErr: true},
+		{name: "malformed public key", answer: "answer", signature: sig, publicKey: "not-a-key", wantErr: true},
+		{name: "malformed signature", answer: "answer", signature: "%%%", publicKey: pub, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyChallengeSignature(tt.answer, tt.signature, tt.publicKey)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("verifyChallengeSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrChallengeSignature) {
+				t.Errorf("verifyChallengeSignature() error = %v, want %v", err, ErrChallengeSignature)
+			}
+		})
+	}
+}
+
+func TestSignChallenge_InvalidKey(t *testing.T) {
+	for _, key := range []string{"%%%", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := signChallenge("answer", key); err == nil {
+			t.Errorf("signChallenge(%q) error = nil, want error", key)
+		}
+	}
+}
+
+func TestChallengeSignature_Secp256k1(t *testing.T) {
+	priv, pub, err := keyalgo.GenerateSigningKey(keyalgo.Secp256k1)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	sig, err := signChallenge("answer", priv)
+	if err != nil {
+		t.Fatalf("signChallenge() error = %v", err)
+	}
+	if err := verifyChallengeSignature("answer", sig, pub); err != nil {
+		t.Errorf("verifyChallengeSignature() error = %v", err)
+	}
+	if err := verifyChallengeSignature("other", sig, pub); !errors.Is(err, ErrChallengeSignature) {
+		t.Errorf("verifyChallengeSignature() of other answer error = %v, want %v", err, ErrChallengeSignature)
+	}
+}
+
+// testEncryptionKey returns a base64 encoded X25519 private or public key.
+func testEncryptionKey(t *testing.T, public bool) string {
+	t.Helper()
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdh.GenerateKey() failed: %v", err)
+	}
+	if public {
+		return base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	}
+	return base64.StdEncoding.EncodeToString(key.Bytes())
+}
+
+func TestChallengeDecryptCause(t *testing.T) {
+	priv := testEncryptionKey(t, false)
+	pub := testEncryptionKey(t, true)
+	blocks := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		name      string
+		challenge string
+		priv      string
+		pub       string
+		want      error
+	}{
+		{name: "private key not base64", challenge: blocks, priv: "%%%", pub: pub, want: ErrChallengePrivateKey},
+		{name: "private key wrong length", challenge: blocks, priv: base64.StdEncoding.EncodeToString([]byte("short")), pub: pub, want: ErrChallengePrivateKey},
+		{name: "registry key not base64", challenge: blocks, priv: priv, pub: "%%%", want: ErrChallengeRegistryKey},
+		{name: "registry key wrong length", challenge: blocks, priv: priv, pub: base64.StdEncoding.EncodeToString([]byte("short")), want: ErrChallengeRegistryKey},
+		{name: "challenge not base64", challenge: "not-base64!", priv: priv, pub: pub, want: ErrChallengeEncoding},
+		{name: "challenge empty", challenge: "", priv: priv, pub: pub, want: ErrChallengeCorrupted},
+		{name: "challenge truncated", challenge: base64.StdEncoding.EncodeToString(make([]byte, 20)), priv: priv, pub: pub, want: ErrChallengeCorrupted},
+		{name: "well formed", challenge: blocks, priv: priv, pub: pub, want: ErrChallengeKeyMismatch},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := challengeDecryptCause(tc.challenge, tc.priv, tc.pub); got != tc.want {
+				t.Errorf("challengeDecryptCause() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// Errors returned when the challenge of an operation cannot be replayed.
+var (
+	// ErrOperationNotApproved occurs if the operation to replay is not APPROVED.
+	ErrOperationNotApproved = errors.New("operation is not approved")
+	// ErrReplaySubscriptionNotFound occurs if the subscription of the operation no longer exists,
+	// or has been updated to another key by a later operation.
+	ErrReplaySubscriptionNotFound = errors.New("subscription of the operation not found")
+)
+
+// ReplayChallenge sends a new /on_subscribe challenge to the subscription approved by an
+// operation and reports whether the subscriber still answers it. The challenge is sent with the
+// operation's message ID, so that the subscriber answers with the keyset it subscribed with.
+// The operation and the subscription are not modified and no event is published; a challenge
+// the subscriber fails is reported in the result rather than as an error.
+func (s *adminService) ReplayChallenge(ctx context.Context, operationID string) (*model.ChallengeReplay, error) {
+	lro, err := s.regRepo.GetOperation(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation %s: %w", operationID, err)
+	}
+	if lro.Status != model.LROStatusApproved {
+		return nil, fmt.Errorf("%w: operation %s has status %s", ErrOperationNotApproved, operationID, lro.Status)
+	}
+	var subReq model.SubscriptionRequest
+	if err := json.Unmarshal(lro.RequestJSON, &subReq); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request of operation %s: %w", operationID, err)
+	}
+	subs, err := s.regRepo.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: subReq.SubscriberID, Domain: subReq.Domain, Type: subReq.Type},
+		KeyID:      subReq.KeyID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lookup failed: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("%w: subscriber_id '%s', domain '%s', type '%s', key_id '%s'", ErrReplaySubscriptionNotFound, subReq.SubscriberID, subReq.Domain, subReq.Type, subReq.KeyID)
+	}
+	sub := subs[0]
+	res := &model.ChallengeReplay{OperationID: operationID, SubscriberID: sub.SubscriberID, KeyID: sub.KeyID, URL: sub.URL}
+	signed, err := s.replayChallenge(ctx, &sub, subReq.MessageID)
+	res.Passed, res.Signed, res.CheckedAt = err == nil, signed, s.now().UTC()
+	if err != nil {
+		res.Error = err.Error()
+		slog.WarnContext(ctx, "AdminService: Replayed challenge failed", "operation_id", operationID, "subscriber_id", sub.SubscriberID, "error", err)
+		return res, nil
+	}
+	slog.InfoContext(ctx, "AdminService: Replayed challenge passed", "operation_id", operationID, "subscriber_id", sub.SubscriberID, "signed", signed)
+	return res, nil
+}
+
+// replayChallenge sends a new challenge to sub and verifies the answer against its registered
+// keys. It reports whether the answer was signed.
+func (s *adminService) replayChallenge(ctx context.Context, sub *model.Subscription, messageID string) (bool, error) {
+	challenge, err := s.chSrv.NewChallenge()
+	if err != nil {
+		return false, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	encrypted, err := s.encryptor.Encrypt(ctx, challenge, sub.EncrPublicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt challenge: %w", err)
+	}
+	resp, err := s.npClient.OnSubscribe(ctx, sub.URL, &model.OnSubscribeRequest{Challenge: encrypted, MessageID: messageID})
+	if err != nil {
+		return false, fmt.Errorf("network Participant /on_subscribe callback failed: %w", err)
+	}
+	if !s.chSrv.Verify(challenge, resp.Answer) {
+		return resp.Signature != "", errors.New("challenge verification failed")
+	}
+	switch {
+	case resp.Signature != "":
+		return true, verifyChallengeSignature(resp.Answer, resp.Signature, sub.SigningPublicKey)
+	case s.cfg.RequireChallengeSignature:
+		return false, fmt.Errorf("%w: /on_subscribe response is not signed", ErrChallengeSignature)
+	}
+	return false, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingNPClient records the /on_subscribe call made to it.
+type recordingNPClient struct {
+	mockNPClient
+	gotURL string
+	gotReq *model.OnSubscribeRequest
+}
+
+func (m *recordingNPClient) OnSubscribe(ctx context.Context, callbackURL string, request *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
+	m.gotURL, m.gotReq = callbackURL, request
+	return m.mockNPClient.OnSubscribe(ctx, callbackURL, request)
+}
+
+func TestAdminService_ReplayChallenge(t *testing.T) {
+	pub, priv := testSigningKeys(t)
+	otherPub, _ := testSigningKeys(t)
+	sig, err := signChallenge("challenge123", priv)
+	if err != nil {
+		t.Fatalf("signChallenge() error = %v", err)
+	}
+	subReq := model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{SubscriberID: "np.example.com", URL: "https://old.example.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:      "key-1",
+		},
+		MessageID: "msg-1",
+	}
+	reqJSON, _ := json.Marshal(subReq)
+	approved := &model.LRO{OperationID: "op-1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusApproved, RequestJSON: reqJSON}
+	sub := model.Subscription{
+		Subscriber:       model.Subscriber{SubscriberID: "np.example.com", URL: "https://np.example.com", Type: model.RoleBAP, Domain: "retail"},
+		KeyID:            "key-1",
+		EncrPublicKey:    "encr-pub",
+		SigningPublicKey: pub,
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := model.ChallengeReplay{OperationID: "op-1", SubscriberID: "np.example.com", KeyID: "key-1", URL: "https://np.example.com", CheckedAt: now}
+
+	tests := []struct {
+		name    string
+		lro     *model.LRO
+		getErr  error
+		subs    []model.Subscription
+		verify  bool
+		resp    *model.OnSubscribeResponse
+		npErr   error
+		require bool
+		signKey string
+		want    func(model.ChallengeReplay) model.ChallengeReplay
+		wantErr error
+	}{
+		{
+			name: "signed answer passes", lro: approved, subs: []model.Subscription{sub}, verify: true,
+			resp: &model.OnSubscribeResponse{Answer: "challenge123", Signature: sig},
+			want: func(r model.ChallengeReplay) model.ChallengeReplay { r.Passed, r.Signed = true, true; return r },
+		},
+		{
+			name: "unsigned answer passes", lro: approved, subs: []model.Subscription{sub}, verify: true,
+			resp: &model.OnSubscribeResponse{Answer: "challenge123"},
+			want: func(r model.ChallengeReplay) model.ChallengeReplay { r.Passed = true; return r },
+		},
+		{
+			name: "unsigned answer fails when signature required", lro: approved, subs: []model.Subscription{sub}, verify: true, require: true,
+			resp: &model.OnSubscribeResponse{Answer: "challenge123"},
+			want: func(r model.ChallengeReplay) model.ChallengeReplay {
+				r.Error = "challenge signature verification failed: /on_subscribe response is not signed"
+				return r
+			},
+		},
+		{
+			name: "signature of another key fails", lro: approved, subs: []model.Subscription{sub}, verify: true, signKey: otherPub,
+			resp: &model.OnSubscribeResponse{Answer: "challenge123", Signature: sig},
+			want: func(r model.ChallengeReplay) model.ChallengeReplay {
+				r.Signed, r.Error = true, ErrChallengeSignature.Error()
+				return r
+			},
+		},
+		{
+			name: "wrong answer fails", lro: approved, subs: []model.Subscription{sub},
+			resp: &model.OnSubscribeResponse{Answer: "wrong"},
+			want: func(r model.ChallengeReplay) model.ChallengeReplay {
+				r.Error = "challenge verification failed"
+				return r
+			},
+		},
+		{
+			name: "unreachable subscriber fails", lro: approved, subs: []model.Subscription{sub}, npErr: errors.New("connection refused"),
+			want: func(r model.ChallengeReplay) model.ChallengeReplay {
+				r.Error = "network Participant /on_subscribe callback failed: connection refused"
+				return r
+			},
+		},
+		{name: "operation not found", getErr: errors.New("not found"), wantErr: errors.New("not found")},
+		{name: "operation pending", lro: &model.LRO{OperationID: "op-1", Status: model.LROStatusPending, RequestJSON: reqJSON}, wantErr: ErrOperationNotApproved},
+		{name: "subscription superseded", lro: approved, wantErr: ErrReplaySubscriptionNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockRegRepo{lroToReturn: tc.lro, getOperationErr: tc.getErr, lookupSubsToReturn: tc.subs}
+			np := &recordingNPClient{mockNPClient: mockNPClient{onSubscribeResponseToReturn: tc.resp, onSubscribeErr: tc.npErr}}
+			srv, err := NewAdminService(repo, &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: tc.verify}, &mockEncryptionSrv{encryptedDataToReturn: "encrypted"}, np, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3, RequireChallengeSignature: tc.require})
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+			srv.now = func() time.Time { return now }
+			if tc.signKey != "" {
+				repo.lookupSubsToReturn = []model.Subscription{sub}
+				repo.lookupSubsToReturn[0].SigningPublicKey = tc.signKey
+			}
+
+			got, err := srv.ReplayChallenge(context.Background(), "op-1")
+			if tc.wantErr != nil {
+				if err == nil || (!errors.Is(err, tc.wantErr) && !strings.Contains(err.Error(), tc.wantErr.Error())) {
+					t.Fatalf("ReplayChallenge() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReplayChallenge() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want(base), *got); diff != "" {
+				t.Errorf("ReplayChallenge() mismatch (-want +got):\n%s", diff)
+			}
+			if np.gotURL != sub.URL || np.gotReq.MessageID != "msg-1" || np.gotReq.Challenge != "encrypted" {
+				t.Errorf("OnSubscribe() called with %s, %+v, want the registered URL, the original message ID and the new challenge", np.gotURL, np.gotReq)
+			}
+			if repo.updateOperationCalls != 0 || repo.upsertCalls != 0 {
+				t.Errorf("ReplayChallenge() wrote to the repository: %d updates, %d upserts", repo.updateOperationCalls, repo.upsertCalls)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package service
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"math/rand"
@@ -25,6 +26,9 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
+// lookupMetrics counts the lookups that found no subscriber to forward the request to.
+var lookupMetrics = expvar.NewMap("gateway_lookup")
+
 // taskQueuer defines the interface for queueing tasks.
 type taskQueuer interface {
 	QueueTxn(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error)
@@ -40,6 +44,21 @@ type lookupClient interface {
 	Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error)
 }
 
+// targetBatcher groups fanout targets into per-host batches.
+type targetBatcher interface {
+	Batches(uris []string) [][]string
+}
+
+// batchQueuer queues a proxy task for several targets at once.
+type batchQueuer interface {
+	QueueBatch(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header, uris []string) (*model.AsyncTask, error)
+}
+
+// targetThrottle decides whether a fanout sends a request to a target.
+type targetThrottle interface {
+	Allow(uri string) bool
+}
+
 // channelLookupProcessor handles tasks that require looking up subscribers
 // and then fanning out proxy tasks to them.
 type channelLookupProcessor struct {
@@ -48,6 +67,9 @@ type channelLookupProcessor struct {
 	registryClient lookupClient
 	authGen        authGen
 	taskQueuer     taskQueuer
+	batcher        targetBatcher
+	batchQueuer    batchQueuer
+	throttle       targetThrottle
 }
 
 // NewLookupTaskProcessor creates a new LookupTaskProcessor.
@@ -83,6 +105,19 @@ func NewChannelLookupProcessor(registryClient lookupClient, authGen authGen, tq
 	}, nil
 }
 
+// SetBatching queues the proxy tasks of a fanout in per-host batches when there are more
+// targets than the batcher's threshold, instead of one task per target.
+func (p *channelLookupProcessor) SetBatching(b targetBatcher, q batchQueuer) {
+	p.batcher = b
+	p.batchQueuer = q
+}
+
+// SetThrottle skips the targets of a fanout that the throttle does not allow,
+// e.g. because they are too slow to answer within the TTL of the request.
+func (p *channelLookupProcessor) SetThrottle(t targetThrottle) {
+	p.throttle = t
+}
+
 // validateTask checks if the AsyncTask is valid for processing.
 func (p *channelLookupProcessor) validateTask(ctx context.Context, task *model.AsyncTask) error {
 	if task == nil {
@@ -108,7 +143,11 @@ func (p *channelLookupProcessor) lookup(ctx context.Context, reqCtx *model.Conte
 			Type:         model.RoleBPP,
 			SubscriberID: reqCtx.BppID,
 			Location:     reqCtx.Location,
-		}}
+		},
+		// Only subscribed participants receive requests; this also lets the registry
+		// serve the lookup from its partial index on subscribed participants.
+		Status: model.SubscriptionStatusSubscribed,
+	}
 
 	slog.DebugContext(ctx, "LookupTaskProcessor: Performing lookup with criteria", "criteria", lookupCriteria)
 	subscriptions, err := p.registryClient.Lookup(ctx, lookupCriteria)
@@ -135,6 +174,15 @@ func (p *channelLookupProcessor) enqueueProxyTasks(ctx context.Context, subscrip
 	rand.Shuffle(len(subscriptions), func(i, j int) {
 		subscriptions[i], subscriptions[j] = subscriptions[j], subscriptions[i]
 	})
+	if p.throttle != nil {
+		subscriptions = p.allowedTargets(ctx, subscriptions)
+	}
+
+	if p.batcher != nil {
+		if batches := p.batcher.Batches(p.targetURIs(subscriptions)); batches != nil {
+			return p.enqueueBatches(ctx, batches, originalTask, headersForProxy)
+		}
+	}
 
 	successfulPublications := 0
 	skipped := 0
@@ -179,6 +227,59 @@ func (p *channelLookupProcessor) enqueueProxyTasks(ctx context.Context, subscrip
 	return firstError // Return the first error encountered, or nil if all successful
 }
 
+// allowedTargets returns the subscriptions whose URL the throttle allows, keeping their order.
+func (p *channelLookupProcessor) allowedTargets(ctx context.Context, subscriptions []model.Subscription) []model.Subscription {
+	allowed := subscriptions[:0]
+	for _, sub := range subscriptions {
+		if sub.URL != "" && !p.throttle.Allow(sub.URL) {
+			slog.DebugContext(ctx, "LookupTaskProcessor: Skipping throttled subscriber", "subscriber_id", sub.SubscriberID, "target_bpp_uri", sub.URL)
+			continue
+		}
+		allowed = append(allowed, sub)
+	}
+	if skipped := len(subscriptions) - len(allowed); skipped > 0 {
+		slog.InfoContext(ctx, "LookupTaskProcessor: Skipped slow subscribers under high load", "skipped", skipped, "remaining", len(allowed))
+	}
+	return allowed
+}
+
+// targetURIs returns the URLs of the subscriptions, skipping those without one,
+// up to the maxProxyTasks limit.
+func (p *channelLookupProcessor) targetURIs(subscriptions []model.Subscription) []string {
+	var uris []string
+	for _, sub := range subscriptions {
+		if sub.URL == "" {
+			continue
+		}
+		uris = append(uris, sub.URL)
+		if p.maxProxyTasks > 0 && len(uris) >= p.maxProxyTasks {
+			break
+		}
+	}
+	return uris
+}
+
+// enqueueBatches queues a batched proxy task for each batch of target URIs. All batches
+// share the signed headers of the fanout.
+func (p *channelLookupProcessor) enqueueBatches(ctx context.Context, batches [][]string, originalTask *model.AsyncTask, headers http.Header) error {
+	var firstError error
+	queued := 0
+	for _, uris := range batches {
+		reqCtx := originalTask.Context
+		if _, err := p.batchQueuer.QueueBatch(ctx, &reqCtx, originalTask.Body, headers, uris); err != nil {
+			errMsg := fmt.Errorf("failed to queue proxy task batch for %d targets (first URL: %s): %w", len(uris), uris[0], err)
+			slog.ErrorContext(ctx, "LookupTaskProcessor: Error enqueuing proxy task batch", "error", errMsg)
+			if firstError == nil {
+				firstError = errMsg
+			}
+			continue
+		}
+		queued += len(uris)
+	}
+	slog.InfoContext(ctx, "LookupTaskProcessor: Finished enqueuing proxy task batches", "batches", len(batches), "targets", queued)
+	return firstError
+}
+
 // Process handles the given LOOKUP asynchronous task.
 // It looks up subscribers based on the task body and queues individual PROXY tasks for each.
 func (p *channelLookupProcessor) Process(ctx context.Context, task *model.AsyncTask) error {
@@ -195,6 +296,7 @@ func (p *channelLookupProcessor) Process(ctx context.Context, task *model.AsyncT
 	// If no subscribers found, nothing more to do.
 	if len(subscriptions) == 0 {
 		slog.InfoContext(ctx, "LookupTaskProcessor: No subscribers found for the given lookup criteria")
+		lookupMetrics.Add("no_subscribers", 1)
 		return nil // No error if no subscribers found, just nothing to do.
 	}
 
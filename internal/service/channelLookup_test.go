@@ -17,21 +17,26 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
 )
 
 // mockLookupClient is a mock for the lookupClient interface.
 type mockLookupClient struct {
 	subscriptions []model.Subscription
 	err           error
+	gotRequest    *model.Subscription
 }
 
 func (m *mockLookupClient) Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
+	m.gotRequest = request
 	return m.subscriptions, m.err
 }
 
@@ -316,3 +321,183 @@ func TestChannelLookupProcessor_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestChannelLookupProcessor_LookupCriteria(t *testing.T) {
+	task := &model.AsyncTask{
+		Type: model.AsyncTaskTypeLookup,
+		Body: []byte(`{"context":{"domain":"test-domain"}}`),
+		Context: model.Context{
+			Domain:   "test-domain",
+			BppID:    "bpp1",
+			Location: &model.Location{City: &model.City{Code: "std:080"}},
+		},
+	}
+	mockLookup := &mockLookupClient{}
+	processor, _ := NewChannelLookupProcessor(mockLookup, &mockAuthGen{}, &mockTaskQueuer{}, "test-id", 10)
+
+	if err := processor.Process(context.Background(), task); err != nil {
+		t.Fatalf("Process() unexpected error = %v", err)
+	}
+
+	want := &model.Subscription{
+		Subscriber: model.Subscriber{
+			SubscriberID: "bpp1",
+			Domain:       "test-domain",
+			Type:         model.RoleBPP,
+			Location:     &model.Location{City: &model.City{Code: "std:080"}},
+		},
+		Status: model.SubscriptionStatusSubscribed,
+	}
+	if diff := cmp.Diff(want, mockLookup.gotRequest); diff != "" {
+		t.Errorf("Process() lookup criteria mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// mockBatchQueuer is a mock for the batchQueuer interface.
+type mockBatchQueuer struct {
+	err        error
+	gotBatches [][]string
+	gotHeaders []http.Header
+}
+
+func (m *mockBatchQueuer) QueueBatch(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header, uris []string) (*model.AsyncTask, error) {
+	m.gotBatches = append(m.gotBatches, uris)
+	m.gotHeaders = append(m.gotHeaders, h)
+	return &model.AsyncTask{}, m.err
+}
+
+func TestChannelLookupProcessor_Process_Batching(t *testing.T) {
+	task := &model.AsyncTask{
+		Type:    model.AsyncTaskTypeLookup,
+		Body:    []byte(`{"context":{"domain":"test-domain"}}`),
+		Context: model.Context{Domain: "test-domain", Action: "search"},
+		Headers: http.Header{},
+	}
+	subs := func(urls ...string) []model.Subscription {
+		var s []model.Subscription
+		for i, u := range urls {
+			s = append(s, model.Subscription{Subscriber: model.Subscriber{SubscriberID: fmt.Sprintf("sub%d", i), URL: u}})
+		}
+		return s
+	}
+	tests := []struct {
+		name          string
+		subscriptions []model.Subscription
+		maxProxyTasks int
+		queueErr      error
+		wantBatches   int
+		wantTargets   []string
+		wantSingles   int
+		wantErr       string
+	}{
+		{
+			name:          "below threshold queues single tasks",
+			subscriptions: subs("http://a.com/1", "http://a.com/2"),
+			wantSingles:   2,
+		},
+		{
+			name:          "above threshold queues batches per host",
+			subscriptions: subs("http://a.com/1", "http://a.com/2", "http://a.com/3", "http://b.com/1", ""),
+			wantBatches:   3,
+			wantTargets:   []string{"http://a.com/1", "http://a.com/2", "http://a.com/3", "http://b.com/1"},
+		},
+		{
+			name:          "maxProxyTasks applies before batching",
+			subscriptions: subs("http://a.com/1", "http://a.com/2", "http://a.com/3", "http://a.com/4"),
+			maxProxyTasks: 2,
+			wantSingles:   2,
+		},
+		{
+			name:          "batch queue error",
+			subscriptions: subs("http://a.com/1", "http://a.com/2", "http://a.com/3"),
+			queueErr:      errors.New("queue full"),
+			wantBatches:   2,
+			wantTargets:   []string{"http://a.com/1", "http://a.com/2", "http://a.com/3"},
+			wantErr:       "queue full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queuer := &mockTaskQueuer{}
+			batchQueuer := &mockBatchQueuer{err: tt.queueErr}
+			b, _ := NewBatcher(&BatchConfig{Threshold: 2, MaxSize: 2})
+			p, _ := NewChannelLookupProcessor(&mockLookupClient{subscriptions: tt.subscriptions}, &mockAuthGen{authHeader: "lookup-auth"}, queuer, "test-sub-id", tt.maxProxyTasks)
+			p.SetBatching(b, batchQueuer)
+
+			err := p.Process(context.Background(), task)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Process() error = %v, want containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Process() unexpected error = %v", err)
+			}
+			if queuer.callCount != tt.wantSingles {
+				t.Errorf("QueueTxn() called %d times, want %d", queuer.callCount, tt.wantSingles)
+			}
+			if len(batchQueuer.gotBatches) != tt.wantBatches {
+				t.Fatalf("QueueBatch() called %d times, want %d: %v", len(batchQueuer.gotBatches), tt.wantBatches, batchQueuer.gotBatches)
+			}
+			// Subscriptions are shuffled, so only the grouping of targets is deterministic.
+			var gotTargets []string
+			for i, batch := range batchQueuer.gotBatches {
+				if len(batch) > 2 {
+					t.Errorf("batch %v has more than 2 targets", batch)
+				}
+				host := mustParseURL(batch[0]).Host
+				for _, uri := range batch {
+					if h := mustParseURL(uri).Host; h != host {
+						t.Errorf("batch %v mixes hosts %s and %s", batch, host, h)
+					}
+				}
+				gotTargets = append(gotTargets, batch...)
+				if got := batchQueuer.gotHeaders[i].Get(model.AuthHeaderGateway); got != "lookup-auth" {
+					t.Errorf("QueueBatch() %s = %q, want %q", model.AuthHeaderGateway, got, "lookup-auth")
+				}
+			}
+			slices.Sort(gotTargets)
+			if diff := cmp.Diff(tt.wantTargets, gotTargets); diff != "" {
+				t.Errorf("QueueBatch() targets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// mockTargetThrottle is a mock for targetThrottle that denies the listed URIs.
+type mockTargetThrottle struct {
+	deny map[string]bool
+}
+
+func (m *mockTargetThrottle) Allow(uri string) bool {
+	return !m.deny[uri]
+}
+
+func TestChannelLookupProcessor_Process_Throttle(t *testing.T) {
+	task := &model.AsyncTask{
+		Type:    model.AsyncTaskTypeLookup,
+		Body:    []byte(`{"context":{"domain":"test-domain"}}`),
+		Context: model.Context{Domain: "test-domain", Action: "search"},
+		Headers: http.Header{},
+	}
+	subscriptions := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "fast", URL: "http://fast.com/beckn"}},
+		{Subscriber: model.Subscriber{SubscriberID: "slow", URL: "http://slow.com/beckn"}},
+		{Subscriber: model.Subscriber{SubscriberID: "other", URL: "http://other.com/beckn"}},
+	}
+	var gotURIs []string
+	queuer := &mockTaskQueuer{QueueTxnFunc: func(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+		gotURIs = append(gotURIs, reqCtx.BppURI)
+		return &model.AsyncTask{}, nil
+	}}
+	p, _ := NewChannelLookupProcessor(&mockLookupClient{subscriptions: subscriptions}, &mockAuthGen{authHeader: "lookup-auth"}, queuer, "test-sub-id", 0)
+	p.SetThrottle(&mockTargetThrottle{deny: map[string]bool{"http://slow.com/beckn": true}})
+
+	if err := p.Process(context.Background(), task); err != nil {
+		t.Fatalf("Process() unexpected error = %v", err)
+	}
+	slices.Sort(gotURIs)
+	if diff := cmp.Diff([]string{"http://fast.com/beckn", "http://other.com/beckn"}, gotURIs); diff != "" {
+		t.Errorf("QueueTxn() targets mismatch (-want +got):\n%s", diff)
+	}
+}
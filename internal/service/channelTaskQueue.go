@@ -16,11 +16,15 @@ package service
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
@@ -31,10 +35,60 @@ type taskProcessor interface {
 	Process(ctx context.Context, task *model.AsyncTask) error
 }
 
+// actionRouter resolves the routing of custom actions.
+type actionRouter interface {
+	Routing(action string) (ActionRouting, bool)
+}
+
+// txnJournal is a write-ahead journal that records accepted tasks until they are processed.
+type txnJournal interface {
+	Append(ctx context.Context, task *model.AsyncTask) (string, error)
+	Ack(ctx context.Context, id string) error
+	Pending(ctx context.Context) ([]*JournalEntry, error)
+}
+
+// deadLetterQueue stores tasks that could not be processed, for later inspection.
+type deadLetterQueue interface {
+	Append(ctx context.Context, task *model.AsyncTask) (string, error)
+}
+
+// taskLeaser claims journal entries, so that replicas sharing a journal process each entry once.
+type taskLeaser interface {
+	Claim(ctx context.Context, id string) (bool, error)
+	Renew(ctx context.Context, id string) (bool, error)
+	Release(ctx context.Context, id string) error
+}
+
+// taskQueueMetrics counts panics in task processors and their outcome.
+var taskQueueMetrics = expvar.NewMap("task_queue")
+
+// ErrTaskQueueFull is returned when a task is rejected because the buffer of its partition is full.
+var ErrTaskQueueFull = errors.New("task queue is full")
+
+// defaultTaskPartition names the partition of the tasks of domains without a partition.
+const defaultTaskPartition = "default"
+
+// TaskQueuePartition reserves workers and buffer space of the task queue for the tasks of
+// some domains, so that a surge in one domain cannot starve the fanout of the others.
+type TaskQueuePartition struct {
+	Name       string   `yaml:"name"`       // Identifies the partition in logs.
+	Domains    []string `yaml:"domains"`    // Domains whose tasks are queued in the partition.
+	Workers    int      `yaml:"workers"`    // Workers processing the partition. Defaults to 1.
+	BufferSize int      `yaml:"bufferSize"` // Capacity of the partition. Defaults to 100.
+}
+
+// taskPartition is a task channel with its own workers.
+type taskPartition struct {
+	name    string
+	ch      chan channelQueueItem
+	workers int
+}
+
 // channelQueueItem wraps an AsyncTask with its original request context.
 type channelQueueItem struct {
 	originalCtx context.Context
 	task        *model.AsyncTask
+	journalID   string
 }
 
 // ChannelTaskQueue implements an in-memory task queue using Go channels and a worker goroutine.
@@ -42,8 +96,22 @@ type ChannelTaskQueue struct {
 	taskChannel     chan channelQueueItem
 	proxyProcessor  taskProcessor
 	lookupProcessor taskProcessor
+	journal         txnJournal
+	deadLetter      deadLetterQueue
+	redactor        bodyRedactor
+	actions         actionRouter
 	numWorkers      int
 
+	// partitions hold the tasks of the domains in byDomain; other tasks go to taskChannel.
+	partitions []*taskPartition
+	byDomain   map[string]*taskPartition
+
+	lease    taskLeaser
+	leaseCfg *TaskLeaseConfig
+	leaseMu  sync.Mutex
+	leases   map[string]struct{} // Journal IDs claimed by this replica.
+	now      func() time.Time
+
 	workerCtx    context.Context
 	workerCancel context.CancelFunc
 	wg           sync.WaitGroup
@@ -82,6 +150,7 @@ func NewChannelTaskQueue(
 		numWorkers:      numWorkers,
 		workerCtx:       workerCtx,
 		workerCancel:    workerCancel,
+		now:             time.Now,
 	}, nil
 }
 
@@ -94,6 +163,310 @@ func (ctq *ChannelTaskQueue) SetLookupProcessor(lookupP taskProcessor) {
 	ctq.lookupProcessor = lookupP
 }
 
+// SetJournal sets the write-ahead journal used to record tasks before they are acknowledged.
+// Tasks are removed from the journal once a worker has processed them.
+func (ctq *ChannelTaskQueue) SetJournal(j txnJournal) {
+	ctq.journal = j
+}
+
+// SetDeadLetter sets the queue that receives tasks whose processing panicked.
+// Without it, such tasks are only logged.
+func (ctq *ChannelTaskQueue) SetDeadLetter(q deadLetterQueue) {
+	ctq.deadLetter = q
+}
+
+// SetRedactor redacts the bodies of tasks before they are dead-lettered.
+func (ctq *ChannelTaskQueue) SetRedactor(r bodyRedactor) {
+	ctq.redactor = r
+}
+
+// SetLease sets the lease used to claim journal entries when replicas share the journal.
+// Each replica claims the entries it appends and renews its claims until they are
+// processed; entries that nobody holds for longer than the lease TTL are recovered
+// by any replica. cfg must have been validated by the lease constructor.
+func (ctq *ChannelTaskQueue) SetLease(l taskLeaser, cfg *TaskLeaseConfig) {
+	ctq.lease = l
+	ctq.leaseCfg = cfg
+	ctq.leases = make(map[string]struct{})
+}
+
+// SetActionRouter sets the router for custom actions enabled through config.
+// Actions other than the built-in search and on_search are rejected without it.
+func (ctq *ChannelTaskQueue) SetActionRouter(r actionRouter) {
+	ctq.actions = r
+}
+
+// SetPartitions queues the tasks of the given domains in partitions with their own workers
+// and buffers. Tasks of other domains stay in the default channel. It must be called before
+// the workers are started.
+func (ctq *ChannelTaskQueue) SetPartitions(cfgs []TaskQueuePartition) error {
+	names := map[string]bool{defaultTaskPartition: true}
+	byDomain := make(map[string]*taskPartition)
+	partitions := make([]*taskPartition, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.Name == "" || names[cfg.Name] {
+			return fmt.Errorf("task queue partition name %q must be non-empty and unique", cfg.Name)
+		}
+		names[cfg.Name] = true
+		if len(cfg.Domains) == 0 {
+			return fmt.Errorf("task queue partition %s has no domains", cfg.Name)
+		}
+		if cfg.Workers < 0 || cfg.BufferSize < 0 {
+			return fmt.Errorf("task queue partition %s: workers and bufferSize cannot be negative", cfg.Name)
+		}
+		p := &taskPartition{name: cfg.Name, workers: cfg.Workers}
+		if p.workers == 0 {
+			p.workers = 1
+		}
+		bufferSize := cfg.BufferSize
+		if bufferSize == 0 {
+			bufferSize = 100
+		}
+		p.ch = make(chan channelQueueItem, bufferSize)
+		for _, d := range cfg.Domains {
+			if other, ok := byDomain[d]; ok {
+				return fmt.Errorf("domain %s is in task queue partitions %s and %s", d, other.name, cfg.Name)
+			}
+			byDomain[d] = p
+		}
+		partitions = append(partitions, p)
+	}
+	ctq.partitions = partitions
+	ctq.byDomain = byDomain
+	return nil
+}
+
+// channel returns the channel that queues the tasks of domain.
+func (ctq *ChannelTaskQueue) channel(domain string) chan channelQueueItem {
+	if p, ok := ctq.byDomain[domain]; ok {
+		return p.ch
+	}
+	return ctq.taskChannel
+}
+
+// Occupancy returns the number of tasks waiting in all partitions and their total capacity.
+func (ctq *ChannelTaskQueue) Occupancy() (queued, capacity int) {
+	queued, capacity = len(ctq.taskChannel), cap(ctq.taskChannel)
+	for _, p := range ctq.partitions {
+		queued += len(p.ch)
+		capacity += cap(p.ch)
+	}
+	return queued, capacity
+}
+
+// DomainOccupancy returns the number of tasks waiting in the partition that queues the tasks
+// of domain and its capacity.
+func (ctq *ChannelTaskQueue) DomainOccupancy(domain string) (queued, capacity int) {
+	ch := ctq.channel(domain)
+	return len(ch), cap(ch)
+}
+
+// ReplayJournal queues every task left unprocessed in the journal, e.g. by a crash
+// between acknowledging a request and fanning it out. It should be called once
+// after the workers are started and all processors are set. With a lease, only
+// orphaned tasks are replayed; see recoverOrphans.
+func (ctq *ChannelTaskQueue) ReplayJournal(ctx context.Context) (int, error) {
+	if ctq.journal == nil {
+		return 0, nil
+	}
+	if ctq.lease != nil {
+		return ctq.recoverOrphans(ctx)
+	}
+	entries, err := ctq.journal.Pending(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pending journal entries: %w", err)
+	}
+	for i, e := range entries {
+		select {
+		case ctq.channel(e.Task.Context.Domain) <- channelQueueItem{originalCtx: ctx, task: e.Task, journalID: e.ID}:
+		case <-ctq.workerCtx.Done():
+			return i, fmt.Errorf("worker is shutting down, replayed %d of %d journal entries", i, len(entries))
+		}
+	}
+	slog.InfoContext(ctx, "ChannelTaskQueue: Replayed journal entries", "count", len(entries))
+	return len(entries), nil
+}
+
+// ack removes a processed item from the journal.
+func (ctq *ChannelTaskQueue) ack(item channelQueueItem) {
+	if ctq.journal == nil || item.journalID == "" {
+		return
+	}
+	if err := ctq.journal.Ack(context.WithoutCancel(ctq.workerCtx), item.journalID); err != nil {
+		slog.ErrorContext(item.originalCtx, "ChannelTaskQueue: Failed to ack journal entry, it will be replayed on restart", "journal_id", item.journalID, "error", err)
+	}
+	// The claim is not released but left to expire, so that a replica that read the
+	// entry before it was acked cannot claim and process it again.
+	ctq.dropLease(item.journalID)
+}
+
+// claim claims a journal entry appended by this replica. A failed claim is only
+// logged: the entry is still processed here, and the claim is taken again on renewal.
+func (ctq *ChannelTaskQueue) claim(ctx context.Context, id string) {
+	if ok, err := ctq.lease.Claim(ctx, id); err != nil || !ok {
+		slog.WarnContext(ctx, "ChannelTaskQueue: Failed to claim journal entry", "journal_id", id, "claimed", ok, "error", err)
+	}
+	ctq.leaseMu.Lock()
+	ctq.leases[id] = struct{}{}
+	ctq.leaseMu.Unlock()
+}
+
+// holdsLease reports whether this replica may process the item. Items that are
+// not journaled, or queued without a lease, are always processed.
+func (ctq *ChannelTaskQueue) holdsLease(item channelQueueItem) bool {
+	if ctq.lease == nil || item.journalID == "" {
+		return true
+	}
+	ctq.leaseMu.Lock()
+	defer ctq.leaseMu.Unlock()
+	_, ok := ctq.leases[item.journalID]
+	return ok
+}
+
+func (ctq *ChannelTaskQueue) dropLease(id string) {
+	if ctq.lease == nil {
+		return
+	}
+	ctq.leaseMu.Lock()
+	delete(ctq.leases, id)
+	ctq.leaseMu.Unlock()
+}
+
+// heldLeases returns the journal IDs currently claimed by this replica.
+func (ctq *ChannelTaskQueue) heldLeases() []string {
+	ctq.leaseMu.Lock()
+	defer ctq.leaseMu.Unlock()
+	ids := make([]string, 0, len(ctq.leases))
+	for id := range ctq.leases {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// renewLeases renews every claim held by this replica, both of queued tasks and of
+// tasks being processed. Claims taken over by another replica are dropped, so that
+// their tasks are skipped if they have not been processed yet.
+func (ctq *ChannelTaskQueue) renewLeases(ctx context.Context) {
+	for _, id := range ctq.heldLeases() {
+		ok, err := ctq.lease.Renew(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "ChannelTaskQueue: Failed to renew journal entry claim", "journal_id", id, "error", err)
+			continue
+		}
+		if !ok {
+			taskQueueMetrics.Add("leases_lost", 1)
+			slog.WarnContext(ctx, "ChannelTaskQueue: Journal entry claimed by another replica", "journal_id", id)
+			ctq.dropLease(id)
+		}
+	}
+}
+
+// recoverOrphans claims and queues journal entries that no replica holds, e.g.
+// because their replica crashed. Entries younger than the lease TTL are left to
+// the replica that appended them, which may not have claimed them yet.
+func (ctq *ChannelTaskQueue) recoverOrphans(ctx context.Context) (int, error) {
+	entries, err := ctq.journal.Pending(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pending journal entries: %w", err)
+	}
+	cutoff := ctq.now().Add(-ctq.leaseCfg.TTL)
+	recovered := 0
+	for _, e := range entries {
+		if ctq.holdsLease(channelQueueItem{journalID: e.ID}) {
+			continue
+		}
+		if t, ok := journalEntryTime(e.ID); ok && t.After(cutoff) {
+			continue
+		}
+		ok, err := ctq.lease.Claim(ctx, e.ID)
+		if err != nil {
+			return recovered, err
+		}
+		if !ok {
+			continue
+		}
+		ctq.leaseMu.Lock()
+		ctq.leases[e.ID] = struct{}{}
+		ctq.leaseMu.Unlock()
+		select {
+		case ctq.channel(e.Task.Context.Domain) <- channelQueueItem{originalCtx: ctx, task: e.Task, journalID: e.ID}:
+		case <-ctq.workerCtx.Done():
+			return recovered, fmt.Errorf("worker is shutting down, recovered %d orphaned journal entries", recovered)
+		}
+		recovered++
+		taskQueueMetrics.Add("orphans_recovered", 1)
+	}
+	if recovered > 0 {
+		slog.InfoContext(ctx, "ChannelTaskQueue: Recovered orphaned journal entries", "count", recovered)
+	}
+	return recovered, nil
+}
+
+// runLease renews the claims of this replica and recovers orphaned journal entries
+// until the queue stops.
+func (ctq *ChannelTaskQueue) runLease() {
+	defer ctq.wg.Done()
+	renew := time.NewTicker(ctq.leaseCfg.RenewInterval)
+	defer renew.Stop()
+	recovery := time.NewTicker(ctq.leaseCfg.RecoveryInterval)
+	defer recovery.Stop()
+	for {
+		select {
+		case <-renew.C:
+			ctq.renewLeases(ctq.workerCtx)
+		case <-recovery.C:
+			if _, err := ctq.recoverOrphans(ctq.workerCtx); err != nil && ctq.workerCtx.Err() == nil {
+				slog.ErrorContext(ctq.workerCtx, "ChannelTaskQueue: Failed to recover orphaned journal entries", "error", err)
+			}
+		case <-ctq.workerCtx.Done():
+			return
+		}
+	}
+}
+
+// releaseLeases releases the claims on tasks left unprocessed at shutdown,
+// so that other replicas can recover them.
+func (ctq *ChannelTaskQueue) releaseLeases() {
+	ctx := context.WithoutCancel(ctq.workerCtx)
+	for _, id := range ctq.heldLeases() {
+		if err := ctq.lease.Release(ctx, id); err != nil {
+			slog.ErrorContext(ctx, "ChannelTaskQueue: Failed to release journal entry claim", "journal_id", id, "error", err)
+		}
+		ctq.dropLease(id)
+	}
+}
+
+// routeTask sets the type and target of a task according to the routing of its action.
+func routeTask(task *model.AsyncTask, routing ActionRouting) error {
+	action := task.Context.Action
+	var uri, name string
+	switch routing {
+	case ActionRoutingBroadcast:
+		if task.Context.BppURI == "" {
+			task.Type = model.AsyncTaskTypeLookup
+			// Target for lookup is not set here; it's determined by the LookupTaskProcessor
+			return nil
+		}
+		uri, name = task.Context.BppURI, "BppURI"
+	case ActionRoutingBPP:
+		uri, name = task.Context.BppURI, "BppURI"
+	case ActionRoutingBAP:
+		uri, name = task.Context.BapURI, "BapURI"
+	default:
+		return fmt.Errorf("unknown routing %q for action %s", routing, action)
+	}
+	if uri == "" {
+		return fmt.Errorf("%s is required for /%s", name, action)
+	}
+	targetURL, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s for %s: %w", name, action, err)
+	}
+	task.Type = model.AsyncTaskTypeProxy
+	task.Target = targetURL.JoinPath(action)
+	return nil
+}
+
 // QueueTxn creates an AsyncTask based on the request context and body,
 // then sends it to an internal channel for asynchronous processing by a worker goroutine.
 // This method implements the taskQueuer interface.
@@ -109,116 +482,221 @@ func (ctq *ChannelTaskQueue) QueueTxn(ctx context.Context, reqCtx *model.Context
 		Context: *reqCtx,
 	}
 	// Determine task type and target based on action
-	switch reqCtx.Action {
-	case "search":
-		if reqCtx.BppURI == "" {
-			task.Type = model.AsyncTaskTypeLookup
-			// Target for lookup is not set here; it's determined by the LookupTaskProcessor
-		} else {
-			task.Type = model.AsyncTaskTypeProxy
-			targetURL, err := url.Parse(reqCtx.BppURI)
-			if err != nil {
-				slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Failed to parse BppURI for search", "error", err, "bpp_uri", reqCtx.BppURI)
-				return nil, fmt.Errorf("failed to parse BppURI for search: %w", err)
-			}
-			task.Target = targetURL.JoinPath("search")
-		}
-	case "on_search":
-		if reqCtx.BapURI == "" {
-			slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: BapURI missing for on_search")
-			return nil, fmt.Errorf("BapURI is required for /on_search")
-		}
-		task.Type = model.AsyncTaskTypeProxy
-		targetURL, err := url.Parse(reqCtx.BapURI)
-		if err != nil {
-			slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Failed to parse BapURI for on_search", "error", err, "bap_uri", reqCtx.BapURI)
-			return nil, fmt.Errorf("failed to parse BapURI for on_search: %w", err)
-		}
-		task.Target = targetURL.JoinPath("on_search")
-	default:
+	routing, ok := builtinActions[reqCtx.Action]
+	if !ok && ctq.actions != nil {
+		routing, ok = ctq.actions.Routing(reqCtx.Action)
+	}
+	if !ok {
 		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Unknown action type", "action", reqCtx.Action)
 		return nil, fmt.Errorf("unknown action type: %s", reqCtx.Action)
 	}
+	if err := routeTask(task, routing); err != nil {
+		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Failed to route task", "error", err, "action", reqCtx.Action, "routing", routing)
+		return nil, err
+	}
+
+	if err := ctq.enqueue(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// QueueBatch creates a PROXY_BATCH task that sends the body to each of the target URIs,
+// which should share a host, and queues it like QueueTxn. The action is appended to each
+// URI as it is for a single proxy task.
+func (ctq *ChannelTaskQueue) QueueBatch(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header, uris []string) (*model.AsyncTask, error) {
+	if reqCtx == nil {
+		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueBatch: request context (model.Context) cannot be nil")
+		return nil, fmt.Errorf("request context (model.Context) is nil")
+	}
+	if len(uris) == 0 {
+		return nil, fmt.Errorf("batch for %s has no targets", reqCtx.Action)
+	}
+	task := &model.AsyncTask{
+		Type:    model.AsyncTaskTypeProxyBatch,
+		Body:    body,
+		Headers: h.Clone(),
+		Context: *reqCtx,
+	}
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse target %q for %s: %w", uri, reqCtx.Action, err)
+		}
+		task.Targets = append(task.Targets, u.JoinPath(reqCtx.Action))
+	}
+	if err := ctq.enqueue(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
 
+// enqueue journals a task, if a journal is set, and sends it to the task channel.
+func (ctq *ChannelTaskQueue) enqueue(ctx context.Context, task *model.AsyncTask) error {
+	action := task.Context.Action
 	item := channelQueueItem{
 		originalCtx: ctx, // Propagate the original request's context
 		task:        task,
 	}
-	slog.DebugContext(ctx, "Queuing task", "action", reqCtx.Action, "type", task.Type, "target", task.Target)
+	if ctq.journal != nil {
+		id, err := ctq.journal.Append(ctx, task)
+		if err != nil {
+			slog.ErrorContext(ctx, "ChannelTaskQueue.enqueue: Failed to journal task", "error", err)
+			return fmt.Errorf("failed to journal task: %w", err)
+		}
+		item.journalID = id
+		if ctq.lease != nil {
+			ctq.claim(ctx, id)
+		}
+	}
+	slog.DebugContext(ctx, "Queuing task", "action", action, "type", task.Type, "target", task.Target)
 
+	ch := ctq.channel(task.Context.Domain)
 	select {
-	case ctq.taskChannel <- item:
-		slog.InfoContext(ctx, "ChannelTaskQueue.QueueTxn: Task successfully sent to channel", "action", reqCtx.Action, "type", task.Type)
-		return task, nil
+	case ch <- item:
+		slog.DebugContext(ctx, "ChannelTaskQueue.enqueue: Task successfully sent to channel", "action", action, "type", task.Type)
+		return nil
 	case <-ctq.workerCtx.Done():
-		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Worker is shutting down, cannot queue task", "action", reqCtx.Action)
-		return nil, fmt.Errorf("worker is shutting down, cannot queue task")
+		slog.ErrorContext(ctx, "ChannelTaskQueue.enqueue: Worker is shutting down, cannot queue task", "action", action)
+		return fmt.Errorf("worker is shutting down, cannot queue task")
 	default:
-		// This case is for a full buffered channel if we want non-blocking behavior.
-		// For now, if the channel is full, it will block until space is available or workerCtx is done.
-		// If non-blocking is desired with task dropping:
-		// slog.WarnContext(ctx, "ChannelTaskQueue.QueueTxn: Task channel is full, dropping task", "action", reqCtx.Action)
-		// return nil, fmt.Errorf("task channel is full, task dropped")
-
-		// Blocking send (current behavior with buffered channel):
-		ctq.taskChannel <- item
-		slog.InfoContext(ctx, "ChannelTaskQueue.QueueTxn: Task successfully sent to channel (after block)", "action", reqCtx.Action, "type", task.Type)
-		return task, nil
+		// Callers are not parked on a full buffer, so that load is shed instead of piling up.
+		taskQueueMetrics.Add("rejected_full", 1)
+		slog.WarnContext(ctx, "ChannelTaskQueue.enqueue: Task channel is full, rejecting task", "action", action, "domain", task.Context.Domain)
+		ctq.ack(item)
+		return ErrTaskQueueFull
 	}
 }
 
 // StartWorkers launches the background worker goroutines that process tasks from the channel.
 func (ctq *ChannelTaskQueue) StartWorkers() {
-	slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue: Starting workers...", "num_workers", ctq.numWorkers)
+	slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue: Starting workers...", "num_workers", ctq.numWorkers, "partitions", len(ctq.partitions))
 	for i := 0; i < ctq.numWorkers; i++ {
+		ctq.startWorker(defaultTaskPartition, ctq.taskChannel, i)
+	}
+	for _, p := range ctq.partitions {
+		for i := 0; i < p.workers; i++ {
+			ctq.startWorker(p.name, p.ch, i)
+		}
+	}
+	if ctq.lease != nil && ctq.journal != nil {
 		ctq.wg.Add(1)
-		go func(workerID int) {
-			defer ctq.wg.Done()
-			slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Starting...", "worker_id", workerID)
-			for {
-				select {
-				case item, ok := <-ctq.taskChannel:
-					if !ok {
-						slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Task channel closed, stopping.", "worker_id", workerID)
-						return
-					}
-					// Log receipt of the task with its original context for correlation
-					slog.InfoContext(item.originalCtx, "ChannelTaskQueue Worker: Received task", "worker_id", workerID, "type", item.task.Type, "target", item.task.Target)
-
-					var err error
-					// Use the worker's context for the actual processing, so it's not prematurely canceled.
-					// The item.originalCtx can still be used for extracting request-scoped values if needed by the processors,
-					// but the primary cancellation for the Process method should come from workerCtx.
-					processingCtx := ctq.workerCtx
-
-					switch item.task.Type {
-					case model.AsyncTaskTypeProxy:
-						if ctq.proxyProcessor == nil {
-							slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: proxyProcessor is nil, cannot process PROXY task", "worker_id", workerID)
-							continue
-						}
-						err = ctq.proxyProcessor.Process(processingCtx, item.task)
-					case model.AsyncTaskTypeLookup:
-						if ctq.lookupProcessor == nil {
-							slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: lookupProcessor is nil, cannot process LOOKUP task", "worker_id", workerID)
-							continue
-						}
-						err = ctq.lookupProcessor.Process(processingCtx, item.task)
-					default:
-						slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Unknown task type received", "worker_id", workerID, "type", item.task.Type)
-					}
-					if err != nil {
-						slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Error processing task", "worker_id", workerID, "type", item.task.Type, "error", err)
-					} else {
-						slog.InfoContext(item.originalCtx, "ChannelTaskQueue Worker: Task processed successfully", "worker_id", workerID, "type", item.task.Type)
-					}
-				case <-ctq.workerCtx.Done():
-					slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Context cancelled, stopping.", "worker_id", workerID)
-					return
-				}
+		go ctq.runLease()
+	}
+}
+
+// startWorker launches a worker goroutine for the channel of a partition. A worker that panics
+// while processing a task is replaced by a new worker with the same ID, unless the queue is shutting down.
+func (ctq *ChannelTaskQueue) startWorker(partition string, ch chan channelQueueItem, workerID int) {
+	ctq.wg.Add(1)
+	go func() {
+		defer ctq.wg.Done()
+		if ctq.runWorker(partition, ch, workerID) && ctq.workerCtx.Err() == nil {
+			taskQueueMetrics.Add("worker_restarts", 1)
+			slog.WarnContext(ctq.workerCtx, "ChannelTaskQueue Worker: Restarting after panic", "partition", partition, "worker_id", workerID)
+			ctq.startWorker(partition, ch, workerID)
+		}
+	}()
+}
+
+// runWorker processes tasks from the channel until the queue stops or a task panics.
+// It reports whether it returned because of a panic.
+func (ctq *ChannelTaskQueue) runWorker(partition string, ch chan channelQueueItem, workerID int) bool {
+	slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Starting...", "partition", partition, "worker_id", workerID)
+	for {
+		select {
+		case item, ok := <-ch:
+			if !ok {
+				slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Task channel closed, stopping.", "worker_id", workerID)
+				return false
+			}
+			if ctq.processItem(workerID, item) {
+				return true
 			}
-		}(i)
+		case <-ctq.workerCtx.Done():
+			slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Context cancelled, stopping.", "worker_id", workerID)
+			return false
+		}
+	}
+}
+
+// processItem processes a single task. A panic in a processor is recovered, logged
+// with its stack trace and the task is dead-lettered; it reports whether it panicked.
+func (ctq *ChannelTaskQueue) processItem(workerID int, item channelQueueItem) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			ctq.taskPanicked(workerID, item, r, debug.Stack())
+		}
+	}()
+
+	if !ctq.holdsLease(item) {
+		taskQueueMetrics.Add("lease_skipped", 1)
+		slog.WarnContext(item.originalCtx, "ChannelTaskQueue Worker: Skipping task claimed by another replica", "worker_id", workerID, "journal_id", item.journalID)
+		return false
+	}
+
+	// Log receipt of the task with its original context for correlation
+	slog.DebugContext(item.originalCtx, "ChannelTaskQueue Worker: Received task", "worker_id", workerID, "type", item.task.Type, "target", item.task.Target)
+
+	var err error
+	// Use the worker's context for the actual processing, so it's not prematurely canceled.
+	// The item.originalCtx can still be used for extracting request-scoped values if needed by the processors,
+	// but the primary cancellation for the Process method should come from workerCtx.
+	processingCtx := ctq.workerCtx
+
+	switch item.task.Type {
+	case model.AsyncTaskTypeProxy, model.AsyncTaskTypeProxyBatch:
+		if ctq.proxyProcessor == nil {
+			slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: proxyProcessor is nil, cannot process PROXY task", "worker_id", workerID)
+			return false
+		}
+		err = ctq.proxyProcessor.Process(processingCtx, item.task)
+	case model.AsyncTaskTypeLookup:
+		if ctq.lookupProcessor == nil {
+			slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: lookupProcessor is nil, cannot process LOOKUP task", "worker_id", workerID)
+			return false
+		}
+		err = ctq.lookupProcessor.Process(processingCtx, item.task)
+	default:
+		slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Unknown task type received", "worker_id", workerID, "type", item.task.Type)
 	}
+	if err != nil {
+		slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Error processing task", "worker_id", workerID, "type", item.task.Type, "error", err)
+	} else {
+		slog.DebugContext(item.originalCtx, "ChannelTaskQueue Worker: Task processed successfully", "worker_id", workerID, "type", item.task.Type)
+	}
+	// Processing errors are final (processors retry internally), so the entry is
+	// removed either way; only tasks interrupted by a crash are replayed.
+	ctq.ack(item)
+	return false
+}
+
+// taskPanicked records a task whose processing panicked and moves it to the
+// dead-letter queue, if one is set. The task is acked so that a restart does
+// not replay it from the journal and panic again.
+func (ctq *ChannelTaskQueue) taskPanicked(workerID int, item channelQueueItem, r any, stack []byte) {
+	taskQueueMetrics.Add("panics", 1)
+	slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Panic while processing task",
+		"worker_id", workerID, "type", item.task.Type, "action", item.task.Context.Action,
+		"transaction_id", item.task.Context.TransactionID, "message_id", item.task.Context.MessageID,
+		"panic", fmt.Sprint(r), "stack", string(stack))
+	if ctq.deadLetter != nil {
+		task := item.task
+		if ctq.redactor != nil {
+			redacted := *task
+			redacted.Body = ctq.redactor.Redact(task.Body)
+			task = &redacted
+		}
+		id, err := ctq.deadLetter.Append(context.WithoutCancel(ctq.workerCtx), task)
+		if err != nil {
+			slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Failed to dead-letter task", "worker_id", workerID, "error", err)
+		} else {
+			taskQueueMetrics.Add("dead_lettered", 1)
+			slog.WarnContext(item.originalCtx, "ChannelTaskQueue Worker: Task dead-lettered", "worker_id", workerID, "dead_letter_id", id)
+		}
+	}
+	ctq.ack(item)
 }
 
 // StopWorkers signals the worker goroutines to stop and waits for them to finish.
@@ -236,8 +714,14 @@ func (ctq *ChannelTaskQueue) StopWorkers() {
 
 	// Wait for the worker to finish processing and exit its loop.
 	ctq.wg.Wait()
+	if ctq.lease != nil {
+		ctq.releaseLeases()
+	}
 
 	// Now it's safe to close the channel as the worker is no longer reading from it.
 	close(ctq.taskChannel)
+	for _, p := range ctq.partitions {
+		close(p.ch)
+	}
 	slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue: All workers stopped and channel closed.")
 }
@@ -17,6 +17,8 @@ package service
 import (
 	"context"
 	"errors"
+	"expvar"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -308,6 +310,101 @@ func TestChannelTaskQueue_QueueTxn(t *testing.T) {
 	}
 }
 
+// mockActionRouter is a mock implementation of actionRouter.
+type mockActionRouter map[string]ActionRouting
+
+func (m mockActionRouter) Routing(action string) (ActionRouting, bool) {
+	r, ok := m[action]
+	return r, ok
+}
+
+func TestChannelTaskQueue_QueueTxn_CustomActions(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "issue", BppURI: "http://bpp.com"}, nil, nil); err == nil || !strings.Contains(err.Error(), "unknown action type: issue") {
+		t.Fatalf("QueueTxn() without action router error = %v, want unknown action", err)
+	}
+	q.SetActionRouter(mockActionRouter{"issue": ActionRoutingBroadcast, "issue_status": ActionRoutingBPP, "on_issue": ActionRoutingBAP})
+
+	tests := []struct {
+		name       string
+		reqCtx     *model.Context
+		wantType   model.AsyncTaskType
+		wantTarget string
+		wantErrMsg string
+	}{
+		{
+			name:     "broadcast without BppURI becomes LOOKUP task",
+			reqCtx:   &model.Context{Action: "issue", Domain: "test-domain"},
+			wantType: model.AsyncTaskTypeLookup,
+		},
+		{
+			name:       "broadcast with BppURI becomes PROXY task",
+			reqCtx:     &model.Context{Action: "issue", BppURI: "http://bpp.com/beckn"},
+			wantType:   model.AsyncTaskTypeProxy,
+			wantTarget: "http://bpp.com/beckn/issue",
+		},
+		{
+			name:       "bpp routing becomes PROXY task",
+			reqCtx:     &model.Context{Action: "issue_status", BppURI: "http://bpp.com/beckn"},
+			wantType:   model.AsyncTaskTypeProxy,
+			wantTarget: "http://bpp.com/beckn/issue_status",
+		},
+		{
+			name:       "bap routing becomes PROXY task",
+			reqCtx:     &model.Context{Action: "on_issue", BapURI: "http://bap.com/beckn"},
+			wantType:   model.AsyncTaskTypeProxy,
+			wantTarget: "http://bap.com/beckn/on_issue",
+		},
+		{
+			name:       "error - bpp routing without BppURI",
+			reqCtx:     &model.Context{Action: "issue_status"},
+			wantErrMsg: "BppURI is required for /issue_status",
+		},
+		{
+			name:       "error - bap routing without BapURI",
+			reqCtx:     &model.Context{Action: "on_issue"},
+			wantErrMsg: "BapURI is required for /on_issue",
+		},
+		{
+			name:       "error - action not configured",
+			reqCtx:     &model.Context{Action: "support"},
+			wantErrMsg: "unknown action type: support",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTask, err := q.QueueTxn(ctx, tt.reqCtx, nil, http.Header{})
+			if tt.wantErrMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("QueueTxn() error = %v, want error containing %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("QueueTxn() unexpected error: %v", err)
+			}
+			<-q.taskChannel
+			if gotTask.Type != tt.wantType {
+				t.Errorf("QueueTxn() task type = %v, want %v", gotTask.Type, tt.wantType)
+			}
+			gotTarget := ""
+			if gotTask.Target != nil {
+				gotTarget = gotTask.Target.String()
+			}
+			if gotTarget != tt.wantTarget {
+				t.Errorf("QueueTxn() task target = %q, want %q", gotTarget, tt.wantTarget)
+			}
+		})
+	}
+}
+
 func TestChannelTaskQueue_WorkerProcessingAndShutdown(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -469,3 +566,678 @@ func TestChannelTaskQueue_WorkerErrorPaths(t *testing.T) {
 		t.Errorf("lookupProcessor call count = %d, want 0", mockLookupP.getCallCount())
 	}
 }
+
+// mockJournal is an in-memory implementation of the txnJournal interface.
+type mockJournal struct {
+	mu        sync.Mutex
+	entries   map[string]*model.AsyncTask
+	acked     []string
+	next      int
+	appendErr error
+	pendErr   error
+	pending   []*JournalEntry
+}
+
+func newMockJournal() *mockJournal {
+	return &mockJournal{entries: make(map[string]*model.AsyncTask)}
+}
+
+func (m *mockJournal) Append(ctx context.Context, task *model.AsyncTask) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.appendErr != nil {
+		return "", m.appendErr
+	}
+	m.next++
+	id := strings.Repeat("x", m.next)
+	m.entries[id] = task
+	return id, nil
+}
+
+func (m *mockJournal) Ack(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, id)
+	m.acked = append(m.acked, id)
+	return nil
+}
+
+func (m *mockJournal) Pending(ctx context.Context) ([]*JournalEntry, error) {
+	return m.pending, m.pendErr
+}
+
+func (m *mockJournal) getAcked() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.acked...)
+}
+
+func TestChannelTaskQueue_Journal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockProxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		return errors.New("target unreachable")
+	}}
+	q, err := NewChannelTaskQueue(1, ctx, mockProxyP, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	j := newMockJournal()
+	q.SetJournal(j)
+
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	if len(j.entries) != 1 {
+		t.Fatalf("journal entries after QueueTxn = %d, want 1", len(j.entries))
+	}
+
+	q.StartWorkers()
+	time.Sleep(100 * time.Millisecond)
+	q.StopWorkers()
+
+	// Entries are acked even when processing fails, since processors retry internally.
+	if diff := cmp.Diff([]string{"x"}, j.getAcked()); diff != "" {
+		t.Errorf("acked entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_JournalError(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	j := newMockJournal()
+	j.appendErr = errors.New("disk full")
+	q.SetJournal(j)
+
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err == nil {
+		t.Fatal("QueueTxn() error = nil, want error")
+	}
+	if len(q.taskChannel) != 0 {
+		t.Errorf("task channel length = %d, want 0 when journaling fails", len(q.taskChannel))
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_Full(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 1)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	j := newMockJournal()
+	q.SetJournal(j)
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com"}
+	if _, err := q.QueueTxn(ctx, reqCtx, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() unexpected error: %v", err)
+	}
+
+	// Workers are not started, so the channel stays full.
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := q.QueueTxn(ctx, reqCtx, nil, nil)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrTaskQueueFull) {
+			t.Errorf("QueueTxn() error = %v, want %v", err, ErrTaskQueueFull)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("QueueTxn() blocked on a full task channel")
+	}
+	if len(q.taskChannel) != 1 {
+		t.Errorf("task channel length = %d, want 1", len(q.taskChannel))
+	}
+	if len(j.getAcked()) != 1 {
+		t.Errorf("acked entries = %d, want the rejected task acked", len(j.getAcked()))
+	}
+}
+
+func TestChannelTaskQueue_Occupancy(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() unexpected error: %v", err)
+		}
+	}
+
+	if queued, capacity := q.Occupancy(); queued != 3 || capacity != 10 {
+		t.Errorf("Occupancy() = (%d, %d), want (3, 10)", queued, capacity)
+	}
+}
+
+func TestChannelTaskQueue_ReplayJournal(t *testing.T) {
+	target, _ := url.Parse("http://bpp.com/search")
+	pending := []*JournalEntry{
+		{ID: "1", Task: &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target}},
+		{ID: "2", Task: &model.AsyncTask{Type: model.AsyncTaskTypeLookup}},
+	}
+
+	t.Run("replays and acks pending entries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockProxyP, mockLookupP := &mockTaskProcessor{}, &mockTaskProcessor{}
+		q, _ := NewChannelTaskQueue(1, ctx, mockProxyP, mockLookupP, 10)
+		j := newMockJournal()
+		j.pending = pending
+		q.SetJournal(j)
+		q.StartWorkers()
+
+		n, err := q.ReplayJournal(ctx)
+		if err != nil {
+			t.Fatalf("ReplayJournal() error = %v", err)
+		}
+		if n != 2 {
+			t.Errorf("ReplayJournal() = %d, want 2", n)
+		}
+		time.Sleep(100 * time.Millisecond)
+		q.StopWorkers()
+
+		if mockProxyP.getCallCount() != 1 || mockLookupP.getCallCount() != 1 {
+			t.Errorf("processor calls = proxy %d, lookup %d, want 1 each", mockProxyP.getCallCount(), mockLookupP.getCallCount())
+		}
+		if diff := cmp.Diff([]string{"1", "2"}, j.getAcked()); diff != "" {
+			t.Errorf("acked entries mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("no journal", func(t *testing.T) {
+		q, _ := NewChannelTaskQueue(1, context.Background(), &mockTaskProcessor{}, nil, 10)
+		if n, err := q.ReplayJournal(context.Background()); n != 0 || err != nil {
+			t.Errorf("ReplayJournal() = %d, %v, want 0, nil", n, err)
+		}
+	})
+
+	t.Run("pending error", func(t *testing.T) {
+		q, _ := NewChannelTaskQueue(1, context.Background(), &mockTaskProcessor{}, nil, 10)
+		j := newMockJournal()
+		j.pendErr = errors.New("redis down")
+		q.SetJournal(j)
+		if _, err := q.ReplayJournal(context.Background()); err == nil {
+			t.Error("ReplayJournal() error = nil, want error")
+		}
+	})
+}
+
+func TestChannelTaskQueue_QueueBatch(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		reqCtx      *model.Context
+		uris        []string
+		wantTargets []string
+		wantErr     string
+	}{
+		{
+			name:        "success",
+			reqCtx:      &model.Context{Action: "search"},
+			uris:        []string{"http://bpp.com/a", "http://bpp.com/b/"},
+			wantTargets: []string{"http://bpp.com/a/search", "http://bpp.com/b/search"},
+		},
+		{
+			name:    "nil context",
+			uris:    []string{"http://bpp.com/a"},
+			wantErr: "request context (model.Context) is nil",
+		},
+		{
+			name:    "no targets",
+			reqCtx:  &model.Context{Action: "search"},
+			wantErr: "batch for search has no targets",
+		},
+		{
+			name:    "invalid target",
+			reqCtx:  &model.Context{Action: "search"},
+			uris:    []string{"http://bpp.com/a", "://bad"},
+			wantErr: `failed to parse target "://bad" for search`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+			if err != nil {
+				t.Fatalf("Failed to create task queue: %v", err)
+			}
+			j := newMockJournal()
+			q.SetJournal(j)
+
+			task, err := q.QueueBatch(ctx, tt.reqCtx, []byte(`{}`), http.Header{"Authorization": []string{"sig"}}, tt.uris)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("QueueBatch() error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(q.taskChannel) != 0 {
+					t.Errorf("task channel length = %d, want 0", len(q.taskChannel))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("QueueBatch() unexpected error = %v", err)
+			}
+			if task.Type != model.AsyncTaskTypeProxyBatch || task.Target != nil {
+				t.Errorf("QueueBatch() task type = %s, target = %v, want %s without target", task.Type, task.Target, model.AsyncTaskTypeProxyBatch)
+			}
+			var got []string
+			for _, u := range task.Targets {
+				got = append(got, u.String())
+			}
+			if diff := cmp.Diff(tt.wantTargets, got); diff != "" {
+				t.Errorf("QueueBatch() targets mismatch (-want +got):\n%s", diff)
+			}
+			if len(q.taskChannel) != 1 || len(j.entries) != 1 {
+				t.Errorf("task channel length = %d, journal entries = %d, want 1 and 1", len(q.taskChannel), len(j.entries))
+			}
+		})
+	}
+}
+
+func TestChannelTaskQueue_WorkerProcessesBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	proxyP := &mockTaskProcessor{}
+	q, err := NewChannelTaskQueue(1, ctx, proxyP, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	if _, err := q.QueueBatch(ctx, &model.Context{Action: "search"}, nil, nil, []string{"http://bpp.com/a", "http://bpp.com/b"}); err != nil {
+		t.Fatalf("QueueBatch() error = %v", err)
+	}
+
+	q.StartWorkers()
+	time.Sleep(100 * time.Millisecond)
+	q.StopWorkers()
+
+	if proxyP.getCallCount() != 1 || proxyP.tasks[0].Type != model.AsyncTaskTypeProxyBatch {
+		t.Errorf("proxy processor received %d tasks, want the batch once", proxyP.getCallCount())
+	}
+}
+
+func TestChannelTaskQueue_WorkerPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockProxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		if task.Context.TransactionID == "malformed" {
+			panic("nil pointer dereference")
+		}
+		return nil
+	}}
+	// A single worker, so the second task is only processed if the worker is restarted.
+	q, err := NewChannelTaskQueue(1, ctx, mockProxyP, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	j := newMockJournal()
+	q.SetJournal(j)
+	deadLetter := newMockJournal()
+	q.SetDeadLetter(deadLetter)
+	panicsBefore := taskQueueCount("panics")
+	restartsBefore := taskQueueCount("worker_restarts")
+
+	q.StartWorkers()
+	for _, txnID := range []string{"malformed", "valid"} {
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com", TransactionID: txnID}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	q.StopWorkers()
+
+	if got := mockProxyP.getCallCount(); got != 2 {
+		t.Errorf("proxyProcessor call count = %d, want 2", got)
+	}
+	if len(deadLetter.entries) != 1 || deadLetter.entries["x"].Context.TransactionID != "malformed" {
+		t.Errorf("dead-lettered tasks = %v, want only the malformed task", deadLetter.entries)
+	}
+	if diff := cmp.Diff([]string{"x", "xx"}, j.getAcked()); diff != "" {
+		t.Errorf("acked entries mismatch (-want +got):\n%s", diff)
+	}
+	if got := taskQueueCount("panics") - panicsBefore; got != 1 {
+		t.Errorf("panics = %d, want 1", got)
+	}
+	if got := taskQueueCount("worker_restarts") - restartsBefore; got != 1 {
+		t.Errorf("worker restarts = %d, want 1", got)
+	}
+}
+
+func TestChannelTaskQueue_WorkerPanic_DeadLetterRedacted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockProxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		panic("nil pointer dereference")
+	}}
+	q, err := NewChannelTaskQueue(1, ctx, mockProxyP, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	deadLetter := newMockJournal()
+	q.SetDeadLetter(deadLetter)
+	r, err := NewPayloadRedactor(testRedactionConfig())
+	if err != nil {
+		t.Fatalf("NewPayloadRedactor() error = %v", err)
+	}
+	q.SetRedactor(r)
+
+	q.StartWorkers()
+	body := []byte(piiPayload)
+	task, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com", TransactionID: "t1"}, body, nil)
+	if err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	q.StopWorkers()
+
+	got, ok := deadLetter.entries["x"]
+	if !ok {
+		t.Fatalf("dead-lettered tasks = %v, want the panicked task", deadLetter.entries)
+	}
+	assertRedacted(t, string(got.Body))
+	if got.Context.TransactionID != "t1" {
+		t.Errorf("dead-lettered transaction ID = %q, want %q", got.Context.TransactionID, "t1")
+	}
+	if string(task.Body) != piiPayload {
+		t.Errorf("queued task body was modified: %s", task.Body)
+	}
+}
+
+func TestChannelTaskQueue_WorkerPanic_NoDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockLookupP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		panic(errors.New("malformed payload"))
+	}}
+	q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, mockLookupP, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	j := newMockJournal()
+	q.SetJournal(j)
+
+	q.StartWorkers()
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	q.StopWorkers()
+
+	// The task is acked so that it is not replayed on restart.
+	if diff := cmp.Diff([]string{"x"}, j.getAcked()); diff != "" {
+		t.Errorf("acked entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func taskQueueCount(key string) int64 {
+	if v, ok := taskQueueMetrics.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// mockTaskLease is an in-memory implementation of the taskLeaser interface.
+type mockTaskLease struct {
+	mu       sync.Mutex
+	claimed  []string
+	released []string
+	taken    map[string]bool // Entries held by another replica.
+	claimErr error
+}
+
+func (m *mockTaskLease) Claim(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.claimErr != nil {
+		return false, m.claimErr
+	}
+	if m.taken[id] {
+		return false, nil
+	}
+	m.claimed = append(m.claimed, id)
+	return true, nil
+}
+
+func (m *mockTaskLease) Renew(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.taken[id], nil
+}
+
+func (m *mockTaskLease) Release(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.released = append(m.released, id)
+	return nil
+}
+
+func TestChannelTaskQueue_Lease(t *testing.T) {
+	leaseCfg := &TaskLeaseConfig{TTL: time.Minute, RenewInterval: time.Hour, RecoveryInterval: time.Hour}
+
+	t.Run("claims queued tasks and leaves claims to expire after ack", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockProxyP := &mockTaskProcessor{}
+		q, _ := NewChannelTaskQueue(1, ctx, mockProxyP, &mockTaskProcessor{}, 10)
+		j := newMockJournal()
+		l := &mockTaskLease{}
+		q.SetJournal(j)
+		q.SetLease(l, leaseCfg)
+
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+		q.StartWorkers()
+		time.Sleep(100 * time.Millisecond)
+		q.StopWorkers()
+
+		if mockProxyP.getCallCount() != 1 {
+			t.Errorf("proxyProcessor call count = %d, want 1", mockProxyP.getCallCount())
+		}
+		if diff := cmp.Diff([]string{"x"}, l.claimed); diff != "" {
+			t.Errorf("claimed entries mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"x"}, j.getAcked()); diff != "" {
+			t.Errorf("acked entries mismatch (-want +got):\n%s", diff)
+		}
+		if len(l.released) != 0 {
+			t.Errorf("released entries = %v, want none", l.released)
+		}
+	})
+
+	t.Run("skips tasks whose claim was taken over", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockProxyP := &mockTaskProcessor{}
+		q, _ := NewChannelTaskQueue(1, ctx, mockProxyP, &mockTaskProcessor{}, 10)
+		j := newMockJournal()
+		l := &mockTaskLease{taken: map[string]bool{"x": true}}
+		q.SetJournal(j)
+		q.SetLease(l, leaseCfg)
+
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+		q.renewLeases(ctx)
+		q.StartWorkers()
+		time.Sleep(100 * time.Millisecond)
+		q.StopWorkers()
+
+		if mockProxyP.getCallCount() != 0 {
+			t.Errorf("proxyProcessor call count = %d, want 0", mockProxyP.getCallCount())
+		}
+		if len(j.getAcked()) != 0 {
+			t.Errorf("acked entries = %v, want none", j.getAcked())
+		}
+	})
+
+	t.Run("recovers orphaned entries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		recent := fmt.Sprintf("%d-0", now.Add(-time.Second).UnixMilli())
+		target, _ := url.Parse("http://bpp.com/search")
+		task := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target}
+		mockProxyP := &mockTaskProcessor{}
+		q, _ := NewChannelTaskQueue(1, ctx, mockProxyP, &mockTaskProcessor{}, 10)
+		q.now = func() time.Time { return now }
+		j := newMockJournal()
+		j.pending = []*JournalEntry{
+			{ID: "1000-0", Task: task},
+			{ID: "2000-0", Task: task},
+			{ID: recent, Task: task},
+		}
+		l := &mockTaskLease{taken: map[string]bool{"2000-0": true}}
+		q.SetJournal(j)
+		q.SetLease(l, leaseCfg)
+		q.StartWorkers()
+
+		n, err := q.ReplayJournal(ctx)
+		if err != nil {
+			t.Fatalf("ReplayJournal() error = %v", err)
+		}
+		if n != 1 {
+			t.Errorf("ReplayJournal() = %d, want 1", n)
+		}
+		time.Sleep(100 * time.Millisecond)
+		q.StopWorkers()
+
+		if diff := cmp.Diff([]string{"1000-0"}, j.getAcked()); diff != "" {
+			t.Errorf("acked entries mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("claim error stops recovery", func(t *testing.T) {
+		q, _ := NewChannelTaskQueue(1, context.Background(), &mockTaskProcessor{}, nil, 10)
+		j := newMockJournal()
+		j.pending = []*JournalEntry{{ID: "1000-0", Task: &model.AsyncTask{Type: model.AsyncTaskTypeLookup}}}
+		q.SetJournal(j)
+		q.SetLease(&mockTaskLease{claimErr: errors.New("redis down")}, leaseCfg)
+		if _, err := q.ReplayJournal(context.Background()); err == nil {
+			t.Error("ReplayJournal() error = nil, want error")
+		}
+	})
+
+	t.Run("releases unprocessed tasks on shutdown", func(t *testing.T) {
+		ctx := context.Background()
+		q, _ := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+		l := &mockTaskLease{}
+		q.SetJournal(newMockJournal())
+		q.SetLease(l, leaseCfg)
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+		q.StopWorkers()
+
+		if diff := cmp.Diff([]string{"x"}, l.released); diff != "" {
+			t.Errorf("released entries mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestChannelTaskQueue_SetPartitions_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgs    []TaskQueuePartition
+		wantErr string
+	}{
+		{name: "missing name", cfgs: []TaskQueuePartition{{Domains: []string{"mobility"}}}, wantErr: "must be non-empty and unique"},
+		{name: "default name", cfgs: []TaskQueuePartition{{Name: "default", Domains: []string{"mobility"}}}, wantErr: "must be non-empty and unique"},
+		{name: "duplicate name", cfgs: []TaskQueuePartition{{Name: "a", Domains: []string{"mobility"}}, {Name: "a", Domains: []string{"retail"}}}, wantErr: "must be non-empty and unique"},
+		{name: "no domains", cfgs: []TaskQueuePartition{{Name: "a"}}, wantErr: "has no domains"},
+		{name: "negative workers", cfgs: []TaskQueuePartition{{Name: "a", Domains: []string{"mobility"}, Workers: -1}}, wantErr: "cannot be negative"},
+		{name: "domain in two partitions", cfgs: []TaskQueuePartition{{Name: "a", Domains: []string{"mobility"}}, {Name: "b", Domains: []string{"mobility"}}}, wantErr: "is in task queue partitions a and b"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := NewChannelTaskQueue(1, context.Background(), &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+			if err != nil {
+				t.Fatalf("Failed to create task queue: %v", err)
+			}
+			if err := q.SetPartitions(tc.cfgs); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("SetPartitions() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestChannelTaskQueue_PartitionOccupancy(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(1, ctx, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	if err := q.SetPartitions([]TaskQueuePartition{{Name: "mobility", Domains: []string{"ONDC:TRV10"}, Workers: 2, BufferSize: 4}}); err != nil {
+		t.Fatalf("SetPartitions() unexpected error: %v", err)
+	}
+	for _, domain := range []string{"ONDC:TRV10", "ONDC:TRV10", "ONDC:TRV10", "ONDC:RET10"} {
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", Domain: domain, BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() unexpected error: %v", err)
+		}
+	}
+
+	if queued, capacity := q.DomainOccupancy("ONDC:TRV10"); queued != 3 || capacity != 4 {
+		t.Errorf("DomainOccupancy(ONDC:TRV10) = (%d, %d), want (3, 4)", queued, capacity)
+	}
+	if queued, capacity := q.DomainOccupancy("ONDC:RET10"); queued != 1 || capacity != 10 {
+		t.Errorf("DomainOccupancy(ONDC:RET10) = (%d, %d), want (1, 10)", queued, capacity)
+	}
+	if queued, capacity := q.Occupancy(); queued != 4 || capacity != 14 {
+		t.Errorf("Occupancy() = (%d, %d), want (4, 14)", queued, capacity)
+	}
+}
+
+// domainBlockingProcessor blocks the tasks of one domain until released and reports the
+// domain of every task it processes.
+type domainBlockingProcessor struct {
+	blocked   string
+	release   chan struct{}
+	processed chan string
+}
+
+func (p *domainBlockingProcessor) Process(ctx context.Context, task *model.AsyncTask) error {
+	if task.Context.Domain == p.blocked {
+		<-p.release
+	}
+	p.processed <- task.Context.Domain
+	return nil
+}
+
+func TestChannelTaskQueue_PartitionIsolation(t *testing.T) {
+	ctx := context.Background()
+	proc := &domainBlockingProcessor{blocked: "ONDC:TRV10", release: make(chan struct{}), processed: make(chan string, 10)}
+	q, err := NewChannelTaskQueue(1, ctx, proc, proc, 10)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	if err := q.SetPartitions([]TaskQueuePartition{{Name: "mobility", Domains: []string{"ONDC:TRV10"}, Workers: 1, BufferSize: 5}}); err != nil {
+		t.Fatalf("SetPartitions() unexpected error: %v", err)
+	}
+	q.StartWorkers()
+	defer q.StopWorkers()
+	defer close(proc.release)
+
+	for i := 0; i < 3; i++ {
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", Domain: "ONDC:TRV10", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() unexpected error: %v", err)
+		}
+	}
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", Domain: "ONDC:RET10", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() unexpected error: %v", err)
+	}
+
+	select {
+	case got := <-proc.processed:
+		if got != "ONDC:RET10" {
+			t.Errorf("processed task of domain %s, want ONDC:RET10 while ONDC:TRV10 is blocked", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("task of ONDC:RET10 was not processed while the ONDC:TRV10 partition was blocked")
+	}
+}
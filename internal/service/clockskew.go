@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// ErrClockSkew is returned when a request's signature or context timestamp is outside the
+// allowed clock skew.
+var ErrClockSkew = errors.New("clock skew")
+
+const defaultMaxClockSkew = 30 * time.Second
+
+// clockSkewMetrics publishes the requests rejected for clock skew on the expvar endpoint (/debug/vars).
+var clockSkewMetrics = expvar.NewMap("clock_skew")
+
+// ClockSkewConfig configures the check of request times against the gateway's clock.
+type ClockSkewConfig struct {
+	// MaxSkew is how far the created time of a signature and the context timestamp may be
+	// from the gateway's clock. Defaults to 30s.
+	MaxSkew time.Duration `yaml:"maxSkew"`
+}
+
+// clockSkew rejects requests whose signature or context timestamp is too far from the
+// gateway's clock. Without it, a skewed clock surfaces as an intermittent invalid
+// signature that does not tell the sender what is wrong.
+type clockSkew struct {
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+// NewClockSkew creates a new clock skew check.
+func NewClockSkew(cfg *ClockSkewConfig) (*clockSkew, error) {
+	if cfg == nil {
+		return nil, errors.New("ClockSkewConfig cannot be nil")
+	}
+	if cfg.MaxSkew < 0 {
+		return nil, fmt.Errorf("maxSkew must not be negative, got %s", cfg.MaxSkew)
+	}
+	maxSkew := cfg.MaxSkew
+	if maxSkew == 0 {
+		maxSkew = defaultMaxClockSkew
+	}
+	return &clockSkew{maxSkew: maxSkew, now: time.Now}, nil
+}
+
+// CheckSignature checks the created and expires parameters of the Authorization header
+// against the gateway's clock. Missing or malformed parameters are left to signature
+// validation.
+func (c *clockSkew) CheckSignature(authHeader string) error {
+	now := c.now()
+	if created, err := signatureTime(authHeader, "created"); err == nil {
+		if skew := created.Sub(now.Truncate(time.Second)); skew > c.maxSkew {
+			clockSkewMetrics.Add("signature_created", 1)
+			return fmt.Errorf("%w: the signature was created %s ahead of the gateway's clock, more than the allowed %s", ErrClockSkew, skew, c.maxSkew)
+		}
+	}
+	if expires, err := signatureTime(authHeader, "expires"); err == nil && !now.Before(expires) {
+		clockSkewMetrics.Add("signature_expires", 1)
+		return fmt.Errorf("%w: the signature expired at %s, %s before the gateway's clock", ErrClockSkew, expires.UTC().Format(time.RFC3339), now.Sub(expires).Truncate(time.Second))
+	}
+	return nil
+}
+
+// CheckTimestamp checks the timestamp of a request context against the gateway's clock.
+// An empty or malformed timestamp is left to schema validation.
+func (c *clockSkew) CheckTimestamp(timestamp string) error {
+	ts, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return nil
+	}
+	skew := ts.Sub(c.now())
+	if skew.Abs() <= c.maxSkew {
+		return nil
+	}
+	clockSkewMetrics.Add("context_timestamp", 1)
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	return fmt.Errorf("%w: the context timestamp %s is %s %s the gateway's clock, more than the allowed %s", ErrClockSkew, timestamp, skew.Abs().Truncate(time.Second), direction, c.maxSkew)
+}
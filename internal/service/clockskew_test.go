@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClockSkew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         *ClockSkewConfig
+		wantMaxSkew time.Duration
+		wantErr     string
+	}{
+		{name: "default max skew", cfg: &ClockSkewConfig{}, wantMaxSkew: defaultMaxClockSkew},
+		{name: "configured max skew", cfg: &ClockSkewConfig{MaxSkew: 10 * time.Second}, wantMaxSkew: 10 * time.Second},
+		{name: "nil config", wantErr: "ClockSkewConfig cannot be nil"},
+		{name: "negative max skew", cfg: &ClockSkewConfig{MaxSkew: -time.Second}, wantErr: "maxSkew must not be negative, got -1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClockSkew(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewClockSkew() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClockSkew() unexpected error: %v", err)
+			}
+			if c.maxSkew != tt.wantMaxSkew {
+				t.Errorf("maxSkew = %v, want %v", c.maxSkew, tt.wantMaxSkew)
+			}
+		})
+	}
+}
+
+func TestClockSkew_CheckSignature(t *testing.T) {
+	now := time.Unix(1735689600, 0)
+	header := func(created, expires int64) string {
+		return fmt.Sprintf(`Signature keyId="np1|key1|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="sig"`, created, expires)
+	}
+	tests := []struct {
+		name       string
+		authHeader string
+		wantErr    string
+		wantMetric string
+	}{
+		{name: "in sync", authHeader: header(now.Unix()-1, now.Unix()+60)},
+		{name: "created within skew", authHeader: header(now.Unix()+30, now.Unix()+90)},
+		{name: "created ahead", authHeader: header(now.Unix()+31, now.Unix()+90), wantErr: "the signature was created 31s ahead of the gateway's clock, more than the allowed 30s", wantMetric: "signature_created"},
+		{name: "expired", authHeader: header(now.Unix()-120, now.Unix()-60), wantErr: "the signature expired at 2024-12-31T23:59:00Z, 1m0s before the gateway's clock", wantMetric: "signature_expires"},
+		{name: "missing parameters", authHeader: `Signature keyId="np1|key1|ed25519"`},
+		{name: "malformed created", authHeader: `Signature created="soon",expires="later"`},
+	}
+	rejections := func(key string) int64 {
+		if v, ok := clockSkewMetrics.Get(key).(interface{ Value() int64 }); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := NewClockSkew(&ClockSkewConfig{})
+			c.now = func() time.Time { return now }
+			before := rejections(tt.wantMetric)
+
+			err := c.CheckSignature(tt.authHeader)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckSignature() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrClockSkew) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckSignature() error = %v, want %v containing %q", err, ErrClockSkew, tt.wantErr)
+			}
+			if got := rejections(tt.wantMetric); got != before+1 {
+				t.Errorf("clock_skew[%s] = %d, want %d", tt.wantMetric, got, before+1)
+			}
+		})
+	}
+}
+
+func TestClockSkew_CheckTimestamp(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		timestamp string
+		wantErr   string
+	}{
+		{name: "in sync", timestamp: "2025-01-01T00:00:01Z"},
+		{name: "behind within skew", timestamp: "2024-12-31T23:59:30Z"},
+		{name: "ahead", timestamp: "2025-01-01T00:01:00Z", wantErr: "the context timestamp 2025-01-01T00:01:00Z is 1m0s ahead of the gateway's clock, more than the allowed 30s"},
+		{name: "behind", timestamp: "2024-12-31T23:58:00.5Z", wantErr: "the context timestamp 2024-12-31T23:58:00.5Z is 1m59s behind the gateway's clock, more than the allowed 30s"},
+		{name: "other time zone", timestamp: "2025-01-01T05:30:00+05:30"},
+		{name: "empty", timestamp: ""},
+		{name: "malformed", timestamp: "yesterday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := NewClockSkew(&ClockSkewConfig{})
+			c.now = func() time.Time { return now }
+
+			err := c.CheckTimestamp(tt.timestamp)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckTimestamp() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrClockSkew) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckTimestamp() error = %v, want %v containing %q", err, ErrClockSkew, tt.wantErr)
+			}
+		})
+	}
+}
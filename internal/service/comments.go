@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidOperationComment is returned when a comment on an operation is malformed.
+var ErrInvalidOperationComment = errors.New("invalid operation comment")
+
+const (
+	// maxCommentLength is the most characters the text of a comment can have.
+	maxCommentLength = 4000
+	// maxCommentAttachments is the most documents a comment can refer to.
+	maxCommentAttachments = 10
+)
+
+// gcsURIPattern matches a GCS object URI, gs://bucket/object, with a valid bucket name.
+var gcsURIPattern = regexp.MustCompile(`^gs://[a-z0-9][a-z0-9._-]{1,220}[a-z0-9]/[^\x00-\x1f]+$`)
+
+// operationCommentRepository defines the repository operations needed to comment on operations.
+type operationCommentRepository interface {
+	GetOperation(ctx context.Context, id string) (*model.LRO, error)
+	AddOperationComment(ctx context.Context, operationID string, comment *model.OperationComment) error
+}
+
+// operationCommentService records the comments and document references admins attach to
+// operations while reviewing them, e.g. the KYC documents checked before an approval.
+type operationCommentService struct {
+	repo  operationCommentRepository
+	newID func() string
+	now   func() time.Time
+}
+
+// NewOperationCommentService creates a new operationCommentService.
+func NewOperationCommentService(repo operationCommentRepository) (*operationCommentService, error) {
+	if repo == nil {
+		slog.Error("NewOperationCommentService: operationCommentRepository cannot be nil")
+		return nil, errors.New("operationCommentRepository cannot be nil")
+	}
+	return &operationCommentService{repo: repo, newID: uuid.NewString, now: time.Now}, nil
+}
+
+// validateComment checks the text and attachments of a comment request.
+func validateComment(req *model.OperationCommentRequest) error {
+	text := strings.TrimSpace(req.Text)
+	if text == "" && len(req.Attachments) == 0 {
+		return fmt.Errorf("%w: text or attachments are required", ErrInvalidOperationComment)
+	}
+	if n := len([]rune(text)); n > maxCommentLength {
+		return fmt.Errorf("%w: text has %d characters, want at most %d", ErrInvalidOperationComment, n, maxCommentLength)
+	}
+	if len(req.Attachments) > maxCommentAttachments {
+		return fmt.Errorf("%w: got %d attachments, want at most %d", ErrInvalidOperationComment, len(req.Attachments), maxCommentAttachments)
+	}
+	for _, a := range req.Attachments {
+		if !gcsURIPattern.MatchString(a) {
+			return fmt.Errorf("%w: attachment %q must be a GCS URI of the form gs://bucket/object", ErrInvalidOperationComment, a)
+		}
+	}
+	return nil
+}
+
+// Add attaches a comment by author to an operation and returns it. Comments can be added
+// whatever the status of the operation, so that the checks behind a decision can be recorded
+// after it was taken.
+func (s *operationCommentService) Add(ctx context.Context, operationID, author string, req *model.OperationCommentRequest) (*model.OperationComment, error) {
+	if err := validateComment(req); err != nil {
+		return nil, err
+	}
+	comment := &model.OperationComment{
+		ID:          s.newID(),
+		Author:      author,
+		Text:        strings.TrimSpace(req.Text),
+		Attachments: req.Attachments,
+		CreatedAt:   s.now().UTC(),
+	}
+	if err := s.repo.AddOperationComment(ctx, operationID, comment); err != nil {
+		return nil, fmt.Errorf("failed to add comment to operation %s: %w", operationID, err)
+	}
+	slog.InfoContext(ctx, "OperationCommentService: Comment added", "operation_id", operationID, "comment_id", comment.ID, "author", author, "attachments", len(comment.Attachments))
+	return comment, nil
+}
+
+// List returns the comments of an operation, oldest first.
+func (s *operationCommentService) List(ctx context.Context, operationID string) ([]model.OperationComment, error) {
+	lro, err := s.repo.GetOperation(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation %s: %w", operationID, err)
+	}
+	if lro.Comments == nil {
+		return []model.OperationComment{}, nil
+	}
+	return lro.Comments, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockCommentRepo is a mock for operationCommentRepository.
+type mockCommentRepo struct {
+	lro        *model.LRO
+	getErr     error
+	addErr     error
+	gotComment *model.OperationComment
+}
+
+func (m *mockCommentRepo) GetOperation(ctx context.Context, id string) (*model.LRO, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	return m.lro, nil
+}
+
+func (m *mockCommentRepo) AddOperationComment(ctx context.Context, operationID string, comment *model.OperationComment) error {
+	if m.addErr != nil {
+		return m.addErr
+	}
+	m.gotComment = comment
+	return nil
+}
+
+func newTestCommentService(repo operationCommentRepository) *operationCommentService {
+	s, _ := NewOperationCommentService(repo)
+	s.newID = func() string { return "c-1" }
+	s.now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestNewOperationCommentService_Error(t *testing.T) {
+	if _, err := NewOperationCommentService(nil); err == nil {
+		t.Error("NewOperationCommentService() expected error, got nil")
+	}
+}
+
+func TestOperationCommentService_Add(t *testing.T) {
+	repo := &mockCommentRepo{}
+	s := newTestCommentService(repo)
+
+	got, err := s.Add(context.Background(), "op-1", "alice", &model.OperationCommentRequest{Text: " PAN and GST checked ", Attachments: []string{"gs://kyc-docs/np1/pan.pdf"}})
+	if err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+	want := &model.OperationComment{
+		ID:          "c-1",
+		Author:      "alice",
+		Text:        "PAN and GST checked",
+		Attachments: []string{"gs://kyc-docs/np1/pan.pdf"},
+		CreatedAt:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Add() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, repo.gotComment); diff != "" {
+		t.Errorf("Add() stored comment mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationCommentService_Add_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *model.OperationCommentRequest
+	}{
+		{name: "empty", req: &model.OperationCommentRequest{Text: "  "}},
+		{name: "text too long", req: &model.OperationCommentRequest{Text: strings.Repeat("a", maxCommentLength+1)}},
+		{name: "too many attachments", req: &model.OperationCommentRequest{Attachments: make([]string, maxCommentAttachments+1)}},
+		{name: "not a GCS URI", req: &model.OperationCommentRequest{Attachments: []string{"https://storage.googleapis.com/kyc/pan.pdf"}}},
+		{name: "no object", req: &model.OperationCommentRequest{Attachments: []string{"gs://kyc-docs/"}}},
+		{name: "invalid bucket", req: &model.OperationCommentRequest{Attachments: []string{"gs://KYC/pan.pdf"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockCommentRepo{}
+			s := newTestCommentService(repo)
+			if _, err := s.Add(context.Background(), "op-1", "alice", tt.req); !errors.Is(err, ErrInvalidOperationComment) {
+				t.Errorf("Add() error = %v, want %v", err, ErrInvalidOperationComment)
+			}
+			if repo.gotComment != nil {
+				t.Error("Add() stored an invalid comment")
+			}
+		})
+	}
+}
+
+func TestOperationCommentService_Add_NotFound(t *testing.T) {
+	s := newTestCommentService(&mockCommentRepo{addErr: repository.ErrOperationNotFound})
+	if _, err := s.Add(context.Background(), "op-1", "", &model.OperationCommentRequest{Text: "note"}); !errors.Is(err, repository.ErrOperationNotFound) {
+		t.Errorf("Add() error = %v, want %v", err, repository.ErrOperationNotFound)
+	}
+}
+
+func TestOperationCommentService_List(t *testing.T) {
+	comments := []model.OperationComment{{ID: "c-1", Text: "note"}}
+	tests := []struct {
+		name    string
+		repo    *mockCommentRepo
+		want    []model.OperationComment
+		wantErr error
+	}{
+		{name: "comments", repo: &mockCommentRepo{lro: &model.LRO{OperationID: "op-1", Comments: comments}}, want: comments},
+		{name: "no comments", repo: &mockCommentRepo{lro: &model.LRO{OperationID: "op-1"}}, want: []model.OperationComment{}},
+		{name: "not found", repo: &mockCommentRepo{getErr: repository.ErrOperationNotFound}, wantErr: repository.ErrOperationNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestCommentService(tt.repo)
+			got, err := s.List(context.Background(), "op-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("List() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
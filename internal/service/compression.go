@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	encodingGzip = "gzip"
+
+	defaultCompressionMinSize        = 1024
+	defaultMaxDecompressedSize int64 = 10 << 20
+)
+
+var (
+	// ErrUnsupportedEncoding occurs if a request body has a Content-Encoding other than gzip.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	// ErrPayloadTooLarge occurs if a request body exceeds the maximum size once decompressed.
+	ErrPayloadTooLarge = errors.New("decompressed payload too large")
+)
+
+// compressionMetrics counts the payloads decompressed and compressed by the gateway.
+var compressionMetrics = expvar.NewMap("gateway_compression")
+
+// PayloadCompressionConfig configures gzip compression of the Beckn payloads the gateway
+// receives and sends.
+type PayloadCompressionConfig struct {
+	// MaxDecompressedSize is the size in bytes a gzip request body may have once
+	// decompressed. Defaults to 10 MiB.
+	MaxDecompressedSize int64 `yaml:"maxDecompressedSize"`
+	// MinSize is the body size in bytes from which requests to targets that accept gzip are
+	// compressed. Defaults to 1024.
+	MinSize int `yaml:"minSize"`
+	// Level is the gzip compression level from 1 (fastest) to 9 (smallest). Defaults to the
+	// gzip default level.
+	Level int `yaml:"level"`
+}
+
+// payloadCompression decompresses gzip request bodies and compresses the requests sent to
+// targets that have advertised gzip support with an Accept-Encoding response header.
+type payloadCompression struct {
+	maxSize int64
+	minSize int
+	level   int
+
+	mu      sync.RWMutex
+	accepts map[string]bool // whether each target host accepts gzip request bodies
+}
+
+// NewPayloadCompression creates a new payloadCompression.
+func NewPayloadCompression(cfg *PayloadCompressionConfig) (*payloadCompression, error) {
+	if cfg == nil {
+		slog.Error("NewPayloadCompression: PayloadCompressionConfig cannot be nil")
+		return nil, errors.New("PayloadCompressionConfig cannot be nil")
+	}
+	if cfg.MaxDecompressedSize < 0 || cfg.MinSize < 0 {
+		return nil, errors.New("invalid compression config: sizes cannot be negative")
+	}
+	if cfg.Level < 0 || cfg.Level > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid compression config: level %d is out of range [1, 9]", cfg.Level)
+	}
+	c := &payloadCompression{
+		maxSize: cfg.MaxDecompressedSize,
+		minSize: cfg.MinSize,
+		level:   cfg.Level,
+		accepts: map[string]bool{},
+	}
+	if c.maxSize == 0 {
+		c.maxSize = defaultMaxDecompressedSize
+	}
+	if c.minSize == 0 {
+		c.minSize = defaultCompressionMinSize
+	}
+	if c.level == 0 {
+		c.level = gzip.DefaultCompression
+	}
+	return c, nil
+}
+
+// Decompress returns the body of a request with the given Content-Encoding header as sent
+// by its signer. Bodies without an encoding are returned as is.
+func (c *payloadCompression) Decompress(encoding string, body []byte) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case encodingGzip, "x-gzip":
+	default:
+		compressionMetrics.Add("inbound_rejected", 1)
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		compressionMetrics.Add("inbound_rejected", 1)
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, c.maxSize+1))
+	if err != nil {
+		compressionMetrics.Add("inbound_rejected", 1)
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	if int64(len(out)) > c.maxSize {
+		compressionMetrics.Add("inbound_rejected", 1)
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrPayloadTooLarge, c.maxSize)
+	}
+	compressionMetrics.Add("inbound_decompressed", 1)
+	return out, nil
+}
+
+// Client wraps client so that request bodies of at least the minimum size are compressed
+// for the hosts that accept gzip.
+func (c *payloadCompression) Client(client httpClient) httpClient {
+	return &gzipClient{next: client, c: c}
+}
+
+// acceptsGzip reports whether host has advertised that it accepts gzip request bodies.
+func (c *payloadCompression) acceptsGzip(host string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.accepts[host]
+}
+
+// learn records whether host accepts gzip request bodies from the Accept-Encoding header of
+// its response (RFC 7694). Responses without the header leave what is known unchanged.
+func (c *payloadCompression) learn(host string, resp *http.Response) {
+	values := resp.Header.Values("Accept-Encoding")
+	if len(values) == 0 && resp.StatusCode != http.StatusUnsupportedMediaType {
+		return
+	}
+	accepts := false
+	for _, v := range values {
+		for _, enc := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(enc, ";")
+			if strings.EqualFold(strings.TrimSpace(name), encodingGzip) && strings.ReplaceAll(params, " ", "") != "q=0" {
+				accepts = true
+			}
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accepts[host] = accepts
+}
+
+// gzipClient compresses the request bodies sent to hosts that accept gzip.
+type gzipClient struct {
+	next httpClient
+	c    *payloadCompression
+}
+
+// Do sends req, compressed if its host accepts gzip and its body is large enough. A compressed
+// request rejected with 415 Unsupported Media Type is sent again uncompressed.
+func (g *gzipClient) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if req.GetBody == nil || req.ContentLength < int64(g.c.minSize) || req.Header.Get("Content-Encoding") != "" || !g.c.acceptsGzip(host) {
+		return g.send(req)
+	}
+	creq, err := g.compress(req)
+	if err != nil {
+		slog.WarnContext(req.Context(), "PayloadCompression: Failed to compress request body, sending it uncompressed", "target", req.URL.String(), "error", err)
+		return g.send(req)
+	}
+	resp, err := g.send(creq)
+	if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		return resp, err
+	}
+	slog.WarnContext(req.Context(), "PayloadCompression: Target rejected compressed body, sending it uncompressed", "target", req.URL.String())
+	compressionMetrics.Add("outbound_rejected", 1)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = body
+	return g.send(req)
+}
+
+// send sends req and learns from the response whether its host accepts gzip.
+func (g *gzipClient) send(req *http.Request) (*http.Response, error) {
+	resp, err := g.next.Do(req)
+	if err == nil {
+		g.c.learn(req.URL.Host, resp)
+	}
+	return resp, err
+}
+
+// compress returns a copy of req with its body gzip compressed. The signature of the request
+// covers the uncompressed body, which the target verifies once it has decompressed it.
+func (g *gzipClient) compress(req *http.Request) (*http.Request, error) {
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, g.c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(zw, body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	compressed := buf.Bytes()
+	creq := req.Clone(req.Context())
+	creq.Body = io.NopCloser(bytes.NewReader(compressed))
+	creq.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(compressed)), nil }
+	creq.ContentLength = int64(len(compressed))
+	creq.Header.Set("Content-Encoding", encodingGzip)
+	compressionMetrics.Add("outbound_compressed", 1)
+	compressionMetrics.Add("outbound_bytes_saved", req.ContentLength-creq.ContentLength)
+	return creq, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gzipBytes returns b gzip compressed.
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatalf("gzip Write() unexpected error: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip Close() unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewPayloadCompression(t *testing.T) {
+	c, err := NewPayloadCompression(&PayloadCompressionConfig{})
+	if err != nil {
+		t.Fatalf("NewPayloadCompression() unexpected error: %v", err)
+	}
+	if c.maxSize != defaultMaxDecompressedSize || c.minSize != defaultCompressionMinSize || c.level != gzip.DefaultCompression {
+		t.Errorf("NewPayloadCompression() = %+v, want defaults", c)
+	}
+
+	for _, cfg := range []*PayloadCompressionConfig{nil, {MinSize: -1}, {MaxDecompressedSize: -1}, {Level: 10}} {
+		if _, err := NewPayloadCompression(cfg); err == nil {
+			t.Errorf("NewPayloadCompression(%+v) error = nil, want error", cfg)
+		}
+	}
+}
+
+func TestPayloadCompression_Decompress(t *testing.T) {
+	body := []byte(`{"context":{"action":"search"}}`)
+	c, err := NewPayloadCompression(&PayloadCompressionConfig{MaxDecompressedSize: int64(len(body))})
+	if err != nil {
+		t.Fatalf("NewPayloadCompression() unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     []byte
+		wantErr  error
+	}{
+		{name: "no encoding", body: body, want: body},
+		{name: "identity", encoding: "identity", body: body, want: body},
+		{name: "gzip", encoding: "gzip", body: gzipBytes(t, body), want: body},
+		{name: "gzip in upper case", encoding: "GZIP", body: gzipBytes(t, body), want: body},
+		{name: "unsupported encoding", encoding: "br", body: body, wantErr: ErrUnsupportedEncoding},
+		{name: "too large", encoding: "gzip", body: gzipBytes(t, append(body, ' ')), wantErr: ErrPayloadTooLarge},
+		{name: "corrupt", encoding: "gzip", body: body, wantErr: gzip.ErrHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Decompress(tt.encoding, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decompress() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Decompress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// compressionTarget is a target that records the bodies it receives and advertises gzip
+// support with its Accept-Encoding response header.
+type compressionTarget struct {
+	acceptEncoding string
+	rejectGzip     bool
+	encodings      []string
+	bodies         [][]byte
+}
+
+func (s *compressionTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	enc := r.Header.Get("Content-Encoding")
+	s.encodings = append(s.encodings, enc)
+	if enc == "gzip" {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ = io.ReadAll(zr)
+	}
+	s.bodies = append(s.bodies, body)
+	if s.acceptEncoding != "" {
+		w.Header().Set("Accept-Encoding", s.acceptEncoding)
+	}
+	if enc == "gzip" && s.rejectGzip {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestGzipClient(t *testing.T) {
+	large := []byte(`{"message":"` + strings.Repeat("a", 2048) + `"}`)
+	tests := []struct {
+		name          string
+		target        *compressionTarget
+		bodies        [][]byte
+		wantEncodings []string
+	}{
+		{
+			name:          "compresses large bodies once the target accepts gzip",
+			target:        &compressionTarget{acceptEncoding: "gzip"},
+			bodies:        [][]byte{large, large},
+			wantEncodings: []string{"", "gzip"},
+		},
+		{
+			name:          "small bodies are not compressed",
+			target:        &compressionTarget{acceptEncoding: "gzip"},
+			bodies:        [][]byte{large, []byte(`{}`)},
+			wantEncodings: []string{"", ""},
+		},
+		{
+			name:          "targets that do not advertise gzip",
+			target:        &compressionTarget{},
+			bodies:        [][]byte{large, large},
+			wantEncodings: []string{"", ""},
+		},
+		{
+			name:          "gzip refused with q=0",
+			target:        &compressionTarget{acceptEncoding: "identity, gzip;q=0"},
+			bodies:        [][]byte{large, large},
+			wantEncodings: []string{"", ""},
+		},
+		{
+			name:          "rejected compressed body is sent again uncompressed",
+			target:        &compressionTarget{acceptEncoding: "gzip", rejectGzip: true},
+			bodies:        [][]byte{large, large},
+			wantEncodings: []string{"", "gzip", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.target)
+			defer srv.Close()
+			c, err := NewPayloadCompression(&PayloadCompressionConfig{})
+			if err != nil {
+				t.Fatalf("NewPayloadCompression() unexpected error: %v", err)
+			}
+			client := c.Client(srv.Client())
+
+			for _, body := range tt.bodies {
+				req, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatalf("Do() unexpected error: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("Do() status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+			}
+			if got := strings.Join(tt.target.encodings, ","); got != strings.Join(tt.wantEncodings, ",") {
+				t.Errorf("Content-Encoding of requests = %q, want %q", tt.target.encodings, tt.wantEncodings)
+			}
+			for i, body := range tt.target.bodies {
+				if !bytes.Equal(body, tt.bodies[min(i, len(tt.bodies)-1)]) {
+					t.Errorf("request %d body = %q, want the original body", i, body)
+				}
+			}
+		})
+	}
+}
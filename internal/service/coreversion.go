@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ErrUnsupportedCoreVersion is returned when a request's core version is not supported for its domain.
+var ErrUnsupportedCoreVersion = errors.New("unsupported core version")
+
+// CoreVersionConfig declares the Beckn core versions supported per domain.
+type CoreVersionConfig struct {
+	// Default lists the versions accepted for domains without an explicit entry.
+	// An empty list accepts any version for such domains.
+	Default []string `yaml:"default" json:"default,omitempty"`
+	// Domains lists the versions accepted for specific domains.
+	Domains map[string][]string `yaml:"domains" json:"domains,omitempty"`
+	// Rewrites maps an unsupported version to a supported one that the request is transformed to.
+	// It is empty by default. Rewriting changes the payload after the sender's signature has been
+	// validated, so receivers that verify the sender's signature reject rewritten requests.
+	Rewrites map[string]string `yaml:"rewrites" json:"rewrites,omitempty"`
+	// MigrationDocs maps a domain to the URL of its upgrade guide, which is linked from the
+	// NACK of requests with an unsupported version.
+	MigrationDocs map[string]string `yaml:"migrationDocs" json:"migrationDocs,omitempty"`
+}
+
+// UnsupportedCoreVersionError reports a core version that is not supported for a domain,
+// with what the sender needs to upgrade.
+type UnsupportedCoreVersionError struct {
+	Version   string
+	Domain    string
+	Supported []string
+	// MigrationDocURL is the upgrade guide of the domain, or empty if none is configured.
+	MigrationDocURL string
+}
+
+func (e *UnsupportedCoreVersionError) Error() string {
+	return fmt.Sprintf("%v: %q for domain %q, supported versions: %v", ErrUnsupportedCoreVersion, e.Version, e.Domain, e.Supported)
+}
+
+func (e *UnsupportedCoreVersionError) Unwrap() error {
+	return ErrUnsupportedCoreVersion
+}
+
+// coreVersionPolicy enforces the core version compatibility matrix.
+type coreVersionPolicy struct {
+	cfg *CoreVersionConfig
+}
+
+// NewCoreVersionPolicy creates a new core version policy from the given config.
+func NewCoreVersionPolicy(cfg *CoreVersionConfig) (*coreVersionPolicy, error) {
+	if cfg == nil {
+		slog.Error("NewCoreVersionPolicy: config cannot be nil")
+		return nil, errors.New("core version config cannot be nil")
+	}
+	for from, to := range cfg.Rewrites {
+		if from == to {
+			return nil, fmt.Errorf("core version rewrite for %q must map to a different version", from)
+		}
+	}
+	if len(cfg.Rewrites) > 0 {
+		slog.Warn("NewCoreVersionPolicy: Core version rewrites are enabled, rewritten requests will fail end-to-end signature verification", "rewrites", cfg.Rewrites)
+	}
+	for domain, doc := range cfg.MigrationDocs {
+		if u, err := url.Parse(doc); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("core version migration doc of domain %q must be an absolute http(s) URL, got %q", domain, doc)
+		}
+	}
+	return &coreVersionPolicy{cfg: cfg}, nil
+}
+
+// Matrix returns the configured compatibility matrix.
+func (p *coreVersionPolicy) Matrix() *CoreVersionConfig {
+	return p.cfg
+}
+
+// Apply checks the request's core version against the matrix for its domain.
+// It returns the body to forward, which is rewritten when a configured rewrite
+// maps the version to a supported one, or an UnsupportedCoreVersionError otherwise.
+func (p *coreVersionPolicy) Apply(reqCtx *model.Context, body []byte) ([]byte, error) {
+	version := reqCtx.CoreVersion
+	if version == "" {
+		version = reqCtx.Version
+	}
+	supported := p.supported(reqCtx.Domain)
+	if len(supported) == 0 || slices.Contains(supported, version) {
+		return body, nil
+	}
+	to, ok := p.cfg.Rewrites[version]
+	if !ok || !slices.Contains(supported, to) {
+		return nil, &UnsupportedCoreVersionError{
+			Version:         version,
+			Domain:          reqCtx.Domain,
+			Supported:       supported,
+			MigrationDocURL: p.cfg.MigrationDocs[reqCtx.Domain],
+		}
+	}
+	rewritten, err := rewriteCoreVersion(body, to)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("CoreVersionPolicy: Rewrote request core version", "domain", reqCtx.Domain, "from", version, "to", to)
+	reqCtx.CoreVersion = to
+	return rewritten, nil
+}
+
+// supported returns the versions accepted for the given domain.
+func (p *coreVersionPolicy) supported(domain string) []string {
+	if v, ok := p.cfg.Domains[domain]; ok {
+		return v
+	}
+	return p.cfg.Default
+}
+
+// rewriteCoreVersion sets context.core_version in body to version, leaving all other fields untouched.
+func rewriteCoreVersion(body []byte, version string) ([]byte, error) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	var reqCtx map[string]json.RawMessage
+	if err := json.Unmarshal(req["context"], &reqCtx); err != nil {
+		return nil, fmt.Errorf("failed to parse request context: %w", err)
+	}
+	v, err := json.Marshal(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal core version: %w", err)
+	}
+	reqCtx["core_version"] = v
+	if req["context"], err = json.Marshal(reqCtx); err != nil {
+		return nil, fmt.Errorf("failed to marshal request context: %w", err)
+	}
+	return json.Marshal(req)
+}
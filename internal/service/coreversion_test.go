@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestNewCoreVersionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *CoreVersionConfig
+		wantErr bool
+	}{
+		{"valid", &CoreVersionConfig{Default: []string{"1.1.0"}}, false},
+		{"nil config", nil, true},
+		{"self rewrite", &CoreVersionConfig{Rewrites: map[string]string{"1.0.0": "1.0.0"}}, true},
+		{"migration doc", &CoreVersionConfig{MigrationDocs: map[string]string{"retail": "https://docs.example.com/retail/upgrade"}}, false},
+		{"relative migration doc", &CoreVersionConfig{MigrationDocs: map[string]string{"retail": "/retail/upgrade"}}, true},
+		{"non-http migration doc", &CoreVersionConfig{MigrationDocs: map[string]string{"retail": "ftp://docs.example.com/upgrade"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCoreVersionPolicy(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCoreVersionPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCoreVersionPolicy_Apply(t *testing.T) {
+	cfg := &CoreVersionConfig{
+		Default:  []string{"1.1.0"},
+		Domains:  map[string][]string{"retail": {"1.1.0", "1.2.0"}, "open": {}},
+		Rewrites: map[string]string{"1.0.0": "1.1.0", "0.9.0": "0.9.1"},
+	}
+	p, err := NewCoreVersionPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewCoreVersionPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		ctx         model.Context
+		body        string
+		wantErr     error
+		wantVersion string
+		wantBody    bool // Whether the body is expected unchanged.
+	}{
+		{"supported domain version", model.Context{Domain: "retail", CoreVersion: "1.2.0"}, `{"context":{"core_version":"1.2.0"}}`, nil, "1.2.0", true},
+		{"default versions", model.Context{Domain: "mobility", CoreVersion: "1.1.0"}, `{"context":{"core_version":"1.1.0"}}`, nil, "1.1.0", true},
+		{"falls back to version field", model.Context{Domain: "mobility", Version: "1.1.0"}, `{"context":{"version":"1.1.0"}}`, nil, "", true},
+		{"empty list accepts any", model.Context{Domain: "open", CoreVersion: "9.9.9"}, `{"context":{"core_version":"9.9.9"}}`, nil, "9.9.9", true},
+		{"rewritten", model.Context{Domain: "retail", CoreVersion: "1.0.0"}, `{"context":{"core_version":"1.0.0","domain":"retail"},"message":{"a":1}}`, nil, "1.1.0", false},
+		{"rewrite target unsupported", model.Context{Domain: "retail", CoreVersion: "0.9.0"}, `{"context":{"core_version":"0.9.0"}}`, ErrUnsupportedCoreVersion, "", false},
+		{"unsupported", model.Context{Domain: "retail", CoreVersion: "2.0.0"}, `{"context":{"core_version":"2.0.0"}}`, ErrUnsupportedCoreVersion, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqCtx := tt.ctx
+			got, err := p.Apply(&reqCtx, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if tt.wantBody && string(got) != tt.body {
+				t.Errorf("Apply() body = %s, want unchanged %s", got, tt.body)
+			}
+			var req struct {
+				Context model.Context   `json:"context"`
+				Message json.RawMessage `json:"message"`
+			}
+			if err := json.Unmarshal(got, &req); err != nil {
+				t.Fatalf("Apply() returned invalid JSON: %v", err)
+			}
+			if req.Context.CoreVersion != tt.wantVersion {
+				t.Errorf("Apply() core_version = %q, want %q", req.Context.CoreVersion, tt.wantVersion)
+			}
+			if !tt.wantBody && reqCtx.CoreVersion != tt.wantVersion {
+				t.Errorf("Apply() reqCtx.CoreVersion = %q, want %q", reqCtx.CoreVersion, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestCoreVersionPolicy_Apply_UpgradeHints(t *testing.T) {
+	p, err := NewCoreVersionPolicy(&CoreVersionConfig{
+		Default:       []string{"1.1.0"},
+		Domains:       map[string][]string{"retail": {"1.1.0", "1.2.0"}},
+		MigrationDocs: map[string]string{"retail": "https://docs.example.com/retail/upgrade"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoreVersionPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  model.Context
+		want *UnsupportedCoreVersionError
+	}{
+		{
+			name: "domain with migration doc",
+			ctx:  model.Context{Domain: "retail", CoreVersion: "1.0.0"},
+			want: &UnsupportedCoreVersionError{Version: "1.0.0", Domain: "retail", Supported: []string{"1.1.0", "1.2.0"}, MigrationDocURL: "https://docs.example.com/retail/upgrade"},
+		},
+		{
+			name: "default versions without migration doc",
+			ctx:  model.Context{Domain: "mobility", Version: "1.0.0"},
+			want: &UnsupportedCoreVersionError{Version: "1.0.0", Domain: "mobility", Supported: []string{"1.1.0"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqCtx := tt.ctx
+			_, err := p.Apply(&reqCtx, []byte(`{"context":{}}`))
+			var verr *UnsupportedCoreVersionError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Apply() error = %v, want an UnsupportedCoreVersionError", err)
+			}
+			if !reflect.DeepEqual(verr, tt.want) {
+				t.Errorf("Apply() error = %+v, want %+v", verr, tt.want)
+			}
+			if !errors.Is(err, ErrUnsupportedCoreVersion) {
+				t.Errorf("Apply() error = %v, want it to wrap ErrUnsupportedCoreVersion", err)
+			}
+		})
+	}
+}
+
+func TestRewriteCoreVersion_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid body", `{`},
+		{"invalid context", `{"context":"x"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rewriteCoreVersion([]byte(tt.body), "1.1.0"); err == nil {
+				t.Error("rewriteCoreVersion() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// maxDecisionImportRows is the maximum number of decisions in a single import.
+const maxDecisionImportRows = 1000
+
+// ErrInvalidDecisionImport is returned when an import file cannot be read as a list of decisions.
+var ErrInvalidDecisionImport = errors.New("invalid decision import")
+
+// decisionActioner applies admin decisions to subscription operations.
+type decisionActioner interface {
+	ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error)
+	RejectSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error)
+}
+
+// decisionImportService applies approval decisions reviewed offline, in batches.
+type decisionImportService struct {
+	admin decisionActioner
+}
+
+// NewDecisionImportService creates a new decisionImportService.
+func NewDecisionImportService(admin decisionActioner) (*decisionImportService, error) {
+	if admin == nil {
+		slog.Error("NewDecisionImportService: decisionActioner cannot be nil")
+		return nil, errors.New("decisionActioner cannot be nil")
+	}
+	return &decisionImportService{admin: admin}, nil
+}
+
+// Import reads decisions from a CSV file with the columns operation_id, action and reason,
+// and an optional header row, and applies them in order on behalf of reviewer.
+// Actions are APPROVE or REJECT, or their full names; the reason is required to reject.
+// Invalid decisions and decisions that cannot be applied are reported as failed without
+// stopping the import. If ctx is canceled, the remaining decisions are skipped.
+func (s *decisionImportService) Import(ctx context.Context, r io.Reader, reviewer string) (*model.DecisionImportReport, error) {
+	rows, err := parseDecisions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.DecisionImportReport{Total: len(rows), Rows: rows}
+	seen := make(map[string]int, len(rows))
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		switch {
+		case row.Result != "":
+			// Rejected while parsing.
+		case ctx.Err() != nil:
+			row.Result = model.DecisionImportResultSkipped
+			row.Error = ctx.Err().Error()
+		default:
+			if line, ok := seen[row.OperationID]; ok {
+				row.Result = model.DecisionImportResultFailed
+				row.Error = fmt.Sprintf("duplicate of the decision on line %d", line)
+				break
+			}
+			seen[row.OperationID] = row.Line
+			s.apply(ctx, row, reviewer)
+		}
+		switch row.Result {
+		case model.DecisionImportResultApplied:
+			report.Applied++
+		case model.DecisionImportResultFailed:
+			report.Failed++
+		case model.DecisionImportResultSkipped:
+			report.Skipped++
+		}
+	}
+	slog.InfoContext(ctx, "DecisionImportService: Imported decisions", "reviewer", reviewer, "total", report.Total, "applied", report.Applied, "failed", report.Failed, "skipped", report.Skipped)
+	return report, nil
+}
+
+// apply applies a single decision and records its outcome on the row.
+func (s *decisionImportService) apply(ctx context.Context, row *model.DecisionImportRow, reviewer string) {
+	req := &model.OperationActionRequest{Action: row.Action, OperationID: row.OperationID, Reason: row.Reason, Reviewer: reviewer}
+	var lro *model.LRO
+	var err error
+	if row.Action == model.OperationActionApproveSubscription {
+		_, lro, err = s.admin.ApproveSubscription(ctx, req)
+	} else {
+		lro, err = s.admin.RejectSubscription(ctx, req)
+	}
+	if lro != nil {
+		row.LROStatus = lro.Status
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "DecisionImportService: Failed to apply decision", "line", row.Line, "operation_id", row.OperationID, "action", row.Action, "error", err)
+		row.Result = model.DecisionImportResultFailed
+		row.Error = err.Error()
+		return
+	}
+	row.Result = model.DecisionImportResultApplied
+}
+
+// parseDecisions reads the decisions of an import file. Rows with an invalid
+// action or missing fields are returned already marked as failed.
+func parseDecisions(r io.Reader) ([]model.DecisionImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var rows []model.DecisionImportRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidDecisionImport, err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rows) == 0 && line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "operation_id") {
+			continue
+		}
+		if len(rows) == maxDecisionImportRows {
+			return nil, fmt.Errorf("%w: more than %d decisions", ErrInvalidDecisionImport, maxDecisionImportRows)
+		}
+		rows = append(rows, parseDecision(line, record))
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no decisions found", ErrInvalidDecisionImport)
+	}
+	return rows, nil
+}
+
+// parseDecision converts a CSV record into a decision.
+func parseDecision(line int, record []string) model.DecisionImportRow {
+	row := model.DecisionImportRow{Line: line, OperationID: strings.TrimSpace(record[0])}
+	if len(record) > 1 {
+		row.Action = decisionAction(strings.TrimSpace(record[1]))
+	}
+	if len(record) > 2 {
+		row.Reason = strings.TrimSpace(record[2])
+	}
+
+	var problem string
+	switch {
+	case len(record) < 2 || len(record) > 3:
+		problem = fmt.Sprintf("expected 2 or 3 columns, got %d", len(record))
+	case row.OperationID == "":
+		problem = "operation_id is required"
+	case row.Action != model.OperationActionApproveSubscription && row.Action != model.OperationActionRejectSubscription:
+		problem = fmt.Sprintf("invalid action %q, must be APPROVE or REJECT", row.Action)
+	case row.Action == model.OperationActionRejectSubscription && row.Reason == "":
+		problem = "reason is required for REJECT action"
+	}
+	if problem != "" {
+		row.Result = model.DecisionImportResultFailed
+		row.Error = problem
+	}
+	return row
+}
+
+// decisionAction maps the action column to an operation action.
+// Unknown actions are returned unchanged.
+func decisionAction(v string) model.OperationAction {
+	switch strings.ToUpper(v) {
+	case "APPROVE", string(model.OperationActionApproveSubscription):
+		return model.OperationActionApproveSubscription
+	case "REJECT", string(model.OperationActionRejectSubscription):
+		return model.OperationActionRejectSubscription
+	}
+	return model.OperationAction(v)
+}
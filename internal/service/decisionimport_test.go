@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockDecisionActioner records the decisions it is asked to apply.
+type mockDecisionActioner struct {
+	errs   map[string]error // Errors by operation ID.
+	reqs   []model.OperationActionRequest
+	cancel context.CancelFunc // Called after the first decision, if set.
+}
+
+func (m *mockDecisionActioner) ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error) {
+	lro, err := m.act(req, model.LROStatusApproved)
+	return nil, lro, err
+}
+
+func (m *mockDecisionActioner) RejectSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error) {
+	return m.act(req, model.LROStatusRejected)
+}
+
+func (m *mockDecisionActioner) act(req *model.OperationActionRequest, status model.LROStatus) (*model.LRO, error) {
+	m.reqs = append(m.reqs, *req)
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if err := m.errs[req.OperationID]; err != nil {
+		return nil, err
+	}
+	return &model.LRO{OperationID: req.OperationID, Status: status}, nil
+}
+
+func TestNewDecisionImportService(t *testing.T) {
+	if _, err := NewDecisionImportService(&mockDecisionActioner{}); err != nil {
+		t.Errorf("NewDecisionImportService() unexpected error: %v", err)
+	}
+	if _, err := NewDecisionImportService(nil); err == nil {
+		t.Error("NewDecisionImportService(nil) expected error, got nil")
+	}
+}
+
+func TestDecisionImportService_Import(t *testing.T) {
+	csv := `operation_id,action,reason
+op-1,APPROVE,
+op-2,reject,Incomplete documents
+op-3,REJECT_SUBSCRIPTION,"Invalid domain, see ticket"
+op-4,REJECT,
+,APPROVE
+op-5,SUSPEND,
+op-6,APPROVE,,extra
+op-1,REJECT,Duplicate
+op-7,APPROVE_SUBSCRIPTION
+`
+	actioner := &mockDecisionActioner{errs: map[string]error{"op-7": ErrLROAlreadyProcessed}}
+	s, _ := NewDecisionImportService(actioner)
+
+	report, err := s.Import(context.Background(), strings.NewReader(csv), "alice@example.com")
+	if err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	want := &model.DecisionImportReport{
+		Total:   9,
+		Applied: 3,
+		Failed:  6,
+		Rows: []model.DecisionImportRow{
+			{Line: 2, OperationID: "op-1", Action: model.OperationActionApproveSubscription, Result: model.DecisionImportResultApplied, LROStatus: model.LROStatusApproved},
+			{Line: 3, OperationID: "op-2", Action: model.OperationActionRejectSubscription, Reason: "Incomplete documents", Result: model.DecisionImportResultApplied, LROStatus: model.LROStatusRejected},
+			{Line: 4, OperationID: "op-3", Action: model.OperationActionRejectSubscription, Reason: "Invalid domain, see ticket", Result: model.DecisionImportResultApplied, LROStatus: model.LROStatusRejected},
+			{Line: 5, OperationID: "op-4", Action: model.OperationActionRejectSubscription, Result: model.DecisionImportResultFailed, Error: "reason is required for REJECT action"},
+			{Line: 6, Action: model.OperationActionApproveSubscription, Result: model.DecisionImportResultFailed, Error: "operation_id is required"},
+			{Line: 7, OperationID: "op-5", Action: "SUSPEND", Result: model.DecisionImportResultFailed, Error: `invalid action "SUSPEND", must be APPROVE or REJECT`},
+			{Line: 8, OperationID: "op-6", Action: model.OperationActionApproveSubscription, Result: model.DecisionImportResultFailed, Error: "expected 2 or 3 columns, got 4"},
+			{Line: 9, OperationID: "op-1", Action: model.OperationActionRejectSubscription, Reason: "Duplicate", Result: model.DecisionImportResultFailed, Error: "duplicate of the decision on line 2"},
+			{Line: 10, OperationID: "op-7", Action: model.OperationActionApproveSubscription, Result: model.DecisionImportResultFailed, Error: ErrLROAlreadyProcessed.Error()},
+		},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("Import() report mismatch (-want +got):\n%s", diff)
+	}
+	for _, req := range actioner.reqs {
+		if req.Reviewer != "alice@example.com" {
+			t.Errorf("decision on %s applied by reviewer %q, want %q", req.OperationID, req.Reviewer, "alice@example.com")
+		}
+	}
+	if len(actioner.reqs) != 4 {
+		t.Errorf("applied decisions = %d, want 4", len(actioner.reqs))
+	}
+}
+
+func TestDecisionImportService_Import_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	actioner := &mockDecisionActioner{cancel: cancel}
+	s, _ := NewDecisionImportService(actioner)
+
+	report, err := s.Import(ctx, strings.NewReader("op-1,APPROVE\nop-2,APPROVE\n"), "")
+	if err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+	if report.Applied != 1 || report.Skipped != 1 {
+		t.Errorf("Import() applied = %d, skipped = %d, want 1 and 1", report.Applied, report.Skipped)
+	}
+	if got := report.Rows[1].Result; got != model.DecisionImportResultSkipped {
+		t.Errorf("second decision result = %q, want %q", got, model.DecisionImportResultSkipped)
+	}
+}
+
+func TestDecisionImportService_Import_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{name: "empty", csv: ""},
+		{name: "header only", csv: "operation_id,action,reason\n"},
+		{name: "malformed csv", csv: "op-1,\"APPROVE\n"},
+		{name: "too many rows", csv: strings.Repeat("op,APPROVE\n", maxDecisionImportRows+1)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actioner := &mockDecisionActioner{}
+			s, _ := NewDecisionImportService(actioner)
+			_, err := s.Import(context.Background(), strings.NewReader(tc.csv), "")
+			if !errors.Is(err, ErrInvalidDecisionImport) {
+				t.Errorf("Import() error = %v, want %v", err, ErrInvalidDecisionImport)
+			}
+			if len(actioner.reqs) != 0 {
+				t.Errorf("applied decisions = %d, want 0", len(actioner.reqs))
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultDeliveryBucket        = 5 * time.Minute
+	defaultDeliveryFlushInterval = 10 * time.Second
+	defaultDeliveryKeyPrefix     = "gateway:delivery:"
+	// maxDeliveryBuckets bounds the number of buckets read for a report.
+	maxDeliveryBuckets = 2016
+)
+
+// defaultDeliveryWindows are the windows reported without configuration.
+var defaultDeliveryWindows = []time.Duration{time.Hour, 24 * time.Hour}
+
+// deliveryStatsVars counts the flushes of delivery counts to Redis on the expvar endpoint (/debug/vars).
+var deliveryStatsVars = expvar.NewMap("gateway_delivery_stats")
+
+// deliveryOutcome is the outcome of a request forwarded to a target.
+type deliveryOutcome string
+
+const (
+	deliverySuccess deliveryOutcome = "success"
+	deliveryError   deliveryOutcome = "error"
+	deliveryTimeout deliveryOutcome = "timeout"
+)
+
+// DeliveryStatsConfig configures the rolling counts of delivery outcomes per target, kept in
+// Redis so that they survive restarts and are shared by the gateway replicas.
+type DeliveryStatsConfig struct {
+	// Windows are the lengths of the rolling windows reported, each a multiple of Bucket.
+	// Defaults to 1h and 24h.
+	Windows []time.Duration `yaml:"windows"`
+	// Bucket is the granularity of the counts, a whole number of seconds. Defaults to 5m.
+	Bucket time.Duration `yaml:"bucket"`
+	// FlushInterval is how often the counts of this replica are added to Redis. Defaults to 10s.
+	FlushInterval time.Duration `yaml:"flushInterval"`
+	// KeyPrefix is prepended to the start of a bucket, in Unix seconds, to form its Redis key.
+	// Defaults to "gateway:delivery:".
+	KeyPrefix string `yaml:"keyPrefix"`
+}
+
+// validate checks the config and applies defaults.
+func (c *DeliveryStatsConfig) validate() error {
+	if c.Bucket < 0 || c.FlushInterval < 0 {
+		return errors.New("invalid delivery stats config: durations cannot be negative")
+	}
+	if c.Bucket == 0 {
+		c.Bucket = defaultDeliveryBucket
+	}
+	if c.Bucket%time.Second != 0 {
+		return fmt.Errorf("invalid delivery stats config: bucket %s must be a whole number of seconds", c.Bucket)
+	}
+	if c.FlushInterval == 0 {
+		c.FlushInterval = defaultDeliveryFlushInterval
+	}
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = defaultDeliveryKeyPrefix
+	}
+	if len(c.Windows) == 0 {
+		c.Windows = defaultDeliveryWindows
+	}
+	for _, w := range c.Windows {
+		if w <= 0 || w%c.Bucket != 0 {
+			return fmt.Errorf("invalid delivery stats config: window %s must be a positive multiple of bucket %s", w, c.Bucket)
+		}
+		if w/c.Bucket > maxDeliveryBuckets {
+			return fmt.Errorf("invalid delivery stats config: window %s spans more than %d buckets of %s", w, maxDeliveryBuckets, c.Bucket)
+		}
+	}
+	c.Windows = slices.Compact(slices.Sorted(slices.Values(c.Windows)))
+	return nil
+}
+
+// deliveryStatsStore is the subset of the Redis client used by the delivery stats.
+type deliveryStatsStore interface {
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+}
+
+// deliveryField identifies a count: the outcome and target in the hash of a bucket.
+type deliveryField struct {
+	bucket int64 // start of the bucket in Unix seconds
+	field  string
+}
+
+// deliveryStats counts the outcomes of the requests the gateway forwards to each target in
+// time buckets, for network participant reliability scorecards. Counts are buffered in
+// memory and added to Redis every flush interval, where a hash per bucket holds the counts
+// of all replicas and expires once it leaves the longest window.
+type deliveryStats struct {
+	client        deliveryStatsStore
+	windows       []time.Duration
+	bucket        time.Duration
+	flushInterval time.Duration
+	keyPrefix     string
+	now           func() time.Time
+
+	mu      sync.Mutex
+	pending map[deliveryField]int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDeliveryStats creates a new deliveryStats.
+func NewDeliveryStats(client deliveryStatsStore, cfg *DeliveryStatsConfig) (*deliveryStats, error) {
+	if client == nil {
+		slog.Error("NewDeliveryStats: client cannot be nil")
+		return nil, errors.New("redis client cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewDeliveryStats: DeliveryStatsConfig cannot be nil")
+		return nil, errors.New("DeliveryStatsConfig cannot be nil")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &deliveryStats{
+		client:        client,
+		windows:       cfg.Windows,
+		bucket:        cfg.Bucket,
+		flushInterval: cfg.FlushInterval,
+		keyPrefix:     cfg.KeyPrefix,
+		now:           time.Now,
+		pending:       map[deliveryField]int64{},
+	}, nil
+}
+
+// Start starts flushing the counts to Redis every flush interval.
+func (s *deliveryStats) Start() {
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(context.Background())
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	slog.Info("DeliveryStats: Counting delivery outcomes per target", "windows", s.windows, "bucket", s.bucket)
+}
+
+// Stop stops the periodic flush and flushes the remaining counts.
+func (s *deliveryStats) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	s.flush(context.Background())
+}
+
+// deliveryTarget returns the subscriber URL of a request forwarded to target for action,
+// which is the target without the action path.
+func deliveryTarget(target *url.URL, action string) string {
+	return strings.TrimSuffix(target.String(), "/"+action)
+}
+
+// outcomeOf classifies the error of a forwarded request.
+func outcomeOf(err error) deliveryOutcome {
+	if err == nil {
+		return deliverySuccess
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return deliveryTimeout
+	}
+	return deliveryError
+}
+
+// bucketStart returns the start of the bucket holding t in Unix seconds.
+func (s *deliveryStats) bucketStart(t time.Time) int64 {
+	size := int64(s.bucket / time.Second)
+	return t.Unix() / size * size
+}
+
+// RecordDelivery counts the outcome of a request forwarded to target for action.
+func (s *deliveryStats) RecordDelivery(target *url.URL, action string, err error) {
+	f := deliveryField{bucket: s.bucketStart(s.now()), field: string(outcomeOf(err)) + "|" + deliveryTarget(target, action)}
+	s.mu.Lock()
+	s.pending[f]++
+	s.mu.Unlock()
+}
+
+// key returns the Redis key of the hash of a bucket.
+func (s *deliveryStats) key(bucket int64) string {
+	return s.keyPrefix + strconv.FormatInt(bucket, 10)
+}
+
+// flush adds the pending counts to Redis. Counts that fail to be added are dropped.
+func (s *deliveryStats) flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = map[deliveryField]int64{}
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	// A bucket is kept until it has left the longest window.
+	ttl := s.windows[len(s.windows)-1] + s.bucket
+	_, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		buckets := map[int64]bool{}
+		for f, n := range pending {
+			p.HIncrBy(ctx, s.key(f.bucket), f.field, n)
+			buckets[f.bucket] = true
+		}
+		for b := range buckets {
+			p.Expire(ctx, s.key(b), ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "DeliveryStats: Failed to flush counts", "counts", len(pending), "error", err)
+		deliveryStatsVars.Add("flush_failed", 1)
+		deliveryStatsVars.Add("dropped", int64(len(pending)))
+		return
+	}
+	deliveryStatsVars.Add("flushed", 1)
+}
+
+// Report returns the delivery outcomes per target over each window, across all replicas.
+// The counts of this replica are flushed first.
+func (s *deliveryStats) Report(ctx context.Context) (*model.DeliveryStatsReport, error) {
+	s.flush(ctx)
+	at := s.now().UTC()
+	current := s.bucketStart(at)
+	step := int64(s.bucket / time.Second)
+	n := int(s.windows[len(s.windows)-1] / s.bucket)
+
+	cmds := make([]*redis.MapStringStringCmd, n)
+	if _, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i := range cmds {
+			cmds[i] = p.HGetAll(ctx, s.key(current-int64(i)*step))
+		}
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read delivery counts: %w", err)
+	}
+
+	counts := map[string][]model.DeliveryCounts{}
+	for i, cmd := range cmds {
+		for field, v := range cmd.Val() {
+			outcome, target, ok := strings.Cut(field, "|")
+			n, err := strconv.ParseInt(v, 10, 64)
+			if !ok || err != nil {
+				slog.WarnContext(ctx, "DeliveryStats: Skipping malformed count", "field", field, "value", v)
+				continue
+			}
+			windows, ok := counts[target]
+			if !ok {
+				windows = make([]model.DeliveryCounts, len(s.windows))
+				counts[target] = windows
+			}
+			for j, w := range s.windows {
+				if i >= int(w/s.bucket) {
+					continue
+				}
+				switch deliveryOutcome(outcome) {
+				case deliverySuccess:
+					windows[j].Success += n
+				case deliveryError:
+					windows[j].Error += n
+				case deliveryTimeout:
+					windows[j].Timeout += n
+				}
+			}
+		}
+	}
+
+	report := &model.DeliveryStatsReport{At: at, Targets: make([]model.TargetDeliveryStats, 0, len(counts))}
+	for target, windows := range counts {
+		for j := range windows {
+			windows[j].Window = s.windows[j].String()
+			if total := windows[j].Success + windows[j].Error + windows[j].Timeout; total > 0 {
+				windows[j].SuccessRate = float64(windows[j].Success) / float64(total)
+			}
+		}
+		report.Targets = append(report.Targets, model.TargetDeliveryStats{Target: target, Windows: windows})
+	}
+	slices.SortFunc(report.Targets, func(a, b model.TargetDeliveryStats) int { return strings.Compare(a.Target, b.Target) })
+	return report, nil
+}
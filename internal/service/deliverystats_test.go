@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDeliveryStatsConfig_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     DeliveryStatsConfig
+		want    DeliveryStatsConfig
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: DeliveryStatsConfig{Windows: []time.Duration{time.Hour, 24 * time.Hour}, Bucket: 5 * time.Minute, FlushInterval: 10 * time.Second, KeyPrefix: "gateway:delivery:"},
+		},
+		{
+			name: "windows sorted and deduplicated",
+			cfg:  DeliveryStatsConfig{Windows: []time.Duration{time.Hour, 10 * time.Minute, time.Hour}, Bucket: time.Minute, FlushInterval: time.Second, KeyPrefix: "d:"},
+			want: DeliveryStatsConfig{Windows: []time.Duration{10 * time.Minute, time.Hour}, Bucket: time.Minute, FlushInterval: time.Second, KeyPrefix: "d:"},
+		},
+		{name: "negative bucket", cfg: DeliveryStatsConfig{Bucket: -time.Minute}, wantErr: true},
+		{name: "negative flush interval", cfg: DeliveryStatsConfig{FlushInterval: -time.Second}, wantErr: true},
+		{name: "fractional bucket", cfg: DeliveryStatsConfig{Bucket: 1500 * time.Millisecond}, wantErr: true},
+		{name: "window not a multiple of bucket", cfg: DeliveryStatsConfig{Windows: []time.Duration{7 * time.Minute}}, wantErr: true},
+		{name: "too many buckets", cfg: DeliveryStatsConfig{Windows: []time.Duration{30 * 24 * time.Hour}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tt.want, tt.cfg); diff != "" {
+					t.Errorf("validate() config mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestNewDeliveryStats_Errors(t *testing.T) {
+	client, _ := redismock.NewClientMock()
+	if _, err := NewDeliveryStats(nil, &DeliveryStatsConfig{}); err == nil {
+		t.Error("NewDeliveryStats() with nil client error = nil, want error")
+	}
+	if _, err := NewDeliveryStats(client, nil); err == nil {
+		t.Error("NewDeliveryStats() with nil config error = nil, want error")
+	}
+	if _, err := NewDeliveryStats(client, &DeliveryStatsConfig{Bucket: -time.Second}); err == nil {
+		t.Error("NewDeliveryStats() with invalid config error = nil, want error")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestOutcomeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want deliveryOutcome
+	}{
+		{name: "success", want: deliverySuccess},
+		{name: "NACK", err: errors.New("received NACK"), want: deliveryError},
+		{name: "deadline", err: &url.Error{Op: "Post", URL: "https://bpp.example.com", Err: context.DeadlineExceeded}, want: deliveryTimeout},
+		{name: "network timeout", err: &url.Error{Op: "Post", URL: "https://bpp.example.com", Err: timeoutError{}}, want: deliveryTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outcomeOf(tt.err); got != tt.want {
+				t.Errorf("outcomeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// testDeliveryStats returns delivery stats with 5 minute buckets and 10 and 20 minute windows,
+// whose clock is 10 seconds into the bucket starting at 300000.
+func testDeliveryStats(t *testing.T) (*deliveryStats, redismock.ClientMock) {
+	t.Helper()
+	client, mock := redismock.NewClientMock()
+	d, err := NewDeliveryStats(client, &DeliveryStatsConfig{Windows: []time.Duration{10 * time.Minute, 20 * time.Minute}, KeyPrefix: "d:"})
+	if err != nil {
+		t.Fatalf("NewDeliveryStats() error = %v", err)
+	}
+	d.now = func() time.Time { return time.Unix(300010, 0) }
+	return d, mock
+}
+
+func TestDeliveryStats_Flush(t *testing.T) {
+	d, mock := testDeliveryStats(t)
+	mock.MatchExpectationsInOrder(false)
+	target, _ := url.Parse("https://bpp.example.com/beckn/search")
+	d.RecordDelivery(target, "search", nil)
+	d.RecordDelivery(target, "search", nil)
+	d.RecordDelivery(target, "search", errors.New("received NACK"))
+
+	mock.ExpectHIncrBy("d:300000", "success|https://bpp.example.com/beckn", 2).SetVal(2)
+	mock.ExpectHIncrBy("d:300000", "error|https://bpp.example.com/beckn", 1).SetVal(1)
+	mock.ExpectExpire("d:300000", 25*time.Minute).SetVal(true)
+	d.Stop()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet redis expectations: %v", err)
+	}
+	if len(d.pending) != 0 {
+		t.Errorf("pending counts = %d after flush, want 0", len(d.pending))
+	}
+}
+
+func TestDeliveryStats_Report(t *testing.T) {
+	d, mock := testDeliveryStats(t)
+	mock.ExpectHGetAll("d:300000").SetVal(map[string]string{
+		"success|https://bpp1.example.com": "3",
+		"timeout|https://bpp1.example.com": "1",
+	})
+	mock.ExpectHGetAll("d:299700").SetVal(map[string]string{
+		"error|https://bpp1.example.com": "2",
+		"malformed":                      "1",
+	})
+	mock.ExpectHGetAll("d:299400").SetVal(map[string]string{
+		"success|https://bpp1.example.com": "4",
+		"success|https://bpp2.example.com": "5",
+	})
+	mock.ExpectHGetAll("d:299100").SetVal(map[string]string{})
+
+	got, err := d.Report(context.Background())
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	want := &model.DeliveryStatsReport{
+		At: time.Unix(300010, 0).UTC(),
+		Targets: []model.TargetDeliveryStats{
+			{Target: "https://bpp1.example.com", Windows: []model.DeliveryCounts{
+				{Window: "10m0s", Success: 3, Error: 2, Timeout: 1, SuccessRate: 0.5},
+				{Window: "20m0s", Success: 7, Error: 2, Timeout: 1, SuccessRate: 0.7},
+			}},
+			{Target: "https://bpp2.example.com", Windows: []model.DeliveryCounts{
+				{Window: "10m0s"},
+				{Window: "20m0s", Success: 5, SuccessRate: 1},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Report() mismatch (-want +got):\n%s", diff)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet redis expectations: %v", err)
+	}
+}
+
+func TestDeliveryStats_Report_Error(t *testing.T) {
+	d, mock := testDeliveryStats(t)
+	mock.ExpectHGetAll("d:300000").SetErr(errors.New("connection refused"))
+
+	if _, err := d.Report(context.Background()); err == nil {
+		t.Error("Report() error = nil, want error")
+	}
+}
+
+type mockDeliveryRecorder struct {
+	target *url.URL
+	action string
+	err    error
+	calls  int
+}
+
+func (m *mockDeliveryRecorder) RecordDelivery(target *url.URL, action string, err error) {
+	m.target, m.action, m.err = target, action, err
+	m.calls++
+}
+
+func TestProxyTaskProcessor_Process_DeliveryStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"ack":{"status":"NACK"}}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewProxyTaskProcessor(&mockAuthGen{authHeader: "Signature test"}, "test-key-id", RetryConfig{})
+	if err != nil {
+		t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+	}
+	rec := &mockDeliveryRecorder{}
+	p.SetDeliveryStats(rec)
+	target, _ := url.Parse(srv.URL + "/on_search")
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target, Body: []byte(`{}`), Headers: http.Header{}, Context: *txnContext("on_search", "ONDC:RET10", "std:080")}
+	if err := p.Process(context.Background(), task); err == nil {
+		t.Fatal("Process() error = nil, want NACK error")
+	}
+
+	if rec.calls != 1 || rec.target != target || rec.action != "on_search" || rec.err == nil {
+		t.Errorf("RecordDelivery() got %d calls with (%v, %q, %v), want 1 call with (%v, %q, NACK error)", rec.calls, rec.target, rec.action, rec.err, target, "on_search")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultDenylistRefreshInterval = 30 * time.Second
+	// denylistCacheKey is the Redis key the admin service publishes the denylist to for the gateway.
+	denylistCacheKey = "onix:denylist"
+)
+
+// denylistMetrics publishes denylist hits on the expvar endpoint (/debug/vars).
+var denylistMetrics = expvar.NewMap("denylist")
+
+// DenylistConfig configures the enforcement of the denylist.
+type DenylistConfig struct {
+	// RefreshInterval is how long the denylist is cached before it is read again. Defaults to 30s.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+}
+
+// denylistSource provides the current denylist entries.
+type denylistSource interface {
+	ListDenylistEntries(ctx context.Context) ([]model.DenylistEntry, error)
+}
+
+// denylistCache is the cache the admin service publishes the denylist to.
+type denylistCache interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// cachedDenylistSource reads the denylist published by the admin service from a cache,
+// for services without access to the registry database.
+type cachedDenylistSource struct {
+	cache denylistCache
+}
+
+// NewCachedDenylistSource creates a new cachedDenylistSource.
+func NewCachedDenylistSource(cache denylistCache) (*cachedDenylistSource, error) {
+	if cache == nil {
+		slog.Error("NewCachedDenylistSource: denylistCache cannot be nil")
+		return nil, errors.New("denylistCache cannot be nil")
+	}
+	return &cachedDenylistSource{cache: cache}, nil
+}
+
+// ListDenylistEntries returns the published denylist. It is empty if none was published yet.
+func (s *cachedDenylistSource) ListDenylistEntries(ctx context.Context) ([]model.DenylistEntry, error) {
+	v, err := s.cache.Get(ctx, denylistCacheKey)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read denylist from cache: %w", err)
+	}
+	var entries []model.DenylistEntry
+	if err := json.Unmarshal([]byte(v), &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal denylist: %w", err)
+	}
+	return entries, nil
+}
+
+// denylistSnapshot indexes denylist entries for matching.
+type denylistSnapshot struct {
+	prefixes    []denylistPrefix
+	subscribers map[string]model.DenylistEntry
+}
+
+// denylistPrefix is an IP entry and the range it matches.
+type denylistPrefix struct {
+	prefix netip.Prefix
+	entry  model.DenylistEntry
+}
+
+// newDenylistSnapshot indexes entries. Entries with a malformed value are skipped.
+func newDenylistSnapshot(entries []model.DenylistEntry) *denylistSnapshot {
+	s := &denylistSnapshot{subscribers: map[string]model.DenylistEntry{}}
+	for _, e := range entries {
+		switch e.Kind {
+		case model.DenylistKindIP:
+			prefix, err := parseDenylistIP(e.Value)
+			if err != nil {
+				slog.Warn("Denylist: Skipping entry with invalid IP", "entry_id", e.ID, "value", e.Value, "error", err)
+				continue
+			}
+			s.prefixes = append(s.prefixes, denylistPrefix{prefix: prefix, entry: e})
+		case model.DenylistKindSubscriber:
+			s.subscribers[e.Value] = e
+		default:
+			slog.Warn("Denylist: Skipping entry with unknown kind", "entry_id", e.ID, "kind", e.Kind)
+		}
+	}
+	return s
+}
+
+// parseDenylistIP parses an IP address or CIDR range into the range it matches.
+func parseDenylistIP(v string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(v); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(v)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()).Masked(), nil
+}
+
+// denylist decides whether a request comes from a denylisted subscriber or IP.
+// Entries are read from a denylistSource and cached for the refresh interval.
+type denylist struct {
+	src     denylistSource
+	refresh time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	snapshot  *denylistSnapshot
+	fetchedAt time.Time
+}
+
+// NewDenylist creates a new denylist.
+func NewDenylist(src denylistSource, cfg *DenylistConfig) (*denylist, error) {
+	if src == nil {
+		slog.Error("NewDenylist: denylistSource cannot be nil")
+		return nil, errors.New("denylistSource cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewDenylist: DenylistConfig cannot be nil")
+		return nil, errors.New("DenylistConfig cannot be nil")
+	}
+	d := &denylist{src: src, refresh: cfg.RefreshInterval, now: time.Now}
+	if d.refresh <= 0 {
+		d.refresh = defaultDenylistRefreshInterval
+	}
+	return d, nil
+}
+
+// Check returns the entry matching the client IP or the subscriber in the Authorization
+// header of a request, or nil if the request is allowed. The Authorization header is not
+// validated, so Check can run before signature validation.
+func (d *denylist) Check(ctx context.Context, remoteAddr, authHeader string) *model.DenylistEntry {
+	s := d.current(ctx)
+	now := d.now()
+	if addr, ok := parseRemoteAddr(remoteAddr); ok {
+		for _, p := range s.prefixes {
+			if p.prefix.Contains(addr) && !denylistEntryExpired(&p.entry, now) {
+				denylistMetrics.Add("hits_ip", 1)
+				e := p.entry
+				return &e
+			}
+		}
+	}
+	if authHeader == "" {
+		return nil
+	}
+	h, err := parseAuthHeader(authHeader)
+	if err != nil {
+		return nil
+	}
+	if e, ok := s.subscribers[h.SubscriberID]; ok && !denylistEntryExpired(&e, now) {
+		denylistMetrics.Add("hits_subscriber", 1)
+		return &e
+	}
+	return nil
+}
+
+// current returns the cached snapshot, reading the denylist again once it is stale.
+// If the denylist cannot be read, the last known snapshot is kept.
+func (d *denylist) current(ctx context.Context) *denylistSnapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.snapshot != nil && d.now().Sub(d.fetchedAt) < d.refresh {
+		return d.snapshot
+	}
+	entries, err := d.src.ListDenylistEntries(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Denylist: Failed to read denylist, using last known entries", "error", err)
+		denylistMetrics.Add("refresh_errors", 1)
+		if d.snapshot == nil {
+			return newDenylistSnapshot(nil)
+		}
+		return d.snapshot
+	}
+	d.snapshot = newDenylistSnapshot(entries)
+	d.fetchedAt = d.now()
+	n := new(expvar.Int)
+	n.Set(int64(len(entries)))
+	denylistMetrics.Set("entries", n)
+	return d.snapshot
+}
+
+// parseRemoteAddr parses the client IP of a request, with or without a port.
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(remoteAddr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// denylistEntryExpired reports whether an entry no longer applies at now.
+func denylistEntryExpired(e *model.DenylistEntry, now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/uuid"
+)
+
+// Denylist management errors.
+var (
+	ErrInvalidDenylistEntry = errors.New("invalid denylist entry")
+	ErrDenylistEntryExists  = errors.New("denylist entry already exists")
+)
+
+// denylistRepository defines the repository operations needed to manage the denylist.
+type denylistRepository interface {
+	InsertDenylistEntry(ctx context.Context, e *model.DenylistEntry) (*model.DenylistEntry, error)
+	ListDenylistEntries(ctx context.Context) ([]model.DenylistEntry, error)
+	DeleteDenylistEntry(ctx context.Context, id string) error
+}
+
+// denylistPublisher is the cache the denylist is published to for the gateway.
+type denylistPublisher interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// denylistManager adds and removes denylist entries. The registry reads entries from
+// the database; the gateway reads the copy published to the cache after every change.
+type denylistManager struct {
+	repo      denylistRepository
+	publisher denylistPublisher
+	now       func() time.Time
+}
+
+// NewDenylistManager creates a new denylistManager.
+func NewDenylistManager(repo denylistRepository) (*denylistManager, error) {
+	if repo == nil {
+		slog.Error("NewDenylistManager: denylistRepository cannot be nil")
+		return nil, errors.New("denylistRepository cannot be nil")
+	}
+	return &denylistManager{repo: repo, now: time.Now}, nil
+}
+
+// SetPublisher sets the cache the denylist is published to for the gateway.
+func (m *denylistManager) SetPublisher(p denylistPublisher) {
+	m.publisher = p
+}
+
+// Add adds an entry to the denylist.
+func (m *denylistManager) Add(ctx context.Context, req *model.DenylistEntryRequest) (*model.DenylistEntry, error) {
+	e := &model.DenylistEntry{ID: uuid.NewString(), Kind: req.Kind, Value: req.Value, Reason: req.Reason, ExpiresAt: req.ExpiresAt}
+	switch req.Kind {
+	case model.DenylistKindIP:
+		prefix, err := parseDenylistIP(req.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value %q is not an IP address or CIDR range", ErrInvalidDenylistEntry, req.Value)
+		}
+		if prefix.IsSingleIP() {
+			e.Value = prefix.Addr().String()
+		} else {
+			e.Value = prefix.String()
+		}
+	case model.DenylistKindSubscriber:
+		if req.Value == "" {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidDenylistEntry)
+		}
+	default:
+		return nil, fmt.Errorf("%w: kind must be one of %s, %s", ErrInvalidDenylistEntry, model.DenylistKindIP, model.DenylistKindSubscriber)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(m.now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidDenylistEntry)
+	}
+
+	entries, err := m.repo.ListDenylistEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list denylist entries: %w", err)
+	}
+	for _, existing := range entries {
+		if existing.Kind == e.Kind && existing.Value == e.Value {
+			return nil, fmt.Errorf("%w: %s %s", ErrDenylistEntryExists, e.Kind, e.Value)
+		}
+	}
+	added, err := m.repo.InsertDenylistEntry(ctx, e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add denylist entry: %w", err)
+	}
+	slog.InfoContext(ctx, "DenylistManager: Entry added", "entry_id", added.ID, "kind", added.Kind, "value", added.Value)
+	m.publish(ctx)
+	return added, nil
+}
+
+// List returns the denylist entries that have not expired.
+func (m *denylistManager) List(ctx context.Context) ([]model.DenylistEntry, error) {
+	entries, err := m.repo.ListDenylistEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list denylist entries: %w", err)
+	}
+	return entries, nil
+}
+
+// Remove removes an entry from the denylist.
+func (m *denylistManager) Remove(ctx context.Context, id string) error {
+	if err := m.repo.DeleteDenylistEntry(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove denylist entry %s: %w", id, err)
+	}
+	slog.InfoContext(ctx, "DenylistManager: Entry removed", "entry_id", id)
+	m.publish(ctx)
+	return nil
+}
+
+// Publish publishes the current denylist to the cache, if one is set.
+// It is called after every change and should be called on startup.
+func (m *denylistManager) Publish(ctx context.Context) error {
+	if m.publisher == nil {
+		return nil
+	}
+	entries, err := m.repo.ListDenylistEntries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list denylist entries: %w", err)
+	}
+	if entries == nil {
+		entries = []model.DenylistEntry{}
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal denylist: %w", err)
+	}
+	if err := m.publisher.Set(ctx, denylistCacheKey, string(b), 0); err != nil {
+		return fmt.Errorf("failed to publish denylist: %w", err)
+	}
+	return nil
+}
+
+// publish publishes the denylist after a change. A failure is logged rather than returned,
+// as the change itself succeeded and is published again with the next change.
+func (m *denylistManager) publish(ctx context.Context) {
+	if err := m.Publish(ctx); err != nil {
+		slog.ErrorContext(ctx, "DenylistManager: Failed to publish denylist", "error", err)
+	}
+}
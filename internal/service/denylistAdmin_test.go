@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// mockDenylistRepo is a mock for denylistRepository.
+type mockDenylistRepo struct {
+	entries   []model.DenylistEntry
+	listErr   error
+	insertErr error
+	deleteErr error
+}
+
+func (m *mockDenylistRepo) InsertDenylistEntry(ctx context.Context, e *model.DenylistEntry) (*model.DenylistEntry, error) {
+	if m.insertErr != nil {
+		return nil, m.insertErr
+	}
+	e.CreatedAt = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	m.entries = append(m.entries, *e)
+	return e, nil
+}
+
+func (m *mockDenylistRepo) ListDenylistEntries(ctx context.Context) ([]model.DenylistEntry, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	return m.entries, nil
+}
+
+func (m *mockDenylistRepo) DeleteDenylistEntry(ctx context.Context, id string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	for i, e := range m.entries {
+		if e.ID == id {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func TestNewDenylistManager_Error(t *testing.T) {
+	if _, err := NewDenylistManager(nil); err == nil {
+		t.Error("NewDenylistManager() expected error, got nil")
+	}
+}
+
+func TestDenylistManager_Add(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	tests := []struct {
+		name string
+		req  *model.DenylistEntryRequest
+		want *model.DenylistEntry
+	}{
+		{
+			name: "ip",
+			req:  &model.DenylistEntryRequest{Kind: model.DenylistKindIP, Value: "192.0.2.7", Reason: "abuse"},
+			want: &model.DenylistEntry{Kind: model.DenylistKindIP, Value: "192.0.2.7", Reason: "abuse"},
+		},
+		{
+			name: "cidr is normalized",
+			req:  &model.DenylistEntryRequest{Kind: model.DenylistKindIP, Value: "10.1.2.3/16"},
+			want: &model.DenylistEntry{Kind: model.DenylistKindIP, Value: "10.1.0.0/16"},
+		},
+		{
+			name: "subscriber with expiry",
+			req:  &model.DenylistEntryRequest{Kind: model.DenylistKindSubscriber, Value: "np1", ExpiresAt: &future},
+			want: &model.DenylistEntry{Kind: model.DenylistKindSubscriber, Value: "np1", ExpiresAt: &future},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockDenylistRepo{}
+			cache := &mockDenylistCache{}
+			m, _ := NewDenylistManager(repo)
+			m.SetPublisher(cache)
+			m.now = func() time.Time { return now }
+
+			got, err := m.Add(context.Background(), tc.req)
+			if err != nil {
+				t.Fatalf("Add() unexpected error: %v", err)
+			}
+			if got.ID == "" {
+				t.Error("Add() ID is empty")
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(model.DenylistEntry{}, "ID", "CreatedAt")); diff != "" {
+				t.Errorf("Add() mismatch (-want +got):\n%s", diff)
+			}
+			var published []model.DenylistEntry
+			if err := json.Unmarshal([]byte(cache.values[denylistCacheKey]), &published); err != nil {
+				t.Fatalf("published denylist is not valid JSON: %v", err)
+			}
+			if len(published) != 1 || published[0].ID != got.ID {
+				t.Errorf("published denylist = %+v, want entry %s", published, got.ID)
+			}
+		})
+	}
+}
+
+func TestDenylistManager_Add_Error(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	tests := []struct {
+		name    string
+		repo    *mockDenylistRepo
+		req     *model.DenylistEntryRequest
+		wantErr error
+	}{
+		{
+			name:    "invalid kind",
+			repo:    &mockDenylistRepo{},
+			req:     &model.DenylistEntryRequest{Kind: "DOMAIN", Value: "example.com"},
+			wantErr: ErrInvalidDenylistEntry,
+		},
+		{
+			name:    "invalid ip",
+			repo:    &mockDenylistRepo{},
+			req:     &model.DenylistEntryRequest{Kind: model.DenylistKindIP, Value: "10.0.0.300"},
+			wantErr: ErrInvalidDenylistEntry,
+		},
+		{
+			name:    "empty subscriber",
+			repo:    &mockDenylistRepo{},
+			req:     &model.DenylistEntryRequest{Kind: model.DenylistKindSubscriber},
+			wantErr: ErrInvalidDenylistEntry,
+		},
+		{
+			name:    "expiry in the past",
+			repo:    &mockDenylistRepo{},
+			req:     &model.DenylistEntryRequest{Kind: model.DenylistKindSubscriber, Value: "np1", ExpiresAt: &past},
+			wantErr: ErrInvalidDenylistEntry,
+		},
+		{
+			name:    "duplicate",
+			repo:    &mockDenylistRepo{entries: []model.DenylistEntry{{ID: "e1", Kind: model.DenylistKindIP, Value: "10.1.0.0/16"}}},
+			req:     &model.DenylistEntryRequest{Kind: model.DenylistKindIP, Value: "10.1.0.0/16"},
+			wantErr: ErrDenylistEntryExists,
+		},
+		{
+			name: "insert fails",
+			repo: &mockDenylistRepo{insertErr: errors.New("db down")},
+			req:  &model.DenylistEntryRequest{Kind: model.DenylistKindSubscriber, Value: "np1"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, _ := NewDenylistManager(tc.repo)
+			m.now = func() time.Time { return now }
+
+			_, err := m.Add(context.Background(), tc.req)
+			if err == nil {
+				t.Fatal("Add() expected error, got nil")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("Add() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDenylistManager_Add_PublishFails(t *testing.T) {
+	m, _ := NewDenylistManager(&mockDenylistRepo{})
+	m.SetPublisher(&mockDenylistCache{setErr: errors.New("connection refused")})
+
+	if _, err := m.Add(context.Background(), &model.DenylistEntryRequest{Kind: model.DenylistKindSubscriber, Value: "np1"}); err != nil {
+		t.Errorf("Add() unexpected error: %v", err)
+	}
+}
+
+func TestDenylistManager_Remove(t *testing.T) {
+	repo := &mockDenylistRepo{entries: []model.DenylistEntry{{ID: "e1", Kind: model.DenylistKindSubscriber, Value: "np1"}}}
+	cache := &mockDenylistCache{}
+	m, _ := NewDenylistManager(repo)
+	m.SetPublisher(cache)
+
+	if err := m.Remove(context.Background(), "e1"); err != nil {
+		t.Fatalf("Remove() unexpected error: %v", err)
+	}
+	if got := cache.values[denylistCacheKey]; got != "[]" {
+		t.Errorf("published denylist = %s, want []", got)
+	}
+	if err := m.Remove(context.Background(), "e1"); err == nil {
+		t.Error("Remove() of removed entry expected error, got nil")
+	}
+}
+
+func TestDenylistManager_List(t *testing.T) {
+	entries := []model.DenylistEntry{{ID: "e1", Kind: model.DenylistKindSubscriber, Value: "np1"}}
+	m, _ := NewDenylistManager(&mockDenylistRepo{entries: entries})
+	got, err := m.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(entries, got); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	m, _ = NewDenylistManager(&mockDenylistRepo{listErr: errors.New("db down")})
+	if _, err := m.List(context.Background()); err == nil {
+		t.Error("List() expected error, got nil")
+	}
+}
+
+func TestDenylistManager_Publish(t *testing.T) {
+	m, _ := NewDenylistManager(&mockDenylistRepo{})
+	if err := m.Publish(context.Background()); err != nil {
+		t.Errorf("Publish() without publisher unexpected error: %v", err)
+	}
+
+	m.SetPublisher(&mockDenylistCache{setErr: errors.New("connection refused")})
+	if err := m.Publish(context.Background()); err == nil {
+		t.Error("Publish() expected error, got nil")
+	}
+
+	m, _ = NewDenylistManager(&mockDenylistRepo{listErr: errors.New("db down")})
+	m.SetPublisher(&mockDenylistCache{})
+	if err := m.Publish(context.Background()); err == nil {
+		t.Error("Publish() expected error, got nil")
+	}
+}
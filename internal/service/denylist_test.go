@@ -0,0 +1,267 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// mockDenylistSource is a mock for denylistSource.
+type mockDenylistSource struct {
+	entries []model.DenylistEntry
+	err     error
+	lists   int
+}
+
+func (m *mockDenylistSource) ListDenylistEntries(ctx context.Context) ([]model.DenylistEntry, error) {
+	m.lists++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.entries, nil
+}
+
+// mockDenylistCache is a mock for denylistCache and denylistPublisher.
+type mockDenylistCache struct {
+	values map[string]string
+	getErr error
+	setErr error
+}
+
+func (m *mockDenylistCache) Get(ctx context.Context, key string) (string, error) {
+	if m.getErr != nil {
+		return "", m.getErr
+	}
+	v, ok := m.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return v, nil
+}
+
+func (m *mockDenylistCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if m.setErr != nil {
+		return m.setErr
+	}
+	if m.values == nil {
+		m.values = map[string]string{}
+	}
+	m.values[key] = value
+	return nil
+}
+
+const denylistTestAuthHeader = `Signature keyId="np1|key-1|ed25519",algorithm="ed25519",signature="sig"`
+
+func TestNewDenylist(t *testing.T) {
+	d, err := NewDenylist(&mockDenylistSource{}, &DenylistConfig{})
+	if err != nil {
+		t.Fatalf("NewDenylist() unexpected error: %v", err)
+	}
+	if d.refresh != defaultDenylistRefreshInterval {
+		t.Errorf("NewDenylist() refresh = %v, want %v", d.refresh, defaultDenylistRefreshInterval)
+	}
+}
+
+func TestNewDenylist_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		src  denylistSource
+		cfg  *DenylistConfig
+	}{
+		{name: "nil source", cfg: &DenylistConfig{}},
+		{name: "nil config", src: &mockDenylistSource{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewDenylist(tc.src, tc.cfg); err == nil {
+				t.Error("NewDenylist() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDenylist_Check(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	entries := []model.DenylistEntry{
+		{ID: "range", Kind: model.DenylistKindIP, Value: "10.1.0.0/16"},
+		{ID: "single", Kind: model.DenylistKindIP, Value: "192.0.2.7"},
+		{ID: "v6", Kind: model.DenylistKindIP, Value: "2001:db8::/32"},
+		{ID: "expired-ip", Kind: model.DenylistKindIP, Value: "198.51.100.1", ExpiresAt: &past},
+		{ID: "malformed", Kind: model.DenylistKindIP, Value: "not-an-ip"},
+		{ID: "np1", Kind: model.DenylistKindSubscriber, Value: "np1", ExpiresAt: &future},
+		{ID: "expired-np", Kind: model.DenylistKindSubscriber, Value: "np2", ExpiresAt: &past},
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		authHeader string
+		wantID     string
+	}{
+		{name: "ip in range", remoteAddr: "10.1.2.3", wantID: "range"},
+		{name: "ip with port", remoteAddr: "10.1.2.3:4567", wantID: "range"},
+		{name: "single ip", remoteAddr: "192.0.2.7", wantID: "single"},
+		{name: "ipv4-mapped ipv6", remoteAddr: "[::ffff:192.0.2.7]:80", wantID: "single"},
+		{name: "ipv6 in range", remoteAddr: "[2001:db8::1]:443", wantID: "v6"},
+		{name: "expired ip", remoteAddr: "198.51.100.1"},
+		{name: "allowed ip", remoteAddr: "10.2.0.1"},
+		{name: "unparsable ip", remoteAddr: "unknown"},
+		{name: "subscriber", remoteAddr: "10.2.0.1", authHeader: denylistTestAuthHeader, wantID: "np1"},
+		{name: "expired subscriber", remoteAddr: "10.2.0.1", authHeader: `Signature keyId="np2|key-1|ed25519"`},
+		{name: "allowed subscriber", remoteAddr: "10.2.0.1", authHeader: `Signature keyId="np3|key-1|ed25519"`},
+		{name: "malformed auth header", remoteAddr: "10.2.0.1", authHeader: "Bearer token"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, _ := NewDenylist(&mockDenylistSource{entries: entries}, &DenylistConfig{})
+			d.now = func() time.Time { return now }
+
+			got := d.Check(context.Background(), tc.remoteAddr, tc.authHeader)
+			gotID := ""
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tc.wantID {
+				t.Errorf("Check(%q, %q) = %q, want %q", tc.remoteAddr, tc.authHeader, gotID, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestDenylist_Check_Metrics(t *testing.T) {
+	d, _ := NewDenylist(&mockDenylistSource{entries: []model.DenylistEntry{
+		{ID: "ip", Kind: model.DenylistKindIP, Value: "192.0.2.7"},
+		{ID: "np1", Kind: model.DenylistKindSubscriber, Value: "np1"},
+	}}, &DenylistConfig{})
+	hits := func(key string) int64 {
+		if v, ok := denylistMetrics.Get(key).(interface{ Value() int64 }); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	ipHits, subscriberHits := hits("hits_ip"), hits("hits_subscriber")
+
+	d.Check(context.Background(), "192.0.2.7", "")
+	d.Check(context.Background(), "10.0.0.1", denylistTestAuthHeader)
+	d.Check(context.Background(), "10.0.0.1", "")
+
+	if got := hits("hits_ip") - ipHits; got != 1 {
+		t.Errorf("hits_ip increased by %d, want 1", got)
+	}
+	if got := hits("hits_subscriber") - subscriberHits; got != 1 {
+		t.Errorf("hits_subscriber increased by %d, want 1", got)
+	}
+	if got := hits("entries"); got != 2 {
+		t.Errorf("entries = %d, want 2", got)
+	}
+}
+
+func TestDenylist_Check_Refresh(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	src := &mockDenylistSource{}
+	d, _ := NewDenylist(src, &DenylistConfig{RefreshInterval: time.Minute})
+	d.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if got := d.Check(ctx, "192.0.2.7", ""); got != nil {
+		t.Fatalf("Check() = %+v, want nil", got)
+	}
+
+	// Entries added within the refresh interval are not seen yet.
+	src.entries = []model.DenylistEntry{{ID: "ip", Kind: model.DenylistKindIP, Value: "192.0.2.7"}}
+	if got := d.Check(ctx, "192.0.2.7", ""); got != nil {
+		t.Errorf("Check() within refresh interval = %+v, want nil", got)
+	}
+	if src.lists != 1 {
+		t.Errorf("ListDenylistEntries() called %d times, want 1", src.lists)
+	}
+
+	now = now.Add(time.Minute)
+	if got := d.Check(ctx, "192.0.2.7", ""); got == nil || got.ID != "ip" {
+		t.Errorf("Check() after refresh interval = %+v, want entry ip", got)
+	}
+
+	// A failed refresh keeps the last known entries.
+	now = now.Add(time.Minute)
+	src.err = errors.New("db down")
+	if got := d.Check(ctx, "192.0.2.7", ""); got == nil || got.ID != "ip" {
+		t.Errorf("Check() after failed refresh = %+v, want entry ip", got)
+	}
+}
+
+func TestDenylist_Check_SourceUnavailable(t *testing.T) {
+	d, _ := NewDenylist(&mockDenylistSource{err: errors.New("db down")}, &DenylistConfig{})
+	if got := d.Check(context.Background(), "192.0.2.7", denylistTestAuthHeader); got != nil {
+		t.Errorf("Check() = %+v, want nil", got)
+	}
+}
+
+func TestNewCachedDenylistSource_Error(t *testing.T) {
+	if _, err := NewCachedDenylistSource(nil); err == nil {
+		t.Error("NewCachedDenylistSource() expected error, got nil")
+	}
+}
+
+func TestCachedDenylistSource_ListDenylistEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   *mockDenylistCache
+		want    []model.DenylistEntry
+		wantErr bool
+	}{
+		{
+			name:  "published",
+			cache: &mockDenylistCache{values: map[string]string{denylistCacheKey: `[{"entry_id":"e1","kind":"SUBSCRIBER","value":"np1","created_at":"2025-06-01T00:00:00Z"}]`}},
+			want:  []model.DenylistEntry{{ID: "e1", Kind: model.DenylistKindSubscriber, Value: "np1", CreatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "not published",
+			cache: &mockDenylistCache{},
+		},
+		{
+			name:    "cache error",
+			cache:   &mockDenylistCache{getErr: errors.New("connection refused")},
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			cache:   &mockDenylistCache{values: map[string]string{denylistCacheKey: "{"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := NewCachedDenylistSource(tc.cache)
+			got, err := s.ListDenylistEntries(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ListDenylistEntries() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ListDenylistEntries() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/uuid"
+)
+
+// Digest frequencies.
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// Digest sections.
+const (
+	DigestSectionStuck    = "stuck"
+	DigestSectionFailures = "failures"
+	DigestSectionExpiring = "expiring"
+)
+
+const (
+	defaultDigestSLA            = 24 * time.Hour
+	defaultDigestExpiringWithin = 7 * 24 * time.Hour
+	defaultDigestMaxItems       = 50
+	defaultDigestTimeout        = 10 * time.Second
+	// digestCheckInterval is how often the job checks whether a digest is due.
+	digestCheckInterval = time.Minute
+)
+
+// digestSections are the sections a digest can include.
+var digestSections = []string{DigestSectionStuck, DigestSectionFailures, DigestSectionExpiring}
+
+// digestMetrics counts the digests sent and the digests that could not be delivered.
+var digestMetrics = expvar.NewMap("lro_digest")
+
+// DigestConfig configures the digests of stuck operations, failures and expiring subscriptions
+// sent to operators.
+type DigestConfig struct {
+	// SLA is how long a PENDING operation may go without an update before it is reported as stuck. Defaults to 24h.
+	SLA time.Duration `yaml:"sla"`
+	// ExpiringWithin is how far ahead subscriptions are reported as expiring. Defaults to 168h.
+	ExpiringWithin time.Duration `yaml:"expiringWithin"`
+	// MaxItems caps the number of entries of each section. Defaults to 50.
+	MaxItems int `yaml:"maxItems"`
+	// Timeout is the timeout of a delivery. Defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+	// Recipients receive the digest on their own schedule.
+	Recipients []DigestRecipient `yaml:"recipients"`
+}
+
+// DigestRecipient is an HTTP endpoint that receives the digest.
+type DigestRecipient struct {
+	// Name identifies the recipient in logs.
+	Name string `yaml:"name"`
+	// URL is the endpoint the digest is posted to.
+	URL string `yaml:"url"`
+	// Secret, if set, signs the digest as webhook requests are signed.
+	Secret string `yaml:"secret"`
+	// Frequency is "hourly" or "daily". Defaults to "daily".
+	Frequency string `yaml:"frequency"`
+	// Hour is the UTC hour, 0 to 23, at which a daily digest is sent.
+	Hour int `yaml:"hour"`
+	// Sections are the sections included in the digest. Empty means all.
+	Sections []string `yaml:"sections"`
+	// SkipEmpty skips digests with nothing to report.
+	SkipEmpty bool `yaml:"skipEmpty"`
+}
+
+// digestRepository defines the repository operations the digest is built from.
+type digestRepository interface {
+	ListStaleOperations(ctx context.Context, before time.Time, limit int) ([]model.LRO, error)
+	OperationFailureCounts(ctx context.Context, since time.Time, limit int) ([]model.LROFailureCount, error)
+	ListExpiringSubscriptions(ctx context.Context, before time.Time, limit int) ([]model.Subscription, error)
+}
+
+// digestRecipient is a recipient with its schedule resolved.
+type digestRecipient struct {
+	DigestRecipient
+	period   time.Duration
+	sections []string
+	next     time.Time
+}
+
+// digestJob periodically sends digests of the operations and subscriptions needing attention.
+type digestJob struct {
+	repo           digestRepository
+	client         httpClient
+	recipients     []*digestRecipient
+	sla            time.Duration
+	expiringWithin time.Duration
+	maxItems       int
+	now            func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewDigestJob creates a new digestJob. The first digest of each recipient is sent at its
+// first scheduled time after the job is created.
+func NewDigestJob(repo digestRepository, cfg *DigestConfig) (*digestJob, error) {
+	if repo == nil {
+		slog.Error("NewDigestJob: digestRepository cannot be nil")
+		return nil, errors.New("digestRepository cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewDigestJob: DigestConfig cannot be nil")
+		return nil, errors.New("DigestConfig cannot be nil")
+	}
+	if len(cfg.Recipients) == 0 {
+		return nil, errors.New("invalid digest config: at least one recipient is required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultDigestTimeout
+	}
+	j := &digestJob{
+		repo:           repo,
+		client:         &http.Client{Timeout: timeout},
+		sla:            cfg.SLA,
+		expiringWithin: cfg.ExpiringWithin,
+		maxItems:       cfg.MaxItems,
+		now:            time.Now,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	if j.sla <= 0 {
+		j.sla = defaultDigestSLA
+	}
+	if j.expiringWithin <= 0 {
+		j.expiringWithin = defaultDigestExpiringWithin
+	}
+	if j.maxItems <= 0 {
+		j.maxItems = defaultDigestMaxItems
+	}
+	now := j.now()
+	for i, rc := range cfg.Recipients {
+		r, err := newDigestRecipient(rc, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest config: recipient %d: %w", i, err)
+		}
+		j.recipients = append(j.recipients, r)
+	}
+	return j, nil
+}
+
+// newDigestRecipient validates a recipient and schedules its first digest after now.
+func newDigestRecipient(cfg DigestRecipient, now time.Time) (*digestRecipient, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url %q must be an absolute http or https URL", cfg.URL)
+	}
+	r := &digestRecipient{DigestRecipient: cfg, sections: cfg.Sections}
+	if r.Name == "" {
+		r.Name = u.Host
+	}
+	switch cfg.Frequency {
+	case DigestHourly:
+		r.period = time.Hour
+		r.next = now.Truncate(time.Hour).Add(time.Hour)
+	case DigestDaily, "":
+		if cfg.Hour < 0 || cfg.Hour > 23 {
+			return nil, fmt.Errorf("hour %d must be between 0 and 23", cfg.Hour)
+		}
+		r.Frequency = DigestDaily
+		r.period = 24 * time.Hour
+		now = now.UTC()
+		r.next = time.Date(now.Year(), now.Month(), now.Day(), cfg.Hour, 0, 0, 0, time.UTC)
+		if !r.next.After(now) {
+			r.next = r.next.Add(r.period)
+		}
+	default:
+		return nil, fmt.Errorf("frequency %q must be %q or %q", cfg.Frequency, DigestHourly, DigestDaily)
+	}
+	for _, s := range cfg.Sections {
+		if !slices.Contains(digestSections, s) {
+			return nil, fmt.Errorf("section %q must be one of %v", s, digestSections)
+		}
+	}
+	if len(r.sections) == 0 {
+		r.sections = digestSections
+	}
+	return r, nil
+}
+
+// Start launches the background digest loop. It returns immediately.
+func (j *digestJob) Start(ctx context.Context) {
+	slog.InfoContext(ctx, "DigestJob: Starting", "recipients", len(j.recipients), "sla", j.sla, "expiring_within", j.expiringWithin)
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.RunOnce(ctx)
+			case <-j.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the digest loop to exit. It is safe to call more than once.
+func (j *digestJob) Stop() {
+	j.stopOnce.Do(func() { close(j.stop) })
+}
+
+// RunOnce sends the digests that are due and returns how many were delivered. A digest that
+// cannot be built or delivered is logged and not sent again; the next one reports the same
+// stuck operations and expiring subscriptions.
+func (j *digestJob) RunOnce(ctx context.Context) int {
+	now := j.now()
+	sent := 0
+	for _, r := range j.recipients {
+		if now.Before(r.next) {
+			continue
+		}
+		for !r.next.After(now) {
+			r.next = r.next.Add(r.period)
+		}
+		digest, err := j.build(ctx, r, now)
+		if err != nil {
+			digestMetrics.Add("failed", 1)
+			slog.ErrorContext(ctx, "DigestJob: Failed to build digest", "recipient", r.Name, "error", err)
+			continue
+		}
+		if r.SkipEmpty && len(digest.StuckOperations) == 0 && len(digest.Failures) == 0 && len(digest.ExpiringSubscriptions) == 0 {
+			digestMetrics.Add("skipped", 1)
+			continue
+		}
+		if err := j.send(ctx, r, digest); err != nil {
+			digestMetrics.Add("failed", 1)
+			slog.ErrorContext(ctx, "DigestJob: Failed to deliver digest", "recipient", r.Name, "error", err)
+			continue
+		}
+		digestMetrics.Add("sent", 1)
+		slog.InfoContext(ctx, "DigestJob: Digest delivered", "recipient", r.Name, "stuck", len(digest.StuckOperations), "failures", len(digest.Failures), "expiring", len(digest.ExpiringSubscriptions))
+		sent++
+	}
+	return sent
+}
+
+// build builds the sections of the digest of a recipient.
+func (j *digestJob) build(ctx context.Context, r *digestRecipient, now time.Time) (*model.OperationsDigest, error) {
+	d := &model.OperationsDigest{GeneratedAt: now, Frequency: r.Frequency}
+	var err error
+	if slices.Contains(r.sections, DigestSectionStuck) {
+		if d.StuckOperations, err = j.repo.ListStaleOperations(ctx, now.Add(-j.sla), j.maxItems); err != nil {
+			return nil, fmt.Errorf("failed to list stuck operations: %w", err)
+		}
+	}
+	if slices.Contains(r.sections, DigestSectionFailures) {
+		d.FailuresSince = now.Add(-r.period)
+		if d.Failures, err = j.repo.OperationFailureCounts(ctx, d.FailuresSince, j.maxItems); err != nil {
+			return nil, fmt.Errorf("failed to count operation failures: %w", err)
+		}
+	}
+	if slices.Contains(r.sections, DigestSectionExpiring) {
+		d.ExpiringBefore = now.Add(j.expiringWithin)
+		if d.ExpiringSubscriptions, err = j.repo.ListExpiringSubscriptions(ctx, d.ExpiringBefore, j.maxItems); err != nil {
+			return nil, fmt.Errorf("failed to list expiring subscriptions: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// send posts a digest to a recipient, signed with its secret if it has one.
+func (j *digestJob) send(ctx context.Context, r *digestRecipient, d *model.OperationsDigest) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(j.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.WebhookEventHeader, model.OperationsDigestEvent)
+	req.Header.Set(model.WebhookDeliveryHeader, uuid.NewString())
+	req.Header.Set(model.WebhookTimestampHeader, timestamp)
+	if r.Secret != "" {
+		req.Header.Set(model.WebhookSignatureHeader, "sha256="+signWebhook(r.Secret, timestamp, body))
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockDigestRepo struct {
+	stale       []model.LRO
+	failures    []model.LROFailureCount
+	expiring    []model.Subscription
+	err         error
+	gotBefore   time.Time
+	gotSince    time.Time
+	gotExpiring time.Time
+	gotLimit    int
+}
+
+func (m *mockDigestRepo) ListStaleOperations(ctx context.Context, before time.Time, limit int) ([]model.LRO, error) {
+	m.gotBefore, m.gotLimit = before, limit
+	return m.stale, m.err
+}
+
+func (m *mockDigestRepo) OperationFailureCounts(ctx context.Context, since time.Time, limit int) ([]model.LROFailureCount, error) {
+	m.gotSince = since
+	return m.failures, m.err
+}
+
+func (m *mockDigestRepo) ListExpiringSubscriptions(ctx context.Context, before time.Time, limit int) ([]model.Subscription, error) {
+	m.gotExpiring = before
+	return m.expiring, m.err
+}
+
+// digestReceiver records the digests posted to it and answers with status.
+type digestReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (d *digestReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, r)
+	d.bodies = append(d.bodies, body)
+	if d.status != 0 {
+		w.WriteHeader(d.status)
+	}
+}
+
+func TestNewDigestJob(t *testing.T) {
+	job, err := NewDigestJob(&mockDigestRepo{}, &DigestConfig{Recipients: []DigestRecipient{{URL: "https://ops.example.com/digest"}}})
+	if err != nil {
+		t.Fatalf("NewDigestJob() error = %v", err)
+	}
+	if job.sla != defaultDigestSLA || job.expiringWithin != defaultDigestExpiringWithin || job.maxItems != defaultDigestMaxItems {
+		t.Errorf("NewDigestJob() defaults = %v, %v, %d, want %v, %v, %d", job.sla, job.expiringWithin, job.maxItems, defaultDigestSLA, defaultDigestExpiringWithin, defaultDigestMaxItems)
+	}
+	r := job.recipients[0]
+	if r.Name != "ops.example.com" || r.Frequency != DigestDaily || r.period != 24*time.Hour {
+		t.Errorf("NewDigestJob() recipient = %q, %q, %v, want ops.example.com, daily, 24h", r.Name, r.Frequency, r.period)
+	}
+	if diff := cmp.Diff(digestSections, r.sections); diff != "" {
+		t.Errorf("NewDigestJob() sections mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewDigestJob_Error(t *testing.T) {
+	recipient := func(f func(*DigestRecipient)) *DigestConfig {
+		r := DigestRecipient{URL: "https://ops.example.com/digest"}
+		f(&r)
+		return &DigestConfig{Recipients: []DigestRecipient{r}}
+	}
+	tests := []struct {
+		name    string
+		repo    digestRepository
+		cfg     *DigestConfig
+		wantErr string
+	}{
+		{name: "nil repo", cfg: &DigestConfig{}, wantErr: "digestRepository cannot be nil"},
+		{name: "nil config", repo: &mockDigestRepo{}, wantErr: "DigestConfig cannot be nil"},
+		{name: "no recipients", repo: &mockDigestRepo{}, cfg: &DigestConfig{}, wantErr: "at least one recipient is required"},
+		{name: "relative url", repo: &mockDigestRepo{}, cfg: recipient(func(r *DigestRecipient) { r.URL = "/digest" }), wantErr: "must be an absolute http or https URL"},
+		{name: "invalid frequency", repo: &mockDigestRepo{}, cfg: recipient(func(r *DigestRecipient) { r.Frequency = "weekly" }), wantErr: `frequency "weekly"`},
+		{name: "invalid hour", repo: &mockDigestRepo{}, cfg: recipient(func(r *DigestRecipient) { r.Hour = 24 }), wantErr: "hour 24"},
+		{name: "invalid section", repo: &mockDigestRepo{}, cfg: recipient(func(r *DigestRecipient) { r.Sections = []string{"keys"} }), wantErr: `section "keys"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewDigestJob(tc.repo, tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("NewDigestJob() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewDigestRecipient_Schedule(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		cfg  DigestRecipient
+		want time.Time
+	}{
+		{name: "hourly", cfg: DigestRecipient{Frequency: DigestHourly}, want: time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC)},
+		{name: "daily later today", cfg: DigestRecipient{Frequency: DigestDaily, Hour: 18}, want: time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC)},
+		{name: "daily tomorrow", cfg: DigestRecipient{Hour: 9}, want: time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.URL = "https://ops.example.com"
+			r, err := newDigestRecipient(tc.cfg, now)
+			if err != nil {
+				t.Fatalf("newDigestRecipient() error = %v", err)
+			}
+			if !r.next.Equal(tc.want) {
+				t.Errorf("newDigestRecipient() next = %v, want %v", r.next, tc.want)
+			}
+		})
+	}
+}
+
+func TestDigestJob_RunOnce(t *testing.T) {
+	now := time.Date(2025, 6, 1, 11, 0, 30, 0, time.UTC)
+	repo := &mockDigestRepo{
+		stale:    []model.LRO{{OperationID: "op-1", Status: model.LROStatusPending}},
+		failures: []model.LROFailureCount{{Code: "callback failed", Count: 2}},
+	}
+	recv := &digestReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	job, err := NewDigestJob(repo, &DigestConfig{
+		SLA:            time.Hour,
+		ExpiringWithin: 48 * time.Hour,
+		MaxItems:       10,
+		Recipients: []DigestRecipient{
+			{Name: "ops", URL: srv.URL, Secret: "s3cret", Frequency: DigestHourly},
+			{Name: "renewals", URL: srv.URL, Sections: []string{DigestSectionExpiring}, SkipEmpty: true},
+			{Name: "later", URL: srv.URL, Frequency: DigestHourly},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewDigestJob() error = %v", err)
+	}
+	job.now = func() time.Time { return now }
+	job.recipients[0].next = now.Add(-30 * time.Second)
+	job.recipients[1].next = now.Add(-time.Hour)
+	job.recipients[2].next = now.Add(time.Minute)
+
+	if got := job.RunOnce(context.Background()); got != 1 {
+		t.Fatalf("RunOnce() = %d, want 1", got)
+	}
+	if len(recv.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(recv.requests))
+	}
+	req := recv.requests[0]
+	if got := req.Header.Get(model.WebhookEventHeader); got != model.OperationsDigestEvent {
+		t.Errorf("%s = %q, want %q", model.WebhookEventHeader, got, model.OperationsDigestEvent)
+	}
+	wantSig := "sha256=" + signWebhook("s3cret", req.Header.Get(model.WebhookTimestampHeader), recv.bodies[0])
+	if got := req.Header.Get(model.WebhookSignatureHeader); got != wantSig {
+		t.Errorf("%s = %q, want %q", model.WebhookSignatureHeader, got, wantSig)
+	}
+	var got model.OperationsDigest
+	if err := json.Unmarshal(recv.bodies[0], &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := model.OperationsDigest{
+		GeneratedAt:     now,
+		Frequency:       DigestHourly,
+		StuckOperations: repo.stale,
+		FailuresSince:   now.Add(-time.Hour),
+		Failures:        repo.failures,
+		ExpiringBefore:  now.Add(48 * time.Hour),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("digest mismatch (-want +got):\n%s", diff)
+	}
+	if !repo.gotBefore.Equal(now.Add(-time.Hour)) || repo.gotLimit != 10 {
+		t.Errorf("ListStaleOperations() called with %v, %d, want %v, 10", repo.gotBefore, repo.gotLimit, now.Add(-time.Hour))
+	}
+
+	// Digests are rescheduled after they are sent or skipped.
+	if got := job.recipients[0].next; !got.Equal(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("hourly next = %v, want 12:00", got)
+	}
+	if got := job.recipients[1].next; !got.Equal(now.Add(23 * time.Hour)) {
+		t.Errorf("daily next = %v, want %v", got, now.Add(23*time.Hour))
+	}
+	if got := job.RunOnce(context.Background()); got != 0 {
+		t.Errorf("second RunOnce() = %d, want 0", got)
+	}
+}
+
+func TestDigestJob_RunOnce_Failure(t *testing.T) {
+	now := time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		repo   *mockDigestRepo
+		status int
+		want   int
+	}{
+		{name: "repository error", repo: &mockDigestRepo{err: errors.New("db error")}},
+		{name: "recipient error", repo: &mockDigestRepo{}, status: http.StatusInternalServerError},
+		{name: "delivered", repo: &mockDigestRepo{}, want: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recv := &digestReceiver{status: tc.status}
+			srv := httptest.NewServer(recv)
+			defer srv.Close()
+			job, err := NewDigestJob(tc.repo, &DigestConfig{Recipients: []DigestRecipient{{URL: srv.URL, Frequency: DigestHourly}}})
+			if err != nil {
+				t.Fatalf("NewDigestJob() error = %v", err)
+			}
+			job.now = func() time.Time { return now }
+			job.recipients[0].next = now
+
+			if got := job.RunOnce(context.Background()); got != tc.want {
+				t.Errorf("RunOnce() = %d, want %d", got, tc.want)
+			}
+			if got := job.recipients[0].next; !got.Equal(now.Add(time.Hour)) {
+				t.Errorf("next = %v, want %v", got, now.Add(time.Hour))
+			}
+		})
+	}
+}
+
+func TestDigestJob_StartStop(t *testing.T) {
+	job, err := NewDigestJob(&mockDigestRepo{}, &DigestConfig{Recipients: []DigestRecipient{{URL: "https://ops.example.com"}}})
+	if err != nil {
+		t.Fatalf("NewDigestJob() error = %v", err)
+	}
+	job.Start(context.Background())
+	job.Stop()
+	job.Stop()
+	select {
+	case <-job.done:
+	case <-time.After(time.Second):
+		t.Fatal("digest loop did not stop")
+	}
+}
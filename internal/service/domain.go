@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const defaultDomainRefreshInterval = 30 * time.Second
+
+// DomainCatalogConfig configures the domain catalog served by the registry.
+type DomainCatalogConfig struct {
+	// Enforce rejects subscription requests for domains that are not in the catalog,
+	// or without the location the domain requires.
+	Enforce bool `yaml:"enforce"`
+	// RefreshInterval is how long the catalog is cached before it is read again. Defaults to 30s.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+}
+
+// domainSource provides the domains of the catalog.
+type domainSource interface {
+	ListDomains(ctx context.Context) ([]model.Domain, error)
+}
+
+// domainCatalog serves the domain catalog with the metadata each domain inherits from
+// its ancestors, and validates subscription requests against it. Domains are read from
+// a domainSource and cached for the refresh interval.
+type domainCatalog struct {
+	src     domainSource
+	refresh time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	domains   []model.Domain
+	byName    map[string]model.Domain
+	fetchedAt time.Time
+}
+
+// NewDomainCatalog creates a new domainCatalog.
+func NewDomainCatalog(src domainSource, cfg *DomainCatalogConfig) (*domainCatalog, error) {
+	if src == nil {
+		slog.Error("NewDomainCatalog: domainSource cannot be nil")
+		return nil, errors.New("domainSource cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewDomainCatalog: DomainCatalogConfig cannot be nil")
+		return nil, errors.New("DomainCatalogConfig cannot be nil")
+	}
+	c := &domainCatalog{src: src, refresh: cfg.RefreshInterval, now: time.Now}
+	if c.refresh <= 0 {
+		c.refresh = defaultDomainRefreshInterval
+	}
+	return c, nil
+}
+
+// resolveDomains fills in the location granularity and schema version each domain
+// inherits from its closest ancestor that sets them.
+func resolveDomains(domains []model.Domain) ([]model.Domain, map[string]model.Domain) {
+	own := make(map[string]model.Domain, len(domains))
+	for _, d := range domains {
+		own[d.Name] = d
+	}
+	resolved := make([]model.Domain, 0, len(domains))
+	byName := make(map[string]model.Domain, len(domains))
+	for _, d := range domains {
+		seen := map[string]bool{d.Name: true}
+		for p := d.Parent; p != "" && !seen[p] && (d.LocationGranularity == "" || d.SchemaVersion == ""); p = own[p].Parent {
+			seen[p] = true
+			parent, ok := own[p]
+			if !ok {
+				break
+			}
+			if d.LocationGranularity == "" {
+				d.LocationGranularity = parent.LocationGranularity
+			}
+			if d.SchemaVersion == "" {
+				d.SchemaVersion = parent.SchemaVersion
+			}
+		}
+		resolved = append(resolved, d)
+		byName[d.Name] = d
+	}
+	return resolved, byName
+}
+
+// current returns the cached catalog, reading it again once it is stale. If the
+// catalog cannot be read, the last known catalog is kept.
+func (c *domainCatalog) current(ctx context.Context) ([]model.Domain, map[string]model.Domain, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.byName != nil && c.now().Sub(c.fetchedAt) < c.refresh {
+		return c.domains, c.byName, nil
+	}
+	domains, err := c.src.ListDomains(ctx)
+	if err != nil {
+		if c.byName == nil {
+			return nil, nil, fmt.Errorf("failed to read domain catalog: %w", err)
+		}
+		slog.WarnContext(ctx, "DomainCatalog: Failed to read domain catalog, using last known domains", "error", err)
+		return c.domains, c.byName, nil
+	}
+	c.domains, c.byName = resolveDomains(domains)
+	c.fetchedAt = c.now()
+	return c.domains, c.byName, nil
+}
+
+// List returns the domains of the catalog ordered by name, with inherited metadata.
+func (c *domainCatalog) List(ctx context.Context) ([]model.Domain, error) {
+	domains, _, err := c.current(ctx)
+	return domains, err
+}
+
+// Validate checks that the domain of a subscription request is in the catalog and that
+// the request declares the location the domain requires. Invalid requests are reported
+// as a *model.ValidationError.
+func (c *domainCatalog) Validate(ctx context.Context, req *model.SubscriptionRequest) error {
+	_, byName, err := c.current(ctx)
+	if err != nil {
+		return err
+	}
+	d, ok := byName[req.Domain]
+	if !ok {
+		return &model.ValidationError{Fields: []model.FieldError{{Field: "domain", Message: fmt.Sprintf("%s is not in the domain catalog", req.Domain)}}}
+	}
+	loc := req.Location
+	var field string
+	switch d.LocationGranularity {
+	case model.LocationGranularityCountry:
+		if loc == nil || loc.Country == nil || loc.Country.Code == "" {
+			field = "location.country.code"
+		}
+	case model.LocationGranularityState:
+		if loc == nil || loc.State == nil || loc.State.Code == "" {
+			field = "location.state.code"
+		}
+	case model.LocationGranularityCity:
+		if loc == nil || loc.City == nil || loc.City.Code == "" {
+			field = "location.city.code"
+		}
+	}
+	if field != "" {
+		return &model.ValidationError{Fields: []model.FieldError{{Field: field, Message: fmt.Sprintf("is required for domain %s", d.Name)}}}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// Domain catalog management errors.
+var (
+	ErrInvalidDomain     = errors.New("invalid domain")
+	ErrDomainExists      = errors.New("domain already exists")
+	ErrDomainHasChildren = errors.New("domain has child domains")
+)
+
+// domainRepository defines the repository operations needed to manage the domain catalog.
+type domainRepository interface {
+	InsertDomain(ctx context.Context, d *model.Domain) (*model.Domain, error)
+	UpdateDomain(ctx context.Context, d *model.Domain) (*model.Domain, error)
+	ListDomains(ctx context.Context) ([]model.Domain, error)
+	DeleteDomain(ctx context.Context, name string) error
+}
+
+// domainManager adds, updates and removes the domains of the catalog.
+type domainManager struct {
+	repo domainRepository
+}
+
+// NewDomainManager creates a new domainManager.
+func NewDomainManager(repo domainRepository) (*domainManager, error) {
+	if repo == nil {
+		slog.Error("NewDomainManager: domainRepository cannot be nil")
+		return nil, errors.New("domainRepository cannot be nil")
+	}
+	return &domainManager{repo: repo}, nil
+}
+
+// validateDomainRequest checks the fields of a request to add or update a domain.
+func validateDomainRequest(name string, req *model.DomainRequest) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidDomain)
+	}
+	if req.DisplayName == "" {
+		return fmt.Errorf("%w: display_name is required", ErrInvalidDomain)
+	}
+	if req.Parent == name {
+		return fmt.Errorf("%w: domain %s cannot be its own parent", ErrInvalidDomain, name)
+	}
+	switch req.LocationGranularity {
+	case "", model.LocationGranularityCountry, model.LocationGranularityState, model.LocationGranularityCity:
+	default:
+		return fmt.Errorf("%w: location_granularity must be one of %s, %s, %s", ErrInvalidDomain, model.LocationGranularityCountry, model.LocationGranularityState, model.LocationGranularityCity)
+	}
+	return nil
+}
+
+// checkParent checks that the parent of a domain is in the catalog and that the
+// domain is not one of its ancestors, which would make the hierarchy a cycle.
+func checkParent(name, parent string, byName map[string]model.Domain) error {
+	for p := parent; p != ""; p = byName[p].Parent {
+		if _, ok := byName[p]; !ok {
+			if p == parent {
+				return fmt.Errorf("%w: parent %s is not in the domain catalog", ErrInvalidDomain, parent)
+			}
+			return nil
+		}
+		if p == name {
+			return fmt.Errorf("%w: domain %s cannot be an ancestor of itself", ErrInvalidDomain, name)
+		}
+	}
+	return nil
+}
+
+// domainsByName lists the catalog, indexed by domain name.
+func (m *domainManager) domainsByName(ctx context.Context) (map[string]model.Domain, error) {
+	domains, err := m.repo.ListDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	byName := make(map[string]model.Domain, len(domains))
+	for _, d := range domains {
+		byName[d.Name] = d
+	}
+	return byName, nil
+}
+
+// Add adds a domain to the catalog. Its parent, if any, must already be in the catalog.
+func (m *domainManager) Add(ctx context.Context, req *model.DomainRequest) (*model.Domain, error) {
+	if err := validateDomainRequest(req.Name, req); err != nil {
+		return nil, err
+	}
+	byName, err := m.domainsByName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := byName[req.Name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrDomainExists, req.Name)
+	}
+	if err := checkParent(req.Name, req.Parent, byName); err != nil {
+		return nil, err
+	}
+	d := &model.Domain{Name: req.Name, Parent: req.Parent, DisplayName: req.DisplayName, LocationGranularity: req.LocationGranularity, SchemaVersion: req.SchemaVersion}
+	added, err := m.repo.InsertDomain(ctx, d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add domain: %w", err)
+	}
+	slog.InfoContext(ctx, "DomainManager: Domain added", "domain", added.Name, "parent", added.Parent)
+	return added, nil
+}
+
+// List returns the domains of the catalog, with their own metadata only.
+func (m *domainManager) List(ctx context.Context) ([]model.Domain, error) {
+	domains, err := m.repo.ListDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	return domains, nil
+}
+
+// Update replaces the parent and metadata of a domain.
+func (m *domainManager) Update(ctx context.Context, name string, req *model.DomainRequest) (*model.Domain, error) {
+	if req.Name != "" && req.Name != name {
+		return nil, fmt.Errorf("%w: a domain cannot be renamed", ErrInvalidDomain)
+	}
+	if err := validateDomainRequest(name, req); err != nil {
+		return nil, err
+	}
+	byName, err := m.domainsByName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := byName[name]; !ok {
+		return nil, fmt.Errorf("failed to update domain %s: %w", name, repository.ErrDomainNotFound)
+	}
+	if err := checkParent(name, req.Parent, byName); err != nil {
+		return nil, err
+	}
+	d := &model.Domain{Name: name, Parent: req.Parent, DisplayName: req.DisplayName, LocationGranularity: req.LocationGranularity, SchemaVersion: req.SchemaVersion}
+	updated, err := m.repo.UpdateDomain(ctx, d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update domain %s: %w", name, err)
+	}
+	slog.InfoContext(ctx, "DomainManager: Domain updated", "domain", updated.Name, "parent", updated.Parent)
+	return updated, nil
+}
+
+// Remove removes a domain from the catalog. Domains with child domains cannot be removed.
+// Existing subscriptions to the domain are kept.
+func (m *domainManager) Remove(ctx context.Context, name string) error {
+	byName, err := m.domainsByName(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range byName {
+		if d.Parent == name {
+			return fmt.Errorf("%w: %s is the parent of %s", ErrDomainHasChildren, name, d.Name)
+		}
+	}
+	if err := m.repo.DeleteDomain(ctx, name); err != nil {
+		return fmt.Errorf("failed to remove domain %s: %w", name, err)
+	}
+	slog.InfoContext(ctx, "DomainManager: Domain removed", "domain", name)
+	return nil
+}
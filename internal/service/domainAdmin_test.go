@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockDomainRepo is a mock for domainRepository.
+type mockDomainRepo struct {
+	domains   []model.Domain
+	listErr   error
+	insertErr error
+	updateErr error
+	deleteErr error
+	deleted   []string
+}
+
+func (m *mockDomainRepo) InsertDomain(ctx context.Context, d *model.Domain) (*model.Domain, error) {
+	if m.insertErr != nil {
+		return nil, m.insertErr
+	}
+	d.CreatedAt = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	d.UpdatedAt = d.CreatedAt
+	m.domains = append(m.domains, *d)
+	return d, nil
+}
+
+func (m *mockDomainRepo) UpdateDomain(ctx context.Context, d *model.Domain) (*model.Domain, error) {
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	d.UpdatedAt = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	return d, nil
+}
+
+func (m *mockDomainRepo) ListDomains(ctx context.Context) ([]model.Domain, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	return m.domains, nil
+}
+
+func (m *mockDomainRepo) DeleteDomain(ctx context.Context, name string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.deleted = append(m.deleted, name)
+	return nil
+}
+
+func TestNewDomainManager_Error(t *testing.T) {
+	if _, err := NewDomainManager(nil); err == nil {
+		t.Error("NewDomainManager() expected error, got nil")
+	}
+}
+
+func TestDomainManager_Add(t *testing.T) {
+	repo := &mockDomainRepo{domains: []model.Domain{{Name: "ONDC:RET", DisplayName: "Retail"}}}
+	m, _ := NewDomainManager(repo)
+
+	req := &model.DomainRequest{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0"}
+	got, err := m.Add(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	created := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	want := &model.Domain{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0", CreatedAt: created, UpdatedAt: created}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Add() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDomainManager_Add_Error(t *testing.T) {
+	existing := []model.Domain{{Name: "ONDC:RET", DisplayName: "Retail"}}
+	tests := []struct {
+		name    string
+		req     *model.DomainRequest
+		repo    *mockDomainRepo
+		wantErr error
+	}{
+		{name: "missing name", req: &model.DomainRequest{DisplayName: "Retail"}, repo: &mockDomainRepo{}, wantErr: ErrInvalidDomain},
+		{name: "missing display name", req: &model.DomainRequest{Name: "ONDC:RET"}, repo: &mockDomainRepo{}, wantErr: ErrInvalidDomain},
+		{name: "invalid granularity", req: &model.DomainRequest{Name: "ONDC:RET", DisplayName: "Retail", LocationGranularity: "STREET"}, repo: &mockDomainRepo{}, wantErr: ErrInvalidDomain},
+		{name: "own parent", req: &model.DomainRequest{Name: "ONDC:RET", Parent: "ONDC:RET", DisplayName: "Retail"}, repo: &mockDomainRepo{}, wantErr: ErrInvalidDomain},
+		{name: "unknown parent", req: &model.DomainRequest{Name: "ONDC:RET10", Parent: "ONDC:XYZ", DisplayName: "Grocery"}, repo: &mockDomainRepo{domains: existing}, wantErr: ErrInvalidDomain},
+		{name: "duplicate", req: &model.DomainRequest{Name: "ONDC:RET", DisplayName: "Retail"}, repo: &mockDomainRepo{domains: existing}, wantErr: ErrDomainExists},
+		{name: "list error", req: &model.DomainRequest{Name: "ONDC:RET", DisplayName: "Retail"}, repo: &mockDomainRepo{listErr: errors.New("db down")}},
+		{name: "insert error", req: &model.DomainRequest{Name: "ONDC:RET", DisplayName: "Retail"}, repo: &mockDomainRepo{insertErr: errors.New("db down")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := NewDomainManager(tt.repo)
+			_, err := m.Add(context.Background(), tt.req)
+			if err == nil {
+				t.Fatal("Add() expected error, got nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDomainManager_Update(t *testing.T) {
+	domains := []model.Domain{
+		{Name: "ONDC:RET", DisplayName: "Retail"},
+		{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery"},
+		{Name: "ONDC:RET10:X", Parent: "ONDC:RET10", DisplayName: "Organic"},
+	}
+	tests := []struct {
+		name    string
+		domain  string
+		req     *model.DomainRequest
+		wantErr error
+	}{
+		{name: "success", domain: "ONDC:RET10", req: &model.DomainRequest{Parent: "ONDC:RET", DisplayName: "Grocery", SchemaVersion: "1.2.5"}},
+		{name: "name matches path", domain: "ONDC:RET10", req: &model.DomainRequest{Name: "ONDC:RET10", DisplayName: "Grocery"}},
+		{name: "rename", domain: "ONDC:RET10", req: &model.DomainRequest{Name: "ONDC:RET11", DisplayName: "Grocery"}, wantErr: ErrInvalidDomain},
+		{name: "not found", domain: "ONDC:XYZ", req: &model.DomainRequest{DisplayName: "Unknown"}, wantErr: repository.ErrDomainNotFound},
+		{name: "descendant as parent", domain: "ONDC:RET", req: &model.DomainRequest{Parent: "ONDC:RET10:X", DisplayName: "Retail"}, wantErr: ErrInvalidDomain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := NewDomainManager(&mockDomainRepo{domains: domains})
+			got, err := m.Update(context.Background(), tt.domain, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Name != tt.domain || got.SchemaVersion != tt.req.SchemaVersion) {
+				t.Errorf("Update() = %+v, want domain %s with schema version %q", got, tt.domain, tt.req.SchemaVersion)
+			}
+		})
+	}
+}
+
+func TestDomainManager_Remove(t *testing.T) {
+	domains := []model.Domain{
+		{Name: "ONDC:RET", DisplayName: "Retail"},
+		{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery"},
+	}
+	tests := []struct {
+		name    string
+		domain  string
+		repo    *mockDomainRepo
+		wantErr error
+	}{
+		{name: "leaf domain", domain: "ONDC:RET10", repo: &mockDomainRepo{domains: domains}},
+		{name: "domain with children", domain: "ONDC:RET", repo: &mockDomainRepo{domains: domains}, wantErr: ErrDomainHasChildren},
+		{name: "not found", domain: "ONDC:XYZ", repo: &mockDomainRepo{domains: domains, deleteErr: repository.ErrDomainNotFound}, wantErr: repository.ErrDomainNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := NewDomainManager(tt.repo)
+			if err := m.Remove(context.Background(), tt.domain); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Remove() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !cmp.Equal(tt.repo.deleted, []string{tt.domain}) {
+				t.Errorf("DeleteDomain() calls = %v, want [%s]", tt.repo.deleted, tt.domain)
+			}
+		})
+	}
+}
+
+func TestDomainManager_List(t *testing.T) {
+	domains := []model.Domain{{Name: "ONDC:RET", DisplayName: "Retail"}, {Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery"}}
+	m, _ := NewDomainManager(&mockDomainRepo{domains: domains})
+	got, err := m.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	// The admin API lists the metadata of each domain as stored, without inheritance.
+	if diff := cmp.Diff(domains, got); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+}
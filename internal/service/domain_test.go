@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockDomainSource is a mock for domainSource.
+type mockDomainSource struct {
+	domains []model.Domain
+	err     error
+	calls   int
+}
+
+func (m *mockDomainSource) ListDomains(ctx context.Context) ([]model.Domain, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.domains, nil
+}
+
+var testDomains = []model.Domain{
+	{Name: "ONDC:RET", DisplayName: "Retail", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0"},
+	{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery"},
+	{Name: "ONDC:RET11", Parent: "ONDC:RET", DisplayName: "F&B", SchemaVersion: "1.2.5"},
+	{Name: "ONDC:RET11:X", Parent: "ONDC:RET11", DisplayName: "Bakeries"},
+	{Name: "ONDC:TRV", DisplayName: "Travel", LocationGranularity: model.LocationGranularityCountry},
+	{Name: "ONDC:FIS", DisplayName: "Financial Services"},
+}
+
+func TestNewDomainCatalog_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		src  domainSource
+		cfg  *DomainCatalogConfig
+	}{
+		{name: "nil source", cfg: &DomainCatalogConfig{}},
+		{name: "nil config", src: &mockDomainSource{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDomainCatalog(tt.src, tt.cfg); err == nil {
+				t.Error("NewDomainCatalog() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDomainCatalog_List(t *testing.T) {
+	c, _ := NewDomainCatalog(&mockDomainSource{domains: testDomains}, &DomainCatalogConfig{})
+	got, err := c.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []model.Domain{
+		{Name: "ONDC:RET", DisplayName: "Retail", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0"},
+		{Name: "ONDC:RET10", Parent: "ONDC:RET", DisplayName: "Grocery", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.0"},
+		{Name: "ONDC:RET11", Parent: "ONDC:RET", DisplayName: "F&B", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.5"},
+		{Name: "ONDC:RET11:X", Parent: "ONDC:RET11", DisplayName: "Bakeries", LocationGranularity: model.LocationGranularityCity, SchemaVersion: "1.2.5"},
+		{Name: "ONDC:TRV", DisplayName: "Travel", LocationGranularity: model.LocationGranularityCountry},
+		{Name: "ONDC:FIS", DisplayName: "Financial Services"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveDomains_Cycle(t *testing.T) {
+	// Cycles cannot be created through the admin API, but must not hang the registry.
+	domains := []model.Domain{
+		{Name: "a", Parent: "b"},
+		{Name: "b", Parent: "a", SchemaVersion: "1.0.0"},
+	}
+	got, _ := resolveDomains(domains)
+	if got[0].SchemaVersion != "1.0.0" || got[1].SchemaVersion != "1.0.0" {
+		t.Errorf("resolveDomains() = %v, want schema version 1.0.0 for both domains", got)
+	}
+}
+
+func TestDomainCatalog_Refresh(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	src := &mockDomainSource{domains: testDomains}
+	c, _ := NewDomainCatalog(src, &DomainCatalogConfig{RefreshInterval: time.Minute})
+	c.now = func() time.Time { return now }
+
+	if _, err := c.List(ctx); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if _, err := c.List(ctx); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if src.calls != 1 {
+		t.Errorf("ListDomains() calls = %d, want 1 within the refresh interval", src.calls)
+	}
+
+	// A failed refresh keeps the last known catalog.
+	now = now.Add(2 * time.Minute)
+	src.err = errors.New("db down")
+	got, err := c.List(ctx)
+	if err != nil {
+		t.Fatalf("List() after failed refresh error = %v", err)
+	}
+	if len(got) != len(testDomains) || src.calls != 2 {
+		t.Errorf("List() after failed refresh = %d domains with %d reads, want %d domains with 2 reads", len(got), src.calls, len(testDomains))
+	}
+}
+
+func TestDomainCatalog_ReadError(t *testing.T) {
+	c, _ := NewDomainCatalog(&mockDomainSource{err: errors.New("db down")}, &DomainCatalogConfig{})
+	if _, err := c.List(context.Background()); err == nil {
+		t.Error("List() expected error, got nil")
+	}
+	if err := c.Validate(context.Background(), &model.SubscriptionRequest{}); err == nil || errors.Is(err, model.ErrValidation) {
+		t.Errorf("Validate() error = %v, want a non-validation error", err)
+	}
+}
+
+func TestDomainCatalog_Validate(t *testing.T) {
+	c, _ := NewDomainCatalog(&mockDomainSource{domains: testDomains}, &DomainCatalogConfig{})
+	city := &model.Location{City: &model.City{Code: "std:080"}}
+	tests := []struct {
+		name      string
+		domain    string
+		location  *model.Location
+		wantField string
+	}{
+		{name: "inherited city granularity", domain: "ONDC:RET10", location: city},
+		{name: "inherited from grandparent", domain: "ONDC:RET11:X", location: city},
+		{name: "parent domain", domain: "ONDC:RET", location: city},
+		{name: "no granularity", domain: "ONDC:FIS"},
+		{name: "country granularity", domain: "ONDC:TRV", location: &model.Location{Country: &model.Country{Code: "IND"}}},
+		{name: "unknown domain", domain: "ONDC:XYZ", location: city, wantField: "domain"},
+		{name: "missing city", domain: "ONDC:RET10", wantField: "location.city.code"},
+		{name: "missing city code", domain: "ONDC:RET10", location: &model.Location{City: &model.City{Name: "Bengaluru"}}, wantField: "location.city.code"},
+		{name: "missing country", domain: "ONDC:TRV", location: city, wantField: "location.country.code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{Domain: tt.domain, Location: tt.location}}}
+			err := c.Validate(context.Background(), req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var verr *model.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *model.ValidationError", err)
+			}
+			if verr.Fields[0].Field != tt.wantField {
+				t.Errorf("Validate() field = %q, want %q", verr.Fields[0].Field, tt.wantField)
+			}
+		})
+	}
+}
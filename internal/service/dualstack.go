@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Address families preferred by the fanout dialer.
+const (
+	PreferIPv6 = "ipv6"
+	PreferIPv4 = "ipv4"
+)
+
+// defaultFallbackDelay is how long the preferred address family is tried alone, as in RFC 8305.
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// dualStackMetrics counts fanout connections by address family, and the connections won by
+// the fallback family.
+var dualStackMetrics = expvar.NewMap("fanout_ip_family")
+
+// DualStackConfig configures how the gateway connects to network participants reachable over
+// both IPv4 and IPv6.
+type DualStackConfig struct {
+	// Prefer is the address family tried first, "ipv6" or "ipv4". Empty follows the resolver's order.
+	Prefer string `yaml:"prefer"`
+	// FallbackDelay is how long the preferred family is tried before the other family is tried
+	// in parallel. Defaults to 300ms.
+	FallbackDelay time.Duration `yaml:"fallbackDelay"`
+}
+
+// dualStackDialer connects to a host over the preferred address family and falls back to the
+// other family with Happy Eyeballs (RFC 8305): the fallback starts when the preferred family
+// fails, or after a delay, and the first connection established wins.
+type dualStackDialer struct {
+	dialer        *net.Dialer
+	lookupIP      func(ctx context.Context, network, host string) ([]net.IP, error)
+	prefer        string
+	fallbackDelay time.Duration
+}
+
+// newDualStackDialer creates a dualStackDialer that makes its connections with dialer.
+func newDualStackDialer(dialer *net.Dialer, cfg *DualStackConfig) (*dualStackDialer, error) {
+	if dialer == nil {
+		slog.Error("newDualStackDialer: dialer cannot be nil")
+		return nil, errors.New("dialer cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("newDualStackDialer: DualStackConfig cannot be nil")
+		return nil, errors.New("DualStackConfig cannot be nil")
+	}
+	switch cfg.Prefer {
+	case PreferIPv6, PreferIPv4, "":
+	default:
+		return nil, fmt.Errorf("invalid dualStack config: prefer %q must be %q or %q", cfg.Prefer, PreferIPv6, PreferIPv4)
+	}
+	if cfg.FallbackDelay < 0 {
+		return nil, fmt.Errorf("invalid dualStack config: fallbackDelay %s must not be negative", cfg.FallbackDelay)
+	}
+	d := &dualStackDialer{dialer: dialer, lookupIP: net.DefaultResolver.LookupIP, prefer: cfg.Prefer, fallbackDelay: cfg.FallbackDelay}
+	if d.fallbackDelay == 0 {
+		d.fallbackDelay = defaultFallbackDelay
+	}
+	return d, nil
+}
+
+// DialContext connects to address, resolving its host and racing its address families.
+func (d *dualStackDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return d.dial(ctx, network, ip, port)
+	}
+	ips, err := d.lookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for host %s", host)
+	}
+	primaries, fallbacks := d.partition(ips)
+	if len(fallbacks) == 0 {
+		return d.dialSerial(ctx, network, primaries, port)
+	}
+	return d.race(ctx, network, primaries, fallbacks, port)
+}
+
+// partition splits ips into the addresses of the preferred family and those of the other
+// family, keeping the resolver's order within each.
+func (d *dualStackDialer) partition(ips []net.IP) (primaries, fallbacks []net.IP) {
+	primaryV4 := ips[0].To4() != nil
+	switch d.prefer {
+	case PreferIPv6:
+		primaryV4 = false
+	case PreferIPv4:
+		primaryV4 = true
+	}
+	for _, ip := range ips {
+		if (ip.To4() != nil) == primaryV4 {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+	if len(primaries) == 0 {
+		return fallbacks, nil
+	}
+	return primaries, fallbacks
+}
+
+// dialResult is the outcome of dialing the addresses of one family.
+type dialResult struct {
+	conn     net.Conn
+	err      error
+	fallback bool
+}
+
+// race dials the primary addresses and, once they fail or the fallback delay passes, the
+// fallback addresses in parallel. It returns the first connection, closing any later one,
+// or the primary family's error if both fail.
+func (d *dualStackDialer) race(ctx context.Context, network string, primaries, fallbacks []net.IP, port string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan dialResult, 2)
+	start := func(ips []net.IP, fallback bool) {
+		go func() {
+			conn, err := d.dialSerial(ctx, network, ips, port)
+			results <- dialResult{conn: conn, err: err, fallback: fallback}
+		}()
+	}
+	start(primaries, false)
+	timer := time.NewTimer(d.fallbackDelay)
+	defer timer.Stop()
+	pending, fallbackStarted := 1, false
+	var primaryErr error
+	for {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				start(fallbacks, true)
+				pending, fallbackStarted = pending+1, true
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if res.fallback {
+					dualStackMetrics.Add("fallback_wins", 1)
+				}
+				if pending > 0 {
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if !res.fallback {
+				primaryErr = res.err
+			}
+			if !fallbackStarted {
+				start(fallbacks, true)
+				pending, fallbackStarted = pending+1, true
+				continue
+			}
+			if pending == 0 {
+				if primaryErr == nil {
+					primaryErr = res.err
+				}
+				return nil, primaryErr
+			}
+		}
+	}
+}
+
+// dialSerial dials ips in turn and returns the first connection established.
+func (d *dualStackDialer) dialSerial(ctx context.Context, network string, ips []net.IP, port string) (net.Conn, error) {
+	var err error
+	for _, ip := range ips {
+		var conn net.Conn
+		if conn, err = d.dial(ctx, network, ip, port); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// dial connects to a single address and counts the outcome for its family. Attempts
+// canceled because another attempt won are not counted as failures.
+func (d *dualStackDialer) dial(ctx context.Context, network string, ip net.IP, port string) (net.Conn, error) {
+	family := "ipv6"
+	if ip.To4() != nil {
+		family = "ipv4"
+	}
+	conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+	switch {
+	case err == nil:
+		dualStackMetrics.Add(family+"_connected", 1)
+	case ctx.Err() == nil:
+		dualStackMetrics.Add(family+"_failed", 1)
+	}
+	return conn, err
+}
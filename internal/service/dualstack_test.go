@@ -0,0 +1,251 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// dualStackCount returns the value of a fanout_ip_family counter.
+func dualStackCount(key string) int64 {
+	if v, ok := dualStackMetrics.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// newLocalListener returns the port of an IPv4 loopback listener that accepts connections.
+func newLocalListener(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+// ipv6Control is a dialer Control function that handles IPv6 dials with ipv6 and lets IPv4 dials through.
+func ipv6Control(ipv6 func() error) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		host, _, _ := net.SplitHostPort(address)
+		if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+			return ipv6()
+		}
+		return nil
+	}
+}
+
+func TestNewDualStackDialer(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialer    *net.Dialer
+		cfg       *DualStackConfig
+		wantErr   string
+		wantDelay time.Duration
+	}{
+		{name: "defaults", dialer: &net.Dialer{}, cfg: &DualStackConfig{}, wantDelay: defaultFallbackDelay},
+		{name: "prefer ipv4", dialer: &net.Dialer{}, cfg: &DualStackConfig{Prefer: PreferIPv4, FallbackDelay: 50 * time.Millisecond}, wantDelay: 50 * time.Millisecond},
+		{name: "nil dialer", cfg: &DualStackConfig{}, wantErr: "dialer cannot be nil"},
+		{name: "nil config", dialer: &net.Dialer{}, wantErr: "DualStackConfig cannot be nil"},
+		{name: "invalid preference", dialer: &net.Dialer{}, cfg: &DualStackConfig{Prefer: "ipx"}, wantErr: `prefer "ipx"`},
+		{name: "negative delay", dialer: &net.Dialer{}, cfg: &DualStackConfig{FallbackDelay: -time.Second}, wantErr: "must not be negative"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := newDualStackDialer(tc.dialer, tc.cfg)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("newDualStackDialer() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newDualStackDialer() error = %v", err)
+			}
+			if d.fallbackDelay != tc.wantDelay {
+				t.Errorf("fallbackDelay = %v, want %v", d.fallbackDelay, tc.wantDelay)
+			}
+		})
+	}
+}
+
+func TestDualStackDialer_Partition(t *testing.T) {
+	v4a, v4b, v6a, v6b := net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2"), net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")
+	tests := []struct {
+		name          string
+		prefer        string
+		ips           []net.IP
+		wantPrimaries []net.IP
+		wantFallbacks []net.IP
+	}{
+		{name: "resolver order", ips: []net.IP{v4a, v6a, v4b}, wantPrimaries: []net.IP{v4a, v4b}, wantFallbacks: []net.IP{v6a}},
+		{name: "prefer ipv6", prefer: PreferIPv6, ips: []net.IP{v4a, v6a, v6b}, wantPrimaries: []net.IP{v6a, v6b}, wantFallbacks: []net.IP{v4a}},
+		{name: "prefer ipv4", prefer: PreferIPv4, ips: []net.IP{v6a, v4a}, wantPrimaries: []net.IP{v4a}, wantFallbacks: []net.IP{v6a}},
+		{name: "ipv6 only host", prefer: PreferIPv4, ips: []net.IP{v6a, v6b}, wantPrimaries: []net.IP{v6a, v6b}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &dualStackDialer{prefer: tc.prefer}
+			primaries, fallbacks := d.partition(tc.ips)
+			if diff := cmp.Diff(tc.wantPrimaries, primaries); diff != "" {
+				t.Errorf("partition() primaries mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantFallbacks, fallbacks); diff != "" {
+				t.Errorf("partition() fallbacks mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDualStackDialer_DialContext(t *testing.T) {
+	errIPv6Unreachable := errors.New("network is unreachable")
+	tests := []struct {
+		name         string
+		prefer       string
+		ipv6         func() error
+		delay        time.Duration
+		wantFallback int64
+		maxElapsed   time.Duration
+	}{
+		{
+			name:         "ipv6 fails, falls back to ipv4 at once",
+			prefer:       PreferIPv6,
+			ipv6:         func() error { return errIPv6Unreachable },
+			delay:        time.Minute,
+			wantFallback: 1,
+		},
+		{
+			name:         "ipv6 hangs, falls back to ipv4 after the delay",
+			prefer:       PreferIPv6,
+			ipv6:         func() error { time.Sleep(time.Second); return errIPv6Unreachable },
+			delay:        20 * time.Millisecond,
+			wantFallback: 1,
+			maxElapsed:   500 * time.Millisecond,
+		},
+		{
+			name:   "ipv4 preferred",
+			prefer: PreferIPv4,
+			ipv6:   func() error { t.Error("ipv6 dialed before the fallback delay"); return errIPv6Unreachable },
+			delay:  time.Minute,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			port := newLocalListener(t)
+			d, err := newDualStackDialer(&net.Dialer{Control: ipv6Control(tc.ipv6)}, &DualStackConfig{Prefer: tc.prefer, FallbackDelay: tc.delay})
+			if err != nil {
+				t.Fatalf("newDualStackDialer() error = %v", err)
+			}
+			d.lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+				return []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("127.0.0.1")}, nil
+			}
+			wins := dualStackCount("fallback_wins")
+			connected := dualStackCount("ipv4_connected")
+
+			start := time.Now()
+			conn, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("np.example.com", port))
+			if err != nil {
+				t.Fatalf("DialContext() error = %v", err)
+			}
+			conn.Close()
+			if tc.maxElapsed > 0 && time.Since(start) > tc.maxElapsed {
+				t.Errorf("DialContext() took %v, want at most %v", time.Since(start), tc.maxElapsed)
+			}
+			if got := dualStackCount("fallback_wins") - wins; got != tc.wantFallback {
+				t.Errorf("fallback_wins increased by %d, want %d", got, tc.wantFallback)
+			}
+			if got := dualStackCount("ipv4_connected") - connected; got != 1 {
+				t.Errorf("ipv4_connected increased by %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestDualStackDialer_DialContext_Error(t *testing.T) {
+	errIPv6Unreachable := errors.New("network is unreachable")
+	errIPv4Refused := errors.New("connection refused")
+	d, err := newDualStackDialer(&net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		host, _, _ := net.SplitHostPort(address)
+		if net.ParseIP(host).To4() == nil {
+			return errIPv6Unreachable
+		}
+		return errIPv4Refused
+	}}, &DualStackConfig{Prefer: PreferIPv6})
+	if err != nil {
+		t.Fatalf("newDualStackDialer() error = %v", err)
+	}
+	d.lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("2001:db8::1")}, nil
+	}
+	failed := dualStackCount("ipv6_failed")
+
+	if _, err := d.DialContext(context.Background(), "tcp", "np.example.com:443"); !errors.Is(err, errIPv6Unreachable) {
+		t.Errorf("DialContext() error = %v, want the preferred family's error %v", err, errIPv6Unreachable)
+	}
+	if got := dualStackCount("ipv6_failed") - failed; got != 1 {
+		t.Errorf("ipv6_failed increased by %d, want 1", got)
+	}
+
+	d.lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) { return nil, nil }
+	if _, err := d.DialContext(context.Background(), "tcp", "np.example.com:443"); err == nil || !strings.Contains(err.Error(), "no addresses found") {
+		t.Errorf("DialContext() error = %v, want no addresses error", err)
+	}
+}
+
+func TestProxyTaskProcessor_SetDualStack(t *testing.T) {
+	p, err := NewProxyTaskProcessor(&mockAuthGen{}, "test-key-id", RetryConfig{})
+	if err != nil {
+		t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+	}
+	if err := p.SetDualStack(&DualStackConfig{Prefer: "ipx"}); err == nil {
+		t.Error("SetDualStack() error = nil, want invalid preference error")
+	}
+	if err := p.SetDualStack(&DualStackConfig{Prefer: PreferIPv6}); err != nil {
+		t.Fatalf("SetDualStack() error = %v", err)
+	}
+	policy, err := NewTargetPolicy(&TargetPolicyConfig{AllowHTTP: true})
+	if err != nil {
+		t.Fatalf("NewTargetPolicy() error = %v", err)
+	}
+	p.SetTargetPolicy(policy)
+
+	// The target policy still guards the dials of the dual-stack dialer.
+	if _, err := p.transport.DialContext(context.Background(), "tcp", "127.0.0.1:1"); !errors.Is(err, ErrTargetNotAllowed) {
+		t.Errorf("DialContext() error = %v, want %v", err, ErrTargetNotAllowed)
+	}
+	if err := (&proxyTaskProcessor{}).SetDualStack(&DualStackConfig{}); err == nil {
+		t.Error("SetDualStack() without transport error = nil, want error")
+	}
+}
@@ -30,6 +30,8 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // encrypter defines the methods for encryption.
@@ -100,6 +102,16 @@ func (es *encryptionService) Init(ctx context.Context) (string, error) {
 		if err := json.Unmarshal(existingVersion.Payload.Data, &keyData); err != nil {
 			return "", fmt.Errorf("failed to unmarshal existing secret payload: %w", err)
 		}
+		// Keysets created before the registry signed its requests have no signing key.
+		if keyData.SigningPrivate == "" {
+			slog.InfoContext(ctx, "Secret has no signing key, adding one in a new version.", "secretName", secretName)
+			if keyData.SigningPrivate, keyData.SigningPublic, err = keyalgo.GenerateSigningKey(keyalgo.Ed25519); err != nil {
+				return "", fmt.Errorf("failed to generate signing key pair: %w", err)
+			}
+			if err := es.addVersion(ctx, secretName, &keyData); err != nil {
+				return "", err
+			}
+		}
 		return keyData.EncrPublic, nil // Return the existing public key
 	}
 
@@ -113,15 +125,17 @@ func (es *encryptionService) Init(ctx context.Context) (string, error) {
 			return "", fmt.Errorf("failed to generate encryption key pair: %w", genErr)
 		}
 
-		keyData := &becknmodel.Keyset{
-			UniqueKeyID: es.keyID,
-			EncrPrivate: encodeBase64(encrPrivateKey.Bytes()),
-			EncrPublic:  encodeBase64(encrPrivateKey.PublicKey().Bytes()),
+		signingPrivate, signingPublic, genErr := keyalgo.GenerateSigningKey(keyalgo.Ed25519)
+		if genErr != nil {
+			return "", fmt.Errorf("failed to generate signing key pair: %w", genErr)
 		}
 
-		payload, marshalErr := json.Marshal(keyData)
-		if marshalErr != nil {
-			return "", fmt.Errorf("failed to marshal payload: %w", marshalErr)
+		keyData := &becknmodel.Keyset{
+			UniqueKeyID:    es.keyID,
+			EncrPrivate:    encodeBase64(encrPrivateKey.Bytes()),
+			EncrPublic:     encodeBase64(encrPrivateKey.PublicKey().Bytes()),
+			SigningPrivate: signingPrivate,
+			SigningPublic:  signingPublic,
 		}
 
 		// Create the secret "container". We ignore "AlreadyExists" errors here.
@@ -143,15 +157,9 @@ func (es *encryptionService) Init(ctx context.Context) (string, error) {
 		}
 
 		// Add the new key as a new version.
-		addVersionReq := &secretmanagerpb.AddSecretVersionRequest{
-			Parent:  secretName,
-			Payload: &secretmanagerpb.SecretPayload{Data: payload},
+		if err := es.addVersion(ctx, secretName, keyData); err != nil {
+			return "", err
 		}
-		if _, addErr := es.sm.AddSecretVersion(ctx, addVersionReq); addErr != nil {
-			return "", fmt.Errorf("failed to add secret version: %w", addErr)
-		}
-
-		slog.InfoContext(ctx, "Successfully created and stored new secret version.", "secretName", secretName)
 		return keyData.EncrPublic, nil
 	}
 
@@ -159,6 +167,23 @@ func (es *encryptionService) Init(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("failed to get secret version: %w", err)
 }
 
+// addVersion stores keyData as the latest version of the secret.
+func (es *encryptionService) addVersion(ctx context.Context, secretName string, keyData *becknmodel.Keyset) error {
+	payload, err := json.Marshal(keyData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	addVersionReq := &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  secretName,
+		Payload: &secretmanagerpb.SecretPayload{Data: payload},
+	}
+	if _, err := es.sm.AddSecretVersion(ctx, addVersionReq); err != nil {
+		return fmt.Errorf("failed to add secret version: %w", err)
+	}
+	slog.InfoContext(ctx, "Successfully created and stored new secret version.", "secretName", secretName)
+	return nil
+}
+
 // Constants for secret ID generation.
 const (
 	maxSecretIDLen = 255
@@ -203,6 +228,32 @@ func encodeBase64(data []byte) string {
 
 // privateKey fetches private key from sercret manager.
 func (es *encryptionService) privateKey(ctx context.Context) (string, error) {
+	keys, err := es.keyset(ctx)
+	if err != nil {
+		return "", err
+	}
+	if keys.EncrPrivate == "" {
+		return "", fmt.Errorf("private key not found in secret data for keyID: %s", es.keyID)
+	}
+
+	return keys.EncrPrivate, nil
+}
+
+// Keyset returns the registry's keyset, whose signing key signs the requests the registry
+// sends as subscriberID.
+func (es *encryptionService) Keyset(ctx context.Context, subscriberID string) (*becknmodel.Keyset, error) {
+	keys, err := es.keyset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if keys.SigningPrivate == "" {
+		return nil, fmt.Errorf("signing key not found in secret data for keyID: %s", es.keyID)
+	}
+	return keys, nil
+}
+
+// keyset fetches the keyset from secret manager.
+func (es *encryptionService) keyset(ctx context.Context) (*becknmodel.Keyset, error) {
 	secretID := generateSecretID(es.keyID)
 
 	secretName := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", es.projectID, secretID)
@@ -212,19 +263,15 @@ func (es *encryptionService) privateKey(ctx context.Context) (string, error) {
 	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
-			return "", fmt.Errorf("private keys for keyID: %s not found", es.keyID)
+			return nil, fmt.Errorf("private keys for keyID: %s not found", es.keyID)
 		}
-		return "", fmt.Errorf("failed to access secret version: %w", err)
+		return nil, fmt.Errorf("failed to access secret version: %w", err)
 	}
 	var keys becknmodel.Keyset
 	if err := json.Unmarshal(res.Payload.Data, &keys); err != nil {
-		return "", fmt.Errorf("failed to unmarshal payload: %w", err)
-	}
-	if keys.EncrPrivate == "" {
-		return "", fmt.Errorf("private key not found in secret data for keyID: %s", es.keyID)
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
-
-	return keys.EncrPrivate, nil
+	return &keys, nil
 }
 
 // Encrypt encrypts the given body using the private key and the provided public key.
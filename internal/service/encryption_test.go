@@ -118,7 +118,7 @@ func TestEncryptionService_Init_Success(t *testing.T) {
 			enc:       mockEnc,
 			configureSM: func(msm *mockSecretManager) { // Simulate AccessSecretVersion succeeding
 				msm.accessSecretVersionResp = &secretmanagerpb.AccessSecretVersionResponse{
-					Payload: &secretmanagerpb.SecretPayload{Data: []byte(`{"EncrPublic":"existing-pub-key"}`)},
+					Payload: &secretmanagerpb.SecretPayload{Data: []byte(`{"EncrPublic":"existing-pub-key","SigningPrivate":"existing-signing-key"}`)},
 				}
 				// CreateSecret will not be called in this path.
 			},
@@ -126,6 +126,21 @@ func TestEncryptionService_Init_Success(t *testing.T) {
 			expectCreateSecretCall:     false,
 			expectAddSecretVersionCall: false,
 		},
+		{
+			name:      "success - signing key added to existing key",
+			projectID: defaultProjectID,
+			keyID:     defaultKeyID,
+			enc:       mockEnc,
+			configureSM: func(msm *mockSecretManager) {
+				msm.accessSecretVersionResp = &secretmanagerpb.AccessSecretVersionResponse{
+					Payload: &secretmanagerpb.SecretPayload{Data: []byte(`{"EncrPublic":"existing-pub-key"}`)},
+				}
+				msm.addSecretVersionResp = &secretmanagerpb.SecretVersion{}
+			},
+			expectService:              true,
+			expectCreateSecretCall:     false,
+			expectAddSecretVersionCall: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -381,6 +396,38 @@ func TestEncryptionService_Encrypt_Success(t *testing.T) {
 	})
 }
 
+func TestEncryptionService_Keyset(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{name: "success", payload: `{"UniqueKeyID":"test-key","SigningPrivate":"signing-private","SigningPublic":"signing-public"}`},
+		{name: "no signing key", payload: `{"UniqueKeyID":"test-key","EncrPrivate":"encr-private"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSM := &mockSecretManager{accessSecretVersionResp: &secretmanagerpb.AccessSecretVersionResponse{
+				Payload: &secretmanagerpb.SecretPayload{Data: []byte(tt.payload)},
+			}}
+			service := &encryptionService{projectID: "test-project", keyID: "test-key", sm: mockSM, encrypter: &mockEncrypter{}}
+			keys, err := service.Keyset(context.Background(), "registry.example.com")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Keyset() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Keyset() unexpected error = %v", err)
+			}
+			if keys.SigningPrivate != "signing-private" || keys.UniqueKeyID != "test-key" {
+				t.Errorf("Keyset() = %+v", keys)
+			}
+		})
+	}
+}
+
 func TestEncryptionService_Encrypt_Error(t *testing.T) {
 	ctx := context.Background()
 	projectID := "test-project"
@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const defaultHedgeDelay = 500 * time.Millisecond
+
+// hedgeMetrics counts the hedged attempts sent to targets and those whose response was used.
+var hedgeMetrics = expvar.NewMap("gateway_hedging")
+
+// HedgeConfig configures hedged requests: when a target has not responded to a request for an
+// idempotent action within Delay, a second attempt is sent to it and the first response wins.
+type HedgeConfig struct {
+	// Actions are the actions whose requests are hedged. They must be idempotent for their
+	// targets, e.g. on_search, since a target may receive both attempts. Required.
+	Actions []string `yaml:"actions"`
+	// Delay is how long the first attempt runs before the second is sent. Defaults to 500ms.
+	Delay time.Duration `yaml:"delay"`
+}
+
+// validate checks the config and applies defaults.
+func (c *HedgeConfig) validate() error {
+	if len(c.Actions) == 0 {
+		return errors.New("invalid hedging config: actions must list at least one idempotent action")
+	}
+	if c.Delay < 0 {
+		return fmt.Errorf("invalid hedging config: delay %s cannot be negative", c.Delay)
+	}
+	if c.Delay == 0 {
+		c.Delay = defaultHedgeDelay
+	}
+	return nil
+}
+
+// hedger races a second attempt of a request against a slow first one.
+type hedger struct {
+	actions []string
+	delay   time.Duration
+}
+
+// NewHedger creates a new hedger.
+func NewHedger(cfg *HedgeConfig) (*hedger, error) {
+	if cfg == nil {
+		slog.Error("NewHedger: HedgeConfig cannot be nil")
+		return nil, errors.New("HedgeConfig cannot be nil")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &hedger{actions: cfg.Actions, delay: cfg.Delay}, nil
+}
+
+// hedgeResult is the outcome of one attempt of a hedged request.
+type hedgeResult struct {
+	resp     *http.Response
+	err      error
+	hedged   bool
+	attempts int
+}
+
+// cancelOnClose cancels the context of the winning attempt once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Do sends req with client. For hedged actions, a second attempt is sent if the first has
+// not responded within the delay. The first attempt to respond without error wins and the
+// other is canceled; if both fail, the error of the last one is returned.
+func (h *hedger) Do(ctx context.Context, client httpClient, req *http.Request, action string) (*http.Response, error) {
+	if !slices.Contains(h.actions, action) || (req.Body != nil && req.GetBody == nil) {
+		return client.Do(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	// cancels holds the cancel functions of the first and the hedged attempt.
+	cancels := map[bool]context.CancelFunc{}
+	attempt := func(hedged bool) error {
+		attemptCtx, cancel := context.WithCancel(req.Context())
+		// Each attempt counts its own retries, so that the racing attempts do not share the
+		// counter of the task.
+		attempts := new(int)
+		r := req.Clone(context.WithValue(attemptCtx, taskAttemptsKey{}, attempts))
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return err
+			}
+			r.Body = body
+		}
+		cancels[hedged] = cancel
+		go func() {
+			resp, err := client.Do(r)
+			results <- hedgeResult{resp: resp, err: err, hedged: hedged, attempts: *attempts}
+		}()
+		return nil
+	}
+	if err := attempt(false); err != nil {
+		return nil, fmt.Errorf("failed to copy request body: %w", err)
+	}
+
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			if err := attempt(true); err != nil {
+				slog.WarnContext(ctx, "Hedger: Failed to send hedged attempt", "target", req.URL.String(), "error", err)
+				continue
+			}
+			pending++
+			hedgeMetrics.Add("hedged", 1)
+			slog.DebugContext(ctx, "Hedger: Sent hedged attempt", "target", req.URL.String(), "delay", h.delay)
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[res.hedged]()
+				if pending > 0 {
+					continue
+				}
+				if res.attempts > 0 {
+					recordAttempt(req.Context(), res.attempts)
+				}
+				return nil, res.err
+			}
+			if pending > 0 {
+				cancels[!res.hedged]()
+				go discardLoser(results)
+			}
+			if res.hedged {
+				hedgeMetrics.Add("hedge_won", 1)
+			}
+			if res.attempts > 0 {
+				recordAttempt(req.Context(), res.attempts)
+			}
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.hedged]}
+			return res.resp, nil
+		}
+	}
+}
+
+// discardLoser releases the response of the canceled attempt that lost the race.
+func discardLoser(results <-chan hedgeResult) {
+	if res := <-results; res.resp != nil {
+		res.resp.Body.Close()
+	}
+}
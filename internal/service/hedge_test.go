@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHedgeConfig_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     HedgeConfig
+		want    HedgeConfig
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			cfg:  HedgeConfig{Actions: []string{"on_search"}},
+			want: HedgeConfig{Actions: []string{"on_search"}, Delay: 500 * time.Millisecond},
+		},
+		{
+			name: "custom delay",
+			cfg:  HedgeConfig{Actions: []string{"on_search"}, Delay: time.Second},
+			want: HedgeConfig{Actions: []string{"on_search"}, Delay: time.Second},
+		},
+		{name: "no actions", cfg: HedgeConfig{Delay: time.Second}, wantErr: true},
+		{name: "negative delay", cfg: HedgeConfig{Actions: []string{"on_search"}, Delay: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tt.want, tt.cfg); diff != "" {
+					t.Errorf("validate() config mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHedger_NilConfig(t *testing.T) {
+	if _, err := NewHedger(nil); err == nil {
+		t.Error("NewHedger(nil) error = nil, want error")
+	}
+}
+
+// hedgeTestClient answers each attempt with the outcome of its 1-based attempt number.
+// An attempt whose outcome is nil blocks until it is canceled.
+type hedgeTestClient struct {
+	calls    atomic.Int32
+	canceled atomic.Int32
+	outcomes map[int32]func(body string) (*http.Response, error)
+}
+
+func (c *hedgeTestClient) Do(req *http.Request) (*http.Response, error) {
+	n := c.calls.Add(1)
+	body, _ := io.ReadAll(req.Body)
+	outcome := c.outcomes[n]
+	if outcome == nil {
+		<-req.Context().Done()
+		c.canceled.Add(1)
+		return nil, req.Context().Err()
+	}
+	return outcome(string(body))
+}
+
+func hedgeOK(body string) (*http.Response, error) {
+	return newMockHTTPResponse(http.StatusOK, body), nil
+}
+
+func hedgeFail(string) (*http.Response, error) {
+	return nil, errors.New("connection reset")
+}
+
+func hedgeSlowFail(string) (*http.Response, error) {
+	time.Sleep(50 * time.Millisecond)
+	return nil, errors.New("connection reset")
+}
+
+func TestHedger_Do(t *testing.T) {
+	tests := []struct {
+		name         string
+		action       string
+		outcomes     map[int32]func(string) (*http.Response, error)
+		wantCalls    int32
+		wantCanceled int32
+		wantErr      bool
+	}{
+		{
+			name:      "action not hedged",
+			action:    "search",
+			outcomes:  map[int32]func(string) (*http.Response, error){1: hedgeOK},
+			wantCalls: 1,
+		},
+		{
+			name:      "first attempt responds in time",
+			action:    "on_search",
+			outcomes:  map[int32]func(string) (*http.Response, error){1: hedgeOK},
+			wantCalls: 1,
+		},
+		{
+			name:         "hedged attempt wins",
+			action:       "on_search",
+			outcomes:     map[int32]func(string) (*http.Response, error){2: hedgeOK},
+			wantCalls:    2,
+			wantCanceled: 1,
+		},
+		{
+			name:      "first attempt fails after hedge",
+			action:    "on_search",
+			outcomes:  map[int32]func(string) (*http.Response, error){1: hedgeSlowFail, 2: hedgeOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "first attempt fails before hedge",
+			action:    "on_search",
+			outcomes:  map[int32]func(string) (*http.Response, error){1: hedgeFail},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "both attempts fail",
+			action:    "on_search",
+			outcomes:  map[int32]func(string) (*http.Response, error){1: hedgeSlowFail, 2: hedgeFail},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHedger(&HedgeConfig{Actions: []string{"on_search"}, Delay: 10 * time.Millisecond})
+			if err != nil {
+				t.Fatalf("NewHedger() error = %v", err)
+			}
+			client := &hedgeTestClient{outcomes: tt.outcomes}
+			body := `{"message":{"ack":{"status":"ACK"}}}`
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://bap.example.com/on_search", bytes.NewReader([]byte(body)))
+
+			resp, err := h.Do(context.Background(), client, req, tt.action)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				got, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(got) != body {
+					t.Errorf("Do() response body = %q, want the request body %q echoed", got, body)
+				}
+			}
+			if got := client.calls.Load(); got != tt.wantCalls {
+				t.Errorf("Do() made %d attempts, want %d", got, tt.wantCalls)
+			}
+			// The canceled loser returns in the background.
+			deadline := time.Now().Add(time.Second)
+			for client.canceled.Load() != tt.wantCanceled && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if got := client.canceled.Load(); got != tt.wantCanceled {
+				t.Errorf("Do() canceled %d attempts, want %d", got, tt.wantCanceled)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_Hedging(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The body is read so that the server notices the canceled attempt closing its connection.
+		io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewProxyTaskProcessor(&mockAuthGen{authHeader: "Signature test"}, "test-key-id", RetryConfig{})
+	if err != nil {
+		t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+	}
+	h, _ := NewHedger(&HedgeConfig{Actions: []string{"on_search"}, Delay: 10 * time.Millisecond})
+	p.SetHedging(h)
+	target, _ := url.Parse(srv.URL + "/on_search")
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target, Body: []byte(`{}`), Headers: http.Header{}, Context: *txnContext("on_search", "ONDC:RET10", "std:080")}
+	start := time.Now()
+	if err := p.Process(context.Background(), task); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Process() took %v, want the hedged attempt to answer", d)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server received %d requests, want 2", got)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHostRateMaxWait = 10 * time.Second
+	// hostRateSweepSize is the number of tracked hosts above which idle hosts are dropped.
+	hostRateSweepSize = 10000
+)
+
+// ErrHostRateLimited is returned when a request would wait longer than allowed for the rate
+// limit of its target host.
+var ErrHostRateLimited = errors.New("outbound rate limit of host exceeded")
+
+// hostRateMetrics counts the requests delayed or rejected by the outbound rate limits.
+var hostRateMetrics = expvar.NewMap("gateway_host_rate_limit")
+
+// HostRateLimit is the outbound rate limit of a target host.
+type HostRateLimit struct {
+	// RequestsPerSecond is the sustained number of requests per second sent to the host.
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	// Burst is the number of requests that may be sent to the host at once. Defaults to 1.
+	Burst int `yaml:"burst"`
+}
+
+// HostRateLimitConfig configures the pacing of the requests the gateway sends to each target
+// host, independently of the rate limits of the requests it receives.
+type HostRateLimitConfig struct {
+	// Default is the limit of hosts that are not listed in Hosts. Without it, those hosts are not limited.
+	Default *HostRateLimit `yaml:"default"`
+	// Hosts are the limits of specific hosts, keyed by the host of the target URL, with its port if any.
+	Hosts map[string]HostRateLimit `yaml:"hosts"`
+	// MaxWait is the longest a request waits for its host's rate limit. Requests that would
+	// wait longer fail without being sent. Defaults to 10s.
+	MaxWait time.Duration `yaml:"maxWait"`
+}
+
+// validate checks a limit and applies defaults.
+func (l *HostRateLimit) validate(name string) error {
+	if l.RequestsPerSecond <= 0 {
+		return fmt.Errorf("invalid host rate limit config: requestsPerSecond of %s must be positive", name)
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("invalid host rate limit config: burst of %s cannot be negative", name)
+	}
+	if l.Burst == 0 {
+		l.Burst = 1
+	}
+	return nil
+}
+
+// hostPace is a rate limit as the emission interval between sustained requests and how
+// far ahead of now the arrival time of a host may run.
+type hostPace struct {
+	interval  time.Duration
+	tolerance time.Duration
+}
+
+func newHostPace(l HostRateLimit) hostPace {
+	interval := time.Duration(float64(time.Second) / l.RequestsPerSecond)
+	return hostPace{interval: interval, tolerance: interval * time.Duration(l.Burst-1)}
+}
+
+// hostRateLimiter paces requests per target host with the generic cell rate algorithm, the
+// equivalent of a token bucket per host: each host has a theoretical arrival time that every
+// request pushes back by the emission interval, and a request waits while the arrival time
+// is more than the burst ahead of now.
+type hostRateLimiter struct {
+	def     *hostPace
+	hosts   map[string]hostPace
+	maxWait time.Duration
+	now     func() time.Time
+
+	mu  sync.Mutex
+	tat map[string]time.Time
+}
+
+// NewHostRateLimiter creates a new hostRateLimiter.
+func NewHostRateLimiter(cfg *HostRateLimitConfig) (*hostRateLimiter, error) {
+	if cfg == nil {
+		slog.Error("NewHostRateLimiter: HostRateLimitConfig cannot be nil")
+		return nil, errors.New("HostRateLimitConfig cannot be nil")
+	}
+	if cfg.Default == nil && len(cfg.Hosts) == 0 {
+		return nil, errors.New("invalid host rate limit config: default or hosts must be set")
+	}
+	if cfg.MaxWait < 0 {
+		return nil, fmt.Errorf("invalid host rate limit config: maxWait %s cannot be negative", cfg.MaxWait)
+	}
+	l := &hostRateLimiter{
+		hosts:   make(map[string]hostPace, len(cfg.Hosts)),
+		maxWait: cfg.MaxWait,
+		now:     time.Now,
+		tat:     map[string]time.Time{},
+	}
+	if l.maxWait == 0 {
+		l.maxWait = defaultHostRateMaxWait
+	}
+	if cfg.Default != nil {
+		if err := cfg.Default.validate("default"); err != nil {
+			return nil, err
+		}
+		p := newHostPace(*cfg.Default)
+		l.def = &p
+	}
+	for host, limit := range cfg.Hosts {
+		if err := limit.validate(host); err != nil {
+			return nil, err
+		}
+		l.hosts[strings.ToLower(host)] = newHostPace(limit)
+	}
+	return l, nil
+}
+
+// Wait blocks until a request to the host is allowed by its rate limit and reserves it.
+// It fails right away with ErrHostRateLimited if the request would wait longer than the
+// maximum wait, without reserving it.
+func (l *hostRateLimiter) Wait(ctx context.Context, host string) error {
+	host = strings.ToLower(host)
+	pace, ok := l.hosts[host]
+	if !ok {
+		if l.def == nil {
+			return nil
+		}
+		pace = *l.def
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	tat := l.tat[host]
+	if tat.Before(now) {
+		tat = now
+	}
+	delay := tat.Sub(now) - pace.tolerance
+	if delay > l.maxWait {
+		l.mu.Unlock()
+		hostRateMetrics.Add("rejected", 1)
+		return fmt.Errorf("%w: %s would wait %s", ErrHostRateLimited, host, delay.Round(time.Millisecond))
+	}
+	if len(l.tat) >= hostRateSweepSize {
+		l.sweep(now)
+	}
+	l.tat[host] = tat.Add(pace.interval)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	hostRateMetrics.Add("delayed", 1)
+	hostRateMetrics.Add("wait_ms_total", delay.Milliseconds())
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// sweep drops the hosts whose arrival time has passed, as they are back to a full burst.
+func (l *hostRateLimiter) sweep(now time.Time) {
+	for host, tat := range l.tat {
+		if !tat.After(now) {
+			delete(l.tat, host)
+		}
+	}
+}
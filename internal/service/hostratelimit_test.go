@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewHostRateLimiter(t *testing.T) {
+	l, err := NewHostRateLimiter(&HostRateLimitConfig{
+		Default: &HostRateLimit{RequestsPerSecond: 10},
+		Hosts:   map[string]HostRateLimit{"BPP.example.com": {RequestsPerSecond: 2, Burst: 4}},
+	})
+	if err != nil {
+		t.Fatalf("NewHostRateLimiter() unexpected error: %v", err)
+	}
+	if l.maxWait != defaultHostRateMaxWait {
+		t.Errorf("maxWait = %v, want %v", l.maxWait, defaultHostRateMaxWait)
+	}
+	if *l.def != (hostPace{interval: 100 * time.Millisecond}) {
+		t.Errorf("default pace = %+v, want an interval of 100ms and no burst", *l.def)
+	}
+	if got := l.hosts["bpp.example.com"]; got != (hostPace{interval: 500 * time.Millisecond, tolerance: 1500 * time.Millisecond}) {
+		t.Errorf("pace of bpp.example.com = %+v, want an interval of 500ms and a tolerance of 1.5s", got)
+	}
+}
+
+func TestNewHostRateLimiter_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *HostRateLimitConfig
+	}{
+		{name: "nil config", cfg: nil},
+		{name: "no limits", cfg: &HostRateLimitConfig{}},
+		{name: "zero rate", cfg: &HostRateLimitConfig{Default: &HostRateLimit{}}},
+		{name: "negative burst", cfg: &HostRateLimitConfig{Hosts: map[string]HostRateLimit{"a.com": {RequestsPerSecond: 1, Burst: -1}}}},
+		{name: "negative max wait", cfg: &HostRateLimitConfig{Default: &HostRateLimit{RequestsPerSecond: 1}, MaxWait: -time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHostRateLimiter(tt.cfg); err == nil {
+				t.Error("NewHostRateLimiter() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestHostRateLimiter_Wait(t *testing.T) {
+	l, _ := NewHostRateLimiter(&HostRateLimitConfig{Hosts: map[string]HostRateLimit{"a.com": {RequestsPerSecond: 20, Burst: 2}}})
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 4 {
+		if err := l.Wait(ctx, "A.com"); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	// The burst of 2 is sent at once, then one request every 50ms.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("4 requests to a.com took %v, want at least 100ms", elapsed)
+	}
+
+	start = time.Now()
+	for range 10 {
+		if err := l.Wait(ctx, "b.com"); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("requests to b.com without a limit took %v, want no delay", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Wait(cancelled, "a.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestHostRateLimiter_Wait_MaxWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l, _ := NewHostRateLimiter(&HostRateLimitConfig{Default: &HostRateLimit{RequestsPerSecond: 1, Burst: 2}, MaxWait: time.Second})
+	l.now = func() time.Time { return now }
+
+	for range 2 {
+		if err := l.Wait(context.Background(), "a.com"); err != nil {
+			t.Fatalf("Wait() within burst unexpected error: %v", err)
+		}
+	}
+	// Reserve a third request, which may wait 1s, without sleeping. A fourth would wait 2s.
+	l.tat["a.com"] = l.tat["a.com"].Add(time.Second)
+	if err := l.Wait(context.Background(), "a.com"); !errors.Is(err, ErrHostRateLimited) {
+		t.Fatalf("Wait() error = %v, want %v", err, ErrHostRateLimited)
+	}
+	if got, want := l.tat["a.com"], now.Add(3*time.Second); !got.Equal(want) {
+		t.Errorf("arrival time after a rejected request = %v, want %v", got, want)
+	}
+
+	// Once the arrival time has passed, the host is back to a full burst.
+	now = now.Add(3 * time.Second)
+	for range 2 {
+		if err := l.Wait(context.Background(), "a.com"); err != nil {
+			t.Fatalf("Wait() after recovery unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// defaultGatewayUserAgent is the product token of the gateway's User-Agent header.
+const defaultGatewayUserAgent = "beckn-onix-gateway"
+
+// ErrInvalidGatewayIdentity is returned when the gateway identity config is invalid.
+var ErrInvalidGatewayIdentity = errors.New("invalid gateway identity")
+
+// GatewayIdentityConfig configures the headers that identify the gateway to network participants.
+type GatewayIdentityConfig struct {
+	ID         string `yaml:"id"`         // Sent as X-Gateway-Id. Defaults to the gateway's subscriber ID.
+	Version    string `yaml:"version"`    // Sent as X-Gateway-Version and appended to the default User-Agent.
+	ContactURL string `yaml:"contactURL"` // Sent as X-Gateway-Contact. Must be an absolute http(s) or mailto URL.
+	UserAgent  string `yaml:"userAgent"`  // Defaults to "beckn-onix-gateway/<version>".
+}
+
+// gatewayIdentity holds the headers that identify the gateway.
+type gatewayIdentity struct {
+	headers http.Header
+}
+
+// NewGatewayIdentity creates the identity of the gateway from its config.
+// subscriberID is used as the gateway ID if the config has none.
+func NewGatewayIdentity(cfg *GatewayIdentityConfig, subscriberID string) (*gatewayIdentity, error) {
+	if cfg == nil {
+		cfg = &GatewayIdentityConfig{}
+	}
+	id := cfg.ID
+	if id == "" {
+		id = subscriberID
+	}
+	if id == "" {
+		slog.Error("NewGatewayIdentity: gateway ID cannot be empty")
+		return nil, fmt.Errorf("%w: id cannot be empty", ErrInvalidGatewayIdentity)
+	}
+	if cfg.ContactURL != "" {
+		u, err := url.Parse(cfg.ContactURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "mailto") || (u.Host == "" && u.Opaque == "") {
+			slog.Error("NewGatewayIdentity: invalid contact URL", "contact_url", cfg.ContactURL)
+			return nil, fmt.Errorf("%w: contactURL %q must be an absolute http(s) or mailto URL", ErrInvalidGatewayIdentity, cfg.ContactURL)
+		}
+	}
+	ua := cfg.UserAgent
+	if ua == "" {
+		ua = defaultGatewayUserAgent
+		if cfg.Version != "" {
+			ua += "/" + cfg.Version
+		}
+	}
+
+	h := http.Header{}
+	for name, v := range map[string]string{
+		model.GatewayIDHeader:      id,
+		model.GatewayVersionHeader: cfg.Version,
+		model.GatewayContactHeader: cfg.ContactURL,
+		"User-Agent":               ua,
+	} {
+		if strings.ContainsAny(v, "\r\n") {
+			slog.Error("NewGatewayIdentity: header value contains a line break", "header", name)
+			return nil, fmt.Errorf("%w: %s cannot contain line breaks", ErrInvalidGatewayIdentity, name)
+		}
+		if v != "" {
+			h.Set(name, v)
+		}
+	}
+	return &gatewayIdentity{headers: h}, nil
+}
+
+// Apply sets the identity headers on h, replacing any existing values.
+// Identity headers that are not configured are removed, so that a header
+// copied from an inbound request is never forwarded as the gateway's own.
+func (g *gatewayIdentity) Apply(h http.Header) {
+	for _, name := range []string{model.GatewayIDHeader, model.GatewayVersionHeader, model.GatewayContactHeader} {
+		h.Del(name)
+	}
+	for name := range g.headers {
+		h.Set(name, g.headers.Get(name))
+	}
+}
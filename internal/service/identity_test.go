@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewGatewayIdentity(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *GatewayIdentityConfig
+		want http.Header
+	}{
+		{
+			name: "nil config defaults to subscriber ID",
+			want: http.Header{
+				model.GatewayIDHeader: {"gw.example.com"},
+				"User-Agent":          {"beckn-onix-gateway"},
+			},
+		},
+		{
+			name: "all fields",
+			cfg:  &GatewayIdentityConfig{ID: "gw-1", Version: "1.2.0", ContactURL: "mailto:ops@example.com", UserAgent: "custom/1.0"},
+			want: http.Header{
+				model.GatewayIDHeader:      {"gw-1"},
+				model.GatewayVersionHeader: {"1.2.0"},
+				model.GatewayContactHeader: {"mailto:ops@example.com"},
+				"User-Agent":               {"custom/1.0"},
+			},
+		},
+		{
+			name: "version is appended to the default user agent",
+			cfg:  &GatewayIdentityConfig{Version: "1.2.0", ContactURL: "https://gw.example.com/contact"},
+			want: http.Header{
+				model.GatewayIDHeader:      {"gw.example.com"},
+				model.GatewayVersionHeader: {"1.2.0"},
+				model.GatewayContactHeader: {"https://gw.example.com/contact"},
+				"User-Agent":               {"beckn-onix-gateway/1.2.0"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGatewayIdentity(tc.cfg, "gw.example.com")
+			if err != nil {
+				t.Fatalf("NewGatewayIdentity() unexpected error: %v", err)
+			}
+			got := http.Header{}
+			g.Apply(got)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Apply() headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewGatewayIdentity_Error(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          *GatewayIdentityConfig
+		subscriberID string
+	}{
+		{name: "no id", cfg: &GatewayIdentityConfig{}},
+		{name: "relative contact url", cfg: &GatewayIdentityConfig{ContactURL: "/contact"}, subscriberID: "gw"},
+		{name: "unsupported contact scheme", cfg: &GatewayIdentityConfig{ContactURL: "ftp://example.com"}, subscriberID: "gw"},
+		{name: "line break in version", cfg: &GatewayIdentityConfig{Version: "1.0\r\nX-Injected: 1"}, subscriberID: "gw"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewGatewayIdentity(tc.cfg, tc.subscriberID); !errors.Is(err, ErrInvalidGatewayIdentity) {
+				t.Errorf("NewGatewayIdentity() error = %v, want %v", err, ErrInvalidGatewayIdentity)
+			}
+		})
+	}
+}
+
+func TestGatewayIdentity_Apply_RemovesUnconfiguredHeaders(t *testing.T) {
+	g, err := NewGatewayIdentity(nil, "gw.example.com")
+	if err != nil {
+		t.Fatalf("NewGatewayIdentity() unexpected error: %v", err)
+	}
+	h := http.Header{
+		model.GatewayIDHeader:      {"spoofed"},
+		model.GatewayVersionHeader: {"9.9.9"},
+		"Content-Type":             {"application/json"},
+	}
+	g.Apply(h)
+
+	want := http.Header{
+		model.GatewayIDHeader: {"gw.example.com"},
+		"User-Agent":          {"beckn-onix-gateway"},
+		"Content-Type":        {"application/json"},
+	}
+	if diff := cmp.Diff(want, h); diff != "" {
+		t.Errorf("Apply() headers mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Journal types.
+const (
+	JournalTypeRedis = "redis"
+	JournalTypeDisk  = "disk"
+)
+
+// JournalConfig configures the write-ahead journal for accepted gateway requests.
+type JournalConfig struct {
+	// Type selects the journal backend, either "redis" or "disk".
+	Type string `yaml:"type"`
+	// Stream is the Redis stream key used by the redis journal.
+	// Each gateway replica must use its own stream so replicas do not replay each other's requests,
+	// unless Lease is set.
+	Stream string `yaml:"stream"`
+	// Dir is the directory used by the disk journal.
+	Dir string `yaml:"dir"`
+	// Lease lets replicas share the stream of a redis journal by claiming its entries.
+	Lease *TaskLeaseConfig `yaml:"lease"`
+}
+
+// NewJournal creates the journal backend selected by cfg.
+// The Redis client is only used by the redis journal.
+func NewJournal(cfg *JournalConfig, client redisStreamer) (txnJournal, error) {
+	if cfg == nil {
+		slog.Error("NewJournal: config cannot be nil")
+		return nil, errors.New("journal config cannot be nil")
+	}
+	switch cfg.Type {
+	case JournalTypeRedis:
+		return NewRedisJournal(client, cfg.Stream)
+	case JournalTypeDisk:
+		return NewDiskJournal(cfg.Dir)
+	default:
+		return nil, fmt.Errorf("unknown journal type: %q", cfg.Type)
+	}
+}
+
+// JournalEntry is a task recorded in the journal that has not been acknowledged yet.
+type JournalEntry struct {
+	ID   string
+	Task *model.AsyncTask
+}
+
+// journalEntryTime returns the time an entry was appended, taken from its ID:
+// Redis stream IDs start with Unix milliseconds and disk journal IDs are Unix nanoseconds.
+func journalEntryTime(id string) (time.Time, bool) {
+	if ms, _, ok := strings.Cut(id, "-"); ok {
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(n), true
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n), true
+}
+
+// journalRecord is the serialized form of a journaled task.
+type journalRecord struct {
+	Type    model.AsyncTaskType `json:"type"`
+	Target  string              `json:"target,omitempty"`
+	Body    []byte              `json:"body"`
+	Headers http.Header         `json:"headers,omitempty"`
+	Context model.Context       `json:"context"`
+	Targets []string            `json:"targets,omitempty"`
+}
+
+func encodeJournalTask(task *model.AsyncTask) ([]byte, error) {
+	rec := journalRecord{Type: task.Type, Body: task.Body, Headers: task.Headers, Context: task.Context}
+	if task.Target != nil {
+		rec.Target = task.Target.String()
+	}
+	for _, t := range task.Targets {
+		rec.Targets = append(rec.Targets, t.String())
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal journal record: %w", err)
+	}
+	return b, nil
+}
+
+func decodeJournalTask(data []byte) (*model.AsyncTask, error) {
+	var rec journalRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal journal record: %w", err)
+	}
+	task := &model.AsyncTask{Type: rec.Type, Body: rec.Body, Headers: rec.Headers, Context: rec.Context}
+	if rec.Target != "" {
+		target, err := url.Parse(rec.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse journaled target %q: %w", rec.Target, err)
+		}
+		task.Target = target
+	}
+	for _, t := range rec.Targets {
+		target, err := url.Parse(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse journaled target %q: %w", t, err)
+		}
+		task.Targets = append(task.Targets, target)
+	}
+	return task, nil
+}
+
+// redisStreamer is the subset of the Redis client used by the redis journal.
+type redisStreamer interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	XDel(ctx context.Context, stream string, ids ...string) *redis.IntCmd
+	XRange(ctx context.Context, stream, start, stop string) *redis.XMessageSliceCmd
+}
+
+const redisJournalField = "task"
+
+// redisJournal journals tasks in a Redis stream, deleting entries once they are processed.
+type redisJournal struct {
+	client redisStreamer
+	stream string
+}
+
+// NewRedisJournal creates a journal backed by the given Redis stream.
+func NewRedisJournal(client redisStreamer, stream string) (*redisJournal, error) {
+	if client == nil {
+		slog.Error("NewRedisJournal: client cannot be nil")
+		return nil, errors.New("redis client cannot be nil")
+	}
+	if stream == "" {
+		slog.Error("NewRedisJournal: stream cannot be empty")
+		return nil, errors.New("journal stream cannot be empty")
+	}
+	return &redisJournal{client: client, stream: stream}, nil
+}
+
+// Append records the task in the stream and returns its entry ID.
+func (j *redisJournal) Append(ctx context.Context, task *model.AsyncTask) (string, error) {
+	b, err := encodeJournalTask(task)
+	if err != nil {
+		return "", err
+	}
+	id, err := j.client.XAdd(ctx, &redis.XAddArgs{Stream: j.stream, Values: map[string]any{redisJournalField: b}}).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to append to journal stream %s: %w", j.stream, err)
+	}
+	return id, nil
+}
+
+// Ack removes a processed entry from the stream.
+func (j *redisJournal) Ack(ctx context.Context, id string) error {
+	if err := j.client.XDel(ctx, j.stream, id).Err(); err != nil {
+		return fmt.Errorf("failed to delete journal entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// Pending returns all unacknowledged entries in the order they were appended.
+// Entries that cannot be decoded are logged and skipped.
+func (j *redisJournal) Pending(ctx context.Context) ([]*JournalEntry, error) {
+	msgs, err := j.client.XRange(ctx, j.stream, "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal stream %s: %w", j.stream, err)
+	}
+	entries := make([]*JournalEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		raw, _ := msg.Values[redisJournalField].(string)
+		task, err := decodeJournalTask([]byte(raw))
+		if err != nil {
+			slog.ErrorContext(ctx, "RedisJournal: Skipping corrupt journal entry", "id", msg.ID, "error", err)
+			continue
+		}
+		entries = append(entries, &JournalEntry{ID: msg.ID, Task: task})
+	}
+	return entries, nil
+}
+
+const diskJournalExt = ".json"
+
+// diskJournal journals tasks as one file per entry in a local directory.
+// Entries are written to a temporary file, synced and renamed into place so a
+// crash never leaves a partially written entry behind.
+type diskJournal struct {
+	dir string
+
+	mu   sync.Mutex
+	last int64
+	now  func() time.Time
+}
+
+// NewDiskJournal creates a journal in dir, creating the directory if necessary.
+func NewDiskJournal(dir string) (*diskJournal, error) {
+	if dir == "" {
+		slog.Error("NewDiskJournal: dir cannot be empty")
+		return nil, errors.New("journal dir cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create journal dir %s: %w", dir, err)
+	}
+	return &diskJournal{dir: dir, now: time.Now}, nil
+}
+
+// nextID returns a strictly increasing, lexically sortable entry ID.
+func (j *diskJournal) nextID() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := j.now().UnixNano()
+	if n <= j.last {
+		n = j.last + 1
+	}
+	j.last = n
+	return fmt.Sprintf("%020d", n)
+}
+
+// Append durably writes the task to the journal directory and returns its entry ID.
+func (j *diskJournal) Append(ctx context.Context, task *model.AsyncTask) (string, error) {
+	b, err := encodeJournalTask(task)
+	if err != nil {
+		return "", err
+	}
+	id := j.nextID()
+	tmp, err := os.CreateTemp(j.dir, id+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create journal entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to sync journal entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close journal entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path(id)); err != nil {
+		return "", fmt.Errorf("failed to commit journal entry: %w", err)
+	}
+	return id, nil
+}
+
+// Ack removes a processed entry from the journal directory.
+func (j *diskJournal) Ack(ctx context.Context, id string) error {
+	if err := os.Remove(j.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete journal entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// Pending returns all unacknowledged entries in the order they were appended.
+// Entries that cannot be decoded are logged and skipped.
+func (j *diskJournal) Pending(ctx context.Context) ([]*JournalEntry, error) {
+	files, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal dir %s: %w", j.dir, err)
+	}
+	var ids []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), diskJournalExt) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(f.Name(), diskJournalExt))
+	}
+	slices.Sort(ids)
+	entries := make([]*JournalEntry, 0, len(ids))
+	for _, id := range ids {
+		b, err := os.ReadFile(j.path(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal entry %s: %w", id, err)
+		}
+		task, err := decodeJournalTask(b)
+		if err != nil {
+			slog.ErrorContext(ctx, "DiskJournal: Skipping corrupt journal entry", "id", id, "error", err)
+			continue
+		}
+		entries = append(entries, &JournalEntry{ID: id, Task: task})
+	}
+	return entries, nil
+}
+
+func (j *diskJournal) path(id string) string {
+	return filepath.Join(j.dir, id+diskJournalExt)
+}
@@ -0,0 +1,296 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/go-cmp/cmp"
+	"github.com/redis/go-redis/v9"
+)
+
+func testJournalTask(t *testing.T) *model.AsyncTask {
+	t.Helper()
+	target, err := url.Parse("http://bpp.com/search")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	return &model.AsyncTask{
+		Type:    model.AsyncTaskTypeProxy,
+		Target:  target,
+		Body:    []byte(`{"context":{"action":"search"}}`),
+		Headers: http.Header{"Authorization": []string{"sig"}},
+		Context: model.Context{Action: "search", BppURI: "http://bpp.com"},
+	}
+}
+
+func TestJournalTask_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		task *model.AsyncTask
+	}{
+		{name: "proxy task", task: testJournalTask(t)},
+		{name: "lookup task without target", task: &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Context: model.Context{Action: "search"}}},
+		{name: "proxy batch task", task: &model.AsyncTask{
+			Type:    model.AsyncTaskTypeProxyBatch,
+			Targets: []*url.URL{{Scheme: "http", Host: "bpp.com", Path: "/a/search"}, {Scheme: "http", Host: "bpp.com", Path: "/b/search"}},
+			Body:    []byte(`{}`),
+			Context: model.Context{Action: "search"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := encodeJournalTask(tt.task)
+			if err != nil {
+				t.Fatalf("encodeJournalTask() error = %v", err)
+			}
+			got, err := decodeJournalTask(b)
+			if err != nil {
+				t.Fatalf("decodeJournalTask() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.task, got); diff != "" {
+				t.Errorf("decodeJournalTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewJournal(t *testing.T) {
+	client, _ := redismock.NewClientMock()
+	tests := []struct {
+		name    string
+		cfg     *JournalConfig
+		wantErr bool
+	}{
+		{name: "redis", cfg: &JournalConfig{Type: JournalTypeRedis, Stream: "gateway:journal"}},
+		{name: "disk", cfg: &JournalConfig{Type: JournalTypeDisk, Dir: t.TempDir()}},
+		{name: "nil config", wantErr: true},
+		{name: "unknown type", cfg: &JournalConfig{Type: "kafka"}, wantErr: true},
+		{name: "redis without stream", cfg: &JournalConfig{Type: JournalTypeRedis}, wantErr: true},
+		{name: "disk without dir", cfg: &JournalConfig{Type: JournalTypeDisk}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := NewJournal(tt.cfg, client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewJournal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && j == nil {
+				t.Error("NewJournal() returned nil journal")
+			}
+		})
+	}
+}
+
+func TestDiskJournal_AppendPendingAck(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewDiskJournal(filepath.Join(t.TempDir(), "journal"))
+	if err != nil {
+		t.Fatalf("NewDiskJournal() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	j.now = func() time.Time { return now } // Same timestamp for every entry still yields ordered IDs.
+
+	task := testJournalTask(t)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := j.Append(ctx, task)
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := os.WriteFile(filepath.Join(j.dir, "00000000000000000000.json"), []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write corrupt entry: %v", err)
+	}
+
+	if err := j.Ack(ctx, ids[1]); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	// Acking an already removed entry is not an error.
+	if err := j.Ack(ctx, ids[1]); err != nil {
+		t.Fatalf("second Ack() error = %v", err)
+	}
+
+	pending, err := j.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	want := []*JournalEntry{{ID: ids[0], Task: task}, {ID: ids[2], Task: task}}
+	if diff := cmp.Diff(want, pending); diff != "" {
+		t.Errorf("Pending() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiskJournal_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	j, err := NewDiskJournal(dir)
+	if err != nil {
+		t.Fatalf("NewDiskJournal() error = %v", err)
+	}
+	id, err := j.Append(ctx, testJournalTask(t))
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	reopened, err := NewDiskJournal(dir)
+	if err != nil {
+		t.Fatalf("NewDiskJournal() on reopen error = %v", err)
+	}
+	pending, err := reopened.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != id {
+		t.Errorf("Pending() after reopen = %v, want entry %s", pending, id)
+	}
+}
+
+func TestRedisJournal_Append(t *testing.T) {
+	ctx := context.Background()
+	task := testJournalTask(t)
+	b, err := encodeJournalTask(task)
+	if err != nil {
+		t.Fatalf("encodeJournalTask() error = %v", err)
+	}
+	args := &redis.XAddArgs{Stream: "journal", Values: map[string]any{redisJournalField: b}}
+
+	t.Run("success", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		mock.ExpectXAdd(args).SetVal("1-0")
+		j, _ := NewRedisJournal(client, "journal")
+
+		id, err := j.Append(ctx, task)
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if id != "1-0" {
+			t.Errorf("Append() id = %q, want %q", id, "1-0")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		mock.ExpectXAdd(args).SetErr(errors.New("connection refused"))
+		j, _ := NewRedisJournal(client, "journal")
+
+		if _, err := j.Append(ctx, task); err == nil {
+			t.Error("Append() error = nil, want error")
+		}
+	})
+}
+
+func TestRedisJournal_Ack(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "success"},
+		{name: "redis error", err: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mock := redismock.NewClientMock()
+			if tt.err != nil {
+				mock.ExpectXDel("journal", "1-0").SetErr(tt.err)
+			} else {
+				mock.ExpectXDel("journal", "1-0").SetVal(1)
+			}
+			j, _ := NewRedisJournal(client, "journal")
+
+			if err := j.Ack(ctx, "1-0"); (err != nil) != tt.wantErr {
+				t.Errorf("Ack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedisJournal_Pending(t *testing.T) {
+	ctx := context.Background()
+	task := testJournalTask(t)
+	b, err := encodeJournalTask(task)
+	if err != nil {
+		t.Fatalf("encodeJournalTask() error = %v", err)
+	}
+
+	t.Run("success skips corrupt entries", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		mock.ExpectXRange("journal", "-", "+").SetVal([]redis.XMessage{
+			{ID: "1-0", Values: map[string]any{redisJournalField: string(b)}},
+			{ID: "2-0", Values: map[string]any{redisJournalField: "not json"}},
+			{ID: "3-0", Values: map[string]any{redisJournalField: string(b)}},
+		})
+		j, _ := NewRedisJournal(client, "journal")
+
+		got, err := j.Pending(ctx)
+		if err != nil {
+			t.Fatalf("Pending() error = %v", err)
+		}
+		want := []*JournalEntry{{ID: "1-0", Task: task}, {ID: "3-0", Task: task}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Pending() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		mock.ExpectXRange("journal", "-", "+").SetErr(errors.New("connection refused"))
+		j, _ := NewRedisJournal(client, "journal")
+
+		if _, err := j.Pending(ctx); err == nil {
+			t.Error("Pending() error = nil, want error")
+		}
+	})
+}
+
+func TestJournalEntryTime(t *testing.T) {
+	tests := []struct {
+		id     string
+		want   time.Time
+		wantOK bool
+	}{
+		{id: "1735689600000-3", want: time.UnixMilli(1735689600000), wantOK: true},
+		{id: "01735689600000000000", want: time.Unix(0, 1735689600000000000), wantOK: true},
+		{id: "x-0"},
+		{id: "xx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, ok := journalEntryTime(tt.id)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("journalEntryTime(%q) = %v, %v, want %v, %v", tt.id, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
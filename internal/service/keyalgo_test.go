@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	becknsigner "github.com/beckn/beckn-onix/pkg/plugin/implementation/signer"
+	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signvalidator"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+)
+
+func TestNewKeyAlgoSigner_NilSigner(t *testing.T) {
+	if _, err := NewKeyAlgoSigner(nil); err == nil {
+		t.Error("NewKeyAlgoSigner(nil) error = nil, want error")
+	}
+}
+
+func TestNewKeyAlgoSignValidator_NilValidator(t *testing.T) {
+	if _, err := NewKeyAlgoSignValidator(nil); err == nil {
+		t.Error("NewKeyAlgoSignValidator(nil) error = nil, want error")
+	}
+}
+
+func TestKeyAlgoSigner_Ed25519UsesBecknSigner(t *testing.T) {
+	s, err := NewKeyAlgoSigner(&mockSigner{signature: "beckn-signature"})
+	if err != nil {
+		t.Fatalf("NewKeyAlgoSigner() error = %v", err)
+	}
+	priv, _, err := keyalgo.GenerateSigningKey(keyalgo.Ed25519)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	got, err := s.Sign(context.Background(), []byte("body"), priv, 1, 2)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if got != "beckn-signature" {
+		t.Errorf("Sign() = %q, want the Beckn signer's signature", got)
+	}
+}
+
+// keyAlgoAuthHeader signs body with a new keyset of alg and returns the header and public key.
+func keyAlgoAuthHeader(t *testing.T, alg keyalgo.Algorithm, body []byte, created, expires time.Time) (string, string) {
+	t.Helper()
+	becknSigner, _, err := becknsigner.New(context.Background(), &becknsigner.Config{})
+	if err != nil {
+		t.Fatalf("becknsigner.New() error = %v", err)
+	}
+	s, err := NewKeyAlgoSigner(becknSigner)
+	if err != nil {
+		t.Fatalf("NewKeyAlgoSigner() error = %v", err)
+	}
+	priv, pub, err := keyalgo.GenerateSigningKey(alg)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	sig, err := s.Sign(context.Background(), body, priv, created.Unix(), expires.Unix())
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	header := fmt.Sprintf(`Signature keyId="np.example.com|key-1|%s",algorithm="%s",created="%d",expires="%d",headers="(created) (expires) digest",signature="%s"`,
+		alg, alg, created.Unix(), expires.Unix(), sig)
+	return header, pub
+}
+
+func TestKeyAlgoSignValidator_RoundTrip(t *testing.T) {
+	becknValidator, _, err := signvalidator.New(context.Background(), &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	v, err := NewKeyAlgoSignValidator(becknValidator)
+	if err != nil {
+		t.Fatalf("NewKeyAlgoSignValidator() error = %v", err)
+	}
+	now := time.Now()
+	for _, alg := range []keyalgo.Algorithm{keyalgo.Ed25519, keyalgo.Secp256k1} {
+		t.Run(string(alg), func(t *testing.T) {
+			body := []byte(`{"context":{"action":"search"}}`)
+			header, pub := keyAlgoAuthHeader(t, alg, body, now.Add(-time.Minute), now.Add(time.Minute))
+			if err := v.Validate(context.Background(), body, header, pub); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if err := v.Validate(context.Background(), []byte(`{"tampered":true}`), header, pub); err == nil {
+				t.Error("Validate() of tampered body error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestKeyAlgoSignValidator_Secp256k1Errors(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"message":"hello"}`)
+	header, pub := keyAlgoAuthHeader(t, keyalgo.Secp256k1, body, now.Add(-time.Minute), now.Add(time.Minute))
+	expiredHeader, expiredPub := keyAlgoAuthHeader(t, keyalgo.Secp256k1, body, now.Add(-time.Hour), now.Add(-time.Minute))
+	_, edPub := keyAlgoAuthHeader(t, keyalgo.Ed25519, body, now.Add(-time.Minute), now.Add(time.Minute))
+	_, otherPub := keyAlgoAuthHeader(t, keyalgo.Secp256k1, body, now.Add(-time.Minute), now.Add(time.Minute))
+
+	tests := []struct {
+		name   string
+		header string
+		pub    string
+	}{
+		{name: "other key", header: header, pub: otherPub},
+		{name: "expired", header: expiredHeader, pub: expiredPub},
+		{name: "ed25519 named for secp256k1 key", header: replaceParam(header, "algorithm", "ed25519"), pub: pub},
+		{name: "secp256k1 named for ed25519 key", header: header, pub: edPub},
+		{name: "missing signature", header: replaceParam(header, "signature", ""), pub: pub},
+		{name: "malformed signature", header: replaceParam(header, "signature", "%%%"), pub: pub},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The Beckn validator accepts everything, so only keyAlgoSignValidator can reject.
+			v, err := NewKeyAlgoSignValidator(&mockSignValidator{})
+			if err != nil {
+				t.Fatalf("NewKeyAlgoSignValidator() error = %v", err)
+			}
+			if err := v.Validate(context.Background(), body, tt.header, tt.pub); err == nil {
+				t.Error("Validate() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestKeyAlgoSignValidator_Ed25519UsesBecknValidator(t *testing.T) {
+	wantErr := errors.New("beckn validation failed")
+	v, err := NewKeyAlgoSignValidator(&mockSignValidator{err: wantErr})
+	if err != nil {
+		t.Fatalf("NewKeyAlgoSignValidator() error = %v", err)
+	}
+	now := time.Now()
+	header, pub := keyAlgoAuthHeader(t, keyalgo.Ed25519, []byte("body"), now.Add(-time.Minute), now.Add(time.Minute))
+	for _, h := range []string{header, replaceParam(header, "algorithm", "hs2019")} {
+		if err := v.Validate(context.Background(), []byte("body"), h, pub); !errors.Is(err, wantErr) {
+			t.Errorf("Validate(%q) error = %v, want %v", h, err, wantErr)
+		}
+	}
+}
+
+// replaceParam replaces the value of a parameter of an Authorization header.
+func replaceParam(header, name, value string) string {
+	old, _ := signatureParam(header, name)
+	return strings.Replace(header, fmt.Sprintf(`%s="%s"`, name, old), fmt.Sprintf(`%s="%s"`, name, value), 1)
+}
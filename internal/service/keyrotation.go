@@ -0,0 +1,267 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/uuid"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+// Key rotation errors.
+var (
+	ErrKeyRotationDisabled  = errors.New("key rotation is not enabled")
+	ErrInvalidRotationToken = errors.New("invalid key rotation token")
+	ErrRotationInProgress   = errors.New("a key rotation is already in progress")
+	ErrNoActiveKeyset       = errors.New("no active keyset to rotate")
+)
+
+const (
+	defaultRotationPollInterval = 10 * time.Second
+	defaultRotationTimeout      = 24 * time.Hour
+)
+
+// KeyRotationConfig configures on-demand key rotation on the subscriber service.
+type KeyRotationConfig struct {
+	// TokenSHA256 is the hex encoded SHA-256 hash of the bearer token that authorizes rotations.
+	TokenSHA256 string `yaml:"tokenSHA256"`
+	// PollInterval is how often the registry is polled for the rotation's operation. Defaults to 10s.
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// Timeout is how long a rotation waits for approval before it is abandoned. Defaults to 24h.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// keyRotator tracks the rotation in flight and the goroutine waiting for its approval.
+type keyRotator struct {
+	tokenHash    []byte
+	pollInterval time.Duration
+	timeout      time.Duration
+
+	mu          sync.Mutex
+	operationID string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// keyRotatedPublisher publishes KEY_ROTATED events. It is satisfied by the event publisher.
+type keyRotatedPublisher interface {
+	PublishKeyRotatedEvent(ctx context.Context, ev *events.KeyRotated) (string, error)
+}
+
+// SetKeyRotatedPublisher publishes a KEY_ROTATED event whenever an approved operation
+// replaces the active keyset of the subscriber with a different key.
+func (s *subscriberService) SetKeyRotatedPublisher(p keyRotatedPublisher) {
+	s.rotatedPub = p
+}
+
+// publishKeyRotated publishes that keys replaced the keyset with ID previousKeyID. Failures are
+// only logged, as the new keyset is already active.
+func (s *subscriberService) publishKeyRotated(ctx context.Context, keys *becknmodel.Keyset, previousKeyID string) {
+	if s.rotatedPub == nil || previousKeyID == "" || previousKeyID == keys.UniqueKeyID {
+		return
+	}
+	ev := &events.KeyRotated{
+		SubscriberID:     keys.SubscriberID,
+		KeyID:            keys.UniqueKeyID,
+		PreviousKeyID:    previousKeyID,
+		SigningPublicKey: keys.SigningPublic,
+		EncrPublicKey:    keys.EncrPublic,
+		RotatedAt:        s.now(),
+	}
+	if _, err := s.rotatedPub.PublishKeyRotatedEvent(ctx, ev); err != nil {
+		slog.WarnContext(ctx, "SubscriberService: Failed to publish key rotated event", "subscriber_id", keys.SubscriberID, "key_id", keys.UniqueKeyID, "error", err)
+	}
+}
+
+// SetKeyRotation enables on-demand key rotation with the given config.
+func (s *subscriberService) SetKeyRotation(cfg *KeyRotationConfig) error {
+	if cfg == nil {
+		return errors.New("KeyRotationConfig cannot be nil")
+	}
+	tokenHash, err := parseTokenHash(cfg.TokenSHA256)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &keyRotator{tokenHash: tokenHash, pollInterval: cfg.PollInterval, timeout: cfg.Timeout, ctx: ctx, cancel: cancel}
+	if r.pollInterval <= 0 {
+		r.pollInterval = defaultRotationPollInterval
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultRotationTimeout
+	}
+	s.rotator = r
+	return nil
+}
+
+// AuthorizeRotation checks a bearer token against the configured key rotation token.
+func (s *subscriberService) AuthorizeRotation(token string) error {
+	if s.rotator == nil {
+		return ErrKeyRotationDisabled
+	}
+	if !tokenMatches(token, s.rotator.tokenHash) {
+		return ErrInvalidRotationToken
+	}
+	return nil
+}
+
+// parseTokenHash decodes the hex encoded SHA-256 hash of a bearer token.
+func parseTokenHash(tokenSHA256 string) ([]byte, error) {
+	tokenHash, err := hex.DecodeString(tokenSHA256)
+	if err != nil || len(tokenHash) != sha256.Size {
+		return nil, errors.New("tokenSHA256 must be a hex encoded SHA-256 hash")
+	}
+	return tokenHash, nil
+}
+
+// tokenMatches reports whether a non-empty bearer token has the given SHA-256 hash.
+func tokenMatches(token string, tokenHash []byte) bool {
+	sum := sha256.Sum256([]byte(token))
+	return token != "" && subtle.ConstantTimeCompare(sum[:], tokenHash) == 1
+}
+
+// Stop stops the key watch and waiting for a rotation in flight. The pending
+// keyset is kept, so the rotation can still be completed through UpdateStatus.
+func (s *subscriberService) Stop() {
+	s.stopKeyWatch()
+	if s.rotator == nil {
+		return
+	}
+	s.rotator.cancel()
+	s.rotator.wg.Wait()
+}
+
+// RotateKeys generates a new keyset for the subscriber and submits it to the registry
+// in an update subscription request. The current keyset stays active until the registry
+// approves the request, at which point the new keyset replaces it. If the request is
+// rejected or not approved in time, the new keyset is discarded.
+// It returns the ID of the registry operation.
+func (s *subscriberService) RotateKeys(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
+	r := s.rotator
+	if r == nil {
+		return "", ErrKeyRotationDisabled
+	}
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.operationID != "" {
+		slog.WarnContext(ctx, "SubscriberService: Key rotation already in progress", "message_id", r.operationID)
+		return "", fmt.Errorf("%w: operation %s", ErrRotationInProgress, r.operationID)
+	}
+
+	current, err := s.keyMgr.Keyset(ctx, req.SubscriberID)
+	if err != nil || current == nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to fetch active keyset for rotation", "subscriber_id", req.SubscriberID, "error", err)
+		return "", fmt.Errorf("%w: %v", ErrNoActiveKeyset, err)
+	}
+
+	rotation := *req
+	rotation.KeyID = ""
+	rotation.MessageID = uuid.NewString()
+	operationID, err := s.UpdateSubscription(ctx, &rotation)
+	if err != nil {
+		return "", err
+	}
+	next, err := s.keyMgr.Keyset(ctx, rotation.MessageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to fetch new keyset for rotation", "message_id", rotation.MessageID, "error", err)
+		return "", fmt.Errorf("%w: %v", ErrKeyFetchFailed, err)
+	}
+
+	r.operationID = operationID
+	r.wg.Add(1)
+	go s.awaitRotation(r, req.SubscriberID, operationID, next.UniqueKeyID)
+	slog.InfoContext(ctx, "SubscriberService: Key rotation started", "subscriber_id", req.SubscriberID, "message_id", operationID, "old_key_id", current.UniqueKeyID, "new_key_id", next.UniqueKeyID)
+	return operationID, nil
+}
+
+// awaitRotation polls the registry until the rotation's operation is approved,
+// rejected or times out.
+func (s *subscriberService) awaitRotation(r *keyRotator, subscriberID, operationID, keyID string) {
+	defer r.wg.Done()
+	defer func() {
+		r.mu.Lock()
+		r.operationID = ""
+		r.mu.Unlock()
+	}()
+
+	ctx := r.ctx
+	timeout := time.NewTimer(r.timeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.WarnContext(ctx, "SubscriberService: Stopped waiting for key rotation", "message_id", operationID)
+			return
+		case <-timeout.C:
+			slog.ErrorContext(ctx, "SubscriberService: Key rotation not approved in time", "message_id", operationID, "timeout", r.timeout)
+			s.abandonRotation(ctx, operationID)
+			return
+		case <-ticker.C:
+		}
+
+		status, err := s.UpdateStatus(ctx, operationID)
+		switch {
+		case err == nil:
+			slog.InfoContext(ctx, "SubscriberService: Key rotation completed", "subscriber_id", subscriberID, "message_id", operationID, "key_id", keyID)
+			return
+		case status == model.LROStatusRejected || status == model.LROStatusFailure:
+			slog.ErrorContext(ctx, "SubscriberService: Key rotation rejected by registry", "message_id", operationID, "status", status)
+			s.abandonRotation(ctx, operationID)
+			return
+		case errors.Is(err, ErrKeyFetchFailed) && s.activeKeyID(ctx, subscriberID) == keyID:
+			// The keyset was already swapped by a status update from the registry.
+			slog.InfoContext(ctx, "SubscriberService: Key rotation completed", "subscriber_id", subscriberID, "message_id", operationID, "key_id", keyID)
+			return
+		default:
+			slog.DebugContext(ctx, "SubscriberService: Key rotation not approved yet", "message_id", operationID, "status", status, "error", err)
+		}
+	}
+}
+
+// abandonRotation discards the keyset of a rotation that will not be approved.
+func (s *subscriberService) abandonRotation(ctx context.Context, operationID string) {
+	if err := s.keyMgr.DeleteKeyset(ctx, operationID); err != nil {
+		slog.WarnContext(ctx, "SubscriberService: Failed to delete keyset of abandoned key rotation", "message_id", operationID, "error", err)
+	}
+}
+
+// activeKeyID returns the ID of the keyset currently stored for the subscriber.
+func (s *subscriberService) activeKeyID(ctx context.Context, subscriberID string) string {
+	keys, err := s.keyMgr.Keyset(ctx, subscriberID)
+	if err != nil || keys == nil {
+		return ""
+	}
+	return keys.UniqueKeyID
+}
@@ -0,0 +1,402 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+// rotationRegistry is a registryClient that echoes the message ID of update requests
+// as the operation ID and reports a configurable operation status.
+type rotationRegistry struct {
+	mu        sync.Mutex
+	status    model.LROStatus
+	updateErr error
+}
+
+func (m *rotationRegistry) CreateSubscription(ctx context.Context, req *model.SubscriptionRequest) (*model.SubscriptionResponse, error) {
+	return nil, errors.New("not implemented")
+}
+func (m *rotationRegistry) UpdateSubscription(ctx context.Context, req *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error) {
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	return &model.SubscriptionResponse{MessageID: req.MessageID, Status: "UNDER_SUBSCRIPTION"}, nil
+}
+func (m *rotationRegistry) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &model.LRO{OperationID: operationID, Status: m.status}, nil
+}
+
+func (m *rotationRegistry) setStatus(status model.LROStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = status
+}
+
+// mapKeyManager is a keyManager that stores keysets by key ID.
+type mapKeyManager struct {
+	mu        sync.Mutex
+	keysets   map[string]*becknmodel.Keyset
+	generated int
+}
+
+func newMapKeyManager(keysets map[string]*becknmodel.Keyset) *mapKeyManager {
+	return &mapKeyManager{keysets: keysets}
+}
+
+func (m *mapKeyManager) Keyset(ctx context.Context, keyID string) (*becknmodel.Keyset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ks, ok := m.keysets[keyID]
+	if !ok {
+		return nil, fmt.Errorf("keyset %s not found", keyID)
+	}
+	c := *ks
+	return &c, nil
+}
+func (m *mapKeyManager) GenerateKeyset() (*becknmodel.Keyset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.generated++
+	return &becknmodel.Keyset{UniqueKeyID: fmt.Sprintf("new-key-%d", m.generated)}, nil
+}
+func (m *mapKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *becknmodel.Keyset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *keyset
+	m.keysets[keyID] = &c
+	return nil
+}
+func (m *mapKeyManager) DeleteKeyset(ctx context.Context, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keysets, keyID)
+	return nil
+}
+func (m *mapKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	return "", "", nil
+}
+
+func (m *mapKeyManager) keyIDs() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make(map[string]string, len(m.keysets))
+	for k, ks := range m.keysets {
+		ids[k] = ks.UniqueKeyID
+	}
+	return ids
+}
+
+func newRotationService(t *testing.T, reg registryClient, km keyManager, cfg *KeyRotationConfig) *subscriberService {
+	t.Helper()
+	svc, err := NewSubscriberService(reg, km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{authHeader: "auth"}, "reg-id", "reg-key-id")
+	if err != nil {
+		t.Fatalf("NewSubscriberService() unexpected error: %v", err)
+	}
+	cfg.TokenSHA256 = rotationTokenHash
+	if err := svc.SetKeyRotation(cfg); err != nil {
+		t.Fatalf("SetKeyRotation() unexpected error: %v", err)
+	}
+	t.Cleanup(svc.Stop)
+	return svc
+}
+
+// waitForRotation waits until no rotation is in flight.
+func waitForRotation(t *testing.T, svc *subscriberService) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		svc.rotator.mu.Lock()
+		opID := svc.rotator.operationID
+		svc.rotator.mu.Unlock()
+		if opID == "" {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("key rotation did not finish in time")
+}
+
+// rotationTokenHash is the SHA-256 hash of "rotation-token".
+var rotationTokenHash = hex.EncodeToString(func() []byte { s := sha256.Sum256([]byte("rotation-token")); return s[:] }())
+
+var rotationReq = &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP}}
+
+func TestSubscriberService_SetKeyRotation_Defaults(t *testing.T) {
+	svc := newRotationService(t, &rotationRegistry{}, newMapKeyManager(nil), &KeyRotationConfig{})
+	if svc.rotator.pollInterval != defaultRotationPollInterval {
+		t.Errorf("pollInterval = %v, want %v", svc.rotator.pollInterval, defaultRotationPollInterval)
+	}
+	if svc.rotator.timeout != defaultRotationTimeout {
+		t.Errorf("timeout = %v, want %v", svc.rotator.timeout, defaultRotationTimeout)
+	}
+}
+
+func TestSubscriberService_SetKeyRotation_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *KeyRotationConfig
+	}{
+		{name: "nil config"},
+		{name: "missing token hash", cfg: &KeyRotationConfig{}},
+		{name: "token hash not hex", cfg: &KeyRotationConfig{TokenSHA256: "not-hex"}},
+		{name: "token hash too short", cfg: &KeyRotationConfig{TokenSHA256: "abcd"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			if err := svc.SetKeyRotation(tc.cfg); err == nil {
+				t.Error("SetKeyRotation() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSubscriberService_AuthorizeRotation(t *testing.T) {
+	svc := newRotationService(t, &rotationRegistry{}, newMapKeyManager(nil), &KeyRotationConfig{})
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid token", token: "rotation-token"},
+		{name: "wrong token", token: "other-token", wantErr: ErrInvalidRotationToken},
+		{name: "empty token", token: "", wantErr: ErrInvalidRotationToken},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := svc.AuthorizeRotation(tc.token); !errors.Is(err, tc.wantErr) {
+				t.Errorf("AuthorizeRotation() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	disabled, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	if err := disabled.AuthorizeRotation("rotation-token"); !errors.Is(err, ErrKeyRotationDisabled) {
+		t.Errorf("AuthorizeRotation() error = %v, want %v", err, ErrKeyRotationDisabled)
+	}
+}
+
+func TestSubscriberService_RotateKeys_Outcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     model.LROStatus
+		timeout    time.Duration
+		wantActive string
+	}{
+		{name: "approved", status: model.LROStatusApproved, timeout: time.Minute, wantActive: "new-key-1"},
+		{name: "rejected", status: model.LROStatusRejected, timeout: time.Minute, wantActive: "old-key"},
+		{name: "timed out", status: model.LROStatusPending, timeout: 10 * time.Millisecond, wantActive: "old-key"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := &rotationRegistry{status: tc.status}
+			km := newMapKeyManager(map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key"}})
+			svc := newRotationService(t, reg, km, &KeyRotationConfig{PollInterval: time.Millisecond, Timeout: tc.timeout})
+
+			opID, err := svc.RotateKeys(context.Background(), rotationReq)
+			if err != nil {
+				t.Fatalf("RotateKeys() unexpected error: %v", err)
+			}
+			if opID == "" {
+				t.Fatal("RotateKeys() returned empty operation ID")
+			}
+			waitForRotation(t, svc)
+
+			ids := km.keyIDs()
+			if ids["sub1"] != tc.wantActive {
+				t.Errorf("active keyset = %q, want %q", ids["sub1"], tc.wantActive)
+			}
+			if _, ok := ids[opID]; ok {
+				t.Errorf("keyset for operation %s was not removed", opID)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_RotateKeys_AlreadySwapped(t *testing.T) {
+	reg := &rotationRegistry{status: model.LROStatusApproved}
+	km := newMapKeyManager(map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key"}})
+	svc := newRotationService(t, reg, km, &KeyRotationConfig{PollInterval: 20 * time.Millisecond, Timeout: time.Minute})
+
+	opID, err := svc.RotateKeys(context.Background(), rotationReq)
+	if err != nil {
+		t.Fatalf("RotateKeys() unexpected error: %v", err)
+	}
+	// The registry notifies the subscriber before the rotation polls it.
+	if _, err := svc.UpdateStatus(context.Background(), opID); err != nil {
+		t.Fatalf("UpdateStatus() unexpected error: %v", err)
+	}
+	waitForRotation(t, svc)
+
+	if got := km.keyIDs()["sub1"]; got != "new-key-1" {
+		t.Errorf("active keyset = %q, want %q", got, "new-key-1")
+	}
+}
+
+func TestSubscriberService_RotateKeys_InProgress(t *testing.T) {
+	reg := &rotationRegistry{status: model.LROStatusPending}
+	km := newMapKeyManager(map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key"}})
+	svc := newRotationService(t, reg, km, &KeyRotationConfig{PollInterval: time.Millisecond, Timeout: time.Minute})
+
+	if _, err := svc.RotateKeys(context.Background(), rotationReq); err != nil {
+		t.Fatalf("RotateKeys() unexpected error: %v", err)
+	}
+	if _, err := svc.RotateKeys(context.Background(), rotationReq); !errors.Is(err, ErrRotationInProgress) {
+		t.Errorf("RotateKeys() error = %v, want %v", err, ErrRotationInProgress)
+	}
+
+	reg.setStatus(model.LROStatusApproved)
+	waitForRotation(t, svc)
+	if _, err := svc.RotateKeys(context.Background(), rotationReq); err != nil {
+		t.Errorf("RotateKeys() after completed rotation unexpected error: %v", err)
+	}
+}
+
+func TestSubscriberService_RotateKeys_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		reg     *rotationRegistry
+		keysets map[string]*becknmodel.Keyset
+		req     *model.NpSubscriptionRequest
+		wantErr error
+	}{
+		{
+			name:    "missing subscriber ID",
+			reg:     &rotationRegistry{},
+			keysets: map[string]*becknmodel.Keyset{},
+			req:     &model.NpSubscriptionRequest{Subscriber: model.Subscriber{Domain: "test.com", Type: model.RoleBAP}},
+			wantErr: model.ErrValidation,
+		},
+		{
+			name:    "no active keyset",
+			reg:     &rotationRegistry{},
+			keysets: map[string]*becknmodel.Keyset{},
+			req:     rotationReq,
+			wantErr: ErrNoActiveKeyset,
+		},
+		{
+			name:    "registry update fails",
+			reg:     &rotationRegistry{updateErr: errors.New("registry down")},
+			keysets: map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key"}},
+			req:     rotationReq,
+			wantErr: ErrRegistryOperationFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newRotationService(t, tc.reg, newMapKeyManager(tc.keysets), &KeyRotationConfig{})
+
+			if _, err := svc.RotateKeys(context.Background(), tc.req); !errors.Is(err, tc.wantErr) {
+				t.Errorf("RotateKeys() error = %v, want %v", err, tc.wantErr)
+			}
+			if svc.rotator.operationID != "" {
+				t.Errorf("rotation in progress after failure: %s", svc.rotator.operationID)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_RotateKeys_Disabled(t *testing.T) {
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+
+	if _, err := svc.RotateKeys(context.Background(), rotationReq); !errors.Is(err, ErrKeyRotationDisabled) {
+		t.Errorf("RotateKeys() error = %v, want %v", err, ErrKeyRotationDisabled)
+	}
+	svc.Stop()
+}
+
+// recordingKeyRotatedPublisher records the KEY_ROTATED events it publishes.
+type recordingKeyRotatedPublisher struct {
+	events []*events.KeyRotated
+	err    error
+}
+
+func (p *recordingKeyRotatedPublisher) PublishKeyRotatedEvent(ctx context.Context, ev *events.KeyRotated) (string, error) {
+	p.events = append(p.events, ev)
+	return "msg-1", p.err
+}
+
+func TestSubscriberService_UpdateStatus_KeyRotatedEvent(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	next := &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-2", SigningPublic: "sign-2", EncrPublic: "encr-2"}
+	tests := []struct {
+		name       string
+		active     *becknmodel.Keyset
+		publishErr error
+		want       []*events.KeyRotated
+	}{
+		{
+			name:   "keys replaced",
+			active: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-1"},
+			want:   []*events.KeyRotated{{SubscriberID: "sub1", KeyID: "key-2", PreviousKeyID: "key-1", SigningPublicKey: "sign-2", EncrPublicKey: "encr-2", RotatedAt: now}},
+		},
+		{
+			name:       "publish fails",
+			active:     &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-1"},
+			publishErr: errors.New("pubsub down"),
+			want:       []*events.KeyRotated{{SubscriberID: "sub1", KeyID: "key-2", PreviousKeyID: "key-1", SigningPublicKey: "sign-2", EncrPublicKey: "encr-2", RotatedAt: now}},
+		},
+		{name: "first keys"},
+		{name: "same keys", active: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keysets := map[string]*becknmodel.Keyset{"op1": next}
+			if tt.active != nil {
+				keysets["sub1"] = tt.active
+			}
+			km := newMapKeyManager(keysets)
+			reg := &rotationRegistry{status: model.LROStatusApproved}
+			svc, err := NewSubscriberService(reg, km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			if err != nil {
+				t.Fatalf("NewSubscriberService() unexpected error: %v", err)
+			}
+			svc.now = func() time.Time { return now }
+			pub := &recordingKeyRotatedPublisher{err: tt.publishErr}
+			svc.SetKeyRotatedPublisher(pub)
+
+			if _, err := svc.UpdateStatus(ctx, "op1"); err != nil {
+				t.Fatalf("UpdateStatus() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, pub.events); diff != "" {
+				t.Errorf("published KEY_ROTATED events mismatch (-want +got):\n%s", diff)
+			}
+			if got := km.keyIDs()["sub1"]; got != "key-2" {
+				t.Errorf("active key ID = %q, want key-2", got)
+			}
+		})
+	}
+}
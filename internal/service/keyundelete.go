@@ -0,0 +1,53 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import "errors"
+
+// Keyset undelete errors.
+var (
+	ErrKeyUndeleteDisabled  = errors.New("keyset undelete is not enabled")
+	ErrInvalidUndeleteToken = errors.New("invalid keyset undelete token")
+)
+
+// KeyUndeleteConfig configures the recovery of soft deleted keysets on the subscriber service.
+type KeyUndeleteConfig struct {
+	// TokenSHA256 is the hex encoded SHA-256 hash of the bearer token that authorizes recovering keysets.
+	TokenSHA256 string `yaml:"tokenSHA256"`
+}
+
+// SetKeyUndelete enables recovering soft deleted keysets with the given config.
+func (s *subscriberService) SetKeyUndelete(cfg *KeyUndeleteConfig) error {
+	if cfg == nil {
+		return errors.New("KeyUndeleteConfig cannot be nil")
+	}
+	tokenHash, err := parseTokenHash(cfg.TokenSHA256)
+	if err != nil {
+		return err
+	}
+	s.undeleteTokenHash = tokenHash
+	return nil
+}
+
+// AuthorizeUndelete checks a bearer token against the configured keyset undelete token.
+func (s *subscriberService) AuthorizeUndelete(token string) error {
+	if s.undeleteTokenHash == nil {
+		return ErrKeyUndeleteDisabled
+	}
+	if !tokenMatches(token, s.undeleteTokenHash) {
+		return ErrInvalidUndeleteToken
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// undeleteTokenHash is the SHA-256 hash of "undelete-token".
+var undeleteTokenHash = hex.EncodeToString(func() []byte { s := sha256.Sum256([]byte("undelete-token")); return s[:] }())
+
+func TestSubscriberService_SetKeyUndelete_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *KeyUndeleteConfig
+	}{
+		{name: "nil config"},
+		{name: "missing token hash", cfg: &KeyUndeleteConfig{}},
+		{name: "token hash not hex", cfg: &KeyUndeleteConfig{TokenSHA256: "not-hex"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			if err := svc.SetKeyUndelete(tc.cfg); err == nil {
+				t.Error("SetKeyUndelete() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSubscriberService_AuthorizeUndelete(t *testing.T) {
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	if err := svc.AuthorizeUndelete("undelete-token"); !errors.Is(err, ErrKeyUndeleteDisabled) {
+		t.Errorf("AuthorizeUndelete() before SetKeyUndelete() error = %v, want %v", err, ErrKeyUndeleteDisabled)
+	}
+	if err := svc.SetKeyUndelete(&KeyUndeleteConfig{TokenSHA256: undeleteTokenHash}); err != nil {
+		t.Fatalf("SetKeyUndelete() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid token", token: "undelete-token"},
+		{name: "wrong token", token: "other-token", wantErr: ErrInvalidUndeleteToken},
+		{name: "empty token", token: "", wantErr: ErrInvalidUndeleteToken},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := svc.AuthorizeUndelete(tc.token); !errors.Is(err, tc.wantErr) {
+				t.Errorf("AuthorizeUndelete() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultKeyWatchPollInterval = 5 * time.Minute
+	defaultKeyWatchRenewBefore  = 7 * 24 * time.Hour
+)
+
+// KeyWatchConfig configures polling of the registry for invalidation of the subscriber's keys.
+type KeyWatchConfig struct {
+	// SubscriberID is the subscriber whose registry entries are watched.
+	SubscriberID string `yaml:"subscriberID"`
+	// PollInterval is how often the registry is polled. Defaults to 5m.
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// RenewBefore is how long before valid_until a keyset is reported as expiring. Defaults to 168h.
+	RenewBefore time.Duration `yaml:"renewBefore"`
+	// AutoRenew rotates expiring keys and re-subscribes when the registry no longer
+	// accepts the active keyset. When false, invalidations are only reported.
+	AutoRenew bool `yaml:"autoRenew"`
+}
+
+// subscriptionLookup looks up subscriptions in the registry.
+type subscriptionLookup interface {
+	Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error)
+}
+
+// keyInvalidation is the reason the registry no longer accepts the subscriber's active keyset.
+type keyInvalidation string
+
+const (
+	keyNotRegistered        keyInvalidation = "not_registered"        // The registry has no entry for the subscriber.
+	keyMissing              keyInvalidation = "keyset_missing"        // The key manager has no active keyset.
+	keyRevoked              keyInvalidation = "key_revoked"           // The registry holds a different key.
+	keySubscriptionInactive keyInvalidation = "subscription_inactive" // The registry entry is expired, unsubscribed or has an invalid SSL.
+	keyExpired              keyInvalidation = "key_expired"           // The key is past its valid_until.
+	keyExpiring             keyInvalidation = "key_expiring"          // The key expires within RenewBefore.
+)
+
+// keyInvalidationMetrics counts key invalidations detected by the key watcher, by reason.
+var keyInvalidationMetrics = expvar.NewMap("subscriber_key_invalidations")
+
+// keyWatcher polls the registry for invalidation of the subscriber's keys.
+type keyWatcher struct {
+	lookup       subscriptionLookup
+	subscriberID string
+	pollInterval time.Duration
+	renewBefore  time.Duration
+	autoRenew    bool
+
+	// pending is the operation ID of a re-subscription awaiting approval.
+	pending string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// SetKeyWatch enables polling of the registry for invalidation of the subscriber's keys.
+// Polling starts with StartKeyWatch.
+func (s *subscriberService) SetKeyWatch(cfg *KeyWatchConfig, lookup subscriptionLookup) error {
+	if cfg == nil {
+		return errors.New("KeyWatchConfig cannot be nil")
+	}
+	if lookup == nil {
+		return errors.New("subscriptionLookup cannot be nil")
+	}
+	if cfg.SubscriberID == "" {
+		return ErrMissingSubscriberID
+	}
+	w := &keyWatcher{lookup: lookup, subscriberID: cfg.SubscriberID, pollInterval: cfg.PollInterval, renewBefore: cfg.RenewBefore, autoRenew: cfg.AutoRenew}
+	if w.pollInterval <= 0 {
+		w.pollInterval = defaultKeyWatchPollInterval
+	}
+	if w.renewBefore <= 0 {
+		w.renewBefore = defaultKeyWatchRenewBefore
+	}
+	s.watcher = w
+	return nil
+}
+
+// StartKeyWatch starts polling the registry in the background until Stop is called.
+// It does nothing if the key watch is not enabled.
+func (s *subscriberService) StartKeyWatch() {
+	w := s.watcher
+	if w == nil || w.cancel != nil {
+		return
+	}
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(context.Background())
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		slog.InfoContext(ctx, "SubscriberService: Starting key watch", "subscriber_id", w.subscriberID, "interval", w.pollInterval)
+		ticker := time.NewTicker(w.pollInterval)
+		defer ticker.Stop()
+		for {
+			s.checkKeys(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopKeyWatch stops the key watch started by StartKeyWatch.
+func (s *subscriberService) stopKeyWatch() {
+	if s.watcher == nil || s.watcher.cancel == nil {
+		return
+	}
+	s.watcher.cancel()
+	s.watcher.wg.Wait()
+}
+
+// checkKeys compares the active keyset with the subscriber's registry entries
+// and reports, and optionally remedies, the first invalidation found.
+func (s *subscriberService) checkKeys(ctx context.Context) {
+	w := s.watcher
+	if w.pending != "" && s.awaitingResubscription(ctx, w) {
+		return
+	}
+
+	subs, err := w.lookup.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: w.subscriberID}})
+	if err != nil {
+		slog.WarnContext(ctx, "SubscriberService: Key watch failed to look up subscriber in registry", "subscriber_id", w.subscriberID, "error", err)
+		return
+	}
+	if len(subs) == 0 {
+		s.keysInvalidated(ctx, w, nil, keyNotRegistered)
+		return
+	}
+	keys, err := s.keyMgr.Keyset(ctx, w.subscriberID)
+	if err != nil || keys == nil {
+		slog.WarnContext(ctx, "SubscriberService: Key watch failed to fetch active keyset", "subscriber_id", w.subscriberID, "error", err)
+		s.keysInvalidated(ctx, w, &subs[0], keyMissing)
+		return
+	}
+	now := s.now()
+	for i := range subs {
+		if reason := invalidation(&subs[i], keys.UniqueKeyID, keys.SigningPublic, now, w.renewBefore); reason != "" {
+			s.keysInvalidated(ctx, w, &subs[i], reason)
+			return
+		}
+	}
+	slog.DebugContext(ctx, "SubscriberService: Key watch found active keyset valid", "subscriber_id", w.subscriberID, "key_id", keys.UniqueKeyID)
+}
+
+// invalidation classifies a registry entry against the active key.
+// It returns an empty reason if the registry accepts the key.
+func invalidation(sub *model.Subscription, keyID, signingPublicKey string, now time.Time, renewBefore time.Duration) keyInvalidation {
+	switch {
+	case sub.Status == model.SubscriptionStatusExpired, sub.Status == model.SubscriptionStatusUnsubscribed, sub.Status == model.SubscriptionStatusInvalidSSL:
+		return keySubscriptionInactive
+	case sub.KeyID != keyID, sub.SigningPublicKey != signingPublicKey:
+		return keyRevoked
+	case !sub.ValidUntil.IsZero() && !now.Before(sub.ValidUntil):
+		return keyExpired
+	case !sub.ValidUntil.IsZero() && now.Add(renewBefore).After(sub.ValidUntil):
+		return keyExpiring
+	}
+	return ""
+}
+
+// keysInvalidated raises an alert for an invalidation and, if enabled, renews the keys.
+// Expiring keys are rotated while they are still accepted by the registry; in all other
+// cases the subscriber re-subscribes, since the registry no longer accepts its signature.
+func (s *subscriberService) keysInvalidated(ctx context.Context, w *keyWatcher, sub *model.Subscription, reason keyInvalidation) {
+	keyInvalidationMetrics.Add(string(reason), 1)
+	attrs := []any{"subscriber_id", w.subscriberID, "reason", reason}
+	if sub != nil {
+		attrs = append(attrs, "domain", sub.Domain, "registry_key_id", sub.KeyID, "registry_status", sub.Status, "valid_until", sub.ValidUntil)
+	}
+	if reason == keyExpiring {
+		slog.WarnContext(ctx, "SubscriberService: Subscriber keys are about to expire", attrs...)
+	} else {
+		slog.ErrorContext(ctx, "SubscriberService: Registry no longer accepts subscriber keys", attrs...)
+	}
+	if !w.autoRenew || sub == nil {
+		return
+	}
+
+	req := &model.NpSubscriptionRequest{Subscriber: sub.Subscriber}
+	if reason == keyExpiring {
+		if s.rotator == nil {
+			slog.WarnContext(ctx, "SubscriberService: Key rotation is not enabled; expiring keys will not be rotated", "subscriber_id", w.subscriberID)
+			return
+		}
+		if _, err := s.RotateKeys(ctx, req); err != nil && !errors.Is(err, ErrRotationInProgress) {
+			slog.ErrorContext(ctx, "SubscriberService: Failed to rotate expiring keys", "subscriber_id", w.subscriberID, "error", err)
+		}
+		return
+	}
+	operationID, err := s.CreateSubscription(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to re-subscribe after key invalidation", "subscriber_id", w.subscriberID, "reason", reason, "error", err)
+		return
+	}
+	w.pending = operationID
+	slog.InfoContext(ctx, "SubscriberService: Re-subscribed after key invalidation", "subscriber_id", w.subscriberID, "reason", reason, "message_id", operationID)
+}
+
+// awaitingResubscription reports whether the pending re-subscription is still
+// awaiting approval. Once approved, UpdateStatus activates its keyset.
+func (s *subscriberService) awaitingResubscription(ctx context.Context, w *keyWatcher) bool {
+	status, err := s.UpdateStatus(ctx, w.pending)
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "SubscriberService: Re-subscription approved", "subscriber_id", w.subscriberID, "message_id", w.pending)
+	case status == model.LROStatusPending || status == "":
+		return true
+	default:
+		slog.ErrorContext(ctx, "SubscriberService: Re-subscription not approved by registry", "subscriber_id", w.subscriberID, "message_id", w.pending, "status", status)
+		s.abandonRotation(ctx, w.pending)
+	}
+	w.pending = ""
+	return false
+}
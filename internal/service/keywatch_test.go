@@ -0,0 +1,333 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+// watchRegistry is a registryClient and subscriptionLookup that returns fixed
+// lookup results and echoes the message ID of create requests as the operation ID.
+type watchRegistry struct {
+	rotationRegistry
+	subs      []model.Subscription
+	lookupErr error
+	lookups   int
+	created   []string
+}
+
+func (m *watchRegistry) CreateSubscription(ctx context.Context, req *model.SubscriptionRequest) (*model.SubscriptionResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.created = append(m.created, req.MessageID)
+	return &model.SubscriptionResponse{MessageID: req.MessageID, Status: "UNDER_SUBSCRIPTION"}, nil
+}
+
+func (m *watchRegistry) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups++
+	return m.subs, m.lookupErr
+}
+
+func (m *watchRegistry) lookupCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lookups
+}
+
+var watchNow = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// watchedSubscription returns a registry entry that accepts the keyset "old-key".
+func watchedSubscription() model.Subscription {
+	return model.Subscription{
+		Subscriber:       model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP},
+		KeyID:            "old-key",
+		SigningPublicKey: "old-signing-key",
+		Status:           model.SubscriptionStatusSubscribed,
+		ValidUntil:       watchNow.AddDate(1, 0, 0),
+	}
+}
+
+func newWatchService(t *testing.T, reg *watchRegistry, km keyManager, cfg *KeyWatchConfig) *subscriberService {
+	t.Helper()
+	svc, err := NewSubscriberService(reg, km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{authHeader: "auth"}, "reg-id", "reg-key-id")
+	if err != nil {
+		t.Fatalf("NewSubscriberService() unexpected error: %v", err)
+	}
+	svc.now = func() time.Time { return watchNow }
+	if err := svc.SetKeyWatch(cfg, reg); err != nil {
+		t.Fatalf("SetKeyWatch() unexpected error: %v", err)
+	}
+	t.Cleanup(svc.Stop)
+	return svc
+}
+
+func invalidationCount(reason keyInvalidation) int64 {
+	if v, ok := keyInvalidationMetrics.Get(string(reason)).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestInvalidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*model.Subscription)
+		want   keyInvalidation
+	}{
+		{name: "valid", modify: func(*model.Subscription) {}},
+		{name: "no valid until", modify: func(s *model.Subscription) { s.ValidUntil = time.Time{} }},
+		{name: "unsubscribed", modify: func(s *model.Subscription) { s.Status = model.SubscriptionStatusUnsubscribed }, want: keySubscriptionInactive},
+		{name: "expired status", modify: func(s *model.Subscription) { s.Status = model.SubscriptionStatusExpired }, want: keySubscriptionInactive},
+		{name: "invalid ssl", modify: func(s *model.Subscription) { s.Status = model.SubscriptionStatusInvalidSSL }, want: keySubscriptionInactive},
+		{name: "different key id", modify: func(s *model.Subscription) { s.KeyID = "other-key" }, want: keyRevoked},
+		{name: "different signing key", modify: func(s *model.Subscription) { s.SigningPublicKey = "other" }, want: keyRevoked},
+		{name: "expired", modify: func(s *model.Subscription) { s.ValidUntil = watchNow }, want: keyExpired},
+		{name: "expiring", modify: func(s *model.Subscription) { s.ValidUntil = watchNow.Add(time.Hour) }, want: keyExpiring},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := watchedSubscription()
+			tc.modify(&sub)
+			if got := invalidation(&sub, "old-key", "old-signing-key", watchNow, 24*time.Hour); got != tc.want {
+				t.Errorf("invalidation() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_SetKeyWatch_Defaults(t *testing.T) {
+	svc := newWatchService(t, &watchRegistry{}, newMapKeyManager(nil), &KeyWatchConfig{SubscriberID: "sub1"})
+	if svc.watcher.pollInterval != defaultKeyWatchPollInterval {
+		t.Errorf("pollInterval = %v, want %v", svc.watcher.pollInterval, defaultKeyWatchPollInterval)
+	}
+	if svc.watcher.renewBefore != defaultKeyWatchRenewBefore {
+		t.Errorf("renewBefore = %v, want %v", svc.watcher.renewBefore, defaultKeyWatchRenewBefore)
+	}
+}
+
+func TestSubscriberService_SetKeyWatch_Error(t *testing.T) {
+	svc, err := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	if err != nil {
+		t.Fatalf("NewSubscriberService() unexpected error: %v", err)
+	}
+	tests := []struct {
+		name   string
+		cfg    *KeyWatchConfig
+		lookup subscriptionLookup
+	}{
+		{name: "nil config", lookup: &watchRegistry{}},
+		{name: "nil lookup", cfg: &KeyWatchConfig{SubscriberID: "sub1"}},
+		{name: "missing subscriber ID", cfg: &KeyWatchConfig{}, lookup: &watchRegistry{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := svc.SetKeyWatch(tc.cfg, tc.lookup); err == nil {
+				t.Error("SetKeyWatch() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSubscriberService_CheckKeys(t *testing.T) {
+	activeKeyset := map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key", SigningPublic: "old-signing-key"}}
+	revoked := watchedSubscription()
+	revoked.KeyID = "other-key"
+	tests := []struct {
+		name            string
+		subs            []model.Subscription
+		lookupErr       error
+		keysets         map[string]*becknmodel.Keyset
+		autoRenew       bool
+		wantReason      keyInvalidation
+		wantResubscribe bool
+	}{
+		{
+			name:    "valid keys",
+			subs:    []model.Subscription{watchedSubscription()},
+			keysets: activeKeyset,
+		},
+		{
+			name:      "registry unreachable",
+			lookupErr: errors.New("connection refused"),
+			keysets:   activeKeyset,
+			autoRenew: true,
+		},
+		{
+			name:       "not registered",
+			keysets:    activeKeyset,
+			autoRenew:  true,
+			wantReason: keyNotRegistered,
+		},
+		{
+			name:            "local keyset missing",
+			subs:            []model.Subscription{watchedSubscription()},
+			keysets:         map[string]*becknmodel.Keyset{},
+			autoRenew:       true,
+			wantReason:      keyMissing,
+			wantResubscribe: true,
+		},
+		{
+			name:       "revoked without auto renew",
+			subs:       []model.Subscription{revoked},
+			keysets:    activeKeyset,
+			wantReason: keyRevoked,
+		},
+		{
+			name:            "revoked with auto renew",
+			subs:            []model.Subscription{watchedSubscription(), revoked},
+			keysets:         activeKeyset,
+			autoRenew:       true,
+			wantReason:      keyRevoked,
+			wantResubscribe: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			keysets := make(map[string]*becknmodel.Keyset, len(tc.keysets))
+			for k, v := range tc.keysets {
+				keysets[k] = v
+			}
+			reg := &watchRegistry{subs: tc.subs, lookupErr: tc.lookupErr}
+			svc := newWatchService(t, reg, newMapKeyManager(keysets), &KeyWatchConfig{SubscriberID: "sub1", AutoRenew: tc.autoRenew})
+			var before int64
+			if tc.wantReason != "" {
+				before = invalidationCount(tc.wantReason)
+			}
+
+			svc.checkKeys(context.Background())
+
+			if tc.wantReason != "" {
+				if got := invalidationCount(tc.wantReason) - before; got != 1 {
+					t.Errorf("invalidations for %q = %d, want 1", tc.wantReason, got)
+				}
+			}
+			if got := len(reg.created) > 0; got != tc.wantResubscribe {
+				t.Errorf("re-subscribed = %t, want %t", got, tc.wantResubscribe)
+			}
+			if tc.wantResubscribe && svc.watcher.pending != reg.created[0] {
+				t.Errorf("pending = %q, want %q", svc.watcher.pending, reg.created[0])
+			}
+		})
+	}
+}
+
+func TestSubscriberService_CheckKeys_Resubscription(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      model.LROStatus
+		wantPending bool
+		wantActive  string
+	}{
+		{name: "pending", status: model.LROStatusPending, wantPending: true, wantActive: "old-key"},
+		{name: "approved", status: model.LROStatusApproved, wantActive: "new-key-1"},
+		{name: "rejected", status: model.LROStatusRejected, wantActive: "old-key"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked := watchedSubscription()
+			revoked.KeyID = "other-key"
+			reg := &watchRegistry{subs: []model.Subscription{revoked}}
+			reg.status = tc.status
+			km := newMapKeyManager(map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key", SigningPublic: "old-signing-key"}})
+			svc := newWatchService(t, reg, km, &KeyWatchConfig{SubscriberID: "sub1", AutoRenew: true})
+
+			svc.checkKeys(context.Background())
+			opID := svc.watcher.pending
+			if opID == "" {
+				t.Fatal("checkKeys() did not re-subscribe")
+			}
+			// The registry now holds the key that is expected to be active.
+			accepted := watchedSubscription()
+			if tc.wantActive != accepted.KeyID {
+				accepted.KeyID, accepted.SigningPublicKey = tc.wantActive, ""
+			}
+			reg.subs = []model.Subscription{accepted}
+			svc.checkKeys(context.Background())
+
+			if got := svc.watcher.pending != ""; got != tc.wantPending {
+				t.Errorf("pending = %t, want %t", got, tc.wantPending)
+			}
+			ids := km.keyIDs()
+			if ids["sub1"] != tc.wantActive {
+				t.Errorf("active keyset = %q, want %q", ids["sub1"], tc.wantActive)
+			}
+			if _, ok := ids[opID]; ok != tc.wantPending {
+				t.Errorf("keyset for operation %s kept = %t, want %t", opID, ok, tc.wantPending)
+			}
+			if len(reg.created) != 1 {
+				t.Errorf("re-subscriptions = %d, want 1", len(reg.created))
+			}
+		})
+	}
+}
+
+func TestSubscriberService_CheckKeys_RotatesExpiringKeys(t *testing.T) {
+	expiring := watchedSubscription()
+	expiring.ValidUntil = watchNow.Add(time.Hour)
+	reg := &watchRegistry{subs: []model.Subscription{expiring}}
+	reg.status = model.LROStatusPending
+	km := newMapKeyManager(map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key", SigningPublic: "old-signing-key"}})
+	svc := newWatchService(t, reg, km, &KeyWatchConfig{SubscriberID: "sub1", AutoRenew: true})
+	if err := svc.SetKeyRotation(&KeyRotationConfig{TokenSHA256: rotationTokenHash, PollInterval: time.Minute}); err != nil {
+		t.Fatalf("SetKeyRotation() unexpected error: %v", err)
+	}
+
+	svc.checkKeys(context.Background())
+
+	svc.rotator.mu.Lock()
+	opID := svc.rotator.operationID
+	svc.rotator.mu.Unlock()
+	if opID == "" {
+		t.Error("checkKeys() did not start a key rotation")
+	}
+	if len(reg.created) != 0 {
+		t.Errorf("re-subscriptions = %d, want 0", len(reg.created))
+	}
+}
+
+func TestSubscriberService_StartKeyWatch(t *testing.T) {
+	reg := &watchRegistry{subs: []model.Subscription{watchedSubscription()}}
+	km := newMapKeyManager(map[string]*becknmodel.Keyset{"sub1": {SubscriberID: "sub1", UniqueKeyID: "old-key", SigningPublic: "old-signing-key"}})
+	svc := newWatchService(t, reg, km, &KeyWatchConfig{SubscriberID: "sub1", PollInterval: time.Millisecond})
+
+	svc.StartKeyWatch()
+	deadline := time.Now().Add(2 * time.Second)
+	for reg.lookupCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	svc.Stop()
+
+	count := reg.lookupCount()
+	if count < 2 {
+		t.Fatalf("lookups = %d, want at least 2", count)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if got := reg.lookupCount(); got != count {
+		t.Errorf("lookups after Stop() = %d, want %d", got, count)
+	}
+}
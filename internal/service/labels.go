@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ErrInvalidLabels is returned when subscription labels, or a search by labels, are malformed.
+var ErrInvalidLabels = errors.New("invalid subscription labels")
+
+// maxLabels is the most labels a subscription can carry.
+const maxLabels = 20
+
+// labelPattern matches a label: up to 63 letters, digits, '.', '_', ':' or '-', starting
+// with a letter or digit, e.g. "pilot" or "tier-1".
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,62}$`)
+
+// subscriptionLabelRepository defines the repository operations needed to label and search subscriptions.
+type subscriptionLabelRepository interface {
+	SetSubscriptionLabels(ctx context.Context, subscriberID, domain string, typ model.Role, labels model.Labels) (time.Time, error)
+	Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
+}
+
+// subscriptionLabelService manages the free-form labels operators attach to subscriptions,
+// and searches subscriptions by them.
+type subscriptionLabelService struct {
+	repo subscriptionLabelRepository
+}
+
+// NewSubscriptionLabelService creates a new subscriptionLabelService.
+func NewSubscriptionLabelService(repo subscriptionLabelRepository) (*subscriptionLabelService, error) {
+	if repo == nil {
+		slog.Error("NewSubscriptionLabelService: subscriptionLabelRepository cannot be nil")
+		return nil, errors.New("subscriptionLabelRepository cannot be nil")
+	}
+	return &subscriptionLabelService{repo: repo}, nil
+}
+
+// normalizeLabels checks labels and returns them sorted without duplicates.
+func normalizeLabels(labels model.Labels) (model.Labels, error) {
+	for _, l := range labels {
+		if !labelPattern.MatchString(l) {
+			return nil, fmt.Errorf("%w: label %q must be 1 to 63 letters, digits, '.', '_', ':' or '-', starting with a letter or digit", ErrInvalidLabels, l)
+		}
+	}
+	normalized := slices.Compact(slices.Sorted(slices.Values(labels)))
+	if len(normalized) > maxLabels {
+		return nil, fmt.Errorf("%w: got %d labels, want at most %d", ErrInvalidLabels, len(normalized), maxLabels)
+	}
+	return normalized, nil
+}
+
+// Set replaces the labels of the subscription of subscriberID identified by the request's domain and type.
+// It returns the subscription's key with its new labels.
+func (s *subscriptionLabelService) Set(ctx context.Context, subscriberID string, req *model.SubscriptionLabelsRequest) (*model.Subscription, error) {
+	if subscriberID == "" || req.Domain == "" || req.Type == "" {
+		return nil, fmt.Errorf("%w: subscriber_id, domain and type are required", ErrInvalidLabels)
+	}
+	labels, err := normalizeLabels(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetSubscriptionLabels(ctx, subscriberID, req.Domain, req.Type, labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set subscription labels: %w", err)
+	}
+	slog.InfoContext(ctx, "SubscriptionLabelService: Labels set", "subscriber_id", subscriberID, "domain", req.Domain, "type", req.Type, "labels", labels)
+	return &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: subscriberID, Domain: req.Domain, Type: req.Type},
+		Labels:     labels,
+		Updated:    updated,
+	}, nil
+}
+
+// Search returns the subscriptions matching the filter. A subscription matches the filter's
+// labels if it carries all of them.
+func (s *subscriptionLabelService) Search(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	labels, err := normalizeLabels(filter.Labels)
+	if err != nil {
+		return nil, err
+	}
+	filter.Labels = labels
+	subs, err := s.repo.Lookup(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search subscriptions: %w", err)
+	}
+	return subs, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockLabelRepo is a mock for subscriptionLabelRepository.
+type mockLabelRepo struct {
+	setErr    error
+	gotLabels model.Labels
+	lookupErr error
+	subs      []model.Subscription
+	gotFilter *model.Subscription
+}
+
+func (m *mockLabelRepo) SetSubscriptionLabels(ctx context.Context, subscriberID, domain string, typ model.Role, labels model.Labels) (time.Time, error) {
+	if m.setErr != nil {
+		return time.Time{}, m.setErr
+	}
+	m.gotLabels = labels
+	return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (m *mockLabelRepo) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	m.gotFilter = filter
+	if m.lookupErr != nil {
+		return nil, m.lookupErr
+	}
+	return m.subs, nil
+}
+
+func TestNewSubscriptionLabelService_Error(t *testing.T) {
+	if _, err := NewSubscriptionLabelService(nil); err == nil {
+		t.Error("NewSubscriptionLabelService() expected error, got nil")
+	}
+}
+
+func TestSubscriptionLabelService_Set(t *testing.T) {
+	repo := &mockLabelRepo{}
+	s, _ := NewSubscriptionLabelService(repo)
+
+	got, err := s.Set(context.Background(), "sub1", &model.SubscriptionLabelsRequest{Domain: "ONDC:RET10", Type: model.RoleBPP, Labels: model.Labels{"tier-1", "pilot", "tier-1"}})
+	if err != nil {
+		t.Fatalf("Set() unexpected error: %v", err)
+	}
+	want := &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "ONDC:RET10", Type: model.RoleBPP},
+		Labels:     model.Labels{"pilot", "tier-1"},
+		Updated:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Set() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(model.Labels{"pilot", "tier-1"}, repo.gotLabels); diff != "" {
+		t.Errorf("Set() stored labels mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriptionLabelService_Set_Error(t *testing.T) {
+	tooMany := make(model.Labels, maxLabels+1)
+	for i := range tooMany {
+		tooMany[i] = "l" + strings.Repeat("x", i)
+	}
+	tests := []struct {
+		name    string
+		repo    *mockLabelRepo
+		id      string
+		req     *model.SubscriptionLabelsRequest
+		wantErr error
+	}{
+		{
+			name:    "missing type",
+			repo:    &mockLabelRepo{},
+			id:      "sub1",
+			req:     &model.SubscriptionLabelsRequest{Domain: "ONDC:RET10", Labels: model.Labels{"pilot"}},
+			wantErr: ErrInvalidLabels,
+		},
+		{
+			name:    "malformed label",
+			repo:    &mockLabelRepo{},
+			id:      "sub1",
+			req:     &model.SubscriptionLabelsRequest{Domain: "ONDC:RET10", Type: model.RoleBPP, Labels: model.Labels{"tier 1"}},
+			wantErr: ErrInvalidLabels,
+		},
+		{
+			name:    "too many labels",
+			repo:    &mockLabelRepo{},
+			id:      "sub1",
+			req:     &model.SubscriptionLabelsRequest{Domain: "ONDC:RET10", Type: model.RoleBPP, Labels: tooMany},
+			wantErr: ErrInvalidLabels,
+		},
+		{
+			name:    "subscription not found",
+			repo:    &mockLabelRepo{setErr: repository.ErrSubscriptionNotFound},
+			id:      "sub1",
+			req:     &model.SubscriptionLabelsRequest{Domain: "ONDC:RET10", Type: model.RoleBPP, Labels: model.Labels{"pilot"}},
+			wantErr: repository.ErrSubscriptionNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := NewSubscriptionLabelService(tc.repo)
+			if _, err := s.Set(context.Background(), tc.id, tc.req); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Set() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriptionLabelService_Search(t *testing.T) {
+	subs := []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub1"}, Labels: model.Labels{"pilot"}}}
+	repo := &mockLabelRepo{subs: subs}
+	s, _ := NewSubscriptionLabelService(repo)
+
+	got, err := s.Search(context.Background(), &model.Subscription{Subscriber: model.Subscriber{Domain: "ONDC:*"}, Labels: model.Labels{"pilot", "pilot"}})
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(subs, got); diff != "" {
+		t.Errorf("Search() mismatch (-want +got):\n%s", diff)
+	}
+	wantFilter := &model.Subscription{Subscriber: model.Subscriber{Domain: "ONDC:*"}, Labels: model.Labels{"pilot"}}
+	if diff := cmp.Diff(wantFilter, repo.gotFilter); diff != "" {
+		t.Errorf("Search() filter mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriptionLabelService_Search_Error(t *testing.T) {
+	s, _ := NewSubscriptionLabelService(&mockLabelRepo{})
+	if _, err := s.Search(context.Background(), &model.Subscription{Labels: model.Labels{"-pilot"}}); !errors.Is(err, ErrInvalidLabels) {
+		t.Errorf("Search() error = %v, want %v", err, ErrInvalidLabels)
+	}
+
+	lookupErr := errors.New("db down")
+	s, _ = NewSubscriptionLabelService(&mockLabelRepo{lookupErr: lookupErr})
+	if _, err := s.Search(context.Background(), &model.Subscription{Labels: model.Labels{"pilot"}}); !errors.Is(err, lookupErr) {
+		t.Errorf("Search() error = %v, want %v", err, lookupErr)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultLookupCacheTTL        = 30 * time.Second
+	defaultLookupCacheMaxEntries = 1000
+)
+
+// lookupCacheMetrics counts the hits, misses and invalidations of the lookup cache.
+var lookupCacheMetrics = expvar.NewMap("registry_lookup_cache")
+
+// LookupCacheConfig configures the cache of lookup results served by the registry.
+type LookupCacheConfig struct {
+	// TTL is how long the results of a lookup are cached. Defaults to 30s.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxEntries bounds the number of lookups cached at once. Defaults to 1000.
+	MaxEntries int `yaml:"maxEntries"`
+}
+
+// subscriptionLookuper looks up subscriptions.
+type subscriptionLookuper interface {
+	Lookup(context.Context, *model.Subscription) ([]model.Subscription, error)
+	LookupAny(context.Context, []*model.Subscription) ([]model.Subscription, error)
+	LookupChanges(context.Context, []*model.Subscription, time.Time) (*model.LookupChanges, error)
+}
+
+// lookupCache caches the results of lookups, keyed by their filters, in front of a
+// subscriptionLookuper. Incremental lookups are not cached.
+type lookupCache struct {
+	svc        subscriptionLookuper
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedLookup
+	// gen is incremented by every invalidation, so that lookups started before it are not cached.
+	gen uint64
+}
+
+type cachedLookup struct {
+	subs      []model.Subscription
+	expiresAt time.Time
+}
+
+// NewLookupCache creates a new lookupCache.
+func NewLookupCache(svc subscriptionLookuper, cfg *LookupCacheConfig) (*lookupCache, error) {
+	if svc == nil {
+		slog.Error("NewLookupCache: subscriptionLookuper cannot be nil")
+		return nil, errors.New("subscriptionLookuper cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewLookupCache: LookupCacheConfig cannot be nil")
+		return nil, errors.New("LookupCacheConfig cannot be nil")
+	}
+	if cfg.TTL < 0 || cfg.MaxEntries < 0 {
+		return nil, errors.New("invalid lookup cache config: ttl and maxEntries cannot be negative")
+	}
+	return &lookupCache{
+		svc:        svc,
+		ttl:        orDefault(cfg.TTL, defaultLookupCacheTTL),
+		maxEntries: orDefault(cfg.MaxEntries, defaultLookupCacheMaxEntries),
+		now:        time.Now,
+		entries:    map[string]cachedLookup{},
+	}, nil
+}
+
+// Lookup returns the subscriptions matching the filter, from the cache if present.
+func (c *lookupCache) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	return c.get(ctx, filter, func() ([]model.Subscription, error) {
+		return c.svc.Lookup(ctx, filter)
+	})
+}
+
+// LookupAny returns the subscriptions matching any of the filters, from the cache if present.
+func (c *lookupCache) LookupAny(ctx context.Context, filters []*model.Subscription) ([]model.Subscription, error) {
+	return c.get(ctx, filters, func() ([]model.Subscription, error) {
+		return c.svc.LookupAny(ctx, filters)
+	})
+}
+
+// LookupChanges returns the changes to the results of the filters at or after since. It is not cached.
+func (c *lookupCache) LookupChanges(ctx context.Context, filters []*model.Subscription, since time.Time) (*model.LookupChanges, error) {
+	return c.svc.LookupChanges(ctx, filters, since)
+}
+
+// Invalidate drops every cached lookup. It is a repository.SubscriptionChangeHook: any change
+// to a subscription can add it to or remove it from the results of filters it did not match
+// before, so the change does not tell which lookups are stale.
+func (c *lookupCache) Invalidate(ctx context.Context, change repository.SubscriptionChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if len(c.entries) == 0 {
+		return
+	}
+	clear(c.entries)
+	lookupCacheMetrics.Add("invalidations", 1)
+	slog.DebugContext(ctx, "LookupCache: Invalidated", "op", change.Op, "subscriber_id", change.SubscriberID)
+}
+
+// get returns the cached results of the lookup with the given filters, or the results of
+// lookup, caching them.
+func (c *lookupCache) get(ctx context.Context, filters any, lookup func() ([]model.Subscription, error)) ([]model.Subscription, error) {
+	b, err := json.Marshal(filters)
+	if err != nil {
+		return lookup()
+	}
+	key := string(b)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	gen := c.gen
+	c.mu.Unlock()
+	if ok && c.now().Before(e.expiresAt) {
+		lookupCacheMetrics.Add("hits", 1)
+		// Callers may clear fields of the results, e.g. for the public lookup tier.
+		return slices.Clone(e.subs), nil
+	}
+	lookupCacheMetrics.Add("misses", 1)
+
+	subs, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		slog.DebugContext(ctx, "LookupCache: Subscriptions changed during lookup, not caching results")
+		return subs, nil
+	}
+	now := c.now()
+	if len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return subs, nil
+		}
+	}
+	c.entries[key] = cachedLookup{subs: slices.Clone(subs), expiresAt: now.Add(c.ttl)}
+	return subs, nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+type countingLookuper struct {
+	subs    []model.Subscription
+	err     error
+	calls   int
+	changes int
+}
+
+func (l *countingLookuper) Lookup(context.Context, *model.Subscription) ([]model.Subscription, error) {
+	l.calls++
+	return l.subs, l.err
+}
+
+func (l *countingLookuper) LookupAny(context.Context, []*model.Subscription) ([]model.Subscription, error) {
+	l.calls++
+	return l.subs, l.err
+}
+
+func (l *countingLookuper) LookupChanges(context.Context, []*model.Subscription, time.Time) (*model.LookupChanges, error) {
+	l.changes++
+	return &model.LookupChanges{Subscriptions: l.subs}, l.err
+}
+
+func TestNewLookupCache(t *testing.T) {
+	tests := []struct {
+		name           string
+		svc            subscriptionLookuper
+		cfg            *LookupCacheConfig
+		wantTTL        time.Duration
+		wantMaxEntries int
+		wantErr        bool
+	}{
+		{name: "defaults", svc: &countingLookuper{}, cfg: &LookupCacheConfig{}, wantTTL: 30 * time.Second, wantMaxEntries: 1000},
+		{name: "custom", svc: &countingLookuper{}, cfg: &LookupCacheConfig{TTL: time.Minute, MaxEntries: 10}, wantTTL: time.Minute, wantMaxEntries: 10},
+		{name: "nil service", cfg: &LookupCacheConfig{}, wantErr: true},
+		{name: "nil config", svc: &countingLookuper{}, wantErr: true},
+		{name: "negative ttl", svc: &countingLookuper{}, cfg: &LookupCacheConfig{TTL: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewLookupCache(tt.svc, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLookupCache() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.ttl != tt.wantTTL || c.maxEntries != tt.wantMaxEntries {
+				t.Errorf("NewLookupCache() ttl = %v, maxEntries = %d, want %v and %d", c.ttl, c.maxEntries, tt.wantTTL, tt.wantMaxEntries)
+			}
+		})
+	}
+}
+
+func TestLookupCache_Lookup(t *testing.T) {
+	ctx := context.Background()
+	svc := &countingLookuper{subs: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "bpp.example.com", Domain: "retail"}, KeyID: "k1"}}}
+	c, err := NewLookupCache(svc, &LookupCacheConfig{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewLookupCache() error = %v", err)
+	}
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	filter := &model.Subscription{Subscriber: model.Subscriber{Domain: "retail"}}
+
+	want := []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "bpp.example.com", Domain: "retail"}, KeyID: "k1"}}
+
+	got, err := c.Lookup(ctx, filter)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	got[0].KeyID = ""
+	got, err = c.Lookup(ctx, filter)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	got[0].KeyID = ""
+	got, err = c.Lookup(ctx, filter)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lookup() from cache mismatch (-want +got):\n%s", diff)
+	}
+	if svc.calls != 1 {
+		t.Errorf("service called %d times, want 1", svc.calls)
+	}
+
+	if _, err := c.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{Domain: "mobility"}}); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if _, err := c.LookupAny(ctx, []*model.Subscription{filter}); err != nil {
+		t.Fatalf("LookupAny() error = %v", err)
+	}
+	if svc.calls != 3 {
+		t.Errorf("service called %d times for other filters, want 3", svc.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := c.Lookup(ctx, filter); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if svc.calls != 4 {
+		t.Errorf("service called %d times after the TTL, want 4", svc.calls)
+	}
+}
+
+func TestLookupCache_InvalidateOnNotify(t *testing.T) {
+	ctx := context.Background()
+	svc := &countingLookuper{subs: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "bpp.example.com", Domain: "retail"}}}}
+	c, err := NewLookupCache(svc, &LookupCacheConfig{})
+	if err != nil {
+		t.Fatalf("NewLookupCache() error = %v", err)
+	}
+	filter := &model.Subscription{Subscriber: model.Subscriber{Domain: "retail"}}
+	for range 2 {
+		if _, err := c.Lookup(ctx, filter); err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+	}
+	if svc.calls != 1 {
+		t.Fatalf("service called %d times before the change, want 1", svc.calls)
+	}
+
+	// The payload of the notify_change_on_subscriptions trigger.
+	var change repository.SubscriptionChange
+	if err := json.Unmarshal([]byte(`{"op":"UPDATE","subscriber_id":"bpp.example.com","key_id":"k2","domain":"retail","type":"BPP"}`), &change); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	var hook repository.SubscriptionChangeHook = c.Invalidate
+	hook(ctx, change)
+
+	if _, err := c.Lookup(ctx, filter); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if svc.calls != 2 {
+		t.Errorf("service called %d times after the change, want 2", svc.calls)
+	}
+
+	hook(ctx, repository.SubscriptionChange{Op: repository.SubscriptionResync})
+	if _, err := c.Lookup(ctx, filter); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if svc.calls != 3 {
+		t.Errorf("service called %d times after a resync, want 3", svc.calls)
+	}
+}
+
+func TestLookupCache_NotCached(t *testing.T) {
+	ctx := context.Background()
+	filter := &model.Subscription{Subscriber: model.Subscriber{Domain: "retail"}}
+
+	t.Run("error", func(t *testing.T) {
+		svc := &countingLookuper{err: errors.New("db down")}
+		c, _ := NewLookupCache(svc, &LookupCacheConfig{})
+		for range 2 {
+			if _, err := c.Lookup(ctx, filter); err == nil {
+				t.Fatal("Lookup() error = nil, want error")
+			}
+		}
+		if svc.calls != 2 {
+			t.Errorf("service called %d times, want 2", svc.calls)
+		}
+	})
+
+	t.Run("changed during lookup", func(t *testing.T) {
+		svc := &countingLookuper{}
+		c, _ := NewLookupCache(svc, &LookupCacheConfig{})
+		if _, err := c.get(ctx, filter, func() ([]model.Subscription, error) {
+			c.Invalidate(ctx, repository.SubscriptionChange{Op: repository.SubscriptionInserted})
+			return nil, nil
+		}); err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+		if len(c.entries) != 0 {
+			t.Errorf("cached %d lookups started before a change, want 0", len(c.entries))
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		svc := &countingLookuper{}
+		c, _ := NewLookupCache(svc, &LookupCacheConfig{MaxEntries: 1})
+		for _, d := range []string{"retail", "mobility", "mobility"} {
+			if _, err := c.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{Domain: d}}); err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+		}
+		if svc.calls != 3 {
+			t.Errorf("service called %d times, want 3", svc.calls)
+		}
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		svc := &countingLookuper{}
+		c, _ := NewLookupCache(svc, &LookupCacheConfig{})
+		for range 2 {
+			if _, err := c.LookupChanges(ctx, []*model.Subscription{filter}, time.Now()); err != nil {
+				t.Fatalf("LookupChanges() error = %v", err)
+			}
+		}
+		if svc.changes != 2 {
+			t.Errorf("service called %d times, want 2", svc.changes)
+		}
+	})
+}
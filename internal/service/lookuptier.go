@@ -0,0 +1,294 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultPublicLookupsPerMinute        = 60
+	defaultPublicLookupBurst             = 10
+	defaultAuthenticatedLookupsPerMinute = 1200
+	defaultAuthenticatedLookupBurst      = 100
+	// lookupLimiterSweepSize is the number of tracked clients above which idle ones are dropped.
+	lookupLimiterSweepSize = 10000
+)
+
+// defaultPublicLookupFields are the fields of a subscription returned to unauthenticated
+// lookups. They are what is needed to route to and verify the signatures of a participant.
+var defaultPublicLookupFields = []string{
+	"subscriber_id", "url", "type", "domain", "status",
+	"key_id", "signing_public_key", "valid_from", "valid_until",
+}
+
+// lookupTierMetrics counts lookups admitted and rejected per tier.
+var lookupTierMetrics = expvar.NewMap("registry_lookup_tiers")
+
+// LookupTier is the tier a lookup request is served in.
+type LookupTier string
+
+const (
+	// LookupTierPublic serves unsigned lookups, limited per client IP.
+	LookupTierPublic LookupTier = "public"
+	// LookupTierAuthenticated serves lookups signed by a subscribed participant, limited per subscriber.
+	LookupTierAuthenticated LookupTier = "authenticated"
+)
+
+// LookupTierLimit is the rate limit of a lookup tier.
+type LookupTierLimit struct {
+	// PerMinute is the sustained number of lookups allowed per minute.
+	PerMinute int `yaml:"perMinute"`
+	// Burst is the number of lookups allowed at once on top of the sustained rate.
+	Burst int `yaml:"burst"`
+}
+
+// LookupTierConfig configures the public and authenticated tiers of the lookup endpoint.
+type LookupTierConfig struct {
+	// Public limits unsigned lookups per client IP. Defaults to 60 per minute with a burst of 10.
+	Public LookupTierLimit `yaml:"public"`
+	// Authenticated limits lookups signed by a subscribed participant per subscriber.
+	// Defaults to 1200 per minute with a burst of 100.
+	Authenticated LookupTierLimit `yaml:"authenticated"`
+	// PublicFields are the subscription fields returned to unsigned lookups. Defaults to
+	// subscriber_id, url, type, domain, status, key_id, signing_public_key, valid_from and valid_until.
+	PublicFields []string `yaml:"publicFields"`
+}
+
+// lookupFieldClearers zero a subscription field, by its JSON name.
+var lookupFieldClearers = map[string]func(*model.Subscription){
+	"subscriber_id":       func(s *model.Subscription) { s.SubscriberID = "" },
+	"url":                 func(s *model.Subscription) { s.URL = "" },
+	"type":                func(s *model.Subscription) { s.Type = "" },
+	"domain":              func(s *model.Subscription) { s.Domain = "" },
+	"location":            func(s *model.Subscription) { s.Location = nil },
+	"key_id":              func(s *model.Subscription) { s.KeyID = "" },
+	"signing_public_key":  func(s *model.Subscription) { s.SigningPublicKey = "" },
+	"encr_public_key":     func(s *model.Subscription) { s.EncrPublicKey = "" },
+	"valid_from":          func(s *model.Subscription) { s.ValidFrom = time.Time{} },
+	"valid_until":         func(s *model.Subscription) { s.ValidUntil = time.Time{} },
+	"status":              func(s *model.Subscription) { s.Status = "" },
+	"labels":              func(s *model.Subscription) { s.Labels = nil },
+	"created":             func(s *model.Subscription) { s.Created = time.Time{} },
+	"updated":             func(s *model.Subscription) { s.Updated = time.Time{} },
+	"nonce":               func(s *model.Subscription) { s.Nonce = "" },
+	"extended_attributes": func(s *model.Subscription) { s.ExtendedAttributes = nil },
+}
+
+func (c *LookupTierConfig) validate() error {
+	if c.Public.PerMinute < 0 || c.Public.Burst < 0 || c.Authenticated.PerMinute < 0 || c.Authenticated.Burst < 0 {
+		return errors.New("invalid lookup tier config: limits cannot be negative")
+	}
+	if c.Public.PerMinute == 0 {
+		c.Public.PerMinute = defaultPublicLookupsPerMinute
+	}
+	if c.Public.Burst == 0 {
+		c.Public.Burst = defaultPublicLookupBurst
+	}
+	if c.Authenticated.PerMinute == 0 {
+		c.Authenticated.PerMinute = defaultAuthenticatedLookupsPerMinute
+	}
+	if c.Authenticated.Burst == 0 {
+		c.Authenticated.Burst = defaultAuthenticatedLookupBurst
+	}
+	if len(c.PublicFields) == 0 {
+		c.PublicFields = defaultPublicLookupFields
+	}
+	for _, f := range c.PublicFields {
+		if _, ok := lookupFieldClearers[f]; !ok {
+			return fmt.Errorf("invalid lookup tier config: unknown public field %q", f)
+		}
+	}
+	return nil
+}
+
+// signingKeyStore provides the signing key of a subscribed participant.
+type signingKeyStore interface {
+	SigningKey(ctx context.Context, subscriberID, keyID string) (string, error)
+}
+
+// LookupAdmission is the outcome of admitting a lookup request into a tier.
+type LookupAdmission struct {
+	// Tier is the tier the request is served in.
+	Tier LookupTier
+	// RetryAfter is how long the client must wait before its next lookup. It is zero if the request is admitted.
+	RetryAfter time.Duration
+	// Caller identifies the client: its IP in the public tier and its subscriber ID in the authenticated tier.
+	Caller string
+
+	hidden []func(*model.Subscription)
+}
+
+// Filter removes the fields the tier does not return from the subscriptions.
+func (a *LookupAdmission) Filter(subs []model.Subscription) {
+	for i := range subs {
+		for _, clear := range a.hidden {
+			clear(&subs[i])
+		}
+	}
+}
+
+// lookupTiers admits lookup requests into the public or the authenticated tier and
+// enforces the rate limit of each.
+type lookupTiers struct {
+	keys         signingKeyStore
+	sigValidator signValidator
+	public       *lookupLimiter
+	auth         *lookupLimiter
+	hidden       []func(*model.Subscription)
+}
+
+// NewLookupTiers creates a new lookupTiers.
+func NewLookupTiers(keys signingKeyStore, sv signValidator, cfg *LookupTierConfig) (*lookupTiers, error) {
+	if keys == nil {
+		slog.Error("NewLookupTiers: signingKeyStore cannot be nil")
+		return nil, errors.New("signingKeyStore cannot be nil")
+	}
+	if sv == nil {
+		slog.Error("NewLookupTiers: signValidator cannot be nil")
+		return nil, errors.New("signValidator cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewLookupTiers: LookupTierConfig cannot be nil")
+		return nil, errors.New("LookupTierConfig cannot be nil")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	t := &lookupTiers{
+		keys:         keys,
+		sigValidator: sv,
+		public:       newLookupLimiter(cfg.Public),
+		auth:         newLookupLimiter(cfg.Authenticated),
+	}
+	for name, clear := range lookupFieldClearers {
+		if !slices.Contains(cfg.PublicFields, name) {
+			t.hidden = append(t.hidden, clear)
+		}
+	}
+	return t, nil
+}
+
+// Admit places a lookup request in a tier and applies its rate limit. Requests without an
+// Authorization header are public and limited per client IP. Signed requests are
+// authenticated against the signing key of a subscribed participant and limited per
+// subscriber; an invalid signature is rejected rather than downgraded to the public tier.
+func (t *lookupTiers) Admit(ctx context.Context, body []byte, authHeader, remoteAddr string) (*LookupAdmission, *model.AuthError) {
+	if authHeader == "" {
+		client := remoteAddr
+		if addr, ok := parseRemoteAddr(remoteAddr); ok {
+			client = addr.String()
+		}
+		a := &LookupAdmission{Tier: LookupTierPublic, Caller: client, hidden: t.hidden}
+		a.RetryAfter = t.public.allow(client)
+		t.record(a)
+		return a, nil
+	}
+
+	ah, authErr := keySet(ctx, authHeader)
+	if authErr != nil {
+		lookupTierMetrics.Add("auth_failed", 1)
+		return nil, authErr
+	}
+	key, err := t.keys.SigningKey(ctx, ah.SubscriberID, ah.UniqueID)
+	if err != nil {
+		slog.ErrorContext(ctx, "LookupTiers: Failed to fetch signing key", "error", err, "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
+		lookupTierMetrics.Add("auth_failed", 1)
+		if errors.Is(err, repository.ErrSubscriberKeyNotFound) {
+			return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeSubscriptionNotFound, "Signing key not found for the subscriber.", ah.SubscriberID)
+		}
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeKeyUnavailable, "Could not retrieve key for signature validation.", ah.SubscriberID)
+	}
+	if err := t.sigValidator.Validate(ctx, body, authHeader, key); err != nil {
+		slog.ErrorContext(ctx, "LookupTiers: Signature validation failed", "error", err, "subscriber_id", ah.SubscriberID)
+		lookupTierMetrics.Add("auth_failed", 1)
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+	}
+	a := &LookupAdmission{Tier: LookupTierAuthenticated, Caller: ah.SubscriberID}
+	a.RetryAfter = t.auth.allow(ah.SubscriberID)
+	t.record(a)
+	return a, nil
+}
+
+// record counts an admission in the metrics of its tier.
+func (t *lookupTiers) record(a *LookupAdmission) {
+	if a.RetryAfter > 0 {
+		lookupTierMetrics.Add(string(a.Tier)+"_limited", 1)
+		return
+	}
+	lookupTierMetrics.Add(string(a.Tier)+"_allowed", 1)
+}
+
+// lookupLimiter rate limits lookups per client with the generic cell rate algorithm: each
+// client has a theoretical arrival time that every admitted request pushes back by the
+// emission interval, and a request is rejected while it is more than the burst ahead of now.
+type lookupLimiter struct {
+	interval  time.Duration // emission interval between sustained requests
+	tolerance time.Duration // how far ahead of now the arrival time may run
+	now       func() time.Time
+
+	mu  sync.Mutex
+	tat map[string]time.Time
+}
+
+func newLookupLimiter(l LookupTierLimit) *lookupLimiter {
+	interval := time.Minute / time.Duration(l.PerMinute)
+	return &lookupLimiter{
+		interval:  interval,
+		tolerance: interval * time.Duration(l.Burst),
+		now:       time.Now,
+		tat:       map[string]time.Time{},
+	}
+}
+
+// allow admits a request from the client and returns zero, or returns how long until it
+// would be admitted.
+func (l *lookupLimiter) allow(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	tat := l.tat[client]
+	if tat.Before(now) {
+		tat = now
+	}
+	if wait := tat.Sub(now) - l.tolerance; wait > 0 {
+		return wait
+	}
+	if len(l.tat) >= lookupLimiterSweepSize {
+		l.sweep(now)
+	}
+	l.tat[client] = tat.Add(l.interval)
+	return 0
+}
+
+// sweep drops the clients whose arrival time has passed, as they are back to a full burst.
+func (l *lookupLimiter) sweep(now time.Time) {
+	for client, tat := range l.tat {
+		if !tat.After(now) {
+			delete(l.tat, client)
+		}
+	}
+}
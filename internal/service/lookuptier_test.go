@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockSigningKeyStore is a mock for signingKeyStore.
+type mockSigningKeyStore struct {
+	key string
+	err error
+}
+
+func (m *mockSigningKeyStore) SigningKey(ctx context.Context, subscriberID, keyID string) (string, error) {
+	return m.key, m.err
+}
+
+const testLookupAuthHeader = `Signature keyId="bap.example.com|key1|ed25519",algorithm="ed25519",signature="sig"`
+
+// newTestLookupTiers creates a lookupTiers whose limiters use a fixed clock.
+func newTestLookupTiers(t *testing.T, keys signingKeyStore, sv signValidator, cfg *LookupTierConfig) *lookupTiers {
+	t.Helper()
+	tiers, err := NewLookupTiers(keys, sv, cfg)
+	if err != nil {
+		t.Fatalf("NewLookupTiers() unexpected error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	tiers.public.now = func() time.Time { return now }
+	tiers.auth.now = func() time.Time { return now }
+	return tiers
+}
+
+func TestNewLookupTiers_Defaults(t *testing.T) {
+	cfg := &LookupTierConfig{}
+	if _, err := NewLookupTiers(&mockSigningKeyStore{}, &mockSignValidator{}, cfg); err != nil {
+		t.Fatalf("NewLookupTiers() unexpected error: %v", err)
+	}
+	if cfg.Public.PerMinute != defaultPublicLookupsPerMinute || cfg.Public.Burst != defaultPublicLookupBurst {
+		t.Errorf("Public = %+v, want defaults", cfg.Public)
+	}
+	if cfg.Authenticated.PerMinute != defaultAuthenticatedLookupsPerMinute || cfg.Authenticated.Burst != defaultAuthenticatedLookupBurst {
+		t.Errorf("Authenticated = %+v, want defaults", cfg.Authenticated)
+	}
+	if len(cfg.PublicFields) != len(defaultPublicLookupFields) {
+		t.Errorf("PublicFields = %v, want %v", cfg.PublicFields, defaultPublicLookupFields)
+	}
+}
+
+func TestNewLookupTiers_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    signingKeyStore
+		sv      signValidator
+		cfg     *LookupTierConfig
+		wantErr string
+	}{
+		{name: "nil key store", sv: &mockSignValidator{}, cfg: &LookupTierConfig{}, wantErr: "signingKeyStore cannot be nil"},
+		{name: "nil validator", keys: &mockSigningKeyStore{}, cfg: &LookupTierConfig{}, wantErr: "signValidator cannot be nil"},
+		{name: "nil config", keys: &mockSigningKeyStore{}, sv: &mockSignValidator{}, wantErr: "LookupTierConfig cannot be nil"},
+		{
+			name:    "negative limit",
+			keys:    &mockSigningKeyStore{},
+			sv:      &mockSignValidator{},
+			cfg:     &LookupTierConfig{Public: LookupTierLimit{PerMinute: -1}},
+			wantErr: "limits cannot be negative",
+		},
+		{
+			name:    "unknown field",
+			keys:    &mockSigningKeyStore{},
+			sv:      &mockSignValidator{},
+			cfg:     &LookupTierConfig{PublicFields: []string{"url", "secret"}},
+			wantErr: `unknown public field "secret"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLookupTiers(tt.keys, tt.sv, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewLookupTiers() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookupTiers_Admit_Public(t *testing.T) {
+	tiers := newTestLookupTiers(t, &mockSigningKeyStore{}, &mockSignValidator{}, &LookupTierConfig{Public: LookupTierLimit{PerMinute: 60, Burst: 2}})
+
+	for i := range 3 {
+		a, authErr := tiers.Admit(context.Background(), nil, "", "192.0.2.1:4000")
+		if authErr != nil {
+			t.Fatalf("Admit() unexpected error: %v", authErr)
+		}
+		if a.Tier != LookupTierPublic || a.RetryAfter != 0 || a.Caller != "192.0.2.1" {
+			t.Fatalf("Admit() #%d = %+v, want 192.0.2.1 admitted to the public tier", i, a)
+		}
+	}
+	// The port of the client does not give it a fresh limit.
+	a, _ := tiers.Admit(context.Background(), nil, "", "192.0.2.1:4001")
+	if a.RetryAfter != time.Second {
+		t.Errorf("Admit() RetryAfter = %v, want 1s once the burst is used up", a.RetryAfter)
+	}
+	// Other clients have their own limit.
+	if a, _ := tiers.Admit(context.Background(), nil, "", "192.0.2.2:4000"); a.RetryAfter != 0 {
+		t.Errorf("Admit() RetryAfter = %v for another client, want 0", a.RetryAfter)
+	}
+}
+
+func TestLookupTiers_Admit_Authenticated(t *testing.T) {
+	tiers := newTestLookupTiers(t, &mockSigningKeyStore{key: "key"}, &mockSignValidator{}, &LookupTierConfig{
+		Public:        LookupTierLimit{PerMinute: 1, Burst: 1},
+		Authenticated: LookupTierLimit{PerMinute: 600, Burst: 4},
+	})
+
+	for i := range 5 {
+		a, authErr := tiers.Admit(context.Background(), []byte(`{}`), testLookupAuthHeader, "192.0.2.1:4000")
+		if authErr != nil {
+			t.Fatalf("Admit() unexpected error: %v", authErr)
+		}
+		if a.Tier != LookupTierAuthenticated || a.RetryAfter != 0 || a.Caller != "bap.example.com" {
+			t.Fatalf("Admit() #%d = %+v, want bap.example.com admitted to the authenticated tier", i, a)
+		}
+	}
+	a, _ := tiers.Admit(context.Background(), []byte(`{}`), testLookupAuthHeader, "192.0.2.1:4000")
+	if a.RetryAfter != 100*time.Millisecond {
+		t.Errorf("Admit() RetryAfter = %v, want 100ms once the burst is used up", a.RetryAfter)
+	}
+	// Authenticated lookups do not use up the public limit of the client.
+	if a, _ := tiers.Admit(context.Background(), nil, "", "192.0.2.1:4000"); a.RetryAfter != 0 {
+		t.Errorf("Admit() public RetryAfter = %v, want 0", a.RetryAfter)
+	}
+}
+
+func TestLookupTiers_Admit_AuthErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     *mockSigningKeyStore
+		sv       *mockSignValidator
+		header   string
+		wantCode model.ErrorCode
+	}{
+		{
+			name:     "malformed header",
+			keys:     &mockSigningKeyStore{key: "key"},
+			sv:       &mockSignValidator{},
+			header:   "Signature",
+			wantCode: model.ErrorCodeInvalidAuthHeader,
+		},
+		{
+			name:     "unknown key",
+			keys:     &mockSigningKeyStore{err: fmt.Errorf("%w: key1", repository.ErrSubscriberKeyNotFound)},
+			sv:       &mockSignValidator{},
+			header:   testLookupAuthHeader,
+			wantCode: model.ErrorCodeSubscriptionNotFound,
+		},
+		{
+			name:     "key store failure",
+			keys:     &mockSigningKeyStore{err: errors.New("db down")},
+			sv:       &mockSignValidator{},
+			header:   testLookupAuthHeader,
+			wantCode: model.ErrorCodeKeyUnavailable,
+		},
+		{
+			name:     "invalid signature",
+			keys:     &mockSigningKeyStore{key: "key"},
+			sv:       &mockSignValidator{err: errors.New("bad signature")},
+			header:   testLookupAuthHeader,
+			wantCode: model.ErrorCodeInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers := newTestLookupTiers(t, tt.keys, tt.sv, &LookupTierConfig{})
+			a, authErr := tiers.Admit(context.Background(), []byte(`{}`), tt.header, "192.0.2.1:4000")
+			if authErr == nil {
+				t.Fatalf("Admit() = %+v, want an error", a)
+			}
+			if authErr.StatusCode != http.StatusUnauthorized || authErr.ErrorCode != tt.wantCode {
+				t.Errorf("Admit() error = %d %s, want 401 %s", authErr.StatusCode, authErr.ErrorCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLookupAdmission_Filter(t *testing.T) {
+	tiers := newTestLookupTiers(t, &mockSigningKeyStore{key: "key"}, &mockSignValidator{}, &LookupTierConfig{PublicFields: []string{"subscriber_id", "url"}})
+	newSubs := func() []model.Subscription {
+		return []model.Subscription{{
+			Subscriber:    model.Subscriber{SubscriberID: "bpp.example.com", URL: "https://bpp.example.com", Domain: "retail"},
+			EncrPublicKey: "encr",
+			Nonce:         "nonce",
+		}}
+	}
+
+	public, _ := tiers.Admit(context.Background(), nil, "", "192.0.2.1:4000")
+	subs := newSubs()
+	public.Filter(subs)
+	want := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp.example.com", URL: "https://bpp.example.com"}}
+	if subs[0].SubscriberID != want.SubscriberID || subs[0].URL != want.URL || subs[0].Domain != "" || subs[0].EncrPublicKey != "" || subs[0].Nonce != "" {
+		t.Errorf("Filter() public = %+v, want %+v", subs[0], want)
+	}
+
+	auth, _ := tiers.Admit(context.Background(), []byte(`{}`), testLookupAuthHeader, "192.0.2.1:4000")
+	subs = newSubs()
+	auth.Filter(subs)
+	if subs[0].Domain != "retail" || subs[0].EncrPublicKey != "encr" || subs[0].Nonce != "nonce" {
+		t.Errorf("Filter() authenticated = %+v, want all fields", subs[0])
+	}
+}
+
+func TestLookupLimiter_Sweep(t *testing.T) {
+	l := newLookupLimiter(LookupTierLimit{PerMinute: 60, Burst: 1})
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+	for i := range lookupLimiterSweepSize {
+		l.allow(fmt.Sprintf("client-%d", i))
+	}
+	now = now.Add(2 * time.Second)
+	l.allow("new-client")
+	if len(l.tat) != 1 {
+		t.Errorf("len(tat) = %d after sweep, want 1", len(l.tat))
+	}
+}
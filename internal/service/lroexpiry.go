@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// Actions taken on expired LROs.
+const (
+	LROExpiryActionReject = "reject"
+	LROExpiryActionStale  = "stale"
+)
+
+const (
+	defaultLROExpiryInterval  = 5 * time.Minute
+	defaultLROExpiryBatchSize = 100
+)
+
+// LROExpiryConfig configures the background job that expires abandoned PENDING LROs.
+type LROExpiryConfig struct {
+	// Timeout is how long a PENDING LRO may go without any admin action before it expires.
+	Timeout time.Duration `yaml:"timeout"`
+	// Interval is how often the job scans for expired LROs.
+	Interval time.Duration `yaml:"interval"`
+	// Action is either "reject", which rejects the LRO and publishes a rejection event,
+	// or "stale", which only flags it as STALE for an admin to review.
+	Action string `yaml:"action"`
+	// BatchSize is the maximum number of LROs expired per scan.
+	BatchSize int `yaml:"batchSize"`
+}
+
+// lroExpiryRepo is the repository used to find and expire stale LROs.
+type lroExpiryRepo interface {
+	ListStaleOperations(ctx context.Context, before time.Time, limit int) ([]model.LRO, error)
+	ExpireOperation(ctx context.Context, lro *model.LRO, before time.Time) (*model.LRO, error)
+}
+
+// rejectionEventPublisher publishes the rejection of a subscription request.
+type rejectionEventPublisher interface {
+	PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error)
+}
+
+// lroExpiryJob periodically expires PENDING LROs that have been abandoned.
+type lroExpiryJob struct {
+	repo        lroExpiryRepo
+	evPublisher rejectionEventPublisher
+	timeout     time.Duration
+	interval    time.Duration
+	status      model.LROStatus
+	batchSize   int
+	transitions transitionNotifier
+	now         func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewLROExpiryJob creates a new lroExpiryJob.
+func NewLROExpiryJob(repo lroExpiryRepo, evPub rejectionEventPublisher, cfg *LROExpiryConfig) (*lroExpiryJob, error) {
+	if repo == nil {
+		slog.Error("NewLROExpiryJob: repo cannot be nil")
+		return nil, errors.New("repo cannot be nil")
+	}
+	if evPub == nil {
+		slog.Error("NewLROExpiryJob: eventPublisher cannot be nil")
+		return nil, errors.New("eventPublisher cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewLROExpiryJob: LROExpiryConfig cannot be nil")
+		return nil, errors.New("LROExpiryConfig cannot be nil")
+	}
+	if cfg.Timeout <= 0 {
+		return nil, errors.New("LROExpiryConfig.Timeout must be positive")
+	}
+	j := &lroExpiryJob{
+		repo:        repo,
+		evPublisher: evPub,
+		timeout:     cfg.Timeout,
+		interval:    cfg.Interval,
+		batchSize:   cfg.BatchSize,
+		now:         time.Now,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	switch cfg.Action {
+	case LROExpiryActionReject, "":
+		j.status = model.LROStatusRejected
+	case LROExpiryActionStale:
+		j.status = model.LROStatusStale
+	default:
+		return nil, fmt.Errorf("invalid LRO expiry action: %q", cfg.Action)
+	}
+	if j.interval <= 0 {
+		j.interval = defaultLROExpiryInterval
+	}
+	if j.batchSize <= 0 {
+		j.batchSize = defaultLROExpiryBatchSize
+	}
+	return j, nil
+}
+
+// SetTransitionNotifier enables notifying n of the LROs flagged as STALE, which have no Pub/Sub event.
+func (j *lroExpiryJob) SetTransitionNotifier(n transitionNotifier) {
+	j.transitions = n
+}
+
+// Start launches the background expiry loop. It returns immediately.
+func (j *lroExpiryJob) Start(ctx context.Context) {
+	slog.InfoContext(ctx, "LROExpiryJob: Starting", "timeout", j.timeout, "interval", j.interval, "status", j.status)
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := j.RunOnce(ctx); err != nil {
+				slog.ErrorContext(ctx, "LROExpiryJob: Expiry run failed", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-j.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the expiry loop to exit. It is safe to call more than once.
+func (j *lroExpiryJob) Stop() {
+	j.stopOnce.Do(func() { close(j.stop) })
+}
+
+// RunOnce expires one batch of stale LROs and returns how many were expired.
+func (j *lroExpiryJob) RunOnce(ctx context.Context) (int, error) {
+	before := j.now().Add(-j.timeout)
+	lros, err := j.repo.ListStaleOperations(ctx, before, j.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stale operations: %w", err)
+	}
+	expired := 0
+	for i := range lros {
+		if err := j.expire(ctx, &lros[i], before); err != nil {
+			if errors.Is(err, repository.ErrOperationNotPending) {
+				slog.InfoContext(ctx, "LROExpiryJob: Operation was acted on before it could expire", "operation_id", lros[i].OperationID)
+				continue
+			}
+			slog.ErrorContext(ctx, "LROExpiryJob: Failed to expire operation", "operation_id", lros[i].OperationID, "error", err)
+			continue
+		}
+		expired++
+	}
+	if expired > 0 {
+		slog.InfoContext(ctx, "LROExpiryJob: Expired abandoned operations", "count", expired, "status", j.status)
+	}
+	return expired, nil
+}
+
+// expire updates a single LRO and, when it is rejected, publishes the rejection event.
+func (j *lroExpiryJob) expire(ctx context.Context, lro *model.LRO, before time.Time) error {
+	reason := fmt.Sprintf("operation expired after %s without admin action", j.timeout)
+	errJSON, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return fmt.Errorf("failed to marshal expiry reason: %w", err)
+	}
+	lro.Status = j.status
+	lro.ErrorDataJSON = errJSON
+	updated, err := j.repo.ExpireOperation(ctx, lro, before)
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "LROExpiryJob: Expired operation", "operation_id", updated.OperationID, "status", updated.Status)
+	if updated.Status != model.LROStatusRejected {
+		if tp, ok := lroTransitionEvent(updated); ok && j.transitions != nil {
+			j.transitions.Notify(ctx, tp, updated)
+		}
+		return nil
+	}
+	if evID, err := j.evPublisher.PublishSubscriptionRequestRejectedEvent(ctx, updated); err != nil {
+		slog.ErrorContext(ctx, "LROExpiryJob: Failed to publish subscription rejected event", "operation_id", updated.OperationID, "error", err)
+	} else {
+		slog.InfoContext(ctx, "LROExpiryJob: Published subscription rejected event", "operation_id", updated.OperationID, "event_id", evID)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+type mockLROExpiryRepo struct {
+	stale     []model.LRO
+	listErr   error
+	expireErr map[string]error
+	gotBefore time.Time
+	gotLimit  int
+	expired   []model.LRO
+}
+
+func (m *mockLROExpiryRepo) ListStaleOperations(ctx context.Context, before time.Time, limit int) ([]model.LRO, error) {
+	m.gotBefore = before
+	m.gotLimit = limit
+	return m.stale, m.listErr
+}
+
+func (m *mockLROExpiryRepo) ExpireOperation(ctx context.Context, lro *model.LRO, before time.Time) (*model.LRO, error) {
+	if err := m.expireErr[lro.OperationID]; err != nil {
+		return nil, err
+	}
+	m.expired = append(m.expired, *lro)
+	return lro, nil
+}
+
+type mockRejectionPublisher struct {
+	published []string
+	err       error
+}
+
+func (m *mockRejectionPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, lro *model.LRO) (string, error) {
+	m.published = append(m.published, lro.OperationID)
+	return "msg-id", m.err
+}
+
+func TestNewLROExpiryJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		repo          lroExpiryRepo
+		evPub         rejectionEventPublisher
+		cfg           *LROExpiryConfig
+		wantErr       bool
+		wantStatus    model.LROStatus
+		wantInterval  time.Duration
+		wantBatchSize int
+	}{
+		{
+			name:          "defaults",
+			repo:          &mockLROExpiryRepo{},
+			evPub:         &mockRejectionPublisher{},
+			cfg:           &LROExpiryConfig{Timeout: time.Hour},
+			wantStatus:    model.LROStatusRejected,
+			wantInterval:  defaultLROExpiryInterval,
+			wantBatchSize: defaultLROExpiryBatchSize,
+		},
+		{
+			name:          "stale action",
+			repo:          &mockLROExpiryRepo{},
+			evPub:         &mockRejectionPublisher{},
+			cfg:           &LROExpiryConfig{Timeout: time.Hour, Interval: time.Minute, Action: LROExpiryActionStale, BatchSize: 10},
+			wantStatus:    model.LROStatusStale,
+			wantInterval:  time.Minute,
+			wantBatchSize: 10,
+		},
+		{name: "nil repo", evPub: &mockRejectionPublisher{}, cfg: &LROExpiryConfig{Timeout: time.Hour}, wantErr: true},
+		{name: "nil publisher", repo: &mockLROExpiryRepo{}, cfg: &LROExpiryConfig{Timeout: time.Hour}, wantErr: true},
+		{name: "nil config", repo: &mockLROExpiryRepo{}, evPub: &mockRejectionPublisher{}, wantErr: true},
+		{name: "zero timeout", repo: &mockLROExpiryRepo{}, evPub: &mockRejectionPublisher{}, cfg: &LROExpiryConfig{}, wantErr: true},
+		{name: "invalid action", repo: &mockLROExpiryRepo{}, evPub: &mockRejectionPublisher{}, cfg: &LROExpiryConfig{Timeout: time.Hour, Action: "delete"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := NewLROExpiryJob(tt.repo, tt.evPub, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLROExpiryJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if j.status != tt.wantStatus || j.interval != tt.wantInterval || j.batchSize != tt.wantBatchSize {
+				t.Errorf("NewLROExpiryJob() = status %s, interval %v, batch %d, want %s, %v, %d",
+					j.status, j.interval, j.batchSize, tt.wantStatus, tt.wantInterval, tt.wantBatchSize)
+			}
+		})
+	}
+}
+
+func TestLROExpiryJob_RunOnce(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	stale := func() []model.LRO {
+		return []model.LRO{
+			{OperationID: "op-1", Status: model.LROStatusPending, Type: model.OperationTypeCreateSubscription},
+			{OperationID: "op-2", Status: model.LROStatusPending, Type: model.OperationTypeUpdateSubscription},
+			{OperationID: "op-3", Status: model.LROStatusPending, Type: model.OperationTypeCreateSubscription},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		action        string
+		expireErr     map[string]error
+		publishErr    error
+		wantCount     int
+		wantStatus    model.LROStatus
+		wantPublished []string
+		wantNotified  int
+	}{
+		{
+			name:          "reject publishes events",
+			action:        LROExpiryActionReject,
+			wantCount:     3,
+			wantStatus:    model.LROStatusRejected,
+			wantPublished: []string{"op-1", "op-2", "op-3"},
+		},
+		{
+			name:         "stale does not publish but notifies",
+			action:       LROExpiryActionStale,
+			wantCount:    3,
+			wantStatus:   model.LROStatusStale,
+			wantNotified: 3,
+		},
+		{
+			name:          "skips operations acted on concurrently and failed updates",
+			action:        LROExpiryActionReject,
+			expireErr:     map[string]error{"op-1": repository.ErrOperationNotPending, "op-2": errors.New("db error")},
+			wantCount:     1,
+			wantStatus:    model.LROStatusRejected,
+			wantPublished: []string{"op-3"},
+		},
+		{
+			name:          "publish failure still expires",
+			action:        LROExpiryActionReject,
+			publishErr:    errors.New("pubsub down"),
+			wantCount:     3,
+			wantStatus:    model.LROStatusRejected,
+			wantPublished: []string{"op-1", "op-2", "op-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockLROExpiryRepo{stale: stale(), expireErr: tt.expireErr}
+			evPub := &mockRejectionPublisher{err: tt.publishErr}
+			j, err := NewLROExpiryJob(repo, evPub, &LROExpiryConfig{Timeout: 24 * time.Hour, Action: tt.action, BatchSize: 50})
+			if err != nil {
+				t.Fatalf("NewLROExpiryJob() error = %v", err)
+			}
+			j.now = func() time.Time { return now }
+			notifier := &recordingNotifier{}
+			j.SetTransitionNotifier(notifier)
+
+			got, err := j.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+			if got != tt.wantCount {
+				t.Errorf("RunOnce() = %d, want %d", got, tt.wantCount)
+			}
+			if want := now.Add(-24 * time.Hour); !repo.gotBefore.Equal(want) || repo.gotLimit != 50 {
+				t.Errorf("ListStaleOperations() called with (%v, %d), want (%v, 50)", repo.gotBefore, repo.gotLimit, want)
+			}
+			for _, lro := range repo.expired {
+				if lro.Status != tt.wantStatus {
+					t.Errorf("expired %s with status %s, want %s", lro.OperationID, lro.Status, tt.wantStatus)
+				}
+				var reason map[string]string
+				if err := json.Unmarshal(lro.ErrorDataJSON, &reason); err != nil || reason["reason"] == "" {
+					t.Errorf("expired %s error data = %s, want a reason", lro.OperationID, lro.ErrorDataJSON)
+				}
+			}
+			if fmt.Sprint(evPub.published) != fmt.Sprint(tt.wantPublished) {
+				t.Errorf("published rejections = %v, want %v", evPub.published, tt.wantPublished)
+			}
+			if len(notifier.events) != tt.wantNotified {
+				t.Errorf("notified transitions = %v, want %d", notifier.events, tt.wantNotified)
+			}
+			for _, tp := range notifier.events {
+				if tp != model.EventTypeSubscriptionRequestExpired {
+					t.Errorf("notified transition %s, want %s", tp, model.EventTypeSubscriptionRequestExpired)
+				}
+			}
+		})
+	}
+}
+
+func TestLROExpiryJob_RunOnce_ListError(t *testing.T) {
+	repo := &mockLROExpiryRepo{listErr: errors.New("db error")}
+	j, err := NewLROExpiryJob(repo, &mockRejectionPublisher{}, &LROExpiryConfig{Timeout: time.Hour})
+	if err != nil {
+		t.Fatalf("NewLROExpiryJob() error = %v", err)
+	}
+	if _, err := j.RunOnce(context.Background()); err == nil {
+		t.Error("RunOnce() error = nil, want error")
+	}
+}
+
+func TestLROExpiryJob_StartStop(t *testing.T) {
+	j, err := NewLROExpiryJob(&mockLROExpiryRepo{}, &mockRejectionPublisher{}, &LROExpiryConfig{Timeout: time.Hour, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewLROExpiryJob() error = %v", err)
+	}
+	j.Start(context.Background())
+	time.Sleep(5 * time.Millisecond)
+	j.Stop()
+	// Stop must be idempotent.
+	j.Stop()
+
+	select {
+	case <-j.done:
+	case <-time.After(time.Second):
+		t.Error("expiry goroutine did not exit after Stop")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultLROStatsWindow = 30 * 24 * time.Hour
+	maxLROStatsWindow     = 366 * 24 * time.Hour
+)
+
+// ErrInvalidStatsWindow is returned when the requested statistics window is empty or too long.
+var ErrInvalidStatsWindow = errors.New("invalid statistics window")
+
+// lroStatsRepository defines the repository operation that computes LRO statistics.
+type lroStatsRepository interface {
+	OperationStats(ctx context.Context, from, to time.Time) (*model.LROStats, error)
+}
+
+// lroStatsService reports statistics of the operations submitted to the registry.
+type lroStatsService struct {
+	repo lroStatsRepository
+	now  func() time.Time
+}
+
+// NewLROStatsService creates a new lroStatsService.
+func NewLROStatsService(repo lroStatsRepository) (*lroStatsService, error) {
+	if repo == nil {
+		slog.Error("NewLROStatsService: lroStatsRepository cannot be nil")
+		return nil, errors.New("lroStatsRepository cannot be nil")
+	}
+	return &lroStatsService{repo: repo, now: time.Now}, nil
+}
+
+// Stats returns statistics of the operations submitted in [from, to).
+// A zero to defaults to now and a zero from to 30 days before to.
+// The window may not be longer than 366 days.
+func (s *lroStatsService) Stats(ctx context.Context, from, to time.Time) (*model.LROStats, error) {
+	if to.IsZero() {
+		to = s.now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultLROStatsWindow)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from %s is not before to %s", ErrInvalidStatsWindow, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	if to.Sub(from) > maxLROStatsWindow {
+		return nil, fmt.Errorf("%w: window may not be longer than %d days", ErrInvalidStatsWindow, int(maxLROStatsWindow.Hours()/24))
+	}
+	stats, err := s.repo.OperationStats(ctx, from.UTC(), to.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "LROStatsService: Failed to compute operation statistics", "from", from, "to", to, "error", err)
+		return nil, fmt.Errorf("failed to compute operation statistics: %w", err)
+	}
+	return stats, nil
+}
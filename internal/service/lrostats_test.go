@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+type mockLROStatsRepository struct {
+	stats *model.LROStats
+	err   error
+
+	gotFrom, gotTo time.Time
+}
+
+func (m *mockLROStatsRepository) OperationStats(ctx context.Context, from, to time.Time) (*model.LROStats, error) {
+	m.gotFrom, m.gotTo = from, to
+	return m.stats, m.err
+}
+
+func TestNewLROStatsService_Error(t *testing.T) {
+	if _, err := NewLROStatsService(nil); err == nil {
+		t.Error("NewLROStatsService() expected error, got nil")
+	}
+}
+
+func TestLROStatsService_Stats(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		from, to time.Time
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{
+			name:     "defaults to the last 30 days",
+			wantFrom: now.Add(-30 * 24 * time.Hour),
+			wantTo:   now,
+		},
+		{
+			name:     "from defaults to 30 days before to",
+			to:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			wantFrom: time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "explicit window is converted to UTC",
+			from:     time.Date(2025, 6, 1, 5, 30, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+			to:       time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+			wantFrom: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockLROStatsRepository{stats: &model.LROStats{Total: 1}}
+			s, _ := NewLROStatsService(repo)
+			s.now = func() time.Time { return now }
+
+			got, err := s.Stats(context.Background(), tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Stats() unexpected error: %v", err)
+			}
+			if got != repo.stats {
+				t.Errorf("Stats() = %v, want %v", got, repo.stats)
+			}
+			if !repo.gotFrom.Equal(tc.wantFrom) || repo.gotFrom.Location() != time.UTC {
+				t.Errorf("from = %v, want %v", repo.gotFrom, tc.wantFrom)
+			}
+			if !repo.gotTo.Equal(tc.wantTo) || repo.gotTo.Location() != time.UTC {
+				t.Errorf("to = %v, want %v", repo.gotTo, tc.wantTo)
+			}
+		})
+	}
+}
+
+func TestLROStatsService_Stats_Error(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	dbErr := errors.New("db down")
+	tests := []struct {
+		name     string
+		from, to time.Time
+		repoErr  error
+		wantErr  error
+	}{
+		{name: "empty window", from: day, to: day, wantErr: ErrInvalidStatsWindow},
+		{name: "from after to", from: day.Add(time.Hour), to: day, wantErr: ErrInvalidStatsWindow},
+		{name: "window too long", from: day.AddDate(-2, 0, 0), to: day, wantErr: ErrInvalidStatsWindow},
+		{name: "repository error", from: day.AddDate(0, 0, -1), to: day, repoErr: dbErr, wantErr: dbErr},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := NewLROStatsService(&mockLROStatsRepository{err: tc.repoErr})
+
+			if _, err := s.Stats(context.Background(), tc.from, tc.to); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Stats() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultMaintenanceRefreshInterval = 5 * time.Second
+	defaultMaintenanceRetryAfter      = time.Minute
+)
+
+// MaintenanceConfig configures the read-only maintenance mode of the registry.
+type MaintenanceConfig struct {
+	// Enabled puts the registry in maintenance mode regardless of the mode set through the admin API.
+	Enabled bool `yaml:"enabled"`
+	// Message is returned to rejected clients while Enabled is set.
+	Message string `yaml:"message"`
+	// RefreshInterval is how long the mode set through the admin API is cached. Defaults to 5s.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+	// RetryAfter is the delay suggested to rejected clients. Defaults to 1m.
+	RetryAfter time.Duration `yaml:"retryAfter"`
+}
+
+// maintenanceRepository stores the maintenance mode set through the admin API.
+type maintenanceRepository interface {
+	GetMaintenance(ctx context.Context) (*model.Maintenance, error)
+	SetMaintenance(ctx context.Context, m *model.Maintenance) (*model.Maintenance, error)
+}
+
+// maintenanceService reports and changes the maintenance mode of the registry.
+// The mode is shared through the database, so that a change made by the admin
+// service reaches every registry instance within the refresh interval.
+type maintenanceService struct {
+	repo       maintenanceRepository
+	forced     bool
+	message    string
+	refresh    time.Duration
+	retryAfter time.Duration
+	now        func() time.Time
+
+	mu        sync.Mutex
+	cached    *model.Maintenance
+	fetchedAt time.Time
+}
+
+// NewMaintenanceService creates a new maintenanceService.
+func NewMaintenanceService(repo maintenanceRepository, cfg *MaintenanceConfig) (*maintenanceService, error) {
+	if repo == nil {
+		slog.Error("NewMaintenanceService: maintenanceRepository cannot be nil")
+		return nil, errors.New("maintenanceRepository cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewMaintenanceService: MaintenanceConfig cannot be nil")
+		return nil, errors.New("MaintenanceConfig cannot be nil")
+	}
+	s := &maintenanceService{
+		repo:       repo,
+		forced:     cfg.Enabled,
+		message:    cfg.Message,
+		refresh:    cfg.RefreshInterval,
+		retryAfter: cfg.RetryAfter,
+		now:        time.Now,
+	}
+	if s.refresh <= 0 {
+		s.refresh = defaultMaintenanceRefreshInterval
+	}
+	if s.retryAfter <= 0 {
+		s.retryAfter = defaultMaintenanceRetryAfter
+	}
+	if s.forced {
+		slog.Warn("NewMaintenanceService: Registry is in maintenance mode by configuration")
+	}
+	return s, nil
+}
+
+// Status returns the current maintenance mode. If the mode cannot be read, the last
+// known mode is returned, so that an unavailable database does not block lookups.
+func (s *maintenanceService) Status(ctx context.Context) *model.Maintenance {
+	if s.forced {
+		return &model.Maintenance{Enabled: true, Message: s.message}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached == nil || s.now().Sub(s.fetchedAt) >= s.refresh {
+		m, err := s.repo.GetMaintenance(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "MaintenanceService: Failed to read maintenance mode, using last known mode", "error", err)
+			if s.cached == nil {
+				return &model.Maintenance{}
+			}
+		} else {
+			s.cached = m
+			s.fetchedAt = s.now()
+		}
+	}
+	m := *s.cached
+	return &m
+}
+
+// Set changes the maintenance mode.
+func (s *maintenanceService) Set(ctx context.Context, m *model.Maintenance) (*model.Maintenance, error) {
+	updated, err := s.repo.SetMaintenance(ctx, m)
+	if err != nil {
+		slog.ErrorContext(ctx, "MaintenanceService: Failed to set maintenance mode", "error", err)
+		return nil, fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+	s.mu.Lock()
+	c := *updated
+	s.cached = &c
+	s.fetchedAt = s.now()
+	s.mu.Unlock()
+	slog.InfoContext(ctx, "MaintenanceService: Maintenance mode changed", "enabled", updated.Enabled, "message", updated.Message)
+	return updated, nil
+}
+
+// RetryAfter returns the delay suggested to clients rejected during maintenance.
+func (s *maintenanceService) RetryAfter() time.Duration {
+	return s.retryAfter
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockMaintenanceRepo is a mock for maintenanceRepository.
+type mockMaintenanceRepo struct {
+	m      *model.Maintenance
+	getErr error
+	setErr error
+	gets   int
+}
+
+func (m *mockMaintenanceRepo) GetMaintenance(ctx context.Context) (*model.Maintenance, error) {
+	m.gets++
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	c := *m.m
+	return &c, nil
+}
+
+func (m *mockMaintenanceRepo) SetMaintenance(ctx context.Context, mt *model.Maintenance) (*model.Maintenance, error) {
+	if m.setErr != nil {
+		return nil, m.setErr
+	}
+	mt.UpdatedAt = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	c := *mt
+	m.m = &c
+	return mt, nil
+}
+
+func TestNewMaintenanceService(t *testing.T) {
+	s, err := NewMaintenanceService(&mockMaintenanceRepo{}, &MaintenanceConfig{})
+	if err != nil {
+		t.Fatalf("NewMaintenanceService() unexpected error: %v", err)
+	}
+	if s.refresh != defaultMaintenanceRefreshInterval || s.RetryAfter() != defaultMaintenanceRetryAfter {
+		t.Errorf("NewMaintenanceService() refresh = %v, retryAfter = %v, want defaults", s.refresh, s.RetryAfter())
+	}
+}
+
+func TestNewMaintenanceService_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		repo maintenanceRepository
+		cfg  *MaintenanceConfig
+	}{
+		{name: "nil repository", cfg: &MaintenanceConfig{}},
+		{name: "nil config", repo: &mockMaintenanceRepo{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewMaintenanceService(tc.repo, tc.cfg); err == nil {
+				t.Error("NewMaintenanceService() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestMaintenanceService_Status(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mockMaintenanceRepo{m: &model.Maintenance{Enabled: true, Message: "migrating"}}
+	s, _ := NewMaintenanceService(repo, &MaintenanceConfig{RefreshInterval: time.Minute})
+	s.now = func() time.Time { return now }
+
+	want := &model.Maintenance{Enabled: true, Message: "migrating"}
+	if diff := cmp.Diff(want, s.Status(ctx)); diff != "" {
+		t.Errorf("Status() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The mode is cached until the refresh interval has passed.
+	repo.m = &model.Maintenance{}
+	s.Status(ctx)
+	if repo.gets != 1 {
+		t.Errorf("GetMaintenance() called %d times, want 1", repo.gets)
+	}
+	now = now.Add(time.Minute)
+	if got := s.Status(ctx); got.Enabled {
+		t.Errorf("Status() Enabled = true after refresh, want false")
+	}
+
+	// The last known mode is kept if it cannot be refreshed.
+	repo.m = &model.Maintenance{Enabled: true}
+	now = now.Add(time.Minute)
+	s.Status(ctx)
+	now = now.Add(time.Minute)
+	repo.getErr = errors.New("db down")
+	if got := s.Status(ctx); !got.Enabled {
+		t.Errorf("Status() Enabled = false on refresh error, want last known true")
+	}
+}
+
+func TestMaintenanceService_Status_NeverRead(t *testing.T) {
+	s, _ := NewMaintenanceService(&mockMaintenanceRepo{getErr: errors.New("db down")}, &MaintenanceConfig{})
+
+	if got := s.Status(context.Background()); got.Enabled {
+		t.Errorf("Status() Enabled = true, want false")
+	}
+}
+
+func TestMaintenanceService_Status_Forced(t *testing.T) {
+	repo := &mockMaintenanceRepo{m: &model.Maintenance{}}
+	s, _ := NewMaintenanceService(repo, &MaintenanceConfig{Enabled: true, Message: "upgrade"})
+
+	want := &model.Maintenance{Enabled: true, Message: "upgrade"}
+	if diff := cmp.Diff(want, s.Status(context.Background())); diff != "" {
+		t.Errorf("Status() mismatch (-want +got):\n%s", diff)
+	}
+	if repo.gets != 0 {
+		t.Errorf("GetMaintenance() called %d times, want 0", repo.gets)
+	}
+}
+
+func TestMaintenanceService_Set(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockMaintenanceRepo{m: &model.Maintenance{}}
+	s, _ := NewMaintenanceService(repo, &MaintenanceConfig{RefreshInterval: time.Hour})
+	s.Status(ctx)
+
+	got, err := s.Set(ctx, &model.Maintenance{Enabled: true, Message: "migrating"})
+	if err != nil {
+		t.Fatalf("Set() unexpected error: %v", err)
+	}
+	want := &model.Maintenance{Enabled: true, Message: "migrating", UpdatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Set() mismatch (-want +got):\n%s", diff)
+	}
+	// The new mode is visible without waiting for a refresh.
+	if diff := cmp.Diff(want, s.Status(ctx)); diff != "" {
+		t.Errorf("Status() after Set() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaintenanceService_Set_Error(t *testing.T) {
+	s, _ := NewMaintenanceService(&mockMaintenanceRepo{setErr: errors.New("db down")}, &MaintenanceConfig{})
+
+	if _, err := s.Set(context.Background(), &model.Maintenance{Enabled: true}); err == nil {
+		t.Error("Set() expected error, got nil")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidMerge is returned when a subscriber merge request is malformed.
+var ErrInvalidMerge = errors.New("invalid subscriber merge")
+
+// subscriberMergeRepository defines the repository operations needed to find and merge duplicate subscribers.
+type subscriberMergeRepository interface {
+	Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
+	MergeSubscribers(ctx context.Context, req *model.SubscriberMergeRequest, mergeID string) (*model.SubscriberMergeReport, error)
+}
+
+// subscriberMergeService finds subscribers that are likely the same participant registered
+// more than once, and merges them into one.
+type subscriberMergeService struct {
+	repo  subscriberMergeRepository
+	newID func() string
+}
+
+// NewSubscriberMergeService creates a new subscriberMergeService.
+func NewSubscriberMergeService(repo subscriberMergeRepository) (*subscriberMergeService, error) {
+	if repo == nil {
+		slog.Error("NewSubscriberMergeService: subscriberMergeRepository cannot be nil")
+		return nil, errors.New("subscriberMergeRepository cannot be nil")
+	}
+	return &subscriberMergeService{repo: repo, newID: uuid.NewString}, nil
+}
+
+// Duplicates returns the groups of subscribers whose subscriptions share a URL or a signing
+// key, or whose IDs are near-identical. A subscriber can be in several groups.
+func (s *subscriberMergeService) Duplicates(ctx context.Context) ([]model.DuplicateGroup, error) {
+	subs, err := s.repo.Lookup(ctx, &model.Subscription{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	groups := []model.DuplicateGroup{}
+	groups = appendDuplicates(groups, model.DuplicateReasonURL, subs, func(sub *model.Subscription) string {
+		return strings.ToLower(strings.TrimRight(strings.TrimSpace(sub.URL), "/"))
+	})
+	groups = appendDuplicates(groups, model.DuplicateReasonSigningKey, subs, func(sub *model.Subscription) string {
+		key := strings.TrimSpace(sub.SigningPublicKey)
+		if key == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	})
+	groups = appendDuplicates(groups, model.DuplicateReasonSubscriberID, subs, func(sub *model.Subscription) string {
+		return normalizeSubscriberID(sub.SubscriberID)
+	})
+	slog.InfoContext(ctx, "SubscriberMergeService: Found duplicate groups", "subscriptions", len(subs), "groups", len(groups))
+	return groups, nil
+}
+
+// appendDuplicates appends to groups the groups of distinct subscribers of subs that have the
+// same non-empty key, sorted by key.
+func appendDuplicates(groups []model.DuplicateGroup, reason model.DuplicateReason, subs []model.Subscription, key func(*model.Subscription) string) []model.DuplicateGroup {
+	byKey := map[string][]string{}
+	for i := range subs {
+		k := key(&subs[i])
+		if k == "" || slices.Contains(byKey[k], subs[i].SubscriberID) {
+			continue
+		}
+		byKey[k] = append(byKey[k], subs[i].SubscriberID)
+	}
+	start := len(groups)
+	for k, ids := range byKey {
+		if len(ids) < 2 {
+			continue
+		}
+		slices.Sort(ids)
+		groups = append(groups, model.DuplicateGroup{Reason: reason, Match: k, SubscriberIDs: ids})
+	}
+	slices.SortFunc(groups[start:], func(a, b model.DuplicateGroup) int { return cmp.Compare(a.Match, b.Match) })
+	return groups
+}
+
+// normalizeSubscriberID returns a subscriber ID without case, scheme, leading "www." or
+// trailing slashes, so that near-identical IDs compare equal.
+func normalizeSubscriberID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "https://"), "http://")
+	id = strings.TrimPrefix(id, "www.")
+	return strings.TrimRight(id, "/")
+}
+
+// Merge merges the source subscriber of the request into its target, which must exist.
+// See MergeSubscribers in the repository for what is merged.
+func (s *subscriberMergeService) Merge(ctx context.Context, req *model.SubscriberMergeRequest) (*model.SubscriberMergeReport, error) {
+	if req.SourceSubscriberID == "" || req.TargetSubscriberID == "" {
+		return nil, fmt.Errorf("%w: source_subscriber_id and target_subscriber_id are required", ErrInvalidMerge)
+	}
+	if req.SourceSubscriberID == req.TargetSubscriberID {
+		return nil, fmt.Errorf("%w: cannot merge subscriber %s into itself", ErrInvalidMerge, req.SourceSubscriberID)
+	}
+	targets, err := s.repo.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: req.TargetSubscriberID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up target subscriber: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: subscriber %s", repository.ErrSubscriptionNotFound, req.TargetSubscriberID)
+	}
+	report, err := s.repo.MergeSubscribers(ctx, req, s.newID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge subscribers: %w", err)
+	}
+	slog.InfoContext(ctx, "SubscriberMergeService: Merged subscribers",
+		"merge_id", report.MergeID, "source", req.SourceSubscriberID, "target", req.TargetSubscriberID, "dry_run", req.DryRun,
+		"moved", len(report.Moved), "dropped", len(report.Dropped), "api_keys", report.APIKeys,
+		"denylist_entries", report.DenylistEntries, "operations", report.Operations)
+	return report, nil
+}
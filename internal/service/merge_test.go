@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockMergeRepo is a mock for subscriberMergeRepository.
+type mockMergeRepo struct {
+	subs       []model.Subscription
+	lookupErr  error
+	mergeErr   error
+	gotFilter  *model.Subscription
+	gotMergeID string
+}
+
+func (m *mockMergeRepo) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	m.gotFilter = filter
+	if m.lookupErr != nil {
+		return nil, m.lookupErr
+	}
+	return m.subs, nil
+}
+
+func (m *mockMergeRepo) MergeSubscribers(ctx context.Context, req *model.SubscriberMergeRequest, mergeID string) (*model.SubscriberMergeReport, error) {
+	if m.mergeErr != nil {
+		return nil, m.mergeErr
+	}
+	m.gotMergeID = mergeID
+	return &model.SubscriberMergeReport{MergeID: mergeID, SourceSubscriberID: req.SourceSubscriberID, TargetSubscriberID: req.TargetSubscriberID}, nil
+}
+
+func mergeTestSubscription(id, url, key string) model.Subscription {
+	return model.Subscription{Subscriber: model.Subscriber{SubscriberID: id, URL: url}, SigningPublicKey: key}
+}
+
+func TestNewSubscriberMergeService_Error(t *testing.T) {
+	if _, err := NewSubscriberMergeService(nil); err == nil {
+		t.Error("NewSubscriberMergeService() expected error, got nil")
+	}
+}
+
+func TestSubscriberMergeService_Duplicates(t *testing.T) {
+	repo := &mockMergeRepo{subs: []model.Subscription{
+		mergeTestSubscription("np1.com", "https://np1.com/beckn", "key1"),
+		mergeTestSubscription("np1.com", "https://np1.com/beckn", "key1"),
+		mergeTestSubscription("https://www.NP1.com/", "https://np1.com/beckn/", "key2"),
+		mergeTestSubscription("np2.com", "https://np2.com/beckn", "key1"),
+		mergeTestSubscription("np3.com", "https://np3.com/beckn", ""),
+		mergeTestSubscription("np4.com", "https://np4.com/beckn", ""),
+	}}
+	s, _ := NewSubscriberMergeService(repo)
+
+	got, err := s.Duplicates(context.Background())
+	if err != nil {
+		t.Fatalf("Duplicates() unexpected error: %v", err)
+	}
+	want := []model.DuplicateGroup{
+		{Reason: model.DuplicateReasonURL, Match: "https://np1.com/beckn", SubscriberIDs: []string{"https://www.NP1.com/", "np1.com"}},
+		{Reason: model.DuplicateReasonSigningKey, Match: "8174099687a26621f4e2cdd7cc03b3dacedb3fb962255b1aafd033cabe831530", SubscriberIDs: []string{"np1.com", "np2.com"}},
+		{Reason: model.DuplicateReasonSubscriberID, Match: "np1.com", SubscriberIDs: []string{"https://www.NP1.com/", "np1.com"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Duplicates() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&model.Subscription{}, repo.gotFilter); diff != "" {
+		t.Errorf("Duplicates() lookup filter mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriberMergeService_Duplicates_LookupError(t *testing.T) {
+	s, _ := NewSubscriberMergeService(&mockMergeRepo{lookupErr: errors.New("db error")})
+	if _, err := s.Duplicates(context.Background()); err == nil {
+		t.Error("Duplicates() expected error, got nil")
+	}
+}
+
+func TestSubscriberMergeService_Merge(t *testing.T) {
+	repo := &mockMergeRepo{subs: []model.Subscription{mergeTestSubscription("np1.org", "https://np1.org", "key1")}}
+	s, _ := NewSubscriberMergeService(repo)
+	s.newID = func() string { return "merge1" }
+
+	got, err := s.Merge(context.Background(), &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"})
+	if err != nil {
+		t.Fatalf("Merge() unexpected error: %v", err)
+	}
+	want := &model.SubscriberMergeReport{MergeID: "merge1", SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Merge() mismatch (-want +got):\n%s", diff)
+	}
+	if repo.gotFilter.SubscriberID != "np1.org" {
+		t.Errorf("Merge() looked up %q, want target np1.org", repo.gotFilter.SubscriberID)
+	}
+}
+
+func TestSubscriberMergeService_Merge_Error(t *testing.T) {
+	target := []model.Subscription{mergeTestSubscription("np1.org", "https://np1.org", "key1")}
+	tests := []struct {
+		name    string
+		repo    *mockMergeRepo
+		req     *model.SubscriberMergeRequest
+		wantErr error
+	}{
+		{
+			name:    "missing source",
+			repo:    &mockMergeRepo{subs: target},
+			req:     &model.SubscriberMergeRequest{TargetSubscriberID: "np1.org"},
+			wantErr: ErrInvalidMerge,
+		},
+		{
+			name:    "merge into itself",
+			repo:    &mockMergeRepo{subs: target},
+			req:     &model.SubscriberMergeRequest{SourceSubscriberID: "np1.org", TargetSubscriberID: "np1.org"},
+			wantErr: ErrInvalidMerge,
+		},
+		{
+			name:    "target not found",
+			repo:    &mockMergeRepo{},
+			req:     &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"},
+			wantErr: repository.ErrSubscriptionNotFound,
+		},
+		{
+			name:    "source not found",
+			repo:    &mockMergeRepo{subs: target, mergeErr: repository.ErrSubscriptionNotFound},
+			req:     &model.SubscriberMergeRequest{SourceSubscriberID: "np1.com", TargetSubscriberID: "np1.org"},
+			wantErr: repository.ErrSubscriptionNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewSubscriberMergeService(tt.repo)
+			if _, err := s.Merge(context.Background(), tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Merge() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
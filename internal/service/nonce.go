@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ErrNonceRequired is returned when a subscription request has no nonce but one is required.
+var ErrNonceRequired = errors.New("nonce is required")
+
+const defaultNonceUniquenessWindow = 24 * time.Hour
+
+// NonceConfig configures the lifecycle of subscription request nonces.
+type NonceConfig struct {
+	// Required rejects subscription requests that carry no nonce.
+	Required bool `yaml:"required"`
+	// UniquenessWindow is how long an unconsumed nonce stays reserved by its operation.
+	// Consumed nonces can never be reused.
+	UniquenessWindow time.Duration `yaml:"uniquenessWindow"`
+	// MaxAge is the longest time between a nonce being reserved and consumed on approval.
+	// It defaults to UniquenessWindow.
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// nonceRepo stores nonce reservations.
+type nonceRepo interface {
+	ReserveNonce(ctx context.Context, nonce, subscriberID, operationID string, windowStart time.Time) error
+	ConsumeNonce(ctx context.Context, nonce, operationID string, issuedAfter time.Time) error
+}
+
+// nonceService enforces single use and expiry of subscription request nonces.
+type nonceService struct {
+	repo     nonceRepo
+	required bool
+	window   time.Duration
+	maxAge   time.Duration
+	now      func() time.Time
+}
+
+// NewNonceService creates a new nonceService.
+func NewNonceService(repo nonceRepo, cfg *NonceConfig) (*nonceService, error) {
+	if repo == nil {
+		slog.Error("NewNonceService: repo cannot be nil")
+		return nil, errors.New("repo cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewNonceService: NonceConfig cannot be nil")
+		return nil, errors.New("NonceConfig cannot be nil")
+	}
+	s := &nonceService{repo: repo, required: cfg.Required, window: cfg.UniquenessWindow, maxAge: cfg.MaxAge, now: time.Now}
+	if s.window <= 0 {
+		s.window = defaultNonceUniquenessWindow
+	}
+	if s.maxAge <= 0 {
+		s.maxAge = s.window
+	}
+	return s, nil
+}
+
+// Reserve claims the request's nonce for its operation, returning
+// repository.ErrNonceReplayed if the nonce is already in use.
+func (s *nonceService) Reserve(ctx context.Context, req *model.SubscriptionRequest) error {
+	if req.Nonce == "" {
+		if s.required {
+			return ErrNonceRequired
+		}
+		return nil
+	}
+	return s.repo.ReserveNonce(ctx, req.Nonce, req.SubscriberID, req.MessageID, s.now().Add(-s.window))
+}
+
+// Consume marks the nonce as used by the operation being approved, returning
+// repository.ErrNonceReplayed or repository.ErrNonceExpired if it cannot be used.
+// Operations without a nonce were accepted before nonces were required and are not checked.
+func (s *nonceService) Consume(ctx context.Context, operationID, nonce string) error {
+	if nonce == "" {
+		return nil
+	}
+	return s.repo.ConsumeNonce(ctx, nonce, operationID, s.now().Add(-s.maxAge))
+}
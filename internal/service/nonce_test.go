@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+type mockNonceRepo struct {
+	reserveErr error
+	consumeErr error
+
+	reserveCalls int
+	consumeCalls int
+	gotNonce     string
+	gotSubID     string
+	gotOpID      string
+	gotTime      time.Time
+}
+
+func (m *mockNonceRepo) ReserveNonce(ctx context.Context, nonce, subscriberID, operationID string, windowStart time.Time) error {
+	m.reserveCalls++
+	m.gotNonce, m.gotSubID, m.gotOpID, m.gotTime = nonce, subscriberID, operationID, windowStart
+	return m.reserveErr
+}
+
+func (m *mockNonceRepo) ConsumeNonce(ctx context.Context, nonce, operationID string, issuedAfter time.Time) error {
+	m.consumeCalls++
+	m.gotNonce, m.gotOpID, m.gotTime = nonce, operationID, issuedAfter
+	return m.consumeErr
+}
+
+func TestNewNonceService(t *testing.T) {
+	tests := []struct {
+		name       string
+		repo       nonceRepo
+		cfg        *NonceConfig
+		wantErr    bool
+		wantWindow time.Duration
+		wantMaxAge time.Duration
+	}{
+		{name: "defaults", repo: &mockNonceRepo{}, cfg: &NonceConfig{}, wantWindow: defaultNonceUniquenessWindow, wantMaxAge: defaultNonceUniquenessWindow},
+		{name: "max age defaults to window", repo: &mockNonceRepo{}, cfg: &NonceConfig{UniquenessWindow: time.Hour}, wantWindow: time.Hour, wantMaxAge: time.Hour},
+		{name: "explicit", repo: &mockNonceRepo{}, cfg: &NonceConfig{UniquenessWindow: time.Hour, MaxAge: time.Minute}, wantWindow: time.Hour, wantMaxAge: time.Minute},
+		{name: "nil repo", cfg: &NonceConfig{}, wantErr: true},
+		{name: "nil config", repo: &mockNonceRepo{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewNonceService(tt.repo, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewNonceService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if s.window != tt.wantWindow || s.maxAge != tt.wantMaxAge {
+				t.Errorf("NewNonceService() = window %v, maxAge %v, want %v, %v", s.window, s.maxAge, tt.wantWindow, tt.wantMaxAge)
+			}
+		})
+	}
+}
+
+func TestNonceService_Reserve(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	req := func(nonce string) *model.SubscriptionRequest {
+		return &model.SubscriptionRequest{
+			MessageID:    "op-1",
+			Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub-1"}, Nonce: nonce},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		required    bool
+		req         *model.SubscriptionRequest
+		repoErr     error
+		wantErr     error
+		wantReserve bool
+	}{
+		{name: "reserves nonce", req: req("nonce-1"), wantReserve: true},
+		{name: "missing nonce allowed", req: req("")},
+		{name: "missing nonce required", required: true, req: req(""), wantErr: ErrNonceRequired},
+		{name: "replayed", req: req("nonce-1"), repoErr: repository.ErrNonceReplayed, wantErr: repository.ErrNonceReplayed, wantReserve: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockNonceRepo{reserveErr: tt.repoErr}
+			s, err := NewNonceService(repo, &NonceConfig{Required: tt.required, UniquenessWindow: time.Hour})
+			if err != nil {
+				t.Fatalf("NewNonceService() error = %v", err)
+			}
+			s.now = func() time.Time { return now }
+
+			if err := s.Reserve(context.Background(), tt.req); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reserve() error = %v, want %v", err, tt.wantErr)
+			}
+			if (repo.reserveCalls == 1) != tt.wantReserve {
+				t.Fatalf("ReserveNonce() calls = %d, want reserve %v", repo.reserveCalls, tt.wantReserve)
+			}
+			if tt.wantReserve && (repo.gotNonce != "nonce-1" || repo.gotSubID != "sub-1" || repo.gotOpID != "op-1" || !repo.gotTime.Equal(now.Add(-time.Hour))) {
+				t.Errorf("ReserveNonce() called with (%s, %s, %s, %v)", repo.gotNonce, repo.gotSubID, repo.gotOpID, repo.gotTime)
+			}
+		})
+	}
+}
+
+func TestNonceService_Consume(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		nonce       string
+		repoErr     error
+		wantErr     error
+		wantConsume bool
+	}{
+		{name: "consumes nonce", nonce: "nonce-1", wantConsume: true},
+		{name: "operation without nonce", nonce: ""},
+		{name: "expired", nonce: "nonce-1", repoErr: repository.ErrNonceExpired, wantErr: repository.ErrNonceExpired, wantConsume: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockNonceRepo{consumeErr: tt.repoErr}
+			s, err := NewNonceService(repo, &NonceConfig{Required: true, UniquenessWindow: time.Hour, MaxAge: 10 * time.Minute})
+			if err != nil {
+				t.Fatalf("NewNonceService() error = %v", err)
+			}
+			s.now = func() time.Time { return now }
+
+			if err := s.Consume(context.Background(), "op-1", tt.nonce); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Consume() error = %v, want %v", err, tt.wantErr)
+			}
+			if (repo.consumeCalls == 1) != tt.wantConsume {
+				t.Fatalf("ConsumeNonce() calls = %d, want consume %v", repo.consumeCalls, tt.wantConsume)
+			}
+			if tt.wantConsume && (repo.gotOpID != "op-1" || !repo.gotTime.Equal(now.Add(-10*time.Minute))) {
+				t.Errorf("ConsumeNonce() called with (%s, %v)", repo.gotOpID, repo.gotTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// maxTimelineSubscriptionChanges is the most subscription history versions shown on a timeline.
+const maxTimelineSubscriptionChanges = 20
+
+// subscriptionChangeVerbs describe the changes recorded in the subscription history.
+var subscriptionChangeVerbs = map[model.SubscriptionChange]string{
+	model.SubscriptionChangeInsert:   "created",
+	model.SubscriptionChangeUpdate:   "updated",
+	model.SubscriptionChangeDelete:   "deleted",
+	model.SubscriptionChangeBackfill: "recorded",
+}
+
+// operationTimelineRepository defines the repository operations that read the records of an operation.
+type operationTimelineRepository interface {
+	GetOperation(ctx context.Context, id string) (*model.LRO, error)
+	ListChallengeAttempts(ctx context.Context, operationID string) ([]model.ChallengeAttempt, error)
+	ListOperationDeliveries(ctx context.Context, operationID string) ([]model.WebhookDelivery, error)
+	SubscriptionChanges(ctx context.Context, subscriberID, domain string, typ model.Role, keyID string, since time.Time, limit int) ([]model.SubscriptionVersion, error)
+}
+
+// operationTimelineService composes the records of an operation, its reviews, challenges,
+// subscription changes and events, into a single chronological timeline for support staff.
+type operationTimelineService struct {
+	repo operationTimelineRepository
+}
+
+// NewOperationTimelineService creates a new operationTimelineService.
+func NewOperationTimelineService(repo operationTimelineRepository) (*operationTimelineService, error) {
+	if repo == nil {
+		slog.Error("NewOperationTimelineService: operationTimelineRepository cannot be nil")
+		return nil, errors.New("operationTimelineRepository cannot be nil")
+	}
+	return &operationTimelineService{repo: repo}, nil
+}
+
+// Timeline returns the timeline of an operation. Entries that happened at the same time keep
+// the order of the operation's lifecycle.
+func (s *operationTimelineService) Timeline(ctx context.Context, operationID string) (*model.OperationTimeline, error) {
+	lro, err := s.repo.GetOperation(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation %s: %w", operationID, err)
+	}
+	var req model.SubscriptionRequest
+	if err := json.Unmarshal(lro.RequestJSON, &req); err != nil {
+		slog.WarnContext(ctx, "OperationTimelineService: Failed to unmarshal operation request", "operation_id", operationID, "error", err)
+	}
+	t := &model.OperationTimeline{OperationID: lro.OperationID, SubscriberID: req.SubscriberID, Type: lro.Type, Status: lro.Status}
+
+	t.Entries = append(t.Entries, submissionEntry(lro, &req))
+	if e, ok := probeEntry(lro); ok {
+		t.Entries = append(t.Entries, e)
+	}
+	t.Entries = append(t.Entries, reviewEntries(lro)...)
+
+	attempts, err := s.repo.ListChallengeAttempts(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenge attempts: %w", err)
+	}
+	for _, a := range attempts {
+		t.Entries = append(t.Entries, challengeEntry(a))
+	}
+
+	if req.SubscriberID != "" {
+		versions, err := s.repo.SubscriptionChanges(ctx, req.SubscriberID, req.Domain, req.Type, req.KeyID, lro.CreatedAt, maxTimelineSubscriptionChanges)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subscription history: %w", err)
+		}
+		for _, v := range versions {
+			t.Entries = append(t.Entries, model.OperationTimelineEntry{
+				At:      v.ChangedAt,
+				Source:  model.OperationTimelineSourceSubscription,
+				Summary: fmt.Sprintf("Subscription %s with status %s", subscriptionChangeVerbs[v.Change], v.Status),
+				Details: v,
+			})
+		}
+	}
+
+	deliveries, err := s.repo.ListOperationDeliveries(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	for _, d := range deliveries {
+		t.Entries = append(t.Entries, deliveryEntry(d))
+	}
+
+	if e, ok := outcomeEntry(lro); ok {
+		t.Entries = append(t.Entries, e)
+	}
+	slices.SortStableFunc(t.Entries, func(a, b model.OperationTimelineEntry) int { return a.At.Compare(b.At) })
+	return t, nil
+}
+
+// submissionEntry describes the request that created the operation.
+func submissionEntry(lro *model.LRO, req *model.SubscriptionRequest) model.OperationTimelineEntry {
+	summary := fmt.Sprintf("%s requested", lro.Type)
+	if req.Domain != "" {
+		summary = fmt.Sprintf("%s requested for domain %s as %s with key %s", lro.Type, req.Domain, req.Type, req.KeyID)
+	}
+	return model.OperationTimelineEntry{
+		At:      lro.CreatedAt,
+		Source:  model.OperationTimelineSourceOperation,
+		Summary: summary,
+		Actor:   req.SubscriberID,
+		Details: lro.RequestJSON,
+	}
+}
+
+// probeEntry describes the probe of the subscriber URL made on submission, if any.
+func probeEntry(lro *model.LRO) (model.OperationTimelineEntry, bool) {
+	var p model.URLProbeResult
+	if len(lro.ProbeJSON) == 0 || json.Unmarshal(lro.ProbeJSON, &p) != nil {
+		return model.OperationTimelineEntry{}, false
+	}
+	summary := fmt.Sprintf("URL %s is reachable", p.URL)
+	if !p.Reachable {
+		summary = fmt.Sprintf("URL %s is not reachable", p.URL)
+	}
+	return model.OperationTimelineEntry{At: p.CheckedAt, Source: model.OperationTimelineSourceOperation, Summary: summary, Details: p}, true
+}
+
+// reviewEntries describes the approvals, the latest review and the comments of admins.
+func reviewEntries(lro *model.LRO) []model.OperationTimelineEntry {
+	var entries []model.OperationTimelineEntry
+	if r := lro.Review; r != nil {
+		if len(r.Approvals) > 0 {
+			// The latest review is the last approval.
+			for i, a := range r.Approvals {
+				entries = append(entries, model.OperationTimelineEntry{
+					At:      a.ApprovedAt,
+					Source:  model.OperationTimelineSourceReview,
+					Summary: withComment(fmt.Sprintf("Approval %d of 2", i+1), a.Comment),
+					Actor:   a.Approver,
+					Details: a,
+				})
+			}
+		} else {
+			entries = append(entries, model.OperationTimelineEntry{
+				At:      r.ReviewedAt,
+				Source:  model.OperationTimelineSourceReview,
+				Summary: withComment(fmt.Sprintf("%s submitted", r.Action), r.Comment),
+				Actor:   r.Reviewer,
+				Details: r,
+			})
+		}
+	}
+	for _, c := range lro.Comments {
+		summary := "Comment added"
+		if c.Text != "" {
+			summary = withComment(summary, c.Text)
+		}
+		if n := len(c.Attachments); n > 0 {
+			summary += fmt.Sprintf(" (%d attachments)", n)
+		}
+		entries = append(entries, model.OperationTimelineEntry{At: c.CreatedAt, Source: model.OperationTimelineSourceReview, Summary: summary, Actor: c.Author, Details: c})
+	}
+	return entries
+}
+
+// challengeEntry describes an /on_subscribe challenge sent on approval.
+func challengeEntry(a model.ChallengeAttempt) model.OperationTimelineEntry {
+	summary := fmt.Sprintf("Challenge sent to %s failed: %s", a.URL, a.Error)
+	switch {
+	case a.Passed && a.Signed:
+		summary = fmt.Sprintf("Challenge sent to %s passed with a signed answer", a.URL)
+	case a.Passed:
+		summary = fmt.Sprintf("Challenge sent to %s passed", a.URL)
+	}
+	return model.OperationTimelineEntry{At: a.AttemptedAt, Source: model.OperationTimelineSourceChallenge, Summary: summary, Details: a}
+}
+
+// deliveryEntry describes the delivery of an event about the operation to a webhook.
+func deliveryEntry(d model.WebhookDelivery) model.OperationTimelineEntry {
+	summary := fmt.Sprintf("%s event to webhook %s %s (attempts: %d)", d.EventType, d.WebhookID, strings.ToLower(string(d.Status)), d.Attempts)
+	if d.LastError != "" {
+		summary += ": " + d.LastError
+	}
+	return model.OperationTimelineEntry{At: d.CreatedAt, Source: model.OperationTimelineSourceEvent, Summary: summary, Details: d}
+}
+
+// outcomeEntry describes the status the operation ended in, or last failed with, if it is not pending.
+func outcomeEntry(lro *model.LRO) (model.OperationTimelineEntry, bool) {
+	if lro.Status == model.LROStatusPending || lro.Status == "" {
+		return model.OperationTimelineEntry{}, false
+	}
+	summary := fmt.Sprintf("Operation %s", lro.Status)
+	var data map[string]any
+	if json.Unmarshal(lro.ErrorDataJSON, &data) == nil {
+		for _, k := range []string{"reason", "error"} {
+			if v, ok := data[k].(string); ok && v != "" {
+				summary += ": " + v
+				break
+			}
+		}
+	}
+	e := model.OperationTimelineEntry{At: lro.UpdatedAt, Source: model.OperationTimelineSourceOperation, Summary: summary}
+	if len(lro.ErrorDataJSON) > 0 {
+		e.Details = lro.ErrorDataJSON
+	}
+	return e, true
+}
+
+// withComment appends a quoted note to a summary, if there is one.
+func withComment(summary, comment string) string {
+	if comment == "" {
+		return summary
+	}
+	return fmt.Sprintf("%s: %q", summary, comment)
+}
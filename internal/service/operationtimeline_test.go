@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockTimelineRepo is a mock for operationTimelineRepository.
+type mockTimelineRepo struct {
+	lro         *model.LRO
+	attempts    []model.ChallengeAttempt
+	deliveries  []model.WebhookDelivery
+	versions    []model.SubscriptionVersion
+	getErr      error
+	attemptsErr error
+	deliveryErr error
+	historyErr  error
+	gotSince    time.Time
+}
+
+func (m *mockTimelineRepo) GetOperation(ctx context.Context, id string) (*model.LRO, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	return m.lro, nil
+}
+
+func (m *mockTimelineRepo) ListChallengeAttempts(ctx context.Context, operationID string) ([]model.ChallengeAttempt, error) {
+	return m.attempts, m.attemptsErr
+}
+
+func (m *mockTimelineRepo) ListOperationDeliveries(ctx context.Context, operationID string) ([]model.WebhookDelivery, error) {
+	return m.deliveries, m.deliveryErr
+}
+
+func (m *mockTimelineRepo) SubscriptionChanges(ctx context.Context, subscriberID, domain string, typ model.Role, keyID string, since time.Time, limit int) ([]model.SubscriptionVersion, error) {
+	m.gotSince = since
+	return m.versions, m.historyErr
+}
+
+// timelineLine is the part of a timeline entry the tests compare.
+type timelineLine struct {
+	At      time.Time
+	Source  model.OperationTimelineSource
+	Summary string
+	Actor   string
+}
+
+func timelineLines(entries []model.OperationTimelineEntry) []timelineLine {
+	var lines []timelineLine
+	for _, e := range entries {
+		lines = append(lines, timelineLine{At: e.At, Source: e.Source, Summary: e.Summary, Actor: e.Actor})
+	}
+	return lines
+}
+
+func TestNewOperationTimelineService_Error(t *testing.T) {
+	if _, err := NewOperationTimelineService(nil); err == nil {
+		t.Error("NewOperationTimelineService() expected error, got nil")
+	}
+}
+
+func TestOperationTimelineService_Timeline(t *testing.T) {
+	created := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return created.Add(time.Duration(m) * time.Minute) }
+	repo := &mockTimelineRepo{
+		lro: &model.LRO{
+			OperationID:   "op-1",
+			Status:        model.LROStatusApproved,
+			Type:          model.OperationTypeCreateSubscription,
+			RequestJSON:   []byte(`{"subscriber_id":"np1","url":"https://np1.example.com","type":"BPP","domain":"retail","key_id":"key-1"}`),
+			ProbeJSON:     []byte(`{"url":"https://np1.example.com","reachable":true,"checked_at":"2025-06-01T10:00:00Z"}`),
+			ErrorDataJSON: nil,
+			Review: &model.OperationReview{
+				Action:     model.OperationActionApproveSubscription,
+				Reviewer:   "bob",
+				ReviewedAt: at(30),
+				Approvals: []model.OperationApproval{
+					{Approver: "alice", Comment: "KYC ok", ApprovedAt: at(20)},
+					{Approver: "bob", ApprovedAt: at(30)},
+				},
+			},
+			Comments:  []model.OperationComment{{ID: "c-1", Author: "alice", Text: "PAN checked", Attachments: []string{"gs://kyc/pan.pdf"}, CreatedAt: at(10)}},
+			CreatedAt: created,
+			UpdatedAt: at(32),
+		},
+		attempts: []model.ChallengeAttempt{
+			{OperationID: "op-1", URL: "https://np1.example.com/on_subscribe", Error: "connection refused", AttemptedAt: at(25)},
+			{OperationID: "op-1", URL: "https://np1.example.com/on_subscribe", Passed: true, Signed: true, AttemptedAt: at(31)},
+		},
+		versions: []model.SubscriptionVersion{{
+			Subscription: model.Subscription{Status: model.SubscriptionStatusSubscribed},
+			Change:       model.SubscriptionChangeInsert,
+			ChangedAt:    at(32),
+		}},
+		deliveries: []model.WebhookDelivery{{
+			WebhookID: "hook-1",
+			EventType: model.EventTypeSubscriptionRequestApproved,
+			Status:    model.WebhookDeliveryStatusDelivered,
+			Attempts:  1,
+			CreatedAt: at(33),
+		}},
+	}
+	s, _ := NewOperationTimelineService(repo)
+
+	got, err := s.Timeline(context.Background(), "op-1")
+	if err != nil {
+		t.Fatalf("Timeline() unexpected error: %v", err)
+	}
+	if got.OperationID != "op-1" || got.SubscriberID != "np1" || got.Status != model.LROStatusApproved || got.Type != model.OperationTypeCreateSubscription {
+		t.Errorf("Timeline() header = %+v, want operation op-1 of np1", got)
+	}
+	if !repo.gotSince.Equal(created) {
+		t.Errorf("SubscriptionChanges() since = %v, want %v", repo.gotSince, created)
+	}
+	want := []timelineLine{
+		{At: created, Source: model.OperationTimelineSourceOperation, Summary: "CREATE_SUBSCRIPTION requested for domain retail as BPP with key key-1", Actor: "np1"},
+		{At: created, Source: model.OperationTimelineSourceOperation, Summary: "URL https://np1.example.com is reachable"},
+		{At: at(10), Source: model.OperationTimelineSourceReview, Summary: `Comment added: "PAN checked" (1 attachments)`, Actor: "alice"},
+		{At: at(20), Source: model.OperationTimelineSourceReview, Summary: `Approval 1 of 2: "KYC ok"`, Actor: "alice"},
+		{At: at(25), Source: model.OperationTimelineSourceChallenge, Summary: "Challenge sent to https://np1.example.com/on_subscribe failed: connection refused"},
+		{At: at(30), Source: model.OperationTimelineSourceReview, Summary: "Approval 2 of 2", Actor: "bob"},
+		{At: at(31), Source: model.OperationTimelineSourceChallenge, Summary: "Challenge sent to https://np1.example.com/on_subscribe passed with a signed answer"},
+		{At: at(32), Source: model.OperationTimelineSourceSubscription, Summary: "Subscription created with status SUBSCRIBED"},
+		{At: at(32), Source: model.OperationTimelineSourceOperation, Summary: "Operation APPROVED"},
+		{At: at(33), Source: model.OperationTimelineSourceEvent, Summary: "SUBSCRIPTION_REQUEST_APPROVED event to webhook hook-1 delivered (attempts: 1)"},
+	}
+	if diff := cmp.Diff(want, timelineLines(got.Entries)); diff != "" {
+		t.Errorf("Timeline() entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationTimelineService_Timeline_Rejected(t *testing.T) {
+	created := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	repo := &mockTimelineRepo{
+		lro: &model.LRO{
+			OperationID:   "op-1",
+			Status:        model.LROStatusRejected,
+			Type:          model.OperationTypeUpdateSubscription,
+			RequestJSON:   []byte(`{"subscriber_id":"np1","type":"BAP","domain":"retail","key_id":"key-2"}`),
+			ErrorDataJSON: []byte(`{"reason":"duplicate registration"}`),
+			Review:        &model.OperationReview{Action: model.OperationActionRejectSubscription, Reviewer: "alice", Comment: "duplicate", ReviewedAt: created.Add(time.Hour)},
+			CreatedAt:     created,
+			UpdatedAt:     created.Add(time.Hour),
+		},
+	}
+	s, _ := NewOperationTimelineService(repo)
+
+	got, err := s.Timeline(context.Background(), "op-1")
+	if err != nil {
+		t.Fatalf("Timeline() unexpected error: %v", err)
+	}
+	want := []timelineLine{
+		{At: created, Source: model.OperationTimelineSourceOperation, Summary: "UPDATE_SUBSCRIPTION requested for domain retail as BAP with key key-2", Actor: "np1"},
+		{At: created.Add(time.Hour), Source: model.OperationTimelineSourceReview, Summary: `REJECT_SUBSCRIPTION submitted: "duplicate"`, Actor: "alice"},
+		{At: created.Add(time.Hour), Source: model.OperationTimelineSourceOperation, Summary: "Operation REJECTED: duplicate registration"},
+	}
+	if diff := cmp.Diff(want, timelineLines(got.Entries)); diff != "" {
+		t.Errorf("Timeline() entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationTimelineService_Timeline_Error(t *testing.T) {
+	lro := &model.LRO{OperationID: "op-1", Status: model.LROStatusPending, RequestJSON: []byte(`{"subscriber_id":"np1"}`)}
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name    string
+		repo    *mockTimelineRepo
+		wantErr error
+	}{
+		{name: "operation not found", repo: &mockTimelineRepo{getErr: repository.ErrOperationNotFound}, wantErr: repository.ErrOperationNotFound},
+		{name: "challenge attempts", repo: &mockTimelineRepo{lro: lro, attemptsErr: dbErr}, wantErr: dbErr},
+		{name: "subscription history", repo: &mockTimelineRepo{lro: lro, historyErr: dbErr}, wantErr: dbErr},
+		{name: "webhook deliveries", repo: &mockTimelineRepo{lro: lro, deliveryErr: dbErr}, wantErr: dbErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewOperationTimelineService(tt.repo)
+			if _, err := s.Timeline(context.Background(), "op-1"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Timeline() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrPendingQuotaExceeded is returned when a subscriber already has the maximum number of pending operations.
+var ErrPendingQuotaExceeded = errors.New("too many pending operations")
+
+const defaultMaxPendingOperations = 3
+
+// PendingQuotaConfig configures the cap on operations awaiting approval per subscriber.
+type PendingQuotaConfig struct {
+	// MaxPending is the maximum number of pending operations of a subscriber. Defaults to 3.
+	MaxPending int `yaml:"maxPending"`
+}
+
+// pendingOperationCounter counts the pending operations of a subscriber.
+type pendingOperationCounter interface {
+	CountPendingOperations(ctx context.Context, subscriberID string) (int, error)
+}
+
+// pendingQuota caps the number of pending operations of each subscriber, so that a
+// subscriber cannot flood the approval queue.
+type pendingQuota struct {
+	repo       pendingOperationCounter
+	maxPending int
+}
+
+// NewPendingQuota creates a new pendingQuota.
+func NewPendingQuota(repo pendingOperationCounter, cfg *PendingQuotaConfig) (*pendingQuota, error) {
+	if repo == nil {
+		slog.Error("NewPendingQuota: repo cannot be nil")
+		return nil, errors.New("repo cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewPendingQuota: PendingQuotaConfig cannot be nil")
+		return nil, errors.New("PendingQuotaConfig cannot be nil")
+	}
+	if cfg.MaxPending < 0 {
+		return nil, fmt.Errorf("invalid pending quota: maxPending %d cannot be negative", cfg.MaxPending)
+	}
+	q := &pendingQuota{repo: repo, maxPending: cfg.MaxPending}
+	if q.maxPending == 0 {
+		q.maxPending = defaultMaxPendingOperations
+	}
+	return q, nil
+}
+
+// Check returns ErrPendingQuotaExceeded if the subscriber already has the maximum number
+// of pending operations. The count is not reserved, so concurrent requests of a subscriber
+// may exceed the quota by the number of requests in flight.
+func (q *pendingQuota) Check(ctx context.Context, subscriberID string) error {
+	count, err := q.repo.CountPendingOperations(ctx, subscriberID)
+	if err != nil {
+		return fmt.Errorf("failed to check pending operations: %w", err)
+	}
+	if count >= q.maxPending {
+		return fmt.Errorf("%w: subscriber %s has %d pending operations, at most %d allowed", ErrPendingQuotaExceeded, subscriberID, count, q.maxPending)
+	}
+	return nil
+}
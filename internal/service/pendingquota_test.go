@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// mockPendingOperationCounter is a mock implementation of pendingOperationCounter.
+type mockPendingOperationCounter struct {
+	count int
+	err   error
+	id    string
+}
+
+func (m *mockPendingOperationCounter) CountPendingOperations(ctx context.Context, subscriberID string) (int, error) {
+	m.id = subscriberID
+	return m.count, m.err
+}
+
+func TestNewPendingQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    pendingOperationCounter
+		cfg     *PendingQuotaConfig
+		wantMax int
+		wantErr string
+	}{
+		{name: "default max", repo: &mockPendingOperationCounter{}, cfg: &PendingQuotaConfig{}, wantMax: defaultMaxPendingOperations},
+		{name: "configured max", repo: &mockPendingOperationCounter{}, cfg: &PendingQuotaConfig{MaxPending: 5}, wantMax: 5},
+		{name: "nil repo", cfg: &PendingQuotaConfig{}, wantErr: "repo cannot be nil"},
+		{name: "nil config", repo: &mockPendingOperationCounter{}, wantErr: "PendingQuotaConfig cannot be nil"},
+		{name: "negative max", repo: &mockPendingOperationCounter{}, cfg: &PendingQuotaConfig{MaxPending: -1}, wantErr: "invalid pending quota: maxPending -1 cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewPendingQuota(tt.repo, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewPendingQuota() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPendingQuota() unexpected error: %v", err)
+			}
+			if q.maxPending != tt.wantMax {
+				t.Errorf("maxPending = %d, want %d", q.maxPending, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestPendingQuota_Check(t *testing.T) {
+	repoErr := errors.New("db down")
+	tests := []struct {
+		name    string
+		count   int
+		err     error
+		wantErr error
+	}{
+		{name: "no pending operations"},
+		{name: "under quota", count: 2},
+		{name: "at quota", count: 3, wantErr: ErrPendingQuotaExceeded},
+		{name: "over quota", count: 4, wantErr: ErrPendingQuotaExceeded},
+		{name: "count fails", err: repoErr, wantErr: repoErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPendingOperationCounter{count: tt.count, err: tt.err}
+			q, _ := NewPendingQuota(repo, &PendingQuotaConfig{MaxPending: 3})
+
+			err := q.Check(context.Background(), "sub1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.id != "sub1" {
+				t.Errorf("CountPendingOperations() subscriberID = %q, want %q", repo.id, "sub1")
+			}
+		})
+	}
+}
@@ -22,7 +22,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"syscall"
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
@@ -49,11 +52,91 @@ type RetryConfig struct {
 }
 
 
+// targetValidator checks that a target URL may be called.
+type targetValidator interface {
+	Validate(ctx context.Context, target *url.URL) error
+	DialControl(network, address string, c syscall.RawConn) error
+}
+
+// bodyTransformer rewrites the body of a task for its target.
+type bodyTransformer interface {
+	Transform(ctx context.Context, target *url.URL, action string, body []byte) ([]byte, bool, error)
+}
+
+// batchPacer paces requests to a host and decides whether a signed header can be reused.
+type batchPacer interface {
+	Wait(ctx context.Context, host string) error
+	Reusable(authHeader string) bool
+}
+
+// hostPacer paces the requests sent to each target host.
+type hostPacer interface {
+	Wait(ctx context.Context, host string) error
+}
+
+// identityApplier sets the headers that identify the gateway.
+type identityApplier interface {
+	Apply(h http.Header)
+}
+
+// latencyLimiter limits concurrent requests to slow hosts and records the latency of requests.
+type latencyLimiter interface {
+	Acquire(ctx context.Context, host string) (func(), error)
+}
+
+// fanoutRecorder records the outcome of each request forwarded to a network participant.
+type fanoutRecorder interface {
+	RecordFanout(c *model.Context, d time.Duration, err error)
+}
+
+// requestHedger sends a request, racing a second attempt against a slow first one.
+type requestHedger interface {
+	Do(ctx context.Context, client httpClient, req *http.Request, action string) (*http.Response, error)
+}
+
+// clientCompressor wraps an HTTP client to compress the request bodies it sends.
+type clientCompressor interface {
+	Client(client httpClient) httpClient
+}
+
+// bodyRedactor removes personal data from a payload before it is logged.
+type bodyRedactor interface {
+	Redact(body []byte) []byte
+}
+
+// deadlineApplier sets the remaining transaction TTL on a request about to be dispatched
+// and bounds its context by the transaction's deadline.
+type deadlineApplier interface {
+	Apply(ctx context.Context, c *model.Context, h http.Header) (context.Context, context.CancelFunc, error)
+}
+
+// deliveryRecorder records the outcome of each request delivered to a target subscriber.
+type deliveryRecorder interface {
+	RecordDelivery(target *url.URL, action string, err error)
+}
+
+// errAuthHeader is returned when the gateway signature of a request cannot be generated.
+var errAuthHeader = errors.New("failed to generate auth header")
+
 // proxyTaskProcessor makes HTTP POST calls for asynchronous proxy tasks.
 type proxyTaskProcessor struct {
-	client httpClient // Changed from *http.Client to httpClient interface
-	auth   authGen
-	keyID  string
+	client      httpClient // Changed from *http.Client to httpClient interface
+	transport   *http.Transport
+	dialer      *net.Dialer
+	auth        authGen
+	keyID       string
+	policy      targetValidator
+	transformer bodyTransformer
+	pacer       batchPacer
+	identity    identityApplier
+	throttle    latencyLimiter
+	taskLog     *taskLogger
+	txnMetrics  fanoutRecorder
+	delivery    deliveryRecorder
+	hedger      requestHedger
+	redactor    bodyRedactor
+	deadline    deadlineApplier
+	hostRate    hostPacer
 }
 
 // NewProxyTaskProcessor creates a new proxyTaskProcessor.
@@ -86,12 +169,19 @@ func NewProxyTaskProcessor(auth authGen, keyID string, retryCfg RetryConfig) (*p
 	if retryCfg.IdleConnTimeout > 0 {
 		transport.IdleConnTimeout = retryCfg.IdleConnTimeout
 	}
+	// Same settings as the default transport's dialer, kept so that the target policy
+	// and the dual-stack dialer can share it.
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
 
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = retryCfg.RetryMax
 	retryClient.RetryWaitMin = retryCfg.RetryWaitMin
 	retryClient.RetryWaitMax = retryCfg.RetryWaitMax
 	retryClient.Logger = nil
+	retryClient.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+		recordAttempt(req.Context(), attempt+1)
+	}
 
 	// Set the underlying http.Client to use our custom transport and timeout.
 	retryClient.HTTPClient = &http.Client{
@@ -99,7 +189,130 @@ func NewProxyTaskProcessor(auth authGen, keyID string, retryCfg RetryConfig) (*p
 		Timeout:   retryCfg.Timeout,
 	}
 
-	return &proxyTaskProcessor{client: retryClient.StandardClient(), auth: auth, keyID: keyID}, nil
+	return &proxyTaskProcessor{client: retryClient.StandardClient(), transport: transport, dialer: dialer, auth: auth, keyID: keyID, taskLog: &taskLogger{rate: 1}}, nil
+}
+
+// SetTargetPolicy validates every target URL against the policy before it is called.
+// The policy is also checked when dialing, so a host cannot pass validation and
+// then resolve to a disallowed address. It must be set before tasks are processed.
+func (p *proxyTaskProcessor) SetTargetPolicy(policy targetValidator) {
+	p.policy = policy
+	if p.dialer == nil {
+		return
+	}
+	p.dialer.Control = policy.DialControl
+}
+
+// SetDualStack connects to targets over the preferred address family, falling back to
+// the other family with Happy Eyeballs, and counts connections per family. It must be
+// set before tasks are processed.
+func (p *proxyTaskProcessor) SetDualStack(cfg *DualStackConfig) error {
+	if p.transport == nil || p.dialer == nil {
+		return errors.New("proxy task processor has no transport")
+	}
+	d, err := newDualStackDialer(p.dialer, cfg)
+	if err != nil {
+		return err
+	}
+	p.transport.DialContext = d.DialContext
+	return nil
+}
+
+// SetTransformer rewrites the body of every task for its target before it is sent.
+// The gateway signs transformed bodies itself, replacing any existing gateway signature.
+// It must be set before tasks are processed.
+func (p *proxyTaskProcessor) SetTransformer(t bodyTransformer) {
+	p.transformer = t
+}
+
+// SetBatchPacer paces the requests of batched tasks to their host and re-signs a batch
+// once its signed header is about to expire. Without it, a batch is sent without pacing
+// and its signed header is reused for every target.
+func (p *proxyTaskProcessor) SetBatchPacer(pacer batchPacer) {
+	p.pacer = pacer
+}
+
+// SetIdentity sets the headers that identify the gateway on every request it sends.
+func (p *proxyTaskProcessor) SetIdentity(identity identityApplier) {
+	p.identity = identity
+}
+
+// SetThrottle records the latency of every request and limits the concurrent requests
+// to hosts the throttle considers slow.
+func (p *proxyTaskProcessor) SetThrottle(throttle latencyLimiter) {
+	p.throttle = throttle
+}
+
+// SetTaskLogger replaces the log of every proxy task with a sampled task log.
+func (p *proxyTaskProcessor) SetTaskLogger(l *taskLogger) {
+	p.taskLog = l
+}
+
+// SetTxnMetrics records the count and latency of proxy tasks per market segment.
+func (p *proxyTaskProcessor) SetTxnMetrics(m fanoutRecorder) {
+	p.txnMetrics = m
+}
+
+// SetHedging sends a second attempt of requests for idempotent actions to targets that have
+// not responded within the hedging delay.
+func (p *proxyTaskProcessor) SetHedging(h requestHedger) {
+	p.hedger = h
+}
+
+// SetDeliveryStats records the outcome of every request sent to a target subscriber.
+func (p *proxyTaskProcessor) SetDeliveryStats(d deliveryRecorder) {
+	p.delivery = d
+}
+
+// SetCompression gzip compresses large request bodies for targets that have advertised
+// that they accept gzip. It must be set before tasks are processed.
+func (p *proxyTaskProcessor) SetCompression(c clientCompressor) {
+	p.client = c.Client(p.client)
+}
+
+// SetRedactor redacts the response bodies that are logged or returned in errors.
+func (p *proxyTaskProcessor) SetRedactor(r bodyRedactor) {
+	p.redactor = r
+}
+
+// SetTTLDeadline sends the remaining TTL of the transaction with every request, so that
+// targets can abort work that can no longer be answered in time, and stops waiting for
+// targets at the deadline. Tasks whose TTL has elapsed before dispatch are dropped.
+func (p *proxyTaskProcessor) SetTTLDeadline(d deadlineApplier) {
+	p.deadline = d
+}
+
+// SetHostRateLimit paces the requests sent to each target host to the host's rate limit.
+// Retries and hedged attempts of a request are not paced again.
+func (p *proxyTaskProcessor) SetHostRateLimit(l hostPacer) {
+	p.hostRate = l
+}
+
+// loggable returns body as it may be logged, redacted if a redactor is set.
+func (p *proxyTaskProcessor) loggable(body []byte) string {
+	if p.redactor != nil {
+		body = p.redactor.Redact(body)
+	}
+	return string(body)
+}
+
+// transform returns a copy of the task with its body transformed for its target, or the task
+// itself if no transform applied. The task is not modified, so retries start from the original body.
+func (p *proxyTaskProcessor) transform(ctx context.Context, task *model.AsyncTask) (*model.AsyncTask, error) {
+	body, changed, err := p.transformer.Transform(ctx, task.Target, task.Context.Action, task.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to transform payload", "target", task.Target.String(), "error", err)
+		return nil, fmt.Errorf("failed to transform payload for %s: %w", task.Target.String(), err)
+	}
+	if !changed {
+		return task, nil
+	}
+	t := *task
+	t.Body = body
+	t.Headers = task.Headers.Clone()
+	// The gateway signature covers the original body, so it is generated again.
+	t.Headers.Del(model.AuthHeaderGateway)
+	return &t, nil
 }
 
 // validateTask checks if the AsyncTask is valid for processing.
@@ -131,25 +344,52 @@ func (p *proxyTaskProcessor) httpReq(ctx context.Context, task *model.AsyncTask)
 	if len(task.Body) > 0 && req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if p.identity != nil {
+		p.identity.Apply(req.Header)
+	}
 
 	// Only attempt to add auth header if it's not already present.
-	if req.Header.Get(model.AuthHeaderGateway) != "" {
-		return req, nil
+	if req.Header.Get(model.AuthHeaderGateway) == "" {
+		slog.DebugContext(ctx, "ProxyTaskProcessor: Generating auth header", "target", task.Target.String(), "key_id", p.keyID)
+		authHeader, err := p.auth.AuthHeader(ctx, task.Body, p.keyID)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to generate auth header", "error", err)
+			return nil, fmt.Errorf("%w: %w", errAuthHeader, err)
+		}
+		req.Header.Set(model.AuthHeaderGateway, authHeader)
 	}
-	slog.InfoContext(ctx, "ProxyTaskProcessor: Generating auth header", "target", task.Target.String(), "key_id", p.keyID)
+	// Tasks hold the gateway signature in the X-Gateway-Authorization format whatever its scheme.
+	encodeRFC9421(req.Header, model.AuthHeaderGateway, gatewaySignatureLabel, task.Body)
+	return req, nil
+}
+
+// signBatch signs a target of a batch into the headers it shares with the other targets,
+// unless they hold a signature that remains valid long enough to be reused.
+func (p *proxyTaskProcessor) signBatch(ctx context.Context, task *model.AsyncTask) error {
+	if auth := task.Headers.Get(model.AuthHeaderGateway); auth != "" && (p.pacer == nil || p.pacer.Reusable(auth)) {
+		return nil
+	}
+	slog.DebugContext(ctx, "ProxyTaskProcessor: Generating auth header for batch", "host", task.Target.Host, "key_id", p.keyID)
 	authHeader, err := p.auth.AuthHeader(ctx, task.Body, p.keyID)
 	if err != nil {
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to generate auth header", "error", err)
-		return nil, fmt.Errorf("failed to generate auth header: %w", err)
+		return fmt.Errorf("%w: %w", errAuthHeader, err)
 	}
-	req.Header.Set(model.AuthHeaderGateway, authHeader)
-	return req, nil
+	task.Headers.Set(model.AuthHeaderGateway, authHeader)
+	return nil
 }
 
-// proxy sends the HTTP request, reads, and parses the response.
-func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error {
+// proxy sends the HTTP request for action, reads, and parses the response.
+func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request, action string) error {
 	targetURLStr := req.URL.String()
-	resp, err := p.client.Do(req)
+	recordAttempt(ctx, 1)
+	var resp *http.Response
+	var err error
+	if p.hedger != nil {
+		resp, err = p.hedger.Do(ctx, p.client, req, action)
+	} else {
+		resp, err = p.client.Do(req)
+	}
 
 	if err != nil {
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: HTTP request failed", "error", err, "target", targetURLStr)
@@ -157,12 +397,13 @@ func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error
 	}
 	defer resp.Body.Close()
 
-	slog.InfoContext(ctx, "ProxyTaskProcessor: Received response", "target", targetURLStr, "status_code", resp.StatusCode)
+	slog.DebugContext(ctx, "ProxyTaskProcessor: Received response", "target", targetURLStr, "status_code", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		respBodyBytes, _ := io.ReadAll(resp.Body) // Read body for error context
-		slog.ErrorContext(ctx, "ProxyTaskProcessor: Unexpected HTTP status code", "target", targetURLStr, "status_code", resp.StatusCode, "response_body", string(respBodyBytes))
-		return fmt.Errorf("unexpected status code %d from %s. Body: %s", resp.StatusCode, targetURLStr, string(respBodyBytes))
+		body := p.loggable(respBodyBytes)
+		slog.ErrorContext(ctx, "ProxyTaskProcessor: Unexpected HTTP status code", "target", targetURLStr, "status_code", resp.StatusCode, "response_body", body)
+		return fmt.Errorf("unexpected status code %d from %s. Body: %s", resp.StatusCode, targetURLStr, body)
 	}
 
 	respBodyBytes, err := io.ReadAll(resp.Body)
@@ -173,8 +414,9 @@ func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error
 
 	var txnResponse model.TxnResponse
 	if err := json.Unmarshal(respBodyBytes, &txnResponse); err != nil {
-		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to unmarshal response body into TxnResponse", "error", err, "target", targetURLStr, "response_body", string(respBodyBytes))
-		return fmt.Errorf("failed to unmarshal response body from %s into model.TxnResponse: %w. Body: %s", targetURLStr, err, string(respBodyBytes))
+		body := p.loggable(respBodyBytes)
+		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to unmarshal response body into TxnResponse", "error", err, "target", targetURLStr, "response_body", body)
+		return fmt.Errorf("failed to unmarshal response body from %s into model.TxnResponse: %w. Body: %s", targetURLStr, err, body)
 	}
 	if txnResponse.Message.Ack.Status != model.StatusACK {
 		slog.WarnContext(ctx, "ProxyTaskProcessor: Response status is not ACK", "target", targetURLStr, "ack_status", txnResponse.Message.Ack.Status, "response_message", txnResponse.Message)
@@ -187,24 +429,126 @@ func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error
 	return nil
 }
 
+// processBatch sends the body of a PROXY_BATCH task to each of its targets in turn.
+// The body is signed at most once and the signed header is reused for every target
+// while it remains valid. It returns the errors of all targets that failed.
+func (p *proxyTaskProcessor) processBatch(ctx context.Context, batch *model.AsyncTask) error {
+	if len(batch.Targets) == 0 {
+		slog.ErrorContext(ctx, "ProxyTaskProcessor: async task batch has no targets")
+		return errors.New("async task batch has no targets")
+	}
+	if batch.Headers == nil {
+		slog.ErrorContext(ctx, "ProxyTaskProcessor: async task headers cannot be nil")
+		return errors.New("async task headers cannot be nil")
+	}
+	slog.InfoContext(ctx, "ProxyTaskProcessor: Processing task batch", "host", batch.Targets[0].Host, "targets", len(batch.Targets))
+	headers := batch.Headers.Clone()
+	var errs []error
+	for _, target := range batch.Targets {
+		if p.pacer != nil {
+			if err := p.pacer.Wait(ctx, target.Host); err != nil {
+				errs = append(errs, fmt.Errorf("batch to %s interrupted: %w", target.Host, err))
+				break
+			}
+		}
+		// The targets share headers, so that a signature made for one is reused by the next.
+		task := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target, Body: batch.Body, Headers: headers, Context: batch.Context}
+		if err := p.process(ctx, task, true); err != nil {
+			errs = append(errs, err)
+			if errors.Is(err, errAuthHeader) {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Process handles the given asynchronous task by making an HTTP POST request
 // to the task's target URL. It expects a 200 OK response with a model.TxnResponse
-// body indicating an ACK status.
-func (p *proxyTaskProcessor) Process(ctx context.Context, task *model.AsyncTask) error {
+// body indicating an ACK status. PROXY_BATCH tasks are sent to each of their targets.
+// The outcome of each task is written to the task log.
+func (p *proxyTaskProcessor) Process(ctx context.Context, task *model.AsyncTask) (err error) {
+	if task != nil && task.Type == model.AsyncTaskTypeProxyBatch {
+		return p.processBatch(ctx, task)
+	}
+	return p.process(ctx, task, false)
+}
+
+// process sends a PROXY task. The targets of a batch already waited for the batch pacer are
+// not paced again by the host rate limit. The request is signed once pacing and throttling
+// are done, so that the time spent waiting does not eat into the validity of the signature.
+func (p *proxyTaskProcessor) process(ctx context.Context, task *model.AsyncTask, batched bool) (err error) {
 	if err := p.validateTask(ctx, task); err != nil {
 		return err
 	}
-	slog.InfoContext(ctx, "ProxyTaskProcessor: Processing task", "target", task.Target.String(), "type", task.Type)
+	if p.taskLog != nil {
+		var attempts *int
+		ctx, attempts = withAttempts(ctx)
+		start := time.Now()
+		defer func() { p.taskLog.log(ctx, task, *attempts, time.Since(start), err) }()
+	}
+	if p.txnMetrics != nil {
+		start := time.Now()
+		defer func() { p.txnMetrics.RecordFanout(&task.Context, time.Since(start), err) }()
+	}
+	slog.DebugContext(ctx, "ProxyTaskProcessor: Processing task", "target", task.Target.String(), "type", task.Type)
+	if p.policy != nil {
+		if err := p.policy.Validate(ctx, task.Target); err != nil {
+			slog.WarnContext(ctx, "ProxyTaskProcessor: Target rejected by policy", "target", task.Target.String(), "error", err)
+			return err
+		}
+	}
+
+	if p.transformer != nil {
+		t, err := p.transform(ctx, task)
+		if err != nil {
+			return err
+		}
+		task = t
+	}
 
+	if p.hostRate != nil && !(batched && p.pacer != nil) {
+		if err := p.hostRate.Wait(ctx, task.Target.Host); err != nil {
+			slog.WarnContext(ctx, "ProxyTaskProcessor: Request not allowed by host rate limit", "target", task.Target.String(), "error", err)
+			return fmt.Errorf("rate limited request to %s not sent: %w", task.Target.String(), err)
+		}
+	}
+	if p.throttle != nil {
+		done, err := p.throttle.Acquire(ctx, task.Target.Host)
+		if err != nil {
+			slog.WarnContext(ctx, "ProxyTaskProcessor: Throttled request interrupted", "target", task.Target.String(), "error", err)
+			return fmt.Errorf("throttled request to %s interrupted: %w", task.Target.String(), err)
+		}
+		defer done()
+	}
+	if batched {
+		if err := p.signBatch(ctx, task); err != nil {
+			return err
+		}
+	}
 	req, err := p.httpReq(ctx, task)
 	if err != nil {
 		return err
 	}
-
-	if err := p.proxy(ctx, req); err != nil {
+	if p.deadline != nil {
+		// The budget is measured after pacing and throttling, when the request is dispatched.
+		dctx, cancel, err := p.deadline.Apply(ctx, &task.Context, req.Header)
+		if err != nil {
+			slog.WarnContext(ctx, "ProxyTaskProcessor: Dropped task past its ttl", "target", task.Target.String(), "error", err)
+			return err
+		}
+		defer cancel()
+		req = req.WithContext(dctx)
+		ctx = dctx
+	}
+	err = p.proxy(ctx, req, task.Context.Action)
+	if p.delivery != nil {
+		p.delivery.RecordDelivery(task.Target, task.Context.Action, err)
+	}
+	if err != nil {
 		return err
 	}
 
-	slog.InfoContext(ctx, "ProxyTaskProcessor: Task processed successfully and received ACK", "target", task.Target.String())
+	slog.DebugContext(ctx, "ProxyTaskProcessor: Task processed successfully and received ACK", "target", task.Target.String())
 	return nil
 }
@@ -18,15 +18,18 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/go-retryablehttp"
 )
 
@@ -295,6 +298,64 @@ func TestProxyTaskProcessor_httpReq(t *testing.T) {
 	}
 }
 
+func TestProxyTaskProcessor_httpReq_Identity(t *testing.T) {
+	identity, err := NewGatewayIdentity(&GatewayIdentityConfig{Version: "1.2.0", ContactURL: "https://gw.example.com/contact"}, "gw.example.com")
+	if err != nil {
+		t.Fatalf("NewGatewayIdentity() unexpected error: %v", err)
+	}
+	p := &proxyTaskProcessor{auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key"}
+	p.SetIdentity(identity)
+	// Identity headers copied from the inbound request are replaced.
+	task := newTestAsyncTask("http://example.com/search", []byte(`{}`), http.Header{model.GatewayIDHeader: []string{"spoofed"}})
+
+	req, err := p.httpReq(context.Background(), task)
+	if err != nil {
+		t.Fatalf("httpReq() unexpected error: %v", err)
+	}
+	want := map[string]string{
+		model.GatewayIDHeader:      "gw.example.com",
+		model.GatewayVersionHeader: "1.2.0",
+		model.GatewayContactHeader: "https://gw.example.com/contact",
+		"User-Agent":               "beckn-onix-gateway/1.2.0",
+	}
+	for name, v := range want {
+		if got := req.Header.Get(name); got != v {
+			t.Errorf("httpReq() %s = %q, want %q", name, got, v)
+		}
+	}
+	if got := task.Headers.Get(model.GatewayIDHeader); got != "spoofed" {
+		t.Errorf("task %s = %q, want the task headers unchanged", model.GatewayIDHeader, got)
+	}
+}
+
+func TestProxyTaskProcessor_httpReq_RFC9421(t *testing.T) {
+	auth := `Signature keyId="gw.example.com|key-1|ed25519",algorithm="ed25519",created="1",expires="2",headers="content-digest",signature="c2ln"`
+	p := &proxyTaskProcessor{auth: &mockAuthGen{authHeader: auth}, keyID: "test-key"}
+	// The subscriber's own RFC 9421 signature is forwarded next to the gateway's.
+	task := newTestAsyncTask("http://example.com/search", []byte(`{}`), http.Header{
+		"Signature-Input": []string{`sig1=("content-digest");created=1;expires=2;keyid="np|k|ed25519";alg="ed25519"`},
+		"Signature":       []string{"sig1=:bnA=:"},
+	})
+
+	req, err := p.httpReq(context.Background(), task)
+	if err != nil {
+		t.Fatalf("httpReq() unexpected error: %v", err)
+	}
+	if got := req.Header.Get(model.AuthHeaderGateway); got != "" {
+		t.Errorf("httpReq() AuthHeaderGateway = %q, want it moved to the RFC 9421 headers", got)
+	}
+	wantInput := []string{`sig1=("content-digest");created=1;expires=2;keyid="np|k|ed25519";alg="ed25519"`, `gateway=("content-digest");created=1;expires=2;keyid="gw.example.com|key-1|ed25519";alg="ed25519"`}
+	if got := req.Header.Values("Signature-Input"); strings.Join(got, "\n") != strings.Join(wantInput, "\n") {
+		t.Errorf("httpReq() Signature-Input = %q, want %q", got, wantInput)
+	}
+	if got := req.Header.Values("Signature"); len(got) != 2 || got[1] != "gateway=:c2ln:" {
+		t.Errorf("httpReq() Signature = %q, want the gateway signature appended", got)
+	}
+	if got := req.Header.Get("Content-Digest"); got != contentDigest([]byte(`{}`)) {
+		t.Errorf("httpReq() Content-Digest = %q, want %q", got, contentDigest([]byte(`{}`)))
+	}
+}
+
 func TestProxyTaskProcessor_proxy(t *testing.T) {
 	ctx := context.Background()
 	p := &proxyTaskProcessor{} // Will set client mock per test
@@ -388,7 +449,7 @@ func TestProxyTaskProcessor_proxy(t *testing.T) {
 			mockClient := &mockHttpClient{}
 			tt.mockClient(mockClient)
 			p.client = mockClient
-			err := p.proxy(ctx, req)
+			err := p.proxy(ctx, req, "search")
 			if tt.wantErr != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 					t.Errorf("proxy() error = %v, want error containing %q", err, tt.wantErr)
@@ -400,6 +461,44 @@ func TestProxyTaskProcessor_proxy(t *testing.T) {
 	}
 }
 
+func TestProxyTaskProcessor_proxy_Redaction(t *testing.T) {
+	ctx := context.Background()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.com/test", nil)
+	r, err := NewPayloadRedactor(testRedactionConfig())
+	if err != nil {
+		t.Fatalf("NewPayloadRedactor() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+	}{
+		{name: "non-200 status code", statusCode: http.StatusInternalServerError, body: piiPayload},
+		{name: "invalid TxnResponse", statusCode: http.StatusOK, body: strings.Replace(piiPayload, `"message":{`, `"message":{"ack":{"status":1},`, 1)},
+		{name: "non-JSON body", statusCode: http.StatusBadGateway, body: "upstream rejected phone 9876543210"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			p := &proxyTaskProcessor{client: &mockHttpClient{doFunc: func(*http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(tt.statusCode, tt.body), nil
+			}}}
+			p.SetRedactor(r)
+
+			err := p.proxy(ctx, req, "confirm")
+			if err == nil {
+				t.Fatal("proxy() error = nil, want error")
+			}
+			assertRedacted(t, err.Error())
+			if !strings.Contains(logs.String(), "response_body") {
+				t.Fatalf("response body was not logged: %s", logs)
+			}
+			assertRedacted(t, logs.String())
+		})
+	}
+}
+
 // errorReader is an io.Reader that always returns an error.
 type errorReader struct{}
 
@@ -485,3 +584,541 @@ func TestProxyTaskProcessor_Process(t *testing.T) {
 		})
 	}
 }
+
+// mockTargetValidator is a mock implementation of targetValidator.
+type mockTargetValidator struct {
+	err error
+}
+
+func (m *mockTargetValidator) Validate(ctx context.Context, target *url.URL) error {
+	return m.err
+}
+
+func (m *mockTargetValidator) DialControl(network, address string, c syscall.RawConn) error {
+	return m.err
+}
+
+func TestProxyTaskProcessor_Process_TargetPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policyErr error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "allowed target is called", wantCalls: 1},
+		{name: "rejected target is not called", policyErr: ErrTargetNotAllowed, wantErr: ErrTargetNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				calls++
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			p := &proxyTaskProcessor{client: mockClient, auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key-id"}
+			p.SetTargetPolicy(&mockTargetValidator{err: tt.policyErr})
+
+			err := p.Process(context.Background(), newTestAsyncTask("https://example.com/process", []byte(`{}`), make(http.Header)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("client called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_SetTargetPolicy_GuardsDial(t *testing.T) {
+	p, err := NewProxyTaskProcessor(&mockAuthGen{}, "test-key-id", RetryConfig{})
+	if err != nil {
+		t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+	}
+	policy, err := NewTargetPolicy(&TargetPolicyConfig{AllowHTTP: true})
+	if err != nil {
+		t.Fatalf("NewTargetPolicy() error = %v", err)
+	}
+	p.SetTargetPolicy(policy)
+
+	// Dialing is refused before any connection attempt, so no listener is needed.
+	_, err = p.transport.DialContext(context.Background(), "tcp", "127.0.0.1:1")
+	if !errors.Is(err, ErrTargetNotAllowed) {
+		t.Errorf("DialContext() error = %v, want %v", err, ErrTargetNotAllowed)
+	}
+}
+
+// mockBodyTransformer is a mock implementation of bodyTransformer.
+type mockBodyTransformer struct {
+	body    []byte
+	changed bool
+	err     error
+}
+
+func (m *mockBodyTransformer) Transform(ctx context.Context, target *url.URL, action string, body []byte) ([]byte, bool, error) {
+	if !m.changed {
+		return body, false, m.err
+	}
+	return m.body, true, m.err
+}
+
+func TestProxyTaskProcessor_Process_Transform(t *testing.T) {
+	tests := []struct {
+		name        string
+		transformer *mockBodyTransformer
+		wantBody    string
+		wantAuth    string
+		wantErr     string
+	}{
+		{
+			name:        "unchanged body keeps signature",
+			transformer: &mockBodyTransformer{},
+			wantBody:    `{"a":1}`,
+			wantAuth:    "Signature original",
+		},
+		{
+			name:        "changed body is signed again",
+			transformer: &mockBodyTransformer{body: []byte(`{"a":2}`), changed: true},
+			wantBody:    `{"a":2}`,
+			wantAuth:    "Signature transformed",
+		},
+		{
+			name:        "transform error",
+			transformer: &mockBodyTransformer{err: errors.New("bad expression")},
+			wantErr:     "failed to transform payload for https://example.com/search: bad expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody, gotAuth string
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				gotBody, gotAuth = string(b), r.Header.Get(model.AuthHeaderGateway)
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			p := &proxyTaskProcessor{client: mockClient, auth: &mockAuthGen{authHeader: "Signature transformed"}, keyID: "test-key-id"}
+			p.SetTransformer(tt.transformer)
+			task := newTestAsyncTask("https://example.com/search", []byte(`{"a":1}`), http.Header{model.AuthHeaderGateway: []string{"Signature original"}})
+
+			err := p.Process(context.Background(), task)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Process() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() unexpected error = %v", err)
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("sent body = %s, want %s", gotBody, tt.wantBody)
+			}
+			if gotAuth != tt.wantAuth {
+				t.Errorf("sent %s = %q, want %q", model.AuthHeaderGateway, gotAuth, tt.wantAuth)
+			}
+			// The task itself is not modified, so that retries transform the original body.
+			if string(task.Body) != `{"a":1}` || task.Headers.Get(model.AuthHeaderGateway) != "Signature original" {
+				t.Errorf("Process() modified the task: body %s, %s %q", task.Body, model.AuthHeaderGateway, task.Headers.Get(model.AuthHeaderGateway))
+			}
+		})
+	}
+}
+
+// mockBatchPacer is a mock implementation of batchPacer.
+type mockBatchPacer struct {
+	reusable bool
+	waitErr  error
+	waits    []string
+}
+
+func (m *mockBatchPacer) Wait(ctx context.Context, host string) error {
+	m.waits = append(m.waits, host)
+	return m.waitErr
+}
+
+func (m *mockBatchPacer) Reusable(authHeader string) bool {
+	return m.reusable
+}
+
+// countingAuthGen counts the auth headers it generates.
+type countingAuthGen struct {
+	calls int
+	err   error
+}
+
+func (m *countingAuthGen) AuthHeader(ctx context.Context, body []byte, keyID string) (string, error) {
+	m.calls++
+	return fmt.Sprintf("Signature %d", m.calls), m.err
+}
+
+func TestProxyTaskProcessor_Process_Batch(t *testing.T) {
+	targets := []*url.URL{mustParseURL("https://a.com/1/search"), mustParseURL("https://a.com/2/search"), mustParseURL("https://a.com/3/search")}
+	tests := []struct {
+		name      string
+		headers   http.Header
+		pacer     *mockBatchPacer
+		failPath  string
+		authErr   error
+		wantAuth  []string
+		wantSigns int
+		wantWaits int
+		wantErr   string
+	}{
+		{
+			name:      "signed header reused while valid",
+			headers:   http.Header{model.AuthHeaderGateway: []string{"Signature lookup"}},
+			pacer:     &mockBatchPacer{reusable: true},
+			wantAuth:  []string{"Signature lookup", "Signature lookup", "Signature lookup"},
+			wantWaits: 3,
+		},
+		{
+			name:      "expiring header signed again",
+			headers:   http.Header{model.AuthHeaderGateway: []string{"Signature lookup"}},
+			pacer:     &mockBatchPacer{},
+			wantAuth:  []string{"Signature 1", "Signature 2", "Signature 3"},
+			wantSigns: 3,
+			wantWaits: 3,
+		},
+		{
+			name:      "missing header signed once without pacer",
+			headers:   http.Header{},
+			wantAuth:  []string{"Signature 1", "Signature 1", "Signature 1"},
+			wantSigns: 1,
+		},
+		{
+			name:     "failed target does not stop the batch",
+			headers:  http.Header{model.AuthHeaderGateway: []string{"Signature lookup"}},
+			failPath: "/2/search",
+			wantAuth: []string{"Signature lookup", "Signature lookup", "Signature lookup"},
+			wantErr:  "unexpected status code 500 from https://a.com/2/search. Body: down",
+		},
+		{
+			name:      "pacing interrupted",
+			headers:   http.Header{model.AuthHeaderGateway: []string{"Signature lookup"}},
+			pacer:     &mockBatchPacer{waitErr: context.Canceled},
+			wantWaits: 1,
+			wantErr:   "batch to a.com interrupted: context canceled",
+		},
+		{
+			name:      "signing fails",
+			headers:   http.Header{},
+			authErr:   errors.New("key unavailable"),
+			wantSigns: 1,
+			wantErr:   "failed to generate auth header: key unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAuth []string
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				gotAuth = append(gotAuth, r.Header.Get(model.AuthHeaderGateway))
+				if r.URL.Path == tt.failPath {
+					return newMockHTTPResponse(http.StatusInternalServerError, "down"), nil
+				}
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			auth := &countingAuthGen{err: tt.authErr}
+			p := &proxyTaskProcessor{client: mockClient, auth: auth, keyID: "test-key-id"}
+			if tt.pacer != nil {
+				p.SetBatchPacer(tt.pacer)
+			}
+			task := &model.AsyncTask{Type: model.AsyncTaskTypeProxyBatch, Targets: targets, Body: []byte(`{}`), Headers: tt.headers, Context: model.Context{Action: "search"}}
+
+			err := p.Process(context.Background(), task)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Process() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Process() unexpected error = %v", err)
+			}
+			if tt.wantAuth != nil {
+				if diff := cmp.Diff(tt.wantAuth, gotAuth); diff != "" {
+					t.Errorf("sent %s mismatch (-want +got):\n%s", model.AuthHeaderGateway, diff)
+				}
+			}
+			if auth.calls != tt.wantSigns {
+				t.Errorf("AuthHeader() called %d times, want %d", auth.calls, tt.wantSigns)
+			}
+			if tt.pacer != nil && len(tt.pacer.waits) != tt.wantWaits {
+				t.Errorf("Wait() called %d times, want %d", len(tt.pacer.waits), tt.wantWaits)
+			}
+			if _, ok := tt.headers[model.AuthHeaderGateway]; ok && tt.headers.Get(model.AuthHeaderGateway) != "Signature lookup" {
+				t.Errorf("Process() modified the task headers: %v", tt.headers)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_EmptyBatch(t *testing.T) {
+	p := &proxyTaskProcessor{client: &mockHttpClient{}, auth: &mockAuthGen{}, keyID: "test-key-id"}
+	err := p.Process(context.Background(), &model.AsyncTask{Type: model.AsyncTaskTypeProxyBatch, Headers: http.Header{}})
+	if err == nil || err.Error() != "async task batch has no targets" {
+		t.Errorf("Process() error = %v, want %q", err, "async task batch has no targets")
+	}
+}
+
+// mockLatencyLimiter is a mock implementation of latencyLimiter.
+type mockLatencyLimiter struct {
+	err      error
+	acquired []string
+	done     int
+}
+
+func (m *mockLatencyLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	m.acquired = append(m.acquired, host)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return func() { m.done++ }, nil
+}
+
+func TestProxyTaskProcessor_Process_Throttle(t *testing.T) {
+	tests := []struct {
+		name      string
+		limitErr  error
+		wantCalls int
+		wantDone  int
+		wantErr   error
+	}{
+		{name: "request is recorded", wantCalls: 1, wantDone: 1},
+		{name: "interrupted wait skips request", limitErr: context.Canceled, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				calls++
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			limiter := &mockLatencyLimiter{err: tt.limitErr}
+			p := &proxyTaskProcessor{client: mockClient, auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key-id"}
+			p.SetThrottle(limiter)
+
+			err := p.Process(context.Background(), newTestAsyncTask("https://example.com:8443/process", []byte(`{}`), make(http.Header)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("client called %d times, want %d", calls, tt.wantCalls)
+			}
+			if diff := cmp.Diff([]string{"example.com:8443"}, limiter.acquired); diff != "" {
+				t.Errorf("Acquire() hosts mismatch (-want +got):\n%s", diff)
+			}
+			if limiter.done != tt.wantDone {
+				t.Errorf("done called %d times, want %d", limiter.done, tt.wantDone)
+			}
+		})
+	}
+}
+
+// mockHostPacer is a mock implementation of hostPacer.
+type mockHostPacer struct {
+	err   error
+	hosts []string
+}
+
+func (m *mockHostPacer) Wait(ctx context.Context, host string) error {
+	m.hosts = append(m.hosts, host)
+	return m.err
+}
+
+func TestProxyTaskProcessor_Process_HostRateLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		waitErr   error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "request is sent once allowed", wantCalls: 1},
+		{name: "rate limited request is not sent", waitErr: ErrHostRateLimited, wantErr: ErrHostRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				calls++
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			pacer := &mockHostPacer{err: tt.waitErr}
+			p := &proxyTaskProcessor{client: mockClient, auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key-id"}
+			p.SetHostRateLimit(pacer)
+
+			err := p.Process(context.Background(), newTestAsyncTask("https://example.com:8443/process", []byte(`{}`), make(http.Header)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("client called %d times, want %d", calls, tt.wantCalls)
+			}
+			if diff := cmp.Diff([]string{"example.com:8443"}, pacer.hosts); diff != "" {
+				t.Errorf("Wait() hosts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_BatchHostRateLimit(t *testing.T) {
+	targets := []*url.URL{mustParseURL("https://a.com/1/search"), mustParseURL("https://a.com/2/search")}
+	tests := []struct {
+		name           string
+		batchPacer     *mockBatchPacer
+		wantBatchWaits []string
+		wantHostWaits  []string
+	}{
+		{name: "paced by the batch pacer only", batchPacer: &mockBatchPacer{reusable: true}, wantBatchWaits: []string{"a.com", "a.com"}},
+		{name: "paced by the host rate limit without a batch pacer", wantHostWaits: []string{"a.com", "a.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			hostPacer := &mockHostPacer{}
+			p := &proxyTaskProcessor{client: mockClient, auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key-id"}
+			p.SetHostRateLimit(hostPacer)
+			if tt.batchPacer != nil {
+				p.SetBatchPacer(tt.batchPacer)
+			}
+
+			batch := &model.AsyncTask{Type: model.AsyncTaskTypeProxyBatch, Targets: targets, Body: []byte(`{}`), Headers: http.Header{}}
+			if err := p.Process(context.Background(), batch); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if tt.batchPacer != nil {
+				if diff := cmp.Diff(tt.wantBatchWaits, tt.batchPacer.waits); diff != "" {
+					t.Errorf("batch pacer Wait() hosts mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if diff := cmp.Diff(tt.wantHostWaits, hostPacer.hosts); diff != "" {
+				t.Errorf("host rate limit Wait() hosts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// orderRecorder records the order in which a request is paced and signed.
+type orderRecorder struct {
+	events []string
+}
+
+func (o *orderRecorder) Wait(ctx context.Context, host string) error {
+	o.events = append(o.events, "wait")
+	return nil
+}
+
+func (o *orderRecorder) Reusable(authHeader string) bool {
+	return false
+}
+
+func (o *orderRecorder) AuthHeader(ctx context.Context, body []byte, keyID string) (string, error) {
+	o.events = append(o.events, "sign")
+	return "Signature test-auth", nil
+}
+
+func TestProxyTaskProcessor_Process_SignsAfterPacing(t *testing.T) {
+	targets := []*url.URL{mustParseURL("https://a.com/1/search"), mustParseURL("https://a.com/2/search")}
+	tests := []struct {
+		name       string
+		task       *model.AsyncTask
+		batchPacer bool
+		want       []string
+	}{
+		{
+			name: "task paced by the host rate limit",
+			task: newTestAsyncTask("https://a.com/search", []byte(`{}`), make(http.Header)),
+			want: []string{"wait", "sign"},
+		},
+		{
+			name:       "batch paced by the batch pacer",
+			task:       &model.AsyncTask{Type: model.AsyncTaskTypeProxyBatch, Targets: targets, Body: []byte(`{}`), Headers: http.Header{}},
+			batchPacer: true,
+			want:       []string{"wait", "sign", "wait", "sign"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			order := &orderRecorder{}
+			p := &proxyTaskProcessor{client: mockClient, auth: order, keyID: "test-key-id"}
+			if tt.batchPacer {
+				p.SetBatchPacer(order)
+			} else {
+				p.SetHostRateLimit(order)
+			}
+
+			if err := p.Process(context.Background(), tt.task); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, order.events); diff != "" {
+				t.Errorf("pacing and signing order mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_TTLDeadline(t *testing.T) {
+	d, err := NewTTLDeadline(&TTLDeadlineConfig{})
+	if err != nil {
+		t.Fatalf("NewTTLDeadline() error = %v", err)
+	}
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	tests := []struct {
+		name          string
+		timestamp     time.Time
+		wantCalls     int
+		wantRemaining string
+		wantErr       error
+	}{
+		{name: "remaining ttl is sent", timestamp: now.Add(-10 * time.Second), wantCalls: 1, wantRemaining: "PT20S"},
+		{name: "expired task is dropped", timestamp: now.Add(-time.Minute), wantErr: ErrTTLExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var gotHeader http.Header
+			var gotDeadline bool
+			mockClient := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				calls++
+				gotHeader = r.Header.Clone()
+				_, gotDeadline = r.Context().Deadline()
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			p := &proxyTaskProcessor{client: mockClient, auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key-id"}
+			p.SetTTLDeadline(d)
+
+			task := newTestAsyncTask("https://example.com/process", []byte(`{}`), make(http.Header))
+			task.Context.Timestamp = tt.timestamp.Format(time.RFC3339Nano)
+			task.Context.TTL = "PT30S"
+			err := p.Process(context.Background(), task)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("client called %d times, want %d", calls, tt.wantCalls)
+			}
+			if calls == 0 {
+				return
+			}
+			if got := gotHeader.Get("X-Onix-TTL-Remaining"); got != tt.wantRemaining {
+				t.Errorf("X-Onix-TTL-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+			if gotHeader.Get("X-Onix-Deadline") == "" {
+				t.Error("X-Onix-Deadline header not set")
+			}
+			if !gotDeadline {
+				t.Error("request context has no deadline")
+			}
+		})
+	}
+}
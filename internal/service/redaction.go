@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const defaultRedactionReplacement = "[REDACTED]"
+
+// RedactionConfig configures the redaction of personal data, such as phone numbers and
+// email addresses, from payloads before the gateway logs or dead-letters them.
+type RedactionConfig struct {
+	// Paths are dotted payload paths of fields to redact, e.g. "message.order.billing.phone".
+	// Arrays on a path are traversed, so the rest of the path applies to each element,
+	// and a "*" key matches every field of an object.
+	Paths []string `yaml:"paths"`
+	// Keys are field names redacted wherever they occur in a payload, e.g. "email".
+	// They are matched case-insensitively.
+	Keys []string `yaml:"keys"`
+	// Replacement is the value written in place of a redacted field. Defaults to "[REDACTED]".
+	Replacement string `yaml:"replacement"`
+}
+
+// payloadRedactor replaces the configured fields of JSON payloads.
+type payloadRedactor struct {
+	paths       [][]string
+	keys        map[string]bool
+	replacement string
+}
+
+// NewPayloadRedactor creates a redactor from cfg.
+func NewPayloadRedactor(cfg *RedactionConfig) (*payloadRedactor, error) {
+	if cfg == nil {
+		return nil, errors.New("redaction config cannot be nil")
+	}
+	if len(cfg.Paths) == 0 && len(cfg.Keys) == 0 {
+		return nil, errors.New("redaction config must set paths or keys")
+	}
+	r := &payloadRedactor{keys: make(map[string]bool, len(cfg.Keys)), replacement: cfg.Replacement}
+	if r.replacement == "" {
+		r.replacement = defaultRedactionReplacement
+	}
+	for _, p := range cfg.Paths {
+		keys, err := splitPayloadPath(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction path: %w", err)
+		}
+		r.paths = append(r.paths, keys)
+	}
+	for _, k := range cfg.Keys {
+		if k == "" {
+			return nil, errors.New("redaction key cannot be empty")
+		}
+		r.keys[strings.ToLower(k)] = true
+	}
+	return r, nil
+}
+
+// Redact returns a copy of body with the configured fields replaced. A body that is not
+// JSON cannot be inspected, so it is replaced as a whole; body itself is never modified.
+func (r *payloadRedactor) Redact(body []byte) []byte {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return body
+	}
+	var payload any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return r.opaque(body)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return r.opaque(body)
+	}
+	for _, path := range r.paths {
+		r.redactPath(payload, path)
+	}
+	if len(r.keys) > 0 {
+		r.redactKeys(payload)
+	}
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return r.opaque(body)
+	}
+	return out
+}
+
+// opaque returns the placeholder for a body that could not be redacted field by field.
+func (r *payloadRedactor) opaque(body []byte) []byte {
+	return fmt.Appendf(nil, "%s (%d bytes, not JSON)", r.replacement, len(body))
+}
+
+// redactPath replaces the fields of v at path.
+func (r *payloadRedactor) redactPath(v any, path []string) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			r.redactPath(e, path)
+		}
+	case map[string]any:
+		for k, child := range v {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			if len(path) == 1 {
+				v[k] = r.replacement
+			} else {
+				r.redactPath(child, path[1:])
+			}
+		}
+	}
+}
+
+// redactKeys replaces the fields of v, at any depth, whose names are in the redacted keys.
+func (r *payloadRedactor) redactKeys(v any) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			r.redactKeys(e)
+		}
+	case map[string]any:
+		for k, child := range v {
+			if r.keys[strings.ToLower(k)] {
+				v[k] = r.replacement
+				continue
+			}
+			r.redactKeys(child)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// piiPayload is a Beckn payload carrying personal data in several places.
+const piiPayload = `{"context":{"action":"confirm","transaction_id":"t1"},"message":{"order":{"billing":{"name":"Asha","phone":"9876543210","email":"asha@example.com"},"fulfillments":[{"customer":{"contact":{"phone":"9123456780","email":"asha@example.com"}}},{"customer":{"contact":{"phone":"9000000000"}}}],"quote":{"price":{"value":"120.50"}},"items":[{"id":"i1","quantity":{"count":2}}]}}}`
+
+// piiValues are the personal data in piiPayload that must never survive redaction.
+var piiValues = []string{"9876543210", "9123456780", "9000000000", "asha@example.com", "Asha"}
+
+// testRedactionConfig redacts every value in piiValues from piiPayload.
+func testRedactionConfig() *RedactionConfig {
+	return &RedactionConfig{
+		Paths: []string{"message.order.billing.name", "message.order.fulfillments.customer.contact.*"},
+		Keys:  []string{"phone", "Email"},
+	}
+}
+
+// assertRedacted fails the test if s contains any of the values in piiValues.
+func assertRedacted(t *testing.T, s string) {
+	t.Helper()
+	for _, v := range piiValues {
+		if strings.Contains(s, v) {
+			t.Errorf("%q was not redacted from %s", v, s)
+		}
+	}
+}
+
+func TestNewPayloadRedactor_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *RedactionConfig
+		wantErr string
+	}{
+		{name: "nil config", cfg: nil, wantErr: "redaction config cannot be nil"},
+		{name: "no paths or keys", cfg: &RedactionConfig{Replacement: "x"}, wantErr: "must set paths or keys"},
+		{name: "invalid path", cfg: &RedactionConfig{Paths: []string{"message..phone"}}, wantErr: "invalid redaction path"},
+		{name: "empty key", cfg: &RedactionConfig{Keys: []string{""}}, wantErr: "redaction key cannot be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPayloadRedactor(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewPayloadRedactor() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayloadRedactor_Redact(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *RedactionConfig
+		body string
+		want string
+	}{
+		{
+			name: "path",
+			cfg:  &RedactionConfig{Paths: []string{"message.order.billing.phone"}},
+			body: `{"message":{"order":{"billing":{"name":"Asha","phone":"9876543210"}}}}`,
+			want: `{"message":{"order":{"billing":{"name":"Asha","phone":"[REDACTED]"}}}}`,
+		},
+		{
+			name: "path through arrays",
+			cfg:  &RedactionConfig{Paths: []string{"items.contact.phone"}},
+			body: `{"items":[{"contact":{"phone":"1"}},{"contact":[{"phone":"2"},{"email":"e"}]}]}`,
+			want: `{"items":[{"contact":{"phone":"[REDACTED]"}},{"contact":[{"phone":"[REDACTED]"},{"email":"e"}]}]}`,
+		},
+		{
+			name: "wildcard redacts whole objects",
+			cfg:  &RedactionConfig{Paths: []string{"message.*.contact"}},
+			body: `{"message":{"a":{"contact":{"phone":"1"}},"b":{"id":"x"}}}`,
+			want: `{"message":{"a":{"contact":"[REDACTED]"},"b":{"id":"x"}}}`,
+		},
+		{
+			name: "keys at any depth, case-insensitive",
+			cfg:  &RedactionConfig{Keys: []string{"email"}},
+			body: `{"Email":"a@b.c","x":[{"y":{"email":"d@e.f"}}]}`,
+			want: `{"Email":"[REDACTED]","x":[{"y":{"email":"[REDACTED]"}}]}`,
+		},
+		{
+			name: "missing fields are not added",
+			cfg:  &RedactionConfig{Paths: []string{"message.order.billing.phone"}, Keys: []string{"email"}},
+			body: `{"message":{"order":{}}}`,
+			want: `{"message":{"order":{}}}`,
+		},
+		{
+			name: "numbers are preserved",
+			cfg:  &RedactionConfig{Keys: []string{"phone"}},
+			body: `{"phone":9876543210,"count":12345678901234567890}`,
+			want: `{"count":12345678901234567890,"phone":"[REDACTED]"}`,
+		},
+		{
+			name: "custom replacement",
+			cfg:  &RedactionConfig{Keys: []string{"phone"}, Replacement: "***"},
+			body: `{"phone":"1"}`,
+			want: `{"phone":"***"}`,
+		},
+		{
+			name: "non-JSON body",
+			cfg:  &RedactionConfig{Keys: []string{"phone"}},
+			body: `phone=9876543210`,
+			want: `[REDACTED] (16 bytes, not JSON)`,
+		},
+		{
+			name: "trailing data",
+			cfg:  &RedactionConfig{Keys: []string{"phone"}},
+			body: `{"id":"x"} {"phone":"9876543210"}`,
+			want: `[REDACTED] (33 bytes, not JSON)`,
+		},
+		{
+			name: "empty body",
+			cfg:  &RedactionConfig{Keys: []string{"phone"}},
+			body: ``,
+			want: ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewPayloadRedactor(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewPayloadRedactor() error = %v", err)
+			}
+			if got := string(r.Redact([]byte(tt.body))); got != tt.want {
+				t.Errorf("Redact() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadRedactor_Redact_Coverage(t *testing.T) {
+	r, err := NewPayloadRedactor(testRedactionConfig())
+	if err != nil {
+		t.Fatalf("NewPayloadRedactor() error = %v", err)
+	}
+	body := []byte(piiPayload)
+	got := r.Redact(body)
+	assertRedacted(t, string(got))
+	if string(body) != piiPayload {
+		t.Errorf("Redact() modified its input")
+	}
+
+	// Fields that are not personal data are kept as they were.
+	var payload map[string]any
+	if err := json.Unmarshal(got, &payload); err != nil {
+		t.Fatalf("redacted payload is not JSON: %v", err)
+	}
+	order := payload["message"].(map[string]any)["order"].(map[string]any)
+	want := map[string]any{"price": map[string]any{"value": "120.50"}}
+	if diff := cmp.Diff(want, order["quote"]); diff != "" {
+		t.Errorf("quote mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]any{"action": "confirm", "transaction_id": "t1"}, payload["context"]); diff != "" {
+		t.Errorf("context mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const defaultRegistrationCheckTimeout = 10 * time.Second
+
+// ErrRegistrationInvalid is returned when the registry does not hold a valid subscription
+// for the gateway's own subscriber ID and key.
+var ErrRegistrationInvalid = errors.New("gateway registration is invalid")
+
+// registrationMetrics counts the outcomes of the gateway's registration check on the expvar
+// endpoint (/debug/vars): ok, or the reason the registration is invalid or expiring.
+var registrationMetrics = expvar.NewMap("gateway_registration")
+
+// RegistrationCheckConfig configures the check of the gateway's own registry entry at startup.
+type RegistrationCheckConfig struct {
+	// Degraded starts the gateway even if the check fails, raising alerts instead.
+	// By default, the gateway refuses to start.
+	Degraded bool `yaml:"degraded"`
+	// RenewBefore is how long before valid_until the registration is reported as expiring.
+	// Defaults to 168h.
+	RenewBefore time.Duration `yaml:"renewBefore"`
+	// Timeout bounds the registry lookup. Defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// registrationCheck verifies that the registry holds an active, unexpired subscription for the
+// gateway with the key it signs with, so that a gateway whose registration lapsed does not start
+// sending requests every participant rejects.
+type registrationCheck struct {
+	lookup       subscriptionLookup
+	km           signingKM
+	subscriberID string
+	degraded     bool
+	renewBefore  time.Duration
+	timeout      time.Duration
+	now          func() time.Time
+}
+
+// NewRegistrationCheck creates a check of the registration of subscriberID. A nil config uses the defaults.
+func NewRegistrationCheck(lookup subscriptionLookup, km signingKM, subscriberID string, cfg *RegistrationCheckConfig) (*registrationCheck, error) {
+	if lookup == nil {
+		slog.Error("NewRegistrationCheck: subscriptionLookup dependency is nil")
+		return nil, errors.New("subscriptionLookup dependency is nil")
+	}
+	if km == nil {
+		slog.Error("NewRegistrationCheck: signingKM dependency is nil")
+		return nil, errors.New("signingKM dependency is nil")
+	}
+	if subscriberID == "" {
+		return nil, ErrMissingSubscriberID
+	}
+	if cfg == nil {
+		cfg = &RegistrationCheckConfig{}
+	}
+	if cfg.RenewBefore < 0 || cfg.Timeout < 0 {
+		return nil, fmt.Errorf("invalid registration check config: renewBefore and timeout must not be negative")
+	}
+	c := &registrationCheck{lookup: lookup, km: km, subscriberID: subscriberID, degraded: cfg.Degraded, renewBefore: cfg.RenewBefore, timeout: cfg.Timeout, now: time.Now}
+	if c.renewBefore == 0 {
+		c.renewBefore = defaultKeyWatchRenewBefore
+	}
+	if c.timeout == 0 {
+		c.timeout = defaultRegistrationCheckTimeout
+	}
+	return c, nil
+}
+
+// Run looks the gateway up in the registry and checks the status, key and validity of each of
+// its subscriptions. A registration that expires within RenewBefore is only reported.
+// It returns an error wrapping ErrRegistrationInvalid if the check fails, unless the check
+// is configured to start degraded, in which case the failure is only reported.
+func (c *registrationCheck) Run(ctx context.Context) error {
+	reason, sub, err := c.check(ctx)
+	if reason == "" {
+		registrationMetrics.Add("ok", 1)
+		slog.InfoContext(ctx, "RegistrationCheck: Gateway registration is valid", "subscriber_id", c.subscriberID)
+		return nil
+	}
+	registrationMetrics.Add(string(reason), 1)
+	attrs := []any{"subscriber_id", c.subscriberID, "reason", reason}
+	if sub != nil {
+		attrs = append(attrs, "domain", sub.Domain, "registry_key_id", sub.KeyID, "registry_status", sub.Status, "valid_until", sub.ValidUntil)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	if reason == keyExpiring {
+		slog.WarnContext(ctx, "RegistrationCheck: Gateway registration is about to expire", attrs...)
+		return nil
+	}
+	if c.degraded {
+		slog.ErrorContext(ctx, "RegistrationCheck: Gateway registration is invalid, starting degraded", attrs...)
+		return nil
+	}
+	slog.ErrorContext(ctx, "RegistrationCheck: Gateway registration is invalid", attrs...)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrRegistrationInvalid, reason, err)
+	}
+	return fmt.Errorf("%w: %s", ErrRegistrationInvalid, reason)
+}
+
+// check returns the reason the registration is invalid or expiring, with the offending
+// registry entry if any, or an empty reason if it is valid. An invalid subscription takes
+// precedence over an expiring one.
+func (c *registrationCheck) check(ctx context.Context) (keyInvalidation, *model.Subscription, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	subs, err := c.lookup.Lookup(lookupCtx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: c.subscriberID}})
+	if err != nil {
+		return registrationLookupFailed, nil, err
+	}
+	if len(subs) == 0 {
+		return keyNotRegistered, nil, nil
+	}
+	keys, err := c.km.Keyset(ctx, c.subscriberID)
+	if err != nil || keys == nil {
+		return keyMissing, &subs[0], err
+	}
+	now := c.now()
+	var expiring *model.Subscription
+	for i := range subs {
+		reason := invalidation(&subs[i], keys.UniqueKeyID, keys.SigningPublic, now, c.renewBefore)
+		switch {
+		case reason == keyExpiring && expiring == nil:
+			expiring = &subs[i]
+		case reason != "" && reason != keyExpiring:
+			return reason, &subs[i], nil
+		}
+	}
+	if expiring != nil {
+		return keyExpiring, expiring, nil
+	}
+	return "", nil, nil
+}
+
+// registrationLookupFailed means the registry could not be reached to verify the registration.
+const registrationLookupFailed keyInvalidation = "lookup_failed"
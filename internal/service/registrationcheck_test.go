@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestNewRegistrationCheck_Error(t *testing.T) {
+	lookup := &mockLookupClient{}
+	km := &mockSigningKM{}
+	tests := []struct {
+		name         string
+		lookup       subscriptionLookup
+		km           signingKM
+		subscriberID string
+		cfg          *RegistrationCheckConfig
+	}{
+		{name: "nil lookup", km: km, subscriberID: "gw"},
+		{name: "nil key manager", lookup: lookup, subscriberID: "gw"},
+		{name: "missing subscriber ID", lookup: lookup, km: km},
+		{name: "negative timeout", lookup: lookup, km: km, subscriberID: "gw", cfg: &RegistrationCheckConfig{Timeout: -time.Second}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewRegistrationCheck(tc.lookup, tc.km, tc.subscriberID, tc.cfg); err == nil {
+				t.Error("NewRegistrationCheck() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRegistrationCheck_Run(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	keys := &becknmodel.Keyset{UniqueKeyID: "k1", SigningPublic: "pub1"}
+	valid := model.Subscription{
+		Subscriber:       model.Subscriber{SubscriberID: "gw", Domain: "retail", Type: model.RoleGateway},
+		KeyID:            "k1",
+		SigningPublicKey: "pub1",
+		Status:           model.SubscriptionStatusSubscribed,
+		ValidUntil:       now.AddDate(1, 0, 0),
+	}
+	expired := valid
+	expired.ValidUntil = now.Add(-time.Hour)
+	expiring := valid
+	expiring.ValidUntil = now.Add(time.Hour)
+	revoked := valid
+	revoked.KeyID = "k0"
+	inactive := valid
+	inactive.Status = model.SubscriptionStatusUnsubscribed
+
+	tests := []struct {
+		name     string
+		subs     []model.Subscription
+		lookErr  error
+		keys     *becknmodel.Keyset
+		keysErr  error
+		degraded bool
+		wantErr  bool
+	}{
+		{name: "valid", subs: []model.Subscription{valid}, keys: keys},
+		{name: "expiring is only reported", subs: []model.Subscription{expiring}, keys: keys},
+		{name: "not registered", keys: keys, wantErr: true},
+		{name: "expired", subs: []model.Subscription{valid, expired}, keys: keys, wantErr: true},
+		{name: "invalid takes precedence over expiring", subs: []model.Subscription{expiring, inactive}, keys: keys, wantErr: true},
+		{name: "key revoked", subs: []model.Subscription{revoked}, keys: keys, wantErr: true},
+		{name: "keyset missing", subs: []model.Subscription{valid}, keysErr: errors.New("no keys"), wantErr: true},
+		{name: "lookup failed", lookErr: errors.New("registry down"), keys: keys, wantErr: true},
+		{name: "degraded starts anyway", subs: []model.Subscription{expired}, keys: keys, degraded: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup := &mockLookupClient{subscriptions: tc.subs, err: tc.lookErr}
+			c, err := NewRegistrationCheck(lookup, &mockSigningKM{keyset: tc.keys, err: tc.keysErr}, "gw", &RegistrationCheckConfig{Degraded: tc.degraded})
+			if err != nil {
+				t.Fatalf("NewRegistrationCheck() error = %v", err)
+			}
+			c.now = func() time.Time { return now }
+
+			err = c.Run(context.Background())
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrRegistrationInvalid) {
+				t.Errorf("Run() error = %v, want %v", err, ErrRegistrationInvalid)
+			}
+			if lookup.gotRequest == nil || lookup.gotRequest.SubscriberID != "gw" {
+				t.Errorf("Run() looked up %+v, want subscriber gw", lookup.gotRequest)
+			}
+		})
+	}
+}
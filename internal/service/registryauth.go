@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// registryAuth validates the signature of requests that the registry sends to the NP, such as
+// /on_subscribe, so that the NP does not rely on a successful challenge decryption alone.
+type registryAuth struct {
+	sv            signValidator
+	km            npKeyProvider
+	regID         string
+	regKeyID      string
+	allowUnsigned bool
+}
+
+// NewRegistryAuth creates a validator of the registry's signature with the signing key
+// registered for regID and regKeyID. Unsigned requests are rejected unless allowUnsigned is
+// set, for registries that do not sign their requests yet.
+func NewRegistryAuth(sv signValidator, km npKeyProvider, regID, regKeyID string, allowUnsigned bool) (*registryAuth, error) {
+	if sv == nil {
+		slog.Error("NewRegistryAuth: signValidator cannot be nil")
+		return nil, errors.New("signValidator cannot be nil")
+	}
+	if km == nil {
+		slog.Error("NewRegistryAuth: npKeyProvider cannot be nil")
+		return nil, errors.New("npKeyProvider cannot be nil")
+	}
+	if regID == "" || regKeyID == "" {
+		slog.Error("NewRegistryAuth: regID and regKeyID cannot be empty")
+		return nil, errors.New("regID and regKeyID cannot be empty")
+	}
+	if allowUnsigned {
+		slog.Warn("NewRegistryAuth: Unsigned requests from the registry are accepted")
+	}
+	return &registryAuth{sv: sv, km: km, regID: regID, regKeyID: regKeyID, allowUnsigned: allowUnsigned}, nil
+}
+
+// Validate checks that authHeader is the registry's signature over body.
+func (a *registryAuth) Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError {
+	if authHeader == "" && a.allowUnsigned {
+		slog.WarnContext(ctx, "registryAuth.Validate: Accepting unsigned request from the registry")
+		return nil
+	}
+	ah, authErr := keySet(ctx, authHeader)
+	if authErr != nil {
+		return authErr
+	}
+	if ah.SubscriberID != a.regID || ah.UniqueID != a.regKeyID {
+		slog.ErrorContext(ctx, "registryAuth.Validate: Request not signed by the registry", "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "Request is not signed by the registry's key.", ah.SubscriberID)
+	}
+	key, _, err := a.km.LookupNPKeys(ctx, a.regID, a.regKeyID)
+	if err != nil || key == "" {
+		slog.ErrorContext(ctx, "registryAuth.Validate: Registry signing key unavailable", "error", err, "reg_key_id", a.regKeyID)
+		return model.NewAuthError(http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeKeyUnavailable, "The registry's signing key could not be resolved. Check regID and regKeyID.", a.regID)
+	}
+	if err := a.sv.Validate(ctx, body, authHeader, key); err != nil {
+		slog.ErrorContext(ctx, "registryAuth.Validate: Signature validation failed", "error", err)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", a.regID)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/plugin/implementation/signvalidator"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestNewRegistryAuth(t *testing.T) {
+	sv := &mockSignValidator{}
+	km := &mockNPKeyProvider{}
+	tests := []struct {
+		name     string
+		sv       signValidator
+		km       npKeyProvider
+		regKeyID string
+	}{
+		{name: "nil signValidator", km: km, regKeyID: "key-1"},
+		{name: "nil npKeyProvider", sv: sv, regKeyID: "key-1"},
+		{name: "empty regKeyID", sv: sv, km: km},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRegistryAuth(tt.sv, tt.km, "registry.example.com", tt.regKeyID, false); err == nil {
+				t.Error("NewRegistryAuth() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRegistryAuth_Validate(t *testing.T) {
+	becknValidator, _, err := signvalidator.New(context.Background(), &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	sv, err := NewKeyAlgoSignValidator(becknValidator)
+	if err != nil {
+		t.Fatalf("NewKeyAlgoSignValidator() error = %v", err)
+	}
+	now := time.Now()
+	body := []byte(`{"message_id":"op-1","challenge":"encrypted"}`)
+	// keyAlgoAuthHeader signs as np.example.com with key-1, which stands in for the registry here.
+	header, pub := keyAlgoAuthHeader(t, keyalgo.Ed25519, body, now.Add(-time.Minute), now.Add(time.Minute))
+
+	tests := []struct {
+		name          string
+		regKeyID      string
+		allowUnsigned bool
+		header        string
+		body          []byte
+		km            *mockNPKeyProvider
+		wantStatus    int
+		wantCode      model.ErrorCode
+	}{
+		{name: "valid signature", regKeyID: "key-1", header: header, body: body, km: &mockNPKeyProvider{signingKey: pub}},
+		{name: "unsigned, allowed", regKeyID: "key-1", allowUnsigned: true, body: body, km: &mockNPKeyProvider{signingKey: pub}},
+		{name: "unsigned", regKeyID: "key-1", body: body, km: &mockNPKeyProvider{signingKey: pub}, wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeMissingAuthHeader},
+		{name: "tampered body", regKeyID: "key-1", header: header, body: []byte(`{"message_id":"op-2","challenge":"encrypted"}`), km: &mockNPKeyProvider{signingKey: pub}, wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeInvalidSignature},
+		{name: "not the registry key", regKeyID: "key-2", header: header, body: body, km: &mockNPKeyProvider{signingKey: pub}, wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeIDMismatch},
+		{name: "registry key not registered", regKeyID: "key-1", header: header, body: body, km: &mockNPKeyProvider{}, wantStatus: http.StatusInternalServerError, wantCode: model.ErrorCodeKeyUnavailable},
+		{name: "registry key lookup fails", regKeyID: "key-1", header: header, body: body, km: &mockNPKeyProvider{err: errors.New("registry down")}, wantStatus: http.StatusInternalServerError, wantCode: model.ErrorCodeKeyUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewRegistryAuth(sv, tt.km, "np.example.com", tt.regKeyID, tt.allowUnsigned)
+			if err != nil {
+				t.Fatalf("NewRegistryAuth() error = %v", err)
+			}
+			authErr := a.Validate(context.Background(), tt.body, tt.header)
+			if tt.wantCode == "" {
+				if authErr != nil {
+					t.Errorf("Validate() error = %v, want nil", authErr)
+				}
+				return
+			}
+			if authErr == nil || authErr.StatusCode != tt.wantStatus || authErr.ErrorCode != tt.wantCode {
+				t.Errorf("Validate() error = %v, want status %d and code %q", authErr, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
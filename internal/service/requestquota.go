@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrRequestQuotaExceeded is returned when a caller has used up a request quota.
+var ErrRequestQuotaExceeded = errors.New("request quota exceeded")
+
+const defaultQuotaPurgeInterval = time.Hour
+
+// requestQuotaMetrics counts requests allowed and rejected per quota scope.
+var requestQuotaMetrics = expvar.NewMap("registry_request_quotas")
+
+// QuotaScope is the kind of request a quota caps.
+type QuotaScope string
+
+const (
+	// QuotaScopeOperations caps the operations created by a subscriber.
+	QuotaScopeOperations QuotaScope = "operations"
+	// QuotaScopeLookups caps the lookups of a caller.
+	QuotaScopeLookups QuotaScope = "lookups"
+)
+
+// Periods of quota windows. Windows are fixed, starting on the hour and at midnight UTC.
+const (
+	QuotaPeriodHour = "hour"
+	QuotaPeriodDay  = "day"
+)
+
+// QuotaLimit caps the requests of a caller per hour and per day. A zero limit is not enforced.
+type QuotaLimit struct {
+	PerHour int `yaml:"perHour"`
+	PerDay  int `yaml:"perDay"`
+}
+
+// RequestQuotaConfig configures the quotas of registry endpoints. Unlike rate limits, quotas
+// are counted in the database, so they hold across restarts and registry replicas.
+type RequestQuotaConfig struct {
+	// Operations caps the operations created through /subscribe per subscriber.
+	Operations QuotaLimit `yaml:"operations"`
+	// Lookups caps the lookups per caller: the subscriber of a lookup authenticated by the
+	// lookup tiers, or else the client IP.
+	Lookups QuotaLimit `yaml:"lookups"`
+	// PurgeInterval is how often the counters of past windows are deleted. Defaults to 1h.
+	PurgeInterval time.Duration `yaml:"purgeInterval"`
+}
+
+// QuotaExceededError reports the quota window a caller has used up.
+type QuotaExceededError struct {
+	Scope  QuotaScope
+	Caller string
+	Period string
+	Limit  int
+	// Reset is when the window ends and the caller may send requests again.
+	Reset time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%v: %s %s quota of %d per %s used up until %s", ErrRequestQuotaExceeded, e.Caller, e.Scope, e.Limit, e.Period, e.Reset.Format(time.RFC3339))
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrRequestQuotaExceeded
+}
+
+// quotaCounterRepo stores the request counters of quota windows.
+type quotaCounterRepo interface {
+	IncrementQuotaCounter(ctx context.Context, scope, caller, period string, windowStart time.Time) (int, error)
+	DeleteQuotaCounters(ctx context.Context, before time.Time) (int64, error)
+}
+
+// quotaWindow is a fixed window in which a caller may send up to limit requests.
+type quotaWindow struct {
+	period string
+	length time.Duration
+	limit  int
+}
+
+// requestQuota enforces the hourly and daily request quotas of callers, and purges the
+// counters of past windows while it runs as a background job.
+type requestQuota struct {
+	repo          quotaCounterRepo
+	windows       map[QuotaScope][]quotaWindow
+	purgeInterval time.Duration
+	now           func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRequestQuota creates a new requestQuota.
+func NewRequestQuota(repo quotaCounterRepo, cfg *RequestQuotaConfig) (*requestQuota, error) {
+	if repo == nil {
+		slog.Error("NewRequestQuota: repo cannot be nil")
+		return nil, errors.New("repo cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewRequestQuota: RequestQuotaConfig cannot be nil")
+		return nil, errors.New("RequestQuotaConfig cannot be nil")
+	}
+	if cfg.PurgeInterval < 0 {
+		return nil, fmt.Errorf("invalid request quota: purgeInterval %v cannot be negative", cfg.PurgeInterval)
+	}
+	q := &requestQuota{
+		repo:          repo,
+		windows:       map[QuotaScope][]quotaWindow{},
+		purgeInterval: cfg.PurgeInterval,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for scope, l := range map[QuotaScope]QuotaLimit{QuotaScopeOperations: cfg.Operations, QuotaScopeLookups: cfg.Lookups} {
+		if l.PerHour < 0 || l.PerDay < 0 {
+			return nil, fmt.Errorf("invalid request quota: %s limits cannot be negative", scope)
+		}
+		// The daily window is checked first, so that a caller over both quotas is told
+		// when it may send requests again rather than when the hour resets.
+		if l.PerDay > 0 {
+			q.windows[scope] = append(q.windows[scope], quotaWindow{period: QuotaPeriodDay, length: 24 * time.Hour, limit: l.PerDay})
+		}
+		if l.PerHour > 0 {
+			q.windows[scope] = append(q.windows[scope], quotaWindow{period: QuotaPeriodHour, length: time.Hour, limit: l.PerHour})
+		}
+	}
+	if len(q.windows) == 0 {
+		return nil, errors.New("invalid request quota: no limit is set")
+	}
+	if q.purgeInterval == 0 {
+		q.purgeInterval = defaultQuotaPurgeInterval
+	}
+	return q, nil
+}
+
+// TakeOperation counts an operation created by the subscriber, or returns a
+// *QuotaExceededError if the subscriber has used up its operation quota.
+func (q *requestQuota) TakeOperation(ctx context.Context, subscriberID string) error {
+	return q.take(ctx, QuotaScopeOperations, subscriberID)
+}
+
+// TakeLookup counts a lookup of the caller, or returns a *QuotaExceededError if the caller
+// has used up its lookup quota. A caller given as a remote address is counted by its IP.
+func (q *requestQuota) TakeLookup(ctx context.Context, caller string) error {
+	if addr, ok := parseRemoteAddr(caller); ok {
+		caller = addr.String()
+	}
+	return q.take(ctx, QuotaScopeLookups, caller)
+}
+
+// take counts a request of caller in each window of the scope. A request rejected by a window
+// is not counted in the windows after it, but remains counted in the windows before it.
+func (q *requestQuota) take(ctx context.Context, scope QuotaScope, caller string) error {
+	windows := q.windows[scope]
+	if len(windows) == 0 {
+		return nil
+	}
+	now := q.now().UTC()
+	for _, w := range windows {
+		// Truncation is relative to the zero time, so windows start on the hour and at midnight UTC.
+		start := now.Truncate(w.length)
+		count, err := q.repo.IncrementQuotaCounter(ctx, string(scope), caller, w.period, start)
+		if err != nil {
+			return fmt.Errorf("failed to check %s quota: %w", scope, err)
+		}
+		if count > w.limit {
+			requestQuotaMetrics.Add(string(scope)+"_limited", 1)
+			return &QuotaExceededError{Scope: scope, Caller: caller, Period: w.period, Limit: w.limit, Reset: start.Add(w.length)}
+		}
+	}
+	requestQuotaMetrics.Add(string(scope)+"_allowed", 1)
+	return nil
+}
+
+// Start launches the background loop that purges the counters of past windows. It returns immediately.
+func (q *requestQuota) Start(ctx context.Context) {
+	slog.InfoContext(ctx, "RequestQuota: Starting counter purge", "interval", q.purgeInterval)
+	go func() {
+		defer close(q.done)
+		ticker := time.NewTicker(q.purgeInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := q.Purge(ctx); err != nil {
+				slog.ErrorContext(ctx, "RequestQuota: Counter purge failed", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-q.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the purge loop to exit. It is safe to call more than once.
+func (q *requestQuota) Stop() {
+	q.stopOnce.Do(func() { close(q.stop) })
+}
+
+// Purge deletes the counters of windows that have ended and returns how many it deleted.
+func (q *requestQuota) Purge(ctx context.Context) (int64, error) {
+	// Every window that started over a day ago has ended, whatever its period.
+	n, err := q.repo.DeleteQuotaCounters(ctx, q.now().Add(-24*time.Hour))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge quota counters: %w", err)
+	}
+	if n > 0 {
+		slog.InfoContext(ctx, "RequestQuota: Purged quota counters", "count", n)
+	}
+	return n, nil
+}
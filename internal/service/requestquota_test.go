@@ -0,0 +1,240 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type quotaCounterKey struct {
+	scope, caller, period string
+	windowStart           time.Time
+}
+
+// mockQuotaCounterRepo is an in-memory implementation of quotaCounterRepo.
+type mockQuotaCounterRepo struct {
+	mu       sync.Mutex
+	counts   map[quotaCounterKey]int
+	err      error
+	deleted  int64
+	before   time.Time
+	purgeErr error
+}
+
+func (m *mockQuotaCounterRepo) IncrementQuotaCounter(ctx context.Context, scope, caller, period string, windowStart time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.counts == nil {
+		m.counts = map[quotaCounterKey]int{}
+	}
+	k := quotaCounterKey{scope, caller, period, windowStart}
+	m.counts[k]++
+	return m.counts[k], nil
+}
+
+func (m *mockQuotaCounterRepo) DeleteQuotaCounters(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.before = before
+	return m.deleted, m.purgeErr
+}
+
+func TestNewRequestQuota(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      quotaCounterRepo
+		cfg       *RequestQuotaConfig
+		wantPurge time.Duration
+		wantErr   string
+	}{
+		{name: "default purge interval", repo: &mockQuotaCounterRepo{}, cfg: &RequestQuotaConfig{Lookups: QuotaLimit{PerHour: 10}}, wantPurge: defaultQuotaPurgeInterval},
+		{name: "configured purge interval", repo: &mockQuotaCounterRepo{}, cfg: &RequestQuotaConfig{Operations: QuotaLimit{PerDay: 10}, PurgeInterval: time.Minute}, wantPurge: time.Minute},
+		{name: "nil repo", cfg: &RequestQuotaConfig{}, wantErr: "repo cannot be nil"},
+		{name: "nil config", repo: &mockQuotaCounterRepo{}, wantErr: "RequestQuotaConfig cannot be nil"},
+		{name: "no limit", repo: &mockQuotaCounterRepo{}, cfg: &RequestQuotaConfig{}, wantErr: "invalid request quota: no limit is set"},
+		{name: "negative limit", repo: &mockQuotaCounterRepo{}, cfg: &RequestQuotaConfig{Lookups: QuotaLimit{PerHour: -1}}, wantErr: "invalid request quota: lookups limits cannot be negative"},
+		{name: "negative purge interval", repo: &mockQuotaCounterRepo{}, cfg: &RequestQuotaConfig{Lookups: QuotaLimit{PerHour: 1}, PurgeInterval: -time.Second}, wantErr: "invalid request quota: purgeInterval -1s cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewRequestQuota(tt.repo, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewRequestQuota() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRequestQuota() unexpected error: %v", err)
+			}
+			if q.purgeInterval != tt.wantPurge {
+				t.Errorf("purgeInterval = %v, want %v", q.purgeInterval, tt.wantPurge)
+			}
+		})
+	}
+}
+
+func TestRequestQuota_TakeOperation(t *testing.T) {
+	now := time.Date(2025, 6, 10, 14, 25, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		limit      QuotaLimit
+		takes      int
+		wantPeriod string
+		wantLimit  int
+		wantReset  time.Time
+	}{
+		{name: "under hourly quota", limit: QuotaLimit{PerHour: 3}, takes: 3},
+		{name: "over hourly quota", limit: QuotaLimit{PerHour: 3}, takes: 4, wantPeriod: QuotaPeriodHour, wantLimit: 3, wantReset: time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)},
+		{name: "over daily quota", limit: QuotaLimit{PerDay: 2}, takes: 3, wantPeriod: QuotaPeriodDay, wantLimit: 2, wantReset: time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "over both quotas reports daily", limit: QuotaLimit{PerHour: 2, PerDay: 2}, takes: 3, wantPeriod: QuotaPeriodDay, wantLimit: 2, wantReset: time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewRequestQuota(&mockQuotaCounterRepo{}, &RequestQuotaConfig{Operations: tt.limit})
+			if err != nil {
+				t.Fatalf("NewRequestQuota() error = %v", err)
+			}
+			q.now = func() time.Time { return now }
+
+			var takeErr error
+			for i := 0; i < tt.takes; i++ {
+				takeErr = q.TakeOperation(context.Background(), "sub1")
+			}
+			if tt.wantPeriod == "" {
+				if takeErr != nil {
+					t.Fatalf("TakeOperation() error = %v, want nil", takeErr)
+				}
+				return
+			}
+			var qerr *QuotaExceededError
+			if !errors.As(takeErr, &qerr) {
+				t.Fatalf("TakeOperation() error = %v, want *QuotaExceededError", takeErr)
+			}
+			if !errors.Is(takeErr, ErrRequestQuotaExceeded) {
+				t.Errorf("TakeOperation() error does not wrap ErrRequestQuotaExceeded")
+			}
+			want := QuotaExceededError{Scope: QuotaScopeOperations, Caller: "sub1", Period: tt.wantPeriod, Limit: tt.wantLimit, Reset: tt.wantReset}
+			if *qerr != want {
+				t.Errorf("TakeOperation() error = %+v, want %+v", *qerr, want)
+			}
+		})
+	}
+}
+
+func TestRequestQuota_WindowResets(t *testing.T) {
+	q, err := NewRequestQuota(&mockQuotaCounterRepo{}, &RequestQuotaConfig{Operations: QuotaLimit{PerHour: 1}})
+	if err != nil {
+		t.Fatalf("NewRequestQuota() error = %v", err)
+	}
+	now := time.Date(2025, 6, 10, 14, 59, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	if err := q.TakeOperation(context.Background(), "sub1"); err != nil {
+		t.Fatalf("TakeOperation() error = %v", err)
+	}
+	if err := q.TakeOperation(context.Background(), "sub1"); !errors.Is(err, ErrRequestQuotaExceeded) {
+		t.Fatalf("TakeOperation() error = %v, want %v", err, ErrRequestQuotaExceeded)
+	}
+	if err := q.TakeOperation(context.Background(), "sub2"); err != nil {
+		t.Errorf("TakeOperation() of another subscriber error = %v, want nil", err)
+	}
+	now = now.Add(time.Minute)
+	if err := q.TakeOperation(context.Background(), "sub1"); err != nil {
+		t.Errorf("TakeOperation() in the next window error = %v, want nil", err)
+	}
+}
+
+func TestRequestQuota_TakeLookup(t *testing.T) {
+	repo := &mockQuotaCounterRepo{}
+	q, err := NewRequestQuota(repo, &RequestQuotaConfig{Lookups: QuotaLimit{PerHour: 1}})
+	if err != nil {
+		t.Fatalf("NewRequestQuota() error = %v", err)
+	}
+	// Lookups from different ports of the same IP share its quota.
+	if err := q.TakeLookup(context.Background(), "192.0.2.1:1234"); err != nil {
+		t.Fatalf("TakeLookup() error = %v", err)
+	}
+	err = q.TakeLookup(context.Background(), "192.0.2.1:5678")
+	var qerr *QuotaExceededError
+	if !errors.As(err, &qerr) || qerr.Caller != "192.0.2.1" || qerr.Scope != QuotaScopeLookups {
+		t.Fatalf("TakeLookup() error = %v, want lookups quota of 192.0.2.1 exceeded", err)
+	}
+	if err := q.TakeLookup(context.Background(), "bap.example.com"); err != nil {
+		t.Errorf("TakeLookup() of a subscriber error = %v, want nil", err)
+	}
+	// Operations have no quota.
+	if err := q.TakeOperation(context.Background(), "bap.example.com"); err != nil {
+		t.Errorf("TakeOperation() error = %v, want nil", err)
+	}
+}
+
+func TestRequestQuota_RepoError(t *testing.T) {
+	repoErr := errors.New("db down")
+	q, err := NewRequestQuota(&mockQuotaCounterRepo{err: repoErr}, &RequestQuotaConfig{Operations: QuotaLimit{PerDay: 1}})
+	if err != nil {
+		t.Fatalf("NewRequestQuota() error = %v", err)
+	}
+	err = q.TakeOperation(context.Background(), "sub1")
+	if !errors.Is(err, repoErr) || errors.Is(err, ErrRequestQuotaExceeded) {
+		t.Errorf("TakeOperation() error = %v, want %v", err, repoErr)
+	}
+}
+
+func TestRequestQuota_Purge(t *testing.T) {
+	now := time.Date(2025, 6, 10, 14, 25, 0, 0, time.UTC)
+	repo := &mockQuotaCounterRepo{deleted: 4}
+	q, err := NewRequestQuota(repo, &RequestQuotaConfig{Lookups: QuotaLimit{PerDay: 1}})
+	if err != nil {
+		t.Fatalf("NewRequestQuota() error = %v", err)
+	}
+	q.now = func() time.Time { return now }
+	n, err := q.Purge(context.Background())
+	if err != nil || n != 4 {
+		t.Fatalf("Purge() = %d, %v, want 4, nil", n, err)
+	}
+	if want := now.Add(-24 * time.Hour); !repo.before.Equal(want) {
+		t.Errorf("DeleteQuotaCounters() before = %v, want %v", repo.before, want)
+	}
+
+	repo.purgeErr = errors.New("db down")
+	if _, err := q.Purge(context.Background()); !errors.Is(err, repo.purgeErr) {
+		t.Errorf("Purge() error = %v, want %v", err, repo.purgeErr)
+	}
+}
+
+func TestRequestQuota_StartStop(t *testing.T) {
+	q, err := NewRequestQuota(&mockQuotaCounterRepo{}, &RequestQuotaConfig{Lookups: QuotaLimit{PerDay: 1}, PurgeInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewRequestQuota() error = %v", err)
+	}
+	q.Start(context.Background())
+	time.Sleep(5 * time.Millisecond)
+	q.Stop()
+	// Stop must be idempotent.
+	q.Stop()
+
+	select {
+	case <-q.done:
+	case <-time.After(time.Second):
+		t.Error("purge goroutine did not exit after Stop")
+	}
+}
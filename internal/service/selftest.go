@@ -0,0 +1,207 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const defaultSelfTestMaxClockSkew = 5 * time.Second
+
+// SelfTestConfig configures the connectivity self-test that network participants can run
+// against the gateway.
+type SelfTestConfig struct {
+	// MaxClockSkew is how far the created time of a signature may be from the gateway's
+	// clock. Defaults to 5s.
+	MaxClockSkew time.Duration `yaml:"maxClockSkew"`
+}
+
+// selfTest diagnoses why requests of a network participant are rejected, by running the
+// checks of the gateway's signature validation one at a time and reporting each outcome.
+type selfTest struct {
+	sv       signValidator
+	km       npKeyProvider
+	registry subscriptionLookup
+	maxSkew  time.Duration
+	now      func() time.Time
+}
+
+// NewSelfTest creates a new connectivity self-test. A nil config uses the defaults.
+func NewSelfTest(sv signValidator, km npKeyProvider, registry subscriptionLookup, cfg *SelfTestConfig) (*selfTest, error) {
+	if sv == nil {
+		slog.Error("NewSelfTest: signValidator dependency is nil")
+		return nil, errors.New("signValidator dependency is nil")
+	}
+	if km == nil {
+		slog.Error("NewSelfTest: npKeyProvider dependency is nil")
+		return nil, errors.New("npKeyProvider dependency is nil")
+	}
+	if registry == nil {
+		slog.Error("NewSelfTest: subscriptionLookup dependency is nil")
+		return nil, errors.New("subscriptionLookup dependency is nil")
+	}
+	if cfg == nil {
+		cfg = &SelfTestConfig{}
+	}
+	if cfg.MaxClockSkew < 0 {
+		return nil, fmt.Errorf("maxClockSkew must not be negative, got %s", cfg.MaxClockSkew)
+	}
+	maxSkew := cfg.MaxClockSkew
+	if maxSkew == 0 {
+		maxSkew = defaultSelfTestMaxClockSkew
+	}
+	return &selfTest{sv: sv, km: km, registry: registry, maxSkew: maxSkew, now: time.Now}, nil
+}
+
+// Run checks a signed request and reports the outcome of each check. Checks that depend on
+// a failed one are skipped.
+func (s *selfTest) Run(ctx context.Context, body []byte, authHeader string) *model.SelfTestReport {
+	now := s.now()
+	report := &model.SelfTestReport{GatewayTime: now.UTC()}
+	add := func(name model.SelfTestCheckName, status model.SelfTestStatus, detail string) {
+		report.Checks = append(report.Checks, model.SelfTestCheck{Name: name, Status: status, Detail: detail})
+	}
+	defer func() {
+		report.OK = true
+		for _, c := range report.Checks {
+			if c.Status != model.SelfTestStatusPass {
+				report.OK = false
+			}
+		}
+		slog.InfoContext(ctx, "SelfTest: Ran connectivity self-test", "subscriber_id", report.SubscriberID, "key_id", report.KeyID, "ok", report.OK)
+	}()
+
+	ah, authErr := keySet(ctx, authHeader)
+	if authErr != nil {
+		add(model.SelfTestCheckAuthHeader, model.SelfTestStatusFail, authErr.Message)
+		for _, name := range []model.SelfTestCheckName{model.SelfTestCheckClock, model.SelfTestCheckSigningKey, model.SelfTestCheckSignature, model.SelfTestCheckSubscriberURL} {
+			add(name, model.SelfTestStatusSkipped, "The Authorization header could not be parsed.")
+		}
+		return report
+	}
+	report.SubscriberID, report.KeyID = ah.SubscriberID, ah.UniqueID
+	add(model.SelfTestCheckAuthHeader, model.SelfTestStatusPass, "")
+
+	status, detail := s.checkClock(authHeader, now)
+	add(model.SelfTestCheckClock, status, detail)
+
+	key, _, err := s.km.LookupNPKeys(ctx, ah.SubscriberID, ah.UniqueID)
+	if err != nil {
+		add(model.SelfTestCheckSigningKey, model.SelfTestStatusFail, fmt.Sprintf("No signing key %q is registered for subscriber %q: %v", ah.UniqueID, ah.SubscriberID, err))
+		add(model.SelfTestCheckSignature, model.SelfTestStatusSkipped, "The signing key could not be retrieved.")
+	} else {
+		add(model.SelfTestCheckSigningKey, model.SelfTestStatusPass, "")
+		if err := s.sv.Validate(ctx, body, authHeader, key); err != nil {
+			add(model.SelfTestCheckSignature, model.SelfTestStatusFail, fmt.Sprintf("The signature does not validate against the registered key: %v. Check that the digest covers the exact request body and that the request is signed with the private key of the registered signing key.", err))
+		} else {
+			add(model.SelfTestCheckSignature, model.SelfTestStatusPass, "")
+		}
+	}
+
+	status, detail = s.checkURL(ctx, ah.SubscriberID, body)
+	add(model.SelfTestCheckSubscriberURL, status, detail)
+	return report
+}
+
+// checkClock checks the created and expires parameters of the Authorization header against now.
+func (s *selfTest) checkClock(authHeader string, now time.Time) (model.SelfTestStatus, string) {
+	created, err := signatureTime(authHeader, "created")
+	if err != nil {
+		return model.SelfTestStatusFail, fmt.Sprintf("The Authorization header is invalid: %v.", err)
+	}
+	expires, err := signatureTime(authHeader, "expires")
+	if err != nil {
+		return model.SelfTestStatusFail, fmt.Sprintf("The Authorization header is invalid: %v.", err)
+	}
+	if !expires.After(created) {
+		return model.SelfTestStatusFail, fmt.Sprintf("The signature expires at %s, which is not after it was created at %s.", expires.Format(time.RFC3339), created.Format(time.RFC3339))
+	}
+	skew := created.Sub(now.Truncate(time.Second))
+	if skew > s.maxSkew {
+		return model.SelfTestStatusFail, fmt.Sprintf("The signature was created %s ahead of the gateway's clock, more than the allowed %s. Synchronize your clock with NTP.", skew, s.maxSkew)
+	}
+	if !now.Before(expires) {
+		return model.SelfTestStatusFail, fmt.Sprintf("The signature expired at %s, %s before the gateway's clock. Synchronize your clock with NTP or sign with a later expires.", expires.Format(time.RFC3339), now.Sub(expires).Truncate(time.Second))
+	}
+	return model.SelfTestStatusPass, fmt.Sprintf("The signature was created %s from the gateway's clock.", skew.Abs())
+}
+
+// checkURL checks that the URL the subscriber sends in the request context is registered for it.
+func (s *selfTest) checkURL(ctx context.Context, subscriberID string, body []byte) (model.SelfTestStatus, string) {
+	var req model.TxnRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return model.SelfTestStatusSkipped, "The request body is not valid JSON."
+	}
+	var uri string
+	switch subscriberID {
+	case req.Context.BapID:
+		uri = req.Context.BapURI
+	case req.Context.BppID:
+		uri = req.Context.BppURI
+	}
+	if uri == "" {
+		return model.SelfTestStatusSkipped, "The request context has no bap_id and bap_uri, or bpp_id and bpp_uri, of the subscriber."
+	}
+	subs, err := s.registry.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: subscriberID}})
+	if err != nil {
+		slog.ErrorContext(ctx, "SelfTest: Failed to look up subscriber", "subscriber_id", subscriberID, "error", err)
+		return model.SelfTestStatusSkipped, "The registry could not be reached to check the subscriber's URL."
+	}
+	if len(subs) == 0 {
+		return model.SelfTestStatusFail, fmt.Sprintf("Subscriber %q is not registered.", subscriberID)
+	}
+	registered := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		if strings.TrimSuffix(sub.URL, "/") == strings.TrimSuffix(uri, "/") {
+			return model.SelfTestStatusPass, ""
+		}
+		registered = append(registered, sub.URL)
+	}
+	return model.SelfTestStatusFail, fmt.Sprintf("%s does not match the URLs registered for the subscriber: %s.", uri, strings.Join(registered, ", "))
+}
+
+// signatureTime returns the Unix time of a parameter of the Authorization header.
+func signatureTime(authHeader, name string) (time.Time, error) {
+	v, ok := signatureParam(authHeader, name)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s parameter is missing", name)
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s parameter is not a Unix timestamp: %q", name, v)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// signatureParam returns the unquoted value of a parameter of the Authorization header.
+func signatureParam(authHeader, name string) (string, bool) {
+	prefix := name + "="
+	for _, part := range strings.Split(authHeader, ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "Signature ")
+		if v, ok := strings.CutPrefix(part, prefix); ok {
+			return strings.Trim(v, `"`), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// selfTestHeader builds an Authorization header signed by np1 with the given created and expires times.
+func selfTestHeader(created, expires time.Time) string {
+	return fmt.Sprintf(`Signature keyId="np1|key1|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="c2ln"`, created.Unix(), expires.Unix())
+}
+
+func TestNewSelfTest(t *testing.T) {
+	tests := []struct {
+		name        string
+		sv          signValidator
+		km          npKeyProvider
+		registry    subscriptionLookup
+		cfg         *SelfTestConfig
+		wantErr     bool
+		wantMaxSkew time.Duration
+	}{
+		{name: "defaults", sv: &mockSignValidator{}, km: &mockNPKeyProvider{}, registry: &mockLookupClient{}, wantMaxSkew: defaultSelfTestMaxClockSkew},
+		{name: "custom skew", sv: &mockSignValidator{}, km: &mockNPKeyProvider{}, registry: &mockLookupClient{}, cfg: &SelfTestConfig{MaxClockSkew: time.Minute}, wantMaxSkew: time.Minute},
+		{name: "negative skew", sv: &mockSignValidator{}, km: &mockNPKeyProvider{}, registry: &mockLookupClient{}, cfg: &SelfTestConfig{MaxClockSkew: -time.Second}, wantErr: true},
+		{name: "nil sign validator", km: &mockNPKeyProvider{}, registry: &mockLookupClient{}, wantErr: true},
+		{name: "nil key provider", sv: &mockSignValidator{}, registry: &mockLookupClient{}, wantErr: true},
+		{name: "nil registry", sv: &mockSignValidator{}, km: &mockNPKeyProvider{}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := NewSelfTest(tc.sv, tc.km, tc.registry, tc.cfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewSelfTest() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && st.maxSkew != tc.wantMaxSkew {
+				t.Errorf("NewSelfTest() maxSkew = %v, want %v", st.maxSkew, tc.wantMaxSkew)
+			}
+		})
+	}
+}
+
+func TestSelfTest_Run(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	validHeader := selfTestHeader(now.Add(-time.Second), now.Add(time.Minute))
+	bapBody := `{"context":{"bap_id":"np1","bap_uri":"https://np1.example.com/beckn/"}}`
+	registered := []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "np1", URL: "https://np1.example.com/beckn"}}}
+	pass := model.SelfTestStatusPass
+	fail := model.SelfTestStatusFail
+	skipped := model.SelfTestStatusSkipped
+
+	tests := []struct {
+		name       string
+		header     string
+		body       string
+		sv         *mockSignValidator
+		km         *mockNPKeyProvider
+		registry   *mockLookupClient
+		wantOK     bool
+		wantStatus []model.SelfTestStatus
+		wantDetail string
+	}{
+		{
+			name:       "all checks pass",
+			header:     validHeader,
+			body:       bapBody,
+			wantOK:     true,
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, pass, pass},
+		},
+		{
+			name:       "bpp url",
+			header:     validHeader,
+			body:       `{"context":{"bap_id":"other","bap_uri":"https://other.example.com","bpp_id":"np1","bpp_uri":"https://np1.example.com/beckn"}}`,
+			wantOK:     true,
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, pass, pass},
+		},
+		{
+			name:       "missing header",
+			body:       bapBody,
+			wantStatus: []model.SelfTestStatus{fail, skipped, skipped, skipped, skipped},
+			wantDetail: "Authorization header missing.",
+		},
+		{
+			name:       "clock ahead",
+			header:     selfTestHeader(now.Add(time.Minute), now.Add(2*time.Minute)),
+			body:       bapBody,
+			wantStatus: []model.SelfTestStatus{pass, fail, pass, pass, pass},
+			wantDetail: "1m0s ahead of the gateway's clock",
+		},
+		{
+			name:       "expired",
+			header:     selfTestHeader(now.Add(-2*time.Minute), now.Add(-time.Minute)),
+			body:       bapBody,
+			wantStatus: []model.SelfTestStatus{pass, fail, pass, pass, pass},
+			wantDetail: "1m0s before the gateway's clock",
+		},
+		{
+			name:       "missing created",
+			header:     `Signature keyId="np1|key1|ed25519",algorithm="ed25519",headers="digest",signature="c2ln"`,
+			body:       bapBody,
+			wantStatus: []model.SelfTestStatus{pass, fail, pass, pass, pass},
+			wantDetail: "created parameter is missing",
+		},
+		{
+			name:       "unknown key",
+			header:     validHeader,
+			body:       bapBody,
+			km:         &mockNPKeyProvider{err: errors.New("not found")},
+			wantStatus: []model.SelfTestStatus{pass, pass, fail, skipped, pass},
+			wantDetail: `No signing key "key1" is registered for subscriber "np1"`,
+		},
+		{
+			name:       "invalid signature",
+			header:     validHeader,
+			body:       bapBody,
+			sv:         &mockSignValidator{err: errors.New("signature mismatch")},
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, fail, pass},
+			wantDetail: "signature mismatch",
+		},
+		{
+			name:       "url mismatch",
+			header:     validHeader,
+			body:       `{"context":{"bap_id":"np1","bap_uri":"https://np1.example.org"}}`,
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, pass, fail},
+			wantDetail: "https://np1.example.org does not match the URLs registered for the subscriber: https://np1.example.com/beckn.",
+		},
+		{
+			name:       "not registered",
+			header:     validHeader,
+			body:       bapBody,
+			registry:   &mockLookupClient{subscriptions: []model.Subscription{}},
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, pass, fail},
+			wantDetail: `Subscriber "np1" is not registered.`,
+		},
+		{
+			name:       "no uri in context",
+			header:     validHeader,
+			body:       `{"context":{}}`,
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, pass, skipped},
+		},
+		{
+			name:       "registry unavailable",
+			header:     validHeader,
+			body:       bapBody,
+			registry:   &mockLookupClient{err: errors.New("timeout")},
+			wantStatus: []model.SelfTestStatus{pass, pass, pass, pass, skipped},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.sv == nil {
+				tc.sv = &mockSignValidator{}
+			}
+			if tc.km == nil {
+				tc.km = &mockNPKeyProvider{signingKey: "key"}
+			}
+			if tc.registry == nil {
+				tc.registry = &mockLookupClient{subscriptions: registered}
+			}
+			st, err := NewSelfTest(tc.sv, tc.km, tc.registry, nil)
+			if err != nil {
+				t.Fatalf("NewSelfTest() unexpected error: %v", err)
+			}
+			st.now = func() time.Time { return now }
+
+			report := st.Run(context.Background(), []byte(tc.body), tc.header)
+
+			if report.OK != tc.wantOK {
+				t.Errorf("Run() OK = %v, want %v", report.OK, tc.wantOK)
+			}
+			if !report.GatewayTime.Equal(now) {
+				t.Errorf("Run() GatewayTime = %v, want %v", report.GatewayTime, now)
+			}
+			wantNames := []model.SelfTestCheckName{model.SelfTestCheckAuthHeader, model.SelfTestCheckClock, model.SelfTestCheckSigningKey, model.SelfTestCheckSignature, model.SelfTestCheckSubscriberURL}
+			if len(report.Checks) != len(wantNames) {
+				t.Fatalf("Run() returned %d checks, want %d: %+v", len(report.Checks), len(wantNames), report.Checks)
+			}
+			var details []string
+			for i, c := range report.Checks {
+				if c.Name != wantNames[i] || c.Status != tc.wantStatus[i] {
+					t.Errorf("check %d = %s %s, want %s %s", i, c.Name, c.Status, wantNames[i], tc.wantStatus[i])
+				}
+				details = append(details, c.Detail)
+			}
+			if tc.wantDetail != "" && !strings.Contains(strings.Join(details, "\n"), tc.wantDetail) {
+				t.Errorf("Run() details = %q, want one containing %q", details, tc.wantDetail)
+			}
+			if tc.header != "" && (report.SubscriberID != "np1" || report.KeyID != "key1") {
+				t.Errorf("Run() subscriber = (%q, %q), want (%q, %q)", report.SubscriberID, report.KeyID, "np1", "key1")
+			}
+		})
+	}
+}
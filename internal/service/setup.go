@@ -21,6 +21,8 @@ import (
 	"log/slog"
 	"time"
 
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
@@ -30,10 +32,12 @@ import (
 type repo interface {
 	EncryptionKey(ctx context.Context, subID, keyID string) (string, error)
 	InsertSubscription(context.Context, *model.Subscription) (*model.Subscription, error)
+	UpdateSigningKey(ctx context.Context, subID, keyID, signingPublicKey string) error
 }
 
 type encrInitializer interface {
 	Init(ctx context.Context) (string, error)
+	Keyset(ctx context.Context, subscriberID string) (*becknmodel.Keyset, error)
 }
 
 // RegistrySelfRegistrationConfig holds the configuration for the registry's self-registration.
@@ -101,7 +105,7 @@ func (s *registrySetupService) SelfRegister(ctx context.Context) error {
 	// If there's no error, the key exists.
 	if err == nil {
 		slog.InfoContext(ctx, "RegistrySetupService: Registry key already exists in DB. No self-registration needed.", "subscriber_id", s.cfg.SubscriberID, "key_id", s.cfg.KeyID)
-		return nil
+		return s.registerSigningKey(ctx)
 	}
 
 	// Using errors.Is for specific error types is preferred.
@@ -120,12 +124,18 @@ func (s *registrySetupService) SelfRegister(ctx context.Context) error {
 		}
 		slog.InfoContext(ctx, "RegistrySetupService: Keys initialized successfully. Public encryption key obtained.", "key_id_for_secret_manager", s.cfg.KeyID)
 
+		keys, err := s.encInit.Keyset(ctx, s.cfg.SubscriberID)
+		if err != nil {
+			slog.ErrorContext(ctx, "RegistrySetupService: Failed to read registry signing key", "error", err, "key_id_for_secret_manager", s.cfg.KeyID)
+			return fmt.Errorf("failed to read registry signing key: %w", err)
+		}
+
 		now := time.Now().UTC()
 		registrySubscription := &model.Subscription{
 			Subscriber:       model.Subscriber{SubscriberID: s.cfg.SubscriberID, URL: s.cfg.URL, Type: model.RoleRegistry, Domain: s.cfg.Domain},
 			KeyID:            s.cfg.KeyID,
 			EncrPublicKey:    registryEncrPublicKey,
-			SigningPublicKey: "", // encryptionService.Init typically only handles encryption keys
+			SigningPublicKey: keys.SigningPublic, // Verifies the requests the registry signs, e.g. /on_subscribe.
 			ValidFrom:        now,
 			ValidUntil:       now.AddDate(100, 0, 0), // Valid for 100 years
 			Status:           model.SubscriptionStatusSubscribed,
@@ -144,3 +154,23 @@ func (s *registrySetupService) SelfRegister(ctx context.Context) error {
 	slog.ErrorContext(ctx, "RegistrySetupService: Error checking for registry key in DB", "error", err, "subscriber_id", s.cfg.SubscriberID, "key_id", s.cfg.KeyID)
 	return fmt.Errorf("error checking for registry key %s for subscriber %s: %w", s.cfg.KeyID, s.cfg.SubscriberID, err)
 }
+
+// registerSigningKey makes sure that the registry's keyset has a signing key and that its
+// self-subscription holds the public key, so that network participants can verify the
+// requests the registry signs. Registries registered before they signed requests have none.
+func (s *registrySetupService) registerSigningKey(ctx context.Context) error {
+	if _, err := s.encInit.Init(ctx); err != nil {
+		slog.ErrorContext(ctx, "RegistrySetupService: Failed to initialize keys via encrInitializer", "error", err, "key_id_for_secret_manager", s.cfg.KeyID)
+		return fmt.Errorf("failed to initialize registry keys: %w", err)
+	}
+	keys, err := s.encInit.Keyset(ctx, s.cfg.SubscriberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "RegistrySetupService: Failed to read registry signing key", "error", err, "key_id_for_secret_manager", s.cfg.KeyID)
+		return fmt.Errorf("failed to read registry signing key: %w", err)
+	}
+	if err := s.repo.UpdateSigningKey(ctx, s.cfg.SubscriberID, s.cfg.KeyID, keys.SigningPublic); err != nil {
+		slog.ErrorContext(ctx, "RegistrySetupService: Failed to register signing key", "error", err, "subscriber_id", s.cfg.SubscriberID)
+		return fmt.Errorf("failed to register signing key for registry: %w", err)
+	}
+	return nil
+}
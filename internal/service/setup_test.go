@@ -20,6 +20,8 @@ import (
 	"strings"
 	"testing"
 
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
@@ -31,8 +33,16 @@ type mockSetupRepo struct {
 	insertSubscriptionToReturn *model.Subscription
 	insertSubscriptionErr      error
 
+	updateSigningKeyErr error
+
 	// To verify calls
 	insertSubscriptionCalledWith *model.Subscription
+	updatedSigningKey            string
+}
+
+func (m *mockSetupRepo) UpdateSigningKey(ctx context.Context, subID, keyID, signingPublicKey string) error {
+	m.updatedSigningKey = signingPublicKey
+	return m.updateSigningKeyErr
 }
 
 func (m *mockSetupRepo) EncryptionKey(ctx context.Context, subID, keyID string) (string, error) {
@@ -53,12 +63,20 @@ func (m *mockSetupRepo) InsertSubscription(ctx context.Context, sub *model.Subsc
 type mockEncrInitializer struct {
 	publicKeyToReturn string
 	initErr           error
+	keysetErr         error
 }
 
 func (m *mockEncrInitializer) Init(ctx context.Context) (string, error) {
 	return m.publicKeyToReturn, m.initErr
 }
 
+func (m *mockEncrInitializer) Keyset(ctx context.Context, subscriberID string) (*becknmodel.Keyset, error) {
+	if m.keysetErr != nil {
+		return nil, m.keysetErr
+	}
+	return &becknmodel.Keyset{SigningPublic: "signing-public-key"}, nil
+}
+
 func TestRegistrySelfRegistrationConfig_Validate(t *testing.T) {
 	validConfig := &RegistrySelfRegistrationConfig{
 		KeyID:        "reg-key",
@@ -152,6 +170,7 @@ func TestRegistrySetupService_SelfRegister(t *testing.T) {
 		mockEncSetup  func(*mockEncrInitializer)
 		wantErrMsg    string
 		wantInsert    bool // whether InsertSubscription should be called
+		wantKeyUpdate bool // whether UpdateSigningKey should be called
 	}{
 		{
 			name: "key already exists",
@@ -159,7 +178,29 @@ func TestRegistrySetupService_SelfRegister(t *testing.T) {
 				m.encryptionKeyToReturn = "existing-key"
 				m.encryptionKeyErr = nil
 			},
-			wantErrMsg: "",
+			wantErrMsg:    "",
+			wantInsert:    false,
+			wantKeyUpdate: true,
+		},
+		{
+			name: "key already exists, signing key update fails",
+			mockRepoSetup: func(m *mockSetupRepo) {
+				m.encryptionKeyToReturn = "existing-key"
+				m.updateSigningKeyErr = errors.New("db update failed")
+			},
+			wantErrMsg: "failed to register signing key for registry: db update failed",
+			wantInsert: false,
+		},
+		{
+			name: "keyset read fails",
+			mockRepoSetup: func(m *mockSetupRepo) {
+				m.encryptionKeyErr = repository.ErrEncrKeyNotFound
+			},
+			mockEncSetup: func(m *mockEncrInitializer) {
+				m.publicKeyToReturn = "new-public-key"
+				m.keysetErr = errors.New("no signing key")
+			},
+			wantErrMsg: "failed to read registry signing key: no signing key",
 			wantInsert: false,
 		},
 		{
@@ -255,10 +296,16 @@ func TestRegistrySetupService_SelfRegister(t *testing.T) {
 					if insertedSub.Status != model.SubscriptionStatusSubscribed {
 						t.Errorf("InsertSubscription called with wrong Status. Got %s, want %s", insertedSub.Status, model.SubscriptionStatusSubscribed)
 					}
+					if insertedSub.SigningPublicKey != "signing-public-key" {
+						t.Errorf("InsertSubscription called with wrong SigningPublicKey. Got %s, want %s", insertedSub.SigningPublicKey, "signing-public-key")
+					}
 				}
 			} else if mockRepo.insertSubscriptionCalledWith != nil {
 				t.Error("Expected InsertSubscription NOT to be called, but it was")
 			}
+			if tt.wantKeyUpdate && mockRepo.updatedSigningKey != "signing-public-key" {
+				t.Errorf("UpdateSigningKey() signingPublicKey = %q, want %q", mockRepo.updatedSigningKey, "signing-public-key")
+			}
 		})
 	}
 }
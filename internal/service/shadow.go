@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	defaultShadowTimeout   = 5 * time.Second
+	defaultShadowQueueSize = 100
+	defaultShadowWorkers   = 4
+)
+
+// shadowMetrics counts the requests mirrored to the shadow gateway, those that failed, and
+// those dropped because too many were pending.
+var shadowMetrics = expvar.NewMap("gateway_shadow")
+
+// ShadowConfig configures the mirroring of validated requests to a shadow gateway, such as
+// the gateway of a test environment, for load and regression testing with production traffic.
+type ShadowConfig struct {
+	// URL is the base URL of the shadow gateway. Requests are mirrored to the same path under it. Required.
+	URL string `yaml:"url"`
+	// Percent is the percentage of validated requests mirrored, above 0 and at most 100. Required.
+	Percent float64 `yaml:"percent"`
+	// SubscriberID names the keyset that re-signs mirrored requests. It must be registered
+	// with the shadow gateway's registry. Required.
+	SubscriberID string `yaml:"subscriberID"`
+	// Headers are the request headers copied to mirrored requests besides Content-Type.
+	// Every other header is scrubbed, and the authorization headers are never copied.
+	Headers []string `yaml:"headers"`
+	// Timeout is the timeout of each mirrored request. Defaults to 5s.
+	Timeout time.Duration `yaml:"timeout"`
+	// QueueSize is the number of mirrored requests that can be pending before new ones are dropped. Defaults to 100.
+	QueueSize int `yaml:"queueSize"`
+	// Workers is the number of mirrored requests sent concurrently. Defaults to 4.
+	Workers int `yaml:"workers"`
+}
+
+// shadowRequest is a request waiting to be mirrored.
+type shadowRequest struct {
+	path   string
+	body   []byte
+	header http.Header
+}
+
+// shadowMirror copies a sample of validated requests to a shadow gateway in the background.
+// Mirroring never delays or fails the request it copies: when the shadow gateway falls
+// behind, requests are dropped.
+type shadowMirror struct {
+	base         *url.URL
+	auth         authGen
+	subscriberID string
+	headers      []string
+	percent      float64
+	timeout      time.Duration
+	workers      int
+	client       httpClient
+	random       func() float64
+
+	mu      sync.RWMutex
+	stopped bool
+	queue   chan *shadowRequest
+	wg      sync.WaitGroup
+}
+
+// NewShadowMirror creates a new shadowMirror that signs mirrored requests with auth.
+func NewShadowMirror(auth authGen, cfg *ShadowConfig) (*shadowMirror, error) {
+	if auth == nil {
+		slog.Error("NewShadowMirror: authGen cannot be nil")
+		return nil, errors.New("authGen cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewShadowMirror: ShadowConfig cannot be nil")
+		return nil, errors.New("ShadowConfig cannot be nil")
+	}
+	base, err := url.Parse(cfg.URL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid shadow config: url %q must be an absolute http or https URL", cfg.URL)
+	}
+	if cfg.Percent <= 0 || cfg.Percent > 100 {
+		return nil, fmt.Errorf("invalid shadow config: percent %v must be above 0 and at most 100", cfg.Percent)
+	}
+	if cfg.SubscriberID == "" {
+		return nil, errors.New("invalid shadow config: subscriberID is required")
+	}
+	if cfg.Timeout < 0 || cfg.QueueSize < 0 || cfg.Workers < 0 {
+		return nil, errors.New("invalid shadow config: values cannot be negative")
+	}
+	m := &shadowMirror{
+		base:         base,
+		auth:         auth,
+		subscriberID: cfg.SubscriberID,
+		headers:      cfg.Headers,
+		percent:      cfg.Percent,
+		timeout:      cfg.Timeout,
+		workers:      cfg.Workers,
+		random:       rand.Float64,
+	}
+	if m.timeout == 0 {
+		m.timeout = defaultShadowTimeout
+	}
+	if m.workers == 0 {
+		m.workers = defaultShadowWorkers
+	}
+	queueSize := cfg.QueueSize
+	if queueSize == 0 {
+		queueSize = defaultShadowQueueSize
+	}
+	m.queue = make(chan *shadowRequest, queueSize)
+	m.client = &http.Client{Timeout: m.timeout}
+	return m, nil
+}
+
+// Start starts the workers that send mirrored requests.
+func (m *shadowMirror) Start() {
+	for range m.workers {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for req := range m.queue {
+				m.send(req)
+			}
+		}()
+	}
+	slog.Info("ShadowMirror: Mirroring requests", "url", m.base.String(), "percent", m.percent, "workers", m.workers)
+}
+
+// Stop stops accepting requests and waits for the pending ones to be sent.
+func (m *shadowMirror) Stop() {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	m.stopped = true
+	close(m.queue)
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+// Mirror queues a sample of requests to be copied to the shadow gateway under path.
+// Only Content-Type and the configured headers are copied from h.
+func (m *shadowMirror) Mirror(path string, body []byte, h http.Header) {
+	if m.random()*100 >= m.percent {
+		return
+	}
+	header := http.Header{}
+	for _, name := range append([]string{"Content-Type"}, m.headers...) {
+		for _, v := range h.Values(name) {
+			header.Add(name, v)
+		}
+	}
+	header.Del(model.AuthHeaderSubscriber)
+	header.Del(model.AuthHeaderGateway)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.stopped {
+		return
+	}
+	select {
+	case m.queue <- &shadowRequest{path: path, body: body, header: header}:
+	default:
+		shadowMetrics.Add("dropped", 1)
+	}
+}
+
+// send re-signs a mirrored request with the shadow keyset and sends it to the shadow gateway.
+// Its response is discarded.
+func (m *shadowMirror) send(req *shadowRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	if err := m.post(ctx, req); err != nil {
+		shadowMetrics.Add("failed", 1)
+		slog.WarnContext(ctx, "ShadowMirror: Failed to mirror request", "path", req.path, "error", err)
+		return
+	}
+	shadowMetrics.Add("mirrored", 1)
+}
+
+func (m *shadowMirror) post(ctx context.Context, req *shadowRequest) error {
+	authHeader, err := m.auth.AuthHeader(ctx, req.body, m.subscriberID)
+	if err != nil {
+		return fmt.Errorf("failed to sign mirrored request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.base.JoinPath(req.path).String(), bytes.NewReader(req.body))
+	if err != nil {
+		return fmt.Errorf("failed to create mirrored request: %w", err)
+	}
+	httpReq.Header = req.header
+	httpReq.Header.Set(model.AuthHeaderSubscriber, authHeader)
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send mirrored request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("shadow gateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
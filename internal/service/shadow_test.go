@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func shadowCount(key string) int64 {
+	if v, ok := shadowMetrics.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// shadowServer records the requests mirrored to it.
+type shadowServer struct {
+	*httptest.Server
+	status int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func newShadowServer(t *testing.T, status int) *shadowServer {
+	t.Helper()
+	s := &shadowServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestNewShadowMirror_Error(t *testing.T) {
+	valid := func() *ShadowConfig {
+		return &ShadowConfig{URL: "https://shadow.example.com", Percent: 10, SubscriberID: "gw.test"}
+	}
+	tests := []struct {
+		name    string
+		auth    authGen
+		cfg     func() *ShadowConfig
+		wantErr string
+	}{
+		{name: "nil authGen", cfg: valid, wantErr: "authGen cannot be nil"},
+		{name: "nil config", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { return nil }, wantErr: "ShadowConfig cannot be nil"},
+		{name: "relative url", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { c := valid(); c.URL = "/shadow"; return c }, wantErr: "must be an absolute http or https URL"},
+		{name: "unsupported scheme", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { c := valid(); c.URL = "ftp://shadow.example.com"; return c }, wantErr: "must be an absolute http or https URL"},
+		{name: "zero percent", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { c := valid(); c.Percent = 0; return c }, wantErr: "percent 0 must be above 0 and at most 100"},
+		{name: "percent above 100", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { c := valid(); c.Percent = 150; return c }, wantErr: "percent 150 must be above 0 and at most 100"},
+		{name: "missing subscriber", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { c := valid(); c.SubscriberID = ""; return c }, wantErr: "subscriberID is required"},
+		{name: "negative workers", auth: &mockAuthGen{}, cfg: func() *ShadowConfig { c := valid(); c.Workers = -1; return c }, wantErr: "values cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewShadowMirror(tt.auth, tt.cfg())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewShadowMirror() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShadowMirror_Mirror(t *testing.T) {
+	srv := newShadowServer(t, http.StatusOK)
+	m, err := NewShadowMirror(&mockAuthGen{authHeader: "shadow-signature"}, &ShadowConfig{URL: srv.URL + "/gateway", Percent: 100, SubscriberID: "gw.test", Headers: []string{"X-Trace-ID", "Authorization"}})
+	if err != nil {
+		t.Fatalf("NewShadowMirror() error = %v", err)
+	}
+	mirrored := shadowCount("mirrored")
+	m.Start()
+
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Trace-ID", "trace1")
+	h.Set("Cookie", "session=secret")
+	h.Set(model.AuthHeaderSubscriber, "np-signature")
+	h.Set(model.AuthHeaderGateway, "gateway-signature")
+	m.Mirror("/search", []byte(`{"context":{"action":"search"}}`), h)
+	m.Stop()
+
+	if len(srv.requests) != 1 {
+		t.Fatalf("shadow gateway received %d requests, want 1", len(srv.requests))
+	}
+	r := srv.requests[0]
+	if r.URL.Path != "/gateway/search" {
+		t.Errorf("path = %q, want /gateway/search", r.URL.Path)
+	}
+	if srv.bodies[0] != `{"context":{"action":"search"}}` {
+		t.Errorf("body = %s, want the original body", srv.bodies[0])
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":             "application/json",
+		"X-Trace-Id":               "trace1",
+		"Cookie":                   "",
+		model.AuthHeaderSubscriber: "shadow-signature",
+		model.AuthHeaderGateway:    "",
+	}
+	for name, want := range wantHeaders {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+	if got := shadowCount("mirrored") - mirrored; got != 1 {
+		t.Errorf("mirrored count = %d, want 1", got)
+	}
+}
+
+func TestShadowMirror_Sample(t *testing.T) {
+	tests := []struct {
+		name    string
+		percent float64
+		random  float64
+		want    int
+	}{
+		{name: "sampled in", percent: 60, random: 0.5, want: 1},
+		{name: "sampled out", percent: 40, random: 0.5, want: 0},
+		{name: "all", percent: 100, random: 0.999, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := NewShadowMirror(&mockAuthGen{}, &ShadowConfig{URL: "https://shadow.example.com", Percent: tt.percent, SubscriberID: "gw.test"})
+			m.random = func() float64 { return tt.random }
+
+			m.Mirror("/search", []byte(`{}`), http.Header{})
+
+			if got := len(m.queue); got != tt.want {
+				t.Errorf("queued requests = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShadowMirror_DropWhenFull(t *testing.T) {
+	m, _ := NewShadowMirror(&mockAuthGen{}, &ShadowConfig{URL: "https://shadow.example.com", Percent: 100, SubscriberID: "gw.test", QueueSize: 1})
+	dropped := shadowCount("dropped")
+
+	m.Mirror("/search", []byte(`{}`), http.Header{})
+	m.Mirror("/search", []byte(`{}`), http.Header{})
+
+	if got := shadowCount("dropped") - dropped; got != 1 {
+		t.Errorf("dropped count = %d, want 1", got)
+	}
+	if got := len(m.queue); got != 1 {
+		t.Errorf("queued requests = %d, want 1", got)
+	}
+}
+
+func TestShadowMirror_Failed(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		auth   *mockAuthGen
+	}{
+		{name: "shadow gateway error", status: http.StatusInternalServerError, auth: &mockAuthGen{authHeader: "sig"}},
+		{name: "signing error", status: http.StatusOK, auth: &mockAuthGen{err: errors.New("keyset not found")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newShadowServer(t, tt.status)
+			m, _ := NewShadowMirror(tt.auth, &ShadowConfig{URL: srv.URL, Percent: 100, SubscriberID: "gw.test"})
+			failed := shadowCount("failed")
+			m.Start()
+
+			m.Mirror("/search", []byte(`{}`), http.Header{})
+			m.Stop()
+
+			if got := shadowCount("failed") - failed; got != 1 {
+				t.Errorf("failed count = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestShadowMirror_MirrorAfterStop(t *testing.T) {
+	m, _ := NewShadowMirror(&mockAuthGen{}, &ShadowConfig{URL: "https://shadow.example.com", Percent: 100, SubscriberID: "gw.test"})
+	m.Start()
+	m.Stop()
+
+	// Mirroring after Stop must not panic on the closed queue.
+	m.Mirror("/search", []byte(`{}`), http.Header{})
+	m.Stop()
+}
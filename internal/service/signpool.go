@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSignPoolQueueTimeout = 500 * time.Millisecond
+	defaultSignPoolKeyCacheTTL  = 5 * time.Minute
+	defaultSignPoolCacheShards  = 16
+)
+
+// ErrSignPoolBusy occurs if no validation slot becomes free within the queue timeout.
+var ErrSignPoolBusy = errors.New("signature validation pool busy")
+
+// signPoolMetrics publishes signature validation pool counters on the expvar endpoint (/debug/vars):
+// key_cache_hits, key_cache_misses, key_cache_evictions, queue_timeouts and prewarmed keys.
+var signPoolMetrics = expvar.NewMap("sign_pool")
+
+// SignPoolConfig configures the signature validation pool of the gateway.
+type SignPoolConfig struct {
+	// Workers is the number of signatures verified at once. Defaults to GOMAXPROCS.
+	Workers int `yaml:"workers"`
+	// QueueTimeout is how long a request waits for a free worker before it is rejected
+	// as overloaded. Defaults to 500ms.
+	QueueTimeout time.Duration `yaml:"queueTimeout"`
+	// KeyCacheTTL is how long looked up signing keys are kept in the pool's cache. Defaults to 5m.
+	KeyCacheTTL time.Duration `yaml:"keyCacheTTL"`
+	// KeyCacheShards is the number of independently locked shards of the key cache. Defaults to 16.
+	KeyCacheShards int `yaml:"keyCacheShards"`
+	// Prewarm lists the keys, as "subscriber_id|unique_key_id", loaded into the cache at startup.
+	Prewarm []string `yaml:"prewarm"`
+}
+
+// signPool bounds concurrent signature verification and caches signing keys in front of
+// the key manager, so that verification under high concurrency does not contend on its locks.
+type signPool struct {
+	km           npKeyProvider
+	slots        chan struct{}
+	queueTimeout time.Duration
+	ttl          time.Duration
+	shards       []*keyCacheShard
+	prewarm      []string
+	now          func() time.Time
+}
+
+// keyCacheShard is one independently locked part of the signing key cache.
+type keyCacheShard struct {
+	mu   sync.RWMutex
+	keys map[string]cachedSigningKey
+}
+
+type cachedSigningKey struct {
+	key       string
+	expiresAt time.Time
+}
+
+// newSignPool creates a signPool looking up keys with km.
+func newSignPool(km npKeyProvider, cfg *SignPoolConfig) (*signPool, error) {
+	if cfg.Workers < 0 || cfg.QueueTimeout < 0 || cfg.KeyCacheTTL < 0 || cfg.KeyCacheShards < 0 {
+		return nil, errors.New("signPool: workers, queueTimeout, keyCacheTTL and keyCacheShards cannot be negative")
+	}
+	for _, k := range cfg.Prewarm {
+		if _, _, ok := splitPrewarmKey(k); !ok {
+			return nil, fmt.Errorf("signPool: prewarm key %q must be subscriber_id|unique_key_id", k)
+		}
+	}
+	p := &signPool{
+		km:           km,
+		slots:        make(chan struct{}, orDefault(cfg.Workers, runtime.GOMAXPROCS(0))),
+		queueTimeout: orDefault(cfg.QueueTimeout, defaultSignPoolQueueTimeout),
+		ttl:          orDefault(cfg.KeyCacheTTL, defaultSignPoolKeyCacheTTL),
+		shards:       make([]*keyCacheShard, orDefault(cfg.KeyCacheShards, defaultSignPoolCacheShards)),
+		prewarm:      cfg.Prewarm,
+		now:          time.Now,
+	}
+	for i := range p.shards {
+		p.shards[i] = &keyCacheShard{keys: make(map[string]cachedSigningKey)}
+	}
+	return p, nil
+}
+
+// orDefault returns v, or def if v is zero.
+func orDefault[T int | time.Duration](v, def T) T {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// splitPrewarmKey splits a "subscriber_id|unique_key_id" prewarm key.
+func splitPrewarmKey(k string) (subscriberID, keyID string, ok bool) {
+	subscriberID, keyID, ok = strings.Cut(k, "|")
+	return subscriberID, keyID, ok && subscriberID != "" && keyID != ""
+}
+
+// shard returns the cache shard holding id.
+func (p *signPool) shard(id string) *keyCacheShard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return p.shards[h.Sum32()%uint32(len(p.shards))]
+}
+
+// signingKey returns the signing public key of the subscriber's key, from the cache if present.
+func (p *signPool) signingKey(ctx context.Context, subscriberID, keyID string) (string, error) {
+	id := subscriberID + "|" + keyID
+	s := p.shard(id)
+	s.mu.RLock()
+	k, ok := s.keys[id]
+	s.mu.RUnlock()
+	if ok && p.now().Before(k.expiresAt) {
+		signPoolMetrics.Add("key_cache_hits", 1)
+		return k.key, nil
+	}
+	signPoolMetrics.Add("key_cache_misses", 1)
+	key, _, err := p.km.LookupNPKeys(ctx, subscriberID, keyID)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.keys[id] = cachedSigningKey{key: key, expiresAt: p.now().Add(p.ttl)}
+	s.mu.Unlock()
+	return key, nil
+}
+
+// evict removes the subscriber's key from the cache, so that it is looked up again.
+func (p *signPool) evict(subscriberID, keyID string) {
+	id := subscriberID + "|" + keyID
+	s := p.shard(id)
+	s.mu.Lock()
+	delete(s.keys, id)
+	s.mu.Unlock()
+	signPoolMetrics.Add("key_cache_evictions", 1)
+}
+
+// acquire waits for a free validation slot. The returned function releases it.
+func (p *signPool) acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	default:
+	}
+	t := time.NewTimer(p.queueTimeout)
+	defer t.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-t.C:
+		signPoolMetrics.Add("queue_timeouts", 1)
+		return nil, ErrSignPoolBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// warm loads the configured prewarm keys into the cache and returns how many were loaded.
+// Keys that cannot be looked up are logged and skipped.
+func (p *signPool) warm(ctx context.Context) int {
+	n := 0
+	for _, k := range p.prewarm {
+		subscriberID, keyID, _ := splitPrewarmKey(k)
+		if _, err := p.signingKey(ctx, subscriberID, keyID); err != nil {
+			slog.WarnContext(ctx, "SignPool: Failed to prewarm signing key", "subscriber_id", subscriberID, "key_id", keyID, "error", err)
+			continue
+		}
+		n++
+	}
+	signPoolMetrics.Add("prewarmed", int64(n))
+	return n
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// countingNPKeyProvider is an npKeyProvider that counts its lookups.
+type countingNPKeyProvider struct {
+	mu      sync.Mutex
+	keys    map[string]string
+	lookups int
+}
+
+func (p *countingNPKeyProvider) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lookups++
+	key, ok := p.keys[subscriberID+"|"+uniqueKeyID]
+	if !ok {
+		return "", "", errors.New("key not found")
+	}
+	return key, "", nil
+}
+
+// blockingSignValidator blocks every validation until release is closed.
+type blockingSignValidator struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (v *blockingSignValidator) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	v.started <- struct{}{}
+	<-v.release
+	return nil
+}
+
+func TestTxnSignValidator_SetPool_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *SignPoolConfig
+	}{
+		{name: "negative workers", cfg: &SignPoolConfig{Workers: -1}},
+		{name: "negative queue timeout", cfg: &SignPoolConfig{QueueTimeout: -time.Second}},
+		{name: "negative shards", cfg: &SignPoolConfig{KeyCacheShards: -1}},
+		{name: "malformed prewarm key", cfg: &SignPoolConfig{Prewarm: []string{"np1"}}},
+		{name: "empty prewarm key ID", cfg: &SignPoolConfig{Prewarm: []string{"np1|"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _ := NewTxnSignValidator(&mockSignValidator{}, &mockNPKeyProvider{})
+			if err := v.SetPool(tt.cfg); err == nil {
+				t.Error("SetPool() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTxnSignValidator_Pool_CachesKeys(t *testing.T) {
+	ctx := context.Background()
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+	km := &countingNPKeyProvider{keys: map[string]string{"test.com|key1": "signing-key"}}
+	v, _ := NewTxnSignValidator(&mockSignValidator{}, km)
+	if err := v.SetPool(&SignPoolConfig{KeyCacheTTL: time.Minute}); err != nil {
+		t.Fatalf("SetPool() error = %v", err)
+	}
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	v.pool.now = func() time.Time { return now }
+
+	for range 3 {
+		if err := v.Validate(ctx, []byte(`{}`), authHeader); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+	}
+	if km.lookups != 1 {
+		t.Errorf("lookups = %d, want 1", km.lookups)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := v.Validate(ctx, []byte(`{}`), authHeader); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if km.lookups != 2 {
+		t.Errorf("lookups after expiry = %d, want 2", km.lookups)
+	}
+}
+
+func TestTxnSignValidator_Pool_EvictsKeyOnInvalidSignature(t *testing.T) {
+	ctx := context.Background()
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+	km := &countingNPKeyProvider{keys: map[string]string{"test.com|key1": "signing-key"}}
+	sv := &mockSignValidator{err: errors.New("signature mismatch")}
+	v, _ := NewTxnSignValidator(sv, km)
+	if err := v.SetPool(&SignPoolConfig{}); err != nil {
+		t.Fatalf("SetPool() error = %v", err)
+	}
+
+	if err := v.Validate(ctx, []byte(`{}`), authHeader); err == nil || err.ErrorCode != model.ErrorCodeInvalidSignature {
+		t.Fatalf("Validate() error = %v, want %s", err, model.ErrorCodeInvalidSignature)
+	}
+	sv.err = nil
+	if err := v.Validate(ctx, []byte(`{}`), authHeader); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if km.lookups != 2 {
+		t.Errorf("lookups = %d, want 2 after the key was evicted", km.lookups)
+	}
+}
+
+func TestTxnSignValidator_Pool_RejectsWhenBusy(t *testing.T) {
+	ctx := context.Background()
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+	sv := &blockingSignValidator{started: make(chan struct{}, 1), release: make(chan struct{})}
+	v, _ := NewTxnSignValidator(sv, &mockNPKeyProvider{signingKey: "signing-key"})
+	if err := v.SetPool(&SignPoolConfig{Workers: 1, QueueTimeout: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("SetPool() error = %v", err)
+	}
+
+	done := make(chan *model.AuthError)
+	go func() { done <- v.Validate(ctx, []byte(`{}`), authHeader) }()
+	<-sv.started
+
+	err := v.Validate(ctx, []byte(`{}`), authHeader)
+	if err == nil || err.StatusCode != http.StatusServiceUnavailable || err.ErrorCode != model.ErrorCodeServiceOverloaded {
+		t.Errorf("Validate() error = %v, want %d %s", err, http.StatusServiceUnavailable, model.ErrorCodeServiceOverloaded)
+	}
+	close(sv.release)
+	if err := <-done; err != nil {
+		t.Errorf("first Validate() error = %v", err)
+	}
+}
+
+func TestTxnSignValidator_Prewarm(t *testing.T) {
+	km := &countingNPKeyProvider{keys: map[string]string{"np1|k1": "key-1", "np2|k2": "key-2"}}
+	v, _ := NewTxnSignValidator(&mockSignValidator{}, km)
+	if n := v.Prewarm(context.Background()); n != 0 {
+		t.Errorf("Prewarm() without pool = %d, want 0", n)
+	}
+	if err := v.SetPool(&SignPoolConfig{Prewarm: []string{"np1|k1", "np2|k2", "np3|k3"}}); err != nil {
+		t.Fatalf("SetPool() error = %v", err)
+	}
+
+	if n := v.Prewarm(context.Background()); n != 2 {
+		t.Errorf("Prewarm() = %d, want 2", n)
+	}
+	if err := v.Validate(context.Background(), []byte(`{}`), `Signature keyId="np1|k1|ed25519"`); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if km.lookups != 3 {
+		t.Errorf("lookups = %d, want 3 with the prewarmed key served from the cache", km.lookups)
+	}
+}
+
+// ed25519SignValidator verifies the body against a base64 signature in the header.
+type ed25519SignValidator struct{}
+
+func (ed25519SignValidator) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	pub, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(header[len(header)-88:])
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, body, sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// contendedNPKeyProvider serialises lookups behind a single lock, like a key manager cache.
+type contendedNPKeyProvider struct {
+	mu  sync.Mutex
+	key string
+}
+
+func (p *contendedNPKeyProvider) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	time.Sleep(time.Microsecond)
+	return p.key, "", nil
+}
+
+// BenchmarkTxnSignValidator_Parallel compares validation straight against the key manager
+// with the pool at different worker counts; run with -cpu to tune workers for a host.
+func BenchmarkTxnSignValidator_Parallel(b *testing.B) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	body := []byte(`{"context":{"action":"search"},"message":{}}`)
+	authHeader := `Signature keyId="np1|k1|ed25519",signature="` + base64.StdEncoding.EncodeToString(ed25519.Sign(priv, body))
+	km := &contendedNPKeyProvider{key: base64.StdEncoding.EncodeToString(pub)}
+
+	run := func(b *testing.B, v *txnSignValidator) {
+		var failed atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if err := v.Validate(context.Background(), body, authHeader); err != nil {
+					failed.Add(1)
+				}
+			}
+		})
+		if n := failed.Load(); n > 0 {
+			b.Errorf("%d validations failed", n)
+		}
+	}
+	b.Run("key manager", func(b *testing.B) {
+		v, _ := NewTxnSignValidator(ed25519SignValidator{}, km)
+		run(b, v)
+	})
+	// Zero workers is the default of GOMAXPROCS.
+	for _, workers := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("pool workers=%d", workers), func(b *testing.B) {
+			v, _ := NewTxnSignValidator(ed25519SignValidator{}, km)
+			if err := v.SetPool(&SignPoolConfig{Workers: workers, QueueTimeout: time.Minute}); err != nil {
+				b.Fatal(err)
+			}
+			run(b, v)
+		})
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/uuid"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+const (
+	defaultSmokeSubscriberID = "onix-smoke-{run}"
+	defaultSmokeTimeout      = 5 * time.Minute
+	defaultSmokePollInterval = 5 * time.Second
+)
+
+// Steps of a smoke test, in the order they run.
+const (
+	SmokeStepKeyset    = "keyset"
+	SmokeStepSubscribe = "subscribe"
+	SmokeStepChallenge = "challenge"
+	SmokeStepOperation = "operation"
+)
+
+// SmokeTestConfig configures a smoke test of a registry environment. The smoke test
+// subscribes a throwaway participant, answers the registry's challenge and waits for its
+// operation to be approved, to validate an environment before real participants onboard.
+type SmokeTestConfig struct {
+	// SubscriberID is the subscriber ID of the throwaway participant. "{run}" is replaced
+	// with a unique ID, so that repeated runs do not conflict. Defaults to "onix-smoke-{run}".
+	SubscriberID string `yaml:"subscriberID"`
+	// URL is the subscriber URL of the participant. The registry must be able to reach
+	// the /on_subscribe endpoint of the smoke test under it.
+	URL string `yaml:"url"`
+	// Domain is the domain of the subscription.
+	Domain string `yaml:"domain"`
+	// Type is the role of the subscription. Defaults to BAP.
+	Type model.Role `yaml:"type"`
+	// Timeout is how long to wait for the operation to be approved. Defaults to 5m.
+	Timeout time.Duration `yaml:"timeout"`
+	// PollInterval is how often the operation is polled. Defaults to 5s.
+	PollInterval time.Duration `yaml:"pollInterval"`
+}
+
+// SmokeStep is the outcome of a step of a smoke test.
+type SmokeStep struct {
+	Name     string
+	Passed   bool
+	Duration time.Duration
+	Detail   string
+}
+
+// SmokeReport is the outcome of a smoke test.
+type SmokeReport struct {
+	SubscriberID string
+	OperationID  string
+	Steps        []SmokeStep
+}
+
+// Passed reports whether every step of the smoke test ran and passed.
+func (r *SmokeReport) Passed() bool {
+	for _, s := range r.Steps {
+		if !s.Passed {
+			return false
+		}
+	}
+	return len(r.Steps) > 0 && r.Steps[len(r.Steps)-1].Name == SmokeStepOperation
+}
+
+// Write prints the report as a table of steps followed by the overall result.
+func (r *SmokeReport) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Smoke test of subscriber %s\n", r.SubscriberID)
+	if r.OperationID != "" {
+		fmt.Fprintf(tw, "Operation: %s\n", r.OperationID)
+	}
+	for _, s := range r.Steps {
+		result := "PASS"
+		if !s.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result, s.Name, s.Duration.Round(time.Millisecond), s.Detail)
+	}
+	result := "PASS"
+	if !r.Passed() {
+		result = "FAIL"
+	}
+	fmt.Fprintf(tw, "Result: %s\n", result)
+	return tw.Flush()
+}
+
+// smokeTest runs a subscribe, challenge and approval cycle against a registry with a
+// throwaway keyset, held in memory rather than in the key manager.
+type smokeTest struct {
+	sub      *subscriberService
+	keys     *scratchKeyManager
+	registry registryClient
+	cfg      SmokeTestConfig
+}
+
+// NewSmokeTest creates a new smokeTest. Keysets are generated by km, and the keys of the
+// registry are looked up with it, but keysets are only stored in memory, and on_subscribe
+// events are not published.
+func NewSmokeTest(registry registryClient, km keyManager, dec decrypter, regID, regKeyID string, cfg *SmokeTestConfig) (*smokeTest, error) {
+	if km == nil {
+		slog.Error("NewSmokeTest: keyManager cannot be nil")
+		return nil, errors.New("keyManager cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewSmokeTest: SmokeTestConfig cannot be nil")
+		return nil, errors.New("SmokeTestConfig cannot be nil")
+	}
+	if cfg.URL == "" {
+		return nil, errors.New("invalid smoke test: url is required")
+	}
+	if cfg.Domain == "" {
+		return nil, errors.New("invalid smoke test: domain is required")
+	}
+	if cfg.Timeout < 0 || cfg.PollInterval < 0 {
+		return nil, errors.New("invalid smoke test: timeout and pollInterval cannot be negative")
+	}
+	c := *cfg
+	if c.SubscriberID == "" {
+		c.SubscriberID = defaultSmokeSubscriberID
+	}
+	c.SubscriberID = strings.ReplaceAll(c.SubscriberID, "{run}", uuid.NewString()[:8])
+	if c.Type == "" {
+		c.Type = model.RoleBAP
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultSmokeTimeout
+	}
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultSmokePollInterval
+	}
+	keys := &scratchKeyManager{keyManager: km, keysets: map[string]*becknmodel.Keyset{}}
+	// Subscription updates are not part of the smoke test, so requests are never signed.
+	sub, err := NewSubscriberService(registry, keys, dec, discardEventPublisher{}, &unsignedAuthGen{}, regID, regKeyID)
+	if err != nil {
+		return nil, err
+	}
+	return &smokeTest{sub: sub, keys: keys, registry: registry, cfg: c}, nil
+}
+
+// Subscriber returns the subscriber service of the smoke test, which answers the registry's
+// challenge with the throwaway keyset.
+func (t *smokeTest) Subscriber() *subscriberService {
+	return t.sub
+}
+
+// Run subscribes the throwaway participant and waits until its operation is done or the
+// timeout elapses. It runs until the first failed step and reports every step it ran.
+func (t *smokeTest) Run(ctx context.Context) *SmokeReport {
+	report := &SmokeReport{SubscriberID: t.cfg.SubscriberID}
+	step := func(name string, start time.Time, err error, detail string) bool {
+		s := SmokeStep{Name: name, Passed: err == nil, Duration: t.sub.now().Sub(start), Detail: detail}
+		if err != nil {
+			s.Detail = err.Error()
+		}
+		report.Steps = append(report.Steps, s)
+		slog.InfoContext(ctx, "SmokeTest: Step finished", "step", name, "passed", s.Passed, "detail", s.Detail)
+		return s.Passed
+	}
+
+	start := t.sub.now()
+	keys, err := t.keys.GenerateKeyset()
+	if err == nil {
+		err = t.keys.InsertKeyset(ctx, keys.UniqueKeyID, keys)
+	}
+	if !step(SmokeStepKeyset, start, err, "generated throwaway key "+keyIDOf(keys)) {
+		return report
+	}
+
+	start = t.sub.now()
+	opID, err := t.sub.CreateSubscription(ctx, &model.NpSubscriptionRequest{
+		Subscriber: model.Subscriber{SubscriberID: t.cfg.SubscriberID, URL: t.cfg.URL, Domain: t.cfg.Domain, Type: t.cfg.Type},
+		KeyID:      keys.UniqueKeyID,
+	})
+	report.OperationID = opID
+	if !step(SmokeStepSubscribe, start, err, "registry accepted the subscription request") {
+		return report
+	}
+
+	// The registry sends the challenge when the operation is approved, so the challenge
+	// and the operation are awaited together.
+	start = t.sub.now()
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(t.cfg.PollInterval)
+	defer ticker.Stop()
+	var lro *model.LRO
+	var pollErr error
+poll:
+	for {
+		got, err := t.registry.GetOperation(ctx, opID)
+		if err == nil && got == nil {
+			err = ErrLRONotFound
+		}
+		pollErr = err
+		if err != nil {
+			slog.WarnContext(ctx, "SmokeTest: Failed to poll operation", "operation_id", opID, "error", err)
+		} else if lro = got; lro.Done() {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			break poll
+		}
+	}
+	var challenge *model.SubscriberChallengeStatus
+	if _, _, ch := t.sub.state.snapshot(); ch != nil && ch.OperationID == opID {
+		challenge = ch
+	}
+
+	switch {
+	case challenge == nil:
+		err = fmt.Errorf("no challenge received from the registry at %s/on_subscribe", strings.TrimSuffix(t.cfg.URL, "/"))
+	case !challenge.Success:
+		err = fmt.Errorf("failed to answer the challenge: %s", challenge.Error)
+	}
+	step(SmokeStepChallenge, start, err, "answered the registry's challenge")
+
+	switch {
+	case lro == nil:
+		err = fmt.Errorf("failed to poll the operation: %w", pollErr)
+	case !lro.Done():
+		err = fmt.Errorf("operation is still %s after %v: approve it in the admin service or enable auto-approval", lro.Status, t.cfg.Timeout)
+	case lro.Status != model.LROStatusApproved:
+		err = fmt.Errorf("operation is %s", lro.Status)
+		if len(lro.ErrorDataJSON) > 0 {
+			err = fmt.Errorf("operation is %s: %s", lro.Status, lro.ErrorDataJSON)
+		}
+	default:
+		err = nil
+	}
+	step(SmokeStepOperation, start, err, "operation was approved")
+	return report
+}
+
+// keyIDOf returns the unique key ID of keys, or an empty string if keys is nil.
+func keyIDOf(keys *becknmodel.Keyset) string {
+	if keys == nil {
+		return ""
+	}
+	return keys.UniqueKeyID
+}
+
+// scratchKeyManager stores keysets in memory, so that the keysets of a smoke test do not
+// reach the key manager. Keysets are generated, and keys of other participants looked up,
+// by the embedded key manager.
+type scratchKeyManager struct {
+	keyManager
+	mu      sync.Mutex
+	keysets map[string]*becknmodel.Keyset
+}
+
+func (m *scratchKeyManager) Keyset(ctx context.Context, keyID string) (*becknmodel.Keyset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys, ok := m.keysets[keyID]
+	if !ok {
+		return nil, fmt.Errorf("keyset %s not found", keyID)
+	}
+	c := *keys
+	return &c, nil
+}
+
+func (m *scratchKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *becknmodel.Keyset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *keyset
+	m.keysets[keyID] = &c
+	return nil
+}
+
+func (m *scratchKeyManager) DeleteKeyset(ctx context.Context, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keysets, keyID)
+	return nil
+}
+
+// discardEventPublisher drops on_subscribe events, so that smoke tests do not trigger
+// the consumers of real subscriptions.
+type discardEventPublisher struct{}
+
+func (discardEventPublisher) PublishOnSubscribeRecievedEvent(ctx context.Context, lroID string) (string, error) {
+	return "", nil
+}
+
+// unsignedAuthGen fails to sign requests, as the smoke test never sends signed requests.
+type unsignedAuthGen struct{}
+
+func (*unsignedAuthGen) AuthHeader(ctx context.Context, body []byte, keyID string) (string, error) {
+	return "", errors.New("smoke test does not sign requests")
+}
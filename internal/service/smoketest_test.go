@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockSmokeRegistry is a registryClient that, like the registry, uses the message ID of a
+// subscription request as its operation ID, and whose operation status is computed per poll.
+type mockSmokeRegistry struct {
+	createErr error
+	opID      string
+	polls     int
+	getOp     func(polls int) (*model.LRO, error)
+}
+
+func (m *mockSmokeRegistry) CreateSubscription(ctx context.Context, req *model.SubscriptionRequest) (*model.SubscriptionResponse, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	m.opID = req.MessageID
+	return &model.SubscriptionResponse{MessageID: req.MessageID, Status: model.SubscriptionStatusUnderSubscription}, nil
+}
+
+func (m *mockSmokeRegistry) UpdateSubscription(ctx context.Context, req *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSmokeRegistry) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	m.polls++
+	return m.getOp(m.polls)
+}
+
+func newTestSmokeTest(t *testing.T, registry registryClient, cfg *SmokeTestConfig) *smokeTest {
+	t.Helper()
+	// Inserts into the key manager fail, so the smoke test only passes if its keysets stay in memory.
+	km := &mockKeyManager{insertKeysetErr: errors.New("read-only"), lookupNPKeysEncr: "reg-encr-pub"}
+	st, err := NewSmokeTest(registry, km, &mockDecrypter{decryptedData: "answer"}, "reg.example.com", "reg-key", cfg)
+	if err != nil {
+		t.Fatalf("NewSmokeTest() error = %v", err)
+	}
+	return st
+}
+
+func TestNewSmokeTest(t *testing.T) {
+	tests := []struct {
+		name    string
+		km      keyManager
+		cfg     *SmokeTestConfig
+		wantErr string
+	}{
+		{name: "nil key manager", cfg: &SmokeTestConfig{}, wantErr: "keyManager cannot be nil"},
+		{name: "nil config", km: &mockKeyManager{}, wantErr: "SmokeTestConfig cannot be nil"},
+		{name: "missing url", km: &mockKeyManager{}, cfg: &SmokeTestConfig{Domain: "retail"}, wantErr: "invalid smoke test: url is required"},
+		{name: "missing domain", km: &mockKeyManager{}, cfg: &SmokeTestConfig{URL: "https://np.example.com"}, wantErr: "invalid smoke test: domain is required"},
+		{name: "negative timeout", km: &mockKeyManager{}, cfg: &SmokeTestConfig{URL: "https://np.example.com", Domain: "retail", Timeout: -time.Second}, wantErr: "invalid smoke test: timeout and pollInterval cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSmokeTest(&mockRegistryClient{}, tt.km, &mockDecrypter{}, "reg.example.com", "reg-key", tt.cfg)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("NewSmokeTest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSmokeTest_Defaults(t *testing.T) {
+	st := newTestSmokeTest(t, &mockRegistryClient{}, &SmokeTestConfig{URL: "https://np.example.com", Domain: "retail"})
+	if !strings.HasPrefix(st.cfg.SubscriberID, "onix-smoke-") || strings.Contains(st.cfg.SubscriberID, "{run}") {
+		t.Errorf("SubscriberID = %q, want onix-smoke- followed by a run ID", st.cfg.SubscriberID)
+	}
+	if st.cfg.Type != model.RoleBAP || st.cfg.Timeout != defaultSmokeTimeout || st.cfg.PollInterval != defaultSmokePollInterval {
+		t.Errorf("config = %+v, want BAP with default timeout and poll interval", st.cfg)
+	}
+	other := newTestSmokeTest(t, &mockRegistryClient{}, &SmokeTestConfig{URL: "https://np.example.com", Domain: "retail"})
+	if other.cfg.SubscriberID == st.cfg.SubscriberID {
+		t.Errorf("SubscriberID = %q for two runs, want unique IDs", st.cfg.SubscriberID)
+	}
+}
+
+func TestSmokeTest_Run(t *testing.T) {
+	cfg := &SmokeTestConfig{SubscriberID: "smoke-{run}.np.example.com", URL: "https://np.example.com/", Domain: "retail", Timeout: 50 * time.Millisecond, PollInterval: time.Millisecond}
+	tests := []struct {
+		name      string
+		createErr error
+		challenge bool
+		getOp     func(polls int) (*model.LRO, error)
+		wantPass  bool
+		wantSteps []string
+	}{
+		{
+			name:      "approved",
+			challenge: true,
+			getOp: func(polls int) (*model.LRO, error) {
+				if polls < 3 {
+					return &model.LRO{Status: model.LROStatusPending}, nil
+				}
+				return &model.LRO{Status: model.LROStatusApproved}, nil
+			},
+			wantPass:  true,
+			wantSteps: []string{"PASS keyset", "PASS subscribe", "PASS challenge", "PASS operation"},
+		},
+		{
+			name:      "subscription rejected by registry",
+			createErr: errors.New("429 quota exceeded"),
+			wantSteps: []string{"PASS keyset", "FAIL subscribe: registry operation failed: 429 quota exceeded"},
+		},
+		{
+			name: "never approved",
+			getOp: func(int) (*model.LRO, error) {
+				return &model.LRO{Status: model.LROStatusPending}, nil
+			},
+			wantSteps: []string{"PASS keyset", "PASS subscribe", "FAIL challenge: no challenge received from the registry at https://np.example.com/on_subscribe", "FAIL operation: operation is still PENDING after 50ms: approve it in the admin service or enable auto-approval"},
+		},
+		{
+			name:      "rejected",
+			challenge: true,
+			getOp: func(int) (*model.LRO, error) {
+				return &model.LRO{Status: model.LROStatusRejected, ErrorDataJSON: []byte(`{"reason":"challenge mismatch"}`)}, nil
+			},
+			wantSteps: []string{"PASS keyset", "PASS subscribe", "PASS challenge", `FAIL operation: operation is REJECTED: {"reason":"challenge mismatch"}`},
+		},
+		{
+			name: "operation cannot be polled",
+			getOp: func(int) (*model.LRO, error) {
+				return nil, errors.New("connection refused")
+			},
+			wantSteps: []string{"PASS keyset", "PASS subscribe", "FAIL challenge: no challenge received from the registry at https://np.example.com/on_subscribe", "FAIL operation: failed to poll the operation: connection refused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &mockSmokeRegistry{createErr: tt.createErr}
+			st := newTestSmokeTest(t, registry, cfg)
+			registry.getOp = func(polls int) (*model.LRO, error) {
+				if tt.challenge && polls == 1 {
+					// The registry challenges the participant when the operation is approved.
+					if _, err := st.Subscriber().OnSubscribe(context.Background(), &model.OnSubscribeRequest{MessageID: registry.opID, Challenge: "encrypted"}); err != nil {
+						t.Errorf("OnSubscribe() error = %v", err)
+					}
+				}
+				return tt.getOp(polls)
+			}
+
+			report := st.Run(context.Background())
+			var got []string
+			for _, s := range report.Steps {
+				result := "PASS " + s.Name
+				if !s.Passed {
+					result = "FAIL " + s.Name + ": " + s.Detail
+				}
+				got = append(got, result)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantSteps, "\n") {
+				t.Errorf("Run() steps =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.wantSteps, "\n"))
+			}
+			if report.Passed() != tt.wantPass {
+				t.Errorf("Run() Passed() = %v, want %v", report.Passed(), tt.wantPass)
+			}
+			if !strings.HasPrefix(report.SubscriberID, "smoke-") || !strings.HasSuffix(report.SubscriberID, ".np.example.com") {
+				t.Errorf("Run() SubscriberID = %q, want smoke-<run>.np.example.com", report.SubscriberID)
+			}
+		})
+	}
+}
+
+func TestSmokeReport_Write(t *testing.T) {
+	report := &SmokeReport{
+		SubscriberID: "smoke-1.np.example.com",
+		OperationID:  "op1",
+		Steps: []SmokeStep{
+			{Name: SmokeStepKeyset, Passed: true, Duration: time.Millisecond, Detail: "generated throwaway key k1"},
+			{Name: SmokeStepSubscribe, Detail: "registry operation failed"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `Smoke test of subscriber smoke-1.np.example.com
+Operation: op1
+PASS  keyset     1ms  generated throwaway key k1
+FAIL  subscribe  0s   registry operation failed
+Result: FAIL
+`
+	if buf.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if report.Passed() {
+		t.Error("Passed() = true, want false")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+var (
+	// ErrInvalidSnapshot is returned when a snapshot cannot be restored as requested.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	// ErrSnapshotRestoreDisabled is returned when restoring snapshots is not allowed.
+	ErrSnapshotRestoreDisabled = errors.New("snapshot restore is disabled")
+)
+
+// SnapshotConfig configures the export and restore of registry snapshots.
+type SnapshotConfig struct {
+	// AllowRestore enables restoring snapshots into this registry. Keep it off in production.
+	AllowRestore bool `yaml:"allowRestore"`
+}
+
+// snapshotRepository defines the repository operations that read and write snapshots.
+type snapshotRepository interface {
+	Snapshot(ctx context.Context) (*model.RegistrySnapshot, error)
+	RestoreSnapshot(ctx context.Context, snap *model.RegistrySnapshot) (*model.SnapshotRestoreReport, error)
+}
+
+// snapshotService exports the state of the registry and restores it into another
+// environment, so that pre-production environments can be tested with realistic data.
+type snapshotService struct {
+	repo         snapshotRepository
+	allowRestore bool
+	now          func() time.Time
+}
+
+// NewSnapshotService creates a new snapshotService. A nil config uses the defaults.
+func NewSnapshotService(repo snapshotRepository, cfg *SnapshotConfig) (*snapshotService, error) {
+	if repo == nil {
+		slog.Error("NewSnapshotService: snapshotRepository cannot be nil")
+		return nil, errors.New("snapshotRepository cannot be nil")
+	}
+	if cfg == nil {
+		cfg = &SnapshotConfig{}
+	}
+	return &snapshotService{repo: repo, allowRestore: cfg.AllowRestore, now: time.Now}, nil
+}
+
+// Export returns a consistent snapshot of the subscriptions and operations of the registry.
+func (s *snapshotService) Export(ctx context.Context) (*model.RegistrySnapshot, error) {
+	takenAt := s.now().UTC()
+	snap, err := s.repo.Snapshot(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "SnapshotService: Failed to take snapshot", "error", err)
+		return nil, fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	snap.TakenAt = takenAt
+	slog.InfoContext(ctx, "SnapshotService: Took snapshot", "subscriptions", len(snap.Subscriptions), "operations", len(snap.Operations))
+	return snap, nil
+}
+
+// Restore writes a snapshot into the registry, after renaming its subscriber IDs and
+// prefixing its operation IDs as requested. Subscriptions and operations with the same
+// keys are overwritten, so restoring the same snapshot twice has no further effect.
+func (s *snapshotService) Restore(ctx context.Context, req *model.SnapshotRestoreRequest) (*model.SnapshotRestoreReport, error) {
+	if !s.allowRestore {
+		return nil, ErrSnapshotRestoreDisabled
+	}
+	if req == nil {
+		return nil, fmt.Errorf("%w: request cannot be nil", ErrInvalidSnapshot)
+	}
+	snap, err := remapSnapshot(&req.Snapshot, req.SubscriberIDs, req.OperationIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.repo.RestoreSnapshot(ctx, snap)
+	if err != nil {
+		slog.ErrorContext(ctx, "SnapshotService: Failed to restore snapshot", "error", err)
+		return nil, fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	slog.InfoContext(ctx, "SnapshotService: Restored snapshot", "taken_at", req.Snapshot.TakenAt, "subscriptions", report.Subscriptions, "operations", report.Operations)
+	return report, nil
+}
+
+// remapSnapshot returns a copy of snap with its subscriber IDs renamed by ids and its
+// operation IDs prefixed with opPrefix. The subscriber ID in the request and result of
+// each operation is renamed too.
+func remapSnapshot(snap *model.RegistrySnapshot, ids map[string]string, opPrefix string) (*model.RegistrySnapshot, error) {
+	for from, to := range ids {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("%w: subscriber ID mapping %q to %q must not be empty", ErrInvalidSnapshot, from, to)
+		}
+	}
+	out := &model.RegistrySnapshot{
+		TakenAt:       snap.TakenAt,
+		Subscriptions: make([]model.Subscription, len(snap.Subscriptions)),
+		Operations:    make([]model.LRO, len(snap.Operations)),
+	}
+	for i, sub := range snap.Subscriptions {
+		if sub.SubscriberID == "" || sub.KeyID == "" {
+			return nil, fmt.Errorf("%w: subscription %d has no subscriber_id or key_id", ErrInvalidSnapshot, i)
+		}
+		if to, ok := ids[sub.SubscriberID]; ok {
+			sub.SubscriberID = to
+		}
+		sub.Nonce = ""
+		out.Subscriptions[i] = sub
+	}
+	for i, lro := range snap.Operations {
+		if lro.OperationID == "" || lro.Type == "" || lro.RequestJSON == nil {
+			return nil, fmt.Errorf("%w: operation %d has no operation_id, type or request_json", ErrInvalidSnapshot, i)
+		}
+		lro.OperationID = opPrefix + lro.OperationID
+		lro.RequestJSON = remapSubscriberID(lro.RequestJSON, ids)
+		lro.ResultJSON = remapSubscriberID(lro.ResultJSON, ids)
+		out.Operations[i] = lro
+	}
+	return out, nil
+}
+
+// remapSubscriberID renames the top-level subscriber_id of a JSON object by ids. Documents
+// without a mapped subscriber_id are returned unchanged.
+func remapSubscriberID(doc json.RawMessage, ids map[string]string) json.RawMessage {
+	if len(doc) == 0 || len(ids) == 0 {
+		return doc
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		// Not an object, so it has no subscriber_id.
+		return doc
+	}
+	var id string
+	if raw, ok := fields["subscriber_id"]; !ok || json.Unmarshal(raw, &id) != nil {
+		return doc
+	}
+	to, ok := ids[id]
+	if !ok {
+		return doc
+	}
+	// Neither can fail: to is a string and fields holds valid JSON.
+	fields["subscriber_id"], _ = json.Marshal(to)
+	remapped, _ := json.Marshal(fields)
+	return remapped
+}
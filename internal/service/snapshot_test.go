@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockSnapshotRepository is a mock implementation of snapshotRepository.
+type mockSnapshotRepository struct {
+	snap       *model.RegistrySnapshot
+	err        error
+	restored   *model.RegistrySnapshot
+	restoreErr error
+}
+
+func (m *mockSnapshotRepository) Snapshot(ctx context.Context) (*model.RegistrySnapshot, error) {
+	return m.snap, m.err
+}
+
+func (m *mockSnapshotRepository) RestoreSnapshot(ctx context.Context, snap *model.RegistrySnapshot) (*model.SnapshotRestoreReport, error) {
+	m.restored = snap
+	if m.restoreErr != nil {
+		return nil, m.restoreErr
+	}
+	return &model.SnapshotRestoreReport{Subscriptions: len(snap.Subscriptions), Operations: len(snap.Operations)}, nil
+}
+
+func TestNewSnapshotService(t *testing.T) {
+	if _, err := NewSnapshotService(nil, nil); err == nil {
+		t.Error("NewSnapshotService(nil) error = nil, want error")
+	}
+	s, err := NewSnapshotService(&mockSnapshotRepository{}, nil)
+	if err != nil {
+		t.Fatalf("NewSnapshotService() error = %v", err)
+	}
+	if s.allowRestore {
+		t.Error("allowRestore = true by default, want false")
+	}
+}
+
+func TestSnapshotService_Export(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		repo := &mockSnapshotRepository{snap: &model.RegistrySnapshot{
+			Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "np1"}, KeyID: "key1"}},
+			Operations:    []model.LRO{},
+		}}
+		s, _ := NewSnapshotService(repo, nil)
+		s.now = func() time.Time { return now }
+
+		got, err := s.Export(context.Background())
+		if err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if !got.TakenAt.Equal(now) || len(got.Subscriptions) != 1 {
+			t.Errorf("Export() = %+v, want snapshot taken at %v with 1 subscription", got, now)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repoErr := errors.New("db down")
+		s, _ := NewSnapshotService(&mockSnapshotRepository{err: repoErr}, nil)
+		if _, err := s.Export(context.Background()); !errors.Is(err, repoErr) {
+			t.Errorf("Export() error = %v, want %v", err, repoErr)
+		}
+	})
+}
+
+func TestSnapshotService_Restore(t *testing.T) {
+	snap := model.RegistrySnapshot{
+		Subscriptions: []model.Subscription{
+			{Subscriber: model.Subscriber{SubscriberID: "np1.prod"}, KeyID: "key1", Nonce: "n1"},
+			{Subscriber: model.Subscriber{SubscriberID: "np2.prod"}, KeyID: "key2"},
+		},
+		Operations: []model.LRO{
+			{OperationID: "op1", Type: model.OperationTypeCreateSubscription, RequestJSON: json.RawMessage(`{"subscriber_id":"np1.prod","message_id":"op1"}`), ResultJSON: json.RawMessage(`{"subscriber_id":"np1.prod"}`)},
+			{OperationID: "op2", Type: model.OperationTypeUpdateSubscription, RequestJSON: json.RawMessage(`{"subscriber_id":"np2.prod"}`), ResultJSON: json.RawMessage(`"done"`)},
+		},
+	}
+
+	repoErr := errors.New("db down")
+	tests := []struct {
+		name       string
+		cfg        *SnapshotConfig
+		req        *model.SnapshotRestoreRequest
+		restoreErr error
+		want       *model.RegistrySnapshot
+		wantErr    error
+	}{
+		{
+			name: "unchanged",
+			cfg:  &SnapshotConfig{AllowRestore: true},
+			req:  &model.SnapshotRestoreRequest{Snapshot: snap},
+			want: &model.RegistrySnapshot{
+				Subscriptions: []model.Subscription{
+					{Subscriber: model.Subscriber{SubscriberID: "np1.prod"}, KeyID: "key1"},
+					{Subscriber: model.Subscriber{SubscriberID: "np2.prod"}, KeyID: "key2"},
+				},
+				Operations: snap.Operations,
+			},
+		},
+		{
+			name: "remapped",
+			cfg:  &SnapshotConfig{AllowRestore: true},
+			req:  &model.SnapshotRestoreRequest{Snapshot: snap, SubscriberIDs: map[string]string{"np1.prod": "np1.staging"}, OperationIDPrefix: "stg-"},
+			want: &model.RegistrySnapshot{
+				Subscriptions: []model.Subscription{
+					{Subscriber: model.Subscriber{SubscriberID: "np1.staging"}, KeyID: "key1"},
+					{Subscriber: model.Subscriber{SubscriberID: "np2.prod"}, KeyID: "key2"},
+				},
+				Operations: []model.LRO{
+					{OperationID: "stg-op1", Type: model.OperationTypeCreateSubscription, RequestJSON: json.RawMessage(`{"message_id":"op1","subscriber_id":"np1.staging"}`), ResultJSON: json.RawMessage(`{"subscriber_id":"np1.staging"}`)},
+					{OperationID: "stg-op2", Type: model.OperationTypeUpdateSubscription, RequestJSON: json.RawMessage(`{"subscriber_id":"np2.prod"}`), ResultJSON: json.RawMessage(`"done"`)},
+				},
+			},
+		},
+		{
+			name:    "restore disabled",
+			req:     &model.SnapshotRestoreRequest{Snapshot: snap},
+			wantErr: ErrSnapshotRestoreDisabled,
+		},
+		{
+			name:    "empty mapping",
+			cfg:     &SnapshotConfig{AllowRestore: true},
+			req:     &model.SnapshotRestoreRequest{Snapshot: snap, SubscriberIDs: map[string]string{"np1.prod": ""}},
+			wantErr: ErrInvalidSnapshot,
+		},
+		{
+			name:    "subscription without key",
+			cfg:     &SnapshotConfig{AllowRestore: true},
+			req:     &model.SnapshotRestoreRequest{Snapshot: model.RegistrySnapshot{Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "np1"}}}}},
+			wantErr: ErrInvalidSnapshot,
+		},
+		{
+			name:    "operation without request",
+			cfg:     &SnapshotConfig{AllowRestore: true},
+			req:     &model.SnapshotRestoreRequest{Snapshot: model.RegistrySnapshot{Operations: []model.LRO{{OperationID: "op1", Type: model.OperationTypeCreateSubscription}}}},
+			wantErr: ErrInvalidSnapshot,
+		},
+		{
+			name:       "repository error",
+			cfg:        &SnapshotConfig{AllowRestore: true},
+			req:        &model.SnapshotRestoreRequest{Snapshot: snap},
+			restoreErr: repoErr,
+			wantErr:    repoErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockSnapshotRepository{restoreErr: tt.restoreErr}
+			s, _ := NewSnapshotService(repo, tt.cfg)
+
+			report, err := s.Restore(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Restore() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, repo.restored); diff != "" {
+				t.Errorf("restored snapshot mismatch (-want +got):\n%s", diff)
+			}
+			if report.Subscriptions != len(tt.want.Subscriptions) || report.Operations != len(tt.want.Operations) {
+				t.Errorf("Restore() report = %+v", report)
+			}
+		})
+	}
+}
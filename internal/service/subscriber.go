@@ -22,6 +22,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	"github.com/google/uuid"
 
@@ -31,14 +32,14 @@ import (
 // Error definitions for the subscriber service
 var (
 	ErrMissingSubscriberID     = errors.New("subscriber_id is required")
-	ErrMissingDomain           = errors.New("domain is required")
-	ErrMissingType             = errors.New("type is required")
 	ErrMissingOperationID      = errors.New("operation_id is required")
+	ErrMissingKeyID            = errors.New("key_id is required")
 	ErrLRONotFound             = errors.New("lro not found")
 	ErrLRONotApproved          = errors.New("lro status is not approved")
 	ErrKeyGenerationFailed     = errors.New("key generation failed")
 	ErrKeyFetchFailed          = errors.New("key fetch failed")
 	ErrKeyStoreFailed          = errors.New("key store failed")
+	ErrKeyRecoveryFailed       = errors.New("key recovery failed")
 	ErrRegistryOperationFailed = errors.New("registry operation failed")
 	ErrSigningFailed           = errors.New("signing failed")
 )
@@ -79,6 +80,16 @@ type subscriberService struct {
 	authGen  authGen
 	regID    string
 	regKeyID string // Public encryption key of the Registry, used as sender key in decryption
+
+	state             subscriberState
+	registryHealth    healthChecker
+	publisherHealth   healthChecker
+	rotator           *keyRotator
+	rotatedPub        keyRotatedPublisher
+	undeleteTokenHash []byte
+	recorder          *challengeRecorder
+	watcher           *keyWatcher
+	now               func() time.Time
 }
 
 // NewSubscriberService creates a new subscriberService.
@@ -119,22 +130,10 @@ func NewSubscriberService(
 		regID:    regID,
 		regKeyID: regKeyID,
 		authGen:  authGen,
+		now:      time.Now,
 	}, nil
 }
 
-func (s *subscriberService) validateSubscriptionRequest(req *model.NpSubscriptionRequest) error {
-	if req.SubscriberID == "" {
-		return ErrMissingSubscriberID
-	}
-	if req.Domain == "" {
-		return ErrMissingDomain
-	}
-	if req.Type == "" {
-		return ErrMissingType
-	}
-	return nil
-}
-
 func (s *subscriberService) keySet(ctx context.Context, req *model.NpSubscriptionRequest) (*becknmodel.Keyset, error) {
 	var keys *becknmodel.Keyset
 	var err error
@@ -183,7 +182,7 @@ func subscriptionRequest(npReq *model.NpSubscriptionRequest, keys *becknmodel.Ke
 
 // CreateSubscription handles the logic for creating a new subscription.
 func (s *subscriberService) CreateSubscription(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
-	if err := s.validateSubscriptionRequest(req); err != nil {
+	if err := req.Validate(); err != nil {
 		return "", err
 	}
 	if req.MessageID == "" {
@@ -201,11 +200,13 @@ func (s *subscriberService) CreateSubscription(ctx context.Context, req *model.N
 		return "", fmt.Errorf("%w: %v", ErrKeyStoreFailed, err)
 	}
 
-	resp, err := s.registry.CreateSubscription(ctx, subscriptionRequest(req, keys))
+	sreq := subscriptionRequest(req, keys)
+	resp, err := s.registry.CreateSubscription(ctx, sreq)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Registry CreateSubscription failed", "error", err)
 		return "", fmt.Errorf("%w: %v", ErrRegistryOperationFailed, err)
 	}
+	s.state.recordSubscription(sreq, resp.MessageID, s.now())
 
 	slog.InfoContext(ctx, "SubscriberService: CreateSubscription successful", "message_id", resp.MessageID, "status", resp.Status)
 	return resp.MessageID, nil
@@ -213,7 +214,7 @@ func (s *subscriberService) CreateSubscription(ctx context.Context, req *model.N
 
 // UpdateSubscription handles the logic for updating an existing subscription.
 func (s *subscriberService) UpdateSubscription(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
-	if err := s.validateSubscriptionRequest(req); err != nil {
+	if err := req.Validate(); err != nil {
 		return "", err
 	}
 	if req.MessageID == "" {
@@ -241,6 +242,7 @@ func (s *subscriberService) UpdateSubscription(ctx context.Context, req *model.N
 		slog.ErrorContext(ctx, "SubscriberService: Registry UpdateSubscription failed", "error", err)
 		return "", fmt.Errorf("%w: %v", ErrRegistryOperationFailed, err)
 	}
+	s.state.recordSubscription(sreq, resp.MessageID, s.now())
 	slog.InfoContext(ctx, "SubscriberService: UpdateSubscription successful", "message_id", resp.MessageID, "status", resp.Status)
 	return resp.MessageID, nil
 }
@@ -261,6 +263,7 @@ func (s *subscriberService) UpdateStatus(ctx context.Context, operationID string
 		slog.WarnContext(ctx, "SubscriberService: LRO not found for status update", "message_id", operationID)
 		return "", ErrLRONotFound
 	}
+	s.state.recordOperationStatus(operationID, lro.Status, s.now())
 
 	if lro.Status != model.LROStatusApproved {
 		slog.WarnContext(ctx, "SubscriberService: LRO status is not approved", "message_id", operationID, "status", lro.Status)
@@ -272,6 +275,10 @@ func (s *subscriberService) UpdateStatus(ctx context.Context, operationID string
 		slog.ErrorContext(ctx, "SubscriberService: Failed to fetch keyset for status update", "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyFetchFailed, err)
 	}
+	var previousKeyID string
+	if s.rotatedPub != nil {
+		previousKeyID = s.activeKeyID(ctx, keys.SubscriberID)
+	}
 	if err := s.keyMgr.InsertKeyset(ctx, keys.SubscriberID, keys); err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to insert keyset after update status", "subscriber_id", keys.SubscriberID, "key_id", keys.UniqueKeyID, "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyStoreFailed, err)
@@ -279,22 +286,38 @@ func (s *subscriberService) UpdateStatus(ctx context.Context, operationID string
 	if err := s.keyMgr.DeleteKeyset(ctx, operationID); err != nil {
 		slog.WarnContext(ctx, "SubscriberService: Failed to delete keyset after update status", "message_id", operationID, "error", err)
 	}
+	s.publishKeyRotated(ctx, keys, previousKeyID)
 	slog.InfoContext(ctx, "SubscriberService: LRO status approved", "message_id", operationID, "status", lro.Status)
 	return lro.Status, nil
 }
 
+// UndeleteKeyset recovers a soft deleted keyset before its recovery window expires.
+func (s *subscriberService) UndeleteKeyset(ctx context.Context, keyID string) error {
+	if keyID == "" {
+		return ErrMissingKeyID
+	}
+	if err := keymanager.Undelete(ctx, s.keyMgr, keyID); err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to undelete keyset", "key_id", keyID, "error", err)
+		return fmt.Errorf("%w: %w", ErrKeyRecoveryFailed, err)
+	}
+	slog.InfoContext(ctx, "SubscriberService: Keyset recovered", "key_id", keyID)
+	return nil
+}
+
 // OnSubscribe handles an incoming on_subscribe request from the Registry.
-// It decrypts the challenge, publishes an event, and returns the decrypted answer.
-func (s *subscriberService) OnSubscribe(ctx context.Context, req *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
+// It decrypts the challenge, publishes an event, and returns the decrypted answer signed with
+// the NP's signing key, so that the Registry can check the key against the subscription request.
+func (s *subscriberService) OnSubscribe(ctx context.Context, req *model.OnSubscribeRequest) (resp *model.OnSubscribeResponse, err error) {
 	slog.InfoContext(ctx, "SubscriberService: Received OnSubscribe request", "message_id", req.MessageID)
+	capture := s.recorder.capture(req, s.now())
+	defer func() {
+		s.state.recordChallenge(req.MessageID, s.now(), err)
+		s.recorder.add(capture, resp, err, s.now())
+	}()
 
-	if req.MessageID == "" {
-		slog.ErrorContext(ctx, "SubscriberService: MessageID is required for OnSubscribe")
-		return nil, errors.New("message_id is required")
-	}
-	if req.Challenge == "" {
-		slog.ErrorContext(ctx, "SubscriberService: Challenge is required for OnSubscribe")
-		return nil, errors.New("challenge is required")
+	if err := req.Validate(); err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Invalid OnSubscribe request", "error", err)
+		return nil, err
 	}
 	// Get subscribers keyset using the MessageID (which is the operation_id for the subscription LRO)
 	// This keyset should contain the NP's private encryption key.
@@ -314,16 +337,26 @@ func (s *subscriberService) OnSubscribe(ctx context.Context, req *model.OnSubscr
 	_, regKey, err := s.keyMgr.LookupNPKeys(ctx, s.regID, s.regKeyID)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to lookup registry keys", "message_id", req.MessageID, "error", err)
-		return nil, fmt.Errorf("failed to lookup registry keys for message_id %s: %w", req.MessageID, err)
+		return nil, fmt.Errorf("failed to lookup registry keys for message_id %s: %w: %w", req.MessageID, err, ErrChallengeRegistryKey)
 	}
 	if regKey == "" {
-		slog.ErrorContext(ctx, "SubscriberService: Registry public key not found", "message_id", req.MessageID)
-		return nil, fmt.Errorf("registry public key not found for message_id %s", req.MessageID)
+		slog.ErrorContext(ctx, "SubscriberService: Registry public key not found", "message_id", req.MessageID, "reg_key_id", s.regKeyID)
+		return nil, fmt.Errorf("registry public key not found for message_id %s: %w", req.MessageID, ErrChallengeRegistryKey)
 	}
+	capture.keys(keys, regKey)
 	decryptedAnswer, err := s.dec.Decrypt(ctx, req.Challenge, keys.EncrPrivate, regKey)
 	if err != nil {
-		slog.ErrorContext(ctx, "SubscriberService: Failed to decrypt challenge", "message_id", req.MessageID, "error", err)
-		return nil, fmt.Errorf("failed to decrypt challenge for message_id %s: %w", req.MessageID, err)
+		cause := challengeDecryptCause(req.Challenge, keys.EncrPrivate, regKey)
+		slog.ErrorContext(ctx, "SubscriberService: Failed to decrypt challenge", "message_id", req.MessageID, "reg_key_id", s.regKeyID, "cause", cause, "error", err)
+		return nil, fmt.Errorf("failed to decrypt challenge for message_id %s: %w: %w", req.MessageID, err, cause)
+	}
+	capture.answer(decryptedAnswer)
+	var signature string
+	if keys.SigningPrivate == "" {
+		slog.WarnContext(ctx, "SubscriberService: Signing private key not found, answering challenge without signature", "message_id", req.MessageID)
+	} else if signature, err = signChallenge(decryptedAnswer, keys.SigningPrivate); err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to sign challenge answer", "message_id", req.MessageID, "error", err)
+		return nil, fmt.Errorf("failed to sign challenge answer for message_id %s: %w", req.MessageID, err)
 	}
 
 	// Publish an OnSubscribeRecievedEvent
@@ -337,7 +370,7 @@ func (s *subscriberService) OnSubscribe(ctx context.Context, req *model.OnSubscr
 	}
 
 	// Respond with the decrypted answer
-	response := &model.OnSubscribeResponse{Answer: decryptedAnswer}
+	response := &model.OnSubscribeResponse{Answer: decryptedAnswer, Signature: signature}
 	slog.InfoContext(ctx, "SubscriberService: Successfully processed OnSubscribe request", "message_id", req.MessageID)
 	return response, nil
 }
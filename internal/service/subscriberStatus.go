@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// statusCheckTimeout bounds each dependency health check made for a status report.
+const statusCheckTimeout = 5 * time.Second
+
+// healthChecker checks that a dependency of the subscriber service is reachable.
+type healthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// subscriberState holds the latest subscription and challenge seen by the subscriber service.
+// It is kept in memory, so it is empty until the first request after a restart.
+type subscriberState struct {
+	mu           sync.Mutex
+	subscription *model.SubscriberSubscriptionStatus
+	keyset       *model.SubscriberKeysetStatus
+	challenge    *model.SubscriberChallengeStatus
+}
+
+// recordSubscription records a subscription request accepted by the registry.
+func (st *subscriberState) recordSubscription(req *model.SubscriptionRequest, operationID string, at time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.subscription = &model.SubscriberSubscriptionStatus{
+		SubscriberID: req.SubscriberID,
+		OperationID:  operationID,
+		Status:       model.LROStatusPending,
+		UpdatedAt:    at,
+	}
+	st.keyset = &model.SubscriberKeysetStatus{
+		KeyID:      req.KeyID,
+		ValidFrom:  req.ValidFrom,
+		ValidUntil: req.ValidUntil,
+	}
+}
+
+// recordOperationStatus records the status of an operation if it is the latest subscription request.
+func (st *subscriberState) recordOperationStatus(operationID string, status model.LROStatus, at time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.subscription == nil || st.subscription.OperationID != operationID {
+		return
+	}
+	st.subscription.Status = status
+	st.subscription.UpdatedAt = at
+}
+
+// recordChallenge records the outcome of an on_subscribe challenge.
+func (st *subscriberState) recordChallenge(operationID string, at time.Time, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.challenge = &model.SubscriberChallengeStatus{OperationID: operationID, ReceivedAt: at, Success: err == nil}
+	if err != nil {
+		st.challenge.Error = err.Error()
+	}
+}
+
+// snapshot returns copies of the recorded state.
+func (st *subscriberState) snapshot() (*model.SubscriberSubscriptionStatus, *model.SubscriberKeysetStatus, *model.SubscriberChallengeStatus) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var sub *model.SubscriberSubscriptionStatus
+	var keys *model.SubscriberKeysetStatus
+	var ch *model.SubscriberChallengeStatus
+	if st.subscription != nil {
+		c := *st.subscription
+		sub = &c
+	}
+	if st.keyset != nil {
+		c := *st.keyset
+		keys = &c
+	}
+	if st.challenge != nil {
+		c := *st.challenge
+		ch = &c
+	}
+	return sub, keys, ch
+}
+
+// SetHealthCheckers sets the checks used to report registry and event publisher health in Status.
+// A nil checker is reported as UNKNOWN.
+func (s *subscriberService) SetHealthCheckers(registry, publisher healthChecker) {
+	s.registryHealth = registry
+	s.publisherHealth = publisher
+}
+
+// Status reports the latest subscription, its keyset, the last challenge
+// received from the registry and the health of the service's dependencies.
+func (s *subscriberService) Status(ctx context.Context) *model.SubscriberStatus {
+	sub, keys, ch := s.state.snapshot()
+	status := &model.SubscriberStatus{Subscription: sub, Keyset: keys, LastChallenge: ch}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		status.Registry = s.checkHealth(ctx, "registry", s.registryHealth)
+	}()
+	go func() {
+		defer wg.Done()
+		status.EventPublisher = s.checkHealth(ctx, "event publisher", s.publisherHealth)
+	}()
+	if sub != nil && keys != nil {
+		s.checkKeyset(ctx, sub, keys)
+	}
+	wg.Wait()
+
+	status.Healthy = status.Registry.Status != model.HealthStatusError && status.EventPublisher.Status != model.HealthStatusError
+	return status
+}
+
+// checkKeyset verifies that the key manager still holds the keyset of the
+// subscription and that the current time is within its validity.
+// Keysets are stored under the operation ID until the subscription is approved
+// and under the subscriber ID afterwards.
+func (s *subscriberService) checkKeyset(ctx context.Context, sub *model.SubscriberSubscriptionStatus, keys *model.SubscriberKeysetStatus) {
+	keyID := sub.OperationID
+	if sub.Status == model.LROStatusApproved {
+		keyID = sub.SubscriberID
+	}
+	ks, err := s.keyMgr.Keyset(ctx, keyID)
+	if err != nil {
+		slog.WarnContext(ctx, "SubscriberService: Failed to fetch keyset for status", "key_id", keyID, "error", err)
+		keys.Error = err.Error()
+		return
+	}
+	if ks == nil || ks.UniqueKeyID != keys.KeyID {
+		keys.Error = "keyset does not match the latest subscription"
+		return
+	}
+	now := s.now()
+	keys.Valid = !now.Before(keys.ValidFrom) && now.Before(keys.ValidUntil)
+}
+
+// checkHealth runs a health check with a timeout and reports its result.
+func (s *subscriberService) checkHealth(ctx context.Context, name string, hc healthChecker) model.ComponentHealth {
+	if hc == nil {
+		return model.ComponentHealth{Status: model.HealthStatusUnknown}
+	}
+	ctx, cancel := context.WithTimeout(ctx, statusCheckTimeout)
+	defer cancel()
+	start := s.now()
+	err := hc.Health(ctx)
+	h := model.ComponentHealth{Status: model.HealthStatusOK, LatencyMS: s.now().Sub(start).Milliseconds()}
+	if err != nil {
+		slog.WarnContext(ctx, "SubscriberService: Health check failed", "component", name, "error", err)
+		h.Status = model.HealthStatusError
+		h.Error = err.Error()
+	}
+	return h
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	becknmodel "github.com/beckn/beckn-onix/pkg/model"
+)
+
+// mockHealthChecker is a mock for healthChecker.
+type mockHealthChecker struct {
+	err error
+}
+
+func (m *mockHealthChecker) Health(ctx context.Context) error {
+	return m.err
+}
+
+func TestSubscriberService_Status_NoState(t *testing.T) {
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+
+	got := svc.Status(context.Background())
+
+	want := &model.SubscriberStatus{
+		Healthy:        true,
+		Registry:       model.ComponentHealth{Status: model.HealthStatusUnknown},
+		EventPublisher: model.ComponentHealth{Status: model.HealthStatusUnknown},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Status() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriberService_Status_Subscription(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Add(time.Minute)
+	req := &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP}}
+	mockReg := &mockRegistryClient{
+		createSubResp: &model.SubscriptionResponse{MessageID: "op1", Status: "UNDER_SUBSCRIPTION"},
+		getOpResp:     &model.LRO{Status: model.LROStatusApproved},
+	}
+	mockKM := &mockKeyManager{keysetToReturn: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "generated-key"}}
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	svc.now = func() time.Time { return now }
+	svc.SetHealthCheckers(&mockHealthChecker{}, &mockHealthChecker{})
+
+	if _, err := svc.CreateSubscription(ctx, req); err != nil {
+		t.Fatalf("CreateSubscription() unexpected error: %v", err)
+	}
+	got := svc.Status(ctx)
+	if !got.Healthy {
+		t.Errorf("Status() Healthy = false, want true")
+	}
+	wantSub := &model.SubscriberSubscriptionStatus{SubscriberID: "sub1", OperationID: "op1", Status: model.LROStatusPending, UpdatedAt: now}
+	if diff := cmp.Diff(wantSub, got.Subscription); diff != "" {
+		t.Errorf("Status() Subscription mismatch (-want +got):\n%s", diff)
+	}
+	if got.Keyset == nil || got.Keyset.KeyID != "generated-key" || !got.Keyset.Valid || got.Keyset.Error != "" {
+		t.Errorf("Status() Keyset = %+v, want valid keyset generated-key", got.Keyset)
+	}
+	if got.Registry.Status != model.HealthStatusOK || got.EventPublisher.Status != model.HealthStatusOK {
+		t.Errorf("Status() Registry = %v, EventPublisher = %v, want OK", got.Registry.Status, got.EventPublisher.Status)
+	}
+
+	if _, err := svc.UpdateStatus(ctx, "op1"); err != nil {
+		t.Fatalf("UpdateStatus() unexpected error: %v", err)
+	}
+	if got := svc.Status(ctx); got.Subscription.Status != model.LROStatusApproved {
+		t.Errorf("Status() Subscription.Status = %q, want %q", got.Subscription.Status, model.LROStatusApproved)
+	}
+
+	// Status updates for other operations do not change the latest subscription.
+	if _, err := svc.UpdateStatus(ctx, "op2"); err != nil {
+		t.Fatalf("UpdateStatus() unexpected error: %v", err)
+	}
+	if got := svc.Status(ctx); got.Subscription.OperationID != "op1" {
+		t.Errorf("Status() Subscription.OperationID = %q, want %q", got.Subscription.OperationID, "op1")
+	}
+}
+
+func TestSubscriberService_Status_Keyset(t *testing.T) {
+	tests := []struct {
+		name      string
+		km        *mockKeyManager
+		now       time.Time
+		wantValid bool
+		wantErr   string
+	}{
+		{
+			name:      "keyset valid",
+			km:        &mockKeyManager{keysetToReturn: &becknmodel.Keyset{UniqueKeyID: "generated-key"}},
+			now:       time.Now().Add(time.Hour),
+			wantValid: true,
+		},
+		{
+			name: "keyset expired",
+			km:   &mockKeyManager{keysetToReturn: &becknmodel.Keyset{UniqueKeyID: "generated-key"}},
+			now:  time.Now().AddDate(101, 0, 0),
+		},
+		{
+			name:    "keyset fetch fails",
+			km:      &mockKeyManager{keysetErr: errors.New("secret not found")},
+			now:     time.Now(),
+			wantErr: "secret not found",
+		},
+		{
+			name:    "keyset replaced",
+			km:      &mockKeyManager{keysetToReturn: &becknmodel.Keyset{UniqueKeyID: "other-key"}},
+			now:     time.Now(),
+			wantErr: "keyset does not match the latest subscription",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			req := &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP}}
+			mockReg := &mockRegistryClient{createSubResp: &model.SubscriptionResponse{MessageID: "op1"}}
+			svc, _ := NewSubscriberService(mockReg, tc.km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			if _, err := svc.CreateSubscription(ctx, req); err != nil {
+				t.Fatalf("CreateSubscription() unexpected error: %v", err)
+			}
+			svc.now = func() time.Time { return tc.now }
+
+			got := svc.Status(ctx).Keyset
+			if got.Valid != tc.wantValid {
+				t.Errorf("Status() Keyset.Valid = %v, want %v", got.Valid, tc.wantValid)
+			}
+			if got.Error != tc.wantErr {
+				t.Errorf("Status() Keyset.Error = %q, want %q", got.Error, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_Status_Unhealthy(t *testing.T) {
+	tests := []struct {
+		name          string
+		registry      healthChecker
+		publisher     healthChecker
+		wantRegistry  model.HealthStatus
+		wantPublisher model.HealthStatus
+	}{
+		{
+			name:          "registry unreachable",
+			registry:      &mockHealthChecker{err: errors.New("connection refused")},
+			publisher:     &mockHealthChecker{},
+			wantRegistry:  model.HealthStatusError,
+			wantPublisher: model.HealthStatusOK,
+		},
+		{
+			name:          "topic missing",
+			registry:      &mockHealthChecker{},
+			publisher:     &mockHealthChecker{err: errors.New("topic not found")},
+			wantRegistry:  model.HealthStatusOK,
+			wantPublisher: model.HealthStatusError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			svc.SetHealthCheckers(tc.registry, tc.publisher)
+
+			got := svc.Status(context.Background())
+			if got.Healthy {
+				t.Errorf("Status() Healthy = true, want false")
+			}
+			if got.Registry.Status != tc.wantRegistry {
+				t.Errorf("Status() Registry.Status = %q, want %q", got.Registry.Status, tc.wantRegistry)
+			}
+			if got.EventPublisher.Status != tc.wantPublisher {
+				t.Errorf("Status() EventPublisher.Status = %q, want %q", got.EventPublisher.Status, tc.wantPublisher)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_Status_LastChallenge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockKM := &mockKeyManager{
+		keysetToReturn:   &becknmodel.Keyset{EncrPrivate: "np-private-key"},
+		lookupNPKeysEncr: "reg-public-key",
+	}
+	mockDec := &mockDecrypter{decryptedData: "decrypted-answer"}
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, mockDec, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.OnSubscribe(ctx, &model.OnSubscribeRequest{MessageID: "op1", Challenge: "challenge"}); err != nil {
+		t.Fatalf("OnSubscribe() unexpected error: %v", err)
+	}
+	want := &model.SubscriberChallengeStatus{OperationID: "op1", ReceivedAt: now, Success: true}
+	if diff := cmp.Diff(want, svc.Status(ctx).LastChallenge); diff != "" {
+		t.Errorf("Status() LastChallenge mismatch (-want +got):\n%s", diff)
+	}
+
+	mockDec.decryptErr = errors.New("bad ciphertext")
+	if _, err := svc.OnSubscribe(ctx, &model.OnSubscribeRequest{MessageID: "op2", Challenge: "challenge"}); err == nil {
+		t.Fatal("OnSubscribe() expected error, got nil")
+	}
+	got := svc.Status(ctx).LastChallenge
+	if got.OperationID != "op2" || got.Success || got.Error == "" {
+		t.Errorf("Status() LastChallenge = %+v, want failed challenge for op2", got)
+	}
+}
@@ -16,11 +16,13 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keymanager"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	becknmodel "github.com/beckn/beckn-onix/pkg/model"
@@ -198,17 +200,17 @@ func TestSubscriberService_CreateSubscription_Error(t *testing.T) {
 		{
 			name:    "validation error - missing subscriber ID",
 			req:     &model.NpSubscriptionRequest{Subscriber: model.Subscriber{Domain: "test.com", Type: model.RoleBAP}},
-			wantErr: ErrMissingSubscriberID,
+			wantErr: &model.ValidationError{Fields: []model.FieldError{{Field: "subscriber_id", Message: "is required"}}},
 		},
 		{
 			name:    "validation error - missing domain",
 			req:     &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Type: model.RoleBAP}},
-			wantErr: ErrMissingDomain,
+			wantErr: &model.ValidationError{Fields: []model.FieldError{{Field: "domain", Message: "is required"}}},
 		},
 		{
 			name:    "validation error - missing type",
 			req:     &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com"}},
-			wantErr: ErrMissingType,
+			wantErr: &model.ValidationError{Fields: []model.FieldError{{Field: "type", Message: "is required"}}},
 		},
 		{
 			name: "keyManager.Keyset error (fetching existing)",
@@ -306,7 +308,7 @@ func TestSubscriberService_UpdateSubscription_Error(t *testing.T) {
 		{
 			name:    "validation error",
 			req:     &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1"}},
-			wantErr: ErrMissingDomain,
+			wantErr: &model.ValidationError{Fields: []model.FieldError{{Field: "domain", Message: "is required"}}},
 		},
 		{
 			name:    "keySet error",
@@ -485,6 +487,23 @@ func TestSubscriberService_OnSubscribe_Success(t *testing.T) {
 	}
 }
 
+func TestSubscriberService_OnSubscribe_Signed(t *testing.T) {
+	pub, priv := testSigningKeys(t)
+	mockKM := &mockKeyManager{
+		keysetToReturn:   &becknmodel.Keyset{EncrPrivate: "np-private-key", SigningPrivate: priv},
+		lookupNPKeysEncr: "reg-public-key",
+	}
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, &mockDecrypter{decryptedData: "decrypted-answer"}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+
+	resp, err := svc.OnSubscribe(context.Background(), &model.OnSubscribeRequest{MessageID: "msg1", Challenge: "encrypted-challenge"})
+	if err != nil {
+		t.Fatalf("OnSubscribe() unexpected error: %v", err)
+	}
+	if err := verifyChallengeSignature(resp.Answer, resp.Signature, pub); err != nil {
+		t.Errorf("OnSubscribe() signature does not verify: %v", err)
+	}
+}
+
 func TestSubscriberService_OnSubscribe_Error(t *testing.T) {
 	ctx := context.Background()
 	baseReq := &model.OnSubscribeRequest{MessageID: "msg1", Challenge: "encrypted-challenge"}
@@ -538,6 +557,13 @@ func TestSubscriberService_OnSubscribe_Error(t *testing.T) {
 			mockDec:    &mockDecrypter{decryptErr: errors.New("decrypt failed")},
 			wantErrMsg: "failed to decrypt challenge for message_id msg1: decrypt failed",
 		},
+		{
+			name:       "Invalid signing private key",
+			req:        baseReq,
+			mockKM:     &mockKeyManager{keysetToReturn: &becknmodel.Keyset{EncrPrivate: "priv", SigningPrivate: "bad-key"}, lookupNPKeysEncr: "pub"},
+			mockDec:    &mockDecrypter{decryptedData: "ok"},
+			wantErrMsg: "failed to sign challenge answer for message_id msg1",
+		},
 		{
 			name:       "Event publish fails (should not return error)",
 			req:        baseReq,
@@ -579,3 +605,115 @@ func TestSubscriberService_OnSubscribe_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriberService_OnSubscribe_DecryptCause(t *testing.T) {
+	priv := testEncryptionKey(t, false)
+	pub := testEncryptionKey(t, true)
+
+	tests := []struct {
+		name      string
+		challenge string
+		mockKM    *mockKeyManager
+		want      error
+	}{
+		{
+			name:      "registry key lookup fails",
+			challenge: "encrypted-challenge",
+			mockKM:    &mockKeyManager{keysetToReturn: &becknmodel.Keyset{EncrPrivate: priv}, lookupNPKeysErr: errors.New("subscriber not found")},
+			want:      ErrChallengeRegistryKey,
+		},
+		{
+			name:      "registry key not found",
+			challenge: "encrypted-challenge",
+			mockKM:    &mockKeyManager{keysetToReturn: &becknmodel.Keyset{EncrPrivate: priv}},
+			want:      ErrChallengeRegistryKey,
+		},
+		{
+			name:      "challenge not base64",
+			challenge: "encrypted-challenge",
+			mockKM:    &mockKeyManager{keysetToReturn: &becknmodel.Keyset{EncrPrivate: priv}, lookupNPKeysEncr: pub},
+			want:      ErrChallengeEncoding,
+		},
+		{
+			name:      "key mismatch",
+			challenge: base64.StdEncoding.EncodeToString(make([]byte, 16)),
+			mockKM:    &mockKeyManager{keysetToReturn: &becknmodel.Keyset{EncrPrivate: priv}, lookupNPKeysEncr: pub},
+			want:      ErrChallengeKeyMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := NewSubscriberService(&mockRegistryClient{}, tt.mockKM, &mockDecrypter{decryptErr: errors.New("decrypt failed")}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+
+			_, err := svc.OnSubscribe(context.Background(), &model.OnSubscribeRequest{MessageID: "msg1", Challenge: tt.challenge})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("OnSubscribe() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// mockUndeleteKeyManager is a mockKeyManager that supports undelete.
+type mockUndeleteKeyManager struct {
+	mockKeyManager
+	undeleteErr   error
+	undeletedKeys []string
+}
+
+func (m *mockUndeleteKeyManager) UndeleteKeyset(ctx context.Context, keyID string) error {
+	m.undeletedKeys = append(m.undeletedKeys, keyID)
+	return m.undeleteErr
+}
+
+func TestSubscriberService_UndeleteKeyset(t *testing.T) {
+	km := &mockUndeleteKeyManager{}
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+
+	if err := svc.UndeleteKeyset(context.Background(), "sub1"); err != nil {
+		t.Fatalf("UndeleteKeyset() unexpected error: %v", err)
+	}
+	if len(km.undeletedKeys) != 1 || km.undeletedKeys[0] != "sub1" {
+		t.Errorf("UndeleteKeyset() undeleted keys = %v, want [sub1]", km.undeletedKeys)
+	}
+}
+
+func TestSubscriberService_UndeleteKeyset_Errors(t *testing.T) {
+	errBackend := errors.New("secret destroyed")
+	tests := []struct {
+		name    string
+		keyID   string
+		km      keyManager
+		wantErr []error
+	}{
+		{
+			name:    "missing key id",
+			km:      &mockUndeleteKeyManager{},
+			wantErr: []error{ErrMissingKeyID},
+		},
+		{
+			name:    "undelete not supported",
+			keyID:   "sub1",
+			km:      &mockKeyManager{},
+			wantErr: []error{ErrKeyRecoveryFailed, keymanager.ErrUndeleteNotSupported},
+		},
+		{
+			name:    "undelete fails",
+			keyID:   "sub1",
+			km:      &mockUndeleteKeyManager{undeleteErr: errBackend},
+			wantErr: []error{ErrKeyRecoveryFailed, errBackend},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, _ := NewSubscriberService(&mockRegistryClient{}, tc.km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+			err := svc.UndeleteKeyset(context.Background(), tc.keyID)
+			for _, want := range tc.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("UndeleteKeyset() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
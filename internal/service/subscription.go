@@ -20,10 +20,18 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
+// maxLookupFilters is the maximum number of filters combined in a single lookup.
+const maxLookupFilters = 50
+
+// ErrInvalidLookup occurs if a lookup has no filters or more than maxLookupFilters.
+var ErrInvalidLookup = errors.New("invalid lookup")
+
 // lroCreator defines the interface for creating LROs.
 type lroCreator interface {
 	Create(ctx context.Context, lro *model.LRO) (*model.LRO, error)
@@ -33,6 +41,8 @@ type lroCreator interface {
 type subscriptionRepository interface {
 	GetSubscriberSigningKey(ctx context.Context, subscriberID string, domain string, subType model.Role, keyID string) (string, error)
 	Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
+	LookupAny(ctx context.Context, filters []*model.Subscription) ([]model.Subscription, error)
+	LookupChanges(ctx context.Context, since time.Time, filters []*model.Subscription) (*model.LookupChanges, error)
 }
 
 // subscriptionEventPublisher defines the interface for publishing subscription events.
@@ -42,10 +52,41 @@ type subscriptionEventPublisher interface {
 	PublishUpdateSubscriptionRequestEvent(ctx context.Context, req *model.SubscriptionRequest) (string, error)
 }
 
+// nonceValidator reserves the nonce of an incoming subscription request.
+type nonceValidator interface {
+	Reserve(ctx context.Context, req *model.SubscriptionRequest) error
+}
+
+// subscriberURLProber probes a subscriber's URL and attaches the result to its operation.
+type subscriberURLProber interface {
+	ProbeOperation(ctx context.Context, operationID, rawURL string)
+}
+
+// pendingQuotaChecker checks that a subscriber may start another operation.
+type pendingQuotaChecker interface {
+	Check(ctx context.Context, subscriberID string) error
+}
+
+// requestQuotaTaker counts a subscriber's operations against its request quota.
+type requestQuotaTaker interface {
+	TakeOperation(ctx context.Context, subscriberID string) error
+}
+
+// domainValidator checks a subscription request against the domain catalog.
+type domainValidator interface {
+	Validate(ctx context.Context, req *model.SubscriptionRequest) error
+}
+
 type subscriptionService struct {
 	lroCreator             lroCreator
 	subscriptionRepository subscriptionRepository
 	evPublisher            subscriptionEventPublisher
+	nonceValidator         nonceValidator
+	urlProber              subscriberURLProber
+	pendingQuota           pendingQuotaChecker
+	requestQuota           requestQuotaTaker
+	domains                domainValidator
+	validity               *ValidityPolicyConfig
 }
 
 // NewSubscriptionService creates a new subscriptionService.
@@ -65,6 +106,119 @@ func NewSubscriptionService(lroCreator lroCreator, subscriptionRepository subscr
 	return &subscriptionService{lroCreator: lroCreator, subscriptionRepository: subscriptionRepository, evPublisher: evPub}, nil
 }
 
+// SetNonceValidator enables single-use enforcement of subscription request nonces.
+func (s *subscriptionService) SetNonceValidator(v nonceValidator) {
+	s.nonceValidator = v
+}
+
+// SetURLProber enables probing the subscriber URL of each subscription request for the approver's review.
+func (s *subscriptionService) SetURLProber(p subscriberURLProber) {
+	s.urlProber = p
+}
+
+// SetPendingQuota rejects subscription requests of subscribers that have too many pending operations.
+func (s *subscriptionService) SetPendingQuota(q pendingQuotaChecker) {
+	s.pendingQuota = q
+}
+
+// SetRequestQuota rejects subscription requests of subscribers that have used up their
+// hourly or daily operation quota.
+func (s *subscriptionService) SetRequestQuota(q requestQuotaTaker) {
+	s.requestQuota = q
+}
+
+// SetDomainValidator rejects subscription requests for domains that are not in the domain
+// catalog, or without the location their domain requires.
+func (s *subscriptionService) SetDomainValidator(v domainValidator) {
+	s.domains = v
+}
+
+// SetValidityPolicy limits the validity of requested subscriptions per role,
+// clamping or rejecting a valid_until beyond the policy.
+func (s *subscriptionService) SetValidityPolicy(cfg *ValidityPolicyConfig) error {
+	if cfg == nil {
+		return errors.New("validity policy config cannot be nil")
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	s.validity = cfg
+	return nil
+}
+
+// applyValidityPolicy enforces the validity policy on the request when one is set.
+func (s *subscriptionService) applyValidityPolicy(ctx context.Context, req *model.SubscriptionRequest) error {
+	if s.validity == nil {
+		return nil
+	}
+	res, err := s.validity.apply(&req.Subscription, time.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Validity policy check failed", "error", err, "message_id", req.MessageID, "type", req.Type)
+		return err
+	}
+	if res != nil && res.Clamped {
+		slog.InfoContext(ctx, "SubscriptionService: Clamped valid_until to validity policy", "message_id", req.MessageID, "type", req.Type, "requested_valid_until", res.RequestedValidUntil, "valid_until", res.ValidUntil)
+	}
+	return nil
+}
+
+// validateDomain checks the request against the domain catalog when one is set.
+func (s *subscriptionService) validateDomain(ctx context.Context, req *model.SubscriptionRequest) error {
+	if s.domains == nil {
+		return nil
+	}
+	if err := s.domains.Validate(ctx, req); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Domain check failed", "error", err, "message_id", req.MessageID, "domain", req.Domain)
+		return err
+	}
+	return nil
+}
+
+// checkPendingQuota checks the pending operations of the request's subscriber when a quota is set.
+func (s *subscriptionService) checkPendingQuota(ctx context.Context, req *model.SubscriptionRequest) error {
+	if s.pendingQuota == nil {
+		return nil
+	}
+	if err := s.pendingQuota.Check(ctx, req.SubscriberID); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Pending operation quota check failed", "error", err, "message_id", req.MessageID, "subscriber_id", req.SubscriberID)
+		return err
+	}
+	return nil
+}
+
+// checkRequestQuota counts the request against its subscriber's operation quota when one is set.
+func (s *subscriptionService) checkRequestQuota(ctx context.Context, req *model.SubscriptionRequest) error {
+	if s.requestQuota == nil {
+		return nil
+	}
+	if err := s.requestQuota.TakeOperation(ctx, req.SubscriberID); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Operation quota check failed", "error", err, "message_id", req.MessageID, "subscriber_id", req.SubscriberID)
+		return err
+	}
+	return nil
+}
+
+// probeURL probes the request's subscriber URL in the background, so that the
+// subscriber's response is not delayed by a slow or unreachable URL.
+func (s *subscriptionService) probeURL(ctx context.Context, lro *model.LRO, req *model.SubscriptionRequest) {
+	if s.urlProber == nil {
+		return
+	}
+	go s.urlProber.ProbeOperation(context.WithoutCancel(ctx), lro.OperationID, req.URL)
+}
+
+// reserveNonce claims the request's nonce when nonce validation is enabled.
+func (s *subscriptionService) reserveNonce(ctx context.Context, req *model.SubscriptionRequest) error {
+	if s.nonceValidator == nil {
+		return nil
+	}
+	if err := s.nonceValidator.Reserve(ctx, req); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Nonce rejected", "error", err, "message_id", req.MessageID, "subscriber_id", req.SubscriberID)
+		return fmt.Errorf("invalid nonce: %w", err)
+	}
+	return nil
+}
+
 // Lookup retrieves subscriptions based on the provided filter criteria.
 func (s *subscriptionService) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
 	slog.Info("SubscriptionService: Handling lookup request", "filter", filter)
@@ -80,6 +234,78 @@ func (s *subscriptionService) Lookup(ctx context.Context, filter *model.Subscrip
 	return subscriptions, nil
 }
 
+// LookupAny retrieves the subscriptions matching any of the filters, e.g. participants in
+// one of several cities, with a single query instead of one lookup per filter.
+func (s *subscriptionService) LookupAny(ctx context.Context, filters []*model.Subscription) ([]model.Subscription, error) {
+	if len(filters) == 0 || len(filters) > maxLookupFilters {
+		return nil, fmt.Errorf("%w: got %d filters, want 1 to %d", ErrInvalidLookup, len(filters), maxLookupFilters)
+	}
+	if slices.Contains(filters, nil) {
+		return nil, fmt.Errorf("%w: filters cannot be null", ErrInvalidLookup)
+	}
+	slog.Info("SubscriptionService: Handling lookup request", "filters", len(filters))
+
+	subscriptions, err := s.subscriptionRepository.LookupAny(ctx, filters)
+	if err != nil {
+		slog.Error("SubscriptionService: Failed to perform lookup in repository", "error", err, "filters", len(filters))
+		return nil, fmt.Errorf("failed to lookup subscriptions: %w", err)
+	}
+
+	slog.Info("SubscriptionService: Lookup successful", "count", len(subscriptions))
+	return subscriptions, nil
+}
+
+// LookupChanges retrieves the changes to the results of the filters at or after since, so that
+// clients can sync a directory of subscribers incrementally instead of fetching it in full.
+// A subscription removed several times since has a single tombstone, for its latest removal.
+func (s *subscriptionService) LookupChanges(ctx context.Context, filters []*model.Subscription, since time.Time) (*model.LookupChanges, error) {
+	if len(filters) == 0 || len(filters) > maxLookupFilters {
+		return nil, fmt.Errorf("%w: got %d filters, want 1 to %d", ErrInvalidLookup, len(filters), maxLookupFilters)
+	}
+	if slices.Contains(filters, nil) {
+		return nil, fmt.Errorf("%w: filters cannot be null", ErrInvalidLookup)
+	}
+	slog.Info("SubscriptionService: Handling lookup changes request", "filters", len(filters), "updated_after", since)
+
+	changes, err := s.subscriptionRepository.LookupChanges(ctx, since, filters)
+	if err != nil {
+		slog.Error("SubscriptionService: Failed to perform lookup changes in repository", "error", err, "filters", len(filters))
+		return nil, fmt.Errorf("failed to lookup subscription changes: %w", err)
+	}
+
+	next := since
+	for _, sub := range changes.Subscriptions {
+		if sub.Updated.After(next) {
+			next = sub.Updated
+		}
+	}
+	type key struct {
+		subscriberID, domain string
+		typ                  model.Role
+	}
+	latest := map[key]int{}
+	deleted := changes.Deleted[:0]
+	for _, t := range changes.Deleted {
+		if t.DeletedAt.After(next) {
+			next = t.DeletedAt
+		}
+		k := key{t.SubscriberID, t.Domain, t.Type}
+		if i, ok := latest[k]; ok {
+			if t.DeletedAt.After(deleted[i].DeletedAt) {
+				deleted[i] = t
+			}
+			continue
+		}
+		latest[k] = len(deleted)
+		deleted = append(deleted, t)
+	}
+	changes.Deleted = deleted
+	changes.NextUpdatedAfter = next
+
+	slog.Info("SubscriptionService: Lookup changes successful", "updated", len(changes.Subscriptions), "deleted", len(changes.Deleted))
+	return changes, nil
+}
+
 // createLRO is a helper method to construct and persist an LRO.
 func (s *subscriptionService) createLRO(ctx context.Context, operationType model.OperationType, req *model.SubscriptionRequest) (*model.LRO, error) {
 	requestBytes, err := json.Marshal(req)
@@ -112,11 +338,31 @@ func (s *subscriptionService) Create(ctx context.Context, req *model.Subscriptio
 	}
 	slog.InfoContext(ctx, "SubscriptionService: Handling create subscription request", "message_id", req.MessageID)
 
+	if err := req.Validate(); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Invalid subscription request", "message_id", req.MessageID, "error", err)
+		return nil, err
+	}
+	if err := s.validateDomain(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.applyValidityPolicy(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPendingQuota(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkRequestQuota(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.reserveNonce(ctx, req); err != nil {
+		return nil, err
+	}
 	createdLRO, err := s.createLRO(ctx, model.OperationTypeCreateSubscription, req)
 	if err != nil {
 		return nil, err
 	}
 	slog.InfoContext(ctx, "SubscriptionService: LRO created for new subscription", "operation_id", createdLRO.OperationID, "status", createdLRO.Status)
+	s.probeURL(ctx, createdLRO, req)
 	if evID, err := s.evPublisher.PublishNewSubscriptionRequestEvent(ctx, req); err != nil {
 		slog.ErrorContext(ctx, "SubscriptionService: Failed to publish new subscription request event", "error", err)
 	} else {
@@ -134,12 +380,32 @@ func (s *subscriptionService) Update(ctx context.Context, req *model.Subscriptio
 	}
 	slog.InfoContext(ctx, "SubscriptionService: Handling update subscription request", "message_id", req.MessageID)
 
+	if err := req.Validate(); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Invalid subscription request", "message_id", req.MessageID, "error", err)
+		return nil, err
+	}
+	if err := s.validateDomain(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.applyValidityPolicy(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPendingQuota(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkRequestQuota(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.reserveNonce(ctx, req); err != nil {
+		return nil, err
+	}
 	createdLRO, err := s.createLRO(ctx, model.OperationTypeUpdateSubscription, req)
 	if err != nil {
 		return nil, err
 	}
 
 	slog.InfoContext(ctx, "SubscriptionService: LRO created for subscription update", "operation_id", createdLRO.OperationID, "status", createdLRO.Status)
+	s.probeURL(ctx, createdLRO, req)
 	if evID, err := s.evPublisher.PublishUpdateSubscriptionRequestEvent(ctx, req); err != nil {
 		slog.ErrorContext(ctx, "SubscriptionService: Failed to publish update subscription request event", "error", err)
 	} else {
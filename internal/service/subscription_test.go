@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event/mock"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/google/go-cmp/cmp"
@@ -44,12 +45,21 @@ type mockSubscriptionRepository struct {
 	key           string
 	err           error
 	subscriptions []model.Subscription
+	changes       *model.LookupChanges
 }
 
 func (m *mockSubscriptionRepository) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
 	return m.subscriptions, m.err
 }
 
+func (m *mockSubscriptionRepository) LookupAny(ctx context.Context, filters []*model.Subscription) ([]model.Subscription, error) {
+	return m.subscriptions, m.err
+}
+
+func (m *mockSubscriptionRepository) LookupChanges(ctx context.Context, since time.Time, filters []*model.Subscription) (*model.LookupChanges, error) {
+	return m.changes, m.err
+}
+
 func (m *mockSubscriptionRepository) GetSubscriberSigningKey(ctx context.Context, subscriberID string, domain string, subType model.Role, keyID string) (string, error) {
 	return m.key, m.err
 }
@@ -231,16 +241,165 @@ func TestSubscriptionServiceLookupError(t *testing.T) {
 	}
 }
 
+func TestSubscriptionServiceLookupAnySuccess(t *testing.T) {
+	want := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "np1", Domain: "retail"}},
+		{Subscriber: model.Subscriber{SubscriberID: "np2", Domain: "mobility"}},
+	}
+	mockRepo := &mockSubscriptionRepository{subscriptions: want}
+	service, err := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+	if err != nil {
+		t.Fatalf("NewSubscriptionService() failed: %v", err)
+	}
+
+	got, err := service.LookupAny(context.Background(), []*model.Subscription{
+		{Subscriber: model.Subscriber{Domain: "retail"}},
+		{Subscriber: model.Subscriber{Domain: "mobility"}},
+	})
+	if err != nil {
+		t.Fatalf("LookupAny() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LookupAny() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestSubscriptionServiceLookupAnyError(t *testing.T) {
+	repoErr := errors.New("database connection failed")
+	tooMany := make([]*model.Subscription, maxLookupFilters+1)
+	for i := range tooMany {
+		tooMany[i] = &model.Subscription{}
+	}
+
+	tests := []struct {
+		name        string
+		filters     []*model.Subscription
+		mockRepoErr error
+		wantErr     error
+	}{
+		{
+			name:    "no filters",
+			filters: nil,
+			wantErr: ErrInvalidLookup,
+		},
+		{
+			name:    "too many filters",
+			filters: tooMany,
+			wantErr: ErrInvalidLookup,
+		},
+		{
+			name:    "null filter",
+			filters: []*model.Subscription{{}, nil},
+			wantErr: ErrInvalidLookup,
+		},
+		{
+			name:        "repository error",
+			filters:     []*model.Subscription{{Subscriber: model.Subscriber{Domain: "retail"}}},
+			mockRepoErr: repoErr,
+			wantErr:     repoErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockSubscriptionRepository{err: tt.mockRepoErr}
+			service, err := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+			if err != nil {
+				t.Fatalf("NewSubscriptionService() failed: %v", err)
+			}
+
+			if _, err := service.LookupAny(context.Background(), tt.filters); !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookupAny() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriptionServiceLookupChanges(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return since.Add(time.Duration(h) * time.Hour) }
+	mockRepo := &mockSubscriptionRepository{changes: &model.LookupChanges{
+		Subscriptions: []model.Subscription{
+			{Subscriber: model.Subscriber{SubscriberID: "np1", Domain: "retail", Type: model.RoleBPP}, Updated: at(2)},
+		},
+		Deleted: []model.SubscriptionTombstone{
+			{SubscriberID: "np2", Domain: "retail", Type: model.RoleBPP, KeyID: "key-1", DeletedAt: at(1)},
+			{SubscriberID: "np3", Domain: "retail", Type: model.RoleBPP, DeletedAt: at(1)},
+			{SubscriberID: "np2", Domain: "retail", Type: model.RoleBPP, KeyID: "key-2", DeletedAt: at(3)},
+		},
+	}}
+	service, _ := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+
+	got, err := service.LookupChanges(context.Background(), []*model.Subscription{{Subscriber: model.Subscriber{Domain: "retail"}}}, since)
+	if err != nil {
+		t.Fatalf("LookupChanges() unexpected error: %v", err)
+	}
+	want := &model.LookupChanges{
+		Subscriptions: []model.Subscription{
+			{Subscriber: model.Subscriber{SubscriberID: "np1", Domain: "retail", Type: model.RoleBPP}, Updated: at(2)},
+		},
+		Deleted: []model.SubscriptionTombstone{
+			{SubscriberID: "np2", Domain: "retail", Type: model.RoleBPP, KeyID: "key-2", DeletedAt: at(3)},
+			{SubscriberID: "np3", Domain: "retail", Type: model.RoleBPP, DeletedAt: at(1)},
+		},
+		NextUpdatedAfter: at(3),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LookupChanges() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriptionServiceLookupChanges_NoChanges(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo := &mockSubscriptionRepository{changes: &model.LookupChanges{Subscriptions: []model.Subscription{}, Deleted: []model.SubscriptionTombstone{}}}
+	service, _ := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+
+	got, err := service.LookupChanges(context.Background(), []*model.Subscription{{}}, since)
+	if err != nil {
+		t.Fatalf("LookupChanges() unexpected error: %v", err)
+	}
+	if !got.NextUpdatedAfter.Equal(since) {
+		t.Errorf("LookupChanges() NextUpdatedAfter = %v, want %v", got.NextUpdatedAfter, since)
+	}
+}
+
+func TestSubscriptionServiceLookupChangesError(t *testing.T) {
+	repoErr := errors.New("database connection failed")
+	tests := []struct {
+		name        string
+		filters     []*model.Subscription
+		mockRepoErr error
+		wantErr     error
+	}{
+		{name: "no filters", filters: nil, wantErr: ErrInvalidLookup},
+		{name: "null filter", filters: []*model.Subscription{nil}, wantErr: ErrInvalidLookup},
+		{name: "repository error", filters: []*model.Subscription{{}}, mockRepoErr: repoErr, wantErr: repoErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := NewSubscriptionService(&mockLROCreator{}, &mockSubscriptionRepository{err: tt.mockRepoErr}, &mock.EventPublisher{})
+			if _, err := service.LookupChanges(context.Background(), tt.filters, time.Now()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookupChanges() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestSubscriptionService_Create_Success(t *testing.T) {
 	ctx := context.Background()
 	defaultReq := &model.SubscriptionRequest{
 		Subscription: model.Subscription{
 			Subscriber: model.Subscriber{
 				SubscriberID: "test-sub-id",
+				URL:          "https://test.com/beckn",
 				Domain:       "test.com",
 				Type:         model.RoleBAP,
 			},
-			Status: model.SubscriptionStatusInitiated,
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Status:           model.SubscriptionStatusInitiated,
 		},
 		MessageID: "test-msg-id",
 	}
@@ -268,10 +427,14 @@ func TestSubscriptionService_Create_Error(t *testing.T) {
 		Subscription: model.Subscription{
 			Subscriber: model.Subscriber{
 				SubscriberID: "test-sub-id",
+				URL:          "https://test.com/beckn",
 				Domain:       "test.com",
 				Type:         model.RoleBAP,
 			},
-			Status: model.SubscriptionStatusInitiated,
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Status:           model.SubscriptionStatusInitiated,
 		},
 		MessageID: "test-msg-id",
 	}
@@ -293,6 +456,14 @@ func TestSubscriptionService_Create_Error(t *testing.T) {
 			mockLRO:    &mockLROCreator{},
 			wantErrMsg: "subscription request cannot be nil",
 		},
+		{
+			name: "invalid request",
+			req: &model.SubscriptionRequest{
+				Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test-sub-id", URL: "np.com", Type: "LSP", Domain: "test.com"}},
+			},
+			mockLRO:    &mockLROCreator{},
+			wantErrMsg: "url must be an absolute http or https URL; type must be one of BAP, BPP, BG; key_id is required; signing_public_key is required; encr_public_key is required; message_id is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -313,8 +484,19 @@ func TestSubscriptionService_Create_Error(t *testing.T) {
 func TestSubscriptionService_Update_Success(t *testing.T) {
 	ctx := context.Background()
 	defaultReq := &model.SubscriptionRequest{
-		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "update-sub-id"}, Status: model.SubscriptionStatusSubscribed},
-		MessageID:    "update-msg-id",
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "update-sub-id",
+				URL:          "https://update.com/beckn",
+				Domain:       "update.com",
+				Type:         model.RoleBPP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Status:           model.SubscriptionStatusSubscribed,
+		},
+		MessageID: "update-msg-id",
 	}
 	reqBytes, _ := json.Marshal(defaultReq)
 	defaultLROWithReqJSON := &model.LRO{OperationID: "update-msg-id", Status: model.LROStatusPending, Type: model.OperationTypeUpdateSubscription, RequestJSON: reqBytes}
@@ -337,8 +519,19 @@ func TestSubscriptionService_Update_Success(t *testing.T) {
 func TestSubscriptionService_Update_Error(t *testing.T) {
 	ctx := context.Background()
 	defaultReq := &model.SubscriptionRequest{
-		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "update-sub-id"}, Status: model.SubscriptionStatusSubscribed},
-		MessageID:    "update-msg-id",
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "update-sub-id",
+				URL:          "https://update.com/beckn",
+				Domain:       "update.com",
+				Type:         model.RoleBPP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Status:           model.SubscriptionStatusSubscribed,
+		},
+		MessageID: "update-msg-id",
 	}
 
 	tests := []struct {
@@ -408,3 +601,346 @@ func TestSubscriptionService_GetSigningPublicKey_Error(t *testing.T) {
 		}
 	})
 }
+
+// mockNonceValidator is a mock implementation of nonceValidator.
+type mockNonceValidator struct {
+	err   error
+	calls int
+}
+
+func (m *mockNonceValidator) Reserve(ctx context.Context, req *model.SubscriptionRequest) error {
+	m.calls++
+	return m.err
+}
+
+func TestSubscriptionService_NonceValidation(t *testing.T) {
+	ctx := context.Background()
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-id",
+				URL:          "https://test.com/beckn",
+				Domain:       "test.com",
+				Type:         model.RoleBAP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Nonce:            "nonce-1",
+		},
+		MessageID: "test-msg-id",
+	}
+	lro := &model.LRO{OperationID: "test-msg-id", Status: model.LROStatusPending}
+
+	tests := []struct {
+		name     string
+		nonceErr error
+		wantErr  error
+	}{
+		{name: "nonce reserved"},
+		{name: "nonce replayed", nonceErr: repository.ErrNonceReplayed, wantErr: repository.ErrNonceReplayed},
+		{name: "nonce required", nonceErr: ErrNonceRequired, wantErr: ErrNonceRequired},
+	}
+
+	ops := map[string]func(*subscriptionService) (*model.LRO, error){
+		"Create": func(s *subscriptionService) (*model.LRO, error) { return s.Create(ctx, req) },
+		"Update": func(s *subscriptionService) (*model.LRO, error) { return s.Update(ctx, req) },
+	}
+	for opName, op := range ops {
+		for _, tt := range tests {
+			t.Run(opName+"/"+tt.name, func(t *testing.T) {
+				evPub := &mock.EventPublisher{}
+				service, _ := NewSubscriptionService(&mockLROCreator{lro: lro}, &mockSubscriptionRepository{}, evPub)
+				nv := &mockNonceValidator{err: tt.nonceErr}
+				service.SetNonceValidator(nv)
+
+				got, err := op(service)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s() error = %v, want %v", opName, err, tt.wantErr)
+				}
+				if nv.calls != 1 {
+					t.Errorf("Reserve() calls = %d, want 1", nv.calls)
+				}
+				if tt.wantErr != nil && got != nil {
+					t.Errorf("%s() LRO = %v, want nil", opName, got)
+				}
+			})
+		}
+	}
+}
+
+// mockSubscriberURLProber is a mock implementation of subscriberURLProber.
+type mockSubscriberURLProber struct {
+	probed chan [2]string
+	ctx    context.Context
+}
+
+func (m *mockSubscriberURLProber) ProbeOperation(ctx context.Context, operationID, rawURL string) {
+	m.ctx = ctx
+	m.probed <- [2]string{operationID, rawURL}
+}
+
+func TestSubscriptionService_ProbesSubscriberURL(t *testing.T) {
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-id",
+				URL:          "https://np.com/beckn",
+				Domain:       "test.com",
+				Type:         model.RoleBAP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+		},
+		MessageID: "test-msg-id",
+	}
+	lro := &model.LRO{OperationID: "test-msg-id", Status: model.LROStatusPending}
+
+	ops := map[string]func(context.Context, *subscriptionService) (*model.LRO, error){
+		"Create": func(ctx context.Context, s *subscriptionService) (*model.LRO, error) { return s.Create(ctx, req) },
+		"Update": func(ctx context.Context, s *subscriptionService) (*model.LRO, error) { return s.Update(ctx, req) },
+	}
+	for opName, op := range ops {
+		t.Run(opName, func(t *testing.T) {
+			service, _ := NewSubscriptionService(&mockLROCreator{lro: lro}, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+			prober := &mockSubscriberURLProber{probed: make(chan [2]string, 1)}
+			service.SetURLProber(prober)
+
+			// The probe must outlive the request context.
+			ctx, cancel := context.WithCancel(context.Background())
+			if _, err := op(ctx, service); err != nil {
+				t.Fatalf("%s() error = %v", opName, err)
+			}
+			cancel()
+
+			select {
+			case got := <-prober.probed:
+				if got != [2]string{"test-msg-id", "https://np.com/beckn"} {
+					t.Errorf("ProbeOperation() called with %v", got)
+				}
+				if prober.ctx.Err() != nil {
+					t.Errorf("probe context was cancelled with the request: %v", prober.ctx.Err())
+				}
+			case <-time.After(time.Second):
+				t.Fatal("ProbeOperation() was not called")
+			}
+		})
+	}
+}
+
+// mockPendingQuotaChecker is a mock implementation of pendingQuotaChecker.
+type mockPendingQuotaChecker struct {
+	err   error
+	calls int
+}
+
+func (m *mockPendingQuotaChecker) Check(ctx context.Context, subscriberID string) error {
+	m.calls++
+	return m.err
+}
+
+func TestSubscriptionService_PendingQuota(t *testing.T) {
+	ctx := context.Background()
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-id",
+				URL:          "https://test.com/beckn",
+				Domain:       "test.com",
+				Type:         model.RoleBAP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Nonce:            "nonce-1",
+		},
+		MessageID: "test-msg-id",
+	}
+	lro := &model.LRO{OperationID: "test-msg-id", Status: model.LROStatusPending}
+
+	tests := []struct {
+		name           string
+		quotaErr       error
+		wantErr        error
+		wantNonceCalls int
+	}{
+		{name: "under quota", wantNonceCalls: 1},
+		{name: "quota exceeded", quotaErr: ErrPendingQuotaExceeded, wantErr: ErrPendingQuotaExceeded},
+	}
+
+	ops := map[string]func(*subscriptionService) (*model.LRO, error){
+		"Create": func(s *subscriptionService) (*model.LRO, error) { return s.Create(ctx, req) },
+		"Update": func(s *subscriptionService) (*model.LRO, error) { return s.Update(ctx, req) },
+	}
+	for opName, op := range ops {
+		for _, tt := range tests {
+			t.Run(opName+"/"+tt.name, func(t *testing.T) {
+				service, _ := NewSubscriptionService(&mockLROCreator{lro: lro}, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+				nv := &mockNonceValidator{}
+				service.SetNonceValidator(nv)
+				quota := &mockPendingQuotaChecker{err: tt.quotaErr}
+				service.SetPendingQuota(quota)
+
+				got, err := op(service)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s() error = %v, want %v", opName, err, tt.wantErr)
+				}
+				if quota.calls != 1 {
+					t.Errorf("Check() calls = %d, want 1", quota.calls)
+				}
+				// A rejected request must not burn its nonce.
+				if nv.calls != tt.wantNonceCalls {
+					t.Errorf("Reserve() calls = %d, want %d", nv.calls, tt.wantNonceCalls)
+				}
+				if tt.wantErr != nil && got != nil {
+					t.Errorf("%s() LRO = %v, want nil", opName, got)
+				}
+			})
+		}
+	}
+}
+
+// mockRequestQuotaTaker is a mock implementation of requestQuotaTaker.
+type mockRequestQuotaTaker struct {
+	err          error
+	subscriberID string
+}
+
+func (m *mockRequestQuotaTaker) TakeOperation(ctx context.Context, subscriberID string) error {
+	m.subscriberID = subscriberID
+	return m.err
+}
+
+func TestSubscriptionService_RequestQuota(t *testing.T) {
+	ctx := context.Background()
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-id",
+				URL:          "https://test.com/beckn",
+				Domain:       "test.com",
+				Type:         model.RoleBAP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Nonce:            "nonce-1",
+		},
+		MessageID: "test-msg-id",
+	}
+	lro := &model.LRO{OperationID: "test-msg-id", Status: model.LROStatusPending}
+	quotaErr := &QuotaExceededError{Scope: QuotaScopeOperations, Caller: "test-sub-id", Period: QuotaPeriodHour, Limit: 5}
+
+	tests := []struct {
+		name           string
+		quotaErr       error
+		wantErr        error
+		wantNonceCalls int
+	}{
+		{name: "under quota", wantNonceCalls: 1},
+		{name: "quota exceeded", quotaErr: quotaErr, wantErr: ErrRequestQuotaExceeded},
+	}
+
+	ops := map[string]func(*subscriptionService) (*model.LRO, error){
+		"Create": func(s *subscriptionService) (*model.LRO, error) { return s.Create(ctx, req) },
+		"Update": func(s *subscriptionService) (*model.LRO, error) { return s.Update(ctx, req) },
+	}
+	for opName, op := range ops {
+		for _, tt := range tests {
+			t.Run(opName+"/"+tt.name, func(t *testing.T) {
+				service, _ := NewSubscriptionService(&mockLROCreator{lro: lro}, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+				nv := &mockNonceValidator{}
+				service.SetNonceValidator(nv)
+				quota := &mockRequestQuotaTaker{err: tt.quotaErr}
+				service.SetRequestQuota(quota)
+
+				got, err := op(service)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s() error = %v, want %v", opName, err, tt.wantErr)
+				}
+				if quota.subscriberID != "test-sub-id" {
+					t.Errorf("TakeOperation() subscriberID = %q, want %q", quota.subscriberID, "test-sub-id")
+				}
+				if nv.calls != tt.wantNonceCalls {
+					t.Errorf("Reserve() calls = %d, want %d", nv.calls, tt.wantNonceCalls)
+				}
+				if tt.wantErr != nil && got != nil {
+					t.Errorf("%s() LRO = %v, want nil", opName, got)
+				}
+			})
+		}
+	}
+}
+
+// mockDomainValidator is a mock implementation of domainValidator.
+type mockDomainValidator struct {
+	err   error
+	calls int
+}
+
+func (m *mockDomainValidator) Validate(ctx context.Context, req *model.SubscriptionRequest) error {
+	m.calls++
+	return m.err
+}
+
+func TestSubscriptionService_DomainValidator(t *testing.T) {
+	ctx := context.Background()
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-id",
+				URL:          "https://test.com/beckn",
+				Domain:       "ONDC:RET10",
+				Type:         model.RoleBAP,
+			},
+			KeyID:            "test-key-id",
+			SigningPublicKey: "test-signing-pub-key",
+			EncrPublicKey:    "test-encr-pub-key",
+			Nonce:            "nonce-1",
+		},
+		MessageID: "test-msg-id",
+	}
+	lro := &model.LRO{OperationID: "test-msg-id", Status: model.LROStatusPending}
+
+	tests := []struct {
+		name           string
+		domainErr      error
+		wantErr        error
+		wantNonceCalls int
+	}{
+		{name: "domain in catalog", wantNonceCalls: 1},
+		{name: "domain not in catalog", domainErr: &model.ValidationError{Fields: []model.FieldError{{Field: "domain", Message: "is not in the domain catalog"}}}, wantErr: model.ErrValidation},
+	}
+
+	ops := map[string]func(*subscriptionService) (*model.LRO, error){
+		"Create": func(s *subscriptionService) (*model.LRO, error) { return s.Create(ctx, req) },
+		"Update": func(s *subscriptionService) (*model.LRO, error) { return s.Update(ctx, req) },
+	}
+	for opName, op := range ops {
+		for _, tt := range tests {
+			t.Run(opName+"/"+tt.name, func(t *testing.T) {
+				service, _ := NewSubscriptionService(&mockLROCreator{lro: lro}, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+				nv := &mockNonceValidator{}
+				service.SetNonceValidator(nv)
+				dv := &mockDomainValidator{err: tt.domainErr}
+				service.SetDomainValidator(dv)
+
+				got, err := op(service)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s() error = %v, want %v", opName, err, tt.wantErr)
+				}
+				if dv.calls != 1 {
+					t.Errorf("Validate() calls = %d, want 1", dv.calls)
+				}
+				// A rejected request must not burn its nonce.
+				if nv.calls != tt.wantNonceCalls {
+					t.Errorf("Reserve() calls = %d, want %d", nv.calls, tt.wantNonceCalls)
+				}
+				if tt.wantErr != nil && got != nil {
+					t.Errorf("%s() LRO = %v, want nil", opName, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+var (
+	// ErrInvalidHistoryQuery is returned when a point-in-time view is requested without a
+	// subscriber or for a time in the future.
+	ErrInvalidHistoryQuery = errors.New("invalid subscription history query")
+
+	// ErrNoSubscriptionHistory is returned when a subscriber had no subscriptions at the requested time.
+	ErrNoSubscriptionHistory = errors.New("no subscriptions at the requested time")
+)
+
+// subscriptionHistoryRepository defines the repository operation that reads the subscription history.
+type subscriptionHistoryRepository interface {
+	SubscriptionsAt(ctx context.Context, subscriberID string, at time.Time) ([]model.SubscriptionVersion, error)
+}
+
+// subscriptionHistoryService reconstructs subscriptions as they were at a point in time,
+// for investigating disputes about which key was valid when.
+type subscriptionHistoryService struct {
+	repo subscriptionHistoryRepository
+	now  func() time.Time
+}
+
+// NewSubscriptionHistoryService creates a new subscriptionHistoryService.
+func NewSubscriptionHistoryService(repo subscriptionHistoryRepository) (*subscriptionHistoryService, error) {
+	if repo == nil {
+		slog.Error("NewSubscriptionHistoryService: subscriptionHistoryRepository cannot be nil")
+		return nil, errors.New("subscriptionHistoryRepository cannot be nil")
+	}
+	return &subscriptionHistoryService{repo: repo, now: time.Now}, nil
+}
+
+// At returns the subscriptions of subscriberID as they were at the given time.
+// A zero at defaults to now.
+func (s *subscriptionHistoryService) At(ctx context.Context, subscriberID string, at time.Time) (*model.SubscriptionHistoryView, error) {
+	if subscriberID == "" {
+		return nil, fmt.Errorf("%w: subscriber_id is required", ErrInvalidHistoryQuery)
+	}
+	now := s.now()
+	if at.IsZero() {
+		at = now
+	}
+	if at.After(now) {
+		return nil, fmt.Errorf("%w: at %s is in the future", ErrInvalidHistoryQuery, at.Format(time.RFC3339))
+	}
+	versions, err := s.repo.SubscriptionsAt(ctx, subscriberID, at.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriptionHistoryService: Failed to read subscription history", "subscriber_id", subscriberID, "at", at, "error", err)
+		return nil, fmt.Errorf("failed to read subscription history: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: subscriber %q at %s", ErrNoSubscriptionHistory, subscriberID, at.Format(time.RFC3339))
+	}
+	return &model.SubscriptionHistoryView{SubscriberID: subscriberID, At: at.UTC(), Subscriptions: versions}, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockSubscriptionHistoryRepository struct {
+	versions []model.SubscriptionVersion
+	err      error
+
+	gotSubscriberID string
+	gotAt           time.Time
+}
+
+func (m *mockSubscriptionHistoryRepository) SubscriptionsAt(ctx context.Context, subscriberID string, at time.Time) ([]model.SubscriptionVersion, error) {
+	m.gotSubscriberID, m.gotAt = subscriberID, at
+	return m.versions, m.err
+}
+
+func TestNewSubscriptionHistoryService_Error(t *testing.T) {
+	if _, err := NewSubscriptionHistoryService(nil); err == nil {
+		t.Error("NewSubscriptionHistoryService() expected error, got nil")
+	}
+}
+
+func TestSubscriptionHistoryService_At(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	versions := []model.SubscriptionVersion{{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np1", Domain: "retail"}, KeyID: "key-1"},
+		Change:       model.SubscriptionChangeInsert,
+		ChangedAt:    now.Add(-48 * time.Hour),
+	}}
+	tests := []struct {
+		name   string
+		at     time.Time
+		wantAt time.Time
+	}{
+		{name: "defaults to now", wantAt: now},
+		{
+			name:   "explicit time is converted to UTC",
+			at:     time.Date(2025, 6, 1, 5, 30, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+			wantAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockSubscriptionHistoryRepository{versions: versions}
+			s, _ := NewSubscriptionHistoryService(repo)
+			s.now = func() time.Time { return now }
+
+			got, err := s.At(context.Background(), "np1", tc.at)
+			if err != nil {
+				t.Fatalf("At() unexpected error: %v", err)
+			}
+			if repo.gotSubscriberID != "np1" || !repo.gotAt.Equal(tc.wantAt) || repo.gotAt.Location() != time.UTC {
+				t.Errorf("SubscriptionsAt() called with (%q, %v), want (%q, %v)", repo.gotSubscriberID, repo.gotAt, "np1", tc.wantAt)
+			}
+			want := &model.SubscriptionHistoryView{SubscriberID: "np1", At: tc.wantAt, Subscriptions: versions}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("At() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSubscriptionHistoryService_At_Error(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	errDB := errors.New("db down")
+	tests := []struct {
+		name         string
+		subscriberID string
+		at           time.Time
+		repo         *mockSubscriptionHistoryRepository
+		wantErr      error
+	}{
+		{name: "missing subscriber", at: now, repo: &mockSubscriptionHistoryRepository{}, wantErr: ErrInvalidHistoryQuery},
+		{name: "future time", subscriberID: "np1", at: now.Add(time.Minute), repo: &mockSubscriptionHistoryRepository{}, wantErr: ErrInvalidHistoryQuery},
+		{name: "no subscriptions", subscriberID: "np1", at: now, repo: &mockSubscriptionHistoryRepository{versions: []model.SubscriptionVersion{}}, wantErr: ErrNoSubscriptionHistory},
+		{name: "repository error", subscriberID: "np1", at: now, repo: &mockSubscriptionHistoryRepository{err: errDB}, wantErr: errDB},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := NewSubscriptionHistoryService(tc.repo)
+			s.now = func() time.Time { return now }
+
+			if _, err := s.At(context.Background(), tc.subscriberID, tc.at); !errors.Is(err, tc.wantErr) {
+				t.Errorf("At() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrTargetNotAllowed is returned when a target URL violates the target policy.
+var ErrTargetNotAllowed = errors.New("target URL not allowed")
+
+// TargetPolicyConfig restricts the subscriber URLs the gateway proxies requests to.
+// Subscriber URLs come from the registry, so without a policy a malicious
+// registration could make the gateway call internal services.
+type TargetPolicyConfig struct {
+	// AllowHTTP permits plain http targets. Only https targets are allowed by default.
+	AllowHTTP bool `yaml:"allowHTTP"`
+	// AllowPrivateIPs permits targets that resolve to loopback, private, link-local
+	// or other non-public addresses.
+	AllowPrivateIPs bool `yaml:"allowPrivateIPs"`
+	// AllowedDomains, when set, limits targets to these hosts. An entry of the form
+	// "*.example.com" matches any subdomain of example.com.
+	AllowedDomains []string `yaml:"allowedDomains"`
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which net.IP does not treat as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// targetPolicy validates proxy target URLs against a TargetPolicyConfig.
+type targetPolicy struct {
+	cfg      *TargetPolicyConfig
+	lookupIP func(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// NewTargetPolicy creates a new target policy from the given config.
+func NewTargetPolicy(cfg *TargetPolicyConfig) (*targetPolicy, error) {
+	if cfg == nil {
+		slog.Error("NewTargetPolicy: config cannot be nil")
+		return nil, errors.New("target policy config cannot be nil")
+	}
+	for _, d := range cfg.AllowedDomains {
+		if strings.TrimPrefix(d, "*.") == "" || strings.Contains(strings.TrimPrefix(d, "*."), "*") {
+			return nil, fmt.Errorf("invalid allowed domain: %q", d)
+		}
+	}
+	return &targetPolicy{cfg: cfg, lookupIP: net.DefaultResolver.LookupIP}, nil
+}
+
+// Validate checks the target's scheme and host against the policy and, unless
+// private IPs are allowed, that none of the addresses it resolves to are private.
+func (p *targetPolicy) Validate(ctx context.Context, target *url.URL) error {
+	switch target.Scheme {
+	case "https":
+	case "http":
+		if !p.cfg.AllowHTTP {
+			return fmt.Errorf("%w: %s does not use https", ErrTargetNotAllowed, target.Redacted())
+		}
+	default:
+		return fmt.Errorf("%w: unsupported scheme %q", ErrTargetNotAllowed, target.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: %s has no host", ErrTargetNotAllowed, target.Redacted())
+	}
+	if !p.domainAllowed(host) {
+		return fmt.Errorf("%w: host %s is not in the allowed domains", ErrTargetNotAllowed, host)
+	}
+	if p.cfg.AllowPrivateIPs {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.CheckIP(ip)
+	}
+	ips, err := p.lookupIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve target host %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if err := p.CheckIP(ip); err != nil {
+			return fmt.Errorf("%w (resolved from %s)", err, host)
+		}
+	}
+	return nil
+}
+
+// CheckIP returns ErrTargetNotAllowed if ip is not a public address and private IPs are not allowed.
+func (p *targetPolicy) CheckIP(ip net.IP) error {
+	if p.cfg.AllowPrivateIPs {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrTargetNotAllowed, ip)
+	}
+	return nil
+}
+
+// DialControl is a net.Dialer Control function that rejects connections to
+// addresses not allowed by CheckIP. It closes the window between Validate
+// resolving a host and the connection being made, in which the DNS answer
+// could change to a private address.
+func (p *targetPolicy) DialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid dial address %q: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: dial address %s is not an IP", ErrTargetNotAllowed, host)
+	}
+	return p.CheckIP(ip)
+}
+
+// domainAllowed reports whether host matches the allowed domains, or true if none are configured.
+func (p *targetPolicy) domainAllowed(host string) bool {
+	if len(p.cfg.AllowedDomains) == 0 {
+		return true
+	}
+	for _, d := range p.cfg.AllowedDomains {
+		if hostMatches(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostMatches reports whether host equals pattern or, for a pattern of the form
+// "*.example.com", is a subdomain of example.com. host must be lower case.
+func hostMatches(host, pattern string) bool {
+	pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix)
+	}
+	return host == pattern
+}
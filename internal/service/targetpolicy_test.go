@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestNewTargetPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *TargetPolicyConfig
+		wantErr bool
+	}{
+		{name: "empty config", cfg: &TargetPolicyConfig{}},
+		{name: "valid domains", cfg: &TargetPolicyConfig{AllowedDomains: []string{"bpp.example.com", "*.example.org"}}},
+		{name: "nil config", wantErr: true},
+		{name: "bare wildcard", cfg: &TargetPolicyConfig{AllowedDomains: []string{"*."}}, wantErr: true},
+		{name: "inner wildcard", cfg: &TargetPolicyConfig{AllowedDomains: []string{"bpp.*.com"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTargetPolicy(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTargetPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTargetPolicy_Validate(t *testing.T) {
+	resolved := map[string][]net.IP{
+		"bpp.example.com":      {net.ParseIP("203.0.113.10")},
+		"shop.example.org":     {net.ParseIP("198.51.100.7")},
+		"internal.example.com": {net.ParseIP("203.0.113.11"), net.ParseIP("10.0.0.5")},
+		"metadata.example.com": {net.ParseIP("169.254.169.254")},
+	}
+	lookup := func(ctx context.Context, network, host string) ([]net.IP, error) {
+		ips, ok := resolved[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return ips, nil
+	}
+
+	tests := []struct {
+		name        string
+		cfg         TargetPolicyConfig
+		target      string
+		wantErr     bool
+		wantBlocked bool
+	}{
+		{name: "public https", target: "https://bpp.example.com/search"},
+		{name: "http denied by default", target: "http://bpp.example.com/search", wantErr: true, wantBlocked: true},
+		{name: "http allowed", cfg: TargetPolicyConfig{AllowHTTP: true}, target: "http://bpp.example.com/search"},
+		{name: "unsupported scheme", target: "ftp://bpp.example.com/search", wantErr: true, wantBlocked: true},
+		{name: "private IP literal", target: "https://192.168.1.1/search", wantErr: true, wantBlocked: true},
+		{name: "loopback IPv6 literal", target: "https://[::1]:8443/search", wantErr: true, wantBlocked: true},
+		{name: "shared address space", target: "https://100.64.1.1/search", wantErr: true, wantBlocked: true},
+		{name: "public IP literal", target: "https://203.0.113.10/search"},
+		{name: "any private resolved address", target: "https://internal.example.com/search", wantErr: true, wantBlocked: true},
+		{name: "link-local metadata address", target: "https://metadata.example.com/", wantErr: true, wantBlocked: true},
+		{name: "private allowed", cfg: TargetPolicyConfig{AllowPrivateIPs: true}, target: "https://10.0.0.5/search"},
+		{name: "resolution failure", target: "https://unknown.example.com/search", wantErr: true},
+		{name: "allowed domain exact", cfg: TargetPolicyConfig{AllowedDomains: []string{"BPP.example.com."}}, target: "https://bpp.example.com/search"},
+		{name: "allowed domain wildcard", cfg: TargetPolicyConfig{AllowedDomains: []string{"*.example.org"}}, target: "https://shop.example.org/search"},
+		{name: "wildcard does not match apex", cfg: TargetPolicyConfig{AllowedDomains: []string{"*.example.org"}}, target: "https://example.org/search", wantErr: true, wantBlocked: true},
+		{name: "domain not allowed", cfg: TargetPolicyConfig{AllowedDomains: []string{"bpp.example.com"}}, target: "https://shop.example.org/search", wantErr: true, wantBlocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewTargetPolicy(&tt.cfg)
+			if err != nil {
+				t.Fatalf("NewTargetPolicy() error = %v", err)
+			}
+			p.lookupIP = lookup
+			target, err := url.Parse(tt.target)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+
+			err = p.Validate(context.Background(), target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrTargetNotAllowed) != tt.wantBlocked {
+				t.Errorf("Validate() error = %v, want ErrTargetNotAllowed %v", err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestTargetPolicy_DialControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TargetPolicyConfig
+		address string
+		wantErr bool
+	}{
+		{name: "public address", address: "203.0.113.10:443"},
+		{name: "private address", address: "10.0.0.5:443", wantErr: true},
+		{name: "private address allowed", cfg: TargetPolicyConfig{AllowPrivateIPs: true}, address: "10.0.0.5:443"},
+		{name: "loopback IPv6", address: "[::1]:443", wantErr: true},
+		{name: "malformed address", address: "10.0.0.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewTargetPolicy(&tt.cfg)
+			if err != nil {
+				t.Fatalf("NewTargetPolicy() error = %v", err)
+			}
+			if err := p.DialControl("tcp", tt.address, nil); (err != nil) != tt.wantErr {
+				t.Errorf("DialControl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const defaultTargetStatusCacheTTL = 30 * time.Second
+
+var (
+	// ErrTargetSuspended occurs if the subscriber a request is addressed to is registered,
+	// but none of its subscriptions is SUBSCRIBED.
+	ErrTargetSuspended = errors.New("subscriber suspended")
+	// ErrTargetExpired occurs if the subscriber a request is addressed to is registered,
+	// but its subscriptions have expired.
+	ErrTargetExpired = errors.New("subscriber expired")
+)
+
+// targetStatusMetrics counts the requests NACKed because their target is suspended or
+// expired, and the target lookups that failed, on the expvar endpoint (/debug/vars).
+var targetStatusMetrics = expvar.NewMap("gateway_target_status")
+
+// TargetStatusConfig configures the check of the subscriber a request is addressed to.
+type TargetStatusConfig struct {
+	// CacheTTL is how long the status of a subscriber is cached. Defaults to 30s.
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+}
+
+// targetStatusEntry is the cached result of a target check.
+type targetStatusEntry struct {
+	err     error
+	expires time.Time
+}
+
+// targetStatus checks that the BPP a request is addressed to can receive it, so that
+// requests to suspended or expired subscribers are NACKed instead of silently dropped.
+type targetStatus struct {
+	registry lookupClient
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]targetStatusEntry
+}
+
+// NewTargetStatus creates a new targetStatus.
+func NewTargetStatus(registry lookupClient, cfg *TargetStatusConfig) (*targetStatus, error) {
+	if registry == nil {
+		slog.Error("NewTargetStatus: registry cannot be nil")
+		return nil, errors.New("registry cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewTargetStatus: TargetStatusConfig cannot be nil")
+		return nil, errors.New("TargetStatusConfig cannot be nil")
+	}
+	if cfg.CacheTTL < 0 {
+		return nil, errors.New("invalid target status config: cacheTTL cannot be negative")
+	}
+	ttl := cfg.CacheTTL
+	if ttl == 0 {
+		ttl = defaultTargetStatusCacheTTL
+	}
+	return &targetStatus{registry: registry, ttl: ttl, now: time.Now, entries: map[string]targetStatusEntry{}}, nil
+}
+
+// Check returns ErrTargetSuspended or ErrTargetExpired if the request is addressed to a BPP
+// that is registered for the domain but has no valid SUBSCRIBED subscription.
+// Requests without a bpp_id, to unknown subscribers, or whose target cannot be looked up
+// pass, and are left to the lookup of the fanout.
+func (t *targetStatus) Check(ctx context.Context, reqCtx *model.Context) error {
+	if reqCtx.BppID == "" {
+		return nil
+	}
+	key := reqCtx.BppID + "|" + reqCtx.Domain
+	now := t.now()
+	t.mu.Lock()
+	e, ok := t.entries[key]
+	t.mu.Unlock()
+	if !ok || now.After(e.expires) {
+		subs, err := t.registry.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: reqCtx.BppID, Domain: reqCtx.Domain, Type: model.RoleBPP}})
+		if err != nil {
+			slog.WarnContext(ctx, "TargetStatus: Failed to look up target subscriber", "bpp_id", reqCtx.BppID, "domain", reqCtx.Domain, "error", err)
+			targetStatusMetrics.Add("lookup_failed", 1)
+			return nil
+		}
+		e = targetStatusEntry{err: targetStatusError(subs, now), expires: now.Add(t.ttl)}
+		t.mu.Lock()
+		t.entries[key] = e
+		t.mu.Unlock()
+	}
+	if e.err != nil {
+		switch {
+		case errors.Is(e.err, ErrTargetExpired):
+			targetStatusMetrics.Add("expired", 1)
+		default:
+			targetStatusMetrics.Add("suspended", 1)
+		}
+		return fmt.Errorf("%w: %s", e.err, reqCtx.BppID)
+	}
+	return nil
+}
+
+// targetStatusError returns the error for a target with the given subscriptions, or nil if
+// one of them can receive requests or there are none.
+func targetStatusError(subs []model.Subscription, now time.Time) error {
+	if len(subs) == 0 {
+		return nil
+	}
+	expired := false
+	for _, sub := range subs {
+		switch {
+		case sub.Status == model.SubscriptionStatusSubscribed && (sub.ValidUntil.IsZero() || now.Before(sub.ValidUntil)):
+			return nil
+		case sub.Status == model.SubscriptionStatusExpired, sub.Status == model.SubscriptionStatusSubscribed:
+			expired = true
+		}
+	}
+	if expired {
+		return ErrTargetExpired
+	}
+	return ErrTargetSuspended
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// countingLookupClient counts the lookups made through it.
+type countingLookupClient struct {
+	mockLookupClient
+	calls int
+}
+
+func (c *countingLookupClient) Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
+	c.calls++
+	return c.mockLookupClient.Lookup(ctx, request)
+}
+
+func TestNewTargetStatus_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry lookupClient
+		cfg      *TargetStatusConfig
+	}{
+		{name: "nil registry", cfg: &TargetStatusConfig{}},
+		{name: "nil config", registry: &mockLookupClient{}},
+		{name: "negative cache TTL", registry: &mockLookupClient{}, cfg: &TargetStatusConfig{CacheTTL: -time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTargetStatus(tt.registry, tt.cfg); err == nil {
+				t.Error("NewTargetStatus() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTargetStatus_Check(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := func(status model.SubscriptionStatus, validUntil time.Time) model.Subscription {
+		return model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp1", Type: model.RoleBPP, Domain: "retail"}, Status: status, ValidUntil: validUntil}
+	}
+	tests := []struct {
+		name      string
+		bppID     string
+		subs      []model.Subscription
+		lookupErr error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "no bpp_id", wantCalls: 0},
+		{name: "unknown subscriber", bppID: "bpp1", wantCalls: 1},
+		{name: "subscribed", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusSubscribed, now.Add(time.Hour))}, wantCalls: 1},
+		{name: "subscribed without valid_until", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusSubscribed, time.Time{})}, wantCalls: 1},
+		{name: "one of several subscribed", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusUnsubscribed, time.Time{}), sub(model.SubscriptionStatusSubscribed, now.Add(time.Hour))}, wantCalls: 1},
+		{name: "unsubscribed", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusUnsubscribed, now.Add(time.Hour))}, wantErr: ErrTargetSuspended, wantCalls: 1},
+		{name: "invalid SSL", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusInvalidSSL, now.Add(time.Hour))}, wantErr: ErrTargetSuspended, wantCalls: 1},
+		{name: "expired status", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusExpired, now.Add(-time.Hour))}, wantErr: ErrTargetExpired, wantCalls: 1},
+		{name: "subscribed past valid_until", bppID: "bpp1", subs: []model.Subscription{sub(model.SubscriptionStatusSubscribed, now.Add(-time.Hour))}, wantErr: ErrTargetExpired, wantCalls: 1},
+		{name: "lookup failure passes", bppID: "bpp1", lookupErr: errors.New("registry down"), wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &countingLookupClient{mockLookupClient: mockLookupClient{subscriptions: tt.subs, err: tt.lookupErr}}
+			ts, err := NewTargetStatus(registry, &TargetStatusConfig{})
+			if err != nil {
+				t.Fatalf("NewTargetStatus() error = %v", err)
+			}
+			ts.now = func() time.Time { return now }
+
+			err = ts.Check(context.Background(), &model.Context{BppID: tt.bppID, Domain: "retail"})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if registry.calls != tt.wantCalls {
+				t.Errorf("Lookup() calls = %d, want %d", registry.calls, tt.wantCalls)
+			}
+			if tt.wantCalls == 0 {
+				return
+			}
+			want := &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp1", Domain: "retail", Type: model.RoleBPP}}
+			if diff := cmp.Diff(want, registry.gotRequest); diff != "" {
+				t.Errorf("Lookup() filter mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTargetStatus_Check_Cache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	registry := &countingLookupClient{mockLookupClient: mockLookupClient{subscriptions: []model.Subscription{{Status: model.SubscriptionStatusUnsubscribed}}}}
+	ts, err := NewTargetStatus(registry, &TargetStatusConfig{CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewTargetStatus() error = %v", err)
+	}
+	ts.now = func() time.Time { return now }
+	reqCtx := &model.Context{BppID: "bpp1", Domain: "retail"}
+
+	for range 2 {
+		if err := ts.Check(context.Background(), reqCtx); !errors.Is(err, ErrTargetSuspended) {
+			t.Fatalf("Check() error = %v, want %v", err, ErrTargetSuspended)
+		}
+	}
+	if registry.calls != 1 {
+		t.Errorf("Lookup() calls = %d, want 1 while cached", registry.calls)
+	}
+
+	// The subscriber is reinstated; its new status is seen once the cached one expires.
+	registry.subscriptions = []model.Subscription{{Status: model.SubscriptionStatusSubscribed}}
+	now = now.Add(2 * time.Minute)
+	if err := ts.Check(context.Background(), reqCtx); err != nil {
+		t.Errorf("Check() after expiry error = %v, want nil", err)
+	}
+	if registry.calls != 2 {
+		t.Errorf("Lookup() calls = %d, want 2 after expiry", registry.calls)
+	}
+}
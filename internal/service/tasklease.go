@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TaskLeaseConfig configures the claims that let gateway replicas share one request journal.
+// Every journaled task is claimed by one replica, which renews the claim until the task is
+// processed; tasks whose claim lapses, e.g. because their replica crashed, are recovered by
+// another replica.
+type TaskLeaseConfig struct {
+	// TTL is how long a claim is held without being renewed.
+	TTL time.Duration `yaml:"ttl"`
+	// RenewInterval is how often held claims are renewed. It must be shorter than TTL.
+	RenewInterval time.Duration `yaml:"renewInterval"`
+	// RecoveryInterval is how often the journal is scanned for orphaned tasks.
+	RecoveryInterval time.Duration `yaml:"recoveryInterval"`
+	// KeyPrefix is prepended to the journal entry ID to form the Redis key of a claim.
+	KeyPrefix string `yaml:"keyPrefix"`
+}
+
+const (
+	defaultTaskLeaseTTL       = 30 * time.Second
+	defaultTaskLeaseKeyPrefix = "gateway:lease:"
+)
+
+// validate checks the config and applies defaults.
+func (c *TaskLeaseConfig) validate() error {
+	if c.TTL < 0 || c.RenewInterval < 0 || c.RecoveryInterval < 0 {
+		return errors.New("task lease durations cannot be negative")
+	}
+	if c.TTL == 0 {
+		c.TTL = defaultTaskLeaseTTL
+	}
+	if c.RenewInterval == 0 {
+		c.RenewInterval = c.TTL / 3
+	}
+	if c.RenewInterval >= c.TTL {
+		return fmt.Errorf("task lease renewInterval %s must be shorter than ttl %s", c.RenewInterval, c.TTL)
+	}
+	if c.RecoveryInterval == 0 {
+		c.RecoveryInterval = c.TTL
+	}
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = defaultTaskLeaseKeyPrefix
+	}
+	return nil
+}
+
+// redisLocker is the subset of the Redis client used by the task lease.
+type redisLocker interface {
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) *redis.BoolCmd
+	Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd
+}
+
+// renewLeaseScript extends a claim held by the owner in ARGV[1] to ARGV[2] milliseconds.
+// A claim that lapsed without being taken over is acquired again.
+const renewLeaseScript = `
+local owner = redis.call('GET', KEYS[1])
+if owner == false or owner == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0`
+
+// releaseLeaseScript deletes a claim if it is held by the owner in ARGV[1].
+const releaseLeaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`
+
+// redisTaskLease claims journal entries with Redis keys that expire unless renewed.
+type redisTaskLease struct {
+	client redisLocker
+	cfg    *TaskLeaseConfig
+	owner  string
+}
+
+// NewRedisTaskLease creates a task lease held on behalf of this replica.
+// Defaults are applied to cfg, which is shared with the task queue.
+func NewRedisTaskLease(client redisLocker, cfg *TaskLeaseConfig) (*redisTaskLease, error) {
+	if client == nil {
+		slog.Error("NewRedisTaskLease: client cannot be nil")
+		return nil, errors.New("redis client cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewRedisTaskLease: config cannot be nil")
+		return nil, errors.New("task lease config cannot be nil")
+	}
+	if err := cfg.validate(); err != nil {
+		slog.Error("NewRedisTaskLease: Invalid config", "error", err)
+		return nil, err
+	}
+	owner, err := leaseOwner()
+	if err != nil {
+		return nil, err
+	}
+	return &redisTaskLease{client: client, cfg: cfg, owner: owner}, nil
+}
+
+// leaseOwner identifies this replica by its hostname and a random suffix,
+// so that a restarted replica does not inherit the claims of its predecessor.
+func leaseOwner() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "gateway"
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease owner: %w", err)
+	}
+	return host + "-" + hex.EncodeToString(b), nil
+}
+
+func (l *redisTaskLease) key(id string) string {
+	return l.cfg.KeyPrefix + id
+}
+
+// Claim claims the journal entry for this replica. It reports false if
+// another replica holds the claim.
+func (l *redisTaskLease) Claim(ctx context.Context, id string) (bool, error) {
+	ok, err := l.client.SetNX(ctx, l.key(id), l.owner, l.cfg.TTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim journal entry %s: %w", id, err)
+	}
+	return ok, nil
+}
+
+// Renew extends the claim of this replica on the journal entry. It reports
+// false if the claim was taken over by another replica.
+func (l *redisTaskLease) Renew(ctx context.Context, id string) (bool, error) {
+	n, err := l.client.Eval(ctx, renewLeaseScript, []string{l.key(id)}, l.owner, l.cfg.TTL.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew claim on journal entry %s: %w", id, err)
+	}
+	return n == 1, nil
+}
+
+// Release gives up the claim of this replica on the journal entry, so that
+// another replica can recover it without waiting for the claim to expire.
+func (l *redisTaskLease) Release(ctx context.Context, id string) error {
+	if err := l.client.Eval(ctx, releaseLeaseScript, []string{l.key(id)}, l.owner).Err(); err != nil {
+		return fmt.Errorf("failed to release claim on journal entry %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTaskLeaseConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *TaskLeaseConfig
+		want    *TaskLeaseConfig
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			cfg:  &TaskLeaseConfig{},
+			want: &TaskLeaseConfig{TTL: 30 * time.Second, RenewInterval: 10 * time.Second, RecoveryInterval: 30 * time.Second, KeyPrefix: "gateway:lease:"},
+		},
+		{
+			name: "explicit values",
+			cfg:  &TaskLeaseConfig{TTL: time.Minute, RenewInterval: 15 * time.Second, RecoveryInterval: 5 * time.Minute, KeyPrefix: "gw:"},
+			want: &TaskLeaseConfig{TTL: time.Minute, RenewInterval: 15 * time.Second, RecoveryInterval: 5 * time.Minute, KeyPrefix: "gw:"},
+		},
+		{name: "renew interval not shorter than ttl", cfg: &TaskLeaseConfig{TTL: 10 * time.Second, RenewInterval: 10 * time.Second}, wantErr: true},
+		{name: "negative ttl", cfg: &TaskLeaseConfig{TTL: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tt.want, tt.cfg); diff != "" {
+					t.Errorf("validate() config mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRedisTaskLease(t *testing.T) {
+	client, _ := redismock.NewClientMock()
+	tests := []struct {
+		name    string
+		client  redisLocker
+		cfg     *TaskLeaseConfig
+		wantErr bool
+	}{
+		{name: "success", client: client, cfg: &TaskLeaseConfig{}},
+		{name: "nil client", cfg: &TaskLeaseConfig{}, wantErr: true},
+		{name: "nil config", client: client, wantErr: true},
+		{name: "invalid config", client: client, cfg: &TaskLeaseConfig{TTL: time.Second, RenewInterval: time.Minute}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewRedisTaskLease(tt.client, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRedisTaskLease() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && l.owner == "" {
+				t.Error("NewRedisTaskLease() owner is empty")
+			}
+		})
+	}
+}
+
+func TestRedisTaskLease(t *testing.T) {
+	ctx := context.Background()
+	cfg := &TaskLeaseConfig{TTL: 30 * time.Second, KeyPrefix: "lease:"}
+
+	t.Run("claim", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		l := &redisTaskLease{client: client, cfg: cfg, owner: "gw-1"}
+		mock.ExpectSetNX("lease:1-0", "gw-1", 30*time.Second).SetVal(true)
+		mock.ExpectSetNX("lease:2-0", "gw-1", 30*time.Second).SetVal(false)
+		mock.ExpectSetNX("lease:3-0", "gw-1", 30*time.Second).SetErr(errors.New("redis down"))
+
+		if ok, err := l.Claim(ctx, "1-0"); !ok || err != nil {
+			t.Errorf("Claim() = %v, %v, want true, nil", ok, err)
+		}
+		if ok, err := l.Claim(ctx, "2-0"); ok || err != nil {
+			t.Errorf("Claim() of a held entry = %v, %v, want false, nil", ok, err)
+		}
+		if _, err := l.Claim(ctx, "3-0"); err == nil {
+			t.Error("Claim() error = nil, want error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("renew", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		l := &redisTaskLease{client: client, cfg: cfg, owner: "gw-1"}
+		mock.ExpectEval(renewLeaseScript, []string{"lease:1-0"}, "gw-1", int64(30000)).SetVal(int64(1))
+		mock.ExpectEval(renewLeaseScript, []string{"lease:2-0"}, "gw-1", int64(30000)).SetVal(int64(0))
+		mock.ExpectEval(renewLeaseScript, []string{"lease:3-0"}, "gw-1", int64(30000)).SetErr(errors.New("redis down"))
+
+		if ok, err := l.Renew(ctx, "1-0"); !ok || err != nil {
+			t.Errorf("Renew() = %v, %v, want true, nil", ok, err)
+		}
+		if ok, err := l.Renew(ctx, "2-0"); ok || err != nil {
+			t.Errorf("Renew() of a taken over entry = %v, %v, want false, nil", ok, err)
+		}
+		if _, err := l.Renew(ctx, "3-0"); err == nil {
+			t.Error("Renew() error = nil, want error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		l := &redisTaskLease{client: client, cfg: cfg, owner: "gw-1"}
+		mock.ExpectEval(releaseLeaseScript, []string{"lease:1-0"}, "gw-1").SetVal(int64(1))
+		mock.ExpectEval(releaseLeaseScript, []string{"lease:2-0"}, "gw-1").SetErr(errors.New("redis down"))
+
+		if err := l.Release(ctx, "1-0"); err != nil {
+			t.Errorf("Release() error = %v", err)
+		}
+		if err := l.Release(ctx, "2-0"); err == nil {
+			t.Error("Release() error = nil, want error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// defaultTaskLogSampleRate is the sample rate of successful task logs if none is configured.
+const defaultTaskLogSampleRate = 100
+
+// TaskLogConfig configures the structured log written for each fanout proxy task.
+type TaskLogConfig struct {
+	// SampleRate logs one in SampleRate successful tasks. Failed tasks are always logged. Defaults to 100.
+	SampleRate int `yaml:"sampleRate"`
+}
+
+// taskAttemptsKey is the context key of the number of HTTP attempts made for a task.
+type taskAttemptsKey struct{}
+
+// taskLogger writes a single structured log per proxy task, tagged with the fanout it
+// belongs to, its target, the number of attempts and its duration.
+type taskLogger struct {
+	rate      uint64
+	successes atomic.Uint64
+}
+
+// NewTaskLogger creates a new taskLogger.
+func NewTaskLogger(cfg *TaskLogConfig) (*taskLogger, error) {
+	if cfg == nil {
+		slog.Error("NewTaskLogger: TaskLogConfig cannot be nil")
+		return nil, errors.New("TaskLogConfig cannot be nil")
+	}
+	if cfg.SampleRate < 0 {
+		return nil, errors.New("invalid task log config: sampleRate cannot be negative")
+	}
+	l := &taskLogger{rate: uint64(cfg.SampleRate)}
+	if l.rate == 0 {
+		l.rate = defaultTaskLogSampleRate
+	}
+	return l, nil
+}
+
+// withAttempts returns a context in which the HTTP client records the attempts made for a task.
+func withAttempts(ctx context.Context) (context.Context, *int) {
+	attempts := new(int)
+	return context.WithValue(ctx, taskAttemptsKey{}, attempts), attempts
+}
+
+// recordAttempt records the 1-based attempt number of a request made with ctx.
+func recordAttempt(ctx context.Context, attempt int) {
+	if attempts, ok := ctx.Value(taskAttemptsKey{}).(*int); ok {
+		*attempts = attempt
+	}
+}
+
+// log logs the outcome of a task. Failures are always logged, successes one in rate.
+func (l *taskLogger) log(ctx context.Context, task *model.AsyncTask, attempts int, d time.Duration, err error) {
+	attrs := []any{"fanout_id", task.Context.MessageID, "target", task.Target.String(), "attempt", attempts, "duration", d}
+	if err != nil {
+		slog.WarnContext(ctx, "ProxyTaskProcessor: Task failed", append(attrs, "error", err)...)
+		return
+	}
+	if (l.successes.Add(1)-1)%l.rate != 0 {
+		return
+	}
+	slog.InfoContext(ctx, "ProxyTaskProcessor: Task succeeded", append(attrs, "sample_rate", l.rate)...)
+}
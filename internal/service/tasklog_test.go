@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// captureLogs redirects the default logger to a buffer until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	return &buf
+}
+
+// taskLogEntries decodes the task log entries written to buf.
+func taskLogEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to decode log entry %q: %v", line, err)
+		}
+		if msg := entry["msg"]; msg == "ProxyTaskProcessor: Task succeeded" || msg == "ProxyTaskProcessor: Task failed" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestNewTaskLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *TaskLogConfig
+		wantRate uint64
+		wantErr  bool
+	}{
+		{name: "nil config", wantErr: true},
+		{name: "negative sample rate", cfg: &TaskLogConfig{SampleRate: -1}, wantErr: true},
+		{name: "default sample rate", cfg: &TaskLogConfig{}, wantRate: defaultTaskLogSampleRate},
+		{name: "custom sample rate", cfg: &TaskLogConfig{SampleRate: 10}, wantRate: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewTaskLogger(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewTaskLogger() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTaskLogger() unexpected error = %v", err)
+			}
+			if l.rate != tt.wantRate {
+				t.Errorf("NewTaskLogger() rate = %d, want %d", l.rate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestTaskLogger_Sampling(t *testing.T) {
+	buf := captureLogs(t)
+	l, err := NewTaskLogger(&TaskLogConfig{SampleRate: 3})
+	if err != nil {
+		t.Fatalf("NewTaskLogger() error = %v", err)
+	}
+	target, _ := url.Parse("http://bpp.example.com/search")
+	task := &model.AsyncTask{Target: target, Context: model.Context{MessageID: "msg-1"}}
+
+	for range 7 {
+		l.log(context.Background(), task, 1, time.Millisecond, nil)
+	}
+	l.log(context.Background(), task, 3, time.Second, errors.New("NACK"))
+
+	entries := taskLogEntries(t, buf)
+	if len(entries) != 4 {
+		t.Fatalf("log() wrote %d task logs, want 3 sampled successes and 1 failure: %v", len(entries), entries)
+	}
+	for _, e := range entries[:3] {
+		if e["msg"] != "ProxyTaskProcessor: Task succeeded" || e["fanout_id"] != "msg-1" || e["target"] != target.String() {
+			t.Errorf("log() success entry = %v", e)
+		}
+	}
+	if failed := entries[3]; failed["msg"] != "ProxyTaskProcessor: Task failed" || failed["attempt"] != float64(3) || failed["error"] != "NACK" {
+		t.Errorf("log() failure entry = %v", failed)
+	}
+}
+
+func TestProxyTaskProcessor_Process_TaskLog(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	defer srv.Close()
+	buf := captureLogs(t)
+
+	p, err := NewProxyTaskProcessor(&mockAuthGen{authHeader: "Signature test"}, "test-key-id", RetryConfig{RetryMax: 2, RetryWaitMin: time.Millisecond, RetryWaitMax: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+	}
+	target, _ := url.Parse(srv.URL + "/search")
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target, Body: []byte(`{}`), Headers: http.Header{}, Context: model.Context{MessageID: "msg-1"}}
+	if err := p.Process(context.Background(), task); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	entries := taskLogEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("Process() wrote %d task logs, want 1", len(entries))
+	}
+	if e := entries[0]; e["fanout_id"] != "msg-1" || e["attempt"] != float64(2) || e["duration"] == nil {
+		t.Errorf("Process() task log = %v, want fanout_id msg-1 after 2 attempts", e)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultThrottleWindow          = 100
+	defaultThrottleMinSamples      = 20
+	defaultThrottleSlowConcurrency = 1
+	defaultThrottleHighLoad        = 0.5
+	defaultThrottleProbeInterval   = 30 * time.Second
+)
+
+// throttleMetrics counts the fanout targets skipped or throttled for being slow.
+var throttleMetrics = expvar.NewMap("fanout_throttle")
+
+// ThrottleConfig configures the throttling of fanout targets whose latency exceeds a budget.
+type ThrottleConfig struct {
+	// LatencyBudget is the p95 latency above which a target host is slow. It should leave
+	// room within the TTL of the requests fanned out. Required.
+	LatencyBudget time.Duration `yaml:"latencyBudget"`
+	// Window is the number of recent requests to a host its p95 latency is computed over. Defaults to 100.
+	Window int `yaml:"window"`
+	// MinSamples is the number of requests to a host before it can be slow. Defaults to 20.
+	MinSamples int `yaml:"minSamples"`
+	// SlowConcurrency is the maximum number of concurrent requests to a slow host. Defaults to 1.
+	SlowConcurrency int `yaml:"slowConcurrency"`
+	// HighLoad is the task queue occupancy, as a fraction of its buffer size, above which
+	// fanouts skip slow hosts. Defaults to 0.5.
+	HighLoad float64 `yaml:"highLoad"`
+	// ProbeInterval is how often a skipped host is still sent a request to probe whether
+	// it has recovered. Defaults to 30s.
+	ProbeInterval time.Duration `yaml:"probeInterval"`
+}
+
+// hostLatency holds the recent latencies of requests to a host.
+type hostLatency struct {
+	samples   []time.Duration // ring buffer of the last Window latencies
+	next      int
+	slow      bool
+	sem       chan struct{} // limits concurrent requests while the host is slow
+	lastProbe time.Time
+	probing   bool
+}
+
+// p95 returns the 95th percentile of the recorded latencies.
+func (h *hostLatency) p95() time.Duration {
+	sorted := slices.Clone(h.samples)
+	slices.Sort(sorted)
+	return sorted[(len(sorted)*95+99)/100-1]
+}
+
+// latencyThrottle tracks the rolling p95 latency of each fanout target host. Slow hosts
+// get fewer concurrent requests and, while the task queue is under high load, are skipped
+// by fanouts except for periodic recovery probes.
+type latencyThrottle struct {
+	queue           queueOccupancy
+	budget          time.Duration
+	window          int
+	minSamples      int
+	slowConcurrency int
+	highLoad        float64
+	probeInterval   time.Duration
+	now             func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*hostLatency
+}
+
+// NewLatencyThrottle creates a new latencyThrottle for the fanouts of the given queue.
+func NewLatencyThrottle(queue queueOccupancy, cfg *ThrottleConfig) (*latencyThrottle, error) {
+	if queue == nil {
+		slog.Error("NewLatencyThrottle: queue cannot be nil")
+		return nil, errors.New("queue cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewLatencyThrottle: ThrottleConfig cannot be nil")
+		return nil, errors.New("ThrottleConfig cannot be nil")
+	}
+	if cfg.LatencyBudget <= 0 {
+		return nil, errors.New("invalid throttle config: latencyBudget must be positive")
+	}
+	if cfg.Window < 0 || cfg.MinSamples < 0 || cfg.SlowConcurrency < 0 || cfg.ProbeInterval < 0 || cfg.HighLoad < 0 || cfg.HighLoad > 1 {
+		return nil, errors.New("invalid throttle config: values cannot be negative and highLoad cannot exceed 1")
+	}
+	t := &latencyThrottle{
+		queue:           queue,
+		budget:          cfg.LatencyBudget,
+		window:          cfg.Window,
+		minSamples:      cfg.MinSamples,
+		slowConcurrency: cfg.SlowConcurrency,
+		highLoad:        cfg.HighLoad,
+		probeInterval:   cfg.ProbeInterval,
+		now:             time.Now,
+		hosts:           map[string]*hostLatency{},
+	}
+	if t.window == 0 {
+		t.window = defaultThrottleWindow
+	}
+	if t.minSamples == 0 {
+		t.minSamples = defaultThrottleMinSamples
+	}
+	if t.minSamples > t.window {
+		return nil, fmt.Errorf("invalid throttle config: minSamples %d cannot exceed window %d", t.minSamples, t.window)
+	}
+	if t.slowConcurrency == 0 {
+		t.slowConcurrency = defaultThrottleSlowConcurrency
+	}
+	if t.highLoad == 0 {
+		t.highLoad = defaultThrottleHighLoad
+	}
+	if t.probeInterval == 0 {
+		t.probeInterval = defaultThrottleProbeInterval
+	}
+	return t, nil
+}
+
+// host returns the latencies of the host, creating them if needed. t.mu must be held.
+func (t *latencyThrottle) host(host string) *hostLatency {
+	h, ok := t.hosts[host]
+	if !ok {
+		h = &hostLatency{samples: make([]time.Duration, 0, t.window), sem: make(chan struct{}, t.slowConcurrency)}
+		t.hosts[host] = h
+	}
+	return h
+}
+
+// highLoaded reports whether the task queue occupancy is above the high load threshold.
+func (t *latencyThrottle) highLoaded() bool {
+	queued, capacity := t.queue.Occupancy()
+	return capacity > 0 && float64(queued)/float64(capacity) >= t.highLoad
+}
+
+// Allow reports whether a fanout should send a request to the target URI. Targets on slow
+// hosts are skipped under high load, except for one probe per host every ProbeInterval.
+func (t *latencyThrottle) Allow(uri string) bool {
+	if !t.highLoaded() {
+		return true
+	}
+	host := targetHost(uri)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.hosts[host]
+	if !ok || !h.slow {
+		return true
+	}
+	if now := t.now(); now.Sub(h.lastProbe) >= t.probeInterval {
+		h.lastProbe = now
+		h.probing = true
+		throttleMetrics.Add("probes", 1)
+		return true
+	}
+	throttleMetrics.Add("skipped", 1)
+	return false
+}
+
+// Acquire waits until a request may be sent to the host, which is immediately unless the
+// host is slow. The returned func must be called once the request has completed to record
+// its latency.
+func (t *latencyThrottle) Acquire(ctx context.Context, host string) (func(), error) {
+	t.mu.Lock()
+	h := t.host(host)
+	slow, sem := h.slow, h.sem
+	t.mu.Unlock()
+
+	if slow {
+		select {
+		case sem <- struct{}{}:
+		default:
+			throttleMetrics.Add("throttled", 1)
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	start := t.now()
+	return func() {
+		t.observe(host, t.now().Sub(start))
+		if slow {
+			<-sem
+		}
+	}, nil
+}
+
+// observe records the latency of a request to the host and updates whether it is slow.
+// A probe within the budget marks a slow host as recovered right away.
+func (t *latencyThrottle) observe(host string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.host(host)
+	if h.probing {
+		h.probing = false
+		if h.slow && d <= t.budget {
+			h.samples = h.samples[:0]
+			h.next = 0
+			h.slow = false
+			throttleMetrics.Add("recovered", 1)
+			slog.Info("LatencyThrottle: Host recovered", "host", host, "latency", d)
+			return
+		}
+	}
+	if len(h.samples) < t.window {
+		h.samples = append(h.samples, d)
+	} else {
+		h.samples[h.next] = d
+		h.next = (h.next + 1) % t.window
+	}
+	if len(h.samples) < t.minSamples {
+		return
+	}
+	p95 := h.p95()
+	if slow := p95 > t.budget; slow != h.slow {
+		h.slow = slow
+		slog.Info("LatencyThrottle: Host latency crossed budget", "host", host, "p95", p95, "budget", t.budget, "slow", slow)
+	}
+}
+
+// targetHost returns the host of a target URI, or the URI itself if it has none.
+func targetHost(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return uri
+}
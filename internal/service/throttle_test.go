@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestThrottle creates a latencyThrottle with a fake clock that advances by the latency
+// of each observed request.
+func newTestThrottle(t *testing.T, queue *mockQueueOccupancy, cfg *ThrottleConfig) (*latencyThrottle, *time.Time) {
+	t.Helper()
+	th, err := NewLatencyThrottle(queue, cfg)
+	if err != nil {
+		t.Fatalf("NewLatencyThrottle() unexpected error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	th.now = func() time.Time { return now }
+	return th, &now
+}
+
+// request sends a request of the given latency to host through the throttle.
+func request(t *testing.T, th *latencyThrottle, now *time.Time, host string, latency time.Duration) {
+	t.Helper()
+	done, err := th.Acquire(context.Background(), host)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error: %v", err)
+	}
+	*now = now.Add(latency)
+	done()
+}
+
+func TestNewLatencyThrottle(t *testing.T) {
+	th, err := NewLatencyThrottle(&mockQueueOccupancy{}, &ThrottleConfig{LatencyBudget: time.Second})
+	if err != nil {
+		t.Fatalf("NewLatencyThrottle() unexpected error: %v", err)
+	}
+	if th.window != defaultThrottleWindow || th.minSamples != defaultThrottleMinSamples || th.slowConcurrency != defaultThrottleSlowConcurrency ||
+		th.highLoad != defaultThrottleHighLoad || th.probeInterval != defaultThrottleProbeInterval {
+		t.Errorf("NewLatencyThrottle() = %+v, want defaults", th)
+	}
+}
+
+func TestNewLatencyThrottle_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		queue   queueOccupancy
+		cfg     *ThrottleConfig
+		wantErr string
+	}{
+		{name: "nil queue", cfg: &ThrottleConfig{LatencyBudget: time.Second}, wantErr: "queue cannot be nil"},
+		{name: "nil config", queue: &mockQueueOccupancy{}, wantErr: "ThrottleConfig cannot be nil"},
+		{name: "missing budget", queue: &mockQueueOccupancy{}, cfg: &ThrottleConfig{}, wantErr: "latencyBudget must be positive"},
+		{name: "negative window", queue: &mockQueueOccupancy{}, cfg: &ThrottleConfig{LatencyBudget: time.Second, Window: -1}, wantErr: "cannot be negative"},
+		{name: "high load above 1", queue: &mockQueueOccupancy{}, cfg: &ThrottleConfig{LatencyBudget: time.Second, HighLoad: 1.5}, wantErr: "cannot exceed 1"},
+		{name: "min samples above window", queue: &mockQueueOccupancy{}, cfg: &ThrottleConfig{LatencyBudget: time.Second, Window: 5, MinSamples: 10}, wantErr: "cannot exceed window"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewLatencyThrottle(tc.queue, tc.cfg); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("NewLatencyThrottle() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLatencyThrottle_Slow(t *testing.T) {
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		wantSlow  bool
+	}{
+		{
+			name:      "fewer than min samples",
+			latencies: []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:      "p95 within budget",
+			latencies: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond, time.Second},
+		},
+		{
+			name:      "p95 above budget",
+			latencies: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond, 2 * time.Second},
+			wantSlow:  true,
+		},
+		{
+			name:      "slow requests leave the window",
+			latencies: []time.Duration{2 * time.Second, 2 * time.Second, 100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th, now := newTestThrottle(t, &mockQueueOccupancy{}, &ThrottleConfig{LatencyBudget: time.Second, Window: 4, MinSamples: 4})
+			for _, d := range tt.latencies {
+				request(t, th, now, "bpp.example.com", d)
+			}
+			if got := th.hosts["bpp.example.com"].slow; got != tt.wantSlow {
+				t.Errorf("slow = %v, want %v", got, tt.wantSlow)
+			}
+		})
+	}
+}
+
+func TestLatencyThrottle_Allow(t *testing.T) {
+	queue := &mockQueueOccupancy{queued: 10, capacity: 100}
+	th, now := newTestThrottle(t, queue, &ThrottleConfig{LatencyBudget: time.Second, Window: 2, MinSamples: 2, ProbeInterval: time.Minute})
+	request(t, th, now, "slow.example.com", 3*time.Second)
+	request(t, th, now, "slow.example.com", 3*time.Second)
+	request(t, th, now, "fast.example.com", 100*time.Millisecond)
+
+	if !th.Allow("https://slow.example.com/beckn") {
+		t.Errorf("Allow() = false for a slow host under low load, want true")
+	}
+
+	queue.queued = 60
+	if !th.Allow("https://fast.example.com/beckn") {
+		t.Errorf("Allow() = false for a fast host under high load, want true")
+	}
+	if !th.Allow("https://unknown.example.com/beckn") {
+		t.Errorf("Allow() = false for an unknown host under high load, want true")
+	}
+	if !th.Allow("https://slow.example.com/beckn") {
+		t.Errorf("Allow() = false for the first probe of a slow host, want true")
+	}
+	if th.Allow("https://slow.example.com/other") {
+		t.Errorf("Allow() = true for a slow host between probes, want false")
+	}
+	*now = now.Add(time.Minute)
+	if !th.Allow("https://slow.example.com/beckn") {
+		t.Errorf("Allow() = false for a slow host once the probe interval passed, want true")
+	}
+}
+
+func TestLatencyThrottle_ProbeRecovery(t *testing.T) {
+	tests := []struct {
+		name         string
+		probeLatency time.Duration
+		wantSlow     bool
+	}{
+		{name: "probe within budget recovers", probeLatency: 100 * time.Millisecond},
+		{name: "slow probe keeps the host slow", probeLatency: 3 * time.Second, wantSlow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &mockQueueOccupancy{queued: 90, capacity: 100}
+			th, now := newTestThrottle(t, queue, &ThrottleConfig{LatencyBudget: time.Second, Window: 2, MinSamples: 2})
+			request(t, th, now, "bpp.example.com", 3*time.Second)
+			request(t, th, now, "bpp.example.com", 3*time.Second)
+
+			if !th.Allow("https://bpp.example.com/beckn") {
+				t.Fatalf("Allow() = false for the probe, want true")
+			}
+			request(t, th, now, "bpp.example.com", tt.probeLatency)
+			if got := th.hosts["bpp.example.com"].slow; got != tt.wantSlow {
+				t.Errorf("slow = %v, want %v", got, tt.wantSlow)
+			}
+			if got := th.Allow("https://bpp.example.com/beckn"); got == tt.wantSlow {
+				t.Errorf("Allow() after probe = %v, want %v", got, !tt.wantSlow)
+			}
+		})
+	}
+}
+
+func TestLatencyThrottle_Acquire_LimitsSlowHost(t *testing.T) {
+	th, now := newTestThrottle(t, &mockQueueOccupancy{}, &ThrottleConfig{LatencyBudget: time.Second, Window: 2, MinSamples: 2, SlowConcurrency: 1})
+	request(t, th, now, "bpp.example.com", 3*time.Second)
+	request(t, th, now, "bpp.example.com", 3*time.Second)
+
+	done, err := th.Acquire(context.Background(), "bpp.example.com")
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error: %v", err)
+	}
+	if _, err := th.Acquire(context.Background(), "other.example.com"); err != nil {
+		t.Errorf("Acquire() for another host unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := th.Acquire(ctx, "bpp.example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() over the slow concurrency error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done()
+	if _, err := th.Acquire(context.Background(), "bpp.example.com"); err != nil {
+		t.Errorf("Acquire() after release unexpected error: %v", err)
+	}
+}
+
+func TestTargetHost(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "https://bpp.example.com:8443/beckn", want: "bpp.example.com:8443"},
+		{uri: "not a url", want: "not a url"},
+	}
+	for _, tt := range tests {
+		if got := targetHost(tt.uri); got != tt.want {
+			t.Errorf("targetHost(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"plugin"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TransformFunc is the signature of the Transform symbol exported by transform plugins.
+// It modifies the decoded payload in place.
+type TransformFunc = func(ctx context.Context, target *url.URL, action string, payload map[string]any) error
+
+// TransformConfig configures a mutation of the payloads the gateway forwards, for
+// network-specific quirks of some targets. Fields are set first, then removed,
+// then the plugin, if any, is called.
+type TransformConfig struct {
+	// Name identifies the transform in logs and errors.
+	Name string `yaml:"name"`
+	// Targets limits the transform to these target hosts. An entry of the form
+	// "*.example.com" matches any subdomain of example.com. Empty matches all targets.
+	Targets []string `yaml:"targets"`
+	// Actions limits the transform to these Beckn actions. Empty matches all actions.
+	Actions []string `yaml:"actions"`
+	// Set maps dotted payload paths, e.g. "context.ttl", to CEL expressions whose results
+	// are written there. Expressions see the payload before the transform as payload,
+	// the target URL as target and the Beckn action as action.
+	Set map[string]string `yaml:"set"`
+	// Remove lists dotted payload paths to delete.
+	Remove []string `yaml:"remove"`
+	// Plugin is the path to a Go plugin exporting a Transform function of type TransformFunc.
+	Plugin string `yaml:"plugin"`
+}
+
+// openTransformPlugin loads the Transform function of a Go plugin.
+var openTransformPlugin = func(path string) (TransformFunc, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Transform")
+	if err != nil {
+		return nil, err
+	}
+	switch fn := sym.(type) {
+	case TransformFunc:
+		return fn, nil
+	case *TransformFunc:
+		return *fn, nil
+	}
+	return nil, fmt.Errorf("symbol Transform has type %T, want %T", sym, TransformFunc(nil))
+}
+
+// fieldSetter writes the result of a CEL program to a payload path.
+type fieldSetter struct {
+	path []string
+	prg  cel.Program
+}
+
+// payloadTransform is a compiled TransformConfig.
+type payloadTransform struct {
+	name    string
+	targets []string
+	actions []string
+	set     []fieldSetter
+	remove  [][]string
+	plugin  TransformFunc
+}
+
+// payloadTransformer applies the configured transforms to outbound payloads.
+type payloadTransformer struct {
+	transforms []*payloadTransform
+}
+
+// NewPayloadTransformer compiles the given transform configs.
+func NewPayloadTransformer(cfgs []TransformConfig) (*payloadTransformer, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("payload", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("target", cel.StringType),
+		cel.Variable("action", cel.StringType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	t := &payloadTransformer{}
+	for i, cfg := range cfgs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("transform-%d", i)
+		}
+		tr, err := newPayloadTransform(env, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform %q: %w", cfg.Name, err)
+		}
+		t.transforms = append(t.transforms, tr)
+	}
+	if len(t.transforms) > 0 {
+		slog.Info("NewPayloadTransformer: Payload transforms enabled", "count", len(t.transforms))
+	}
+	return t, nil
+}
+
+// newPayloadTransform compiles the CEL expressions and loads the plugin of a transform.
+func newPayloadTransform(env *cel.Env, cfg TransformConfig) (*payloadTransform, error) {
+	if len(cfg.Set) == 0 && len(cfg.Remove) == 0 && cfg.Plugin == "" {
+		return nil, errors.New("at least one of set, remove or plugin is required")
+	}
+	for _, d := range cfg.Targets {
+		if strings.TrimPrefix(d, "*.") == "" || strings.Contains(strings.TrimPrefix(d, "*."), "*") {
+			return nil, fmt.Errorf("invalid target: %q", d)
+		}
+	}
+	tr := &payloadTransform{name: cfg.Name, targets: cfg.Targets, actions: cfg.Actions}
+	paths := make([]string, 0, len(cfg.Set))
+	for path := range cfg.Set {
+		paths = append(paths, path)
+	}
+	// Sort so that fields are set in the same order on every request.
+	slices.Sort(paths)
+	for _, path := range paths {
+		p, err := splitPayloadPath(path)
+		if err != nil {
+			return nil, err
+		}
+		ast, iss := env.Compile(cfg.Set[path])
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("failed to compile expression for %q: %w", path, iss.Err())
+		}
+		prg, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create program for %q: %w", path, err)
+		}
+		tr.set = append(tr.set, fieldSetter{path: p, prg: prg})
+	}
+	for _, path := range cfg.Remove {
+		p, err := splitPayloadPath(path)
+		if err != nil {
+			return nil, err
+		}
+		tr.remove = append(tr.remove, p)
+	}
+	if cfg.Plugin != "" {
+		fn, err := openTransformPlugin(cfg.Plugin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load plugin %s: %w", cfg.Plugin, err)
+		}
+		tr.plugin = fn
+	}
+	return tr, nil
+}
+
+// splitPayloadPath splits a dotted payload path into its keys.
+func splitPayloadPath(path string) ([]string, error) {
+	keys := strings.Split(path, ".")
+	if slices.Contains(keys, "") {
+		return nil, fmt.Errorf("invalid payload path: %q", path)
+	}
+	return keys, nil
+}
+
+// matches reports whether the transform applies to the target and action.
+func (tr *payloadTransform) matches(target *url.URL, action string) bool {
+	if len(tr.actions) > 0 && !slices.Contains(tr.actions, action) {
+		return false
+	}
+	if len(tr.targets) == 0 {
+		return true
+	}
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	for _, d := range tr.targets {
+		if hostMatches(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply mutates payload according to the transform.
+func (tr *payloadTransform) apply(ctx context.Context, target *url.URL, action string, payload map[string]any) error {
+	if len(tr.set) > 0 {
+		vars := map[string]any{"payload": celValue(payload), "target": target.String(), "action": action}
+		values := make([]any, len(tr.set))
+		for i, s := range tr.set {
+			out, _, err := s.prg.ContextEval(ctx, vars)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate expression for %q: %w", strings.Join(s.path, "."), err)
+			}
+			v, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+			if err != nil {
+				return fmt.Errorf("expression for %q does not evaluate to a JSON value: %w", strings.Join(s.path, "."), err)
+			}
+			values[i] = v.(*structpb.Value).AsInterface()
+		}
+		for i, s := range tr.set {
+			if err := setPayloadField(payload, s.path, values[i]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range tr.remove {
+		removePayloadField(payload, path)
+	}
+	if tr.plugin != nil {
+		if err := tr.plugin(ctx, target, action, payload); err != nil {
+			return fmt.Errorf("plugin failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// setPayloadField writes value at path, creating intermediate objects as needed.
+func setPayloadField(payload map[string]any, path []string, value any) error {
+	m := payload
+	for i, key := range path[:len(path)-1] {
+		next, ok := m[key]
+		if !ok || next == nil {
+			child := map[string]any{}
+			m[key] = child
+			m = child
+			continue
+		}
+		if m, ok = next.(map[string]any); !ok {
+			return fmt.Errorf("cannot set %q: %q is not an object", strings.Join(path, "."), strings.Join(path[:i+1], "."))
+		}
+	}
+	m[path[len(path)-1]] = value
+	return nil
+}
+
+// removePayloadField deletes the field at path, if present.
+func removePayloadField(payload map[string]any, path []string) {
+	m := payload
+	for _, key := range path[:len(path)-1] {
+		var ok bool
+		if m, ok = m[key].(map[string]any); !ok {
+			return
+		}
+	}
+	delete(m, path[len(path)-1])
+}
+
+// celValue converts the json.Number values of a decoded payload to float64, which CEL understands.
+func celValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = celValue(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = celValue(e)
+		}
+		return s
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// Transform applies the transforms matching the target and action to body. It returns
+// the transformed body and whether any transform applied. The body is returned unchanged
+// if none did.
+func (t *payloadTransformer) Transform(ctx context.Context, target *url.URL, action string, body []byte) ([]byte, bool, error) {
+	var payload map[string]any
+	applied := false
+	for _, tr := range t.transforms {
+		if !tr.matches(target, action) {
+			continue
+		}
+		if payload == nil {
+			// Numbers are decoded as json.Number so that fields not touched by a transform keep their exact representation.
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			if err := dec.Decode(&payload); err != nil {
+				return nil, false, fmt.Errorf("failed to decode payload: %w", err)
+			}
+			if payload == nil {
+				return nil, false, errors.New("payload is not a JSON object")
+			}
+		}
+		if err := tr.apply(ctx, target, action, payload); err != nil {
+			return nil, false, fmt.Errorf("transform %q: %w", tr.name, err)
+		}
+		slog.DebugContext(ctx, "PayloadTransformer: Applied transform", "transform", tr.name, "target", target.String(), "action", action)
+		applied = true
+	}
+	if !applied {
+		return body, false, nil
+	}
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode transformed payload: %w", err)
+	}
+	return out, true, nil
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPayloadTransformer_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgs    []TransformConfig
+		wantErr string
+	}{
+		{
+			name:    "no mutation",
+			cfgs:    []TransformConfig{{Name: "empty", Targets: []string{"bpp.example.com"}}},
+			wantErr: `invalid transform "empty": at least one of set, remove or plugin is required`,
+		},
+		{
+			name:    "default name",
+			cfgs:    []TransformConfig{{}},
+			wantErr: `invalid transform "transform-0"`,
+		},
+		{
+			name:    "invalid target",
+			cfgs:    []TransformConfig{{Name: "t", Targets: []string{"*.*.example.com"}, Remove: []string{"a"}}},
+			wantErr: `invalid target: "*.*.example.com"`,
+		},
+		{
+			name:    "invalid path",
+			cfgs:    []TransformConfig{{Name: "t", Remove: []string{"context..ttl"}}},
+			wantErr: `invalid payload path: "context..ttl"`,
+		},
+		{
+			name:    "invalid expression",
+			cfgs:    []TransformConfig{{Name: "t", Set: map[string]string{"context.ttl": "'PT30S"}}},
+			wantErr: `failed to compile expression for "context.ttl"`,
+		},
+		{
+			name:    "unknown variable",
+			cfgs:    []TransformConfig{{Name: "t", Set: map[string]string{"context.ttl": "ttl"}}},
+			wantErr: `failed to compile expression for "context.ttl"`,
+		},
+		{
+			name:    "plugin not found",
+			cfgs:    []TransformConfig{{Name: "t", Plugin: "/nonexistent/transform.so"}},
+			wantErr: "failed to load plugin /nonexistent/transform.so",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPayloadTransformer(tc.cfgs)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("NewPayloadTransformer() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayloadTransformer_Transform(t *testing.T) {
+	body := `{"context":{"action":"search","bap_id":"bap.example.com","ttl":"PT10S","count":12345678901},"message":{"intent":{"tags":["a"],"item":{"id":"1"}}}}`
+	tests := []struct {
+		name        string
+		cfgs        []TransformConfig
+		target      string
+		action      string
+		wantChanged bool
+		want        string
+	}{
+		{
+			name: "set fields",
+			cfgs: []TransformConfig{{
+				Set: map[string]string{
+					"context.bpp_id":    "'bpp.example.com'",
+					"context.ttl":       "'PT30S'",
+					"context.from_bap":  "payload.context.bap_id + '/' + action",
+					"message.meta.url":  "target",
+					"context.attempt":   "1",
+					"context.transform": "{'by': 'gateway', 'count': payload.context.count}",
+				},
+			}},
+			target:      "https://bpp.example.com/search",
+			action:      "search",
+			wantChanged: true,
+			want:        `{"context":{"action":"search","attempt":1,"bap_id":"bap.example.com","bpp_id":"bpp.example.com","count":12345678901,"from_bap":"bap.example.com/search","transform":{"by":"gateway","count":12345678901},"ttl":"PT30S"},"message":{"intent":{"item":{"id":"1"},"tags":["a"]},"meta":{"url":"https://bpp.example.com/search"}}}`,
+		},
+		{
+			name:        "remove fields",
+			cfgs:        []TransformConfig{{Remove: []string{"message.intent.tags", "context.missing", "missing.path"}}},
+			target:      "https://bpp.example.com/search",
+			action:      "search",
+			wantChanged: true,
+			want:        `{"context":{"action":"search","bap_id":"bap.example.com","count":12345678901,"ttl":"PT10S"},"message":{"intent":{"item":{"id":"1"}}}}`,
+		},
+		{
+			name: "transforms apply in order",
+			cfgs: []TransformConfig{
+				{Set: map[string]string{"context.ttl": "'PT30S'"}},
+				{Set: map[string]string{"context.bpp_id": "payload.context.ttl"}, Remove: []string{"context.ttl"}},
+			},
+			target:      "https://bpp.example.com/search",
+			action:      "search",
+			wantChanged: true,
+			want:        `{"context":{"action":"search","bap_id":"bap.example.com","bpp_id":"PT30S","count":12345678901},"message":{"intent":{"item":{"id":"1"},"tags":["a"]}}}`,
+		},
+		{
+			name:        "wildcard target matches",
+			cfgs:        []TransformConfig{{Targets: []string{"*.example.com"}, Remove: []string{"message"}}},
+			target:      "https://BPP.Example.com./search",
+			action:      "search",
+			wantChanged: true,
+			want:        `{"context":{"action":"search","bap_id":"bap.example.com","count":12345678901,"ttl":"PT10S"}}`,
+		},
+		{
+			name:   "other target is unchanged",
+			cfgs:   []TransformConfig{{Targets: []string{"bpp.example.com"}, Remove: []string{"message"}}},
+			target: "https://other.example.org/search",
+			action: "search",
+			want:   body,
+		},
+		{
+			name:   "other action is unchanged",
+			cfgs:   []TransformConfig{{Actions: []string{"select"}, Remove: []string{"message"}}},
+			target: "https://bpp.example.com/search",
+			action: "search",
+			want:   body,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr, err := NewPayloadTransformer(tc.cfgs)
+			if err != nil {
+				t.Fatalf("NewPayloadTransformer() error = %v", err)
+			}
+			target, _ := url.Parse(tc.target)
+
+			got, changed, err := tr.Transform(context.Background(), target, tc.action, []byte(body))
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			if changed != tc.wantChanged {
+				t.Errorf("Transform() changed = %v, want %v", changed, tc.wantChanged)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("Transform() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPayloadTransformer_Transform_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TransformConfig
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "invalid json",
+			cfg:     TransformConfig{Name: "t", Remove: []string{"a"}},
+			body:    `{`,
+			wantErr: "failed to decode payload",
+		},
+		{
+			name:    "not an object",
+			cfg:     TransformConfig{Name: "t", Remove: []string{"a"}},
+			body:    `null`,
+			wantErr: "payload is not a JSON object",
+		},
+		{
+			name:    "missing field in expression",
+			cfg:     TransformConfig{Name: "t", Set: map[string]string{"a": "payload.missing"}},
+			body:    `{}`,
+			wantErr: `transform "t": failed to evaluate expression for "a"`,
+		},
+		{
+			name:    "set below a scalar",
+			cfg:     TransformConfig{Name: "t", Set: map[string]string{"a.b": "1"}},
+			body:    `{"a":"x"}`,
+			wantErr: `transform "t": cannot set "a.b": "a" is not an object`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr, err := NewPayloadTransformer([]TransformConfig{tc.cfg})
+			if err != nil {
+				t.Fatalf("NewPayloadTransformer() error = %v", err)
+			}
+			_, _, err = tr.Transform(context.Background(), &url.URL{Scheme: "https", Host: "bpp.example.com"}, "search", []byte(tc.body))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Transform() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayloadTransformer_Transform_Plugin(t *testing.T) {
+	orig := openTransformPlugin
+	defer func() { openTransformPlugin = orig }()
+
+	tests := []struct {
+		name    string
+		fn      TransformFunc
+		want    string
+		wantErr string
+	}{
+		{
+			name: "plugin modifies payload",
+			fn: func(ctx context.Context, target *url.URL, action string, payload map[string]any) error {
+				payload["context"].(map[string]any)["bpp_id"] = target.Hostname()
+				return nil
+			},
+			want: `{"context":{"bpp_id":"bpp.example.com"}}`,
+		},
+		{
+			name: "plugin error",
+			fn: func(ctx context.Context, target *url.URL, action string, payload map[string]any) error {
+				return errors.New("unsupported domain")
+			},
+			wantErr: `transform "quirks": plugin failed: unsupported domain`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath string
+			openTransformPlugin = func(path string) (TransformFunc, error) {
+				gotPath = path
+				return tc.fn, nil
+			}
+			tr, err := NewPayloadTransformer([]TransformConfig{{Name: "quirks", Plugin: "/plugins/quirks.so"}})
+			if err != nil {
+				t.Fatalf("NewPayloadTransformer() error = %v", err)
+			}
+			if gotPath != "/plugins/quirks.so" {
+				t.Errorf("plugin loaded from %q, want %q", gotPath, "/plugins/quirks.so")
+			}
+
+			got, _, err := tr.Transform(context.Background(), &url.URL{Scheme: "https", Host: "bpp.example.com"}, "search", []byte(`{"context":{}}`))
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("Transform() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			var gotMap, wantMap map[string]any
+			json.Unmarshal(got, &gotMap)
+			json.Unmarshal([]byte(tc.want), &wantMap)
+			if diff := cmp.Diff(wantMap, gotMap); diff != "" {
+				t.Errorf("Transform() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
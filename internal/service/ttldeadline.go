@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ErrTTLExpired is returned for tasks whose transaction TTL elapsed before they were dispatched.
+var ErrTTLExpired = errors.New("transaction ttl expired")
+
+const (
+	defaultTTLRemainingHeader = "X-Onix-TTL-Remaining"
+	defaultTTLDeadlineHeader  = "X-Onix-Deadline"
+)
+
+// ttlBudgetMetrics counts dispatched requests by the TTL budget they had left, so that
+// operators can see how much of the TTL is spent before requests reach BPPs.
+var ttlBudgetMetrics = expvar.NewMap("gateway_ttl_budget")
+
+// ttlBudgetBuckets are the upper bounds of the remaining budget buckets, in ascending order.
+var ttlBudgetBuckets = []struct {
+	name  string
+	bound time.Duration
+}{
+	{"le_1s", time.Second},
+	{"le_5s", 5 * time.Second},
+	{"le_30s", 30 * time.Second},
+}
+
+// TTLDeadlineConfig configures the propagation of the remaining transaction TTL to BPPs.
+type TTLDeadlineConfig struct {
+	// Header carries the remaining TTL as an ISO 8601 duration, in the format of context.ttl.
+	// Defaults to "X-Onix-TTL-Remaining".
+	Header string `yaml:"header"`
+	// DeadlineHeader carries the deadline as an RFC 3339 timestamp. Defaults to "X-Onix-Deadline".
+	DeadlineHeader string `yaml:"deadlineHeader"`
+	// DefaultTTL is the TTL of requests without context.ttl. Zero, the default, leaves
+	// them without a deadline.
+	DefaultTTL time.Duration `yaml:"defaultTTL"`
+}
+
+// ttlDeadline computes the deadline of a request from its context.timestamp and context.ttl.
+type ttlDeadline struct {
+	header         string
+	deadlineHeader string
+	defaultTTL     time.Duration
+	now            func() time.Time
+}
+
+// NewTTLDeadline creates a new ttlDeadline.
+func NewTTLDeadline(cfg *TTLDeadlineConfig) (*ttlDeadline, error) {
+	if cfg == nil {
+		slog.Error("NewTTLDeadline: TTLDeadlineConfig cannot be nil")
+		return nil, errors.New("TTLDeadlineConfig cannot be nil")
+	}
+	if cfg.DefaultTTL < 0 {
+		return nil, fmt.Errorf("invalid ttl deadline config: defaultTTL %v cannot be negative", cfg.DefaultTTL)
+	}
+	d := &ttlDeadline{header: cfg.Header, deadlineHeader: cfg.DeadlineHeader, defaultTTL: cfg.DefaultTTL, now: time.Now}
+	if d.header == "" {
+		d.header = defaultTTLRemainingHeader
+	}
+	if d.deadlineHeader == "" {
+		d.deadlineHeader = defaultTTLDeadlineHeader
+	}
+	return d, nil
+}
+
+// Apply sets the remaining TTL and the deadline of the transaction on the headers of a
+// request about to be dispatched, and returns a context that expires at the deadline.
+// Requests whose TTL has already elapsed get ErrTTLExpired. Requests without a valid
+// timestamp or TTL are dispatched unchanged, with a context that never expires.
+func (d *ttlDeadline) Apply(ctx context.Context, c *model.Context, h http.Header) (context.Context, context.CancelFunc, error) {
+	deadline, ok := d.deadline(ctx, c)
+	if !ok {
+		ttlBudgetMetrics.Add("no_ttl", 1)
+		return ctx, func() {}, nil
+	}
+	remaining := deadline.Sub(d.now())
+	if remaining <= 0 {
+		ttlBudgetMetrics.Add("expired", 1)
+		return ctx, func() {}, fmt.Errorf("%w: deadline %s passed %v ago", ErrTTLExpired, deadline.UTC().Format(time.RFC3339Nano), -remaining)
+	}
+	ttlBudgetMetrics.Add(ttlBudgetBucket(remaining), 1)
+	ttlBudgetMetrics.Add("dispatched", 1)
+	ttlBudgetMetrics.Add("remaining_ms_total", remaining.Milliseconds())
+	slog.DebugContext(ctx, "TTLDeadline: Dispatching request", "message_id", c.MessageID, "action", c.Action, "remaining", remaining)
+
+	h.Set(d.header, formatISO8601Duration(remaining))
+	h.Set(d.deadlineHeader, deadline.UTC().Format(time.RFC3339Nano))
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, cancel, nil
+}
+
+// deadline returns context.timestamp plus context.ttl, or the default TTL without one.
+func (d *ttlDeadline) deadline(ctx context.Context, c *model.Context) (time.Time, bool) {
+	if c.Timestamp == "" {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, c.Timestamp)
+	if err != nil {
+		slog.DebugContext(ctx, "TTLDeadline: Invalid context timestamp", "message_id", c.MessageID, "timestamp", c.Timestamp, "error", err)
+		return time.Time{}, false
+	}
+	ttl := d.defaultTTL
+	if c.TTL != "" {
+		if ttl, err = parseISO8601Duration(c.TTL); err != nil {
+			slog.DebugContext(ctx, "TTLDeadline: Invalid context ttl", "message_id", c.MessageID, "ttl", c.TTL, "error", err)
+			return time.Time{}, false
+		}
+	}
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	return ts.Add(ttl), true
+}
+
+// ttlBudgetBucket returns the metric name of the bucket of a remaining budget.
+func ttlBudgetBucket(remaining time.Duration) string {
+	for _, b := range ttlBudgetBuckets {
+		if remaining <= b.bound {
+			return b.name
+		}
+	}
+	return "gt_30s"
+}
+
+// iso8601Duration matches the time-based ISO 8601 durations used by context.ttl, e.g. PT30S or P1DT2H.
+// Years and months are not accepted, as their length depends on the date.
+var iso8601Duration = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// parseISO8601Duration parses a time-based ISO 8601 duration.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	m := iso8601Duration.FindStringSubmatch(s)
+	if m == nil || s == "P" || s[len(s)-1] == 'T' {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(m[i+1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", s, err)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[5] != "" {
+		secs, err := strconv.ParseFloat(strings.Replace(m[5], ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", s, err)
+		}
+		d += time.Duration(secs * float64(time.Second))
+	}
+	return d, nil
+}
+
+// formatISO8601Duration formats d as an ISO 8601 duration in seconds with millisecond precision, e.g. PT4.25S.
+func formatISO8601Duration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(float64(d.Round(time.Millisecond).Milliseconds())/1000, 'f', -1, 64) + "S"
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewTTLDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *TTLDeadlineConfig
+		want    *ttlDeadline
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			cfg:  &TTLDeadlineConfig{},
+			want: &ttlDeadline{header: "X-Onix-TTL-Remaining", deadlineHeader: "X-Onix-Deadline"},
+		},
+		{
+			name: "custom",
+			cfg:  &TTLDeadlineConfig{Header: "X-TTL", DeadlineHeader: "X-Deadline", DefaultTTL: 30 * time.Second},
+			want: &ttlDeadline{header: "X-TTL", deadlineHeader: "X-Deadline", defaultTTL: 30 * time.Second},
+		},
+		{name: "nil config", wantErr: true},
+		{name: "negative default ttl", cfg: &TTLDeadlineConfig{DefaultTTL: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTTLDeadline(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTTLDeadline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.now == nil {
+				t.Fatal("NewTTLDeadline() now = nil, want clock")
+			}
+			got.now = nil
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(ttlDeadline{})); diff != "" {
+				t.Errorf("NewTTLDeadline() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{in: "PT30S", want: 30 * time.Second},
+		{in: "PT1.5S", want: 1500 * time.Millisecond},
+		{in: "PT0,25S", want: 250 * time.Millisecond},
+		{in: "PT2M", want: 2 * time.Minute},
+		{in: "PT1H30M", want: 90 * time.Minute},
+		{in: "P1D", want: 24 * time.Hour},
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "P1DT2H3M4S", want: 26*time.Hour + 3*time.Minute + 4*time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseISO8601Duration(tt.in)
+			if err != nil {
+				t.Fatalf("parseISO8601Duration(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseISO8601Duration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseISO8601Duration_Error(t *testing.T) {
+	for _, in := range []string{"", "P", "PT", "P1DT", "30S", "PT30", "P1Y", "P1M", "PT-5S", "PT1S2M"} {
+		t.Run(in, func(t *testing.T) {
+			if d, err := parseISO8601Duration(in); err == nil {
+				t.Errorf("parseISO8601Duration(%q) = %v, want error", in, d)
+			}
+		})
+	}
+}
+
+func TestFormatISO8601Duration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 30 * time.Second, want: "PT30S"},
+		{in: 4250 * time.Millisecond, want: "PT4.25S"},
+		{in: 1234567 * time.Microsecond, want: "PT1.235S"},
+		{in: 2 * time.Minute, want: "PT120S"},
+	}
+	for _, tt := range tests {
+		if got := formatISO8601Duration(tt.in); got != tt.want {
+			t.Errorf("formatISO8601Duration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTTLDeadline_Apply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 0, 10, 0, time.UTC)
+	tests := []struct {
+		name         string
+		defaultTTL   time.Duration
+		c            model.Context
+		wantHeaders  http.Header
+		wantDeadline time.Time
+	}{
+		{
+			name:         "context ttl",
+			c:            model.Context{Timestamp: "2025-06-01T10:00:00Z", TTL: "PT30S"},
+			wantHeaders:  http.Header{"X-Onix-Ttl-Remaining": {"PT20S"}, "X-Onix-Deadline": {"2025-06-01T10:00:30Z"}},
+			wantDeadline: now.Add(20 * time.Second),
+		},
+		{
+			name:         "timestamp with offset",
+			c:            model.Context{Timestamp: "2025-06-01T15:30:05.5+05:30", TTL: "PT10S"},
+			wantHeaders:  http.Header{"X-Onix-Ttl-Remaining": {"PT5.5S"}, "X-Onix-Deadline": {"2025-06-01T10:00:15.5Z"}},
+			wantDeadline: now.Add(5500 * time.Millisecond),
+		},
+		{
+			name:         "default ttl",
+			defaultTTL:   time.Minute,
+			c:            model.Context{Timestamp: "2025-06-01T10:00:00Z"},
+			wantHeaders:  http.Header{"X-Onix-Ttl-Remaining": {"PT50S"}, "X-Onix-Deadline": {"2025-06-01T10:01:00Z"}},
+			wantDeadline: now.Add(50 * time.Second),
+		},
+		{name: "no ttl", c: model.Context{Timestamp: "2025-06-01T10:00:00Z"}, wantHeaders: http.Header{}},
+		{name: "no timestamp", c: model.Context{TTL: "PT30S"}, wantHeaders: http.Header{}},
+		{name: "invalid timestamp", c: model.Context{Timestamp: "yesterday", TTL: "PT30S"}, wantHeaders: http.Header{}},
+		{name: "invalid ttl", defaultTTL: time.Minute, c: model.Context{Timestamp: "2025-06-01T10:00:00Z", TTL: "30s"}, wantHeaders: http.Header{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewTTLDeadline(&TTLDeadlineConfig{DefaultTTL: tt.defaultTTL})
+			if err != nil {
+				t.Fatalf("NewTTLDeadline() error = %v", err)
+			}
+			d.now = func() time.Time { return now }
+
+			h := http.Header{}
+			ctx, cancel, err := d.Apply(context.Background(), &tt.c, h)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			defer cancel()
+			if diff := cmp.Diff(tt.wantHeaders, h); diff != "" {
+				t.Errorf("Apply() headers mismatch (-want +got):\n%s", diff)
+			}
+			got, ok := ctx.Deadline()
+			if ok != !tt.wantDeadline.IsZero() || !got.Equal(tt.wantDeadline) {
+				t.Errorf("Apply() context deadline = %v, %t, want %v", got, ok, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestTTLDeadline_Apply_Expired(t *testing.T) {
+	d, err := NewTTLDeadline(&TTLDeadlineConfig{})
+	if err != nil {
+		t.Fatalf("NewTTLDeadline() error = %v", err)
+	}
+	d.now = func() time.Time { return time.Date(2025, 6, 1, 10, 0, 45, 0, time.UTC) }
+
+	h := http.Header{}
+	ctx := context.Background()
+	gotCtx, cancel, err := d.Apply(ctx, &model.Context{Timestamp: "2025-06-01T10:00:00Z", TTL: "PT30S"}, h)
+	defer cancel()
+	if !errors.Is(err, ErrTTLExpired) {
+		t.Fatalf("Apply() error = %v, want %v", err, ErrTTLExpired)
+	}
+	if gotCtx != ctx {
+		t.Error("Apply() returned a derived context for an expired request")
+	}
+	if len(h) != 0 {
+		t.Errorf("Apply() set headers on an expired request: %v", h)
+	}
+}
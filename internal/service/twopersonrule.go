@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+var (
+	// ErrApproverRequired occurs if an operation requires two approvals but the approval
+	// carries no reviewer identity to tell the approvers apart.
+	ErrApproverRequired = errors.New("approver identity required")
+
+	// ErrDuplicateApprover occurs if an admin approves an operation that requires two approvals
+	// a second time. The second approval must come from a different admin.
+	ErrDuplicateApprover = errors.New("operation already approved by this approver")
+)
+
+// TwoPersonRuleConfig requires two distinct admins to approve high-risk operations. The first
+// approval is recorded on the operation, which stays pending in the PENDING_SECOND_APPROVAL
+// sub-state, and the second approval applies it.
+type TwoPersonRuleConfig struct {
+	// OperationTypes lists the operation types that require two approvals. Defaults to
+	// UPDATE_SUBSCRIPTION, which changes the keys or URL of an already subscribed NP.
+	OperationTypes []model.OperationType `yaml:"operationTypes"`
+}
+
+// validate checks the config and applies defaults.
+func (c *TwoPersonRuleConfig) validate(reviewer *ReviewerConfig) error {
+	if reviewer == nil || !reviewer.Required {
+		return errors.New("twoPersonRule requires reviewer.required, so that approvers can be told apart")
+	}
+	if len(c.OperationTypes) == 0 {
+		c.OperationTypes = []model.OperationType{model.OperationTypeUpdateSubscription}
+	}
+	for _, tp := range c.OperationTypes {
+		if tp != model.OperationTypeCreateSubscription && tp != model.OperationTypeUpdateSubscription {
+			return fmt.Errorf("twoPersonRule: invalid operation type %q", tp)
+		}
+	}
+	return nil
+}
+
+// requiresTwoApprovals reports whether lro may only be applied with the approval of two admins.
+func (s *adminService) requiresTwoApprovals(lro *model.LRO) bool {
+	return s.cfg.TwoPersonRule != nil && slices.Contains(s.cfg.TwoPersonRule.OperationTypes, lro.Type)
+}
+
+// recordApproval adds the approval in lro.Review to the approvals in prev, the review the
+// operation had before, and reports whether two distinct admins have now approved it.
+// The first approval is persisted and leaves the operation pending its second approval.
+// An admin of an approved pair may retry an approval whose application failed.
+func (s *adminService) recordApproval(ctx context.Context, lro *model.LRO, prev *model.OperationReview) (bool, error) {
+	approver := lro.Review.Reviewer
+	if approver == "" {
+		slog.WarnContext(ctx, "AdminService: Approval without reviewer identity for operation requiring two approvals", "operation_id", lro.OperationID)
+		return false, fmt.Errorf("%w: operation %s requires two approvals", ErrApproverRequired, lro.OperationID)
+	}
+	var approvals []model.OperationApproval
+	if prev != nil && prev.Action == model.OperationActionApproveSubscription {
+		approvals = prev.Approvals
+	}
+	if slices.ContainsFunc(approvals, func(a model.OperationApproval) bool { return a.Approver == approver }) {
+		if len(approvals) >= 2 {
+			lro.Review.Approvals = approvals
+			return true, nil
+		}
+		slog.WarnContext(ctx, "AdminService: Duplicate approval", "operation_id", lro.OperationID, "approver", approver)
+		return false, fmt.Errorf("%w: %s has already approved operation %s", ErrDuplicateApprover, approver, lro.OperationID)
+	}
+	lro.Review.Approvals = append(slices.Clone(approvals), model.OperationApproval{
+		Approver:   approver,
+		Comment:    lro.Review.Comment,
+		ApprovedAt: lro.Review.ReviewedAt,
+	})
+	if len(lro.Review.Approvals) >= 2 {
+		slog.InfoContext(ctx, "AdminService: Second approval recorded, applying operation", "operation_id", lro.OperationID, "approver", approver)
+		return true, nil
+	}
+
+	if _, err := s.regRepo.UpdateOperation(ctx, lro); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to record first approval", "operation_id", lro.OperationID, "error", err)
+		return false, fmt.Errorf("failed to record approval: %w", err)
+	}
+	lro.SubState = model.LROSubStatePendingSecondApproval
+	slog.InfoContext(ctx, "AdminService: First approval recorded, awaiting second approver", "operation_id", lro.OperationID, "approver", approver)
+	s.notify(ctx, model.EventTypeSubscriptionRequestPendingSecondApproval, lro)
+	return false, nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestTwoPersonRuleConfig_Validate(t *testing.T) {
+	required := &ReviewerConfig{Header: "X-Reviewer", Required: true}
+	tests := []struct {
+		name     string
+		cfg      *TwoPersonRuleConfig
+		reviewer *ReviewerConfig
+		want     []model.OperationType
+		wantErr  bool
+	}{
+		{name: "defaults to updates", cfg: &TwoPersonRuleConfig{}, reviewer: required, want: []model.OperationType{model.OperationTypeUpdateSubscription}},
+		{name: "explicit types", cfg: &TwoPersonRuleConfig{OperationTypes: []model.OperationType{model.OperationTypeCreateSubscription}}, reviewer: required, want: []model.OperationType{model.OperationTypeCreateSubscription}},
+		{name: "no reviewer config", cfg: &TwoPersonRuleConfig{}, wantErr: true},
+		{name: "reviewer not required", cfg: &TwoPersonRuleConfig{}, reviewer: &ReviewerConfig{Header: "X-Reviewer"}, wantErr: true},
+		{name: "invalid type", cfg: &TwoPersonRuleConfig{OperationTypes: []model.OperationType{"DELETE_SUBSCRIPTION"}}, reviewer: required, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate(tt.reviewer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tt.want, tt.cfg.OperationTypes); diff != "" {
+					t.Errorf("validate() OperationTypes mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_TwoPersonRule(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+		},
+		MessageID: "op-1",
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	aliceApproval := model.OperationApproval{Approver: "alice", Comment: "keys checked", ApprovedAt: earlier}
+	bobApproval := model.OperationApproval{Approver: "bob", ApprovedAt: now}
+	firstReview := &model.OperationReview{Reviewer: "alice", Action: model.OperationActionApproveSubscription, Comment: "keys checked", ReviewedAt: earlier, Approvals: []model.OperationApproval{aliceApproval}}
+	failedPairReview := &model.OperationReview{Reviewer: "bob", Action: model.OperationActionApproveSubscription, ReviewedAt: earlier, Approvals: []model.OperationApproval{aliceApproval, bobApproval}}
+
+	tests := []struct {
+		name          string
+		lroType       model.OperationType
+		status        model.LROStatus
+		prevReview    *model.OperationReview
+		reviewer      string
+		comment       string
+		wantErr       error
+		wantApplied   bool
+		wantSubState  model.LROSubState
+		wantApprovals []model.OperationApproval
+		wantEvents    []model.EventType
+	}{
+		{
+			name:          "first approval is recorded",
+			lroType:       model.OperationTypeUpdateSubscription,
+			status:        model.LROStatusPending,
+			reviewer:      "alice",
+			comment:       "keys checked",
+			wantSubState:  model.LROSubStatePendingSecondApproval,
+			wantApprovals: []model.OperationApproval{{Approver: "alice", Comment: "keys checked", ApprovedAt: now}},
+			wantEvents:    []model.EventType{model.EventTypeSubscriptionRequestPendingSecondApproval},
+		},
+		{
+			name:          "second approval by another admin applies",
+			lroType:       model.OperationTypeUpdateSubscription,
+			status:        model.LROStatusPending,
+			prevReview:    firstReview,
+			reviewer:      "bob",
+			wantApplied:   true,
+			wantApprovals: []model.OperationApproval{aliceApproval, bobApproval},
+		},
+		{
+			name:       "second approval by the same admin",
+			lroType:    model.OperationTypeUpdateSubscription,
+			status:     model.LROStatusPending,
+			prevReview: firstReview,
+			reviewer:   "alice",
+			wantErr:    ErrDuplicateApprover,
+		},
+		{
+			name:     "approval without reviewer identity",
+			lroType:  model.OperationTypeUpdateSubscription,
+			status:   model.LROStatusPending,
+			reviewer: "",
+			wantErr:  ErrApproverRequired,
+		},
+		{
+			name:          "retry by an approver after the application failed",
+			lroType:       model.OperationTypeUpdateSubscription,
+			status:        model.LROStatusFailure,
+			prevReview:    failedPairReview,
+			reviewer:      "alice",
+			wantApplied:   true,
+			wantApprovals: []model.OperationApproval{aliceApproval, bobApproval},
+		},
+		{
+			name:        "operation type not covered by the rule",
+			lroType:     model.OperationTypeCreateSubscription,
+			status:      model.LROStatusPending,
+			reviewer:    "alice",
+			wantApplied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: "op-1", Type: tt.lroType, Status: tt.status, RequestJSON: subReqJSON, Review: tt.prevReview}
+			mockRepo := &mockRegRepo{lroToReturn: lro, subToReturn: &model.Subscription{}, updatedLROToReturn: lro}
+			if tt.lroType == model.OperationTypeUpdateSubscription {
+				mockRepo.lookupSubsToReturn = []model.Subscription{subReq.Subscription}
+			}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{
+				OperationRetryMax: 3,
+				Reviewer:          &ReviewerConfig{Header: "X-Reviewer", Required: true},
+				TwoPersonRule:     &TwoPersonRuleConfig{},
+			}
+			srv, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+			srv.now = func() time.Time { return now }
+			notifier := &recordingNotifier{}
+			srv.SetTransitionNotifier(notifier)
+
+			req := &model.OperationActionRequest{OperationID: "op-1", Action: model.OperationActionApproveSubscription, Reviewer: tt.reviewer, Comment: tt.comment}
+			sub, gotLRO, err := srv.ApproveSubscription(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantEvents, notifier.events); diff != "" {
+				t.Errorf("ApproveSubscription() notified transitions mismatch (-want +got):\n%s", diff)
+			}
+			if applied := mockRepo.upsertCalls > 0; applied != tt.wantApplied {
+				t.Errorf("ApproveSubscription() applied = %v, want %v", applied, tt.wantApplied)
+			}
+			if tt.wantApplied && sub == nil {
+				t.Error("ApproveSubscription() subscription = nil, want the applied subscription")
+			}
+			if tt.wantErr != nil {
+				if mockRepo.updateOperationCalls != 0 {
+					t.Errorf("ApproveSubscription() updated the LRO %d times, want none", mockRepo.updateOperationCalls)
+				}
+				return
+			}
+			if gotLRO.SubState != tt.wantSubState {
+				t.Errorf("ApproveSubscription() SubState = %q, want %q", gotLRO.SubState, tt.wantSubState)
+			}
+			if diff := cmp.Diff(tt.wantApprovals, mockRepo.gotLRO.Review.Approvals); diff != "" {
+				t.Errorf("stored approvals mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewAdminService_TwoPersonRuleRequiresReviewer(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, TwoPersonRule: &TwoPersonRuleConfig{}}
+	if _, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg); err == nil {
+		t.Error("NewAdminService() error = nil, want error for two-person rule without required reviewer")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"cmp"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const defaultTxnMetricsMaxSegments = 500
+
+// otherTxnSegment collects the transactions of the segments seen after maxSegments, so
+// that requests with arbitrary contexts cannot grow the metrics without bound.
+var otherTxnSegment = model.TxnSegment{Action: "other", Domain: "other", City: "other"}
+
+// txnMetricsVars publishes the stats of each segment on the expvar endpoint (/debug/vars),
+// keyed by action/domain/city.
+var txnMetricsVars = expvar.NewMap("gateway_transactions")
+
+// latencyBucketsMS are the upper bounds of the latency histogram buckets in milliseconds.
+var latencyBucketsMS = [...]int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// TxnMetricsConfig configures the aggregation of transaction metrics per market segment.
+type TxnMetricsConfig struct {
+	// MaxSegments is the number of (action, domain, city) segments tracked. The transactions
+	// of further segments are counted under other/other/other. Defaults to 500.
+	MaxSegments int `yaml:"maxSegments"`
+}
+
+// latencyHistogram counts latencies in latencyBucketsMS buckets.
+type latencyHistogram struct {
+	buckets [len(latencyBucketsMS) + 1]int64 // the last bucket counts longer latencies
+	count   int64
+	sumMS   int64
+	maxMS   int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	ms := d.Milliseconds()
+	i, _ := slices.BinarySearch(latencyBucketsMS[:], ms)
+	h.buckets[i]++
+	h.count++
+	h.sumMS += ms
+	h.maxMS = max(h.maxMS, ms)
+}
+
+// quantile returns the upper bound of the bucket holding the q quantile, capped at the maximum.
+func (h *latencyHistogram) quantile(q float64) int64 {
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, n := range h.buckets {
+		if seen += n; seen >= rank && i < len(latencyBucketsMS) {
+			return min(latencyBucketsMS[i], h.maxMS)
+		}
+	}
+	return h.maxMS
+}
+
+func (h *latencyHistogram) summary() model.LatencySummary {
+	if h.count == 0 {
+		return model.LatencySummary{}
+	}
+	return model.LatencySummary{
+		AvgMS: float64(h.sumMS) / float64(h.count),
+		P50MS: h.quantile(0.5),
+		P95MS: h.quantile(0.95),
+		MaxMS: h.maxMS,
+	}
+}
+
+// txnStats holds the counts and latencies of a segment.
+type txnStats struct {
+	requests       int64
+	nacks          int64
+	requestLatency latencyHistogram
+	fanouts        int64
+	fanoutErrors   int64
+	fanoutLatency  latencyHistogram
+}
+
+// txnMetrics aggregates the requests received and fanned out by the gateway per
+// (action, domain, city) segment, so that operators can see the health of each market
+// segment rather than only of the gateway as a whole.
+type txnMetrics struct {
+	maxSegments int
+	since       time.Time
+
+	mu       sync.Mutex
+	segments map[model.TxnSegment]*txnStats
+}
+
+// NewTxnMetrics creates a new txnMetrics.
+func NewTxnMetrics(cfg *TxnMetricsConfig) (*txnMetrics, error) {
+	if cfg == nil {
+		slog.Error("NewTxnMetrics: TxnMetricsConfig cannot be nil")
+		return nil, errors.New("TxnMetricsConfig cannot be nil")
+	}
+	if cfg.MaxSegments < 0 {
+		return nil, fmt.Errorf("maxSegments must not be negative, got %d", cfg.MaxSegments)
+	}
+	m := &txnMetrics{maxSegments: cfg.MaxSegments, since: time.Now().UTC(), segments: map[model.TxnSegment]*txnStats{}}
+	if m.maxSegments == 0 {
+		m.maxSegments = defaultTxnMetricsMaxSegments
+	}
+	return m, nil
+}
+
+// txnSegment returns the segment of a request context. The city is its code, or its name
+// for contexts without a code.
+func txnSegment(c *model.Context) model.TxnSegment {
+	seg := model.TxnSegment{Action: c.Action, Domain: c.Domain}
+	if c.Location != nil && c.Location.City != nil {
+		seg.City = cmp.Or(c.Location.City.Code, c.Location.City.Name)
+	}
+	return seg
+}
+
+// record applies f to the stats of the segment of c.
+func (m *txnMetrics) record(c *model.Context, f func(*txnStats)) {
+	seg := txnSegment(c)
+	m.mu.Lock()
+	s, ok := m.segments[seg]
+	if !ok && len(m.segments) >= m.maxSegments {
+		seg = otherTxnSegment
+		s, ok = m.segments[seg]
+	}
+	if !ok {
+		s = &txnStats{}
+		m.segments[seg] = s
+	}
+	f(s)
+	m.mu.Unlock()
+
+	// Published outside of mu, as expvar holds its own lock while reading the stats.
+	if !ok {
+		txnMetricsVars.Set(seg.Action+"/"+seg.Domain+"/"+seg.City, expvar.Func(func() any { return m.segmentStats(seg) }))
+	}
+}
+
+// RecordRequest records a request received by the gateway, whether it was ACKed and the
+// time taken to respond.
+func (m *txnMetrics) RecordRequest(c *model.Context, acked bool, d time.Duration) {
+	m.record(c, func(s *txnStats) {
+		s.requests++
+		if !acked {
+			s.nacks++
+		}
+		s.requestLatency.observe(d)
+	})
+}
+
+// RecordFanout records a request forwarded to a network participant, the time taken
+// including retries and whether it failed.
+func (m *txnMetrics) RecordFanout(c *model.Context, d time.Duration, err error) {
+	m.record(c, func(s *txnStats) {
+		s.fanouts++
+		if err != nil {
+			s.fanoutErrors++
+		}
+		s.fanoutLatency.observe(d)
+	})
+}
+
+// segmentStats returns the stats of a segment.
+func (m *txnMetrics) segmentStats(seg model.TxnSegment) model.TxnSegmentStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statsLocked(seg, m.segments[seg])
+}
+
+func (m *txnMetrics) statsLocked(seg model.TxnSegment, s *txnStats) model.TxnSegmentStats {
+	return model.TxnSegmentStats{
+		TxnSegment:     seg,
+		Requests:       s.requests,
+		Nacks:          s.nacks,
+		RequestLatency: s.requestLatency.summary(),
+		Fanouts:        s.fanouts,
+		FanoutErrors:   s.fanoutErrors,
+		FanoutLatency:  s.fanoutLatency.summary(),
+	}
+}
+
+// Summary returns the stats of every segment since the gateway started.
+func (m *txnMetrics) Summary() *model.TxnSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sum := &model.TxnSummary{Since: m.since, Segments: make([]model.TxnSegmentStats, 0, len(m.segments))}
+	for seg, s := range m.segments {
+		sum.Segments = append(sum.Segments, m.statsLocked(seg, s))
+	}
+	slices.SortFunc(sum.Segments, func(a, b model.TxnSegmentStats) int {
+		return cmp.Or(cmp.Compare(a.Action, b.Action), cmp.Compare(a.Domain, b.Domain), cmp.Compare(a.City, b.City))
+	})
+	return sum
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func txnContext(action, domain, city string) *model.Context {
+	return &model.Context{Action: action, Domain: domain, Location: &model.Location{City: &model.City{Code: city}}}
+}
+
+func TestNewTxnMetrics(t *testing.T) {
+	m, err := NewTxnMetrics(&TxnMetricsConfig{})
+	if err != nil {
+		t.Fatalf("NewTxnMetrics() unexpected error: %v", err)
+	}
+	if m.maxSegments != defaultTxnMetricsMaxSegments {
+		t.Errorf("maxSegments = %d, want %d", m.maxSegments, defaultTxnMetricsMaxSegments)
+	}
+}
+
+func TestNewTxnMetrics_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *TxnMetricsConfig
+	}{
+		{name: "nil config"},
+		{name: "negative max segments", cfg: &TxnMetricsConfig{MaxSegments: -1}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewTxnMetrics(tc.cfg); err == nil {
+				t.Error("NewTxnMetrics() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTxnSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  *model.Context
+		want model.TxnSegment
+	}{
+		{name: "city code", ctx: txnContext("search", "ONDC:RET10", "std:080"), want: model.TxnSegment{Action: "search", Domain: "ONDC:RET10", City: "std:080"}},
+		{name: "city name", ctx: &model.Context{Action: "search", Domain: "ONDC:RET10", Location: &model.Location{City: &model.City{Name: "Bengaluru"}}}, want: model.TxnSegment{Action: "search", Domain: "ONDC:RET10", City: "Bengaluru"}},
+		{name: "no location", ctx: &model.Context{Action: "on_search", Domain: "ONDC:RET10"}, want: model.TxnSegment{Action: "on_search", Domain: "ONDC:RET10"}},
+		{name: "no city", ctx: &model.Context{Action: "search", Location: &model.Location{}}, want: model.TxnSegment{Action: "search"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := txnSegment(tc.ctx); got != tc.want {
+				t.Errorf("txnSegment() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLatencyHistogram_Summary(t *testing.T) {
+	var h latencyHistogram
+	if got := h.summary(); got != (model.LatencySummary{}) {
+		t.Errorf("summary() of empty histogram = %+v, want zero", got)
+	}
+	for i := 0; i < 18; i++ {
+		h.observe(20 * time.Millisecond)
+	}
+	h.observe(300 * time.Millisecond)
+	h.observe(45 * time.Second)
+
+	want := model.LatencySummary{AvgMS: 2283, P50MS: 25, P95MS: 500, MaxMS: 45000}
+	if got := h.summary(); got != want {
+		t.Errorf("summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLatencyHistogram_QuantileCappedAtMax(t *testing.T) {
+	var h latencyHistogram
+	h.observe(60 * time.Millisecond)
+	if got := h.quantile(0.95); got != 60 {
+		t.Errorf("quantile(0.95) = %d, want 60", got)
+	}
+}
+
+func TestTxnMetrics_Summary(t *testing.T) {
+	m, _ := NewTxnMetrics(&TxnMetricsConfig{})
+	m.RecordRequest(txnContext("search", "ONDC:RET10", "std:080"), true, 10*time.Millisecond)
+	m.RecordRequest(txnContext("search", "ONDC:RET10", "std:080"), false, 30*time.Millisecond)
+	m.RecordFanout(txnContext("search", "ONDC:RET10", "std:080"), 200*time.Millisecond, nil)
+	m.RecordFanout(txnContext("search", "ONDC:RET10", "std:080"), 400*time.Millisecond, errors.New("NACK"))
+	m.RecordRequest(txnContext("on_search", "ONDC:RET10", "std:080"), true, 5*time.Millisecond)
+	m.RecordRequest(txnContext("search", "ONDC:RET11", "std:011"), true, 5*time.Millisecond)
+
+	want := []model.TxnSegmentStats{
+		{
+			TxnSegment:     model.TxnSegment{Action: "on_search", Domain: "ONDC:RET10", City: "std:080"},
+			Requests:       1,
+			RequestLatency: model.LatencySummary{AvgMS: 5, P50MS: 5, P95MS: 5, MaxMS: 5},
+		},
+		{
+			TxnSegment:     model.TxnSegment{Action: "search", Domain: "ONDC:RET10", City: "std:080"},
+			Requests:       2,
+			Nacks:          1,
+			RequestLatency: model.LatencySummary{AvgMS: 20, P50MS: 10, P95MS: 30, MaxMS: 30},
+			Fanouts:        2,
+			FanoutErrors:   1,
+			FanoutLatency:  model.LatencySummary{AvgMS: 300, P50MS: 250, P95MS: 400, MaxMS: 400},
+		},
+		{
+			TxnSegment:     model.TxnSegment{Action: "search", Domain: "ONDC:RET11", City: "std:011"},
+			Requests:       1,
+			RequestLatency: model.LatencySummary{AvgMS: 5, P50MS: 5, P95MS: 5, MaxMS: 5},
+		},
+	}
+	got := m.Summary()
+	if got.Since.IsZero() {
+		t.Error("Summary() since is zero")
+	}
+	if diff := cmp.Diff(want, got.Segments); diff != "" {
+		t.Errorf("Summary() segments mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTxnMetrics_MaxSegments(t *testing.T) {
+	m, _ := NewTxnMetrics(&TxnMetricsConfig{MaxSegments: 1})
+	m.RecordRequest(txnContext("search", "ONDC:RET10", "std:080"), true, time.Millisecond)
+	m.RecordRequest(txnContext("search", "ONDC:RET11", "std:080"), true, time.Millisecond)
+	m.RecordRequest(txnContext("search", "ONDC:RET12", "std:080"), true, time.Millisecond)
+	m.RecordRequest(txnContext("search", "ONDC:RET10", "std:080"), true, time.Millisecond)
+
+	got := map[model.TxnSegment]int64{}
+	for _, s := range m.Summary().Segments {
+		got[s.TxnSegment] = s.Requests
+	}
+	want := map[model.TxnSegment]int64{
+		{Action: "search", Domain: "ONDC:RET10", City: "std:080"}: 2,
+		otherTxnSegment: 2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Summary() requests mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTxnMetrics_Expvar(t *testing.T) {
+	m, _ := NewTxnMetrics(&TxnMetricsConfig{})
+	m.RecordRequest(txnContext("select", "ONDC:TST", "std:999"), true, time.Millisecond)
+
+	v := txnMetricsVars.Get("select/ONDC:TST/std:999")
+	if v == nil {
+		t.Fatal("gateway_transactions has no select/ONDC:TST/std:999 entry")
+	}
+	var got model.TxnSegmentStats
+	if err := json.Unmarshal([]byte(v.(expvar.Func).String()), &got); err != nil {
+		t.Fatalf("Failed to unmarshal expvar entry: %v", err)
+	}
+	if got.Requests != 1 {
+		t.Errorf("expvar entry requests = %d, want 1", got.Requests)
+	}
+}
+
+func TestProxyTaskProcessor_Process_TxnMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewProxyTaskProcessor(&mockAuthGen{authHeader: "Signature test"}, "test-key-id", RetryConfig{})
+	if err != nil {
+		t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+	}
+	m, _ := NewTxnMetrics(&TxnMetricsConfig{})
+	p.SetTxnMetrics(m)
+	target, _ := url.Parse(srv.URL + "/on_search")
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Target: target, Body: []byte(`{}`), Headers: http.Header{}, Context: *txnContext("on_search", "ONDC:RET10", "std:080")}
+	if err := p.Process(context.Background(), task); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	segments := m.Summary().Segments
+	if len(segments) != 1 {
+		t.Fatalf("Summary() has %d segments, want 1", len(segments))
+	}
+	if s := segments[0]; s.Fanouts != 1 || s.FanoutErrors != 0 || s.Requests != 0 {
+		t.Errorf("Summary() segment = %+v, want 1 successful fanout", s)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const defaultURLProbeTimeout = 10 * time.Second
+
+// URLProbeConfig configures the reachability probe of subscriber URLs made when a
+// subscription request is received.
+type URLProbeConfig struct {
+	// Timeout bounds the whole probe. Defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+	// Method is the HTTP method of the probe request, HEAD (default) or GET.
+	Method string `yaml:"method"`
+	// TargetPolicy, when set, restricts the URLs that are probed so that a
+	// subscription request cannot make the registry call internal services.
+	TargetPolicy *TargetPolicyConfig `yaml:"targetPolicy"`
+}
+
+// probeResultStore stores probe results on their operation.
+type probeResultStore interface {
+	SetOperationProbe(ctx context.Context, operationID string, probe json.RawMessage) error
+}
+
+// urlProber checks that a subscriber URL resolves, presents a valid TLS
+// certificate and answers HTTP requests.
+type urlProber struct {
+	store      probeResultStore
+	client     *http.Client
+	method     string
+	timeout    time.Duration
+	policy     *targetPolicy
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	now        func() time.Time
+}
+
+// NewURLProber creates a new urlProber.
+func NewURLProber(store probeResultStore, cfg *URLProbeConfig) (*urlProber, error) {
+	if store == nil {
+		slog.Error("NewURLProber: store cannot be nil")
+		return nil, errors.New("store cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewURLProber: URLProbeConfig cannot be nil")
+		return nil, errors.New("URLProbeConfig cannot be nil")
+	}
+	p := &urlProber{store: store, method: cfg.Method, timeout: cfg.Timeout, lookupHost: net.DefaultResolver.LookupHost, now: time.Now}
+	switch p.method {
+	case "":
+		p.method = http.MethodHead
+	case http.MethodHead, http.MethodGet:
+	default:
+		return nil, fmt.Errorf("invalid URL probe method: %q", cfg.Method)
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultURLProbeTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TargetPolicy != nil {
+		policy, err := NewTargetPolicy(cfg.TargetPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create URL probe target policy: %w", err)
+		}
+		p.policy = policy
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: policy.DialControl}
+		transport.DialContext = dialer.DialContext
+	}
+	p.client = &http.Client{
+		Transport: transport,
+		// Redirects are reported as the probe's status rather than followed.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return p, nil
+}
+
+// ProbeOperation probes the subscriber URL of an operation and stores the result on it.
+// Failures are logged rather than returned, as the probe is advisory.
+func (p *urlProber) ProbeOperation(ctx context.Context, operationID, rawURL string) {
+	res := p.Probe(ctx, rawURL)
+	b, err := json.Marshal(res)
+	if err != nil {
+		slog.ErrorContext(ctx, "URLProber: Failed to marshal probe result", "operation_id", operationID, "error", err)
+		return
+	}
+	if err := p.store.SetOperationProbe(ctx, operationID, b); err != nil {
+		slog.ErrorContext(ctx, "URLProber: Failed to store probe result", "operation_id", operationID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "URLProber: Probed subscriber URL", "operation_id", operationID, "url", rawURL, "reachable", res.Reachable)
+}
+
+// Probe checks DNS resolution, TLS validity and the HTTP response of rawURL.
+func (p *urlProber) Probe(ctx context.Context, rawURL string) *model.URLProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	res := &model.URLProbeResult{URL: rawURL, CheckedAt: p.now().UTC()}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		res.HTTPError = fmt.Sprintf("invalid URL %q", rawURL)
+		return res
+	}
+	if p.policy != nil {
+		if err := p.policy.Validate(ctx, u); err != nil {
+			res.HTTPError = err.Error()
+			return res
+		}
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		res.ResolvedIPs = []string{ip.String()}
+	} else if res.ResolvedIPs, err = p.lookupHost(ctx, u.Hostname()); err != nil {
+		res.DNSError = err.Error()
+		return res
+	}
+	if u.Scheme != "https" {
+		res.TLSError = "URL does not use https"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.method, u.String(), nil)
+	if err != nil {
+		res.HTTPError = err.Error()
+		return res
+	}
+	start := p.now()
+	resp, err := p.client.Do(req)
+	res.LatencyMS = p.now().Sub(start).Milliseconds()
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			res.TLSError = certErr.Err.Error()
+		} else {
+			res.HTTPError = err.Error()
+		}
+		return res
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	res.HTTPStatus = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		res.TLSValid = true
+		notAfter := resp.TLS.PeerCertificates[0].NotAfter.UTC()
+		res.TLSExpiresAt = &notAfter
+	}
+	// Any response below 500 shows the server is up; the subscriber URL need not accept the probe method.
+	res.Reachable = res.TLSValid && resp.StatusCode < http.StatusInternalServerError
+	return res
+}
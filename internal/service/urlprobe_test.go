@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+type mockProbeResultStore struct {
+	err      error
+	gotOpID  string
+	gotProbe json.RawMessage
+}
+
+func (m *mockProbeResultStore) SetOperationProbe(ctx context.Context, operationID string, probe json.RawMessage) error {
+	m.gotOpID, m.gotProbe = operationID, probe
+	return m.err
+}
+
+func TestNewURLProber(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      probeResultStore
+		cfg        *URLProbeConfig
+		wantErr    bool
+		wantMethod string
+	}{
+		{name: "defaults", store: &mockProbeResultStore{}, cfg: &URLProbeConfig{}, wantMethod: http.MethodHead},
+		{name: "get", store: &mockProbeResultStore{}, cfg: &URLProbeConfig{Method: http.MethodGet}, wantMethod: http.MethodGet},
+		{name: "with target policy", store: &mockProbeResultStore{}, cfg: &URLProbeConfig{TargetPolicy: &TargetPolicyConfig{}}, wantMethod: http.MethodHead},
+		{name: "nil store", cfg: &URLProbeConfig{}, wantErr: true},
+		{name: "nil config", store: &mockProbeResultStore{}, wantErr: true},
+		{name: "invalid method", store: &mockProbeResultStore{}, cfg: &URLProbeConfig{Method: http.MethodPost}, wantErr: true},
+		{name: "invalid target policy", store: &mockProbeResultStore{}, cfg: &URLProbeConfig{TargetPolicy: &TargetPolicyConfig{AllowedDomains: []string{"*."}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewURLProber(tt.store, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewURLProber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if p.method != tt.wantMethod || p.timeout != defaultURLProbeTimeout {
+				t.Errorf("NewURLProber() = method %s, timeout %v, want %s, %v", p.method, p.timeout, tt.wantMethod, defaultURLProbeTimeout)
+			}
+		})
+	}
+}
+
+func TestURLProber_Probe(t *testing.T) {
+	handler := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodHead {
+				t.Errorf("probe method = %s, want HEAD", r.Method)
+			}
+			w.WriteHeader(status)
+		}
+	}
+	tlsSrv := httptest.NewTLSServer(handler(http.StatusMethodNotAllowed))
+	defer tlsSrv.Close()
+	failingSrv := httptest.NewTLSServer(handler(http.StatusServiceUnavailable))
+	defer failingSrv.Close()
+	plainSrv := httptest.NewServer(handler(http.StatusOK))
+	defer plainSrv.Close()
+
+	tests := []struct {
+		name          string
+		url           string
+		cfg           URLProbeConfig
+		trustTestCert bool
+		lookupErr     error
+		want          func(t *testing.T, res *model.URLProbeResult)
+	}{
+		{
+			name:          "reachable with trusted certificate",
+			url:           tlsSrv.URL,
+			trustTestCert: true,
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if !res.Reachable || !res.TLSValid || res.TLSExpiresAt == nil || res.HTTPStatus != http.StatusMethodNotAllowed {
+					t.Errorf("Probe() = %+v, want reachable with valid TLS and status 405", res)
+				}
+				if len(res.ResolvedIPs) != 1 || res.ResolvedIPs[0] != "127.0.0.1" {
+					t.Errorf("Probe() ResolvedIPs = %v, want [127.0.0.1]", res.ResolvedIPs)
+				}
+			},
+		},
+		{
+			name: "untrusted certificate",
+			url:  tlsSrv.URL,
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if res.Reachable || res.TLSValid || res.TLSError == "" {
+					t.Errorf("Probe() = %+v, want TLS error", res)
+				}
+			},
+		},
+		{
+			name:          "server error",
+			url:           failingSrv.URL,
+			trustTestCert: true,
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if res.Reachable || !res.TLSValid || res.HTTPStatus != http.StatusServiceUnavailable {
+					t.Errorf("Probe() = %+v, want unreachable with status 503", res)
+				}
+			},
+		},
+		{
+			name: "plain http",
+			url:  plainSrv.URL,
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if res.Reachable || res.TLSError != "URL does not use https" || res.HTTPStatus != http.StatusOK {
+					t.Errorf("Probe() = %+v, want https error with status 200", res)
+				}
+			},
+		},
+		{
+			name:      "dns failure",
+			url:       "https://np.invalid/subscriber",
+			lookupErr: errors.New("no such host"),
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if res.Reachable || res.DNSError != "no such host" || res.HTTPStatus != 0 {
+					t.Errorf("Probe() = %+v, want DNS error without a request", res)
+				}
+			},
+		},
+		{
+			name:          "blocked by target policy",
+			url:           tlsSrv.URL,
+			cfg:           URLProbeConfig{TargetPolicy: &TargetPolicyConfig{}},
+			trustTestCert: true,
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if res.Reachable || !strings.Contains(res.HTTPError, ErrTargetNotAllowed.Error()) || res.HTTPStatus != 0 {
+					t.Errorf("Probe() = %+v, want target policy error without a request", res)
+				}
+			},
+		},
+		{
+			name: "invalid url",
+			url:  "://np",
+			want: func(t *testing.T, res *model.URLProbeResult) {
+				if res.Reachable || res.HTTPError == "" {
+					t.Errorf("Probe() = %+v, want invalid URL error", res)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewURLProber(&mockProbeResultStore{}, &tt.cfg)
+			if err != nil {
+				t.Fatalf("NewURLProber() error = %v", err)
+			}
+			if tt.trustTestCert {
+				p.client.Transport = tlsSrv.Client().Transport
+			}
+			p.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+				return nil, tt.lookupErr
+			}
+
+			res := p.Probe(context.Background(), tt.url)
+			if res.URL != tt.url || res.CheckedAt.IsZero() {
+				t.Errorf("Probe() url = %q, checked at %v", res.URL, res.CheckedAt)
+			}
+			tt.want(t, res)
+		})
+	}
+}
+
+func TestURLProber_ProbeOperation(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	for _, storeErr := range []error{nil, errors.New("db down")} {
+		store := &mockProbeResultStore{err: storeErr}
+		p, err := NewURLProber(store, &URLProbeConfig{})
+		if err != nil {
+			t.Fatalf("NewURLProber() error = %v", err)
+		}
+		p.client.Transport = srv.Client().Transport
+
+		// Store failures are only logged.
+		p.ProbeOperation(context.Background(), "op-1", srv.URL)
+
+		var got model.URLProbeResult
+		if err := json.Unmarshal(store.gotProbe, &got); err != nil {
+			t.Fatalf("stored probe is not valid JSON: %v", err)
+		}
+		if store.gotOpID != "op-1" || !got.Reachable || got.URL != srv.URL {
+			t.Errorf("stored probe for %s = %+v, want reachable probe of %s", store.gotOpID, got, srv.URL)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+const (
+	// ValidityActionClamp moves a valid_until beyond policy to the limit of the policy.
+	ValidityActionClamp = "clamp"
+	// ValidityActionReject rejects a valid_until beyond policy.
+	ValidityActionReject = "reject"
+)
+
+// ValidityPolicyConfig limits how long subscriptions of each role may be valid.
+type ValidityPolicyConfig struct {
+	// MaxValidity is the longest allowed duration between valid_from and valid_until, per role.
+	// Roles that are not listed are not limited.
+	MaxValidity map[model.Role]time.Duration `yaml:"maxValidity"`
+	// Action is applied to a valid_until beyond policy: clamp (default) or reject.
+	// A missing valid_until is always set to the limit of the policy.
+	Action string `yaml:"action"`
+}
+
+// validate checks the config and applies defaults.
+func (c *ValidityPolicyConfig) validate() error {
+	if len(c.MaxValidity) == 0 {
+		return errors.New("invalid validityPolicy config: maxValidity must list at least one role")
+	}
+	for role, d := range c.MaxValidity {
+		if d <= 0 {
+			return fmt.Errorf("invalid validityPolicy config: maxValidity of %s must be positive", role)
+		}
+	}
+	switch c.Action {
+	case "":
+		c.Action = ValidityActionClamp
+	case ValidityActionClamp, ValidityActionReject:
+	default:
+		return fmt.Errorf("invalid validityPolicy config: unknown action %q", c.Action)
+	}
+	return nil
+}
+
+// apply enforces the policy on sub, clamping its valid_until if needed.
+// The validity is measured from valid_from, or from now if the subscription has none.
+// It returns nil if the subscription's role is not limited, or a *model.ValidationError
+// if the policy rejects the subscription.
+func (c *ValidityPolicyConfig) apply(sub *model.Subscription, now time.Time) (*model.ValidityPolicyResult, error) {
+	limit, maxValidity, ok := c.limit(sub, now)
+	if !ok {
+		return nil, nil
+	}
+	res := &model.ValidityPolicyResult{
+		Role:                sub.Type,
+		MaxValidity:         maxValidity.String(),
+		RequestedValidUntil: sub.ValidUntil,
+		ValidUntil:          sub.ValidUntil,
+	}
+	if !sub.ValidUntil.IsZero() && !sub.ValidUntil.After(limit) {
+		return res, nil
+	}
+	if !sub.ValidUntil.IsZero() && c.Action == ValidityActionReject {
+		return nil, validUntilBeyondLimit(sub.Type, limit, maxValidity)
+	}
+	sub.ValidUntil = limit
+	res.ValidUntil = limit
+	res.Clamped = true
+	return res, nil
+}
+
+// limit returns the latest valid_until the policy allows for sub and the maximum validity of its
+// role, measured from its valid_from, or from now if it has none. ok is false if the role is not limited.
+func (c *ValidityPolicyConfig) limit(sub *model.Subscription, now time.Time) (_ time.Time, maxValidity time.Duration, ok bool) {
+	maxValidity, ok = c.MaxValidity[sub.Type]
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	from := sub.ValidFrom
+	if from.IsZero() {
+		from = now
+	}
+	return from.Add(maxValidity).UTC(), maxValidity, true
+}
+
+// validUntilBeyondLimit returns the validation error of a valid_until beyond the limit of a role.
+func validUntilBeyondLimit(role model.Role, limit time.Time, maxValidity time.Duration) error {
+	return &model.ValidationError{Fields: []model.FieldError{{Field: "valid_until", Message: fmt.Sprintf("must not be later than %s, the maximum validity of %s for role %s", limit.Format(time.RFC3339), maxValidity, role)}}}
+}
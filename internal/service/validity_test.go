@@ -0,0 +1,407 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/event/mock"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestValidityPolicyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *ValidityPolicyConfig
+		wantAction string
+		wantErr    bool
+	}{
+		{name: "defaults to clamp", cfg: &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: time.Hour}}, wantAction: ValidityActionClamp},
+		{name: "reject", cfg: &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBPP: time.Hour}, Action: ValidityActionReject}, wantAction: ValidityActionReject},
+		{name: "no roles", cfg: &ValidityPolicyConfig{}, wantErr: true},
+		{name: "zero duration", cfg: &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleGateway: 0}}, wantErr: true},
+		{name: "unknown action", cfg: &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: time.Hour}, Action: "truncate"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.cfg.Action != tt.wantAction {
+				t.Errorf("validate() Action = %q, want %q", tt.cfg.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestValidityPolicyConfig_Apply(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	from := now.Add(24 * time.Hour)
+	maxValidity := map[model.Role]time.Duration{model.RoleBAP: 30 * 24 * time.Hour}
+	tests := []struct {
+		name          string
+		action        string
+		sub           model.Subscription
+		want          *model.ValidityPolicyResult
+		wantValidTill time.Time
+		wantErr       bool
+	}{
+		{
+			name:          "role without policy",
+			action:        ValidityActionClamp,
+			sub:           model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBPP}, ValidUntil: now.Add(1000 * 24 * time.Hour)},
+			wantValidTill: now.Add(1000 * 24 * time.Hour),
+		},
+		{
+			name:          "within policy",
+			action:        ValidityActionReject,
+			sub:           model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBAP}, ValidFrom: from, ValidUntil: from.Add(10 * 24 * time.Hour)},
+			want:          &model.ValidityPolicyResult{Role: model.RoleBAP, MaxValidity: "720h0m0s", RequestedValidUntil: from.Add(10 * 24 * time.Hour), ValidUntil: from.Add(10 * 24 * time.Hour)},
+			wantValidTill: from.Add(10 * 24 * time.Hour),
+		},
+		{
+			name:          "beyond policy is clamped from valid_from",
+			action:        ValidityActionClamp,
+			sub:           model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBAP}, ValidFrom: from, ValidUntil: from.Add(365 * 24 * time.Hour)},
+			want:          &model.ValidityPolicyResult{Role: model.RoleBAP, MaxValidity: "720h0m0s", RequestedValidUntil: from.Add(365 * 24 * time.Hour), ValidUntil: from.Add(30 * 24 * time.Hour), Clamped: true},
+			wantValidTill: from.Add(30 * 24 * time.Hour),
+		},
+		{
+			name:          "beyond policy without valid_from is clamped from now",
+			action:        ValidityActionClamp,
+			sub:           model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBAP}, ValidUntil: now.Add(365 * 24 * time.Hour)},
+			want:          &model.ValidityPolicyResult{Role: model.RoleBAP, MaxValidity: "720h0m0s", RequestedValidUntil: now.Add(365 * 24 * time.Hour), ValidUntil: now.Add(30 * 24 * time.Hour), Clamped: true},
+			wantValidTill: now.Add(30 * 24 * time.Hour),
+		},
+		{
+			name:    "beyond policy is rejected",
+			action:  ValidityActionReject,
+			sub:     model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBAP}, ValidFrom: from, ValidUntil: from.Add(365 * 24 * time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:          "missing valid_until is set to the limit when rejecting",
+			action:        ValidityActionReject,
+			sub:           model.Subscription{Subscriber: model.Subscriber{Type: model.RoleBAP}},
+			want:          &model.ValidityPolicyResult{Role: model.RoleBAP, MaxValidity: "720h0m0s", ValidUntil: now.Add(30 * 24 * time.Hour), Clamped: true},
+			wantValidTill: now.Add(30 * 24 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &ValidityPolicyConfig{MaxValidity: maxValidity, Action: tt.action}
+			sub := tt.sub
+			got, err := cfg.apply(&sub, now)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrValidation) {
+					t.Fatalf("apply() error = %v, want %v", err, model.ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() unexpected error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("apply() result mismatch (-want +got):\n%s", diff)
+			}
+			if !sub.ValidUntil.Equal(tt.wantValidTill) {
+				t.Errorf("apply() ValidUntil = %v, want %v", sub.ValidUntil, tt.wantValidTill)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_ValidityPolicy(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lro := &model.LRO{OperationID: "test-msg-id", Status: model.LROStatusPending}
+	newReq := func() *model.SubscriptionRequest {
+		return &model.SubscriptionRequest{
+			Subscription: model.Subscription{
+				Subscriber:       model.Subscriber{SubscriberID: "test-sub-id", URL: "https://test.com/beckn", Domain: "ONDC:RET10", Type: model.RoleBAP},
+				KeyID:            "test-key-id",
+				SigningPublicKey: "test-signing-pub-key",
+				EncrPublicKey:    "test-encr-pub-key",
+				ValidFrom:        from,
+				ValidUntil:       from.Add(365 * 24 * time.Hour),
+			},
+			MessageID: "test-msg-id",
+		}
+	}
+
+	tests := []struct {
+		name           string
+		action         string
+		wantErr        error
+		wantValidUntil time.Time
+	}{
+		{name: "clamp", action: ValidityActionClamp, wantValidUntil: from.Add(24 * time.Hour)},
+		{name: "reject", action: ValidityActionReject, wantErr: model.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := NewSubscriptionService(&mockLROCreator{lro: lro}, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+			if err := service.SetValidityPolicy(&ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: 24 * time.Hour}, Action: tt.action}); err != nil {
+				t.Fatalf("SetValidityPolicy() error = %v", err)
+			}
+			nv := &mockNonceValidator{}
+			service.SetNonceValidator(nv)
+
+			req := newReq()
+			got, err := service.Create(ctx, req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if got != nil {
+					t.Errorf("Create() LRO = %v, want nil", got)
+				}
+				// A rejected request must not burn its nonce.
+				if nv.calls != 0 {
+					t.Errorf("Reserve() calls = %d, want 0", nv.calls)
+				}
+				return
+			}
+			if !req.ValidUntil.Equal(tt.wantValidUntil) {
+				t.Errorf("Create() ValidUntil = %v, want %v", req.ValidUntil, tt.wantValidUntil)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_SetValidityPolicy_Invalid(t *testing.T) {
+	service, _ := NewSubscriptionService(&mockLROCreator{}, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+	if err := service.SetValidityPolicy(nil); err == nil {
+		t.Error("SetValidityPolicy(nil) error = nil, want error")
+	}
+	if err := service.SetValidityPolicy(&ValidityPolicyConfig{}); err == nil {
+		t.Error("SetValidityPolicy() error = nil, want error for config without roles")
+	}
+}
+
+func TestAdminService_ApproveSubscription_ValidityPolicy(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+			ValidFrom:        now,
+			ValidUntil:       now.Add(365 * 24 * time.Hour),
+		},
+		MessageID: "op-1",
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+
+	tests := []struct {
+		name           string
+		policy         *ValidityPolicyConfig
+		wantErr        error
+		wantValidUntil time.Time
+		wantResult     *model.ApprovalResult
+	}{
+		{
+			name:           "no policy",
+			wantValidUntil: now.Add(365 * 24 * time.Hour),
+		},
+		{
+			name:           "role without policy",
+			policy:         &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBPP: time.Hour}},
+			wantValidUntil: now.Add(365 * 24 * time.Hour),
+		},
+		{
+			name:           "clamped",
+			policy:         &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: 90 * 24 * time.Hour}},
+			wantValidUntil: now.Add(90 * 24 * time.Hour),
+			wantResult: &model.ApprovalResult{ValidityPolicy: &model.ValidityPolicyResult{
+				Role: model.RoleBAP, MaxValidity: "2160h0m0s", RequestedValidUntil: now.Add(365 * 24 * time.Hour), ValidUntil: now.Add(90 * 24 * time.Hour), Clamped: true,
+			}},
+		},
+		{
+			name:           "within policy",
+			policy:         &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: 400 * 24 * time.Hour}, Action: ValidityActionReject},
+			wantValidUntil: now.Add(365 * 24 * time.Hour),
+			wantResult: &model.ApprovalResult{ValidityPolicy: &model.ValidityPolicyResult{
+				Role: model.RoleBAP, MaxValidity: "9600h0m0s", RequestedValidUntil: now.Add(365 * 24 * time.Hour), ValidUntil: now.Add(365 * 24 * time.Hour),
+			}},
+		},
+		{
+			name:    "rejected",
+			policy:  &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: 90 * 24 * time.Hour}, Action: ValidityActionReject},
+			wantErr: model.ErrValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: "op-1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro, subToReturn: &model.Subscription{}, updatedLROToReturn: lro}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{OperationRetryMax: 3, ValidityPolicy: tt.policy}
+			srv, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+			srv.now = func() time.Time { return now }
+
+			req := &model.OperationActionRequest{OperationID: "op-1", Action: model.OperationActionApproveSubscription}
+			_, _, err = srv.ApproveSubscription(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if mockRepo.upsertCalls != 0 {
+					t.Errorf("ApproveSubscription() upserted %d times, want none", mockRepo.upsertCalls)
+				}
+				if mockRepo.gotLRO == nil || mockRepo.gotLRO.Status != model.LROStatusRejected {
+					t.Errorf("ApproveSubscription() stored LRO = %+v, want status %s", mockRepo.gotLRO, model.LROStatusRejected)
+				}
+				return
+			}
+			if !mockRepo.gotSub.ValidUntil.Equal(tt.wantValidUntil) {
+				t.Errorf("stored ValidUntil = %v, want %v", mockRepo.gotSub.ValidUntil, tt.wantValidUntil)
+			}
+			var got *model.ApprovalResult
+			if mockRepo.gotLRO.ResultJSON != nil {
+				got = &model.ApprovalResult{}
+				if err := json.Unmarshal(mockRepo.gotLRO.ResultJSON, got); err != nil {
+					t.Fatalf("failed to unmarshal ResultJSON: %v", err)
+				}
+			}
+			if diff := cmp.Diff(tt.wantResult, got); diff != "" {
+				t.Errorf("ResultJSON mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_ValidityOverride(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:            "key1",
+			EncrPublicKey:    "np-encr-pub-key",
+			SigningPublicKey: "np-signing-pub-key",
+			ValidFrom:        now,
+			ValidUntil:       now.Add(365 * 24 * time.Hour),
+		},
+		MessageID: "op-1",
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	policy := &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: 400 * 24 * time.Hour}}
+
+	tests := []struct {
+		name           string
+		policy         *ValidityPolicyConfig
+		validFrom      time.Time
+		validUntil     time.Time
+		wantErr        error
+		wantValidFrom  time.Time
+		wantValidUntil time.Time
+		wantResult     *model.ApprovalResult
+	}{
+		{
+			name:           "valid_until shortened",
+			validUntil:     now.Add(30 * 24 * time.Hour),
+			wantValidFrom:  now,
+			wantValidUntil: now.Add(30 * 24 * time.Hour),
+			wantResult: &model.ApprovalResult{ValidityOverride: &model.ValidityOverrideResult{
+				Approver: "alice", RequestedValidFrom: now, RequestedValidUntil: now.Add(365 * 24 * time.Hour), ValidFrom: now, ValidUntil: now.Add(30 * 24 * time.Hour),
+			}},
+		},
+		{
+			name:           "window moved within policy",
+			policy:         policy,
+			validFrom:      now.Add(24 * time.Hour),
+			validUntil:     now.Add(395 * 24 * time.Hour),
+			wantValidFrom:  now.Add(24 * time.Hour),
+			wantValidUntil: now.Add(395 * 24 * time.Hour),
+			wantResult: &model.ApprovalResult{
+				ValidityPolicy: &model.ValidityPolicyResult{
+					Role: model.RoleBAP, MaxValidity: "9600h0m0s", RequestedValidUntil: now.Add(395 * 24 * time.Hour), ValidUntil: now.Add(395 * 24 * time.Hour),
+				},
+				ValidityOverride: &model.ValidityOverrideResult{
+					Approver: "alice", RequestedValidFrom: now, RequestedValidUntil: now.Add(365 * 24 * time.Hour), ValidFrom: now.Add(24 * time.Hour), ValidUntil: now.Add(395 * 24 * time.Hour),
+				},
+			},
+		},
+		{
+			name:       "valid_until beyond policy",
+			policy:     policy,
+			validUntil: now.Add(500 * 24 * time.Hour),
+			wantErr:    model.ErrValidation,
+		},
+		{
+			name:      "valid_from after requested valid_until",
+			validFrom: now.Add(400 * 24 * time.Hour),
+			wantErr:   model.ErrValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: "op-1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro, subToReturn: &model.Subscription{}, updatedLROToReturn: lro}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{OperationRetryMax: 3, ValidityPolicy: tt.policy}
+			srv, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{encryptedDataToReturn: "enc"}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+			srv.now = func() time.Time { return now }
+
+			req := &model.OperationActionRequest{OperationID: "op-1", Action: model.OperationActionApproveSubscription, Reviewer: "alice", ValidFrom: tt.validFrom, ValidUntil: tt.validUntil}
+			_, _, err = srv.ApproveSubscription(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if mockRepo.upsertCalls != 0 {
+					t.Errorf("ApproveSubscription() upserted %d times, want none", mockRepo.upsertCalls)
+				}
+				if mockRepo.gotLRO != nil {
+					t.Errorf("ApproveSubscription() stored LRO = %+v, want none", mockRepo.gotLRO)
+				}
+				return
+			}
+			if !mockRepo.gotSub.ValidFrom.Equal(tt.wantValidFrom) || !mockRepo.gotSub.ValidUntil.Equal(tt.wantValidUntil) {
+				t.Errorf("stored validity = [%v, %v], want [%v, %v]", mockRepo.gotSub.ValidFrom, mockRepo.gotSub.ValidUntil, tt.wantValidFrom, tt.wantValidUntil)
+			}
+			got := &model.ApprovalResult{}
+			if err := json.Unmarshal(mockRepo.gotLRO.ResultJSON, got); err != nil {
+				t.Fatalf("failed to unmarshal ResultJSON: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantResult, got); diff != "" {
+				t.Errorf("ResultJSON mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewAdminService_InvalidValidityPolicy(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, ValidityPolicy: &ValidityPolicyConfig{MaxValidity: map[model.Role]time.Duration{model.RoleBAP: time.Hour}, Action: "truncate"}}
+	if _, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg); err == nil {
+		t.Error("NewAdminService() error = nil, want error for invalid validity policy")
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/uuid"
+)
+
+// Webhook errors.
+var (
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https URL")
+	ErrInvalidEventType  = errors.New("invalid event type")
+)
+
+const (
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookMaxAttempts = 5
+	defaultWebhookBackoff     = time.Second
+	// webhookSecretBytes is the number of random bytes in a webhook secret.
+	webhookSecretBytes = 32
+	// defaultWebhookDeliveriesLimit is the number of deliveries listed when no limit is given.
+	defaultWebhookDeliveriesLimit = 20
+	// maxWebhookDeliveriesLimit is the largest number of deliveries that can be listed at once.
+	maxWebhookDeliveriesLimit = 100
+)
+
+// lroTransitionEvents are the events that signal the transition of an LRO to a status or, for
+// a PENDING LRO, to a sub-state.
+var lroTransitionEvents = map[string]model.EventType{
+	string(model.LROSubStatePendingSecondApproval): model.EventTypeSubscriptionRequestPendingSecondApproval,
+	string(model.LROStatusApproved):                model.EventTypeSubscriptionRequestApproved,
+	string(model.LROStatusRejected):                model.EventTypeSubscriptionRequestRejected,
+	string(model.LROStatusFailure):                 model.EventTypeSubscriptionRequestFailed,
+	string(model.LROStatusStale):                   model.EventTypeSubscriptionRequestExpired,
+}
+
+// lroTransitionEvent returns the event that signals the transition of lro to its current status or sub-state.
+func lroTransitionEvent(lro *model.LRO) (model.EventType, bool) {
+	if lro.Status == model.LROStatusPending && lro.SubState != "" {
+		tp, ok := lroTransitionEvents[string(lro.SubState)]
+		return tp, ok
+	}
+	tp, ok := lroTransitionEvents[string(lro.Status)]
+	return tp, ok
+}
+
+// webhookEventTypes are the events delivered to webhooks: every transition of an LRO and of the
+// subscription an approved LRO applies.
+var webhookEventTypes = []model.EventType{
+	model.EventTypeSubscriptionRequestPendingSecondApproval,
+	model.EventTypeSubscriptionRequestApproved,
+	model.EventTypeSubscriptionRequestRejected,
+	model.EventTypeSubscriptionRequestFailed,
+	model.EventTypeSubscriptionRequestExpired,
+	model.EventTypeSubscriptionApprovedPendingActivation,
+	model.EventTypeSubscriptionActivated,
+	model.EventTypeKeyRotated,
+}
+
+// WebhookConfig configures the delivery of LRO transitions to webhooks.
+type WebhookConfig struct {
+	// Timeout is the timeout of a single delivery attempt. Defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts is the number of attempts before a delivery is marked as failed. Defaults to 5.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// Backoff is the delay before the second attempt, doubled for every further attempt. Defaults to 1s.
+	Backoff time.Duration `yaml:"backoff"`
+}
+
+// webhookRepository defines the repository operations needed to manage webhooks and their deliveries.
+type webhookRepository interface {
+	InsertWebhook(ctx context.Context, hook *model.Webhook) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	InsertWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) (*model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, webhookID, deliveryID string) (*model.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error)
+}
+
+// webhookService manages webhooks and delivers LRO transitions to them in the background.
+type webhookService struct {
+	repo        webhookRepository
+	client      httpClient
+	maxAttempts int
+	backoff     time.Duration
+	now         func() time.Time
+
+	// ctx outlives the requests that trigger deliveries and is cancelled by Stop.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookService creates a new webhookService.
+func NewWebhookService(repo webhookRepository, cfg *WebhookConfig) (*webhookService, error) {
+	if repo == nil {
+		slog.Error("NewWebhookService: webhookRepository cannot be nil")
+		return nil, errors.New("webhookRepository cannot be nil")
+	}
+	if cfg == nil {
+		slog.Error("NewWebhookService: WebhookConfig cannot be nil")
+		return nil, errors.New("WebhookConfig cannot be nil")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	s := &webhookService{
+		repo:        repo,
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.Backoff,
+		now:         time.Now,
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultWebhookMaxAttempts
+	}
+	if s.backoff <= 0 {
+		s.backoff = defaultWebhookBackoff
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// Stop cancels pending deliveries and waits for running attempts to finish.
+// Deliveries that have not succeeded remain PENDING and can be retried.
+func (s *webhookService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Register registers a webhook. The returned secret is not returned again.
+func (s *webhookService) Register(ctx context.Context, req *model.WebhookRequest) (*model.RegisteredWebhook, error) {
+	if req == nil {
+		return nil, errors.New("WebhookRequest cannot be nil")
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	for _, tp := range req.EventTypes {
+		if !slices.Contains(webhookEventTypes, tp) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidEventType, tp)
+		}
+	}
+	raw := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	hook, err := s.repo.InsertWebhook(ctx, &model.Webhook{ID: uuid.NewString(), URL: req.URL, EventTypes: req.EventTypes, Secret: secret})
+	if err != nil {
+		slog.ErrorContext(ctx, "WebhookService: Failed to store webhook", "url", req.URL, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "WebhookService: Webhook registered", "webhook_id", hook.ID, "url", hook.URL)
+	return &model.RegisteredWebhook{Webhook: *hook, Secret: secret}, nil
+}
+
+// List returns the registered webhooks.
+func (s *webhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	return s.repo.ListWebhooks(ctx)
+}
+
+// Delete deletes a webhook and its delivery log.
+func (s *webhookService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "WebhookService: Webhook deleted", "webhook_id", id)
+	return nil
+}
+
+// Deliveries returns the latest deliveries of a webhook, newest first. The limit
+// defaults to 20 and is capped at 100.
+func (s *webhookService) Deliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.repo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	limit = min(limit, maxWebhookDeliveriesLimit)
+	return s.repo.ListWebhookDeliveries(ctx, webhookID, limit)
+}
+
+// Redeliver attempts a delivery once more, regardless of its status, and returns its updated record.
+func (s *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	hook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.repo.GetWebhookDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attempt(ctx, hook, d, true); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Notify delivers an LRO transition to the webhooks subscribed to its event type.
+// Deliveries are logged and attempted in the background; failures do not affect the caller.
+func (s *webhookService) Notify(ctx context.Context, tp model.EventType, lro *model.LRO) {
+	s.notify(ctx, &model.WebhookEvent{EventType: tp, Operation: lro}, lro.OperationID)
+}
+
+// NotifySubscription delivers a transition of a subscription that no LRO is waiting for, such as
+// its activation, to the webhooks subscribed to its event type. Its deliveries have no operation ID.
+func (s *webhookService) NotifySubscription(ctx context.Context, tp model.EventType, sub *model.Subscription) {
+	s.notify(ctx, &model.WebhookEvent{EventType: tp, Subscription: sub}, "")
+}
+
+// notify logs a delivery of ev to every webhook subscribed to its event type and attempts them in the background.
+func (s *webhookService) notify(ctx context.Context, ev *model.WebhookEvent, operationID string) {
+	tp := ev.EventType
+	hooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "WebhookService: Failed to list webhooks", "event_type", tp, "error", err)
+		return
+	}
+	for _, hook := range hooks {
+		if len(hook.EventTypes) > 0 && !slices.Contains(hook.EventTypes, tp) {
+			continue
+		}
+		id := uuid.NewString()
+		e := *ev
+		e.DeliveryID = id
+		payload, err := json.Marshal(&e)
+		if err != nil {
+			slog.ErrorContext(ctx, "WebhookService: Failed to marshal webhook event", "webhook_id", hook.ID, "error", err)
+			continue
+		}
+		d, err := s.repo.InsertWebhookDelivery(ctx, &model.WebhookDelivery{
+			ID:          id,
+			WebhookID:   hook.ID,
+			EventType:   tp,
+			OperationID: operationID,
+			Payload:     payload,
+			Status:      model.WebhookDeliveryStatusPending,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "WebhookService: Failed to log webhook delivery", "webhook_id", hook.ID, "operation_id", operationID, "error", err)
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.deliver(&hook, d)
+		}()
+	}
+}
+
+// deliver attempts a delivery until it succeeds, its attempts are exhausted or the service is stopped.
+func (s *webhookService) deliver(hook *model.Webhook, d *model.WebhookDelivery) {
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(s.backoff << (attempt - 2)):
+			}
+		}
+		if err := s.attempt(s.ctx, hook, d, attempt == s.maxAttempts); err != nil {
+			slog.ErrorContext(s.ctx, "WebhookService: Failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+			return
+		}
+		if d.Status == model.WebhookDeliveryStatusDelivered {
+			return
+		}
+	}
+}
+
+// attempt sends a delivery once and records the outcome. If final is true, a failed
+// delivery is marked as FAILED rather than PENDING. It only returns an error if the
+// outcome cannot be recorded.
+func (s *webhookService) attempt(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery, final bool) error {
+	code, err := s.send(ctx, hook, d)
+	d.Attempts++
+	d.ResponseCode = code
+	switch {
+	case err == nil:
+		d.Status, d.LastError = model.WebhookDeliveryStatusDelivered, ""
+		slog.InfoContext(ctx, "WebhookService: Webhook delivered", "webhook_id", hook.ID, "delivery_id", d.ID, "attempts", d.Attempts)
+	case final:
+		d.Status, d.LastError = model.WebhookDeliveryStatusFailed, err.Error()
+		slog.ErrorContext(ctx, "WebhookService: Webhook delivery failed", "webhook_id", hook.ID, "delivery_id", d.ID, "attempts", d.Attempts, "error", err)
+	default:
+		d.Status, d.LastError = model.WebhookDeliveryStatusPending, err.Error()
+		slog.WarnContext(ctx, "WebhookService: Webhook delivery attempt failed", "webhook_id", hook.ID, "delivery_id", d.ID, "attempts", d.Attempts, "error", err)
+	}
+	return s.repo.UpdateWebhookDelivery(ctx, d)
+}
+
+// send posts the payload of a delivery to the webhook, signed with its secret.
+// It returns the response status code, if any, and an error unless the status is 2xx.
+func (s *webhookService) send(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.WebhookEventHeader, string(d.EventType))
+	req.Header.Set(model.WebhookDeliveryHeader, d.ID)
+	req.Header.Set(model.WebhookTimestampHeader, timestamp)
+	req.Header.Set(model.WebhookSignatureHeader, "sha256="+signWebhook(hook.Secret, timestamp, d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook returns the hex encoded HMAC-SHA256 of the timestamp, a dot and the body, keyed with secret.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// lroEventPublisher publishes the LRO transitions, and the activations of the subscriptions
+// they approved, that have Pub/Sub events.
+type lroEventPublisher interface {
+	adminEventPublisher
+	activationEventPublisher
+}
+
+// Publisher wraps an event publisher so that the LRO transitions it publishes are also
+// delivered to webhooks. The transitions without Pub/Sub events are delivered by Notify.
+func (s *webhookService) Publisher(pub lroEventPublisher) *webhookEventPublisher {
+	return &webhookEventPublisher{pub: pub, webhooks: s}
+}
+
+// webhookEventPublisher publishes LRO transitions and notifies webhooks of them.
+type webhookEventPublisher struct {
+	pub      lroEventPublisher
+	webhooks *webhookService
+}
+
+// PublishSubscriptionRequestApprovedEvent publishes an approved LRO and notifies webhooks of it.
+func (p *webhookEventPublisher) PublishSubscriptionRequestApprovedEvent(ctx context.Context, lro *model.LRO) (string, error) {
+	id, err := p.pub.PublishSubscriptionRequestApprovedEvent(ctx, lro)
+	p.webhooks.Notify(ctx, model.EventTypeSubscriptionRequestApproved, lro)
+	return id, err
+}
+
+// PublishSubscriptionRequestRejectedEvent publishes a rejected LRO and notifies webhooks of it.
+func (p *webhookEventPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, lro *model.LRO) (string, error) {
+	id, err := p.pub.PublishSubscriptionRequestRejectedEvent(ctx, lro)
+	p.webhooks.Notify(ctx, model.EventTypeSubscriptionRequestRejected, lro)
+	return id, err
+}
+
+// PublishSubscriptionActivatedEvent publishes an activated subscription and notifies webhooks of it.
+func (p *webhookEventPublisher) PublishSubscriptionActivatedEvent(ctx context.Context, sub *model.Subscription) (string, error) {
+	id, err := p.pub.PublishSubscriptionActivatedEvent(ctx, sub)
+	p.webhooks.NotifySubscription(ctx, model.EventTypeSubscriptionActivated, sub)
+	return id, err
+}
@@ -0,0 +1,551 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// mockWebhookRepo is an in-memory implementation of webhookRepository.
+type mockWebhookRepo struct {
+	mu         sync.Mutex
+	hooks      []model.Webhook
+	deliveries map[string]*model.WebhookDelivery
+	updates    chan model.WebhookDelivery
+	insertErr  error
+	listErr    error
+}
+
+func newMockWebhookRepo(hooks ...model.Webhook) *mockWebhookRepo {
+	return &mockWebhookRepo{hooks: hooks, deliveries: map[string]*model.WebhookDelivery{}, updates: make(chan model.WebhookDelivery, 100)}
+}
+
+func (m *mockWebhookRepo) InsertWebhook(ctx context.Context, hook *model.Webhook) (*model.Webhook, error) {
+	if m.insertErr != nil {
+		return nil, m.insertErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, *hook)
+	return hook, nil
+}
+
+func (m *mockWebhookRepo) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hooks, m.listErr
+}
+
+func (m *mockWebhookRepo) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.hooks {
+		if h.ID == id {
+			return &h, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+func (m *mockWebhookRepo) DeleteWebhook(ctx context.Context, id string) error {
+	if _, err := m.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, h := range m.hooks {
+		if h.ID == id {
+			m.hooks = append(m.hooks[:i], m.hooks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockWebhookRepo) InsertWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *d
+	m.deliveries[d.ID] = &cp
+	return d, nil
+}
+
+func (m *mockWebhookRepo) UpdateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	m.mu.Lock()
+	cp := *d
+	m.deliveries[d.ID] = &cp
+	m.mu.Unlock()
+	m.updates <- cp
+	return nil
+}
+
+func (m *mockWebhookRepo) GetWebhookDelivery(ctx context.Context, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[deliveryID]
+	if !ok || d.WebhookID != webhookID {
+		return nil, repository.ErrWebhookDeliveryNotFound
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (m *mockWebhookRepo) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ds []model.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID && len(ds) < limit {
+			ds = append(ds, *d)
+		}
+	}
+	return ds, nil
+}
+
+// nextUpdate waits for the next recorded delivery attempt.
+func (m *mockWebhookRepo) nextUpdate(t *testing.T) model.WebhookDelivery {
+	t.Helper()
+	select {
+	case d := <-m.updates:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a webhook delivery attempt")
+		return model.WebhookDelivery{}
+	}
+}
+
+// webhookReceiver is a webhook endpoint that answers with the given status codes in turn.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestNewWebhookService(t *testing.T) {
+	tests := []struct {
+		name            string
+		repo            webhookRepository
+		cfg             *WebhookConfig
+		wantErr         string
+		wantMaxAttempts int
+		wantBackoff     time.Duration
+	}{
+		{
+			name:            "defaults",
+			repo:            newMockWebhookRepo(),
+			cfg:             &WebhookConfig{},
+			wantMaxAttempts: defaultWebhookMaxAttempts,
+			wantBackoff:     defaultWebhookBackoff,
+		},
+		{
+			name:            "configured",
+			repo:            newMockWebhookRepo(),
+			cfg:             &WebhookConfig{MaxAttempts: 3, Backoff: time.Minute},
+			wantMaxAttempts: 3,
+			wantBackoff:     time.Minute,
+		},
+		{
+			name:    "nil repo",
+			cfg:     &WebhookConfig{},
+			wantErr: "webhookRepository cannot be nil",
+		},
+		{
+			name:    "nil config",
+			repo:    newMockWebhookRepo(),
+			wantErr: "WebhookConfig cannot be nil",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewWebhookService(tc.repo, tc.cfg)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("NewWebhookService() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWebhookService() unexpected error: %v", err)
+			}
+			defer s.Stop()
+			if s.maxAttempts != tc.wantMaxAttempts || s.backoff != tc.wantBackoff {
+				t.Errorf("NewWebhookService() maxAttempts = %d, backoff = %v, want %d, %v", s.maxAttempts, s.backoff, tc.wantMaxAttempts, tc.wantBackoff)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Register(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *model.WebhookRequest
+		repoErr error
+		wantErr error
+	}{
+		{
+			name: "all events",
+			req:  &model.WebhookRequest{URL: "https://hooks.example.com/onix"},
+		},
+		{
+			name: "approved events only",
+			req:  &model.WebhookRequest{URL: "http://hooks.example.com", EventTypes: []model.EventType{model.EventTypeSubscriptionRequestApproved}},
+		},
+		{
+			name:    "relative url",
+			req:     &model.WebhookRequest{URL: "/onix"},
+			wantErr: ErrInvalidWebhookURL,
+		},
+		{
+			name:    "unsupported scheme",
+			req:     &model.WebhookRequest{URL: "ftp://hooks.example.com"},
+			wantErr: ErrInvalidWebhookURL,
+		},
+		{
+			name:    "unknown event type",
+			req:     &model.WebhookRequest{URL: "https://hooks.example.com", EventTypes: []model.EventType{"SUBSCRIPTION_DELETED"}},
+			wantErr: ErrInvalidEventType,
+		},
+		{
+			name:    "repository error",
+			req:     &model.WebhookRequest{URL: "https://hooks.example.com"},
+			repoErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newMockWebhookRepo()
+			repo.insertErr = tc.repoErr
+			s, _ := NewWebhookService(repo, &WebhookConfig{})
+			defer s.Stop()
+
+			got, err := s.Register(context.Background(), tc.req)
+			if tc.wantErr != nil || tc.repoErr != nil {
+				want := tc.wantErr
+				if want == nil {
+					want = tc.repoErr
+				}
+				if !errors.Is(err, want) {
+					t.Fatalf("Register() error = %v, want %v", err, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register() unexpected error: %v", err)
+			}
+			if got.ID == "" || got.Secret == "" || got.URL != tc.req.URL {
+				t.Errorf("Register() = %+v, want an ID, a secret and URL %q", got, tc.req.URL)
+			}
+			if len(repo.hooks) != 1 || repo.hooks[0].Secret != got.Secret {
+				t.Errorf("Register() stored %+v, want the webhook with its secret", repo.hooks)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Notify(t *testing.T) {
+	recv := &webhookReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+	hook := model.Webhook{ID: "hook-1", URL: srv.URL, Secret: "s3cret"}
+	skipped := model.Webhook{ID: "hook-2", URL: srv.URL, Secret: "other", EventTypes: []model.EventType{model.EventTypeSubscriptionRequestRejected}}
+	repo := newMockWebhookRepo(hook, skipped)
+	s, _ := NewWebhookService(repo, &WebhookConfig{})
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+	defer s.Stop()
+
+	lro := &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved}
+	s.Notify(context.Background(), model.EventTypeSubscriptionRequestApproved, lro)
+
+	got := repo.nextUpdate(t)
+	if got.WebhookID != hook.ID || got.Status != model.WebhookDeliveryStatusDelivered || got.Attempts != 1 || got.ResponseCode != http.StatusOK {
+		t.Errorf("Notify() recorded %+v, want a delivered attempt to %s", got, hook.ID)
+	}
+	s.Stop()
+	if len(recv.requests) != 1 {
+		t.Fatalf("Notify() sent %d requests, want 1", len(recv.requests))
+	}
+	req, body := recv.requests[0], recv.bodies[0]
+	if got := req.Header.Get(model.WebhookEventHeader); got != string(model.EventTypeSubscriptionRequestApproved) {
+		t.Errorf("Notify() %s = %q, want %q", model.WebhookEventHeader, got, model.EventTypeSubscriptionRequestApproved)
+	}
+	if id := req.Header.Get(model.WebhookDeliveryHeader); id != got.ID {
+		t.Errorf("Notify() %s = %q, want %q", model.WebhookDeliveryHeader, id, got.ID)
+	}
+	if got := req.Header.Get(model.WebhookTimestampHeader); got != "1700000000" {
+		t.Errorf("Notify() %s = %q, want %q", model.WebhookTimestampHeader, got, "1700000000")
+	}
+	mac := hmac.New(sha256.New, []byte(hook.Secret))
+	mac.Write([]byte("1700000000." + string(body)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.Header.Get(model.WebhookSignatureHeader) != want {
+		t.Errorf("Notify() %s = %q, want %q", model.WebhookSignatureHeader, req.Header.Get(model.WebhookSignatureHeader), want)
+	}
+	var ev model.WebhookEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		t.Fatalf("Notify() sent invalid JSON: %v", err)
+	}
+	if ev.DeliveryID != got.ID || ev.EventType != model.EventTypeSubscriptionRequestApproved || ev.Operation.OperationID != "op-1" {
+		t.Errorf("Notify() sent event %+v, want delivery %s of op-1", ev, got.ID)
+	}
+}
+
+func TestWebhookService_Notify_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   []model.WebhookDeliveryStatus
+		wantLastCode int
+	}{
+		{
+			name:         "succeeds after retry",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusNoContent},
+			wantStatus:   []model.WebhookDeliveryStatus{model.WebhookDeliveryStatusPending, model.WebhookDeliveryStatusDelivered},
+			wantLastCode: http.StatusNoContent,
+		},
+		{
+			name:         "attempts exhausted",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNotFound},
+			wantStatus:   []model.WebhookDeliveryStatus{model.WebhookDeliveryStatusPending, model.WebhookDeliveryStatusPending, model.WebhookDeliveryStatusFailed},
+			wantLastCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(&webhookReceiver{statuses: tc.statuses})
+			defer srv.Close()
+			repo := newMockWebhookRepo(model.Webhook{ID: "hook-1", URL: srv.URL, Secret: "s3cret"})
+			s, _ := NewWebhookService(repo, &WebhookConfig{MaxAttempts: 3, Backoff: time.Millisecond})
+			defer s.Stop()
+
+			s.Notify(context.Background(), model.EventTypeSubscriptionRequestRejected, &model.LRO{OperationID: "op-1"})
+
+			var got model.WebhookDelivery
+			for i, want := range tc.wantStatus {
+				got = repo.nextUpdate(t)
+				if got.Status != want || got.Attempts != i+1 {
+					t.Errorf("attempt %d recorded status %s with %d attempts, want %s", i+1, got.Status, got.Attempts, want)
+				}
+			}
+			if got.ResponseCode != tc.wantLastCode {
+				t.Errorf("last attempt recorded response code %d, want %d", got.ResponseCode, tc.wantLastCode)
+			}
+			if got.Status == model.WebhookDeliveryStatusFailed && !strings.Contains(got.LastError, "404") {
+				t.Errorf("last attempt recorded error %q, want it to mention the status code", got.LastError)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Notify_ListError(t *testing.T) {
+	repo := newMockWebhookRepo()
+	repo.listErr = errors.New("db error")
+	s, _ := NewWebhookService(repo, &WebhookConfig{})
+
+	s.Notify(context.Background(), model.EventTypeSubscriptionRequestApproved, &model.LRO{OperationID: "op-1"})
+	s.Stop()
+
+	if len(repo.deliveries) != 0 {
+		t.Errorf("Notify() logged %d deliveries, want 0", len(repo.deliveries))
+	}
+}
+
+func TestWebhookService_Redeliver(t *testing.T) {
+	srv := httptest.NewServer(&webhookReceiver{statuses: []int{http.StatusOK}})
+	defer srv.Close()
+	repo := newMockWebhookRepo(model.Webhook{ID: "hook-1", URL: srv.URL, Secret: "s3cret"})
+	repo.deliveries["d-1"] = &model.WebhookDelivery{ID: "d-1", WebhookID: "hook-1", Payload: json.RawMessage(`{}`), Status: model.WebhookDeliveryStatusFailed, Attempts: 5, LastError: "timeout"}
+	s, _ := NewWebhookService(repo, &WebhookConfig{})
+	defer s.Stop()
+
+	got, err := s.Redeliver(context.Background(), "hook-1", "d-1")
+	if err != nil {
+		t.Fatalf("Redeliver() unexpected error: %v", err)
+	}
+	if got.Status != model.WebhookDeliveryStatusDelivered || got.Attempts != 6 || got.LastError != "" {
+		t.Errorf("Redeliver() = %+v, want a delivered delivery after 6 attempts", got)
+	}
+
+	if _, err := s.Redeliver(context.Background(), "hook-1", "missing"); !errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+		t.Errorf("Redeliver() error = %v, want %v", err, repository.ErrWebhookDeliveryNotFound)
+	}
+	if _, err := s.Redeliver(context.Background(), "missing", "d-1"); !errors.Is(err, repository.ErrWebhookNotFound) {
+		t.Errorf("Redeliver() error = %v, want %v", err, repository.ErrWebhookNotFound)
+	}
+}
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	repo := newMockWebhookRepo(model.Webhook{ID: "hook-1"})
+	for _, id := range []string{"d-1", "d-2", "d-3"} {
+		repo.deliveries[id] = &model.WebhookDelivery{ID: id, WebhookID: "hook-1"}
+	}
+	s, _ := NewWebhookService(repo, &WebhookConfig{})
+	defer s.Stop()
+
+	got, err := s.Deliveries(context.Background(), "hook-1", 2)
+	if err != nil {
+		t.Fatalf("Deliveries() unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Deliveries() returned %d deliveries, want 2", len(got))
+	}
+	if _, err := s.Deliveries(context.Background(), "missing", 0); !errors.Is(err, repository.ErrWebhookNotFound) {
+		t.Errorf("Deliveries() error = %v, want %v", err, repository.ErrWebhookNotFound)
+	}
+}
+
+func TestWebhookService_Delete(t *testing.T) {
+	repo := newMockWebhookRepo(model.Webhook{ID: "hook-1"})
+	s, _ := NewWebhookService(repo, &WebhookConfig{})
+	defer s.Stop()
+
+	if err := s.Delete(context.Background(), "hook-1"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if err := s.Delete(context.Background(), "hook-1"); !errors.Is(err, repository.ErrWebhookNotFound) {
+		t.Errorf("Delete() error = %v, want %v", err, repository.ErrWebhookNotFound)
+	}
+}
+
+func TestWebhookEventPublisher(t *testing.T) {
+	repo := newMockWebhookRepo()
+	s, _ := NewWebhookService(repo, &WebhookConfig{})
+	defer s.Stop()
+	inner := &mockAdminEventPublisher{msgID: "msg-1"}
+	srv := httptest.NewServer(&webhookReceiver{})
+	defer srv.Close()
+	repo.hooks = []model.Webhook{{ID: "hook-1", URL: srv.URL, Secret: "s3cret"}}
+	pub := s.Publisher(inner)
+
+	id, err := pub.PublishSubscriptionRequestApprovedEvent(context.Background(), &model.LRO{OperationID: "op-1"})
+	if err != nil || id != "msg-1" {
+		t.Errorf("PublishSubscriptionRequestApprovedEvent() = %q, %v, want %q, nil", id, err, "msg-1")
+	}
+	if inner.approvedCalls != 1 {
+		t.Errorf("PublishSubscriptionRequestApprovedEvent() called the inner publisher %d times, want 1", inner.approvedCalls)
+	}
+	if got := repo.nextUpdate(t); got.EventType != model.EventTypeSubscriptionRequestApproved {
+		t.Errorf("PublishSubscriptionRequestApprovedEvent() delivered %s, want %s", got.EventType, model.EventTypeSubscriptionRequestApproved)
+	}
+
+	inner.err = errors.New("pubsub down")
+	if _, err := pub.PublishSubscriptionRequestRejectedEvent(context.Background(), &model.LRO{OperationID: "op-2"}); err == nil {
+		t.Error("PublishSubscriptionRequestRejectedEvent() expected error, got nil")
+	}
+	if got := repo.nextUpdate(t); got.EventType != model.EventTypeSubscriptionRequestRejected {
+		t.Errorf("PublishSubscriptionRequestRejectedEvent() delivered %s, want %s despite the publish error", got.EventType, model.EventTypeSubscriptionRequestRejected)
+	}
+
+	inner.err = nil
+	if _, err := pub.PublishSubscriptionActivatedEvent(context.Background(), &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub-1"}}); err != nil {
+		t.Errorf("PublishSubscriptionActivatedEvent() error = %v", err)
+	}
+	got := repo.nextUpdate(t)
+	if got.EventType != model.EventTypeSubscriptionActivated || got.OperationID != "" {
+		t.Errorf("PublishSubscriptionActivatedEvent() delivered %s of operation %q, want %s without operation", got.EventType, got.OperationID, model.EventTypeSubscriptionActivated)
+	}
+	var ev model.WebhookEvent
+	if err := json.Unmarshal(got.Payload, &ev); err != nil || ev.Subscription == nil || ev.Subscription.SubscriberID != "sub-1" || ev.Operation != nil {
+		t.Errorf("PublishSubscriptionActivatedEvent() delivered payload %s, want the subscription of sub-1", got.Payload)
+	}
+}
+
+// enumValues returns the values listed in the enum tag of a field of v.
+func enumValues(t *testing.T, v any, field string) []string {
+	t.Helper()
+	f, ok := reflect.TypeOf(v).FieldByName(field)
+	if !ok {
+		t.Fatalf("%T has no field %s", v, field)
+	}
+	return strings.Split(f.Tag.Get("enum"), ",")
+}
+
+// TestWebhookEventTypes_LROTransitions fails when an LRO can reach a status or sub-state
+// whose transition is not delivered to webhooks.
+func TestWebhookEventTypes_LROTransitions(t *testing.T) {
+	for _, status := range enumValues(t, model.LRO{}, "Status") {
+		if model.LROStatus(status) == model.LROStatusPending {
+			continue
+		}
+		tp, ok := lroTransitionEvent(&model.LRO{Status: model.LROStatus(status)})
+		if !ok || !slices.Contains(webhookEventTypes, tp) {
+			t.Errorf("transition to status %s is delivered as %q, want an event type in webhookEventTypes", status, tp)
+		}
+	}
+	for _, subState := range enumValues(t, model.LRO{}, "SubState") {
+		tp, ok := lroTransitionEvent(&model.LRO{Status: model.LROStatusPending, SubState: model.LROSubState(subState)})
+		if !ok || !slices.Contains(webhookEventTypes, tp) {
+			t.Errorf("transition to sub-state %s is delivered as %q, want an event type in webhookEventTypes", subState, tp)
+		}
+	}
+}
+
+// TestWebhookEventPublisher_DeliversEveryEvent fails when an event published by the webhook
+// publisher is not one that webhooks can register for.
+func TestWebhookEventPublisher_DeliversEveryEvent(t *testing.T) {
+	repo := newMockWebhookRepo()
+	s, _ := NewWebhookService(repo, &WebhookConfig{MaxAttempts: 1})
+	defer s.Stop()
+	srv := httptest.NewServer(&webhookReceiver{})
+	defer srv.Close()
+	repo.hooks = []model.Webhook{{ID: "hook-1", URL: srv.URL, Secret: "s3cret"}}
+	pub := reflect.ValueOf(s.Publisher(&mockAdminEventPublisher{}))
+
+	for i := 0; i < pub.NumMethod(); i++ {
+		m := pub.Type().Method(i)
+		args := []reflect.Value{reflect.ValueOf(context.Background())}
+		// In(0) is the receiver and In(1) the context; the payload is a pointer.
+		for j := 2; j < m.Type.NumIn(); j++ {
+			args = append(args, reflect.New(m.Type.In(j).Elem()))
+		}
+		pub.Method(i).Call(args)
+		if got := repo.nextUpdate(t); !slices.Contains(webhookEventTypes, got.EventType) {
+			t.Errorf("%s() delivered %s, want an event type in webhookEventTypes", m.Name, got.EventType)
+		}
+	}
+}
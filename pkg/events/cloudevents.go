@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// CloudEvents 1.0 structured-mode JSON format, see https://github.com/cloudevents/spec.
+const (
+	// CloudEventsSpecVersion is the version of the CloudEvents specification events conform to.
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content type of an event in structured mode.
+	CloudEventsContentType = "application/cloudevents+json"
+	// AttributeContentType carries CloudEventsContentType on published messages, as in the
+	// CloudEvents Pub/Sub protocol binding for structured mode.
+	AttributeContentType = "content-type"
+	// DefaultSource is the source of events published without a configured source.
+	DefaultSource = "/onix"
+)
+
+// ErrInvalidCloudEvent occurs if a message in structured mode is not a valid CloudEvent.
+var ErrInvalidCloudEvent = errors.New("invalid CloudEvent")
+
+// CloudEvent is a published event in CloudEvents 1.0 JSON format.
+type CloudEvent struct {
+	SpecVersion string `json:"specversion"`
+	// ID identifies the event; it is unique for its Source.
+	ID string `json:"id"`
+	// Source is the URI of the service that published the event, e.g. "https://registry.example.com".
+	Source string `json:"source"`
+	// Type is the model.EventType of the event.
+	Type string `json:"type"`
+	// Subject is the subscriber, or for ON_SUBSCRIBE_RECIEVED the operation, the event is about.
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	// EventVersion is the extension attribute carrying the version of the payload schema.
+	EventVersion string          `json:"eventversion"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// NewCloudEvent wraps the JSON payload data of an event of type tp in a CloudEvent.
+func NewCloudEvent(id, source string, tp model.EventType, subject string, at time.Time, data []byte) *CloudEvent {
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            string(tp),
+		Subject:         subject,
+		Time:            at.UTC(),
+		DataContentType: "application/json",
+		EventVersion:    Version,
+		Data:            data,
+	}
+}
+
+// parseCloudEvent decodes a CloudEvent and checks its required attributes.
+func parseCloudEvent(data []byte) (*CloudEvent, error) {
+	var ce CloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCloudEvent, err)
+	}
+	if ce.SpecVersion != CloudEventsSpecVersion {
+		return nil, fmt.Errorf("%w: specversion %q, want %q", ErrInvalidCloudEvent, ce.SpecVersion, CloudEventsSpecVersion)
+	}
+	if ce.ID == "" || ce.Source == "" || ce.Type == "" {
+		return nil, fmt.Errorf("%w: id, source and type are required", ErrInvalidCloudEvent)
+	}
+	return &ce, nil
+}
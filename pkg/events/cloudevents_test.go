@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// cloudEventAttrs returns the attributes of a message carrying a CloudEvent of the given type.
+func cloudEventAttrs(tp model.EventType) map[string]string {
+	attrs := Attributes(tp)
+	attrs[AttributeContentType] = CloudEventsContentType
+	return attrs
+}
+
+func TestDecode_CloudEvent(t *testing.T) {
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	payload := []byte(`{"subscriber_id":"s1","key_id":"k2","previous_key_id":"k1"}`)
+	data, err := json.Marshal(NewCloudEvent("ev1", "https://registry.example.com", model.EventTypeKeyRotated, "s1", at, payload))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	e, err := Decode(cloudEventAttrs(model.EventTypeKeyRotated), data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v, want nil", err)
+	}
+	want := &Envelope{
+		Type:    model.EventTypeKeyRotated,
+		Version: Version,
+		ID:      "ev1",
+		Source:  "https://registry.example.com",
+		Subject: "s1",
+		Time:    at,
+		Data:    payload,
+		Payload: &KeyRotated{SubscriberID: "s1", KeyID: "k2", PreviousKeyID: "k1"},
+	}
+	if diff := cmp.Diff(want, e); diff != "" {
+		t.Errorf("Decode() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewCloudEvent_JSON(t *testing.T) {
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(NewCloudEvent("ev1", "/onix", model.EventTypeOnSubscribeRecieved, "", at, []byte(`{"operation_id":"op1"}`)))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"specversion":"1.0","id":"ev1","source":"/onix","type":"ON_SUBSCRIBE_RECIEVED","time":"2025-06-01T10:00:00Z","datacontenttype":"application/json","eventversion":"v1","data":{"operation_id":"op1"}}`
+	if got := string(b); got != want {
+		t.Errorf("json.Marshal(NewCloudEvent()) = %s, want %s", got, want)
+	}
+}
+
+func TestDecode_CloudEventError(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"not json", `{`, ErrInvalidCloudEvent},
+		{"wrong specversion", `{"specversion":"0.3","id":"ev1","source":"/onix","type":"KEY_ROTATED","data":{}}`, ErrInvalidCloudEvent},
+		{"missing id", `{"specversion":"1.0","source":"/onix","type":"KEY_ROTATED","data":{}}`, ErrInvalidCloudEvent},
+		{"unknown type", `{"specversion":"1.0","id":"ev1","source":"/onix","type":"SOMETHING","data":{}}`, ErrUnknownEventType},
+		{"unsupported version", `{"specversion":"1.0","id":"ev1","source":"/onix","type":"KEY_ROTATED","eventversion":"v9","data":{}}`, ErrUnsupportedVersion},
+		{"schema violation", `{"specversion":"1.0","id":"ev1","source":"/onix","type":"KEY_ROTATED","data":{"key_id":"k1"}}`, ErrSchemaViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(cloudEventAttrs(model.EventTypeKeyRotated), []byte(tt.data)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
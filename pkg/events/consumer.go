@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// Decode validates a published event against its schema and decodes it into a typed Envelope.
+// Messages with the content-type attribute application/cloudevents+json are CloudEvents whose
+// data is the payload; other messages are the payload itself, described by their attributes.
+// Messages published before the envelope was versioned carry no version attribute and are treated as v1.
+func Decode(attrs map[string]string, data []byte) (*Envelope, error) {
+	e := &Envelope{Type: model.EventType(attrs[AttributeEventType]), Version: attrs[AttributeEventVersion]}
+	if attrs[AttributeContentType] == CloudEventsContentType {
+		ce, err := parseCloudEvent(data)
+		if err != nil {
+			return nil, err
+		}
+		e.Type, e.Version = model.EventType(ce.Type), ce.EventVersion
+		e.ID, e.Source, e.Subject, e.Time = ce.ID, ce.Source, ce.Subject, ce.Time
+		data = ce.Data
+	}
+	newPayload, ok := payloadFactories[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEventType, e.Type)
+	}
+	if e.Version == "" {
+		e.Version = Version
+	}
+	if err := Validate(e.Type, e.Version, data); err != nil {
+		return nil, err
+	}
+	e.Payload = newPayload()
+	if err := json.Unmarshal(data, e.Payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", e.Type, err)
+	}
+	e.Data = data
+	return e, nil
+}
+
+// Handler processes a decoded event.
+type Handler func(ctx context.Context, e *Envelope) error
+
+// Consumer validates, decodes and dispatches events to per-type handlers.
+// It is transport agnostic; with Cloud Pub/Sub it can be used as:
+//
+//	c := events.NewConsumer()
+//	c.Handle(model.EventTypeSubscriptionRequestApproved, onApproved)
+//	sub.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+//		if err := c.Consume(ctx, m.Attributes, m.Data); err != nil {
+//			m.Nack()
+//			return
+//		}
+//		m.Ack()
+//	})
+type Consumer struct {
+	handlers map[model.EventType]Handler
+}
+
+// NewConsumer creates a new Consumer with no handlers registered.
+func NewConsumer() *Consumer {
+	return &Consumer{handlers: make(map[model.EventType]Handler)}
+}
+
+// Handle registers h as the handler for events of type tp, replacing any previous handler.
+func (c *Consumer) Handle(tp model.EventType, h Handler) {
+	c.handlers[tp] = h
+}
+
+// Consume decodes a single event and dispatches it to the registered handler.
+// Valid events without a registered handler are ignored.
+func (c *Consumer) Consume(ctx context.Context, attrs map[string]string, data []byte) error {
+	e, err := Decode(attrs, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Consumer: failed to decode event", "event_type", attrs[AttributeEventType], "error", err)
+		return err
+	}
+	h, ok := c.handlers[e.Type]
+	if !ok {
+		slog.DebugContext(ctx, "Consumer: no handler registered, ignoring event", "event_type", e.Type)
+		return nil
+	}
+	return h(ctx, e)
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecode_Success(t *testing.T) {
+	tests := []struct {
+		name        string
+		attrs       map[string]string
+		data        string
+		wantVersion string
+		wantPayload any
+	}{
+		{
+			name:        "approved lro",
+			attrs:       Attributes(model.EventTypeSubscriptionRequestApproved),
+			data:        `{"operation_id":"op1","status":"APPROVED"}`,
+			wantVersion: Version,
+			wantPayload: &model.LRO{OperationID: "op1", Status: model.LROStatusApproved},
+		},
+		{
+			name:        "legacy message without version",
+			attrs:       map[string]string{AttributeEventType: string(model.EventTypeOnSubscribeRecieved)},
+			data:        `{"operation_id":"op2"}`,
+			wantVersion: Version,
+			wantPayload: &OnSubscribeRecieved{OperationID: "op2"},
+		},
+		{
+			name:        "key rotated",
+			attrs:       Attributes(model.EventTypeKeyRotated),
+			data:        `{"subscriber_id":"s1","key_id":"k2","previous_key_id":"k1"}`,
+			wantVersion: Version,
+			wantPayload: &KeyRotated{SubscriberID: "s1", KeyID: "k2", PreviousKeyID: "k1"},
+		},
+		{
+			name:        "key accessed",
+			attrs:       Attributes(model.EventTypeKeyAccessed),
+			data:        `{"service":"gateway","operation":"LOOKUP_NP_KEYS","key_id":"k1","subscriber_id":"s1","result":"SUCCESS"}`,
+			wantVersion: Version,
+			wantPayload: &KeyAccessed{Service: "gateway", Operation: KeyAccessLookupNPKeys, KeyID: "k1", SubscriberID: "s1", Result: KeyAccessSuccess},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Decode(tt.attrs, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Decode() error = %v, want nil", err)
+			}
+			if e.Version != tt.wantVersion {
+				t.Errorf("Decode() version = %q, want %q", e.Version, tt.wantVersion)
+			}
+			if diff := cmp.Diff(tt.wantPayload, e.Payload); diff != "" {
+				t.Errorf("Decode() payload mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecode_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   map[string]string
+		data    string
+		wantErr error
+	}{
+		{"missing type", map[string]string{}, `{}`, ErrUnknownEventType},
+		{"unknown type", map[string]string{AttributeEventType: "SOMETHING"}, `{}`, ErrUnknownEventType},
+		{"unsupported version", map[string]string{AttributeEventType: string(model.EventTypeKeyRotated), AttributeEventVersion: "v9"}, `{}`, ErrUnsupportedVersion},
+		{"schema violation", Attributes(model.EventTypeKeyRotated), `{"key_id":"k1"}`, ErrSchemaViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.attrs, []byte(tt.data)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConsumer_Consume(t *testing.T) {
+	var got *Envelope
+	handlerErr := errors.New("handler failed")
+	c := NewConsumer()
+	c.Handle(model.EventTypeOnSubscribeRecieved, func(ctx context.Context, e *Envelope) error {
+		got = e
+		return nil
+	})
+	c.Handle(model.EventTypeSubscriptionRequestRejected, func(ctx context.Context, e *Envelope) error {
+		return handlerErr
+	})
+	ctx := context.Background()
+
+	if err := c.Consume(ctx, Attributes(model.EventTypeOnSubscribeRecieved), []byte(`{"operation_id":"op1"}`)); err != nil {
+		t.Fatalf("Consume() error = %v, want nil", err)
+	}
+	if got == nil || got.Payload.(*OnSubscribeRecieved).OperationID != "op1" {
+		t.Errorf("handler received %+v, want operation_id op1", got)
+	}
+
+	if err := c.Consume(ctx, Attributes(model.EventTypeSubscriptionRequestRejected), []byte(`{"operation_id":"op1"}`)); !errors.Is(err, handlerErr) {
+		t.Errorf("Consume() error = %v, want %v", err, handlerErr)
+	}
+
+	// Valid events without a handler are ignored.
+	if err := c.Consume(ctx, Attributes(model.EventTypeKeyRotated), []byte(`{"subscriber_id":"s1","key_id":"k1"}`)); err != nil {
+		t.Errorf("Consume() error = %v, want nil for unhandled type", err)
+	}
+
+	if err := c.Consume(ctx, Attributes(model.EventTypeOnSubscribeRecieved), []byte(`{}`)); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Consume() error = %v, want %v", err, ErrSchemaViolation)
+	}
+}
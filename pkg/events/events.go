@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events defines the versioned envelope, typed payloads and JSON schemas
+// for every event published by the registry, together with a small consumer
+// helper that network participants and gateways can use to validate and decode them.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// Version is the current version of the event envelope and payload schemas.
+const Version = "v1"
+
+// Message attribute keys that make up the envelope of a published event.
+const (
+	// AttributeEventType carries the model.EventType of the event.
+	AttributeEventType = "event_type"
+	// AttributeEventVersion carries the envelope/schema version of the event.
+	AttributeEventVersion = "event_version"
+	// AttributeSubscriberID carries the subscriber the event is about, if any. Events with the
+	// same subscriber are published in order, so it can also serve as a partition key when
+	// events are bridged to other brokers.
+	AttributeSubscriberID = "subscriber_id"
+)
+
+var (
+	// ErrUnknownEventType occurs if the event type is missing or not registered.
+	ErrUnknownEventType = errors.New("unknown event type")
+
+	// ErrUnsupportedVersion occurs if the event version is not supported by this library.
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+
+	// ErrSchemaViolation occurs if the event payload does not conform to its JSON schema.
+	ErrSchemaViolation = errors.New("event payload violates schema")
+)
+
+// Envelope is a decoded event together with its metadata.
+type Envelope struct {
+	Type    model.EventType
+	Version string
+	// ID, Source, Subject and Time are the CloudEvents attributes of the event.
+	// They are empty for events published before events were CloudEvents.
+	ID      string
+	Source  string
+	Subject string
+	Time    time.Time
+	// Data is the raw JSON payload as published.
+	Data json.RawMessage
+	// Payload is the typed payload, e.g. *model.SubscriptionRequest, *model.LRO,
+	// *model.Subscription, *OnSubscribeRecieved, *KeyRotated or *KeyAccessed depending on Type.
+	Payload any
+}
+
+// OnSubscribeRecieved is the payload of an ON_SUBSCRIBE_RECIEVED event.
+type OnSubscribeRecieved struct {
+	OperationID string `json:"operation_id"`
+}
+
+// KeyRotated is the payload of a KEY_ROTATED event.
+type KeyRotated struct {
+	SubscriberID     string    `json:"subscriber_id"`
+	KeyID            string    `json:"key_id"`
+	PreviousKeyID    string    `json:"previous_key_id,omitempty"`
+	SigningPublicKey string    `json:"signing_public_key,omitempty"`
+	EncrPublicKey    string    `json:"encr_public_key,omitempty"`
+	RotatedAt        time.Time `json:"rotated_at,omitzero"`
+}
+
+// KeyAccessOperation identifies the key manager call a KEY_ACCESSED event records.
+type KeyAccessOperation string
+
+const (
+	// KeyAccessKeyset is a read of one of the service's own keysets.
+	KeyAccessKeyset KeyAccessOperation = "KEYSET"
+	// KeyAccessLookupNPKeys is a lookup of the public keys of another network participant.
+	KeyAccessLookupNPKeys KeyAccessOperation = "LOOKUP_NP_KEYS"
+)
+
+// KeyAccessResult is the outcome of an audited key manager call.
+type KeyAccessResult string
+
+const (
+	// KeyAccessSuccess means the keys were returned.
+	KeyAccessSuccess KeyAccessResult = "SUCCESS"
+	// KeyAccessFailure means the call failed; the event carries the error.
+	KeyAccessFailure KeyAccessResult = "FAILURE"
+)
+
+// KeyAccessed is the payload of a KEY_ACCESSED event.
+type KeyAccessed struct {
+	// Service is the service that read the keys, e.g. gateway or subscriber.
+	Service string `json:"service"`
+	// Instance is the host name of the service instance.
+	Instance  string             `json:"instance,omitempty"`
+	Operation KeyAccessOperation `json:"operation"`
+	KeyID     string             `json:"key_id"`
+	// SubscriberID is the network participant whose keys were looked up, for LOOKUP_NP_KEYS.
+	SubscriberID string          `json:"subscriber_id,omitempty"`
+	Result       KeyAccessResult `json:"result"`
+	Error        string          `json:"error,omitempty"`
+	AccessedAt   time.Time       `json:"accessed_at,omitzero"`
+}
+
+// payloadFactories maps each event type to a constructor for its typed payload.
+var payloadFactories = map[model.EventType]func() any{
+	model.EventTypeNewSubscriptionRequest:      func() any { return &model.SubscriptionRequest{} },
+	model.EventTypeUpdateSubscriptionRequest:   func() any { return &model.SubscriptionRequest{} },
+	model.EventTypeSubscriptionRequestApproved: func() any { return &model.LRO{} },
+	model.EventTypeSubscriptionRequestRejected: func() any { return &model.LRO{} },
+	model.EventTypeOnSubscribeRecieved:         func() any { return &OnSubscribeRecieved{} },
+	model.EventTypeKeyRotated:                  func() any { return &KeyRotated{} },
+	model.EventTypeKeyAccessed:                 func() any { return &KeyAccessed{} },
+	model.EventTypeSubscriptionActivated:       func() any { return &model.Subscription{} },
+}
+
+// Attributes returns the envelope attributes to set on a published message of the given type.
+func Attributes(tp model.EventType) map[string]string {
+	return map[string]string{
+		AttributeEventType:    string(tp),
+		AttributeEventVersion: Version,
+	}
+}
+
+// Types returns all event types known to this library.
+func Types() []model.EventType {
+	return []model.EventType{
+		model.EventTypeNewSubscriptionRequest,
+		model.EventTypeUpdateSubscriptionRequest,
+		model.EventTypeSubscriptionRequestApproved,
+		model.EventTypeSubscriptionRequestRejected,
+		model.EventTypeOnSubscribeRecieved,
+		model.EventTypeKeyRotated,
+		model.EventTypeKeyAccessed,
+		model.EventTypeSubscriptionActivated,
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"embed"
+	"fmt"
+	"path"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/jsonschema"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+//go:embed schemas
+var schemaFS embed.FS
+
+// schemaFiles maps each event type to the file describing its payload.
+var schemaFiles = map[model.EventType]string{
+	model.EventTypeNewSubscriptionRequest:      "subscription_request.json",
+	model.EventTypeUpdateSubscriptionRequest:   "subscription_request.json",
+	model.EventTypeSubscriptionRequestApproved: "lro.json",
+	model.EventTypeSubscriptionRequestRejected: "lro.json",
+	model.EventTypeOnSubscribeRecieved:         "on_subscribe_recieved.json",
+	model.EventTypeKeyRotated:                  "key_rotated.json",
+	model.EventTypeKeyAccessed:                 "key_accessed.json",
+	model.EventTypeSubscriptionActivated:       "subscription.json",
+}
+
+// Schema returns the raw JSON schema for the payload of the given event type and version.
+func Schema(tp model.EventType, version string) ([]byte, error) {
+	file, ok := schemaFiles[tp]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEventType, tp)
+	}
+	b, err := schemaFS.ReadFile(path.Join("schemas", version, file))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedVersion, version)
+	}
+	return b, nil
+}
+
+// Validate checks data against the JSON schema for the given event type and version.
+func Validate(tp model.EventType, version string, data []byte) error {
+	raw, err := Schema(tp, version)
+	if err != nil {
+		return err
+	}
+	s, err := jsonschema.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s/%s: %w", tp, version, err)
+	}
+	if err := s.Validate(data); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaViolation, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/jsonschema"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestSchema_AllTypesHaveSchema(t *testing.T) {
+	for _, tp := range Types() {
+		b, err := Schema(tp, Version)
+		if err != nil {
+			t.Errorf("Schema(%s, %s) error = %v, want nil", tp, Version, err)
+			continue
+		}
+		if _, err := jsonschema.Parse(b); err != nil {
+			t.Errorf("Schema(%s, %s) is not valid JSON: %v", tp, Version, err)
+		}
+		if _, ok := payloadFactories[tp]; !ok {
+			t.Errorf("no payload type registered for %s", tp)
+		}
+	}
+}
+
+func TestSchema_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		tp      model.EventType
+		version string
+		wantErr error
+	}{
+		{"unknown type", "UNKNOWN", Version, ErrUnknownEventType},
+		{"unknown version", model.EventTypeKeyRotated, "v99", ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Schema(tt.tp, tt.version); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Schema() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tp      model.EventType
+		data    string
+		wantErr bool
+	}{
+		{"valid subscription request", model.EventTypeNewSubscriptionRequest, `{"message_id":"m1","subscriber_id":"s1","type":"BAP"}`, false},
+		{"missing required", model.EventTypeNewSubscriptionRequest, `{"subscriber_id":"s1"}`, true},
+		{"empty required", model.EventTypeOnSubscribeRecieved, `{"operation_id":""}`, true},
+		{"wrong property type", model.EventTypeSubscriptionRequestApproved, `{"operation_id":"op1","retry_count":"three"}`, true},
+		{"enum violation", model.EventTypeSubscriptionRequestRejected, `{"operation_id":"op1","status":"DONE"}`, true},
+		{"valid lro", model.EventTypeSubscriptionRequestApproved, `{"operation_id":"op1","status":"APPROVED","retry_count":1}`, false},
+		{"not an object", model.EventTypeKeyRotated, `[]`, true},
+		{"invalid json", model.EventTypeKeyRotated, `{`, true},
+		{"valid key rotated", model.EventTypeKeyRotated, `{"subscriber_id":"s1","key_id":"k2"}`, false},
+		{"valid key accessed", model.EventTypeKeyAccessed, `{"service":"gateway","operation":"KEYSET","key_id":"k1","result":"FAILURE","error":"not found"}`, false},
+		{"unknown key access result", model.EventTypeKeyAccessed, `{"service":"gateway","operation":"KEYSET","key_id":"k1","result":"DENIED"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.tp, Version, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrSchemaViolation) {
+				t.Errorf("Validate() error = %v, want wrapping %v", err, ErrSchemaViolation)
+			}
+		})
+	}
+}
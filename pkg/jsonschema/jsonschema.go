@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsonschema validates JSON documents against the subset of JSON Schema
+// used by ONIX: type, required, properties and enum.
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrViolation occurs if a document does not conform to its schema.
+var ErrViolation = errors.New("document violates schema")
+
+// Schema is the subset of JSON Schema supported by this package.
+type Schema struct {
+	Type       any                `json:"type"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Enum       []any              `json:"enum"`
+}
+
+// Parse parses a raw JSON schema.
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// maxViolations caps the number of violations reported for a single document.
+const maxViolations = 10
+
+// Violation describes where and how a document departs from its schema.
+type Violation struct {
+	// Path is the JSON path of the offending value, such as "$.message.order.id".
+	Path string
+	// Expected describes what the schema requires at Path.
+	Expected string
+	// Got describes what the document has at Path.
+	Got string
+}
+
+// String formats the violation as "path: expected ..., got ...".
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: expected %s, got %s", v.Path, v.Expected, v.Got)
+}
+
+// ValidationError lists the violations found in a document. It wraps ErrViolation.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error joins the violations into a single message.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrViolation, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns ErrViolation.
+func (e *ValidationError) Unwrap() error {
+	return ErrViolation
+}
+
+// Validate checks data against the schema. Violations are reported as a *ValidationError
+// listing up to maxViolations of them in a stable order.
+func (s *Schema) Validate(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: document is not valid JSON: %v", ErrViolation, err)
+	}
+	var violations []Violation
+	s.validate("$", v, &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validate appends the violations of v against the schema to violations.
+func (s *Schema) validate(at string, v any, violations *[]Violation) {
+	report := func(path, expected, got string) {
+		if len(*violations) < maxViolations {
+			*violations = append(*violations, Violation{Path: path, Expected: expected, Got: got})
+		}
+	}
+	if s.Type != nil && !matchesType(s.Type, v) {
+		report(at, "type "+typeName(s.Type), jsonType(v))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+		report(at, "one of "+jsonValue(s.Enum), jsonValue(v))
+		return
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	for _, name := range s.Required {
+		val, ok := obj[name]
+		switch {
+		case !ok:
+			report(at+"."+name, "required property", "missing")
+		case val == nil || val == "":
+			report(at+"."+name, "required property", "empty value")
+		}
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		val, ok := obj[name]
+		prop := s.Properties[name]
+		// Required properties that are null have already been reported as missing.
+		if !ok || prop == nil || val == nil && slices.Contains(s.Required, name) {
+			continue
+		}
+		prop.validate(at+"."+name, val, violations)
+	}
+}
+
+// typeName formats a schema type, which may be a string or a list of strings.
+func typeName(t any) string {
+	if tt, ok := t.([]any); ok {
+		names := make([]string, len(tt))
+		for i, e := range tt {
+			names[i] = fmt.Sprint(e)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// jsonValue formats a decoded value as JSON for violation messages.
+func jsonValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// matchesType reports whether v is of the schema type t, which may be a string or a list of strings.
+func matchesType(t any, v any) bool {
+	switch tt := t.(type) {
+	case string:
+		return typeMatches(tt, v)
+	case []any:
+		for _, e := range tt {
+			if s, ok := e.(string); ok && typeMatches(s, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeMatches(t string, v any) bool {
+	got := jsonType(v)
+	if t == "number" && got == "integer" {
+		return true
+	}
+	return t == got
+}
+
+// jsonType returns the JSON Schema type name of a value decoded by encoding/json.
+func jsonType(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if vv == float64(int64(vv)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
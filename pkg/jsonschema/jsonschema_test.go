@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParse_Error(t *testing.T) {
+	if _, err := Parse([]byte(`{"type":`)); err == nil {
+		t.Error("Parse() error = nil, want error for invalid JSON")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["context", "message"],
+		"properties": {
+			"context": {
+				"type": "object",
+				"required": ["action"],
+				"properties": {"action": {"type": "string", "enum": ["issue_status"]}}
+			},
+			"message": {"type": "object", "properties": {"count": {"type": ["integer", "null"]}, "rating": {"type": "number"}}}
+		}
+	}`
+	s, err := Parse([]byte(schema))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"context":{"action":"issue_status"},"message":{"count":1,"rating":4.5}}`, false},
+		{"null allowed by type list", `{"context":{"action":"issue_status"},"message":{"count":null}}`, false},
+		{"integer is a number", `{"context":{"action":"issue_status"},"message":{"rating":4}}`, false},
+		{"missing required", `{"context":{"action":"issue_status"}}`, true},
+		{"empty required", `{"context":{"action":""},"message":{}}`, true},
+		{"enum violation", `{"context":{"action":"support"},"message":{}}`, true},
+		{"wrong type", `{"context":{"action":"issue_status"},"message":{"count":1.5}}`, true},
+		{"not an object", `[]`, true},
+		{"invalid json", `{`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrViolation) {
+				t.Errorf("Validate() error = %v, want wrapping %v", err, ErrViolation)
+			}
+		})
+	}
+}
+
+func TestValidate_Violations(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["context", "message"],
+		"properties": {
+			"context": {
+				"type": "object",
+				"required": ["action", "domain"],
+				"properties": {"action": {"type": "string", "enum": ["issue_status"]}}
+			},
+			"message": {"type": "object", "properties": {"count": {"type": ["integer", "null"]}, "rating": {"type": "number"}}}
+		}
+	}`
+	s, err := Parse([]byte(schema))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []Violation
+	}{
+		{
+			name: "missing required",
+			data: `{"context":{"action":"issue_status","domain":"d"}}`,
+			want: []Violation{{Path: "$.message", Expected: "required property", Got: "missing"}},
+		},
+		{
+			name: "null required",
+			data: `{"context":{"action":"issue_status","domain":"d"},"message":null}`,
+			want: []Violation{{Path: "$.message", Expected: "required property", Got: "empty value"}},
+		},
+		{
+			name: "type list",
+			data: `{"context":{"action":"issue_status","domain":"d"},"message":{"count":1.5}}`,
+			want: []Violation{{Path: "$.message.count", Expected: "type integer or null", Got: "number"}},
+		},
+		{
+			name: "multiple violations",
+			data: `{"context":{"action":"support"},"message":{"rating":"good"}}`,
+			want: []Violation{
+				{Path: "$.context.domain", Expected: "required property", Got: "missing"},
+				{Path: "$.context.action", Expected: `one of ["issue_status"]`, Got: `"support"`},
+				{Path: "$.message.rating", Expected: "type number", Got: "string"},
+			},
+		},
+		{
+			name: "root type",
+			data: `[]`,
+			want: []Violation{{Path: "$", Expected: "type object", Got: "array"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.data))
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Violations, tt.want) {
+				t.Errorf("Validate() violations = %+v, want %+v", verr.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_MaxViolations(t *testing.T) {
+	required := make([]string, maxViolations+5)
+	for i := range required {
+		required[i] = fmt.Sprintf("p%d", i)
+	}
+	raw, _ := json.Marshal(map[string]any{"type": "object", "required": required})
+	s, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var verr *ValidationError
+	if err := s.Validate([]byte(`{}`)); !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	if len(verr.Violations) != maxViolations {
+		t.Errorf("Validate() reported %d violations, want %d", len(verr.Violations), maxViolations)
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Violations: []Violation{
+		{Path: "$.message", Expected: "required property", Got: "missing"},
+		{Path: "$.context.action", Expected: "type string", Got: "integer"},
+	}}
+	want := "document violates schema: $.message: expected required property, got missing; $.context.action: expected type string, got integer"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrViolation) {
+		t.Error("ValidationError does not wrap ErrViolation")
+	}
+}
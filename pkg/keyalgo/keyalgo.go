@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyalgo implements the signing key algorithms that network participant keysets
+// can use. Ed25519 is the Beckn default; secp256k1 is available for network profiles that
+// require it.
+//
+// The keyset model is defined by beckn-onix and has no algorithm field, so private keys of
+// algorithms other than Ed25519 carry the algorithm as a prefix, e.g. "secp256k1:<base64>".
+// Public keys are stored and registered unprefixed; their algorithm follows from their length.
+package keyalgo
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Algorithm identifies a signing key algorithm.
+type Algorithm string
+
+const (
+	// Ed25519 signs with Ed25519 keys. Private keys are the base64 encoded 32 byte seed.
+	Ed25519 Algorithm = "ed25519"
+	// Secp256k1 signs with ECDSA over secp256k1 and SHA-256. Private keys are the base64
+	// encoded 32 byte scalar, public keys the 33 byte compressed point, and signatures are
+	// DER encoded.
+	Secp256k1 Algorithm = "secp256k1"
+)
+
+// Default is the algorithm used when none is configured.
+const Default = Ed25519
+
+var (
+	// ErrUnsupported occurs if an algorithm is not recognised.
+	ErrUnsupported = errors.New("unsupported signing algorithm")
+
+	// ErrInvalidKey occurs if a key cannot be decoded for its algorithm.
+	ErrInvalidKey = errors.New("invalid signing key")
+
+	// ErrVerification occurs if a signature does not verify.
+	ErrVerification = errors.New("signature verification failed")
+)
+
+// Parse returns the algorithm named by s, or Default if s is empty.
+func Parse(s string) (Algorithm, error) {
+	switch alg := Algorithm(s); alg {
+	case "":
+		return Default, nil
+	case Ed25519, Secp256k1:
+		return alg, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupported, s)
+	}
+}
+
+// GenerateSigningKey generates a signing key pair of alg. The private key is returned in the
+// tagged form stored in keysets, the public key in the base64 form registered with the registry.
+func GenerateSigningKey(alg Algorithm) (private, public string, err error) {
+	switch alg {
+	case "", Ed25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		return encode(priv.Seed()), encode(pub), nil
+	case Secp256k1:
+		priv, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate secp256k1 key: %w", err)
+		}
+		return string(Secp256k1) + ":" + encode(priv.Serialize()), encode(priv.PubKey().SerializeCompressed()), nil
+	default:
+		return "", "", fmt.Errorf("%w: %q", ErrUnsupported, alg)
+	}
+}
+
+// PrivateKey splits a keyset signing private key into its algorithm and base64 key.
+// Untagged keys are Ed25519.
+func PrivateKey(private string) (Algorithm, string) {
+	if alg, key, ok := strings.Cut(private, ":"); ok {
+		return Algorithm(alg), key
+	}
+	return Ed25519, private
+}
+
+// PublicKey returns the algorithm of a base64 encoded signing public key.
+func PublicKey(public string) (Algorithm, error) {
+	pub, err := base64.StdEncoding.DecodeString(public)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	switch len(pub) {
+	case ed25519.PublicKeySize:
+		return Ed25519, nil
+	case secp256k1.PubKeyBytesLenCompressed:
+		return Secp256k1, nil
+	default:
+		return "", fmt.Errorf("%w: unexpected public key length %d", ErrInvalidKey, len(pub))
+	}
+}
+
+// Sign signs msg with a keyset signing private key and returns the algorithm and signature.
+func Sign(private string, msg []byte) (Algorithm, []byte, error) {
+	alg, key := PrivateKey(private)
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	switch alg {
+	case Ed25519:
+		if len(raw) != ed25519.SeedSize {
+			return "", nil, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKey, len(raw), ed25519.SeedSize)
+		}
+		return alg, ed25519.Sign(ed25519.NewKeyFromSeed(raw), msg), nil
+	case Secp256k1:
+		if len(raw) != secp256k1.PrivKeyBytesLen {
+			return "", nil, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKey, len(raw), secp256k1.PrivKeyBytesLen)
+		}
+		digest := sha256.Sum256(msg)
+		return alg, ecdsa.Sign(secp256k1.PrivKeyFromBytes(raw), digest[:]).Serialize(), nil
+	default:
+		return "", nil, fmt.Errorf("%w: %q", ErrUnsupported, alg)
+	}
+}
+
+// Verify checks sig over msg with a base64 encoded signing public key and returns the
+// algorithm of the key.
+func Verify(public string, msg, sig []byte) (Algorithm, error) {
+	alg, err := PublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	pub, _ := base64.StdEncoding.DecodeString(public)
+	switch alg {
+	case Ed25519:
+		if !ed25519.Verify(ed25519.PublicKey(pub), msg, sig) {
+			return alg, ErrVerification
+		}
+	case Secp256k1:
+		key, err := secp256k1.ParsePubKey(pub)
+		if err != nil {
+			return alg, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		s, err := ecdsa.ParseDERSignature(sig)
+		if err != nil {
+			return alg, fmt.Errorf("%w: %v", ErrVerification, err)
+		}
+		digest := sha256.Sum256(msg)
+		if !s.Verify(digest[:], key) {
+			return alg, ErrVerification
+		}
+	}
+	return alg, nil
+}
+
+func encode(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyalgo
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Algorithm
+		wantErr error
+	}{
+		{in: "", want: Ed25519},
+		{in: "ed25519", want: Ed25519},
+		{in: "secp256k1", want: Secp256k1},
+		{in: "SECP256K1", wantErr: ErrUnsupported},
+		{in: "rsa", wantErr: ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	for _, alg := range []Algorithm{Ed25519, Secp256k1} {
+		t.Run(string(alg), func(t *testing.T) {
+			priv, pub, err := GenerateSigningKey(alg)
+			if err != nil {
+				t.Fatalf("GenerateSigningKey() error = %v", err)
+			}
+			if got, _ := PrivateKey(priv); got != alg {
+				t.Errorf("PrivateKey() algorithm = %q, want %q", got, alg)
+			}
+			if got, err := PublicKey(pub); err != nil || got != alg {
+				t.Errorf("PublicKey() = %q, %v, want %q", got, err, alg)
+			}
+
+			gotAlg, sig, err := Sign(priv, []byte("msg"))
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if gotAlg != alg {
+				t.Errorf("Sign() algorithm = %q, want %q", gotAlg, alg)
+			}
+			if _, err := Verify(pub, []byte("msg"), sig); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+			if _, err := Verify(pub, []byte("other"), sig); !errors.Is(err, ErrVerification) {
+				t.Errorf("Verify() of other message error = %v, want %v", err, ErrVerification)
+			}
+		})
+	}
+}
+
+func TestGenerateSigningKey_Ed25519Untagged(t *testing.T) {
+	priv, _, err := GenerateSigningKey(Ed25519)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	if strings.Contains(priv, ":") {
+		t.Errorf("GenerateSigningKey() private key = %q, want untagged base64", priv)
+	}
+}
+
+func TestGenerateSigningKey_Unsupported(t *testing.T) {
+	if _, _, err := GenerateSigningKey("rsa"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("GenerateSigningKey() error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestSign_InvalidKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "bad encoding", key: "%%%", wantErr: ErrInvalidKey},
+		{name: "short ed25519", key: base64.StdEncoding.EncodeToString([]byte("short")), wantErr: ErrInvalidKey},
+		{name: "short secp256k1", key: "secp256k1:" + base64.StdEncoding.EncodeToString([]byte("short")), wantErr: ErrInvalidKey},
+		{name: "unknown algorithm", key: "rsa:" + base64.StdEncoding.EncodeToString([]byte("key")), wantErr: ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Sign(tt.key, []byte("msg")); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Sign() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify_MismatchedAlgorithm(t *testing.T) {
+	edPriv, _, _ := GenerateSigningKey(Ed25519)
+	_, k1Pub, _ := GenerateSigningKey(Secp256k1)
+	_, sig, err := Sign(edPriv, []byte("msg"))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if _, err := Verify(k1Pub, []byte("msg"), sig); !errors.Is(err, ErrVerification) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrVerification)
+	}
+}
+
+func TestPublicKey_Invalid(t *testing.T) {
+	for _, key := range []string{"%%%", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := PublicKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("PublicKey(%q) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
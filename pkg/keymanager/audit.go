@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
+)
+
+// maxPendingAuditEvents caps the audit events being published at once. Events beyond it are
+// dropped, so that a slow event publisher cannot hold up key reads.
+const maxPendingAuditEvents = 1000
+
+// ErrInvalidAudit occurs if the key access audit config is invalid.
+var ErrInvalidAudit = errors.New("invalid key access audit config")
+
+// auditMetrics counts key access audit events: published, failed to publish, dropped
+// because too many were pending, and sampled out.
+var auditMetrics = expvar.NewMap("keymanager_audit")
+
+// AuditConfig configures the key access audit. While it is set, every Keyset and LookupNPKeys
+// call is recorded as a KEY_ACCESSED event with the service, key ID and result.
+type AuditConfig struct {
+	// SampleRate is the fraction of successful calls that are audited, between 0 and 1.
+	// Defaults to 1. Failed calls are always audited.
+	SampleRate float64 `yaml:"sampleRate"`
+}
+
+// auditPublisher publishes key access audit events.
+type auditPublisher interface {
+	PublishKeyAccessedEvent(ctx context.Context, ev *events.KeyAccessed) (string, error)
+}
+
+// auditingKeyManager records the keyset reads and network key lookups of the key manager it
+// wraps as audit events. Events are published in the background and never fail the call.
+type auditingKeyManager struct {
+	next       KeyManager
+	pub        auditPublisher
+	service    string
+	instance   string
+	sampleRate float64
+	random     func() float64
+	now        func() time.Time
+	pending    chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewAuditing wraps km so that its Keyset and LookupNPKeys calls are published as audit events
+// attributed to service. The returned function waits for pending events to be published.
+func NewAuditing(km KeyManager, pub auditPublisher, service string, cfg *AuditConfig) (KeyManager, func() error, error) {
+	if km == nil {
+		slog.Error("keymanager.NewAuditing: key manager cannot be nil")
+		return nil, nil, errors.New("key manager cannot be nil")
+	}
+	if pub == nil {
+		slog.Error("keymanager.NewAuditing: audit publisher cannot be nil")
+		return nil, nil, errors.New("audit publisher cannot be nil")
+	}
+	if service == "" {
+		return nil, nil, fmt.Errorf("%w: service cannot be empty", ErrInvalidAudit)
+	}
+	sampleRate := 1.0
+	if cfg != nil && cfg.SampleRate != 0 {
+		sampleRate = cfg.SampleRate
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, nil, fmt.Errorf("%w: sampleRate %v must be between 0 and 1", ErrInvalidAudit, sampleRate)
+	}
+	instance, err := os.Hostname()
+	if err != nil {
+		slog.Warn("KeyManager: Failed to read host name for key access audit", "error", err)
+	}
+	a := &auditingKeyManager{
+		next:       km,
+		pub:        pub,
+		service:    service,
+		instance:   instance,
+		sampleRate: sampleRate,
+		random:     rand.Float64,
+		now:        time.Now,
+		pending:    make(chan struct{}, maxPendingAuditEvents),
+	}
+	slog.Info("KeyManager: Auditing key access", "service", service, "sample_rate", sampleRate)
+	return a, func() error { a.wg.Wait(); return nil }, nil
+}
+
+// GenerateKeyset generates a keyset with the wrapped key manager.
+func (a *auditingKeyManager) GenerateKeyset() (*model.Keyset, error) {
+	return a.next.GenerateKeyset()
+}
+
+// InsertKeyset stores the keyset with the wrapped key manager.
+func (a *auditingKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
+	return a.next.InsertKeyset(ctx, keyID, keyset)
+}
+
+// Keyset reads the keyset from the wrapped key manager and audits the read.
+func (a *auditingKeyManager) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	ks, err := a.next.Keyset(ctx, keyID)
+	a.audit(ctx, &events.KeyAccessed{Operation: events.KeyAccessKeyset, KeyID: keyID}, err)
+	return ks, err
+}
+
+// DeleteKeyset deletes the keyset from the wrapped key manager.
+func (a *auditingKeyManager) DeleteKeyset(ctx context.Context, keyID string) error {
+	return a.next.DeleteKeyset(ctx, keyID)
+}
+
+// UndeleteKeyset recovers the keyset in the wrapped key manager, if it supports it.
+func (a *auditingKeyManager) UndeleteKeyset(ctx context.Context, keyID string) error {
+	return Undelete(ctx, a.next, keyID)
+}
+
+// WipeKeys wipes the private keys the wrapped key manager holds in memory, if it holds any.
+func (a *auditingKeyManager) WipeKeys() int {
+	return Wipe(a.next)
+}
+
+// LookupNPKeys looks up the keys of another network participant through the wrapped key
+// manager and audits the lookup.
+func (a *auditingKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	signingKey, encrKey, err := a.next.LookupNPKeys(ctx, subscriberID, uniqueKeyID)
+	a.audit(ctx, &events.KeyAccessed{Operation: events.KeyAccessLookupNPKeys, KeyID: uniqueKeyID, SubscriberID: subscriberID}, err)
+	return signingKey, encrKey, err
+}
+
+// audit completes ev with the result err and publishes it in the background, unless it is
+// sampled out or too many events are pending.
+func (a *auditingKeyManager) audit(ctx context.Context, ev *events.KeyAccessed, err error) {
+	ev.Result = events.KeyAccessSuccess
+	if err != nil {
+		ev.Result, ev.Error = events.KeyAccessFailure, err.Error()
+	} else if a.sampleRate < 1 && a.random() >= a.sampleRate {
+		auditMetrics.Add("sampled_out", 1)
+		return
+	}
+	ev.Service, ev.Instance, ev.AccessedAt = a.service, a.instance, a.now().UTC()
+
+	select {
+	case a.pending <- struct{}{}:
+	default:
+		slog.WarnContext(ctx, "KeyManager: Too many pending key access audit events, dropping event", "operation", ev.Operation, "key_id", ev.KeyID)
+		auditMetrics.Add("dropped", 1)
+		return
+	}
+	a.wg.Add(1)
+	go func() {
+		defer func() {
+			<-a.pending
+			a.wg.Done()
+		}()
+		// The event outlives the call it records.
+		if _, err := a.pub.PublishKeyAccessedEvent(context.WithoutCancel(ctx), ev); err != nil {
+			slog.ErrorContext(ctx, "KeyManager: Failed to publish key access audit event", "operation", ev.Operation, "key_id", ev.KeyID, "error", err)
+			auditMetrics.Add("failed", 1)
+			return
+		}
+		auditMetrics.Add("published", 1)
+	}()
+}
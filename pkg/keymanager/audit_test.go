@@ -0,0 +1,248 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/events"
+)
+
+// recordingAuditPublisher records the key access events published to it.
+type recordingAuditPublisher struct {
+	mu     sync.Mutex
+	events []*events.KeyAccessed
+	err    error
+	block  chan struct{}
+}
+
+func (p *recordingAuditPublisher) PublishKeyAccessedEvent(ctx context.Context, ev *events.KeyAccessed) (string, error) {
+	if p.block != nil {
+		<-p.block
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, ev)
+	return "msg-id", p.err
+}
+
+// lookupKeyManager is a map key manager that also serves network key lookups.
+type lookupKeyManager struct {
+	*mapKeyManager
+	lookupErr error
+}
+
+func (m *lookupKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	if m.lookupErr != nil {
+		return "", "", m.lookupErr
+	}
+	return "signing-" + uniqueKeyID, "encr-" + uniqueKeyID, nil
+}
+
+func newTestAuditing(t *testing.T, km KeyManager, pub auditPublisher, cfg *AuditConfig) (*auditingKeyManager, func() error) {
+	t.Helper()
+	a, closeFn, err := NewAuditing(km, pub, "gateway", cfg)
+	if err != nil {
+		t.Fatalf("NewAuditing() error = %v, want nil", err)
+	}
+	akm := a.(*auditingKeyManager)
+	akm.instance = "host-1"
+	akm.now = func() time.Time { return time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC) }
+	return akm, closeFn
+}
+
+func TestNewAuditing_Error(t *testing.T) {
+	km, pub := newMapKeyManager(nil), &recordingAuditPublisher{}
+	tests := []struct {
+		name    string
+		km      KeyManager
+		pub     auditPublisher
+		service string
+		cfg     *AuditConfig
+		wantErr error
+	}{
+		{name: "nil key manager", pub: pub, service: "gateway"},
+		{name: "nil publisher", km: km, service: "gateway"},
+		{name: "missing service", km: km, pub: pub, wantErr: ErrInvalidAudit},
+		{name: "negative sample rate", km: km, pub: pub, service: "gateway", cfg: &AuditConfig{SampleRate: -0.1}, wantErr: ErrInvalidAudit},
+		{name: "sample rate above one", km: km, pub: pub, service: "gateway", cfg: &AuditConfig{SampleRate: 1.5}, wantErr: ErrInvalidAudit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewAuditing(tt.km, tt.pub, tt.service, tt.cfg)
+			if err == nil {
+				t.Fatal("NewAuditing() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewAuditing() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuditing_Events(t *testing.T) {
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		call func(km KeyManager) error
+		want *events.KeyAccessed
+	}{
+		{
+			name: "keyset read",
+			call: func(km KeyManager) error {
+				_, err := km.Keyset(context.Background(), "key-1")
+				return err
+			},
+			want: &events.KeyAccessed{Service: "gateway", Instance: "host-1", Operation: events.KeyAccessKeyset, KeyID: "key-1", Result: events.KeyAccessSuccess, AccessedAt: at},
+		},
+		{
+			name: "keyset read failure",
+			call: func(km KeyManager) error {
+				_, err := km.Keyset(context.Background(), "missing")
+				if !errors.Is(err, errNotFound) {
+					return errors.New("Keyset() did not return the error of the wrapped key manager")
+				}
+				return nil
+			},
+			want: &events.KeyAccessed{Service: "gateway", Instance: "host-1", Operation: events.KeyAccessKeyset, KeyID: "missing", Result: events.KeyAccessFailure, Error: errNotFound.Error(), AccessedAt: at},
+		},
+		{
+			name: "network key lookup",
+			call: func(km KeyManager) error {
+				signing, _, err := km.LookupNPKeys(context.Background(), "np1", "key-2")
+				if signing != "signing-key-2" {
+					return errors.New("LookupNPKeys() did not return the keys of the wrapped key manager")
+				}
+				return err
+			},
+			want: &events.KeyAccessed{Service: "gateway", Instance: "host-1", Operation: events.KeyAccessLookupNPKeys, KeyID: "key-2", SubscriberID: "np1", Result: events.KeyAccessSuccess, AccessedAt: at},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &recordingAuditPublisher{}
+			km := &lookupKeyManager{mapKeyManager: newMapKeyManager(map[string]*model.Keyset{"key-1": {UniqueKeyID: "key-1"}})}
+			a, closeFn := newTestAuditing(t, km, pub, nil)
+
+			if err := tt.call(a); err != nil {
+				t.Fatal(err)
+			}
+			if err := closeFn(); err != nil {
+				t.Fatalf("close() error = %v", err)
+			}
+			if diff := cmp.Diff([]*events.KeyAccessed{tt.want}, pub.events); diff != "" {
+				t.Errorf("published events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuditing_LookupFailure(t *testing.T) {
+	errLookup := errors.New("registry unavailable")
+	pub := &recordingAuditPublisher{}
+	a, closeFn := newTestAuditing(t, &lookupKeyManager{mapKeyManager: newMapKeyManager(nil), lookupErr: errLookup}, pub, nil)
+
+	if _, _, err := a.LookupNPKeys(context.Background(), "np1", "key-2"); !errors.Is(err, errLookup) {
+		t.Errorf("LookupNPKeys() error = %v, want %v", err, errLookup)
+	}
+	closeFn()
+	if len(pub.events) != 1 || pub.events[0].Result != events.KeyAccessFailure || pub.events[0].Error != errLookup.Error() {
+		t.Errorf("published events = %+v, want one failure with %q", pub.events, errLookup)
+	}
+}
+
+func TestAuditing_Sampling(t *testing.T) {
+	pub := &recordingAuditPublisher{}
+	a, closeFn := newTestAuditing(t, newMapKeyManager(map[string]*model.Keyset{"key-1": {}}), pub, &AuditConfig{SampleRate: 0.25})
+	draws := []float64{0.1, 0.5, 0.9, 0.2}
+	a.random = func() float64 {
+		d := draws[0]
+		draws = draws[1:]
+		return d
+	}
+
+	for range 4 {
+		a.Keyset(context.Background(), "key-1")
+	}
+	// Failed reads are audited regardless of sampling.
+	a.Keyset(context.Background(), "missing")
+	closeFn()
+
+	var got []string
+	for _, ev := range pub.events {
+		got = append(got, ev.KeyID+"/"+string(ev.Result))
+	}
+	want := []string{"key-1/SUCCESS", "key-1/SUCCESS", "missing/FAILURE"}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("audited reads mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuditing_PublishFailureDoesNotFailRead(t *testing.T) {
+	pub := &recordingAuditPublisher{err: errors.New("pubsub down")}
+	a, closeFn := newTestAuditing(t, newMapKeyManager(map[string]*model.Keyset{"key-1": {UniqueKeyID: "key-1"}}), pub, nil)
+
+	ks, err := a.Keyset(context.Background(), "key-1")
+	closeFn()
+	if err != nil || ks.UniqueKeyID != "key-1" {
+		t.Errorf("Keyset() = %v, %v, want keyset key-1", ks, err)
+	}
+}
+
+func TestAuditing_DropsWhenTooManyPending(t *testing.T) {
+	pub := &recordingAuditPublisher{block: make(chan struct{})}
+	a, closeFn := newTestAuditing(t, newMapKeyManager(map[string]*model.Keyset{"key-1": {}}), pub, nil)
+	a.pending = make(chan struct{}, 1)
+	dropped := func() int64 {
+		if v, ok := auditMetrics.Get("dropped").(interface{ Value() int64 }); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := dropped()
+
+	a.Keyset(context.Background(), "key-1")
+	a.Keyset(context.Background(), "key-1")
+	close(pub.block)
+	closeFn()
+
+	if len(pub.events) != 1 {
+		t.Errorf("published %d events, want 1", len(pub.events))
+	}
+	if got := dropped() - before; got != 1 {
+		t.Errorf("dropped = %d, want 1", got)
+	}
+}
+
+func TestAuditing_Undelete(t *testing.T) {
+	km := newMapKeyManager(map[string]*model.Keyset{"key-1": {}})
+	a, closeFn := newTestAuditing(t, km, &recordingAuditPublisher{}, nil)
+	defer closeFn()
+
+	if err := a.DeleteKeyset(context.Background(), "key-1"); err != nil {
+		t.Fatalf("DeleteKeyset() error = %v", err)
+	}
+	if err := Undelete(context.Background(), a, "key-1"); err != nil {
+		t.Errorf("Undelete() error = %v, want nil", err)
+	}
+}
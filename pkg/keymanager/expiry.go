@@ -0,0 +1,199 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+)
+
+// defaultExpiryInterval is how often the expiry gauges are updated if no interval is configured.
+const defaultExpiryInterval = time.Minute
+
+// ErrInvalidExpiry occurs if the key expiry config is invalid.
+var ErrInvalidExpiry = errors.New("invalid key expiry config")
+
+var (
+	// keysetTTLMetrics publishes the seconds until each keyset cached in memory expires, by key ID.
+	keysetTTLMetrics = expvar.NewMap("keymanager_keyset_ttl_seconds")
+	// keyValidityMetrics publishes the seconds until the subscription of each tracked key
+	// expires in the registry, by subscriber ID and key ID.
+	keyValidityMetrics = expvar.NewMap("keymanager_key_validity_seconds")
+)
+
+// ExpiryConfig configures the gauges of how long keys remain usable, so that alerts can
+// fire before keys expire rather than after signatures start to fail.
+type ExpiryConfig struct {
+	// Keys are the subscriptions whose validity in the registry is tracked, usually the
+	// service's own.
+	Keys []NPKey `yaml:"keys"`
+	// Interval is how often the gauges are updated and the validity of Keys is looked up.
+	// Defaults to 1m.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// CacheExpirer is implemented by key managers that cache keysets in memory.
+type CacheExpirer interface {
+	// CachedKeysets returns the time at which each cached keyset expires, by key ID.
+	CachedKeysets() map[string]time.Time
+}
+
+// expiryMonitor periodically publishes the remaining TTL of cached keysets and the
+// remaining validity of the tracked keys in the registry.
+type expiryMonitor struct {
+	cache    CacheExpirer // nil if the key manager does not cache keysets
+	registry plugin.RegistryLookup
+	keys     []NPKey
+	interval time.Duration
+	now      func() time.Time
+
+	validUntil map[NPKey]time.Time // last known validity of each key, owned by run
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// newExpiryMonitor creates an expiryMonitor for km, which looks up the validity of keys in registry.
+func newExpiryMonitor(km KeyManager, registry plugin.RegistryLookup, cfg *ExpiryConfig) (*expiryMonitor, error) {
+	if cfg.Interval < 0 {
+		return nil, fmt.Errorf("%w: interval cannot be negative", ErrInvalidExpiry)
+	}
+	if len(cfg.Keys) > 0 && registry == nil {
+		return nil, fmt.Errorf("%w: a registry lookup is required to track keys", ErrInvalidExpiry)
+	}
+	for _, k := range cfg.Keys {
+		if k.SubscriberID == "" || k.KeyID == "" {
+			return nil, fmt.Errorf("%w: keys need a subscriberID and keyID", ErrInvalidExpiry)
+		}
+	}
+	m := &expiryMonitor{
+		registry:   registry,
+		keys:       cfg.Keys,
+		interval:   cfg.Interval,
+		now:        time.Now,
+		validUntil: map[NPKey]time.Time{},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if m.interval == 0 {
+		m.interval = defaultExpiryInterval
+	}
+	if c, ok := km.(CacheExpirer); ok {
+		m.cache = c
+	}
+	return m, nil
+}
+
+// start updates the gauges now and then every interval until close is called.
+func (m *expiryMonitor) start(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			m.sample(ctx)
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	slog.Info("KeyManager: Publishing key expiry gauges", "interval", m.interval, "keys", len(m.keys), "cache", m.cache != nil)
+}
+
+// close stops updating the gauges.
+func (m *expiryMonitor) close() {
+	close(m.stop)
+	<-m.done
+}
+
+// sample updates the gauges. A key whose validity cannot be looked up keeps its last known
+// validity, so that a registry outage does not silence alerts.
+func (m *expiryMonitor) sample(ctx context.Context) {
+	now := m.now()
+	if m.cache != nil {
+		ttls := map[string]float64{}
+		for keyID, expiresAt := range m.cache.CachedKeysets() {
+			ttls[keyID] = expiresAt.Sub(now).Seconds()
+		}
+		setGauges(keysetTTLMetrics, ttls)
+	}
+	if len(m.keys) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+	for _, k := range m.keys {
+		validUntil, err := m.lookupValidity(ctx, k)
+		if err != nil {
+			slog.WarnContext(ctx, "KeyManager: Failed to look up key validity", "subscriber_id", k.SubscriberID, "key_id", k.KeyID, "error", err)
+			continue
+		}
+		m.validUntil[k] = validUntil
+	}
+	validity := map[string]float64{}
+	for k, validUntil := range m.validUntil {
+		validity[k.SubscriberID+"|"+k.KeyID] = validUntil.Sub(now).Seconds()
+	}
+	setGauges(keyValidityMetrics, validity)
+}
+
+// lookupValidity returns the earliest valid_until of the subscriptions of k in the registry.
+func (m *expiryMonitor) lookupValidity(ctx context.Context, k NPKey) (time.Time, error) {
+	subs, err := m.registry.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: k.SubscriberID},
+		KeyID:      k.KeyID,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	var validUntil time.Time
+	for _, s := range subs {
+		if !s.ValidUntil.IsZero() && (validUntil.IsZero() || s.ValidUntil.Before(validUntil)) {
+			validUntil = s.ValidUntil
+		}
+	}
+	if validUntil.IsZero() {
+		return time.Time{}, errors.New("no subscription with a valid_until found")
+	}
+	return validUntil, nil
+}
+
+// setGauges replaces the values of m with values.
+func setGauges(m *expvar.Map, values map[string]float64) {
+	var stale []string
+	m.Do(func(kv expvar.KeyValue) {
+		if _, ok := values[kv.Key]; !ok {
+			stale = append(stale, kv.Key)
+		}
+	})
+	for _, key := range stale {
+		m.Delete(key)
+	}
+	for key, v := range values {
+		f := new(expvar.Float)
+		f.Set(v)
+		m.Set(key, f)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+	"github.com/google/go-cmp/cmp"
+)
+
+// cachingKeyManager is a key manager that reports the expiry of its cached keysets.
+type cachingKeyManager struct {
+	*mapKeyManager
+	expiry map[string]time.Time
+}
+
+func (c *cachingKeyManager) CachedKeysets() map[string]time.Time {
+	return c.expiry
+}
+
+// fakeRegistryLookup returns the subscriptions of each subscriber ID.
+type fakeRegistryLookup struct {
+	subs map[string][]model.Subscription
+	err  error
+}
+
+func (f *fakeRegistryLookup) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	return f.subs[req.SubscriberID], f.err
+}
+
+// gauges returns the values published in m.
+func gauges(m *expvar.Map) map[string]float64 {
+	got := map[string]float64{}
+	m.Do(func(kv expvar.KeyValue) {
+		got[kv.Key] = kv.Value.(*expvar.Float).Value()
+	})
+	return got
+}
+
+func TestExpiryMonitor_Sample(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	km := &cachingKeyManager{
+		mapKeyManager: newMapKeyManager(nil),
+		expiry:        map[string]time.Time{"gateway.example.com": now.Add(90 * time.Second)},
+	}
+	registry := &fakeRegistryLookup{subs: map[string][]model.Subscription{
+		"gateway.example.com": {
+			{KeyID: "key1", ValidUntil: now.Add(48 * time.Hour)},
+			{KeyID: "key1", ValidUntil: now.Add(24 * time.Hour)},
+		},
+	}}
+	m, err := newExpiryMonitor(km, registry, &ExpiryConfig{Keys: []NPKey{{SubscriberID: "gateway.example.com", KeyID: "key1"}}})
+	if err != nil {
+		t.Fatalf("newExpiryMonitor() unexpected error: %v", err)
+	}
+	m.now = func() time.Time { return now }
+
+	m.sample(context.Background())
+	if diff := cmp.Diff(map[string]float64{"gateway.example.com": 90}, gauges(keysetTTLMetrics)); diff != "" {
+		t.Errorf("keyset TTL gauges mismatch (-want +got):\n%s", diff)
+	}
+	wantValidity := map[string]float64{"gateway.example.com|key1": 24 * 3600}
+	if diff := cmp.Diff(wantValidity, gauges(keyValidityMetrics)); diff != "" {
+		t.Errorf("key validity gauges mismatch (-want +got):\n%s", diff)
+	}
+
+	// Evicted keysets are removed and the last known validity survives a failed lookup.
+	km.expiry = nil
+	registry.err = errors.New("registry unavailable")
+	now = now.Add(time.Hour)
+	m.sample(context.Background())
+	if got := gauges(keysetTTLMetrics); len(got) != 0 {
+		t.Errorf("keyset TTL gauges = %v, want none", got)
+	}
+	wantValidity = map[string]float64{"gateway.example.com|key1": 23 * 3600}
+	if diff := cmp.Diff(wantValidity, gauges(keyValidityMetrics)); diff != "" {
+		t.Errorf("key validity gauges mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewExpiryMonitor_Errors(t *testing.T) {
+	key := NPKey{SubscriberID: "gateway.example.com", KeyID: "key1"}
+	tests := []struct {
+		name     string
+		registry plugin.RegistryLookup
+		cfg      *ExpiryConfig
+	}{
+		{name: "negative interval", cfg: &ExpiryConfig{Interval: -time.Second}},
+		{name: "keys without registry", cfg: &ExpiryConfig{Keys: []NPKey{key}}},
+		{name: "incomplete key", registry: &fakeRegistryLookup{}, cfg: &ExpiryConfig{Keys: []NPKey{{SubscriberID: "gateway.example.com"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newExpiryMonitor(newMapKeyManager(nil), tt.registry, tt.cfg)
+			if !errors.Is(err, ErrInvalidExpiry) {
+				t.Errorf("newExpiryMonitor() error = %v, want %v", err, ErrInvalidExpiry)
+			}
+		})
+	}
+}
+
+func TestNew_Expiry(t *testing.T) {
+	km := &cachingKeyManager{mapKeyManager: newMapKeyManager(nil)}
+	withConstructor(t, TypeVault, func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+		return km, func() error { km.closed = true; return nil }, nil
+	})
+
+	_, closeFn, err := New(context.Background(), nil, nil, &Config{Type: TypeVault, Expiry: &ExpiryConfig{Interval: time.Hour}})
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	if err := closeFn(); err != nil {
+		t.Fatalf("close error = %v, want nil", err)
+	}
+	if !km.closed {
+		t.Error("close did not close the key manager")
+	}
+
+	_, _, err = New(context.Background(), nil, nil, &Config{Type: TypeVault, Expiry: &ExpiryConfig{Keys: []NPKey{{SubscriberID: "s", KeyID: "k"}}}})
+	if !errors.Is(err, ErrInvalidExpiry) {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidExpiry)
+	}
+}
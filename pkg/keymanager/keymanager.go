@@ -0,0 +1,246 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keymanager provides a single entry point for creating key managers,
+// so binaries can select a key storage backend purely through configuration
+// instead of importing a concrete plugin package.
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/inmemorysecretkeymanager"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/k8ssecretkeymanager"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/secretskeymanager"
+)
+
+// KeyManager is the interface implemented by every key manager backend.
+type KeyManager = plugin.KeyManager
+
+// Type identifies a key manager backend.
+type Type string
+
+const (
+	// TypeGCPSecret stores keys in GCP Secret Manager and caches network keys in the shared cache.
+	TypeGCPSecret Type = "gcp-secret"
+	// TypeGCPInMemory stores keys in GCP Secret Manager and caches them in process memory.
+	TypeGCPInMemory Type = "gcp-inmemory"
+	// TypeVault stores keys in HashiCorp Vault.
+	TypeVault Type = "vault"
+	// TypeAWS stores keys in AWS Secrets Manager.
+	TypeAWS Type = "aws"
+	// TypeK8sSecret stores keys in Kubernetes Secrets.
+	TypeK8sSecret Type = "k8s-secret"
+)
+
+// DefaultType is the backend used when no type is configured.
+const DefaultType = TypeGCPInMemory
+
+var (
+	// ErrUnknownType occurs if the configured key manager type is not recognised.
+	ErrUnknownType = errors.New("unknown key manager type")
+
+	// ErrBackendNotAvailable occurs if the key manager type is recognised but no
+	// implementation has been registered for it in this binary.
+	ErrBackendNotAvailable = errors.New("key manager backend not available")
+
+	// ErrUndeleteNotSupported occurs if the key manager backend cannot recover deleted keys.
+	ErrUndeleteNotSupported = errors.New("key manager does not support undelete")
+)
+
+// CacheTTL holds the TTL configuration for cached keys in seconds.
+type CacheTTL struct {
+	PrivateKeysSeconds int `yaml:"privateKeysSeconds"`
+	PublicKeysSeconds  int `yaml:"publicKeysSeconds"`
+}
+
+// SoftDeleteConfig configures soft delete of keysets.
+type SoftDeleteConfig struct {
+	// RecoveryWindow is how long a deleted keyset can be recovered before it is destroyed.
+	RecoveryWindow time.Duration `yaml:"recoveryWindow"`
+}
+
+// K8sSecretConfig configures the k8s-secret backend. Inside a cluster, every field is optional.
+type K8sSecretConfig struct {
+	// APIServer is the URL of the Kubernetes API server. Defaults to the in-cluster address.
+	APIServer string `yaml:"apiServer"`
+	// Namespace holds the secrets. Defaults to the namespace of the pod's service account.
+	Namespace string `yaml:"namespace"`
+	// NameTemplate is the name of the secret of a key ID, in which "{keyID}" is replaced with
+	// the key ID. Defaults to "onix-keyset-{keyID}".
+	NameTemplate string `yaml:"nameTemplate"`
+	// TokenFile holds the bearer token of requests. Defaults to the service account token in the cluster.
+	TokenFile string `yaml:"tokenFile"`
+	// CAFile holds the CA certificates that verify the API server. Defaults to the service account CA in the cluster.
+	CAFile string `yaml:"caFile"`
+}
+
+// Config holds the configuration for creating a key manager.
+type Config struct {
+	Type      Type
+	ProjectID string
+	CacheTTL  CacheTTL
+	// SoftDelete enables soft delete of keysets if set. Otherwise keysets are deleted permanently.
+	SoftDelete *SoftDeleteConfig
+	// Migration enables dual-read migration from another backend if set.
+	Migration *MigrationConfig
+	// SigningAlgorithm is the algorithm of generated signing keys. Defaults to keyalgo.Default.
+	SigningAlgorithm keyalgo.Algorithm
+	// Warmup lists keys to load when the key manager is created if set.
+	Warmup *WarmupConfig
+	// Expiry publishes gauges of the remaining TTL and validity of keys if set.
+	Expiry *ExpiryConfig
+	// K8sSecret configures the k8s-secret backend. It may be nil inside a cluster.
+	K8sSecret *K8sSecretConfig
+}
+
+// Undeleter is implemented by key managers that can recover soft deleted keysets.
+type Undeleter interface {
+	UndeleteKeyset(ctx context.Context, keyID string) error
+}
+
+// Undelete recovers the soft deleted keyset of keyID, if the key manager supports it.
+func Undelete(ctx context.Context, km KeyManager, keyID string) error {
+	u, ok := km.(Undeleter)
+	if !ok {
+		return ErrUndeleteNotSupported
+	}
+	return u.UndeleteKeyset(ctx, keyID)
+}
+
+// Constructor creates a key manager backend from the given config.
+type Constructor func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error)
+
+// constructors maps each known backend type to its constructor.
+// A nil constructor marks a type that is recognised but not built into this binary.
+var constructors = map[Type]Constructor{
+	TypeGCPSecret:   newGCPSecret,
+	TypeGCPInMemory: newGCPInMemory,
+	TypeVault:       nil,
+	TypeAWS:         nil,
+	TypeK8sSecret:   newK8sSecret,
+}
+
+// Register sets the constructor for a backend type, replacing any existing one.
+// It is intended to be called during program initialisation and is not safe for concurrent use.
+func Register(tp Type, c Constructor) {
+	constructors[tp] = c
+}
+
+// New creates the key manager backend selected by cfg.Type, defaulting to DefaultType.
+// If cfg.Migration is set, the backend is wrapped to migrate keys from the backend it names.
+// If cfg.Warmup is set, the keys it lists are loaded before New returns.
+// If cfg.Expiry is set, key expiry gauges are updated until the key manager is closed.
+func New(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+	if cfg == nil {
+		slog.Error("keymanager.New: config cannot be nil")
+		return nil, nil, errors.New("key manager config cannot be nil")
+	}
+	tp := cfg.Type
+	if tp == "" {
+		tp = DefaultType
+	}
+	c, err := constructor(tp)
+	if err != nil {
+		return nil, nil, err
+	}
+	var km KeyManager
+	var closeKM func() error
+	if cfg.Migration != nil {
+		km, closeKM, err = newMigrating(ctx, cache, registry, tp, c, cfg)
+	} else {
+		slog.Info("KeyManager: Creating key manager", "type", tp)
+		km, closeKM, err = c(ctx, cache, registry, cfg)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var expiry *expiryMonitor
+	if cfg.Expiry != nil {
+		expiry, err = newExpiryMonitor(km, registry, cfg.Expiry)
+	}
+	if err == nil && cfg.Warmup != nil {
+		err = warmup(ctx, km, cfg.Warmup)
+	}
+	if err != nil {
+		if cerr := closeKM(); cerr != nil {
+			slog.Error("keymanager.New: Failed to close key manager", "type", tp, "error", cerr)
+		}
+		return nil, nil, err
+	}
+	if expiry == nil {
+		return km, closeKM, nil
+	}
+	expiry.start(ctx)
+	return km, func() error { expiry.close(); return closeKM() }, nil
+}
+
+// constructor returns the constructor registered for tp.
+func constructor(tp Type) (Constructor, error) {
+	c, ok := constructors[tp]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, tp)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w: %q", ErrBackendNotAvailable, tp)
+	}
+	return c, nil
+}
+
+func newGCPSecret(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+	return secretskeymanager.New(ctx, cache, registry, &secretskeymanager.Config{
+		ProjectID:                cfg.ProjectID,
+		SoftDeleteRecoveryWindow: cfg.recoveryWindow(),
+		SigningAlgorithm:         cfg.SigningAlgorithm,
+	})
+}
+
+func newGCPInMemory(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+	return inmemorysecretkeymanager.New(ctx, cache, registry, &inmemorysecretkeymanager.Config{
+		ProjectID: cfg.ProjectID,
+		CacheTTL: inmemorysecretkeymanager.CacheTTL{
+			PrivateKeysSeconds: cfg.CacheTTL.PrivateKeysSeconds,
+			PublicKeysSeconds:  cfg.CacheTTL.PublicKeysSeconds,
+		},
+		SoftDeleteRecoveryWindow: cfg.recoveryWindow(),
+		SigningAlgorithm:         cfg.SigningAlgorithm,
+	})
+}
+
+func newK8sSecret(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+	k8sCfg := &k8ssecretkeymanager.Config{SigningAlgorithm: cfg.SigningAlgorithm}
+	if c := cfg.K8sSecret; c != nil {
+		k8sCfg.APIServer = c.APIServer
+		k8sCfg.Namespace = c.Namespace
+		k8sCfg.NameTemplate = c.NameTemplate
+		k8sCfg.TokenFile = c.TokenFile
+		k8sCfg.CAFile = c.CAFile
+	}
+	return k8ssecretkeymanager.New(ctx, cache, registry, k8sCfg)
+}
+
+// recoveryWindow returns the soft delete recovery window, or zero if soft delete is disabled.
+func (cfg *Config) recoveryWindow() time.Duration {
+	if cfg.SoftDelete == nil {
+		return 0
+	}
+	return cfg.SoftDelete.RecoveryWindow
+}
@@ -0,0 +1,265 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+)
+
+type stubKeyManager struct {
+	cfg *Config
+}
+
+func (s *stubKeyManager) GenerateKeyset() (*model.Keyset, error) { return nil, nil }
+func (s *stubKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
+	return nil
+}
+func (s *stubKeyManager) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	return nil, nil
+}
+func (s *stubKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	return "", "", nil
+}
+func (s *stubKeyManager) DeleteKeyset(ctx context.Context, keyID string) error { return nil }
+
+// withConstructor registers c for tp for the duration of the test.
+func withConstructor(t *testing.T, tp Type, c Constructor) {
+	t.Helper()
+	prev, existed := constructors[tp]
+	Register(tp, c)
+	t.Cleanup(func() {
+		if existed {
+			constructors[tp] = prev
+			return
+		}
+		delete(constructors, tp)
+	})
+}
+
+func stubConstructor(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+	return &stubKeyManager{cfg: cfg}, func() error { return nil }, nil
+}
+
+func TestNew_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		register Type
+		cfg      *Config
+	}{
+		{
+			name:     "explicit type",
+			register: TypeVault,
+			cfg:      &Config{Type: TypeVault, ProjectID: "proj"},
+		},
+		{
+			name:     "empty type uses default",
+			register: DefaultType,
+			cfg:      &Config{ProjectID: "proj"},
+		},
+		{
+			name:     "custom type",
+			register: Type("custom"),
+			cfg:      &Config{Type: Type("custom")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConstructor(t, tt.register, stubConstructor)
+
+			km, closeFn, err := New(context.Background(), nil, nil, tt.cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v, want nil", err)
+			}
+			stub, ok := km.(*stubKeyManager)
+			if !ok {
+				t.Fatalf("New() returned %T, want *stubKeyManager", km)
+			}
+			if stub.cfg != tt.cfg {
+				t.Errorf("constructor received config %+v, want %+v", stub.cfg, tt.cfg)
+			}
+			if closeFn == nil {
+				t.Error("New() close function is nil")
+			}
+		})
+	}
+}
+
+func TestNew_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr error
+	}{
+		{
+			name: "nil config",
+		},
+		{
+			name:    "unknown type",
+			cfg:     &Config{Type: Type("gcp-kms")},
+			wantErr: ErrUnknownType,
+		},
+		{
+			name:    "vault not available",
+			cfg:     &Config{Type: TypeVault},
+			wantErr: ErrBackendNotAvailable,
+		},
+		{
+			name:    "aws not available",
+			cfg:     &Config{Type: TypeAWS},
+			wantErr: ErrBackendNotAvailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, _, err := New(context.Background(), nil, nil, tt.cfg)
+			if err == nil {
+				t.Fatal("New() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if km != nil {
+				t.Errorf("New() key manager = %v, want nil", km)
+			}
+		})
+	}
+}
+
+// stubCache is a plugin.Cache that holds nothing.
+type stubCache struct{}
+
+func (stubCache) Get(ctx context.Context, key string) (string, error) {
+	return "", errors.New("not found")
+}
+func (stubCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return nil
+}
+func (stubCache) Delete(ctx context.Context, key string) error { return nil }
+func (stubCache) Clear(ctx context.Context) error              { return nil }
+
+func TestNew_K8sSecret(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	km, closeKM, err := New(context.Background(), stubCache{}, &fakeRegistryLookup{}, &Config{
+		Type:      TypeK8sSecret,
+		K8sSecret: &K8sSecretConfig{APIServer: srv.URL, Namespace: "onix", NameTemplate: "keys-{keyID}"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer closeKM()
+
+	if err := km.DeleteKeyset(context.Background(), "np.example.com"); err != nil {
+		t.Fatalf("DeleteKeyset() error = %v", err)
+	}
+	if want := "/api/v1/namespaces/onix/secrets/keys-np.example.com"; gotMethod != http.MethodDelete || gotPath != want {
+		t.Errorf("DeleteKeyset() sent %s %s, want DELETE %s", gotMethod, gotPath, want)
+	}
+}
+
+func TestNew_K8sSecret_Error(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	tests := []struct {
+		name string
+		cfg  *K8sSecretConfig
+	}{
+		{name: "outside a cluster without apiServer"},
+		{name: "invalid name template", cfg: &K8sSecretConfig{APIServer: "https://k8s.example.com", Namespace: "onix", NameTemplate: "keys"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, _, err := New(context.Background(), stubCache{}, &fakeRegistryLookup{}, &Config{Type: TypeK8sSecret, K8sSecret: tt.cfg})
+			if err == nil {
+				t.Fatal("New() error = nil, want error")
+			}
+			if km != nil {
+				t.Errorf("New() key manager = %v, want nil", km)
+			}
+		})
+	}
+}
+
+type stubUndeleter struct {
+	stubKeyManager
+	gotKeyID string
+	err      error
+}
+
+func (s *stubUndeleter) UndeleteKeyset(ctx context.Context, keyID string) error {
+	s.gotKeyID = keyID
+	return s.err
+}
+
+func TestUndelete(t *testing.T) {
+	errUndelete := errors.New("undelete failed")
+	tests := []struct {
+		name    string
+		km      KeyManager
+		wantErr error
+	}{
+		{
+			name: "supported",
+			km:   &stubUndeleter{},
+		},
+		{
+			name:    "backend fails",
+			km:      &stubUndeleter{err: errUndelete},
+			wantErr: errUndelete,
+		},
+		{
+			name:    "not supported",
+			km:      &stubKeyManager{},
+			wantErr: ErrUndeleteNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Undelete(context.Background(), tt.km, "key1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Undelete() error = %v, want %v", err, tt.wantErr)
+			}
+			if u, ok := tt.km.(*stubUndeleter); ok && u.gotKeyID != "key1" {
+				t.Errorf("UndeleteKeyset() keyID = %q, want %q", u.gotKeyID, "key1")
+			}
+		})
+	}
+}
+
+func TestConfig_RecoveryWindow(t *testing.T) {
+	if got := (&Config{}).recoveryWindow(); got != 0 {
+		t.Errorf("recoveryWindow() without soft delete = %v, want 0", got)
+	}
+	cfg := &Config{SoftDelete: &SoftDeleteConfig{RecoveryWindow: 24 * time.Hour}}
+	if got := cfg.recoveryWindow(); got != 24*time.Hour {
+		t.Errorf("recoveryWindow() = %v, want %v", got, 24*time.Hour)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+)
+
+// ErrInvalidMigration occurs if the migration source is missing or is the configured backend.
+var ErrInvalidMigration = errors.New("invalid key manager migration")
+
+// migrationMetrics counts keyset reads and writes served during a migration.
+var migrationMetrics = expvar.NewMap("keymanager_migration")
+
+// MigrationConfig configures a zero-downtime migration between key manager backends.
+// While it is set, keysets are read from the configured backend first and from the
+// From backend if missing there, and are written to and deleted from both.
+type MigrationConfig struct {
+	// From is the backend keys are being migrated from.
+	From Type `yaml:"from"`
+}
+
+// migratingKeyManager reads keysets from the new backend with a fallback to the old one
+// and writes them to both, so that either can serve every key during the migration.
+type migratingKeyManager struct {
+	next KeyManager
+	prev KeyManager
+	from Type
+}
+
+// newMigrating creates the backend tp with c and wraps it with the migration source.
+func newMigrating(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, tp Type, c Constructor, cfg *Config) (KeyManager, func() error, error) {
+	from := cfg.Migration.From
+	if from == "" || from == tp {
+		slog.Error("keymanager.New: Invalid migration source.", "type", tp, "from", from)
+		return nil, nil, fmt.Errorf("%w: cannot migrate from %q to %q", ErrInvalidMigration, from, tp)
+	}
+	prevC, err := constructor(from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("migration source: %w", err)
+	}
+	slog.Info("KeyManager: Creating key manager in migration mode", "type", tp, "from", from)
+	next, closeNext, err := c(ctx, cache, registry, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	prev, closePrev, err := prevC(ctx, cache, registry, cfg)
+	if err != nil {
+		if cerr := closeNext(); cerr != nil {
+			slog.Error("keymanager.New: Failed to close key manager", "type", tp, "error", cerr)
+		}
+		return nil, nil, fmt.Errorf("failed to create migration source %q: %w", from, err)
+	}
+	km := &migratingKeyManager{next: next, prev: prev, from: from}
+	return km, func() error { return errors.Join(closeNext(), closePrev()) }, nil
+}
+
+// GenerateKeyset generates a keyset with the new backend.
+func (m *migratingKeyManager) GenerateKeyset() (*model.Keyset, error) {
+	return m.next.GenerateKeyset()
+}
+
+// InsertKeyset stores the keyset in both backends, so that the migration can be rolled back.
+func (m *migratingKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
+	if err := m.next.InsertKeyset(ctx, keyID, keyset); err != nil {
+		return err
+	}
+	if err := m.prev.InsertKeyset(ctx, keyID, keyset); err != nil {
+		slog.ErrorContext(ctx, "KeyManager: Failed to write keyset to migration source", "key_id", keyID, "from", m.from, "error", err)
+		return fmt.Errorf("failed to write keyset to migration source %q: %w", m.from, err)
+	}
+	migrationMetrics.Add("dual_writes", 1)
+	return nil
+}
+
+// Keyset reads the keyset from the new backend, falling back to the migration source.
+func (m *migratingKeyManager) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	ks, err := m.next.Keyset(ctx, keyID)
+	if err == nil {
+		migrationMetrics.Add("reads", 1)
+		return ks, nil
+	}
+	ks, prevErr := m.prev.Keyset(ctx, keyID)
+	if prevErr != nil {
+		return nil, errors.Join(err, prevErr)
+	}
+	slog.InfoContext(ctx, "KeyManager: Read keyset from migration source", "key_id", keyID, "from", m.from, "error", err)
+	migrationMetrics.Add("fallback_reads", 1)
+	return ks, nil
+}
+
+// DeleteKeyset deletes the keyset from both backends. It succeeds if either held it.
+func (m *migratingKeyManager) DeleteKeyset(ctx context.Context, keyID string) error {
+	err, prevErr := m.next.DeleteKeyset(ctx, keyID), m.prev.DeleteKeyset(ctx, keyID)
+	if err != nil && prevErr != nil {
+		return errors.Join(err, prevErr)
+	}
+	return nil
+}
+
+// UndeleteKeyset recovers the keyset in both backends. It succeeds if either recovered it.
+func (m *migratingKeyManager) UndeleteKeyset(ctx context.Context, keyID string) error {
+	err, prevErr := Undelete(ctx, m.next, keyID), Undelete(ctx, m.prev, keyID)
+	if err != nil && prevErr != nil {
+		return errors.Join(err, prevErr)
+	}
+	return nil
+}
+
+// CachedKeysets returns the keysets cached by the new backend, if it caches any.
+func (m *migratingKeyManager) CachedKeysets() map[string]time.Time {
+	if c, ok := m.next.(CacheExpirer); ok {
+		return c.CachedKeysets()
+	}
+	return nil
+}
+
+// WipeKeys wipes the private keys both backends hold in memory.
+func (m *migratingKeyManager) WipeKeys() int {
+	return Wipe(m.next) + Wipe(m.prev)
+}
+
+// LookupNPKeys looks up the keys of other network participants through the new backend.
+func (m *migratingKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	return m.next.LookupNPKeys(ctx, subscriberID, uniqueKeyID)
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+)
+
+var errNotFound = errors.New("keyset not found")
+
+// mapKeyManager is an in-memory KeyManager that can recover deleted keysets.
+type mapKeyManager struct {
+	stubKeyManager
+	keysets   map[string]*model.Keyset
+	deleted   map[string]*model.Keyset
+	insertErr error
+	closed    bool
+}
+
+func newMapKeyManager(keysets map[string]*model.Keyset) *mapKeyManager {
+	if keysets == nil {
+		keysets = map[string]*model.Keyset{}
+	}
+	return &mapKeyManager{keysets: keysets, deleted: map[string]*model.Keyset{}}
+}
+
+func (m *mapKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
+	if m.insertErr != nil {
+		return m.insertErr
+	}
+	m.keysets[keyID] = keyset
+	return nil
+}
+
+func (m *mapKeyManager) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	ks, ok := m.keysets[keyID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return ks, nil
+}
+
+func (m *mapKeyManager) DeleteKeyset(ctx context.Context, keyID string) error {
+	ks, ok := m.keysets[keyID]
+	if !ok {
+		return errNotFound
+	}
+	delete(m.keysets, keyID)
+	m.deleted[keyID] = ks
+	return nil
+}
+
+func (m *mapKeyManager) UndeleteKeyset(ctx context.Context, keyID string) error {
+	ks, ok := m.deleted[keyID]
+	if !ok {
+		return errNotFound
+	}
+	delete(m.deleted, keyID)
+	m.keysets[keyID] = ks
+	return nil
+}
+
+// withMigration registers map key managers for the new and old backends and creates a
+// key manager migrating between them.
+func withMigration(t *testing.T, next, prev *mapKeyManager) KeyManager {
+	t.Helper()
+	constructorFor := func(km *mapKeyManager) Constructor {
+		return func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+			return km, func() error { km.closed = true; return nil }, nil
+		}
+	}
+	withConstructor(t, TypeVault, constructorFor(next))
+	withConstructor(t, TypeAWS, constructorFor(prev))
+	km, closeFn, err := New(context.Background(), nil, nil, &Config{Type: TypeVault, Migration: &MigrationConfig{From: TypeAWS}})
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	t.Cleanup(func() {
+		if err := closeFn(); err != nil {
+			t.Errorf("close() error = %v", err)
+		}
+		if !next.closed || !prev.closed {
+			t.Error("close() did not close both backends")
+		}
+	})
+	return km
+}
+
+func TestNew_Migration_Error(t *testing.T) {
+	errCreate := errors.New("create failed")
+	failing := func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+		return nil, nil, errCreate
+	}
+	tests := []struct {
+		name    string
+		from    Type
+		prev    Constructor
+		wantErr error
+	}{
+		{name: "missing source", wantErr: ErrInvalidMigration},
+		{name: "same backend", from: TypeVault, wantErr: ErrInvalidMigration},
+		{name: "unknown source", from: Type("gcp-kms"), wantErr: ErrUnknownType},
+		{name: "source not available", from: TypeAWS, wantErr: ErrBackendNotAvailable},
+		{name: "source fails", from: TypeAWS, prev: failing, wantErr: errCreate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := newMapKeyManager(nil)
+			withConstructor(t, TypeVault, func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+				return next, func() error { next.closed = true; return nil }, nil
+			})
+			withConstructor(t, TypeAWS, tt.prev)
+
+			km, _, err := New(context.Background(), nil, nil, &Config{Type: TypeVault, Migration: &MigrationConfig{From: tt.from}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if km != nil {
+				t.Errorf("New() key manager = %v, want nil", km)
+			}
+			if tt.prev != nil && !next.closed {
+				t.Error("New() did not close the new backend after the source failed")
+			}
+		})
+	}
+}
+
+func TestMigration_Keyset(t *testing.T) {
+	tests := []struct {
+		name    string
+		next    map[string]*model.Keyset
+		prev    map[string]*model.Keyset
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "new backend first",
+			next: map[string]*model.Keyset{"k1": {UniqueKeyID: "next"}},
+			prev: map[string]*model.Keyset{"k1": {UniqueKeyID: "prev"}},
+			want: "next",
+		},
+		{
+			name: "fallback to source",
+			prev: map[string]*model.Keyset{"k1": {UniqueKeyID: "prev"}},
+			want: "prev",
+		},
+		{
+			name:    "missing in both",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := withMigration(t, newMapKeyManager(tt.next), newMapKeyManager(tt.prev))
+
+			ks, err := km.Keyset(context.Background(), "k1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Keyset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errNotFound) {
+					t.Errorf("Keyset() error = %v, want %v", err, errNotFound)
+				}
+				return
+			}
+			if ks.UniqueKeyID != tt.want {
+				t.Errorf("Keyset() = %q, want %q", ks.UniqueKeyID, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigration_InsertKeyset(t *testing.T) {
+	next, prev := newMapKeyManager(nil), newMapKeyManager(nil)
+	km := withMigration(t, next, prev)
+
+	ks := &model.Keyset{UniqueKeyID: "k1"}
+	if err := km.InsertKeyset(context.Background(), "k1", ks); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	if next.keysets["k1"] != ks || prev.keysets["k1"] != ks {
+		t.Error("InsertKeyset() did not write the keyset to both backends")
+	}
+}
+
+func TestMigration_InsertKeyset_Error(t *testing.T) {
+	errInsert := errors.New("insert failed")
+	tests := []struct {
+		name     string
+		nextErr  error
+		prevErr  error
+		wantPrev bool
+	}{
+		{name: "new backend fails", nextErr: errInsert},
+		{name: "source fails", prevErr: errInsert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, prev := newMapKeyManager(nil), newMapKeyManager(nil)
+			next.insertErr, prev.insertErr = tt.nextErr, tt.prevErr
+			km := withMigration(t, next, prev)
+
+			if err := km.InsertKeyset(context.Background(), "k1", &model.Keyset{}); !errors.Is(err, errInsert) {
+				t.Errorf("InsertKeyset() error = %v, want %v", err, errInsert)
+			}
+			if tt.nextErr != nil && len(prev.keysets) != 0 {
+				t.Error("InsertKeyset() wrote to the source after the new backend failed")
+			}
+		})
+	}
+}
+
+func TestMigration_DeleteAndUndelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		next    map[string]*model.Keyset
+		prev    map[string]*model.Keyset
+		wantErr bool
+	}{
+		{name: "in both", next: map[string]*model.Keyset{"k1": {}}, prev: map[string]*model.Keyset{"k1": {}}},
+		{name: "only in source", prev: map[string]*model.Keyset{"k1": {}}},
+		{name: "only in new backend", next: map[string]*model.Keyset{"k1": {}}},
+		{name: "in neither", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, prev := newMapKeyManager(tt.next), newMapKeyManager(tt.prev)
+			km := withMigration(t, next, prev)
+			ctx := context.Background()
+
+			if err := km.DeleteKeyset(ctx, "k1"); (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteKeyset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(next.keysets) != 0 || len(prev.keysets) != 0 {
+				t.Error("DeleteKeyset() left the keyset in a backend")
+			}
+			if err := Undelete(ctx, km, "k1"); (err != nil) != tt.wantErr {
+				t.Fatalf("Undelete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(next.keysets) != len(tt.next) || len(prev.keysets) != len(tt.prev) {
+				t.Error("Undelete() did not restore the keyset in the backends that held it")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// defaultWarmupTimeout bounds the warmup if no timeout is configured.
+const defaultWarmupTimeout = 30 * time.Second
+
+// ErrWarmupFailed occurs if a required warmup could not load every configured key.
+var ErrWarmupFailed = errors.New("key manager warmup failed")
+
+// warmupMetrics counts the keys loaded and failed to load at startup.
+var warmupMetrics = expvar.NewMap("keymanager_warmup")
+
+// NPKey identifies the public key of another network participant.
+type NPKey struct {
+	SubscriberID string `yaml:"subscriberID"`
+	KeyID        string `yaml:"keyID"`
+}
+
+// WarmupConfig lists keys that are loaded when the key manager is created, so that
+// the first requests after a deploy do not wait for them to be fetched.
+type WarmupConfig struct {
+	// KeyIDs are the keysets of the service itself, such as the gateway's own key.
+	KeyIDs []string `yaml:"keyIDs"`
+	// NPKeys are the public keys of other participants, such as the registry.
+	NPKeys []NPKey `yaml:"npKeys"`
+	// Timeout bounds the whole warmup. Defaults to 30s.
+	Timeout time.Duration `yaml:"timeout"`
+	// Required fails startup if a key cannot be loaded. Otherwise failures are only logged
+	// and the key is fetched on first use as usual.
+	Required bool `yaml:"required"`
+}
+
+// warmup loads the configured keys through km, which caches them.
+func warmup(ctx context.Context, km KeyManager, cfg *WarmupConfig) error {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWarmupTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var errs []error
+	for _, keyID := range cfg.KeyIDs {
+		if _, err := km.Keyset(ctx, keyID); err != nil {
+			slog.WarnContext(ctx, "KeyManager: Failed to preload keyset", "key_id", keyID, "error", err)
+			warmupMetrics.Add("failed", 1)
+			errs = append(errs, fmt.Errorf("keyset %q: %w", keyID, err))
+			continue
+		}
+		warmupMetrics.Add("loaded", 1)
+	}
+	for _, k := range cfg.NPKeys {
+		if _, _, err := km.LookupNPKeys(ctx, k.SubscriberID, k.KeyID); err != nil {
+			slog.WarnContext(ctx, "KeyManager: Failed to preload network participant keys", "subscriber_id", k.SubscriberID, "key_id", k.KeyID, "error", err)
+			warmupMetrics.Add("failed", 1)
+			errs = append(errs, fmt.Errorf("keys of %q/%q: %w", k.SubscriberID, k.KeyID, err))
+			continue
+		}
+		warmupMetrics.Add("loaded", 1)
+	}
+	slog.InfoContext(ctx, "KeyManager: Preloaded keys", "keys", len(cfg.KeyIDs)+len(cfg.NPKeys), "failed", len(errs), "duration", time.Since(start))
+	if len(errs) > 0 && cfg.Required {
+		return fmt.Errorf("%w: %w", ErrWarmupFailed, errors.Join(errs...))
+	}
+	return nil
+}
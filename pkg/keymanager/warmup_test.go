@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+	"github.com/google/go-cmp/cmp"
+)
+
+// warmupKeyManager records the keys loaded through it.
+type warmupKeyManager struct {
+	*mapKeyManager
+	npKeys map[NPKey]bool
+	loaded []string
+	looked []NPKey
+}
+
+func (w *warmupKeyManager) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	w.loaded = append(w.loaded, keyID)
+	return w.mapKeyManager.Keyset(ctx, keyID)
+}
+
+func (w *warmupKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	k := NPKey{SubscriberID: subscriberID, KeyID: uniqueKeyID}
+	w.looked = append(w.looked, k)
+	if !w.npKeys[k] {
+		return "", "", errNotFound
+	}
+	return "signing", "encr", nil
+}
+
+func (w *warmupKeyManager) close() error {
+	w.closed = true
+	return nil
+}
+
+func TestNew_Warmup(t *testing.T) {
+	registry := NPKey{SubscriberID: "registry.example.com", KeyID: "registry-key"}
+	tests := []struct {
+		name       string
+		cfg        *WarmupConfig
+		wantErr    error
+		wantLoaded []string
+		wantLooked []NPKey
+	}{
+		{
+			name:       "all keys loaded",
+			cfg:        &WarmupConfig{KeyIDs: []string{"gateway-key"}, NPKeys: []NPKey{registry}, Required: true},
+			wantLoaded: []string{"gateway-key"},
+			wantLooked: []NPKey{registry},
+		},
+		{
+			name:       "missing key is tolerated",
+			cfg:        &WarmupConfig{KeyIDs: []string{"missing", "gateway-key"}, NPKeys: []NPKey{{SubscriberID: "np", KeyID: "k"}, registry}},
+			wantLoaded: []string{"missing", "gateway-key"},
+			wantLooked: []NPKey{{SubscriberID: "np", KeyID: "k"}, registry},
+		},
+		{
+			name:       "missing keyset fails required warmup",
+			cfg:        &WarmupConfig{KeyIDs: []string{"missing"}, Required: true},
+			wantErr:    ErrWarmupFailed,
+			wantLoaded: []string{"missing"},
+		},
+		{
+			name:       "missing network key fails required warmup",
+			cfg:        &WarmupConfig{NPKeys: []NPKey{{SubscriberID: "np", KeyID: "k"}}, Required: true},
+			wantErr:    ErrWarmupFailed,
+			wantLooked: []NPKey{{SubscriberID: "np", KeyID: "k"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := &warmupKeyManager{
+				mapKeyManager: newMapKeyManager(map[string]*model.Keyset{"gateway-key": {UniqueKeyID: "gateway-key"}}),
+				npKeys:        map[NPKey]bool{registry: true},
+			}
+			withConstructor(t, TypeVault, func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *Config) (KeyManager, func() error, error) {
+				return km, km.close, nil
+			})
+
+			got, _, err := New(context.Background(), nil, nil, &Config{Type: TypeVault, Warmup: tt.cfg})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if got != nil {
+					t.Errorf("New() = %v, want nil", got)
+				}
+				if !km.closed {
+					t.Error("New() did not close the key manager after a failed warmup")
+				}
+			} else if km.closed {
+				t.Error("New() closed the key manager after a successful warmup")
+			}
+			if diff := cmp.Diff(tt.wantLoaded, km.loaded); diff != "" {
+				t.Errorf("preloaded keysets mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantLooked, km.looked); diff != "" {
+				t.Errorf("preloaded network keys mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
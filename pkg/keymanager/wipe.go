@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Wiper is implemented by key managers that hold private keys in memory.
+type Wiper interface {
+	// WipeKeys overwrites the private keys held in memory and drops them, returning how many
+	// keysets were wiped. The key manager remains usable and loads keys again when read.
+	WipeKeys() int
+}
+
+// Wipe wipes the private keys km holds in memory, if it holds any, and returns how many
+// keysets were wiped.
+func Wipe(km KeyManager) int {
+	w, ok := km.(Wiper)
+	if !ok {
+		return 0
+	}
+	n := w.WipeKeys()
+	slog.Info("KeyManager: Wiped private keys held in memory", "keysets", n)
+	return n
+}
+
+// WipeOnSignal wipes the private keys km holds in memory and calls exit with status 1 if a
+// signal is received on sigs before the returned stop function is called. Services use it
+// while shutting down gracefully, so that a second shutdown signal still wipes the keys
+// before the process exits without running its deferred Close calls.
+func WipeOnSignal(km KeyManager, sigs <-chan os.Signal, exit func(code int)) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case sig := <-sigs:
+			slog.Warn("KeyManager: Shutdown signal received again, wiping keys and exiting", "signal", sig.String())
+			Wipe(km)
+			exit(1)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		wg.Wait()
+	}
+}
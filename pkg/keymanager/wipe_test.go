@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// wipingKeyManager is a key manager that holds keysets in memory until they are wiped.
+type wipingKeyManager struct {
+	stubKeyManager
+	keysets int
+	wipes   atomic.Int32
+}
+
+func (m *wipingKeyManager) WipeKeys() int {
+	m.wipes.Add(1)
+	n := m.keysets
+	m.keysets = 0
+	return n
+}
+
+func TestWipe(t *testing.T) {
+	tests := []struct {
+		name string
+		km   func() (KeyManager, []*wipingKeyManager)
+		want int
+	}{
+		{
+			name: "key manager without keys in memory",
+			km:   func() (KeyManager, []*wipingKeyManager) { return &stubKeyManager{}, nil },
+			want: 0,
+		},
+		{
+			name: "key manager with keys in memory",
+			km: func() (KeyManager, []*wipingKeyManager) {
+				w := &wipingKeyManager{keysets: 2}
+				return w, []*wipingKeyManager{w}
+			},
+			want: 2,
+		},
+		{
+			name: "migration wipes both backends",
+			km: func() (KeyManager, []*wipingKeyManager) {
+				next, prev := &wipingKeyManager{keysets: 1}, &wipingKeyManager{keysets: 2}
+				return &migratingKeyManager{next: next, prev: prev, from: TypeAWS}, []*wipingKeyManager{next, prev}
+			},
+			want: 3,
+		},
+		{
+			name: "migration from a backend without keys in memory",
+			km: func() (KeyManager, []*wipingKeyManager) {
+				next := &wipingKeyManager{keysets: 1}
+				return &migratingKeyManager{next: next, prev: &stubKeyManager{}, from: TypeAWS}, []*wipingKeyManager{next}
+			},
+			want: 1,
+		},
+		{
+			name: "auditing forwards to the wrapped key manager",
+			km: func() (KeyManager, []*wipingKeyManager) {
+				w := &wipingKeyManager{keysets: 1}
+				a, _ := newTestAuditing(t, w, &recordingAuditPublisher{}, nil)
+				return a, []*wipingKeyManager{w}
+			},
+			want: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			km, wipers := tc.km()
+			if got := Wipe(km); got != tc.want {
+				t.Errorf("Wipe() = %d, want %d", got, tc.want)
+			}
+			for i, w := range wipers {
+				if got := w.wipes.Load(); got != 1 {
+					t.Errorf("backend %d wiped %d times, want 1", i, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWipeOnSignal_Signal(t *testing.T) {
+	km := &wipingKeyManager{keysets: 1}
+	sigs := make(chan os.Signal, 1)
+	exited := make(chan int, 1)
+	stop := WipeOnSignal(km, sigs, func(code int) { exited <- code })
+
+	sigs <- syscall.SIGTERM
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WipeOnSignal() did not exit after a signal")
+	}
+	if got := km.wipes.Load(); got != 1 {
+		t.Errorf("keys wiped %d times before exit, want 1", got)
+	}
+	stop()
+}
+
+func TestWipeOnSignal_Stop(t *testing.T) {
+	km := &wipingKeyManager{keysets: 1}
+	sigs := make(chan os.Signal, 1)
+	stop := WipeOnSignal(km, sigs, func(code int) { t.Errorf("exit(%d) called after stop", code) })
+
+	stop()
+	stop() // Stopping again is a no-op.
+	sigs <- syscall.SIGINT
+	if got := km.wipes.Load(); got != 0 {
+		t.Errorf("keys wiped %d times after stop, want 0", got)
+	}
+	if len(sigs) != 1 {
+		t.Error("WipeOnSignal() consumed a signal after stop")
+	}
+}
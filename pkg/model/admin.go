@@ -14,16 +14,54 @@
 
 package model
 
+import "time"
+
 // OperationActionRequest defines the request body for the admin subscription action endpoint.
 type OperationActionRequest struct {
 	// Action specifies the action to perform on the subscription (APPROVE/REJECT).
-	Action OperationAction `json:"action"`
+	Action OperationAction `json:"action" enum:"APPROVE_SUBSCRIPTION,REJECT_SUBSCRIPTION"`
 
 	// OperationID specifies the ID of the target operation.
 	OperationID string `json:"operation_id"`
 
 	// Reason provides the rejection reason when rejecting a subscription.
 	Reason string `json:"reason,omitempty"`
+
+	// Comment is an optional note from the reviewer, recorded on the operation.
+	Comment string `json:"comment,omitempty"`
+
+	// Reviewer is the identity of the admin taking the action. It is taken from a
+	// trusted request header set by the authenticating proxy, never from the body.
+	Reviewer string `json:"-"`
+
+	// DryRun runs the approval checks (challenge, on_subscribe call and verification)
+	// without persisting the subscription or updating the operation.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// ValidFrom overrides the valid_from requested by the network participant on approval.
+	ValidFrom time.Time `json:"valid_from,omitzero"`
+
+	// ValidUntil overrides the valid_until requested by the network participant on approval.
+	// It must be within the validity policy of the admin service, if one is set.
+	ValidUntil time.Time `json:"valid_until,omitzero"`
+}
+
+// ApprovalDryRunResponse is the result of an approval dry run.
+type ApprovalDryRunResponse struct {
+	// OperationID is the ID of the operation that was tested.
+	OperationID string `json:"operation_id"`
+
+	// DryRun is always true, to distinguish the response from an LRO.
+	DryRun bool `json:"dry_run"`
+
+	// Ready reports whether the network participant passed the challenge.
+	Ready bool `json:"ready"`
+
+	// Subscription is the subscription that would be stored on approval.
+	Subscription *Subscription `json:"subscription,omitempty"`
+
+	// Error describes why the dry run failed, if it did.
+	Error string `json:"error,omitempty"`
 }
 
 // OperationAction defines the possible actions an admin can take on a subscription.
@@ -37,3 +75,383 @@ const (
 	// OperationActionRejectSubscription represents the action to reject a subscription.
 	OperationActionRejectSubscription OperationAction = "REJECT_SUBSCRIPTION"
 )
+
+// OperationReview records which admin acted on an operation and when, for accountability.
+type OperationReview struct {
+	// Reviewer is the identity of the admin, if the admin API is configured to receive one.
+	Reviewer string `json:"reviewer,omitempty"`
+
+	// Action is the action the admin took.
+	Action OperationAction `json:"action" enum:"APPROVE_SUBSCRIPTION,REJECT_SUBSCRIPTION"`
+
+	// Comment is the admin's optional note on the action.
+	Comment string `json:"comment,omitempty"`
+
+	// ReviewedAt is when the action was taken.
+	ReviewedAt time.Time `json:"reviewed_at"`
+
+	// Approvals lists the approvals of distinct admins, oldest first, for operations that
+	// require two approvals.
+	Approvals []OperationApproval `json:"approvals,omitempty"`
+}
+
+// SubState returns the sub-state of an operation with this review.
+func (r *OperationReview) SubState() LROSubState {
+	if r != nil && r.Action == OperationActionApproveSubscription && len(r.Approvals) == 1 {
+		return LROSubStatePendingSecondApproval
+	}
+	return ""
+}
+
+// OperationApproval records one admin's approval of an operation that requires two approvals.
+type OperationApproval struct {
+	// Approver is the identity of the admin.
+	Approver string `json:"approver"`
+
+	// Comment is the admin's optional note on the approval.
+	Comment string `json:"comment,omitempty"`
+
+	// ApprovedAt is when the approval was recorded.
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// OperationComment is a note an admin attached to an operation while reviewing it, with
+// references to the documents checked, for networks whose onboarding requires manual checks.
+type OperationComment struct {
+	// ID identifies the comment.
+	ID string `json:"id"`
+
+	// Author is the identity of the admin, if the admin API is configured to receive one.
+	Author string `json:"author,omitempty"`
+
+	// Text is the note.
+	Text string `json:"text,omitempty"`
+
+	// Attachments are the GCS URIs (gs://bucket/object) of the documents the comment refers to.
+	// The documents themselves are not stored by the registry.
+	Attachments []string `json:"attachments,omitempty"`
+
+	// CreatedAt is when the comment was added.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChallengeReplay reports whether a subscriber still passes the /on_subscribe challenge of an
+// approved operation, e.g. after it reports changes to its infrastructure.
+type ChallengeReplay struct {
+	// OperationID is the approved operation whose challenge was replayed.
+	OperationID string `json:"operation_id"`
+
+	// SubscriberID, KeyID and URL identify the subscription the challenge was sent to.
+	SubscriberID string `json:"subscriber_id"`
+	KeyID        string `json:"key_id"`
+	URL          string `json:"url"`
+
+	// Passed is true if the subscriber decrypted the challenge and, if it signed the answer,
+	// the signature matches its registered signing key.
+	Passed bool `json:"passed"`
+
+	// Signed is true if the answer was signed.
+	Signed bool `json:"signed"`
+
+	// Error is why the challenge did not pass.
+	Error string `json:"error,omitempty"`
+
+	// CheckedAt is when the challenge was replayed.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// OperationCommentRequest adds a comment to an operation. The operation is taken from the path.
+type OperationCommentRequest struct {
+	// Text is the note. It is required unless attachments are given.
+	Text string `json:"text"`
+
+	// Attachments are the GCS URIs (gs://bucket/object) of the documents the comment refers to.
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+// DecisionImportResult defines the outcome of an imported decision.
+type DecisionImportResult string
+
+// Defines the valid DecisionImportResult values.
+const (
+	// DecisionImportResultApplied indicates that the decision was applied to the operation.
+	DecisionImportResultApplied DecisionImportResult = "APPLIED"
+
+	// DecisionImportResultFailed indicates that the decision is invalid or could not be applied.
+	DecisionImportResultFailed DecisionImportResult = "FAILED"
+
+	// DecisionImportResultSkipped indicates that the import was canceled before the decision was processed.
+	DecisionImportResultSkipped DecisionImportResult = "SKIPPED"
+)
+
+// DecisionImportRow is one approval decision of an import and its outcome.
+type DecisionImportRow struct {
+	// Line is the line of the decision in the imported CSV file.
+	Line int `json:"line"`
+
+	// OperationID is the ID of the operation the decision applies to.
+	OperationID string `json:"operation_id"`
+
+	// Action is the action to take on the operation.
+	Action OperationAction `json:"action" enum:"APPROVE_SUBSCRIPTION,REJECT_SUBSCRIPTION"`
+
+	// Reason is the rejection reason.
+	Reason string `json:"reason,omitempty"`
+
+	// Result is the outcome of the decision.
+	Result DecisionImportResult `json:"result" enum:"APPLIED,FAILED,SKIPPED"`
+
+	// LROStatus is the status of the operation after the decision was applied.
+	LROStatus LROStatus `json:"lro_status,omitempty" enum:"PENDING,APPROVED,FAILURE,REJECTED,STALE"`
+
+	// Error describes why the decision failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// DecisionImportReport is the result of importing a batch of approval decisions.
+type DecisionImportReport struct {
+	// Total is the number of decisions in the import.
+	Total int `json:"total"`
+
+	// Applied is the number of decisions that were applied.
+	Applied int `json:"applied"`
+
+	// Failed is the number of decisions that failed.
+	Failed int `json:"failed"`
+
+	// Skipped is the number of decisions that were not processed.
+	Skipped int `json:"skipped"`
+
+	// Rows are the decisions in the order of the imported file.
+	Rows []DecisionImportRow `json:"rows"`
+}
+
+// SubscriptionChange defines the kind of change recorded in the subscription history.
+type SubscriptionChange string
+
+// Defines the valid SubscriptionChange values.
+const (
+	// SubscriptionChangeInsert indicates that the subscription was created.
+	SubscriptionChangeInsert SubscriptionChange = "INSERT"
+
+	// SubscriptionChangeUpdate indicates that the subscription was updated.
+	SubscriptionChangeUpdate SubscriptionChange = "UPDATE"
+
+	// SubscriptionChangeDelete indicates that the subscription was deleted.
+	SubscriptionChangeDelete SubscriptionChange = "DELETE"
+
+	// SubscriptionChangeBackfill indicates a subscription that predates the history,
+	// recorded as it was when the history was created.
+	SubscriptionChangeBackfill SubscriptionChange = "BACKFILL"
+)
+
+// SubscriptionVersion is a subscription as recorded by one change in its history.
+type SubscriptionVersion struct {
+	Subscription `json:",inline"`
+
+	// Change is the change that produced this version.
+	Change SubscriptionChange `json:"change" enum:"INSERT,UPDATE,DELETE,BACKFILL" db:"change"`
+
+	// ChangedAt is when this version took effect.
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// SubscriptionHistoryView is the state of the subscriptions of a subscriber at a point in time.
+type SubscriptionHistoryView struct {
+	// SubscriberID is the ID of the subscriber.
+	SubscriberID string `json:"subscriber_id"`
+
+	// At is the point in time of the view.
+	At time.Time `json:"at"`
+
+	// Subscriptions are the versions of the subscriber's subscriptions in effect at At,
+	// one per domain and type.
+	Subscriptions []SubscriptionVersion `json:"subscriptions"`
+}
+
+// SubscriptionLabelsRequest replaces the labels of one subscription of a subscriber.
+// The subscriber is taken from the path.
+type SubscriptionLabelsRequest struct {
+	// Domain and Type identify the subscription of the subscriber.
+	Domain string `json:"domain"`
+	Type   Role   `json:"type" enum:"BAP,BPP,BG"`
+
+	// Labels replace the current labels of the subscription. An empty list removes them all.
+	Labels Labels `json:"labels"`
+}
+
+// RegistrySnapshot is a consistent export of the subscriptions and operations of a registry,
+// used to clone an environment. It holds no secrets: API keys, webhooks and nonces are not exported,
+// including the nonces in the operation requests, and the error data of operations is reduced to its code.
+type RegistrySnapshot struct {
+	// TakenAt is when the snapshot was taken.
+	TakenAt time.Time `json:"taken_at"`
+
+	// Subscriptions are the subscriptions of the registry.
+	Subscriptions []Subscription `json:"subscriptions"`
+
+	// Operations are the subscription operations of the registry.
+	Operations []LRO `json:"operations"`
+}
+
+// SnapshotRestoreRequest is a request to restore a snapshot into the registry.
+type SnapshotRestoreRequest struct {
+	// Snapshot is the snapshot to restore.
+	Snapshot RegistrySnapshot `json:"snapshot"`
+
+	// SubscriberIDs maps subscriber IDs of the snapshot to the IDs they are restored as.
+	// Subscriber IDs that are not mapped are restored unchanged.
+	SubscriberIDs map[string]string `json:"subscriber_ids,omitempty"`
+
+	// OperationIDPrefix is prepended to the IDs of the restored operations.
+	OperationIDPrefix string `json:"operation_id_prefix,omitempty"`
+}
+
+// SnapshotRestoreReport is the result of restoring a snapshot.
+type SnapshotRestoreReport struct {
+	// Subscriptions is the number of subscriptions restored.
+	Subscriptions int `json:"subscriptions"`
+
+	// Operations is the number of operations restored.
+	Operations int `json:"operations"`
+}
+
+// DuplicateReason is why subscribers are reported as likely duplicates of each other.
+type DuplicateReason string
+
+const (
+	// DuplicateReasonURL reports subscribers whose subscriptions share a callback URL.
+	DuplicateReasonURL DuplicateReason = "SAME_URL"
+	// DuplicateReasonSigningKey reports subscribers whose subscriptions share a signing public key.
+	DuplicateReasonSigningKey DuplicateReason = "SAME_SIGNING_KEY"
+	// DuplicateReasonSubscriberID reports subscribers whose IDs only differ in case, scheme,
+	// a leading "www." or a trailing slash.
+	DuplicateReasonSubscriberID DuplicateReason = "SIMILAR_SUBSCRIBER_ID"
+)
+
+// DuplicateGroup is a set of subscribers that are likely the same participant registered more than once.
+type DuplicateGroup struct {
+	// Reason is what the subscribers have in common.
+	Reason DuplicateReason `json:"reason" enum:"SAME_URL,SAME_SIGNING_KEY,SIMILAR_SUBSCRIBER_ID"`
+
+	// Match is the shared URL, the SHA-256 fingerprint of the shared signing key, or the
+	// normalized subscriber ID.
+	Match string `json:"match"`
+
+	// SubscriberIDs are the subscribers in the group, sorted.
+	SubscriberIDs []string `json:"subscriber_ids"`
+}
+
+// SubscriberMergeRequest is a request to merge a duplicate subscriber into another.
+type SubscriberMergeRequest struct {
+	// SourceSubscriberID is the duplicate subscriber. It no longer exists after the merge.
+	SourceSubscriberID string `json:"source_subscriber_id"`
+
+	// TargetSubscriberID is the subscriber that is kept.
+	TargetSubscriberID string `json:"target_subscriber_id"`
+
+	// DryRun reports what the merge would change without changing anything.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// SubscriberMergeReport is the result of merging a duplicate subscriber into another.
+type SubscriberMergeReport struct {
+	// MergeID identifies the merge in the merge audit log. It is empty for a dry run.
+	MergeID string `json:"merge_id,omitempty"`
+
+	SourceSubscriberID string `json:"source_subscriber_id"`
+	TargetSubscriberID string `json:"target_subscriber_id"`
+	DryRun             bool   `json:"dry_run,omitempty"`
+
+	// Moved are the subscriptions of the source that now belong to the target.
+	Moved []Subscriber `json:"moved_subscriptions"`
+
+	// Dropped are the subscriptions of the source that were deleted because the target
+	// already has a subscription with the same domain and type.
+	Dropped []Subscriber `json:"dropped_subscriptions"`
+
+	// APIKeys is the number of API keys moved to the target.
+	APIKeys int `json:"api_keys"`
+
+	// DenylistEntries is the number of denylist entries moved to the target.
+	DenylistEntries int `json:"denylist_entries"`
+
+	// Operations is the number of operations whose request or result now names the target.
+	Operations int `json:"operations"`
+
+	// MergedAt is when the merge was recorded. It is zero for a dry run.
+	MergedAt time.Time `json:"merged_at,omitzero"`
+}
+
+// ChallengeAttempt records an /on_subscribe challenge sent to a subscriber while its operation
+// was being approved.
+type ChallengeAttempt struct {
+	// OperationID is the operation being approved.
+	OperationID string `json:"operation_id" db:"operation_id"`
+
+	// URL is the subscriber URL the challenge was sent to.
+	URL string `json:"url" db:"url"`
+
+	// Passed is true if the subscriber decrypted the challenge and, if it signed the answer,
+	// the signature matches the signing key of the request.
+	Passed bool `json:"passed" db:"passed"`
+
+	// Signed is true if the answer was signed.
+	Signed bool `json:"signed" db:"signed"`
+
+	// Error is why the challenge did not pass.
+	Error string `json:"error,omitempty" db:"error"`
+
+	// AttemptedAt is when the challenge was sent.
+	AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// OperationTimelineSource is where a timeline entry was taken from.
+type OperationTimelineSource string
+
+// Defines the valid OperationTimelineSource values.
+const (
+	// OperationTimelineSourceOperation is the operation itself: its submission, URL probe and final status.
+	OperationTimelineSourceOperation OperationTimelineSource = "OPERATION"
+
+	// OperationTimelineSourceReview is an admin's approval, rejection or comment.
+	OperationTimelineSourceReview OperationTimelineSource = "REVIEW"
+
+	// OperationTimelineSourceChallenge is an /on_subscribe challenge sent while approving the operation.
+	OperationTimelineSourceChallenge OperationTimelineSource = "CHALLENGE"
+
+	// OperationTimelineSourceSubscription is a change to the subscription, from the subscription history.
+	OperationTimelineSourceSubscription OperationTimelineSource = "SUBSCRIPTION"
+
+	// OperationTimelineSourceEvent is an event about the operation delivered to a webhook.
+	OperationTimelineSourceEvent OperationTimelineSource = "EVENT"
+)
+
+// OperationTimelineEntry is something that happened to an operation.
+type OperationTimelineEntry struct {
+	// At is when it happened.
+	At time.Time `json:"at"`
+
+	// Source is where the entry was taken from.
+	Source OperationTimelineSource `json:"source" enum:"OPERATION,REVIEW,CHALLENGE,SUBSCRIPTION,EVENT"`
+
+	// Summary describes what happened in a sentence.
+	Summary string `json:"summary"`
+
+	// Actor is who did it: the subscriber, an admin or the registry. It is empty if unknown.
+	Actor string `json:"actor,omitempty"`
+
+	// Details is the record the entry was taken from.
+	Details any `json:"details,omitempty"`
+}
+
+// OperationTimeline is everything that happened to an operation, oldest first.
+type OperationTimeline struct {
+	OperationID  string        `json:"operation_id"`
+	SubscriberID string        `json:"subscriber_id,omitempty"`
+	Type         OperationType `json:"type" enum:"CREATE_SUBSCRIPTION,UPDATE_SUBSCRIPTION"`
+	Status       LROStatus     `json:"status" enum:"PENDING,APPROVED,FAILURE,REJECTED,STALE"`
+
+	// Entries are the entries of the timeline in chronological order.
+	Entries []OperationTimelineEntry `json:"entries"`
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// APIKeyHeader is the HTTP header that carries a subscriber API key.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is a key issued to a subscriber for reading its own records from the registry
+// without signing the request. Only a hash of the key is stored.
+type APIKey struct {
+	// KeyID identifies the key, e.g. for revocation.
+	KeyID string `json:"key_id" db:"key_id"`
+
+	// SubscriberID is the subscriber the key was issued to.
+	SubscriberID string `json:"subscriber_id" db:"subscriber_id"`
+
+	// Hash is the hex encoded SHA-256 hash of the key. It is never returned to clients.
+	Hash string `json:"-" db:"key_hash"`
+
+	// CreatedAt is when the key was issued.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// RevokedAt is when the key was revoked, if it was.
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// IssuedAPIKey is returned once when an API key is issued. The key itself cannot be retrieved later.
+type IssuedAPIKey struct {
+	APIKey
+
+	// Key is the API key to send in requests.
+	Key string `json:"api_key"`
+}
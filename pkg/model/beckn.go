@@ -40,7 +40,8 @@ type Subscription struct {
 	EncrPublicKey      string             `json:"encr_public_key,omitzero" db:"encr_public_key"`
 	ValidFrom          time.Time          `json:"valid_from,omitzero" format:"date-time" db:"valid_from"`
 	ValidUntil         time.Time          `json:"valid_until,omitzero" format:"date-time" db:"valid_until"`
-	Status             SubscriptionStatus `json:"status,omitzero" enum:"INITIATED,UNDER_SUBSCRIPTION,SUBSCRIBED,EXPIRED,UNSUBSCRIBED,INVALID_SSL" db:"status"`
+	Status             SubscriptionStatus `json:"status,omitzero" enum:"INITIATED,UNDER_SUBSCRIPTION,APPROVED_PENDING_ACTIVATION,SUBSCRIBED,EXPIRED,UNSUBSCRIBED,INVALID_SSL" db:"status"`
+	Labels             Labels             `json:"labels,omitzero" db:"labels"`
 	Created            time.Time          `json:"created,omitzero" format:"date-time" db:"created_at"`
 	Updated            time.Time          `json:"updated,omitzero" format:"date-time" db:"updated_at"`
 	Nonce              string             `json:"nonce,omitzero" db:"nonce"`
@@ -68,6 +69,9 @@ const (
 	SubscriptionStatusInitiated SubscriptionStatus = "INITIATED"
 	// SubscriptionStatusUnderSubscription indicates that the subscription is currently being processed or is pending approval.
 	SubscriptionStatusUnderSubscription SubscriptionStatus = "UNDER_SUBSCRIPTION"
+	// SubscriptionStatusApprovedPendingActivation indicates that the subscription has been approved
+	// with a future valid_from, and becomes SUBSCRIBED at that time.
+	SubscriptionStatusApprovedPendingActivation SubscriptionStatus = "APPROVED_PENDING_ACTIVATION"
 	// SubscriptionStatusSubscribed indicates that the participant is actively subscribed to the network.
 	SubscriptionStatusSubscribed SubscriptionStatus = "SUBSCRIBED"
 	// SubscriptionStatusExpired indicates that the subscription has expired.
@@ -79,13 +83,14 @@ const (
 )
 
 var validSubscriptionStatuses = map[SubscriptionStatus]bool{
-	SubscriptionStatusEmpty:             true,
-	SubscriptionStatusInitiated:         true,
-	SubscriptionStatusUnderSubscription: true,
-	SubscriptionStatusSubscribed:        true,
-	SubscriptionStatusExpired:           true,
-	SubscriptionStatusUnsubscribed:      true,
-	SubscriptionStatusInvalidSSL:        true,
+	SubscriptionStatusEmpty:                     true,
+	SubscriptionStatusInitiated:                 true,
+	SubscriptionStatusUnderSubscription:         true,
+	SubscriptionStatusApprovedPendingActivation: true,
+	SubscriptionStatusSubscribed:                true,
+	SubscriptionStatusExpired:                   true,
+	SubscriptionStatusUnsubscribed:              true,
+	SubscriptionStatusInvalidSSL:                true,
 }
 
 // MarshalJSON implements the json.Marshaler interface for SubscriptionStatus.
@@ -113,6 +118,26 @@ type SubscriptionResponse struct {
 	MessageID string             `json:"message_id"`
 }
 
+// SubscriptionTombstone identifies a subscription that was deleted, or that no longer
+// matches the filter of an incremental lookup.
+type SubscriptionTombstone struct {
+	SubscriberID string    `json:"subscriber_id" db:"subscriber_id"`
+	Type         Role      `json:"type" db:"type"`
+	Domain       string    `json:"domain" db:"domain"`
+	KeyID        string    `json:"key_id,omitzero" db:"key_id"`
+	DeletedAt    time.Time `json:"deleted_at" format:"date-time" db:"deleted_at"`
+}
+
+// LookupChanges is the response of a lookup with updated_after: the subscriptions matching
+// the filter that changed at or after that time, and those removed from its results since.
+type LookupChanges struct {
+	Subscriptions []Subscription          `json:"subscriptions"`
+	Deleted       []SubscriptionTombstone `json:"deleted"`
+	// NextUpdatedAfter is the updated_after of the next incremental lookup: the time of the
+	// latest change returned, or the requested updated_after if nothing changed.
+	NextUpdatedAfter time.Time `json:"next_updated_after" format:"date-time"`
+}
+
 // AuthHeaderSubscriber is the standard HTTP header key for subscriber authorization.
 const (
 	AuthHeaderSubscriber string = "Authorization"
@@ -123,6 +148,16 @@ const (
 	AuthHeaderGateway string = "X-Gateway-Authorization"
 )
 
+// Headers identifying the gateway on its outbound requests and health checks.
+const (
+	// GatewayIDHeader carries the identifier of the gateway, by default its subscriber ID.
+	GatewayIDHeader string = "X-Gateway-Id"
+	// GatewayVersionHeader carries the version of the gateway deployment.
+	GatewayVersionHeader string = "X-Gateway-Version"
+	// GatewayContactHeader carries a URL where the gateway operator can be reached.
+	GatewayContactHeader string = "X-Gateway-Contact"
+)
+
 // Role defines the functional type of a participant in the network.
 type Role string
 
@@ -210,6 +245,34 @@ func (l Location) Value() (driver.Value, error) {
 	return json.Marshal(l)
 }
 
+// Labels are free-form tags that operators attach to a subscription, such as "pilot" or "tier-1",
+// to group participants operationally. In a lookup filter, a subscription matches if it carries
+// every label of the filter.
+type Labels []string
+
+// Scan implements the sql.Scanner interface for Labels.
+// It converts a database JSONB array ([]byte) into Labels.
+func (l *Labels) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("Scan source was not []byte; got %T", value)
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// Value implements the driver.Valuer interface for Labels.
+// It converts Labels into a JSONB array, storing no labels as an empty array.
+func (l Labels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(l))
+}
+
 type LocationDescriptor struct {
 	Name           string                `json:"name,omitempty"`
 	Code           string                `json:"code,omitempty"`
@@ -292,6 +355,8 @@ type OnSubscribeRequest struct {
 // This is a simplified version; a full Beckn response would be more complex.
 type OnSubscribeResponse struct {
 	Answer string `json:"answer"` // Decrypted challenge string
+	// Signature is the base64 encoded ed25519 signature over Answer by the NP's signing key.
+	Signature string `json:"signature,omitempty"`
 }
 
 // AuthHeader holds the components from the parsed Authorization header.
@@ -307,6 +372,7 @@ type Context struct {
 	Location      *Location `json:"location,omitempty"`       // Transaction fulfillment location
 	Action        string    `json:"action,omitempty"`         // Beckn protocol method
 	Version       string    `json:"version,omitempty"`        // Protocol version
+	CoreVersion   string    `json:"core_version,omitempty"`   // Beckn core specification version
 	BapID         string    `json:"bap_id,omitempty"`         // Subscriber ID of BAP
 	BapURI        string    `json:"bap_uri,omitempty"`        // Subscriber URL of BAP (URI format)
 	BppID         string    `json:"bpp_id,omitempty"`         // Subscriber ID of BPP
@@ -99,6 +99,11 @@ func TestSubscriptionStatus_UnmarshalJSON_Success(t *testing.T) {
 			jsonData: `"SUBSCRIBED"`,
 			expected: SubscriptionStatusSubscribed,
 		},
+		{
+			name:     "ValidStatusApprovedPendingActivation",
+			jsonData: `"APPROVED_PENDING_ACTIVATION"`,
+			expected: SubscriptionStatusApprovedPendingActivation,
+		},
 		{
 			name:     "ValidStatusEmpty",
 			jsonData: `""`,
@@ -193,3 +198,48 @@ func TestRole_UnmarshalYAML_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestLabels_ValueScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		labels    Labels
+		wantValue string
+		wantScan  Labels
+	}{
+		{name: "Nil", labels: nil, wantValue: `[]`, wantScan: Labels{}},
+		{name: "Labels", labels: Labels{"pilot", "tier-1"}, wantValue: `["pilot","tier-1"]`, wantScan: Labels{"pilot", "tier-1"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.labels.Value()
+			if err != nil {
+				t.Fatalf("Value() returned unexpected error: %v", err)
+			}
+			b, ok := v.([]byte)
+			if !ok || string(b) != tc.wantValue {
+				t.Fatalf("Value() = %v, want %s", v, tc.wantValue)
+			}
+			var got Labels
+			if err := got.Scan(b); err != nil {
+				t.Fatalf("Scan(%s) returned unexpected error: %v", b, err)
+			}
+			if diff := cmp.Diff(tc.wantScan, got); diff != "" {
+				t.Errorf("Scan(%s) mismatch (-want +got):\n%s", b, diff)
+			}
+		})
+	}
+}
+
+func TestLabels_ScanNull(t *testing.T) {
+	labels := Labels{"pilot"}
+	if err := labels.Scan(nil); err != nil {
+		t.Fatalf("Scan(nil) returned unexpected error: %v", err)
+	}
+	if labels != nil {
+		t.Errorf("Scan(nil) = %v, want nil", labels)
+	}
+	if err := labels.Scan("pilot"); err == nil {
+		t.Error("Scan(string) returned nil error, want error")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// DeliveryCounts are the outcomes of the requests the gateway forwarded to a target in a window.
+type DeliveryCounts struct {
+	// Window is the length of the window, ending at the time of the report, e.g. "24h0m0s".
+	Window string `json:"window"`
+
+	// Success is the number of requests the target ACKed.
+	Success int64 `json:"success"`
+
+	// Error is the number of requests that failed after retries, other than by timing out,
+	// e.g. because the target NACKed them, answered with an error status or refused the connection.
+	Error int64 `json:"error"`
+
+	// Timeout is the number of requests that timed out.
+	Timeout int64 `json:"timeout"`
+
+	// SuccessRate is Success over all requests, or 0 if there were none.
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// TargetDeliveryStats are the delivery outcomes of a target over each configured window.
+type TargetDeliveryStats struct {
+	// Target is the subscriber URL the requests were forwarded to, as registered in the
+	// registry, without the action path.
+	Target string `json:"target"`
+
+	// Windows are the counts over each window, shortest first.
+	Windows []DeliveryCounts `json:"windows"`
+}
+
+// DeliveryStatsReport is the summary of the delivery outcomes per target served by the gateway,
+// from which network participant reliability scorecards are computed.
+type DeliveryStatsReport struct {
+	// At is when the report was computed. Each window ends at At, and starts at the start of a
+	// bucket, so it may be up to one bucket shorter than its length.
+	At time.Time `json:"at"`
+
+	// Targets are the stats of each target with deliveries in the longest window, ordered by target.
+	Targets []TargetDeliveryStats `json:"targets"`
+}
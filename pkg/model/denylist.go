@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// DenylistKind is what a denylist entry matches.
+type DenylistKind string
+
+const (
+	// DenylistKindIP matches the client IP of a request. The value is an IP address or a CIDR range.
+	DenylistKindIP DenylistKind = "IP"
+	// DenylistKindSubscriber matches the subscriber ID in the keyId of a request's Authorization header.
+	DenylistKindSubscriber DenylistKind = "SUBSCRIBER"
+)
+
+// DenylistEntry blocks traffic from a subscriber or an IP range at the gateway and the registry.
+type DenylistEntry struct {
+	// ID identifies the entry.
+	ID string `json:"entry_id" db:"entry_id"`
+
+	// Kind is what the entry matches.
+	Kind DenylistKind `json:"kind" enum:"IP,SUBSCRIBER" db:"kind"`
+
+	// Value is the IP address, CIDR range or subscriber ID that is blocked.
+	Value string `json:"value" db:"value"`
+
+	// Reason records why the entry was added. It is not returned to blocked clients.
+	Reason string `json:"reason,omitempty" db:"reason"`
+
+	// CreatedAt is when the entry was added.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// ExpiresAt is when the entry stops applying. Entries without it apply until removed.
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// DenylistEntryRequest is the request to add an entry to the denylist.
+type DenylistEntryRequest struct {
+	Kind      DenylistKind `json:"kind" enum:"IP,SUBSCRIBER"`
+	Value     string       `json:"value"`
+	Reason    string       `json:"reason,omitempty"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// LocationGranularity is the most specific part of its location a subscriber must declare to subscribe to a domain.
+type LocationGranularity string
+
+const (
+	// LocationGranularityCountry requires location.country.code.
+	LocationGranularityCountry LocationGranularity = "COUNTRY"
+	// LocationGranularityState requires location.state.code.
+	LocationGranularityState LocationGranularity = "STATE"
+	// LocationGranularityCity requires location.city.code.
+	LocationGranularityCity LocationGranularity = "CITY"
+)
+
+// Domain is an entry of the registry's catalog of network domains.
+// Domains form a hierarchy: a domain without its own location granularity or
+// schema version inherits those of its closest ancestor that sets them.
+type Domain struct {
+	// Name is the domain code used in subscriptions, such as "ONDC:RET10".
+	Name string `json:"name" db:"name"`
+
+	// Parent is the name of the parent domain, if any.
+	Parent string `json:"parent,omitempty" db:"parent"`
+
+	// DisplayName is the human-readable name of the domain.
+	DisplayName string `json:"display_name" db:"display_name"`
+
+	// LocationGranularity is the location subscribers to the domain must declare.
+	LocationGranularity LocationGranularity `json:"location_granularity,omitempty" enum:"COUNTRY,STATE,CITY" db:"location_granularity"`
+
+	// SchemaVersion is the version of the domain's schema, such as "1.2.0".
+	SchemaVersion string `json:"schema_version,omitempty" db:"schema_version"`
+
+	// CreatedAt is when the domain was added.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// UpdatedAt is when the domain was last changed.
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DomainRequest is the request to add or update a domain. The name of an
+// updated domain is taken from the path.
+type DomainRequest struct {
+	Name                string              `json:"name,omitempty"`
+	Parent              string              `json:"parent,omitempty"`
+	DisplayName         string              `json:"display_name"`
+	LocationGranularity LocationGranularity `json:"location_granularity,omitempty" enum:"COUNTRY,STATE,CITY"`
+	SchemaVersion       string              `json:"schema_version,omitempty"`
+}
@@ -78,40 +78,116 @@ const (
 	ErrorCodeKeyUnavailable ErrorCode = "AUTH_ERROR_CODE_KEY_UNAVAILABLE"
 	// ErrorCodeInvalidSignature indicates that the request signature is invalid.
 	ErrorCodeInvalidSignature ErrorCode = "AUTH_ERROR_CODE_INVALID_SIGNATURE"
+	// ErrorCodeDenylisted indicates that the request's subscriber or client IP is on the denylist.
+	ErrorCodeDenylisted ErrorCode = "AUTH_ERROR_CODE_DENYLISTED"
+	// ErrorCodeClockSkew indicates that the request's signature or context timestamp is outside the allowed clock skew.
+	ErrorCodeClockSkew ErrorCode = "AUTH_ERROR_CODE_CLOCK_SKEW"
+	// ErrorCodeAttestationFailed indicates that the request's attestation token is missing or was not accepted.
+	ErrorCodeAttestationFailed ErrorCode = "AUTH_ERROR_CODE_ATTESTATION_FAILED"
 	// Validation Errors
 	// ErrorCodeInvalidJSON indicates that the request body contains malformed or invalid JSON.
 	ErrorCodeInvalidJSON ErrorCode = "VALIDATION_ERROR_INVALID_JSON"
 	// ErrorCodeBadRequest indicates a general validation error with the request.
 	ErrorCodeBadRequest ErrorCode = "VALIDATION_ERROR_BAD_REQUEST" // General validation
+	// ErrorCodeUnsupportedVersion indicates that the request's core version is not supported for its domain.
+	ErrorCodeUnsupportedVersion ErrorCode = "VALIDATION_ERROR_UNSUPPORTED_VERSION"
+	// ErrorCodeUnsupportedEncoding indicates a request body with an unsupported Content-Encoding.
+	ErrorCodeUnsupportedEncoding ErrorCode = "VALIDATION_ERROR_UNSUPPORTED_ENCODING"
+	// ErrorCodeNonceReplayed indicates that the request's nonce has already been used.
+	ErrorCodeNonceReplayed ErrorCode = "VALIDATION_ERROR_NONCE_REPLAYED"
+	// ErrorCodeNonceExpired indicates that the request's nonce is older than the allowed maximum age.
+	ErrorCodeNonceExpired ErrorCode = "VALIDATION_ERROR_NONCE_EXPIRED"
 	// Not Found Errors
 	// ErrorCodeSubscriptionNotFound indicates that a specific subscription was not found.
 	ErrorCodeSubscriptionNotFound ErrorCode = "SUBSCRIPTION_NOT_FOUND"
 	// Not Found Error
 	ErrorCodeOperationNotFound ErrorCode = "OPERATION_NOT_FOUND"
+	// ErrorCodeAPIKeyNotFound indicates that a specific API key was not found.
+	ErrorCodeAPIKeyNotFound ErrorCode = "API_KEY_NOT_FOUND"
+	// ErrorCodeWebhookNotFound indicates that a specific webhook or webhook delivery was not found.
+	ErrorCodeWebhookNotFound ErrorCode = "WEBHOOK_NOT_FOUND"
+	// ErrorCodeDenylistEntryNotFound indicates that a specific denylist entry was not found.
+	ErrorCodeDenylistEntryNotFound ErrorCode = "DENYLIST_ENTRY_NOT_FOUND"
+	// ErrorCodeDomainNotFound indicates that a specific domain is not in the domain catalog.
+	ErrorCodeDomainNotFound ErrorCode = "DOMAIN_NOT_FOUND"
+	// ErrorCodeNetworkNotFound indicates that the network profile selected by a request is not configured.
+	ErrorCodeNetworkNotFound ErrorCode = "NETWORK_NOT_FOUND"
+	// ErrorCodeSubscriberInactive indicates that the subscriber a request is addressed to is registered, but suspended or expired.
+	ErrorCodeSubscriberInactive ErrorCode = "SUBSCRIBER_SUSPENDED_OR_EXPIRED"
 	// Conflict Errors
 	// ErrorCodeDuplicateRequest indicates that the request is a duplicate of a previous one, often identified by a message ID.
 	ErrorCodeDuplicateRequest ErrorCode = "DUPLICATE_REQUEST"
+	// ErrorCodeTooManyPendingOperations indicates that the subscriber has too many operations awaiting approval.
+	ErrorCodeTooManyPendingOperations ErrorCode = "TOO_MANY_PENDING_OPERATIONS"
+	// ErrorCodeRateLimited indicates that the client has sent more requests than its rate limit allows.
+	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED"
+	// ErrorCodeQuotaExceeded indicates that the client has used up its hourly or daily request quota.
+	ErrorCodeQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
+	// ErrorCodeDuplicateApprover indicates that the admin has already approved an operation that requires a second, distinct approver.
+	ErrorCodeDuplicateApprover ErrorCode = "DUPLICATE_APPROVER"
+	// Challenge Errors
+	// ErrorCodeChallengeRegistryKey indicates that the registry's encryption key configured on the NP cannot be resolved or is invalid.
+	ErrorCodeChallengeRegistryKey ErrorCode = "CHALLENGE_ERROR_REGISTRY_KEY"
+	// ErrorCodeChallengePrivateKey indicates that the NP's encryption private key for the operation is invalid.
+	ErrorCodeChallengePrivateKey ErrorCode = "CHALLENGE_ERROR_PRIVATE_KEY"
+	// ErrorCodeChallengeEncoding indicates that the challenge is not base64 encoded.
+	ErrorCodeChallengeEncoding ErrorCode = "CHALLENGE_ERROR_ENCODING"
+	// ErrorCodeChallengeCorrupted indicates that the challenge ciphertext is truncated or corrupted.
+	ErrorCodeChallengeCorrupted ErrorCode = "CHALLENGE_ERROR_CORRUPTED"
+	// ErrorCodeChallengeKeyMismatch indicates that the challenge was encrypted for a different key pair than the NP holds.
+	ErrorCodeChallengeKeyMismatch ErrorCode = "CHALLENGE_ERROR_KEY_MISMATCH"
 	// Internal Errors
 	// ErrorCodeInternalServerError indicates a generic, unexpected error on the server.
 	ErrorCodeInternalServerError ErrorCode = "INTERNAL_SERVER_ERROR"
+	// ErrorCodeServiceOverloaded indicates that the service is temporarily unable to accept requests.
+	ErrorCodeServiceOverloaded ErrorCode = "SERVICE_OVERLOADED"
+	// ErrorCodeMaintenance indicates that the registry is in read-only maintenance mode.
+	ErrorCodeMaintenance ErrorCode = "REGISTRY_MAINTENANCE"
+	// ErrorCodeQueryTimeout indicates that a database query did not complete in time.
+	ErrorCodeQueryTimeout ErrorCode = "QUERY_TIMEOUT"
 
 	// ErrorCodeTypeInvalidAction indicates that the action performed is invalid.
 	ErrorCodeTypeInvalidAction ErrorCode = "INVALID_ACTION"
 )
 
 var validErrorCodes = map[ErrorCode]bool{
-	ErrorCodeMissingAuthHeader:    true,
-	ErrorCodeInvalidAuthHeader:    true,
-	ErrorCodeIDMismatch:           true,
-	ErrorCodeKeyUnavailable:       true,
-	ErrorCodeInvalidSignature:     true,
-	ErrorCodeInvalidJSON:          true,
-	ErrorCodeBadRequest:           true,
-	ErrorCodeSubscriptionNotFound: true,
-	ErrorCodeDuplicateRequest:     true,
-	ErrorCodeOperationNotFound:    true,
-	ErrorCodeInternalServerError:  true,
-	ErrorCodeTypeInvalidAction:    true,
+	ErrorCodeMissingAuthHeader:        true,
+	ErrorCodeInvalidAuthHeader:        true,
+	ErrorCodeIDMismatch:               true,
+	ErrorCodeKeyUnavailable:           true,
+	ErrorCodeInvalidSignature:         true,
+	ErrorCodeInvalidJSON:              true,
+	ErrorCodeBadRequest:               true,
+	ErrorCodeUnsupportedVersion:       true,
+	ErrorCodeUnsupportedEncoding:      true,
+	ErrorCodeNonceReplayed:            true,
+	ErrorCodeNonceExpired:             true,
+	ErrorCodeSubscriptionNotFound:     true,
+	ErrorCodeDuplicateRequest:         true,
+	ErrorCodeTooManyPendingOperations: true,
+	ErrorCodeRateLimited:              true,
+	ErrorCodeQuotaExceeded:            true,
+	ErrorCodeDuplicateApprover:        true,
+	ErrorCodeOperationNotFound:        true,
+	ErrorCodeAPIKeyNotFound:           true,
+	ErrorCodeWebhookNotFound:          true,
+	ErrorCodeDenylisted:               true,
+	ErrorCodeClockSkew:                true,
+	ErrorCodeAttestationFailed:        true,
+	ErrorCodeDenylistEntryNotFound:    true,
+	ErrorCodeDomainNotFound:           true,
+	ErrorCodeNetworkNotFound:          true,
+	ErrorCodeSubscriberInactive:       true,
+	ErrorCodeChallengeRegistryKey:     true,
+	ErrorCodeChallengePrivateKey:      true,
+	ErrorCodeChallengeEncoding:        true,
+	ErrorCodeChallengeCorrupted:       true,
+	ErrorCodeChallengeKeyMismatch:     true,
+	ErrorCodeInternalServerError:      true,
+	ErrorCodeServiceOverloaded:        true,
+	ErrorCodeMaintenance:              true,
+	ErrorCodeQueryTimeout:             true,
+	ErrorCodeTypeInvalidAction:        true,
 }
 
 // MarshalJSON implements the json.Marshaler interface for ErrorCode.
@@ -138,6 +214,18 @@ type Error struct {
 	Code    ErrorCode `json:"code"`
 	Path    string    `json:"path,omitempty"`
 	Message string    `json:"message"`
+	// Fields lists the invalid fields of a request that failed validation.
+	Fields []FieldError `json:"fields,omitempty"`
+	// Upgrade tells the sender of a request with an unsupported core version how to upgrade.
+	Upgrade *UpgradeHint `json:"upgrade,omitempty"`
+}
+
+// UpgradeHint lists what a sender needs to move a request to a supported core version.
+type UpgradeHint struct {
+	// SupportedVersions are the core versions accepted for the domain of the request.
+	SupportedVersions []string `json:"supported_versions"`
+	// MigrationDocURL links to the upgrade guide of the domain, if one is configured.
+	MigrationDocURL string `json:"migration_doc_url,omitempty"`
 }
 
 // ErrorResponse wraps the Error.
@@ -110,7 +110,11 @@ func TestErrorCode_MarshalJSON(t *testing.T) {
 		{"InvalidJSON", ErrorCodeInvalidJSON, `"VALIDATION_ERROR_INVALID_JSON"`, false},
 		{"SubscriptionNotFound", ErrorCodeSubscriptionNotFound, `"SUBSCRIPTION_NOT_FOUND"`, false},
 		{"DuplicateRequest", ErrorCodeDuplicateRequest, `"DUPLICATE_REQUEST"`, false},
+		{"TooManyPendingOperations", ErrorCodeTooManyPendingOperations, `"TOO_MANY_PENDING_OPERATIONS"`, false},
+		{"RateLimited", ErrorCodeRateLimited, `"RATE_LIMITED"`, false},
+		{"ClockSkew", ErrorCodeClockSkew, `"AUTH_ERROR_CODE_CLOCK_SKEW"`, false},
 		{"InternalServerError", ErrorCodeInternalServerError, `"INTERNAL_SERVER_ERROR"`, false},
+		{"ServiceOverloaded", ErrorCodeServiceOverloaded, `"SERVICE_OVERLOADED"`, false},
 	}
 
 	for _, tt := range tests {
@@ -137,7 +141,25 @@ func TestErrorCode_UnmarshalJSON_Success(t *testing.T) {
 		{"InvalidJSON", `"VALIDATION_ERROR_INVALID_JSON"`, ErrorCodeInvalidJSON},
 		{"SubscriptionNotFound", `"SUBSCRIPTION_NOT_FOUND"`, ErrorCodeSubscriptionNotFound},
 		{"DuplicateRequest", `"DUPLICATE_REQUEST"`, ErrorCodeDuplicateRequest},
+		{"TooManyPendingOperations", `"TOO_MANY_PENDING_OPERATIONS"`, ErrorCodeTooManyPendingOperations},
+		{"RateLimited", `"RATE_LIMITED"`, ErrorCodeRateLimited},
+		{"QuotaExceeded", `"QUOTA_EXCEEDED"`, ErrorCodeQuotaExceeded},
 		{"InternalServerError", `"INTERNAL_SERVER_ERROR"`, ErrorCodeInternalServerError},
+		{"ServiceOverloaded", `"SERVICE_OVERLOADED"`, ErrorCodeServiceOverloaded},
+		{"APIKeyNotFound", `"API_KEY_NOT_FOUND"`, ErrorCodeAPIKeyNotFound},
+		{"WebhookNotFound", `"WEBHOOK_NOT_FOUND"`, ErrorCodeWebhookNotFound},
+		{"Maintenance", `"REGISTRY_MAINTENANCE"`, ErrorCodeMaintenance},
+		{"QueryTimeout", `"QUERY_TIMEOUT"`, ErrorCodeQueryTimeout},
+		{"Denylisted", `"AUTH_ERROR_CODE_DENYLISTED"`, ErrorCodeDenylisted},
+		{"ClockSkew", `"AUTH_ERROR_CODE_CLOCK_SKEW"`, ErrorCodeClockSkew},
+		{"DenylistEntryNotFound", `"DENYLIST_ENTRY_NOT_FOUND"`, ErrorCodeDenylistEntryNotFound},
+		{"DomainNotFound", `"DOMAIN_NOT_FOUND"`, ErrorCodeDomainNotFound},
+		{"NetworkNotFound", `"NETWORK_NOT_FOUND"`, ErrorCodeNetworkNotFound},
+		{"ChallengeRegistryKey", `"CHALLENGE_ERROR_REGISTRY_KEY"`, ErrorCodeChallengeRegistryKey},
+		{"ChallengePrivateKey", `"CHALLENGE_ERROR_PRIVATE_KEY"`, ErrorCodeChallengePrivateKey},
+		{"ChallengeEncoding", `"CHALLENGE_ERROR_ENCODING"`, ErrorCodeChallengeEncoding},
+		{"ChallengeCorrupted", `"CHALLENGE_ERROR_CORRUPTED"`, ErrorCodeChallengeCorrupted},
+		{"ChallengeKeyMismatch", `"CHALLENGE_ERROR_KEY_MISMATCH"`, ErrorCodeChallengeKeyMismatch},
 	}
 
 	for _, tt := range tests {
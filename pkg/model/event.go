@@ -34,6 +34,24 @@ const (
 	EventTypeSubscriptionRequestRejected EventType = "SUBSCRIPTION_REQUEST_REJECTED"
 	// EventTypeOnSubscribeRecieved signals am OnSubscribe call recieved event.
 	EventTypeOnSubscribeRecieved EventType = "ON_SUBSCRIBE_RECIEVED"
+	// EventTypeKeyRotated signals that a subscriber has rotated its signing and encryption keys.
+	EventTypeKeyRotated EventType = "KEY_ROTATED"
+	// EventTypeKeyAccessed signals that a keyset was read from, or network keys were looked up through, a key manager.
+	EventTypeKeyAccessed EventType = "KEY_ACCESSED"
+	// EventTypeSubscriptionActivated signals that a subscription approved with a future valid_from has become SUBSCRIBED.
+	EventTypeSubscriptionActivated EventType = "SUBSCRIPTION_ACTIVATED"
+)
+
+// Event types that are only delivered to webhooks, for the LRO transitions not published to Pub/Sub.
+const (
+	// EventTypeSubscriptionRequestPendingSecondApproval signals that the first of two required approvals was recorded.
+	EventTypeSubscriptionRequestPendingSecondApproval EventType = "SUBSCRIPTION_REQUEST_PENDING_SECOND_APPROVAL"
+	// EventTypeSubscriptionRequestFailed signals that the processing of a subscription request failed and can be retried.
+	EventTypeSubscriptionRequestFailed EventType = "SUBSCRIPTION_REQUEST_FAILED"
+	// EventTypeSubscriptionRequestExpired signals that a subscription request went STALE without admin action.
+	EventTypeSubscriptionRequestExpired EventType = "SUBSCRIPTION_REQUEST_EXPIRED"
+	// EventTypeSubscriptionApprovedPendingActivation signals that an approved subscription waits for its valid_from to be activated.
+	EventTypeSubscriptionApprovedPendingActivation EventType = "SUBSCRIPTION_APPROVED_PENDING_ACTIVATION"
 )
 
 var validEventTypes = map[EventType]bool{
@@ -42,6 +60,14 @@ var validEventTypes = map[EventType]bool{
 	EventTypeSubscriptionRequestApproved: true,
 	EventTypeSubscriptionRequestRejected: true,
 	EventTypeOnSubscribeRecieved:         true,
+	EventTypeKeyRotated:                  true,
+	EventTypeKeyAccessed:                 true,
+	EventTypeSubscriptionActivated:       true,
+
+	EventTypeSubscriptionRequestPendingSecondApproval: true,
+	EventTypeSubscriptionRequestFailed:                true,
+	EventTypeSubscriptionRequestExpired:               true,
+	EventTypeSubscriptionApprovedPendingActivation:    true,
 }
 
 // MarshalJSON implements the json.Marshaler interface for EventType.
@@ -30,6 +30,9 @@ func TestEventType_MarshalJSON(t *testing.T) {
 		{"SubscriptionRequestApproved", EventTypeSubscriptionRequestApproved, `"SUBSCRIPTION_REQUEST_APPROVED"`},
 		{"SubscriptionRequestRejected", EventTypeSubscriptionRequestRejected, `"SUBSCRIPTION_REQUEST_REJECTED"`},
 		{"OnSubscribeRecieved", EventTypeOnSubscribeRecieved, `"ON_SUBSCRIBE_RECIEVED"`},
+		{"KeyRotated", EventTypeKeyRotated, `"KEY_ROTATED"`},
+		{"SubscriptionActivated", EventTypeSubscriptionActivated, `"SUBSCRIPTION_ACTIVATED"`},
+		{"KeyAccessed", EventTypeKeyAccessed, `"KEY_ACCESSED"`},
 	}
 
 	for _, tt := range tests {
@@ -56,6 +59,13 @@ func TestEventType_UnmarshalJSON_Success(t *testing.T) {
 		{"SubscriptionRequestApproved", `"SUBSCRIPTION_REQUEST_APPROVED"`, EventTypeSubscriptionRequestApproved},
 		{"SubscriptionRequestRejected", `"SUBSCRIPTION_REQUEST_REJECTED"`, EventTypeSubscriptionRequestRejected},
 		{"OnSubscribeRecieved", `"ON_SUBSCRIBE_RECIEVED"`, EventTypeOnSubscribeRecieved},
+		{"KeyRotated", `"KEY_ROTATED"`, EventTypeKeyRotated},
+		{"SubscriptionActivated", `"SUBSCRIPTION_ACTIVATED"`, EventTypeSubscriptionActivated},
+		{"KeyAccessed", `"KEY_ACCESSED"`, EventTypeKeyAccessed},
+		{"SubscriptionRequestPendingSecondApproval", `"SUBSCRIPTION_REQUEST_PENDING_SECOND_APPROVAL"`, EventTypeSubscriptionRequestPendingSecondApproval},
+		{"SubscriptionRequestFailed", `"SUBSCRIPTION_REQUEST_FAILED"`, EventTypeSubscriptionRequestFailed},
+		{"SubscriptionRequestExpired", `"SUBSCRIPTION_REQUEST_EXPIRED"`, EventTypeSubscriptionRequestExpired},
+		{"SubscriptionApprovedPendingActivation", `"SUBSCRIPTION_APPROVED_PENDING_ACTIVATION"`, EventTypeSubscriptionApprovedPendingActivation},
 	}
 
 	for _, tt := range tests {
@@ -16,6 +16,7 @@ package model
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -32,6 +33,18 @@ const (
 	LROStatusFailure LROStatus = "FAILURE"
 	// LROStatusRejected indicates that the long-running operation has been rejected or failed.
 	LROStatusRejected LROStatus = "REJECTED"
+	// LROStatusStale indicates that a pending operation expired without admin action.
+	// A stale operation can still be approved or rejected by an admin.
+	LROStatusStale LROStatus = "STALE"
+)
+
+// LROSubState refines the status of a pending LRO.
+type LROSubState string
+
+const (
+	// LROSubStatePendingSecondApproval indicates that the operation has been approved by one
+	// admin and awaits the approval of a second, distinct admin before it is applied.
+	LROSubStatePendingSecondApproval LROSubState = "PENDING_SECOND_APPROVAL"
 )
 
 // OperationType defines the set of possible types for an LRO.
@@ -46,13 +59,255 @@ const (
 )
 
 type LRO struct {
-	OperationID   string          `json:"operation_id"`
-	Status        LROStatus       `json:"status,omitempty"`
-	Type          OperationType   `json:"type,omitempty"`
-	RetryCount    int             `json:"retry_count,omitempty"`
-	RequestJSON   json.RawMessage `json:"request_json,omitempty"`
-	ResultJSON    json.RawMessage `json:"result_json,omitempty"`
-	ErrorDataJSON json.RawMessage `json:"error_data_json,omitempty"`
-	CreatedAt     time.Time       `json:"created_at,omitempty"`
-	UpdatedAt     time.Time       `json:"updated_at,omitempty"`
+	OperationID   string             `json:"operation_id"`
+	Status        LROStatus          `json:"status,omitempty" enum:"PENDING,APPROVED,FAILURE,REJECTED,STALE"`
+	SubState      LROSubState        `json:"sub_state,omitempty" enum:"PENDING_SECOND_APPROVAL"`
+	Type          OperationType      `json:"type,omitempty" enum:"CREATE_SUBSCRIPTION,UPDATE_SUBSCRIPTION"`
+	RetryCount    int                `json:"retry_count,omitempty"`
+	RequestJSON   json.RawMessage    `json:"request_json,omitempty"`
+	ResultJSON    json.RawMessage    `json:"result_json,omitempty"`
+	ErrorDataJSON json.RawMessage    `json:"error_data_json,omitempty"`
+	ProbeJSON     json.RawMessage    `json:"probe_json,omitempty"`
+	Review        *OperationReview   `json:"review,omitempty"`
+	Comments      []OperationComment `json:"comments,omitempty"`
+	CreatedAt     time.Time          `json:"created_at,omitempty"`
+	UpdatedAt     time.Time          `json:"updated_at,omitempty"`
+}
+
+// Done reports whether the operation reached a final status. Failed operations are not
+// done, as their approval can be retried until the retry limit rejects them.
+func (l *LRO) Done() bool {
+	switch l.Status {
+	case LROStatusApproved, LROStatusRejected, LROStatusStale:
+		return true
+	}
+	return false
+}
+
+// Codes of google.rpc.Code used in the error of a finished Operation.
+const (
+	// OperationCodeDeadlineExceeded is the code of operations that went stale without admin action.
+	OperationCodeDeadlineExceeded = 4
+	// OperationCodeFailedPrecondition is the code of rejected operations.
+	OperationCodeFailedPrecondition = 9
+)
+
+// Operation is an LRO in the form of a google.longrunning.Operation, so that clients can
+// poll it with existing LRO tooling. The fields of the LRO are kept for existing clients.
+type Operation struct {
+	*LRO
+	// Name is the resource name of the operation, "operations/<operation_id>".
+	Name string `json:"name"`
+	// Done reports whether the operation reached a final status.
+	Done bool `json:"done"`
+	// Metadata describes the progress of the operation.
+	Metadata OperationMetadata `json:"metadata"`
+	// Response is the result of an approved operation, an empty object if it has none.
+	Response json.RawMessage `json:"response,omitempty"`
+	// Error is set for operations that are done but were not approved.
+	Error *OperationError `json:"error,omitempty"`
+}
+
+// OperationMetadata is the metadata of an Operation.
+type OperationMetadata struct {
+	Type       OperationType `json:"type,omitempty" enum:"CREATE_SUBSCRIPTION,UPDATE_SUBSCRIPTION"`
+	Status     LROStatus     `json:"status,omitempty" enum:"PENDING,APPROVED,FAILURE,REJECTED,STALE"`
+	SubState   LROSubState   `json:"sub_state,omitempty" enum:"PENDING_SECOND_APPROVAL"`
+	RetryCount int           `json:"retry_count,omitempty"`
+	CreateTime time.Time     `json:"create_time,omitzero"`
+	UpdateTime time.Time     `json:"update_time,omitzero"`
+}
+
+// OperationError is the error of an Operation in the form of a google.rpc.Status.
+type OperationError struct {
+	// Code is a google.rpc.Code value.
+	Code int `json:"code"`
+	// Message is the rejection reason or error recorded for the operation.
+	Message string `json:"message"`
+	// Details holds the error data of the operation.
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+// NewOperation returns the google.longrunning form of lro.
+func NewOperation(lro *LRO) *Operation {
+	op := &Operation{
+		LRO:  lro,
+		Name: "operations/" + lro.OperationID,
+		Done: lro.Done(),
+		Metadata: OperationMetadata{
+			Type:       lro.Type,
+			Status:     lro.Status,
+			SubState:   lro.SubState,
+			RetryCount: lro.RetryCount,
+			CreateTime: lro.CreatedAt,
+			UpdateTime: lro.UpdatedAt,
+		},
+	}
+	switch lro.Status {
+	case LROStatusApproved:
+		op.Response = lro.ResultJSON
+		if len(op.Response) == 0 {
+			op.Response = json.RawMessage("{}")
+		}
+	case LROStatusRejected:
+		op.Error = operationError(lro, OperationCodeFailedPrecondition)
+	case LROStatusStale:
+		op.Error = operationError(lro, OperationCodeDeadlineExceeded)
+	}
+	return op
+}
+
+// NewOperations returns the google.longrunning form of lros.
+func NewOperations(lros []LRO) []*Operation {
+	ops := make([]*Operation, len(lros))
+	for i := range lros {
+		ops[i] = NewOperation(&lros[i])
+	}
+	return ops
+}
+
+// operationError returns the error of a finished operation with its recorded reason or error.
+func operationError(lro *LRO, code int) *OperationError {
+	e := &OperationError{Code: code, Message: "operation " + strings.ToLower(string(lro.Status))}
+	if len(lro.ErrorDataJSON) == 0 {
+		return e
+	}
+	e.Details = []json.RawMessage{lro.ErrorDataJSON}
+	var data struct {
+		Reason string `json:"reason"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(lro.ErrorDataJSON, &data); err == nil {
+		if data.Reason != "" {
+			e.Message = data.Reason
+		} else if data.Error != "" {
+			e.Message = data.Error
+		}
+	}
+	return e
+}
+
+// ApprovalResult is recorded as the result of an approved subscription operation.
+type ApprovalResult struct {
+	// ValidityPolicy is the validity policy applied to the subscription, if any.
+	ValidityPolicy *ValidityPolicyResult `json:"validity_policy,omitempty"`
+	// ValidityOverride is the validity window set by the approver in place of the requested one, if any.
+	ValidityOverride *ValidityOverrideResult `json:"validity_override,omitempty"`
+}
+
+// ValidityOverrideResult records the validity window an approver set on approval.
+type ValidityOverrideResult struct {
+	// Approver is the identity of the admin who set the window, if the admin API receives one.
+	Approver string `json:"approver,omitempty"`
+	// RequestedValidFrom is the valid_from of the request, unset if it had none.
+	RequestedValidFrom time.Time `json:"requested_valid_from,omitzero"`
+	// RequestedValidUntil is the valid_until of the request, unset if it had none.
+	RequestedValidUntil time.Time `json:"requested_valid_until,omitzero"`
+	// ValidFrom is the valid_from after the override, unset if neither set one.
+	ValidFrom time.Time `json:"valid_from,omitzero"`
+	// ValidUntil is the valid_until after the override, before the validity policy is applied.
+	ValidUntil time.Time `json:"valid_until,omitzero"`
+}
+
+// ValidityPolicyResult records how the maximum validity of a role was applied to a subscription.
+type ValidityPolicyResult struct {
+	// Role is the role whose policy was applied.
+	Role Role `json:"role"`
+	// MaxValidity is the longest validity the policy allows for the role, e.g. "8760h0m0s".
+	MaxValidity string `json:"max_validity"`
+	// RequestedValidUntil is the valid_until of the request, unset if it had none.
+	RequestedValidUntil time.Time `json:"requested_valid_until,omitzero"`
+	// ValidUntil is the valid_until the subscription was stored with.
+	ValidUntil time.Time `json:"valid_until"`
+	// Clamped reports whether ValidUntil was moved to the limit of the policy.
+	Clamped bool `json:"clamped"`
+}
+
+// URLProbeResult records a reachability probe of a subscriber's URL, made when the
+// subscription request is received so that an approver can review it.
+type URLProbeResult struct {
+	// URL is the subscriber URL that was probed.
+	URL string `json:"url"`
+	// CheckedAt is when the probe was made.
+	CheckedAt time.Time `json:"checked_at"`
+	// Reachable reports whether every check passed.
+	Reachable bool `json:"reachable"`
+	// ResolvedIPs lists the addresses the URL's host resolved to.
+	ResolvedIPs []string `json:"resolved_ips,omitempty"`
+	// DNSError describes why the host could not be resolved.
+	DNSError string `json:"dns_error,omitempty"`
+	// TLSValid reports whether the server presented a certificate trusted for the host.
+	TLSValid bool `json:"tls_valid"`
+	// TLSExpiresAt is when the server's certificate expires.
+	TLSExpiresAt *time.Time `json:"tls_expires_at,omitempty"`
+	// TLSError describes why the TLS check failed.
+	TLSError string `json:"tls_error,omitempty"`
+	// HTTPStatus is the status code of the probe request.
+	HTTPStatus int `json:"http_status,omitempty"`
+	// HTTPError describes why the probe request failed.
+	HTTPError string `json:"http_error,omitempty"`
+	// LatencyMS is how long the probe request took, in milliseconds.
+	LatencyMS int64 `json:"latency_ms,omitempty"`
+}
+
+// LROStats summarizes the operations submitted in a time window, so that network
+// operators can track onboarding SLAs.
+type LROStats struct {
+	// From is the start of the window, inclusive.
+	From time.Time `json:"from"`
+	// To is the end of the window, exclusive.
+	To time.Time `json:"to"`
+	// Total is the number of operations submitted in the window.
+	Total int `json:"total"`
+	// ByStatus counts the operations submitted in the window by their current status.
+	ByStatus map[LROStatus]int `json:"by_status"`
+	// TimeToApproval summarizes how long the approved operations waited for approval.
+	TimeToApproval LROLatency `json:"time_to_approval"`
+	// Daily is the number of operations submitted on each UTC day of the window, oldest first.
+	// Days without submissions are omitted.
+	Daily []LRODailyVolume `json:"daily"`
+}
+
+// LROLatency holds percentiles of a set of operation durations, in seconds.
+type LROLatency struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_seconds"`
+	P90   float64 `json:"p90_seconds"`
+	P99   float64 `json:"p99_seconds"`
+}
+
+// LRODailyVolume is the number of operations submitted on a UTC day.
+type LRODailyVolume struct {
+	// Date is the day in YYYY-MM-DD format.
+	Date  string `json:"date" db:"day"`
+	Count int    `json:"count" db:"count"`
+}
+
+// OperationsDigestEvent is the X-Onix-Event header value of operations digest requests.
+const OperationsDigestEvent = "OPERATIONS_DIGEST"
+
+// LROFailureCount is the number of operations that failed with the same error.
+type LROFailureCount struct {
+	// Code is the error code of the failures or, for failures recorded without one, their error message.
+	Code  string `json:"code" db:"code"`
+	Count int    `json:"count" db:"count"`
+}
+
+// OperationsDigest summarizes the operations and subscriptions that need the attention of
+// network operators. It is sent to digest recipients on their schedule.
+type OperationsDigest struct {
+	// GeneratedAt is when the digest was generated.
+	GeneratedAt time.Time `json:"generated_at"`
+	// Frequency is the schedule of the recipient, "hourly" or "daily".
+	Frequency string `json:"frequency"`
+	// StuckOperations are the PENDING operations without an update for longer than the SLA, oldest first.
+	StuckOperations []LRO `json:"stuck_operations,omitempty"`
+	// FailuresSince is the start of the window Failures are counted in, one period before GeneratedAt.
+	FailuresSince time.Time `json:"failures_since,omitzero"`
+	// Failures counts the operations that failed in the window by error, most frequent first.
+	Failures []LROFailureCount `json:"failures,omitempty"`
+	// ExpiringBefore is the end of the window ExpiringSubscriptions are listed for.
+	ExpiringBefore time.Time `json:"expiring_before,omitzero"`
+	// ExpiringSubscriptions are the SUBSCRIBED subscriptions whose validity ends before ExpiringBefore, soonest first.
+	ExpiringSubscriptions []Subscription `json:"expiring_subscriptions,omitempty"`
 }
@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewOperation(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name         string
+		lro          *LRO
+		wantDone     bool
+		wantResponse json.RawMessage
+		wantError    *OperationError
+	}{
+		{
+			name:     "pending",
+			lro:      &LRO{OperationID: "op-1", Status: LROStatusPending},
+			wantDone: false,
+		},
+		{
+			name:     "failure is retryable",
+			lro:      &LRO{OperationID: "op-1", Status: LROStatusFailure, ErrorDataJSON: json.RawMessage(`{"error":"challenge failed"}`)},
+			wantDone: false,
+		},
+		{
+			name:         "approved without result",
+			lro:          &LRO{OperationID: "op-1", Status: LROStatusApproved},
+			wantDone:     true,
+			wantResponse: json.RawMessage(`{}`),
+		},
+		{
+			name:         "approved with result",
+			lro:          &LRO{OperationID: "op-1", Status: LROStatusApproved, ResultJSON: json.RawMessage(`{"validity_policy":{"role":"BAP"}}`)},
+			wantDone:     true,
+			wantResponse: json.RawMessage(`{"validity_policy":{"role":"BAP"}}`),
+		},
+		{
+			name:      "rejected with reason",
+			lro:       &LRO{OperationID: "op-1", Status: LROStatusRejected, ErrorDataJSON: json.RawMessage(`{"reason":"KYC incomplete"}`)},
+			wantDone:  true,
+			wantError: &OperationError{Code: OperationCodeFailedPrecondition, Message: "KYC incomplete", Details: []json.RawMessage{json.RawMessage(`{"reason":"KYC incomplete"}`)}},
+		},
+		{
+			name:      "rejected after retries",
+			lro:       &LRO{OperationID: "op-1", Status: LROStatusRejected, ErrorDataJSON: json.RawMessage(`{"error":"challenge failed"}`)},
+			wantDone:  true,
+			wantError: &OperationError{Code: OperationCodeFailedPrecondition, Message: "challenge failed", Details: []json.RawMessage{json.RawMessage(`{"error":"challenge failed"}`)}},
+		},
+		{
+			name:      "stale without error data",
+			lro:       &LRO{OperationID: "op-1", Status: LROStatusStale},
+			wantDone:  true,
+			wantError: &OperationError{Code: OperationCodeDeadlineExceeded, Message: "operation stale"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.lro.Type = OperationTypeCreateSubscription
+			tc.lro.CreatedAt, tc.lro.UpdatedAt = now, now
+			op := NewOperation(tc.lro)
+			if op.Name != "operations/op-1" {
+				t.Errorf("Name = %q, want %q", op.Name, "operations/op-1")
+			}
+			if op.Done != tc.wantDone {
+				t.Errorf("Done = %v, want %v", op.Done, tc.wantDone)
+			}
+			wantMetadata := OperationMetadata{Type: OperationTypeCreateSubscription, Status: tc.lro.Status, CreateTime: now, UpdateTime: now}
+			if diff := cmp.Diff(wantMetadata, op.Metadata); diff != "" {
+				t.Errorf("Metadata mismatch (-want +got):\n%s", diff)
+			}
+			if string(op.Response) != string(tc.wantResponse) {
+				t.Errorf("Response = %s, want %s", op.Response, tc.wantResponse)
+			}
+			if diff := cmp.Diff(tc.wantError, op.Error); diff != "" {
+				t.Errorf("Error mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewOperation_KeepsLROFields(t *testing.T) {
+	lro := &LRO{OperationID: "op-1", Status: LROStatusApproved, Type: OperationTypeUpdateSubscription, RetryCount: 1}
+	b, err := json.Marshal(NewOperation(lro))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	for key, want := range map[string]any{
+		"operation_id": "op-1",
+		"status":       "APPROVED",
+		"type":         "UPDATE_SUBSCRIPTION",
+		"name":         "operations/op-1",
+		"done":         true,
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// MaintenanceHeader is set to "read-only" on every registry response while the registry
+// is in maintenance mode, so that clients such as gateways can detect it.
+const MaintenanceHeader = "X-Onix-Maintenance"
+
+// MaintenanceModeReadOnly is the value of MaintenanceHeader in maintenance mode.
+const MaintenanceModeReadOnly = "read-only"
+
+// Maintenance is the maintenance mode of the registry. While it is enabled, lookups
+// and reads succeed and requests that modify the registry are rejected.
+type Maintenance struct {
+	// Enabled puts the registry in read-only mode.
+	Enabled bool `json:"enabled"`
+
+	// Message is returned to clients whose requests are rejected, e.g. the expected end of a migration.
+	Message string `json:"message,omitempty"`
+
+	// UpdatedAt is when the mode was last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
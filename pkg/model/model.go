@@ -17,6 +17,7 @@ package model
 import (
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // AsyncTaskType defines the type of asynchronous task.
@@ -27,6 +28,8 @@ const (
 	AsyncTaskTypeProxy AsyncTaskType = "PROXY"
 	// AsyncTaskTypeLookup indicates a task that requires a lookup.
 	AsyncTaskTypeLookup AsyncTaskType = "LOOKUP"
+	// AsyncTaskTypeProxyBatch indicates a task that should be proxied to several target URLs on the same host.
+	AsyncTaskTypeProxyBatch AsyncTaskType = "PROXY_BATCH"
 )
 
 // AsyncTask holds the details for an asynchronous task.
@@ -36,6 +39,8 @@ type AsyncTask struct {
 	Body    []byte        `json:"body"`
 	Headers http.Header   `json:"headers"`
 	Context Context       `json:"context,omitempty"`
+	// Targets are the target URLs of a PROXY_BATCH task, in place of Target.
+	Targets []*url.URL `json:"targets,omitempty"`
 }
 
 // NpSubscriptionRequest models the request for subscriber service.
@@ -44,3 +49,78 @@ type NpSubscriptionRequest struct {
 	KeyID      string `json:"key_id"`
 	MessageID  string `json:"message_id"`
 }
+
+// UndeleteKeysetRequest models the request to recover a soft deleted keyset.
+type UndeleteKeysetRequest struct {
+	KeyID string `json:"key_id"`
+}
+
+// HealthStatus is the result of a dependency health check.
+type HealthStatus string
+
+const (
+	// HealthStatusOK indicates that the dependency is reachable.
+	HealthStatusOK HealthStatus = "OK"
+	// HealthStatusError indicates that the dependency check failed.
+	HealthStatusError HealthStatus = "ERROR"
+	// HealthStatusUnknown indicates that the dependency is not checked.
+	HealthStatusUnknown HealthStatus = "UNKNOWN"
+)
+
+// ComponentHealth reports the health of a dependency of a service.
+type ComponentHealth struct {
+	Status    HealthStatus `json:"status"`
+	Error     string       `json:"error,omitempty"`
+	LatencyMS int64        `json:"latency_ms"`
+}
+
+// SubscriberSubscriptionStatus describes the latest subscription request made by the subscriber service.
+type SubscriberSubscriptionStatus struct {
+	SubscriberID string    `json:"subscriber_id"`
+	OperationID  string    `json:"operation_id"`
+	Status       LROStatus `json:"status"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SubscriberKeysetStatus describes the keyset of the latest subscription request.
+type SubscriberKeysetStatus struct {
+	KeyID      string    `json:"key_id"`
+	ValidFrom  time.Time `json:"valid_from"`
+	ValidUntil time.Time `json:"valid_until"`
+	Valid      bool      `json:"valid"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// SubscriberChallengeStatus describes the latest on_subscribe challenge received from the registry.
+type SubscriberChallengeStatus struct {
+	OperationID string    `json:"operation_id"`
+	ReceivedAt  time.Time `json:"received_at"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ChallengeRecord is an on_subscribe exchange captured by the subscriber service to debug
+// challenge failures. It never contains private keys.
+type ChallengeRecord struct {
+	MessageID  string             `json:"message_id"`
+	ReceivedAt time.Time          `json:"received_at"`
+	DurationMS int64              `json:"duration_ms"`
+	Request    OnSubscribeRequest `json:"request"`
+	// EncrPublicKey is the NP's public key the registry must have encrypted the challenge with.
+	EncrPublicKey string `json:"encr_public_key,omitempty"`
+	// RegistryPublicKey is the registry's public key the challenge was decrypted with.
+	RegistryPublicKey  string               `json:"registry_public_key,omitempty"`
+	DecryptedChallenge string               `json:"decrypted_challenge,omitempty"`
+	Response           *OnSubscribeResponse `json:"response,omitempty"`
+	Error              string               `json:"error,omitempty"`
+}
+
+// SubscriberStatus is the status report of the subscriber service, served on GET /status.
+type SubscriberStatus struct {
+	Healthy        bool                          `json:"healthy"`
+	Subscription   *SubscriberSubscriptionStatus `json:"subscription,omitempty"`
+	Keyset         *SubscriberKeysetStatus       `json:"keyset,omitempty"`
+	LastChallenge  *SubscriberChallengeStatus    `json:"last_challenge,omitempty"`
+	Registry       ComponentHealth               `json:"registry"`
+	EventPublisher ComponentHealth               `json:"event_publisher"`
+}
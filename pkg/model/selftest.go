@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// SelfTestCheckName identifies a check of the gateway connectivity self-test.
+type SelfTestCheckName string
+
+// Defines the valid SelfTestCheckName values, in the order the checks run.
+const (
+	// SelfTestCheckAuthHeader checks that the Authorization header can be parsed.
+	SelfTestCheckAuthHeader SelfTestCheckName = "AUTH_HEADER"
+
+	// SelfTestCheckClock checks that the created and expires times of the signature
+	// are consistent with the gateway's clock.
+	SelfTestCheckClock SelfTestCheckName = "CLOCK"
+
+	// SelfTestCheckSigningKey checks that the signing key in the keyId is registered.
+	SelfTestCheckSigningKey SelfTestCheckName = "SIGNING_KEY"
+
+	// SelfTestCheckSignature checks that the signature validates against the registered key.
+	SelfTestCheckSignature SelfTestCheckName = "SIGNATURE"
+
+	// SelfTestCheckSubscriberURL checks that the bap_uri or bpp_uri in the request context
+	// matches a URL registered for the subscriber.
+	SelfTestCheckSubscriberURL SelfTestCheckName = "SUBSCRIBER_URL"
+)
+
+// SelfTestStatus is the outcome of a self-test check.
+type SelfTestStatus string
+
+// Defines the valid SelfTestStatus values.
+const (
+	// SelfTestStatusPass indicates that the check passed.
+	SelfTestStatusPass SelfTestStatus = "PASS"
+
+	// SelfTestStatusFail indicates that the check failed.
+	SelfTestStatusFail SelfTestStatus = "FAIL"
+
+	// SelfTestStatusSkipped indicates that the check could not run because an earlier one failed
+	// or the request lacks what it needs.
+	SelfTestStatusSkipped SelfTestStatus = "SKIPPED"
+)
+
+// SelfTestCheck is the outcome of one check of the self-test.
+type SelfTestCheck struct {
+	// Name identifies the check.
+	Name SelfTestCheckName `json:"name"`
+
+	// Status is the outcome of the check.
+	Status SelfTestStatus `json:"status"`
+
+	// Detail explains the outcome, and how to fix a failure.
+	Detail string `json:"detail,omitempty"`
+}
+
+// SelfTestReport is the diagnostic report of a connectivity self-test of a network participant.
+type SelfTestReport struct {
+	// OK reports whether every check passed.
+	OK bool `json:"ok"`
+
+	// SubscriberID is the subscriber ID from the keyId of the Authorization header.
+	SubscriberID string `json:"subscriber_id,omitempty"`
+
+	// KeyID is the unique key ID from the keyId of the Authorization header.
+	KeyID string `json:"key_id,omitempty"`
+
+	// GatewayTime is the gateway's clock when the request was checked.
+	GatewayTime time.Time `json:"gateway_time"`
+
+	// Checks are the outcomes of the checks, in the order they ran.
+	Checks []SelfTestCheck `json:"checks"`
+}
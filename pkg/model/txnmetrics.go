@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// TxnSegment is a market segment of the transactions through the gateway.
+type TxnSegment struct {
+	// Action is the Beckn action of the transactions.
+	Action string `json:"action"`
+
+	// Domain is the domain code in the context of the transactions.
+	Domain string `json:"domain"`
+
+	// City is the city code in the context location of the transactions.
+	City string `json:"city"`
+}
+
+// LatencySummary summarizes a set of latencies in milliseconds. Percentiles are the upper
+// bound of the histogram bucket they fall in, capped at the maximum.
+type LatencySummary struct {
+	AvgMS float64 `json:"avg_ms"`
+	P50MS int64   `json:"p50_ms"`
+	P95MS int64   `json:"p95_ms"`
+	MaxMS int64   `json:"max_ms"`
+}
+
+// TxnSegmentStats are the counts and latencies of the transactions of a segment.
+type TxnSegmentStats struct {
+	TxnSegment
+
+	// Requests is the number of requests received, and Nacks the number of them that were NACKed.
+	Requests int64 `json:"requests"`
+	Nacks    int64 `json:"nacks"`
+
+	// RequestLatency is the time taken to ACK or NACK the requests.
+	RequestLatency LatencySummary `json:"request_latency"`
+
+	// Fanouts is the number of requests forwarded to network participants, and FanoutErrors
+	// the number of them that failed after retries.
+	Fanouts      int64 `json:"fanouts"`
+	FanoutErrors int64 `json:"fanout_errors"`
+
+	// FanoutLatency is the time taken to forward the requests, including retries.
+	FanoutLatency LatencySummary `json:"fanout_latency"`
+}
+
+// TxnSummary is the summary of the transactions through the gateway per segment.
+type TxnSummary struct {
+	// Since is when the gateway started counting.
+	Since time.Time `json:"since"`
+
+	// Segments are the stats of each segment, ordered by action, domain and city.
+	Segments []TxnSegmentStats `json:"segments"`
+}
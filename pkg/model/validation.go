@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrValidation occurs if a model has invalid fields. ValidationError wraps it.
+var ErrValidation = errors.New("validation failed")
+
+// FieldError describes an invalid field of a model.
+type FieldError struct {
+	// Field is the JSON name of the field, such as "subscriber_id".
+	Field string `json:"field"`
+	// Message says what is wrong with the field, such as "is required".
+	Message string `json:"message"`
+}
+
+// String formats the field error as "field message".
+func (e FieldError) String() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError lists the invalid fields of a model. It wraps ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error joins the field errors into a single message.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrValidation.
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// ErrorResponse returns the response reporting the error with the given code.
+// Its path is the first invalid field.
+func (e *ValidationError) ErrorResponse(code ErrorCode) ErrorResponse {
+	resp := ErrorResponse{Error: Error{Type: ErrorTypeValidationError, Code: code, Message: e.Error(), Fields: e.Fields}}
+	if len(e.Fields) > 0 {
+		resp.Error.Path = e.Fields[0].Field
+	}
+	return resp
+}
+
+// fieldErrors collects the field errors of a model.
+type fieldErrors []FieldError
+
+// add records a field error.
+func (f *fieldErrors) add(field, format string, args ...any) {
+	*f = append(*f, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// require records an error if value is empty.
+func (f *fieldErrors) require(field, value string) {
+	if value == "" {
+		f.add(field, "is required")
+	}
+}
+
+// url records an error if value is set and not an absolute http or https URL.
+func (f *fieldErrors) url(field, value string) {
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		f.add(field, "must be an absolute http or https URL")
+	}
+}
+
+// err returns the collected field errors as a *ValidationError, or nil if there are none.
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: f}
+}
+
+// validate records the errors of the subscriber fields. If required is false,
+// only the fields that are set are checked.
+func (s *Subscriber) validate(f *fieldErrors, required bool) {
+	if required {
+		f.require("subscriber_id", s.SubscriberID)
+		f.require("url", s.URL)
+		f.require("type", string(s.Type))
+		f.require("domain", s.Domain)
+	}
+	f.url("url", s.URL)
+	if s.Type != "" && !validRoles[s.Type] {
+		f.add("type", "must be one of %s, %s, %s", RoleBAP, RoleBPP, RoleGateway)
+	}
+}
+
+// validate records the errors of the subscription fields.
+func (s *Subscription) validate(f *fieldErrors) {
+	s.Subscriber.validate(f, true)
+	f.require("key_id", s.KeyID)
+	f.require("signing_public_key", s.SigningPublicKey)
+	f.require("encr_public_key", s.EncrPublicKey)
+	if !s.ValidFrom.IsZero() && !s.ValidUntil.IsZero() && !s.ValidUntil.After(s.ValidFrom) {
+		f.add("valid_until", "must be after valid_from")
+	}
+}
+
+// Validate checks that the subscription identifies its subscriber and carries its keys.
+func (s *Subscription) Validate() error {
+	var f fieldErrors
+	s.validate(&f)
+	return f.err()
+}
+
+// Validate checks the subscription and that the request has a message ID.
+func (r *SubscriptionRequest) Validate() error {
+	var f fieldErrors
+	r.Subscription.validate(&f)
+	f.require("message_id", r.MessageID)
+	return f.err()
+}
+
+// Validate checks that the request identifies the subscriber to subscribe.
+// The URL is optional, as updates may keep the registered one.
+func (r *NpSubscriptionRequest) Validate() error {
+	var f fieldErrors
+	f.require("subscriber_id", r.SubscriberID)
+	f.require("domain", r.Domain)
+	f.require("type", string(r.Type))
+	r.Subscriber.validate(&f, false)
+	return f.err()
+}
+
+// Validate checks that the request carries a message ID and a challenge.
+func (r *OnSubscribeRequest) Validate() error {
+	var f fieldErrors
+	f.require("message_id", r.MessageID)
+	f.require("challenge", r.Challenge)
+	return f.err()
+}
+
+// Validate checks the operation ID and action of the request, that rejections have a reason,
+// and that a validity window overridden on approval ends after it starts.
+func (r *OperationActionRequest) Validate() error {
+	var f fieldErrors
+	f.require("operation_id", r.OperationID)
+	switch r.Action {
+	case OperationActionApproveSubscription:
+		if !r.ValidFrom.IsZero() && !r.ValidUntil.IsZero() && !r.ValidUntil.After(r.ValidFrom) {
+			f.add("valid_until", "must be after valid_from")
+		}
+	case OperationActionRejectSubscription:
+		f.require("reason", r.Reason)
+		if !r.ValidFrom.IsZero() {
+			f.add("valid_from", "is only allowed when approving")
+		}
+		if !r.ValidUntil.IsZero() {
+			f.add("valid_until", "is only allowed when approving")
+		}
+	case "":
+		f.add("action", "is required")
+	default:
+		f.add("action", "must be one of %s, %s", OperationActionApproveSubscription, OperationActionRejectSubscription)
+	}
+	return f.err()
+}
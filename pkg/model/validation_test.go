@@ -0,0 +1,287 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func validSubscriptionRequest() SubscriptionRequest {
+	return SubscriptionRequest{
+		Subscription: Subscription{
+			Subscriber: Subscriber{
+				SubscriberID: "np1.example.com",
+				URL:          "https://np1.example.com/beckn",
+				Type:         RoleBAP,
+				Domain:       "retail",
+			},
+			KeyID:            "key1",
+			SigningPublicKey: "signing-pub-key",
+			EncrPublicKey:    "encr-pub-key",
+		},
+		MessageID: "msg1",
+	}
+}
+
+// fieldsOf returns the field errors of err, or nil if err is not a ValidationError.
+func fieldsOf(t *testing.T, err error) []FieldError {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want *ValidationError", err)
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("errors.Is(%v, ErrValidation) = false, want true", err)
+	}
+	return verr.Fields
+}
+
+func TestSubscriptionRequest_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		modify func(*SubscriptionRequest)
+		want   []FieldError
+	}{
+		{
+			name:   "valid",
+			modify: func(r *SubscriptionRequest) {},
+		},
+		{
+			name: "valid with validity window",
+			modify: func(r *SubscriptionRequest) {
+				r.ValidFrom = now
+				r.ValidUntil = now.Add(time.Hour)
+			},
+		},
+		{
+			name:   "empty",
+			modify: func(r *SubscriptionRequest) { *r = SubscriptionRequest{} },
+			want: []FieldError{
+				{Field: "subscriber_id", Message: "is required"},
+				{Field: "url", Message: "is required"},
+				{Field: "type", Message: "is required"},
+				{Field: "domain", Message: "is required"},
+				{Field: "key_id", Message: "is required"},
+				{Field: "signing_public_key", Message: "is required"},
+				{Field: "encr_public_key", Message: "is required"},
+				{Field: "message_id", Message: "is required"},
+			},
+		},
+		{
+			name:   "relative url",
+			modify: func(r *SubscriptionRequest) { r.URL = "/beckn" },
+			want:   []FieldError{{Field: "url", Message: "must be an absolute http or https URL"}},
+		},
+		{
+			name:   "non http url",
+			modify: func(r *SubscriptionRequest) { r.URL = "ftp://np1.example.com" },
+			want:   []FieldError{{Field: "url", Message: "must be an absolute http or https URL"}},
+		},
+		{
+			name:   "unknown type",
+			modify: func(r *SubscriptionRequest) { r.Type = "LSP" },
+			want:   []FieldError{{Field: "type", Message: "must be one of BAP, BPP, BG"}},
+		},
+		{
+			name: "valid_until before valid_from",
+			modify: func(r *SubscriptionRequest) {
+				r.ValidFrom = now
+				r.ValidUntil = now.Add(-time.Hour)
+			},
+			want: []FieldError{{Field: "valid_until", Message: "must be after valid_from"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validSubscriptionRequest()
+			tt.modify(&req)
+			got := fieldsOf(t, req.Validate())
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() fields mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	req := validSubscriptionRequest()
+	if err := req.Subscription.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+	req.EncrPublicKey = ""
+	want := []FieldError{{Field: "encr_public_key", Message: "is required"}}
+	if diff := cmp.Diff(want, fieldsOf(t, req.Subscription.Validate())); diff != "" {
+		t.Errorf("Validate() fields mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNpSubscriptionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  NpSubscriptionRequest
+		want []FieldError
+	}{
+		{
+			name: "valid without url",
+			req:  NpSubscriptionRequest{Subscriber: Subscriber{SubscriberID: "np1", Domain: "retail", Type: RoleBPP}},
+		},
+		{
+			name: "empty",
+			want: []FieldError{
+				{Field: "subscriber_id", Message: "is required"},
+				{Field: "domain", Message: "is required"},
+				{Field: "type", Message: "is required"},
+			},
+		},
+		{
+			name: "invalid url and type",
+			req:  NpSubscriptionRequest{Subscriber: Subscriber{SubscriberID: "np1", Domain: "retail", Type: "LSP", URL: "np1.example.com"}},
+			want: []FieldError{
+				{Field: "url", Message: "must be an absolute http or https URL"},
+				{Field: "type", Message: "must be one of BAP, BPP, BG"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldsOf(t, tt.req.Validate())
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() fields mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOnSubscribeRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  OnSubscribeRequest
+		want []FieldError
+	}{
+		{
+			name: "valid",
+			req:  OnSubscribeRequest{MessageID: "msg1", Challenge: "challenge"},
+		},
+		{
+			name: "missing challenge",
+			req:  OnSubscribeRequest{MessageID: "msg1"},
+			want: []FieldError{{Field: "challenge", Message: "is required"}},
+		},
+		{
+			name: "empty",
+			want: []FieldError{
+				{Field: "message_id", Message: "is required"},
+				{Field: "challenge", Message: "is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldsOf(t, tt.req.Validate())
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() fields mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOperationActionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  OperationActionRequest
+		want []FieldError
+	}{
+		{
+			name: "approve",
+			req:  OperationActionRequest{OperationID: "op1", Action: OperationActionApproveSubscription},
+		},
+		{
+			name: "reject with reason",
+			req:  OperationActionRequest{OperationID: "op1", Action: OperationActionRejectSubscription, Reason: "bad keys"},
+		},
+		{
+			name: "reject without reason",
+			req:  OperationActionRequest{OperationID: "op1", Action: OperationActionRejectSubscription},
+			want: []FieldError{{Field: "reason", Message: "is required"}},
+		},
+		{
+			name: "approve with validity window",
+			req:  OperationActionRequest{OperationID: "op1", Action: OperationActionApproveSubscription, ValidFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ValidUntil: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "approve with empty validity window",
+			req:  OperationActionRequest{OperationID: "op1", Action: OperationActionApproveSubscription, ValidFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ValidUntil: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want: []FieldError{{Field: "valid_until", Message: "must be after valid_from"}},
+		},
+		{
+			name: "reject with validity window",
+			req:  OperationActionRequest{OperationID: "op1", Action: OperationActionRejectSubscription, Reason: "bad keys", ValidFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ValidUntil: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want: []FieldError{
+				{Field: "valid_from", Message: "is only allowed when approving"},
+				{Field: "valid_until", Message: "is only allowed when approving"},
+			},
+		},
+		{
+			name: "missing action",
+			req:  OperationActionRequest{OperationID: "op1"},
+			want: []FieldError{{Field: "action", Message: "is required"}},
+		},
+		{
+			name: "unknown action",
+			req:  OperationActionRequest{Action: "DELETE"},
+			want: []FieldError{
+				{Field: "operation_id", Message: "is required"},
+				{Field: "action", Message: "must be one of APPROVE_SUBSCRIPTION, REJECT_SUBSCRIPTION"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldsOf(t, tt.req.Validate())
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() fields mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidationError_ErrorResponse(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{
+		{Field: "url", Message: "is required"},
+		{Field: "type", Message: "must be one of BAP, BPP, BG"},
+	}}
+	want := ErrorResponse{Error: Error{
+		Type:    ErrorTypeValidationError,
+		Code:    ErrorCodeBadRequest,
+		Message: "url is required; type must be one of BAP, BPP, BG",
+		Path:    "url",
+		Fields:  err.Fields,
+	}}
+	if diff := cmp.Diff(want, err.ErrorResponse(ErrorCodeBadRequest)); diff != "" {
+		t.Errorf("ErrorResponse() mismatch (-want +got):\n%s", diff)
+	}
+}
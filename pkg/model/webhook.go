@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Headers set on webhook requests.
+const (
+	// WebhookEventHeader carries the event type of a webhook request.
+	WebhookEventHeader = "X-Onix-Event"
+	// WebhookDeliveryHeader carries the delivery ID, which is the same on every attempt.
+	WebhookDeliveryHeader = "X-Onix-Delivery"
+	// WebhookTimestampHeader carries the Unix time at which the request was signed.
+	WebhookTimestampHeader = "X-Onix-Timestamp"
+	// WebhookSignatureHeader carries "sha256=" followed by the hex encoded HMAC-SHA256,
+	// keyed with the webhook secret, of the timestamp, a dot and the request body.
+	WebhookSignatureHeader = "X-Onix-Signature"
+)
+
+// Webhook is an HTTP endpoint that the admin service notifies of LRO transitions.
+type Webhook struct {
+	// ID identifies the webhook.
+	ID string `json:"webhook_id"`
+
+	// URL is the endpoint that receives the notifications.
+	URL string `json:"url" format:"uri"`
+
+	// EventTypes are the events the webhook receives. Empty means all events.
+	EventTypes []EventType `json:"event_types,omitempty" enum:"NEW_SUBSCRIPTION_REQUEST,UPDATE_SUBSCRIPTION_REQUEST,SUBSCRIPTION_REQUEST_APPROVED,SUBSCRIPTION_REQUEST_REJECTED,ON_SUBSCRIBE_RECIEVED,KEY_ROTATED,KEY_ACCESSED,SUBSCRIPTION_ACTIVATED,SUBSCRIPTION_REQUEST_PENDING_SECOND_APPROVAL,SUBSCRIPTION_REQUEST_FAILED,SUBSCRIPTION_REQUEST_EXPIRED,SUBSCRIPTION_APPROVED_PENDING_ACTIVATION"`
+
+	// Secret is the key requests to the webhook are signed with. It is never returned to clients
+	// after registration.
+	Secret string `json:"-"`
+
+	// CreatedAt is when the webhook was registered.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookRequest is the request to register a webhook.
+type WebhookRequest struct {
+	URL        string      `json:"url" format:"uri"`
+	EventTypes []EventType `json:"event_types,omitempty" enum:"NEW_SUBSCRIPTION_REQUEST,UPDATE_SUBSCRIPTION_REQUEST,SUBSCRIPTION_REQUEST_APPROVED,SUBSCRIPTION_REQUEST_REJECTED,ON_SUBSCRIBE_RECIEVED,KEY_ROTATED,KEY_ACCESSED,SUBSCRIPTION_ACTIVATED,SUBSCRIPTION_REQUEST_PENDING_SECOND_APPROVAL,SUBSCRIPTION_REQUEST_FAILED,SUBSCRIPTION_REQUEST_EXPIRED,SUBSCRIPTION_APPROVED_PENDING_ACTIVATION"`
+}
+
+// RegisteredWebhook is returned once when a webhook is registered. The secret cannot be retrieved later.
+type RegisteredWebhook struct {
+	Webhook
+
+	// Secret is the key to verify the signature of webhook requests with.
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the body of a webhook request. It carries the operation the event is about,
+// or, for the activation of a subscription, the subscription.
+type WebhookEvent struct {
+	DeliveryID   string        `json:"delivery_id"`
+	EventType    EventType     `json:"event_type"`
+	Operation    *LRO          `json:"operation,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+}
+
+// WebhookDeliveryStatus is the status of a webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending indicates that the delivery has not succeeded yet and will be attempted again.
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "PENDING"
+	// WebhookDeliveryStatusDelivered indicates that the webhook responded with a 2xx status.
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	// WebhookDeliveryStatusFailed indicates that all attempts failed.
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookDelivery records the delivery of an event to a webhook.
+type WebhookDelivery struct {
+	ID          string                `json:"delivery_id"`
+	WebhookID   string                `json:"webhook_id"`
+	EventType   EventType             `json:"event_type" enum:"NEW_SUBSCRIPTION_REQUEST,UPDATE_SUBSCRIPTION_REQUEST,SUBSCRIPTION_REQUEST_APPROVED,SUBSCRIPTION_REQUEST_REJECTED,ON_SUBSCRIBE_RECIEVED,KEY_ROTATED,KEY_ACCESSED,SUBSCRIPTION_ACTIVATED,SUBSCRIPTION_REQUEST_PENDING_SECOND_APPROVAL,SUBSCRIPTION_REQUEST_FAILED,SUBSCRIPTION_REQUEST_EXPIRED,SUBSCRIPTION_APPROVED_PENDING_ACTIVATION"`
+	OperationID string                `json:"operation_id"`
+	Payload     json.RawMessage       `json:"payload"`
+	Status      WebhookDeliveryStatus `json:"status" enum:"PENDING,DELIVERED,FAILED"`
+	Attempts    int                   `json:"attempts"`
+	// ResponseCode is the HTTP status of the last attempt, if the webhook responded.
+	ResponseCode int `json:"response_code,omitempty"`
+	// LastError describes why the last attempt failed.
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
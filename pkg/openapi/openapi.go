@@ -0,0 +1,434 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi generates OpenAPI 3 documents for ONIX routers.
+// Paths and methods are taken from the routes registered on a chi router;
+// request and response bodies are derived from the annotated models in pkg/model,
+// using their json tags and the format and enum tags.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+// Spec describes an API whose document is generated from its router.
+type Spec struct {
+	Title   string
+	Version string
+	// Error is the model of error responses, documented as the default response of every operation.
+	Error any
+	// Endpoints annotates the registered routes, keyed by method and route pattern,
+	// such as "GET /webhooks/{webhook_id}". Routes without an annotation are not documented.
+	Endpoints map[string]Endpoint
+}
+
+// Endpoint annotates a route with its summary and the models it exchanges.
+type Endpoint struct {
+	ID      string
+	Summary string
+	Query   []Param
+	// Request is a value of the request body model, or nil if the route takes no body.
+	Request any
+	// RequestType is the media type of the request body. Defaults to application/json.
+	RequestType string
+	// Responses maps status codes to a value of the response body model, or to nil if the response has no body.
+	Responses map[int]any
+}
+
+// OneOf is a body that is one of several models.
+type OneOf []any
+
+// Param describes a query parameter.
+type Param struct {
+	Name        string
+	Description string
+	// Type is the JSON type of the parameter. Defaults to string.
+	Type   string
+	Format string
+}
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info holds the metadata of the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to the operations of a path.
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by the document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is the subset of the OpenAPI schema object generated from Go types.
+// The empty schema accepts any value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// ServeHTTP writes the document as JSON.
+func (d *Document) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// pathParam matches the parameters of a chi route pattern, with an optional regexp.
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Generate builds the document of the annotated routes registered on routes.
+// It fails if an endpoint is annotated but not registered, so that the document
+// cannot drift from the router.
+func Generate(spec Spec, routes chi.Routes) (*Document, error) {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: spec.Title, Version: spec.Version},
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+	g := &generator{schemas: doc.Components.Schemas, types: map[string]reflect.Type{}}
+	var errSchema *Schema
+	if spec.Error != nil {
+		var err error
+		if errSchema, err = g.schema(reflect.TypeOf(spec.Error)); err != nil {
+			return nil, err
+		}
+	}
+
+	documented := map[string]bool{}
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		key := method + " " + route
+		ep, ok := spec.Endpoints[key]
+		if !ok {
+			return nil
+		}
+		documented[key] = true
+		path := pathParam.ReplaceAllString(route, "{$1}")
+		op, err := g.operation(path, ep)
+		if err != nil {
+			return fmt.Errorf("endpoint %q: %w", key, err)
+		}
+		if errSchema != nil {
+			op.Responses["default"] = &Response{Description: "Error", Content: jsonContent(errSchema)}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = op
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for key := range spec.Endpoints {
+		if !documented[key] {
+			return nil, fmt.Errorf("endpoint %q is annotated but not registered", key)
+		}
+	}
+	return doc, nil
+}
+
+// generator derives schemas from Go types, collecting named struct types as components.
+type generator struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+}
+
+// operation builds the operation of an annotated endpoint on path.
+func (g *generator) operation(path string, ep Endpoint) (*Operation, error) {
+	op := &Operation{OperationID: ep.ID, Summary: ep.Summary, Responses: map[string]*Response{}}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, q := range ep.Query {
+		s := &Schema{Type: q.Type, Format: q.Format}
+		if s.Type == "" {
+			s.Type = "string"
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: q.Name, In: "query", Description: q.Description, Schema: s})
+	}
+	if ep.Request != nil {
+		s, err := g.body(ep.Request)
+		if err != nil {
+			return nil, err
+		}
+		mt := ep.RequestType
+		if mt == "" {
+			mt = "application/json"
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{mt: {Schema: s}}}
+	}
+	for code, v := range ep.Responses {
+		resp := &Response{Description: http.StatusText(code)}
+		if v != nil {
+			s, err := g.body(v)
+			if err != nil {
+				return nil, err
+			}
+			resp.Content = jsonContent(s)
+		}
+		op.Responses[fmt.Sprint(code)] = resp
+	}
+	return op, nil
+}
+
+// jsonContent returns the content of a JSON body with schema s.
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// body returns the schema of a body model.
+func (g *generator) body(v any) (*Schema, error) {
+	oneOf, ok := v.(OneOf)
+	if !ok {
+		return g.schema(reflect.TypeOf(v))
+	}
+	s := &Schema{}
+	for _, m := range oneOf {
+		ms, err := g.schema(reflect.TypeOf(m))
+		if err != nil {
+			return nil, err
+		}
+		s.OneOf = append(s.OneOf, ms)
+	}
+	return s, nil
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schema returns the schema of t. Named struct types are added to the components and referenced.
+func (g *generator) schema(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType:
+		return &Schema{}, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// structSchema returns a reference to the component of a named struct type,
+// or the inline schema of an anonymous one.
+func (g *generator) structSchema(t reflect.Type) (*Schema, error) {
+	name := t.Name()
+	if name == "" {
+		return g.object(t)
+	}
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if seen, ok := g.types[name]; ok {
+		if seen != t {
+			return nil, fmt.Errorf("types %s and %s share the schema name %q", seen, t, name)
+		}
+		return ref, nil
+	}
+	// Registered before the fields are visited, so that recursive types terminate.
+	g.types[name] = t
+	s, err := g.object(t)
+	if err != nil {
+		return nil, err
+	}
+	g.schemas[name] = s
+	return ref, nil
+}
+
+// object returns the schema of the fields of struct type t as encoded by encoding/json.
+// Fields of embedded structs without a json name are promoted, and fields tagged
+// omitempty or omitzero are optional.
+func (g *generator) object(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if err := g.addFields(s, t); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) error {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := g.addFields(s, ft); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs, err := g.field(f, opts)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), f.Name, err)
+		}
+		s.Properties[name] = fs
+		optional := slices.ContainsFunc(strings.Split(opts, ","), func(o string) bool {
+			return o == "omitempty" || o == "omitzero"
+		})
+		if !optional && !slices.Contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return nil
+}
+
+// field returns the schema of a struct field, applying its format and enum tags.
+// The enum tag applies to the items of slice fields.
+func (g *generator) field(f reflect.StructField, opts string) (*Schema, error) {
+	if slices.Contains(strings.Split(opts, ","), "string") {
+		return &Schema{Type: "string"}, nil
+	}
+	s, err := g.schema(f.Type)
+	if err != nil {
+		return nil, err
+	}
+	format, hasFormat := f.Tag.Lookup("format")
+	enum, hasEnum := f.Tag.Lookup("enum")
+	if !hasFormat && !hasEnum {
+		return s, nil
+	}
+	target := s
+	if s.Type == "array" {
+		// Copy the items so that shared component references are not modified.
+		items := *s.Items
+		target = &items
+		s = &Schema{Type: "array", Items: target}
+	}
+	if target.Ref != "" {
+		return nil, fmt.Errorf("format and enum tags apply only to scalar types")
+	}
+	if hasFormat {
+		target.Format = format
+	}
+	if hasEnum {
+		target.Enum = strings.Split(enum, ",")
+	}
+	return s, nil
+}
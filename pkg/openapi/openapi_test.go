@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+type testBase struct {
+	ID string `json:"id" format:"uuid"`
+}
+
+type testItem struct {
+	testBase `json:",inline"`
+	Name     string            `json:"name,omitempty"`
+	Kind     string            `json:"kind" enum:"A,B"`
+	Tags     []string          `json:"tags,omitzero" enum:"x,y"`
+	Count    int               `json:"count,string"`
+	Size     int64             `json:"size"`
+	Ratio    float64           `json:"ratio"`
+	Enabled  bool              `json:"enabled"`
+	Created  time.Time         `json:"created"`
+	Expires  *time.Time        `json:"expires,omitempty"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Parent   *testItem         `json:"parent,omitempty"`
+	Secret   string            `json:"-"`
+	Untagged string
+	private  string
+}
+
+type testError struct {
+	Message string `json:"message"`
+}
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func TestGenerate_Schemas(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/items", noop)
+	doc, err := Generate(Spec{Endpoints: map[string]Endpoint{"POST /items": {Request: testItem{}}}}, r)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	want := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":       {Type: "string", Format: "uuid"},
+			"name":     {Type: "string"},
+			"kind":     {Type: "string", Enum: []string{"A", "B"}},
+			"tags":     {Type: "array", Items: &Schema{Type: "string", Enum: []string{"x", "y"}}},
+			"count":    {Type: "string"},
+			"size":     {Type: "integer", Format: "int64"},
+			"ratio":    {Type: "number", Format: "double"},
+			"enabled":  {Type: "boolean"},
+			"created":  {Type: "string", Format: "date-time"},
+			"expires":  {Type: "string", Format: "date-time"},
+			"raw":      {},
+			"data":     {Type: "string", Format: "byte"},
+			"labels":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"parent":   {Ref: "#/components/schemas/testItem"},
+			"Untagged": {Type: "string"},
+		},
+		Required: []string{"id", "kind", "count", "size", "ratio", "enabled", "created", "Untagged"},
+	}
+	if diff := cmp.Diff(want, doc.Components.Schemas["testItem"]); diff != "" {
+		t.Errorf("testItem schema mismatch (-want +got):\n%s", diff)
+	}
+	body := doc.Paths["/items"]["post"].RequestBody
+	if got := body.Content["application/json"].Schema.Ref; got != "#/components/schemas/testItem" {
+		t.Errorf("request body schema = %q, want a reference to testItem", got)
+	}
+}
+
+func TestGenerate_Operations(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/health", noop)
+	r.Route("/items", func(r chi.Router) {
+		r.Get("/", noop)
+		r.Delete("/{item_id}", noop)
+		r.Post("/{item_id:[0-9]+}/import", noop)
+	})
+	spec := Spec{
+		Title:   "Test API",
+		Version: "1.0.0",
+		Error:   testError{},
+		Endpoints: map[string]Endpoint{
+			"GET /items": {
+				ID:        "listItems",
+				Query:     []Param{{Name: "limit", Type: "integer", Description: "Page size."}},
+				Responses: map[int]any{http.StatusOK: []testItem{}},
+			},
+			"DELETE /items/{item_id}": {
+				ID:        "deleteItem",
+				Responses: map[int]any{http.StatusNoContent: nil},
+			},
+			"POST /items/{item_id:[0-9]+}/import": {
+				ID:          "importItem",
+				Request:     "",
+				RequestType: "text/csv",
+				Responses:   map[int]any{http.StatusOK: OneOf{testItem{}, testError{}}},
+			},
+		},
+	}
+
+	doc, err := Generate(spec, r)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	errContent := map[string]MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/testError"}}}
+	itemRef := &Schema{Ref: "#/components/schemas/testItem"}
+	want := map[string]PathItem{
+		"/items": {
+			"get": {
+				OperationID: "listItems",
+				Parameters:  []Parameter{{Name: "limit", In: "query", Description: "Page size.", Schema: &Schema{Type: "integer"}}},
+				Responses: map[string]*Response{
+					"200":     {Description: "OK", Content: map[string]MediaType{"application/json": {Schema: &Schema{Type: "array", Items: itemRef}}}},
+					"default": {Description: "Error", Content: errContent},
+				},
+			},
+		},
+		"/items/{item_id}": {
+			"delete": {
+				OperationID: "deleteItem",
+				Parameters:  []Parameter{{Name: "item_id", In: "path", Required: true, Schema: &Schema{Type: "string"}}},
+				Responses: map[string]*Response{
+					"204":     {Description: "No Content"},
+					"default": {Description: "Error", Content: errContent},
+				},
+			},
+		},
+		"/items/{item_id}/import": {
+			"post": {
+				OperationID: "importItem",
+				Parameters:  []Parameter{{Name: "item_id", In: "path", Required: true, Schema: &Schema{Type: "string"}}},
+				RequestBody: &RequestBody{Required: true, Content: map[string]MediaType{"text/csv": {Schema: &Schema{Type: "string"}}}},
+				Responses: map[string]*Response{
+					"200":     {Description: "OK", Content: map[string]MediaType{"application/json": {Schema: &Schema{OneOf: []*Schema{itemRef, {Ref: "#/components/schemas/testError"}}}}}},
+					"default": {Description: "Error", Content: errContent},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, doc.Paths); diff != "" {
+		t.Errorf("Paths mismatch (-want +got):\n%s", diff)
+	}
+	if doc.OpenAPI != Version || doc.Info != (Info{Title: "Test API", Version: "1.0.0"}) {
+		t.Errorf("Header = %q %+v, want %q with title and version of the spec", doc.OpenAPI, doc.Info, Version)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints map[string]Endpoint
+		wantErr   string
+	}{
+		{
+			name:      "unregistered endpoint",
+			endpoints: map[string]Endpoint{"PUT /items": {}},
+			wantErr:   `endpoint "PUT /items" is annotated but not registered`,
+		},
+		{
+			name:      "unsupported type",
+			endpoints: map[string]Endpoint{"GET /items": {Responses: map[int]any{http.StatusOK: make(chan int)}}},
+			wantErr:   "unsupported type chan int",
+		},
+		{
+			name: "format on struct field",
+			endpoints: map[string]Endpoint{"GET /items": {Responses: map[int]any{http.StatusOK: struct {
+				Item testItem `json:"item" format:"uri"`
+			}{}}}},
+			wantErr: "format and enum tags apply only to scalar types",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Get("/items", noop)
+			_, err := Generate(Spec{Endpoints: tc.endpoints}, r)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Generate() error = %v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDocument_ServeHTTP(t *testing.T) {
+	doc := &Document{OpenAPI: Version, Info: Info{Title: "Test API", Version: "1.0.0"}, Paths: map[string]PathItem{}}
+	rr := httptest.NewRecorder()
+	doc.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var got Document
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if got.OpenAPI != Version || got.Info.Title != "Test API" {
+		t.Errorf("ServeHTTP() = %+v, want %+v", got, doc)
+	}
+}
@@ -17,7 +17,6 @@ package cachingsecretskeymanager
 import (
 	"context"
 	"crypto/ecdh"
-	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -38,6 +37,8 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // Config Required for the module.
@@ -45,6 +46,11 @@ type Config struct {
 	ProjectID           string
 	SubscriberKeysCache bool
 	NetworkKeysCache    bool
+	// ReplicaLocations pins new secrets to these locations, e.g. "asia-south1", using
+	// user-managed replication. Secrets are replicated automatically when it is empty.
+	ReplicaLocations []string
+	// SigningAlgorithm is the algorithm of generated signing keys. Defaults to keyalgo.Default.
+	SigningAlgorithm keyalgo.Algorithm
 }
 
 type secretMgr interface {
@@ -62,6 +68,8 @@ type keyMgr struct {
 	cache                     plugin.Cache
 	subscriberKeysCache       bool
 	networkKeysCache          bool
+	replicaLocations          []string
+	signingAlgorithm keyalgo.Algorithm
 }
 
 // Constants for secret ID generation.
@@ -100,6 +108,8 @@ func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg
 		cache:                cache,
 		subscriberKeysCache:  cfg.SubscriberKeysCache,
 		networkKeysCache:     cfg.NetworkKeysCache,
+		replicaLocations:     cfg.ReplicaLocations,
+		signingAlgorithm: cfg.SigningAlgorithm,
 	}
 
 	return km, km.close, nil
@@ -108,7 +118,7 @@ func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg
 // GenerateKeyset generates new signing and encryption key pairs.
 func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 	// Generate Signing keys.
-	signingPublic, signingPrivate, err := ed25519.GenerateKey(rand.Reader)
+	signingPrivate, signingPublic, err := keyalgo.GenerateSigningKey(km.signingAlgorithm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate signing key pair: %w", err)
 	}
@@ -129,8 +139,8 @@ func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 
 	return &model.Keyset{
 		UniqueKeyID:    uuid.String(),
-		SigningPrivate: encodeBase64(signingPrivate.Seed()),
-		SigningPublic:  encodeBase64(signingPublic),
+		SigningPrivate: signingPrivate,
+		SigningPublic:  signingPublic,
 		EncrPrivate:    encodeBase64(encrPrivateKey.Bytes()),
 		EncrPublic:     encodeBase64(encrPublicKey),
 	}, nil
@@ -153,11 +163,7 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 		Parent:   fmt.Sprintf("projects/%s", km.projectID),
 		SecretId: secretID,
 		Secret: &secretmanagerpb.Secret{
-			Replication: &secretmanagerpb.Replication{
-				Replication: &secretmanagerpb.Replication_Automatic_{
-					Automatic: &secretmanagerpb.Replication_Automatic{},
-				},
-			},
+			Replication: km.replication(),
 		},
 	})
 
@@ -361,11 +367,41 @@ func (km *keyMgr) lookupRegistry(ctx context.Context, subscriberID, uniqueKeyID
 	}, nil
 }
 
+// replication returns the replication policy of new secrets.
+func (km *keyMgr) replication() *secretmanagerpb.Replication {
+	if len(km.replicaLocations) == 0 {
+		return &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{
+				Automatic: &secretmanagerpb.Replication_Automatic{},
+			},
+		}
+	}
+	replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(km.replicaLocations))
+	for _, location := range km.replicaLocations {
+		replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+	}
+	return &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_UserManaged_{
+			UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas},
+		},
+	}
+}
+
 // validateCfg validates the config.
 func validateCfg(cfg *Config) error {
 	if cfg.ProjectID == "" {
 		return ErrEmptyProjectID
 	}
+	seen := make(map[string]bool, len(cfg.ReplicaLocations))
+	for _, location := range cfg.ReplicaLocations {
+		if location == "" || seen[location] {
+			return fmt.Errorf("%w: %q", ErrInvalidReplicaLocation, location)
+		}
+		seen[location] = true
+	}
+	if _, err := keyalgo.Parse(string(cfg.SigningAlgorithm)); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 	return nil
 }
 
@@ -381,13 +417,14 @@ func validateParams(subscriberID, uniqueKeyID string) error {
 
 // Error definitions.
 var (
-	ErrEmptyProjectID     = errors.New("invalid config: projectID cannot be empty")
-	ErrNilCache           = errors.New("cache cannot be nil")
-	ErrNilKeySet          = errors.New("keyset cannot be nil")
-	ErrNilRegistryLookup  = errors.New("registry lookup cannot be nil")
-	ErrEmptySubscriberID  = errors.New("subscriberID cannot be empty")
-	ErrEmptyUniqueKeyID   = errors.New("uniqueKeyID cannot be empty")
-	ErrEmptyKeyID         = errors.New("keyID cannot be empty")
-	ErrSubscriberNotFound = errors.New("no subscriber found with given credentials")
+	ErrEmptyProjectID         = errors.New("invalid config: projectID cannot be empty")
+	ErrInvalidReplicaLocation = errors.New("invalid config: replica locations must be non-empty and unique")
+	ErrNilCache               = errors.New("cache cannot be nil")
+	ErrNilKeySet              = errors.New("keyset cannot be nil")
+	ErrNilRegistryLookup      = errors.New("registry lookup cannot be nil")
+	ErrEmptySubscriberID      = errors.New("subscriberID cannot be empty")
+	ErrEmptyUniqueKeyID       = errors.New("uniqueKeyID cannot be empty")
+	ErrEmptyKeyID             = errors.New("keyID cannot be empty")
+	ErrSubscriberNotFound     = errors.New("no subscriber found with given credentials")
 )
 
@@ -33,6 +33,8 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // mockSecretMgr implements the secretMgr interface for testing.
@@ -155,6 +157,36 @@ func TestNewErrors(t *testing.T) {
 			reg:     nil,
 			wantErr: ErrNilRegistryLookup,
 		},
+		{
+			name: "duplicate replica location",
+			cfg: &Config{
+				ProjectID:        "test-project",
+				ReplicaLocations: []string{"asia-south1", "asia-south1"},
+			},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: ErrInvalidReplicaLocation,
+		},
+		{
+			name: "empty replica location",
+			cfg: &Config{
+				ProjectID:        "test-project",
+				ReplicaLocations: []string{""},
+			},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: ErrInvalidReplicaLocation,
+		},
+		{
+			name: "unsupported signing algorithm",
+			cfg: &Config{
+				ProjectID:        "test-project",
+				SigningAlgorithm: "rsa",
+			},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: keyalgo.ErrUnsupported,
+		},
 	}
 
 	for _, tt := range tests {
@@ -195,6 +227,20 @@ func TestGenerateKeyset(t *testing.T) {
 	}
 }
 
+func TestGenerateKeyset_Secp256k1(t *testing.T) {
+	km := &keyMgr{signingAlgorithm: keyalgo.Secp256k1}
+	keyset, err := km.GenerateKeyset()
+	if err != nil {
+		t.Fatalf("GenerateKeyset() error = %v", err)
+	}
+	if alg, _ := keyalgo.PrivateKey(keyset.SigningPrivate); alg != keyalgo.Secp256k1 {
+		t.Errorf("GenerateKeyset() signing private key algorithm = %q, want %q", alg, keyalgo.Secp256k1)
+	}
+	if alg, err := keyalgo.PublicKey(keyset.SigningPublic); err != nil || alg != keyalgo.Secp256k1 {
+		t.Errorf("GenerateKeyset() signing public key algorithm = %q, %v, want %q", alg, err, keyalgo.Secp256k1)
+	}
+}
+
 func TestInsertKeyset(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -297,6 +343,56 @@ func TestInsertKeyset(t *testing.T) {
 	}
 }
 
+func TestInsertKeyset_Replication(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations []string
+		want      []string
+	}{
+		{
+			name: "automatic replication",
+		},
+		{
+			name:      "user managed replication",
+			locations: []string{"asia-south1", "asia-south2"},
+			want:      []string{"asia-south1", "asia-south2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *secretmanagerpb.Replication
+			mock := &mockSecretMgr{
+				createSecret: func(ctx context.Context, req *secretmanagerpb.CreateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+					got = req.GetSecret().GetReplication()
+					return &secretmanagerpb.Secret{}, nil
+				},
+				addSecretVersion: func(ctx context.Context, req *secretmanagerpb.AddSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+					return &secretmanagerpb.SecretVersion{}, nil
+				},
+			}
+			km := &keyMgr{projectID: "test-project", secretClient: mock, replicaLocations: tt.locations}
+
+			if err := km.InsertKeyset(context.Background(), "key1", &model.Keyset{}); err != nil {
+				t.Fatalf("InsertKeyset() error = %v", err)
+			}
+			if len(tt.want) == 0 {
+				if got.GetAutomatic() == nil {
+					t.Errorf("InsertKeyset() replication = %v, want automatic", got)
+				}
+				return
+			}
+			var locations []string
+			for _, replica := range got.GetUserManaged().GetReplicas() {
+				locations = append(locations, replica.GetLocation())
+			}
+			if strings.Join(locations, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("InsertKeyset() replica locations = %v, want %v", locations, tt.want)
+			}
+		})
+	}
+}
+
 func TestInsertKeysetErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -19,7 +19,9 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/cachingsecretskeymanager"
 
 	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition" // Plugin definitions will be imported from here.
@@ -81,8 +83,23 @@ func parseConfig(config map[string]string) (*keymgr.Config, error) {
 		ProjectID:           projectID,
 		SubscriberKeysCache: enableSubscriberKeysCache,
 		NetworkKeysCache:    enableNetworkKeysCache,
+		ReplicaLocations:    parseReplicaLocations(config),
+		SigningAlgorithm:    keyalgo.Algorithm(config["signingAlgorithm"]),
 	}, nil
 }
 
+// parseReplicaLocations splits a comma-separated list of replica locations.
+func parseReplicaLocations(config map[string]string) []string {
+	value, exists := config["replicaLocations"]
+	if !exists || strings.TrimSpace(value) == "" {
+		return nil
+	}
+	locations := strings.Split(value, ",")
+	for i, location := range locations {
+		locations[i] = strings.TrimSpace(location)
+	}
+	return locations
+}
+
 // Provider is the exported symbol that the plugin manager will look for.
 var Provider = keyMgrProvider{}
@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -43,6 +44,7 @@ func TestParseConfig(t *testing.T) {
 		wantProjectID               string
 		wantSubscriberKeysCache     bool
 		wantNetworkKeysCache        bool
+		wantReplicaLocations        []string
 	}{
 		{
 			name:                    "default no caching flags",
@@ -79,6 +81,12 @@ func TestParseConfig(t *testing.T) {
 			wantSubscriberKeysCache: false,
 			wantNetworkKeysCache:    true,
 		},
+		{
+			name:                 "replica locations",
+			config:               map[string]string{"projectID": "test-project", "replicaLocations": "asia-south1, asia-south2"},
+			wantProjectID:        "test-project",
+			wantReplicaLocations: []string{"asia-south1", "asia-south2"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -103,6 +111,9 @@ func TestParseConfig(t *testing.T) {
 			if got.NetworkKeysCache != tt.wantNetworkKeysCache {
 				t.Errorf("parseConfig() for %s got NetworkKeysCache = %t, want %t", tt.name, got.NetworkKeysCache, tt.wantNetworkKeysCache)
 			}
+			if !reflect.DeepEqual(got.ReplicaLocations, tt.wantReplicaLocations) {
+				t.Errorf("parseConfig() for %s got ReplicaLocations = %v, want %v", tt.name, got.ReplicaLocations, tt.wantReplicaLocations)
+			}
 		})
 	}
 }
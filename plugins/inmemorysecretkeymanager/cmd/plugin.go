@@ -19,7 +19,9 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 	// Import the new key manager package
 	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/inmemorysecretkeymanager"
 
@@ -77,12 +79,23 @@ func parseConfig(config map[string]string) (*keymgr.Config, error) {
 		publicKeyTTL = ttl
 	}
 
+	var recoveryWindow time.Duration
+	if windowStr, exists := config["softDeleteRecoveryWindow"]; exists {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid value for softDeleteRecoveryWindow: %q, must be a positive duration", windowStr)
+		}
+		recoveryWindow = window
+	}
+
 	return &keymgr.Config{
 		ProjectID: projectID,
 		CacheTTL: keymgr.CacheTTL{
 			PrivateKeysSeconds: privateKeyTTL,
 			PublicKeysSeconds:  publicKeyTTL,
 		},
+		SoftDeleteRecoveryWindow: recoveryWindow,
+		SigningAlgorithm:         keyalgo.Algorithm(config["signingAlgorithm"]),
 	}, nil
 }
 
@@ -43,6 +43,7 @@ func TestParseConfig(t *testing.T) {
 		wantProjectID     string
 		wantPrivateKeyTTL int
 		wantPublicKeyTTL  int
+		wantWindow        time.Duration
 	}{
 		{
 			name: "valid full config",
@@ -62,6 +63,14 @@ func TestParseConfig(t *testing.T) {
 			wantPrivateKeyTTL: 15,   // Default
 			wantPublicKeyTTL:  3600, // Default
 		},
+		{
+			name:              "valid config with soft delete",
+			config:            map[string]string{"projectID": "test-p", "softDeleteRecoveryWindow": "72h"},
+			wantProjectID:     "test-p",
+			wantPrivateKeyTTL: 15,
+			wantPublicKeyTTL:  3600,
+			wantWindow:        72 * time.Hour,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -79,6 +88,9 @@ func TestParseConfig(t *testing.T) {
 			if got.CacheTTL.PublicKeysSeconds != tc.wantPublicKeyTTL {
 				t.Errorf("got PublicKeysSeconds = %d, want %d", got.CacheTTL.PublicKeysSeconds, tc.wantPublicKeyTTL)
 			}
+			if got.SoftDeleteRecoveryWindow != tc.wantWindow {
+				t.Errorf("got SoftDeleteRecoveryWindow = %v, want %v", got.SoftDeleteRecoveryWindow, tc.wantWindow)
+			}
 		})
 	}
 }
@@ -109,6 +121,11 @@ func TestParseConfig_Errors(t *testing.T) {
 			config:  map[string]string{"projectID": "test-p", "privateKeyCacheTTLSeconds": "-10"},
 			wantErr: "must be a positive integer",
 		},
+		{
+			name:    "invalid soft delete recovery window",
+			config:  map[string]string{"projectID": "test-p", "softDeleteRecoveryWindow": "0s"},
+			wantErr: "must be a positive duration",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -17,13 +17,13 @@ package inmemorysecretkeymanager
 import (
 	"context"
 	"crypto/ecdh"
-	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"sync"
 	"time"
@@ -37,6 +37,10 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // Error definitions.
@@ -71,10 +75,17 @@ type fetchResult struct {
 type Config struct {
 	ProjectID string
 	CacheTTL  CacheTTL
+	// SoftDeleteRecoveryWindow enables soft delete when positive. DeleteKeyset then disables
+	// the secret version and schedules the secret for destruction after this window, during
+	// which UndeleteKeyset can recover it.
+	SoftDeleteRecoveryWindow time.Duration
+	// SigningAlgorithm is the algorithm of generated signing keys. Defaults to keyalgo.Default.
+	SigningAlgorithm keyalgo.Algorithm
 }
 
 // inMemoryCacheItem holds the cached data and its expiration time.
 type inMemoryCacheItem struct {
+	keyID     string
 	keyset    *model.Keyset
 	expiresAt time.Time
 }
@@ -96,11 +107,12 @@ func (c *inMemoryCache) Get(key string) (*model.Keyset, bool) {
 	return item.keyset, true
 }
 
-// Set adds an item to the cache with the configured TTL.
-func (c *inMemoryCache) Set(key string, keyset *model.Keyset) {
+// Set adds the keyset of keyID to the cache with the configured TTL.
+func (c *inMemoryCache) Set(key, keyID string, keyset *model.Keyset) {
 	c.Lock()
 	defer c.Unlock()
 	c.items[key] = inMemoryCacheItem{
+		keyID:     keyID,
 		keyset:    keyset,
 		expiresAt: time.Now().Add(c.ttl),
 	}
@@ -126,6 +138,10 @@ type secretMgr interface {
 	AddSecretVersion(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
 	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	GetSecretVersion(context.Context, *secretmanagerpb.GetSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	DisableSecretVersion(context.Context, *secretmanagerpb.DisableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	EnableSecretVersion(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	UpdateSecret(context.Context, *secretmanagerpb.UpdateSecretRequest, ...gax.CallOption) (*secretmanagerpb.Secret, error)
 	Close() error
 }
 
@@ -136,8 +152,10 @@ type keyMgr struct {
 	redisCache        plugin.Cache
 	inMemoryCache     *inMemoryCache
 	publicKeyCacheTTL time.Duration
+	recoveryWindow    time.Duration
 	requestMutex sync.Mutex
     requests     map[string]*inFlightRequest
+	signingAlgorithm keyalgo.Algorithm
 }
 
 // Constants for secret ID generation.
@@ -180,7 +198,9 @@ func newWithClient(redisCache plugin.Cache, registryLookup plugin.RegistryLookup
 		redisCache:        redisCache,
 		inMemoryCache:     inMemCache,
 		publicKeyCacheTTL: time.Duration(cfg.CacheTTL.PublicKeysSeconds) * time.Second,
+		recoveryWindow:    cfg.SoftDeleteRecoveryWindow,
 		requests:          make(map[string]*inFlightRequest),
+		signingAlgorithm: cfg.SigningAlgorithm,
 	}
 
 	return km, km.close, nil
@@ -192,7 +212,7 @@ func newWithClient(redisCache plugin.Cache, registryLookup plugin.RegistryLookup
 // generates new signing and encryption key pairs.
 func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 	// Generate Signing keys.
-	signingPublic, signingPrivate, err := ed25519.GenerateKey(rand.Reader)
+	signingPrivate, signingPublic, err := keyalgo.GenerateSigningKey(km.signingAlgorithm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate signing key pair: %w", err)
 	}
@@ -213,8 +233,8 @@ func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 
 	return &model.Keyset{
 		UniqueKeyID:    uuid.String(),
-		SigningPrivate: encodeBase64(signingPrivate.Seed()),
-		SigningPublic:  encodeBase64(signingPublic),
+		SigningPrivate: signingPrivate,
+		SigningPublic:  signingPublic,
 		EncrPrivate:    encodeBase64(encrPrivateKey.Bytes()),
 		EncrPublic:     encodeBase64(encrPublicKey),
 	}, nil
@@ -248,8 +268,10 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 	if err != nil {
 		// check for already exists error.
 		if status.Code(err) == codes.AlreadyExists {
-			// Delete existing secret with same keyID.
-			if err := km.DeleteKeyset(ctx, keyID); err != nil {
+			// Delete existing secret with same keyID. This is always a hard delete,
+			// as a soft deleted secret would still exist.
+			km.inMemoryCache.Delete(secretID)
+			if err := km.deleteSecret(ctx, secretName); err != nil {
 				return fmt.Errorf("failed to delete existing secret with same keyID: %w", err)
 			}
 
@@ -272,7 +294,7 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 	}
 
 	// Add to in-memory cache
-	km.inMemoryCache.Set(secretID, keyset)
+	km.inMemoryCache.Set(secretID, keyID, keyset)
 
 	return nil
 }
@@ -330,7 +352,8 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 
 	var fetchedKeyset *model.Keyset
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
+		// A soft deleted keyset has its version disabled, which fails with FailedPrecondition.
+		if c := status.Code(err); c == codes.NotFound || c == codes.FailedPrecondition {
 			err = model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found", keyID))
 		} else {
 			err = fmt.Errorf("failed to access secret version: %w", err)
@@ -340,7 +363,7 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 			err = fmt.Errorf("failed to unmarshal payload: %w", err)
 		} else {
 			// Step 7 (Leader): If unmarshaling is successful, populate the in-memory cache.
-			km.inMemoryCache.Set(secretID, fetchedKeyset)
+			km.inMemoryCache.Set(secretID, keyID, fetchedKeyset)
 		}
 	}
 
@@ -351,6 +374,7 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 }
 
 // DeleteKeyset deletes the private keys from the secret manager and the in-memory cache.
+// With soft delete enabled, the keys are disabled and destroyed after the recovery window instead.
 func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	if keyID == "" {
 		return model.NewBadReqErr(ErrEmptyKeyID)
@@ -363,6 +387,51 @@ func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	km.inMemoryCache.Delete(secretID)
 
 	// Then delete from secret manager.
+	if km.recoveryWindow > 0 {
+		return km.softDeleteSecret(ctx, secretName)
+	}
+	return km.deleteSecret(ctx, secretName)
+}
+
+// UndeleteKeyset recovers soft deleted private keys that have not yet been destroyed.
+func (km *keyMgr) UndeleteKeyset(ctx context.Context, keyID string) error {
+	if keyID == "" {
+		return model.NewBadReqErr(ErrEmptyKeyID)
+	}
+
+	secretID := generateSecretID(keyID)
+	secretName := fmt.Sprintf("projects/%s/secrets/%s", km.projectID, secretID)
+
+	version, err := km.secretClient.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: secretName + "/versions/latest",
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found or no longer recoverable", keyID))
+		}
+		return fmt.Errorf("failed to get secret version: %w", err)
+	}
+
+	// Cancel the scheduled destruction before re-enabling the keys.
+	if _, err := km.secretClient.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret:     &secretmanagerpb.Secret{Name: secretName},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"ttl"}},
+	}); err != nil {
+		return fmt.Errorf("failed to clear secret expiration: %w", err)
+	}
+	if version.GetState() == secretmanagerpb.SecretVersion_DISABLED {
+		if _, err := km.secretClient.EnableSecretVersion(ctx, &secretmanagerpb.EnableSecretVersionRequest{
+			Name: version.GetName(),
+		}); err != nil {
+			return fmt.Errorf("failed to enable secret version: %w", err)
+		}
+	}
+	slog.Info("UndeleteKeyset: keyset recovered", "keyID", keyID)
+	return nil
+}
+
+// deleteSecret permanently deletes a secret.
+func (km *keyMgr) deleteSecret(ctx context.Context, secretName string) error {
 	if err := km.secretClient.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{
 		Name: secretName,
 	}); err != nil {
@@ -371,6 +440,50 @@ func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	return nil
 }
 
+// softDeleteSecret disables the latest version of a secret and schedules the secret
+// for destruction after the recovery window.
+func (km *keyMgr) softDeleteSecret(ctx context.Context, secretName string) error {
+	version, err := km.secretClient.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: secretName + "/versions/latest",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get secret version: %w", err)
+	}
+	if version.GetState() == secretmanagerpb.SecretVersion_ENABLED {
+		if _, err := km.secretClient.DisableSecretVersion(ctx, &secretmanagerpb.DisableSecretVersionRequest{
+			Name: version.GetName(),
+		}); err != nil {
+			return fmt.Errorf("failed to disable secret version: %w", err)
+		}
+	}
+	if _, err := km.secretClient.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret: &secretmanagerpb.Secret{
+			Name:       secretName,
+			Expiration: &secretmanagerpb.Secret_Ttl{Ttl: durationpb.New(km.recoveryWindow)},
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"ttl"}},
+	}); err != nil {
+		return fmt.Errorf("failed to schedule secret destruction: %w", err)
+	}
+	slog.Info("DeleteKeyset: keyset soft deleted", "secret", secretName, "recoveryWindow", km.recoveryWindow)
+	return nil
+}
+
+// CachedKeysets returns the time at which each keyset cached in memory expires, by key ID.
+// Expired keysets that have not been read again since are left out.
+func (km *keyMgr) CachedKeysets() map[string]time.Time {
+	km.inMemoryCache.RLock()
+	defer km.inMemoryCache.RUnlock()
+	now := time.Now()
+	expiry := make(map[string]time.Time, len(km.inMemoryCache.items))
+	for _, item := range km.inMemoryCache.items {
+		if item.expiresAt.After(now) {
+			expiry[item.keyID] = item.expiresAt
+		}
+	}
+	return expiry
+}
+
 // LookupNPKeys fetches public keys from the Redis cache or registry.
 func (km *keyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
 	if err := validateParams(subscriberID, uniqueKeyID); err != nil {
@@ -410,6 +523,13 @@ func (km *keyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID st
 	return publicKeys.SigningPublic, publicKeys.EncrPublic, nil
 }
 
+// WipeKeys wipes the private keys cached in memory and clears the cache, so that they do not
+// outlive a shutdown of the service. Keysets read afterwards are fetched from Secret Manager
+// again. It returns the number of keysets wiped.
+func (km *keyMgr) WipeKeys() int {
+	return km.securelyWipeAndClearCache()
+}
+
 // close closes the connections.
 func (km *keyMgr) close() error {
 	km.securelyWipeAndClearCache()
@@ -470,6 +590,9 @@ func validateCfg(cfg *Config) error {
 	if cfg.CacheTTL.PrivateKeysSeconds <= 0 || cfg.CacheTTL.PublicKeysSeconds <= 0 {
 		return ErrInvalidTTL
 	}
+	if _, err := keyalgo.Parse(string(cfg.SigningAlgorithm)); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 	return nil
 }
 
@@ -511,16 +634,20 @@ func securelyWipeKeyset(ks *model.Keyset) {
 }
 
 // securelyWipeAndClearCache iterates through the cache, wipes each keyset, and clears the map.
-func (km *keyMgr) securelyWipeAndClearCache() {
+// It returns the number of keysets wiped.
+func (km *keyMgr) securelyWipeAndClearCache() int {
 	km.inMemoryCache.Lock()
 	defer km.inMemoryCache.Unlock()
 
+	wiped := 0
 	for key, item := range km.inMemoryCache.items {
 		if item.keyset != nil {
 			securelyWipeKeyset(item.keyset)
+			wiped++
 		}
 		delete(km.inMemoryCache.items, key)
 	}
+	return wiped
 }
 
 
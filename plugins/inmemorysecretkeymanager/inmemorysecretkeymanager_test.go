@@ -32,6 +32,8 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // --- Mocks ---
@@ -40,6 +42,8 @@ import (
 type mockSecretMgr struct {
 	mu                  sync.Mutex
 	secrets             map[string][]byte
+	disabled            map[string]bool
+	ttls                map[string]time.Duration
 	accessCallCount     int32
 	createCallCount     int32
 	deleteCallCount     int32
@@ -48,12 +52,15 @@ type mockSecretMgr struct {
 	addSecretVersionErr error
 	deleteSecretErr     error
 	accessSecretErr     error
+	updateSecretErr     error
 	closeErr            error
 }
 
 func newMockSecretMgr(latency time.Duration) *mockSecretMgr {
 	return &mockSecretMgr{
 		secrets:       make(map[string][]byte),
+		disabled:      make(map[string]bool),
+		ttls:          make(map[string]time.Duration),
 		accessLatency: latency,
 	}
 }
@@ -98,6 +105,8 @@ func (m *mockSecretMgr) DeleteSecret(ctx context.Context, req *secretmanagerpb.D
 		return m.deleteSecretErr
 	}
 	delete(m.secrets, req.Name+"/versions/latest")
+	delete(m.disabled, req.Name+"/versions/latest")
+	delete(m.ttls, req.Name)
 	return nil
 }
 
@@ -114,11 +123,55 @@ func (m *mockSecretMgr) AccessSecretVersion(ctx context.Context, req *secretmana
 	if !ok {
 		return nil, status.Errorf(codes.NotFound, "secret not found: %s", req.Name)
 	}
+	if m.disabled[req.Name] {
+		return nil, status.Errorf(codes.FailedPrecondition, "secret version is disabled: %s", req.Name)
+	}
 	return &secretmanagerpb.AccessSecretVersionResponse{
 		Payload: &secretmanagerpb.SecretPayload{Data: payload},
 	}, nil
 }
 
+func (m *mockSecretMgr) GetSecretVersion(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "secret not found: %s", req.Name)
+	}
+	state := secretmanagerpb.SecretVersion_ENABLED
+	if m.disabled[req.Name] {
+		state = secretmanagerpb.SecretVersion_DISABLED
+	}
+	return &secretmanagerpb.SecretVersion{Name: req.Name, State: state}, nil
+}
+
+func (m *mockSecretMgr) DisableSecretVersion(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.disabled[req.Name] = true
+	return &secretmanagerpb.SecretVersion{Name: req.Name}, nil
+}
+
+func (m *mockSecretMgr) EnableSecretVersion(ctx context.Context, req *secretmanagerpb.EnableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.disabled, req.Name)
+	return &secretmanagerpb.SecretVersion{Name: req.Name}, nil
+}
+
+func (m *mockSecretMgr) UpdateSecret(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.updateSecretErr != nil {
+		return nil, m.updateSecretErr
+	}
+	if ttl := req.Secret.GetTtl(); ttl != nil {
+		m.ttls[req.Secret.Name] = ttl.AsDuration()
+	} else {
+		delete(m.ttls, req.Secret.Name)
+	}
+	return req.Secret, nil
+}
+
 func (m *mockSecretMgr) Close() error { return m.closeErr }
 
 // mockCache implements the plugin.Cache interface for testing.
@@ -230,6 +283,7 @@ func TestNew_Errors(t *testing.T) {
 		{"empty project ID", &Config{CacheTTL: CacheTTL{1, 1}}, &mockRegistry{}, newMockCache(), ErrEmptyProjectID},
 		{"invalid private key TTL", &Config{ProjectID: "p", CacheTTL: CacheTTL{0, 1}}, &mockRegistry{}, newMockCache(), ErrInvalidTTL},
 		{"invalid public key TTL", &Config{ProjectID: "p", CacheTTL: CacheTTL{1, 0}}, &mockRegistry{}, newMockCache(), ErrInvalidTTL},
+		{"unsupported signing algorithm", &Config{ProjectID: "p", CacheTTL: CacheTTL{1, 1}, SigningAlgorithm: "rsa"}, &mockRegistry{}, newMockCache(), keyalgo.ErrUnsupported},
 	}
 	 for _, tc := range testCases {
         t.Run(tc.name, func(t *testing.T) {
@@ -253,6 +307,20 @@ func TestGenerateKeyset(t *testing.T) {
 	}
 }
 
+func TestGenerateKeyset_Secp256k1(t *testing.T) {
+	km := &keyMgr{signingAlgorithm: keyalgo.Secp256k1}
+	keyset, err := km.GenerateKeyset()
+	if err != nil {
+		t.Fatalf("GenerateKeyset() error = %v", err)
+	}
+	if alg, _ := keyalgo.PrivateKey(keyset.SigningPrivate); alg != keyalgo.Secp256k1 {
+		t.Errorf("GenerateKeyset() signing private key algorithm = %q, want %q", alg, keyalgo.Secp256k1)
+	}
+	if alg, err := keyalgo.PublicKey(keyset.SigningPublic); err != nil || alg != keyalgo.Secp256k1 {
+		t.Errorf("GenerateKeyset() signing public key algorithm = %q, %v, want %q", alg, err, keyalgo.Secp256k1)
+	}
+}
+
 func TestInsertKeyset(t *testing.T) {
 	ctx := context.Background()
 	keyID := "test-subscriber"
@@ -376,7 +444,7 @@ func TestKeyset(t *testing.T) {
 	t.Run("cache hit", func(t *testing.T) {
 		mockSM := newMockSecretMgr(0)
 		km := setupTestKeyManager(t, mockSM, nil, nil)
-		km.inMemoryCache.Set(secretID, keyset)
+		km.inMemoryCache.Set(secretID, keyID, keyset)
 
 		retrieved, err := km.Keyset(ctx, keyID)
 		if err != nil {
@@ -503,7 +571,7 @@ func TestDeleteKeyset(t *testing.T) {
 	secretID := generateSecretID(keyID)
 	mockSM := newMockSecretMgr(0)
 	km := setupTestKeyManager(t, mockSM, nil, nil)
-	km.inMemoryCache.Set(secretID, &model.Keyset{})
+	km.inMemoryCache.Set(secretID, keyID, &model.Keyset{})
 
 	err := km.DeleteKeyset(ctx, keyID)
 	if err != nil {
@@ -518,6 +586,98 @@ func TestDeleteKeyset(t *testing.T) {
 	}
 }
 
+func TestDeleteKeyset_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	keyID := "key-to-delete"
+	secretID := generateSecretID(keyID)
+	secretName := fmt.Sprintf("projects/test-project/secrets/%s", secretID)
+	mockSM := newMockSecretMgr(0)
+	km := setupTestKeyManager(t, mockSM, nil, nil)
+	km.recoveryWindow = 72 * time.Hour
+
+	if err := km.InsertKeyset(ctx, keyID, &model.Keyset{UniqueKeyID: "ukid"}); err != nil {
+		t.Fatalf("InsertKeyset() failed: %v", err)
+	}
+	if _, err := km.Keyset(ctx, keyID); err != nil {
+		t.Fatalf("Keyset() failed: %v", err)
+	}
+
+	if err := km.DeleteKeyset(ctx, keyID); err != nil {
+		t.Fatalf("DeleteKeyset() failed: %v", err)
+	}
+	if atomic.LoadInt32(&mockSM.deleteCallCount) != 0 {
+		t.Error("DeleteSecret was called for a soft delete")
+	}
+	if got := mockSM.ttls[secretName]; got != 72*time.Hour {
+		t.Errorf("secret ttl = %v, want %v", got, 72*time.Hour)
+	}
+	if _, err := km.Keyset(ctx, keyID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Keyset() after soft delete error = %v, want not found", err)
+	}
+
+	if err := km.UndeleteKeyset(ctx, keyID); err != nil {
+		t.Fatalf("UndeleteKeyset() failed: %v", err)
+	}
+	if _, ok := mockSM.ttls[secretName]; ok {
+		t.Error("secret ttl was not cleared by UndeleteKeyset")
+	}
+	got, err := km.Keyset(ctx, keyID)
+	if err != nil {
+		t.Fatalf("Keyset() after undelete failed: %v", err)
+	}
+	if got.UniqueKeyID != "ukid" {
+		t.Errorf("Keyset() UniqueKeyID = %q, want %q", got.UniqueKeyID, "ukid")
+	}
+}
+
+func TestInsertKeyset_SoftDeleteReplacesExisting(t *testing.T) {
+	mockSM := newMockSecretMgr(0)
+	mockSM.createSecretErr = status.Error(codes.AlreadyExists, "already exists")
+	km := setupTestKeyManager(t, mockSM, nil, nil)
+	km.recoveryWindow = time.Hour
+
+	if err := km.InsertKeyset(context.Background(), "key1", &model.Keyset{}); err != nil {
+		t.Fatalf("InsertKeyset() failed: %v", err)
+	}
+	if atomic.LoadInt32(&mockSM.deleteCallCount) != 1 {
+		t.Error("existing secret was not hard deleted")
+	}
+}
+
+func TestUndeleteKeyset_Errors(t *testing.T) {
+	testCases := []struct {
+		name      string
+		keyID     string
+		setupMock func(*mockSecretMgr)
+		wantErr   string
+	}{
+		{"empty keyID", "", nil, ErrEmptyKeyID.Error()},
+		{"secret destroyed", "key1", nil, "no longer recoverable"},
+		{
+			"update fails", "key1",
+			func(m *mockSecretMgr) {
+				m.secrets[fmt.Sprintf("projects/test-project/secrets/%s/versions/latest", generateSecretID("key1"))] = []byte("{}")
+				m.updateSecretErr = errors.New("update failed")
+			},
+			"failed to clear secret expiration",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSM := newMockSecretMgr(0)
+			if tc.setupMock != nil {
+				tc.setupMock(mockSM)
+			}
+			km := setupTestKeyManager(t, mockSM, nil, nil)
+			err := km.UndeleteKeyset(context.Background(), tc.keyID)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestDeleteKeyset_Errors(t *testing.T) {
 	ctx := context.Background()
 	keyID := "key-to-delete"
@@ -668,7 +828,7 @@ func TestClose_Success(t *testing.T) {
 	mockSM := newMockSecretMgr(0)
 	mockSM.closeErr = nil
 	km := setupTestKeyManager(t, mockSM, nil, nil)
-	km.inMemoryCache.Set("some-key", &model.Keyset{SigningPrivate: base64.StdEncoding.EncodeToString([]byte("secret"))})
+	km.inMemoryCache.Set("some-key", "some-key", &model.Keyset{SigningPrivate: base64.StdEncoding.EncodeToString([]byte("secret"))})
 
 	err := km.close()
 	if err != nil {
@@ -690,11 +850,52 @@ func TestClose_Error(t *testing.T) {
 	}
 }
 
+func TestWipeKeys(t *testing.T) {
+	ctx := context.Background()
+	keyID := "test-subscriber"
+	secretID := generateSecretID(keyID)
+	payload, _ := json.Marshal(&model.Keyset{UniqueKeyID: "test-key-456", SigningPrivate: "c2lnbmluZw=="})
+	mockSM := newMockSecretMgr(0)
+	mockSM.secrets[fmt.Sprintf("projects/test-project/secrets/%s/versions/latest", secretID)] = payload
+	km := setupTestKeyManager(t, mockSM, nil, nil)
+	cached := &model.Keyset{
+		UniqueKeyID:    "test-key-456",
+		SigningPrivate: base64.StdEncoding.EncodeToString([]byte("secret1")),
+		EncrPrivate:    base64.StdEncoding.EncodeToString([]byte("secret2")),
+	}
+	km.inMemoryCache.Set(secretID, keyID, cached)
+
+	if got := km.WipeKeys(); got != 1 {
+		t.Errorf("WipeKeys() = %d, want 1", got)
+	}
+	if cached.SigningPrivate != "" || cached.EncrPrivate != "" {
+		t.Error("cached keyset private fields were not cleared by WipeKeys()")
+	}
+	if len(km.inMemoryCache.items) != 0 {
+		t.Error("in-memory cache was not cleared by WipeKeys()")
+	}
+
+	// The key manager stays usable and fetches the keyset again.
+	got, err := km.Keyset(ctx, keyID)
+	if err != nil {
+		t.Fatalf("Keyset() after WipeKeys() failed: %v", err)
+	}
+	if got.SigningPrivate != "c2lnbmluZw==" {
+		t.Errorf("Keyset() after WipeKeys() SigningPrivate = %q, want the keyset from Secret Manager", got.SigningPrivate)
+	}
+	if n := atomic.LoadInt32(&mockSM.accessCallCount); n != 1 {
+		t.Errorf("AccessSecretVersion calls = %d, want 1", n)
+	}
+	if got := km.WipeKeys(); got != 1 {
+		t.Errorf("WipeKeys() after reload = %d, want 1", got)
+	}
+}
+
 func TestInMemoryCache(t *testing.T) {
 	t.Run("get and set", func(t *testing.T) {
 		cache := &inMemoryCache{items: make(map[string]inMemoryCacheItem), ttl: time.Minute}
 		keyset := &model.Keyset{UniqueKeyID: "1"}
-		cache.Set("key1", keyset)
+		cache.Set("key1", "key1", keyset)
 		retrieved, found := cache.Get("key1")
 		if !found {
 			t.Fatal("expected to find item in cache")
@@ -706,7 +907,7 @@ func TestInMemoryCache(t *testing.T) {
 
 	t.Run("item expires", func(t *testing.T) {
 		cache := &inMemoryCache{items: make(map[string]inMemoryCacheItem), ttl: 10 * time.Millisecond}
-		cache.Set("key1", &model.Keyset{})
+		cache.Set("key1", "key1", &model.Keyset{})
 		time.Sleep(20 * time.Millisecond)
 		_, found := cache.Get("key1")
 		if found {
@@ -716,7 +917,7 @@ func TestInMemoryCache(t *testing.T) {
 
 	t.Run("delete", func(t *testing.T) {
 		cache := &inMemoryCache{items: make(map[string]inMemoryCacheItem), ttl: time.Minute}
-		cache.Set("key1", &model.Keyset{})
+		cache.Set("key1", "key1", &model.Keyset{})
 		cache.Delete("key1")
 		_, found := cache.Get("key1")
 		if found {
@@ -725,6 +926,20 @@ func TestInMemoryCache(t *testing.T) {
 	})
 }
 
+func TestCachedKeysets(t *testing.T) {
+	km := setupTestKeyManager(t, nil, nil, nil)
+	km.inMemoryCache.Set(generateSecretID("bap.example.com"), "bap.example.com", &model.Keyset{})
+	km.inMemoryCache.items["expired"] = inMemoryCacheItem{keyID: "expired.example.com", expiresAt: time.Now().Add(-time.Second)}
+
+	got := km.CachedKeysets()
+	if len(got) != 1 {
+		t.Fatalf("CachedKeysets() = %v, want only the unexpired keyset", got)
+	}
+	if ttl := time.Until(got["bap.example.com"]); ttl <= 0 || ttl > km.inMemoryCache.ttl {
+		t.Errorf("CachedKeysets() TTL of bap.example.com = %v, want within (0, %v]", ttl, km.inMemoryCache.ttl)
+	}
+}
+
 func TestSecurelyWipeKeyset(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -836,6 +1051,18 @@ func (m *mockBenchSecretMgr) AddSecretVersion(context.Context, *secretmanagerpb.
 func (m *mockBenchSecretMgr) DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error {
 	return nil 
 }
+func (m *mockBenchSecretMgr) GetSecretVersion(context.Context, *secretmanagerpb.GetSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return nil, nil
+}
+func (m *mockBenchSecretMgr) DisableSecretVersion(context.Context, *secretmanagerpb.DisableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return nil, nil
+}
+func (m *mockBenchSecretMgr) EnableSecretVersion(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return nil, nil
+}
+func (m *mockBenchSecretMgr) UpdateSecret(context.Context, *secretmanagerpb.UpdateSecretRequest, ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+	return nil, nil
+}
 func (m *mockBenchSecretMgr) Close() error {
 	return nil 
 }
@@ -886,7 +1113,7 @@ func BenchmarkKeyset_CacheHit(b *testing.B) {
 	testKeyID := "my-cached-key"
 	secretID := generateSecretID(testKeyID)
 	keyset := &model.Keyset{UniqueKeyID: "123"}
-	km.inMemoryCache.Set(secretID, keyset)
+	km.inMemoryCache.Set(secretID, testKeyID, keyset)
 
 	b.ResetTimer()
 	b.ReportAllocs()
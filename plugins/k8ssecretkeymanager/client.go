@@ -0,0 +1,222 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8ssecretkeymanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Paths of the service account credentials mounted into every pod.
+const (
+	serviceAccountDir      = "/var/run/secrets/kubernetes.io/serviceaccount"
+	defaultTokenFile       = serviceAccountDir + "/token"
+	defaultCAFile          = serviceAccountDir + "/ca.crt"
+	defaultNamespaceFile   = serviceAccountDir + "/namespace"
+	defaultRequestTimeout  = 10 * time.Second
+	maxErrorResponseLength = 4 << 10
+)
+
+// objectMeta holds the metadata of a Kubernetes object used by the key manager.
+type objectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// secret is a Kubernetes Secret. Data values are base64 encoded in JSON, as by the API.
+type secret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   objectMeta        `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+}
+
+// statusError is a failure reported by the Kubernetes API as a Status object.
+type statusError struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("kubernetes API returned %d %s: %s", e.Code, e.Reason, e.Message)
+}
+
+// isStatus reports whether err is a statusError with the HTTP status code.
+func isStatus(err error, code int) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.Code == code
+}
+
+// secretClient reads and writes the Secrets of one namespace through the Kubernetes REST API.
+type secretClient struct {
+	baseURL   string
+	namespace string
+	tokenFile string // Empty if requests are not authenticated.
+	client    *http.Client
+}
+
+// newSecretClient creates a client for the API server and namespace of cfg, defaulting
+// to the in-cluster configuration of the pod's service account.
+func newSecretClient(cfg *Config) (*secretClient, error) {
+	baseURL := cfg.APIServer
+	if baseURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, ErrNotInCluster
+		}
+		baseURL = "https://" + net.JoinHostPort(host, port)
+	}
+	namespace := cfg.Namespace
+	if namespace == "" {
+		b, err := os.ReadFile(defaultNamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(b))
+	}
+	// Outside the cluster, e.g. through kubectl proxy, requests are only authenticated
+	// and the API server verified if a token or CA file is configured.
+	tokenFile, caFile := cfg.TokenFile, cfg.CAFile
+	if cfg.APIServer == "" {
+		tokenFile, caFile = defaultTokenFile, defaultCAFile
+		if cfg.TokenFile != "" {
+			tokenFile = cfg.TokenFile
+		}
+		if cfg.CAFile != "" {
+			caFile = cfg.CAFile
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API server CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in API server CA file %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &secretClient{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		namespace: namespace,
+		tokenFile: tokenFile,
+		client:    &http.Client{Transport: transport, Timeout: defaultRequestTimeout},
+	}, nil
+}
+
+// Get returns the secret with the name.
+func (c *secretClient) Get(ctx context.Context, name string) (*secret, error) {
+	var s secret
+	if err := c.do(ctx, http.MethodGet, c.url(name), nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create creates the secret.
+func (c *secretClient) Create(ctx context.Context, s *secret) error {
+	return c.do(ctx, http.MethodPost, c.url(""), s, nil)
+}
+
+// Update replaces the secret. It fails with 409 Conflict if the secret was changed since
+// the resource version in its metadata was read.
+func (c *secretClient) Update(ctx context.Context, s *secret) error {
+	return c.do(ctx, http.MethodPut, c.url(s.Metadata.Name), s, nil)
+}
+
+// Delete deletes the secret with the name.
+func (c *secretClient) Delete(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, c.url(name), nil, nil)
+}
+
+// url returns the URL of the secret with the name, or of the secrets collection if name is empty.
+func (c *secretClient) url(name string) string {
+	u := c.baseURL + "/api/v1/namespaces/" + url.PathEscape(c.namespace) + "/secrets"
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	return u
+}
+
+// do sends a request with the JSON encoding of in, if any, and decodes the response into out, if any.
+func (c *secretClient) do(ctx context.Context, method, u string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokenFile != "" {
+		// The token is read on every request, as the kubelet rotates projected service account tokens.
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read service account token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorResponseLength))
+		se := &statusError{}
+		if json.Unmarshal(b, se) != nil || se.Message == "" {
+			se.Message = strings.TrimSpace(string(b))
+		}
+		se.Code = resp.StatusCode
+		if se.Reason == "" {
+			se.Reason = http.StatusText(resp.StatusCode)
+		}
+		return se
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode kubernetes API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8ssecretkeymanager
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewSecretClient(t *testing.T) {
+	t.Run("in cluster", func(t *testing.T) {
+		t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+		t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+		c, err := newSecretClient(&Config{Namespace: "onix", CAFile: writeFile(t, "ca.crt", testCAPEM(t))})
+		if err != nil {
+			t.Fatalf("newSecretClient() error = %v", err)
+		}
+		if c.baseURL != "https://10.0.0.1:443" || c.tokenFile != defaultTokenFile {
+			t.Errorf("newSecretClient() = %q with token %q, want in-cluster defaults", c.baseURL, c.tokenFile)
+		}
+		if got, want := c.url("key"), "https://10.0.0.1:443/api/v1/namespaces/onix/secrets/key"; got != want {
+			t.Errorf("url() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("API server without token", func(t *testing.T) {
+		c, err := newSecretClient(&Config{APIServer: "http://127.0.0.1:8001/", Namespace: "onix"})
+		if err != nil {
+			t.Fatalf("newSecretClient() error = %v", err)
+		}
+		if c.baseURL != "http://127.0.0.1:8001" || c.tokenFile != "" {
+			t.Errorf("newSecretClient() = %q with token %q, want unauthenticated client", c.baseURL, c.tokenFile)
+		}
+	})
+}
+
+func TestNewSecretClientErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		cfg         *Config
+		errContains string
+	}{
+		{name: "not in cluster", env: map[string]string{"KUBERNETES_SERVICE_HOST": ""}, cfg: &Config{Namespace: "onix"}, errContains: ErrNotInCluster.Error()},
+		{name: "missing namespace file", cfg: &Config{APIServer: "https://localhost"}, errContains: "failed to read service account namespace"},
+		{name: "missing CA file", cfg: &Config{APIServer: "https://localhost", Namespace: "onix", CAFile: "/nonexistent/ca.crt"}, errContains: "failed to read API server CA"},
+		{name: "invalid CA file", cfg: &Config{APIServer: "https://localhost", Namespace: "onix", CAFile: writeFile(t, "bad.crt", []byte("not a certificate"))}, errContains: "no certificates found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := newSecretClient(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("newSecretClient() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestSecretClient_TLS(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"key","resourceVersion":"7"},"data":{"uniqueKeyID":"a2V5LTE="}}`))
+	}))
+	defer srv.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	c, err := newSecretClient(&Config{
+		APIServer: srv.URL,
+		Namespace: "onix",
+		TokenFile: writeFile(t, "token", []byte("rotated-token\n")),
+		CAFile:    writeFile(t, "ca.crt", ca),
+	})
+	if err != nil {
+		t.Fatalf("newSecretClient() error = %v", err)
+	}
+	s, err := c.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if s.Metadata.ResourceVersion != "7" || string(s.Data["uniqueKeyID"]) != "key-1" {
+		t.Errorf("Get() = %+v, want the decoded secret", s)
+	}
+	if gotAuth != "Bearer rotated-token" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer rotated-token")
+	}
+}
+
+func TestSecretClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/onix/secrets/plain":
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		case "/api/v1/namespaces/onix/secrets/invalid":
+			w.Write([]byte("{"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"kind":"Status","code":403,"reason":"Forbidden","message":"secrets \"x\" is forbidden"}`))
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c, err := newSecretClient(&Config{APIServer: srv.URL, Namespace: "onix"})
+	if err != nil {
+		t.Fatalf("newSecretClient() error = %v", err)
+	}
+	_, err = c.Get(ctx, "x")
+	var se *statusError
+	if !errors.As(err, &se) || se.Code != http.StatusForbidden || se.Reason != "Forbidden" || se.Message != `secrets "x" is forbidden` {
+		t.Errorf("Get() error = %v, want the decoded Status", err)
+	}
+	if _, err := c.Get(ctx, "plain"); !isStatus(err, http.StatusBadGateway) || !strings.Contains(err.Error(), "502 Bad Gateway: upstream unavailable") {
+		t.Errorf("Get() error = %v, want 502 error with the response body", err)
+	}
+	if _, err := c.Get(ctx, "invalid"); err == nil || !strings.Contains(err.Error(), "failed to decode kubernetes API response") {
+		t.Errorf("Get() error = %v, want decode error", err)
+	}
+
+	c.tokenFile = filepath.Join(t.TempDir(), "missing")
+	if err := c.Delete(ctx, "x"); err == nil || !strings.Contains(err.Error(), "failed to read service account token") {
+		t.Errorf("Delete() error = %v, want token error", err)
+	}
+}
+
+// writeFile writes data to a file in a temporary directory and returns its path.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testCAPEM returns a PEM encoded certificate.
+func testCAPEM(t *testing.T) []byte {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+}
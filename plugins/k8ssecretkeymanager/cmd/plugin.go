@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/k8ssecretkeymanager"
+
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition" // Plugin definitions will be imported from here.
+)
+
+var newKeyManager = func(ctx context.Context, cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg *keymgr.Config) (plugin.KeyManager, func() error, error) {
+	return keymgr.New(ctx, cache, registryLookup, cfg)
+}
+
+// keyMgrProvider implements the KeyManagerProvider interface.
+type keyMgrProvider struct{}
+
+// New creates a new KeyManager instance.
+func (kp keyMgrProvider) New(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, config map[string]string) (plugin.KeyManager, func() error, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return newKeyManager(ctx, cache, registry, cfg)
+}
+
+// parseConfig converts the map[string]string to the keyManager.Config struct.
+// All keys are optional; inside a cluster, the pod's service account is used by default.
+func parseConfig(config map[string]string) (*keymgr.Config, error) {
+	for key := range config {
+		switch key {
+		case "apiServer", "namespace", "nameTemplate", "tokenFile", "caFile", "signingAlgorithm":
+		default:
+			return nil, fmt.Errorf("unknown config key %q", key)
+		}
+	}
+	return &keymgr.Config{
+		APIServer:        config["apiServer"],
+		Namespace:        config["namespace"],
+		NameTemplate:     config["nameTemplate"],
+		TokenFile:        config["tokenFile"],
+		CAFile:           config["caFile"],
+		SigningAlgorithm: keyalgo.Algorithm(config["signingAlgorithm"]),
+	}, nil
+}
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = keyMgrProvider{}
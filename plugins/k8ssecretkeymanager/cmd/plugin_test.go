@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/k8ssecretkeymanager"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+)
+
+// mockKeyManager is a fake KeyManager that does nothing.
+type mockKeyManager struct{}
+
+func (m *mockKeyManager) GenerateKeyset() (*model.Keyset, error)                    { return nil, nil }
+func (m *mockKeyManager) InsertKeyset(context.Context, string, *model.Keyset) error { return nil }
+func (m *mockKeyManager) Keyset(context.Context, string) (*model.Keyset, error)     { return nil, nil }
+func (m *mockKeyManager) DeleteKeyset(context.Context, string) error                { return nil }
+func (m *mockKeyManager) LookupNPKeys(context.Context, string, string) (string, string, error) {
+	return "", "", nil
+}
+
+func TestParseConfig(t *testing.T) {
+	t.Run("empty config", func(t *testing.T) {
+		got, err := parseConfig(map[string]string{})
+		if err != nil {
+			t.Fatalf("parseConfig() error = %v", err)
+		}
+		if *got != (keymgr.Config{}) {
+			t.Errorf("parseConfig() = %+v, want zero config", got)
+		}
+	})
+
+	t.Run("all keys", func(t *testing.T) {
+		config := map[string]string{
+			"apiServer":        "https://127.0.0.1:6443",
+			"namespace":        "onix",
+			"nameTemplate":     "keys-{keyID}",
+			"tokenFile":        "/tmp/token",
+			"caFile":           "/tmp/ca.crt",
+			"signingAlgorithm": "secp256k1",
+		}
+		want := keymgr.Config{
+			APIServer:        "https://127.0.0.1:6443",
+			Namespace:        "onix",
+			NameTemplate:     "keys-{keyID}",
+			TokenFile:        "/tmp/token",
+			CAFile:           "/tmp/ca.crt",
+			SigningAlgorithm: keyalgo.Secp256k1,
+		}
+		got, err := parseConfig(config)
+		if err != nil {
+			t.Fatalf("parseConfig() error = %v", err)
+		}
+		if *got != want {
+			t.Errorf("parseConfig() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := parseConfig(map[string]string{"projectID": "test-project"})
+		if err == nil || !strings.Contains(err.Error(), `unknown config key "projectID"`) {
+			t.Errorf("parseConfig() error = %v, want unknown config key error", err)
+		}
+	})
+}
+
+func TestKeyMgrProviderNew(t *testing.T) {
+	originalNewKeyManager := newKeyManager
+	defer func() { newKeyManager = originalNewKeyManager }()
+
+	var gotCfg *keymgr.Config
+	newKeyManager = func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *keymgr.Config) (plugin.KeyManager, func() error, error) {
+		gotCfg = cfg
+		return &mockKeyManager{}, func() error { return nil }, nil
+	}
+	km, cleanup, err := keyMgrProvider{}.New(context.Background(), nil, nil, map[string]string{"namespace": "onix"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if km == nil || cleanup == nil {
+		t.Fatal("New() returned nil key manager or cleanup function")
+	}
+	if gotCfg.Namespace != "onix" {
+		t.Errorf("New() namespace = %q, want %q", gotCfg.Namespace, "onix")
+	}
+}
+
+func TestKeyMgrProviderNewErrors(t *testing.T) {
+	originalNewKeyManager := newKeyManager
+	defer func() { newKeyManager = originalNewKeyManager }()
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, _, err := keyMgrProvider{}.New(context.Background(), nil, nil, map[string]string{"invalid": "test"})
+		if err == nil || !strings.Contains(err.Error(), "invalid config") {
+			t.Errorf("New() error = %v, want invalid config error", err)
+		}
+	})
+
+	t.Run("key manager fails", func(t *testing.T) {
+		newKeyManager = func(ctx context.Context, cache plugin.Cache, registry plugin.RegistryLookup, cfg *keymgr.Config) (plugin.KeyManager, func() error, error) {
+			return nil, nil, errors.New("not in cluster")
+		}
+		_, _, err := keyMgrProvider{}.New(context.Background(), nil, nil, map[string]string{})
+		if err == nil || !strings.Contains(err.Error(), "not in cluster") {
+			t.Errorf("New() error = %v, want key manager error", err)
+		}
+	})
+}
@@ -0,0 +1,420 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8ssecretkeymanager implements a key manager that stores keysets as
+// Kubernetes Secrets, for deployments that manage key material through GitOps or
+// external-secrets rather than a cloud secret manager.
+package k8ssecretkeymanager
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition"
+
+	"github.com/google/uuid"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+)
+
+// Config Required for the module.
+type Config struct {
+	// APIServer is the URL of the Kubernetes API server. Defaults to the in-cluster address
+	// of the API server, authenticated with the pod's service account.
+	APIServer string
+	// Namespace holds the secrets. Defaults to the namespace of the pod's service account.
+	Namespace string
+	// NameTemplate is the name of the secret of a key ID, in which "{keyID}" is replaced with
+	// the key ID. Defaults to "onix-keyset-{keyID}".
+	NameTemplate string
+	// TokenFile holds the bearer token of requests. Defaults to the service account token
+	// in the cluster; requests to an APIServer are not authenticated without it.
+	TokenFile string
+	// CAFile holds the CA certificates that verify the API server. Defaults to the service
+	// account CA in the cluster, and to the system roots for an APIServer.
+	CAFile string
+	// SigningAlgorithm is the algorithm of generated signing keys. Defaults to keyalgo.Default.
+	SigningAlgorithm keyalgo.Algorithm
+}
+
+type secretAPI interface {
+	Get(ctx context.Context, name string) (*secret, error)
+	Create(ctx context.Context, s *secret) error
+	Update(ctx context.Context, s *secret) error
+	Delete(ctx context.Context, name string) error
+}
+
+type keyMgr struct {
+	secrets          secretAPI
+	registry         plugin.RegistryLookup
+	cache            plugin.Cache
+	namePrefix       string
+	nameSuffix       string
+	signingAlgorithm keyalgo.Algorithm
+}
+
+// Constants for secret names and data.
+const (
+	keyIDPlaceholder    = "{keyID}"
+	defaultNameTemplate = "onix-keyset-" + keyIDPlaceholder
+	maxSecretNameLen    = 253
+	nameHashLen         = 10 // Hex characters of the SHA-256 of a key ID appended to altered names.
+	managedByLabel      = "app.kubernetes.io/managed-by"
+	managedByValue      = "onix"
+	keyIDAnnotation     = "onix.beckn.org/key-id"
+
+	// Keys of the secret data, one per keyset field, so that external secret stores can map them.
+	dataSubscriberID   = "subscriberID"
+	dataUniqueKeyID    = "uniqueKeyID"
+	dataSigningPrivate = "signingPrivateKey"
+	dataSigningPublic  = "signingPublicKey"
+	dataEncrPrivate    = "encrPrivateKey"
+	dataEncrPublic     = "encrPublicKey"
+)
+
+var (
+	// invalidNameChars matches characters that may not appear in a secret name.
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+	// dotRuns matches runs of separators that hold a dot, each of which becomes a single dot.
+	dotRuns = regexp.MustCompile(`[.-]*\.[.-]*`)
+	// validSecretName matches a DNS subdomain, the format of secret names.
+	validSecretName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// New method creates a new KeyManager instance.
+func New(ctx context.Context, cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg *Config) (*keyMgr, func() error, error) {
+	if cfg == nil {
+		return nil, nil, ErrNilConfig
+	}
+	client, err := newSecretClient(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubernetes secret client: %w", err)
+	}
+	// Call the internal, testable constructor.
+	return newWithClient(cache, registryLookup, cfg, client)
+}
+
+// newWithClient is an internal constructor that accepts a secret API client interface.
+func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg *Config, client secretAPI) (*keyMgr, func() error, error) {
+	if err := validateCfg(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	if cache == nil {
+		return nil, nil, ErrNilCache
+	}
+
+	if registryLookup == nil {
+		return nil, nil, ErrNilRegistryLookup
+	}
+
+	template := cfg.NameTemplate
+	if template == "" {
+		template = defaultNameTemplate
+	}
+	prefix, suffix, _ := strings.Cut(template, keyIDPlaceholder)
+	km := &keyMgr{
+		secrets:          client,
+		registry:         registryLookup,
+		cache:            cache,
+		namePrefix:       prefix,
+		nameSuffix:       suffix,
+		signingAlgorithm: cfg.SigningAlgorithm,
+	}
+
+	return km, km.close, nil
+}
+
+// GenerateKeyset generates new signing and encryption key pairs.
+func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
+	// Generate Signing keys.
+	signingPrivate, signingPublic, err := keyalgo.GenerateSigningKey(km.signingAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key pair: %w", err)
+	}
+
+	// Generate x25519 Keys.
+	encrPrivateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key pair: %w", err)
+	}
+
+	// Generate uuid for UniqueKeyID.
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate unique key id uuid: %w", err)
+	}
+
+	return &model.Keyset{
+		UniqueKeyID:    uuid.String(),
+		SigningPrivate: signingPrivate,
+		SigningPublic:  signingPublic,
+		EncrPrivate:    base64.StdEncoding.EncodeToString(encrPrivateKey.Bytes()),
+		EncrPublic:     base64.StdEncoding.EncodeToString(encrPrivateKey.PublicKey().Bytes()),
+	}, nil
+}
+
+// InsertKeyset stores the keyset in the secret of keyID, replacing the keys of an existing secret.
+// The labels and annotations of an existing secret are kept.
+func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
+	if keyID == "" {
+		return model.NewBadReqErr(ErrEmptyKeyID)
+	}
+	if keyset == nil {
+		return model.NewBadReqErr(ErrNilKeySet)
+	}
+
+	name := km.secretName(keyID)
+	err := km.secrets.Create(ctx, &secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: objectMeta{
+			Name:        name,
+			Labels:      map[string]string{managedByLabel: managedByValue},
+			Annotations: map[string]string{keyIDAnnotation: keyID},
+		},
+		Type: "Opaque",
+		Data: secretData(keyset),
+	})
+	if err == nil {
+		return nil
+	}
+	if !isStatus(err, http.StatusConflict) {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	// The secret already exists, so replace its data. The update is conditional on the
+	// resource version read, so a concurrent change fails instead of being overwritten.
+	existing, err := km.secrets.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get existing secret: %w", err)
+	}
+	existing.APIVersion, existing.Kind = "v1", "Secret"
+	existing.Data = secretData(keyset)
+	if err := km.secrets.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+	slog.Info("InsertKeyset: existing secret replaced", "secret", name)
+	return nil
+}
+
+// Keyset fetches the keyset from the secret of keyID.
+func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	if keyID == "" {
+		return nil, model.NewBadReqErr(ErrEmptyKeyID)
+	}
+
+	name := km.secretName(keyID)
+	s, err := km.secrets.Get(ctx, name)
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return nil, model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found", keyID))
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	keyset := &model.Keyset{SubscriberID: string(s.Data[dataSubscriberID])}
+	for _, f := range []struct {
+		key   string
+		value *string
+	}{
+		{dataUniqueKeyID, &keyset.UniqueKeyID},
+		{dataSigningPrivate, &keyset.SigningPrivate},
+		{dataSigningPublic, &keyset.SigningPublic},
+		{dataEncrPrivate, &keyset.EncrPrivate},
+		{dataEncrPublic, &keyset.EncrPublic},
+	} {
+		v := s.Data[f.key]
+		if len(v) == 0 {
+			return nil, fmt.Errorf("secret %s is missing %q", name, f.key)
+		}
+		*f.value = string(v)
+	}
+	return keyset, nil
+}
+
+// DeleteKeyset deletes the secret of keyID.
+func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
+	if keyID == "" {
+		return model.NewBadReqErr(ErrEmptyKeyID)
+	}
+
+	if err := km.secrets.Delete(ctx, km.secretName(keyID)); err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found", keyID))
+		}
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	return nil
+}
+
+// LookupNPKeys fetches public keys from the registry or cache.
+func (km *keyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	if err := validateParams(subscriberID, uniqueKeyID); err != nil {
+		return "", "", model.NewBadReqErr(err)
+	}
+
+	// Check if the public keys corresponding to the subscriberID and uniqueKeyID are present in cache or not.
+	cacheKey := fmt.Sprintf("%s_%s", subscriberID, uniqueKeyID)
+
+	cachedData, err := km.cache.Get(ctx, cacheKey)
+	if err == nil {
+		// Cache hit: keys are present in cache,so return the keys.
+		var keys *model.Keyset
+		if err := json.Unmarshal([]byte(cachedData), &keys); err == nil {
+			return keys.SigningPublic, keys.EncrPublic, nil
+		}
+	}
+
+	// Cache miss: fetch from registry.
+	publicKeys, err := km.lookupRegistry(ctx, subscriberID, uniqueKeyID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Set fetched values in cache.
+	cacheValue, err := json.Marshal(publicKeys)
+	if err == nil {
+		err := km.cache.Set(ctx, cacheKey, string(cacheValue), time.Hour)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to set public keys in cache", "error", err)
+		}
+	}
+
+	return publicKeys.SigningPublic, publicKeys.EncrPublic, nil
+}
+
+// close releases the idle connections to the API server.
+func (km *keyMgr) close() error {
+	if c, ok := km.secrets.(*secretClient); ok {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// lookupRegistry makes the lookup call to registry using registryLookup implementation.
+func (km *keyMgr) lookupRegistry(ctx context.Context, subscriberID, uniqueKeyID string) (*model.Keyset, error) {
+	subscribers, err := km.registry.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{
+			SubscriberID: subscriberID,
+		},
+		KeyID: uniqueKeyID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup registry: %w", err)
+	}
+
+	if len(subscribers) == 0 {
+		return nil, model.NewBadReqErr(ErrSubscriberNotFound)
+	}
+	return &model.Keyset{
+		SigningPublic: subscribers[0].SigningPublicKey,
+		EncrPublic:    subscribers[0].EncrPublicKey,
+	}, nil
+}
+
+// secretName returns the name of the secret of keyID. A key ID that is already a valid
+// name, such as a lowercase domain name, is used as is, so that secrets created outside
+// ONIX can be named predictably. Otherwise the key ID is sanitized and a hash of it is
+// appended, so that distinct key IDs never share a secret.
+func (km *keyMgr) secretName(keyID string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(keyID), "-")
+	name = strings.Trim(dotRuns.ReplaceAllString(name, "."), ".-")
+	maxLen := maxSecretNameLen - len(km.namePrefix) - len(km.nameSuffix)
+	if name != keyID || len(name) > maxLen {
+		sum := sha256.Sum256([]byte(keyID))
+		hash := hex.EncodeToString(sum[:])[:nameHashLen]
+		if len(name) > maxLen-nameHashLen-1 {
+			name = strings.TrimRight(name[:maxLen-nameHashLen-1], ".-")
+		}
+		if name == "" {
+			name = hash
+		} else {
+			name += "-" + hash
+		}
+	}
+	return km.namePrefix + name + km.nameSuffix
+}
+
+// secretData returns the secret data that holds keyset.
+func secretData(keyset *model.Keyset) map[string][]byte {
+	data := map[string][]byte{
+		dataUniqueKeyID:    []byte(keyset.UniqueKeyID),
+		dataSigningPrivate: []byte(keyset.SigningPrivate),
+		dataSigningPublic:  []byte(keyset.SigningPublic),
+		dataEncrPrivate:    []byte(keyset.EncrPrivate),
+		dataEncrPublic:     []byte(keyset.EncrPublic),
+	}
+	if keyset.SubscriberID != "" {
+		data[dataSubscriberID] = []byte(keyset.SubscriberID)
+	}
+	return data
+}
+
+// validateCfg validates the config.
+func validateCfg(cfg *Config) error {
+	if cfg == nil {
+		return ErrNilConfig
+	}
+	if cfg.NameTemplate != "" {
+		if strings.Count(cfg.NameTemplate, keyIDPlaceholder) != 1 {
+			return fmt.Errorf("%w: %q must contain %s once", ErrInvalidNameTemplate, cfg.NameTemplate, keyIDPlaceholder)
+		}
+		// The template must form a valid name around any valid key ID.
+		if name := strings.Replace(cfg.NameTemplate, keyIDPlaceholder, "x", 1); !validSecretName.MatchString(name) || len(name) > maxSecretNameLen-nameHashLen {
+			return fmt.Errorf("%w: %q does not form a valid secret name", ErrInvalidNameTemplate, cfg.NameTemplate)
+		}
+	}
+	if _, err := keyalgo.Parse(string(cfg.SigningAlgorithm)); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+func validateParams(subscriberID, uniqueKeyID string) error {
+	if subscriberID == "" {
+		return ErrEmptySubscriberID
+	}
+	if uniqueKeyID == "" {
+		return ErrEmptyUniqueKeyID
+	}
+	return nil
+}
+
+// Error definitions.
+var (
+	ErrNilConfig           = errors.New("invalid config: config cannot be nil")
+	ErrNotInCluster        = errors.New("invalid config: apiServer must be set outside a Kubernetes cluster")
+	ErrInvalidNameTemplate = errors.New("invalid config: invalid nameTemplate")
+	ErrNilCache            = errors.New("cache cannot be nil")
+	ErrNilKeySet           = errors.New("keyset cannot be nil")
+	ErrNilRegistryLookup   = errors.New("registry lookup cannot be nil")
+	ErrEmptySubscriberID   = errors.New("subscriberID cannot be empty")
+	ErrEmptyUniqueKeyID    = errors.New("uniqueKeyID cannot be empty")
+	ErrEmptyKeyID          = errors.New("keyID cannot be empty")
+	ErrSubscriberNotFound  = errors.New("no subscriber found with given credentials")
+)
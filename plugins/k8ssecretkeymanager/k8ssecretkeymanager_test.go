@@ -0,0 +1,497 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8ssecretkeymanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beckn/beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
+)
+
+const testToken = "test-token"
+
+// fakeAPIServer serves the Secrets API of the "onix" namespace from memory.
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	secrets map[string]*secret
+	version int
+	// fail, if set, is returned as the status of the next request with the method.
+	fail map[string]int
+}
+
+func newFakeAPIServer(t *testing.T) (*fakeAPIServer, *httptest.Server) {
+	t.Helper()
+	f := &fakeAPIServer{secrets: map[string]*secret{}, fail: map[string]int{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		f.status(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if code, ok := f.fail[r.Method]; ok {
+		delete(f.fail, r.Method)
+		f.status(w, code, http.StatusText(code))
+		return
+	}
+	const collection = "/api/v1/namespaces/onix/secrets"
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, collection), "/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == collection:
+		var s secret
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			f.status(w, http.StatusBadRequest, "BadRequest")
+			return
+		}
+		if _, ok := f.secrets[s.Metadata.Name]; ok {
+			f.status(w, http.StatusConflict, "AlreadyExists")
+			return
+		}
+		f.store(&s)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(s)
+	case r.Method == http.MethodGet && name != "":
+		s, ok := f.secrets[name]
+		if !ok {
+			f.status(w, http.StatusNotFound, "NotFound")
+			return
+		}
+		json.NewEncoder(w).Encode(s)
+	case r.Method == http.MethodPut && name != "":
+		var s secret
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			f.status(w, http.StatusBadRequest, "BadRequest")
+			return
+		}
+		existing, ok := f.secrets[name]
+		if !ok {
+			f.status(w, http.StatusNotFound, "NotFound")
+			return
+		}
+		if s.Metadata.ResourceVersion != existing.Metadata.ResourceVersion {
+			f.status(w, http.StatusConflict, "Conflict")
+			return
+		}
+		f.store(&s)
+		json.NewEncoder(w).Encode(s)
+	case r.Method == http.MethodDelete && name != "":
+		if _, ok := f.secrets[name]; !ok {
+			f.status(w, http.StatusNotFound, "NotFound")
+			return
+		}
+		delete(f.secrets, name)
+		f.status(w, http.StatusOK, "")
+	default:
+		f.status(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// store saves s with a new resource version.
+func (f *fakeAPIServer) store(s *secret) {
+	f.version++
+	s.Metadata.Namespace = "onix"
+	s.Metadata.ResourceVersion = strconv.Itoa(f.version)
+	f.secrets[s.Metadata.Name] = s
+}
+
+// status writes a Status object, as the API server does for failures.
+func (f *fakeAPIServer) status(w http.ResponseWriter, code int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","code":%d,"reason":%q,"message":"fake %s"}`, code, reason, strings.ToLower(reason))
+}
+
+// newTestKeyMgr creates a key manager backed by a fake API server.
+func newTestKeyMgr(t *testing.T, cfg *Config) (*keyMgr, *fakeAPIServer) {
+	t.Helper()
+	f, srv := newFakeAPIServer(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(testToken+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.APIServer, cfg.Namespace, cfg.TokenFile = srv.URL, "onix", tokenFile
+	km, closeFunc, err := New(context.Background(), &mockCache{}, &mockRegistry{}, cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { closeFunc() })
+	return km, f
+}
+
+func testKeyset() *model.Keyset {
+	return &model.Keyset{
+		SubscriberID:   "bap.example.com",
+		UniqueKeyID:    "key-1",
+		SigningPrivate: "signing-private",
+		SigningPublic:  "signing-public",
+		EncrPrivate:    "encr-private",
+		EncrPublic:     "encr-public",
+	}
+}
+
+// mockCache implements the Cache interface for testing.
+type mockCache struct {
+	get func(ctx context.Context, key string) (string, error)
+	set func(ctx context.Context, key string, value string, expiration time.Duration) error
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) (string, error) {
+	if m.get != nil {
+		return m.get(ctx, key)
+	}
+	return "", errors.New("cache miss")
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if m.set != nil {
+		return m.set(ctx, key, value, expiration)
+	}
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, key string) error { return nil }
+func (m *mockCache) Clear(ctx context.Context) error              { return nil }
+func (m *mockCache) Close() error                                 { return nil }
+
+// mockRegistry implements the RegistryLookup interface for testing.
+type mockRegistry struct {
+	lookup func(ctx context.Context, req *model.Subscription) ([]model.Subscription, error)
+}
+
+func (m *mockRegistry) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	if m.lookup != nil {
+		return m.lookup(ctx, req)
+	}
+	return nil, nil
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr error
+	}{
+		{name: "nil config", cfg: nil, wantErr: ErrNilConfig},
+		{name: "template without placeholder", cfg: &Config{APIServer: "http://localhost", Namespace: "onix", NameTemplate: "keys"}, wantErr: ErrInvalidNameTemplate},
+		{name: "template with two placeholders", cfg: &Config{APIServer: "http://localhost", Namespace: "onix", NameTemplate: "{keyID}-{keyID}"}, wantErr: ErrInvalidNameTemplate},
+		{name: "template with invalid characters", cfg: &Config{APIServer: "http://localhost", Namespace: "onix", NameTemplate: "Keys_{keyID}"}, wantErr: ErrInvalidNameTemplate},
+		{name: "template too long", cfg: &Config{APIServer: "http://localhost", Namespace: "onix", NameTemplate: strings.Repeat("k", 250) + "{keyID}"}, wantErr: ErrInvalidNameTemplate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := New(context.Background(), &mockCache{}, &mockRegistry{}, tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("invalid signing algorithm", func(t *testing.T) {
+		_, _, err := New(context.Background(), &mockCache{}, &mockRegistry{}, &Config{APIServer: "http://localhost", Namespace: "onix", SigningAlgorithm: "rsa"})
+		if err == nil || !strings.Contains(err.Error(), "invalid config") {
+			t.Errorf("New() error = %v, want invalid config error", err)
+		}
+	})
+
+	t.Run("nil cache", func(t *testing.T) {
+		_, _, err := New(context.Background(), nil, &mockRegistry{}, &Config{APIServer: "http://localhost", Namespace: "onix"})
+		if !errors.Is(err, ErrNilCache) {
+			t.Errorf("New() error = %v, want %v", err, ErrNilCache)
+		}
+	})
+
+	t.Run("nil registry", func(t *testing.T) {
+		_, _, err := New(context.Background(), &mockCache{}, nil, &Config{APIServer: "http://localhost", Namespace: "onix"})
+		if !errors.Is(err, ErrNilRegistryLookup) {
+			t.Errorf("New() error = %v, want %v", err, ErrNilRegistryLookup)
+		}
+	})
+}
+
+func TestGenerateKeyset(t *testing.T) {
+	for _, alg := range []keyalgo.Algorithm{"", keyalgo.Secp256k1} {
+		km := &keyMgr{signingAlgorithm: alg}
+		keyset, err := km.GenerateKeyset()
+		if err != nil {
+			t.Fatalf("GenerateKeyset(%q) error = %v", alg, err)
+		}
+		if keyset.UniqueKeyID == "" || keyset.SigningPrivate == "" || keyset.SigningPublic == "" || keyset.EncrPrivate == "" || keyset.EncrPublic == "" {
+			t.Errorf("GenerateKeyset(%q) = %+v, want all keys set", alg, keyset)
+		}
+	}
+}
+
+func TestInsertKeyset(t *testing.T) {
+	km, f := newTestKeyMgr(t, &Config{})
+	ctx := context.Background()
+
+	if err := km.InsertKeyset(ctx, "bap.example.com", testKeyset()); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	s, ok := f.secrets["onix-keyset-bap.example.com"]
+	if !ok {
+		t.Fatalf("secrets = %v, want onix-keyset-bap.example.com", f.secrets)
+	}
+	if got := s.Metadata.Labels[managedByLabel]; got != managedByValue {
+		t.Errorf("label %s = %q, want %q", managedByLabel, got, managedByValue)
+	}
+	if got := s.Metadata.Annotations[keyIDAnnotation]; got != "bap.example.com" {
+		t.Errorf("annotation %s = %q, want %q", keyIDAnnotation, got, "bap.example.com")
+	}
+	if s.Type != "Opaque" || string(s.Data[dataSigningPrivate]) != "signing-private" || string(s.Data[dataSubscriberID]) != "bap.example.com" {
+		t.Errorf("secret = %+v, want opaque secret with the keyset", s)
+	}
+
+	got, err := km.Keyset(ctx, "bap.example.com")
+	if err != nil {
+		t.Fatalf("Keyset() error = %v", err)
+	}
+	if diff := cmp.Diff(testKeyset(), got); diff != "" {
+		t.Errorf("Keyset() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInsertKeyset_ReplacesExisting(t *testing.T) {
+	km, f := newTestKeyMgr(t, &Config{NameTemplate: "{keyID}.keys"})
+	ctx := context.Background()
+	if err := km.InsertKeyset(ctx, "bap.example.com", testKeyset()); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	// Labels added outside ONIX, e.g. by GitOps tooling, are kept.
+	f.secrets["bap.example.com.keys"].Metadata.Labels["team"] = "payments"
+
+	rotated := testKeyset()
+	rotated.UniqueKeyID, rotated.SigningPrivate = "key-2", "rotated-private"
+	if err := km.InsertKeyset(ctx, "bap.example.com", rotated); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	got, err := km.Keyset(ctx, "bap.example.com")
+	if err != nil {
+		t.Fatalf("Keyset() error = %v", err)
+	}
+	if diff := cmp.Diff(rotated, got); diff != "" {
+		t.Errorf("Keyset() mismatch (-want +got):\n%s", diff)
+	}
+	if got := f.secrets["bap.example.com.keys"].Metadata.Labels["team"]; got != "payments" {
+		t.Errorf("label team = %q, want %q", got, "payments")
+	}
+}
+
+func TestInsertKeysetErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyID       string
+		keyset      *model.Keyset
+		fail        map[string]int
+		errContains string
+	}{
+		{name: "empty key ID", keyID: "", keyset: testKeyset(), errContains: ErrEmptyKeyID.Error()},
+		{name: "nil keyset", keyID: "key1", keyset: nil, errContains: ErrNilKeySet.Error()},
+		{name: "create fails", keyID: "key1", keyset: testKeyset(), fail: map[string]int{http.MethodPost: http.StatusForbidden}, errContains: "failed to create secret: kubernetes API returned 403 Forbidden"},
+		{name: "get existing fails", keyID: "existing", keyset: testKeyset(), fail: map[string]int{http.MethodGet: http.StatusInternalServerError}, errContains: "failed to get existing secret"},
+		{name: "update conflicts", keyID: "existing", keyset: testKeyset(), fail: map[string]int{http.MethodPut: http.StatusConflict}, errContains: "failed to update secret: kubernetes API returned 409"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, f := newTestKeyMgr(t, &Config{})
+			if err := km.InsertKeyset(context.Background(), "existing", testKeyset()); err != nil {
+				t.Fatalf("InsertKeyset() error = %v", err)
+			}
+			f.fail = tt.fail
+			err := km.InsertKeyset(context.Background(), tt.keyID, tt.keyset)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("InsertKeyset() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestKeysetErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyID       string
+		data        map[string][]byte
+		fail        map[string]int
+		errContains string
+		badReq      bool
+	}{
+		{name: "empty key ID", keyID: "", errContains: ErrEmptyKeyID.Error(), badReq: true},
+		{name: "not found", keyID: "missing", errContains: "keys for subscriberID: missing not found", badReq: true},
+		{name: "get fails", keyID: "key1", fail: map[string]int{http.MethodGet: http.StatusForbidden}, errContains: "failed to get secret"},
+		{name: "missing key", keyID: "key1", data: map[string][]byte{dataUniqueKeyID: []byte("k"), dataSigningPrivate: []byte("s")}, errContains: `secret onix-keyset-key1 is missing "signingPublicKey"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, f := newTestKeyMgr(t, &Config{})
+			f.secrets["onix-keyset-key1"] = &secret{Metadata: objectMeta{Name: "onix-keyset-key1"}, Data: tt.data}
+			f.fail = tt.fail
+			_, err := km.Keyset(context.Background(), tt.keyID)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Fatalf("Keyset() error = %v, want error containing %q", err, tt.errContains)
+			}
+			var badReq *model.BadReqErr
+			if errors.As(err, &badReq) != tt.badReq {
+				t.Errorf("Keyset() error = %T, want bad request %t", err, tt.badReq)
+			}
+		})
+	}
+}
+
+func TestDeleteKeyset(t *testing.T) {
+	km, f := newTestKeyMgr(t, &Config{})
+	ctx := context.Background()
+	if err := km.InsertKeyset(ctx, "key1", testKeyset()); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	if err := km.DeleteKeyset(ctx, "key1"); err != nil {
+		t.Fatalf("DeleteKeyset() error = %v", err)
+	}
+	if len(f.secrets) != 0 {
+		t.Errorf("secrets = %v, want none", f.secrets)
+	}
+	if err := km.DeleteKeyset(ctx, "key1"); err == nil || !strings.Contains(err.Error(), "keys for subscriberID: key1 not found") {
+		t.Errorf("DeleteKeyset() error = %v, want not found error", err)
+	}
+	if err := km.DeleteKeyset(ctx, ""); err == nil || err.Error() != ErrEmptyKeyID.Error() {
+		t.Errorf("DeleteKeyset() error = %v, want %v", err, ErrEmptyKeyID)
+	}
+	f.fail = map[string]int{http.MethodDelete: http.StatusForbidden}
+	if err := km.DeleteKeyset(ctx, "key1"); err == nil || !strings.Contains(err.Error(), "failed to delete secret") {
+		t.Errorf("DeleteKeyset() error = %v, want delete error", err)
+	}
+}
+
+func TestLookupNPKeys(t *testing.T) {
+	ctx := context.Background()
+	t.Run("cache hit", func(t *testing.T) {
+		km := &keyMgr{
+			cache: &mockCache{get: func(ctx context.Context, key string) (string, error) {
+				if key != "bpp.example.com_key-1" {
+					t.Errorf("cache key = %q, want %q", key, "bpp.example.com_key-1")
+				}
+				return `{"SigningPublic":"sp","EncrPublic":"ep"}`, nil
+			}},
+			registry: &mockRegistry{lookup: func(context.Context, *model.Subscription) ([]model.Subscription, error) {
+				t.Error("registry looked up on cache hit")
+				return nil, nil
+			}},
+		}
+		sp, ep, err := km.LookupNPKeys(ctx, "bpp.example.com", "key-1")
+		if err != nil || sp != "sp" || ep != "ep" {
+			t.Errorf("LookupNPKeys() = %q, %q, %v, want sp, ep, nil", sp, ep, err)
+		}
+	})
+
+	t.Run("cache miss", func(t *testing.T) {
+		var cached string
+		km := &keyMgr{
+			cache: &mockCache{set: func(ctx context.Context, key, value string, exp time.Duration) error {
+				cached = value
+				return nil
+			}},
+			registry: &mockRegistry{lookup: func(context.Context, *model.Subscription) ([]model.Subscription, error) {
+				return []model.Subscription{{SigningPublicKey: "sp", EncrPublicKey: "ep"}}, nil
+			}},
+		}
+		sp, ep, err := km.LookupNPKeys(ctx, "bpp.example.com", "key-1")
+		if err != nil || sp != "sp" || ep != "ep" {
+			t.Errorf("LookupNPKeys() = %q, %q, %v, want sp, ep, nil", sp, ep, err)
+		}
+		if !strings.Contains(cached, `"SigningPublic":"sp"`) {
+			t.Errorf("cached value = %q, want the public keys", cached)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		km := &keyMgr{cache: &mockCache{}, registry: &mockRegistry{}}
+		if _, _, err := km.LookupNPKeys(ctx, "", "key-1"); err == nil || err.Error() != ErrEmptySubscriberID.Error() {
+			t.Errorf("LookupNPKeys() error = %v, want %v", err, ErrEmptySubscriberID)
+		}
+		if _, _, err := km.LookupNPKeys(ctx, "bpp.example.com", ""); err == nil || err.Error() != ErrEmptyUniqueKeyID.Error() {
+			t.Errorf("LookupNPKeys() error = %v, want %v", err, ErrEmptyUniqueKeyID)
+		}
+		if _, _, err := km.LookupNPKeys(ctx, "bpp.example.com", "key-1"); err == nil || err.Error() != ErrSubscriberNotFound.Error() {
+			t.Errorf("LookupNPKeys() error = %v, want %v", err, ErrSubscriberNotFound)
+		}
+		km.registry = &mockRegistry{lookup: func(context.Context, *model.Subscription) ([]model.Subscription, error) {
+			return nil, errors.New("registry down")
+		}}
+		if _, _, err := km.LookupNPKeys(ctx, "bpp.example.com", "key-1"); err == nil || !strings.Contains(err.Error(), "failed to lookup registry") {
+			t.Errorf("LookupNPKeys() error = %v, want registry error", err)
+		}
+	})
+}
+
+func TestSecretName(t *testing.T) {
+	km := &keyMgr{namePrefix: "onix-keyset-"}
+	tests := []struct {
+		keyID string
+		want  string
+	}{
+		{keyID: "bap.example.com", want: "onix-keyset-bap.example.com"},
+		{keyID: "key-1", want: "onix-keyset-key-1"},
+		{keyID: "BAP.example.com", want: "onix-keyset-bap.example.com-" + hashOf("BAP.example.com")},
+		{keyID: "bap_example", want: "onix-keyset-bap-example-" + hashOf("bap_example")},
+		{keyID: "a..b-.c", want: "onix-keyset-a.b.c-" + hashOf("a..b-.c")},
+		{keyID: "-key-", want: "onix-keyset-key-" + hashOf("-key-")},
+		{keyID: "@@@", want: "onix-keyset-" + hashOf("@@@")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyID, func(t *testing.T) {
+			got := km.secretName(tt.keyID)
+			if got != tt.want {
+				t.Errorf("secretName(%q) = %q, want %q", tt.keyID, got, tt.want)
+			}
+			if !validSecretName.MatchString(got) {
+				t.Errorf("secretName(%q) = %q is not a valid secret name", tt.keyID, got)
+			}
+		})
+	}
+
+	t.Run("long key ID", func(t *testing.T) {
+		keyID := strings.Repeat("a", 300)
+		got := km.secretName(keyID)
+		if len(got) != maxSecretNameLen || !strings.HasSuffix(got, "-"+hashOf(keyID)) || !validSecretName.MatchString(got) {
+			t.Errorf("secretName(%q) = %q, want a valid %d character name ending with its hash", keyID, got, maxSecretNameLen)
+		}
+	})
+}
+
+// hashOf returns the hash suffix of the secret name of keyID.
+func hashOf(keyID string) string {
+	km := &keyMgr{}
+	name := km.secretName(keyID)
+	return name[strings.LastIndex(name, "-")+1:]
+}
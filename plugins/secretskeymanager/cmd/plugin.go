@@ -18,7 +18,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/secretskeymanager"
 
 	plugin "github.com/beckn/beckn-onix/pkg/plugin/definition" // Plugin definitions will be imported from here.
@@ -48,10 +51,35 @@ func parseConfig(config map[string]string) (*keymgr.Config, error) {
 		return &keymgr.Config{}, errors.New("projectID not found in config")
 	}
 
+	var recoveryWindow time.Duration
+	if windowStr, exists := config["softDeleteRecoveryWindow"]; exists {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid value for softDeleteRecoveryWindow: %q, must be a positive duration", windowStr)
+		}
+		recoveryWindow = window
+	}
+
 	return &keymgr.Config{
-		ProjectID: projectID,
+		ProjectID:                projectID,
+		SoftDeleteRecoveryWindow: recoveryWindow,
+		ReplicaLocations:         parseReplicaLocations(config),
+		SigningAlgorithm:         keyalgo.Algorithm(config["signingAlgorithm"]),
 	}, nil
 }
 
+// parseReplicaLocations splits a comma-separated list of replica locations.
+func parseReplicaLocations(config map[string]string) []string {
+	value, exists := config["replicaLocations"]
+	if !exists || strings.TrimSpace(value) == "" {
+		return nil
+	}
+	locations := strings.Split(value, ",")
+	for i, location := range locations {
+		locations[i] = strings.TrimSpace(location)
+	}
+	return locations
+}
+
 // Provider is the exported symbol that the plugin manager will look for.
 var Provider = keyMgrProvider{}
@@ -17,10 +17,12 @@ package main
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/secretskeymanager"
 
 	"github.com/beckn/beckn-onix/pkg/model"
@@ -55,6 +57,49 @@ func TestParseConfig(t *testing.T) {
 			t.Errorf("parseConfig() = %v, want %v", got, want)
 		}
 	})
+
+	t.Run("soft delete config", func(t *testing.T) {
+		config := map[string]string{
+			"projectID":                "test-project",
+			"softDeleteRecoveryWindow": "168h",
+		}
+		got, err := parseConfig(config)
+		if err != nil {
+			t.Fatalf("parseConfig() error = %v", err)
+		}
+		if got.SoftDeleteRecoveryWindow != 168*time.Hour {
+			t.Errorf("parseConfig() SoftDeleteRecoveryWindow = %v, want %v", got.SoftDeleteRecoveryWindow, 168*time.Hour)
+		}
+	})
+
+	t.Run("signing algorithm config", func(t *testing.T) {
+		config := map[string]string{
+			"projectID":        "test-project",
+			"signingAlgorithm": "secp256k1",
+		}
+		got, err := parseConfig(config)
+		if err != nil {
+			t.Fatalf("parseConfig() error = %v", err)
+		}
+		if got.SigningAlgorithm != keyalgo.Secp256k1 {
+			t.Errorf("parseConfig() SigningAlgorithm = %q, want %q", got.SigningAlgorithm, keyalgo.Secp256k1)
+		}
+	})
+
+	t.Run("replica locations config", func(t *testing.T) {
+		config := map[string]string{
+			"projectID":        "test-project",
+			"replicaLocations": "asia-south1, asia-south2",
+		}
+		got, err := parseConfig(config)
+		if err != nil {
+			t.Fatalf("parseConfig() error = %v", err)
+		}
+		want := []string{"asia-south1", "asia-south2"}
+		if !reflect.DeepEqual(got.ReplicaLocations, want) {
+			t.Errorf("parseConfig() ReplicaLocations = %v, want %v", got.ReplicaLocations, want)
+		}
+	})
 }
 
 func TestParseConfigErrors(t *testing.T) {
@@ -70,6 +115,14 @@ func TestParseConfigErrors(t *testing.T) {
 			name:   "empty config",
 			config: map[string]string{},
 		},
+		{
+			name:   "invalid recovery window",
+			config: map[string]string{"projectID": "test-project", "softDeleteRecoveryWindow": "a week"},
+		},
+		{
+			name:   "negative recovery window",
+			config: map[string]string{"projectID": "test-project", "softDeleteRecoveryWindow": "-1h"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -17,7 +17,6 @@ package secretskeymanager
 import (
 	"context"
 	"crypto/ecdh"
-	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -37,11 +36,24 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // Config Required for the module.
 type Config struct {
 	ProjectID string
+	// SoftDeleteRecoveryWindow enables soft delete when positive. DeleteKeyset then disables
+	// the secret version and schedules the secret for destruction after this window, during
+	// which UndeleteKeyset can recover it.
+	SoftDeleteRecoveryWindow time.Duration
+	// ReplicaLocations pins new secrets to these locations, e.g. "asia-south1", using
+	// user-managed replication. Secrets are replicated automatically when it is empty.
+	ReplicaLocations []string
+	// SigningAlgorithm is the algorithm of generated signing keys. Defaults to keyalgo.Default.
+	SigningAlgorithm keyalgo.Algorithm
 }
 
 type secretMgr interface {
@@ -49,14 +61,21 @@ type secretMgr interface {
 	AddSecretVersion(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
 	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	GetSecretVersion(context.Context, *secretmanagerpb.GetSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	DisableSecretVersion(context.Context, *secretmanagerpb.DisableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	EnableSecretVersion(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	UpdateSecret(context.Context, *secretmanagerpb.UpdateSecretRequest, ...gax.CallOption) (*secretmanagerpb.Secret, error)
 	Close() error
 }
 
 type keyMgr struct {
-	projectID    string
-	secretClient secretMgr
-	registry     plugin.RegistryLookup
-	cache        plugin.Cache
+	projectID        string
+	secretClient     secretMgr
+	registry         plugin.RegistryLookup
+	cache            plugin.Cache
+	recoveryWindow   time.Duration
+	replicaLocations []string
+	signingAlgorithm keyalgo.Algorithm
 }
 
 // Constants for secret ID generation.
@@ -93,10 +112,13 @@ func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg
 	}
 
 	km := &keyMgr{
-		projectID:    cfg.ProjectID,
-		secretClient: client,
-		registry:     registryLookup,
-		cache:        cache,
+		projectID:        cfg.ProjectID,
+		secretClient:     client,
+		registry:         registryLookup,
+		cache:            cache,
+		recoveryWindow:   cfg.SoftDeleteRecoveryWindow,
+		replicaLocations: cfg.ReplicaLocations,
+		signingAlgorithm: cfg.SigningAlgorithm,
 	}
 
 	return km, km.close, nil
@@ -105,7 +127,7 @@ func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg
 // GenerateKeyset generates new signing and encryption key pairs.
 func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 	// Generate Signing keys.
-	signingPublic, signingPrivate, err := ed25519.GenerateKey(rand.Reader)
+	signingPrivate, signingPublic, err := keyalgo.GenerateSigningKey(km.signingAlgorithm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate signing key pair: %w", err)
 	}
@@ -126,8 +148,8 @@ func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 
 	return &model.Keyset{
 		UniqueKeyID:    uuid.String(),
-		SigningPrivate: encodeBase64(signingPrivate.Seed()),
-		SigningPublic:  encodeBase64(signingPublic),
+		SigningPrivate: signingPrivate,
+		SigningPublic:  signingPublic,
 		EncrPrivate:    encodeBase64(encrPrivateKey.Bytes()),
 		EncrPublic:     encodeBase64(encrPublicKey),
 	}, nil
@@ -150,19 +172,16 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 		Parent:   fmt.Sprintf("projects/%s", km.projectID),
 		SecretId: secretID,
 		Secret: &secretmanagerpb.Secret{
-			Replication: &secretmanagerpb.Replication{
-				Replication: &secretmanagerpb.Replication_Automatic_{
-					Automatic: &secretmanagerpb.Replication_Automatic{},
-				},
-			},
+			Replication: km.replication(),
 		},
 	})
 
 	if err != nil {
 		// check for already exists error.
 		if status.Code(err) == codes.AlreadyExists {
-			// Delete existing secret with same keyID.
-			if err := km.DeleteKeyset(ctx, keyID); err != nil {
+			// Delete existing secret with same keyID. This is always a hard delete,
+			// as a soft deleted secret would still exist.
+			if err := km.deleteSecret(ctx, secretName); err != nil {
 				return fmt.Errorf("failed to delete existing secret with same keyID: %w", err)
 			}
 
@@ -199,7 +218,8 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 		Name: secretName,
 	})
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
+		// A soft deleted keyset has its version disabled, which fails with FailedPrecondition.
+		if c := status.Code(err); c == codes.NotFound || c == codes.FailedPrecondition {
 			return nil, model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found", keyID))
 		}
 		return nil, fmt.Errorf("failed to access secret version: %w", err)
@@ -213,6 +233,7 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 }
 
 // DeleteKeyset deletes the private keys from the secret manager.
+// With soft delete enabled, the keys are disabled and destroyed after the recovery window instead.
 func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	if keyID == "" {
 		return model.NewBadReqErr(ErrEmptyKeyID)
@@ -221,6 +242,51 @@ func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	secretID := generateSecretID(keyID)
 	secretName := fmt.Sprintf("projects/%s/secrets/%s", km.projectID, secretID)
 
+	if km.recoveryWindow > 0 {
+		return km.softDeleteSecret(ctx, secretName)
+	}
+	return km.deleteSecret(ctx, secretName)
+}
+
+// UndeleteKeyset recovers soft deleted private keys that have not yet been destroyed.
+func (km *keyMgr) UndeleteKeyset(ctx context.Context, keyID string) error {
+	if keyID == "" {
+		return model.NewBadReqErr(ErrEmptyKeyID)
+	}
+
+	secretID := generateSecretID(keyID)
+	secretName := fmt.Sprintf("projects/%s/secrets/%s", km.projectID, secretID)
+
+	version, err := km.secretClient.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: secretName + "/versions/latest",
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found or no longer recoverable", keyID))
+		}
+		return fmt.Errorf("failed to get secret version: %w", err)
+	}
+
+	// Cancel the scheduled destruction before re-enabling the keys.
+	if _, err := km.secretClient.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret:     &secretmanagerpb.Secret{Name: secretName},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"ttl"}},
+	}); err != nil {
+		return fmt.Errorf("failed to clear secret expiration: %w", err)
+	}
+	if version.GetState() == secretmanagerpb.SecretVersion_DISABLED {
+		if _, err := km.secretClient.EnableSecretVersion(ctx, &secretmanagerpb.EnableSecretVersionRequest{
+			Name: version.GetName(),
+		}); err != nil {
+			return fmt.Errorf("failed to enable secret version: %w", err)
+		}
+	}
+	slog.Info("UndeleteKeyset: keyset recovered", "keyID", keyID)
+	return nil
+}
+
+// deleteSecret permanently deletes a secret.
+func (km *keyMgr) deleteSecret(ctx context.Context, secretName string) error {
 	if err := km.secretClient.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{
 		Name: secretName,
 	}); err != nil {
@@ -229,6 +295,35 @@ func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	return nil
 }
 
+// softDeleteSecret disables the latest version of a secret and schedules the secret
+// for destruction after the recovery window.
+func (km *keyMgr) softDeleteSecret(ctx context.Context, secretName string) error {
+	version, err := km.secretClient.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: secretName + "/versions/latest",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get secret version: %w", err)
+	}
+	if version.GetState() == secretmanagerpb.SecretVersion_ENABLED {
+		if _, err := km.secretClient.DisableSecretVersion(ctx, &secretmanagerpb.DisableSecretVersionRequest{
+			Name: version.GetName(),
+		}); err != nil {
+			return fmt.Errorf("failed to disable secret version: %w", err)
+		}
+	}
+	if _, err := km.secretClient.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret: &secretmanagerpb.Secret{
+			Name:       secretName,
+			Expiration: &secretmanagerpb.Secret_Ttl{Ttl: durationpb.New(km.recoveryWindow)},
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"ttl"}},
+	}); err != nil {
+		return fmt.Errorf("failed to schedule secret destruction: %w", err)
+	}
+	slog.Info("DeleteKeyset: keyset soft deleted", "secret", secretName, "recoveryWindow", km.recoveryWindow)
+	return nil
+}
+
 // LookupNPKeys fetches public keys from the registry or cache.
 func (km *keyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
 	if err := validateParams(subscriberID, uniqueKeyID); err != nil {
@@ -324,11 +419,41 @@ func (km *keyMgr) lookupRegistry(ctx context.Context, subscriberID, uniqueKeyID
 	}, nil
 }
 
+// replication returns the replication policy of new secrets.
+func (km *keyMgr) replication() *secretmanagerpb.Replication {
+	if len(km.replicaLocations) == 0 {
+		return &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{
+				Automatic: &secretmanagerpb.Replication_Automatic{},
+			},
+		}
+	}
+	replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(km.replicaLocations))
+	for _, location := range km.replicaLocations {
+		replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+	}
+	return &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_UserManaged_{
+			UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas},
+		},
+	}
+}
+
 // validateCfg validates the config.
 func validateCfg(cfg *Config) error {
 	if cfg.ProjectID == "" {
 		return ErrEmptyProjectID
 	}
+	seen := make(map[string]bool, len(cfg.ReplicaLocations))
+	for _, location := range cfg.ReplicaLocations {
+		if location == "" || seen[location] {
+			return fmt.Errorf("%w: %q", ErrInvalidReplicaLocation, location)
+		}
+		seen[location] = true
+	}
+	if _, err := keyalgo.Parse(string(cfg.SigningAlgorithm)); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 	return nil
 }
 
@@ -344,12 +469,13 @@ func validateParams(subscriberID, uniqueKeyID string) error {
 
 // Error definitions.
 var (
-	ErrEmptyProjectID     = errors.New("invalid config: projectID cannot be empty")
-	ErrNilCache           = errors.New("cache cannot be nil")
-	ErrNilKeySet          = errors.New("keyset cannot be nil")
-	ErrNilRegistryLookup  = errors.New("registry lookup cannot be nil")
-	ErrEmptySubscriberID  = errors.New("subscriberID cannot be empty")
-	ErrEmptyUniqueKeyID   = errors.New("uniqueKeyID cannot be empty")
-	ErrEmptyKeyID         = errors.New("keyID cannot be empty")
-	ErrSubscriberNotFound = errors.New("no subscriber found with given credentials")
+	ErrEmptyProjectID         = errors.New("invalid config: projectID cannot be empty")
+	ErrInvalidReplicaLocation = errors.New("invalid config: replica locations must be non-empty and unique")
+	ErrNilCache               = errors.New("cache cannot be nil")
+	ErrNilKeySet              = errors.New("keyset cannot be nil")
+	ErrNilRegistryLookup      = errors.New("registry lookup cannot be nil")
+	ErrEmptySubscriberID      = errors.New("subscriberID cannot be empty")
+	ErrEmptyUniqueKeyID       = errors.New("uniqueKeyID cannot be empty")
+	ErrEmptyKeyID             = errors.New("keyID cannot be empty")
+	ErrSubscriberNotFound     = errors.New("no subscriber found with given credentials")
 )
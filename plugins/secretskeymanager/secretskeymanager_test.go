@@ -33,6 +33,8 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/keyalgo"
 )
 
 // mockSecretMgr implements the secretMgr interface for testing.
@@ -41,6 +43,10 @@ type mockSecretMgr struct {
 	addSecretVersion    func(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	deleteSecret        func(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
 	accessSecretVersion func(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	getSecretVersion    func(context.Context, *secretmanagerpb.GetSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	disableVersion      func(context.Context, *secretmanagerpb.DisableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	enableVersion       func(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	updateSecret        func(context.Context, *secretmanagerpb.UpdateSecretRequest, ...gax.CallOption) (*secretmanagerpb.Secret, error)
 	close               func() error
 }
 
@@ -60,6 +66,22 @@ func (m *mockSecretMgr) AccessSecretVersion(ctx context.Context, req *secretmana
 	return m.accessSecretVersion(ctx, req, opts...)
 }
 
+func (m *mockSecretMgr) GetSecretVersion(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return m.getSecretVersion(ctx, req, opts...)
+}
+
+func (m *mockSecretMgr) DisableSecretVersion(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return m.disableVersion(ctx, req, opts...)
+}
+
+func (m *mockSecretMgr) EnableSecretVersion(ctx context.Context, req *secretmanagerpb.EnableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return m.enableVersion(ctx, req, opts...)
+}
+
+func (m *mockSecretMgr) UpdateSecret(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+	return m.updateSecret(ctx, req, opts...)
+}
+
 func (m *mockSecretMgr) Close() error {
 	return m.close()
 }
@@ -164,6 +186,36 @@ func TestNewErrors(t *testing.T) {
 			reg:     nil,
 			wantErr: ErrNilRegistryLookup,
 		},
+		{
+			name: "duplicate replica location",
+			cfg: &Config{
+				ProjectID:        "test-project",
+				ReplicaLocations: []string{"asia-south1", "asia-south1"},
+			},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: ErrInvalidReplicaLocation,
+		},
+		{
+			name: "empty replica location",
+			cfg: &Config{
+				ProjectID:        "test-project",
+				ReplicaLocations: []string{""},
+			},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: ErrInvalidReplicaLocation,
+		},
+		{
+			name: "unsupported signing algorithm",
+			cfg: &Config{
+				ProjectID:        "test-project",
+				SigningAlgorithm: "rsa",
+			},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: keyalgo.ErrUnsupported,
+		},
 	}
 
 	for _, tt := range tests {
@@ -203,6 +255,20 @@ func TestGenerateKeyset(t *testing.T) {
 	}
 }
 
+func TestGenerateKeyset_Secp256k1(t *testing.T) {
+	km := &keyMgr{signingAlgorithm: keyalgo.Secp256k1}
+	keyset, err := km.GenerateKeyset()
+	if err != nil {
+		t.Fatalf("GenerateKeyset() error = %v", err)
+	}
+	if alg, _ := keyalgo.PrivateKey(keyset.SigningPrivate); alg != keyalgo.Secp256k1 {
+		t.Errorf("GenerateKeyset() signing private key algorithm = %q, want %q", alg, keyalgo.Secp256k1)
+	}
+	if alg, err := keyalgo.PublicKey(keyset.SigningPublic); err != nil || alg != keyalgo.Secp256k1 {
+		t.Errorf("GenerateKeyset() signing public key algorithm = %q, %v, want %q", alg, err, keyalgo.Secp256k1)
+	}
+}
+
 func TestInsertKeyset(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -271,6 +337,56 @@ func TestInsertKeyset(t *testing.T) {
 	}
 }
 
+func TestInsertKeyset_Replication(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations []string
+		want      []string
+	}{
+		{
+			name: "automatic replication",
+		},
+		{
+			name:      "user managed replication",
+			locations: []string{"asia-south1", "asia-south2"},
+			want:      []string{"asia-south1", "asia-south2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *secretmanagerpb.Replication
+			mock := &mockSecretMgr{
+				createSecret: func(ctx context.Context, req *secretmanagerpb.CreateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+					got = req.GetSecret().GetReplication()
+					return &secretmanagerpb.Secret{}, nil
+				},
+				addSecretVersion: func(ctx context.Context, req *secretmanagerpb.AddSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+					return &secretmanagerpb.SecretVersion{}, nil
+				},
+			}
+			km := &keyMgr{projectID: "test-project", secretClient: mock, replicaLocations: tt.locations}
+
+			if err := km.InsertKeyset(context.Background(), "key1", &model.Keyset{}); err != nil {
+				t.Fatalf("InsertKeyset() error = %v", err)
+			}
+			if len(tt.want) == 0 {
+				if got.GetAutomatic() == nil {
+					t.Errorf("InsertKeyset() replication = %v, want automatic", got)
+				}
+				return
+			}
+			var locations []string
+			for _, replica := range got.GetUserManaged().GetReplicas() {
+				locations = append(locations, replica.GetLocation())
+			}
+			if strings.Join(locations, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("InsertKeyset() replica locations = %v, want %v", locations, tt.want)
+			}
+		})
+	}
+}
+
 func TestInsertKeysetErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -437,6 +553,16 @@ func TestKeysetErrors(t *testing.T) {
 			},
 			errContains: "keys for subscriberID: key1 not found",
 		},
+		{
+			name:  "keys soft deleted",
+			keyID: "key1",
+			mockSecret: &mockSecretMgr{
+				accessSecretVersion: func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+					return nil, status.Error(codes.FailedPrecondition, "version is disabled")
+				},
+			},
+			errContains: "keys for subscriberID: key1 not found",
+		},
 		{
 			name:        "empty key ID",
 			keyID:       "",
@@ -765,6 +891,231 @@ func TestDeleteKeysetErrors(t *testing.T) {
 	}
 }
 
+// softDeleteMock returns a secret manager mock that records the soft delete and undelete calls.
+func softDeleteMock(state secretmanagerpb.SecretVersion_State, calls *[]string) *mockSecretMgr {
+	return &mockSecretMgr{
+		getSecretVersion: func(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+			*calls = append(*calls, "get "+req.Name)
+			return &secretmanagerpb.SecretVersion{Name: strings.TrimSuffix(req.Name, "latest") + "3", State: state}, nil
+		},
+		disableVersion: func(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+			*calls = append(*calls, "disable "+req.Name)
+			return &secretmanagerpb.SecretVersion{}, nil
+		},
+		enableVersion: func(ctx context.Context, req *secretmanagerpb.EnableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+			*calls = append(*calls, "enable "+req.Name)
+			return &secretmanagerpb.SecretVersion{}, nil
+		},
+		updateSecret: func(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+			*calls = append(*calls, fmt.Sprintf("update %s %v ttl=%v", req.Secret.Name, req.UpdateMask.Paths, req.Secret.GetTtl().AsDuration()))
+			return &secretmanagerpb.Secret{}, nil
+		},
+		deleteSecret: func(ctx context.Context, req *secretmanagerpb.DeleteSecretRequest, opts ...gax.CallOption) error {
+			*calls = append(*calls, "delete "+req.Name)
+			return nil
+		},
+	}
+}
+
+func TestDeleteKeyset_SoftDelete(t *testing.T) {
+	secretName := "projects/test-project/secrets/" + generateSecretID("key1")
+	tests := []struct {
+		name      string
+		state     secretmanagerpb.SecretVersion_State
+		wantCalls []string
+	}{
+		{
+			name:  "enabled version",
+			state: secretmanagerpb.SecretVersion_ENABLED,
+			wantCalls: []string{
+				"get " + secretName + "/versions/latest",
+				"disable " + secretName + "/versions/3",
+				"update " + secretName + " [ttl] ttl=168h0m0s",
+			},
+		},
+		{
+			name:  "already disabled version",
+			state: secretmanagerpb.SecretVersion_DISABLED,
+			wantCalls: []string{
+				"get " + secretName + "/versions/latest",
+				"update " + secretName + " [ttl] ttl=168h0m0s",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			km := &keyMgr{
+				projectID:      "test-project",
+				secretClient:   softDeleteMock(tt.state, &calls),
+				recoveryWindow: 7 * 24 * time.Hour,
+			}
+			if err := km.DeleteKeyset(context.Background(), "key1"); err != nil {
+				t.Fatalf("DeleteKeyset() error = %v", err)
+			}
+			if strings.Join(calls, "\n") != strings.Join(tt.wantCalls, "\n") {
+				t.Errorf("DeleteKeyset() calls = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDeleteKeyset_SoftDeleteErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		mock        func(m *mockSecretMgr)
+		errContains string
+	}{
+		{
+			name: "get version fails",
+			mock: func(m *mockSecretMgr) {
+				m.getSecretVersion = func(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+					return nil, errors.New("get failed")
+				}
+			},
+			errContains: "failed to get secret version",
+		},
+		{
+			name: "disable fails",
+			mock: func(m *mockSecretMgr) {
+				m.disableVersion = func(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+					return nil, errors.New("disable failed")
+				}
+			},
+			errContains: "failed to disable secret version",
+		},
+		{
+			name: "update fails",
+			mock: func(m *mockSecretMgr) {
+				m.updateSecret = func(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+					return nil, errors.New("update failed")
+				}
+			},
+			errContains: "failed to schedule secret destruction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			mock := softDeleteMock(secretmanagerpb.SecretVersion_ENABLED, &calls)
+			tt.mock(mock)
+			km := &keyMgr{projectID: "test-project", secretClient: mock, recoveryWindow: time.Hour}
+			err := km.DeleteKeyset(context.Background(), "key1")
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("DeleteKeyset() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestInsertKeyset_SoftDeleteReplacesExisting(t *testing.T) {
+	var calls []string
+	mock := softDeleteMock(secretmanagerpb.SecretVersion_ENABLED, &calls)
+	created := false
+	mock.createSecret = func(ctx context.Context, req *secretmanagerpb.CreateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+		if !created {
+			created = true
+			return nil, status.Error(codes.AlreadyExists, "already exists")
+		}
+		return &secretmanagerpb.Secret{}, nil
+	}
+	mock.addSecretVersion = func(ctx context.Context, req *secretmanagerpb.AddSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+		return &secretmanagerpb.SecretVersion{}, nil
+	}
+	km := &keyMgr{projectID: "test-project", secretClient: mock, recoveryWindow: time.Hour}
+
+	if err := km.InsertKeyset(context.Background(), "key1", &model.Keyset{}); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	want := []string{"delete projects/test-project/secrets/" + generateSecretID("key1")}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("InsertKeyset() calls = %q, want %q", calls, want)
+	}
+}
+
+func TestUndeleteKeyset(t *testing.T) {
+	secretName := "projects/test-project/secrets/" + generateSecretID("key1")
+	var calls []string
+	km := &keyMgr{
+		projectID:      "test-project",
+		secretClient:   softDeleteMock(secretmanagerpb.SecretVersion_DISABLED, &calls),
+		recoveryWindow: time.Hour,
+	}
+
+	if err := km.UndeleteKeyset(context.Background(), "key1"); err != nil {
+		t.Fatalf("UndeleteKeyset() error = %v", err)
+	}
+	want := []string{
+		"get " + secretName + "/versions/latest",
+		"update " + secretName + " [ttl] ttl=0s",
+		"enable " + secretName + "/versions/3",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("UndeleteKeyset() calls = %q, want %q", calls, want)
+	}
+}
+
+func TestUndeleteKeysetErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyID       string
+		mock        func(m *mockSecretMgr)
+		errContains string
+	}{
+		{
+			name:        "empty key ID",
+			keyID:       "",
+			mock:        func(m *mockSecretMgr) {},
+			errContains: ErrEmptyKeyID.Error(),
+		},
+		{
+			name:  "already destroyed",
+			keyID: "key1",
+			mock: func(m *mockSecretMgr) {
+				m.getSecretVersion = func(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+					return nil, status.Error(codes.NotFound, "not found")
+				}
+			},
+			errContains: "not found or no longer recoverable",
+		},
+		{
+			name:  "update fails",
+			keyID: "key1",
+			mock: func(m *mockSecretMgr) {
+				m.updateSecret = func(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+					return nil, errors.New("update failed")
+				}
+			},
+			errContains: "failed to clear secret expiration",
+		},
+		{
+			name:  "enable fails",
+			keyID: "key1",
+			mock: func(m *mockSecretMgr) {
+				m.enableVersion = func(ctx context.Context, req *secretmanagerpb.EnableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+					return nil, errors.New("enable failed")
+				}
+			},
+			errContains: "failed to enable secret version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			mock := softDeleteMock(secretmanagerpb.SecretVersion_DISABLED, &calls)
+			tt.mock(mock)
+			km := &keyMgr{projectID: "test-project", secretClient: mock}
+			err := km.UndeleteKeyset(context.Background(), tt.keyID)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("UndeleteKeyset() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
 func TestClose(t *testing.T) {
 	t.Run("successful close", func(t *testing.T) {
 		mockSecret := &mockSecretMgr{
@@ -22,7 +22,7 @@ BEGIN
         CREATE TYPE subscriber_status_enum AS ENUM ('INITIATED', 'UNDER_SUBSCRIPTION', 'SUBSCRIBED', 'INVALID_SSL', 'UNSUBSCRIBED');
     END IF;
     IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'operation_status_enum') THEN
-        CREATE TYPE operation_status_enum AS ENUM ('PENDING', 'APPROVED', 'REJECTED', 'FAILURE');
+        CREATE TYPE operation_status_enum AS ENUM ('PENDING', 'APPROVED', 'REJECTED', 'FAILURE', 'STALE');
     END IF;
     IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'operation_type_enum') THEN
         CREATE TYPE operation_type_enum AS ENUM ('CREATE_SUBSCRIPTION', 'UPDATE_SUBSCRIPTION');
@@ -32,6 +32,12 @@ BEGIN
     END IF;
 END$$;
 
+-- Databases created before operations could expire lack the STALE status.
+ALTER TYPE operation_status_enum ADD VALUE IF NOT EXISTS 'STALE';
+
+-- Databases created before approvals could be scheduled lack the APPROVED_PENDING_ACTIVATION status.
+ALTER TYPE subscriber_status_enum ADD VALUE IF NOT EXISTS 'APPROVED_PENDING_ACTIVATION';
+
 -- Subscribers Table:
 CREATE TABLE IF NOT EXISTS subscriptions (
     subscriber_id VARCHAR(255) NOT NULL,
@@ -48,17 +54,85 @@ CREATE TABLE IF NOT EXISTS subscriptions (
     -- This DEFAULT value handles the creation timestamp automatically on INSERT.
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    nonce VARCHAR(255),
+    -- Encrypted when the repository is configured with column encryption.
+    nonce TEXT,
     extended_attributes JSONB,
+    -- Free-form operator labels such as ["pilot", "tier-1"], managed through the admin API.
+    labels JSONB NOT NULL DEFAULT '[]'::jsonb,
+    -- City and country codes extracted from location, so that the lookups of the gateway fanout
+    -- filter on an indexed column instead of the JSONB document.
+    city_code TEXT GENERATED ALWAYS AS (location -> 'city' ->> 'code') STORED,
+    country_code TEXT GENERATED ALWAYS AS (location -> 'country' ->> 'code') STORED,
     PRIMARY KEY (subscriber_id, domain, type)
 );
 
+-- Databases created before subscriptions were labelled lack the labels column.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '[]'::jsonb;
+-- Databases created before the location codes were extracted lack the generated columns.
+-- Adding them rewrites the table once to compute the codes of existing rows.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS city_code TEXT GENERATED ALWAYS AS (location -> 'city' ->> 'code') STORED;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS country_code TEXT GENERATED ALWAYS AS (location -> 'country' ->> 'code') STORED;
+-- Encrypted nonces do not fit the VARCHAR(255) of databases created before column encryption.
+ALTER TABLE subscriptions ALTER COLUMN nonce TYPE TEXT;
+
 -- Indexes for subscriptions table:
 CREATE INDEX IF NOT EXISTS idx_subscribers_key_id ON subscriptions (key_id);
 CREATE INDEX IF NOT EXISTS idx_subscribers_status ON subscriptions (status);
+-- Supports prefix (wildcard) domain lookups such as 'nic2004:*'.
+CREATE INDEX IF NOT EXISTS idx_subscribers_domain_pattern ON subscriptions (domain varchar_pattern_ops);
 CREATE INDEX IF NOT EXISTS Idx_subscribers_location_city_country ON subscriptions USING BTREE ((location ->> 'city'), (location ->> 'country'));
+-- Lookup hot path: gateway lookups only target SUBSCRIBED participants of a domain and type.
+-- varchar_pattern_ops serves both exact and prefix (wildcard) domain matches.
+CREATE INDEX IF NOT EXISTS idx_subscribers_subscribed_domain_type ON subscriptions (domain varchar_pattern_ops, type) WHERE status = 'SUBSCRIBED';
+-- Serves location filters, which are expressed as JSONB containment (location @> '{...}').
+CREATE INDEX IF NOT EXISTS idx_subscribers_location_gin ON subscriptions USING GIN (location jsonb_path_ops);
+-- Serve city and country code filters, the most common location filters of fanout lookups.
+CREATE INDEX IF NOT EXISTS idx_subscribers_city_code ON subscriptions (city_code);
+CREATE INDEX IF NOT EXISTS idx_subscribers_country_code ON subscriptions (country_code);
+-- Serves label filters, which are expressed as JSONB containment (labels @> '["pilot"]').
+CREATE INDEX IF NOT EXISTS idx_subscribers_labels_gin ON subscriptions USING GIN (labels jsonb_path_ops);
+-- Serves incremental lookups of the subscriptions updated after a time.
+CREATE INDEX IF NOT EXISTS idx_subscribers_updated_at ON subscriptions (updated_at);
 
 
+-- Subscription History Table:
+-- Holds every version of every subscription row, written by the record_subscription_history
+-- trigger below, so that a subscription can be viewed as of any point in time.
+CREATE TABLE IF NOT EXISTS subscription_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    -- INSERT, UPDATE or DELETE, or BACKFILL for rows that predate the history.
+    change VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    subscriber_id VARCHAR(255) NOT NULL,
+    type subscriber_type_enum NOT NULL,
+    domain VARCHAR(255) NOT NULL,
+    location JSONB,
+    signing_public_key TEXT NOT NULL,
+    encr_public_key TEXT NOT NULL,
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    status subscriber_status_enum NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    key_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    extended_attributes JSONB
+);
+
+-- Serves point-in-time views of the subscriptions of a subscriber.
+CREATE INDEX IF NOT EXISTS idx_subscription_history_subscriber_changed_at ON subscription_history (subscriber_id, changed_at DESC);
+-- Serves the tombstones of subscriptions deleted after a time in incremental lookups.
+CREATE INDEX IF NOT EXISTS idx_subscription_history_deleted_changed_at ON subscription_history (changed_at) WHERE change = 'DELETE';
+
+-- Seeds the history with the current version of subscriptions that predate it.
+INSERT INTO subscription_history (change, changed_at, subscriber_id, type, domain, location, signing_public_key, encr_public_key, valid_from, valid_until, status, url, key_id, created_at, updated_at, extended_attributes)
+SELECT 'BACKFILL', COALESCE(s.updated_at, s.created_at, CURRENT_TIMESTAMP), s.subscriber_id, s.type, s.domain, s.location, s.signing_public_key, s.encr_public_key, s.valid_from, s.valid_until, s.status, s.url, s.key_id, s.created_at, s.updated_at, s.extended_attributes
+FROM subscriptions s
+WHERE NOT EXISTS (
+    SELECT 1 FROM subscription_history h
+    WHERE h.subscriber_id = s.subscriber_id AND h.domain = s.domain AND h.type = s.type
+);
+
 -- Operations Table:
 CREATE TABLE IF NOT EXISTS Operations (
     operation_id VARCHAR(255) PRIMARY KEY,
@@ -67,15 +141,153 @@ CREATE TABLE IF NOT EXISTS Operations (
     request_json JSONB NOT NULL,
     result_json JSONB,
     error_data_json JSONB,
+    probe_json JSONB,
+    review_json JSONB,
+    -- Comments and document references added by admins while reviewing the operation.
+    comments_json JSONB,
     retry_count INTEGER DEFAULT 0,
     -- This DEFAULT value handles the creation timestamp automatically on INSERT.
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Databases created before subscriber URLs were probed lack the probe_json column.
+ALTER TABLE Operations ADD COLUMN IF NOT EXISTS probe_json JSONB;
+
+-- Databases created before admin reviews were recorded lack the review_json column.
+ALTER TABLE Operations ADD COLUMN IF NOT EXISTS review_json JSONB;
+
+-- Databases created before operations could be commented on lack the comments_json column.
+ALTER TABLE Operations ADD COLUMN IF NOT EXISTS comments_json JSONB;
+
 -- Indexes for Operations table:
 CREATE INDEX IF NOT EXISTS Idx_operations_status ON Operations (status);
 CREATE INDEX IF NOT EXISTS Idx_operations_updated_at ON Operations (updated_at);
+-- Serves listing the operations requested by a subscriber.
+CREATE INDEX IF NOT EXISTS Idx_operations_subscriber_id ON Operations ((request_json->>'subscriber_id'));
+-- Serves the LRO statistics, which are computed over a window of submission times.
+CREATE INDEX IF NOT EXISTS Idx_operations_created_at ON Operations (created_at);
+
+-- Subscription Nonces Table:
+-- Tracks the nonce of every subscription request so that each nonce is used by a single operation.
+-- With column encryption, a nonce is stored as its HMAC-SHA256 rather than in clear.
+CREATE TABLE IF NOT EXISTS subscription_nonces (
+    nonce VARCHAR(255) PRIMARY KEY,
+    subscriber_id VARCHAR(255) NOT NULL,
+    operation_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    consumed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Subscriber API Keys Table:
+-- Holds hashes of API keys that let subscribers read their own records without signing requests.
+CREATE TABLE IF NOT EXISTS subscriber_api_keys (
+    key_id VARCHAR(255) PRIMARY KEY,
+    subscriber_id VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_subscriber_api_keys_subscriber_id ON subscriber_api_keys (subscriber_id);
+
+-- Webhooks Table:
+-- Holds the HTTP endpoints the admin service notifies of LRO transitions.
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id VARCHAR(255) PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    secret VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Webhook Deliveries Table:
+-- Logs every event sent to a webhook and the outcome of its delivery.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id VARCHAR(255) PRIMARY KEY,
+    webhook_id VARCHAR(255) NOT NULL REFERENCES webhooks (webhook_id) ON DELETE CASCADE,
+    event_type VARCHAR(255) NOT NULL,
+    operation_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    response_code INT,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id_created_at ON webhook_deliveries (webhook_id, created_at DESC);
+-- Serves the timeline of an operation.
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_operation_id ON webhook_deliveries (operation_id);
+
+-- Operation Challenges Table:
+-- Logs every /on_subscribe challenge sent while approving an operation and its outcome.
+CREATE TABLE IF NOT EXISTS operation_challenges (
+    challenge_id BIGSERIAL PRIMARY KEY,
+    operation_id VARCHAR(255) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    passed BOOLEAN NOT NULL,
+    signed BOOLEAN NOT NULL,
+    error TEXT,
+    attempted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_operation_challenges_operation_id ON operation_challenges (operation_id, attempted_at);
+
+-- Registry Maintenance Table:
+-- Holds the single row that puts the registry in read-only maintenance mode.
+CREATE TABLE IF NOT EXISTS registry_maintenance (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    message TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Denylist Table:
+-- Holds the subscribers and IP ranges whose traffic the gateway and registry drop.
+CREATE TABLE IF NOT EXISTS denylist (
+    entry_id VARCHAR(255) PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (kind, value)
+);
+
+-- Domains Table:
+-- Holds the catalog of network domains, their parent domain and the metadata subscriptions are validated against.
+CREATE TABLE IF NOT EXISTS domains (
+    name VARCHAR(255) PRIMARY KEY,
+    parent VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL,
+    location_granularity VARCHAR(50) NOT NULL DEFAULT '',
+    schema_version VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Subscriber Merges Table:
+-- Records every merge of a duplicate subscriber into another, with the report of what it changed.
+-- The merged subscriptions themselves are recorded in subscription_history.
+CREATE TABLE IF NOT EXISTS subscriber_merges (
+    merge_id VARCHAR(255) PRIMARY KEY,
+    source_subscriber_id VARCHAR(255) NOT NULL,
+    target_subscriber_id VARCHAR(255) NOT NULL,
+    report JSONB NOT NULL,
+    merged_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Request Quota Counters Table:
+-- Counts the requests of each caller per quota scope and fixed hourly or daily window.
+-- Counters of past windows are purged by the registry.
+CREATE TABLE IF NOT EXISTS request_quota_counters (
+    scope VARCHAR(50) NOT NULL,
+    caller VARCHAR(255) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (scope, caller, period, window_start)
+);
+CREATE INDEX IF NOT EXISTS idx_request_quota_counters_window_start ON request_quota_counters (window_start);
 
 --------------------------------------------------------------------------------
 -- AUTO-UPDATE TIMESTAMP LOGIC
@@ -103,4 +315,82 @@ DROP TRIGGER IF EXISTS set_updated_at_on_Operations ON Operations;
 CREATE TRIGGER set_updated_at_on_Operations
 BEFORE UPDATE ON Operations
 FOR EACH ROW
-EXECUTE FUNCTION update_updated_at_column();
\ No newline at end of file
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Attach the trigger to the 'webhook_deliveries' table for UPDATEs.
+DROP TRIGGER IF EXISTS set_updated_at_on_webhook_deliveries ON webhook_deliveries;
+CREATE TRIGGER set_updated_at_on_webhook_deliveries
+BEFORE UPDATE ON webhook_deliveries
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Attach the trigger to the 'domains' table for UPDATEs.
+DROP TRIGGER IF EXISTS set_updated_at_on_domains ON domains;
+CREATE TRIGGER set_updated_at_on_domains
+BEFORE UPDATE ON domains
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+--------------------------------------------------------------------------------
+-- SUBSCRIPTION HISTORY LOGIC
+--------------------------------------------------------------------------------
+
+-- Copies every inserted, updated or deleted subscription row into subscription_history.
+-- Deleted rows are recorded with their last values.
+CREATE OR REPLACE FUNCTION record_subscription_history()
+RETURNS TRIGGER AS $$
+DECLARE
+    r subscriptions%ROWTYPE;
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+       r := OLD;
+   ELSE
+       r := NEW;
+   END IF;
+   INSERT INTO subscription_history (change, changed_at, subscriber_id, type, domain, location, signing_public_key, encr_public_key, valid_from, valid_until, status, url, key_id, created_at, updated_at, extended_attributes)
+   VALUES (TG_OP, NOW(), r.subscriber_id, r.type, r.domain, r.location, r.signing_public_key, r.encr_public_key, r.valid_from, r.valid_until, r.status, r.url, r.key_id, r.created_at, r.updated_at, r.extended_attributes);
+   RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+-- Attach the trigger to the 'subscriptions' table after every change, so that it sees the final
+-- row including updated_at.
+DROP TRIGGER IF EXISTS record_history_on_subscriptions ON subscriptions;
+CREATE TRIGGER record_history_on_subscriptions
+AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+FOR EACH ROW
+EXECUTE FUNCTION record_subscription_history();
+
+--------------------------------------------------------------------------------
+-- SUBSCRIPTION CHANGE NOTIFICATION LOGIC
+--------------------------------------------------------------------------------
+
+-- Publishes every inserted, updated or deleted subscription row on the 'subscription_changes'
+-- channel, for registry instances that invalidate their caches with LISTEN. Notifications are
+-- delivered when the transaction commits and are dropped when nobody listens.
+CREATE OR REPLACE FUNCTION notify_subscription_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    r subscriptions%ROWTYPE;
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+       r := OLD;
+   ELSE
+       r := NEW;
+   END IF;
+   PERFORM pg_notify('subscription_changes', json_build_object(
+       'op', TG_OP,
+       'subscriber_id', r.subscriber_id,
+       'key_id', r.key_id,
+       'domain', r.domain,
+       'type', r.type
+   )::text);
+   RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS notify_change_on_subscriptions ON subscriptions;
+CREATE TRIGGER notify_change_on_subscriptions
+AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+FOR EACH ROW
+EXECUTE FUNCTION notify_subscription_change();